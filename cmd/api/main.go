@@ -2,9 +2,11 @@ package main
 
 import (
 	"context"
+	"fitness-hack/internal/messages"
 	"fitness-hack/internal/server"
+	"flag"
 	"fmt"
-	"log"
+	"net"
 	"os"
 	"os/signal"
 	"strconv"
@@ -12,9 +14,10 @@ import (
 	"time"
 
 	_ "github.com/joho/godotenv/autoload"
+	"google.golang.org/grpc"
 )
 
-func gracefulShutdown(fiberServer *server.FiberServer, done chan bool) {
+func gracefulShutdown(fiberServer *server.FiberServer, grpcServer *grpc.Server, done chan bool) {
 	// Create context that listens for the interrupt signal from the OS.
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
@@ -22,28 +25,63 @@ func gracefulShutdown(fiberServer *server.FiberServer, done chan bool) {
 	// Listen for the interrupt signal.
 	<-ctx.Done()
 
-	log.Println("shutting down gracefully, press Ctrl+C again to force")
+	messages.Log(messages.APIShuttingDown)
 
 	// The context is used to inform the server it has 5 seconds to finish
 	// the request it is currently handling
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 	if err := fiberServer.ShutdownWithContext(ctx); err != nil {
-		log.Printf("Server forced to shutdown with error: %v", err)
+		messages.Log(messages.APIForcedShutdown, err)
+	}
+	if grpcServer != nil {
+		// GracefulStop waits for every RPC, so cut off whatever is left at the deadline
+		stopped := make(chan struct{})
+		go func() {
+			grpcServer.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-ctx.Done():
+			grpcServer.Stop()
+		}
 	}
 
-	log.Println("Server exiting")
+	messages.Log(messages.APIExiting)
 
 	// Notify the main goroutine that the shutdown is complete
 	done <- true
 }
 
 func main() {
+	standalone := flag.Bool("standalone", false, "run the whole app in this process, with a bundled Postgres unless BLUEPRINT_DB_HOST is set")
+	flag.Parse()
+	if *standalone {
+		os.Setenv("STANDALONE", "true")
+		if os.Getenv("PORT") == "" {
+			os.Setenv("PORT", "8080")
+		}
+	}
 
 	server := server.New()
 
 	server.RegisterFiberRoutes()
 
+	// Background jobs stop once the server shuts down
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+
+	// Scheduled and queued jobs run here too unless a separate cmd/worker deployment
+	// handles them
+	if os.Getenv("SCHEDULERS_DISABLED") != "true" {
+		server.StartSchedulers(jobsCtx)
+	}
+	if os.Getenv("JOB_WORKER_DISABLED") != "true" {
+		go server.RunJobWorker(jobsCtx)
+	}
+	server.StartAlerting(jobsCtx)
+
 	// Create a done channel to signal when the shutdown is complete
 	done := make(chan bool, 1)
 
@@ -55,10 +93,30 @@ func main() {
 		}
 	}()
 
+	// GRPC_PORT serves the gRPC API alongside the REST one
+	var grpcServer *grpc.Server
+	if grpcPort := os.Getenv("GRPC_PORT"); grpcPort != "" {
+		listener, err := net.Listen("tcp", ":"+grpcPort)
+		if err != nil {
+			messages.Fatal(messages.APIGRPCFailed, err)
+		}
+		grpcServer = server.NewGRPCServer()
+		go func() {
+			messages.Log(messages.APIGRPCListening, listener.Addr())
+			if err := grpcServer.Serve(listener); err != nil {
+				messages.Fatal(messages.APIGRPCFailed, err)
+			}
+		}()
+	}
+
 	// Run graceful shutdown in a separate goroutine
-	go gracefulShutdown(server, done)
+	go gracefulShutdown(server, grpcServer, done)
 
 	// Wait for the graceful shutdown to complete
 	<-done
-	log.Println("Graceful shutdown complete.")
+	stopJobs()
+	if err := server.Close(); err != nil {
+		messages.Log(messages.APICloseFailed, err)
+	}
+	messages.Log(messages.APIShutdownComplete)
 }
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"fitness-hack/internal/server"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+// event holds the fields that tell the invocations apart: SQS batches have Records, and
+// EventBridge schedules are set up with the constant input {"schedule": "<name>"}
+type event struct {
+	Records  []json.RawMessage `json:"Records"`
+	Schedule string            `json:"schedule"`
+}
+
+func main() {
+	// The Lambda worker runs queued and scheduled jobs with JOB_QUEUE_DRIVER=sqs
+	server := server.New()
+
+	lambda.Start(func(ctx context.Context, raw json.RawMessage) (interface{}, error) {
+		var e event
+		if err := json.Unmarshal(raw, &e); err != nil {
+			return nil, fmt.Errorf("failed to decode event: %w", err)
+		}
+		switch {
+		case e.Schedule != "":
+			return nil, server.RunScheduledJob(ctx, e.Schedule)
+		case e.Records != nil:
+			var batch events.SQSEvent
+			if err := json.Unmarshal(raw, &batch); err != nil {
+				return nil, fmt.Errorf("failed to decode SQS event: %w", err)
+			}
+			return server.HandleSQSEvent(ctx, batch)
+		}
+		return nil, fmt.Errorf("unsupported event: %s", raw)
+	})
+}
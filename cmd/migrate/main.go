@@ -6,6 +6,7 @@ import (
 	"log"
 
 	"fitness-hack/internal/database"
+	"fitness-hack/internal/messages"
 
 	_ "github.com/joho/godotenv/autoload"
 )
@@ -16,18 +17,7 @@ func main() {
 	args := flag.Args()
 
 	if len(args) == 0 {
-		fmt.Println("Database Migration CLI")
-		fmt.Println("======================")
-		fmt.Println("Usage:")
-		fmt.Println("  go migrate                    - Run all pending migrations")
-		fmt.Println("  go migrate status             - Show migration status")
-		fmt.Println("  go migrate generate-models    - Generate Go models from database schema")
-		fmt.Println("  go migrate create-migration <name or filename> - Create a new migration file")
-		fmt.Println("")
-		fmt.Println("Examples:")
-		fmt.Println("  go migrate create-migration add user profiles")
-		fmt.Println("  go migrate create-migration add_user_profiles.sql")
-		fmt.Println("  go migrate create-migration add-user-profiles")
+		fmt.Println(messages.Text(messages.CLIUsage))
 		return
 	}
 
@@ -41,6 +31,6 @@ func main() {
 
 	// Run the command
 	if err := cli.Run(args); err != nil {
-		log.Fatalf("Error: %v", err)
+		log.Fatal(messages.Text(messages.CLICommandFailed, err))
 	}
 }
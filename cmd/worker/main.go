@@ -0,0 +1,24 @@
+package main
+
+import (
+	"context"
+	"fitness-hack/internal/messages"
+	"fitness-hack/internal/server"
+	"os/signal"
+	"syscall"
+
+	_ "github.com/joho/godotenv/autoload"
+)
+
+func main() {
+	// The worker shares the API's configuration and connections but serves no HTTP
+	server := server.New()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	messages.Log(messages.WorkerStarted)
+	server.StartSchedulers(ctx)
+	server.RunJobWorker(ctx)
+	messages.Log(messages.WorkerStopped)
+}
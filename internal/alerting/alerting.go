@@ -0,0 +1,144 @@
+// Package alerting watches the API's error rate and latency and notifies operators when
+// either breaches its threshold.
+package alerting
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// Rules an alert can be raised for
+const (
+	RuleErrorRate  = "error_rate"
+	RuleLatencyP95 = "latency_p95"
+)
+
+// Alert is a threshold breach to notify operators of
+type Alert struct {
+	Rule string
+	// Source names the replica that saw the breach
+	Source  string
+	Message string
+	Stats   Stats
+}
+
+// Notifier sends alerts to operators
+type Notifier interface {
+	Notify(ctx context.Context, alert Alert) error
+}
+
+// NewFromEnv returns the notifier for ALERT_SLACK_WEBHOOK_URL, or nil when it isn't set, in
+// which case nothing is watched
+func NewFromEnv() Notifier {
+	if url := os.Getenv("ALERT_SLACK_WEBHOOK_URL"); url != "" {
+		return NewSlack(url)
+	}
+	return nil
+}
+
+// Thresholds are the limits that raise an alert when breached. A zero threshold is not
+// checked.
+type Thresholds struct {
+	// ErrorRate is the share of requests answered with a 5xx status
+	ErrorRate float64
+	// LatencyP95 is the 95th percentile of request latency
+	LatencyP95 time.Duration
+	// MinRequests is how many requests the window needs before either threshold is
+	// checked, so a handful of slow or failed requests at night don't page anyone
+	MinRequests int
+}
+
+// Check returns an alert for each threshold stats breaches
+func Check(stats Stats, thresholds Thresholds) []Alert {
+	if stats.Requests == 0 || stats.Requests < thresholds.MinRequests {
+		return nil
+	}
+
+	var alerts []Alert
+	if thresholds.ErrorRate > 0 && stats.ErrorRate() > thresholds.ErrorRate {
+		alerts = append(alerts, Alert{
+			Rule: RuleErrorRate,
+			Message: fmt.Sprintf("5xx error rate %.1f%% over the last %s (%d of %d requests), threshold %.1f%%",
+				stats.ErrorRate()*100, stats.Window, stats.Errors, stats.Requests, thresholds.ErrorRate*100),
+			Stats: stats,
+		})
+	}
+	if thresholds.LatencyP95 > 0 && stats.LatencyP95 > thresholds.LatencyP95 {
+		alerts = append(alerts, Alert{
+			Rule: RuleLatencyP95,
+			Message: fmt.Sprintf("p95 latency %s over the last %s (%d requests), threshold %s",
+				stats.LatencyP95, stats.Window, stats.Requests, thresholds.LatencyP95),
+			Stats: stats,
+		})
+	}
+	return alerts
+}
+
+// Monitor checks the requests recorded in its window against the thresholds and notifies
+// of breaches. An alert that was sent isn't sent again until Cooldown has passed, however
+// long the breach lasts.
+type Monitor struct {
+	Window     *Window
+	Thresholds Thresholds
+	Notifier   Notifier
+	Cooldown   time.Duration
+	Source     string
+
+	// Claim, when set, is asked before an alert is sent and reports whether this replica
+	// should send it. It lets replicas that each watch their own traffic share cooldowns,
+	// so a deployment-wide outage is reported once rather than once per replica. When
+	// Claim fails the alert is sent anyway.
+	Claim func(ctx context.Context, rule string, cooldown time.Duration) (bool, error)
+
+	mu       sync.Mutex
+	lastSent map[string]time.Time
+}
+
+// Check notifies of each threshold breached at now that isn't cooling down. Errors from
+// Claim and the notifier are joined; a failed notification is retried on the next check.
+func (m *Monitor) Check(ctx context.Context, now time.Time) error {
+	var errs []error
+	for _, alert := range Check(m.Window.Stats(now), m.Thresholds) {
+		if !m.cooledDown(alert.Rule, now) {
+			continue
+		}
+		if m.Claim != nil {
+			claimed, err := m.Claim(ctx, alert.Rule, m.Cooldown)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("claim %s alert: %w", alert.Rule, err))
+			} else if !claimed {
+				// Another replica sent it; cool down here too
+				m.markSent(alert.Rule, now)
+				continue
+			}
+		}
+
+		alert.Source = m.Source
+		if err := m.Notifier.Notify(ctx, alert); err != nil {
+			errs = append(errs, fmt.Errorf("send %s alert: %w", alert.Rule, err))
+			continue
+		}
+		m.markSent(alert.Rule, now)
+	}
+	return errors.Join(errs...)
+}
+
+func (m *Monitor) cooledDown(rule string, now time.Time) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	sent, ok := m.lastSent[rule]
+	return !ok || now.Sub(sent) >= m.Cooldown
+}
+
+func (m *Monitor) markSent(rule string, now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.lastSent == nil {
+		m.lastSent = map[string]time.Time{}
+	}
+	m.lastSent[rule] = now
+}
@@ -0,0 +1,137 @@
+package alerting
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWindowStats(t *testing.T) {
+	w := NewWindow(5 * time.Minute)
+	start := time.Date(2025, 8, 15, 12, 0, 0, 0, time.UTC)
+
+	// Old requests that leave the window before it is read
+	for i := 0; i < 10; i++ {
+		w.Record(start, 500, 8*time.Second)
+	}
+	now := start.Add(10 * time.Minute)
+	for i := 0; i < 100; i++ {
+		status, latency := 200, 40*time.Millisecond
+		if i < 7 {
+			status = 503
+		}
+		if i >= 90 {
+			latency = 1500 * time.Millisecond
+		}
+		w.Record(now.Add(-time.Duration(i)*time.Second), status, latency)
+	}
+
+	stats := w.Stats(now)
+	if stats.Requests != 100 || stats.Errors != 7 {
+		t.Fatalf("expected 7 of 100 requests failed, got %+v", stats)
+	}
+	if stats.LatencyP95 != 2500*time.Millisecond {
+		t.Errorf("expected p95 rounded up to 2.5s, got %s", stats.LatencyP95)
+	}
+	if empty := w.Stats(now.Add(time.Hour)); empty.Requests != 0 || empty.LatencyP95 != 0 {
+		t.Errorf("expected an empty window an hour later, got %+v", empty)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	thresholds := Thresholds{ErrorRate: 0.05, LatencyP95: 2 * time.Second, MinRequests: 50}
+
+	cases := []struct {
+		name  string
+		stats Stats
+		rules []string
+	}{
+		{"healthy", Stats{Requests: 100, Errors: 5, LatencyP95: time.Second}, nil},
+		{"failing", Stats{Requests: 100, Errors: 6, LatencyP95: time.Second}, []string{RuleErrorRate}},
+		{"slow", Stats{Requests: 100, LatencyP95: 2500 * time.Millisecond}, []string{RuleLatencyP95}},
+		{"both", Stats{Requests: 100, Errors: 50, LatencyP95: 10 * time.Second}, []string{RuleErrorRate, RuleLatencyP95}},
+		{"too quiet", Stats{Requests: 10, Errors: 10, LatencyP95: 10 * time.Second}, nil},
+	}
+	for _, tc := range cases {
+		alerts := Check(tc.stats, thresholds)
+		if len(alerts) != len(tc.rules) {
+			t.Errorf("%s: expected %v, got %+v", tc.name, tc.rules, alerts)
+			continue
+		}
+		for i, rule := range tc.rules {
+			if alerts[i].Rule != rule {
+				t.Errorf("%s: expected %v, got %+v", tc.name, tc.rules, alerts)
+			}
+		}
+	}
+}
+
+type recordingNotifier struct {
+	sent []Alert
+	err  error
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, alert Alert) error {
+	if n.err != nil {
+		return n.err
+	}
+	n.sent = append(n.sent, alert)
+	return nil
+}
+
+func TestMonitorCooldown(t *testing.T) {
+	notifier := &recordingNotifier{}
+	claims := map[string]bool{}
+	m := &Monitor{
+		Window:     NewWindow(5 * time.Minute),
+		Thresholds: Thresholds{ErrorRate: 0.05},
+		Notifier:   notifier,
+		Cooldown:   30 * time.Minute,
+		Source:     "api-1",
+		Claim: func(ctx context.Context, rule string, cooldown time.Duration) (bool, error) {
+			if claims[rule] {
+				return false, nil
+			}
+			claims[rule] = true
+			return true, nil
+		},
+	}
+	ctx := context.Background()
+	now := time.Date(2025, 8, 15, 12, 0, 0, 0, time.UTC)
+	failing := func(at time.Time) {
+		for i := 0; i < 10; i++ {
+			m.Window.Record(at, 500, time.Millisecond)
+		}
+	}
+
+	failing(now)
+	if err := m.Check(ctx, now); err != nil || len(notifier.sent) != 1 || notifier.sent[0].Source != "api-1" {
+		t.Fatalf("expected one alert from api-1, got %+v (%v)", notifier.sent, err)
+	}
+	failing(now.Add(time.Minute))
+	if m.Check(ctx, now.Add(time.Minute)); len(notifier.sent) != 1 {
+		t.Errorf("expected the alert to cool down, got %d sent", len(notifier.sent))
+	}
+
+	// Another replica already claimed the alert after the cooldown
+	later := now.Add(31 * time.Minute)
+	failing(later)
+	if m.Check(ctx, later); len(notifier.sent) != 1 {
+		t.Errorf("expected the claimed alert not to be sent twice, got %d sent", len(notifier.sent))
+	}
+
+	// A failed notification isn't counted towards the cooldown
+	delete(claims, RuleErrorRate)
+	notifier.err = errors.New("slack is down")
+	evenLater := now.Add(62 * time.Minute)
+	failing(evenLater)
+	if err := m.Check(ctx, evenLater); err == nil {
+		t.Error("expected the notifier error")
+	}
+	notifier.err = nil
+	delete(claims, RuleErrorRate)
+	if err := m.Check(ctx, evenLater.Add(time.Minute)); err != nil || len(notifier.sent) != 2 {
+		t.Errorf("expected the alert retried, got %d sent (%v)", len(notifier.sent), err)
+	}
+}
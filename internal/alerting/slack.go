@@ -0,0 +1,51 @@
+package alerting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Slack posts alerts to a Slack incoming webhook
+type Slack struct {
+	WebhookURL string
+	Client     *http.Client
+}
+
+// NewSlack returns a notifier posting to the incoming webhook at webhookURL
+func NewSlack(webhookURL string) *Slack {
+	return &Slack{WebhookURL: webhookURL, Client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// Notify posts the alert as a message
+func (s *Slack) Notify(ctx context.Context, alert Alert) error {
+	text := ":rotating_light: *fitness-hack* " + alert.Message
+	if alert.Source != "" {
+		text += " on `" + alert.Source + "`"
+	}
+	body, err := json.Marshal(map[string]string{"text": text})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("slack webhook returned %d: %s", resp.StatusCode, detail)
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package alerting
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSlackNotify(t *testing.T) {
+	var got map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Error(err)
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer srv.Close()
+
+	alert := Alert{Rule: RuleErrorRate, Source: "api-1", Message: "5xx error rate 12.0%"}
+	if err := NewSlack(srv.URL).Notify(context.Background(), alert); err != nil {
+		t.Fatal(err)
+	}
+	if text := got["text"]; !strings.Contains(text, "5xx error rate 12.0%") || !strings.Contains(text, "api-1") {
+		t.Errorf("unexpected message %q", text)
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "invalid_token", http.StatusForbidden)
+	}))
+	defer failing.Close()
+	if err := NewSlack(failing.URL).Notify(context.Background(), alert); err == nil || !strings.Contains(err.Error(), "invalid_token") {
+		t.Errorf("expected Slack's error, got %v", err)
+	}
+}
@@ -0,0 +1,121 @@
+package alerting
+
+import (
+	"sync"
+	"time"
+)
+
+// latencyBounds are the upper bounds of the latency histogram buckets. Percentiles are
+// reported as the bound of the bucket they fall in, so they are rounded up.
+var latencyBounds = [...]time.Duration{
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+// windowBuckets is how many buckets a window is split into; requests leave the window a
+// bucket at a time
+const windowBuckets = 30
+
+// Stats summarizes the requests in a window
+type Stats struct {
+	Window   time.Duration
+	Requests int
+	Errors   int
+	// LatencyP95 is rounded up to a histogram bound, and is the largest bound for
+	// requests slower than all of them
+	LatencyP95 time.Duration
+}
+
+// ErrorRate returns the share of requests that failed
+func (s Stats) ErrorRate() float64 {
+	if s.Requests == 0 {
+		return 0
+	}
+	return float64(s.Errors) / float64(s.Requests)
+}
+
+type bucket struct {
+	start    time.Time
+	requests int
+	errors   int
+	latency  [len(latencyBounds)]int
+}
+
+// Window counts requests, failures and latencies over a sliding window. It is safe for
+// concurrent use.
+type Window struct {
+	size    time.Duration
+	width   time.Duration
+	mu      sync.Mutex
+	buckets [windowBuckets]bucket
+}
+
+// NewWindow returns a window covering the last size of requests
+func NewWindow(size time.Duration) *Window {
+	return &Window{size: size, width: max(size/windowBuckets, time.Millisecond)}
+}
+
+// Record adds a request that completed at now with the given status and latency. 5xx
+// statuses count as failures.
+func (w *Window) Record(now time.Time, status int, latency time.Duration) {
+	start := now.Truncate(w.width)
+	i := int(start.UnixNano()/int64(w.width)) % windowBuckets
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	b := &w.buckets[i]
+	if !b.start.Equal(start) {
+		*b = bucket{start: start}
+	}
+	b.requests++
+	if status >= 500 {
+		b.errors++
+	}
+	slot := len(latencyBounds) - 1
+	for j, bound := range latencyBounds {
+		if latency <= bound {
+			slot = j
+			break
+		}
+	}
+	b.latency[slot]++
+}
+
+// Stats summarizes the requests recorded in the window ending at now
+func (w *Window) Stats(now time.Time) Stats {
+	stats := Stats{Window: w.size}
+	var latency [len(latencyBounds)]int
+
+	w.mu.Lock()
+	oldest := now.Add(-w.size)
+	for _, b := range w.buckets {
+		if b.requests == 0 || !b.start.After(oldest) || b.start.After(now) {
+			continue
+		}
+		stats.Requests += b.requests
+		stats.Errors += b.errors
+		for j, n := range b.latency {
+			latency[j] += n
+		}
+	}
+	w.mu.Unlock()
+
+	// The 95th percentile is the first bucket holding 95% of the requests
+	need := (stats.Requests*95 + 99) / 100
+	seen := 0
+	for j, n := range latency {
+		seen += n
+		if n > 0 && seen >= need {
+			stats.LatencyP95 = latencyBounds[j]
+			break
+		}
+	}
+	return stats
+}
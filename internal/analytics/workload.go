@@ -0,0 +1,154 @@
+// Package analytics computes training load metrics from the volume a user lifts. It knows
+// nothing of the database or HTTP, so the calculations can be tested on their own.
+//
+// The workload at a point in time compares the acute load, the volume of the last week,
+// with the chronic load, the weekly average of the last ChronicWeeks weeks. Their ratio
+// (the acute:chronic workload ratio) is around 1 when a week is like a usual one; a ratio
+// well above 1 is a jump in volume the body isn't used to yet.
+package analytics
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// ChronicWeeks is how many weeks the chronic load averages
+const ChronicWeeks = 4
+
+// Ratio thresholds. Ratios from UnderloadRatio to ElevatedRatio are the usual "sweet spot".
+const (
+	UnderloadRatio = 0.8
+	ElevatedRatio  = 1.3
+	SpikeRatio     = 1.5
+)
+
+// ElevatedWeeksForDeload is how many weeks in a row must end at ElevatedRatio or above for
+// a deload week to be suggested
+const ElevatedWeeksForDeload = 3
+
+const week = 7 * 24 * time.Hour
+
+// Status is how the acute load compares with the chronic load
+type Status string
+
+const (
+	// StatusInsufficientData means there is no volume before the last week to compare with
+	StatusInsufficientData Status = "insufficient_data"
+	StatusLow              Status = "low"
+	StatusOptimal          Status = "optimal"
+	StatusElevated         Status = "elevated"
+	StatusSpike            Status = "spike"
+)
+
+// Session is the volume, in kg lifted, of a session started at a time
+type Session struct {
+	At       time.Time
+	VolumeKg float64
+}
+
+// Week is the workload over the week ending at End
+type Week struct {
+	End       time.Time
+	AcuteKg   float64
+	ChronicKg float64
+	// Ratio is nil when nothing was lifted in the chronic window before the acute week
+	Ratio *float64
+}
+
+// Workload is the current workload, how the weeks before it went and whether a deload
+// week is due
+type Workload struct {
+	Week
+	Status          Status
+	DeloadSuggested bool
+	Reasons         []string
+	// History holds the weeks ending at one-week steps before now, oldest first, ending
+	// with the current week
+	History []Week
+}
+
+// WeekEnding returns the workload of the week ending at end. Sessions from the window
+// (end - ChronicWeeks weeks, end] count.
+func WeekEnding(sessions []Session, end time.Time) Week {
+	w := Week{End: end}
+	acuteStart := end.Add(-week)
+	chronicStart := end.Add(-ChronicWeeks * week)
+	earlier := 0.0
+	for _, s := range sessions {
+		if !s.At.After(chronicStart) || s.At.After(end) {
+			continue
+		}
+		w.ChronicKg += s.VolumeKg
+		if s.At.After(acuteStart) {
+			w.AcuteKg += s.VolumeKg
+		} else {
+			earlier += s.VolumeKg
+		}
+	}
+	w.ChronicKg = round(w.ChronicKg / ChronicWeeks)
+	w.AcuteKg = round(w.AcuteKg)
+	if earlier > 0 {
+		ratio := math.Round(w.AcuteKg/w.ChronicKg*100) / 100
+		w.Ratio = &ratio
+	}
+	return w
+}
+
+// StatusOf returns the status of a workload ratio
+func StatusOf(ratio *float64) Status {
+	switch {
+	case ratio == nil:
+		return StatusInsufficientData
+	case *ratio >= SpikeRatio:
+		return StatusSpike
+	case *ratio >= ElevatedRatio:
+		return StatusElevated
+	case *ratio < UnderloadRatio:
+		return StatusLow
+	default:
+		return StatusOptimal
+	}
+}
+
+// ComputeWorkload returns the workload at now with the given number of weeks of history,
+// at least one. A deload week is suggested when this week's ratio reaches SpikeRatio, or
+// when the last ElevatedWeeksForDeload weeks all ended at ElevatedRatio or above.
+func ComputeWorkload(sessions []Session, now time.Time, weeks int) Workload {
+	weeks = max(weeks, 1)
+	history := make([]Week, weeks)
+	for i := range history {
+		history[i] = WeekEnding(sessions, now.Add(-time.Duration(weeks-1-i)*week))
+	}
+	w := Workload{Week: history[weeks-1], History: history, Reasons: []string{}}
+	w.Status = StatusOf(w.Ratio)
+
+	if w.Status == StatusSpike {
+		w.DeloadSuggested = true
+		w.Reasons = append(w.Reasons, fmt.Sprintf("Volume this week is %.1f times your usual", *w.Ratio))
+	}
+	if elevated := ElevatedWeeks(history); elevated >= ElevatedWeeksForDeload {
+		w.DeloadSuggested = true
+		w.Reasons = append(w.Reasons, fmt.Sprintf("Volume has run well above your usual for %d weeks in a row", elevated))
+	}
+	if w.Status == StatusLow {
+		w.Reasons = append(w.Reasons, "Volume this week is below your usual")
+	}
+	return w
+}
+
+// ElevatedWeeks counts the weeks at the end of history whose ratio reached ElevatedRatio
+func ElevatedWeeks(history []Week) int {
+	n := 0
+	for i := len(history) - 1; i >= 0; i-- {
+		if r := history[i].Ratio; r == nil || *r < ElevatedRatio {
+			break
+		}
+		n++
+	}
+	return n
+}
+
+func round(kg float64) float64 {
+	return math.Round(kg*100) / 100
+}
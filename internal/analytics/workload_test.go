@@ -0,0 +1,74 @@
+package analytics
+
+import (
+	"testing"
+	"time"
+)
+
+var now = time.Date(2025, 8, 30, 12, 0, 0, 0, time.UTC)
+
+// weekly returns one session per week ending at now, oldest first, with the volumes given
+func weekly(volumes ...float64) []Session {
+	sessions := []Session{}
+	for i, v := range volumes {
+		sessions = append(sessions, Session{At: now.Add(-time.Duration(len(volumes)-1-i)*week - time.Hour), VolumeKg: v})
+	}
+	return sessions
+}
+
+func TestWeekEnding(t *testing.T) {
+	w := WeekEnding(weekly(9000, 10000, 11000, 12000), now)
+	if w.AcuteKg != 12000 || w.ChronicKg != 10500 || w.Ratio == nil || *w.Ratio != 1.14 {
+		t.Errorf("unexpected week %+v", w)
+	}
+
+	// Sessions outside the chronic window, or after its end, don't count
+	sessions := append(weekly(50000, 0, 0, 0, 10000), Session{At: now.Add(time.Hour), VolumeKg: 99999})
+	if w := WeekEnding(sessions, now); w.AcuteKg != 10000 || w.ChronicKg != 2500 || w.Ratio != nil {
+		t.Errorf("expected only this week to count, got %+v", w)
+	}
+
+	if w := WeekEnding(nil, now); w.Ratio != nil || StatusOf(w.Ratio) != StatusInsufficientData {
+		t.Errorf("expected no ratio without sessions, got %+v", w)
+	}
+}
+
+func TestStatusOf(t *testing.T) {
+	for ratio, want := range map[float64]Status{
+		0.5:  StatusLow,
+		0.8:  StatusOptimal,
+		1.29: StatusOptimal,
+		1.3:  StatusElevated,
+		1.5:  StatusSpike,
+		2.4:  StatusSpike,
+	} {
+		if got := StatusOf(&ratio); got != want {
+			t.Errorf("%v: expected %s, got %s", ratio, want, got)
+		}
+	}
+}
+
+func TestComputeWorkload(t *testing.T) {
+	steady := ComputeWorkload(weekly(10000, 10000, 10000, 10000, 10000, 10000), now, 3)
+	if steady.Status != StatusOptimal || steady.DeloadSuggested || len(steady.History) != 3 || !steady.History[2].End.Equal(now) {
+		t.Errorf("expected a steady optimal workload, got %+v", steady)
+	}
+
+	spike := ComputeWorkload(weekly(10000, 10000, 10000, 22000), now, 1)
+	if spike.Status != StatusSpike || !spike.DeloadSuggested || len(spike.Reasons) != 1 {
+		t.Errorf("expected a spike to suggest a deload, got %+v", spike)
+	}
+
+	// Each week 30% up on the one before keeps the ratio elevated without spiking
+	climbing := ComputeWorkload(weekly(5000, 6500, 8450, 10985, 14280, 18565, 24135), now, 4)
+	if climbing.Status != StatusElevated || ElevatedWeeks(climbing.History) < ElevatedWeeksForDeload || !climbing.DeloadSuggested {
+		t.Errorf("expected weeks of climbing volume to suggest a deload, got %+v", climbing)
+	}
+
+	if light := ComputeWorkload(weekly(10000, 10000, 10000, 5000), now, 1); light.Status != StatusLow || light.DeloadSuggested {
+		t.Errorf("expected a light week to be low, got %+v", light)
+	}
+	if empty := ComputeWorkload(nil, now, 0); empty.Status != StatusInsufficientData || len(empty.History) != 1 {
+		t.Errorf("expected no data, got %+v", empty)
+	}
+}
@@ -0,0 +1,349 @@
+package billing
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	appleProductionURL = "https://buy.itunes.apple.com/verifyReceipt"
+	appleSandboxURL    = "https://sandbox.itunes.apple.com/verifyReceipt"
+
+	// appleStatusSandboxReceipt means a sandbox receipt was sent to production
+	appleStatusSandboxReceipt = 21007
+)
+
+// AppleVerifier validates App Store receipts and App Store Server Notifications V2
+type AppleVerifier struct {
+	SharedSecret string
+	BundleID     string
+	// Roots contains the Apple root certificates used to verify signed notifications
+	Roots *x509.CertPool
+
+	ProductionURL string
+	SandboxURL    string
+	Client        *http.Client
+}
+
+// NewAppleVerifierFromEnv configures App Store validation from APPLE_IAP_SHARED_SECRET,
+// APPLE_BUNDLE_ID and APPLE_ROOT_CA_PATH (PEM file containing Apple Root CA - G3)
+func NewAppleVerifierFromEnv() *AppleVerifier {
+	v := &AppleVerifier{
+		SharedSecret:  os.Getenv("APPLE_IAP_SHARED_SECRET"),
+		BundleID:      os.Getenv("APPLE_BUNDLE_ID"),
+		ProductionURL: appleProductionURL,
+		SandboxURL:    appleSandboxURL,
+		Client:        &http.Client{Timeout: 10 * time.Second},
+	}
+
+	if path := os.Getenv("APPLE_ROOT_CA_PATH"); path != "" {
+		if data, err := os.ReadFile(path); err == nil {
+			pool := x509.NewCertPool()
+			if pool.AppendCertsFromPEM(data) {
+				v.Roots = pool
+			}
+		}
+	}
+
+	return v
+}
+
+type appleReceiptResponse struct {
+	Status  int `json:"status"`
+	Receipt struct {
+		BundleID string `json:"bundle_id"`
+	} `json:"receipt"`
+	LatestReceiptInfo  []appleReceiptInfo `json:"latest_receipt_info"`
+	PendingRenewalInfo []appleRenewalInfo `json:"pending_renewal_info"`
+}
+
+type appleReceiptInfo struct {
+	ProductID             string `json:"product_id"`
+	OriginalTransactionID string `json:"original_transaction_id"`
+	ExpiresDateMS         string `json:"expires_date_ms"`
+	CancellationDateMS    string `json:"cancellation_date_ms"`
+}
+
+type appleRenewalInfo struct {
+	OriginalTransactionID string `json:"original_transaction_id"`
+	AutoRenewStatus       string `json:"auto_renew_status"`
+	IsInBillingRetry      string `json:"is_in_billing_retry_period"`
+	GracePeriodExpiresMS  string `json:"grace_period_expires_date_ms"`
+}
+
+// VerifyReceipt validates a base64 app receipt and returns the latest subscription it contains
+func (v *AppleVerifier) VerifyReceipt(ctx context.Context, receipt string) (*Purchase, error) {
+	if v.SharedSecret == "" {
+		return nil, ErrNotConfigured
+	}
+
+	resp, err := v.postReceipt(ctx, v.ProductionURL, receipt)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Status == appleStatusSandboxReceipt {
+		if resp, err = v.postReceipt(ctx, v.SandboxURL, receipt); err != nil {
+			return nil, err
+		}
+	}
+	if resp.Status != 0 {
+		return nil, fmt.Errorf("%w: app store status %d", ErrInvalidReceipt, resp.Status)
+	}
+	if v.BundleID != "" && resp.Receipt.BundleID != v.BundleID {
+		return nil, fmt.Errorf("%w: unexpected bundle id %q", ErrInvalidReceipt, resp.Receipt.BundleID)
+	}
+
+	// latest_receipt_info is not guaranteed to be ordered, so pick the latest expiry
+	var latest *appleReceiptInfo
+	var latestExpiry time.Time
+	for i := range resp.LatestReceiptInfo {
+		info := &resp.LatestReceiptInfo[i]
+		expiry := msToTime(info.ExpiresDateMS)
+		if latest == nil || expiry.After(latestExpiry) {
+			latest, latestExpiry = info, expiry
+		}
+	}
+	if latest == nil {
+		return nil, fmt.Errorf("%w: receipt contains no subscriptions", ErrInvalidReceipt)
+	}
+
+	purchase := &Purchase{
+		Platform:              PlatformApple,
+		ProductID:             latest.ProductID,
+		OriginalTransactionID: latest.OriginalTransactionID,
+		ExpiresAt:             latestExpiry,
+		Status:                StatusActive,
+	}
+
+	for _, renewal := range resp.PendingRenewalInfo {
+		if renewal.OriginalTransactionID != latest.OriginalTransactionID {
+			continue
+		}
+		purchase.AutoRenew = renewal.AutoRenewStatus == "1"
+		if grace := msToTime(renewal.GracePeriodExpiresMS); grace.After(purchase.ExpiresAt) {
+			purchase.ExpiresAt = grace
+			purchase.Status = StatusGrace
+		}
+	}
+
+	switch {
+	case latest.CancellationDateMS != "":
+		purchase.Status = StatusRefunded
+	case !purchase.ExpiresAt.After(time.Now()):
+		purchase.Status = StatusExpired
+	case purchase.Status == StatusActive && !purchase.AutoRenew:
+		purchase.Status = StatusCanceled
+	}
+
+	return purchase, nil
+}
+
+func (v *AppleVerifier) postReceipt(ctx context.Context, url, receipt string) (*appleReceiptResponse, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"receipt-data":             receipt,
+		"password":                 v.SharedSecret,
+		"exclude-old-transactions": true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := v.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to contact app store: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("app store returned HTTP %d", res.StatusCode)
+	}
+
+	var out appleReceiptResponse
+	if err := json.NewDecoder(res.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("failed to decode app store response: %w", err)
+	}
+	return &out, nil
+}
+
+// appleNotification is the decoded payload of an App Store Server Notification V2
+type appleNotification struct {
+	NotificationType string `json:"notificationType"`
+	Subtype          string `json:"subtype"`
+	NotificationUUID string `json:"notificationUUID"`
+	SignedDate       int64  `json:"signedDate"`
+	Data             struct {
+		BundleID              string `json:"bundleId"`
+		SignedTransactionInfo string `json:"signedTransactionInfo"`
+		SignedRenewalInfo     string `json:"signedRenewalInfo"`
+	} `json:"data"`
+}
+
+type appleTransaction struct {
+	ProductID             string `json:"productId"`
+	OriginalTransactionID string `json:"originalTransactionId"`
+	ExpiresDate           int64  `json:"expiresDate"`
+	RevocationDate        int64  `json:"revocationDate"`
+}
+
+type appleRenewal struct {
+	AutoRenewStatus        int   `json:"autoRenewStatus"`
+	GracePeriodExpiresDate int64 `json:"gracePeriodExpiresDate"`
+}
+
+// AppleNotification is a verified App Store Server Notification
+type AppleNotification struct {
+	// ID is unique per notification and stays the same when Apple retries delivery
+	ID       string
+	SignedAt time.Time
+	Type     string
+	Purchase *Purchase
+}
+
+// ParseNotification verifies a signedPayload from App Store Server Notifications V2
+// and returns the subscription state it describes
+func (v *AppleVerifier) ParseNotification(signedPayload string) (*AppleNotification, error) {
+	if v.Roots == nil {
+		return nil, ErrNotConfigured
+	}
+
+	var notification appleNotification
+	if err := v.decodeJWS(signedPayload, &notification); err != nil {
+		return nil, err
+	}
+	if v.BundleID != "" && notification.Data.BundleID != v.BundleID {
+		return nil, fmt.Errorf("%w: unexpected bundle id %q", ErrInvalidNotification, notification.Data.BundleID)
+	}
+	if notification.Data.SignedTransactionInfo == "" {
+		return nil, fmt.Errorf("%w: %s carries no transaction", ErrInvalidNotification, notification.NotificationType)
+	}
+
+	var txn appleTransaction
+	if err := v.decodeJWS(notification.Data.SignedTransactionInfo, &txn); err != nil {
+		return nil, err
+	}
+
+	purchase := &Purchase{
+		Platform:              PlatformApple,
+		ProductID:             txn.ProductID,
+		OriginalTransactionID: txn.OriginalTransactionID,
+		ExpiresAt:             time.UnixMilli(txn.ExpiresDate),
+		Status:                StatusActive,
+	}
+
+	if notification.Data.SignedRenewalInfo != "" {
+		var renewal appleRenewal
+		if err := v.decodeJWS(notification.Data.SignedRenewalInfo, &renewal); err != nil {
+			return nil, err
+		}
+		purchase.AutoRenew = renewal.AutoRenewStatus == 1
+		if grace := time.UnixMilli(renewal.GracePeriodExpiresDate); renewal.GracePeriodExpiresDate > 0 && grace.After(purchase.ExpiresAt) {
+			purchase.ExpiresAt = grace
+		}
+	}
+
+	switch notification.NotificationType {
+	case "REFUND", "REVOKE":
+		purchase.Status = StatusRefunded
+	case "EXPIRED", "GRACE_PERIOD_EXPIRED":
+		purchase.Status = StatusExpired
+	case "DID_FAIL_TO_RENEW":
+		if notification.Subtype == "GRACE_PERIOD" {
+			purchase.Status = StatusGrace
+		} else {
+			purchase.Status = StatusExpired
+		}
+	default:
+		if txn.RevocationDate > 0 {
+			purchase.Status = StatusRefunded
+		} else if !purchase.ExpiresAt.After(time.Now()) {
+			purchase.Status = StatusExpired
+		} else if !purchase.AutoRenew {
+			purchase.Status = StatusCanceled
+		}
+	}
+
+	return &AppleNotification{
+		ID:       notification.NotificationUUID,
+		SignedAt: time.UnixMilli(notification.SignedDate),
+		Type:     notification.NotificationType,
+		Purchase: purchase,
+	}, nil
+}
+
+// decodeJWS verifies an App Store JWS against the x5c certificate chain in its
+// header, which must chain to one of the configured Apple roots
+func (v *AppleVerifier) decodeJWS(token string, out interface{}) error {
+	parsed, err := jwt.Parse(token, func(t *jwt.Token) (interface{}, error) {
+		chain, ok := t.Header["x5c"].([]interface{})
+		if !ok || len(chain) == 0 {
+			return nil, fmt.Errorf("missing x5c header")
+		}
+
+		certs := make([]*x509.Certificate, len(chain))
+		for i, raw := range chain {
+			encoded, ok := raw.(string)
+			if !ok {
+				return nil, fmt.Errorf("malformed x5c entry")
+			}
+			der, err := base64.StdEncoding.DecodeString(encoded)
+			if err != nil {
+				return nil, fmt.Errorf("malformed x5c entry: %w", err)
+			}
+			if certs[i], err = x509.ParseCertificate(der); err != nil {
+				return nil, fmt.Errorf("malformed x5c certificate: %w", err)
+			}
+		}
+
+		intermediates := x509.NewCertPool()
+		for _, cert := range certs[1:] {
+			intermediates.AddCert(cert)
+		}
+		if _, err := certs[0].Verify(x509.VerifyOptions{
+			Roots:         v.Roots,
+			Intermediates: intermediates,
+			KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+		}); err != nil {
+			return nil, fmt.Errorf("untrusted certificate chain: %w", err)
+		}
+
+		return certs[0].PublicKey, nil
+	}, jwt.WithValidMethods([]string{"ES256"}))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidNotification, err)
+	}
+
+	// Re-encode the verified claims into the typed payload
+	claims, err := json.Marshal(parsed.Claims)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(claims, out); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidNotification, err)
+	}
+	return nil
+}
+
+// msToTime converts the millisecond timestamps used by verifyReceipt
+func msToTime(ms string) time.Time {
+	value, err := strconv.ParseInt(ms, 10, 64)
+	if err != nil || value <= 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(value)
+}
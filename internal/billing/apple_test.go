@@ -0,0 +1,133 @@
+package billing
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type testChain struct {
+	roots *x509.CertPool
+	key   *ecdsa.PrivateKey
+	x5c   []string
+}
+
+func newTestChain(t *testing.T) *testChain {
+	rootKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("error generating key. Err: %v", err)
+	}
+	rootTmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test Root"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	rootDER, err := x509.CreateCertificate(rand.Reader, rootTmpl, rootTmpl, &rootKey.PublicKey, rootKey)
+	if err != nil {
+		t.Fatalf("error creating root. Err: %v", err)
+	}
+	root, _ := x509.ParseCertificate(rootDER)
+
+	leafKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("error generating key. Err: %v", err)
+	}
+	leafTmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "Test Leaf"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	leafDER, err := x509.CreateCertificate(rand.Reader, leafTmpl, root, &leafKey.PublicKey, rootKey)
+	if err != nil {
+		t.Fatalf("error creating leaf. Err: %v", err)
+	}
+
+	roots := x509.NewCertPool()
+	roots.AddCert(root)
+	return &testChain{
+		roots: roots,
+		key:   leafKey,
+		x5c:   []string{base64.StdEncoding.EncodeToString(leafDER), base64.StdEncoding.EncodeToString(rootDER)},
+	}
+}
+
+func (c *testChain) sign(t *testing.T, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, claims)
+	token.Header["x5c"] = c.x5c
+	signed, err := token.SignedString(c.key)
+	if err != nil {
+		t.Fatalf("error signing token. Err: %v", err)
+	}
+	return signed
+}
+
+func TestParseAppleNotification(t *testing.T) {
+	chain := newTestChain(t)
+	v := &AppleVerifier{BundleID: "com.fitnesshack.app", Roots: chain.roots}
+
+	expires := time.Now().Add(30 * 24 * time.Hour).Truncate(time.Millisecond)
+	payload := func(notificationType string) string {
+		return chain.sign(t, jwt.MapClaims{
+			"notificationType": notificationType,
+			"notificationUUID": "6f3a9c1e-0000-4000-8000-000000000001",
+			"signedDate":       time.Now().UnixMilli(),
+			"data": map[string]interface{}{
+				"bundleId": "com.fitnesshack.app",
+				"signedTransactionInfo": chain.sign(t, jwt.MapClaims{
+					"productId":             "premium_monthly",
+					"originalTransactionId": "1000000001",
+					"expiresDate":           expires.UnixMilli(),
+				}),
+				"signedRenewalInfo": chain.sign(t, jwt.MapClaims{"autoRenewStatus": 1}),
+			},
+		})
+	}
+
+	notification, err := v.ParseNotification(payload("DID_RENEW"))
+	if err != nil {
+		t.Fatalf("expected valid notification, got error: %v", err)
+	}
+	if notification.ID != "6f3a9c1e-0000-4000-8000-000000000001" || notification.SignedAt.IsZero() {
+		t.Errorf("unexpected notification metadata: %+v", notification)
+	}
+	purchase := notification.Purchase
+	if purchase.OriginalTransactionID != "1000000001" || purchase.ProductID != "premium_monthly" ||
+		!purchase.ExpiresAt.Equal(expires) || !purchase.AutoRenew || purchase.Status != StatusActive {
+		t.Errorf("unexpected purchase: %+v", purchase)
+	}
+
+	notification, err = v.ParseNotification(payload("REFUND"))
+	if err != nil {
+		t.Fatalf("expected valid notification, got error: %v", err)
+	}
+	purchase = notification.Purchase
+	if purchase.Status != StatusRefunded || purchase.GrantsPremium(time.Now()) {
+		t.Errorf("expected refunded purchase without premium, got %+v", purchase)
+	}
+}
+
+func TestParseAppleNotificationUntrustedChain(t *testing.T) {
+	trusted := newTestChain(t)
+	untrusted := newTestChain(t)
+	v := &AppleVerifier{Roots: trusted.roots}
+
+	_, err := v.ParseNotification(untrusted.sign(t, jwt.MapClaims{"notificationType": "DID_RENEW"}))
+	if !errors.Is(err, ErrInvalidNotification) {
+		t.Errorf("expected ErrInvalidNotification, got %v", err)
+	}
+}
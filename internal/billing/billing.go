@@ -0,0 +1,69 @@
+// Package billing validates in-app purchases and subscription notifications
+// from the Apple App Store and Google Play.
+package billing
+
+import (
+	"errors"
+	"time"
+)
+
+// Supported store platforms
+const (
+	PlatformApple  = "apple"
+	PlatformGoogle = "google"
+)
+
+// Subscription statuses stored in the subscriptions table
+const (
+	StatusActive   = "active"
+	StatusGrace    = "grace"
+	StatusCanceled = "canceled"
+	StatusExpired  = "expired"
+	StatusRefunded = "refunded"
+)
+
+var (
+	// ErrNotConfigured is returned when the store credentials are missing
+	ErrNotConfigured = errors.New("billing provider is not configured")
+
+	// ErrInvalidReceipt is returned when the store rejects a receipt or token
+	ErrInvalidReceipt = errors.New("invalid receipt")
+
+	// ErrInvalidNotification is returned when a server notification cannot be verified
+	ErrInvalidNotification = errors.New("invalid notification")
+)
+
+// Purchase is the normalized state of a store subscription
+type Purchase struct {
+	Platform              string
+	ProductID             string
+	OriginalTransactionID string
+	Status                string
+	ExpiresAt             time.Time
+	AutoRenew             bool
+}
+
+// GrantsPremium reports whether the purchase currently entitles the user to premium.
+// Canceled subscriptions remain valid until the end of the paid period.
+func (p *Purchase) GrantsPremium(now time.Time) bool {
+	switch p.Status {
+	case StatusActive, StatusGrace, StatusCanceled:
+		return p.ExpiresAt.After(now)
+	default:
+		return false
+	}
+}
+
+// Providers holds the configured store verifiers
+type Providers struct {
+	Apple  *AppleVerifier
+	Google *GoogleVerifier
+}
+
+// NewProvidersFromEnv configures the store verifiers from environment variables
+func NewProvidersFromEnv() *Providers {
+	return &Providers{
+		Apple:  NewAppleVerifierFromEnv(),
+		Google: NewGoogleVerifierFromEnv(),
+	}
+}
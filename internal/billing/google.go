@@ -0,0 +1,277 @@
+package billing
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	googleTokenURL        = "https://oauth2.googleapis.com/token"
+	googlePublisherScope  = "https://www.googleapis.com/auth/androidpublisher"
+	googlePublisherAPIURL = "https://androidpublisher.googleapis.com/androidpublisher/v3"
+)
+
+// GoogleServiceAccount holds the fields of a service account key file used to call the Play Developer API
+type GoogleServiceAccount struct {
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+	TokenURI    string `json:"token_uri"`
+}
+
+// GoogleVerifier validates Play Billing purchase tokens and Real-time Developer Notifications
+type GoogleVerifier struct {
+	PackageName string
+	Account     *GoogleServiceAccount
+	// PushToken is the shared secret expected on Pub/Sub push requests
+	PushToken string
+
+	APIURL string
+	Client *http.Client
+
+	mu          sync.Mutex
+	accessToken string
+	tokenExpiry time.Time
+}
+
+// NewGoogleVerifierFromEnv configures Play Billing validation from GOOGLE_PLAY_PACKAGE_NAME,
+// GOOGLE_PLAY_SERVICE_ACCOUNT (path to the key file) and GOOGLE_PLAY_PUSH_TOKEN
+func NewGoogleVerifierFromEnv() *GoogleVerifier {
+	v := &GoogleVerifier{
+		PackageName: os.Getenv("GOOGLE_PLAY_PACKAGE_NAME"),
+		PushToken:   os.Getenv("GOOGLE_PLAY_PUSH_TOKEN"),
+		APIURL:      googlePublisherAPIURL,
+		Client:      &http.Client{Timeout: 10 * time.Second},
+	}
+
+	if path := os.Getenv("GOOGLE_PLAY_SERVICE_ACCOUNT"); path != "" {
+		if data, err := os.ReadFile(path); err == nil {
+			var account GoogleServiceAccount
+			if json.Unmarshal(data, &account) == nil {
+				v.Account = &account
+			}
+		}
+	}
+
+	return v
+}
+
+type googleSubscription struct {
+	SubscriptionState string `json:"subscriptionState"`
+	LineItems         []struct {
+		ProductID        string `json:"productId"`
+		ExpiryTime       string `json:"expiryTime"`
+		AutoRenewingPlan *struct {
+			AutoRenewEnabled bool `json:"autoRenewEnabled"`
+		} `json:"autoRenewingPlan"`
+	} `json:"lineItems"`
+}
+
+// VerifyPurchase looks up a subscription purchase token with the Play Developer API
+func (v *GoogleVerifier) VerifyPurchase(ctx context.Context, purchaseToken string) (*Purchase, error) {
+	if v.Account == nil || v.PackageName == "" {
+		return nil, ErrNotConfigured
+	}
+
+	accessToken, err := v.getAccessToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := fmt.Sprintf("%s/applications/%s/purchases/subscriptionsv2/tokens/%s",
+		v.APIURL, url.PathEscape(v.PackageName), url.PathEscape(purchaseToken))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	res, err := v.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to contact google play: %w", err)
+	}
+	defer res.Body.Close()
+
+	switch {
+	case res.StatusCode == http.StatusNotFound || res.StatusCode == http.StatusBadRequest:
+		return nil, fmt.Errorf("%w: google play returned HTTP %d", ErrInvalidReceipt, res.StatusCode)
+	case res.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("google play returned HTTP %d", res.StatusCode)
+	}
+
+	var sub googleSubscription
+	if err := json.NewDecoder(res.Body).Decode(&sub); err != nil {
+		return nil, fmt.Errorf("failed to decode google play response: %w", err)
+	}
+	if len(sub.LineItems) == 0 {
+		return nil, fmt.Errorf("%w: purchase has no line items", ErrInvalidReceipt)
+	}
+
+	purchase := &Purchase{
+		Platform:              PlatformGoogle,
+		OriginalTransactionID: purchaseToken,
+	}
+	for _, item := range sub.LineItems {
+		expiry, err := time.Parse(time.RFC3339, item.ExpiryTime)
+		if err != nil || !expiry.After(purchase.ExpiresAt) {
+			continue
+		}
+		purchase.ExpiresAt = expiry
+		purchase.ProductID = item.ProductID
+		purchase.AutoRenew = item.AutoRenewingPlan != nil && item.AutoRenewingPlan.AutoRenewEnabled
+	}
+
+	switch sub.SubscriptionState {
+	case "SUBSCRIPTION_STATE_ACTIVE":
+		purchase.Status = StatusActive
+	case "SUBSCRIPTION_STATE_IN_GRACE_PERIOD":
+		purchase.Status = StatusGrace
+	case "SUBSCRIPTION_STATE_CANCELED":
+		purchase.Status = StatusCanceled
+	default:
+		// ON_HOLD, PAUSED, EXPIRED and PENDING do not grant access
+		purchase.Status = StatusExpired
+	}
+
+	return purchase, nil
+}
+
+// getAccessToken exchanges a signed service account assertion for an OAuth access token,
+// caching it until shortly before it expires
+func (v *GoogleVerifier) getAccessToken(ctx context.Context) (string, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.accessToken != "" && time.Now().Before(v.tokenExpiry) {
+		return v.accessToken, nil
+	}
+
+	tokenURL := v.Account.TokenURI
+	if tokenURL == "" {
+		tokenURL = googleTokenURL
+	}
+
+	key, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(v.Account.PrivateKey))
+	if err != nil {
+		return "", fmt.Errorf("failed to parse service account key: %w", err)
+	}
+
+	now := time.Now()
+	assertion, err := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":   v.Account.ClientEmail,
+		"scope": googlePublisherScope,
+		"aud":   tokenURL,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	}).SignedString(key)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign service account assertion: %w", err)
+	}
+
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {assertion},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	res, err := v.Client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch google access token: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("google token endpoint returned HTTP %d", res.StatusCode)
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("failed to decode google access token: %w", err)
+	}
+
+	v.accessToken = token.AccessToken
+	v.tokenExpiry = now.Add(time.Duration(token.ExpiresIn)*time.Second - time.Minute)
+	return v.accessToken, nil
+}
+
+// GoogleNotification is a Real-time Developer Notification about a subscription
+type GoogleNotification struct {
+	// MessageID is the Pub/Sub message ID, which is kept across redeliveries
+	MessageID        string
+	PublishedAt      time.Time
+	PackageName      string
+	PurchaseToken    string
+	SubscriptionID   string
+	NotificationType int
+}
+
+// ParseNotification authenticates and decodes a Pub/Sub push request carrying an RTDN.
+// Test notifications and one-time product notifications return a nil notification.
+func (v *GoogleVerifier) ParseNotification(pushToken string, body []byte) (*GoogleNotification, error) {
+	if v.PushToken == "" {
+		return nil, ErrNotConfigured
+	}
+	if subtle.ConstantTimeCompare([]byte(pushToken), []byte(v.PushToken)) != 1 {
+		return nil, fmt.Errorf("%w: bad push token", ErrInvalidNotification)
+	}
+
+	var push struct {
+		Message struct {
+			Data        string    `json:"data"`
+			MessageID   string    `json:"messageId"`
+			PublishTime time.Time `json:"publishTime"`
+		} `json:"message"`
+	}
+	if err := json.Unmarshal(body, &push); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidNotification, err)
+	}
+
+	data, err := base64.StdEncoding.DecodeString(push.Message.Data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidNotification, err)
+	}
+
+	var rtdn struct {
+		PackageName              string `json:"packageName"`
+		SubscriptionNotification *struct {
+			NotificationType int    `json:"notificationType"`
+			PurchaseToken    string `json:"purchaseToken"`
+			SubscriptionID   string `json:"subscriptionId"`
+		} `json:"subscriptionNotification"`
+	}
+	if err := json.Unmarshal(data, &rtdn); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidNotification, err)
+	}
+	if v.PackageName != "" && rtdn.PackageName != v.PackageName {
+		return nil, fmt.Errorf("%w: unexpected package %q", ErrInvalidNotification, rtdn.PackageName)
+	}
+	if rtdn.SubscriptionNotification == nil {
+		return nil, nil
+	}
+
+	return &GoogleNotification{
+		MessageID:        push.Message.MessageID,
+		PublishedAt:      push.Message.PublishTime,
+		PackageName:      rtdn.PackageName,
+		PurchaseToken:    rtdn.SubscriptionNotification.PurchaseToken,
+		SubscriptionID:   rtdn.SubscriptionNotification.SubscriptionID,
+		NotificationType: rtdn.SubscriptionNotification.NotificationType,
+	}, nil
+}
@@ -0,0 +1,154 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// PurgedAccount describes what a completed account purge removed, so callers can
+// clean up state kept outside Postgres
+type PurgedAccount struct {
+	UserID      string
+	WorkoutIDs  []string
+	StorageKeys []string
+}
+
+// ScheduleAccountDeletion schedules the user's account to be purged after purgeAfter.
+// If a deletion is already scheduled it is returned unchanged.
+func (s *service) ScheduleAccountDeletion(ctx context.Context, userID, requestedIP string, purgeAfter time.Time) (*Account_deletions, error) {
+	var deletion Account_deletions
+	query := `INSERT INTO account_deletions (user_id, requested_ip, purge_after)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) WHERE status = 'pending' DO NOTHING
+		RETURNING *`
+	err := s.db.GetContext(ctx, &deletion, query, userID, requestedIP, purgeAfter)
+	if errors.Is(err, sql.ErrNoRows) {
+		return s.GetPendingAccountDeletion(ctx, userID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &deletion, nil
+}
+
+// GetPendingAccountDeletion returns the user's scheduled deletion.
+// Returns sql.ErrNoRows if none is scheduled.
+func (s *service) GetPendingAccountDeletion(ctx context.Context, userID string) (*Account_deletions, error) {
+	var deletion Account_deletions
+	query := `SELECT * FROM account_deletions WHERE user_id = $1 AND status = 'pending'`
+	if err := s.db.GetContext(ctx, &deletion, query, userID); err != nil {
+		return nil, err
+	}
+	return &deletion, nil
+}
+
+// CancelAccountDeletion cancels the user's scheduled deletion.
+// Returns sql.ErrNoRows if none is scheduled.
+func (s *service) CancelAccountDeletion(ctx context.Context, userID string) error {
+	query := `UPDATE account_deletions SET status = 'canceled', canceled_at = NOW()
+		WHERE user_id = $1 AND status = 'pending'`
+	result, err := s.db.ExecContext(ctx, query, userID)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// ListDueAccountDeletions returns up to limit pending deletions whose grace period ended before now.
+// Users under legal hold are left out; their deletion stays pending until the hold is released.
+func (s *service) ListDueAccountDeletions(ctx context.Context, now time.Time, limit int) ([]Account_deletions, error) {
+	var deletions []Account_deletions
+	query := `SELECT * FROM account_deletions d
+		WHERE status = 'pending' AND purge_after <= $1 AND NOT ` + heldUser("d") + `
+		ORDER BY purge_after
+		LIMIT $2`
+	err := s.db.SelectContext(ctx, &deletions, query, now, limit)
+	return deletions, err
+}
+
+// PurgeAccount permanently deletes the user of a due deletion and everything they own
+// in a single transaction, then marks the deletion completed with per-table row counts.
+// Returns sql.ErrNoRows if the deletion is no longer pending, was canceled, is being
+// purged by another instance, or the user has been placed under legal hold.
+func (s *service) PurgeAccount(ctx context.Context, deletionID string) (*PurgedAccount, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var userID string
+	err = tx.GetContext(ctx, &userID, `SELECT user_id FROM account_deletions d
+		WHERE id = $1 AND status = 'pending' AND purge_after <= NOW() AND NOT `+heldUser("d")+`
+		FOR UPDATE OF d SKIP LOCKED`, deletionID)
+	if err != nil {
+		return nil, err
+	}
+
+	purged := &PurgedAccount{UserID: userID}
+	if err := tx.SelectContext(ctx, &purged.WorkoutIDs, `SELECT id FROM workouts WHERE user_id = $1`, userID); err != nil {
+		return nil, fmt.Errorf("failed to list workouts: %w", err)
+	}
+	err = tx.SelectContext(ctx, &purged.StorageKeys,
+		`SELECT storage_key FROM data_exports WHERE user_id = $1 AND storage_key <> ''
+		UNION ALL
+		SELECT key FROM progress_photos, unnest(ARRAY[storage_key, thumbnail_key]) AS key
+		WHERE user_id = $1 AND key <> ''`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stored files: %w", err)
+	}
+
+	// Tables created before 007 lost their foreign keys to users, so they are cleared
+	// explicitly; newer tables cascade from the users row.
+	steps := []struct {
+		Table string
+		Query string
+	}{
+		{"workout_exercises", `DELETE FROM workout_exercises WHERE workout_id IN (SELECT id FROM workouts WHERE user_id = $1)`},
+		{"workout_sessions", `DELETE FROM workout_sessions WHERE user_id = $1`},
+		{"workouts", `DELETE FROM workouts WHERE user_id = $1`},
+		{"programs", `DELETE FROM programs WHERE user_id = $1`},
+		{"users", `DELETE FROM users WHERE id = $1`},
+	}
+
+	counts := make(map[string]int64, len(steps))
+	for _, step := range steps {
+		result, err := tx.ExecContext(ctx, step.Query, userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to purge %s: %w", step.Table, err)
+		}
+		counts[step.Table], _ = result.RowsAffected()
+	}
+
+	purgedRows, err := json.Marshal(counts)
+	if err != nil {
+		return nil, err
+	}
+	_, err = tx.ExecContext(ctx, `UPDATE account_deletions
+		SET status = 'completed', completed_at = NOW(), attempts = attempts + 1, last_error = '', purged_rows = $2
+		WHERE id = $1`, deletionID, purgedRows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record account purge: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit account purge: %w", err)
+	}
+	return purged, nil
+}
+
+// RecordAccountDeletionFailure stores the error of a failed purge attempt; the deletion
+// stays pending and is retried on the next run
+func (s *service) RecordAccountDeletionFailure(ctx context.Context, deletionID, reason string) error {
+	_, err := s.db.ExecContext(ctx,
+		`UPDATE account_deletions SET attempts = attempts + 1, last_error = $2 WHERE id = $1`,
+		deletionID, reason)
+	return err
+}
@@ -0,0 +1,112 @@
+package database
+
+import (
+	"context"
+	"strings"
+)
+
+// UserResourceCounts is how many of each kind of resource a user owns
+type UserResourceCounts struct {
+	Workouts        int `db:"workouts" json:"workouts"`
+	WorkoutSessions int `db:"workout_sessions" json:"workoutSessions"`
+	Programs        int `db:"programs" json:"programs"`
+	CustomExercises int `db:"custom_exercises" json:"customExercises"`
+	ProgressPhotos  int `db:"progress_photos" json:"progressPhotos"`
+	BodyMetrics     int `db:"body_metrics" json:"bodyMetrics"`
+	NutritionLogs   int `db:"nutrition_logs" json:"nutritionLogs"`
+	APIKeys         int `db:"api_keys" json:"apiKeys"`
+	Webhooks        int `db:"webhooks" json:"webhooks"`
+	Devices         int `db:"devices" json:"devices"`
+	ActiveSessions  int `db:"active_sessions" json:"activeSessions"`
+	Organizations   int `db:"organizations" json:"organizations"`
+}
+
+// SearchUsers returns the users whose ID is query, or whose email, username or name
+// contains it, newest first
+func (s *service) SearchUsers(ctx context.Context, query string, limit, offset int) ([]Users, error) {
+	pattern := "%" + strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(query) + "%"
+	users := []Users{}
+	err := s.db.SelectContext(ctx, &users, `SELECT * FROM users
+		WHERE id::text = $1
+			OR email ILIKE $2 OR username ILIKE $2
+			OR first_name ILIKE $2 OR last_name ILIKE $2
+		ORDER BY created_at DESC
+		LIMIT $3 OFFSET $4`, query, pattern, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// SetUserDisabled disables or re-enables a user's account. Disabling an account that is
+// already disabled keeps the original time. Returns sql.ErrNoRows if there's no such user.
+func (s *service) SetUserDisabled(ctx context.Context, userID string, disabled bool) (*Users, error) {
+	var user Users
+	query := `UPDATE users SET
+			disabled_at = CASE WHEN $2 THEN COALESCE(disabled_at, NOW()) END,
+			updated_at = NOW(), version = version + 1
+		WHERE id = $1
+		RETURNING *`
+	if err := s.db.GetContext(ctx, &user, query, userID, disabled); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// RequirePasswordReset makes the user's next password sign-in only good for changing the
+// password. Returns sql.ErrNoRows if there's no such user.
+func (s *service) RequirePasswordReset(ctx context.Context, userID string) (*Users, error) {
+	var user Users
+	query := `UPDATE users SET password_reset_required = TRUE, updated_at = NOW(), version = version + 1
+		WHERE id = $1
+		RETURNING *`
+	if err := s.db.GetContext(ctx, &user, query, userID); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// CountUserResources counts what the user owns, for support to see at a glance
+func (s *service) CountUserResources(ctx context.Context, userID string) (*UserResourceCounts, error) {
+	var counts UserResourceCounts
+	err := s.db.GetContext(ctx, &counts, `SELECT
+		(SELECT COUNT(*) FROM workouts WHERE user_id = $1) AS workouts,
+		(SELECT COUNT(*) FROM workout_sessions WHERE user_id = $1) AS workout_sessions,
+		(SELECT COUNT(*) FROM programs WHERE user_id = $1) AS programs,
+		(SELECT COUNT(*) FROM exercises WHERE created_by = $1) AS custom_exercises,
+		(SELECT COUNT(*) FROM progress_photos WHERE user_id = $1) AS progress_photos,
+		(SELECT COUNT(*) FROM body_metrics WHERE user_id = $1) AS body_metrics,
+		(SELECT COUNT(*) FROM nutrition_logs WHERE user_id = $1) AS nutrition_logs,
+		(SELECT COUNT(*) FROM api_keys WHERE user_id = $1) AS api_keys,
+		(SELECT COUNT(*) FROM webhooks WHERE user_id = $1) AS webhooks,
+		(SELECT COUNT(*) FROM devices WHERE user_id = $1) AS devices,
+		(SELECT COUNT(*) FROM user_sessions
+			WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > NOW()) AS active_sessions,
+		(SELECT COUNT(*) FROM organization_members WHERE user_id = $1) AS organizations`, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &counts, nil
+}
+
+// CreateAdminAuditLog records a request an admin made
+func (s *service) CreateAdminAuditLog(ctx context.Context, entry *Admin_audit_log) error {
+	_, err := s.db.NamedExecContext(ctx, `INSERT INTO admin_audit_log
+			(admin_id, action, path, target_user_id, status_code, ip_address)
+		VALUES (:admin_id, :action, :path, :target_user_id, :status_code, :ip_address)`, entry)
+	return err
+}
+
+// ListAdminAuditLog returns audit entries newest first, only those about targetUserID
+// when it is set
+func (s *service) ListAdminAuditLog(ctx context.Context, targetUserID string, limit, offset int) ([]Admin_audit_log, error) {
+	entries := []Admin_audit_log{}
+	err := s.db.SelectContext(ctx, &entries, `SELECT * FROM admin_audit_log
+		WHERE $1 = '' OR target_user_id::text = $1
+		ORDER BY created_at DESC
+		LIMIT $2 OFFSET $3`, targetUserID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
@@ -0,0 +1,60 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+)
+
+// CreateAPIKey stores a new API key; only its hash is persisted
+func (s *service) CreateAPIKey(ctx context.Context, key *Api_keys) (*Api_keys, error) {
+	query := `INSERT INTO api_keys (user_id, name, prefix, key_hash, scope)
+		VALUES (:user_id, :name, :prefix, :key_hash, :scope)
+		RETURNING *`
+
+	query, args, err := s.db.BindNamed(query, key)
+	if err != nil {
+		return nil, err
+	}
+
+	var created Api_keys
+	if err := s.db.QueryRowxContext(ctx, query, args...).StructScan(&created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// AuthenticateAPIKey looks up a key by hash and records its use.
+// Returns sql.ErrNoRows if no such key exists or its owner's account is disabled.
+func (s *service) AuthenticateAPIKey(ctx context.Context, keyHash string) (*Api_keys, error) {
+	var key Api_keys
+	query := `UPDATE api_keys SET last_used_at = NOW()
+		WHERE key_hash = $1
+			AND NOT EXISTS (SELECT 1 FROM users u WHERE u.id = api_keys.user_id AND u.disabled_at IS NOT NULL)
+		RETURNING *`
+	err := s.db.GetContext(ctx, &key, query, keyHash)
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// ListAPIKeysByUser returns the user's API keys, newest first
+func (s *service) ListAPIKeysByUser(ctx context.Context, userID string) ([]Api_keys, error) {
+	var keys []Api_keys
+	query := `SELECT * FROM api_keys WHERE user_id = $1 ORDER BY created_at DESC`
+	err := s.db.SelectContext(ctx, &keys, query, userID)
+	return keys, err
+}
+
+// DeleteAPIKey revokes one of the user's API keys.
+// Returns sql.ErrNoRows if the key does not exist or belongs to someone else.
+func (s *service) DeleteAPIKey(ctx context.Context, id, userID string) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM api_keys WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
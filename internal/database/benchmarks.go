@@ -0,0 +1,183 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// BenchmarkAllClass is the bodyweight class of distributions over every lifter, including
+// those who never logged their bodyweight
+const BenchmarkAllClass = "all"
+
+// BodyweightClass is a range of bodyweights lifters are compared within, from MinKg up to
+// but not including MaxKg. Zero bounds are open.
+type BodyweightClass struct {
+	Name  string
+	MinKg float64
+	MaxKg float64
+}
+
+// BodyweightClasses are the classes benchmarks are split by, lightest first
+var BodyweightClasses = []BodyweightClass{
+	{Name: "u60", MaxKg: 60},
+	{Name: "60-70", MinKg: 60, MaxKg: 70},
+	{Name: "70-80", MinKg: 70, MaxKg: 80},
+	{Name: "80-90", MinKg: 80, MaxKg: 90},
+	{Name: "90-100", MinKg: 90, MaxKg: 100},
+	{Name: "100-110", MinKg: 100, MaxKg: 110},
+	{Name: "110-120", MinKg: 110, MaxKg: 120},
+	{Name: "o120", MinKg: 120},
+}
+
+// BodyweightClassOf returns the name of the class a bodyweight falls in
+func BodyweightClassOf(kg float64) string {
+	for _, class := range BodyweightClasses[:len(BodyweightClasses)-1] {
+		if kg < class.MaxKg {
+			return class.Name
+		}
+	}
+	return BodyweightClasses[len(BodyweightClasses)-1].Name
+}
+
+// ValidBodyweightClass reports whether name is one of BodyweightClasses or BenchmarkAllClass
+func ValidBodyweightClass(name string) bool {
+	if name == BenchmarkAllClass {
+		return true
+	}
+	for _, class := range BodyweightClasses {
+		if class.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// BenchmarkProfile is what benchmarks need to know about a user
+type BenchmarkProfile struct {
+	ConsentedAt  *time.Time       `db:"consented_at"`
+	BodyweightKg *decimal.Decimal `db:"bodyweight_kg"`
+}
+
+// GetBenchmarkProfile returns whether the user opted in to benchmarking and their latest
+// logged bodyweight
+func (s *service) GetBenchmarkProfile(ctx context.Context, userID string) (*BenchmarkProfile, error) {
+	var profile BenchmarkProfile
+	query := `SELECT
+			(SELECT consented_at FROM benchmark_consents WHERE user_id = $1) AS consented_at,
+			(SELECT measured_value FROM body_metrics WHERE user_id = $1 AND metric = 'weight_kg'
+			ORDER BY recorded_at DESC LIMIT 1) AS bodyweight_kg`
+	if err := s.db.GetContext(ctx, &profile, query, userID); err != nil {
+		return nil, fmt.Errorf("failed to get benchmark profile: %w", err)
+	}
+	return &profile, nil
+}
+
+// GrantBenchmarkConsent opts the user in to benchmarking. Opting in again keeps the time
+// they first did.
+func (s *service) GrantBenchmarkConsent(ctx context.Context, userID string) (*Benchmark_consents, error) {
+	var consent Benchmark_consents
+	query := `INSERT INTO benchmark_consents (user_id) VALUES ($1)
+		ON CONFLICT (user_id) DO UPDATE SET user_id = EXCLUDED.user_id
+		RETURNING *`
+	if err := s.db.GetContext(ctx, &consent, query, userID); err != nil {
+		return nil, fmt.Errorf("failed to save benchmark consent: %w", err)
+	}
+	return &consent, nil
+}
+
+// RevokeBenchmarkConsent opts the user out of benchmarking, returning sql.ErrNoRows if they
+// weren't opted in
+func (s *service) RevokeBenchmarkConsent(ctx context.Context, userID string) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM benchmark_consents WHERE user_id = $1`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke benchmark consent: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// GetBenchmarkDistribution returns the distribution of an exercise in a bodyweight class, or
+// sql.ErrNoRows if too few consenting users lift it for one to be published
+func (s *service) GetBenchmarkDistribution(ctx context.Context, exerciseID, bodyweightClass string) (*Benchmark_distributions, error) {
+	var distribution Benchmark_distributions
+	query := `SELECT * FROM benchmark_distributions WHERE exercise_id = $1 AND bodyweight_class = $2`
+	if err := s.db.GetContext(ctx, &distribution, query, exerciseID, bodyweightClass); err != nil {
+		return nil, err
+	}
+	return &distribution, nil
+}
+
+// RefreshBenchmarkDistributions rebuilds every distribution from the lifts consenting users
+// logged since the given time, returning how many were stored. Each user counts once per
+// exercise, with their best estimated one-rep max (Epley's formula, reps capped at 12), in
+// their bodyweight class by their latest logged bodyweight and in BenchmarkAllClass.
+// Custom exercises are private and left out, as are distributions of fewer than
+// minSampleSize users, so no published figure can be traced back to a handful of lifters.
+func (s *service) RefreshBenchmarkDistributions(ctx context.Context, since time.Time, minSampleSize int) (int, error) {
+	bounds := make([]float64, len(BodyweightClasses)-1)
+	names := make([]string, len(BodyweightClasses))
+	for i, class := range BodyweightClasses {
+		names[i] = class.Name
+		if i > 0 {
+			bounds[i-1] = class.MinKg
+		}
+	}
+	fractions := make([]float64, 99)
+	for i := range fractions {
+		fractions[i] = float64(i+1) / 100
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM benchmark_distributions`); err != nil {
+		return 0, fmt.Errorf("failed to clear benchmark distributions: %w", err)
+	}
+	result, err := tx.ExecContext(ctx, `WITH bests AS (
+			SELECT ws.user_id, wss.exercise_id,
+				MAX(CASE WHEN wss.reps <= 1 THEN wss.weight_kg
+					ELSE wss.weight_kg * (30 + LEAST(wss.reps, 12)) / 30 END) AS one_rep_max_kg
+			FROM workout_session_sets wss
+			JOIN workout_sessions ws ON ws.id = wss.session_id
+			JOIN benchmark_consents bc ON bc.user_id = ws.user_id
+			JOIN exercises e ON e.id = wss.exercise_id AND e.created_by IS NULL
+			WHERE wss.completed_at >= $1 AND wss.weight_kg > 0 AND wss.reps > 0
+				AND left(wss.client_id, 5) <> 'copy:'
+			GROUP BY ws.user_id, wss.exercise_id
+		), classed AS (
+			SELECT b.exercise_id, b.one_rep_max_kg,
+				($3::text[])[width_bucket(bw.measured_value, $2::numeric[]) + 1] AS bodyweight_class
+			FROM bests b
+			LEFT JOIN LATERAL (
+				SELECT measured_value FROM body_metrics
+				WHERE user_id = b.user_id AND metric = 'weight_kg'
+				ORDER BY recorded_at DESC LIMIT 1
+			) bw ON TRUE
+		)
+		INSERT INTO benchmark_distributions (exercise_id, bodyweight_class, sample_size, percentiles)
+		SELECT exercise_id,
+			CASE WHEN GROUPING(bodyweight_class) = 1 THEN $4::text ELSE bodyweight_class END,
+			COUNT(*),
+			to_jsonb(percentile_cont($5::float8[]) WITHIN GROUP (ORDER BY one_rep_max_kg::float8))
+		FROM classed
+		GROUP BY GROUPING SETS ((exercise_id, bodyweight_class), (exercise_id))
+		HAVING COUNT(*) >= $6 AND (GROUPING(bodyweight_class) = 1 OR bodyweight_class IS NOT NULL)`,
+		since, bounds, names, BenchmarkAllClass, fractions, minSampleSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to compute benchmark distributions: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit benchmark distributions: %w", err)
+	}
+	n, _ := result.RowsAffected()
+	return int(n), nil
+}
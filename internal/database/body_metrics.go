@@ -0,0 +1,139 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"fitness-hack/internal/database/sqlbuild"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// HealthImportResult counts the records stored and the duplicates skipped by an import
+type HealthImportResult struct {
+	SessionsImported int
+	SessionsSkipped  int
+	MetricsImported  int
+	MetricsSkipped   int
+}
+
+// ImportHealthRecords stores imported sessions and body metrics in one transaction.
+// A session is skipped if it was imported before or if the user already has a session
+// starting within matchWindow of it, e.g. the same run logged in the app or synced from
+// Strava. Metrics are skipped if one of the same kind was recorded at the same time.
+func (s *service) ImportHealthRecords(ctx context.Context, userID string, sessions []Workout_sessions, metrics []Body_metrics, matchWindow time.Duration) (*HealthImportResult, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result := &HealthImportResult{}
+	for i := range sessions {
+		id, err := insertImportedSession(ctx, tx, userID, &sessions[i], matchWindow)
+		if err != nil {
+			return nil, err
+		}
+		if id != "" {
+			result.SessionsImported++
+		} else {
+			result.SessionsSkipped++
+		}
+	}
+
+	for _, metric := range metrics {
+		inserted, err := tx.ExecContext(ctx,
+			`INSERT INTO body_metrics (user_id, metric, measured_value, recorded_at, source) VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (user_id, metric, recorded_at) DO NOTHING`,
+			userID, metric.Metric, metric.Measured_value, metric.Recorded_at, metric.Source)
+		if err != nil {
+			return nil, fmt.Errorf("failed to import body metric: %w", err)
+		}
+		if n, _ := inserted.RowsAffected(); n > 0 {
+			result.MetricsImported++
+		} else {
+			result.MetricsSkipped++
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit import: %w", err)
+	}
+	return result, nil
+}
+
+// insertImportedSession stores an imported session unless it is a duplicate, as described
+// by ImportHealthRecords. Returns the new session's ID, or "" if it was skipped.
+func insertImportedSession(ctx context.Context, tx *sqlx.Tx, userID string, ws *Workout_sessions, matchWindow time.Duration) (string, error) {
+	var id string
+	err := tx.GetContext(ctx, &id,
+		`INSERT INTO workout_sessions (user_id, name, started_at, completed_at, duration_minutes, notes, source, external_id, exercise_id,
+			duration_seconds, distance_meters, avg_heart_rate, max_heart_rate, start_latitude, start_longitude)
+		SELECT $1::uuid, $2, $3::timestamptz, $4::timestamptz, $5::integer, $6, $7, $8, $9::uuid,
+			$11::integer, $12::double precision, $13::integer, $14::integer, $15::double precision, $16::double precision
+		WHERE NOT EXISTS (
+			SELECT 1 FROM workout_sessions
+			WHERE user_id = $1 AND started_at BETWEEN $3::timestamptz - $10::interval AND $3::timestamptz + $10::interval
+		)
+		ON CONFLICT (user_id, source, external_id) WHERE external_id <> '' DO NOTHING
+		RETURNING id`,
+		userID, ws.Name, ws.Started_at, ws.Completed_at, ws.Duration_minutes, ws.Notes,
+		ws.Source, ws.External_id, ws.Exercise_id, fmt.Sprintf("%d seconds", int(matchWindow.Seconds())),
+		ws.Duration_seconds, ws.Distance_meters, ws.Avg_heart_rate, ws.Max_heart_rate, ws.Start_latitude, ws.Start_longitude)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to import session: %w", err)
+	}
+	return id, nil
+}
+
+// ListBodyMetricsOpts narrows ListBodyMetrics. Zero fields don't filter, and they combine
+// with the sorting, paging and equality filters of ListOptions.
+type ListBodyMetricsOpts struct {
+	ListOptions
+
+	// Metric keeps measurements of one kind
+	Metric string
+
+	// From and To keep measurements recorded in [From, To)
+	From time.Time
+	To   time.Time
+}
+
+// query builds the SELECT of the user's measurements for the options
+func (o ListBodyMetricsOpts) query(userID string) (string, []interface{}, error) {
+	q := sqlbuild.New(`SELECT * FROM body_metrics WHERE user_id = $1`, userID)
+	if o.Metric != "" {
+		q.Filter(bodyMetricList.filters, "metric", o.Metric)
+	}
+	if !o.From.IsZero() {
+		q.Compare(bodyMetricList.sorts, "recorded_at", sqlbuild.Gte, o.From)
+	}
+	if !o.To.IsZero() {
+		q.Compare(bodyMetricList.sorts, "recorded_at", sqlbuild.Lt, o.To)
+	}
+	return o.ListOptions.build(q, bodyMetricList)
+}
+
+// ListBodyMetrics returns the user's measurements matching opts, newest first by default
+func (s *service) ListBodyMetrics(ctx context.Context, userID string, opts ListBodyMetricsOpts) ([]Body_metrics, error) {
+	query, args, err := opts.query(userID)
+	if err != nil {
+		return nil, err
+	}
+	var metrics []Body_metrics
+	err = s.db.SelectContext(ctx, &metrics, query, args...)
+	return metrics, err
+}
+
+var bodyMetricList = listSpec{
+	sorts:        map[string]string{"recorded_at": "recorded_at"},
+	filters:      map[string]string{"metric": "metric", "source": "source"},
+	defaultOrder: "recorded_at DESC",
+	tiebreak:     "id",
+}
@@ -0,0 +1,219 @@
+package database
+
+import (
+	"archive/tar"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"fitness-hack/internal/database/postgres"
+
+	embeddedpostgres "github.com/fergusstrange/embedded-postgres"
+	"github.com/xi2/xz"
+)
+
+// bundledVersion is the Postgres release standalone mode runs, and the version `make standalone`
+// embeds. A data directory made by another major version is wiped and initialized again, so
+// changing it needs a dump and restore.
+const bundledVersion = embeddedpostgres.V15
+
+// ErrPostgresNotBundled is returned by StartBundled when the binary was built without a Postgres
+// archive for its platform and no extracted binaries were configured
+var ErrPostgresNotBundled = errors.New("this binary doesn't include Postgres " + string(bundledVersion) + " for " +
+	runtime.GOOS + "/" + runtime.GOARCH + "; build it with make standalone or set STANDALONE_POSTGRES_BINARIES")
+
+// BundledConfig is where standalone mode's bundled Postgres keeps its files and listens
+type BundledConfig struct {
+	// DataDir holds the database, the extracted Postgres binaries and the generated password
+	DataDir string
+	Port    uint32
+	// Password is the Postgres user's; when empty, one is generated on first start and kept
+	// in DataDir
+	Password string
+	// BinariesDir holds already extracted Postgres binaries, used instead of the embedded archive
+	BinariesDir string
+	// Logger receives the Postgres server's output
+	Logger io.Writer
+}
+
+// BundledConfigFromEnv reads STANDALONE_DATA_DIR (default ./fitnesshack-data),
+// STANDALONE_POSTGRES_PORT (default 54329), STANDALONE_POSTGRES_PASSWORD and
+// STANDALONE_POSTGRES_BINARIES
+func BundledConfigFromEnv() BundledConfig {
+	config := BundledConfig{
+		DataDir:     os.Getenv("STANDALONE_DATA_DIR"),
+		Port:        54329,
+		Password:    os.Getenv("STANDALONE_POSTGRES_PASSWORD"),
+		BinariesDir: os.Getenv("STANDALONE_POSTGRES_BINARIES"),
+		Logger:      os.Stderr,
+	}
+	if config.DataDir == "" {
+		config.DataDir = "fitnesshack-data"
+	}
+	if port, err := strconv.ParseUint(os.Getenv("STANDALONE_POSTGRES_PORT"), 10, 16); err == nil && port > 0 {
+		config.Port = uint32(port)
+	}
+	return config
+}
+
+// StartBundled runs the Postgres server bundled with standalone mode, as a child process
+// listening on localhost, and returns the configuration to connect to it with a function
+// that stops it. The database is created on first start and kept in config.DataDir.
+// Nothing is downloaded: the binaries come from config.BinariesDir or the embedded archive.
+func StartBundled(config BundledConfig) (*Config, func() error, error) {
+	dir, err := filepath.Abs(config.DataDir)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, nil, fmt.Errorf("failed to create data directory: %w", err)
+	}
+
+	binaries := config.BinariesDir
+	if binaries == "" {
+		binaries = filepath.Join(dir, "binaries", string(bundledVersion))
+		if err := extractBundledPostgres(binaries); err != nil {
+			return nil, nil, err
+		}
+	}
+	// embedded-postgres downloads the binaries when they are missing, which standalone mode
+	// never wants
+	if _, err := os.Stat(filepath.Join(binaries, "bin")); err != nil {
+		return nil, nil, fmt.Errorf("no Postgres binaries in %s: %w", binaries, err)
+	}
+
+	password := config.Password
+	if password == "" {
+		if password, err = bundledPassword(dir); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	const name = "fitnesshack"
+	pgConfig := embeddedpostgres.DefaultConfig().
+		Version(bundledVersion).
+		Port(config.Port).
+		Database(name).
+		Username(name).
+		Password(password).
+		DataPath(filepath.Join(dir, "postgres")).
+		RuntimePath(filepath.Join(dir, "runtime")).
+		BinariesPath(binaries).
+		Logger(config.Logger)
+
+	pg := embeddedpostgres.NewDatabase(pgConfig)
+	if err := pg.Start(); err != nil {
+		return nil, nil, fmt.Errorf("failed to start bundled postgres: %w", err)
+	}
+
+	dbConfig := DefaultConfig()
+	dbConfig.Host = "localhost"
+	dbConfig.Port = strconv.FormatUint(uint64(config.Port), 10)
+	dbConfig.Database = name
+	dbConfig.Username = name
+	dbConfig.Password = password
+	dbConfig.Schema = ""
+	dbConfig.SSLMode = "disable"
+	return dbConfig, pg.Stop, nil
+}
+
+// bundledPassword returns the password of the bundled Postgres's user, generated on first
+// start and kept readable only by the owner in dir
+func bundledPassword(dir string) (string, error) {
+	path := filepath.Join(dir, "postgres-password")
+	if saved, err := os.ReadFile(path); err == nil {
+		return strings.TrimSpace(string(saved)), nil
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return "", fmt.Errorf("failed to read postgres password: %w", err)
+	}
+
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	password := hex.EncodeToString(b)
+	if err := os.WriteFile(path, []byte(password+"\n"), 0o600); err != nil {
+		return "", fmt.Errorf("failed to save postgres password: %w", err)
+	}
+	return password, nil
+}
+
+// extractBundledPostgres unpacks the Postgres archive embedded for this platform into dir,
+// unless an earlier start already did. It unpacks next to dir first, so a start interrupted
+// halfway leaves nothing that looks complete.
+func extractBundledPostgres(dir string) error {
+	if _, err := os.Stat(filepath.Join(dir, "bin")); err == nil {
+		return nil
+	}
+	archive, err := postgres.Archives.Open(fmt.Sprintf("%s-%s-%s.txz", runtime.GOOS, runtime.GOARCH, bundledVersion))
+	if errors.Is(err, fs.ErrNotExist) {
+		return ErrPostgresNotBundled
+	} else if err != nil {
+		return err
+	}
+	defer archive.Close()
+
+	if err := os.MkdirAll(filepath.Dir(dir), 0o700); err != nil {
+		return err
+	}
+	tmp, err := os.MkdirTemp(filepath.Dir(dir), "extract-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	xzReader, err := xz.NewReader(archive, 0)
+	if err != nil {
+		return fmt.Errorf("failed to read postgres archive: %w", err)
+	}
+	files := tar.NewReader(xzReader)
+	for {
+		header, err := files.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read postgres archive: %w", err)
+		}
+		target := filepath.Join(tmp, header.Name)
+		if !strings.HasPrefix(target, tmp+string(filepath.Separator)) {
+			return fmt.Errorf("postgres archive has a path outside it: %s", header.Name)
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+			return err
+		}
+		switch header.Typeflag {
+		case tar.TypeDir:
+			err = os.MkdirAll(target, 0o755)
+		case tar.TypeSymlink:
+			err = os.Symlink(header.Linkname, target)
+		case tar.TypeReg:
+			err = extractFile(target, files, header.FileInfo().Mode().Perm())
+		}
+		if err != nil {
+			return fmt.Errorf("failed to extract postgres archive: %w", err)
+		}
+	}
+	return os.Rename(tmp, dir)
+}
+
+// extractFile writes the contents of r to a new file at path
+func extractFile(path string, r io.Reader, perm fs.FileMode) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,28 @@
+package database
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBundledPassword(t *testing.T) {
+	dir := t.TempDir()
+	password, err := bundledPassword(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(password) < 32 {
+		t.Errorf("expected a long random password, got %q", password)
+	}
+	again, err := bundledPassword(dir)
+	if err != nil || again != password {
+		t.Errorf("expected the saved password on the next start, got %q %v", again, err)
+	}
+	if info, err := os.Stat(filepath.Join(dir, "postgres-password")); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("expected the password file readable only by its owner, got %v %v", info, err)
+	}
+	if other, _ := bundledPassword(t.TempDir()); other == password {
+		t.Error("expected each data directory to get its own password")
+	}
+}
@@ -0,0 +1,192 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// ErrChallengeEnded is returned when joining a challenge that is over
+var ErrChallengeEnded = errors.New("challenge has ended")
+
+// Challenge is a challenge with its number of participants and whether the user reading
+// it joined
+type Challenge struct {
+	Challenges
+	Participants int  `db:"participants"`
+	Joined       bool `db:"joined"`
+}
+
+// ChallengeStanding is a participant's progress in a challenge. Completed_at is when
+// their progress first reached the target, nil until it does.
+type ChallengeStanding struct {
+	User_id      string          `db:"user_id"`
+	Display_name string          `db:"display_name"`
+	Progress     decimal.Decimal `db:"progress"`
+	Completed_at *time.Time      `db:"completed_at"`
+	Joined_at    time.Time       `db:"joined_at"`
+}
+
+// challengeSelect reads challenges as c with their participant count and whether the
+// user in $1 joined them
+const challengeSelect = `SELECT c.*,
+		(SELECT COUNT(*) FROM challenge_participants cp WHERE cp.challenge_id = c.id) AS participants,
+		EXISTS (SELECT 1 FROM challenge_participants cp WHERE cp.challenge_id = c.id AND cp.user_id = $1) AS joined
+	FROM challenges c`
+
+// CreateChallenge stores a new challenge and makes its creator its first participant
+func (s *service) CreateChallenge(ctx context.Context, challenge *Challenges) (*Challenges, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var created Challenges
+	query := `INSERT INTO challenges (created_by, name, description, metric, target, starts_at, ends_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING *`
+	err = tx.GetContext(ctx, &created, query, challenge.Created_by, challenge.Name, challenge.Description,
+		challenge.Metric, challenge.Target, challenge.Starts_at, challenge.Ends_at)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create challenge: %w", err)
+	}
+	if created.Created_by != nil {
+		_, err = tx.ExecContext(ctx, `INSERT INTO challenge_participants (challenge_id, user_id) VALUES ($1, $2)`,
+			created.Id, *created.Created_by)
+		if err != nil {
+			return nil, fmt.Errorf("failed to join challenge: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit challenge: %w", err)
+	}
+	return &created, nil
+}
+
+// GetChallenge returns the challenge as seen by the user, or sql.ErrNoRows
+func (s *service) GetChallenge(ctx context.Context, id, userID string) (*Challenge, error) {
+	var challenge Challenge
+	if err := s.db.GetContext(ctx, &challenge, challengeSelect+` WHERE c.id = $2`, userID, id); err != nil {
+		return nil, err
+	}
+	return &challenge, nil
+}
+
+// ListChallenges returns the challenges that haven't ended, ending soonest first, as seen
+// by the user. With joined set it returns the challenges the user joined instead, ended
+// ones included, most recently started first.
+func (s *service) ListChallenges(ctx context.Context, userID string, joined bool, limit, offset int) ([]Challenge, error) {
+	challenges := []Challenge{}
+	query := challengeSelect + ` WHERE c.ends_at > NOW() ORDER BY c.ends_at, c.id LIMIT $2 OFFSET $3`
+	if joined {
+		query = challengeSelect + ` JOIN challenge_participants me ON me.challenge_id = c.id AND me.user_id = $1
+			ORDER BY c.starts_at DESC, c.id LIMIT $2 OFFSET $3`
+	}
+	if err := s.db.SelectContext(ctx, &challenges, query, userID, limit, offset); err != nil {
+		return nil, fmt.Errorf("failed to list challenges: %w", err)
+	}
+	return challenges, nil
+}
+
+// DeleteChallenge deletes a challenge created by the user. Returns sql.ErrNoRows if the
+// user created no such challenge.
+func (s *service) DeleteChallenge(ctx context.Context, id, userID string) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM challenges WHERE id = $1 AND created_by = $2`, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete challenge: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// JoinChallenge makes the user a participant of the challenge. created is false if they
+// already were. Returns sql.ErrNoRows for unknown challenges and ErrChallengeEnded once
+// the challenge is over.
+func (s *service) JoinChallenge(ctx context.Context, challengeID, userID string) (participant *Challenge_participants, created bool, err error) {
+	var endsAt time.Time
+	if err := s.db.GetContext(ctx, &endsAt, `SELECT ends_at FROM challenges WHERE id = $1`, challengeID); err != nil {
+		return nil, false, err
+	}
+	if !time.Now().Before(endsAt) {
+		return nil, false, ErrChallengeEnded
+	}
+
+	var row struct {
+		Challenge_participants
+		Inserted bool `db:"inserted"`
+	}
+	query := `INSERT INTO challenge_participants (challenge_id, user_id) VALUES ($1, $2)
+		ON CONFLICT (challenge_id, user_id) DO UPDATE SET joined_at = challenge_participants.joined_at
+		RETURNING *, (xmax = 0) AS inserted`
+	if err := s.db.GetContext(ctx, &row, query, challengeID, userID); err != nil {
+		return nil, false, fmt.Errorf("failed to join challenge: %w", err)
+	}
+	return &row.Challenge_participants, row.Inserted, nil
+}
+
+// LeaveChallenge removes the user from the challenge. Returns sql.ErrNoRows if they
+// weren't taking part.
+func (s *service) LeaveChallenge(ctx context.Context, challengeID, userID string) error {
+	result, err := s.db.ExecContext(ctx,
+		`DELETE FROM challenge_participants WHERE challenge_id = $1 AND user_id = $2`, challengeID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to leave challenge: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// ListChallengeStandings counts every participant's progress from the sessions they
+// completed within the challenge, leaders first. Participants who reached the target
+// earlier rank ahead of those who reached it later, then those who joined first. A
+// session counts once for workouts, by its duration_minutes for minutes, and by the weight
+// times reps of its logged sets for volume.
+func (s *service) ListChallengeStandings(ctx context.Context, challengeID string) ([]ChallengeStanding, error) {
+	standings := []ChallengeStanding{}
+	query := `WITH contributions AS (
+			SELECT cp.user_id, ws.completed_at,
+				CASE c.metric
+					WHEN 'workouts' THEN 1
+					WHEN 'minutes' THEN COALESCE(ws.duration_minutes, 0)
+					ELSE (SELECT COALESCE(SUM(wss.weight_kg * wss.reps), 0)
+						FROM workout_session_sets wss
+						WHERE wss.session_id = ws.id AND left(wss.client_id, 5) <> 'copy:')
+				END AS amount
+			FROM challenge_participants cp
+			JOIN challenges c ON c.id = cp.challenge_id
+			JOIN workout_sessions ws ON ws.user_id = cp.user_id
+				AND ws.completed_at >= c.starts_at AND ws.completed_at < c.ends_at
+			WHERE cp.challenge_id = $1
+		), running AS (
+			SELECT user_id, completed_at,
+				SUM(amount) OVER (PARTITION BY user_id ORDER BY completed_at, amount) AS total
+			FROM contributions
+		)
+		SELECT cp.user_id, ` + memberDisplayName + ` AS display_name,
+			COALESCE(MAX(r.total), 0) AS progress,
+			MIN(r.completed_at) FILTER (WHERE r.total >= c.target) AS completed_at,
+			cp.joined_at
+		FROM challenge_participants cp
+		JOIN challenges c ON c.id = cp.challenge_id
+		JOIN users u ON u.id = cp.user_id
+		LEFT JOIN running r ON r.user_id = cp.user_id
+		WHERE cp.challenge_id = $1
+		GROUP BY cp.user_id, cp.joined_at, c.target, u.first_name, u.last_name, u.username
+		ORDER BY LEAST(COALESCE(MAX(r.total), 0), c.target) DESC,
+			MIN(r.completed_at) FILTER (WHERE r.total >= c.target) NULLS LAST,
+			COALESCE(MAX(r.total), 0) DESC, cp.joined_at, cp.user_id`
+	if err := s.db.SelectContext(ctx, &standings, query, challengeID); err != nil {
+		return nil, fmt.Errorf("failed to list challenge standings: %w", err)
+	}
+	return standings, nil
+}
@@ -2,6 +2,7 @@ package database
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
@@ -9,6 +10,9 @@ import (
 	"path/filepath"
 	"strings"
 	"time"
+	"unicode/utf8"
+
+	"fitness-hack/internal/messages"
 
 	"github.com/jmoiron/sqlx"
 )
@@ -26,29 +30,96 @@ func NewCLI(db *sqlx.DB) *CLI {
 // Run executes the CLI based on command line arguments
 func (c *CLI) Run(args []string) error {
 	if len(args) < 1 {
-		return fmt.Errorf("no command specified")
+		return errors.New(messages.Text(messages.CLINoCommand))
 	}
 
 	command := args[0]
 	switch command {
 	case "migrate":
-		return c.runMigrations()
+		return c.runMigrations(hasForce(args[1:]))
 	case "generate-models":
 		return c.generateModels()
 	case "status":
-		return c.showStatus()
+		return c.showStatus(hasForce(args[1:]))
 	case "create-migration":
 		if len(args) < 2 {
-			return fmt.Errorf("usage: create-migration <name or filename>. Example: create-migration add_user_profiles.sql")
+			return errors.New(messages.Text(messages.CLICreateMigrationUsage))
 		}
 		return c.createMigration(args[1])
+	case "diff-migration":
+		if len(args) < 2 {
+			return errors.New(messages.Text(messages.CLIDiffMigrationUsage))
+		}
+		return c.diffMigration(args[1], hasFlag(args[2:], "from-snapshot"))
+	case "snapshot-schema":
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		return c.snapshotSchema(ctx, nil)
+	case "grant-admin", "revoke-admin":
+		if len(args) < 2 {
+			return errors.New(messages.Text(messages.CLIRoleUsage, command))
+		}
+		return c.setUserRole(args[1], command == "grant-admin")
 	default:
-		return fmt.Errorf("unknown command: %s", command)
+		return errors.New(messages.Text(messages.CLIUnknownCommand, command))
+	}
+}
+
+// hasForce reports whether the command's arguments include --force
+func hasForce(args []string) bool {
+	return hasFlag(args, "force")
+}
+
+// hasFlag reports whether the command's arguments include --name
+func hasFlag(args []string, name string) bool {
+	for _, arg := range args {
+		if arg == "--"+name || arg == "-"+name {
+			return true
+		}
 	}
+	return false
+}
+
+// setUserRole promotes the user with the given email to platform admin, or demotes them
+func (c *CLI) setUserRole(email string, admin bool) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	role := "user"
+	if admin {
+		role = "admin"
+	}
+
+	result, err := c.db.ExecContext(ctx, `UPDATE users SET role = $2, updated_at = NOW() WHERE lower(email) = lower($1)`, email, role)
+	if err != nil {
+		return fmt.Errorf("failed to update role: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return errors.New(messages.Text(messages.CLINoUserWithEmail, email))
+	}
+
+	fmt.Println(messages.Text(messages.CLIRoleChanged, email, role))
+	return nil
 }
 
 // createMigration creates a new migration file with proper naming standards
 func (c *CLI) createMigration(input string) error {
+	return c.writeMigration(input, `-- Add your SQL statements here
+-- Example:
+-- CREATE TABLE IF NOT EXISTS table_name (
+--     id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+--     name VARCHAR(255) NOT NULL,
+--     created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+--     updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+-- );
+
+-- CREATE INDEX IF NOT EXISTS idx_table_name_column ON table_name(column);
+`)
+}
+
+// writeMigration writes the next numbered migration file named after input, with the
+// standard header followed by body
+func (c *CLI) writeMigration(input, body string) error {
 	// Remove .sql extension if present, and clean/format the name
 	name := input
 	if strings.HasSuffix(strings.ToLower(name), ".sql") {
@@ -64,7 +135,7 @@ func (c *CLI) createMigration(input string) error {
 		return -1
 	}, name)
 	if name == "" {
-		return fmt.Errorf("invalid migration name")
+		return errors.New(messages.Text(messages.CLIInvalidMigrationName))
 	}
 
 	// Get the next migration number
@@ -82,25 +153,64 @@ func (c *CLI) createMigration(input string) error {
 -- Description: %s
 -- Date: %s
 
--- Add your SQL statements here
--- Example:
--- CREATE TABLE IF NOT EXISTS table_name (
---     id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
---     name VARCHAR(255) NOT NULL,
---     created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
---     updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
--- );
-
--- CREATE INDEX IF NOT EXISTS idx_table_name_column ON table_name(column);
-`, filename, strings.ReplaceAll(name, "_", " "), time.Now().Format("2006-01-02"))
+%s`, filename, strings.ReplaceAll(name, "_", " "), time.Now().Format("2006-01-02"), body)
 
 	// Create the file
 	if err := os.WriteFile(filepath, []byte(content), 0644); err != nil {
 		return fmt.Errorf("failed to create migration file: %w", err)
 	}
 
-	fmt.Printf("Created migration file: %s\n", filepath)
-	fmt.Printf("Edit the file to add your SQL statements.\n")
+	fmt.Println(messages.Text(messages.CLIMigrationCreated, filepath))
+	fmt.Println(messages.Text(messages.CLIMigrationEditHint))
+	return nil
+}
+
+// diffMigration writes a migration with the statements that bring the database to the
+// declarative schema file. With fromSnapshot it instead writes the statements that bring
+// the snapshot to the database, for changes made by hand, and refreshes the snapshot.
+func (c *CLI) diffMigration(input string, fromSnapshot bool) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	live, err := IntrospectSchema(ctx, c.db)
+	if err != nil {
+		return fmt.Errorf("failed to read database schema: %w", err)
+	}
+	file, err := LoadSchemaFile(DefaultSchemaFile())
+	if err != nil {
+		return fmt.Errorf("failed to load schema file: %w", err)
+	}
+
+	statements := DiffSchemas(live, file)
+	if fromSnapshot {
+		statements = DiffSchemas(file, live)
+	}
+	if len(statements) == 0 {
+		fmt.Println(messages.Text(messages.CLISchemaUpToDate, DefaultSchemaFile()))
+		return nil
+	}
+
+	if err := c.writeMigration(input, "-- Generated by diff-migration as a starting point, review before applying\n\n"+strings.Join(statements, "\n\n")+"\n"); err != nil {
+		return err
+	}
+	if fromSnapshot {
+		return c.snapshotSchema(ctx, live)
+	}
+	return nil
+}
+
+// snapshotSchema writes schema, or the database's schema when nil, to the schema file
+func (c *CLI) snapshotSchema(ctx context.Context, schema *Schema) error {
+	if schema == nil {
+		var err error
+		if schema, err = IntrospectSchema(ctx, c.db); err != nil {
+			return fmt.Errorf("failed to read database schema: %w", err)
+		}
+	}
+	if err := SaveSchemaFile(DefaultSchemaFile(), schema); err != nil {
+		return fmt.Errorf("failed to write schema file: %w", err)
+	}
+	fmt.Println(messages.Text(messages.CLISchemaSnapshotWritten, DefaultSchemaFile()))
 	return nil
 }
 
@@ -135,17 +245,20 @@ func (c *CLI) getNextMigrationNumber() (int, error) {
 	return maxNumber + 1, nil
 }
 
-// runMigrations runs all pending migrations
-func (c *CLI) runMigrations() error {
+// runMigrations runs all pending migrations. Unless force is set, it refuses to when
+// applied migrations have been edited.
+func (c *CLI) runMigrations(force bool) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	log.Println("Running migrations...")
-	if err := RunMigrations(ctx, c.db); err != nil {
+	log.Println(messages.Text(messages.CLIRunningMigrations))
+	manager := NewMigrationManager(c.db)
+	manager.Force = force
+	if err := manager.RunMigrations(ctx, DefaultMigrationsDir()); err != nil {
 		return fmt.Errorf("failed to run migrations: %w", err)
 	}
 
-	log.Println("Migrations completed successfully")
+	log.Println(messages.Text(messages.CLIMigrationsCompleted))
 	return nil
 }
 
@@ -154,17 +267,18 @@ func (c *CLI) generateModels() error {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	log.Println("Generating models from database schema...")
+	log.Println(messages.Text(messages.CLIGeneratingModels))
 	if err := GenerateModelsFromDB(ctx, c.db); err != nil {
 		return fmt.Errorf("failed to generate models: %w", err)
 	}
 
-	log.Println("Models generated successfully")
+	log.Println(messages.Text(messages.CLIModelsGenerated))
 	return nil
 }
 
-// showStatus shows the current migration status
-func (c *CLI) showStatus() error {
+// showStatus shows the current migration status. It fails after listing the migrations
+// if applied ones have been edited, unless force is set.
+func (c *CLI) showStatus(force bool) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
@@ -176,16 +290,31 @@ func (c *CLI) showStatus() error {
 		return fmt.Errorf("failed to get applied migrations: %w", err)
 	}
 
-	fmt.Println("Migration Status:")
-	fmt.Println("=================")
+	title := messages.Text(messages.CLIStatusTitle)
+	fmt.Println(title)
+	fmt.Println(strings.Repeat("=", utf8.RuneCountInString(title)))
 
 	if len(applied) == 0 {
-		fmt.Println("No migrations applied yet.")
+		fmt.Println(messages.Text(messages.CLINoMigrationsApplied))
 		return nil
 	}
 
+	// Load migration files to check for edited and pending ones
+	migrationFiles, err := manager.LoadMigrationFiles(DefaultMigrationsDir())
+	if err != nil {
+		return fmt.Errorf("failed to load migration files: %w", err)
+	}
+
+	drifted := make(map[string]bool)
+	for _, name := range DriftedMigrations(applied, migrationFiles) {
+		drifted[name] = true
+	}
 	for _, migration := range applied {
-		fmt.Printf("✓ %s (applied at %s)\n", migration.Name, migration.AppliedAt.Format("2006-01-02 15:04:05"))
+		if drifted[migration.Name] {
+			fmt.Println(messages.Text(messages.CLIMigrationDrifted, migration.Name))
+			continue
+		}
+		fmt.Println(messages.Text(messages.CLIMigrationAppliedAt, migration.Name, migration.AppliedAt.Format("2006-01-02 15:04:05")))
 	}
 
 	// Check for pending migrations
@@ -194,12 +323,6 @@ func (c *CLI) showStatus() error {
 		appliedMap[migration.Name] = true
 	}
 
-	// Load migration files to check for pending ones
-	migrationFiles, err := manager.LoadMigrationFiles(DefaultMigrationsDir())
-	if err != nil {
-		return fmt.Errorf("failed to load migration files: %w", err)
-	}
-
 	var pending []string
 	for _, migrationFile := range migrationFiles {
 		if !appliedMap[migrationFile.Name] {
@@ -208,15 +331,18 @@ func (c *CLI) showStatus() error {
 	}
 
 	if len(pending) > 0 {
-		fmt.Println("\nPending migrations:")
+		fmt.Println()
+		fmt.Println(messages.Text(messages.CLIPendingMigrations))
 		for _, name := range pending {
-			fmt.Printf("  - %s\n", name)
+			fmt.Println(messages.Text(messages.CLIPendingMigration, name))
 		}
 	} else {
-		fmt.Println("\nAll migrations are up to date.")
+		fmt.Println()
+		fmt.Println(messages.Text(messages.CLIMigrationsUpToDate))
 	}
 
-	return nil
+	manager.Force = force
+	return manager.checkDrift(applied, migrationFiles)
 }
 
 // RunCLI is a convenience function to run the CLI with the database service
@@ -226,16 +352,7 @@ func RunCLI() error {
 	args := flag.Args()
 
 	if len(args) == 0 {
-		fmt.Println("Database CLI Usage:")
-		fmt.Println("  migrate                    - Run all pending migrations")
-		fmt.Println("  generate-models            - Generate Go models from database schema")
-		fmt.Println("  status                     - Show migration status")
-		fmt.Println("  create-migration <name or filename> - Create a new migration file (e.g. add_user_profiles.sql or \"add user profiles\")")
-		fmt.Println("")
-		fmt.Println("Examples:")
-		fmt.Println("  create-migration add user profiles")
-		fmt.Println("  create-migration add_user_profiles.sql")
-		fmt.Println("  create-migration add-user-profiles")
+		fmt.Println(messages.Text(messages.CLIDatabaseUsage))
 		return nil
 	}
 
@@ -0,0 +1,198 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// CoachClient is a coaching invitation or relationship with the username of the user on
+// the other side of it, nil while an invitation is pending
+type CoachClient struct {
+	Coach_clients
+	Username *string `db:"username"`
+}
+
+// CreateCoachInvite stores a new coaching invitation. Returns ErrInvitePending if the
+// address already has an open invitation from the coach.
+func (s *service) CreateCoachInvite(ctx context.Context, invite *Coach_clients) (*Coach_clients, error) {
+	query := `INSERT INTO coach_clients (coach_id, email, token_hash, expires_at)
+		VALUES (:coach_id, :email, :token_hash, :expires_at)
+		ON CONFLICT (coach_id, lower(email)) WHERE status = 'pending' DO NOTHING
+		RETURNING *`
+
+	query, args, err := s.db.BindNamed(query, invite)
+	if err != nil {
+		return nil, err
+	}
+
+	var created Coach_clients
+	if err := s.db.QueryRowxContext(ctx, query, args...).StructScan(&created); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrInvitePending
+		}
+		return nil, err
+	}
+	return &created, nil
+}
+
+// ListCoachClients returns the coach's active clients and pending invitations, including
+// expired ones, newest first
+func (s *service) ListCoachClients(ctx context.Context, coachID string) ([]CoachClient, error) {
+	clients := []CoachClient{}
+	query := `SELECT cc.*, u.username
+		FROM coach_clients cc
+		LEFT JOIN users u ON u.id = cc.client_id
+		WHERE cc.coach_id = $1 AND cc.status IN ('pending', 'active')
+		ORDER BY cc.created_at DESC`
+	if err := s.db.SelectContext(ctx, &clients, query, coachID); err != nil {
+		return nil, fmt.Errorf("failed to list coach clients: %w", err)
+	}
+	return clients, nil
+}
+
+// ListClientCoaches returns the coaches the client has an active relationship with,
+// earliest first
+func (s *service) ListClientCoaches(ctx context.Context, clientID string) ([]CoachClient, error) {
+	coaches := []CoachClient{}
+	query := `SELECT cc.*, u.username
+		FROM coach_clients cc
+		JOIN users u ON u.id = cc.coach_id
+		WHERE cc.client_id = $1 AND cc.status = 'active'
+		ORDER BY cc.accepted_at`
+	if err := s.db.SelectContext(ctx, &coaches, query, clientID); err != nil {
+		return nil, fmt.Errorf("failed to list client coaches: %w", err)
+	}
+	return coaches, nil
+}
+
+// RevokeCoachInvite cancels a pending coaching invitation.
+// Returns sql.ErrNoRows if no pending invitation of the coach matches.
+func (s *service) RevokeCoachInvite(ctx context.Context, coachID, inviteID string) error {
+	query := `UPDATE coach_clients SET status = 'ended', ended_at = NOW()
+		WHERE id = $1 AND coach_id = $2 AND status = 'pending'`
+	result, err := s.db.ExecContext(ctx, query, inviteID, coachID)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// AcceptCoachInvite makes the user a client of the invitation's coach. Users already
+// coached by them keep their current relationship, which is returned. Returns
+// sql.ErrNoRows for unknown tokens.
+func (s *service) AcceptCoachInvite(ctx context.Context, tokenHash, userID string) (*Coach_clients, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var invite Coach_clients
+	err = tx.GetContext(ctx, &invite,
+		`SELECT * FROM coach_clients WHERE token_hash = $1 FOR UPDATE`, tokenHash)
+	if err != nil {
+		return nil, err
+	}
+	if invite.Status != Coach_clients_status_pending || invite.Coach_id == userID {
+		return nil, ErrInviteClosed
+	}
+	if time.Now().After(invite.Expires_at) {
+		return nil, ErrInviteExpired
+	}
+
+	var email sql.NullString
+	if err := tx.GetContext(ctx, &email, `SELECT email FROM users WHERE id = $1`, userID); err != nil {
+		return nil, fmt.Errorf("failed to load user: %w", err)
+	}
+	if !email.Valid || !strings.EqualFold(email.String, invite.Email) {
+		return nil, ErrInviteEmailMismatch
+	}
+
+	var relationship Coach_clients
+	err = tx.GetContext(ctx, &relationship,
+		`SELECT * FROM coach_clients WHERE coach_id = $1 AND client_id = $2 AND status = 'active'`,
+		invite.Coach_id, userID)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		err = tx.GetContext(ctx, &relationship,
+			`UPDATE coach_clients SET status = 'active', client_id = $2, accepted_at = NOW()
+			WHERE id = $1
+			RETURNING *`, invite.Id, userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to mark invitation accepted: %w", err)
+		}
+	case err != nil:
+		return nil, fmt.Errorf("failed to load coach relationship: %w", err)
+	default:
+		_, err = tx.ExecContext(ctx,
+			`UPDATE coach_clients SET status = 'ended', client_id = $2, ended_at = NOW() WHERE id = $1`,
+			invite.Id, userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to close invitation: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit invitation: %w", err)
+	}
+	return &relationship, nil
+}
+
+// EndCoachClient ends the active relationship between the coach and the client. The
+// client's programs lose that coach, rejecting the adjustments awaiting their review.
+// Returns sql.ErrNoRows if the two have no active relationship.
+func (s *service) EndCoachClient(ctx context.Context, coachID, clientID string) error {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx,
+		`UPDATE coach_clients SET status = 'ended', ended_at = NOW()
+		WHERE coach_id = $1 AND client_id = $2 AND status = 'active'`, coachID, clientID)
+	if err != nil {
+		return fmt.Errorf("failed to end coach relationship: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return sql.ErrNoRows
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`UPDATE program_adjustments
+		SET status = 'rejected', reviewed_at = NOW(), review_note = 'Coach changed before review'
+		WHERE status = 'pending' AND program_id IN (SELECT id FROM programs WHERE user_id = $2 AND coach_id = $1)`,
+		coachID, clientID)
+	if err != nil {
+		return fmt.Errorf("failed to reject program adjustments: %w", err)
+	}
+	_, err = tx.ExecContext(ctx,
+		`UPDATE programs SET coach_id = NULL, updated_at = NOW(), version = version + 1
+		WHERE user_id = $2 AND coach_id = $1`, coachID, clientID)
+	if err != nil {
+		return fmt.Errorf("failed to remove program coach: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit coach relationship: %w", err)
+	}
+	return nil
+}
+
+// IsCoachOf reports whether coachID has an active relationship with clientID
+func (s *service) IsCoachOf(ctx context.Context, coachID, clientID string) (bool, error) {
+	var coach bool
+	query := `SELECT EXISTS (
+			SELECT 1 FROM coach_clients
+			WHERE coach_id = $1 AND client_id = $2 AND status = 'active'
+		)`
+	err := s.db.GetContext(ctx, &coach, query, coachID, clientID)
+	return coach, err
+}
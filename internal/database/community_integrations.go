@@ -0,0 +1,116 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// MemberCommunityIntegration is the community channel of an organization a member's
+// personal records are posted to, with the name the member is posted under
+type MemberCommunityIntegration struct {
+	Community_integrations
+	Display_name string `db:"display_name"`
+}
+
+// GetCommunityIntegration returns the organization's community channel.
+// Returns sql.ErrNoRows if none is configured.
+func (s *service) GetCommunityIntegration(ctx context.Context, orgID string) (*Community_integrations, error) {
+	var integration Community_integrations
+	err := s.db.GetContext(ctx, &integration, `SELECT * FROM community_integrations WHERE organization_id = $1`, orgID)
+	if err != nil {
+		return nil, err
+	}
+	return &integration, nil
+}
+
+// UpsertCommunityIntegration creates or replaces the organization's community channel.
+// Leaderboard_posted_for is only stored when the channel is created, so changing the
+// settings doesn't post a week again.
+func (s *service) UpsertCommunityIntegration(ctx context.Context, integration *Community_integrations) (*Community_integrations, error) {
+	query := `INSERT INTO community_integrations (organization_id, provider, webhook_url, timezone,
+			post_personal_records, post_weekly_leaderboard, enabled, leaderboard_posted_for)
+		VALUES (:organization_id, :provider, :webhook_url, :timezone,
+			:post_personal_records, :post_weekly_leaderboard, :enabled, :leaderboard_posted_for)
+		ON CONFLICT (organization_id) DO UPDATE SET
+			provider = EXCLUDED.provider,
+			webhook_url = EXCLUDED.webhook_url,
+			timezone = EXCLUDED.timezone,
+			post_personal_records = EXCLUDED.post_personal_records,
+			post_weekly_leaderboard = EXCLUDED.post_weekly_leaderboard,
+			enabled = EXCLUDED.enabled,
+			leaderboard_posted_for = COALESCE(community_integrations.leaderboard_posted_for, EXCLUDED.leaderboard_posted_for),
+			updated_at = NOW()
+		RETURNING *`
+
+	query, args, err := s.db.BindNamed(query, integration)
+	if err != nil {
+		return nil, err
+	}
+
+	var saved Community_integrations
+	if err := s.db.QueryRowxContext(ctx, query, args...).StructScan(&saved); err != nil {
+		return nil, err
+	}
+	return &saved, nil
+}
+
+// DeleteCommunityIntegration disconnects the organization's community channel.
+// Returns sql.ErrNoRows if none is configured.
+func (s *service) DeleteCommunityIntegration(ctx context.Context, orgID string) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM community_integrations WHERE organization_id = $1`, orgID)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// ListMemberCommunityIntegrations returns the community channels the user's personal
+// records are posted to: those of organizations they are an active member of and opted in
+// to being named on the leaderboard of, that post personal records
+func (s *service) ListMemberCommunityIntegrations(ctx context.Context, userID string) ([]MemberCommunityIntegration, error) {
+	query := `SELECT ci.*, ` + memberDisplayName + ` AS display_name
+		FROM community_integrations ci
+		JOIN organization_members m ON m.organization_id = ci.organization_id
+		JOIN users u ON u.id = m.user_id
+		WHERE m.user_id = $1 AND m.active AND m.show_on_leaderboard
+			AND ci.enabled AND ci.post_personal_records`
+
+	integrations := []MemberCommunityIntegration{}
+	if err := s.db.SelectContext(ctx, &integrations, query, userID); err != nil {
+		return nil, fmt.Errorf("failed to list member community integrations: %w", err)
+	}
+	return integrations, nil
+}
+
+// ListLeaderboardCommunityIntegrations returns the community channels that post weekly
+// leaderboards
+func (s *service) ListLeaderboardCommunityIntegrations(ctx context.Context) ([]Community_integrations, error) {
+	integrations := []Community_integrations{}
+	err := s.db.SelectContext(ctx, &integrations, `SELECT * FROM community_integrations
+		WHERE enabled AND post_weekly_leaderboard ORDER BY organization_id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list leaderboard community integrations: %w", err)
+	}
+	return integrations, nil
+}
+
+// ClaimWeeklyLeaderboard records that the leaderboard of the week before weekStart is
+// being posted to the organization's community channel. It reports false if that week, or
+// a later one, was already claimed, so each week is posted at most once.
+func (s *service) ClaimWeeklyLeaderboard(ctx context.Context, orgID string, weekStart time.Time) (bool, error) {
+	result, err := s.db.ExecContext(ctx, `UPDATE community_integrations SET leaderboard_posted_for = $2
+		WHERE organization_id = $1 AND (leaderboard_posted_for IS NULL OR leaderboard_posted_for < $2)`, orgID, weekStart)
+	if err != nil {
+		return false, fmt.Errorf("failed to claim weekly leaderboard: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
@@ -0,0 +1,63 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// DailySummary is everything recorded for a user within one day
+type DailySummary struct {
+	Sessions      []Workout_sessions
+	TotalVolumeKg decimal.Decimal
+	SetCount      int
+	BodyMetrics   []Body_metrics
+}
+
+// GetDailySummary collects the sessions started and body metrics recorded in [from, to),
+// usually a day; weekly summaries pass a week. A session's volume comes from the sets
+// logged during it; sessions without logged sets fall back to the planned sets of the
+// workout they were started from.
+func (s *service) GetDailySummary(ctx context.Context, userID string, from, to time.Time) (*DailySummary, error) {
+	summary := DailySummary{Sessions: []Workout_sessions{}, BodyMetrics: []Body_metrics{}}
+
+	query := `SELECT * FROM workout_sessions
+		WHERE user_id = $1 AND started_at >= $2 AND started_at < $3
+		ORDER BY started_at, id`
+	if err := s.db.SelectContext(ctx, &summary.Sessions, query, userID, from, to); err != nil {
+		return nil, fmt.Errorf("failed to list sessions for daily summary: %w", err)
+	}
+
+	query = `SELECT COALESCE(SUM(CASE WHEN logged.sets > 0 THEN logged.volume ELSE planned.volume END), 0) AS total_volume_kg,
+			COALESCE(SUM(CASE WHEN logged.sets > 0 THEN logged.sets ELSE planned.sets END), 0) AS set_count
+		FROM workout_sessions ws
+		CROSS JOIN LATERAL (
+			SELECT COUNT(*) AS sets, COALESCE(SUM(reps * weight_kg), 0) AS volume
+			FROM workout_session_sets WHERE session_id = ws.id
+		) logged
+		CROSS JOIN LATERAL (
+			SELECT COALESCE(SUM(sets), 0) AS sets, COALESCE(SUM(sets * reps * weight_kg), 0) AS volume
+			FROM workout_exercises WHERE workout_id = ws.workout_id
+		) planned
+		WHERE ws.user_id = $1 AND ws.started_at >= $2 AND ws.started_at < $3`
+	var totals struct {
+		TotalVolumeKg decimal.Decimal `db:"total_volume_kg"`
+		SetCount      int             `db:"set_count"`
+	}
+	if err := s.db.GetContext(ctx, &totals, query, userID, from, to); err != nil {
+		return nil, fmt.Errorf("failed to total volume for daily summary: %w", err)
+	}
+	summary.TotalVolumeKg = totals.TotalVolumeKg
+	summary.SetCount = totals.SetCount
+
+	query = `SELECT * FROM body_metrics
+		WHERE user_id = $1 AND recorded_at >= $2 AND recorded_at < $3
+		ORDER BY recorded_at, id`
+	if err := s.db.SelectContext(ctx, &summary.BodyMetrics, query, userID, from, to); err != nil {
+		return nil, fmt.Errorf("failed to list body metrics for daily summary: %w", err)
+	}
+
+	return &summary, nil
+}
@@ -0,0 +1,139 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// userDataQueries selects every row belonging to a user, one query per export section.
+// Credentials (password and API key hashes) are left out.
+var userDataQueries = []struct {
+	Section string
+	Query   string
+}{
+	{"profile", `SELECT id, email, username, first_name, last_name, timezone, created_at, updated_at FROM users WHERE id = $1`},
+	{"programs", `SELECT * FROM programs WHERE user_id = $1 ORDER BY created_at`},
+	{"program_adjustments", `SELECT * FROM program_adjustments WHERE user_id = $1 ORDER BY created_at`},
+	{"workouts", `SELECT * FROM workouts WHERE user_id = $1 ORDER BY created_at`},
+	{"workout_exercises", `SELECT we.* FROM workout_exercises we
+		JOIN workouts w ON w.id = we.workout_id
+		WHERE w.user_id = $1 ORDER BY we.workout_id, we.order_index`},
+	{"workout_sessions", `SELECT * FROM workout_sessions WHERE user_id = $1 ORDER BY started_at`},
+	{"workout_session_sets", `SELECT ss.* FROM workout_session_sets ss
+		JOIN workout_sessions ws ON ws.id = ss.session_id
+		WHERE ws.user_id = $1 ORDER BY ss.completed_at`},
+	{"session_feedback", `SELECT * FROM session_feedback WHERE user_id = $1 ORDER BY created_at`},
+	{"progression_rules", `SELECT * FROM progression_rules WHERE user_id = $1 ORDER BY created_at`},
+	{"progression_steps", `SELECT ps.* FROM progression_steps ps
+		JOIN progression_rules pr ON pr.id = ps.rule_id
+		WHERE pr.user_id = $1 ORDER BY ps.created_at`},
+	{"oauth_identities", `SELECT provider, email, created_at FROM oauth_identities WHERE user_id = $1`},
+	{"entitlements", `SELECT * FROM entitlements WHERE user_id = $1`},
+	{"subscriptions", `SELECT * FROM subscriptions WHERE user_id = $1 ORDER BY created_at`},
+	{"referral_codes", `SELECT * FROM referral_codes WHERE user_id = $1`},
+	{"referrals", `SELECT * FROM referrals WHERE referrer_id = $1 OR referred_user_id = $1 ORDER BY created_at`},
+	{"body_metrics", `SELECT metric, measured_value, recorded_at, source FROM body_metrics WHERE user_id = $1 ORDER BY recorded_at`},
+	{"foods", `SELECT * FROM foods WHERE user_id = $1 ORDER BY created_at`},
+	{"nutrition_logs", `SELECT * FROM nutrition_logs WHERE user_id = $1 ORDER BY eaten_at`},
+	{"daily_habits", `SELECT * FROM daily_habits WHERE user_id = $1 ORDER BY day`},
+	{"api_keys", `SELECT id, name, prefix, scope, last_used_at, created_at FROM api_keys WHERE user_id = $1`},
+	{"integrations", `SELECT provider, external_user_id, scope, last_synced_at, created_at FROM integrations WHERE user_id = $1`},
+	{"webhooks", `SELECT id, url, description, events, active, created_at FROM webhooks WHERE user_id = $1`},
+	{"devices", `SELECT id, platform, name, created_at FROM devices WHERE user_id = $1`},
+	{"user_sessions", `SELECT id, user_agent, ip_address, created_at, last_seen_at, expires_at, revoked_at FROM user_sessions WHERE user_id = $1 ORDER BY created_at`},
+	{"notification_preferences", `SELECT * FROM notification_preferences WHERE user_id = $1`},
+	{"benchmark_consents", `SELECT * FROM benchmark_consents WHERE user_id = $1`},
+	{"coach_clients", `SELECT id, coach_id, client_id, email, status, expires_at, accepted_at, ended_at, created_at FROM coach_clients
+		WHERE coach_id = $1 OR client_id = $1 ORDER BY created_at`},
+	{"follows", `SELECT * FROM follows WHERE follower_id = $1 OR followee_id = $1 ORDER BY created_at`},
+	{"privacy_settings", `SELECT * FROM privacy_settings WHERE user_id = $1`},
+	{"challenges", `SELECT * FROM challenges WHERE created_by = $1 ORDER BY created_at`},
+	{"challenge_participants", `SELECT * FROM challenge_participants WHERE user_id = $1 ORDER BY joined_at`},
+	{"reminders", `SELECT * FROM reminders WHERE user_id = $1`},
+	{"training_maxes", `SELECT * FROM training_maxes WHERE user_id = $1`},
+	{"training_max_history", `SELECT h.* FROM training_max_history h
+		JOIN training_maxes tm ON tm.id = h.training_max_id
+		WHERE tm.user_id = $1 ORDER BY h.recorded_at`},
+	{"progress_photos", `SELECT id, pose, taken_at, notes, content_type, size_bytes, width, height, created_at FROM progress_photos
+		WHERE user_id = $1 AND NOT vaulted AND status = 'ready' ORDER BY taken_at`},
+}
+
+// vaultDataQueries select the vaulted photos, exported only when the user asks for them.
+// The images stay encrypted in storage, so only their details are exported.
+var vaultDataQueries = []struct {
+	Section string
+	Query   string
+}{
+	{"photo_vault", `SELECT created_at, updated_at FROM photo_vaults WHERE user_id = $1`},
+	{"vaulted_progress_photos", `SELECT id, pose, taken_at, notes, content_type, size_bytes, width, height, created_at FROM progress_photos
+		WHERE user_id = $1 AND vaulted AND status = 'ready' ORDER BY taken_at`},
+}
+
+// CollectUserData returns all data stored about the user as JSON arrays keyed by section,
+// leaving out the photo vault unless includeVault is set. Rows are serialized by Postgres
+// so nullable columns need no special handling.
+func (s *service) CollectUserData(ctx context.Context, userID string, includeVault bool) (map[string]json.RawMessage, error) {
+	queries := userDataQueries
+	if includeVault {
+		queries = append(queries[:len(queries):len(queries)], vaultDataQueries...)
+	}
+	data := make(map[string]json.RawMessage, len(queries))
+	for _, q := range queries {
+		var rows []byte
+		query := fmt.Sprintf(`SELECT COALESCE(json_agg(t), '[]'::json) FROM (%s) t`, q.Query)
+		if err := s.db.GetContext(ctx, &rows, query, userID); err != nil {
+			return nil, fmt.Errorf("failed to export %s: %w", q.Section, err)
+		}
+		data[q.Section] = rows
+	}
+	return data, nil
+}
+
+// CreateDataExport records a pending export request for the user, including their photo
+// vault if includeVault is set
+func (s *service) CreateDataExport(ctx context.Context, userID string, includeVault bool) (*Data_exports, error) {
+	var export Data_exports
+	query := `INSERT INTO data_exports (user_id, include_vault) VALUES ($1, $2) RETURNING *`
+	if err := s.db.GetContext(ctx, &export, query, userID, includeVault); err != nil {
+		return nil, err
+	}
+	return &export, nil
+}
+
+// GetDataExport returns one of the user's exports, or sql.ErrNoRows
+func (s *service) GetDataExport(ctx context.Context, id, userID string) (*Data_exports, error) {
+	var export Data_exports
+	query := `SELECT * FROM data_exports WHERE id = $1 AND user_id = $2`
+	if err := s.db.GetContext(ctx, &export, query, id, userID); err != nil {
+		return nil, err
+	}
+	return &export, nil
+}
+
+// GetPendingDataExport returns the user's in-progress export, or sql.ErrNoRows
+func (s *service) GetPendingDataExport(ctx context.Context, userID string) (*Data_exports, error) {
+	var export Data_exports
+	query := `SELECT * FROM data_exports WHERE user_id = $1 AND status = 'pending' ORDER BY created_at DESC LIMIT 1`
+	if err := s.db.GetContext(ctx, &export, query, userID); err != nil {
+		return nil, err
+	}
+	return &export, nil
+}
+
+// CompleteDataExport marks an export as ready for download until expiresAt
+func (s *service) CompleteDataExport(ctx context.Context, id, storageKey string, sizeBytes int64, expiresAt time.Time) error {
+	query := `UPDATE data_exports
+		SET status = 'ready', storage_key = $2, size_bytes = $3, completed_at = NOW(), expires_at = $4
+		WHERE id = $1`
+	_, err := s.db.ExecContext(ctx, query, id, storageKey, sizeBytes, expiresAt)
+	return err
+}
+
+// FailDataExport marks an export as failed with a reason
+func (s *service) FailDataExport(ctx context.Context, id, reason string) error {
+	query := `UPDATE data_exports SET status = 'failed', error = $2, completed_at = NOW() WHERE id = $1`
+	_, err := s.db.ExecContext(ctx, query, id, reason)
+	return err
+}
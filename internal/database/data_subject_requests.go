@@ -0,0 +1,128 @@
+package database
+
+import (
+	"context"
+	"fmt"
+)
+
+// GetUserRole returns the user's platform role, or sql.ErrNoRows if the user does not exist
+func (s *service) GetUserRole(ctx context.Context, userID string) (string, error) {
+	var role string
+	err := s.db.GetContext(ctx, &role, `SELECT role FROM users WHERE id = $1`, userID)
+	return role, err
+}
+
+// CreateDataSubjectRequest records a newly received request
+func (s *service) CreateDataSubjectRequest(ctx context.Context, req *Data_subject_requests) (*Data_subject_requests, error) {
+	query := `INSERT INTO data_subject_requests (user_id, subject_email, type, notes, due_at, created_by)
+		VALUES (:user_id, :subject_email, :type, :notes, :due_at, :created_by)
+		RETURNING *`
+	query, args, err := s.db.BindNamed(query, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var created Data_subject_requests
+	if err := s.db.QueryRowxContext(ctx, query, args...).StructScan(&created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// GetDataSubjectRequest returns a request by ID, or sql.ErrNoRows
+func (s *service) GetDataSubjectRequest(ctx context.Context, id string) (*Data_subject_requests, error) {
+	var req Data_subject_requests
+	if err := s.db.GetContext(ctx, &req, `SELECT * FROM data_subject_requests WHERE id = $1`, id); err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+// ListDataSubjectRequests returns requests ordered by due date, optionally filtered by
+// status; overdue limits the result to unresolved requests past their due date
+func (s *service) ListDataSubjectRequests(ctx context.Context, status string, overdue bool, opts ListOptions) ([]Data_subject_requests, error) {
+	query, args, err := opts.apply(`SELECT * FROM data_subject_requests
+		WHERE ($1 = '' OR status = $1)
+		AND (NOT $2 OR (status IN ('received', 'in_progress') AND due_at < NOW()))`,
+		[]interface{}{status, overdue}, dataSubjectRequestList)
+	if err != nil {
+		return nil, err
+	}
+	var requests []Data_subject_requests
+	err = s.db.SelectContext(ctx, &requests, query, args...)
+	return requests, err
+}
+
+var dataSubjectRequestList = listSpec{
+	sorts:        map[string]string{"due_at": "due_at", "created_at": "created_at"},
+	filters:      map[string]string{"type": "type", "user_id": "user_id"},
+	defaultOrder: "due_at, created_at",
+	tiebreak:     "id",
+}
+
+// StartDataSubjectRequest marks an unresolved request in progress and links the export or
+// account deletion fulfilling it.
+// Returns sql.ErrNoRows if the request does not exist or was already resolved.
+func (s *service) StartDataSubjectRequest(ctx context.Context, id, resolvedBy string, exportID, deletionID *string) (*Data_subject_requests, error) {
+	var req Data_subject_requests
+	query := `UPDATE data_subject_requests
+		SET status = 'in_progress', resolved_by = $2,
+			data_export_id = COALESCE($3, data_export_id),
+			account_deletion_id = COALESCE($4, account_deletion_id),
+			updated_at = NOW()
+		WHERE id = $1 AND status IN ('received', 'in_progress')
+		RETURNING *`
+	if err := s.db.GetContext(ctx, &req, query, id, resolvedBy, exportID, deletionID); err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+// RejectDataSubjectRequest closes a request that has not been acted on, recording why.
+// Returns sql.ErrNoRows if the request does not exist or is no longer in the received state.
+func (s *service) RejectDataSubjectRequest(ctx context.Context, id, resolvedBy, reason string) (*Data_subject_requests, error) {
+	var req Data_subject_requests
+	query := `UPDATE data_subject_requests
+		SET status = 'rejected', resolved_by = $2,
+			notes = CASE WHEN notes = '' THEN $3::text ELSE notes || E'\n' || $3::text END,
+			completed_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND status = 'received'
+		RETURNING *`
+	if err := s.db.GetContext(ctx, &req, query, id, resolvedBy, reason); err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+// SyncDataSubjectRequests completes in-progress requests whose export is ready or whose
+// account purge has finished. Both run asynchronously, so callers sync before reading.
+func (s *service) SyncDataSubjectRequests(ctx context.Context) error {
+	steps := []struct {
+		Artifact string
+		Query    string
+	}{
+		{"data exports", `UPDATE data_subject_requests r
+			SET status = 'completed', completed_at = e.completed_at, updated_at = NOW()
+			FROM data_exports e
+			WHERE r.status = 'in_progress' AND r.data_export_id = e.id AND e.status = 'ready'`},
+		{"account deletions", `UPDATE data_subject_requests r
+			SET status = 'completed', completed_at = d.completed_at, updated_at = NOW()
+			FROM account_deletions d
+			WHERE r.status = 'in_progress' AND r.account_deletion_id = d.id AND d.status = 'completed'`},
+	}
+	for _, step := range steps {
+		if _, err := s.db.ExecContext(ctx, step.Query); err != nil {
+			return fmt.Errorf("failed to sync requests with %s: %w", step.Artifact, err)
+		}
+	}
+	return nil
+}
+
+// GetAccountDeletion returns an account deletion by ID regardless of status, or sql.ErrNoRows
+func (s *service) GetAccountDeletion(ctx context.Context, id string) (*Account_deletions, error) {
+	var deletion Account_deletions
+	if err := s.db.GetContext(ctx, &deletion, `SELECT * FROM account_deletions WHERE id = $1`, id); err != nil {
+		return nil, err
+	}
+	return &deletion, nil
+}
@@ -2,12 +2,19 @@ package database
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"log/slog"
+	"net"
+	"net/url"
 	"os"
 	"strconv"
 	"time"
 
+	"fitness-hack/internal/messages"
+
 	_ "github.com/jackc/pgx/v5/stdlib"
 	"github.com/jmoiron/sqlx"
 	_ "github.com/joho/godotenv/autoload"
@@ -19,6 +26,9 @@ type Service interface {
 	// The keys and values in the map are service-specific.
 	Health() map[string]string
 
+	// Ping checks that the database accepts connections, giving up when ctx ends
+	Ping(ctx context.Context) error
+
 	// Close terminates the database connection.
 	// It returns an error if the connection cannot be closed.
 	Close() error
@@ -35,100 +45,473 @@ type Service interface {
 	// Stats returns database statistics
 	Stats() map[string]interface{}
 
-	// --- USERS CRUD ---
-	CreateUser(ctx context.Context, user *Users) (*Users, error)
-	GetUserByID(ctx context.Context, id string) (*Users, error)
-	GetUserByEmail(ctx context.Context, email string) (*Users, error)
-	ListUsers(ctx context.Context, limit, offset int) ([]Users, error)
-	UpdateUser(ctx context.Context, user *Users) (*Users, error)
-	DeleteUser(ctx context.Context, id string) error
-
-	// --- WORKOUTS CRUD ---
-	CreateWorkout(ctx context.Context, workout *Workouts) (*Workouts, error)
-	GetWorkoutByID(ctx context.Context, id string) (*Workouts, error)
-	ListWorkouts(ctx context.Context, limit, offset int) ([]Workouts, error)
-	UpdateWorkout(ctx context.Context, workout *Workouts) (*Workouts, error)
-	DeleteWorkout(ctx context.Context, id string) error
-
-	// --- EXERCISES CRUD ---
-	CreateExercise(ctx context.Context, exercise *Exercises) (*Exercises, error)
-	GetExerciseByID(ctx context.Context, id string) (*Exercises, error)
-	ListExercises(ctx context.Context, limit, offset int) ([]Exercises, error)
-	UpdateExercise(ctx context.Context, exercise *Exercises) (*Exercises, error)
-	DeleteExercise(ctx context.Context, id string) error
-
-	// --- WORKOUT_EXERCISES CRUD ---
-	CreateWorkoutExercise(ctx context.Context, we *Workout_exercises) (*Workout_exercises, error)
-	GetWorkoutExerciseByID(ctx context.Context, id string) (*Workout_exercises, error)
-	ListWorkoutExercises(ctx context.Context, limit, offset int) ([]Workout_exercises, error)
-	UpdateWorkoutExercise(ctx context.Context, we *Workout_exercises) (*Workout_exercises, error)
-	DeleteWorkoutExercise(ctx context.Context, id string) error
-
-	// --- WORKOUT_SESSIONS CRUD ---
-	CreateWorkoutSession(ctx context.Context, ws *Workout_sessions) (*Workout_sessions, error)
-	GetWorkoutSessionByID(ctx context.Context, id string) (*Workout_sessions, error)
-	ListWorkoutSessions(ctx context.Context, limit, offset int) ([]Workout_sessions, error)
-	UpdateWorkoutSession(ctx context.Context, ws *Workout_sessions) (*Workout_sessions, error)
-	DeleteWorkoutSession(ctx context.Context, id string) error
-
-	// --- PROGRAMS CRUD ---
-	CreateProgram(ctx context.Context, program *Programs) (*Programs, error)
-	GetProgramByID(ctx context.Context, id string) (*Programs, error)
-	ListPrograms(ctx context.Context, limit, offset int) ([]Programs, error)
-	UpdateProgram(ctx context.Context, program *Programs) (*Programs, error)
-	DeleteProgram(ctx context.Context, id string) error
-}
-
+	// Entities with their own repositories; see users.go, workouts.go and the other
+	// per-entity files
+	UserRepository
+	WorkoutRepository
+	ExerciseRepository
+	WorkoutExerciseRepository
+	WorkoutSessionRepository
+	ProgramRepository
+
+	// --- GUEST ACCOUNTS ---
+	CreateGuestAccount(ctx context.Context, user *Users, expiresAt time.Time) (*Users, error)
+	DeleteExpiredGuestAccounts(ctx context.Context, before time.Time) ([]string, error)
+
+	// --- ACCOUNT MERGE ---
+	MergeUsers(ctx context.Context, sourceID, targetID string) (*MergeResult, error)
+
+	// --- OAUTH IDENTITIES ---
+	GetUserByOAuthIdentity(ctx context.Context, provider, subject string) (*Users, error)
+	LinkOAuthIdentity(ctx context.Context, identity *Oauth_identities) (*Oauth_identities, error)
+	CreateOAuthUser(ctx context.Context, user *Users, identity *Oauth_identities) (*Users, error)
+
+	// --- ENTITLEMENTS ---
+	GetEntitlement(ctx context.Context, userID string) (*Entitlements, error)
+	GrantPremium(ctx context.Context, userID string, duration time.Duration, source string) (*Entitlements, error)
+	GetPremiumUntil(ctx context.Context, userID string) (*time.Time, error)
+
+	// --- QUOTAS ---
+	GetQuotaUsage(ctx context.Context, userID string) (*QuotaUsage, error)
+
+	// --- SUBSCRIPTIONS ---
+	UpsertSubscription(ctx context.Context, sub *Subscriptions) (*Subscriptions, error)
+	UpdateSubscriptionState(ctx context.Context, sub *Subscriptions) (*Subscriptions, error)
+	ListSubscriptionsByUser(ctx context.Context, userID string) ([]Subscriptions, error)
+
+	// --- REFERRALS ---
+	GetReferralCode(ctx context.Context, userID string) (*Referral_codes, error)
+	CreateReferralCode(ctx context.Context, userID, code string) (*Referral_codes, error)
+	RecordReferral(ctx context.Context, code, referredUserID string, rewardWeeks int) (*Referrals, error)
+	ListReferralsByReferrer(ctx context.Context, referrerID string) ([]Referrals, error)
+
+	// --- API KEYS ---
+	CreateAPIKey(ctx context.Context, key *Api_keys) (*Api_keys, error)
+	AuthenticateAPIKey(ctx context.Context, keyHash string) (*Api_keys, error)
+	ListAPIKeysByUser(ctx context.Context, userID string) ([]Api_keys, error)
+	DeleteAPIKey(ctx context.Context, id, userID string) error
+
+	// --- USER SESSIONS ---
+	CreateUserSession(ctx context.Context, session *User_sessions) (*User_sessions, error)
+	ListUserSessions(ctx context.Context, userID string) ([]User_sessions, error)
+	RevokeUserSession(ctx context.Context, id, userID string) (*User_sessions, error)
+	RevokeAllUserSessions(ctx context.Context, userID string) ([]User_sessions, error)
+	RevokeUserSessionByToken(ctx context.Context, tokenID string) error
+	TouchUserSession(ctx context.Context, tokenID, ipAddress string) error
+
+	// --- DATA EXPORTS ---
+	CollectUserData(ctx context.Context, userID string, includeVault bool) (map[string]json.RawMessage, error)
+	CreateDataExport(ctx context.Context, userID string, includeVault bool) (*Data_exports, error)
+	GetDataExport(ctx context.Context, id, userID string) (*Data_exports, error)
+	GetPendingDataExport(ctx context.Context, userID string) (*Data_exports, error)
+	CompleteDataExport(ctx context.Context, id, storageKey string, sizeBytes int64, expiresAt time.Time) error
+	FailDataExport(ctx context.Context, id, reason string) error
+
+	// --- ACCOUNT DELETIONS ---
+	ScheduleAccountDeletion(ctx context.Context, userID, requestedIP string, purgeAfter time.Time) (*Account_deletions, error)
+	GetPendingAccountDeletion(ctx context.Context, userID string) (*Account_deletions, error)
+	CancelAccountDeletion(ctx context.Context, userID string) error
+	ListDueAccountDeletions(ctx context.Context, now time.Time, limit int) ([]Account_deletions, error)
+	PurgeAccount(ctx context.Context, deletionID string) (*PurgedAccount, error)
+	RecordAccountDeletionFailure(ctx context.Context, deletionID, reason string) error
+
+	// --- ORGANIZATIONS ---
+	CreateOrganization(ctx context.Context, name, ownerID string) (*Organizations, error)
+	GetOrganizationByID(ctx context.Context, id string) (*Organizations, error)
+	ListOrganizationsByUser(ctx context.Context, userID string) ([]Organizations, error)
+	GetOrganizationRole(ctx context.Context, orgID, userID string) (string, error)
+	CreateOrgInvite(ctx context.Context, invite *Organization_invites) (*Organization_invites, error)
+	ListPendingOrgInvites(ctx context.Context, orgID string) ([]Organization_invites, error)
+	ResendOrgInvite(ctx context.Context, orgID, inviteID, tokenHash string, expiresAt time.Time) (*Organization_invites, error)
+	RevokeOrgInvite(ctx context.Context, orgID, inviteID string) error
+	AcceptOrgInvite(ctx context.Context, tokenHash, userID string) (*Organization_members, error)
+
+	// --- COACHING ---
+	CreateCoachInvite(ctx context.Context, invite *Coach_clients) (*Coach_clients, error)
+	ListCoachClients(ctx context.Context, coachID string) ([]CoachClient, error)
+	ListClientCoaches(ctx context.Context, clientID string) ([]CoachClient, error)
+	RevokeCoachInvite(ctx context.Context, coachID, inviteID string) error
+	AcceptCoachInvite(ctx context.Context, tokenHash, userID string) (*Coach_clients, error)
+	EndCoachClient(ctx context.Context, coachID, clientID string) error
+	IsCoachOf(ctx context.Context, coachID, clientID string) (bool, error)
+
+	// --- FOLLOWS AND FEED ---
+	FollowUser(ctx context.Context, followerID, followeeID string) (*Follows, error)
+	UnfollowUser(ctx context.Context, followerID, followeeID string) error
+	ListFollowing(ctx context.Context, userID string) ([]FollowedUser, error)
+	ListFollowers(ctx context.Context, userID string) ([]FollowedUser, error)
+	GetPrivacySettings(ctx context.Context, userID string) (*Privacy_settings, error)
+	UpdatePrivacySettings(ctx context.Context, settings *Privacy_settings) (*Privacy_settings, error)
+	ListFeed(ctx context.Context, userID string, before time.Time, limit int) ([]FeedItem, error)
+
+	// --- LEADERBOARDS ---
+	ListLeaderboardScores(ctx context.Context, metric string, since time.Time, exerciseID string) ([]LeaderboardScore, error)
+
+	// --- CHALLENGES ---
+	CreateChallenge(ctx context.Context, challenge *Challenges) (*Challenges, error)
+	GetChallenge(ctx context.Context, id, userID string) (*Challenge, error)
+	ListChallenges(ctx context.Context, userID string, joined bool, limit, offset int) ([]Challenge, error)
+	DeleteChallenge(ctx context.Context, id, userID string) error
+	JoinChallenge(ctx context.Context, challengeID, userID string) (*Challenge_participants, bool, error)
+	LeaveChallenge(ctx context.Context, challengeID, userID string) error
+	ListChallengeStandings(ctx context.Context, challengeID string) ([]ChallengeStanding, error)
+
+	// --- GYM OCCUPANCY ---
+	CheckInGymVisit(ctx context.Context, orgID, userID string, maxStay time.Duration) (*Gym_visits, bool, error)
+	CheckOutGymVisit(ctx context.Context, orgID, userID string) (*Gym_visits, error)
+	CountOpenGymVisits(ctx context.Context, orgID string, maxStay time.Duration) (int, error)
+	GetHourlyOccupancy(ctx context.Context, orgID string, from, to time.Time, tz string, maxStay time.Duration) ([]OccupancyHour, error)
+
+	// --- EQUIPMENT RESERVATIONS ---
+	CreateGymEquipment(ctx context.Context, orgID, name string) (*Gym_equipment, error)
+	ListGymEquipment(ctx context.Context, orgID string) ([]Gym_equipment, error)
+	ArchiveGymEquipment(ctx context.Context, orgID, equipmentID string) error
+	CreateEquipmentReservation(ctx context.Context, orgID string, reservation *Equipment_reservations) (*Equipment_reservations, error)
+	GetEquipmentReservation(ctx context.Context, orgID, id string) (*Equipment_reservations, error)
+	ListEquipmentReservations(ctx context.Context, orgID string, filter ReservationFilter) ([]Equipment_reservations, error)
+	CancelEquipmentReservation(ctx context.Context, orgID, id string) (*Equipment_reservations, error)
+	MarkEquipmentReservationNoShow(ctx context.Context, orgID, id string) (*Equipment_reservations, error)
+	CountEquipmentNoShows(ctx context.Context, orgID, userID string, since time.Time) (int, error)
+
+	// --- KIOSK DISPLAYS ---
+	CreateKioskDisplay(ctx context.Context, display *Kiosk_displays) (*Kiosk_displays, error)
+	ListKioskDisplays(ctx context.Context, orgID string) ([]Kiosk_displays, error)
+	DeleteKioskDisplay(ctx context.Context, orgID, id string) error
+	AuthenticateKioskDisplay(ctx context.Context, keyHash string) (*Kiosk_displays, error)
+	SetLeaderboardVisibility(ctx context.Context, orgID, userID string, visible bool) error
+	ListLeaderboard(ctx context.Context, orgID string, from, to time.Time, limit int) ([]LeaderboardEntry, error)
+
+	// --- SCIM PROVISIONING ---
+	SetOrganizationSCIMToken(ctx context.Context, orgID, tokenHash string) error
+	AuthenticateSCIMToken(ctx context.Context, tokenHash string) (string, error)
+	ListSCIMMembers(ctx context.Context, orgID string, filter SCIMMemberFilter, opts ListOptions) ([]SCIMMember, int, error)
+	GetSCIMMember(ctx context.Context, orgID, userID string) (*SCIMMember, error)
+	ProvisionSCIMMember(ctx context.Context, orgID string, member *SCIMMember, newUser *Users) (*SCIMMember, error)
+	UpdateSCIMMember(ctx context.Context, orgID, userID string, update SCIMMemberUpdate) (*SCIMMember, error)
+	RemoveSCIMMember(ctx context.Context, orgID, userID string) error
+
+	// --- ORGANIZATION SSO ---
+	GetOrganizationSSO(ctx context.Context, orgID string) (*Organization_sso, error)
+	UpsertOrganizationSSO(ctx context.Context, config *Organization_sso) (*Organization_sso, error)
+	DeleteOrganizationSSO(ctx context.Context, orgID string) error
+	SyncSSOMember(ctx context.Context, orgID, userID, role string) (*Organization_members, error)
+
+	// --- COMMUNITY INTEGRATIONS ---
+	GetCommunityIntegration(ctx context.Context, orgID string) (*Community_integrations, error)
+	UpsertCommunityIntegration(ctx context.Context, integration *Community_integrations) (*Community_integrations, error)
+	DeleteCommunityIntegration(ctx context.Context, orgID string) error
+	ListMemberCommunityIntegrations(ctx context.Context, userID string) ([]MemberCommunityIntegration, error)
+	ListLeaderboardCommunityIntegrations(ctx context.Context) ([]Community_integrations, error)
+	ClaimWeeklyLeaderboard(ctx context.Context, orgID string, weekStart time.Time) (bool, error)
+
+	// --- INTEGRATIONS ---
+	UpsertIntegration(ctx context.Context, integration *Integrations) (*Integrations, error)
+	GetIntegration(ctx context.Context, userID, provider string) (*Integrations, error)
+	GetIntegrationByExternalUser(ctx context.Context, provider, externalUserID string) (*Integrations, error)
+	ListIntegrationsByUser(ctx context.Context, userID string) ([]Integrations, error)
+	ListIntegrationsDueForSync(ctx context.Context, provider string, syncedBefore time.Time, limit int) ([]Integrations, error)
+	UpdateIntegrationTokens(ctx context.Context, id, accessToken, refreshToken string, expiresAt time.Time) error
+	MarkIntegrationSynced(ctx context.Context, id string, syncedAt time.Time, syncErr string) error
+	DeleteIntegration(ctx context.Context, userID, provider string) error
+	UpsertImportedSession(ctx context.Context, ws *Workout_sessions) (*Workout_sessions, error)
+	DeleteImportedSession(ctx context.Context, userID, source, externalID string) error
+	FindOrCreateExercise(ctx context.Context, name, muscleGroup, equipment string) (*Exercises, error)
+
+	// --- HEALTH IMPORT ---
+	ImportHealthRecords(ctx context.Context, userID string, sessions []Workout_sessions, metrics []Body_metrics, matchWindow time.Duration) (*HealthImportResult, error)
+	ListBodyMetrics(ctx context.Context, userID string, opts ListBodyMetricsOpts) ([]Body_metrics, error)
+	FindDuplicateImports(ctx context.Context, userID, source string, sessions []Workout_sessions, matchWindow time.Duration) (map[string]bool, error)
+	ImportSetLog(ctx context.Context, userID string, sessions []ImportedSession, matchWindow time.Duration) (*SetImportResult, error)
+
+	// --- NUTRITION ---
+	CreateFood(ctx context.Context, food *Foods) (*Foods, error)
+	GetFood(ctx context.Context, id, userID string) (*Foods, error)
+	SearchFoods(ctx context.Context, userID, query, barcode string, limit int) ([]Foods, error)
+	UpsertExternalFood(ctx context.Context, food *Foods) (*Foods, error)
+	UpdateFood(ctx context.Context, food *Foods) (*Foods, error)
+	DeleteFood(ctx context.Context, id, userID string) error
+	CreateNutritionLog(ctx context.Context, entry *Nutrition_logs) (*Nutrition_logs, error)
+	GetNutritionLog(ctx context.Context, id, userID string) (*Nutrition_logs, error)
+	ListNutritionLogs(ctx context.Context, userID string, opts ListNutritionLogsOpts) ([]Nutrition_logs, error)
+	UpdateNutritionLog(ctx context.Context, entry *Nutrition_logs) (*Nutrition_logs, error)
+	DeleteNutritionLog(ctx context.Context, id, userID string) error
+	GetNutritionTotals(ctx context.Context, userID string, from, to time.Time) ([]MealTotals, error)
+
+	// --- BENCHMARKS ---
+	GetBenchmarkProfile(ctx context.Context, userID string) (*BenchmarkProfile, error)
+	GrantBenchmarkConsent(ctx context.Context, userID string) (*Benchmark_consents, error)
+	RevokeBenchmarkConsent(ctx context.Context, userID string) error
+	GetBenchmarkDistribution(ctx context.Context, exerciseID, bodyweightClass string) (*Benchmark_distributions, error)
+	RefreshBenchmarkDistributions(ctx context.Context, since time.Time, minSampleSize int) (int, error)
+
+	// --- HABITS ---
+	UpsertDailyHabits(ctx context.Context, habits *Daily_habits) (*Daily_habits, error)
+	GetDailyHabits(ctx context.Context, userID string, day time.Time) (*Daily_habits, error)
+	ListDailyHabits(ctx context.Context, userID string, from, to time.Time) ([]Daily_habits, error)
+	DeleteDailyHabits(ctx context.Context, userID string, day time.Time) error
+
+	// --- PROGRESS PHOTOS ---
+	CreateProgressPhoto(ctx context.Context, photo *Progress_photos) (*Progress_photos, error)
+	GetProgressPhoto(ctx context.Context, id, userID string) (*Progress_photos, error)
+	GetProgressPhotoUpload(ctx context.Context, id, userID string) (*Progress_photos, error)
+	ListProgressPhotos(ctx context.Context, userID string, opts ListProgressPhotosOpts) ([]Progress_photos, error)
+	CompleteProgressPhoto(ctx context.Context, photo *Progress_photos) (*Progress_photos, error)
+	MoveProgressPhoto(ctx context.Context, photo *Progress_photos) (*Progress_photos, error)
+	DeleteProgressPhoto(ctx context.Context, id, userID string) (*Progress_photos, error)
+	DeleteAbandonedProgressPhotos(ctx context.Context, before time.Time, limit int) ([]Progress_photos, error)
+	GetBodyMetricsNear(ctx context.Context, userID string, at time.Time, window time.Duration, metrics []string) ([]Body_metrics, error)
+	CreatePhotoVault(ctx context.Context, userID string, pinSalt, wrappedKey []byte) (*Photo_vaults, error)
+	GetPhotoVault(ctx context.Context, userID string) (*Photo_vaults, error)
+	ChangePhotoVaultPIN(ctx context.Context, userID string, pinSalt, wrappedKey []byte) error
+	RecordPhotoVaultAttempt(ctx context.Context, userID string, succeeded bool, maxAttempts int, lockout time.Duration) (*Photo_vaults, error)
+
+	// --- RETENTION AND LEGAL HOLDS ---
+	PurgeRetainedData(ctx context.Context, category string, olderThan time.Time, limit int) (*RetainedDataPurge, error)
+	CreateLegalHold(ctx context.Context, orgID string, userID *string, reason, createdBy string) (*Legal_holds, error)
+	ListLegalHolds(ctx context.Context, orgID string) ([]Legal_holds, error)
+	ReleaseLegalHold(ctx context.Context, orgID, holdID, releasedBy string) (*Legal_holds, error)
+
+	// --- DATA SUBJECT REQUESTS ---
+	GetUserRole(ctx context.Context, userID string) (string, error)
+	CreateDataSubjectRequest(ctx context.Context, req *Data_subject_requests) (*Data_subject_requests, error)
+	GetDataSubjectRequest(ctx context.Context, id string) (*Data_subject_requests, error)
+	ListDataSubjectRequests(ctx context.Context, status string, overdue bool, opts ListOptions) ([]Data_subject_requests, error)
+	StartDataSubjectRequest(ctx context.Context, id, resolvedBy string, exportID, deletionID *string) (*Data_subject_requests, error)
+	RejectDataSubjectRequest(ctx context.Context, id, resolvedBy, reason string) (*Data_subject_requests, error)
+	SyncDataSubjectRequests(ctx context.Context) error
+	GetAccountDeletion(ctx context.Context, id string) (*Account_deletions, error)
+
+	// --- ADMIN USER MANAGEMENT ---
+	SearchUsers(ctx context.Context, query string, limit, offset int) ([]Users, error)
+	SetUserDisabled(ctx context.Context, userID string, disabled bool) (*Users, error)
+	RequirePasswordReset(ctx context.Context, userID string) (*Users, error)
+	CountUserResources(ctx context.Context, userID string) (*UserResourceCounts, error)
+	CreateAdminAuditLog(ctx context.Context, entry *Admin_audit_log) error
+	ListAdminAuditLog(ctx context.Context, targetUserID string, limit, offset int) ([]Admin_audit_log, error)
+
+	// --- WEBHOOKS ---
+	CreateWebhook(ctx context.Context, webhook *Webhooks) (*Webhooks, error)
+	GetWebhook(ctx context.Context, id, userID string) (*Webhooks, error)
+	ListWebhooks(ctx context.Context, userID string) ([]Webhooks, error)
+	UpdateWebhook(ctx context.Context, webhook *Webhooks) (*Webhooks, error)
+	DeleteWebhook(ctx context.Context, id, userID string) error
+	ListWebhookDeliveries(ctx context.Context, webhookID string, opts ListOptions) ([]Webhook_deliveries, error)
+	EnqueueWebhookEvent(ctx context.Context, userID, eventID, event string, payload []byte, lease time.Duration) ([]WebhookDeliveryTarget, error)
+	ClaimWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]WebhookDeliveryTarget, error)
+	RecordWebhookAttempt(ctx context.Context, id string, succeeded bool, responseStatus *int, lastError string, nextAttemptAt *time.Time) error
+	CountOverdueWebhookDeliveries(ctx context.Context, dueBefore time.Time) (int, error)
+	ListIntegrationEvents(ctx context.Context, userID, event string, since time.Time, limit int) ([]Integration_events, error)
+	PurgeIntegrationEvents(ctx context.Context, before time.Time, limit int) (int64, error)
+	GetPersonalRecord(ctx context.Context, workoutExerciseID string) (*PersonalRecord, error)
+
+	// --- WORKOUT COMPANION ---
+	LogWorkoutSessionSet(ctx context.Context, set *Workout_session_sets) (*Workout_session_sets, bool, error)
+	ListWorkoutSessionSets(ctx context.Context, sessionID string) ([]Workout_session_sets, error)
+	CopyLastWorkoutSessionSets(ctx context.Context, sessionID, userID string) (string, []Workout_session_sets, error)
+
+	// --- PUSH NOTIFICATIONS ---
+	RegisterDevice(ctx context.Context, device *Devices) (*Devices, error)
+	ListDevices(ctx context.Context, userID string) ([]Devices, error)
+	DeleteDevice(ctx context.Context, id, userID string) error
+	DeleteDeviceByToken(ctx context.Context, token string) error
+	GetNotificationPreferences(ctx context.Context, userID string) (*Notification_preferences, error)
+	UpdateNotificationPreferences(ctx context.Context, prefs *Notification_preferences) (*Notification_preferences, error)
+	ClaimWorkoutReminders(ctx context.Context, limit int) ([]string, error)
+	CreateReminder(ctx context.Context, reminder *Reminders) (*Reminders, error)
+	GetReminder(ctx context.Context, id, userID string) (*Reminders, error)
+	ListReminders(ctx context.Context, userID string) ([]Reminders, error)
+	UpdateReminder(ctx context.Context, reminder *Reminders) (*Reminders, error)
+	DeleteReminder(ctx context.Context, id, userID string) error
+	ClaimDueReminders(ctx context.Context, limit int, lease time.Duration) ([]Reminders, error)
+	RescheduleReminder(ctx context.Context, id string, claimedUntil, nextRunAt time.Time, sent bool) error
+
+	// --- DAILY AND WEEKLY SUMMARIES ---
+	GetDailySummary(ctx context.Context, userID string, from, to time.Time) (*DailySummary, error)
+	ListPersonalRecordSets(ctx context.Context, userID string, from, to time.Time) ([]PersonalRecordSet, error)
+	ListActiveWeeks(ctx context.Context, userID, tz string, before time.Time, limit int) ([]time.Time, error)
+	ClaimWeeklySummaries(ctx context.Context, weekStart time.Time, limit int) ([]string, error)
+
+	// --- TRAINING MAXES ---
+	ListTrainingMaxes(ctx context.Context, userID string) ([]Training_maxes, error)
+	GetTrainingMax(ctx context.Context, userID, name string) (*Training_maxes, error)
+	UpsertTrainingMax(ctx context.Context, tm *Training_maxes, source string) (*Training_maxes, error)
+	DeleteTrainingMax(ctx context.Context, userID, name string) error
+	ListTrainingMaxHistory(ctx context.Context, trainingMaxID string, opts ListOptions) ([]Training_max_history, error)
+	ListAMRAPSets(ctx context.Context, userID string) ([]AMRAPSet, error)
+	ListExerciseSets(ctx context.Context, userID, exerciseID string, since time.Time) ([]Workout_session_sets, error)
+
+	// --- SESSION FEEDBACK ---
+	UpsertSessionFeedback(ctx context.Context, feedback *Session_feedback) (*Session_feedback, bool, error)
+	GetSessionFeedback(ctx context.Context, sessionID string) (*Session_feedback, error)
+	DeleteSessionFeedback(ctx context.Context, sessionID string) error
+	ListSessionLoads(ctx context.Context, userID string, since time.Time) ([]SessionLoad, error)
+
+	// --- WORKLOAD ---
+	ListSessionVolumes(ctx context.Context, userID string, since time.Time) ([]SessionVolume, error)
+
+	// --- PROGRESSION RULES ---
+	UpsertProgressionRule(ctx context.Context, rule *Progression_rules) (*Progression_rules, bool, error)
+	GetProgressionRule(ctx context.Context, workoutExerciseID string) (*Progression_rules, error)
+	DeleteProgressionRule(ctx context.Context, workoutExerciseID string) error
+	ListProgressionRules(ctx context.Context, workoutExerciseIDs []string) ([]Progression_rules, error)
+	ListProgressionSteps(ctx context.Context, sessionID string) ([]ProgressionStep, error)
+	ApplyProgressionSteps(ctx context.Context, steps []Progression_steps) (int, error)
+
+	// --- PROGRAM ADJUSTMENTS ---
+	ListProgramsDueForAdjustment(ctx context.Context, now time.Time, limit int) ([]Programs, error)
+	MarkProgramAdjusted(ctx context.Context, programID string, week int) error
+	CountProgramWorkouts(ctx context.Context, programID string) (int, error)
+	ListProgramSessionStats(ctx context.Context, programID, userID string, from, to time.Time) ([]ProgramSessionStats, error)
+	ListProgramWeeks(ctx context.Context, programID string) ([]Program_weeks, error)
+	CreateProgramAdjustment(ctx context.Context, adj *Program_adjustments) (*Program_adjustments, error)
+	GetProgramAdjustment(ctx context.Context, id string) (*Program_adjustments, error)
+	ListProgramAdjustments(ctx context.Context, programID string, opts ListOptions) ([]Program_adjustments, error)
+	ListPendingProgramAdjustments(ctx context.Context, coachID string, opts ListOptions) ([]Program_adjustments, error)
+	ReviewProgramAdjustment(ctx context.Context, id, reviewerID string, status Program_adjustments_status, note string) (*Program_adjustments, error)
+	SetProgramCoach(ctx context.Context, programID string, coachID *string) (*Programs, error)
+	IsOrganizationCoach(ctx context.Context, coachID, userID string) (bool, error)
+
+	// --- SESSION WEATHER ---
+	ListSessionsNeedingWeather(ctx context.Context, endedBefore time.Time, limit int) ([]Workout_sessions, error)
+	SetSessionWeather(ctx context.Context, sessionID string, weather *SessionWeather) error
+
+	// --- REST ANALYTICS ---
+	ListRestSets(ctx context.Context, filter RestSetFilter) ([]RestSet, error)
+
+	// --- WORKOUT SUGGESTIONS ---
+	ListSuggestionCandidates(ctx context.Context, userID string, limit int) ([]SuggestionCandidate, error)
+	ListWorkoutMuscles(ctx context.Context, workoutIDs []string) ([]WorkoutMuscle, error)
+	ListRecentMuscleSets(ctx context.Context, userID string, since time.Time) ([]MuscleSets, error)
+
+	// --- WORKOUT RECOMMENDATIONS ---
+	ListSessionStarts(ctx context.Context, userID string, since time.Time) ([]time.Time, error)
+	ListLibraryExercises(ctx context.Context, userID string, slugs []string, perMuscle int) ([]LibraryExercise, error)
+}
+
+// service implements the entity repositories by embedding them, and everything else on
+// the connection pool directly
 type service struct {
 	db *sqlx.DB
-}
-
-var (
-	database   = os.Getenv("BLUEPRINT_DB_DATABASE")
-	password   = os.Getenv("BLUEPRINT_DB_PASSWORD")
-	username   = os.Getenv("BLUEPRINT_DB_USERNAME")
-	port       = os.Getenv("BLUEPRINT_DB_PORT")
-	host       = os.Getenv("BLUEPRINT_DB_HOST")
-	schema     = os.Getenv("BLUEPRINT_DB_SCHEMA")
-	dbInstance *service
-)
-
-// Config holds database configuration
+	// name is the database name, used in log messages
+	name   string
+	quotas *quotaChecker
+
+	*userRepository
+	*workoutRepository
+	*exerciseRepository
+	*workoutExerciseRepository
+	*workoutSessionRepository
+	*programRepository
+}
+
+// newService composes a service whose repositories share db and enforce quotas
+func newService(db *sqlx.DB, name string, quotas Quotas, logger *slog.Logger) *service {
+	checker := &quotaChecker{db: db, quotas: quotas}
+	return &service{
+		db:                        db,
+		name:                      name,
+		quotas:                    checker,
+		userRepository:            &userRepository{db: db, log: logger},
+		workoutRepository:         &workoutRepository{db: db, quotas: checker},
+		exerciseRepository:        &exerciseRepository{db: db, quotas: checker},
+		workoutExerciseRepository: &workoutExerciseRepository{db: db},
+		workoutSessionRepository:  &workoutSessionRepository{db: db},
+		programRepository:         &programRepository{db: db},
+	}
+}
+
+// Config holds database configuration. Each Service owns its own connection pool, so
+// several can be open at once, e.g. the primary database and an analytics replica.
 type Config struct {
+	Host     string
+	Port     string
+	Database string
+	Username string
+	Password string
+	Schema   string
+	SSLMode  string
+
 	MaxOpenConns    int
 	MaxIdleConns    int
 	ConnMaxLifetime time.Duration
 	ConnMaxIdleTime time.Duration
+
+	// Quotas limit the content each user may save, by subscription tier
+	Quotas Quotas
+
+	// Logger receives the database's log entries; slog's default logger when nil
+	Logger *slog.Logger
 }
 
-// DefaultConfig returns default database configuration
+// DefaultConfig returns the configuration of the primary database, read from the
+// BLUEPRINT_DB_* environment variables
 func DefaultConfig() *Config {
+	return ConfigFromEnv("BLUEPRINT_DB")
+}
+
+// ConfigFromEnv reads connection settings from <prefix>_HOST, <prefix>_PORT,
+// <prefix>_DATABASE, <prefix>_USERNAME, <prefix>_PASSWORD, <prefix>_SCHEMA and
+// <prefix>_SSLMODE, with default pool settings and the quotas of QuotasFromEnv
+func ConfigFromEnv(prefix string) *Config {
+	sslMode := os.Getenv(prefix + "_SSLMODE")
+	if sslMode == "" {
+		sslMode = "disable"
+	}
 	return &Config{
+		Host:            os.Getenv(prefix + "_HOST"),
+		Port:            os.Getenv(prefix + "_PORT"),
+		Database:        os.Getenv(prefix + "_DATABASE"),
+		Username:        os.Getenv(prefix + "_USERNAME"),
+		Password:        os.Getenv(prefix + "_PASSWORD"),
+		Schema:          os.Getenv(prefix + "_SCHEMA"),
+		SSLMode:         sslMode,
 		MaxOpenConns:    25,
 		MaxIdleConns:    5,
 		ConnMaxLifetime: 5 * time.Minute,
 		ConnMaxIdleTime: 5 * time.Minute,
+		Quotas:          QuotasFromEnv(),
 	}
 }
 
-// New creates a new database service instance with default configuration
+// connString builds the pgx connection URL for the configuration
+func (c *Config) connString() string {
+	u := url.URL{
+		Scheme: "postgres",
+		User:   url.UserPassword(c.Username, c.Password),
+		Host:   net.JoinHostPort(c.Host, c.Port),
+		Path:   "/" + c.Database,
+	}
+	query := url.Values{}
+	query.Set("sslmode", c.SSLMode)
+	if c.Schema != "" {
+		query.Set("search_path", c.Schema)
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// New connects to the primary database with the default configuration, exiting the
+// process if it is unreachable
 func New() Service {
 	return NewWithConfig(DefaultConfig())
 }
 
-// NewWithConfig creates a new database service instance with custom configuration
+// NewWithConfig connects to the configured database, exiting the process if it is
+// unreachable. Every call opens a separate connection pool.
 func NewWithConfig(config *Config) Service {
-	// Reuse Connection
-	if dbInstance != nil {
-		return dbInstance
+	s, err := Open(config)
+	if err != nil {
+		log.Fatal(err)
 	}
+	return s
+}
 
-	connStr := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable&search_path=%s",
-		username, password, host, port, database, schema)
-
-	db, err := sqlx.Open("pgx", connStr)
+// Open connects to the configured database, returning an error rather than exiting if
+// it is unreachable. Every call opens a separate connection pool; Close it when done.
+func Open(config *Config) (Service, error) {
+	db, err := sqlx.Open("pgx", config.connString())
 	if err != nil {
-		log.Fatalf("Failed to open database connection: %v", err)
+		return nil, fmt.Errorf("failed to open database connection: %w", err)
 	}
 
 	// Configure connection pool
@@ -142,15 +525,16 @@ func NewWithConfig(config *Config) Service {
 	defer cancel()
 
 	if err := db.PingContext(ctx); err != nil {
-		log.Fatalf("Failed to ping database: %v", err)
+		db.Close()
+		return nil, fmt.Errorf("failed to ping database %s: %w", config.Database, err)
 	}
 
-	dbInstance = &service{
-		db: db,
+	messages.Log(messages.DBConnected, config.Database)
+	logger := config.Logger
+	if logger == nil {
+		logger = slog.Default()
 	}
-
-	log.Printf("Successfully connected to database: %s", database)
-	return dbInstance
+	return newService(db, config.Database, config.Quotas, logger), nil
 }
 
 // GetDB returns the underlying sqlx.DB instance for direct access
@@ -195,7 +579,7 @@ func (s *service) Health() map[string]string {
 	if err != nil {
 		stats["status"] = "down"
 		stats["error"] = fmt.Sprintf("db down: %v", err)
-		log.Printf("Database health check failed: %v", err)
+		messages.Log(messages.DBHealthCheckFailed, err)
 		return stats
 	}
 
@@ -233,411 +617,20 @@ func (s *service) Health() map[string]string {
 	return stats
 }
 
+// Ping checks that the database accepts connections
+func (s *service) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
 // Close closes the database connection.
 // It logs a message indicating the disconnection from the specific database.
 // If the connection is successfully closed, it returns nil.
 // If an error occurs while closing the connection, it returns the error.
 func (s *service) Close() error {
-	log.Printf("Disconnecting from database: %s", database)
+	messages.Log(messages.DBDisconnecting, s.name)
 	return s.db.Close()
 }
 
-func (s *service) CreateUser(ctx context.Context, user *Users) (*Users, error) {
-	query := `INSERT INTO users (email, username, password_hash, first_name, last_name, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
-		RETURNING *`
-
-	// Handle type assertions for interface{} fields
-	var email, username, passwordHash, firstName, lastName string
-
-	if user.Email != nil {
-		if str, ok := user.Email.(string); ok {
-			email = str
-		}
-	}
-	if user.Username != nil {
-		if str, ok := user.Username.(string); ok {
-			username = str
-		}
-	}
-	if user.Password_hash != nil {
-		if str, ok := user.Password_hash.(string); ok {
-			passwordHash = str
-		}
-	}
-	if user.First_name != nil {
-		if str, ok := user.First_name.(string); ok {
-			firstName = str
-		}
-	}
-	if user.Last_name != nil {
-		if str, ok := user.Last_name.(string); ok {
-			lastName = str
-		}
-	}
-
-	// Log the values being inserted for debugging
-	fmt.Printf("DEBUG: Inserting user with values: email=%s, username=%s, passwordHash=%s, firstName=%s, lastName=%s\n",
-		email, username, passwordHash, firstName, lastName)
-
-	row := s.db.QueryRowContext(ctx, query, email, username, passwordHash, firstName, lastName, user.Created_at, user.Updated_at)
-
-	var created Users
-	err := row.Scan(&created.Id, &created.Email, &created.Username, &created.Password_hash, &created.First_name, &created.Last_name, &created.Created_at, &created.Updated_at)
-	if err != nil {
-		fmt.Printf("DEBUG: Error scanning result: %v\n", err)
-		return nil, fmt.Errorf("failed to scan user result: %w", err)
-	}
-
-	return &created, nil
-}
-
-func (s *service) GetUserByID(ctx context.Context, id string) (*Users, error) {
-	var user Users
-	query := `SELECT * FROM users WHERE id = $1`
-	err := s.db.GetContext(ctx, &user, query, id)
-	if err != nil {
-		return nil, err
-	}
-	return &user, nil
-}
-
-func (s *service) GetUserByEmail(ctx context.Context, email string) (*Users, error) {
-	var user Users
-	query := `SELECT * FROM users WHERE email = $1`
-	err := s.db.GetContext(ctx, &user, query, email)
-	if err != nil {
-		return nil, err
-	}
-	return &user, nil
-}
-
-func (s *service) ListUsers(ctx context.Context, limit, offset int) ([]Users, error) {
-	var users []Users
-	query := `SELECT * FROM users ORDER BY created_at DESC LIMIT $1 OFFSET $2`
-	err := s.db.SelectContext(ctx, &users, query, limit, offset)
-	return users, err
-}
-
-func (s *service) UpdateUser(ctx context.Context, user *Users) (*Users, error) {
-	query := `UPDATE users SET email=:email, username=:username, password_hash=:password_hash, first_name=:first_name, last_name=:last_name, updated_at=:updated_at WHERE id=:id RETURNING *`
-	row, err := s.db.NamedQueryContext(ctx, query, user)
-	if err != nil {
-		return nil, err
-	}
-	defer row.Close()
-	if row.Next() {
-		var updated Users
-		if err := row.StructScan(&updated); err != nil {
-			return nil, err
-		}
-		return &updated, nil
-	}
-	return nil, fmt.Errorf("failed to update user")
-}
-
-func (s *service) DeleteUser(ctx context.Context, id string) error {
-	query := `DELETE FROM users WHERE id = $1`
-	_, err := s.db.ExecContext(ctx, query, id)
-	return err
-}
-
-// --- WORKOUTS CRUD ---
-func (s *service) CreateWorkout(ctx context.Context, workout *Workouts) (*Workouts, error) {
-	query := `INSERT INTO workouts (id, user_id, name, description, duration_minutes, program_id, created_at, updated_at)
-		VALUES (:id, :user_id, :name, :description, :duration_minutes, :program_id, :created_at, :updated_at)
-		RETURNING *`
-	row, err := s.db.NamedQueryContext(ctx, query, workout)
-	if err != nil {
-		return nil, err
-	}
-	defer row.Close()
-	if row.Next() {
-		var created Workouts
-		if err := row.StructScan(&created); err != nil {
-			return nil, err
-		}
-		return &created, nil
-	}
-	return nil, fmt.Errorf("failed to insert workout")
-}
-
-func (s *service) GetWorkoutByID(ctx context.Context, id string) (*Workouts, error) {
-	var workout Workouts
-	query := `SELECT * FROM workouts WHERE id = $1`
-	err := s.db.GetContext(ctx, &workout, query, id)
-	if err != nil {
-		return nil, err
-	}
-	return &workout, nil
-}
-
-func (s *service) ListWorkouts(ctx context.Context, limit, offset int) ([]Workouts, error) {
-	var workouts []Workouts
-	query := `SELECT * FROM workouts ORDER BY created_at DESC LIMIT $1 OFFSET $2`
-	err := s.db.SelectContext(ctx, &workouts, query, limit, offset)
-	return workouts, err
-}
-
-func (s *service) UpdateWorkout(ctx context.Context, workout *Workouts) (*Workouts, error) {
-	query := `UPDATE workouts SET user_id=:user_id, name=:name, description=:description, duration_minutes=:duration_minutes, program_id=:program_id, updated_at=:updated_at WHERE id=:id RETURNING *`
-	row, err := s.db.NamedQueryContext(ctx, query, workout)
-	if err != nil {
-		return nil, err
-	}
-	defer row.Close()
-	if row.Next() {
-		var updated Workouts
-		if err := row.StructScan(&updated); err != nil {
-			return nil, err
-		}
-		return &updated, nil
-	}
-	return nil, fmt.Errorf("failed to update workout")
-}
-
-func (s *service) DeleteWorkout(ctx context.Context, id string) error {
-	query := `DELETE FROM workouts WHERE id = $1`
-	_, err := s.db.ExecContext(ctx, query, id)
-	return err
-}
-
-// --- EXERCISES CRUD ---
-func (s *service) CreateExercise(ctx context.Context, exercise *Exercises) (*Exercises, error) {
-	query := `INSERT INTO exercises (id, name, description, muscle_group, equipment, difficulty_level, instructions, created_at, updated_at)
-		VALUES (:id, :name, :description, :muscle_group, :equipment, :difficulty_level, :instructions, :created_at, :updated_at)
-		RETURNING *`
-	row, err := s.db.NamedQueryContext(ctx, query, exercise)
-	if err != nil {
-		return nil, err
-	}
-	defer row.Close()
-	if row.Next() {
-		var created Exercises
-		if err := row.StructScan(&created); err != nil {
-			return nil, err
-		}
-		return &created, nil
-	}
-	return nil, fmt.Errorf("failed to insert exercise")
-}
-
-func (s *service) GetExerciseByID(ctx context.Context, id string) (*Exercises, error) {
-	var exercise Exercises
-	query := `SELECT * FROM exercises WHERE id = $1`
-	err := s.db.GetContext(ctx, &exercise, query, id)
-	if err != nil {
-		return nil, err
-	}
-	return &exercise, nil
-}
-
-func (s *service) ListExercises(ctx context.Context, limit, offset int) ([]Exercises, error) {
-	var exercises []Exercises
-	query := `SELECT * FROM exercises ORDER BY created_at DESC LIMIT $1 OFFSET $2`
-	err := s.db.SelectContext(ctx, &exercises, query, limit, offset)
-	return exercises, err
-}
-
-func (s *service) UpdateExercise(ctx context.Context, exercise *Exercises) (*Exercises, error) {
-	query := `UPDATE exercises SET name=:name, description=:description, muscle_group=:muscle_group, equipment=:equipment, difficulty_level=:difficulty_level, instructions=:instructions, updated_at=:updated_at WHERE id=:id RETURNING *`
-	row, err := s.db.NamedQueryContext(ctx, query, exercise)
-	if err != nil {
-		return nil, err
-	}
-	defer row.Close()
-	if row.Next() {
-		var updated Exercises
-		if err := row.StructScan(&updated); err != nil {
-			return nil, err
-		}
-		return &updated, nil
-	}
-	return nil, fmt.Errorf("failed to update exercise")
-}
-
-func (s *service) DeleteExercise(ctx context.Context, id string) error {
-	query := `DELETE FROM exercises WHERE id = $1`
-	_, err := s.db.ExecContext(ctx, query, id)
-	return err
-}
-
-// --- WORKOUT_EXERCISES CRUD ---
-func (s *service) CreateWorkoutExercise(ctx context.Context, we *Workout_exercises) (*Workout_exercises, error) {
-	query := `INSERT INTO workout_exercises (id, workout_id, exercise_id, sets, reps, weight_kg, duration_seconds, order_index, rest_seconds, notes, created_at)
-		VALUES (:id, :workout_id, :exercise_id, :sets, :reps, :weight_kg, :duration_seconds, :order_index, :rest_seconds, :notes, :created_at)
-		RETURNING *`
-	row, err := s.db.NamedQueryContext(ctx, query, we)
-	if err != nil {
-		return nil, err
-	}
-	defer row.Close()
-	if row.Next() {
-		var created Workout_exercises
-		if err := row.StructScan(&created); err != nil {
-			return nil, err
-		}
-		return &created, nil
-	}
-	return nil, fmt.Errorf("failed to insert workout_exercise")
-}
-
-func (s *service) GetWorkoutExerciseByID(ctx context.Context, id string) (*Workout_exercises, error) {
-	var we Workout_exercises
-	query := `SELECT * FROM workout_exercises WHERE id = $1`
-	err := s.db.GetContext(ctx, &we, query, id)
-	if err != nil {
-		return nil, err
-	}
-	return &we, nil
-}
-
-func (s *service) ListWorkoutExercises(ctx context.Context, limit, offset int) ([]Workout_exercises, error) {
-	var wes []Workout_exercises
-	query := `SELECT * FROM workout_exercises ORDER BY created_at DESC LIMIT $1 OFFSET $2`
-	err := s.db.SelectContext(ctx, &wes, query, limit, offset)
-	return wes, err
-}
-
-func (s *service) UpdateWorkoutExercise(ctx context.Context, we *Workout_exercises) (*Workout_exercises, error) {
-	query := `UPDATE workout_exercises SET workout_id=:workout_id, exercise_id=:exercise_id, sets=:sets, reps=:reps, weight_kg=:weight_kg, duration_seconds=:duration_seconds, order_index=:order_index, rest_seconds=:rest_seconds, notes=:notes WHERE id=:id RETURNING *`
-	row, err := s.db.NamedQueryContext(ctx, query, we)
-	if err != nil {
-		return nil, err
-	}
-	defer row.Close()
-	if row.Next() {
-		var updated Workout_exercises
-		if err := row.StructScan(&updated); err != nil {
-			return nil, err
-		}
-		return &updated, nil
-	}
-	return nil, fmt.Errorf("failed to update workout_exercise")
-}
-
-func (s *service) DeleteWorkoutExercise(ctx context.Context, id string) error {
-	query := `DELETE FROM workout_exercises WHERE id = $1`
-	_, err := s.db.ExecContext(ctx, query, id)
-	return err
-}
-
-// --- WORKOUT_SESSIONS CRUD ---
-func (s *service) CreateWorkoutSession(ctx context.Context, ws *Workout_sessions) (*Workout_sessions, error) {
-	query := `INSERT INTO workout_sessions (id, user_id, workout_id, name, started_at, completed_at, duration_minutes, notes, created_at, updated_at)
-		VALUES (:id, :user_id, :workout_id, :name, :started_at, :completed_at, :duration_minutes, :notes, :created_at, :updated_at)
-		RETURNING *`
-	row, err := s.db.NamedQueryContext(ctx, query, ws)
-	if err != nil {
-		return nil, err
-	}
-	defer row.Close()
-	if row.Next() {
-		var created Workout_sessions
-		if err := row.StructScan(&created); err != nil {
-			return nil, err
-		}
-		return &created, nil
-	}
-	return nil, fmt.Errorf("failed to insert workout_session")
-}
-
-func (s *service) GetWorkoutSessionByID(ctx context.Context, id string) (*Workout_sessions, error) {
-	var ws Workout_sessions
-	query := `SELECT * FROM workout_sessions WHERE id = $1`
-	err := s.db.GetContext(ctx, &ws, query, id)
-	if err != nil {
-		return nil, err
-	}
-	return &ws, nil
-}
-
-func (s *service) ListWorkoutSessions(ctx context.Context, limit, offset int) ([]Workout_sessions, error) {
-	var wss []Workout_sessions
-	query := `SELECT * FROM workout_sessions ORDER BY created_at DESC LIMIT $1 OFFSET $2`
-	err := s.db.SelectContext(ctx, &wss, query, limit, offset)
-	return wss, err
-}
-
-func (s *service) UpdateWorkoutSession(ctx context.Context, ws *Workout_sessions) (*Workout_sessions, error) {
-	query := `UPDATE workout_sessions SET user_id=:user_id, workout_id=:workout_id, name=:name, started_at=:started_at, completed_at=:completed_at, duration_minutes=:duration_minutes, notes=:notes, updated_at=:updated_at WHERE id=:id RETURNING *`
-	row, err := s.db.NamedQueryContext(ctx, query, ws)
-	if err != nil {
-		return nil, err
-	}
-	defer row.Close()
-	if row.Next() {
-		var updated Workout_sessions
-		if err := row.StructScan(&updated); err != nil {
-			return nil, err
-		}
-		return &updated, nil
-	}
-	return nil, fmt.Errorf("failed to update workout_session")
-}
-
-func (s *service) DeleteWorkoutSession(ctx context.Context, id string) error {
-	query := `DELETE FROM workout_sessions WHERE id = $1`
-	_, err := s.db.ExecContext(ctx, query, id)
-	return err
-}
-
-// --- PROGRAMS CRUD ---
-func (s *service) CreateProgram(ctx context.Context, program *Programs) (*Programs, error) {
-	query := `INSERT INTO programs (id, name, description, user_id, duration_weeks, difficulty, is_active, created_at, updated_at)
-		VALUES (:id, :name, :description, :user_id, :duration_weeks, :difficulty, :is_active, :created_at, :updated_at)
-		RETURNING *`
-	row, err := s.db.NamedQueryContext(ctx, query, program)
-	if err != nil {
-		return nil, err
-	}
-	defer row.Close()
-	if row.Next() {
-		var created Programs
-		if err := row.StructScan(&created); err != nil {
-			return nil, err
-		}
-		return &created, nil
-	}
-	return nil, fmt.Errorf("failed to insert program")
-}
-
-func (s *service) GetProgramByID(ctx context.Context, id string) (*Programs, error) {
-	var program Programs
-	query := `SELECT * FROM programs WHERE id = $1`
-	err := s.db.GetContext(ctx, &program, query, id)
-	if err != nil {
-		return nil, err
-	}
-	return &program, nil
-}
-
-func (s *service) ListPrograms(ctx context.Context, limit, offset int) ([]Programs, error) {
-	var programs []Programs
-	query := `SELECT * FROM programs ORDER BY created_at DESC LIMIT $1 OFFSET $2`
-	err := s.db.SelectContext(ctx, &programs, query, limit, offset)
-	return programs, err
-}
-
-func (s *service) UpdateProgram(ctx context.Context, program *Programs) (*Programs, error) {
-	query := `UPDATE programs SET name=:name, description=:description, user_id=:user_id, duration_weeks=:duration_weeks, difficulty=:difficulty, is_active=:is_active, updated_at=:updated_at WHERE id=:id RETURNING *`
-	row, err := s.db.NamedQueryContext(ctx, query, program)
-	if err != nil {
-		return nil, err
-	}
-	defer row.Close()
-	if row.Next() {
-		var updated Programs
-		if err := row.StructScan(&updated); err != nil {
-			return nil, err
-		}
-		return &updated, nil
-	}
-	return nil, fmt.Errorf("failed to update program")
-}
-
-func (s *service) DeleteProgram(ctx context.Context, id string) error {
-	query := `DELETE FROM programs WHERE id = $1`
-	_, err := s.db.ExecContext(ctx, query, id)
-	return err
-}
+// ErrVersionConflict is returned by updates when the row is no longer at the version the
+// caller read it at, because another update got there first or it was deleted
+var ErrVersionConflict = errors.New("row was changed by another update")
@@ -2,7 +2,11 @@ package database
 
 import (
 	"context"
+	"errors"
 	"log"
+	"os"
+	"path/filepath"
+	"sync"
 	"testing"
 	"time"
 
@@ -33,9 +37,9 @@ func mustStartPostgresContainer() (func(context.Context) error, error) {
 		return nil, err
 	}
 
-	database = dbName
-	password = dbPwd
-	username = dbUser
+	os.Setenv("BLUEPRINT_DB_DATABASE", dbName)
+	os.Setenv("BLUEPRINT_DB_PASSWORD", dbPwd)
+	os.Setenv("BLUEPRINT_DB_USERNAME", dbUser)
 
 	dbHost, err := dbContainer.Host(context.Background())
 	if err != nil {
@@ -47,8 +51,8 @@ func mustStartPostgresContainer() (func(context.Context) error, error) {
 		return func(ctx context.Context) error { return dbContainer.Container.Terminate(ctx) }, err
 	}
 
-	host = dbHost
-	port = dbPort.Port()
+	os.Setenv("BLUEPRINT_DB_HOST", dbHost)
+	os.Setenv("BLUEPRINT_DB_PORT", dbPort.Port())
 
 	return func(ctx context.Context) error { return dbContainer.Container.Terminate(ctx) }, nil
 }
@@ -66,6 +70,16 @@ func TestMain(m *testing.M) {
 	}
 }
 
+// newMigratedService connects to the test container with every migration applied
+func newMigratedService(t *testing.T) Service {
+	t.Helper()
+	srv := New()
+	if err := RunEmbeddedMigrations(context.Background(), srv.GetDB()); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+	return srv
+}
+
 func TestNew(t *testing.T) {
 	srv := New()
 	if srv == nil {
@@ -98,3 +112,73 @@ func TestClose(t *testing.T) {
 		t.Fatalf("expected Close() to return nil")
 	}
 }
+
+func TestNewWithConfigOpensSeparatePools(t *testing.T) {
+	primary := NewWithConfig(DefaultConfig())
+	defer primary.Close()
+
+	replicaConfig := DefaultConfig()
+	replicaConfig.MaxOpenConns = 3
+	replica := NewWithConfig(replicaConfig)
+
+	if primary.GetDB() == replica.GetDB() {
+		t.Fatal("expected each call to open its own connection pool")
+	}
+	if got := replica.GetDB().Stats().MaxOpenConnections; got != 3 {
+		t.Fatalf("expected the second config to apply, got max open connections %d", got)
+	}
+
+	if err := replica.Close(); err != nil {
+		t.Fatalf("expected Close() to return nil, got %v", err)
+	}
+	if err := primary.PingContext(context.Background()); err != nil {
+		t.Fatalf("expected closing one service to leave the other open, got %v", err)
+	}
+}
+
+func TestRunMigrationsConcurrently(t *testing.T) {
+	dir := t.TempDir()
+	// The sleep keeps the first run inside the lock while the others start
+	sql := `SELECT pg_sleep(0.5); CREATE TABLE concurrent_migration_test (id INT);`
+	if err := os.WriteFile(filepath.Join(dir, "001_concurrent.sql"), []byte(sql), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := New()
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	errs := make(chan error, 3)
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- RunMigrationsFromDir(ctx, srv.GetDB(), dir)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("expected every run to succeed, got %v", err)
+		}
+	}
+
+	var applied int
+	if err := srv.GetDB().GetContext(ctx, &applied, `SELECT COUNT(*) FROM migrations WHERE name = '001_concurrent'`); err != nil {
+		t.Fatal(err)
+	}
+	if applied != 1 {
+		t.Fatalf("expected the migration to be applied once, got %d", applied)
+	}
+
+	manager := NewMigrationManager(srv.GetDB())
+	manager.LockTimeout = 100 * time.Millisecond
+	unlock, err := manager.lock(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unlock()
+	if err := manager.RunMigrations(ctx, dir); !errors.Is(err, ErrMigrationLockTimeout) {
+		t.Fatalf("expected ErrMigrationLockTimeout while the lock is held, got %v", err)
+	}
+}
@@ -0,0 +1,1050 @@
+// Package dbtest provides an in-memory database.Service so handler tests can run without
+// Postgres
+package dbtest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"fitness-hack/internal/database"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// ErrNoTransactions is returned by BeginTx, since the fake has no connection to begin one on
+var ErrNoTransactions = errors.New("dbtest: the fake database does not support transactions")
+
+// Fake is an in-memory database.Service. Every table is kept in a map, and the methods
+// behave like the Postgres repositories: missing rows return sql.ErrNoRows, updates check
+// versions, unique constraints fail with a *database.ConstraintError, and lists filter,
+// sort and page. Foreign keys aren't enforced and deletes only cascade where a method
+// promises to, and muscle groups are made up as exercises are given them rather than
+// seeded. Transactions aren't supported, see BeginTx.
+type Fake struct {
+	accountTables
+	orgTables
+	socialTables
+	integrationTables
+	healthTables
+	trainingTables
+
+	mu               sync.Mutex
+	users            map[string]database.Users
+	workouts         map[string]database.Workouts
+	exercises        map[string]database.Exercises
+	exerciseMuscles  map[string][]database.ExerciseMuscle
+	muscleGroups     map[string]database.Muscle_groups
+	exerciseMedia    map[string][]database.Exercise_media
+	progressPhotos   map[string]database.Progress_photos
+	workoutExercises map[string]database.Workout_exercises
+	workoutSessions  map[string]database.Workout_sessions
+	programs         map[string]database.Programs
+	userSessions     map[string]database.User_sessions
+	closed           bool
+}
+
+var _ database.Service = (*Fake)(nil)
+
+// NewFake returns an empty fake database
+func NewFake() *Fake {
+	return &Fake{
+		users:             map[string]database.Users{},
+		workouts:          map[string]database.Workouts{},
+		exercises:         map[string]database.Exercises{},
+		exerciseMuscles:   map[string][]database.ExerciseMuscle{},
+		muscleGroups:      map[string]database.Muscle_groups{},
+		exerciseMedia:     map[string][]database.Exercise_media{},
+		progressPhotos:    map[string]database.Progress_photos{},
+		workoutExercises:  map[string]database.Workout_exercises{},
+		workoutSessions:   map[string]database.Workout_sessions{},
+		programs:          map[string]database.Programs{},
+		userSessions:      map[string]database.User_sessions{},
+		accountTables:     newAccountTables(),
+		orgTables:         newOrgTables(),
+		socialTables:      newSocialTables(),
+		integrationTables: newIntegrationTables(),
+		healthTables:      newHealthTables(),
+		trainingTables:    newTrainingTables(),
+	}
+}
+
+// Health reports the fake as up until it is closed
+func (f *Fake) Health() map[string]string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return map[string]string{"status": "down", "error": "db down: closed"}
+	}
+	return map[string]string{"status": "up", "message": "It's healthy"}
+}
+
+// Ping fails once the fake is closed
+func (f *Fake) Ping(ctx context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return sql.ErrConnDone
+	}
+	return ctx.Err()
+}
+
+// PingContext is Ping
+func (f *Fake) PingContext(ctx context.Context) error {
+	return f.Ping(ctx)
+}
+
+// Close marks the fake as down
+func (f *Fake) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.closed = true
+	return nil
+}
+
+// GetDB returns nil; code that needs a real connection can't run against the fake
+func (f *Fake) GetDB() *sqlx.DB {
+	return nil
+}
+
+// BeginTx always fails with ErrNoTransactions
+func (f *Fake) BeginTx(ctx context.Context) (*sqlx.Tx, error) {
+	return nil, ErrNoTransactions
+}
+
+// Stats returns an empty pool's statistics
+func (f *Fake) Stats() map[string]interface{} {
+	return map[string]interface{}{"open_connections": 0, "in_use": 0, "idle": 0}
+}
+
+// now is the timestamp Postgres's NOW() would give
+func now() time.Time {
+	return time.Now().UTC()
+}
+
+// newID returns id, or a new uuid the way Postgres defaults it when id is empty
+func newID(id string) string {
+	if id == "" {
+		return uuid.New().String()
+	}
+	return id
+}
+
+// created fills in what Postgres defaults on insert
+func created(id *string, createdAt, updatedAt *time.Time, version *int) {
+	if *id == "" {
+		*id = uuid.New().String()
+	}
+	now := time.Now().UTC()
+	if createdAt.IsZero() {
+		*createdAt = now
+	}
+	if updatedAt != nil && updatedAt.IsZero() {
+		*updatedAt = now
+	}
+	*version = 1
+}
+
+// --- USERS ---
+
+func (f *Fake) CreateUser(ctx context.Context, user *database.Users) (*database.Users, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	u := *user
+	created(&u.Id, &u.Created_at, &u.Updated_at, &u.Version)
+	if u.Role == "" {
+		u.Role = "user"
+	}
+	if u.Timezone == "" {
+		u.Timezone = "UTC"
+	}
+	for _, existing := range f.users {
+		// Fail like Postgres's unique constraints do
+		if u.Email != "" && existing.Email == u.Email {
+			return nil, &database.ConstraintError{Kind: database.ErrDuplicate, Table: "users", Constraint: "users_email_key", Column: "email"}
+		}
+		if u.Username != "" && existing.Username == u.Username {
+			return nil, &database.ConstraintError{Kind: database.ErrDuplicate, Table: "users", Constraint: "users_username_key", Column: "username"}
+		}
+	}
+	f.users[u.Id] = u
+	return &u, nil
+}
+
+func (f *Fake) GetUserByID(ctx context.Context, id string) (*database.Users, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	u, ok := f.users[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &u, nil
+}
+
+func (f *Fake) GetUserByEmail(ctx context.Context, email string) (*database.Users, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, u := range f.users {
+		if u.Email == email {
+			return &u, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (f *Fake) ListUsers(ctx context.Context, opts database.ListOptions) ([]database.Users, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	rows := make([]database.Users, 0, len(f.users))
+	for _, u := range f.users {
+		rows = append(rows, u)
+	}
+	return rows, list(&rows, opts)
+}
+
+func (f *Fake) UpdateUser(ctx context.Context, user *database.Users) (*database.Users, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	existing, ok := f.users[user.Id]
+	if !ok || existing.Version != user.Version {
+		return nil, database.ErrVersionConflict
+	}
+	u := *user
+	u.Created_at, u.Role, u.Disabled_at, u.Version = existing.Created_at, existing.Role, existing.Disabled_at, existing.Version+1
+	f.users[u.Id] = u
+	return &u, nil
+}
+
+func (f *Fake) SearchUsers(ctx context.Context, query string, limit, offset int) ([]database.Users, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	rows := []database.Users{}
+	needle := strings.ToLower(query)
+	for _, u := range f.users {
+		fields := []string{u.Email, u.Username}
+		for _, name := range []*string{u.First_name, u.Last_name} {
+			if name != nil {
+				fields = append(fields, *name)
+			}
+		}
+		if u.Id == query || strings.Contains(strings.ToLower(strings.Join(fields, "\n")), needle) {
+			rows = append(rows, u)
+		}
+	}
+	return rows, list(&rows, database.ListOptions{Limit: limit, Offset: offset})
+}
+
+func (f *Fake) SetUserDisabled(ctx context.Context, userID string, disabled bool) (*database.Users, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	u, ok := f.users[userID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	if !disabled {
+		u.Disabled_at = nil
+	} else if u.Disabled_at == nil {
+		now := time.Now().UTC()
+		u.Disabled_at = &now
+	}
+	u.Version++
+	f.users[u.Id] = u
+	return &u, nil
+}
+
+func (f *Fake) RequirePasswordReset(ctx context.Context, userID string) (*database.Users, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	u, ok := f.users[userID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	u.Password_reset_required = true
+	u.Version++
+	f.users[u.Id] = u
+	return &u, nil
+}
+
+func (f *Fake) DeleteUser(ctx context.Context, id string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.users, id)
+	return nil
+}
+
+// --- WORKOUTS ---
+
+func (f *Fake) CreateWorkout(ctx context.Context, workout *database.Workouts) (*database.Workouts, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	w := *workout
+	created(&w.Id, &w.Created_at, &w.Updated_at, &w.Version)
+	f.workouts[w.Id] = w
+	return &w, nil
+}
+
+func (f *Fake) GetWorkoutByID(ctx context.Context, id string) (*database.Workouts, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	w, ok := f.workouts[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &w, nil
+}
+
+func (f *Fake) GetWorkoutsByIDs(ctx context.Context, ids []string) ([]database.Workouts, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	rows := []database.Workouts{}
+	for _, id := range ids {
+		if w, ok := f.workouts[id]; ok {
+			rows = append(rows, w)
+		}
+	}
+	return rows, nil
+}
+
+func (f *Fake) ListWorkouts(ctx context.Context, opts database.ListWorkoutsOpts) ([]database.Workouts, error) {
+	return f.listWorkouts(opts.ListOptions, func(w *database.Workouts) bool {
+		return (opts.UserID == "" || w.User_id == opts.UserID) &&
+			(opts.MuscleGroup == "" || f.hasMuscleGroup(w.Id, opts.MuscleGroup)) &&
+			(opts.From.IsZero() || !w.Created_at.Before(opts.From)) &&
+			(opts.To.IsZero() || w.Created_at.Before(opts.To))
+	})
+}
+
+func (f *Fake) ListWorkoutTemplates(ctx context.Context, opts database.ListOptions) ([]database.Workouts, error) {
+	return f.listWorkouts(opts, func(w *database.Workouts) bool { return w.Is_template })
+}
+
+func (f *Fake) listWorkouts(opts database.ListOptions, keep func(*database.Workouts) bool) ([]database.Workouts, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	rows := make([]database.Workouts, 0, len(f.workouts))
+	for _, w := range f.workouts {
+		if keep(&w) {
+			rows = append(rows, w)
+		}
+	}
+	return rows, list(&rows, opts)
+}
+
+// hasMuscleGroup reports whether the workout has an exercise whose primary muscles include
+// the muscle group, by slug or name. The caller holds f.mu.
+func (f *Fake) hasMuscleGroup(workoutID, muscleGroup string) bool {
+	for _, we := range f.workoutExercises {
+		if we.Workout_id == workoutID && f.worksMuscle(we.Exercise_id, database.MuscleSlug(muscleGroup), database.Exercise_muscle_groups_role_primary) {
+			return true
+		}
+	}
+	return false
+}
+
+// worksMuscle reports whether the exercise works the muscle, in the role unless it is
+// empty. The caller holds f.mu.
+func (f *Fake) worksMuscle(exerciseID, slug string, role database.Exercise_muscle_groups_role) bool {
+	for _, m := range f.exerciseMuscles[exerciseID] {
+		if m.Slug == slug && (role == "" || m.Role == role) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *Fake) UpdateWorkout(ctx context.Context, workout *database.Workouts) (*database.Workouts, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	existing, ok := f.workouts[workout.Id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	if existing.Version != workout.Version {
+		return nil, database.ErrVersionConflict
+	}
+	w := *workout
+	w.Created_at, w.Version = existing.Created_at, existing.Version+1
+	f.workouts[w.Id] = w
+	return &w, nil
+}
+
+func (f *Fake) DeleteWorkout(ctx context.Context, id string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.workouts, id)
+	return nil
+}
+
+// CloneWorkout copies a workout and its workout exercises the way the Postgres
+// repository does
+func (f *Fake) CloneWorkout(ctx context.Context, id, userID, name string) (*database.Workouts, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	source, ok := f.workouts[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	if !source.Is_template && source.User_id != userID {
+		return nil, database.ErrNotCloneable
+	}
+
+	clone := source
+	clone.Id, clone.User_id, clone.Is_template = uuid.New().String(), userID, false
+	clone.Created_at, clone.Updated_at, clone.Version = time.Time{}, time.Time{}, 0
+	created(&clone.Id, &clone.Created_at, &clone.Updated_at, &clone.Version)
+	if name != "" {
+		clone.Name = name
+	}
+	if source.User_id != userID {
+		clone.Program_id = ""
+	}
+	f.workouts[clone.Id] = clone
+
+	for _, we := range f.workoutExercises {
+		if we.Workout_id == id {
+			we.Id, we.Workout_id = uuid.New().String(), clone.Id
+			we.Created_at, we.Updated_at = clone.Created_at, clone.Created_at
+			we.Version = 1
+			f.workoutExercises[we.Id] = we
+		}
+	}
+	return &clone, nil
+}
+
+// --- EXERCISES ---
+
+func (f *Fake) CreateExercise(ctx context.Context, exercise *database.Exercises) (*database.Exercises, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	e := *exercise
+	created(&e.Id, &e.Created_at, &e.Updated_at, &e.Version)
+	f.exercises[e.Id] = e
+	return &e, nil
+}
+
+func (f *Fake) GetExerciseByID(ctx context.Context, id string) (*database.Exercises, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	e, ok := f.exercises[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &e, nil
+}
+
+func (f *Fake) GetExercisesByIDs(ctx context.Context, ids []string) ([]database.Exercises, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	rows := []database.Exercises{}
+	for _, id := range ids {
+		if e, ok := f.exercises[id]; ok {
+			rows = append(rows, e)
+		}
+	}
+	return rows, nil
+}
+
+func (f *Fake) ListExercises(ctx context.Context, opts database.ListExercisesOpts) ([]database.Exercises, error) {
+	if opts.Role != "" && !opts.Role.Valid() {
+		return nil, database.ErrInvalidListOption
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	rows := make([]database.Exercises, 0, len(f.exercises))
+	for _, e := range f.exercises {
+		if opts.Muscle == "" || f.worksMuscle(e.Id, opts.Muscle, opts.Role) {
+			rows = append(rows, e)
+		}
+	}
+	return rows, list(&rows, opts.ListOptions)
+}
+
+func (f *Fake) ListExerciseNames(ctx context.Context) ([]database.ExerciseName, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	names := make([]database.ExerciseName, 0, len(f.exercises))
+	for _, e := range f.exercises {
+		names = append(names, database.ExerciseName{Id: e.Id, Name: e.Name})
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if names[i].Name != names[j].Name {
+			return names[i].Name < names[j].Name
+		}
+		return names[i].Id < names[j].Id
+	})
+	return names, nil
+}
+
+func (f *Fake) UpdateExercise(ctx context.Context, exercise *database.Exercises) (*database.Exercises, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	existing, ok := f.exercises[exercise.Id]
+	if !ok || existing.Version != exercise.Version {
+		return nil, database.ErrVersionConflict
+	}
+	e := *exercise
+	e.Created_at, e.Source, e.External_id, e.Version = existing.Created_at, existing.Source, existing.External_id, existing.Version+1
+	f.exercises[e.Id] = e
+	return &e, nil
+}
+
+func (f *Fake) DeleteExercise(ctx context.Context, id string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.exercises, id)
+	delete(f.exerciseMuscles, id)
+	delete(f.exerciseMedia, id)
+	return nil
+}
+
+// ListMuscleGroups lists the muscle groups exercises have been given, by name
+func (f *Fake) ListMuscleGroups(ctx context.Context) ([]database.Muscle_groups, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	groups := make([]database.Muscle_groups, 0, len(f.muscleGroups))
+	for _, g := range f.muscleGroups {
+		groups = append(groups, g)
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Name < groups[j].Name })
+	return groups, nil
+}
+
+func (f *Fake) ListExerciseMuscles(ctx context.Context, exerciseIDs []string) ([]database.ExerciseMuscle, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	muscles := []database.ExerciseMuscle{}
+	for _, id := range exerciseIDs {
+		muscles = append(muscles, f.exerciseMuscles[id]...)
+	}
+	return muscles, nil
+}
+
+// SetExerciseMuscles replaces the exercise's muscles, making up a muscle group named
+// after any slug it hasn't seen
+func (f *Fake) SetExerciseMuscles(ctx context.Context, exerciseID string, muscles []database.MuscleAssignment) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.setExerciseMuscles(exerciseID, muscles)
+	return nil
+}
+
+// setExerciseMuscles replaces the exercise's muscles, reporting whether they changed. The
+// caller holds f.mu.
+func (f *Fake) setExerciseMuscles(exerciseID string, muscles []database.MuscleAssignment) bool {
+	set := []database.ExerciseMuscle{}
+	for _, role := range database.Exercise_muscle_groups_roleValues {
+		for _, m := range muscles {
+			if m.Role != role || hasSlug(set, m.Muscle) {
+				continue
+			}
+			group, ok := f.muscleGroups[m.Muscle]
+			if !ok {
+				group = database.Muscle_groups{Id: uuid.New().String(), Slug: m.Muscle, Name: m.Muscle, Created_at: time.Now().UTC()}
+				f.muscleGroups[m.Muscle] = group
+			}
+			set = append(set, database.ExerciseMuscle{Exercise_id: exerciseID, Slug: group.Slug, Name: group.Name, Role: role})
+		}
+	}
+	previous := f.exerciseMuscles[exerciseID]
+	f.exerciseMuscles[exerciseID] = set
+	return len(previous) != len(set) || slices.ContainsFunc(set, func(m database.ExerciseMuscle) bool {
+		return !slices.Contains(previous, m)
+	})
+}
+
+// UpsertExercises makes up muscle groups like SetExerciseMuscles, so it never returns
+// database.ErrUnknownMuscle
+func (f *Fake) UpsertExercises(ctx context.Context, exercises []database.CatalogExercise) (*database.UpsertExercisesResult, error) {
+	for i, e := range exercises {
+		if e.Source == "" || e.External_id == "" {
+			return nil, fmt.Errorf("%w: exercise %d", database.ErrMissingExternalID, i)
+		}
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	result := &database.UpsertExercisesResult{ChangedIDs: []string{}}
+	for _, catalog := range exercises {
+		var existing *database.Exercises
+		for _, e := range f.exercises {
+			if e.Source == catalog.Source && e.External_id == catalog.External_id {
+				existing = &e
+				break
+			}
+		}
+
+		e := catalog.Exercises
+		inserted, changed := existing == nil, false
+		if inserted {
+			e.Created_by, e.Updated_at = nil, time.Time{}
+			created(&e.Id, &e.Created_at, &e.Updated_at, &e.Version)
+		} else {
+			changed = e.Name != existing.Name || e.Description != existing.Description || e.Instructions != existing.Instructions ||
+				!equalString(e.Equipment, existing.Equipment) || !equalString(e.Difficulty_level, existing.Difficulty_level)
+			saved := *existing
+			saved.Name, saved.Description, saved.Equipment = e.Name, e.Description, e.Equipment
+			saved.Difficulty_level, saved.Instructions = e.Difficulty_level, e.Instructions
+			e = saved
+		}
+		if f.setExerciseMuscles(e.Id, catalog.Muscles) && !inserted {
+			changed = true
+		}
+		if changed {
+			e.Updated_at = now()
+		}
+		f.exercises[e.Id] = e
+
+		switch {
+		case inserted:
+			result.Inserted++
+		case changed:
+			result.Updated++
+		default:
+			result.Unchanged++
+			continue
+		}
+		result.ChangedIDs = append(result.ChangedIDs, e.Id)
+	}
+	return result, nil
+}
+
+// equalString reports whether two nullable columns hold the same value
+func equalString(a, b *string) bool {
+	return a == nil && b == nil || a != nil && b != nil && *a == *b
+}
+
+// touchExercise bumps the exercise's updated_at the way media changes do in Postgres. The
+// caller holds f.mu.
+func (f *Fake) touchExercise(id string) {
+	if e, ok := f.exercises[id]; ok {
+		e.Updated_at = time.Now().UTC()
+		f.exercises[id] = e
+	}
+}
+
+func (f *Fake) CreateExerciseMedia(ctx context.Context, media *database.Exercise_media) (*database.Exercise_media, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	m := *media
+	if m.Id == "" {
+		m.Id = uuid.New().String()
+	}
+	m.Created_at = time.Now().UTC()
+	m.Position = 0
+	for _, existing := range f.exerciseMedia[m.Exercise_id] {
+		m.Position = max(m.Position, existing.Position+1)
+	}
+	f.exerciseMedia[m.Exercise_id] = append(f.exerciseMedia[m.Exercise_id], m)
+	f.touchExercise(m.Exercise_id)
+	return &m, nil
+}
+
+func (f *Fake) GetExerciseMedia(ctx context.Context, exerciseID, mediaID string) (*database.Exercise_media, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, m := range f.exerciseMedia[exerciseID] {
+		if m.Id == mediaID {
+			return &m, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (f *Fake) ListExerciseMedia(ctx context.Context, exerciseIDs []string) ([]database.Exercise_media, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	media := []database.Exercise_media{}
+	for _, id := range exerciseIDs {
+		sorted := append([]database.Exercise_media(nil), f.exerciseMedia[id]...)
+		sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Position < sorted[j].Position })
+		media = append(media, sorted...)
+	}
+	return media, nil
+}
+
+func (f *Fake) MarkExerciseMediaReady(ctx context.Context, exerciseID, mediaID string) (*database.Exercise_media, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i, m := range f.exerciseMedia[exerciseID] {
+		if m.Id == mediaID {
+			m.Status = database.Exercise_media_status_ready
+			f.exerciseMedia[exerciseID][i] = m
+			f.touchExercise(exerciseID)
+			return &m, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (f *Fake) ReorderExerciseMedia(ctx context.Context, exerciseID string, mediaIDs []string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	positions := make(map[string]int, len(mediaIDs))
+	for i, id := range mediaIDs {
+		positions[id] = i
+	}
+	ready := 0
+	for _, m := range f.exerciseMedia[exerciseID] {
+		if m.Status == database.Exercise_media_status_ready {
+			if _, ok := positions[m.Id]; !ok {
+				return database.ErrMediaOrderMismatch
+			}
+			ready++
+		}
+	}
+	if ready != len(mediaIDs) || len(positions) != len(mediaIDs) {
+		return database.ErrMediaOrderMismatch
+	}
+	for i, m := range f.exerciseMedia[exerciseID] {
+		if position, ok := positions[m.Id]; ok {
+			f.exerciseMedia[exerciseID][i].Position = position
+		}
+	}
+	f.touchExercise(exerciseID)
+	return nil
+}
+
+func (f *Fake) DeleteExerciseMedia(ctx context.Context, exerciseID, mediaID string) (*database.Exercise_media, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	media := f.exerciseMedia[exerciseID]
+	for i, m := range media {
+		if m.Id == mediaID {
+			f.exerciseMedia[exerciseID] = append(media[:i:i], media[i+1:]...)
+			f.touchExercise(exerciseID)
+			return &m, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (f *Fake) CreateProgressPhoto(ctx context.Context, photo *database.Progress_photos) (*database.Progress_photos, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	p := *photo
+	p.Created_at = time.Now().UTC()
+	p.Updated_at = p.Created_at
+	f.progressPhotos[p.Id] = p
+	return &p, nil
+}
+
+func (f *Fake) GetProgressPhoto(ctx context.Context, id, userID string) (*database.Progress_photos, error) {
+	p, err := f.GetProgressPhotoUpload(ctx, id, userID)
+	if err != nil || p.Status != database.Progress_photos_status_ready {
+		return nil, sql.ErrNoRows
+	}
+	return p, nil
+}
+
+func (f *Fake) GetProgressPhotoUpload(ctx context.Context, id, userID string) (*database.Progress_photos, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	p, ok := f.progressPhotos[id]
+	if !ok || p.User_id != userID {
+		return nil, sql.ErrNoRows
+	}
+	return &p, nil
+}
+
+func (f *Fake) CompleteProgressPhoto(ctx context.Context, photo *database.Progress_photos) (*database.Progress_photos, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	p, ok := f.progressPhotos[photo.Id]
+	if !ok || p.User_id != photo.User_id || p.Status != database.Progress_photos_status_pending {
+		return nil, sql.ErrNoRows
+	}
+	p.Status = database.Progress_photos_status_ready
+	p.Size_bytes, p.Width, p.Height = photo.Size_bytes, photo.Width, photo.Height
+	p.Thumbnail_key, p.Thumbnail_width, p.Thumbnail_height = photo.Thumbnail_key, photo.Thumbnail_width, photo.Thumbnail_height
+	p.Updated_at = time.Now().UTC()
+	f.progressPhotos[p.Id] = p
+	return &p, nil
+}
+
+// hasSlug reports whether the muscles include slug
+func hasSlug(set []database.ExerciseMuscle, slug string) bool {
+	for _, m := range set {
+		if m.Slug == slug {
+			return true
+		}
+	}
+	return false
+}
+
+// --- WORKOUT EXERCISES ---
+
+func (f *Fake) CreateWorkoutExercise(ctx context.Context, we *database.Workout_exercises) (*database.Workout_exercises, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	w := *we
+	created(&w.Id, &w.Created_at, &w.Updated_at, &w.Version)
+	f.workoutExercises[w.Id] = w
+	return &w, nil
+}
+
+func (f *Fake) GetWorkoutExerciseByID(ctx context.Context, id string) (*database.Workout_exercises, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	w, ok := f.workoutExercises[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &w, nil
+}
+
+func (f *Fake) ListWorkoutExercises(ctx context.Context, opts database.ListOptions) ([]database.Workout_exercises, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	rows := make([]database.Workout_exercises, 0, len(f.workoutExercises))
+	for _, w := range f.workoutExercises {
+		rows = append(rows, w)
+	}
+	return rows, list(&rows, opts)
+}
+
+func (f *Fake) ListWorkoutExercisesOf(ctx context.Context, workoutIDs []string) ([]database.Workout_exercises, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	wanted := make(map[string]bool, len(workoutIDs))
+	for _, id := range workoutIDs {
+		wanted[id] = true
+	}
+	rows := []database.Workout_exercises{}
+	for _, we := range f.workoutExercises {
+		if wanted[we.Workout_id] {
+			rows = append(rows, we)
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Workout_id != rows[j].Workout_id {
+			return rows[i].Workout_id < rows[j].Workout_id
+		}
+		if rows[i].Order_index != rows[j].Order_index {
+			return rows[i].Order_index < rows[j].Order_index
+		}
+		return rows[i].Id < rows[j].Id
+	})
+	return rows, nil
+}
+
+func (f *Fake) UpdateWorkoutExercise(ctx context.Context, we *database.Workout_exercises) (*database.Workout_exercises, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	existing, ok := f.workoutExercises[we.Id]
+	if !ok || existing.Version != we.Version {
+		return nil, database.ErrVersionConflict
+	}
+	w := *we
+	w.Created_at, w.Updated_at, w.Version = existing.Created_at, time.Now().UTC(), existing.Version+1
+	f.workoutExercises[w.Id] = w
+	return &w, nil
+}
+
+func (f *Fake) DeleteWorkoutExercise(ctx context.Context, id string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.workoutExercises, id)
+	return nil
+}
+
+// --- WORKOUT SESSIONS ---
+
+func (f *Fake) CreateWorkoutSession(ctx context.Context, ws *database.Workout_sessions) (*database.Workout_sessions, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	s := *ws
+	created(&s.Id, &s.Created_at, &s.Updated_at, &s.Version)
+	if s.Started_at.IsZero() {
+		s.Started_at = s.Created_at
+	}
+	if s.Plan == nil {
+		s.Plan = []byte("[]")
+	}
+	f.workoutSessions[s.Id] = s
+	return &s, nil
+}
+
+func (f *Fake) GetWorkoutSessionByID(ctx context.Context, id string) (*database.Workout_sessions, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	s, ok := f.workoutSessions[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &s, nil
+}
+
+func (f *Fake) ListWorkoutSessions(ctx context.Context, opts database.ListWorkoutSessionsOpts) ([]database.Workout_sessions, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	rows := make([]database.Workout_sessions, 0, len(f.workoutSessions))
+	for _, s := range f.workoutSessions {
+		if (opts.From.IsZero() || !s.Started_at.Before(opts.From)) && (opts.To.IsZero() || s.Started_at.Before(opts.To)) {
+			rows = append(rows, s)
+		}
+	}
+	return rows, list(&rows, opts.ListOptions)
+}
+
+func (f *Fake) UpdateWorkoutSession(ctx context.Context, ws *database.Workout_sessions) (*database.Workout_sessions, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	existing, ok := f.workoutSessions[ws.Id]
+	if !ok || existing.Version != ws.Version {
+		return nil, database.ErrVersionConflict
+	}
+	// Only the columns the Postgres update sets change
+	s := existing
+	s.User_id, s.Workout_id, s.Name = ws.User_id, ws.Workout_id, ws.Name
+	s.Started_at, s.Completed_at, s.Duration_minutes = ws.Started_at, ws.Completed_at, ws.Duration_minutes
+	s.Notes, s.Updated_at, s.Version = ws.Notes, ws.Updated_at, existing.Version+1
+	f.workoutSessions[s.Id] = s
+	return &s, nil
+}
+
+func (f *Fake) DeleteWorkoutSession(ctx context.Context, id string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.workoutSessions, id)
+	return nil
+}
+
+// --- PROGRAMS ---
+
+func (f *Fake) CreateProgram(ctx context.Context, program *database.Programs) (*database.Programs, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	p := *program
+	created(&p.Id, &p.Created_at, &p.Updated_at, &p.Version)
+	f.programs[p.Id] = p
+	return &p, nil
+}
+
+func (f *Fake) GetProgramByID(ctx context.Context, id string) (*database.Programs, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	p, ok := f.programs[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &p, nil
+}
+
+func (f *Fake) ListPrograms(ctx context.Context, opts database.ListOptions) ([]database.Programs, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	rows := make([]database.Programs, 0, len(f.programs))
+	for _, p := range f.programs {
+		rows = append(rows, p)
+	}
+	return rows, list(&rows, opts)
+}
+
+func (f *Fake) UpdateProgram(ctx context.Context, program *database.Programs) (*database.Programs, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	existing, ok := f.programs[program.Id]
+	if !ok || existing.Version != program.Version {
+		return nil, database.ErrVersionConflict
+	}
+	p := *program
+	p.Created_at, p.Version = existing.Created_at, existing.Version+1
+	f.programs[p.Id] = p
+	return &p, nil
+}
+
+func (f *Fake) DeleteProgram(ctx context.Context, id string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.programs, id)
+	return nil
+}
+
+// --- USER SESSIONS ---
+
+func (f *Fake) CreateUserSession(ctx context.Context, session *database.User_sessions) (*database.User_sessions, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	u := *session
+	if u.Id == "" {
+		u.Id = uuid.New().String()
+	}
+	u.Created_at = time.Now().UTC()
+	u.Last_seen_at = u.Created_at
+	f.userSessions[u.Id] = u
+	return &u, nil
+}
+
+func (f *Fake) ListUserSessions(ctx context.Context, userID string) ([]database.User_sessions, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	now := time.Now()
+	rows := []database.User_sessions{}
+	for _, u := range f.userSessions {
+		if u.User_id == userID && u.Revoked_at == nil && u.Expires_at.After(now) {
+			rows = append(rows, u)
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].Last_seen_at.After(rows[j].Last_seen_at) })
+	return rows, nil
+}
+
+func (f *Fake) RevokeUserSession(ctx context.Context, id, userID string) (*database.User_sessions, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	u, ok := f.userSessions[id]
+	now := time.Now()
+	if !ok || u.User_id != userID || u.Revoked_at != nil || !u.Expires_at.After(now) {
+		return nil, sql.ErrNoRows
+	}
+	u.Revoked_at = &now
+	f.userSessions[id] = u
+	return &u, nil
+}
+
+func (f *Fake) RevokeAllUserSessions(ctx context.Context, userID string) ([]database.User_sessions, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	now := time.Now()
+	revoked := []database.User_sessions{}
+	for id, u := range f.userSessions {
+		if u.User_id == userID && u.Revoked_at == nil && u.Expires_at.After(now) {
+			u.Revoked_at = &now
+			f.userSessions[id] = u
+			revoked = append(revoked, u)
+		}
+	}
+	return revoked, nil
+}
+
+func (f *Fake) RevokeUserSessionByToken(ctx context.Context, tokenID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	now := time.Now()
+	for id, u := range f.userSessions {
+		if u.Token_id == tokenID && u.Revoked_at == nil {
+			u.Revoked_at = &now
+			f.userSessions[id] = u
+		}
+	}
+	return nil
+}
+
+func (f *Fake) TouchUserSession(ctx context.Context, tokenID, ipAddress string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for id, u := range f.userSessions {
+		if u.Token_id == tokenID {
+			u.Last_seen_at, u.Ip_address = time.Now().UTC(), ipAddress
+			f.userSessions[id] = u
+			return nil
+		}
+	}
+	return sql.ErrNoRows
+}
@@ -0,0 +1,1065 @@
+package dbtest
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"fitness-hack/internal/database"
+)
+
+// accountTables are the rows about accounts themselves: how users sign in and pay, what
+// they asked to export or delete, and the holds that stop deletion
+type accountTables struct {
+	guestAccounts       map[string]database.Guest_accounts
+	oauthIdentities     map[string]database.Oauth_identities
+	entitlements        map[string]database.Entitlements
+	subscriptions       map[string]database.Subscriptions
+	referralCodes       map[string]database.Referral_codes
+	referrals           map[string]database.Referrals
+	apiKeys             map[string]database.Api_keys
+	dataExports         map[string]database.Data_exports
+	accountDeletions    map[string]database.Account_deletions
+	dataSubjectRequests map[string]database.Data_subject_requests
+	adminAuditLog       []database.Admin_audit_log
+	legalHolds          map[string]database.Legal_holds
+}
+
+func newAccountTables() accountTables {
+	return accountTables{
+		guestAccounts:       map[string]database.Guest_accounts{},
+		oauthIdentities:     map[string]database.Oauth_identities{},
+		entitlements:        map[string]database.Entitlements{},
+		subscriptions:       map[string]database.Subscriptions{},
+		referralCodes:       map[string]database.Referral_codes{},
+		referrals:           map[string]database.Referrals{},
+		apiKeys:             map[string]database.Api_keys{},
+		dataExports:         map[string]database.Data_exports{},
+		accountDeletions:    map[string]database.Account_deletions{},
+		dataSubjectRequests: map[string]database.Data_subject_requests{},
+		legalHolds:          map[string]database.Legal_holds{},
+	}
+}
+
+// --- GUEST ACCOUNTS ---
+
+func (f *Fake) CreateGuestAccount(ctx context.Context, user *database.Users, expiresAt time.Time) (*database.Users, error) {
+	u, err := f.CreateUser(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.guestAccounts[u.Id] = database.Guest_accounts{User_id: u.Id, Expires_at: expiresAt, Created_at: now()}
+	return u, nil
+}
+
+func (f *Fake) DeleteExpiredGuestAccounts(ctx context.Context, before time.Time) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	ids := []string{}
+	for id, guest := range f.guestAccounts {
+		if !guest.Expires_at.Before(before) {
+			continue
+		}
+		f.deleteOwnedBy(id)
+		delete(f.users, id)
+		delete(f.guestAccounts, id)
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// deleteOwnedBy deletes the user's workouts with their exercises, sessions and programs,
+// which Postgres has no foreign keys to cascade from users for. The caller holds f.mu.
+func (f *Fake) deleteOwnedBy(userID string) map[string]int64 {
+	counts := map[string]int64{}
+	for id, w := range f.workouts {
+		if w.User_id != userID {
+			continue
+		}
+		for weID, we := range f.workoutExercises {
+			if we.Workout_id == id {
+				delete(f.workoutExercises, weID)
+				counts["workout_exercises"]++
+			}
+		}
+		delete(f.workouts, id)
+		counts["workouts"]++
+	}
+	for id, s := range f.workoutSessions {
+		if s.User_id == userID {
+			delete(f.workoutSessions, id)
+			counts["workout_sessions"]++
+		}
+	}
+	for id, p := range f.programs {
+		if p.User_id == userID {
+			delete(f.programs, id)
+			counts["programs"]++
+		}
+	}
+	return counts
+}
+
+// --- ACCOUNT MERGE ---
+
+// MergeUsers refuses the same conflicts as Postgres, then moves the source's workouts,
+// sessions, programs and the other rows the fake keeps by user to the target and deletes the
+// source. Imported sessions the target already has are dropped rather than moved.
+func (f *Fake) MergeUsers(ctx context.Context, sourceID, targetID string) (*database.MergeResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.users[sourceID]; !ok {
+		return nil, sql.ErrNoRows
+	}
+	if _, ok := f.users[targetID]; !ok {
+		return nil, sql.ErrNoRows
+	}
+	if f.held(sourceID) {
+		return nil, database.ErrMergeSourceHeld
+	}
+	_, sourceVault := f.photoVaults[sourceID]
+	_, targetVault := f.photoVaults[targetID]
+	if sourceVault && targetVault {
+		return nil, database.ErrMergeVaults
+	}
+	for _, src := range f.integrations {
+		if src.User_id == sourceID && f.findIntegration(targetID, src.Provider) != nil {
+			return nil, database.ErrMergeIntegrations
+		}
+	}
+
+	result := &database.MergeResult{SourceUserID: sourceID, TargetUserID: targetID}
+	for id, p := range f.programs {
+		if p.User_id == sourceID {
+			p.User_id = targetID
+			f.programs[id] = p
+			result.Programs++
+		}
+	}
+	for id, w := range f.workouts {
+		if w.User_id == sourceID {
+			w.User_id = targetID
+			f.workouts[id] = w
+			result.Workouts++
+		}
+	}
+	for id, s := range f.workoutSessions {
+		if s.User_id != sourceID {
+			continue
+		}
+		if s.External_id != "" && f.hasImportedSession(targetID, s.Source, s.External_id) {
+			delete(f.workoutSessions, id)
+			result.DuplicateSessions++
+			continue
+		}
+		s.User_id = targetID
+		f.workoutSessions[id] = s
+		result.WorkoutSessions++
+	}
+	for id, p := range f.progressPhotos {
+		if p.User_id == sourceID {
+			p.User_id = targetID
+			f.progressPhotos[id] = p
+		}
+	}
+	for id, m := range f.bodyMetrics {
+		if m.User_id == sourceID {
+			m.User_id = targetID
+			f.bodyMetrics[id] = m
+		}
+	}
+	for id, l := range f.nutritionLogs {
+		if l.User_id == sourceID {
+			l.User_id = targetID
+			f.nutritionLogs[id] = l
+		}
+	}
+	for id, k := range f.apiKeys {
+		if k.User_id == sourceID {
+			k.User_id = targetID
+			f.apiKeys[id] = k
+		}
+	}
+	for id, i := range f.oauthIdentities {
+		if i.User_id == sourceID {
+			i.User_id = targetID
+			f.oauthIdentities[id] = i
+		}
+	}
+	if vault, ok := f.photoVaults[sourceID]; ok {
+		vault.User_id = targetID
+		f.photoVaults[targetID] = vault
+		delete(f.photoVaults, sourceID)
+	}
+	for id, u := range f.userSessions {
+		if u.User_id == sourceID {
+			delete(f.userSessions, id)
+		}
+	}
+	delete(f.guestAccounts, sourceID)
+	delete(f.users, sourceID)
+	return result, nil
+}
+
+// hasImportedSession reports whether the user has a session imported from source with
+// the external id. The caller holds f.mu.
+func (f *Fake) hasImportedSession(userID, source, externalID string) bool {
+	for _, s := range f.workoutSessions {
+		if s.User_id == userID && s.Source == source && s.External_id == externalID {
+			return true
+		}
+	}
+	return false
+}
+
+// --- OAUTH IDENTITIES ---
+
+func (f *Fake) GetUserByOAuthIdentity(ctx context.Context, provider, subject string) (*database.Users, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, identity := range f.oauthIdentities {
+		if identity.Provider == provider && identity.Subject == subject {
+			if u, ok := f.users[identity.User_id]; ok {
+				return &u, nil
+			}
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (f *Fake) LinkOAuthIdentity(ctx context.Context, identity *database.Oauth_identities) (*database.Oauth_identities, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.linkOAuthIdentity(*identity)
+}
+
+// linkOAuthIdentity inserts the identity, failing like the unique constraint on provider
+// and subject. The caller holds f.mu.
+func (f *Fake) linkOAuthIdentity(identity database.Oauth_identities) (*database.Oauth_identities, error) {
+	for _, existing := range f.oauthIdentities {
+		if existing.Provider == identity.Provider && existing.Subject == identity.Subject {
+			return nil, &database.ConstraintError{Kind: database.ErrDuplicate, Table: "oauth_identities", Constraint: "oauth_identities_provider_subject_key"}
+		}
+	}
+	identity.Id = newID(identity.Id)
+	identity.Created_at, identity.Updated_at = now(), now()
+	f.oauthIdentities[identity.Id] = identity
+	return &identity, nil
+}
+
+func (f *Fake) CreateOAuthUser(ctx context.Context, user *database.Users, identity *database.Oauth_identities) (*database.Users, error) {
+	u, err := f.CreateUser(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	linked := *identity
+	linked.User_id = u.Id
+	if _, err := f.linkOAuthIdentity(linked); err != nil {
+		delete(f.users, u.Id)
+		return nil, err
+	}
+	return u, nil
+}
+
+// --- ENTITLEMENTS ---
+
+func (f *Fake) GetEntitlement(ctx context.Context, userID string) (*database.Entitlements, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	e, ok := f.entitlements[userID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &e, nil
+}
+
+func (f *Fake) GrantPremium(ctx context.Context, userID string, duration time.Duration, source string) (*database.Entitlements, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.grantPremium(userID, duration, source), nil
+}
+
+// grantPremium extends the user's premium access from now or from when it ends. The
+// caller holds f.mu.
+func (f *Fake) grantPremium(userID string, duration time.Duration, source string) *database.Entitlements {
+	e, ok := f.entitlements[userID]
+	if !ok {
+		e = database.Entitlements{User_id: userID, Created_at: now()}
+	}
+	start := now()
+	if e.Premium_until.After(start) {
+		start = e.Premium_until
+	}
+	e.Premium_until, e.Source, e.Updated_at = start.Add(duration), source, now()
+	f.entitlements[userID] = e
+	return &e
+}
+
+func (f *Fake) GetPremiumUntil(ctx context.Context, userID string) (*time.Time, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.premiumUntil(userID), nil
+}
+
+// premiumUntil returns the later of the user's entitlement and their live store
+// subscriptions, or nil if they never had premium. The caller holds f.mu.
+func (f *Fake) premiumUntil(userID string) *time.Time {
+	var until *time.Time
+	later := func(t time.Time) {
+		if until == nil || t.After(*until) {
+			until = &t
+		}
+	}
+	if e, ok := f.entitlements[userID]; ok {
+		later(e.Premium_until)
+	}
+	for _, sub := range f.subscriptions {
+		switch sub.Status {
+		case database.Subscriptions_status_active, database.Subscriptions_status_grace, database.Subscriptions_status_canceled:
+			if sub.User_id == userID {
+				later(sub.Expires_at)
+			}
+		}
+	}
+	return until
+}
+
+// --- QUOTAS ---
+
+// GetQuotaUsage reports usage against database.DefaultQuotas; the fake doesn't enforce them
+func (f *Fake) GetQuotaUsage(ctx context.Context, userID string) (*database.QuotaUsage, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	usage := &database.QuotaUsage{Tier: database.TierFree, PremiumUntil: f.premiumUntil(userID)}
+	if usage.PremiumUntil != nil && usage.PremiumUntil.After(time.Now()) {
+		usage.Tier = database.TierPremium
+	}
+	used := map[database.QuotaResource]int64{}
+	for _, e := range f.exercises {
+		if e.Created_by != nil && *e.Created_by == userID {
+			used[database.QuotaCustomExercises]++
+		}
+	}
+	for _, w := range f.workouts {
+		if w.User_id == userID {
+			used[database.QuotaWorkouts]++
+		}
+	}
+	for _, p := range f.progressPhotos {
+		if p.User_id == userID {
+			used[database.QuotaPhotoStorage] += p.Size_bytes
+		}
+	}
+	for _, resource := range database.QuotaResources {
+		usage.Resources = append(usage.Resources, database.ResourceUsage{
+			Resource: resource,
+			Used:     used[resource],
+			Limit:    database.DefaultQuotas[usage.Tier][resource],
+		})
+	}
+	return usage, nil
+}
+
+// --- SUBSCRIPTIONS ---
+
+func (f *Fake) UpsertSubscription(ctx context.Context, sub *database.Subscriptions) (*database.Subscriptions, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	s := *sub
+	if existing := f.findSubscription(s.Platform, s.Original_transaction_id); existing != nil {
+		if existing.User_id != s.User_id {
+			return nil, database.ErrSubscriptionOwned
+		}
+		s.Id, s.Created_at = existing.Id, existing.Created_at
+	} else {
+		s.Id, s.Created_at = newID(s.Id), now()
+	}
+	s.Updated_at = now()
+	f.subscriptions[s.Id] = s
+	return &s, nil
+}
+
+func (f *Fake) UpdateSubscriptionState(ctx context.Context, sub *database.Subscriptions) (*database.Subscriptions, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	existing := f.findSubscription(sub.Platform, sub.Original_transaction_id)
+	if existing == nil {
+		return nil, sql.ErrNoRows
+	}
+	s := *existing
+	if sub.Product_id != "" {
+		s.Product_id = sub.Product_id
+	}
+	s.Status, s.Expires_at, s.Auto_renew, s.Updated_at = sub.Status, sub.Expires_at, sub.Auto_renew, now()
+	f.subscriptions[s.Id] = s
+	return &s, nil
+}
+
+// findSubscription returns the store's subscription with the transaction id. The caller
+// holds f.mu.
+func (f *Fake) findSubscription(platform database.Subscriptions_platform, transactionID string) *database.Subscriptions {
+	for _, s := range f.subscriptions {
+		if s.Platform == platform && s.Original_transaction_id == transactionID {
+			return &s
+		}
+	}
+	return nil
+}
+
+func (f *Fake) ListSubscriptionsByUser(ctx context.Context, userID string) ([]database.Subscriptions, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	subs := []database.Subscriptions{}
+	for _, s := range f.subscriptions {
+		if s.User_id == userID {
+			subs = append(subs, s)
+		}
+	}
+	sort.Slice(subs, func(i, j int) bool { return subs[i].Expires_at.After(subs[j].Expires_at) })
+	return subs, nil
+}
+
+// --- REFERRALS ---
+
+func (f *Fake) GetReferralCode(ctx context.Context, userID string) (*database.Referral_codes, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	code, ok := f.referralCodes[userID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &code, nil
+}
+
+func (f *Fake) CreateReferralCode(ctx context.Context, userID, code string) (*database.Referral_codes, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, existing := range f.referralCodes {
+		if existing.User_id == userID || existing.Code == code {
+			return nil, &database.ConstraintError{Kind: database.ErrDuplicate, Table: "referral_codes", Constraint: "referral_codes_code_key", Column: "code"}
+		}
+	}
+	created := database.Referral_codes{User_id: userID, Code: code, Created_at: now()}
+	f.referralCodes[userID] = created
+	return &created, nil
+}
+
+func (f *Fake) RecordReferral(ctx context.Context, code, referredUserID string, rewardWeeks int) (*database.Referrals, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	referrerID := ""
+	for _, c := range f.referralCodes {
+		if c.Code == code {
+			referrerID = c.User_id
+		}
+	}
+	if referrerID == "" {
+		return nil, sql.ErrNoRows
+	}
+	if referrerID == referredUserID {
+		return nil, database.ErrSelfReferral
+	}
+	for _, r := range f.referrals {
+		if r.Referred_user_id == referredUserID {
+			return nil, fmt.Errorf("failed to record referral: %w", &database.ConstraintError{Kind: database.ErrDuplicate, Table: "referrals", Constraint: "referrals_referred_user_id_key", Column: "referred_user_id"})
+		}
+	}
+	referral := database.Referrals{Id: newID(""), Referrer_id: referrerID, Referred_user_id: referredUserID, Code: code, Reward_weeks: rewardWeeks, Created_at: now()}
+	f.referrals[referral.Id] = referral
+	if rewardWeeks > 0 {
+		reward := time.Duration(rewardWeeks) * 7 * 24 * time.Hour
+		f.grantPremium(referrerID, reward, "referral")
+		f.grantPremium(referredUserID, reward, "referral")
+	}
+	return &referral, nil
+}
+
+func (f *Fake) ListReferralsByReferrer(ctx context.Context, referrerID string) ([]database.Referrals, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	referrals := []database.Referrals{}
+	for _, r := range f.referrals {
+		if r.Referrer_id == referrerID {
+			referrals = append(referrals, r)
+		}
+	}
+	sort.Slice(referrals, func(i, j int) bool { return referrals[i].Created_at.After(referrals[j].Created_at) })
+	return referrals, nil
+}
+
+// --- API KEYS ---
+
+func (f *Fake) CreateAPIKey(ctx context.Context, key *database.Api_keys) (*database.Api_keys, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	k := *key
+	k.Id, k.Created_at, k.Last_used_at = newID(k.Id), now(), nil
+	f.apiKeys[k.Id] = k
+	return &k, nil
+}
+
+func (f *Fake) AuthenticateAPIKey(ctx context.Context, keyHash string) (*database.Api_keys, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for id, k := range f.apiKeys {
+		if k.Key_hash != keyHash {
+			continue
+		}
+		if u, ok := f.users[k.User_id]; ok && u.Disabled_at != nil {
+			return nil, sql.ErrNoRows
+		}
+		used := now()
+		k.Last_used_at = &used
+		f.apiKeys[id] = k
+		return &k, nil
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (f *Fake) ListAPIKeysByUser(ctx context.Context, userID string) ([]database.Api_keys, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	keys := []database.Api_keys{}
+	for _, k := range f.apiKeys {
+		if k.User_id == userID {
+			keys = append(keys, k)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Created_at.After(keys[j].Created_at) })
+	return keys, nil
+}
+
+func (f *Fake) DeleteAPIKey(ctx context.Context, id, userID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if k, ok := f.apiKeys[id]; !ok || k.User_id != userID {
+		return sql.ErrNoRows
+	}
+	delete(f.apiKeys, id)
+	return nil
+}
+
+// --- DATA EXPORTS ---
+
+// CollectUserData exports the tables the fake keeps, under the section names Postgres uses
+func (f *Fake) CollectUserData(ctx context.Context, userID string, includeVault bool) (map[string]json.RawMessage, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	u, ok := f.users[userID]
+	profile := []map[string]interface{}{}
+	if ok {
+		profile = append(profile, map[string]interface{}{
+			"id": u.Id, "email": u.Email, "username": u.Username, "first_name": u.First_name, "last_name": u.Last_name,
+			"timezone": u.Timezone, "created_at": u.Created_at, "updated_at": u.Updated_at,
+		})
+	}
+	sections := map[string]interface{}{"profile": profile}
+	sections["workouts"] = ownedBy(f.workouts, userID, func(w database.Workouts) string { return w.User_id })
+	sections["workout_sessions"] = ownedBy(f.workoutSessions, userID, func(s database.Workout_sessions) string { return s.User_id })
+	sections["programs"] = ownedBy(f.programs, userID, func(p database.Programs) string { return p.User_id })
+	sections["body_metrics"] = ownedBy(f.bodyMetrics, userID, func(m database.Body_metrics) string { return m.User_id })
+	sections["nutrition_logs"] = ownedBy(f.nutritionLogs, userID, func(l database.Nutrition_logs) string { return l.User_id })
+	sections["subscriptions"] = ownedBy(f.subscriptions, userID, func(s database.Subscriptions) string { return s.User_id })
+	sections["reminders"] = ownedBy(f.reminders, userID, func(r database.Reminders) string { return r.User_id })
+	sections["training_maxes"] = ownedBy(f.trainingMaxes, userID, func(tm database.Training_maxes) string { return tm.User_id })
+	photos, vaulted := []database.Progress_photos{}, []database.Progress_photos{}
+	for _, p := range f.progressPhotos {
+		if p.User_id != userID || p.Status != database.Progress_photos_status_ready {
+			continue
+		}
+		if p.Vaulted {
+			vaulted = append(vaulted, p)
+		} else {
+			photos = append(photos, p)
+		}
+	}
+	sections["progress_photos"] = photos
+	if includeVault {
+		sections["vaulted_progress_photos"] = vaulted
+	}
+
+	data := make(map[string]json.RawMessage, len(sections))
+	for name, rows := range sections {
+		raw, err := json.Marshal(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to export %s: %w", name, err)
+		}
+		data[name] = raw
+	}
+	return data, nil
+}
+
+// ownedBy returns the rows of a table whose owner is userID, oldest first
+func ownedBy[R any](table map[string]R, userID string, owner func(R) string) []R {
+	rows := []R{}
+	for _, row := range table {
+		if owner(row) == userID {
+			rows = append(rows, row)
+		}
+	}
+	_ = list(&rows, database.ListOptions{Sort: "created_at", Limit: len(rows) + 1})
+	return rows
+}
+
+func (f *Fake) CreateDataExport(ctx context.Context, userID string, includeVault bool) (*database.Data_exports, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	export := database.Data_exports{Id: newID(""), User_id: userID, Status: database.Data_exports_status_pending, Include_vault: includeVault, Created_at: now()}
+	f.dataExports[export.Id] = export
+	return &export, nil
+}
+
+func (f *Fake) GetDataExport(ctx context.Context, id, userID string) (*database.Data_exports, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	export, ok := f.dataExports[id]
+	if !ok || export.User_id != userID {
+		return nil, sql.ErrNoRows
+	}
+	return &export, nil
+}
+
+func (f *Fake) GetPendingDataExport(ctx context.Context, userID string) (*database.Data_exports, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var latest *database.Data_exports
+	for _, export := range f.dataExports {
+		if export.User_id == userID && export.Status == database.Data_exports_status_pending &&
+			(latest == nil || export.Created_at.After(latest.Created_at)) {
+			latest = &export
+		}
+	}
+	if latest == nil {
+		return nil, sql.ErrNoRows
+	}
+	return latest, nil
+}
+
+func (f *Fake) CompleteDataExport(ctx context.Context, id, storageKey string, sizeBytes int64, expiresAt time.Time) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if export, ok := f.dataExports[id]; ok {
+		completed := now()
+		export.Status, export.Storage_key, export.Size_bytes = database.Data_exports_status_ready, storageKey, sizeBytes
+		export.Completed_at, export.Expires_at = &completed, &expiresAt
+		f.dataExports[id] = export
+	}
+	return nil
+}
+
+func (f *Fake) FailDataExport(ctx context.Context, id, reason string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if export, ok := f.dataExports[id]; ok {
+		completed := now()
+		export.Status, export.Error, export.Completed_at = database.Data_exports_status_failed, reason, &completed
+		f.dataExports[id] = export
+	}
+	return nil
+}
+
+// --- ACCOUNT DELETIONS ---
+
+func (f *Fake) ScheduleAccountDeletion(ctx context.Context, userID, requestedIP string, purgeAfter time.Time) (*database.Account_deletions, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if pending := f.pendingAccountDeletion(userID); pending != nil {
+		return pending, nil
+	}
+	deletion := database.Account_deletions{
+		Id: newID(""), User_id: userID, Status: database.Account_deletions_status_pending,
+		Requested_ip: requestedIP, Requested_at: now(), Purge_after: purgeAfter, Purged_rows: json.RawMessage("{}"),
+	}
+	f.accountDeletions[deletion.Id] = deletion
+	return &deletion, nil
+}
+
+func (f *Fake) GetPendingAccountDeletion(ctx context.Context, userID string) (*database.Account_deletions, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if pending := f.pendingAccountDeletion(userID); pending != nil {
+		return pending, nil
+	}
+	return nil, sql.ErrNoRows
+}
+
+// pendingAccountDeletion returns the user's pending deletion, if any. The caller holds f.mu.
+func (f *Fake) pendingAccountDeletion(userID string) *database.Account_deletions {
+	for _, d := range f.accountDeletions {
+		if d.User_id == userID && d.Status == database.Account_deletions_status_pending {
+			return &d
+		}
+	}
+	return nil
+}
+
+func (f *Fake) CancelAccountDeletion(ctx context.Context, userID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	pending := f.pendingAccountDeletion(userID)
+	if pending == nil {
+		return sql.ErrNoRows
+	}
+	canceled := now()
+	pending.Status, pending.Canceled_at = database.Account_deletions_status_canceled, &canceled
+	f.accountDeletions[pending.Id] = *pending
+	return nil
+}
+
+func (f *Fake) ListDueAccountDeletions(ctx context.Context, now time.Time, limit int) ([]database.Account_deletions, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	due := []database.Account_deletions{}
+	for _, d := range f.accountDeletions {
+		if d.Status == database.Account_deletions_status_pending && !d.Purge_after.After(now) && !f.held(d.User_id) {
+			due = append(due, d)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].Purge_after.Before(due[j].Purge_after) })
+	return due[:min(limit, len(due))], nil
+}
+
+// PurgeAccount deletes the user with their workouts, sessions and programs, like Postgres
+func (f *Fake) PurgeAccount(ctx context.Context, deletionID string) (*database.PurgedAccount, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	d, ok := f.accountDeletions[deletionID]
+	if !ok || d.Status != database.Account_deletions_status_pending || d.Purge_after.After(time.Now()) || f.held(d.User_id) {
+		return nil, sql.ErrNoRows
+	}
+
+	purged := &database.PurgedAccount{UserID: d.User_id}
+	for id, w := range f.workouts {
+		if w.User_id == d.User_id {
+			purged.WorkoutIDs = append(purged.WorkoutIDs, id)
+		}
+	}
+	for _, export := range f.dataExports {
+		if export.User_id == d.User_id && export.Storage_key != "" {
+			purged.StorageKeys = append(purged.StorageKeys, export.Storage_key)
+		}
+	}
+	for _, p := range f.progressPhotos {
+		if p.User_id != d.User_id {
+			continue
+		}
+		for _, key := range []string{p.Storage_key, p.Thumbnail_key} {
+			if key != "" {
+				purged.StorageKeys = append(purged.StorageKeys, key)
+			}
+		}
+	}
+
+	counts := f.deleteOwnedBy(d.User_id)
+	if _, ok := f.users[d.User_id]; ok {
+		delete(f.users, d.User_id)
+		counts["users"] = 1
+	}
+	completed := now()
+	d.Status, d.Completed_at, d.Attempts, d.Last_error = database.Account_deletions_status_completed, &completed, d.Attempts+1, ""
+	d.Purged_rows, _ = json.Marshal(counts)
+	f.accountDeletions[d.Id] = d
+	return purged, nil
+}
+
+func (f *Fake) RecordAccountDeletionFailure(ctx context.Context, deletionID, reason string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if d, ok := f.accountDeletions[deletionID]; ok {
+		d.Attempts, d.Last_error = d.Attempts+1, reason
+		f.accountDeletions[deletionID] = d
+	}
+	return nil
+}
+
+func (f *Fake) GetAccountDeletion(ctx context.Context, id string) (*database.Account_deletions, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	d, ok := f.accountDeletions[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &d, nil
+}
+
+// --- RETENTION AND LEGAL HOLDS ---
+
+// held reports whether the user is under an active legal hold, personally or through an
+// organization they belong to. The caller holds f.mu.
+func (f *Fake) held(userID string) bool {
+	for _, h := range f.legalHolds {
+		if h.Released_at != nil {
+			continue
+		}
+		if h.User_id != nil && *h.User_id == userID {
+			return true
+		}
+		if _, member := f.orgMembers[memberKey(h.Organization_id, userID)]; h.User_id == nil && member {
+			return true
+		}
+	}
+	return false
+}
+
+// PurgeRetainedData deletes the fake's sessions, body measurements and progress photos older
+// than the cutoff, skipping held users
+func (f *Fake) PurgeRetainedData(ctx context.Context, category string, olderThan time.Time, limit int) (*database.RetainedDataPurge, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	purge := &database.RetainedDataPurge{}
+	purgeable := func(userID string, at time.Time) bool {
+		return purge.Rows < int64(limit) && at.Before(olderThan) && !f.held(userID)
+	}
+	switch category {
+	case database.RetentionSessions:
+		for id, s := range f.workoutSessions {
+			if purgeable(s.User_id, s.Started_at) {
+				delete(f.workoutSessions, id)
+				purge.Rows++
+			}
+		}
+	case database.RetentionBodyMetrics:
+		for id, m := range f.bodyMetrics {
+			if purgeable(m.User_id, m.Recorded_at) {
+				delete(f.bodyMetrics, id)
+				purge.Rows++
+			}
+		}
+	case database.RetentionPhotos:
+		for id, p := range f.progressPhotos {
+			if !purgeable(p.User_id, p.Taken_at) {
+				continue
+			}
+			delete(f.progressPhotos, id)
+			purge.Rows++
+			for _, key := range []string{p.Storage_key, p.Thumbnail_key} {
+				if key != "" {
+					purge.StorageKeys = append(purge.StorageKeys, key)
+				}
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unknown retention category %q", category)
+	}
+	return purge, nil
+}
+
+func (f *Fake) CreateLegalHold(ctx context.Context, orgID string, userID *string, reason, createdBy string) (*database.Legal_holds, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.organizations[orgID]; !ok {
+		return nil, &database.ConstraintError{Kind: database.ErrReferenceNotFound, Table: "legal_holds", Constraint: "legal_holds_organization_id_fkey", Column: "organization_id"}
+	}
+	hold := database.Legal_holds{Id: newID(""), Organization_id: orgID, User_id: userID, Reason: reason, Created_by: createdBy, Created_at: now()}
+	f.legalHolds[hold.Id] = hold
+	return &hold, nil
+}
+
+func (f *Fake) ListLegalHolds(ctx context.Context, orgID string) ([]database.Legal_holds, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	holds := []database.Legal_holds{}
+	for _, h := range f.legalHolds {
+		if h.Organization_id == orgID {
+			holds = append(holds, h)
+		}
+	}
+	sort.Slice(holds, func(i, j int) bool {
+		if (holds[i].Released_at == nil) != (holds[j].Released_at == nil) {
+			return holds[i].Released_at == nil
+		}
+		return holds[i].Created_at.After(holds[j].Created_at)
+	})
+	return holds, nil
+}
+
+func (f *Fake) ReleaseLegalHold(ctx context.Context, orgID, holdID, releasedBy string) (*database.Legal_holds, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	h, ok := f.legalHolds[holdID]
+	if !ok || h.Organization_id != orgID || h.Released_at != nil {
+		return nil, sql.ErrNoRows
+	}
+	released := now()
+	h.Released_at, h.Released_by = &released, &releasedBy
+	f.legalHolds[holdID] = h
+	return &h, nil
+}
+
+// --- DATA SUBJECT REQUESTS ---
+
+func (f *Fake) GetUserRole(ctx context.Context, userID string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	u, ok := f.users[userID]
+	if !ok {
+		return "", sql.ErrNoRows
+	}
+	return string(u.Role), nil
+}
+
+func (f *Fake) CreateDataSubjectRequest(ctx context.Context, req *database.Data_subject_requests) (*database.Data_subject_requests, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	r := *req
+	r.Id, r.Status = newID(r.Id), database.Data_subject_requests_status_received
+	r.Created_at, r.Updated_at = now(), now()
+	r.Data_export_id, r.Account_deletion_id, r.Resolved_by, r.Completed_at = nil, nil, nil, nil
+	f.dataSubjectRequests[r.Id] = r
+	return &r, nil
+}
+
+func (f *Fake) GetDataSubjectRequest(ctx context.Context, id string) (*database.Data_subject_requests, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	r, ok := f.dataSubjectRequests[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &r, nil
+}
+
+// ListDataSubjectRequests lists requests due first unless opts sort them otherwise
+func (f *Fake) ListDataSubjectRequests(ctx context.Context, status string, overdue bool, opts database.ListOptions) ([]database.Data_subject_requests, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	requests := []database.Data_subject_requests{}
+	for _, r := range f.dataSubjectRequests {
+		open := r.Status == database.Data_subject_requests_status_received || r.Status == database.Data_subject_requests_status_in_progress
+		if (status == "" || string(r.Status) == status) && (!overdue || open && r.Due_at.Before(time.Now())) {
+			requests = append(requests, r)
+		}
+	}
+	if opts.Sort == "" {
+		opts.Sort = "due_at"
+	}
+	return requests, list(&requests, opts)
+}
+
+func (f *Fake) StartDataSubjectRequest(ctx context.Context, id, resolvedBy string, exportID, deletionID *string) (*database.Data_subject_requests, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	r, ok := f.dataSubjectRequests[id]
+	if !ok || (r.Status != database.Data_subject_requests_status_received && r.Status != database.Data_subject_requests_status_in_progress) {
+		return nil, sql.ErrNoRows
+	}
+	r.Status, r.Resolved_by, r.Updated_at = database.Data_subject_requests_status_in_progress, &resolvedBy, now()
+	if exportID != nil {
+		r.Data_export_id = exportID
+	}
+	if deletionID != nil {
+		r.Account_deletion_id = deletionID
+	}
+	f.dataSubjectRequests[id] = r
+	return &r, nil
+}
+
+func (f *Fake) RejectDataSubjectRequest(ctx context.Context, id, resolvedBy, reason string) (*database.Data_subject_requests, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	r, ok := f.dataSubjectRequests[id]
+	if !ok || r.Status != database.Data_subject_requests_status_received {
+		return nil, sql.ErrNoRows
+	}
+	if r.Notes == "" {
+		r.Notes = reason
+	} else {
+		r.Notes += "\n" + reason
+	}
+	completed := now()
+	r.Status, r.Resolved_by, r.Completed_at, r.Updated_at = database.Data_subject_requests_status_rejected, &resolvedBy, &completed, completed
+	f.dataSubjectRequests[id] = r
+	return &r, nil
+}
+
+func (f *Fake) SyncDataSubjectRequests(ctx context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for id, r := range f.dataSubjectRequests {
+		if r.Status != database.Data_subject_requests_status_in_progress {
+			continue
+		}
+		var completed *time.Time
+		if r.Data_export_id != nil {
+			if e, ok := f.dataExports[*r.Data_export_id]; ok && e.Status == database.Data_exports_status_ready {
+				completed = e.Completed_at
+			}
+		}
+		if r.Account_deletion_id != nil {
+			if d, ok := f.accountDeletions[*r.Account_deletion_id]; ok && d.Status == database.Account_deletions_status_completed {
+				completed = d.Completed_at
+			}
+		}
+		if completed != nil {
+			r.Status, r.Completed_at, r.Updated_at = database.Data_subject_requests_status_completed, completed, now()
+			f.dataSubjectRequests[id] = r
+		}
+	}
+	return nil
+}
+
+// --- ADMIN USER MANAGEMENT ---
+
+func (f *Fake) CountUserResources(ctx context.Context, userID string) (*database.UserResourceCounts, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	counts := &database.UserResourceCounts{
+		Workouts:        len(ownedBy(f.workouts, userID, func(w database.Workouts) string { return w.User_id })),
+		WorkoutSessions: len(ownedBy(f.workoutSessions, userID, func(s database.Workout_sessions) string { return s.User_id })),
+		Programs:        len(ownedBy(f.programs, userID, func(p database.Programs) string { return p.User_id })),
+		ProgressPhotos:  len(ownedBy(f.progressPhotos, userID, func(p database.Progress_photos) string { return p.User_id })),
+		BodyMetrics:     len(ownedBy(f.bodyMetrics, userID, func(m database.Body_metrics) string { return m.User_id })),
+		NutritionLogs:   len(ownedBy(f.nutritionLogs, userID, func(l database.Nutrition_logs) string { return l.User_id })),
+		APIKeys:         len(ownedBy(f.apiKeys, userID, func(k database.Api_keys) string { return k.User_id })),
+		Webhooks:        len(ownedBy(f.webhooks, userID, func(w database.Webhooks) string { return w.User_id })),
+		Devices:         len(ownedBy(f.devices, userID, func(d database.Devices) string { return d.User_id })),
+	}
+	for _, e := range f.exercises {
+		if e.Created_by != nil && *e.Created_by == userID {
+			counts.CustomExercises++
+		}
+	}
+	for _, s := range f.userSessions {
+		if s.User_id == userID && s.Revoked_at == nil && s.Expires_at.After(time.Now()) {
+			counts.ActiveSessions++
+		}
+	}
+	for _, m := range f.orgMembers {
+		if m.User_id == userID {
+			counts.Organizations++
+		}
+	}
+	return counts, nil
+}
+
+func (f *Fake) CreateAdminAuditLog(ctx context.Context, entry *database.Admin_audit_log) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	e := *entry
+	e.Id, e.Created_at = newID(e.Id), now()
+	f.adminAuditLog = append(f.adminAuditLog, e)
+	return nil
+}
+
+func (f *Fake) ListAdminAuditLog(ctx context.Context, targetUserID string, limit, offset int) ([]database.Admin_audit_log, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	entries := []database.Admin_audit_log{}
+	for i := len(f.adminAuditLog) - 1; i >= 0; i-- {
+		e := f.adminAuditLog[i]
+		if targetUserID == "" || e.Target_user_id != nil && strings.EqualFold(*e.Target_user_id, targetUserID) {
+			entries = append(entries, e)
+		}
+	}
+	start := min(max(offset, 0), len(entries))
+	return entries[start:min(start+limit, len(entries))], nil
+}
@@ -0,0 +1,537 @@
+package dbtest
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"fitness-hack/internal/database"
+)
+
+// integrationTables are connections to other services and what comes in and goes out
+// through them: imported body measurements, webhooks and the events they're sent
+type integrationTables struct {
+	integrations      map[string]database.Integrations
+	bodyMetrics       map[string]database.Body_metrics
+	webhooks          map[string]database.Webhooks
+	webhookDeliveries map[string]database.Webhook_deliveries
+	integrationEvents map[string]database.Integration_events
+}
+
+func newIntegrationTables() integrationTables {
+	return integrationTables{
+		integrations:      map[string]database.Integrations{},
+		bodyMetrics:       map[string]database.Body_metrics{},
+		webhooks:          map[string]database.Webhooks{},
+		webhookDeliveries: map[string]database.Webhook_deliveries{},
+		integrationEvents: map[string]database.Integration_events{},
+	}
+}
+
+// --- INTEGRATIONS ---
+
+// findIntegration returns the user's connection to the provider, or nil. The caller holds
+// f.mu.
+func (f *Fake) findIntegration(userID string, provider database.Integrations_provider) *database.Integrations {
+	for _, i := range f.integrations {
+		if i.User_id == userID && i.Provider == provider {
+			return &i
+		}
+	}
+	return nil
+}
+
+func (f *Fake) UpsertIntegration(ctx context.Context, integration *database.Integrations) (*database.Integrations, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, existing := range f.integrations {
+		if existing.Provider == integration.Provider && existing.External_user_id == integration.External_user_id && existing.User_id != integration.User_id {
+			return nil, database.ErrIntegrationInUse
+		}
+	}
+	i := *integration
+	if existing := f.findIntegration(i.User_id, i.Provider); existing != nil {
+		i.Id, i.Created_at, i.Last_synced_at = existing.Id, existing.Created_at, existing.Last_synced_at
+	} else {
+		i.Id, i.Created_at, i.Last_synced_at = newID(""), now(), nil
+	}
+	i.Last_error, i.Updated_at = "", now()
+	f.integrations[i.Id] = i
+	return &i, nil
+}
+
+func (f *Fake) GetIntegration(ctx context.Context, userID, provider string) (*database.Integrations, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	i := f.findIntegration(userID, database.Integrations_provider(provider))
+	if i == nil {
+		return nil, sql.ErrNoRows
+	}
+	return i, nil
+}
+
+func (f *Fake) GetIntegrationByExternalUser(ctx context.Context, provider, externalUserID string) (*database.Integrations, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, i := range f.integrations {
+		if string(i.Provider) == provider && i.External_user_id == externalUserID {
+			return &i, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (f *Fake) ListIntegrationsByUser(ctx context.Context, userID string) ([]database.Integrations, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	integrations := []database.Integrations{}
+	for _, i := range f.integrations {
+		if i.User_id == userID {
+			integrations = append(integrations, i)
+		}
+	}
+	sort.Slice(integrations, func(i, j int) bool { return integrations[i].Provider < integrations[j].Provider })
+	return integrations, nil
+}
+
+func (f *Fake) ListIntegrationsDueForSync(ctx context.Context, provider string, syncedBefore time.Time, limit int) ([]database.Integrations, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	integrations := []database.Integrations{}
+	for _, i := range f.integrations {
+		if string(i.Provider) == provider && (i.Last_synced_at == nil || i.Last_synced_at.Before(syncedBefore)) {
+			integrations = append(integrations, i)
+		}
+	}
+	sort.Slice(integrations, func(i, j int) bool {
+		a, b := integrations[i].Last_synced_at, integrations[j].Last_synced_at
+		return a == nil && b != nil || a != nil && b != nil && a.Before(*b)
+	})
+	return integrations[:min(limit, len(integrations))], nil
+}
+
+func (f *Fake) UpdateIntegrationTokens(ctx context.Context, id, accessToken, refreshToken string, expiresAt time.Time) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if i, ok := f.integrations[id]; ok {
+		i.Access_token, i.Refresh_token, i.Expires_at, i.Updated_at = accessToken, refreshToken, expiresAt, now()
+		f.integrations[id] = i
+	}
+	return nil
+}
+
+func (f *Fake) MarkIntegrationSynced(ctx context.Context, id string, syncedAt time.Time, syncErr string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if i, ok := f.integrations[id]; ok {
+		if syncErr == "" {
+			i.Last_synced_at = &syncedAt
+		}
+		i.Last_error, i.Updated_at = syncErr, now()
+		f.integrations[id] = i
+	}
+	return nil
+}
+
+func (f *Fake) DeleteIntegration(ctx context.Context, userID, provider string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	i := f.findIntegration(userID, database.Integrations_provider(provider))
+	if i == nil {
+		return sql.ErrNoRows
+	}
+	delete(f.integrations, i.Id)
+	return nil
+}
+
+// importedSession returns the user's session imported from source with the external ID.
+// The caller holds f.mu.
+func (f *Fake) importedSession(userID, source, externalID string) *database.Workout_sessions {
+	for _, s := range f.workoutSessions {
+		if externalID != "" && s.User_id == userID && s.Source == source && s.External_id == externalID {
+			return &s
+		}
+	}
+	return nil
+}
+
+func (f *Fake) UpsertImportedSession(ctx context.Context, ws *database.Workout_sessions) (*database.Workout_sessions, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	existing := f.importedSession(ws.User_id, ws.Source, ws.External_id)
+	if existing == nil {
+		return f.insertSession(*ws), nil
+	}
+	s := *existing
+	moved := !s.Started_at.Equal(ws.Started_at) || !equalFloat(s.Start_latitude, ws.Start_latitude) || !equalFloat(s.Start_longitude, ws.Start_longitude)
+	s.Name, s.Started_at, s.Completed_at, s.Duration_minutes, s.Notes = ws.Name, ws.Started_at, ws.Completed_at, ws.Duration_minutes, ws.Notes
+	s.Exercise_id, s.Duration_seconds, s.Distance_meters = ws.Exercise_id, ws.Duration_seconds, ws.Distance_meters
+	s.Avg_heart_rate, s.Max_heart_rate = ws.Avg_heart_rate, ws.Max_heart_rate
+	s.Start_latitude, s.Start_longitude, s.Updated_at = ws.Start_latitude, ws.Start_longitude, now()
+	if moved {
+		s.Weather_checked_at = nil
+	}
+	f.workoutSessions[s.Id] = s
+	return &s, nil
+}
+
+// insertSession stores a new session with what Postgres defaults. The caller holds f.mu.
+func (f *Fake) insertSession(s database.Workout_sessions) *database.Workout_sessions {
+	s.Id, s.Created_at, s.Updated_at = "", time.Time{}, time.Time{}
+	created(&s.Id, &s.Created_at, &s.Updated_at, &s.Version)
+	if s.Plan == nil {
+		s.Plan = []byte("[]")
+	}
+	f.workoutSessions[s.Id] = s
+	return &s
+}
+
+// equalFloat reports whether two nullable numbers are the same, NULLs included
+func equalFloat(a, b *float64) bool {
+	return a == nil && b == nil || a != nil && b != nil && *a == *b
+}
+
+func (f *Fake) DeleteImportedSession(ctx context.Context, userID, source, externalID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	s := f.importedSession(userID, source, externalID)
+	if s == nil {
+		return sql.ErrNoRows
+	}
+	delete(f.workoutSessions, s.Id)
+	return nil
+}
+
+// FindOrCreateExercise finds the exercise by name, or creates it with muscleGroup as its
+// primary muscle if the fake has seen that muscle group
+func (f *Fake) FindOrCreateExercise(ctx context.Context, name, muscleGroup, equipment string) (*database.Exercises, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var found *database.Exercises
+	for _, e := range f.exercises {
+		if strings.EqualFold(e.Name, name) && (found == nil || e.Created_at.Before(found.Created_at)) {
+			found = &e
+		}
+	}
+	if found != nil {
+		return found, nil
+	}
+	e := database.Exercises{Name: name, Equipment: &equipment}
+	created(&e.Id, &e.Created_at, &e.Updated_at, &e.Version)
+	f.exercises[e.Id] = e
+	if group, ok := f.muscleGroups[database.MuscleSlug(muscleGroup)]; ok {
+		f.exerciseMuscles[e.Id] = []database.ExerciseMuscle{{Exercise_id: e.Id, Slug: group.Slug, Name: group.Name, Role: database.Exercise_muscle_groups_role_primary}}
+	}
+	return &e, nil
+}
+
+// --- HEALTH AND SET IMPORTS ---
+
+// isDuplicateImport reports whether the session was imported before or the user has a
+// session starting within matchWindow of it. The caller holds f.mu.
+func (f *Fake) isDuplicateImport(userID string, ws database.Workout_sessions, matchWindow time.Duration) bool {
+	if f.importedSession(userID, ws.Source, ws.External_id) != nil {
+		return true
+	}
+	for _, s := range f.workoutSessions {
+		if s.User_id == userID && !s.Started_at.Before(ws.Started_at.Add(-matchWindow)) && !s.Started_at.After(ws.Started_at.Add(matchWindow)) {
+			return true
+		}
+	}
+	return false
+}
+
+// importSession stores the session for the user unless it is a duplicate, returning its ID
+// or "". The caller holds f.mu.
+func (f *Fake) importSession(userID string, ws database.Workout_sessions, matchWindow time.Duration) string {
+	if f.isDuplicateImport(userID, ws, matchWindow) {
+		return ""
+	}
+	ws.User_id, ws.Plan, ws.Weather_checked_at = userID, nil, nil
+	return f.insertSession(ws).Id
+}
+
+func (f *Fake) ImportHealthRecords(ctx context.Context, userID string, sessions []database.Workout_sessions, metrics []database.Body_metrics, matchWindow time.Duration) (*database.HealthImportResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	result := &database.HealthImportResult{}
+	for _, ws := range sessions {
+		if f.importSession(userID, ws, matchWindow) != "" {
+			result.SessionsImported++
+		} else {
+			result.SessionsSkipped++
+		}
+	}
+	for _, metric := range metrics {
+		if f.hasBodyMetric(userID, metric.Metric, metric.Recorded_at) {
+			result.MetricsSkipped++
+			continue
+		}
+		m := database.Body_metrics{
+			Id: newID(""), User_id: userID, Metric: metric.Metric, Measured_value: metric.Measured_value,
+			Recorded_at: metric.Recorded_at, Source: metric.Source, Created_at: now(),
+		}
+		f.bodyMetrics[m.Id] = m
+		result.MetricsImported++
+	}
+	return result, nil
+}
+
+// hasBodyMetric reports whether the user has a measurement of the kind recorded at the
+// time. The caller holds f.mu.
+func (f *Fake) hasBodyMetric(userID string, metric database.Body_metrics_metric, at time.Time) bool {
+	for _, m := range f.bodyMetrics {
+		if m.User_id == userID && m.Metric == metric && m.Recorded_at.Equal(at) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *Fake) ListBodyMetrics(ctx context.Context, userID string, opts database.ListBodyMetricsOpts) ([]database.Body_metrics, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	metrics := []database.Body_metrics{}
+	for _, m := range f.bodyMetrics {
+		if m.User_id == userID && (opts.Metric == "" || string(m.Metric) == opts.Metric) &&
+			(opts.From.IsZero() || !m.Recorded_at.Before(opts.From)) && (opts.To.IsZero() || m.Recorded_at.Before(opts.To)) {
+			metrics = append(metrics, m)
+		}
+	}
+	if opts.Sort == "" {
+		opts.Sort, opts.Order = "recorded_at", "desc"
+	}
+	return metrics, list(&metrics, opts.ListOptions)
+}
+
+func (f *Fake) FindDuplicateImports(ctx context.Context, userID, source string, sessions []database.Workout_sessions, matchWindow time.Duration) (map[string]bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	found := map[string]bool{}
+	for _, ws := range sessions {
+		ws.Source = source
+		if f.isDuplicateImport(userID, ws, matchWindow) {
+			found[ws.External_id] = true
+		}
+	}
+	return found, nil
+}
+
+func (f *Fake) ImportSetLog(ctx context.Context, userID string, sessions []database.ImportedSession, matchWindow time.Duration) (*database.SetImportResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	result := &database.SetImportResult{}
+	for _, imported := range sessions {
+		sessionID := f.importSession(userID, imported.Session, matchWindow)
+		if sessionID == "" {
+			result.SessionsSkipped++
+			continue
+		}
+		result.SessionsImported++
+		for _, set := range imported.Sets {
+			set.Id, set.Session_id, set.Rest_seconds, set.Amrap, set.Created_at = newID(""), sessionID, nil, false, now()
+			f.sessionSets[set.Id] = set
+			result.SetsImported++
+		}
+	}
+	return result, nil
+}
+
+// --- WEBHOOKS ---
+
+func (f *Fake) CreateWebhook(ctx context.Context, webhook *database.Webhooks) (*database.Webhooks, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	w := database.Webhooks{User_id: webhook.User_id, Url: webhook.Url, Description: webhook.Description, Events: webhook.Events, Secret: webhook.Secret, Active: true}
+	created(&w.Id, &w.Created_at, &w.Updated_at, &w.Version)
+	f.webhooks[w.Id] = w
+	return &w, nil
+}
+
+func (f *Fake) GetWebhook(ctx context.Context, id, userID string) (*database.Webhooks, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	w, ok := f.webhooks[id]
+	if !ok || w.User_id != userID {
+		return nil, sql.ErrNoRows
+	}
+	return &w, nil
+}
+
+func (f *Fake) ListWebhooks(ctx context.Context, userID string) ([]database.Webhooks, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	webhooks := ownedBy(f.webhooks, userID, func(w database.Webhooks) string { return w.User_id })
+	sort.Slice(webhooks, func(i, j int) bool { return webhooks[i].Created_at.Before(webhooks[j].Created_at) })
+	return webhooks, nil
+}
+
+func (f *Fake) UpdateWebhook(ctx context.Context, webhook *database.Webhooks) (*database.Webhooks, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	w, ok := f.webhooks[webhook.Id]
+	if !ok || w.User_id != webhook.User_id {
+		return nil, sql.ErrNoRows
+	}
+	if w.Version != webhook.Version {
+		return nil, database.ErrVersionConflict
+	}
+	w.Url, w.Description, w.Events, w.Active = webhook.Url, webhook.Description, webhook.Events, webhook.Active
+	w.Updated_at, w.Version = now(), w.Version+1
+	f.webhooks[w.Id] = w
+	return &w, nil
+}
+
+func (f *Fake) DeleteWebhook(ctx context.Context, id, userID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if w, ok := f.webhooks[id]; !ok || w.User_id != userID {
+		return sql.ErrNoRows
+	}
+	delete(f.webhooks, id)
+	for deliveryID, d := range f.webhookDeliveries {
+		if d.Webhook_id == id {
+			delete(f.webhookDeliveries, deliveryID)
+		}
+	}
+	return nil
+}
+
+func (f *Fake) ListWebhookDeliveries(ctx context.Context, webhookID string, opts database.ListOptions) ([]database.Webhook_deliveries, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	deliveries := []database.Webhook_deliveries{}
+	for _, d := range f.webhookDeliveries {
+		if d.Webhook_id == webhookID {
+			deliveries = append(deliveries, d)
+		}
+	}
+	return deliveries, list(&deliveries, opts)
+}
+
+// subscribed reports whether the webhook's events include event
+func subscribed(w database.Webhooks, event string) bool {
+	var events []string
+	return json.Unmarshal(w.Events, &events) == nil && slices.Contains(events, event)
+}
+
+// deliveryTarget joins the delivery to its webhook. The caller holds f.mu.
+func (f *Fake) deliveryTarget(d database.Webhook_deliveries) database.WebhookDeliveryTarget {
+	w := f.webhooks[d.Webhook_id]
+	return database.WebhookDeliveryTarget{
+		Id: d.Id, Webhook_id: d.Webhook_id, Event_id: d.Event_id, Event: d.Event, Payload: d.Payload,
+		Attempts: d.Attempts, Url: w.Url, Secret: w.Secret,
+	}
+}
+
+func (f *Fake) EnqueueWebhookEvent(ctx context.Context, userID, eventID, event string, payload []byte, lease time.Duration) ([]database.WebhookDeliveryTarget, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.integrationEvents[eventID]; ok {
+		return nil, &database.ConstraintError{Kind: database.ErrDuplicate, Table: "integration_events", Constraint: "integration_events_pkey", Column: "id"}
+	}
+	f.integrationEvents[eventID] = database.Integration_events{Id: eventID, User_id: userID, Event: event, Payload: payload, Created_at: now()}
+	targets := []database.WebhookDeliveryTarget{}
+	for _, w := range f.webhooks {
+		if w.User_id != userID || !w.Active || !subscribed(w, event) {
+			continue
+		}
+		d := database.Webhook_deliveries{
+			Id: newID(""), Webhook_id: w.Id, Event_id: eventID, Event: event, Payload: payload,
+			Status: database.Webhook_deliveries_status_pending, Next_attempt_at: now().Add(lease), Created_at: now(),
+		}
+		f.webhookDeliveries[d.Id] = d
+		targets = append(targets, f.deliveryTarget(d))
+	}
+	return targets, nil
+}
+
+func (f *Fake) ClaimWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]database.WebhookDeliveryTarget, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	due := []database.Webhook_deliveries{}
+	for _, d := range f.webhookDeliveries {
+		if d.Status == database.Webhook_deliveries_status_pending && !d.Next_attempt_at.After(time.Now()) {
+			due = append(due, d)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].Next_attempt_at.Before(due[j].Next_attempt_at) })
+	targets := []database.WebhookDeliveryTarget{}
+	for _, d := range due[:min(limit, len(due))] {
+		d.Next_attempt_at = now().Add(lease)
+		f.webhookDeliveries[d.Id] = d
+		targets = append(targets, f.deliveryTarget(d))
+	}
+	return targets, nil
+}
+
+func (f *Fake) RecordWebhookAttempt(ctx context.Context, id string, succeeded bool, responseStatus *int, lastError string, nextAttemptAt *time.Time) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	d, ok := f.webhookDeliveries[id]
+	if !ok {
+		return nil
+	}
+	d.Attempts, d.Response_status, d.Last_error, d.Delivered_at = d.Attempts+1, responseStatus, lastError, nil
+	switch {
+	case succeeded:
+		delivered := now()
+		d.Status, d.Delivered_at = database.Webhook_deliveries_status_succeeded, &delivered
+	case nextAttemptAt == nil:
+		d.Status = database.Webhook_deliveries_status_failed
+	default:
+		d.Status = database.Webhook_deliveries_status_pending
+	}
+	if nextAttemptAt != nil {
+		d.Next_attempt_at = *nextAttemptAt
+	}
+	f.webhookDeliveries[id] = d
+	return nil
+}
+
+func (f *Fake) CountOverdueWebhookDeliveries(ctx context.Context, dueBefore time.Time) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	count := 0
+	for _, d := range f.webhookDeliveries {
+		if d.Status == database.Webhook_deliveries_status_pending && d.Next_attempt_at.Before(dueBefore) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (f *Fake) ListIntegrationEvents(ctx context.Context, userID, event string, since time.Time, limit int) ([]database.Integration_events, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	events := []database.Integration_events{}
+	for _, e := range f.integrationEvents {
+		if e.User_id == userID && e.Event == event && e.Created_at.After(since) {
+			events = append(events, e)
+		}
+	}
+	sort.Slice(events, func(i, j int) bool {
+		if !events[i].Created_at.Equal(events[j].Created_at) {
+			return events[i].Created_at.After(events[j].Created_at)
+		}
+		return events[i].Id < events[j].Id
+	})
+	return events[:min(limit, len(events))], nil
+}
+
+func (f *Fake) PurgeIntegrationEvents(ctx context.Context, before time.Time, limit int) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var purged int64
+	for id, e := range f.integrationEvents {
+		if purged < int64(limit) && e.Created_at.Before(before) {
+			delete(f.integrationEvents, id)
+			purged++
+		}
+	}
+	return purged, nil
+}
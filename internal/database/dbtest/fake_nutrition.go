@@ -0,0 +1,759 @@
+package dbtest
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"fitness-hack/internal/database"
+
+	"github.com/shopspring/decimal"
+)
+
+// healthTables are what users log about themselves outside of training, their progress
+// photos' vaults, and how they're notified
+type healthTables struct {
+	foods                   map[string]database.Foods
+	nutritionLogs           map[string]database.Nutrition_logs
+	benchmarkConsents       map[string]database.Benchmark_consents
+	benchmarkDistributions  map[string]database.Benchmark_distributions
+	dailyHabits             map[string]database.Daily_habits
+	photoVaults             map[string]database.Photo_vaults
+	devices                 map[string]database.Devices
+	notificationPreferences map[string]database.Notification_preferences
+	reminders               map[string]database.Reminders
+}
+
+func newHealthTables() healthTables {
+	return healthTables{
+		foods:                   map[string]database.Foods{},
+		nutritionLogs:           map[string]database.Nutrition_logs{},
+		benchmarkConsents:       map[string]database.Benchmark_consents{},
+		benchmarkDistributions:  map[string]database.Benchmark_distributions{},
+		dailyHabits:             map[string]database.Daily_habits{},
+		photoVaults:             map[string]database.Photo_vaults{},
+		devices:                 map[string]database.Devices{},
+		notificationPreferences: map[string]database.Notification_preferences{},
+		reminders:               map[string]database.Reminders{},
+	}
+}
+
+// --- NUTRITION ---
+
+// loggable reports whether the user can log the food: it's in the catalog or theirs
+func loggable(food database.Foods, userID string) bool {
+	return food.User_id == nil || *food.User_id == userID
+}
+
+func (f *Fake) CreateFood(ctx context.Context, food *database.Foods) (*database.Foods, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	created := database.Foods{
+		User_id: food.User_id, Name: food.Name, Brand: food.Brand, Barcode: food.Barcode,
+		Serving_size: food.Serving_size, Serving_unit: food.Serving_unit,
+		Calories: food.Calories, Protein_g: food.Protein_g, Carbs_g: food.Carbs_g, Fat_g: food.Fat_g,
+	}
+	return f.insertFood(created), nil
+}
+
+// insertFood stores a new food with what Postgres defaults. The caller holds f.mu.
+func (f *Fake) insertFood(food database.Foods) *database.Foods {
+	created(&food.Id, &food.Created_at, &food.Updated_at, &food.Version)
+	f.foods[food.Id] = food
+	return &food
+}
+
+func (f *Fake) GetFood(ctx context.Context, id, userID string) (*database.Foods, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	food, ok := f.foods[id]
+	if !ok || !loggable(food, userID) {
+		return nil, sql.ErrNoRows
+	}
+	return &food, nil
+}
+
+func (f *Fake) SearchFoods(ctx context.Context, userID, query, barcode string, limit int) ([]database.Foods, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	needle := strings.ToLower(query)
+	foods := []database.Foods{}
+	for _, food := range f.foods {
+		if !loggable(food, userID) {
+			continue
+		}
+		if barcode != "" && food.Barcode == barcode || barcode == "" && strings.Contains(strings.ToLower(food.Name+" "+food.Brand), needle) {
+			foods = append(foods, food)
+		}
+	}
+	// The user's own foods first, then names starting with the query
+	rank := func(food database.Foods) int {
+		r := 0
+		if food.User_id == nil {
+			r += 2
+		}
+		if barcode == "" && !strings.HasPrefix(strings.ToLower(food.Name), needle) {
+			r++
+		}
+		return r
+	}
+	sort.Slice(foods, func(i, j int) bool {
+		if ri, rj := rank(foods[i]), rank(foods[j]); ri != rj {
+			return ri < rj
+		}
+		if foods[i].Name != foods[j].Name {
+			return foods[i].Name < foods[j].Name
+		}
+		return foods[i].Id < foods[j].Id
+	})
+	return foods[:min(limit, len(foods))], nil
+}
+
+func (f *Fake) UpsertExternalFood(ctx context.Context, food *database.Foods) (*database.Foods, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	saved := database.Foods{
+		Name: food.Name, Brand: food.Brand, Barcode: food.Barcode, Serving_size: food.Serving_size, Serving_unit: food.Serving_unit,
+		Calories: food.Calories, Protein_g: food.Protein_g, Carbs_g: food.Carbs_g, Fat_g: food.Fat_g,
+		Source: food.Source, External_id: food.External_id,
+	}
+	for _, existing := range f.foods {
+		if food.External_id != "" && existing.Source == food.Source && existing.External_id == food.External_id {
+			saved.Id, saved.User_id, saved.Created_at = existing.Id, existing.User_id, existing.Created_at
+			saved.Updated_at, saved.Version = now(), existing.Version+1
+			f.foods[saved.Id] = saved
+			return &saved, nil
+		}
+	}
+	return f.insertFood(saved), nil
+}
+
+func (f *Fake) UpdateFood(ctx context.Context, food *database.Foods) (*database.Foods, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	existing, ok := f.foods[food.Id]
+	if !ok || food.User_id == nil || existing.User_id == nil || *existing.User_id != *food.User_id {
+		return nil, sql.ErrNoRows
+	}
+	if existing.Version != food.Version {
+		return nil, database.ErrVersionConflict
+	}
+	updated := existing
+	updated.Name, updated.Brand, updated.Barcode = food.Name, food.Brand, food.Barcode
+	updated.Serving_size, updated.Serving_unit = food.Serving_size, food.Serving_unit
+	updated.Calories, updated.Protein_g, updated.Carbs_g, updated.Fat_g = food.Calories, food.Protein_g, food.Carbs_g, food.Fat_g
+	updated.Updated_at, updated.Version = now(), existing.Version+1
+	f.foods[updated.Id] = updated
+	return &updated, nil
+}
+
+func (f *Fake) DeleteFood(ctx context.Context, id, userID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	food, ok := f.foods[id]
+	if !ok || food.User_id == nil || *food.User_id != userID {
+		return sql.ErrNoRows
+	}
+	delete(f.foods, id)
+	return nil
+}
+
+func (f *Fake) CreateNutritionLog(ctx context.Context, entry *database.Nutrition_logs) (*database.Nutrition_logs, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	e := *entry
+	e.Id, e.Created_at, e.Updated_at = "", time.Time{}, time.Time{}
+	created(&e.Id, &e.Created_at, &e.Updated_at, &e.Version)
+	f.nutritionLogs[e.Id] = e
+	return &e, nil
+}
+
+func (f *Fake) GetNutritionLog(ctx context.Context, id, userID string) (*database.Nutrition_logs, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	e, ok := f.nutritionLogs[id]
+	if !ok || e.User_id != userID {
+		return nil, sql.ErrNoRows
+	}
+	return &e, nil
+}
+
+func (f *Fake) ListNutritionLogs(ctx context.Context, userID string, opts database.ListNutritionLogsOpts) ([]database.Nutrition_logs, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	entries := []database.Nutrition_logs{}
+	for _, e := range f.nutritionLogs {
+		if e.User_id == userID && (opts.From.IsZero() || !e.Eaten_at.Before(opts.From)) && (opts.To.IsZero() || e.Eaten_at.Before(opts.To)) {
+			entries = append(entries, e)
+		}
+	}
+	if opts.Sort == "" {
+		opts.Sort, opts.Order = "eaten_at", "desc"
+	}
+	return entries, list(&entries, opts.ListOptions)
+}
+
+func (f *Fake) UpdateNutritionLog(ctx context.Context, entry *database.Nutrition_logs) (*database.Nutrition_logs, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	existing, ok := f.nutritionLogs[entry.Id]
+	if !ok || existing.User_id != entry.User_id {
+		return nil, sql.ErrNoRows
+	}
+	if existing.Version != entry.Version {
+		return nil, database.ErrVersionConflict
+	}
+	e := existing
+	e.Name, e.Meal, e.Servings, e.Calories = entry.Name, entry.Meal, entry.Servings, entry.Calories
+	e.Protein_g, e.Carbs_g, e.Fat_g, e.Eaten_at, e.Notes = entry.Protein_g, entry.Carbs_g, entry.Fat_g, entry.Eaten_at, entry.Notes
+	e.Updated_at, e.Version = now(), existing.Version+1
+	f.nutritionLogs[e.Id] = e
+	return &e, nil
+}
+
+func (f *Fake) DeleteNutritionLog(ctx context.Context, id, userID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if e, ok := f.nutritionLogs[id]; !ok || e.User_id != userID {
+		return sql.ErrNoRows
+	}
+	delete(f.nutritionLogs, id)
+	return nil
+}
+
+func (f *Fake) GetNutritionTotals(ctx context.Context, userID string, from, to time.Time) ([]database.MealTotals, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	meals := map[database.Nutrition_logs_meal]*database.MealTotals{}
+	for _, e := range f.nutritionLogs {
+		if e.User_id != userID || e.Eaten_at.Before(from) || !e.Eaten_at.Before(to) {
+			continue
+		}
+		t, ok := meals[e.Meal]
+		if !ok {
+			t = &database.MealTotals{Meal: e.Meal}
+			meals[e.Meal] = t
+		}
+		t.Entries++
+		t.Calories, t.Protein_g = t.Calories.Add(e.Calories), t.Protein_g.Add(e.Protein_g)
+		t.Carbs_g, t.Fat_g = t.Carbs_g.Add(e.Carbs_g), t.Fat_g.Add(e.Fat_g)
+	}
+	totals := []database.MealTotals{}
+	for _, t := range meals {
+		totals = append(totals, *t)
+	}
+	sort.Slice(totals, func(i, j int) bool { return totals[i].Meal < totals[j].Meal })
+	return totals, nil
+}
+
+// --- BENCHMARKS ---
+
+// latestBodyweight returns the user's most recently recorded bodyweight. The caller holds
+// f.mu.
+func (f *Fake) latestBodyweight(userID string) *decimal.Decimal {
+	var latest *database.Body_metrics
+	for _, m := range f.bodyMetrics {
+		if m.User_id == userID && m.Metric == database.Body_metrics_metric_weight_kg && (latest == nil || m.Recorded_at.After(latest.Recorded_at)) {
+			latest = &m
+		}
+	}
+	if latest == nil {
+		return nil
+	}
+	return &latest.Measured_value
+}
+
+func (f *Fake) GetBenchmarkProfile(ctx context.Context, userID string) (*database.BenchmarkProfile, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	profile := &database.BenchmarkProfile{BodyweightKg: f.latestBodyweight(userID)}
+	if consent, ok := f.benchmarkConsents[userID]; ok {
+		profile.ConsentedAt = &consent.Consented_at
+	}
+	return profile, nil
+}
+
+func (f *Fake) GrantBenchmarkConsent(ctx context.Context, userID string) (*database.Benchmark_consents, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	consent, ok := f.benchmarkConsents[userID]
+	if !ok {
+		consent = database.Benchmark_consents{User_id: userID, Consented_at: now()}
+		f.benchmarkConsents[userID] = consent
+	}
+	return &consent, nil
+}
+
+func (f *Fake) RevokeBenchmarkConsent(ctx context.Context, userID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.benchmarkConsents[userID]; !ok {
+		return sql.ErrNoRows
+	}
+	delete(f.benchmarkConsents, userID)
+	return nil
+}
+
+func (f *Fake) GetBenchmarkDistribution(ctx context.Context, exerciseID, bodyweightClass string) (*database.Benchmark_distributions, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	distribution, ok := f.benchmarkDistributions[pairKey(exerciseID, bodyweightClass)]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &distribution, nil
+}
+
+// RefreshBenchmarkDistributions rebuilds the distributions the way Postgres does, with
+// percentiles interpolated like percentile_cont
+func (f *Fake) RefreshBenchmarkDistributions(ctx context.Context, since time.Time, minSampleSize int) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	bests := map[string]decimal.Decimal{}
+	lifters := map[string]string{}
+	for _, set := range f.sessionSets {
+		s, ok := f.workoutSessions[set.Session_id]
+		if !ok || set.Exercise_id == nil || copiedSet(set) || set.Completed_at.Before(since) || !set.Weight_kg.IsPositive() || set.Reps <= 0 {
+			continue
+		}
+		e, public := f.exercises[*set.Exercise_id]
+		if _, consented := f.benchmarkConsents[s.User_id]; !consented || !public || e.Created_by != nil {
+			continue
+		}
+		key := pairKey(s.User_id, e.Id)
+		bests[key], lifters[key] = decimal.Max(bests[key], estimatedOneRepMax(set)), s.User_id
+	}
+
+	samples := map[string][]float64{}
+	for key, best := range bests {
+		userID := lifters[key]
+		exerciseID := strings.TrimPrefix(key, userID+"/")
+		value, _ := best.Float64()
+		samples[pairKey(exerciseID, database.BenchmarkAllClass)] = append(samples[pairKey(exerciseID, database.BenchmarkAllClass)], value)
+		if kg := f.latestBodyweight(userID); kg != nil {
+			class := database.BodyweightClassOf(kg.InexactFloat64())
+			samples[pairKey(exerciseID, class)] = append(samples[pairKey(exerciseID, class)], value)
+		}
+	}
+
+	f.benchmarkDistributions = map[string]database.Benchmark_distributions{}
+	for key, values := range samples {
+		if len(values) < minSampleSize {
+			continue
+		}
+		exerciseID, class, _ := strings.Cut(key, "/")
+		sort.Float64s(values)
+		percentiles := make([]float64, 99)
+		for i := range percentiles {
+			position := float64(i+1) / 100 * float64(len(values)-1)
+			lower := int(position)
+			upper := min(lower+1, len(values)-1)
+			percentiles[i] = values[lower] + (values[upper]-values[lower])*(position-float64(lower))
+		}
+		encoded, err := json.Marshal(percentiles)
+		if err != nil {
+			return 0, err
+		}
+		f.benchmarkDistributions[key] = database.Benchmark_distributions{
+			Exercise_id: exerciseID, Bodyweight_class: class, Sample_size: len(values), Percentiles: encoded, Computed_at: now(),
+		}
+	}
+	return len(f.benchmarkDistributions), nil
+}
+
+// --- HABITS ---
+
+// habitKey is the key of the user's habits on the day
+func habitKey(userID string, day time.Time) string {
+	return pairKey(userID, day.Format(time.DateOnly))
+}
+
+// UpsertDailyHabits saves the day's habits, keeping any the new ones leave unset
+func (f *Fake) UpsertDailyHabits(ctx context.Context, habits *database.Daily_habits) (*database.Daily_habits, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key := habitKey(habits.User_id, habits.Day)
+	day, _ := time.Parse(time.DateOnly, habits.Day.Format(time.DateOnly))
+	saved, ok := f.dailyHabits[key]
+	if !ok {
+		saved = database.Daily_habits{User_id: habits.User_id, Day: day, Created_at: now()}
+	}
+	if habits.Water_ml != nil {
+		saved.Water_ml = habits.Water_ml
+	}
+	if habits.Sleep_hours != nil {
+		saved.Sleep_hours = habits.Sleep_hours
+	}
+	if habits.Steps != nil {
+		saved.Steps = habits.Steps
+	}
+	saved.Updated_at = now()
+	f.dailyHabits[key] = saved
+	return &saved, nil
+}
+
+func (f *Fake) GetDailyHabits(ctx context.Context, userID string, day time.Time) (*database.Daily_habits, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	habits, ok := f.dailyHabits[habitKey(userID, day)]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &habits, nil
+}
+
+func (f *Fake) ListDailyHabits(ctx context.Context, userID string, from, to time.Time) ([]database.Daily_habits, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	first, last := from.Format(time.DateOnly), to.Format(time.DateOnly)
+	days := []database.Daily_habits{}
+	for _, habits := range f.dailyHabits {
+		if day := habits.Day.Format(time.DateOnly); habits.User_id == userID && day >= first && day <= last {
+			days = append(days, habits)
+		}
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Day.Before(days[j].Day) })
+	return days, nil
+}
+
+func (f *Fake) DeleteDailyHabits(ctx context.Context, userID string, day time.Time) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key := habitKey(userID, day)
+	if _, ok := f.dailyHabits[key]; !ok {
+		return sql.ErrNoRows
+	}
+	delete(f.dailyHabits, key)
+	return nil
+}
+
+// --- PROGRESS PHOTOS ---
+
+func (f *Fake) ListProgressPhotos(ctx context.Context, userID string, opts database.ListProgressPhotosOpts) ([]database.Progress_photos, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	photos := []database.Progress_photos{}
+	for _, p := range f.progressPhotos {
+		if p.User_id == userID && p.Status == database.Progress_photos_status_ready &&
+			(opts.From.IsZero() || !p.Taken_at.Before(opts.From)) && (opts.To.IsZero() || p.Taken_at.Before(opts.To)) {
+			photos = append(photos, p)
+		}
+	}
+	if opts.Sort == "" {
+		opts.Sort, opts.Order = "taken_at", "desc"
+	}
+	return photos, list(&photos, opts.ListOptions)
+}
+
+func (f *Fake) MoveProgressPhoto(ctx context.Context, photo *database.Progress_photos) (*database.Progress_photos, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	p, ok := f.progressPhotos[photo.Id]
+	if !ok || p.User_id != photo.User_id || p.Status != database.Progress_photos_status_ready {
+		return nil, sql.ErrNoRows
+	}
+	p.Vaulted, p.Storage_key, p.Thumbnail_key = photo.Vaulted, photo.Storage_key, photo.Thumbnail_key
+	p.Thumbnail_width, p.Thumbnail_height, p.Updated_at = photo.Thumbnail_width, photo.Thumbnail_height, now()
+	f.progressPhotos[p.Id] = p
+	return &p, nil
+}
+
+func (f *Fake) DeleteProgressPhoto(ctx context.Context, id, userID string) (*database.Progress_photos, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	p, ok := f.progressPhotos[id]
+	if !ok || p.User_id != userID {
+		return nil, sql.ErrNoRows
+	}
+	delete(f.progressPhotos, id)
+	return &p, nil
+}
+
+func (f *Fake) DeleteAbandonedProgressPhotos(ctx context.Context, before time.Time, limit int) ([]database.Progress_photos, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	photos := []database.Progress_photos{}
+	for id, p := range f.progressPhotos {
+		if len(photos) < limit && p.Status == database.Progress_photos_status_pending && p.Created_at.Before(before) {
+			delete(f.progressPhotos, id)
+			photos = append(photos, p)
+		}
+	}
+	return photos, nil
+}
+
+func (f *Fake) GetBodyMetricsNear(ctx context.Context, userID string, at time.Time, window time.Duration, metrics []string) ([]database.Body_metrics, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	nearest := map[database.Body_metrics_metric]database.Body_metrics{}
+	for _, m := range f.bodyMetrics {
+		distance := m.Recorded_at.Sub(at).Abs()
+		if m.User_id != userID || !slices.Contains(metrics, string(m.Metric)) || distance > window {
+			continue
+		}
+		best, ok := nearest[m.Metric]
+		if bestDistance := best.Recorded_at.Sub(at).Abs(); !ok || distance < bestDistance || distance == bestDistance && m.Recorded_at.After(best.Recorded_at) {
+			nearest[m.Metric] = m
+		}
+	}
+	found := []database.Body_metrics{}
+	for _, m := range nearest {
+		found = append(found, m)
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].Metric < found[j].Metric })
+	return found, nil
+}
+
+func (f *Fake) CreatePhotoVault(ctx context.Context, userID string, pinSalt, wrappedKey []byte) (*database.Photo_vaults, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.photoVaults[userID]; ok {
+		return nil, database.ErrPhotoVaultExists
+	}
+	vault := database.Photo_vaults{User_id: userID, Pin_salt: pinSalt, Wrapped_key: wrappedKey, Created_at: now(), Updated_at: now()}
+	f.photoVaults[userID] = vault
+	return &vault, nil
+}
+
+func (f *Fake) GetPhotoVault(ctx context.Context, userID string) (*database.Photo_vaults, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	vault, ok := f.photoVaults[userID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &vault, nil
+}
+
+func (f *Fake) ChangePhotoVaultPIN(ctx context.Context, userID string, pinSalt, wrappedKey []byte) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	vault, ok := f.photoVaults[userID]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	vault.Pin_salt, vault.Wrapped_key, vault.Failed_attempts, vault.Locked_until, vault.Updated_at = pinSalt, wrappedKey, 0, nil, now()
+	f.photoVaults[userID] = vault
+	return nil
+}
+
+func (f *Fake) RecordPhotoVaultAttempt(ctx context.Context, userID string, succeeded bool, maxAttempts int, lockout time.Duration) (*database.Photo_vaults, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	vault, ok := f.photoVaults[userID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	switch {
+	case succeeded:
+		vault.Failed_attempts, vault.Locked_until = 0, nil
+	case vault.Failed_attempts+1 >= maxAttempts:
+		until := now().Add(lockout)
+		vault.Failed_attempts, vault.Locked_until = 0, &until
+	default:
+		vault.Failed_attempts++
+	}
+	f.photoVaults[userID] = vault
+	return &vault, nil
+}
+
+// --- DEVICES AND NOTIFICATIONS ---
+
+func (f *Fake) RegisterDevice(ctx context.Context, device *database.Devices) (*database.Devices, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	d := database.Devices{Id: newID(""), User_id: device.User_id, Platform: device.Platform, Token: device.Token, Name: device.Name, Created_at: now()}
+	for _, existing := range f.devices {
+		if existing.Token == device.Token {
+			d.Id, d.Created_at = existing.Id, existing.Created_at
+		}
+	}
+	d.Updated_at = now()
+	f.devices[d.Id] = d
+	return &d, nil
+}
+
+func (f *Fake) ListDevices(ctx context.Context, userID string) ([]database.Devices, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	devices := ownedBy(f.devices, userID, func(d database.Devices) string { return d.User_id })
+	sort.Slice(devices, func(i, j int) bool { return devices[i].Updated_at.After(devices[j].Updated_at) })
+	return devices, nil
+}
+
+func (f *Fake) DeleteDevice(ctx context.Context, id, userID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if d, ok := f.devices[id]; !ok || d.User_id != userID {
+		return sql.ErrNoRows
+	}
+	delete(f.devices, id)
+	return nil
+}
+
+func (f *Fake) DeleteDeviceByToken(ctx context.Context, token string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for id, d := range f.devices {
+		if d.Token == token {
+			delete(f.devices, id)
+		}
+	}
+	return nil
+}
+
+// notificationPrefs returns the user's notification settings, defaulting them like the
+// table. The caller holds f.mu.
+func (f *Fake) notificationPrefs(userID string) database.Notification_preferences {
+	if prefs, ok := f.notificationPreferences[userID]; ok {
+		return prefs
+	}
+	return database.Notification_preferences{User_id: userID, Workout_reminders: true, Reminder_after_days: 3, Personal_records: true}
+}
+
+func (f *Fake) GetNotificationPreferences(ctx context.Context, userID string) (*database.Notification_preferences, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	prefs := f.notificationPrefs(userID)
+	return &prefs, nil
+}
+
+func (f *Fake) UpdateNotificationPreferences(ctx context.Context, prefs *database.Notification_preferences) (*database.Notification_preferences, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	updated := f.notificationPrefs(prefs.User_id)
+	updated.Workout_reminders, updated.Reminder_after_days, updated.Personal_records = prefs.Workout_reminders, prefs.Reminder_after_days, prefs.Personal_records
+	updated.Quiet_hours_start, updated.Quiet_hours_end = prefs.Quiet_hours_start, prefs.Quiet_hours_end
+	updated.Weekly_summary_email, updated.Updated_at = prefs.Weekly_summary_email, now()
+	f.notificationPreferences[prefs.User_id] = updated
+	return &updated, nil
+}
+
+func (f *Fake) ClaimWorkoutReminders(ctx context.Context, limit int) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	userIDs := []string{}
+	for _, u := range f.users {
+		if len(userIDs) >= limit {
+			break
+		}
+		prefs := f.notificationPrefs(u.Id)
+		lastActive := u.Created_at
+		for _, s := range f.workoutSessions {
+			if s.User_id == u.Id && s.Started_at.After(lastActive) {
+				lastActive = s.Started_at
+			}
+		}
+		hasDevice := len(ownedBy(f.devices, u.Id, func(d database.Devices) string { return d.User_id })) > 0
+		if !prefs.Workout_reminders || !lastActive.Before(time.Now().AddDate(0, 0, -prefs.Reminder_after_days)) ||
+			prefs.Last_reminded_at != nil && !prefs.Last_reminded_at.Before(lastActive) ||
+			!hasDevice || f.pendingAccountDeletion(u.Id) != nil {
+			continue
+		}
+		reminded := now()
+		prefs.Last_reminded_at = &reminded
+		f.notificationPreferences[u.Id] = prefs
+		userIDs = append(userIDs, u.Id)
+	}
+	return userIDs, nil
+}
+
+// --- REMINDERS ---
+
+func (f *Fake) CreateReminder(ctx context.Context, reminder *database.Reminders) (*database.Reminders, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	r := *reminder
+	r.Id, r.Last_sent_at, r.Created_at, r.Updated_at = "", nil, time.Time{}, time.Time{}
+	created(&r.Id, &r.Created_at, &r.Updated_at, &r.Version)
+	f.reminders[r.Id] = r
+	return &r, nil
+}
+
+func (f *Fake) GetReminder(ctx context.Context, id, userID string) (*database.Reminders, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	r, ok := f.reminders[id]
+	if !ok || r.User_id != userID {
+		return nil, sql.ErrNoRows
+	}
+	return &r, nil
+}
+
+func (f *Fake) ListReminders(ctx context.Context, userID string) ([]database.Reminders, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	reminders := ownedBy(f.reminders, userID, func(r database.Reminders) string { return r.User_id })
+	sort.Slice(reminders, func(i, j int) bool {
+		if !reminders[i].Created_at.Equal(reminders[j].Created_at) {
+			return reminders[i].Created_at.Before(reminders[j].Created_at)
+		}
+		return reminders[i].Id < reminders[j].Id
+	})
+	return reminders, nil
+}
+
+func (f *Fake) UpdateReminder(ctx context.Context, reminder *database.Reminders) (*database.Reminders, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	existing, ok := f.reminders[reminder.Id]
+	if !ok || existing.User_id != reminder.User_id {
+		return nil, sql.ErrNoRows
+	}
+	if existing.Version != reminder.Version {
+		return nil, database.ErrVersionConflict
+	}
+	r := *reminder
+	r.Last_sent_at, r.Created_at, r.Updated_at, r.Version = existing.Last_sent_at, existing.Created_at, now(), existing.Version+1
+	f.reminders[r.Id] = r
+	return &r, nil
+}
+
+func (f *Fake) DeleteReminder(ctx context.Context, id, userID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if r, ok := f.reminders[id]; !ok || r.User_id != userID {
+		return sql.ErrNoRows
+	}
+	delete(f.reminders, id)
+	return nil
+}
+
+func (f *Fake) ClaimDueReminders(ctx context.Context, limit int, lease time.Duration) ([]database.Reminders, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	due := []database.Reminders{}
+	for _, r := range f.reminders {
+		if r.Enabled && r.Next_run_at != nil && !r.Next_run_at.After(time.Now()) {
+			due = append(due, r)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].Next_run_at.Before(*due[j].Next_run_at) })
+	due = due[:min(limit, len(due))]
+	for i := range due {
+		leased := now().Add(lease)
+		due[i].Next_run_at = &leased
+		f.reminders[due[i].Id] = due[i]
+	}
+	return due, nil
+}
+
+func (f *Fake) RescheduleReminder(ctx context.Context, id string, claimedUntil, nextRunAt time.Time, sent bool) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	r, ok := f.reminders[id]
+	if !ok || r.Next_run_at == nil || !r.Next_run_at.Equal(claimedUntil) {
+		return nil
+	}
+	r.Next_run_at = &nextRunAt
+	if sent {
+		at := now()
+		r.Last_sent_at = &at
+	}
+	f.reminders[id] = r
+	return nil
+}
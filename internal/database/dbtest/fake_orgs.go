@@ -0,0 +1,1008 @@
+package dbtest
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"fitness-hack/internal/database"
+)
+
+// orgTables are organizations and what belongs to them: members, invitations, sign-in
+// settings, gym visits, equipment and kiosks, and coaching relationships
+type orgTables struct {
+	organizations         map[string]database.Organizations
+	orgMembers            map[string]database.Organization_members
+	orgInvites            map[string]database.Organization_invites
+	scimTokens            map[string]database.Organization_scim_tokens
+	orgSSO                map[string]database.Organization_sso
+	communityIntegrations map[string]database.Community_integrations
+	coachClients          map[string]database.Coach_clients
+	gymVisits             map[string]database.Gym_visits
+	gymEquipment          map[string]database.Gym_equipment
+	reservations          map[string]database.Equipment_reservations
+	kioskDisplays         map[string]database.Kiosk_displays
+}
+
+func newOrgTables() orgTables {
+	return orgTables{
+		organizations:         map[string]database.Organizations{},
+		orgMembers:            map[string]database.Organization_members{},
+		orgInvites:            map[string]database.Organization_invites{},
+		scimTokens:            map[string]database.Organization_scim_tokens{},
+		orgSSO:                map[string]database.Organization_sso{},
+		communityIntegrations: map[string]database.Community_integrations{},
+		coachClients:          map[string]database.Coach_clients{},
+		gymVisits:             map[string]database.Gym_visits{},
+		gymEquipment:          map[string]database.Gym_equipment{},
+		reservations:          map[string]database.Equipment_reservations{},
+		kioskDisplays:         map[string]database.Kiosk_displays{},
+	}
+}
+
+// memberKey is the key of a membership in orgMembers, its primary key in Postgres
+func memberKey(orgID, userID string) string {
+	return orgID + "/" + userID
+}
+
+// addMember adds the user to the organization unless they already belong to it, and
+// returns the membership. The caller holds f.mu.
+func (f *Fake) addMember(orgID, userID string, role database.Organization_members_role) (database.Organization_members, bool) {
+	key := memberKey(orgID, userID)
+	if m, ok := f.orgMembers[key]; ok {
+		return m, false
+	}
+	m := database.Organization_members{Organization_id: orgID, User_id: userID, Role: role, Active: true, Created_at: now(), Updated_at: now()}
+	f.orgMembers[key] = m
+	return m, true
+}
+
+// --- ORGANIZATIONS ---
+
+func (f *Fake) CreateOrganization(ctx context.Context, name, ownerID string) (*database.Organizations, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	org := database.Organizations{Id: newID(""), Name: name, Created_by: &ownerID, Created_at: now(), Updated_at: now()}
+	f.organizations[org.Id] = org
+	f.addMember(org.Id, ownerID, database.Organization_members_role_owner)
+	return &org, nil
+}
+
+func (f *Fake) GetOrganizationByID(ctx context.Context, id string) (*database.Organizations, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	org, ok := f.organizations[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &org, nil
+}
+
+func (f *Fake) ListOrganizationsByUser(ctx context.Context, userID string) ([]database.Organizations, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	orgs := []database.Organizations{}
+	for _, m := range f.orgMembers {
+		if org, ok := f.organizations[m.Organization_id]; ok && m.User_id == userID && m.Active {
+			orgs = append(orgs, org)
+		}
+	}
+	sort.Slice(orgs, func(i, j int) bool { return orgs[i].Name < orgs[j].Name })
+	return orgs, nil
+}
+
+func (f *Fake) GetOrganizationRole(ctx context.Context, orgID, userID string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	m, ok := f.orgMembers[memberKey(orgID, userID)]
+	if !ok || !m.Active {
+		return "", sql.ErrNoRows
+	}
+	return string(m.Role), nil
+}
+
+// --- ORGANIZATION INVITES ---
+
+func (f *Fake) CreateOrgInvite(ctx context.Context, invite *database.Organization_invites) (*database.Organization_invites, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, existing := range f.orgInvites {
+		if existing.Organization_id == invite.Organization_id && strings.EqualFold(existing.Email, invite.Email) &&
+			existing.Accepted_at == nil && existing.Revoked_at == nil {
+			return nil, database.ErrInvitePending
+		}
+	}
+	i := *invite
+	i.Id, i.Send_count, i.Last_sent_at, i.Created_at = newID(i.Id), 1, now(), now()
+	i.Accepted_at, i.Accepted_by, i.Revoked_at = nil, nil, nil
+	f.orgInvites[i.Id] = i
+	return &i, nil
+}
+
+func (f *Fake) ListPendingOrgInvites(ctx context.Context, orgID string) ([]database.Organization_invites, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	invites := []database.Organization_invites{}
+	for _, i := range f.orgInvites {
+		if i.Organization_id == orgID && i.Accepted_at == nil && i.Revoked_at == nil {
+			invites = append(invites, i)
+		}
+	}
+	sort.Slice(invites, func(i, j int) bool { return invites[i].Created_at.After(invites[j].Created_at) })
+	return invites, nil
+}
+
+func (f *Fake) ResendOrgInvite(ctx context.Context, orgID, inviteID, tokenHash string, expiresAt time.Time) (*database.Organization_invites, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	i, ok := f.orgInvites[inviteID]
+	if !ok || i.Organization_id != orgID || i.Accepted_at != nil || i.Revoked_at != nil {
+		return nil, sql.ErrNoRows
+	}
+	i.Token_hash, i.Expires_at, i.Send_count, i.Last_sent_at = tokenHash, expiresAt, i.Send_count+1, now()
+	f.orgInvites[inviteID] = i
+	return &i, nil
+}
+
+func (f *Fake) RevokeOrgInvite(ctx context.Context, orgID, inviteID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	i, ok := f.orgInvites[inviteID]
+	if !ok || i.Organization_id != orgID || i.Accepted_at != nil || i.Revoked_at != nil {
+		return sql.ErrNoRows
+	}
+	revoked := now()
+	i.Revoked_at = &revoked
+	f.orgInvites[inviteID] = i
+	return nil
+}
+
+func (f *Fake) AcceptOrgInvite(ctx context.Context, tokenHash, userID string) (*database.Organization_members, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var invite *database.Organization_invites
+	for _, i := range f.orgInvites {
+		if i.Token_hash == tokenHash {
+			invite = &i
+		}
+	}
+	switch {
+	case invite == nil:
+		return nil, sql.ErrNoRows
+	case invite.Accepted_at != nil || invite.Revoked_at != nil:
+		return nil, database.ErrInviteClosed
+	case time.Now().After(invite.Expires_at):
+		return nil, database.ErrInviteExpired
+	}
+	u, ok := f.users[userID]
+	if !ok {
+		return nil, fmt.Errorf("failed to load user: %w", sql.ErrNoRows)
+	}
+	if !strings.EqualFold(u.Email, invite.Email) {
+		return nil, database.ErrInviteEmailMismatch
+	}
+	member, _ := f.addMember(invite.Organization_id, userID, database.Organization_members_role(invite.Role))
+	accepted := now()
+	invite.Accepted_at, invite.Accepted_by = &accepted, &userID
+	f.orgInvites[invite.Id] = *invite
+	return &member, nil
+}
+
+// --- COACHING ---
+
+func (f *Fake) CreateCoachInvite(ctx context.Context, invite *database.Coach_clients) (*database.Coach_clients, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, existing := range f.coachClients {
+		if existing.Coach_id == invite.Coach_id && strings.EqualFold(existing.Email, invite.Email) && existing.Status == database.Coach_clients_status_pending {
+			return nil, database.ErrInvitePending
+		}
+	}
+	c := *invite
+	c.Id, c.Status, c.Created_at = newID(c.Id), database.Coach_clients_status_pending, now()
+	c.Client_id, c.Accepted_at, c.Ended_at = nil, nil, nil
+	f.coachClients[c.Id] = c
+	return &c, nil
+}
+
+// coachClients returns the relationships that keep, with the username of the user whose
+// id other picks. The caller holds f.mu.
+func (f *Fake) listCoachClients(keep func(database.Coach_clients) bool, other func(database.Coach_clients) *string) []database.CoachClient {
+	rows := []database.CoachClient{}
+	for _, c := range f.coachClients {
+		if !keep(c) {
+			continue
+		}
+		row := database.CoachClient{Coach_clients: c}
+		if id := other(c); id != nil {
+			if u, ok := f.users[*id]; ok {
+				row.Username = &u.Username
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func (f *Fake) ListCoachClients(ctx context.Context, coachID string) ([]database.CoachClient, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	clients := f.listCoachClients(func(c database.Coach_clients) bool {
+		return c.Coach_id == coachID && (c.Status == database.Coach_clients_status_pending || c.Status == database.Coach_clients_status_active)
+	}, func(c database.Coach_clients) *string { return c.Client_id })
+	sort.Slice(clients, func(i, j int) bool { return clients[i].Created_at.After(clients[j].Created_at) })
+	return clients, nil
+}
+
+func (f *Fake) ListClientCoaches(ctx context.Context, clientID string) ([]database.CoachClient, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	coaches := f.listCoachClients(func(c database.Coach_clients) bool {
+		_, coachExists := f.users[c.Coach_id]
+		return coachExists && c.Client_id != nil && *c.Client_id == clientID && c.Status == database.Coach_clients_status_active
+	}, func(c database.Coach_clients) *string { return &c.Coach_id })
+	sort.Slice(coaches, func(i, j int) bool { return coaches[i].Accepted_at.Before(*coaches[j].Accepted_at) })
+	return coaches, nil
+}
+
+func (f *Fake) RevokeCoachInvite(ctx context.Context, coachID, inviteID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	c, ok := f.coachClients[inviteID]
+	if !ok || c.Coach_id != coachID || c.Status != database.Coach_clients_status_pending {
+		return sql.ErrNoRows
+	}
+	ended := now()
+	c.Status, c.Ended_at = database.Coach_clients_status_ended, &ended
+	f.coachClients[inviteID] = c
+	return nil
+}
+
+func (f *Fake) AcceptCoachInvite(ctx context.Context, tokenHash, userID string) (*database.Coach_clients, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var invite *database.Coach_clients
+	for _, c := range f.coachClients {
+		if c.Token_hash == tokenHash {
+			invite = &c
+		}
+	}
+	switch {
+	case invite == nil:
+		return nil, sql.ErrNoRows
+	case invite.Status != database.Coach_clients_status_pending || invite.Coach_id == userID:
+		return nil, database.ErrInviteClosed
+	case time.Now().After(invite.Expires_at):
+		return nil, database.ErrInviteExpired
+	}
+	u, ok := f.users[userID]
+	if !ok {
+		return nil, fmt.Errorf("failed to load user: %w", sql.ErrNoRows)
+	}
+	if !strings.EqualFold(u.Email, invite.Email) {
+		return nil, database.ErrInviteEmailMismatch
+	}
+
+	at := now()
+	invite.Client_id = &userID
+	if active := f.activeCoaching(invite.Coach_id, userID); active != nil {
+		// Already coached: the invitation is closed and the existing relationship kept
+		invite.Status, invite.Ended_at = database.Coach_clients_status_ended, &at
+		f.coachClients[invite.Id] = *invite
+		return active, nil
+	}
+	invite.Status, invite.Accepted_at = database.Coach_clients_status_active, &at
+	f.coachClients[invite.Id] = *invite
+	return invite, nil
+}
+
+// activeCoaching returns the active relationship between the coach and client, if any. The
+// caller holds f.mu.
+func (f *Fake) activeCoaching(coachID, clientID string) *database.Coach_clients {
+	for _, c := range f.coachClients {
+		if c.Coach_id == coachID && c.Client_id != nil && *c.Client_id == clientID && c.Status == database.Coach_clients_status_active {
+			return &c
+		}
+	}
+	return nil
+}
+
+// EndCoachClient ends the relationship, rejecting the coach's pending adjustments to the
+// client's programs and taking the coach off them
+func (f *Fake) EndCoachClient(ctx context.Context, coachID, clientID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	c := f.activeCoaching(coachID, clientID)
+	if c == nil {
+		return sql.ErrNoRows
+	}
+	ended := now()
+	c.Status, c.Ended_at = database.Coach_clients_status_ended, &ended
+	f.coachClients[c.Id] = *c
+
+	for id, p := range f.programs {
+		if p.User_id != clientID || p.Coach_id == nil || *p.Coach_id != coachID {
+			continue
+		}
+		for adjID, adj := range f.programAdjustments {
+			if adj.Program_id == id && adj.Status == database.Program_adjustments_status_pending {
+				adj.Status, adj.Reviewed_at, adj.Review_note = database.Program_adjustments_status_rejected, &ended, "Coach changed before review"
+				f.programAdjustments[adjID] = adj
+			}
+		}
+		p.Coach_id, p.Updated_at, p.Version = nil, ended, p.Version+1
+		f.programs[id] = p
+	}
+	return nil
+}
+
+func (f *Fake) IsCoachOf(ctx context.Context, coachID, clientID string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.activeCoaching(coachID, clientID) != nil, nil
+}
+
+// --- GYM OCCUPANCY ---
+
+func (f *Fake) CheckInGymVisit(ctx context.Context, orgID, userID string, maxStay time.Duration) (*database.Gym_visits, bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for id, v := range f.gymVisits {
+		if v.Organization_id != orgID || v.User_id != userID || v.Checked_out_at != nil {
+			continue
+		}
+		if v.Checked_in_at.Before(time.Now().Add(-maxStay)) {
+			// A stale visit is closed when its longest stay ran out
+			out := v.Checked_in_at.Add(maxStay)
+			v.Checked_out_at = &out
+			f.gymVisits[id] = v
+			continue
+		}
+		return &v, false, nil
+	}
+	v := database.Gym_visits{Id: newID(""), Organization_id: orgID, User_id: userID, Checked_in_at: now()}
+	f.gymVisits[v.Id] = v
+	return &v, true, nil
+}
+
+func (f *Fake) CheckOutGymVisit(ctx context.Context, orgID, userID string) (*database.Gym_visits, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for id, v := range f.gymVisits {
+		if v.Organization_id == orgID && v.User_id == userID && v.Checked_out_at == nil {
+			out := now()
+			v.Checked_out_at = &out
+			f.gymVisits[id] = v
+			return &v, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (f *Fake) CountOpenGymVisits(ctx context.Context, orgID string, maxStay time.Duration) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	count := 0
+	for _, v := range f.gymVisits {
+		if v.Organization_id == orgID && v.Checked_out_at == nil && !v.Checked_in_at.Before(time.Now().Add(-maxStay)) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// GetHourlyOccupancy counts the visitors of each local hour from from until to and averages
+// them by weekday and hour, like Postgres
+func (f *Fake) GetHourlyOccupancy(ctx context.Context, orgID string, from, to time.Time, tz string, maxStay time.Duration) ([]database.OccupancyHour, error) {
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate gym occupancy: %w", err)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	type slot struct{ weekday, hour int }
+	totals, counts := map[slot]int{}, map[slot]int{}
+	peaks := map[slot]int{}
+	start := from.In(loc)
+	start = time.Date(start.Year(), start.Month(), start.Day(), start.Hour(), 0, 0, 0, loc)
+	for hour := start; !hour.After(to.In(loc).Add(-time.Hour)); hour = hour.Add(time.Hour) {
+		visitors := 0
+		for _, v := range f.gymVisits {
+			out := time.Now()
+			if v.Checked_out_at != nil {
+				out = *v.Checked_out_at
+			}
+			if longest := v.Checked_in_at.Add(maxStay); longest.Before(out) {
+				out = longest
+			}
+			if v.Organization_id == orgID && v.Checked_in_at.Before(hour.Add(time.Hour)) && out.After(hour) {
+				visitors++
+			}
+		}
+		weekday := int(hour.Weekday())
+		if weekday == 0 {
+			weekday = 7
+		}
+		s := slot{weekday, hour.Hour()}
+		totals[s] += visitors
+		counts[s]++
+		peaks[s] = max(peaks[s], visitors)
+	}
+	hours := []database.OccupancyHour{}
+	for s, n := range counts {
+		hours = append(hours, database.OccupancyHour{Weekday: s.weekday, Hour: s.hour, AverageVisitors: float64(totals[s]) / float64(n), PeakVisitors: peaks[s]})
+	}
+	sort.Slice(hours, func(i, j int) bool {
+		if hours[i].Weekday != hours[j].Weekday {
+			return hours[i].Weekday < hours[j].Weekday
+		}
+		return hours[i].Hour < hours[j].Hour
+	})
+	return hours, nil
+}
+
+// --- EQUIPMENT RESERVATIONS ---
+
+func (f *Fake) CreateGymEquipment(ctx context.Context, orgID, name string) (*database.Gym_equipment, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, e := range f.gymEquipment {
+		if e.Organization_id == orgID && strings.EqualFold(e.Name, name) && e.Archived_at == nil {
+			return nil, database.ErrEquipmentExists
+		}
+	}
+	e := database.Gym_equipment{Id: newID(""), Organization_id: orgID, Name: name, Created_at: now(), Updated_at: now()}
+	f.gymEquipment[e.Id] = e
+	return &e, nil
+}
+
+func (f *Fake) ListGymEquipment(ctx context.Context, orgID string) ([]database.Gym_equipment, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	equipment := []database.Gym_equipment{}
+	for _, e := range f.gymEquipment {
+		if e.Organization_id == orgID && e.Archived_at == nil {
+			equipment = append(equipment, e)
+		}
+	}
+	sort.Slice(equipment, func(i, j int) bool { return strings.ToLower(equipment[i].Name) < strings.ToLower(equipment[j].Name) })
+	return equipment, nil
+}
+
+// ArchiveGymEquipment archives the equipment and cancels its future bookings
+func (f *Fake) ArchiveGymEquipment(ctx context.Context, orgID, equipmentID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	e, ok := f.gymEquipment[equipmentID]
+	if !ok || e.Organization_id != orgID || e.Archived_at != nil {
+		return sql.ErrNoRows
+	}
+	archived := now()
+	e.Archived_at, e.Updated_at = &archived, archived
+	f.gymEquipment[equipmentID] = e
+	for id, r := range f.reservations {
+		if r.Equipment_id == equipmentID && r.Status == database.Equipment_reservations_status_booked && r.Starts_at.After(archived) {
+			r.Status, r.Cancelled_at = database.Equipment_reservations_status_cancelled, &archived
+			f.reservations[id] = r
+		}
+	}
+	return nil
+}
+
+func (f *Fake) CreateEquipmentReservation(ctx context.Context, orgID string, reservation *database.Equipment_reservations) (*database.Equipment_reservations, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if e, ok := f.gymEquipment[reservation.Equipment_id]; !ok || e.Organization_id != orgID || e.Archived_at != nil {
+		return nil, sql.ErrNoRows
+	}
+	for _, r := range f.reservations {
+		if r.Equipment_id == reservation.Equipment_id && r.Status == database.Equipment_reservations_status_booked &&
+			r.Starts_at.Before(reservation.Ends_at) && r.Ends_at.After(reservation.Starts_at) {
+			return nil, database.ErrReservationConflict
+		}
+	}
+	r := database.Equipment_reservations{
+		Id: newID(""), Equipment_id: reservation.Equipment_id, User_id: reservation.User_id,
+		Starts_at: reservation.Starts_at, Ends_at: reservation.Ends_at,
+		Status: database.Equipment_reservations_status_booked, Created_at: now(),
+	}
+	f.reservations[r.Id] = r
+	return &r, nil
+}
+
+// orgReservation returns the reservation if its equipment belongs to the organization. The
+// caller holds f.mu.
+func (f *Fake) orgReservation(orgID, id string) (database.Equipment_reservations, bool) {
+	r, ok := f.reservations[id]
+	if !ok {
+		return r, false
+	}
+	e, ok := f.gymEquipment[r.Equipment_id]
+	return r, ok && e.Organization_id == orgID
+}
+
+func (f *Fake) GetEquipmentReservation(ctx context.Context, orgID, id string) (*database.Equipment_reservations, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	r, ok := f.orgReservation(orgID, id)
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &r, nil
+}
+
+func (f *Fake) ListEquipmentReservations(ctx context.Context, orgID string, filter database.ReservationFilter) ([]database.Equipment_reservations, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	reservations := []database.Equipment_reservations{}
+	for id := range f.reservations {
+		r, ok := f.orgReservation(orgID, id)
+		if ok && (filter.EquipmentID == "" || r.Equipment_id == filter.EquipmentID) &&
+			(filter.UserID == "" || r.User_id == filter.UserID) &&
+			(filter.Status == "" || string(r.Status) == filter.Status) &&
+			(filter.From.IsZero() || r.Ends_at.After(filter.From)) &&
+			(filter.To.IsZero() || r.Starts_at.Before(filter.To)) {
+			reservations = append(reservations, r)
+		}
+	}
+	sort.Slice(reservations, func(i, j int) bool {
+		if !reservations[i].Starts_at.Equal(reservations[j].Starts_at) {
+			return reservations[i].Starts_at.Before(reservations[j].Starts_at)
+		}
+		return reservations[i].Id < reservations[j].Id
+	})
+	return reservations, nil
+}
+
+func (f *Fake) CancelEquipmentReservation(ctx context.Context, orgID, id string) (*database.Equipment_reservations, error) {
+	return f.closeEquipmentReservation(orgID, id, database.Equipment_reservations_status_cancelled)
+}
+
+func (f *Fake) MarkEquipmentReservationNoShow(ctx context.Context, orgID, id string) (*database.Equipment_reservations, error) {
+	return f.closeEquipmentReservation(orgID, id, database.Equipment_reservations_status_no_show)
+}
+
+// closeEquipmentReservation moves a booked reservation to status
+func (f *Fake) closeEquipmentReservation(orgID, id string, status database.Equipment_reservations_status) (*database.Equipment_reservations, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	r, ok := f.orgReservation(orgID, id)
+	if !ok || r.Status != database.Equipment_reservations_status_booked {
+		return nil, sql.ErrNoRows
+	}
+	r.Status = status
+	if status == database.Equipment_reservations_status_cancelled {
+		cancelled := now()
+		r.Cancelled_at = &cancelled
+	}
+	f.reservations[id] = r
+	return &r, nil
+}
+
+func (f *Fake) CountEquipmentNoShows(ctx context.Context, orgID, userID string, since time.Time) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	count := 0
+	for id := range f.reservations {
+		r, ok := f.orgReservation(orgID, id)
+		if ok && r.User_id == userID && r.Status == database.Equipment_reservations_status_no_show && !r.Starts_at.Before(since) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// --- KIOSK DISPLAYS ---
+
+func (f *Fake) CreateKioskDisplay(ctx context.Context, display *database.Kiosk_displays) (*database.Kiosk_displays, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	d := database.Kiosk_displays{
+		Id: newID(""), Organization_id: display.Organization_id, Name: display.Name, Timezone: display.Timezone,
+		Prefix: display.Prefix, Key_hash: display.Key_hash, Created_at: now(),
+	}
+	f.kioskDisplays[d.Id] = d
+	return &d, nil
+}
+
+func (f *Fake) ListKioskDisplays(ctx context.Context, orgID string) ([]database.Kiosk_displays, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	displays := []database.Kiosk_displays{}
+	for _, d := range f.kioskDisplays {
+		if d.Organization_id == orgID {
+			displays = append(displays, d)
+		}
+	}
+	sort.Slice(displays, func(i, j int) bool { return displays[i].Created_at.After(displays[j].Created_at) })
+	return displays, nil
+}
+
+func (f *Fake) DeleteKioskDisplay(ctx context.Context, orgID, id string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if d, ok := f.kioskDisplays[id]; !ok || d.Organization_id != orgID {
+		return sql.ErrNoRows
+	}
+	delete(f.kioskDisplays, id)
+	return nil
+}
+
+func (f *Fake) AuthenticateKioskDisplay(ctx context.Context, keyHash string) (*database.Kiosk_displays, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for id, d := range f.kioskDisplays {
+		if d.Key_hash == keyHash {
+			seen := now()
+			d.Last_seen_at = &seen
+			f.kioskDisplays[id] = d
+			return &d, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (f *Fake) SetLeaderboardVisibility(ctx context.Context, orgID, userID string, visible bool) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key := memberKey(orgID, userID)
+	m, ok := f.orgMembers[key]
+	if !ok || !m.Active {
+		return sql.ErrNoRows
+	}
+	m.Show_on_leaderboard, m.Updated_at = visible, now()
+	f.orgMembers[key] = m
+	return nil
+}
+
+// displayName is how a member is shown on leaderboards: their first name and last initial,
+// or their username
+func displayName(u database.Users) string {
+	if u.First_name == nil || *u.First_name == "" {
+		return u.Username
+	}
+	if u.Last_name == nil || *u.Last_name == "" {
+		return *u.First_name
+	}
+	return *u.First_name + " " + (*u.Last_name)[:1] + "."
+}
+
+func (f *Fake) ListLeaderboard(ctx context.Context, orgID string, from, to time.Time, limit int) ([]database.LeaderboardEntry, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	entries := []database.LeaderboardEntry{}
+	for _, m := range f.orgMembers {
+		u, ok := f.users[m.User_id]
+		if !ok || m.Organization_id != orgID || !m.Active || !m.Show_on_leaderboard {
+			continue
+		}
+		entry := database.LeaderboardEntry{Display_name: displayName(u)}
+		for _, s := range f.workoutSessions {
+			if s.User_id == m.User_id && !s.Completed_at.Before(from) && s.Completed_at.Before(to) {
+				entry.Sessions++
+				entry.Minutes += s.Duration_minutes
+			}
+		}
+		if entry.Sessions > 0 {
+			entries = append(entries, entry)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if a.Sessions != b.Sessions {
+			return a.Sessions > b.Sessions
+		}
+		if a.Minutes != b.Minutes {
+			return a.Minutes > b.Minutes
+		}
+		return a.Display_name < b.Display_name
+	})
+	return entries[:min(limit, len(entries))], nil
+}
+
+// --- SCIM PROVISIONING ---
+
+func (f *Fake) SetOrganizationSCIMToken(ctx context.Context, orgID, tokenHash string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.scimTokens[orgID] = database.Organization_scim_tokens{Organization_id: orgID, Token_hash: tokenHash, Created_at: now()}
+	return nil
+}
+
+func (f *Fake) AuthenticateSCIMToken(ctx context.Context, tokenHash string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for orgID, t := range f.scimTokens {
+		if t.Token_hash == tokenHash {
+			used := now()
+			t.Last_used_at = &used
+			f.scimTokens[orgID] = t
+			return orgID, nil
+		}
+	}
+	return "", sql.ErrNoRows
+}
+
+// scimMember returns the organization's non-owner member as SCIM sees them. The caller
+// holds f.mu.
+func (f *Fake) scimMember(orgID, userID string) (*database.SCIMMember, bool) {
+	m, ok := f.orgMembers[memberKey(orgID, userID)]
+	u, found := f.users[userID]
+	if !ok || !found || m.Role == database.Organization_members_role_owner {
+		return nil, false
+	}
+	member := &database.SCIMMember{User_id: userID, Email: u.Email, Active: m.Active, External_id: m.External_id, Created_at: m.Created_at, Updated_at: m.Updated_at}
+	if u.First_name != nil {
+		member.First_name = *u.First_name
+	}
+	if u.Last_name != nil {
+		member.Last_name = *u.Last_name
+	}
+	if u.Updated_at.After(member.Updated_at) {
+		member.Updated_at = u.Updated_at
+	}
+	return member, true
+}
+
+func (f *Fake) ListSCIMMembers(ctx context.Context, orgID string, filter database.SCIMMemberFilter, opts database.ListOptions) ([]database.SCIMMember, int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	members := []database.SCIMMember{}
+	for _, m := range f.orgMembers {
+		member, ok := f.scimMember(orgID, m.User_id)
+		if ok && m.Organization_id == orgID && (filter.Email == "" || strings.EqualFold(member.Email, filter.Email)) &&
+			(filter.ExternalID == "" || member.External_id == filter.ExternalID) {
+			members = append(members, *member)
+		}
+	}
+	sort.Slice(members, func(i, j int) bool {
+		if !members[i].Created_at.Equal(members[j].Created_at) {
+			return members[i].Created_at.Before(members[j].Created_at)
+		}
+		return members[i].User_id < members[j].User_id
+	})
+	total := len(members)
+	if opts.Limit == 0 {
+		return []database.SCIMMember{}, total, nil
+	}
+	start := min(max(opts.Offset, 0), total)
+	return members[start:min(start+opts.Limit, total)], total, nil
+}
+
+func (f *Fake) GetSCIMMember(ctx context.Context, orgID, userID string) (*database.SCIMMember, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	member, ok := f.scimMember(orgID, userID)
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return member, nil
+}
+
+// ProvisionSCIMMember adds the member, creating newUser for them unless an account with their
+// email exists and was invited to the organization
+func (f *Fake) ProvisionSCIMMember(ctx context.Context, orgID string, member *database.SCIMMember, newUser *database.Users) (*database.SCIMMember, error) {
+	var existing *database.Users
+	f.mu.Lock()
+	for _, u := range f.users {
+		if strings.EqualFold(u.Email, member.Email) {
+			existing = &u
+		}
+	}
+	f.mu.Unlock()
+
+	role, scimCreated := database.Organization_members_role_member, false
+	if existing == nil {
+		newUser.Email = member.Email
+		created, err := f.CreateUser(ctx, newUser)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create user: %w", err)
+		}
+		existing, scimCreated = created, true
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !scimCreated {
+		if _, ok := f.orgMembers[memberKey(orgID, existing.Id)]; ok {
+			return nil, database.ErrMemberExists
+		}
+		accepted := false
+		for id, i := range f.orgInvites {
+			if i.Organization_id == orgID && strings.EqualFold(i.Email, member.Email) && i.Accepted_at == nil && i.Revoked_at == nil && i.Expires_at.After(time.Now()) {
+				at := now()
+				i.Accepted_at, i.Accepted_by = &at, &existing.Id
+				f.orgInvites[id] = i
+				role, accepted = database.Organization_members_role(i.Role), true
+			}
+		}
+		if !accepted {
+			return nil, database.ErrAccountExists
+		}
+	}
+	m, added := f.addMember(orgID, existing.Id, role)
+	if !added {
+		return nil, database.ErrMemberExists
+	}
+	m.Active, m.External_id, m.Scim_created = member.Active, member.External_id, scimCreated
+	f.orgMembers[memberKey(orgID, existing.Id)] = m
+	created, _ := f.scimMember(orgID, existing.Id)
+	return created, nil
+}
+
+// UpdateSCIMMember updates the membership, and the member's name if SCIM created the account
+func (f *Fake) UpdateSCIMMember(ctx context.Context, orgID, userID string, update database.SCIMMemberUpdate) (*database.SCIMMember, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key := memberKey(orgID, userID)
+	m, ok := f.orgMembers[key]
+	if !ok || m.Role == database.Organization_members_role_owner {
+		return nil, sql.ErrNoRows
+	}
+	if update.Active != nil {
+		m.Active = *update.Active
+	}
+	if update.ExternalID != nil {
+		m.External_id = *update.ExternalID
+	}
+	m.Updated_at = now()
+	f.orgMembers[key] = m
+	if u, ok := f.users[userID]; ok && m.Scim_created && (update.FirstName != nil || update.LastName != nil) {
+		if update.FirstName != nil {
+			u.First_name = update.FirstName
+		}
+		if update.LastName != nil {
+			u.Last_name = update.LastName
+		}
+		u.Updated_at, u.Version = now(), u.Version+1
+		f.users[userID] = u
+	}
+	updated, _ := f.scimMember(orgID, userID)
+	return updated, nil
+}
+
+func (f *Fake) RemoveSCIMMember(ctx context.Context, orgID, userID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key := memberKey(orgID, userID)
+	if m, ok := f.orgMembers[key]; !ok || m.Role == database.Organization_members_role_owner {
+		return sql.ErrNoRows
+	}
+	delete(f.orgMembers, key)
+	return nil
+}
+
+// --- ORGANIZATION SSO ---
+
+func (f *Fake) GetOrganizationSSO(ctx context.Context, orgID string) (*database.Organization_sso, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	config, ok := f.orgSSO[orgID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &config, nil
+}
+
+func (f *Fake) UpsertOrganizationSSO(ctx context.Context, config *database.Organization_sso) (*database.Organization_sso, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	c := *config
+	c.Created_at = now()
+	if existing, ok := f.orgSSO[c.Organization_id]; ok {
+		c.Created_at = existing.Created_at
+	}
+	c.Updated_at = now()
+	f.orgSSO[c.Organization_id] = c
+	return &c, nil
+}
+
+func (f *Fake) DeleteOrganizationSSO(ctx context.Context, orgID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.orgSSO[orgID]; !ok {
+		return sql.ErrNoRows
+	}
+	delete(f.orgSSO, orgID)
+	return nil
+}
+
+// SyncSSOMember adds the user with role, or changes their role unless they own the organization
+func (f *Fake) SyncSSOMember(ctx context.Context, orgID, userID, role string) (*database.Organization_members, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	m, added := f.addMember(orgID, userID, database.Organization_members_role(role))
+	if !added {
+		if m.Role != database.Organization_members_role_owner {
+			m.Role = database.Organization_members_role(role)
+		}
+		m.Updated_at = now()
+		f.orgMembers[memberKey(orgID, userID)] = m
+	}
+	return &m, nil
+}
+
+// --- COMMUNITY INTEGRATIONS ---
+
+func (f *Fake) GetCommunityIntegration(ctx context.Context, orgID string) (*database.Community_integrations, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	integration, ok := f.communityIntegrations[orgID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &integration, nil
+}
+
+// UpsertCommunityIntegration saves the integration, keeping the week it last posted a
+// leaderboard for
+func (f *Fake) UpsertCommunityIntegration(ctx context.Context, integration *database.Community_integrations) (*database.Community_integrations, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	i := *integration
+	i.Created_at = now()
+	if existing, ok := f.communityIntegrations[i.Organization_id]; ok {
+		i.Created_at = existing.Created_at
+		if existing.Leaderboard_posted_for != nil {
+			i.Leaderboard_posted_for = existing.Leaderboard_posted_for
+		}
+	}
+	i.Updated_at = now()
+	f.communityIntegrations[i.Organization_id] = i
+	return &i, nil
+}
+
+func (f *Fake) DeleteCommunityIntegration(ctx context.Context, orgID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.communityIntegrations[orgID]; !ok {
+		return sql.ErrNoRows
+	}
+	delete(f.communityIntegrations, orgID)
+	return nil
+}
+
+func (f *Fake) ListMemberCommunityIntegrations(ctx context.Context, userID string) ([]database.MemberCommunityIntegration, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	integrations := []database.MemberCommunityIntegration{}
+	u, ok := f.users[userID]
+	if !ok {
+		return integrations, nil
+	}
+	for _, m := range f.orgMembers {
+		i, found := f.communityIntegrations[m.Organization_id]
+		if found && m.User_id == userID && m.Active && m.Show_on_leaderboard && i.Enabled && i.Post_personal_records {
+			integrations = append(integrations, database.MemberCommunityIntegration{Community_integrations: i, Display_name: displayName(u)})
+		}
+	}
+	return integrations, nil
+}
+
+func (f *Fake) ListLeaderboardCommunityIntegrations(ctx context.Context) ([]database.Community_integrations, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	integrations := []database.Community_integrations{}
+	for _, i := range f.communityIntegrations {
+		if i.Enabled && i.Post_weekly_leaderboard {
+			integrations = append(integrations, i)
+		}
+	}
+	sort.Slice(integrations, func(i, j int) bool { return integrations[i].Organization_id < integrations[j].Organization_id })
+	return integrations, nil
+}
+
+func (f *Fake) ClaimWeeklyLeaderboard(ctx context.Context, orgID string, weekStart time.Time) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	i, ok := f.communityIntegrations[orgID]
+	if !ok || i.Leaderboard_posted_for != nil && !i.Leaderboard_posted_for.Before(weekStart) {
+		return false, nil
+	}
+	i.Leaderboard_posted_for = &weekStart
+	f.communityIntegrations[orgID] = i
+	return true, nil
+}
@@ -0,0 +1,426 @@
+package dbtest
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"fitness-hack/internal/database"
+
+	"github.com/shopspring/decimal"
+)
+
+// socialTables are who follows whom, what they share, and the challenges they take part in
+type socialTables struct {
+	follows               map[string]database.Follows
+	privacySettings       map[string]database.Privacy_settings
+	challenges            map[string]database.Challenges
+	challengeParticipants map[string]database.Challenge_participants
+}
+
+func newSocialTables() socialTables {
+	return socialTables{
+		follows:               map[string]database.Follows{},
+		privacySettings:       map[string]database.Privacy_settings{},
+		challenges:            map[string]database.Challenges{},
+		challengeParticipants: map[string]database.Challenge_participants{},
+	}
+}
+
+// pairKey is the key of a row whose primary key is the pair of ids
+func pairKey(a, b string) string {
+	return a + "/" + b
+}
+
+// --- FOLLOWS ---
+
+func (f *Fake) FollowUser(ctx context.Context, followerID, followeeID string) (*database.Follows, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key := pairKey(followerID, followeeID)
+	follow, ok := f.follows[key]
+	if !ok {
+		follow = database.Follows{Follower_id: followerID, Followee_id: followeeID, Created_at: now()}
+		f.follows[key] = follow
+	}
+	return &follow, nil
+}
+
+func (f *Fake) UnfollowUser(ctx context.Context, followerID, followeeID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key := pairKey(followerID, followeeID)
+	if _, ok := f.follows[key]; !ok {
+		return sql.ErrNoRows
+	}
+	delete(f.follows, key)
+	return nil
+}
+
+func (f *Fake) ListFollowing(ctx context.Context, userID string) ([]database.FollowedUser, error) {
+	return f.listFollows(func(follow database.Follows) (string, bool) { return follow.Followee_id, follow.Follower_id == userID })
+}
+
+func (f *Fake) ListFollowers(ctx context.Context, userID string) ([]database.FollowedUser, error) {
+	return f.listFollows(func(follow database.Follows) (string, bool) { return follow.Follower_id, follow.Followee_id == userID })
+}
+
+// listFollows returns the users on the other side of the follows side keeps, most recent first
+func (f *Fake) listFollows(side func(database.Follows) (string, bool)) ([]database.FollowedUser, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	users := []database.FollowedUser{}
+	for _, follow := range f.follows {
+		id, ok := side(follow)
+		if u, found := f.users[id]; ok && found {
+			users = append(users, database.FollowedUser{User_id: id, Username: u.Username, Followed_at: follow.Created_at})
+		}
+	}
+	sort.Slice(users, func(i, j int) bool {
+		if !users[i].Followed_at.Equal(users[j].Followed_at) {
+			return users[i].Followed_at.After(users[j].Followed_at)
+		}
+		return users[i].User_id < users[j].User_id
+	})
+	return users, nil
+}
+
+// privacy returns the user's privacy settings, defaulting them like GetPrivacySettings. The
+// caller holds f.mu.
+func (f *Fake) privacy(userID string) database.Privacy_settings {
+	if settings, ok := f.privacySettings[userID]; ok {
+		return settings
+	}
+	return database.Privacy_settings{User_id: userID, Allow_followers: true, Share_sessions: true, Share_personal_records: true}
+}
+
+func (f *Fake) GetPrivacySettings(ctx context.Context, userID string) (*database.Privacy_settings, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	settings := f.privacy(userID)
+	return &settings, nil
+}
+
+func (f *Fake) UpdatePrivacySettings(ctx context.Context, settings *database.Privacy_settings) (*database.Privacy_settings, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	s := *settings
+	s.Updated_at = now()
+	f.privacySettings[s.User_id] = s
+	return &s, nil
+}
+
+// ListFeed gathers the followed users' completed sessions and personal records when read,
+// like Postgres
+func (f *Fake) ListFeed(ctx context.Context, userID string, before time.Time, limit int) ([]database.FeedItem, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	items := []database.FeedItem{}
+	for _, follow := range f.follows {
+		u, ok := f.users[follow.Followee_id]
+		if follow.Follower_id != userID || !ok {
+			continue
+		}
+		settings := f.privacy(u.Id)
+		if settings.Share_sessions {
+			for _, s := range f.workoutSessions {
+				if s.User_id == u.Id && !s.Completed_at.IsZero() && s.Completed_at.Before(before) {
+					items = append(items, database.FeedItem{
+						Kind: "session", Item_id: s.Id, Occurred_at: s.Completed_at, User_id: u.Id, Username: u.Username,
+						Session_id: &s.Id, Session_name: &s.Name, Duration_minutes: &s.Duration_minutes,
+					})
+				}
+			}
+		}
+		if settings.Share_personal_records {
+			items = append(items, f.personalRecordItems(u, before)...)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if !items[i].Occurred_at.Equal(items[j].Occurred_at) {
+			return items[i].Occurred_at.After(items[j].Occurred_at)
+		}
+		return items[i].Item_id > items[j].Item_id
+	})
+	return items[:min(limit, len(items))], nil
+}
+
+// personalRecordItems returns the user's workout exercises from before the given time that
+// beat the heaviest earlier weight of their exercise. The caller holds f.mu.
+func (f *Fake) personalRecordItems(u database.Users, before time.Time) []database.FeedItem {
+	items := []database.FeedItem{}
+	for _, we := range f.workoutExercises {
+		e, ok := f.exercises[we.Exercise_id]
+		if !ok || !f.ownWorkout(we.Workout_id, u.Id) || !we.Created_at.Before(before) {
+			continue
+		}
+		best, found := decimal.Zero, false
+		for _, other := range f.workoutExercises {
+			if other.Exercise_id == we.Exercise_id && other.Created_at.Before(we.Created_at) && f.ownWorkout(other.Workout_id, u.Id) {
+				best, found = decimal.Max(best, other.Weight_kg), true
+			}
+		}
+		if found && best.IsPositive() && we.Weight_kg.GreaterThan(best) {
+			items = append(items, database.FeedItem{
+				Kind: "personal_record", Item_id: we.Id, Occurred_at: we.Created_at, User_id: u.Id, Username: u.Username,
+				Exercise_id: &e.Id, Exercise_name: &e.Name, Weight_kg: &we.Weight_kg, Reps: &we.Reps, Previous_best_kg: &best,
+			})
+		}
+	}
+	return items
+}
+
+// ownWorkout reports whether the workout is the user's and not a template. The caller holds
+// f.mu.
+func (f *Fake) ownWorkout(workoutID, userID string) bool {
+	w, ok := f.workouts[workoutID]
+	return ok && w.User_id == userID && !w.Is_template
+}
+
+// --- LEADERBOARDS ---
+
+func (f *Fake) ListLeaderboardScores(ctx context.Context, metric string, since time.Time, exerciseID string) ([]database.LeaderboardScore, error) {
+	if !database.ValidLeaderboardMetric(metric) {
+		return nil, fmt.Errorf("unknown leaderboard metric %q", metric)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	scores := map[string]*database.LeaderboardScore{}
+	for _, set := range f.sessionSets {
+		s, ok := f.workoutSessions[set.Session_id]
+		if !ok || set.Exercise_id == nil || copiedSet(set) || set.Completed_at.Before(since) ||
+			!set.Weight_kg.IsPositive() || set.Reps <= 0 || (exerciseID != "" && *set.Exercise_id != exerciseID) {
+			continue
+		}
+		u, found := f.users[s.User_id]
+		e, public := f.exercises[*set.Exercise_id]
+		if !found || !public || e.Created_by != nil || !f.privacy(u.Id).Show_on_leaderboards {
+			continue
+		}
+		key := pairKey(e.Id, u.Id)
+		score, ok := scores[key]
+		if !ok {
+			score = &database.LeaderboardScore{Exercise_id: e.Id, User_id: u.Id, Display_name: displayName(u)}
+			scores[key] = score
+		}
+		if metric == database.LeaderboardMetricVolume {
+			score.Score = score.Score.Add(set.Weight_kg.Mul(decimal.NewFromInt(int64(set.Reps))))
+		} else {
+			score.Score = decimal.Max(score.Score, estimatedOneRepMax(set))
+		}
+	}
+	rows := make([]database.LeaderboardScore, 0, len(scores))
+	for _, score := range scores {
+		rows = append(rows, *score)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		a, b := rows[i], rows[j]
+		if a.Exercise_id != b.Exercise_id {
+			return a.Exercise_id < b.Exercise_id
+		}
+		if !a.Score.Equal(b.Score) {
+			return a.Score.GreaterThan(b.Score)
+		}
+		return a.User_id > b.User_id
+	})
+	return rows, nil
+}
+
+// estimatedOneRepMax is the set's one-rep max by Epley's formula, reps capped at 12
+func estimatedOneRepMax(set database.Workout_session_sets) decimal.Decimal {
+	if set.Reps <= 1 {
+		return set.Weight_kg
+	}
+	return set.Weight_kg.Mul(decimal.NewFromInt(int64(30 + min(set.Reps, 12)))).Div(decimal.NewFromInt(30))
+}
+
+// --- CHALLENGES ---
+
+func (f *Fake) CreateChallenge(ctx context.Context, challenge *database.Challenges) (*database.Challenges, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	c := *challenge
+	c.Id, c.Created_at = newID(""), now()
+	f.challenges[c.Id] = c
+	if c.Created_by != nil {
+		f.challengeParticipants[pairKey(c.Id, *c.Created_by)] = database.Challenge_participants{Challenge_id: c.Id, User_id: *c.Created_by, Joined_at: now()}
+	}
+	return &c, nil
+}
+
+// challengeSeenBy returns the challenge with its participant count and whether the user
+// joined it. The caller holds f.mu.
+func (f *Fake) challengeSeenBy(c database.Challenges, userID string) database.Challenge {
+	challenge := database.Challenge{Challenges: c}
+	for _, p := range f.challengeParticipants {
+		if p.Challenge_id == c.Id {
+			challenge.Participants++
+			challenge.Joined = challenge.Joined || p.User_id == userID
+		}
+	}
+	return challenge
+}
+
+func (f *Fake) GetChallenge(ctx context.Context, id, userID string) (*database.Challenge, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	c, ok := f.challenges[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	challenge := f.challengeSeenBy(c, userID)
+	return &challenge, nil
+}
+
+func (f *Fake) ListChallenges(ctx context.Context, userID string, joined bool, limit, offset int) ([]database.Challenge, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	challenges := []database.Challenge{}
+	for _, c := range f.challenges {
+		challenge := f.challengeSeenBy(c, userID)
+		if joined && challenge.Joined || !joined && c.Ends_at.After(time.Now()) {
+			challenges = append(challenges, challenge)
+		}
+	}
+	sort.Slice(challenges, func(i, j int) bool {
+		a, b := challenges[i], challenges[j]
+		if joined && !a.Starts_at.Equal(b.Starts_at) {
+			return a.Starts_at.After(b.Starts_at)
+		}
+		if !joined && !a.Ends_at.Equal(b.Ends_at) {
+			return a.Ends_at.Before(b.Ends_at)
+		}
+		return a.Id < b.Id
+	})
+	start := min(max(offset, 0), len(challenges))
+	return challenges[start:min(start+limit, len(challenges))], nil
+}
+
+func (f *Fake) DeleteChallenge(ctx context.Context, id, userID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	c, ok := f.challenges[id]
+	if !ok || c.Created_by == nil || *c.Created_by != userID {
+		return sql.ErrNoRows
+	}
+	delete(f.challenges, id)
+	for key, p := range f.challengeParticipants {
+		if p.Challenge_id == id {
+			delete(f.challengeParticipants, key)
+		}
+	}
+	return nil
+}
+
+func (f *Fake) JoinChallenge(ctx context.Context, challengeID, userID string) (*database.Challenge_participants, bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	c, ok := f.challenges[challengeID]
+	if !ok {
+		return nil, false, sql.ErrNoRows
+	}
+	if !time.Now().Before(c.Ends_at) {
+		return nil, false, database.ErrChallengeEnded
+	}
+	key := pairKey(challengeID, userID)
+	if p, ok := f.challengeParticipants[key]; ok {
+		return &p, false, nil
+	}
+	p := database.Challenge_participants{Challenge_id: challengeID, User_id: userID, Joined_at: now()}
+	f.challengeParticipants[key] = p
+	return &p, true, nil
+}
+
+func (f *Fake) LeaveChallenge(ctx context.Context, challengeID, userID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key := pairKey(challengeID, userID)
+	if _, ok := f.challengeParticipants[key]; !ok {
+		return sql.ErrNoRows
+	}
+	delete(f.challengeParticipants, key)
+	return nil
+}
+
+// ListChallengeStandings adds up each participant's sessions in order of completion, ranking
+// them the way the Postgres query does
+func (f *Fake) ListChallengeStandings(ctx context.Context, challengeID string) ([]database.ChallengeStanding, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	standings := []database.ChallengeStanding{}
+	c, ok := f.challenges[challengeID]
+	if !ok {
+		return standings, nil
+	}
+	for _, p := range f.challengeParticipants {
+		u, found := f.users[p.User_id]
+		if p.Challenge_id != challengeID || !found {
+			continue
+		}
+		type contribution struct {
+			at     time.Time
+			amount decimal.Decimal
+		}
+		contributions := []contribution{}
+		for _, s := range f.workoutSessions {
+			if s.User_id == p.User_id && !s.Completed_at.Before(c.Starts_at) && s.Completed_at.Before(c.Ends_at) {
+				contributions = append(contributions, contribution{s.Completed_at, f.challengeAmount(c.Metric, s)})
+			}
+		}
+		sort.Slice(contributions, func(i, j int) bool {
+			if !contributions[i].at.Equal(contributions[j].at) {
+				return contributions[i].at.Before(contributions[j].at)
+			}
+			return contributions[i].amount.LessThan(contributions[j].amount)
+		})
+		standing := database.ChallengeStanding{User_id: p.User_id, Display_name: displayName(u), Joined_at: p.Joined_at}
+		for _, contribution := range contributions {
+			standing.Progress = standing.Progress.Add(contribution.amount)
+			if standing.Completed_at == nil && standing.Progress.GreaterThanOrEqual(c.Target) {
+				at := contribution.at
+				standing.Completed_at = &at
+			}
+		}
+		standings = append(standings, standing)
+	}
+	sort.Slice(standings, func(i, j int) bool {
+		a, b := standings[i], standings[j]
+		if capped, other := decimal.Min(a.Progress, c.Target), decimal.Min(b.Progress, c.Target); !capped.Equal(other) {
+			return capped.GreaterThan(other)
+		}
+		if (a.Completed_at == nil) != (b.Completed_at == nil) {
+			return b.Completed_at == nil
+		}
+		if a.Completed_at != nil && !a.Completed_at.Equal(*b.Completed_at) {
+			return a.Completed_at.Before(*b.Completed_at)
+		}
+		if !a.Progress.Equal(b.Progress) {
+			return a.Progress.GreaterThan(b.Progress)
+		}
+		if !a.Joined_at.Equal(b.Joined_at) {
+			return a.Joined_at.Before(b.Joined_at)
+		}
+		return a.User_id < b.User_id
+	})
+	return standings, nil
+}
+
+// challengeAmount is what the session adds to a challenge of the metric. The caller holds
+// f.mu.
+func (f *Fake) challengeAmount(metric database.Challenges_metric, s database.Workout_sessions) decimal.Decimal {
+	switch metric {
+	case database.Challenges_metric_workouts:
+		return decimal.NewFromInt(1)
+	case database.Challenges_metric_minutes:
+		return decimal.NewFromInt(int64(s.Duration_minutes))
+	}
+	volume := decimal.Zero
+	for _, set := range f.sessionSets {
+		if set.Session_id == s.Id && !copiedSet(set) {
+			volume = volume.Add(set.Weight_kg.Mul(decimal.NewFromInt(int64(set.Reps))))
+		}
+	}
+	return volume
+}
@@ -0,0 +1,148 @@
+package dbtest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"fitness-hack/internal/database"
+)
+
+func TestFakeListsLikePostgres(t *testing.T) {
+	ctx := context.Background()
+	f := NewFake()
+	start := time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC)
+	ids := map[string]string{}
+	for i, name := range []string{"b", "c", "a"} {
+		userID := "u1"
+		if name == "c" {
+			userID = "u2"
+		}
+		w, err := f.CreateWorkout(ctx, &database.Workouts{User_id: userID, Name: name, Created_at: start.Add(time.Duration(i) * time.Hour)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids[name] = w.Id
+	}
+	squat, _ := f.CreateExercise(ctx, &database.Exercises{Name: "Squat"})
+	if err := f.SetExerciseMuscles(ctx, squat.Id, []database.MuscleAssignment{
+		{Muscle: "legs", Role: database.Exercise_muscle_groups_role_primary},
+		{Muscle: "core", Role: database.Exercise_muscle_groups_role_secondary},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.CreateWorkoutExercise(ctx, &database.Workout_exercises{Workout_id: ids["b"], Exercise_id: squat.Id}); err != nil {
+		t.Fatal(err)
+	}
+
+	names := func(opts database.ListWorkoutsOpts) string {
+		t.Helper()
+		rows, err := f.ListWorkouts(ctx, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s := ""
+		for _, w := range rows {
+			s += w.Name
+		}
+		return s
+	}
+	for _, tc := range []struct {
+		opts database.ListWorkoutsOpts
+		want string
+	}{
+		{database.ListWorkoutsOpts{}, "acb"},
+		{database.ListWorkoutsOpts{ListOptions: database.ListOptions{Sort: "name"}}, "abc"},
+		{database.ListWorkoutsOpts{ListOptions: database.ListOptions{Sort: "name", Order: "desc", Limit: 2}}, "cb"},
+		{database.ListWorkoutsOpts{ListOptions: database.ListOptions{Sort: "name", Offset: 2}}, "c"},
+		{database.ListWorkoutsOpts{ListOptions: database.ListOptions{Filters: map[string]string{"user_id": "u1"}}}, "ab"},
+		{database.ListWorkoutsOpts{UserID: "u1", From: start.Add(time.Hour)}, "a"},
+		{database.ListWorkoutsOpts{To: start.Add(2 * time.Hour)}, "cb"},
+		{database.ListWorkoutsOpts{MuscleGroup: "Legs"}, "b"},
+		{database.ListWorkoutsOpts{MuscleGroup: "core"}, ""},
+	} {
+		if got := names(tc.opts); got != tc.want {
+			t.Errorf("ListWorkouts(%+v) = %q, want %q", tc.opts, got, tc.want)
+		}
+	}
+
+	if _, err := f.ListWorkouts(ctx, database.ListWorkoutsOpts{ListOptions: database.ListOptions{Sort: "nope"}}); !errors.Is(err, database.ErrInvalidListOption) {
+		t.Errorf("unknown sort: got %v, want ErrInvalidListOption", err)
+	}
+}
+
+func TestFakeChecksVersions(t *testing.T) {
+	ctx := context.Background()
+	f := NewFake()
+	program, err := f.CreateProgram(ctx, &database.Programs{Name: "5/3/1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	program.Name = "5/3/1 BBB"
+	updated, err := f.UpdateProgram(ctx, program)
+	if err != nil || updated.Version != 2 {
+		t.Fatalf("UpdateProgram = %+v, %v; want version 2", updated, err)
+	}
+	if _, err := f.UpdateProgram(ctx, program); !errors.Is(err, database.ErrVersionConflict) {
+		t.Errorf("stale update: got %v, want ErrVersionConflict", err)
+	}
+
+	if err := f.DeleteProgram(ctx, program.Id); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.GetProgramByID(ctx, program.Id); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("deleted program: got %v, want sql.ErrNoRows", err)
+	}
+
+	workout, err := f.CreateWorkout(ctx, &database.Workouts{User_id: "u1", Name: "Legs"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale := *workout
+	stale.Version = 0
+	if _, err := f.UpdateWorkout(ctx, &stale); !errors.Is(err, database.ErrVersionConflict) {
+		t.Errorf("stale workout update: got %v, want ErrVersionConflict", err)
+	}
+	if err := f.DeleteWorkout(ctx, workout.Id); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.UpdateWorkout(ctx, workout); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("deleted workout update: got %v, want sql.ErrNoRows", err)
+	}
+}
+
+func TestFakeCopiesLastSessionSets(t *testing.T) {
+	ctx := context.Background()
+	f := NewFake()
+	workout, _ := f.CreateWorkout(ctx, &database.Workouts{User_id: "u1", Name: "Legs"})
+	start := time.Now().Add(-48 * time.Hour)
+	last, _ := f.CreateWorkoutSession(ctx, &database.Workout_sessions{User_id: "u1", Workout_id: &workout.Id, Started_at: start})
+	today, _ := f.CreateWorkoutSession(ctx, &database.Workout_sessions{User_id: "u1", Workout_id: &workout.Id, Started_at: start.Add(24 * time.Hour)})
+
+	if _, _, err := f.CopyLastWorkoutSessionSets(ctx, today.Id, "u1"); !errors.Is(err, database.ErrNoPreviousSession) {
+		t.Errorf("copy without earlier sets: got %v, want ErrNoPreviousSession", err)
+	}
+	for i, clientID := range []string{"a", "b", "a"} {
+		_, created, err := f.LogWorkoutSessionSet(ctx, &database.Workout_session_sets{Session_id: last.Id, Set_number: i + 1, Reps: 5, Client_id: clientID, Amrap: true})
+		if err != nil || created != (i < 2) {
+			t.Fatalf("logging set %d = %v, %v; want created only the first time a client id is sent", i, created, err)
+		}
+	}
+
+	sourceID, copied, err := f.CopyLastWorkoutSessionSets(ctx, today.Id, "u1")
+	if err != nil || sourceID != last.Id || len(copied) != 2 {
+		t.Fatalf("CopyLastWorkoutSessionSets = %q, %d sets, %v; want the 2 sets of the last session", sourceID, len(copied), err)
+	}
+	if copied[0].Set_number != 1 || copied[0].Amrap || copied[0].Client_id[:5] != "copy:" {
+		t.Errorf("unexpected copy %+v", copied[0])
+	}
+	if _, _, err := f.CopyLastWorkoutSessionSets(ctx, today.Id, "u1"); !errors.Is(err, database.ErrSessionHasSets) {
+		t.Errorf("copying twice: got %v, want ErrSessionHasSets", err)
+	}
+	if _, _, err := f.CopyLastWorkoutSessionSets(ctx, today.Id, "u2"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("copying into someone else's session: got %v, want sql.ErrNoRows", err)
+	}
+}
@@ -0,0 +1,1096 @@
+package dbtest
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"fitness-hack/internal/database"
+
+	"github.com/shopspring/decimal"
+)
+
+// trainingTables are what users log while training and what is worked out from it: sets,
+// training maxes, feedback, progression and program adjustments
+type trainingTables struct {
+	sessionSets        map[string]database.Workout_session_sets
+	trainingMaxes      map[string]database.Training_maxes
+	trainingMaxHistory map[string]database.Training_max_history
+	sessionFeedback    map[string]database.Session_feedback
+	progressionRules   map[string]database.Progression_rules
+	progressionSteps   map[string]database.Progression_steps
+	programAdjustments map[string]database.Program_adjustments
+	programWeeks       map[string]database.Program_weeks
+}
+
+func newTrainingTables() trainingTables {
+	return trainingTables{
+		sessionSets:        map[string]database.Workout_session_sets{},
+		trainingMaxes:      map[string]database.Training_maxes{},
+		trainingMaxHistory: map[string]database.Training_max_history{},
+		sessionFeedback:    map[string]database.Session_feedback{},
+		progressionRules:   map[string]database.Progression_rules{},
+		progressionSteps:   map[string]database.Progression_steps{},
+		programAdjustments: map[string]database.Program_adjustments{},
+		programWeeks:       map[string]database.Program_weeks{},
+	}
+}
+
+// copiedSet reports whether the set was copied from an earlier session rather than logged
+func copiedSet(set database.Workout_session_sets) bool {
+	return strings.HasPrefix(set.Client_id, "copy:")
+}
+
+// --- WORKOUT SESSION SETS ---
+
+func (f *Fake) LogWorkoutSessionSet(ctx context.Context, set *database.Workout_session_sets) (*database.Workout_session_sets, bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, existing := range f.sessionSets {
+		if existing.Session_id == set.Session_id && existing.Client_id == set.Client_id {
+			return &existing, false, nil
+		}
+	}
+	logged := *set
+	logged.Id, logged.Completed_at, logged.Created_at = newID(""), now(), now()
+	f.sessionSets[logged.Id] = logged
+	return &logged, true, nil
+}
+
+func (f *Fake) ListWorkoutSessionSets(ctx context.Context, sessionID string) ([]database.Workout_session_sets, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.setsOfSession(sessionID), nil
+}
+
+// setsOfSession returns the session's sets in the order they were completed. The caller
+// holds f.mu.
+func (f *Fake) setsOfSession(sessionID string) []database.Workout_session_sets {
+	sets := []database.Workout_session_sets{}
+	for _, set := range f.sessionSets {
+		if set.Session_id == sessionID {
+			sets = append(sets, set)
+		}
+	}
+	sort.Slice(sets, func(i, j int) bool {
+		if !sets[i].Completed_at.Equal(sets[j].Completed_at) {
+			return sets[i].Completed_at.Before(sets[j].Completed_at)
+		}
+		return sets[i].Set_number < sets[j].Set_number
+	})
+	return sets
+}
+
+func (f *Fake) CopyLastWorkoutSessionSets(ctx context.Context, sessionID, userID string) (string, []database.Workout_session_sets, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	target, ok := f.workoutSessions[sessionID]
+	if !ok || target.User_id != userID {
+		return "", nil, sql.ErrNoRows
+	}
+	if target.Workout_id == nil {
+		return "", nil, database.ErrNoPreviousSession
+	}
+	if len(f.setsOfSession(sessionID)) > 0 {
+		return "", nil, database.ErrSessionHasSets
+	}
+
+	var source *database.Workout_sessions
+	for _, s := range f.workoutSessions {
+		if s.User_id != userID || s.Workout_id == nil || *s.Workout_id != *target.Workout_id || s.Id == sessionID ||
+			!s.Started_at.Before(target.Started_at) || len(f.setsOfSession(s.Id)) == 0 {
+			continue
+		}
+		if source == nil || s.Started_at.After(source.Started_at) {
+			source = &s
+		}
+	}
+	if source == nil {
+		return "", nil, database.ErrNoPreviousSession
+	}
+
+	originals := f.setsOfSession(source.Id)
+	last := originals[len(originals)-1].Completed_at
+	copiedAt := now()
+	for _, o := range originals {
+		set := database.Workout_session_sets{
+			Id: newID(""), Session_id: sessionID, Exercise_id: o.Exercise_id, Set_number: o.Set_number,
+			Reps: o.Reps, Weight_kg: o.Weight_kg, Duration_seconds: o.Duration_seconds, Rest_seconds: o.Rest_seconds,
+			Client_id: "copy:" + o.Id, Completed_at: copiedAt.Add(-last.Sub(o.Completed_at)), Created_at: copiedAt,
+		}
+		f.sessionSets[set.Id] = set
+	}
+	return source.Id, f.setsOfSession(sessionID), nil
+}
+
+// userSets returns the sets logged in the user's sessions, keep deciding which. The caller
+// holds f.mu.
+func (f *Fake) userSets(userID string, keep func(database.Workout_session_sets) bool) []database.Workout_session_sets {
+	sets := []database.Workout_session_sets{}
+	for _, set := range f.sessionSets {
+		if s, ok := f.workoutSessions[set.Session_id]; ok && s.User_id == userID && keep(set) {
+			sets = append(sets, set)
+		}
+	}
+	return sets
+}
+
+// sortByCompletion orders sets oldest first, id breaking ties
+func sortByCompletion(sets []database.Workout_session_sets) {
+	sort.Slice(sets, func(i, j int) bool {
+		if !sets[i].Completed_at.Equal(sets[j].Completed_at) {
+			return sets[i].Completed_at.Before(sets[j].Completed_at)
+		}
+		return sets[i].Id < sets[j].Id
+	})
+}
+
+func (f *Fake) ListExerciseSets(ctx context.Context, userID, exerciseID string, since time.Time) ([]database.Workout_session_sets, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	sets := f.userSets(userID, func(set database.Workout_session_sets) bool {
+		return set.Exercise_id != nil && *set.Exercise_id == exerciseID && !set.Completed_at.Before(since) &&
+			set.Weight_kg.IsPositive() && set.Reps > 0 && !copiedSet(set)
+	})
+	sortByCompletion(sets)
+	return sets, nil
+}
+
+// --- PERSONAL RECORDS ---
+
+func (f *Fake) GetPersonalRecord(ctx context.Context, workoutExerciseID string) (*database.PersonalRecord, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	we, ok := f.workoutExercises[workoutExerciseID]
+	if !ok {
+		return nil, nil
+	}
+	w, ok := f.workouts[we.Workout_id]
+	if !ok || w.Is_template {
+		return nil, nil
+	}
+	var best *decimal.Decimal
+	for _, o := range f.workoutExercises {
+		ow, ok := f.workouts[o.Workout_id]
+		if !ok || ow.User_id != w.User_id || ow.Is_template || o.Exercise_id != we.Exercise_id || o.Id == we.Id {
+			continue
+		}
+		if best == nil || o.Weight_kg.GreaterThan(*best) {
+			best = &o.Weight_kg
+		}
+	}
+	if best == nil || !best.IsPositive() || !we.Weight_kg.GreaterThan(*best) {
+		return nil, nil
+	}
+	return &database.PersonalRecord{
+		User_id: w.User_id, Exercise_id: we.Exercise_id, Workout_id: we.Workout_id, Workout_exercise_id: we.Id,
+		Weight_kg: we.Weight_kg, Reps: we.Reps, Previous_best_kg: *best,
+	}, nil
+}
+
+func (f *Fake) ListPersonalRecordSets(ctx context.Context, userID string, from, to time.Time) ([]database.PersonalRecordSet, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	best := map[string]decimal.Decimal{}
+	for _, set := range f.userSets(userID, func(set database.Workout_session_sets) bool {
+		return set.Exercise_id != nil && set.Completed_at.Before(from) && set.Reps > 0
+	}) {
+		if b, ok := best[*set.Exercise_id]; !ok || set.Weight_kg.GreaterThan(b) {
+			best[*set.Exercise_id] = set.Weight_kg
+		}
+	}
+
+	records := map[string]database.PersonalRecordSet{}
+	for _, set := range f.userSets(userID, func(set database.Workout_session_sets) bool {
+		return set.Exercise_id != nil && !set.Completed_at.Before(from) && set.Completed_at.Before(to) &&
+			set.Reps > 0 && !copiedSet(set)
+	}) {
+		previous, ok := best[*set.Exercise_id]
+		e, found := f.exercises[*set.Exercise_id]
+		if !ok || !found || !set.Weight_kg.GreaterThan(previous) {
+			continue
+		}
+		if r, ok := records[e.Id]; ok && (r.Weight_kg.GreaterThan(set.Weight_kg) ||
+			r.Weight_kg.Equal(set.Weight_kg) && (r.Reps > set.Reps || r.Reps == set.Reps && !set.Completed_at.Before(r.Completed_at))) {
+			continue
+		}
+		records[e.Id] = database.PersonalRecordSet{
+			Exercise_id: e.Id, Exercise_name: e.Name, Weight_kg: set.Weight_kg, Reps: set.Reps,
+			Completed_at: set.Completed_at, Previous_best_kg: previous,
+		}
+	}
+
+	rows := make([]database.PersonalRecordSet, 0, len(records))
+	for _, r := range records {
+		rows = append(rows, r)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Exercise_name != rows[j].Exercise_name {
+			return rows[i].Exercise_name < rows[j].Exercise_name
+		}
+		return rows[i].Exercise_id < rows[j].Exercise_id
+	})
+	return rows, nil
+}
+
+// --- TRAINING MAXES ---
+
+func (f *Fake) ListTrainingMaxes(ctx context.Context, userID string) ([]database.Training_maxes, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	maxes := ownedBy(f.trainingMaxes, userID, func(tm database.Training_maxes) string { return tm.User_id })
+	sort.Slice(maxes, func(i, j int) bool { return maxes[i].Name < maxes[j].Name })
+	return maxes, nil
+}
+
+// trainingMax returns the user's training max with the given name. The caller holds f.mu.
+func (f *Fake) trainingMax(userID, name string) (database.Training_maxes, bool) {
+	for _, tm := range f.trainingMaxes {
+		if tm.User_id == userID && tm.Name == name {
+			return tm, true
+		}
+	}
+	return database.Training_maxes{}, false
+}
+
+func (f *Fake) GetTrainingMax(ctx context.Context, userID, name string) (*database.Training_maxes, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	tm, ok := f.trainingMax(userID, name)
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &tm, nil
+}
+
+func (f *Fake) UpsertTrainingMax(ctx context.Context, tm *database.Training_maxes, source string) (*database.Training_maxes, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	saved, ok := f.trainingMax(tm.User_id, tm.Name)
+	previous := saved.Weight_kg
+	if !ok {
+		saved = database.Training_maxes{Id: newID(""), User_id: tm.User_id, Name: tm.Name, Created_at: now()}
+	}
+	saved.Exercise_id, saved.Weight_kg, saved.Updated_at = tm.Exercise_id, tm.Weight_kg, now()
+	f.trainingMaxes[saved.Id] = saved
+	if !ok || !previous.Equal(saved.Weight_kg) {
+		entry := database.Training_max_history{
+			Id: newID(""), Training_max_id: saved.Id, Weight_kg: saved.Weight_kg,
+			Source: database.Training_max_history_source(source), Recorded_at: now(),
+		}
+		f.trainingMaxHistory[entry.Id] = entry
+	}
+	return &saved, nil
+}
+
+func (f *Fake) DeleteTrainingMax(ctx context.Context, userID, name string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	tm, ok := f.trainingMax(userID, name)
+	if !ok {
+		return sql.ErrNoRows
+	}
+	delete(f.trainingMaxes, tm.Id)
+	for id, entry := range f.trainingMaxHistory {
+		if entry.Training_max_id == tm.Id {
+			delete(f.trainingMaxHistory, id)
+		}
+	}
+	return nil
+}
+
+func (f *Fake) ListTrainingMaxHistory(ctx context.Context, trainingMaxID string, opts database.ListOptions) ([]database.Training_max_history, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	history := ownedBy(f.trainingMaxHistory, trainingMaxID, func(h database.Training_max_history) string { return h.Training_max_id })
+	if opts.Sort == "" {
+		opts.Sort, opts.Order = "recorded_at", "desc"
+	}
+	return history, list(&history, opts)
+}
+
+func (f *Fake) ListAMRAPSets(ctx context.Context, userID string) ([]database.AMRAPSet, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	rows := []database.AMRAPSet{}
+	for _, tm := range f.trainingMaxes {
+		if tm.User_id != userID || tm.Exercise_id == nil {
+			continue
+		}
+		for _, set := range f.userSets(userID, func(set database.Workout_session_sets) bool {
+			return set.Exercise_id != nil && *set.Exercise_id == *tm.Exercise_id && set.Amrap && set.Reps > 0 &&
+				set.Completed_at.After(tm.Updated_at)
+		}) {
+			row := database.AMRAPSet{TrainingMaxName: tm.Name, Workout_session_sets: set}
+			if feedback, ok := f.sessionFeedback[set.Session_id]; ok {
+				rpe := feedback.Rpe
+				row.SessionRPE, row.PainFlagged = &rpe, painFlagged(feedback)
+			}
+			rows = append(rows, row)
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if !rows[i].Completed_at.Equal(rows[j].Completed_at) {
+			return rows[i].Completed_at.Before(rows[j].Completed_at)
+		}
+		return rows[i].Id < rows[j].Id
+	})
+	return rows, nil
+}
+
+// --- SESSION FEEDBACK ---
+
+// painFlagged reports whether the feedback names any pain areas
+func painFlagged(feedback database.Session_feedback) bool {
+	var areas []json.RawMessage
+	return json.Unmarshal(feedback.Pain_areas, &areas) == nil && len(areas) > 0
+}
+
+func (f *Fake) UpsertSessionFeedback(ctx context.Context, feedback *database.Session_feedback) (*database.Session_feedback, bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	saved, ok := f.sessionFeedback[feedback.Session_id]
+	if !ok {
+		saved = database.Session_feedback{Session_id: feedback.Session_id, User_id: feedback.User_id, Created_at: now()}
+	}
+	saved.Rpe, saved.Enjoyment, saved.Pain_areas, saved.Notes = feedback.Rpe, feedback.Enjoyment, feedback.Pain_areas, feedback.Notes
+	saved.Updated_at = now()
+	f.sessionFeedback[saved.Session_id] = saved
+	return &saved, !ok, nil
+}
+
+func (f *Fake) GetSessionFeedback(ctx context.Context, sessionID string) (*database.Session_feedback, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	feedback, ok := f.sessionFeedback[sessionID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &feedback, nil
+}
+
+func (f *Fake) DeleteSessionFeedback(ctx context.Context, sessionID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.sessionFeedback[sessionID]; !ok {
+		return sql.ErrNoRows
+	}
+	delete(f.sessionFeedback, sessionID)
+	return nil
+}
+
+func (f *Fake) ListSessionLoads(ctx context.Context, userID string, since time.Time) ([]database.SessionLoad, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	loads := []database.SessionLoad{}
+	for _, feedback := range f.sessionFeedback {
+		s, ok := f.workoutSessions[feedback.Session_id]
+		if !ok || feedback.User_id != userID || s.Started_at.Before(since) {
+			continue
+		}
+		loads = append(loads, database.SessionLoad{Started_at: s.Started_at, Duration_minutes: s.Duration_minutes, Session_feedback: feedback})
+	}
+	sort.Slice(loads, func(i, j int) bool { return loads[i].Started_at.Before(loads[j].Started_at) })
+	return loads, nil
+}
+
+// --- WORKLOAD ---
+
+// sessionsOf returns the user's sessions, keep deciding which, in the order they were
+// started. The caller holds f.mu.
+func (f *Fake) sessionsOf(userID string, keep func(database.Workout_sessions) bool) []database.Workout_sessions {
+	sessions := []database.Workout_sessions{}
+	for _, s := range f.workoutSessions {
+		if s.User_id == userID && keep(s) {
+			sessions = append(sessions, s)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		if !sessions[i].Started_at.Equal(sessions[j].Started_at) {
+			return sessions[i].Started_at.Before(sessions[j].Started_at)
+		}
+		return sessions[i].Id < sessions[j].Id
+	})
+	return sessions
+}
+
+// setVolume is the set's weight times reps
+func setVolume(set database.Workout_session_sets) decimal.Decimal {
+	return set.Weight_kg.Mul(decimal.NewFromInt(int64(set.Reps)))
+}
+
+func (f *Fake) ListSessionVolumes(ctx context.Context, userID string, since time.Time) ([]database.SessionVolume, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	volumes := []database.SessionVolume{}
+	for _, s := range f.sessionsOf(userID, func(s database.Workout_sessions) bool { return !s.Started_at.Before(since) }) {
+		volume := database.SessionVolume{Started_at: s.Started_at}
+		for _, set := range f.setsOfSession(s.Id) {
+			if !copiedSet(set) {
+				volume.Volume_kg = volume.Volume_kg.Add(setVolume(set))
+			}
+		}
+		volumes = append(volumes, volume)
+	}
+	return volumes, nil
+}
+
+func (f *Fake) ListSessionStarts(ctx context.Context, userID string, since time.Time) ([]time.Time, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	starts := []time.Time{}
+	sessions := f.sessionsOf(userID, func(s database.Workout_sessions) bool { return !s.Started_at.Before(since) })
+	for i := len(sessions) - 1; i >= 0; i-- {
+		starts = append(starts, sessions[i].Started_at)
+	}
+	return starts, nil
+}
+
+func (f *Fake) GetDailySummary(ctx context.Context, userID string, from, to time.Time) (*database.DailySummary, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	summary := database.DailySummary{
+		Sessions: f.sessionsOf(userID, func(s database.Workout_sessions) bool {
+			return !s.Started_at.Before(from) && s.Started_at.Before(to)
+		}),
+		BodyMetrics: []database.Body_metrics{},
+	}
+	for _, s := range summary.Sessions {
+		logged := f.setsOfSession(s.Id)
+		for _, set := range logged {
+			summary.TotalVolumeKg = summary.TotalVolumeKg.Add(setVolume(set))
+		}
+		summary.SetCount += len(logged)
+		if len(logged) > 0 || s.Workout_id == nil {
+			continue
+		}
+		for _, we := range f.workoutExercises {
+			if we.Workout_id == *s.Workout_id {
+				summary.TotalVolumeKg = summary.TotalVolumeKg.Add(we.Weight_kg.Mul(decimal.NewFromInt(int64(we.Sets * we.Reps))))
+				summary.SetCount += we.Sets
+			}
+		}
+	}
+	for _, m := range f.bodyMetrics {
+		if m.User_id == userID && !m.Recorded_at.Before(from) && m.Recorded_at.Before(to) {
+			summary.BodyMetrics = append(summary.BodyMetrics, m)
+		}
+	}
+	sort.Slice(summary.BodyMetrics, func(i, j int) bool {
+		a, b := summary.BodyMetrics[i], summary.BodyMetrics[j]
+		if !a.Recorded_at.Equal(b.Recorded_at) {
+			return a.Recorded_at.Before(b.Recorded_at)
+		}
+		return a.Id < b.Id
+	})
+	return &summary, nil
+}
+
+func (f *Fake) ListActiveWeeks(ctx context.Context, userID, tz string, before time.Time, limit int) ([]time.Time, error) {
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list active weeks: %w", err)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	seen := map[time.Time]bool{}
+	weeks := []time.Time{}
+	for _, s := range f.sessionsOf(userID, func(s database.Workout_sessions) bool { return s.Started_at.Before(before) }) {
+		local := s.Started_at.In(loc)
+		monday := time.Date(local.Year(), local.Month(), local.Day()-(int(local.Weekday())+6)%7, 0, 0, 0, 0, time.UTC)
+		if !seen[monday] {
+			seen[monday] = true
+			weeks = append(weeks, monday)
+		}
+	}
+	sort.Slice(weeks, func(i, j int) bool { return weeks[i].After(weeks[j]) })
+	return weeks[:min(len(weeks), limit)], nil
+}
+
+// ClaimWeeklySummaries claims in no particular order, like Postgres
+func (f *Fake) ClaimWeeklySummaries(ctx context.Context, weekStart time.Time, limit int) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	week := time.Date(weekStart.Year(), weekStart.Month(), weekStart.Day(), 0, 0, 0, 0, time.UTC)
+	userIDs := []string{}
+	for userID, prefs := range f.notificationPreferences {
+		if len(userIDs) >= limit {
+			break
+		}
+		u, ok := f.users[userID]
+		if !ok || !prefs.Weekly_summary_email || u.Email == "" ||
+			prefs.Weekly_summary_sent_for != nil && !prefs.Weekly_summary_sent_for.Before(week) ||
+			f.pendingAccountDeletion(userID) != nil {
+			continue
+		}
+		loc, err := time.LoadLocation(u.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("failed to claim weekly summaries: %w", err)
+		}
+		local := time.Now().In(loc)
+		if time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC).Before(week.AddDate(0, 0, 7)) {
+			continue
+		}
+		prefs.Weekly_summary_sent_for = &week
+		f.notificationPreferences[userID] = prefs
+		userIDs = append(userIDs, userID)
+	}
+	return userIDs, nil
+}
+
+// --- PROGRESSION ---
+
+// progressionRuleByID returns the rule with the given ID. The caller holds f.mu.
+func (f *Fake) progressionRuleByID(id string) (database.Progression_rules, bool) {
+	for _, rule := range f.progressionRules {
+		if rule.Id == id {
+			return rule, true
+		}
+	}
+	return database.Progression_rules{}, false
+}
+
+func (f *Fake) UpsertProgressionRule(ctx context.Context, rule *database.Progression_rules) (*database.Progression_rules, bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	saved, ok := f.progressionRules[rule.Workout_exercise_id]
+	if !ok {
+		saved = database.Progression_rules{Id: newID(""), Workout_exercise_id: rule.Workout_exercise_id, User_id: rule.User_id, Created_at: now()}
+	}
+	saved.Weight_increment_kg, saved.Min_reps, saved.Max_reps = rule.Weight_increment_kg, rule.Min_reps, rule.Max_reps
+	saved.Deload_after_failures, saved.Deload_percent = rule.Deload_after_failures, rule.Deload_percent
+	saved.Failures, saved.Updated_at = 0, now()
+	f.progressionRules[saved.Workout_exercise_id] = saved
+	return &saved, !ok, nil
+}
+
+func (f *Fake) GetProgressionRule(ctx context.Context, workoutExerciseID string) (*database.Progression_rules, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	rule, ok := f.progressionRules[workoutExerciseID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &rule, nil
+}
+
+func (f *Fake) DeleteProgressionRule(ctx context.Context, workoutExerciseID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	rule, ok := f.progressionRules[workoutExerciseID]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	delete(f.progressionRules, workoutExerciseID)
+	for id, step := range f.progressionSteps {
+		if step.Rule_id == rule.Id {
+			delete(f.progressionSteps, id)
+		}
+	}
+	return nil
+}
+
+func (f *Fake) ListProgressionRules(ctx context.Context, workoutExerciseIDs []string) ([]database.Progression_rules, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	rules := []database.Progression_rules{}
+	for _, id := range workoutExerciseIDs {
+		if rule, ok := f.progressionRules[id]; ok {
+			rules = append(rules, rule)
+		}
+	}
+	return rules, nil
+}
+
+func (f *Fake) ListProgressionSteps(ctx context.Context, sessionID string) ([]database.ProgressionStep, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	steps := []database.ProgressionStep{}
+	order := map[string]int{}
+	for _, step := range f.progressionSteps {
+		rule, ok := f.progressionRuleByID(step.Rule_id)
+		if step.Session_id != sessionID || !ok {
+			continue
+		}
+		we, ok := f.workoutExercises[rule.Workout_exercise_id]
+		if !ok {
+			continue
+		}
+		order[we.Id] = we.Order_index
+		steps = append(steps, database.ProgressionStep{Progression_steps: step, Workout_exercise_id: we.Id, Exercise_id: we.Exercise_id})
+	}
+	sort.Slice(steps, func(i, j int) bool {
+		a, b := steps[i].Workout_exercise_id, steps[j].Workout_exercise_id
+		if order[a] != order[b] {
+			return order[a] < order[b]
+		}
+		return a < b
+	})
+	return steps, nil
+}
+
+func (f *Fake) ApplyProgressionSteps(ctx context.Context, steps []database.Progression_steps) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	applied := 0
+	for _, step := range steps {
+		taken := false
+		for _, existing := range f.progressionSteps {
+			if existing.Rule_id == step.Rule_id && existing.Session_id == step.Session_id {
+				taken = true
+				break
+			}
+		}
+		if taken {
+			continue
+		}
+		step.Id, step.Created_at = newID(""), now()
+		f.progressionSteps[step.Id] = step
+
+		if rule, ok := f.progressionRuleByID(step.Rule_id); ok {
+			if we, ok := f.workoutExercises[rule.Workout_exercise_id]; ok {
+				we.Reps, we.Weight_kg, we.Updated_at, we.Version = step.Reps, step.Weight_kg, now(), we.Version+1
+				f.workoutExercises[we.Id] = we
+			}
+			if step.Outcome == database.Progression_steps_outcome_repeated {
+				rule.Failures++
+			} else {
+				rule.Failures = 0
+			}
+			rule.Updated_at = now()
+			f.progressionRules[rule.Workout_exercise_id] = rule
+		}
+		applied++
+	}
+	return applied, nil
+}
+
+// --- PROGRAM ADJUSTMENTS ---
+
+// programWeek numbers the week of the program at falls in, week 1 starting when it started
+func programWeek(p database.Programs, at time.Time) int {
+	return int(at.Sub(p.Started_at)/(7*24*time.Hour)) + 1
+}
+
+func (f *Fake) ListProgramsDueForAdjustment(ctx context.Context, at time.Time, limit int) ([]database.Programs, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	programs := []database.Programs{}
+	for _, p := range f.programs {
+		week := programWeek(p, at)
+		if p.Is_active && !p.Started_at.After(at.AddDate(0, 0, -7)) && week > p.Adjusted_week &&
+			(p.Duration_weeks <= 0 || week <= p.Duration_weeks) {
+			programs = append(programs, p)
+		}
+	}
+	sort.Slice(programs, func(i, j int) bool {
+		if !programs[i].Started_at.Equal(programs[j].Started_at) {
+			return programs[i].Started_at.Before(programs[j].Started_at)
+		}
+		return programs[i].Id < programs[j].Id
+	})
+	return programs[:min(len(programs), limit)], nil
+}
+
+func (f *Fake) MarkProgramAdjusted(ctx context.Context, programID string, week int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if p, ok := f.programs[programID]; ok && p.Adjusted_week < week {
+		p.Adjusted_week = week
+		f.programs[programID] = p
+	}
+	return nil
+}
+
+func (f *Fake) CountProgramWorkouts(ctx context.Context, programID string) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	count := 0
+	for _, w := range f.workouts {
+		if w.Program_id == programID && !w.Is_template {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (f *Fake) ListProgramSessionStats(ctx context.Context, programID, userID string, from, to time.Time) ([]database.ProgramSessionStats, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	stats := []database.ProgramSessionStats{}
+	for _, s := range f.sessionsOf(userID, func(s database.Workout_sessions) bool {
+		return !s.Started_at.Before(from) && s.Started_at.Before(to)
+	}) {
+		if s.Workout_id == nil || f.workouts[*s.Workout_id].Program_id != programID {
+			continue
+		}
+		stat := database.ProgramSessionStats{Session_id: s.Id, Started_at: s.Started_at, Logged_sets: len(f.setsOfSession(s.Id))}
+		var plan []struct {
+			Sets int `json:"sets"`
+		}
+		if len(s.Plan) > 0 && json.Unmarshal(s.Plan, &plan) != nil {
+			return nil, fmt.Errorf("failed to list program session stats: session %s has an invalid plan", s.Id)
+		}
+		for _, p := range plan {
+			stat.Planned_sets += p.Sets
+		}
+		if feedback, ok := f.sessionFeedback[s.Id]; ok {
+			rpe := feedback.Rpe
+			stat.Rpe, stat.Pain = &rpe, painFlagged(feedback)
+		}
+		stats = append(stats, stat)
+	}
+	return stats, nil
+}
+
+func (f *Fake) ListProgramWeeks(ctx context.Context, programID string) ([]database.Program_weeks, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	weeks := ownedBy(f.programWeeks, programID, func(w database.Program_weeks) string { return w.Program_id })
+	sort.Slice(weeks, func(i, j int) bool { return weeks[i].Week < weeks[j].Week })
+	return weeks, nil
+}
+
+// applyProgramWeek sets the week of the adjustment's program to it once it is applied. The
+// caller holds f.mu.
+func (f *Fake) applyProgramWeek(adj database.Program_adjustments) {
+	if adj.Status != database.Program_adjustments_status_applied {
+		return
+	}
+	f.programWeeks[pairKey(adj.Program_id, fmt.Sprint(adj.Week))] = database.Program_weeks{
+		Program_id: adj.Program_id, Week: adj.Week, Volume_percent: adj.Volume_percent, Deload: adj.Deload, Updated_at: now(),
+	}
+}
+
+func (f *Fake) CreateProgramAdjustment(ctx context.Context, adj *database.Program_adjustments) (*database.Program_adjustments, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, existing := range f.programAdjustments {
+		if existing.Program_id == adj.Program_id && existing.Week == adj.Week {
+			return nil, sql.ErrNoRows
+		}
+	}
+	saved := *adj
+	saved.Id, saved.Created_at = newID(""), now()
+	saved.Reviewed_by, saved.Reviewed_at, saved.Review_note, saved.Applied_at = nil, nil, "", nil
+	if saved.Status == database.Program_adjustments_status_applied {
+		applied := now()
+		saved.Applied_at = &applied
+	}
+	f.programAdjustments[saved.Id] = saved
+	f.applyProgramWeek(saved)
+	return &saved, nil
+}
+
+func (f *Fake) GetProgramAdjustment(ctx context.Context, id string) (*database.Program_adjustments, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	adj, ok := f.programAdjustments[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	return &adj, nil
+}
+
+func (f *Fake) ListProgramAdjustments(ctx context.Context, programID string, opts database.ListOptions) ([]database.Program_adjustments, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	adjustments := ownedBy(f.programAdjustments, programID, func(a database.Program_adjustments) string { return a.Program_id })
+	return adjustments, list(&adjustments, opts)
+}
+
+func (f *Fake) ListPendingProgramAdjustments(ctx context.Context, coachID string, opts database.ListOptions) ([]database.Program_adjustments, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	adjustments := []database.Program_adjustments{}
+	for _, adj := range f.programAdjustments {
+		p, ok := f.programs[adj.Program_id]
+		if ok && adj.Status == database.Program_adjustments_status_pending && p.Coach_id != nil && *p.Coach_id == coachID {
+			adjustments = append(adjustments, adj)
+		}
+	}
+	if opts.Sort == "" {
+		opts.Sort, opts.Order = "created_at", "asc"
+	}
+	return adjustments, list(&adjustments, opts)
+}
+
+func (f *Fake) ReviewProgramAdjustment(ctx context.Context, id, reviewerID string, status database.Program_adjustments_status, note string) (*database.Program_adjustments, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	adj, ok := f.programAdjustments[id]
+	if !ok || adj.Status != database.Program_adjustments_status_pending {
+		return nil, sql.ErrNoRows
+	}
+	reviewed := now()
+	adj.Status, adj.Reviewed_by, adj.Reviewed_at, adj.Review_note, adj.Applied_at = status, &reviewerID, &reviewed, note, nil
+	if status == database.Program_adjustments_status_applied {
+		adj.Applied_at = &reviewed
+	}
+	f.programAdjustments[id] = adj
+	f.applyProgramWeek(adj)
+	return &adj, nil
+}
+
+func (f *Fake) SetProgramCoach(ctx context.Context, programID string, coachID *string) (*database.Programs, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	p, ok := f.programs[programID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	changed := (p.Coach_id == nil) != (coachID == nil) || p.Coach_id != nil && *p.Coach_id != *coachID
+	rejected := now()
+	for id, adj := range f.programAdjustments {
+		if changed && adj.Program_id == programID && adj.Status == database.Program_adjustments_status_pending {
+			adj.Status, adj.Reviewed_at, adj.Review_note = database.Program_adjustments_status_rejected, &rejected, "Coach changed before review"
+			f.programAdjustments[id] = adj
+		}
+	}
+	p.Coach_id, p.Updated_at, p.Version = coachID, now(), p.Version+1
+	f.programs[programID] = p
+	return &p, nil
+}
+
+func (f *Fake) IsOrganizationCoach(ctx context.Context, coachID, userID string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, c := range f.orgMembers {
+		if c.User_id != coachID || !c.Active ||
+			c.Role != database.Organization_members_role_owner && c.Role != database.Organization_members_role_admin {
+			continue
+		}
+		if m, ok := f.orgMembers[memberKey(c.Organization_id, userID)]; ok && m.Active {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// --- WEATHER ---
+
+func (f *Fake) ListSessionsNeedingWeather(ctx context.Context, endedBefore time.Time, limit int) ([]database.Workout_sessions, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	sessions := []database.Workout_sessions{}
+	for _, s := range f.workoutSessions {
+		if s.Start_latitude != nil && s.Start_longitude != nil && s.Weather_checked_at == nil &&
+			!s.Completed_at.IsZero() && s.Completed_at.Before(endedBefore) {
+			sessions = append(sessions, s)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].Started_at.After(sessions[j].Started_at) })
+	return sessions[:min(len(sessions), limit)], nil
+}
+
+func (f *Fake) SetSessionWeather(ctx context.Context, sessionID string, weather *database.SessionWeather) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	s, ok := f.workoutSessions[sessionID]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	s.Temperature_c, s.Wind_speed_kmh, s.Wind_direction = nil, nil, nil
+	if weather != nil {
+		w := *weather
+		s.Temperature_c, s.Wind_speed_kmh, s.Wind_direction = &w.TemperatureC, &w.WindSpeedKmh, &w.WindDirection
+	}
+	checked := now()
+	s.Weather_checked_at, s.Updated_at = &checked, checked
+	f.workoutSessions[sessionID] = s
+	return nil
+}
+
+// --- ANALYTICS ---
+
+func (f *Fake) ListRestSets(ctx context.Context, filter database.RestSetFilter) ([]database.RestSet, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var sessions []database.Workout_sessions
+	for _, s := range f.workoutSessions {
+		if (filter.UserID == "" || s.User_id == filter.UserID) && (filter.SessionID == "" || s.Id == filter.SessionID) &&
+			(filter.From.IsZero() || !s.Started_at.Before(filter.From)) && (filter.To.IsZero() || s.Started_at.Before(filter.To)) {
+			sessions = append(sessions, s)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		if !sessions[i].Started_at.Equal(sessions[j].Started_at) {
+			return sessions[i].Started_at.Before(sessions[j].Started_at)
+		}
+		return sessions[i].Id < sessions[j].Id
+	})
+
+	rows := []database.RestSet{}
+	for _, s := range sessions {
+		var plan []struct {
+			ExerciseID  string `json:"exerciseId"`
+			OrderIndex  int    `json:"orderIndex"`
+			RestSeconds int    `json:"restSeconds"`
+		}
+		if len(s.Plan) > 0 && json.Unmarshal(s.Plan, &plan) != nil {
+			return nil, fmt.Errorf("failed to list rest sets: session %s has an invalid plan", s.Id)
+		}
+		sort.SliceStable(plan, func(i, j int) bool { return plan[i].OrderIndex < plan[j].OrderIndex })
+		for _, set := range f.setsOfSession(s.Id) {
+			if set.Exercise_id == nil || copiedSet(set) {
+				continue
+			}
+			row := database.RestSet{Workout_session_sets: set}
+			for _, p := range plan {
+				if p.ExerciseID == *set.Exercise_id {
+					if p.RestSeconds != 0 {
+						rest := p.RestSeconds
+						row.Prescribed_rest_seconds = &rest
+					}
+					break
+				}
+			}
+			rows = append(rows, row)
+		}
+	}
+	return rows, nil
+}
+
+// primaryMuscles returns the muscle groups the exercise works as a primary muscle. The
+// caller holds f.mu.
+func (f *Fake) primaryMuscles(exerciseID string) []database.ExerciseMuscle {
+	muscles := []database.ExerciseMuscle{}
+	for _, m := range f.exerciseMuscles[exerciseID] {
+		if m.Role == database.Exercise_muscle_groups_role_primary {
+			muscles = append(muscles, m)
+		}
+	}
+	return muscles
+}
+
+func (f *Fake) ListSuggestionCandidates(ctx context.Context, userID string, limit int) ([]database.SuggestionCandidate, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	candidates := []database.SuggestionCandidate{}
+	for _, w := range f.workouts {
+		if w.User_id != userID || w.Is_template {
+			continue
+		}
+		c := database.SuggestionCandidate{Workouts: w}
+		if p, ok := f.programs[w.Program_id]; ok && p.User_id == w.User_id {
+			c.In_active_program = p.Is_active
+		}
+		for _, s := range f.workoutSessions {
+			if s.User_id == w.User_id && s.Workout_id != nil && *s.Workout_id == w.Id &&
+				(c.Last_done_at == nil || s.Started_at.After(*c.Last_done_at)) {
+				started := s.Started_at
+				c.Last_done_at = &started
+			}
+		}
+		candidates = append(candidates, c)
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		switch {
+		case a.In_active_program != b.In_active_program:
+			return a.In_active_program
+		case (a.Last_done_at == nil) != (b.Last_done_at == nil):
+			return a.Last_done_at == nil
+		case a.Last_done_at != nil && !a.Last_done_at.Equal(*b.Last_done_at):
+			return a.Last_done_at.Before(*b.Last_done_at)
+		case !a.Created_at.Equal(b.Created_at):
+			return a.Created_at.Before(b.Created_at)
+		}
+		return a.Id < b.Id
+	})
+	return candidates[:min(len(candidates), limit)], nil
+}
+
+func (f *Fake) ListRecentMuscleSets(ctx context.Context, userID string, since time.Time) ([]database.MuscleSets, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	counts := map[string]int{}
+	for _, set := range f.userSets(userID, func(set database.Workout_session_sets) bool {
+		return set.Exercise_id != nil && !set.Completed_at.Before(since) && !copiedSet(set)
+	}) {
+		for _, m := range f.primaryMuscles(*set.Exercise_id) {
+			counts[m.Slug]++
+		}
+	}
+	muscles := make([]database.MuscleSets, 0, len(counts))
+	for slug, sets := range counts {
+		muscles = append(muscles, database.MuscleSets{Slug: slug, Sets: sets})
+	}
+	sort.Slice(muscles, func(i, j int) bool {
+		if muscles[i].Sets != muscles[j].Sets {
+			return muscles[i].Sets > muscles[j].Sets
+		}
+		return muscles[i].Slug < muscles[j].Slug
+	})
+	return muscles, nil
+}
+
+func (f *Fake) ListWorkoutMuscles(ctx context.Context, workoutIDs []string) ([]database.WorkoutMuscle, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	seen := map[database.WorkoutMuscle]bool{}
+	muscles := []database.WorkoutMuscle{}
+	for _, we := range f.workoutExercises {
+		if !slices.Contains(workoutIDs, we.Workout_id) {
+			continue
+		}
+		for _, m := range f.primaryMuscles(we.Exercise_id) {
+			muscle := database.WorkoutMuscle{Workout_id: we.Workout_id, Slug: m.Slug, Name: m.Name}
+			if !seen[muscle] {
+				seen[muscle] = true
+				muscles = append(muscles, muscle)
+			}
+		}
+	}
+	sort.Slice(muscles, func(i, j int) bool {
+		if muscles[i].Workout_id != muscles[j].Workout_id {
+			return muscles[i].Workout_id < muscles[j].Workout_id
+		}
+		return muscles[i].Name < muscles[j].Name
+	})
+	return muscles, nil
+}
+
+func (f *Fake) ListLibraryExercises(ctx context.Context, userID string, slugs []string, perMuscle int) ([]database.LibraryExercise, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	logged := map[string]int{}
+	for _, set := range f.userSets(userID, func(set database.Workout_session_sets) bool {
+		return set.Exercise_id != nil && !copiedSet(set)
+	}) {
+		logged[*set.Exercise_id]++
+	}
+
+	exercises := []database.LibraryExercise{}
+	for _, slug := range slugs {
+		var offered []database.LibraryExercise
+		for _, e := range f.exercises {
+			if e.Created_by != nil && *e.Created_by != userID {
+				continue
+			}
+			for _, m := range f.primaryMuscles(e.Id) {
+				if m.Slug == slug {
+					offered = append(offered, database.LibraryExercise{Exercises: e, Muscle_slug: slug, Sets_logged: logged[e.Id]})
+					break
+				}
+			}
+		}
+		sort.Slice(offered, func(i, j int) bool {
+			a, b := offered[i], offered[j]
+			if a.Sets_logged != b.Sets_logged {
+				return a.Sets_logged > b.Sets_logged
+			}
+			if a.Name != b.Name {
+				return a.Name < b.Name
+			}
+			return a.Id < b.Id
+		})
+		exercises = append(exercises, offered[:min(len(offered), perMuscle)]...)
+	}
+	return exercises, nil
+}
@@ -0,0 +1,146 @@
+package dbtest
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"fitness-hack/internal/database"
+
+	"github.com/shopspring/decimal"
+)
+
+// defaultListLimit matches the Postgres repositories
+const defaultListLimit = 10
+
+// list filters, orders and pages rows, a pointer to a slice of models, in place. Filters
+// and sorts name db columns; unlike Postgres lists, the fake accepts any column the model
+// has, so tests of which options a list supports belong with the database package.
+func list(rows interface{}, opts database.ListOptions) error {
+	v := reflect.ValueOf(rows).Elem()
+
+	kept := reflect.MakeSlice(v.Type(), 0, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		row := v.Index(i)
+		match := true
+		for name, want := range opts.Filters {
+			value, ok := column(row, name)
+			if !ok {
+				return fmt.Errorf("%w: cannot filter on %q", database.ErrInvalidListOption, name)
+			}
+			if text(value) != want {
+				match = false
+				break
+			}
+		}
+		if match {
+			kept = reflect.Append(kept, row)
+		}
+	}
+
+	// Newest first by default, like every Postgres list; id breaks ties so pages are stable
+	key, desc := "created_at", true
+	if opts.Sort != "" {
+		key = opts.Sort
+		switch strings.ToLower(opts.Order) {
+		case "", "asc":
+			desc = false
+		case "desc":
+		default:
+			return fmt.Errorf("%w: order must be asc or desc", database.ErrInvalidListOption)
+		}
+	}
+	if kept.Len() > 0 {
+		if _, ok := column(kept.Index(0), key); !ok {
+			return fmt.Errorf("%w: cannot sort by %q", database.ErrInvalidListOption, key)
+		}
+	}
+	sort.SliceStable(kept.Interface(), func(i, j int) bool {
+		a, _ := column(kept.Index(i), key)
+		b, _ := column(kept.Index(j), key)
+		if c := compare(a, b); c != 0 {
+			return (c < 0) != desc
+		}
+		idA, _ := column(kept.Index(i), "id")
+		idB, _ := column(kept.Index(j), "id")
+		return text(idA) < text(idB)
+	})
+
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+	start := min(max(opts.Offset, 0), kept.Len())
+	end := min(start+limit, kept.Len())
+	v.Set(kept.Slice(start, end))
+	return nil
+}
+
+// column returns the field of row tagged db:"name", with pointers and interfaces unwrapped.
+// A nil value is returned as the zero reflect.Value.
+func column(row reflect.Value, name string) (reflect.Value, bool) {
+	t := row.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Tag.Get("db") != name {
+			continue
+		}
+		value := row.Field(i)
+		for value.Kind() == reflect.Pointer || value.Kind() == reflect.Interface {
+			if value.IsNil() {
+				return reflect.Value{}, true
+			}
+			value = value.Elem()
+		}
+		return value, true
+	}
+	return reflect.Value{}, false
+}
+
+// text formats a column the way Postgres casts it to text for comparison with a filter
+func text(value reflect.Value) string {
+	if !value.IsValid() {
+		return ""
+	}
+	return fmt.Sprint(value.Interface())
+}
+
+// compare orders two values of the same column, with NULLs last as in an ascending
+// Postgres sort
+func compare(a, b reflect.Value) int {
+	switch {
+	case !a.IsValid() && !b.IsValid():
+		return 0
+	case !a.IsValid():
+		return 1
+	case !b.IsValid():
+		return -1
+	}
+	switch x := a.Interface().(type) {
+	case time.Time:
+		return x.Compare(b.Interface().(time.Time))
+	case decimal.Decimal:
+		return x.Cmp(b.Interface().(decimal.Decimal))
+	}
+	switch a.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return cmpOrdered(a.Int() < b.Int(), a.Int() > b.Int())
+	case reflect.Float32, reflect.Float64:
+		return cmpOrdered(a.Float() < b.Float(), a.Float() > b.Float())
+	case reflect.Bool:
+		return cmpOrdered(!a.Bool() && b.Bool(), a.Bool() && !b.Bool())
+	}
+	return strings.Compare(text(a), text(b))
+}
+
+// cmpOrdered turns the results of < and > into a comparison
+func cmpOrdered(less, greater bool) int {
+	switch {
+	case less:
+		return -1
+	case greater:
+		return 1
+	}
+	return 0
+}
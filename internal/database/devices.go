@@ -0,0 +1,136 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// RegisterDevice saves a device's push token for the user. Tokens identify an app install,
+// so registering a token that belongs to another account moves it to this user.
+func (s *service) RegisterDevice(ctx context.Context, device *Devices) (*Devices, error) {
+	var registered Devices
+	query := `INSERT INTO devices (user_id, platform, token, name)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (token) DO UPDATE SET
+			user_id = EXCLUDED.user_id,
+			platform = EXCLUDED.platform,
+			name = EXCLUDED.name,
+			updated_at = NOW()
+		RETURNING *`
+	err := s.db.GetContext(ctx, &registered, query, device.User_id, device.Platform, device.Token, device.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to register device: %w", err)
+	}
+	return &registered, nil
+}
+
+// ListDevices returns the user's registered devices, most recently registered first
+func (s *service) ListDevices(ctx context.Context, userID string) ([]Devices, error) {
+	devices := []Devices{}
+	query := `SELECT * FROM devices WHERE user_id = $1 ORDER BY updated_at DESC`
+	if err := s.db.SelectContext(ctx, &devices, query, userID); err != nil {
+		return nil, err
+	}
+	return devices, nil
+}
+
+// DeleteDevice unregisters one of the user's devices, returning sql.ErrNoRows if it isn't theirs
+func (s *service) DeleteDevice(ctx context.Context, id, userID string) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM devices WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete device: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// DeleteDeviceByToken forgets a token the push provider reported as no longer valid
+func (s *service) DeleteDeviceByToken(ctx context.Context, token string) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM devices WHERE token = $1`, token); err != nil {
+		return fmt.Errorf("failed to delete device: %w", err)
+	}
+	return nil
+}
+
+// GetNotificationPreferences returns the user's notification settings. Users who never
+// changed them have no row and get the same defaults as the table.
+func (s *service) GetNotificationPreferences(ctx context.Context, userID string) (*Notification_preferences, error) {
+	var prefs Notification_preferences
+	err := s.db.GetContext(ctx, &prefs, `SELECT * FROM notification_preferences WHERE user_id = $1`, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return &Notification_preferences{
+			User_id:             userID,
+			Workout_reminders:   true,
+			Reminder_after_days: 3,
+			Personal_records:    true,
+		}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &prefs, nil
+}
+
+// UpdateNotificationPreferences saves the user's notification settings
+func (s *service) UpdateNotificationPreferences(ctx context.Context, prefs *Notification_preferences) (*Notification_preferences, error) {
+	var updated Notification_preferences
+	query := `INSERT INTO notification_preferences
+			(user_id, workout_reminders, reminder_after_days, personal_records, quiet_hours_start, quiet_hours_end,
+			weekly_summary_email)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (user_id) DO UPDATE SET
+			workout_reminders = EXCLUDED.workout_reminders,
+			reminder_after_days = EXCLUDED.reminder_after_days,
+			personal_records = EXCLUDED.personal_records,
+			quiet_hours_start = EXCLUDED.quiet_hours_start,
+			quiet_hours_end = EXCLUDED.quiet_hours_end,
+			weekly_summary_email = EXCLUDED.weekly_summary_email,
+			updated_at = NOW()
+		RETURNING *`
+	err := s.db.GetContext(ctx, &updated, query,
+		prefs.User_id, prefs.Workout_reminders, prefs.Reminder_after_days, prefs.Personal_records,
+		prefs.Quiet_hours_start, prefs.Quiet_hours_end, prefs.Weekly_summary_email)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update notification preferences: %w", err)
+	}
+	return &updated, nil
+}
+
+// ClaimWorkoutReminders returns up to limit users due a workout reminder and marks them
+// reminded. A user is due once they have gone reminder_after_days without starting a
+// session (counting from signup if they never have), and is reminded once per such stretch.
+// Only users with a registered device and no pending account deletion are considered.
+func (s *service) ClaimWorkoutReminders(ctx context.Context, limit int) ([]string, error) {
+	userIDs := []string{}
+	query := `WITH due AS (
+			SELECT u.id
+			FROM users u
+			LEFT JOIN notification_preferences p ON p.user_id = u.id
+			CROSS JOIN LATERAL (
+				SELECT GREATEST(u.created_at, MAX(ws.started_at)) AS last_active
+				FROM workout_sessions ws
+				WHERE ws.user_id = u.id
+			) activity
+			WHERE COALESCE(p.workout_reminders, TRUE)
+				AND activity.last_active < NOW() - COALESCE(p.reminder_after_days, 3) * INTERVAL '1 day'
+				AND (p.last_reminded_at IS NULL OR p.last_reminded_at < activity.last_active)
+				AND EXISTS (SELECT 1 FROM devices d WHERE d.user_id = u.id)
+				AND NOT EXISTS (SELECT 1 FROM account_deletions ad WHERE ad.user_id = u.id AND ad.status = 'pending')
+			LIMIT $1
+		)
+		INSERT INTO notification_preferences (user_id, last_reminded_at)
+		SELECT id, NOW() FROM due
+		ON CONFLICT (user_id) DO UPDATE SET last_reminded_at = NOW()
+		-- Another instance claiming the same users concurrently has just reminded them
+		WHERE notification_preferences.last_reminded_at IS NULL
+			OR notification_preferences.last_reminded_at < NOW() - INTERVAL '1 hour'
+		RETURNING user_id`
+	if err := s.db.SelectContext(ctx, &userIDs, query, limit); err != nil {
+		return nil, fmt.Errorf("failed to claim workout reminders: %w", err)
+	}
+	return userIDs, nil
+}
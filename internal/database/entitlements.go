@@ -0,0 +1,67 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// GetEntitlement returns the user's entitlements, or sql.ErrNoRows if none were ever granted
+func (s *service) GetEntitlement(ctx context.Context, userID string) (*Entitlements, error) {
+	var entitlement Entitlements
+	query := `SELECT * FROM entitlements WHERE user_id = $1`
+	err := s.db.GetContext(ctx, &entitlement, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &entitlement, nil
+}
+
+// GrantPremium extends the user's premium access by duration, starting from now or
+// from the end of any access they already have
+func (s *service) GrantPremium(ctx context.Context, userID string, duration time.Duration, source string) (*Entitlements, error) {
+	return grantPremium(ctx, s.db, userID, duration, source)
+}
+
+// GetPremiumUntil returns when the user's premium access ends, combining granted
+// entitlements with store subscriptions. Returns nil if the user never had premium.
+func (s *service) GetPremiumUntil(ctx context.Context, userID string) (*time.Time, error) {
+	return premiumUntil(ctx, s.db, userID)
+}
+
+// grantPremium runs the grant against a database or an open transaction
+func grantPremium(ctx context.Context, q sqlx.QueryerContext, userID string, duration time.Duration, source string) (*Entitlements, error) {
+	query := `INSERT INTO entitlements (user_id, premium_until, source, updated_at)
+		VALUES ($1, NOW() + make_interval(secs => $2), $3, NOW())
+		ON CONFLICT (user_id) DO UPDATE SET
+			premium_until = GREATEST(entitlements.premium_until, NOW()) + make_interval(secs => $2),
+			source = EXCLUDED.source,
+			updated_at = NOW()
+		RETURNING *`
+
+	var entitlement Entitlements
+	if err := sqlx.GetContext(ctx, q, &entitlement, query, userID, duration.Seconds(), source); err != nil {
+		return nil, err
+	}
+	return &entitlement, nil
+}
+
+// premiumUntil runs GetPremiumUntil against a database or an open transaction
+func premiumUntil(ctx context.Context, q sqlx.QueryerContext, userID string) (*time.Time, error) {
+	query := `SELECT GREATEST(
+			(SELECT premium_until FROM entitlements WHERE user_id = $1),
+			(SELECT MAX(expires_at) FROM subscriptions
+				WHERE user_id = $1 AND status IN ('active', 'grace', 'canceled'))
+		)`
+
+	var until sql.NullTime
+	if err := sqlx.GetContext(ctx, q, &until, query, userID); err != nil {
+		return nil, err
+	}
+	if !until.Valid {
+		return nil, nil
+	}
+	return &until.Time, nil
+}
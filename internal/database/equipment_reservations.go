@@ -0,0 +1,232 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"fitness-hack/internal/database/sqlbuild"
+)
+
+// Reservation statuses
+const (
+	ReservationBooked    = "booked"
+	ReservationCancelled = "cancelled"
+	ReservationNoShow    = "no_show"
+)
+
+var (
+	// ErrEquipmentExists is returned when the organization already has current equipment with the name
+	ErrEquipmentExists = errors.New("equipment with this name already exists")
+
+	// ErrReservationConflict is returned when a booking overlaps another booking of the same equipment
+	ErrReservationConflict = errors.New("equipment is already booked for part of this time")
+)
+
+// reservationColumns are the reservation columns ListEquipmentReservations filters and orders on
+var reservationColumns = sqlbuild.Columns{
+	"equipment_id": "r.equipment_id",
+	"user_id":      "r.user_id",
+	"status":       "r.status",
+	"starts_at":    "r.starts_at",
+	"ends_at":      "r.ends_at",
+}
+
+// ReservationFilter narrows ListEquipmentReservations. Zero fields don't filter.
+type ReservationFilter struct {
+	EquipmentID string
+	UserID      string
+	Status      string
+
+	// From and To select reservations overlapping [From, To)
+	From time.Time
+	To   time.Time
+}
+
+// CreateGymEquipment adds bookable equipment to an organization. Returns ErrEquipmentExists
+// if the organization already has current equipment of the same name.
+func (s *service) CreateGymEquipment(ctx context.Context, orgID, name string) (*Gym_equipment, error) {
+	var equipment Gym_equipment
+	err := s.db.GetContext(ctx, &equipment, `INSERT INTO gym_equipment (organization_id, name) VALUES ($1, $2)
+		ON CONFLICT (organization_id, lower(name)) WHERE archived_at IS NULL DO NOTHING
+		RETURNING *`, orgID, name)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrEquipmentExists
+		}
+		return nil, fmt.Errorf("failed to create equipment: %w", err)
+	}
+	return &equipment, nil
+}
+
+// ListGymEquipment returns the organization's current equipment by name
+func (s *service) ListGymEquipment(ctx context.Context, orgID string) ([]Gym_equipment, error) {
+	equipment := []Gym_equipment{}
+	query := `SELECT * FROM gym_equipment WHERE organization_id = $1 AND archived_at IS NULL ORDER BY lower(name)`
+	err := s.db.SelectContext(ctx, &equipment, query, orgID)
+	return equipment, err
+}
+
+// ArchiveGymEquipment removes equipment from booking and cancels its upcoming reservations.
+// Past reservations are kept. Returns sql.ErrNoRows if the organization has no such current
+// equipment.
+func (s *service) ArchiveGymEquipment(ctx context.Context, orgID, equipmentID string) error {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `UPDATE gym_equipment SET archived_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND organization_id = $2 AND archived_at IS NULL`, equipmentID, orgID)
+	if err != nil {
+		return fmt.Errorf("failed to archive equipment: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE equipment_reservations SET status = $2, cancelled_at = NOW()
+		WHERE equipment_id = $1 AND status = $3 AND starts_at > NOW()`,
+		equipmentID, ReservationCancelled, ReservationBooked)
+	if err != nil {
+		return fmt.Errorf("failed to cancel reservations of archived equipment: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit equipment archive: %w", err)
+	}
+	return nil
+}
+
+// CreateEquipmentReservation books the equipment for [Starts_at, Ends_at). Returns
+// sql.ErrNoRows if the organization has no such current equipment, and
+// ErrReservationConflict if another booking of it overlaps.
+func (s *service) CreateEquipmentReservation(ctx context.Context, orgID string, reservation *Equipment_reservations) (*Equipment_reservations, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Locking the equipment row makes concurrent bookings of it take turns, so the
+	// overlap check below can't pass for two of them at once
+	var equipmentID string
+	err = tx.GetContext(ctx, &equipmentID, `SELECT id FROM gym_equipment
+		WHERE id = $1 AND organization_id = $2 AND archived_at IS NULL
+		FOR UPDATE`, reservation.Equipment_id, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	var overlapping bool
+	err = tx.GetContext(ctx, &overlapping, `SELECT EXISTS (
+			SELECT 1 FROM equipment_reservations
+			WHERE equipment_id = $1 AND status = $2 AND starts_at < $4 AND ends_at > $3
+		)`, equipmentID, ReservationBooked, reservation.Starts_at, reservation.Ends_at)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check reservation conflicts: %w", err)
+	}
+	if overlapping {
+		return nil, ErrReservationConflict
+	}
+
+	var created Equipment_reservations
+	err = tx.GetContext(ctx, &created, `INSERT INTO equipment_reservations (equipment_id, user_id, starts_at, ends_at)
+		VALUES ($1, $2, $3, $4) RETURNING *`,
+		equipmentID, reservation.User_id, reservation.Starts_at, reservation.Ends_at)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create reservation: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit reservation: %w", err)
+	}
+	return &created, nil
+}
+
+// GetEquipmentReservation returns a reservation of the organization's equipment
+func (s *service) GetEquipmentReservation(ctx context.Context, orgID, id string) (*Equipment_reservations, error) {
+	var reservation Equipment_reservations
+	query := `SELECT r.* FROM equipment_reservations r
+		JOIN gym_equipment e ON e.id = r.equipment_id
+		WHERE r.id = $1 AND e.organization_id = $2`
+	if err := s.db.GetContext(ctx, &reservation, query, id, orgID); err != nil {
+		return nil, err
+	}
+	return &reservation, nil
+}
+
+// ListEquipmentReservations returns the organization's reservations matching filter, in
+// start order
+func (s *service) ListEquipmentReservations(ctx context.Context, orgID string, filter ReservationFilter) ([]Equipment_reservations, error) {
+	q := sqlbuild.New(`SELECT r.* FROM equipment_reservations r
+		JOIN gym_equipment e ON e.id = r.equipment_id
+		WHERE e.organization_id = $1`, orgID)
+	if filter.EquipmentID != "" {
+		q.Filter(reservationColumns, "equipment_id", filter.EquipmentID)
+	}
+	if filter.UserID != "" {
+		q.Filter(reservationColumns, "user_id", filter.UserID)
+	}
+	if filter.Status != "" {
+		q.Filter(reservationColumns, "status", filter.Status)
+	}
+	if !filter.From.IsZero() {
+		q.Compare(reservationColumns, "ends_at", sqlbuild.Gt, filter.From)
+	}
+	if !filter.To.IsZero() {
+		q.Compare(reservationColumns, "starts_at", sqlbuild.Lt, filter.To)
+	}
+	query, args, err := q.OrderBy(reservationColumns, "starts_at", "asc", "r.id", "").Build()
+	if err != nil {
+		return nil, err
+	}
+
+	reservations := []Equipment_reservations{}
+	if err := s.db.SelectContext(ctx, &reservations, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to list reservations: %w", err)
+	}
+	return reservations, nil
+}
+
+// CancelEquipmentReservation cancels a booked reservation.
+// Returns sql.ErrNoRows if the organization has no such booked reservation.
+func (s *service) CancelEquipmentReservation(ctx context.Context, orgID, id string) (*Equipment_reservations, error) {
+	return s.closeEquipmentReservation(ctx, orgID, id, ReservationCancelled)
+}
+
+// MarkEquipmentReservationNoShow records that the member didn't turn up for a booked
+// reservation. Returns sql.ErrNoRows if the organization has no such booked reservation.
+func (s *service) MarkEquipmentReservationNoShow(ctx context.Context, orgID, id string) (*Equipment_reservations, error) {
+	return s.closeEquipmentReservation(ctx, orgID, id, ReservationNoShow)
+}
+
+// closeEquipmentReservation moves a booked reservation to status, freeing its slot
+func (s *service) closeEquipmentReservation(ctx context.Context, orgID, id, status string) (*Equipment_reservations, error) {
+	var reservation Equipment_reservations
+	query := `UPDATE equipment_reservations r
+		SET status = $3, cancelled_at = CASE WHEN $3 = 'cancelled' THEN NOW() END
+		FROM gym_equipment e
+		WHERE r.id = $1 AND e.id = r.equipment_id AND e.organization_id = $2 AND r.status = 'booked'
+		RETURNING r.*`
+	if err := s.db.GetContext(ctx, &reservation, query, id, orgID, status); err != nil {
+		return nil, err
+	}
+	return &reservation, nil
+}
+
+// CountEquipmentNoShows returns how many of the user's reservations at the organization
+// starting since then were marked as no-shows
+func (s *service) CountEquipmentNoShows(ctx context.Context, orgID, userID string, since time.Time) (int, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM equipment_reservations r
+		JOIN gym_equipment e ON e.id = r.equipment_id
+		WHERE e.organization_id = $1 AND r.user_id = $2 AND r.status = $3 AND r.starts_at >= $4`
+	if err := s.db.GetContext(ctx, &count, query, orgID, userID, ReservationNoShow, since); err != nil {
+		return 0, fmt.Errorf("failed to count no-shows: %w", err)
+	}
+	return count, nil
+}
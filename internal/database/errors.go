@@ -0,0 +1,122 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Writes Postgres refuses for breaking a constraint are returned as a *ConstraintError,
+// which errors.Is matches against one of these
+var (
+	// ErrDuplicate is a second row with the same value in a unique column
+	ErrDuplicate = errors.New("duplicate value")
+	// ErrReferenceNotFound is a row that refers to a missing row
+	ErrReferenceNotFound = errors.New("referenced row does not exist")
+	// ErrStillReferenced is a delete or update of a row other rows still refer to
+	ErrStillReferenced = errors.New("row is still referenced")
+	// ErrInvalidValue is a value a CHECK or NOT NULL constraint rejects
+	ErrInvalidValue = errors.New("value violates a constraint")
+)
+
+// ConstraintError is a write Postgres refused because it broke a constraint. Kind is one
+// of ErrDuplicate, ErrReferenceNotFound, ErrStillReferenced or ErrInvalidValue.
+type ConstraintError struct {
+	Kind       error
+	Table      string
+	Constraint string
+	// Column is the column the constraint is on, or "" if it spans several or Postgres
+	// didn't say
+	Column string
+	// Err is the driver's error
+	Err error
+}
+
+func (e *ConstraintError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Kind, e.Constraint)
+}
+
+func (e *ConstraintError) Is(target error) bool {
+	return target == e.Kind
+}
+
+func (e *ConstraintError) Unwrap() error {
+	return e.Err
+}
+
+// constraintKinds are the SQLSTATEs of constraint violations, less foreign keys, whose kind
+// depends on which side of the reference the write was on
+var constraintKinds = map[string]error{
+	"23505": ErrDuplicate,
+	"23514": ErrInvalidValue,
+	"23502": ErrInvalidValue,
+}
+
+// TranslateError returns a *ConstraintError for a constraint violation in err's chain, and
+// err unchanged otherwise. Repositories translate the errors of their writes; the server
+// translates any other error it is handed, so raw driver errors are never shown to users.
+func TranslateError(err error) error {
+	var constraintErr *ConstraintError
+	if errors.As(err, &constraintErr) {
+		return err
+	}
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return err
+	}
+
+	kind, ok := constraintKinds[pgErr.Code]
+	if pgErr.Code == "23503" {
+		// "Key (exercise_id)=(...) is not present in table" on insert, "... is still
+		// referenced from table" on delete
+		kind, ok = ErrReferenceNotFound, true
+		if strings.Contains(pgErr.Detail, "still referenced") {
+			kind = ErrStillReferenced
+		}
+	}
+	if !ok {
+		return err
+	}
+
+	column := pgErr.ColumnName
+	if column == "" {
+		column = keyColumn(pgErr.Detail)
+	}
+	return &ConstraintError{Kind: kind, Table: pgErr.TableName, Constraint: pgErr.ConstraintName, Column: column, Err: pgErr}
+}
+
+// keyColumn returns the column of a violation's detail, such as email in
+// "Key (email)=(ann@example.com) already exists.", or "" if the key has several columns or
+// is an expression
+func keyColumn(detail string) string {
+	rest, ok := strings.CutPrefix(detail, "Key (")
+	if !ok {
+		return ""
+	}
+	column, _, ok := strings.Cut(rest, ")=(")
+	if !ok || strings.ContainsAny(column, ",( ") {
+		return ""
+	}
+	return column
+}
+
+// IsInvalidInput reports whether err is Postgres rejecting a value: text that isn't a valid
+// UUID, number or timestamp, a number out of range, or a failed CHECK or NOT NULL constraint
+func IsInvalidInput(err error) bool {
+	if errors.Is(TranslateError(err), ErrInvalidValue) {
+		return true
+	}
+	// Class 22 is data exceptions
+	return strings.HasPrefix(pgErrorCode(err), "22")
+}
+
+// pgErrorCode returns the SQLSTATE of the Postgres error in err's chain, or "" if it has none
+func pgErrorCode(err error) string {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code
+	}
+	return ""
+}
@@ -0,0 +1,42 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestTranslateError(t *testing.T) {
+	for _, tc := range []struct {
+		err    *pgconn.PgError
+		kind   error
+		column string
+	}{
+		{&pgconn.PgError{Code: "23505", ConstraintName: "users_email_key", Detail: "Key (email)=(ann@example.com) already exists."}, ErrDuplicate, "email"},
+		{&pgconn.PgError{Code: "23505", Detail: "Key (organization_id, lower(name))=(o1, rack) already exists."}, ErrDuplicate, ""},
+		{&pgconn.PgError{Code: "23503", Detail: `Key (exercise_id)=(e1) is not present in table "exercises".`}, ErrReferenceNotFound, "exercise_id"},
+		{&pgconn.PgError{Code: "23503", Detail: `Key (id)=(w1) is still referenced from table "workout_sessions".`}, ErrStillReferenced, "id"},
+		{&pgconn.PgError{Code: "23514", ConstraintName: "reminders_days_check"}, ErrInvalidValue, ""},
+		{&pgconn.PgError{Code: "23502", ColumnName: "name"}, ErrInvalidValue, "name"},
+	} {
+		err := TranslateError(fmt.Errorf("failed to insert: %w", tc.err))
+		var constraintErr *ConstraintError
+		if !errors.As(err, &constraintErr) || !errors.Is(err, tc.kind) || constraintErr.Column != tc.column {
+			t.Errorf("expected %v on %q for %s, got %#v", tc.kind, tc.column, tc.err.Code, err)
+		}
+		if !errors.Is(err, tc.err) {
+			t.Errorf("expected the driver error kept in the chain of %v", err)
+		}
+	}
+
+	for _, err := range []error{nil, errors.New("connection refused"), &pgconn.PgError{Code: "40001"}} {
+		if got := TranslateError(err); got != err {
+			t.Errorf("expected %v unchanged, got %v", err, got)
+		}
+	}
+	if !IsInvalidInput(&pgconn.PgError{Code: "22P02"}) || !IsInvalidInput(&pgconn.PgError{Code: "23514"}) {
+		t.Error("expected data exceptions and check violations to be invalid input")
+	}
+}
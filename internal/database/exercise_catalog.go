@@ -0,0 +1,158 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ErrMissingExternalID is returned when an exercise passed to UpsertExercises has no catalog key
+var ErrMissingExternalID = errors.New("exercise has no source and external ID")
+
+// exerciseUpsertBatch keeps each statement well under Postgres' 65535 parameter limit
+const exerciseUpsertBatch = 1000
+
+// CatalogExercise is an exercise from an upstream catalog with the muscles it works
+type CatalogExercise struct {
+	Exercises
+	Muscles []MuscleAssignment
+}
+
+// UpsertExercisesResult counts what a catalog sync changed
+type UpsertExercisesResult struct {
+	Inserted  int
+	Updated   int
+	Unchanged int
+	// ChangedIDs are the IDs of inserted and updated exercises, for cache invalidation
+	ChangedIDs []string
+}
+
+// UpsertExercises inserts or updates catalog exercises keyed by source and external ID, in
+// batches of multi-row INSERT ... ON CONFLICT statements inside one transaction. Rows whose
+// fields and muscles already match are left untouched, so repeating a sync changes nothing,
+// not even updated_at. A key may only appear once per call. Returns ErrUnknownMuscle if a
+// muscle isn't in muscle_groups, without changing anything.
+func (r *exerciseRepository) UpsertExercises(ctx context.Context, exercises []CatalogExercise) (*UpsertExercisesResult, error) {
+	for i := range exercises {
+		if exercises[i].Source == "" || exercises[i].External_id == "" {
+			return nil, fmt.Errorf("%w: exercise %d", ErrMissingExternalID, i)
+		}
+	}
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin exercise upsert: %w", err)
+	}
+	defer tx.Rollback()
+
+	result := &UpsertExercisesResult{ChangedIDs: []string{}}
+	changed := map[string]bool{}
+	for start := 0; start < len(exercises); start += exerciseUpsertBatch {
+		batch := exercises[start:min(start+exerciseUpsertBatch, len(exercises))]
+
+		var values strings.Builder
+		args := make([]interface{}, 0, len(batch)*7)
+		for i, e := range batch {
+			if i > 0 {
+				values.WriteString(", ")
+			}
+			n := len(args)
+			fmt.Fprintf(&values, "($%d, $%d, $%d, $%d, $%d, $%d, $%d)", n+1, n+2, n+3, n+4, n+5, n+6, n+7)
+			args = append(args, e.Source, e.External_id, e.Name, e.Description,
+				e.Equipment, e.Difficulty_level, e.Instructions)
+		}
+
+		query := `INSERT INTO exercises AS e
+				(source, external_id, name, description, equipment, difficulty_level, instructions)
+			VALUES ` + values.String() + `
+			ON CONFLICT (source, external_id) WHERE external_id <> '' DO UPDATE SET
+				name = EXCLUDED.name,
+				description = EXCLUDED.description,
+				equipment = EXCLUDED.equipment,
+				difficulty_level = EXCLUDED.difficulty_level,
+				instructions = EXCLUDED.instructions,
+				updated_at = NOW()
+			WHERE (e.name, e.description, e.equipment, e.difficulty_level, e.instructions)
+				IS DISTINCT FROM (EXCLUDED.name, EXCLUDED.description,
+					EXCLUDED.equipment, EXCLUDED.difficulty_level, EXCLUDED.instructions)
+			RETURNING e.id, (xmax = 0) AS inserted`
+
+		var rows []struct {
+			Id       string `db:"id"`
+			Inserted bool   `db:"inserted"`
+		}
+		if err := tx.SelectContext(ctx, &rows, query, args...); err != nil {
+			return nil, fmt.Errorf("failed to upsert exercises: %w", err)
+		}
+		for _, row := range rows {
+			if row.Inserted {
+				result.Inserted++
+			} else {
+				result.Updated++
+			}
+			result.ChangedIDs = append(result.ChangedIDs, row.Id)
+			changed[row.Id] = true
+		}
+		result.Unchanged += len(batch) - len(rows)
+	}
+
+	// Exercises whose muscles are all that changed count as updated too
+	musclesChanged, err := r.syncCatalogMuscles(ctx, tx, exercises)
+	if err != nil {
+		return nil, err
+	}
+	var touched []string
+	for _, id := range musclesChanged {
+		if !changed[id] {
+			touched = append(touched, id)
+		}
+	}
+	if len(touched) > 0 {
+		if _, err := tx.ExecContext(ctx, `UPDATE exercises SET updated_at = NOW() WHERE id = ANY($1)`, touched); err != nil {
+			return nil, fmt.Errorf("failed to touch exercises: %w", err)
+		}
+		result.Updated += len(touched)
+		result.Unchanged -= len(touched)
+		result.ChangedIDs = append(result.ChangedIDs, touched...)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit exercise upsert: %w", err)
+	}
+	return result, nil
+}
+
+// syncCatalogMuscles sets the muscles of the catalog exercises, which must already be
+// stored, returning the IDs of those whose muscles changed
+func (r *exerciseRepository) syncCatalogMuscles(ctx context.Context, tx *sqlx.Tx, exercises []CatalogExercise) ([]string, error) {
+	sources := make([]string, len(exercises))
+	externalIDs := make([]string, len(exercises))
+	for i, e := range exercises {
+		sources[i], externalIDs[i] = e.Source, e.External_id
+	}
+
+	var keys []struct {
+		Id          string `db:"id"`
+		Source      string `db:"source"`
+		External_id string `db:"external_id"`
+	}
+	err := tx.SelectContext(ctx, &keys, `SELECT e.id, e.source, e.external_id
+		FROM exercises e JOIN unnest($1::text[], $2::text[]) AS k(source, external_id)
+			ON e.source = k.source AND e.external_id = k.external_id`, sources, externalIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up catalog exercises: %w", err)
+	}
+	ids := make(map[[2]string]string, len(keys))
+	for _, k := range keys {
+		ids[[2]string{k.Source, k.External_id}] = k.Id
+	}
+
+	wanted := make(map[string][]MuscleAssignment, len(exercises))
+	for _, e := range exercises {
+		wanted[ids[[2]string{e.Source, e.External_id}]] = e.Muscles
+	}
+	return syncExerciseMuscles(ctx, tx, wanted)
+}
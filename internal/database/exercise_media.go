@@ -0,0 +1,127 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// ErrMediaOrderMismatch is returned when a new media order doesn't list exactly the
+// exercise's ready media
+var ErrMediaOrderMismatch = errors.New("media order must list each of the exercise's media once")
+
+// Every change to an exercise's media touches the exercise, so its ETag and cached copies
+// change with it.
+const touchExercise = `touched AS (UPDATE exercises SET updated_at = NOW() WHERE id = $1)`
+
+// CreateExerciseMedia attaches media to the end of the exercise's media
+func (r *exerciseRepository) CreateExerciseMedia(ctx context.Context, media *Exercise_media) (*Exercise_media, error) {
+	var created Exercise_media
+	err := r.db.GetContext(ctx, &created, `WITH created AS (
+			INSERT INTO exercise_media (id, exercise_id, kind, status, url, storage_key, content_type, caption, position)
+			SELECT $2, $1, $3, $4, $5, $6, $7, $8, COALESCE(MAX(position) + 1, 0)
+			FROM exercise_media WHERE exercise_id = $1
+			RETURNING *
+		), `+touchExercise+`
+		SELECT * FROM created`,
+		media.Exercise_id, media.Id, media.Kind, media.Status, media.Url, media.Storage_key, media.Content_type, media.Caption)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create exercise media: %w", err)
+	}
+	return &created, nil
+}
+
+// GetExerciseMedia returns one of the exercise's media, or sql.ErrNoRows
+func (r *exerciseRepository) GetExerciseMedia(ctx context.Context, exerciseID, mediaID string) (*Exercise_media, error) {
+	var media Exercise_media
+	err := r.db.GetContext(ctx, &media, `SELECT * FROM exercise_media WHERE exercise_id = $1 AND id = $2`, exerciseID, mediaID)
+	if err != nil {
+		return nil, err
+	}
+	return &media, nil
+}
+
+// ListExerciseMedia returns the media of the exercises, pending uploads included, in
+// position order
+func (r *exerciseRepository) ListExerciseMedia(ctx context.Context, exerciseIDs []string) ([]Exercise_media, error) {
+	media := []Exercise_media{}
+	if len(exerciseIDs) == 0 {
+		return media, nil
+	}
+	err := r.db.SelectContext(ctx, &media, `SELECT * FROM exercise_media WHERE exercise_id = ANY($1)
+		ORDER BY exercise_id, position, created_at, id`, exerciseIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list exercise media: %w", err)
+	}
+	return media, nil
+}
+
+// MarkExerciseMediaReady shows an uploaded media once its upload is confirmed. Returns
+// sql.ErrNoRows if the exercise has no such media.
+func (r *exerciseRepository) MarkExerciseMediaReady(ctx context.Context, exerciseID, mediaID string) (*Exercise_media, error) {
+	var media Exercise_media
+	err := r.db.GetContext(ctx, &media, `WITH updated AS (
+			UPDATE exercise_media SET status = 'ready' WHERE exercise_id = $1 AND id = $2 RETURNING *
+		), `+touchExercise+`
+		SELECT * FROM updated`, exerciseID, mediaID)
+	if err != nil {
+		return nil, err
+	}
+	return &media, nil
+}
+
+// ReorderExerciseMedia puts the exercise's ready media in the order of mediaIDs. Returns
+// ErrMediaOrderMismatch unless mediaIDs lists each of them exactly once.
+func (r *exerciseRepository) ReorderExerciseMedia(ctx context.Context, exerciseID string, mediaIDs []string) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin media reorder: %w", err)
+	}
+	defer tx.Rollback()
+
+	var current []string
+	err = tx.SelectContext(ctx, &current, `SELECT id FROM exercise_media WHERE exercise_id = $1 AND status = 'ready'
+		ORDER BY id FOR UPDATE`, exerciseID)
+	if err != nil {
+		return fmt.Errorf("failed to lock exercise media: %w", err)
+	}
+	requested := append([]string(nil), mediaIDs...)
+	sort.Strings(requested)
+	if len(requested) != len(current) {
+		return ErrMediaOrderMismatch
+	}
+	for i := range requested {
+		if requested[i] != current[i] {
+			return ErrMediaOrderMismatch
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `WITH reordered AS (
+			UPDATE exercise_media m SET position = o.position - 1
+			FROM unnest($2::uuid[]) WITH ORDINALITY AS o(id, position)
+			WHERE m.exercise_id = $1 AND m.id = o.id
+		), `+touchExercise+`
+		SELECT 1`, exerciseID, mediaIDs)
+	if err != nil {
+		return fmt.Errorf("failed to reorder exercise media: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit media reorder: %w", err)
+	}
+	return nil
+}
+
+// DeleteExerciseMedia detaches media from the exercise, returning it so an uploaded
+// object can be removed from storage. Returns sql.ErrNoRows if there is no such media.
+func (r *exerciseRepository) DeleteExerciseMedia(ctx context.Context, exerciseID, mediaID string) (*Exercise_media, error) {
+	var media Exercise_media
+	err := r.db.GetContext(ctx, &media, `WITH deleted AS (
+			DELETE FROM exercise_media WHERE exercise_id = $1 AND id = $2 RETURNING *
+		), `+touchExercise+`
+		SELECT * FROM deleted`, exerciseID, mediaID)
+	if err != nil {
+		return nil, err
+	}
+	return &media, nil
+}
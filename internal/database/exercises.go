@@ -0,0 +1,150 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ExerciseRepository stores the exercise catalog
+type ExerciseRepository interface {
+	CreateExercise(ctx context.Context, exercise *Exercises) (*Exercises, error)
+	GetExerciseByID(ctx context.Context, id string) (*Exercises, error)
+	GetExercisesByIDs(ctx context.Context, ids []string) ([]Exercises, error)
+	ListExercises(ctx context.Context, opts ListExercisesOpts) ([]Exercises, error)
+	ListExerciseNames(ctx context.Context) ([]ExerciseName, error)
+	UpdateExercise(ctx context.Context, exercise *Exercises) (*Exercises, error)
+	DeleteExercise(ctx context.Context, id string) error
+	UpsertExercises(ctx context.Context, exercises []CatalogExercise) (*UpsertExercisesResult, error)
+	ListMuscleGroups(ctx context.Context) ([]Muscle_groups, error)
+	ListExerciseMuscles(ctx context.Context, exerciseIDs []string) ([]ExerciseMuscle, error)
+	SetExerciseMuscles(ctx context.Context, exerciseID string, muscles []MuscleAssignment) error
+	CreateExerciseMedia(ctx context.Context, media *Exercise_media) (*Exercise_media, error)
+	GetExerciseMedia(ctx context.Context, exerciseID, mediaID string) (*Exercise_media, error)
+	ListExerciseMedia(ctx context.Context, exerciseIDs []string) ([]Exercise_media, error)
+	MarkExerciseMediaReady(ctx context.Context, exerciseID, mediaID string) (*Exercise_media, error)
+	ReorderExerciseMedia(ctx context.Context, exerciseID string, mediaIDs []string) error
+	DeleteExerciseMedia(ctx context.Context, exerciseID, mediaID string) (*Exercise_media, error)
+}
+
+type exerciseRepository struct {
+	db     *sqlx.DB
+	quotas *quotaChecker
+}
+
+// NewExerciseRepository returns a ExerciseRepository that uses db
+func NewExerciseRepository(db *sqlx.DB) ExerciseRepository {
+	return &exerciseRepository{db: db}
+}
+
+// CreateExercise saves a new exercise. Exercises with Created_by set count towards that
+// user's custom exercise quota; a *QuotaError is returned once it is used up.
+func (r *exerciseRepository) CreateExercise(ctx context.Context, exercise *Exercises) (*Exercises, error) {
+	if exercise.Created_by != nil {
+		if err := r.quotas.check(ctx, *exercise.Created_by, QuotaCustomExercises, 1); err != nil {
+			return nil, err
+		}
+	}
+	query := `INSERT INTO exercises (id, name, description, equipment, difficulty_level, instructions, created_at, updated_at, created_by)
+		VALUES (:id, :name, :description, :equipment, :difficulty_level, :instructions, :created_at, :updated_at, :created_by)
+		RETURNING *`
+	row, err := r.db.NamedQueryContext(ctx, query, exercise)
+	if err != nil {
+		return nil, TranslateError(err)
+	}
+	defer row.Close()
+	if row.Next() {
+		var created Exercises
+		if err := row.StructScan(&created); err != nil {
+			return nil, err
+		}
+		return &created, nil
+	}
+	if err := row.Err(); err != nil {
+		return nil, TranslateError(err)
+	}
+	return nil, fmt.Errorf("failed to insert exercise")
+}
+
+func (r *exerciseRepository) GetExerciseByID(ctx context.Context, id string) (*Exercises, error) {
+	var exercise Exercises
+	query := `SELECT * FROM exercises WHERE id = $1`
+	err := r.db.GetContext(ctx, &exercise, query, id)
+	if err != nil {
+		return nil, err
+	}
+	return &exercise, nil
+}
+
+// GetExercisesByIDs returns the exercises with the IDs in one query, in no particular order.
+// IDs without an exercise are left out.
+func (r *exerciseRepository) GetExercisesByIDs(ctx context.Context, ids []string) ([]Exercises, error) {
+	exercises := []Exercises{}
+	if len(ids) == 0 {
+		return exercises, nil
+	}
+	query, args, err := sqlx.In(`SELECT * FROM exercises WHERE id IN (?)`, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get exercises: %w", err)
+	}
+	if err := r.db.SelectContext(ctx, &exercises, r.db.Rebind(query), args...); err != nil {
+		return nil, fmt.Errorf("failed to get exercises: %w", err)
+	}
+	return exercises, nil
+}
+
+// ListExercises lists the exercises matching opts, newest first by default
+func (r *exerciseRepository) ListExercises(ctx context.Context, opts ListExercisesOpts) ([]Exercises, error) {
+	query, args, err := opts.query()
+	if err != nil {
+		return nil, err
+	}
+	var rows []Exercises
+	err = r.db.SelectContext(ctx, &rows, query, args...)
+	return rows, err
+}
+
+// ExerciseName is the ID and name of a catalog exercise
+type ExerciseName struct {
+	Id   string `db:"id"`
+	Name string `db:"name"`
+}
+
+// ListExerciseNames returns the name of every exercise in the catalog, for matching names
+// from other apps against. Unlike ListExercises it isn't paged.
+func (r *exerciseRepository) ListExerciseNames(ctx context.Context) ([]ExerciseName, error) {
+	names := []ExerciseName{}
+	if err := r.db.SelectContext(ctx, &names, `SELECT id, name FROM exercises ORDER BY name, id`); err != nil {
+		return nil, fmt.Errorf("failed to list exercise names: %w", err)
+	}
+	return names, nil
+}
+
+func (r *exerciseRepository) UpdateExercise(ctx context.Context, exercise *Exercises) (*Exercises, error) {
+	query := `UPDATE exercises SET name=:name, description=:description, equipment=:equipment, difficulty_level=:difficulty_level, instructions=:instructions, updated_at=:updated_at, version=version+1 WHERE id=:id AND version=:version RETURNING *`
+	row, err := r.db.NamedQueryContext(ctx, query, exercise)
+	if err != nil {
+		return nil, TranslateError(err)
+	}
+	defer row.Close()
+	if row.Next() {
+		var updated Exercises
+		if err := row.StructScan(&updated); err != nil {
+			return nil, err
+		}
+		return &updated, nil
+	}
+	if err := row.Err(); err != nil {
+		return nil, TranslateError(err)
+	}
+	return nil, ErrVersionConflict
+}
+
+func (r *exerciseRepository) DeleteExercise(ctx context.Context, id string) error {
+	query := `DELETE FROM exercises WHERE id = $1`
+	_, err := r.db.ExecContext(ctx, query, id)
+	return TranslateError(err)
+}
+
+// --- WORKOUT_EXERCISES CRUD ---
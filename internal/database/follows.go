@@ -0,0 +1,183 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// FollowedUser is a user on one side of a follow, with when the follow started
+type FollowedUser struct {
+	User_id     string    `db:"user_id"`
+	Username    string    `db:"username"`
+	Followed_at time.Time `db:"followed_at"`
+}
+
+// FeedItem is a completed session or personal record of a followed user. The session
+// columns are set for sessions and the record columns for personal records.
+type FeedItem struct {
+	Kind        string    `db:"kind"`
+	Item_id     string    `db:"item_id"`
+	Occurred_at time.Time `db:"occurred_at"`
+	User_id     string    `db:"user_id"`
+	Username    string    `db:"username"`
+
+	Session_id       *string `db:"session_id"`
+	Session_name     *string `db:"session_name"`
+	Duration_minutes *int    `db:"duration_minutes"`
+
+	Exercise_id      *string          `db:"exercise_id"`
+	Exercise_name    *string          `db:"exercise_name"`
+	Weight_kg        *decimal.Decimal `db:"weight_kg"`
+	Reps             *int             `db:"reps"`
+	Previous_best_kg *decimal.Decimal `db:"previous_best_kg"`
+}
+
+// FollowUser makes the follower follow the followee, returning the existing follow if
+// they already do
+func (s *service) FollowUser(ctx context.Context, followerID, followeeID string) (*Follows, error) {
+	var follow Follows
+	query := `INSERT INTO follows (follower_id, followee_id) VALUES ($1, $2)
+		ON CONFLICT (follower_id, followee_id) DO UPDATE SET created_at = follows.created_at
+		RETURNING *`
+	if err := s.db.GetContext(ctx, &follow, query, followerID, followeeID); err != nil {
+		return nil, fmt.Errorf("failed to follow user: %w", err)
+	}
+	return &follow, nil
+}
+
+// UnfollowUser stops the follower following the followee, returning sql.ErrNoRows if they
+// didn't
+func (s *service) UnfollowUser(ctx context.Context, followerID, followeeID string) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM follows WHERE follower_id = $1 AND followee_id = $2`, followerID, followeeID)
+	if err != nil {
+		return fmt.Errorf("failed to unfollow user: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// ListFollowing returns the users the user follows, most recently followed first
+func (s *service) ListFollowing(ctx context.Context, userID string) ([]FollowedUser, error) {
+	users := []FollowedUser{}
+	query := `SELECT u.id AS user_id, u.username, f.created_at AS followed_at
+		FROM follows f
+		JOIN users u ON u.id = f.followee_id
+		WHERE f.follower_id = $1
+		ORDER BY f.created_at DESC, u.id`
+	if err := s.db.SelectContext(ctx, &users, query, userID); err != nil {
+		return nil, fmt.Errorf("failed to list followed users: %w", err)
+	}
+	return users, nil
+}
+
+// ListFollowers returns the users following the user, most recent first
+func (s *service) ListFollowers(ctx context.Context, userID string) ([]FollowedUser, error) {
+	users := []FollowedUser{}
+	query := `SELECT u.id AS user_id, u.username, f.created_at AS followed_at
+		FROM follows f
+		JOIN users u ON u.id = f.follower_id
+		WHERE f.followee_id = $1
+		ORDER BY f.created_at DESC, u.id`
+	if err := s.db.SelectContext(ctx, &users, query, userID); err != nil {
+		return nil, fmt.Errorf("failed to list followers: %w", err)
+	}
+	return users, nil
+}
+
+// GetPrivacySettings returns the user's privacy settings. Users who never changed them
+// share everything with followers but stay off leaderboards.
+func (s *service) GetPrivacySettings(ctx context.Context, userID string) (*Privacy_settings, error) {
+	var settings Privacy_settings
+	err := s.db.GetContext(ctx, &settings, `SELECT * FROM privacy_settings WHERE user_id = $1`, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return &Privacy_settings{
+			User_id:                userID,
+			Allow_followers:        true,
+			Share_sessions:         true,
+			Share_personal_records: true,
+		}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+// UpdatePrivacySettings saves the user's privacy settings
+func (s *service) UpdatePrivacySettings(ctx context.Context, settings *Privacy_settings) (*Privacy_settings, error) {
+	var updated Privacy_settings
+	query := `INSERT INTO privacy_settings (user_id, allow_followers, share_sessions, share_personal_records, show_on_leaderboards)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id) DO UPDATE SET
+			allow_followers = EXCLUDED.allow_followers,
+			share_sessions = EXCLUDED.share_sessions,
+			share_personal_records = EXCLUDED.share_personal_records,
+			show_on_leaderboards = EXCLUDED.show_on_leaderboards,
+			updated_at = NOW()
+		RETURNING *`
+	err := s.db.GetContext(ctx, &updated, query,
+		settings.User_id, settings.Allow_followers, settings.Share_sessions, settings.Share_personal_records,
+		settings.Show_on_leaderboards)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update privacy settings: %w", err)
+	}
+	return &updated, nil
+}
+
+// ListFeed returns up to limit of the newest completed sessions and personal records of
+// the users the user follows from before the given time, leaving out what each of them
+// doesn't share. The feed is gathered when read rather than written to followers as it
+// happens, so privacy changes and unfollows apply to past items too.
+func (s *service) ListFeed(ctx context.Context, userID string, before time.Time, limit int) ([]FeedItem, error) {
+	items := []FeedItem{}
+	query := `WITH followed AS (
+			SELECT u.id AS user_id, u.username,
+				COALESCE(ps.share_sessions, TRUE) AS share_sessions,
+				COALESCE(ps.share_personal_records, TRUE) AS share_personal_records
+			FROM follows f
+			JOIN users u ON u.id = f.followee_id
+			LEFT JOIN privacy_settings ps ON ps.user_id = f.followee_id
+			WHERE f.follower_id = $1
+		)
+		(SELECT 'session' AS kind, ws.id AS item_id, ws.completed_at AS occurred_at, fu.user_id, fu.username,
+				ws.id AS session_id, ws.name AS session_name, ws.duration_minutes,
+				NULL::uuid AS exercise_id, NULL::text AS exercise_name, NULL::numeric AS weight_kg,
+				NULL::integer AS reps, NULL::numeric AS previous_best_kg
+			FROM followed fu
+			JOIN workout_sessions ws ON ws.user_id = fu.user_id
+			WHERE fu.share_sessions AND ws.completed_at IS NOT NULL AND ws.completed_at < $2
+			ORDER BY ws.completed_at DESC
+			LIMIT $3)
+		UNION ALL
+		(SELECT 'personal_record', we.id, we.created_at, fu.user_id, fu.username,
+				NULL, NULL, NULL,
+				we.exercise_id, e.name, we.weight_kg, we.reps, best.weight_kg
+			FROM followed fu
+			JOIN workouts w ON w.user_id = fu.user_id AND NOT w.is_template
+			JOIN workout_exercises we ON we.workout_id = w.id
+			JOIN exercises e ON e.id = we.exercise_id
+			CROSS JOIN LATERAL (
+				SELECT MAX(o.weight_kg) AS weight_kg
+				FROM workout_exercises o
+				JOIN workouts ow ON ow.id = o.workout_id
+				WHERE ow.user_id = w.user_id AND NOT ow.is_template
+					AND o.exercise_id = we.exercise_id AND o.created_at < we.created_at
+			) best
+			WHERE fu.share_personal_records AND we.created_at < $2
+				AND best.weight_kg > 0 AND we.weight_kg > best.weight_kg
+			ORDER BY we.created_at DESC
+			LIMIT $3)
+		ORDER BY occurred_at DESC, item_id DESC
+		LIMIT $3`
+	if err := s.db.SelectContext(ctx, &items, query, userID, before, limit); err != nil {
+		return nil, fmt.Errorf("failed to list feed: %w", err)
+	}
+	return items, nil
+}
@@ -0,0 +1,71 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// CreateGuestAccount creates a user and marks it as a guest expiring at expiresAt
+func (s *service) CreateGuestAccount(ctx context.Context, user *Users, expiresAt time.Time) (*Users, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO users (email, username, password_hash, first_name, last_name, created_at, updated_at)
+		VALUES (:email, :username, :password_hash, :first_name, :last_name, :created_at, :updated_at)
+		RETURNING *`
+	query, args, err := tx.BindNamed(query, user)
+	if err != nil {
+		return nil, err
+	}
+
+	var created Users
+	if err := tx.QueryRowxContext(ctx, query, args...).StructScan(&created); err != nil {
+		return nil, fmt.Errorf("failed to insert guest user: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO guest_accounts (user_id, expires_at) VALUES ($1, $2)`, created.Id, expiresAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record guest account: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// DeleteExpiredGuestAccounts purges guest users that expired before the given time,
+// along with everything they created. It returns the IDs of the deleted users.
+func (s *service) DeleteExpiredGuestAccounts(ctx context.Context, before time.Time) ([]string, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	expired := `SELECT user_id FROM guest_accounts WHERE expires_at < $1`
+
+	// Child tables are cleared explicitly rather than relying on ON DELETE CASCADE,
+	// since recreating the users table in 007 dropped those foreign keys.
+	for _, table := range []string{"workout_sessions", "workouts", "programs"} {
+		query := fmt.Sprintf(`DELETE FROM %s WHERE user_id IN (%s)`, table, expired)
+		if _, err := tx.ExecContext(ctx, query, before); err != nil {
+			return nil, fmt.Errorf("failed to purge guest %s: %w", table, err)
+		}
+	}
+
+	var ids []string
+	query := fmt.Sprintf(`DELETE FROM users WHERE id IN (%s) RETURNING id`, expired)
+	if err := tx.SelectContext(ctx, &ids, query, before); err != nil {
+		return nil, fmt.Errorf("failed to purge guest users: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
@@ -0,0 +1,118 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// OccupancyHour is how many members visited an organization during one hour of the week,
+// averaged over the weeks in the requested range
+type OccupancyHour struct {
+	// Weekday is the ISO day of the week, 1 for Monday through 7 for Sunday
+	Weekday         int     `db:"weekday"`
+	Hour            int     `db:"hour"`
+	AverageVisitors float64 `db:"average_visitors"`
+	PeakVisitors    int     `db:"peak_visitors"`
+}
+
+// CheckInGymVisit opens a visit for the user at the organization. If the user is already
+// checked in, the open visit is returned with created false. Visits left open for longer
+// than maxStay are closed at check-in plus maxStay first, so a forgotten check-out doesn't
+// keep the member checked in forever.
+func (s *service) CheckInGymVisit(ctx context.Context, orgID, userID string, maxStay time.Duration) (*Gym_visits, bool, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `UPDATE gym_visits
+		SET checked_out_at = checked_in_at + $3 * INTERVAL '1 second'
+		WHERE organization_id = $1 AND user_id = $2 AND checked_out_at IS NULL
+			AND checked_in_at < NOW() - $3 * INTERVAL '1 second'`,
+		orgID, userID, maxStay.Seconds())
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to close stale gym visits: %w", err)
+	}
+
+	var visit Gym_visits
+	created := true
+	err = tx.GetContext(ctx, &visit, `INSERT INTO gym_visits (organization_id, user_id) VALUES ($1, $2)
+		ON CONFLICT (organization_id, user_id) WHERE checked_out_at IS NULL DO NOTHING
+		RETURNING *`, orgID, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		created = false
+		err = tx.GetContext(ctx, &visit, `SELECT * FROM gym_visits
+			WHERE organization_id = $1 AND user_id = $2 AND checked_out_at IS NULL`, orgID, userID)
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to check in: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, false, fmt.Errorf("failed to commit check-in: %w", err)
+	}
+	return &visit, created, nil
+}
+
+// CheckOutGymVisit closes the user's open visit at the organization.
+// Returns sql.ErrNoRows if the user is not checked in.
+func (s *service) CheckOutGymVisit(ctx context.Context, orgID, userID string) (*Gym_visits, error) {
+	var visit Gym_visits
+	err := s.db.GetContext(ctx, &visit, `UPDATE gym_visits SET checked_out_at = NOW()
+		WHERE organization_id = $1 AND user_id = $2 AND checked_out_at IS NULL
+		RETURNING *`, orgID, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &visit, nil
+}
+
+// CountOpenGymVisits returns how many members are checked in at the organization,
+// leaving out visits open for longer than maxStay
+func (s *service) CountOpenGymVisits(ctx context.Context, orgID string, maxStay time.Duration) (int, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM gym_visits
+		WHERE organization_id = $1 AND checked_out_at IS NULL
+			AND checked_in_at >= NOW() - $2 * INTERVAL '1 second'`
+	if err := s.db.GetContext(ctx, &count, query, orgID, maxStay.Seconds()); err != nil {
+		return 0, fmt.Errorf("failed to count open gym visits: %w", err)
+	}
+	return count, nil
+}
+
+// GetHourlyOccupancy counts the members at the organization during each hour of [from, to)
+// in the time zone tz, then aggregates the counts by weekday and hour. A visit counts in
+// every hour it overlaps; visits without a check-out, or longer than maxStay, end maxStay
+// after check-in.
+func (s *service) GetHourlyOccupancy(ctx context.Context, orgID string, from, to time.Time, tz string, maxStay time.Duration) ([]OccupancyHour, error) {
+	query := `WITH hours AS (
+			SELECT local_hour, local_hour AT TIME ZONE $4 AS starts_at
+			FROM generate_series(date_trunc('hour', $2::timestamptz AT TIME ZONE $4),
+				$3::timestamptz AT TIME ZONE $4 - INTERVAL '1 hour', INTERVAL '1 hour') AS local_hour
+		),
+		counts AS (
+			SELECT h.local_hour, COUNT(v.id) AS visitors
+			FROM hours h
+			LEFT JOIN gym_visits v ON v.organization_id = $1
+				AND v.checked_in_at < h.starts_at + INTERVAL '1 hour'
+				AND LEAST(COALESCE(v.checked_out_at, NOW()), v.checked_in_at + $5 * INTERVAL '1 second') > h.starts_at
+			GROUP BY h.local_hour
+		)
+		SELECT EXTRACT(ISODOW FROM local_hour)::int AS weekday,
+			EXTRACT(HOUR FROM local_hour)::int AS hour,
+			AVG(visitors)::float8 AS average_visitors,
+			MAX(visitors) AS peak_visitors
+		FROM counts
+		GROUP BY 1, 2
+		ORDER BY 1, 2`
+
+	hours := []OccupancyHour{}
+	if err := s.db.SelectContext(ctx, &hours, query, orgID, from, to, tz, maxStay.Seconds()); err != nil {
+		return nil, fmt.Errorf("failed to aggregate gym occupancy: %w", err)
+	}
+	return hours, nil
+}
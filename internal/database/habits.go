@@ -0,0 +1,63 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// UpsertDailyHabits saves the user's habits for habits.Day. Habits that are nil keep the
+// value already logged for the day.
+func (s *service) UpsertDailyHabits(ctx context.Context, habits *Daily_habits) (*Daily_habits, error) {
+	var saved Daily_habits
+	query := `INSERT INTO daily_habits (user_id, day, water_ml, sleep_hours, steps)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id, day) DO UPDATE SET
+			water_ml = COALESCE(EXCLUDED.water_ml, daily_habits.water_ml),
+			sleep_hours = COALESCE(EXCLUDED.sleep_hours, daily_habits.sleep_hours),
+			steps = COALESCE(EXCLUDED.steps, daily_habits.steps),
+			updated_at = NOW()
+		RETURNING *`
+	err := s.db.GetContext(ctx, &saved, query,
+		habits.User_id, habits.Day.Format(time.DateOnly), habits.Water_ml, habits.Sleep_hours, habits.Steps)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save daily habits: %w", err)
+	}
+	return &saved, nil
+}
+
+// GetDailyHabits returns the user's habits for the day, or sql.ErrNoRows
+func (s *service) GetDailyHabits(ctx context.Context, userID string, day time.Time) (*Daily_habits, error) {
+	var habits Daily_habits
+	query := `SELECT * FROM daily_habits WHERE user_id = $1 AND day = $2`
+	if err := s.db.GetContext(ctx, &habits, query, userID, day.Format(time.DateOnly)); err != nil {
+		return nil, err
+	}
+	return &habits, nil
+}
+
+// ListDailyHabits returns the days from from to to, both included, that the user logged
+// habits on, oldest first
+func (s *service) ListDailyHabits(ctx context.Context, userID string, from, to time.Time) ([]Daily_habits, error) {
+	days := []Daily_habits{}
+	query := `SELECT * FROM daily_habits WHERE user_id = $1 AND day BETWEEN $2 AND $3 ORDER BY day`
+	err := s.db.SelectContext(ctx, &days, query, userID, from.Format(time.DateOnly), to.Format(time.DateOnly))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list daily habits: %w", err)
+	}
+	return days, nil
+}
+
+// DeleteDailyHabits removes the user's habits for the day, returning sql.ErrNoRows if
+// none were logged
+func (s *service) DeleteDailyHabits(ctx context.Context, userID string, day time.Time) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM daily_habits WHERE user_id = $1 AND day = $2`, userID, day.Format(time.DateOnly))
+	if err != nil {
+		return fmt.Errorf("failed to delete daily habits: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// ListIntegrationEvents returns up to limit of the user's events of one type recorded after
+// since, newest first
+func (s *service) ListIntegrationEvents(ctx context.Context, userID, event string, since time.Time, limit int) ([]Integration_events, error) {
+	events := []Integration_events{}
+	query := `SELECT * FROM integration_events
+		WHERE user_id = $1 AND event = $2 AND created_at > $3
+		ORDER BY created_at DESC, id
+		LIMIT $4`
+	if err := s.db.SelectContext(ctx, &events, query, userID, event, since, limit); err != nil {
+		return nil, fmt.Errorf("failed to list integration events: %w", err)
+	}
+	return events, nil
+}
+
+// PurgeIntegrationEvents deletes up to limit events recorded before the cutoff and returns
+// how many were deleted
+func (s *service) PurgeIntegrationEvents(ctx context.Context, before time.Time, limit int) (int64, error) {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM integration_events WHERE id IN (
+			SELECT id FROM integration_events WHERE created_at < $1 LIMIT $2
+		)`, before, limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge integration events: %w", err)
+	}
+	return result.RowsAffected()
+}
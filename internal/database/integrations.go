@@ -0,0 +1,214 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrIntegrationInUse is returned when the provider account is already connected to another user
+var ErrIntegrationInUse = errors.New("provider account is connected to another user")
+
+// UpsertIntegration stores the user's connection to a provider, replacing any previous tokens.
+// Returns ErrIntegrationInUse if the provider account belongs to a different user.
+func (s *service) UpsertIntegration(ctx context.Context, integration *Integrations) (*Integrations, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var ownerID string
+	err = tx.GetContext(ctx, &ownerID,
+		`SELECT user_id FROM integrations WHERE provider = $1 AND external_user_id = $2`,
+		integration.Provider, integration.External_user_id)
+	if err == nil && ownerID != integration.User_id {
+		return nil, ErrIntegrationInUse
+	}
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to look up integration: %w", err)
+	}
+
+	query := `INSERT INTO integrations (user_id, provider, external_user_id, access_token, refresh_token, expires_at, scope)
+		VALUES (:user_id, :provider, :external_user_id, :access_token, :refresh_token, :expires_at, :scope)
+		ON CONFLICT (user_id, provider) DO UPDATE SET
+			external_user_id = EXCLUDED.external_user_id,
+			access_token = EXCLUDED.access_token,
+			refresh_token = EXCLUDED.refresh_token,
+			expires_at = EXCLUDED.expires_at,
+			scope = EXCLUDED.scope,
+			last_error = '',
+			updated_at = NOW()
+		RETURNING *`
+	query, args, err := tx.BindNamed(query, integration)
+	if err != nil {
+		return nil, err
+	}
+
+	var saved Integrations
+	if err := tx.QueryRowxContext(ctx, query, args...).StructScan(&saved); err != nil {
+		return nil, fmt.Errorf("failed to save integration: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit integration: %w", err)
+	}
+	return &saved, nil
+}
+
+// GetIntegration returns the user's connection to a provider, or sql.ErrNoRows
+func (s *service) GetIntegration(ctx context.Context, userID, provider string) (*Integrations, error) {
+	var integration Integrations
+	query := `SELECT * FROM integrations WHERE user_id = $1 AND provider = $2`
+	if err := s.db.GetContext(ctx, &integration, query, userID, provider); err != nil {
+		return nil, err
+	}
+	return &integration, nil
+}
+
+// GetIntegrationByExternalUser returns the connection for a provider account, or sql.ErrNoRows
+func (s *service) GetIntegrationByExternalUser(ctx context.Context, provider, externalUserID string) (*Integrations, error) {
+	var integration Integrations
+	query := `SELECT * FROM integrations WHERE provider = $1 AND external_user_id = $2`
+	if err := s.db.GetContext(ctx, &integration, query, provider, externalUserID); err != nil {
+		return nil, err
+	}
+	return &integration, nil
+}
+
+// ListIntegrationsByUser returns all of the user's connections
+func (s *service) ListIntegrationsByUser(ctx context.Context, userID string) ([]Integrations, error) {
+	var integrations []Integrations
+	query := `SELECT * FROM integrations WHERE user_id = $1 ORDER BY provider`
+	err := s.db.SelectContext(ctx, &integrations, query, userID)
+	return integrations, err
+}
+
+// ListIntegrationsDueForSync returns connections to a provider that have not been synced since
+// the given time, least recently synced first
+func (s *service) ListIntegrationsDueForSync(ctx context.Context, provider string, syncedBefore time.Time, limit int) ([]Integrations, error) {
+	var integrations []Integrations
+	query := `SELECT * FROM integrations
+		WHERE provider = $1 AND (last_synced_at IS NULL OR last_synced_at < $2)
+		ORDER BY last_synced_at NULLS FIRST
+		LIMIT $3`
+	err := s.db.SelectContext(ctx, &integrations, query, provider, syncedBefore, limit)
+	return integrations, err
+}
+
+// UpdateIntegrationTokens stores refreshed tokens for a connection
+func (s *service) UpdateIntegrationTokens(ctx context.Context, id, accessToken, refreshToken string, expiresAt time.Time) error {
+	query := `UPDATE integrations SET access_token = $2, refresh_token = $3, expires_at = $4, updated_at = NOW() WHERE id = $1`
+	_, err := s.db.ExecContext(ctx, query, id, accessToken, refreshToken, expiresAt)
+	return err
+}
+
+// MarkIntegrationSynced records the outcome of a sync; an empty syncErr means it succeeded
+// and advances last_synced_at
+func (s *service) MarkIntegrationSynced(ctx context.Context, id string, syncedAt time.Time, syncErr string) error {
+	query := `UPDATE integrations
+		SET last_synced_at = CASE WHEN $3 = '' THEN $2 ELSE last_synced_at END, last_error = $3, updated_at = NOW()
+		WHERE id = $1`
+	_, err := s.db.ExecContext(ctx, query, id, syncedAt, syncErr)
+	return err
+}
+
+// DeleteIntegration removes the user's connection to a provider. Imported sessions are kept.
+// Returns sql.ErrNoRows if the user is not connected.
+func (s *service) DeleteIntegration(ctx context.Context, userID, provider string) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM integrations WHERE user_id = $1 AND provider = $2`, userID, provider)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// UpsertImportedSession creates or updates a workout session imported from an external source,
+// matched on the user, source and external ID. The weather is looked up again when the
+// session's start time or place changed.
+func (s *service) UpsertImportedSession(ctx context.Context, ws *Workout_sessions) (*Workout_sessions, error) {
+	query := `INSERT INTO workout_sessions (user_id, name, started_at, completed_at, duration_minutes, notes, source, external_id, exercise_id,
+			duration_seconds, distance_meters, avg_heart_rate, max_heart_rate, start_latitude, start_longitude)
+		VALUES (:user_id, :name, :started_at, :completed_at, :duration_minutes, :notes, :source, :external_id, :exercise_id,
+			:duration_seconds, :distance_meters, :avg_heart_rate, :max_heart_rate, :start_latitude, :start_longitude)
+		ON CONFLICT (user_id, source, external_id) WHERE external_id <> '' DO UPDATE SET
+			name = EXCLUDED.name,
+			started_at = EXCLUDED.started_at,
+			completed_at = EXCLUDED.completed_at,
+			duration_minutes = EXCLUDED.duration_minutes,
+			notes = EXCLUDED.notes,
+			exercise_id = EXCLUDED.exercise_id,
+			duration_seconds = EXCLUDED.duration_seconds,
+			distance_meters = EXCLUDED.distance_meters,
+			avg_heart_rate = EXCLUDED.avg_heart_rate,
+			max_heart_rate = EXCLUDED.max_heart_rate,
+			start_latitude = EXCLUDED.start_latitude,
+			start_longitude = EXCLUDED.start_longitude,
+			weather_checked_at = CASE
+				WHEN (workout_sessions.started_at, workout_sessions.start_latitude, workout_sessions.start_longitude)
+					IS NOT DISTINCT FROM (EXCLUDED.started_at, EXCLUDED.start_latitude, EXCLUDED.start_longitude)
+				THEN workout_sessions.weather_checked_at
+			END,
+			updated_at = NOW()
+		RETURNING *`
+	query, args, err := s.db.BindNamed(query, ws)
+	if err != nil {
+		return nil, err
+	}
+
+	var saved Workout_sessions
+	if err := s.db.QueryRowxContext(ctx, query, args...).StructScan(&saved); err != nil {
+		return nil, err
+	}
+	return &saved, nil
+}
+
+// DeleteImportedSession removes a session imported from an external source.
+// Returns sql.ErrNoRows if it was never imported.
+func (s *service) DeleteImportedSession(ctx context.Context, userID, source, externalID string) error {
+	result, err := s.db.ExecContext(ctx,
+		`DELETE FROM workout_sessions WHERE user_id = $1 AND source = $2 AND external_id = $3`,
+		userID, source, externalID)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// FindOrCreateExercise returns the exercise with the given name (case-insensitive),
+// creating it if the catalog has none. A created exercise gets muscleGroup as its primary
+// muscle when the taxonomy has it.
+func (s *service) FindOrCreateExercise(ctx context.Context, name, muscleGroup, equipment string) (*Exercises, error) {
+	var exercise Exercises
+	err := s.db.GetContext(ctx, &exercise,
+		`SELECT * FROM exercises WHERE lower(name) = lower($1) ORDER BY created_at LIMIT 1`, name)
+	if err == nil {
+		return &exercise, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("failed to look up exercise: %w", err)
+	}
+
+	err = s.db.GetContext(ctx, &exercise,
+		`WITH created AS (
+			INSERT INTO exercises (name, description, equipment, instructions)
+			VALUES ($1, '', $2, '') RETURNING *
+		), muscle AS (
+			INSERT INTO exercise_muscle_groups (exercise_id, muscle_group_id, role)
+			SELECT created.id, m.id, 'primary' FROM created, muscle_groups m WHERE m.slug = $3
+		)
+		SELECT * FROM created`,
+		name, equipment, MuscleSlug(muscleGroup))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create exercise: %w", err)
+	}
+	return &exercise, nil
+}
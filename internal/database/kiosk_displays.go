@@ -0,0 +1,105 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// LeaderboardEntry is a member's training at an organization over a leaderboard period.
+// Display_name is the member's first name and last initial, or their username when they
+// haven't given a name.
+type LeaderboardEntry struct {
+	Display_name string `db:"display_name"`
+	Sessions     int    `db:"sessions"`
+	Minutes      int    `db:"minutes"`
+}
+
+// CreateKioskDisplay stores a new display; only the hash of its key is persisted
+func (s *service) CreateKioskDisplay(ctx context.Context, display *Kiosk_displays) (*Kiosk_displays, error) {
+	var created Kiosk_displays
+	err := s.db.GetContext(ctx, &created, `INSERT INTO kiosk_displays (organization_id, name, timezone, prefix, key_hash)
+		VALUES ($1, $2, $3, $4, $5) RETURNING *`,
+		display.Organization_id, display.Name, display.Timezone, display.Prefix, display.Key_hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kiosk display: %w", err)
+	}
+	return &created, nil
+}
+
+// ListKioskDisplays returns the organization's displays, newest first
+func (s *service) ListKioskDisplays(ctx context.Context, orgID string) ([]Kiosk_displays, error) {
+	displays := []Kiosk_displays{}
+	query := `SELECT * FROM kiosk_displays WHERE organization_id = $1 ORDER BY created_at DESC`
+	err := s.db.SelectContext(ctx, &displays, query, orgID)
+	return displays, err
+}
+
+// DeleteKioskDisplay revokes one of the organization's displays.
+// Returns sql.ErrNoRows if the organization has no such display.
+func (s *service) DeleteKioskDisplay(ctx context.Context, orgID, id string) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM kiosk_displays WHERE id = $1 AND organization_id = $2`, id, orgID)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// AuthenticateKioskDisplay looks up a display by the hash of its key and records that it
+// was seen. Returns sql.ErrNoRows if no such display exists.
+func (s *service) AuthenticateKioskDisplay(ctx context.Context, keyHash string) (*Kiosk_displays, error) {
+	var display Kiosk_displays
+	query := `UPDATE kiosk_displays SET last_seen_at = NOW() WHERE key_hash = $1 RETURNING *`
+	if err := s.db.GetContext(ctx, &display, query, keyHash); err != nil {
+		return nil, err
+	}
+	return &display, nil
+}
+
+// SetLeaderboardVisibility opts the member in to or out of the organization's leaderboard.
+// Returns sql.ErrNoRows if the user is not an active member.
+func (s *service) SetLeaderboardVisibility(ctx context.Context, orgID, userID string, visible bool) error {
+	result, err := s.db.ExecContext(ctx, `UPDATE organization_members SET show_on_leaderboard = $3, updated_at = NOW()
+		WHERE organization_id = $1 AND user_id = $2 AND active`, orgID, userID, visible)
+	if err != nil {
+		return fmt.Errorf("failed to set leaderboard visibility: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// memberDisplayName is how members are named to the rest of an organization: their first
+// name and last initial, or their username when they haven't given a name. It reads the
+// users table as u.
+const memberDisplayName = `CASE WHEN COALESCE(u.first_name, '') <> ''
+		THEN u.first_name || COALESCE(' ' || NULLIF(left(u.last_name, 1), '') || '.', '')
+		ELSE u.username END`
+
+// ListLeaderboard ranks the organization's members who opted in to its leaderboard by the
+// sessions they completed from from until before to, then by minutes trained. Members
+// without a session in the period are left out.
+func (s *service) ListLeaderboard(ctx context.Context, orgID string, from, to time.Time, limit int) ([]LeaderboardEntry, error) {
+	query := `SELECT
+			` + memberDisplayName + ` AS display_name,
+			COUNT(ws.id) AS sessions,
+			COALESCE(SUM(ws.duration_minutes), 0) AS minutes
+		FROM organization_members m
+		JOIN users u ON u.id = m.user_id
+		JOIN workout_sessions ws ON ws.user_id = m.user_id AND ws.completed_at >= $2 AND ws.completed_at < $3
+		WHERE m.organization_id = $1 AND m.active AND m.show_on_leaderboard
+		GROUP BY m.user_id, u.first_name, u.last_name, u.username
+		ORDER BY sessions DESC, minutes DESC, display_name
+		LIMIT $4`
+
+	entries := []LeaderboardEntry{}
+	if err := s.db.SelectContext(ctx, &entries, query, orgID, from, to, limit); err != nil {
+		return nil, fmt.Errorf("failed to list leaderboard: %w", err)
+	}
+	return entries, nil
+}
@@ -0,0 +1,68 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// The metrics exercise leaderboards rank lifters by
+const (
+	// LeaderboardMetricOneRepMax is the best estimated one-rep max (Epley's formula, reps
+	// capped at 12) of any set
+	LeaderboardMetricOneRepMax = "1rm"
+	// LeaderboardMetricVolume is the total weight lifted, weight times reps over every set
+	LeaderboardMetricVolume = "volume"
+)
+
+// leaderboardMetricScores are the SQL aggregates of the leaderboard metrics over the sets
+// of a user, read as wss
+var leaderboardMetricScores = map[string]string{
+	LeaderboardMetricOneRepMax: `MAX(CASE WHEN wss.reps <= 1 THEN wss.weight_kg
+		ELSE wss.weight_kg * (30 + LEAST(wss.reps, 12)) / 30 END)`,
+	LeaderboardMetricVolume: `SUM(wss.weight_kg * wss.reps)`,
+}
+
+// ValidLeaderboardMetric reports whether metric is one leaderboards rank by
+func ValidLeaderboardMetric(metric string) bool {
+	_, ok := leaderboardMetricScores[metric]
+	return ok
+}
+
+// LeaderboardScore is a lifter's score in an exercise's leaderboard
+type LeaderboardScore struct {
+	Exercise_id  string          `db:"exercise_id"`
+	User_id      string          `db:"user_id"`
+	Display_name string          `db:"display_name"`
+	Score        decimal.Decimal `db:"score"`
+}
+
+// ListLeaderboardScores scores the users who opted in to leaderboards by the metric over
+// the sets they logged since the given time, in the exercise or, when exerciseID is empty,
+// in every exercise. Scores come highest first within each exercise, ties by user ID
+// descending as Redis orders them. Custom exercises are private and left out.
+func (s *service) ListLeaderboardScores(ctx context.Context, metric string, since time.Time, exerciseID string) ([]LeaderboardScore, error) {
+	score, ok := leaderboardMetricScores[metric]
+	if !ok {
+		return nil, fmt.Errorf("unknown leaderboard metric %q", metric)
+	}
+	scores := []LeaderboardScore{}
+	query := `SELECT wss.exercise_id, u.id AS user_id, ` + memberDisplayName + ` AS display_name,
+			` + score + ` AS score
+		FROM workout_session_sets wss
+		JOIN workout_sessions ws ON ws.id = wss.session_id
+		JOIN privacy_settings ps ON ps.user_id = ws.user_id AND ps.show_on_leaderboards
+		JOIN users u ON u.id = ws.user_id
+		JOIN exercises e ON e.id = wss.exercise_id AND e.created_by IS NULL
+		WHERE wss.completed_at >= $1 AND wss.weight_kg > 0 AND wss.reps > 0
+			AND left(wss.client_id, 5) <> 'copy:'
+			AND ($2 = '' OR wss.exercise_id = NULLIF($2, '')::uuid)
+		GROUP BY wss.exercise_id, u.id, u.first_name, u.last_name, u.username
+		ORDER BY wss.exercise_id, score DESC, u.id DESC`
+	if err := s.db.SelectContext(ctx, &scores, query, since, exerciseID); err != nil {
+		return nil, fmt.Errorf("failed to list leaderboard scores: %w", err)
+	}
+	return scores, nil
+}
@@ -0,0 +1,118 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"fitness-hack/internal/database/sqlbuild"
+)
+
+// ErrInvalidListOption is returned when ListOptions asks for a sort or filter the list doesn't support
+var ErrInvalidListOption = errors.New("invalid list option")
+
+// defaultListLimit is used when ListOptions.Limit is not set
+const defaultListLimit = 10
+
+// ListOptions controls paging, ordering and filtering for List methods. Each list
+// supports its own set of sort keys and filters; anything else is rejected with
+// ErrInvalidListOption, so values can be passed through from query parameters.
+type ListOptions struct {
+	Limit  int
+	Offset int
+
+	// Sort is a sort key supported by the list, empty for its default order
+	Sort string
+	// Order is "asc" or "desc", and defaults to "asc" when Sort is set
+	Order string
+
+	// Filters restricts results to rows whose column equals the value, keyed by filter name
+	Filters map[string]string
+}
+
+// listSpec describes what one List method lets callers sort and filter on
+type listSpec struct {
+	// sorts and filters map the names callers use to column expressions
+	sorts   sqlbuild.Columns
+	filters sqlbuild.Columns
+	// defaultOrder is the ORDER BY used when no sort is requested
+	defaultOrder string
+	// tiebreak is appended to requested sorts so pages don't overlap on equal values
+	tiebreak string
+}
+
+// apply appends the filters, ordering and page to query, numbering placeholders after
+// args. query must end with a WHERE clause, "WHERE TRUE" when there is no condition.
+func (o ListOptions) apply(query string, args []interface{}, spec listSpec) (string, []interface{}, error) {
+	return o.build(sqlbuild.New(query, args...), spec)
+}
+
+// build adds the filters, ordering and page to q, after any conditions added to it already
+func (o ListOptions) build(q *sqlbuild.Query, spec listSpec) (string, []interface{}, error) {
+	// Sorted so the generated SQL is stable for the same options
+	names := make([]string, 0, len(o.Filters))
+	for name := range o.Filters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		q.Filter(spec.filters, name, o.Filters[name])
+	}
+
+	limit := o.Limit
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+	q.OrderBy(spec.sorts, o.Sort, o.Order, spec.tiebreak, spec.defaultOrder).Page(limit, o.Offset)
+
+	query, args, err := q.Build()
+	if err != nil {
+		return "", nil, fmt.Errorf("%w: %w", ErrInvalidListOption, err)
+	}
+	return query, args, nil
+}
+
+var (
+	userList = listSpec{
+		sorts:        map[string]string{"created_at": "created_at", "email": "email", "username": "username"},
+		filters:      map[string]string{},
+		defaultOrder: "created_at DESC",
+		tiebreak:     "id",
+	}
+	workoutList = listSpec{
+		sorts:        map[string]string{"created_at": "created_at", "updated_at": "updated_at", "name": "name", "duration_minutes": "duration_minutes"},
+		filters:      map[string]string{"user_id": "user_id", "program_id": "program_id", "is_template": "is_template"},
+		defaultOrder: "created_at DESC",
+		tiebreak:     "id",
+	}
+	workoutTemplateList = listSpec{
+		sorts:        workoutList.sorts,
+		filters:      map[string]string{"user_id": "user_id", "program_id": "program_id"},
+		defaultOrder: "created_at DESC",
+		tiebreak:     "id",
+	}
+	exerciseList = listSpec{
+		sorts:        map[string]string{"created_at": "created_at", "name": "name", "difficulty_level": "difficulty_level"},
+		filters:      map[string]string{"equipment": "equipment", "difficulty_level": "difficulty_level"},
+		defaultOrder: "created_at DESC",
+		tiebreak:     "id",
+	}
+	workoutExerciseList = listSpec{
+		sorts:        map[string]string{"created_at": "created_at", "order_index": "order_index", "weight_kg": "weight_kg"},
+		filters:      map[string]string{"workout_id": "workout_id", "exercise_id": "exercise_id"},
+		defaultOrder: "created_at DESC",
+		tiebreak:     "id",
+	}
+	workoutSessionList = listSpec{
+		sorts:        map[string]string{"created_at": "created_at", "started_at": "started_at", "completed_at": "completed_at", "name": "name"},
+		filters:      map[string]string{"user_id": "user_id", "workout_id": "workout_id", "exercise_id": "exercise_id", "source": "source"},
+		defaultOrder: "created_at DESC",
+		tiebreak:     "id",
+	}
+	programList = listSpec{
+		sorts:        map[string]string{"created_at": "created_at", "name": "name", "duration_weeks": "duration_weeks"},
+		filters:      map[string]string{"user_id": "user_id", "is_active": "is_active", "difficulty": "difficulty"},
+		defaultOrder: "created_at DESC",
+		tiebreak:     "id",
+	}
+)
@@ -0,0 +1,204 @@
+package database
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestListOptionsApply(t *testing.T) {
+	opts := ListOptions{
+		Limit:   20,
+		Offset:  40,
+		Sort:    "started_at",
+		Order:   "desc",
+		Filters: map[string]string{"workout_id": "w1", "source": "strava"},
+	}
+	query, args, err := opts.apply(`SELECT * FROM workout_sessions WHERE user_id = $1`, []interface{}{"u1"}, workoutSessionList)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `SELECT * FROM workout_sessions WHERE user_id = $1 AND source = $2 AND workout_id = $3 ORDER BY started_at DESC, id LIMIT $4 OFFSET $5`
+	if query != expected {
+		t.Errorf("expected query\n%s\ngot\n%s", expected, query)
+	}
+	if want := []interface{}{"u1", "strava", "w1", 20, 40}; !reflect.DeepEqual(args, want) {
+		t.Errorf("expected args %v, got %v", want, args)
+	}
+}
+
+func TestListOptionsApplyDefaults(t *testing.T) {
+	query, args, err := ListOptions{}.apply(`SELECT * FROM users WHERE TRUE`, nil, userList)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if query != `SELECT * FROM users WHERE TRUE ORDER BY created_at DESC LIMIT $1 OFFSET $2` {
+		t.Errorf("unexpected query %s", query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{defaultListLimit, 0}) {
+		t.Errorf("unexpected args %v", args)
+	}
+}
+
+func TestListOptionsApplyRejectsUnknownColumns(t *testing.T) {
+	invalid := []ListOptions{
+		{Sort: "password_hash"},
+		{Sort: "created_at; DROP TABLE users"},
+		{Sort: "created_at", Order: "sideways"},
+		{Filters: map[string]string{"email": "a@example.com"}},
+	}
+	for _, opts := range invalid {
+		if _, _, err := opts.apply(`SELECT * FROM users WHERE TRUE`, nil, userList); !errors.Is(err, ErrInvalidListOption) {
+			t.Errorf("expected ErrInvalidListOption for %+v, got %v", opts, err)
+		}
+	}
+}
+
+func TestListWorkoutsOptsQuery(t *testing.T) {
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	opts := ListWorkoutsOpts{
+		ListOptions: ListOptions{Sort: "name", Filters: map[string]string{"is_template": "false"}},
+		UserID:      "u1",
+		MuscleGroup: "Legs",
+		From:        from,
+	}
+	query, args, err := opts.query()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `SELECT * FROM workouts WHERE TRUE AND user_id = $1 AND EXISTS (SELECT 1 FROM workout_exercises we
+		JOIN exercise_muscle_groups emg ON emg.exercise_id = we.exercise_id AND emg.role = 'primary'
+		JOIN muscle_groups m ON m.id = emg.muscle_group_id
+		WHERE we.workout_id = workouts.id AND m.slug = $2) AND created_at >= $3 AND is_template = $4` +
+		` ORDER BY name ASC, id LIMIT $5 OFFSET $6`
+	if query != expected {
+		t.Errorf("expected query\n%s\ngot\n%s", expected, query)
+	}
+	if want := []interface{}{"u1", "legs", from, "false", defaultListLimit, 0}; !reflect.DeepEqual(args, want) {
+		t.Errorf("expected args %v, got %v", want, args)
+	}
+
+	if _, _, err := (ListWorkoutsOpts{ListOptions: ListOptions{Sort: "password_hash"}}).query(); !errors.Is(err, ErrInvalidListOption) {
+		t.Errorf("expected ErrInvalidListOption, got %v", err)
+	}
+}
+
+func TestListSpecsRejectUnknownSorts(t *testing.T) {
+	specs := map[string]listSpec{
+		"users": userList, "workouts": workoutList, "workout_templates": workoutTemplateList,
+		"exercises": exerciseList, "workout_exercises": workoutExerciseList, "workout_sessions": workoutSessionList,
+		"programs": programList, "body_metrics": bodyMetricList, "data_subject_requests": dataSubjectRequestList,
+		"program_adjustments": programAdjustmentList, "progress_photos": progressPhotoList,
+		"training_max_history": trainingMaxHistoryList, "webhook_deliveries": webhookDeliveryList,
+	}
+	for name, spec := range specs {
+		if spec.defaultOrder == "" || spec.tiebreak == "" || len(spec.sorts) == 0 {
+			t.Errorf("%s: expected a default order, a tiebreak and at least one sort key", name)
+		}
+		if _, _, err := (ListOptions{Sort: "id; DROP TABLE users"}).apply(`SELECT 1 WHERE TRUE`, nil, spec); !errors.Is(err, ErrInvalidListOption) {
+			t.Errorf("%s: expected an unknown sort to be rejected, got %v", name, err)
+		}
+	}
+}
+
+func TestListWorkoutSessionsOptsQuery(t *testing.T) {
+	from := time.Date(2025, 8, 4, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 7)
+	query, args, err := ListWorkoutSessionsOpts{ListOptions: ListOptions{Sort: "started_at"}, From: from, To: to}.query()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `SELECT * FROM workout_sessions WHERE TRUE AND started_at >= $1 AND started_at < $2 ORDER BY started_at ASC, id LIMIT $3 OFFSET $4`
+	if query != expected {
+		t.Errorf("expected query\n%s\ngot\n%s", expected, query)
+	}
+	if want := []interface{}{from, to, defaultListLimit, 0}; !reflect.DeepEqual(args, want) {
+		t.Errorf("expected args %v, got %v", want, args)
+	}
+}
+
+func TestListBodyMetricsOptsQuery(t *testing.T) {
+	from := time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC)
+	query, args, err := ListBodyMetricsOpts{Metric: "weight_kg", From: from}.query("u1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `SELECT * FROM body_metrics WHERE user_id = $1 AND metric = $2 AND recorded_at >= $3 ORDER BY recorded_at DESC LIMIT $4 OFFSET $5`
+	if query != expected {
+		t.Errorf("expected query\n%s\ngot\n%s", expected, query)
+	}
+	if want := []interface{}{"u1", "weight_kg", from, defaultListLimit, 0}; !reflect.DeepEqual(args, want) {
+		t.Errorf("expected args %v, got %v", want, args)
+	}
+}
+
+func TestListNutritionLogsOptsQuery(t *testing.T) {
+	from := time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 1)
+	opts := ListNutritionLogsOpts{ListOptions: ListOptions{Filters: map[string]string{"meal": "lunch"}, Sort: "calories"}, From: from, To: to}
+	query, args, err := opts.query("u1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `SELECT * FROM nutrition_logs WHERE user_id = $1 AND eaten_at >= $2 AND eaten_at < $3 AND meal = $4 ORDER BY calories ASC, id LIMIT $5 OFFSET $6`
+	if query != expected {
+		t.Errorf("expected query\n%s\ngot\n%s", expected, query)
+	}
+	if want := []interface{}{"u1", from, to, "lunch", defaultListLimit, 0}; !reflect.DeepEqual(args, want) {
+		t.Errorf("expected args %v, got %v", want, args)
+	}
+}
+
+func TestListProgressPhotosOptsQuery(t *testing.T) {
+	from := time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+	opts := ListProgressPhotosOpts{ListOptions: ListOptions{Filters: map[string]string{"pose": "side"}}, From: from, To: to}
+	query, args, err := opts.query("u1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `SELECT * FROM progress_photos WHERE user_id = $1 AND status = 'ready' AND taken_at >= $2 AND taken_at < $3 AND pose = $4 ORDER BY taken_at DESC, id LIMIT $5 OFFSET $6`
+	if query != expected {
+		t.Errorf("expected query\n%s\ngot\n%s", expected, query)
+	}
+	if want := []interface{}{"u1", from, to, "side", defaultListLimit, 0}; !reflect.DeepEqual(args, want) {
+		t.Errorf("expected args %v, got %v", want, args)
+	}
+}
+
+func TestListExercisesOptsQuery(t *testing.T) {
+	query, args, err := ListExercisesOpts{Muscle: "hamstrings", Role: Exercise_muscle_groups_role_secondary}.query()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `SELECT * FROM exercises WHERE TRUE AND EXISTS (SELECT 1 FROM exercise_muscle_groups emg JOIN muscle_groups m ON m.id = emg.muscle_group_id
+		WHERE emg.exercise_id = exercises.id AND emg.role = 'secondary' AND m.slug = $1) ORDER BY created_at DESC LIMIT $2 OFFSET $3`
+	if query != expected {
+		t.Errorf("expected query\n%s\ngot\n%s", expected, query)
+	}
+	if want := []interface{}{"hamstrings", defaultListLimit, 0}; !reflect.DeepEqual(args, want) {
+		t.Errorf("expected args %v, got %v", want, args)
+	}
+}
+
+func TestMuscleSlug(t *testing.T) {
+	for name, want := range map[string]string{
+		"Legs":          "legs",
+		"Upper Back":    "upper-back",
+		" Full  Body! ": "full-body",
+		"hip-flexors":   "hip-flexors",
+		"":              "",
+	} {
+		if got := MuscleSlug(name); got != want {
+			t.Errorf("MuscleSlug(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
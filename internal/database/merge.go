@@ -0,0 +1,307 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	// ErrMergeSourceHeld is returned by MergeUsers when the source user is under a legal hold
+	ErrMergeSourceHeld = errors.New("source user is under a legal hold")
+	// ErrMergeVaults is returned by MergeUsers when both users have a photo vault; each
+	// encrypts its photos with its own key, so they can't be combined
+	ErrMergeVaults = errors.New("both users have a photo vault")
+	// ErrMergeIntegrations is returned by MergeUsers when both users are connected to the
+	// same integration provider, which allows one connection per user
+	ErrMergeIntegrations = errors.New("both users are connected to the same integration")
+)
+
+// MergeResult summarizes the records moved by MergeUsers
+type MergeResult struct {
+	SourceUserID      string `json:"sourceUserId"`
+	TargetUserID      string `json:"targetUserId"`
+	Programs          int64  `json:"programs"`
+	Workouts          int64  `json:"workouts"`
+	WorkoutSessions   int64  `json:"workoutSessions"`
+	DuplicateSessions int64  `json:"duplicateSessions"`
+}
+
+// mergeSteps combine everything else the source user owns or is referenced by into the
+// target, in order. Each query gets the source user as $1 and the target as $2. Rows that
+// would collide with the target's are folded into them first (the target's values win where
+// both are set), so the re-parenting updates that follow can't violate a unique constraint.
+var mergeSteps = []struct {
+	Name  string
+	Query string
+}{
+	{"training max history", `UPDATE training_max_history h SET training_max_id = dst.id
+		FROM training_maxes src JOIN training_maxes dst ON dst.name = src.name AND dst.user_id = $2
+		WHERE h.training_max_id = src.id AND src.user_id = $1`},
+	{"duplicate training maxes", `DELETE FROM training_maxes src WHERE src.user_id = $1
+		AND EXISTS (SELECT 1 FROM training_maxes dst WHERE dst.user_id = $2 AND dst.name = src.name)`},
+	{"duplicate body metrics", `DELETE FROM body_metrics src WHERE src.user_id = $1
+		AND EXISTS (SELECT 1 FROM body_metrics dst
+			WHERE dst.user_id = $2 AND dst.metric = src.metric AND dst.recorded_at = src.recorded_at)`},
+	{"shared daily habits", `UPDATE daily_habits dst SET
+			water_ml = COALESCE(dst.water_ml, src.water_ml),
+			sleep_hours = COALESCE(dst.sleep_hours, src.sleep_hours),
+			steps = COALESCE(dst.steps, src.steps),
+			updated_at = NOW()
+		FROM daily_habits src
+		WHERE dst.user_id = $2 AND src.user_id = $1 AND src.day = dst.day`},
+	{"duplicate daily habits", `DELETE FROM daily_habits src WHERE src.user_id = $1
+		AND EXISTS (SELECT 1 FROM daily_habits dst WHERE dst.user_id = $2 AND dst.day = src.day)`},
+	{"open gym visits", `UPDATE gym_visits src SET checked_out_at = GREATEST(NOW(), src.checked_in_at)
+		WHERE src.user_id = $1 AND src.checked_out_at IS NULL
+		AND EXISTS (SELECT 1 FROM gym_visits dst
+			WHERE dst.user_id = $2 AND dst.organization_id = src.organization_id AND dst.checked_out_at IS NULL)`},
+	{"organization roles", `UPDATE organization_members dst SET role = src.role, updated_at = NOW()
+		FROM organization_members src
+		WHERE dst.user_id = $2 AND src.user_id = $1 AND dst.organization_id = src.organization_id
+		AND array_position(ARRAY['member', 'admin', 'owner'], src.role) > array_position(ARRAY['member', 'admin', 'owner'], dst.role)`},
+	{"duplicate organization memberships", `DELETE FROM organization_members src WHERE src.user_id = $1
+		AND EXISTS (SELECT 1 FROM organization_members dst
+			WHERE dst.user_id = $2 AND dst.organization_id = src.organization_id)`},
+	{"shared challenges", `UPDATE challenge_participants dst SET joined_at = LEAST(dst.joined_at, src.joined_at)
+		FROM challenge_participants src
+		WHERE dst.user_id = $2 AND src.user_id = $1 AND dst.challenge_id = src.challenge_id`},
+	{"duplicate challenges", `DELETE FROM challenge_participants src WHERE src.user_id = $1
+		AND EXISTS (SELECT 1 FROM challenge_participants dst
+			WHERE dst.user_id = $2 AND dst.challenge_id = src.challenge_id)`},
+	// Following between the two accounts, or someone both already follow, has nothing to move
+	{"duplicate follows", `DELETE FROM follows f
+		WHERE (f.follower_id = $1 AND (f.followee_id = $2 OR EXISTS (
+				SELECT 1 FROM follows d WHERE d.follower_id = $2 AND d.followee_id = f.followee_id)))
+			OR (f.followee_id = $1 AND (f.follower_id = $2 OR EXISTS (
+				SELECT 1 FROM follows d WHERE d.followee_id = $2 AND d.follower_id = f.follower_id)))`},
+	// A coach can't coach themselves, so coaching between the two accounts ends with the merge
+	{"coaching between the accounts", `DELETE FROM coach_clients
+		WHERE (coach_id = $1 AND client_id = $2) OR (coach_id = $2 AND client_id = $1)`},
+	{"duplicate coaching", `UPDATE coach_clients src SET status = 'ended', ended_at = NOW()
+		WHERE (src.status = 'active' AND src.client_id = $1 AND EXISTS (SELECT 1 FROM coach_clients dst
+				WHERE dst.status = 'active' AND dst.client_id = $2 AND dst.coach_id = src.coach_id))
+			OR (src.status = 'active' AND src.coach_id = $1 AND EXISTS (SELECT 1 FROM coach_clients dst
+				WHERE dst.status = 'active' AND dst.coach_id = $2 AND dst.client_id = src.client_id))
+			OR (src.status = 'pending' AND src.coach_id = $1 AND EXISTS (SELECT 1 FROM coach_clients dst
+				WHERE dst.status = 'pending' AND dst.coach_id = $2 AND lower(dst.email) = lower(src.email)))`},
+	// A user is referred at most once, and not by themselves
+	{"duplicate referrals", `DELETE FROM referrals
+		WHERE (referrer_id = $1 AND referred_user_id = $2) OR (referrer_id = $2 AND referred_user_id = $1)
+			OR (referred_user_id = $1 AND EXISTS (SELECT 1 FROM referrals dst WHERE dst.referred_user_id = $2))`},
+	{"entitlements", `UPDATE entitlements dst SET premium_until = GREATEST(dst.premium_until, src.premium_until), updated_at = NOW()
+		FROM entitlements src
+		WHERE dst.user_id = $2 AND src.user_id = $1`},
+	{"programs coached", `UPDATE programs SET coach_id = $2 WHERE coach_id = $1`},
+	{"program adjustments", `UPDATE program_adjustments SET user_id = $2 WHERE user_id = $1`},
+	{"program adjustments reviewed", `UPDATE program_adjustments SET reviewed_by = $2 WHERE reviewed_by = $1`},
+	{"session feedback", `UPDATE session_feedback SET user_id = $2 WHERE user_id = $1`},
+	{"progression rules", `UPDATE progression_rules SET user_id = $2 WHERE user_id = $1`},
+	{"training maxes", `UPDATE training_maxes SET user_id = $2 WHERE user_id = $1`},
+	{"body metrics", `UPDATE body_metrics SET user_id = $2 WHERE user_id = $1`},
+	{"daily habits", `UPDATE daily_habits SET user_id = $2 WHERE user_id = $1`},
+	{"nutrition logs", `UPDATE nutrition_logs SET user_id = $2 WHERE user_id = $1`},
+	{"foods", `UPDATE foods SET user_id = $2 WHERE user_id = $1`},
+	{"progress photos", `UPDATE progress_photos SET user_id = $2 WHERE user_id = $1`},
+	{"reminders", `UPDATE reminders SET user_id = $2 WHERE user_id = $1`},
+	{"devices", `UPDATE devices SET user_id = $2 WHERE user_id = $1`},
+	{"webhooks", `UPDATE webhooks SET user_id = $2 WHERE user_id = $1`},
+	{"api keys", `UPDATE api_keys SET user_id = $2 WHERE user_id = $1`},
+	{"integrations", `UPDATE integrations SET user_id = $2 WHERE user_id = $1`},
+	{"integration events", `UPDATE integration_events SET user_id = $2 WHERE user_id = $1`},
+	{"oauth identities", `UPDATE oauth_identities SET user_id = $2 WHERE user_id = $1`},
+	{"subscriptions", `UPDATE subscriptions SET user_id = $2 WHERE user_id = $1`},
+	{"data exports", `UPDATE data_exports SET user_id = $2 WHERE user_id = $1`},
+	{"gym visits", `UPDATE gym_visits SET user_id = $2 WHERE user_id = $1`},
+	{"equipment reservations", `UPDATE equipment_reservations SET user_id = $2 WHERE user_id = $1`},
+	{"organization memberships", `UPDATE organization_members SET user_id = $2 WHERE user_id = $1`},
+	{"organizations created", `UPDATE organizations SET created_by = $2 WHERE created_by = $1`},
+	{"organization invites sent", `UPDATE organization_invites SET invited_by = $2 WHERE invited_by = $1`},
+	{"organization invites accepted", `UPDATE organization_invites SET accepted_by = $2 WHERE accepted_by = $1`},
+	{"challenge participation", `UPDATE challenge_participants SET user_id = $2 WHERE user_id = $1`},
+	{"challenges created", `UPDATE challenges SET created_by = $2 WHERE created_by = $1`},
+	{"exercises created", `UPDATE exercises SET created_by = $2 WHERE created_by = $1`},
+	{"follows", `UPDATE follows SET follower_id = $2 WHERE follower_id = $1`},
+	{"followers", `UPDATE follows SET followee_id = $2 WHERE followee_id = $1`},
+	{"coach clients", `UPDATE coach_clients SET coach_id = $2 WHERE coach_id = $1`},
+	{"coaches", `UPDATE coach_clients SET client_id = $2 WHERE client_id = $1`},
+	{"referrals made", `UPDATE referrals SET referrer_id = $2 WHERE referrer_id = $1`},
+	{"referral received", `UPDATE referrals SET referred_user_id = $2 WHERE referred_user_id = $1`},
+	{"admin audit log", `UPDATE admin_audit_log SET admin_id = $2 WHERE admin_id = $1`},
+}
+
+// mergeSettings are tables holding at most one row per user. The source's row moves over
+// when the target has none; otherwise the target keeps its own and the source's is dropped.
+var mergeSettings = []string{
+	"entitlements",
+	"referral_codes",
+	"notification_preferences",
+	"privacy_settings",
+	"benchmark_consents",
+	"photo_vaults",
+}
+
+// MergeUsers moves all data owned by sourceID to targetID and deletes the source user.
+// Sessions already present on the target (same name and start time, or the same imported
+// activity) are dropped instead of duplicated, records that exist once per user are combined
+// with the target's, and empty profile fields on the target are filled from the source.
+// The merge is refused with ErrMergeSourceHeld, ErrMergeVaults or ErrMergeIntegrations
+// when the accounts can't be combined without losing data. Compliance records keyed by user ID (legal
+// holds, account deletions, data subject requests and audit log targets) keep the source's
+// ID. Everything happens in a single transaction.
+func (s *service) MergeUsers(ctx context.Context, sourceID, targetID string) (*MergeResult, error) {
+	if sourceID == targetID {
+		return nil, fmt.Errorf("cannot merge a user into itself")
+	}
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Lock both users so concurrent merges cannot interleave
+	var locked int
+	if err := tx.GetContext(ctx, &locked, `SELECT COUNT(*) FROM (SELECT id FROM users WHERE id IN ($1, $2) FOR UPDATE) u`, sourceID, targetID); err != nil {
+		return nil, fmt.Errorf("failed to lock users: %w", err)
+	}
+	if locked != 2 {
+		return nil, fmt.Errorf("source or target user not found")
+	}
+
+	var conflicts struct {
+		Held         bool
+		Vaults       bool
+		Integrations bool
+	}
+	err = tx.GetContext(ctx, &conflicts, `SELECT `+heldUser("u")+` AS held,
+			EXISTS (SELECT 1 FROM photo_vaults WHERE user_id = $1)
+				AND EXISTS (SELECT 1 FROM photo_vaults WHERE user_id = $2) AS vaults,
+			EXISTS (SELECT 1 FROM integrations src JOIN integrations dst ON dst.provider = src.provider
+				WHERE src.user_id = $1 AND dst.user_id = $2) AS integrations
+		FROM (SELECT $1::uuid AS user_id) u`, sourceID, targetID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check for merge conflicts: %w", err)
+	}
+	switch {
+	case conflicts.Held:
+		return nil, ErrMergeSourceHeld
+	case conflicts.Vaults:
+		return nil, ErrMergeVaults
+	case conflicts.Integrations:
+		return nil, ErrMergeIntegrations
+	}
+
+	result := &MergeResult{SourceUserID: sourceID, TargetUserID: targetID}
+
+	dedup, err := tx.ExecContext(ctx, `DELETE FROM workout_sessions src
+		WHERE src.user_id = $1
+		AND EXISTS (
+			SELECT 1 FROM workout_sessions dst
+			WHERE dst.user_id = $2 AND (
+				(dst.name = src.name AND dst.started_at = src.started_at)
+				OR (src.external_id <> '' AND dst.source = src.source AND dst.external_id = src.external_id))
+		)`, sourceID, targetID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to deduplicate sessions: %w", err)
+	}
+	result.DuplicateSessions, _ = dedup.RowsAffected()
+
+	moves := []struct {
+		table string
+		count *int64
+	}{
+		{"programs", &result.Programs},
+		{"workouts", &result.Workouts},
+		{"workout_sessions", &result.WorkoutSessions},
+	}
+	for _, move := range moves {
+		query := fmt.Sprintf(`UPDATE %s SET user_id = $1, updated_at = NOW() WHERE user_id = $2`, move.table)
+		res, err := tx.ExecContext(ctx, query, targetID, sourceID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to move %s: %w", move.table, err)
+		}
+		*move.count, _ = res.RowsAffected()
+	}
+
+	for _, step := range mergeSteps {
+		if _, err := tx.ExecContext(ctx, step.Query, sourceID, targetID); err != nil {
+			return nil, fmt.Errorf("failed to merge %s: %w", step.Name, err)
+		}
+	}
+	for _, table := range mergeSettings {
+		query := fmt.Sprintf(`UPDATE %[1]s SET user_id = $2 WHERE user_id = $1
+			AND NOT EXISTS (SELECT 1 FROM %[1]s WHERE user_id = $2)`, table)
+		if _, err := tx.ExecContext(ctx, query, sourceID, targetID); err != nil {
+			return nil, fmt.Errorf("failed to merge %s: %w", table, err)
+		}
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE user_id = $1`, table), sourceID); err != nil {
+			return nil, fmt.Errorf("failed to merge %s: %w", table, err)
+		}
+	}
+
+	// The source's sign-ins and guest expiry end with the account rather than carrying over
+	for _, table := range []string{"user_sessions", "guest_accounts"} {
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE user_id = $1`, table), sourceID); err != nil {
+			return nil, fmt.Errorf("failed to delete %s: %w", table, err)
+		}
+	}
+
+	if err := checkNothingReferencesUser(ctx, tx, sourceID); err != nil {
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE users dst SET
+			first_name = COALESCE(NULLIF(dst.first_name, ''), src.first_name),
+			last_name = COALESCE(NULLIF(dst.last_name, ''), src.last_name),
+			updated_at = NOW(),
+			version = dst.version + 1
+		FROM users src
+		WHERE dst.id = $1 AND src.id = $2`, targetID, sourceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to merge profile: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM users WHERE id = $1`, sourceID); err != nil {
+		return nil, fmt.Errorf("failed to delete source user: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// userReferences lists every column with a foreign key to users, as table and column names
+// quoted for use in a query
+const userReferences = `SELECT c.conrelid::regclass::text AS table_name, quote_ident(a.attname) AS column_name
+	FROM pg_constraint c
+	JOIN pg_attribute a ON a.attrelid = c.conrelid AND a.attnum = c.conkey[1]
+	WHERE c.contype = 'f' AND c.confrelid = 'users'::regclass
+	ORDER BY 1, 2`
+
+// checkNothingReferencesUser fails when a row anywhere still references userID, so a table
+// added without teaching MergeUsers about it stops merges instead of losing its rows when
+// the source user is deleted
+func checkNothingReferencesUser(ctx context.Context, tx *sqlx.Tx, userID string) error {
+	var refs []struct {
+		Table_name  string
+		Column_name string
+	}
+	if err := tx.SelectContext(ctx, &refs, userReferences); err != nil {
+		return fmt.Errorf("failed to list references to users: %w", err)
+	}
+	for _, ref := range refs {
+		var left bool
+		query := fmt.Sprintf(`SELECT EXISTS (SELECT 1 FROM %s WHERE %s = $1)`, ref.Table_name, ref.Column_name)
+		if err := tx.GetContext(ctx, &left, query, userID); err != nil {
+			return fmt.Errorf("failed to check %s: %w", ref.Table_name, err)
+		}
+		if left {
+			return fmt.Errorf("merge left rows in %s.%s", ref.Table_name, ref.Column_name)
+		}
+	}
+	return nil
+}
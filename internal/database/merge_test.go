@@ -0,0 +1,264 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// mergeFixture is a migrated database with two users to merge and a third they share
+// followers, coaching and referrals with
+type mergeFixture struct {
+	t      *testing.T
+	srv    Service
+	source string
+	target string
+	other  string
+}
+
+func newMergeFixture(t *testing.T) *mergeFixture {
+	t.Helper()
+	f := &mergeFixture{t: t, srv: newMigratedService(t)}
+	f.source = f.id(`INSERT INTO users (email, username, password_hash, first_name) VALUES ($1, $2, 'hash', 'Source') RETURNING id`, testEmail(), "u_"+uuid.NewString()[:8])
+	f.target = f.id(`INSERT INTO users (email, username, password_hash) VALUES ($1, $2, 'hash') RETURNING id`, testEmail(), "u_"+uuid.NewString()[:8])
+	f.other = f.id(`INSERT INTO users (email, username, password_hash) VALUES ($1, $2, 'hash') RETURNING id`, testEmail(), "u_"+uuid.NewString()[:8])
+	return f
+}
+
+// exec runs a seeding statement
+func (f *mergeFixture) exec(query string, args ...any) {
+	f.t.Helper()
+	if _, err := f.srv.GetDB().ExecContext(context.Background(), query, args...); err != nil {
+		f.t.Fatalf("failed to seed %q: %v", query, err)
+	}
+}
+
+// id runs a seeding statement that returns the ID of the row it inserted
+func (f *mergeFixture) id(query string, args ...any) string {
+	f.t.Helper()
+	var id string
+	if err := f.srv.GetDB().GetContext(context.Background(), &id, query, args...); err != nil {
+		f.t.Fatalf("failed to seed %q: %v", query, err)
+	}
+	return id
+}
+
+// count returns how many rows of table have column set to userID
+func (f *mergeFixture) count(table, column, userID string) int {
+	f.t.Helper()
+	var n int
+	query := fmt.Sprintf(`SELECT COUNT(*) FROM %s WHERE %s = $1`, table, column)
+	if err := f.srv.GetDB().GetContext(context.Background(), &n, query, userID); err != nil {
+		f.t.Fatal(err)
+	}
+	return n
+}
+
+// seedEverything gives userID a row in every table that references users
+func (f *mergeFixture) seedEverything(userID string) {
+	f.t.Helper()
+	f.exec(`INSERT INTO guest_accounts (user_id, expires_at) VALUES ($1, NOW() + INTERVAL '30 days')`, userID)
+	f.exec(`INSERT INTO oauth_identities (user_id, provider, subject) VALUES ($1, 'google', gen_random_uuid()::text)`, userID)
+	f.exec(`INSERT INTO entitlements (user_id, premium_until) VALUES ($1, NOW() + INTERVAL '7 days')`, userID)
+	f.exec(`INSERT INTO referral_codes (user_id, code) VALUES ($1, gen_random_uuid()::text)`, userID)
+	f.exec(`INSERT INTO referrals (referrer_id, referred_user_id, code) VALUES ($1, $2, 'a'), ($2, $1, 'b')`, userID, f.other)
+	f.exec(`INSERT INTO subscriptions (user_id, platform, product_id, original_transaction_id, status, expires_at)
+		VALUES ($1, 'apple', 'premium', gen_random_uuid()::text, 'active', NOW() + INTERVAL '30 days')`, userID)
+	f.exec(`INSERT INTO api_keys (user_id, prefix, key_hash, scope) VALUES ($1, 'fh_test', gen_random_uuid()::text, 'read')`, userID)
+	f.exec(`INSERT INTO data_exports (user_id) VALUES ($1)`, userID)
+	f.exec(`INSERT INTO integrations (user_id, provider, external_user_id, access_token, refresh_token, expires_at)
+		VALUES ($1, 'strava', gen_random_uuid()::text, 'access', 'refresh', NOW())`, userID)
+	f.exec(`INSERT INTO integration_events (id, user_id, event, payload) VALUES (gen_random_uuid(), $1, 'workout.completed', '{}')`, userID)
+	f.exec(`INSERT INTO body_metrics (user_id, metric, measured_value, recorded_at) VALUES ($1, 'weight_kg', 80, NOW())`, userID)
+	f.exec(`INSERT INTO webhooks (user_id, url, secret) VALUES ($1, 'https://example.com/hook', 'secret')`, userID)
+	f.exec(`INSERT INTO devices (user_id, platform, token) VALUES ($1, 'ios', gen_random_uuid()::text)`, userID)
+	f.exec(`INSERT INTO notification_preferences (user_id) VALUES ($1)`, userID)
+	f.exec(`INSERT INTO reminders (user_id, title, days_of_week, time_of_day) VALUES ($1, 'Train', 1, 480)`, userID)
+	f.exec(`INSERT INTO training_maxes (user_id, name, weight_kg) VALUES ($1, 'squat', 100)`, userID)
+	f.exec(`INSERT INTO progress_photos (user_id, taken_at, content_type, storage_key) VALUES ($1, NOW(), 'image/jpeg', 'progress-photos/p.jpg')`, userID)
+	f.exec(`INSERT INTO photo_vaults (user_id, pin_salt, wrapped_key) VALUES ($1, 'salt', 'key')`, userID)
+	f.exec(`INSERT INTO daily_habits (user_id, day, steps) VALUES ($1, CURRENT_DATE, 1000)`, userID)
+	f.exec(`INSERT INTO benchmark_consents (user_id) VALUES ($1)`, userID)
+	f.exec(`INSERT INTO privacy_settings (user_id) VALUES ($1)`, userID)
+	f.exec(`INSERT INTO follows (follower_id, followee_id) VALUES ($1, $2), ($2, $1)`, userID, f.other)
+	f.exec(`INSERT INTO coach_clients (coach_id, client_id, email, status, token_hash, expires_at)
+		VALUES ($1, $2, 'client@example.com', 'active', gen_random_uuid()::text, NOW()),
+			($2, $1, 'coach@example.com', 'active', gen_random_uuid()::text, NOW())`, userID, f.other)
+	f.exec(`INSERT INTO user_sessions (user_id, token_id, expires_at) VALUES ($1, gen_random_uuid()::text, NOW() + INTERVAL '1 day')`, userID)
+	f.exec(`INSERT INTO admin_audit_log (admin_id, action, path, status_code) VALUES ($1, 'GET', '/api/v1/admin/users', 200)`, userID)
+
+	org := f.id(`INSERT INTO organizations (name, created_by) VALUES ('Gym', $1) RETURNING id`, userID)
+	f.exec(`INSERT INTO organization_members (organization_id, user_id, role) VALUES ($1, $2, 'owner')`, org, userID)
+	f.exec(`INSERT INTO organization_invites (organization_id, email, token_hash, invited_by, expires_at, accepted_at, accepted_by)
+		VALUES ($1, 'member@example.com', gen_random_uuid()::text, $2, NOW() + INTERVAL '7 days', NOW(), $2)`, org, userID)
+	f.exec(`INSERT INTO gym_visits (organization_id, user_id) VALUES ($1, $2)`, org, userID)
+	equipment := f.id(`INSERT INTO gym_equipment (organization_id, name) VALUES ($1, 'Rack') RETURNING id`, org)
+	f.exec(`INSERT INTO equipment_reservations (equipment_id, user_id, starts_at, ends_at) VALUES ($1, $2, NOW(), NOW() + INTERVAL '1 hour')`, equipment, userID)
+
+	program := f.id(`INSERT INTO programs (name, user_id) VALUES ('Own plan', $1) RETURNING id`, userID)
+	coached := f.id(`INSERT INTO programs (name, user_id, coach_id) VALUES ('Coached plan', $1, $2) RETURNING id`, f.other, userID)
+	f.exec(`INSERT INTO program_adjustments (program_id, user_id, week, kind, volume_percent, reason) VALUES ($1, $2, 1, 'add_volume', 110, 'test')`, program, userID)
+	f.exec(`INSERT INTO program_adjustments (program_id, user_id, week, kind, volume_percent, reason, reviewed_by)
+		VALUES ($1, $2, 1, 'add_volume', 110, 'test', $3)`, coached, f.other, userID)
+
+	workout := f.id(`INSERT INTO workouts (user_id, name) VALUES ($1, 'Legs') RETURNING id`, userID)
+	session := f.id(`INSERT INTO workout_sessions (user_id, workout_id, name) VALUES ($1, $2, 'Legs') RETURNING id`, userID, workout)
+	f.exec(`INSERT INTO session_feedback (session_id, user_id, rpe) VALUES ($1, $2, 7)`, session, userID)
+	exercise := f.id(`INSERT INTO exercises (name, created_by) VALUES ($1, $2) RETURNING id`, "Squat "+userID, userID)
+	workoutExercise := f.id(`INSERT INTO workout_exercises (workout_id, exercise_id) VALUES ($1, $2) RETURNING id`, workout, exercise)
+	f.exec(`INSERT INTO progression_rules (workout_exercise_id, user_id) VALUES ($1, $2)`, workoutExercise, userID)
+
+	food := f.id(`INSERT INTO foods (user_id, name) VALUES ($1, 'Oats') RETURNING id`, userID)
+	f.exec(`INSERT INTO nutrition_logs (user_id, food_id, name) VALUES ($1, $2, 'Oats')`, userID, food)
+
+	challenge := f.id(`INSERT INTO challenges (created_by, name, metric, target, starts_at, ends_at)
+		VALUES ($1, 'Move', 'workouts', 10, NOW(), NOW() + INTERVAL '30 days') RETURNING id`, userID)
+	f.exec(`INSERT INTO challenge_participants (challenge_id, user_id) VALUES ($1, $2)`, challenge, userID)
+}
+
+func TestMergeUsersKeepsEveryRow(t *testing.T) {
+	f := newMergeFixture(t)
+	ctx := context.Background()
+	f.seedEverything(f.source)
+
+	var refs []struct {
+		Table_name  string
+		Column_name string
+	}
+	if err := f.srv.GetDB().SelectContext(ctx, &refs, userReferences); err != nil {
+		t.Fatal(err)
+	}
+	// Tables from before 007 lost their foreign keys to users but are merged all the same
+	for _, table := range []string{"workouts", "workout_sessions", "programs"} {
+		refs = append(refs, struct {
+			Table_name  string
+			Column_name string
+		}{table, "user_id"})
+	}
+
+	before := make(map[string]int, len(refs))
+	for _, ref := range refs {
+		key := ref.Table_name + "." + ref.Column_name
+		before[key] = f.count(ref.Table_name, ref.Column_name, f.source)
+		if before[key] == 0 {
+			t.Errorf("%s references users but isn't seeded; add it to seedEverything and MergeUsers", key)
+		}
+	}
+
+	if _, err := f.srv.MergeUsers(ctx, f.source, f.target); err != nil {
+		t.Fatalf("failed to merge: %v", err)
+	}
+
+	// The source's sign-ins and guest expiry end with it
+	dropped := map[string]bool{"user_sessions.user_id": true, "guest_accounts.user_id": true}
+	for _, ref := range refs {
+		key := ref.Table_name + "." + ref.Column_name
+		want := before[key]
+		if dropped[key] {
+			want = 0
+		}
+		if got := f.count(ref.Table_name, ref.Column_name, f.target); got != want {
+			t.Errorf("expected %d rows of %s moved to the target, got %d", want, key, got)
+		}
+	}
+}
+
+func TestMergeUsersCombinesOverlappingRows(t *testing.T) {
+	f := newMergeFixture(t)
+	ctx := context.Background()
+
+	for _, userID := range []string{f.source, f.target} {
+		trainingMax := f.id(`INSERT INTO training_maxes (user_id, name, weight_kg) VALUES ($1, 'squat', 100) RETURNING id`, userID)
+		f.exec(`INSERT INTO training_max_history (training_max_id, weight_kg) VALUES ($1, 100)`, trainingMax)
+		f.exec(`INSERT INTO body_metrics (user_id, metric, measured_value, recorded_at) VALUES ($1, 'weight_kg', 80, '2026-01-01T08:00:00Z')`, userID)
+		f.exec(`INSERT INTO workout_sessions (user_id, name, started_at) VALUES ($1, 'Legs', '2026-01-01T09:00:00Z')`, userID)
+		f.exec(`INSERT INTO follows (follower_id, followee_id) VALUES ($1, $2)`, userID, f.other)
+	}
+	f.exec(`INSERT INTO daily_habits (user_id, day, steps) VALUES ($1, '2026-01-01', 1000)`, f.source)
+	f.exec(`INSERT INTO daily_habits (user_id, day, water_ml) VALUES ($1, '2026-01-01', 500)`, f.target)
+	f.exec(`INSERT INTO follows (follower_id, followee_id) VALUES ($1, $2)`, f.source, f.target)
+	org := f.id(`INSERT INTO organizations (name) VALUES ('Gym') RETURNING id`)
+	f.exec(`INSERT INTO organization_members (organization_id, user_id, role) VALUES ($1, $2, 'owner'), ($1, $3, 'member')`, org, f.source, f.target)
+
+	result, err := f.srv.MergeUsers(ctx, f.source, f.target)
+	if err != nil {
+		t.Fatalf("failed to merge: %v", err)
+	}
+	if result.DuplicateSessions != 1 || f.count("workout_sessions", "user_id", f.target) != 1 {
+		t.Errorf("expected the duplicate session dropped, got %+v", result)
+	}
+	if f.count("training_maxes", "user_id", f.target) != 1 {
+		t.Error("expected one squat training max")
+	}
+	var history int
+	if err := f.srv.GetDB().GetContext(ctx, &history, `SELECT COUNT(*) FROM training_max_history h
+		JOIN training_maxes m ON m.id = h.training_max_id WHERE m.user_id = $1`, f.target); err != nil {
+		t.Fatal(err)
+	}
+	if history != 2 {
+		t.Errorf("expected both squat histories kept, got %d entries", history)
+	}
+	if f.count("body_metrics", "user_id", f.target) != 1 {
+		t.Error("expected the duplicate body metric dropped")
+	}
+	if f.count("follows", "follower_id", f.target) != 1 {
+		t.Error("expected one follow of the other user and none of the target itself")
+	}
+
+	var habit struct {
+		Water_ml *int
+		Steps    *int
+	}
+	if err := f.srv.GetDB().GetContext(ctx, &habit, `SELECT water_ml, steps FROM daily_habits WHERE user_id = $1`, f.target); err != nil {
+		t.Fatal(err)
+	}
+	if habit.Water_ml == nil || *habit.Water_ml != 500 || habit.Steps == nil || *habit.Steps != 1000 {
+		t.Errorf("expected the day's habits combined, got %+v", habit)
+	}
+
+	var role string
+	if err := f.srv.GetDB().GetContext(ctx, &role, `SELECT role FROM organization_members WHERE organization_id = $1 AND user_id = $2`, org, f.target); err != nil {
+		t.Fatal(err)
+	}
+	if role != "owner" {
+		t.Errorf("expected the target to keep the source's higher role, got %q", role)
+	}
+}
+
+func TestMergeUsersRefusesConflicts(t *testing.T) {
+	for name, tc := range map[string]struct {
+		seed func(f *mergeFixture)
+		want error
+	}{
+		"legal hold": {func(f *mergeFixture) {
+			org := f.id(`INSERT INTO organizations (name) VALUES ('Gym') RETURNING id`)
+			f.exec(`INSERT INTO legal_holds (organization_id, user_id, reason, created_by) VALUES ($1, $2, 'litigation', $2)`, org, f.source)
+		}, ErrMergeSourceHeld},
+		"organization legal hold": {func(f *mergeFixture) {
+			org := f.id(`INSERT INTO organizations (name) VALUES ('Gym') RETURNING id`)
+			f.exec(`INSERT INTO organization_members (organization_id, user_id) VALUES ($1, $2)`, org, f.source)
+			f.exec(`INSERT INTO legal_holds (organization_id, reason, created_by) VALUES ($1, 'litigation', $2)`, org, f.other)
+		}, ErrMergeSourceHeld},
+		"photo vaults": {func(f *mergeFixture) {
+			f.exec(`INSERT INTO photo_vaults (user_id, pin_salt, wrapped_key) VALUES ($1, 'salt', 'key'), ($2, 'salt', 'key')`, f.source, f.target)
+		}, ErrMergeVaults},
+		"integrations": {func(f *mergeFixture) {
+			f.exec(`INSERT INTO integrations (user_id, provider, external_user_id, access_token, refresh_token, expires_at)
+				VALUES ($1, 'strava', gen_random_uuid()::text, 'a', 'r', NOW()), ($2, 'strava', gen_random_uuid()::text, 'a', 'r', NOW())`, f.source, f.target)
+		}, ErrMergeIntegrations},
+	} {
+		t.Run(name, func(t *testing.T) {
+			f := newMergeFixture(t)
+			tc.seed(f)
+			if _, err := f.srv.MergeUsers(context.Background(), f.source, f.target); !errors.Is(err, tc.want) {
+				t.Fatalf("expected %v, got %v", tc.want, err)
+			}
+			if f.count("users", "id", f.source) != 1 {
+				t.Error("expected the source kept after a refused merge")
+			}
+		})
+	}
+}
@@ -1,25 +1,55 @@
 package database
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"go/format"
 	"io/fs"
-	"log"
 	"os"
 	"path/filepath"
+	"regexp"
 	"sort"
 	"strings"
 	"text/template"
 	"time"
+	"unicode"
+
+	"fitness-hack/internal/messages"
 
 	"github.com/jmoiron/sqlx"
 )
 
+var (
+	// ErrMigrationDrift is returned when migrations were edited after they were applied
+	ErrMigrationDrift = errors.New("applied migrations have been edited")
+
+	// ErrMigrationLockTimeout is returned when another migration run holds the lock for
+	// longer than the manager's LockTimeout
+	ErrMigrationLockTimeout = errors.New("timed out waiting for another migration run to finish")
+)
+
+const (
+	// migrationLockKey identifies the advisory lock held while migrating. It is an
+	// arbitrary constant that only needs to differ from other advisory locks on the database.
+	migrationLockKey int64 = 0x6669746e657373 // "fitness"
+
+	// DefaultMigrationLockTimeout is how long a migration run waits for another to finish
+	DefaultMigrationLockTimeout = 5 * time.Minute
+)
+
 // Migration represents a database migration
 type Migration struct {
 	ID        int       `db:"id"`
 	Name      string    `db:"name"`
 	AppliedAt time.Time `db:"applied_at"`
+
+	// Checksum is the SHA-256 of the SQL as applied; nil for migrations applied before
+	// checksums were recorded
+	Checksum *string `db:"checksum"`
 }
 
 // MigrationFile represents a migration file
@@ -28,16 +58,74 @@ type MigrationFile struct {
 	Path     string
 	SQL      string
 	Filename string
+	Checksum string
 }
 
 // MigrationManager handles database migrations
 type MigrationManager struct {
 	db *sqlx.DB
+
+	// Force applies pending migrations even though applied ones were edited, warning
+	// about each instead of failing
+	Force bool
+
+	// LockTimeout bounds the wait for the migration lock while another pod or Lambda
+	// instance is migrating the same database
+	LockTimeout time.Duration
 }
 
-// NewMigrationManager creates a new migration manager
+// NewMigrationManager creates a new migration manager. Its lock timeout is read from
+// MIGRATION_LOCK_TIMEOUT, DefaultMigrationLockTimeout if unset.
 func NewMigrationManager(db *sqlx.DB) *MigrationManager {
-	return &MigrationManager{db: db}
+	timeout := DefaultMigrationLockTimeout
+	if d, err := time.ParseDuration(os.Getenv("MIGRATION_LOCK_TIMEOUT")); err == nil && d > 0 {
+		timeout = d
+	}
+	return &MigrationManager{db: db, LockTimeout: timeout}
+}
+
+// lock takes the session-level advisory lock that serializes migration runs, waiting up
+// to m.LockTimeout for a run elsewhere to finish. The lock belongs to the connection it
+// was taken on, so that connection is kept out of the pool until unlock.
+func (m *MigrationManager) lock(ctx context.Context) (unlock func(), err error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get a connection for the migration lock: %w", err)
+	}
+
+	deadline := time.Now().Add(m.LockTimeout)
+	for waited := false; ; waited = true {
+		var acquired bool
+		if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1)`, migrationLockKey).Scan(&acquired); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to take the migration lock: %w", err)
+		}
+		if acquired {
+			break
+		}
+		if time.Now().After(deadline) {
+			conn.Close()
+			return nil, ErrMigrationLockTimeout
+		}
+		if !waited {
+			messages.Log(messages.MigrationLockWaiting)
+		}
+		select {
+		case <-ctx.Done():
+			conn.Close()
+			return nil, ctx.Err()
+		case <-time.After(time.Second):
+		}
+	}
+
+	return func() {
+		// A fresh context, so the lock is released even when ctx has run out. Closing the
+		// connection would release it as well, but the pool may keep it open.
+		unlockCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		conn.ExecContext(unlockCtx, `SELECT pg_advisory_unlock($1)`, migrationLockKey)
+		conn.Close()
+	}, nil
 }
 
 // InitMigrationsTable creates the migrations table if it doesn't exist
@@ -48,6 +136,7 @@ func (m *MigrationManager) InitMigrationsTable(ctx context.Context) error {
 			name VARCHAR(255) NOT NULL UNIQUE,
 			applied_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
 		);
+		ALTER TABLE migrations ADD COLUMN IF NOT EXISTS checksum CHAR(64);
 	`
 	_, err := m.db.ExecContext(ctx, query)
 	return err
@@ -56,11 +145,74 @@ func (m *MigrationManager) InitMigrationsTable(ctx context.Context) error {
 // GetAppliedMigrations returns all applied migrations
 func (m *MigrationManager) GetAppliedMigrations(ctx context.Context) ([]Migration, error) {
 	var migrations []Migration
-	query := `SELECT id, name, applied_at FROM migrations ORDER BY id ASC`
+	query := `SELECT id, name, applied_at, checksum FROM migrations ORDER BY id ASC`
 	err := m.db.SelectContext(ctx, &migrations, query)
 	return migrations, err
 }
 
+// Checksum returns the hex SHA-256 of a migration's SQL. Line endings are normalized so a
+// checkout with CRLF line endings doesn't look edited.
+func Checksum(sql string) string {
+	sum := sha256.Sum256([]byte(strings.ReplaceAll(sql, "\r\n", "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// DriftedMigrations returns the names of applied migrations whose file no longer matches
+// the checksum recorded when it was applied. Migrations without a checksum, and those
+// whose file is gone, can't be checked and aren't returned.
+func DriftedMigrations(applied []Migration, files []MigrationFile) []string {
+	checksums := make(map[string]string, len(files))
+	for _, file := range files {
+		checksums[file.Name] = file.Checksum
+	}
+
+	var drifted []string
+	for _, migration := range applied {
+		current, ok := checksums[migration.Name]
+		if ok && migration.Checksum != nil && *migration.Checksum != current {
+			drifted = append(drifted, migration.Name)
+		}
+	}
+	return drifted
+}
+
+// checkDrift fails with ErrMigrationDrift if applied migrations were edited, or only
+// warns about them when m.Force is set
+func (m *MigrationManager) checkDrift(applied []Migration, files []MigrationFile) error {
+	drifted := DriftedMigrations(applied, files)
+	if len(drifted) == 0 {
+		return nil
+	}
+	if !m.Force {
+		return fmt.Errorf("%w: %s (use --force to continue anyway)", ErrMigrationDrift, strings.Join(drifted, ", "))
+	}
+	for _, name := range drifted {
+		messages.Log(messages.MigrationDriftIgnored, name)
+	}
+	return nil
+}
+
+// recordMissingChecksums stores the current checksum of migrations applied before
+// checksums were recorded, trusting that their files are unchanged since
+func (m *MigrationManager) recordMissingChecksums(ctx context.Context, applied []Migration, files []MigrationFile) error {
+	checksums := make(map[string]string, len(files))
+	for _, file := range files {
+		checksums[file.Name] = file.Checksum
+	}
+
+	for _, migration := range applied {
+		checksum, ok := checksums[migration.Name]
+		if migration.Checksum != nil || !ok {
+			continue
+		}
+		_, err := m.db.ExecContext(ctx, `UPDATE migrations SET checksum = $2 WHERE name = $1 AND checksum IS NULL`, migration.Name, checksum)
+		if err != nil {
+			return fmt.Errorf("failed to record checksum of migration %s: %w", migration.Name, err)
+		}
+	}
+	return nil
+}
+
 // ApplyMigration applies a single migration
 func (m *MigrationManager) ApplyMigration(ctx context.Context, name, sql string) error {
 	tx, err := m.db.BeginTxx(ctx, nil)
@@ -76,7 +228,7 @@ func (m *MigrationManager) ApplyMigration(ctx context.Context, name, sql string)
 	}
 
 	// Record the migration
-	_, err = tx.ExecContext(ctx, "INSERT INTO migrations (name) VALUES ($1)", name)
+	_, err = tx.ExecContext(ctx, "INSERT INTO migrations (name, checksum) VALUES ($1, $2)", name, Checksum(sql))
 	if err != nil {
 		return fmt.Errorf("failed to record migration %s: %w", name, err)
 	}
@@ -90,7 +242,7 @@ func (m *MigrationManager) LoadMigrationFiles(migrationsDir string) ([]Migration
 
 	// Check if migrations directory exists
 	if _, err := os.Stat(migrationsDir); os.IsNotExist(err) {
-		log.Printf("Migrations directory does not exist: %s", migrationsDir)
+		messages.Log(messages.MigrationsDirMissing, migrationsDir)
 		return migrationFiles, nil
 	}
 
@@ -119,6 +271,7 @@ func (m *MigrationManager) LoadMigrationFiles(migrationsDir string) ([]Migration
 			Path:     path,
 			SQL:      string(content),
 			Filename: d.Name(),
+			Checksum: Checksum(string(content)),
 		}
 
 		migrationFiles = append(migrationFiles, migrationFile)
@@ -137,21 +290,68 @@ func (m *MigrationManager) LoadMigrationFiles(migrationsDir string) ([]Migration
 	return migrationFiles, nil
 }
 
-// RunMigrations runs all pending migrations from SQL files
-func (m *MigrationManager) RunMigrations(ctx context.Context, migrationsDir string) error {
-	// Initialize migrations table
-	if err := m.InitMigrationsTable(ctx); err != nil {
-		return fmt.Errorf("failed to initialize migrations table: %w", err)
+// LoadMigrationFS loads the migration SQL files at the root of fsys, such as the
+// migrations embedded in the binary
+func (m *MigrationManager) LoadMigrationFS(fsys fs.FS) ([]MigrationFile, error) {
+	paths, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list migration files: %w", err)
 	}
+	sort.Strings(paths)
 
+	migrationFiles := make([]MigrationFile, 0, len(paths))
+	for _, path := range paths {
+		content, err := fs.ReadFile(fsys, path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration file %s: %w", path, err)
+		}
+		migrationFiles = append(migrationFiles, MigrationFile{
+			Name:     strings.TrimSuffix(path, ".sql"),
+			Path:     path,
+			SQL:      string(content),
+			Filename: path,
+			Checksum: Checksum(string(content)),
+		})
+	}
+	return migrationFiles, nil
+}
+
+// RunMigrations runs all pending migrations from SQL files
+func (m *MigrationManager) RunMigrations(ctx context.Context, migrationsDir string) error {
 	// Load migration files
 	migrationFiles, err := m.LoadMigrationFiles(migrationsDir)
 	if err != nil {
 		return fmt.Errorf("failed to load migration files: %w", err)
 	}
+	return m.applyMigrations(ctx, migrationFiles)
+}
+
+// RunMigrationFS runs all pending migrations from the SQL files at the root of fsys
+func (m *MigrationManager) RunMigrationFS(ctx context.Context, fsys fs.FS) error {
+	migrationFiles, err := m.LoadMigrationFS(fsys)
+	if err != nil {
+		return fmt.Errorf("failed to load migration files: %w", err)
+	}
+	return m.applyMigrations(ctx, migrationFiles)
+}
+
+// applyMigrations applies the migrations in migrationFiles that haven't been applied yet
+func (m *MigrationManager) applyMigrations(ctx context.Context, migrationFiles []MigrationFile) error {
+	// Hold the lock for the whole run, so a concurrent run only starts once this one has
+	// recorded what it applied
+	unlock, err := m.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	// Initialize migrations table
+	if err := m.InitMigrationsTable(ctx); err != nil {
+		return fmt.Errorf("failed to initialize migrations table: %w", err)
+	}
 
 	if len(migrationFiles) == 0 {
-		log.Println("No migration files found")
+		messages.Log(messages.MigrationsNoFiles)
 		return nil
 	}
 
@@ -160,6 +360,12 @@ func (m *MigrationManager) RunMigrations(ctx context.Context, migrationsDir stri
 	if err != nil {
 		return fmt.Errorf("failed to get applied migrations: %w", err)
 	}
+	if err := m.checkDrift(applied, migrationFiles); err != nil {
+		return err
+	}
+	if err := m.recordMissingChecksums(ctx, applied, migrationFiles); err != nil {
+		return err
+	}
 
 	appliedMap := make(map[string]bool)
 	for _, migration := range applied {
@@ -169,19 +375,21 @@ func (m *MigrationManager) RunMigrations(ctx context.Context, migrationsDir stri
 	// Apply pending migrations
 	for _, migrationFile := range migrationFiles {
 		if !appliedMap[migrationFile.Name] {
-			log.Printf("Applying migration: %s", migrationFile.Name)
+			messages.Log(messages.MigrationApplying, migrationFile.Name)
 			if err := m.ApplyMigration(ctx, migrationFile.Name, migrationFile.SQL); err != nil {
 				return fmt.Errorf("failed to apply migration %s: %w", migrationFile.Name, err)
 			}
-			log.Printf("Applied migration: %s", migrationFile.Name)
+			messages.Log(messages.MigrationApplied, migrationFile.Name)
 		}
 	}
 
 	return nil
 }
 
-// GenerateModels generates Go models from the current database schema
-func (m *MigrationManager) GenerateModels(ctx context.Context, outputPath string) error {
+// GenerateModels generates Go models from the current database schema into outputDir,
+// one models_<table>.go file per table and models.go for the shared types. Generated
+// model files of tables that no longer exist are removed.
+func (m *MigrationManager) GenerateModels(ctx context.Context, outputDir string) error {
 	// Get all tables
 	tables, err := m.getTables(ctx)
 	if err != nil {
@@ -195,6 +403,13 @@ func (m *MigrationManager) GenerateModels(ctx context.Context, outputPath string
 		if err != nil {
 			return fmt.Errorf("failed to get columns for table %s: %w", table, err)
 		}
+		enums, err := m.getEnumValues(ctx, table)
+		if err != nil {
+			return fmt.Errorf("failed to get enum values for table %s: %w", table, err)
+		}
+		for i := range columns {
+			columns[i].Enum = enums[columns[i].Name]
+		}
 
 		model := TableModel{
 			Name:    table,
@@ -203,14 +418,25 @@ func (m *MigrationManager) GenerateModels(ctx context.Context, outputPath string
 		models = append(models, model)
 	}
 
-	// Generate the Go file
-	return m.generateGoFile(models, outputPath)
+	foreignKeys, err := m.getForeignKeys(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get foreign keys: %w", err)
+	}
+	relateModels(models, foreignKeys)
+
+	// Generate the Go files
+	return m.generateGoFiles(models, outputDir)
 }
 
 // TableModel represents a database table for model generation
 type TableModel struct {
 	Name    string
 	Columns []Column
+
+	// BelongsTo are the table's foreign keys, HasMany the foreign keys of other tables
+	// referencing it
+	BelongsTo []ForeignKey
+	HasMany   []ForeignKey
 }
 
 // Column represents a database column
@@ -221,6 +447,45 @@ type Column struct {
 	IsPrimary  bool
 	IsUnique   bool
 	Default    *string
+
+	// Enum lists the values the column is limited to, by a Postgres enum type or a
+	// CHECK (column IN (...)) constraint
+	Enum []string
+
+	// References is the foreign key on the column, if any
+	References *ForeignKey
+}
+
+// ForeignKey is a foreign key from Table.Column to RefTable.RefColumn. Generated models
+// list theirs in BelongsTo and HasMany, so queries can join tables without hardcoding
+// the key columns.
+type ForeignKey struct {
+	Table     string
+	Column    string
+	RefTable  string
+	RefColumn string
+	// OnDelete is the key's delete rule, such as CASCADE or SET NULL
+	OnDelete string
+}
+
+// On returns the condition joining the tables, with alias standing for Table and refAlias
+// for RefTable, e.g. "ws.workout_id = w.id"
+func (fk ForeignKey) On(alias, refAlias string) string {
+	return alias + "." + fk.Column + " = " + refAlias + "." + fk.RefColumn
+}
+
+// EnumModel is a string type generated for a column limited to a set of values
+type EnumModel struct {
+	Type   string
+	Table  string
+	Column string
+	Values []EnumValue
+}
+
+// EnumValue is one of an enum's constants
+type EnumValue struct {
+	Const string
+	Value string
 }
 
 // getTables returns all table names in the current schema
@@ -244,6 +509,7 @@ func (m *MigrationManager) getColumns(ctx context.Context, tableName string) ([]
 		SELECT 
 			c.column_name,
 			c.data_type,
+			c.udt_name,
 			c.is_nullable,
 			CASE WHEN pk.column_name IS NOT NULL THEN true ELSE false END as is_primary,
 			CASE WHEN u.column_name IS NOT NULL THEN true ELSE false END as is_unique,
@@ -261,7 +527,7 @@ func (m *MigrationManager) getColumns(ctx context.Context, tableName string) ([]
 			JOIN information_schema.key_column_usage ku ON tc.constraint_name = ku.constraint_name
 			WHERE tc.constraint_type = 'UNIQUE' AND ku.table_name = $1
 		) u ON c.column_name = u.column_name
-		WHERE c.table_name = $1
+		WHERE c.table_name = $1 AND c.table_schema = current_schema()
 		ORDER BY c.ordinal_position
 	`
 
@@ -274,14 +540,19 @@ func (m *MigrationManager) getColumns(ctx context.Context, tableName string) ([]
 	var columns []Column
 	for rows.Next() {
 		var col Column
-		var isNullable, isPrimary, isUnique string
+		var udtName, isNullable, isPrimary, isUnique string
 		var defaultVal *string
 
-		err := rows.Scan(&col.Name, &col.Type, &isNullable, &isPrimary, &isUnique, &defaultVal)
+		err := rows.Scan(&col.Name, &col.Type, &udtName, &isNullable, &isPrimary, &isUnique, &defaultVal)
 		if err != nil {
 			return nil, err
 		}
 
+		// Postgres enum types report USER-DEFINED, their values come from getEnumValues
+		if col.Type == "USER-DEFINED" {
+			col.Type = "text"
+		}
+
 		col.IsNullable = isNullable == "YES"
 		col.IsPrimary = isPrimary == "true"
 		col.IsUnique = isUnique == "true"
@@ -291,7 +562,134 @@ func (m *MigrationManager) getColumns(ctx context.Context, tableName string) ([]
 		columns = append(columns, col)
 	}
 
-	return columns, nil
+	return columns, rows.Err()
+}
+
+// getForeignKeys returns the single-column foreign keys between the tables of the current
+// schema, ordered by table and column
+func (m *MigrationManager) getForeignKeys(ctx context.Context) ([]ForeignKey, error) {
+	query := `
+		SELECT kcu.table_name, kcu.column_name, ccu.table_name AS ref_table, ccu.column_name AS ref_column, rc.delete_rule
+		FROM information_schema.referential_constraints rc
+		JOIN information_schema.key_column_usage kcu
+			ON kcu.constraint_schema = rc.constraint_schema AND kcu.constraint_name = rc.constraint_name
+		JOIN information_schema.constraint_column_usage ccu
+			ON ccu.constraint_schema = rc.unique_constraint_schema AND ccu.constraint_name = rc.unique_constraint_name
+		WHERE rc.constraint_schema = current_schema()
+			AND (SELECT COUNT(*) FROM information_schema.key_column_usage k
+				WHERE k.constraint_schema = rc.constraint_schema AND k.constraint_name = rc.constraint_name) = 1
+		ORDER BY kcu.table_name, kcu.column_name
+	`
+	rows, err := m.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []ForeignKey
+	for rows.Next() {
+		var fk ForeignKey
+		if err := rows.Scan(&fk.Table, &fk.Column, &fk.RefTable, &fk.RefColumn, &fk.OnDelete); err != nil {
+			return nil, err
+		}
+		keys = append(keys, fk)
+	}
+	return keys, rows.Err()
+}
+
+// relateModels sets the foreign keys of the models' columns, and each model's BelongsTo
+// and HasMany. Keys to or from tables without a model are left out.
+func relateModels(models []TableModel, keys []ForeignKey) {
+	index := make(map[string]int, len(models))
+	for i, model := range models {
+		index[model.Name] = i
+	}
+	for _, fk := range keys {
+		i, ok := index[fk.Table]
+		j, refOK := index[fk.RefTable]
+		if !ok || !refOK {
+			continue
+		}
+		model := &models[i]
+		for c := range model.Columns {
+			if model.Columns[c].Name == fk.Column {
+				key := fk
+				model.Columns[c].References = &key
+			}
+		}
+		model.BelongsTo = append(model.BelongsTo, fk)
+		models[j].HasMany = append(models[j].HasMany, fk)
+	}
+}
+
+var (
+	// checkInPattern matches the definition Postgres prints for CHECK (column IN (...)),
+	// with the column cast to text for varchar columns
+	checkInPattern = regexp.MustCompile(`^CHECK \(\(\(?(\w+)\)?(?:::text)? = ANY \(\(?ARRAY\[(.*)\]\)?(?:::text\[\])?\)\)\)$`)
+
+	// checkEqualsPattern matches the definition Postgres prints for CHECK (column IN ('value'))
+	checkEqualsPattern = regexp.MustCompile(`^CHECK \(\(\(?(\w+)\)?(?:::text)? = ('(?:[^']|'')*'::[\w ]+)\)\)$`)
+
+	// enumLiteralPattern matches the quoted values in a check constraint
+	enumLiteralPattern = regexp.MustCompile(`'((?:[^']|'')*)'`)
+)
+
+// getEnumValues returns the values of the table's columns that are limited to a set of
+// values by a Postgres enum type or a CHECK (column IN (...)) constraint, by column name
+func (m *MigrationManager) getEnumValues(ctx context.Context, tableName string) (map[string][]string, error) {
+	var enumColumns []struct {
+		Column string `db:"column_name"`
+		Label  string `db:"enumlabel"`
+	}
+	err := m.db.SelectContext(ctx, &enumColumns, `SELECT a.attname AS column_name, e.enumlabel
+		FROM pg_attribute a
+		JOIN pg_enum e ON e.enumtypid = a.atttypid
+		WHERE a.attrelid = (quote_ident(current_schema()) || '.' || quote_ident($1))::regclass
+			AND a.attnum > 0 AND NOT a.attisdropped
+		ORDER BY a.attnum, e.enumsortorder`, tableName)
+	if err != nil {
+		return nil, err
+	}
+	enums := make(map[string][]string)
+	for _, col := range enumColumns {
+		enums[col.Column] = append(enums[col.Column], col.Label)
+	}
+
+	var checks []string
+	err = m.db.SelectContext(ctx, &checks, `SELECT pg_get_constraintdef(oid)
+		FROM pg_constraint
+		WHERE conrelid = (quote_ident(current_schema()) || '.' || quote_ident($1))::regclass AND contype = 'c'
+		ORDER BY conname`, tableName)
+	if err != nil {
+		return nil, err
+	}
+	for _, check := range checks {
+		if column, values := parseEnumCheck(check); column != "" {
+			enums[column] = values
+		}
+	}
+	return enums, nil
+}
+
+// parseEnumCheck returns the column and values of a CHECK (column IN (...)) constraint
+// definition, or an empty column for any other check
+func parseEnumCheck(definition string) (string, []string) {
+	match := checkInPattern.FindStringSubmatch(definition)
+	if match == nil {
+		match = checkEqualsPattern.FindStringSubmatch(definition)
+	}
+	if match == nil {
+		return "", nil
+	}
+
+	var values []string
+	for _, literal := range enumLiteralPattern.FindAllStringSubmatch(match[2], -1) {
+		values = append(values, strings.ReplaceAll(literal[1], "''", "'"))
+	}
+	if len(values) == 0 {
+		return "", nil
+	}
+	return match[1], values
 }
 
 // mapSQLTypeToGoType maps PostgreSQL types to Go types
@@ -299,7 +697,7 @@ func (m *MigrationManager) mapSQLTypeToGoType(sqlType string) string {
 	switch strings.ToLower(sqlType) {
 	case "uuid":
 		return "string"
-	case "varchar", "text", "char":
+	case "varchar", "text", "char", "character varying", "character":
 		return "string"
 	case "integer", "int", "int4":
 		return "int"
@@ -323,51 +721,164 @@ func (m *MigrationManager) mapSQLTypeToGoType(sqlType string) string {
 		return "time.Time"
 	case "json", "jsonb":
 		return "json.RawMessage"
+	case "bytea":
+		return "[]byte"
 	default:
 		return "interface{}"
 	}
 }
 
-// generateGoFile generates the Go models file
-func (m *MigrationManager) generateGoFile(models []TableModel, outputPath string) error {
-	// Ensure output directory exists
-	dir := filepath.Dir(outputPath)
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return fmt.Errorf("failed to create output directory: %w", err)
+// enumModels returns the enum types of a table's columns and sets the columns' types to them
+func (m *MigrationManager) enumModels(model *TableModel) []EnumModel {
+	var enums []EnumModel
+	for i, col := range model.Columns {
+		if len(col.Enum) == 0 {
+			continue
+		}
+		enum := EnumModel{
+			Type:   strings.Title(model.Name) + "_" + col.Name,
+			Table:  model.Name,
+			Column: col.Name,
+		}
+		for _, value := range col.Enum {
+			enum.Values = append(enum.Values, EnumValue{Const: enum.Type + "_" + identifierPart(value), Value: value})
+		}
+		model.Columns[i].Type = enum.Type
+		enums = append(enums, enum)
 	}
+	return enums
+}
 
-	// Create the file
-	file, err := os.Create(outputPath)
-	if err != nil {
-		return fmt.Errorf("failed to create output file: %w", err)
+// identifierPart turns an enum value into the part of a Go identifier after its type name
+func identifierPart(value string) string {
+	part := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return '_'
+	}, strings.ToLower(value))
+	if part == "" {
+		return "empty"
+	}
+	return part
+}
+
+// fieldType is the Go type of a column's struct field. Nullable scalar columns are pointers,
+// while JSON, bytea and unmapped types already hold NULL.
+func fieldType(col Column) string {
+	if !col.IsNullable || col.Type == "json.RawMessage" || col.Type == "[]byte" || col.Type == "interface{}" {
+		return col.Type
+	}
+	return "*" + col.Type
+}
+
+// modelImports returns the packages a table's model file uses, standard library first
+func modelImports(model TableModel) [][]string {
+	std := []string{"database/sql/driver", "encoding/json", "fmt"}
+	var external []string
+	for _, col := range model.Columns {
+		switch col.Type {
+		case "time.Time":
+			if !contains(std, "time") {
+				std = append(std, "time")
+			}
+		case "decimal.Decimal":
+			if !contains(external, "github.com/shopspring/decimal") {
+				external = append(external, "github.com/shopspring/decimal")
+			}
+		}
+	}
+	if len(external) == 0 {
+		return [][]string{std}
+	}
+	return [][]string{std, external}
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// generateGoFiles writes each table's model file and the shared types file, gofmt'd
+func (m *MigrationManager) generateGoFiles(models []TableModel, outputDir string) error {
+	// Ensure output directory exists
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
 	}
-	defer file.Close()
 
 	// Create template with functions
 	funcMap := template.FuncMap{
 		"title": strings.Title,
 		"snake": m.toSnakeCase,
+		"field": fieldType,
 	}
 
-	// Parse and execute the template
-	tmpl, err := template.New("models").Funcs(funcMap).Parse(modelsTemplate)
+	// Parse the templates
+	tmpl, err := template.New("model").Funcs(funcMap).Parse(modelTemplate)
 	if err != nil {
 		return fmt.Errorf("failed to parse template: %w", err)
 	}
+	if _, err := tmpl.New("types").Parse(modelTypesTemplate); err != nil {
+		return fmt.Errorf("failed to parse template: %w", err)
+	}
 
-	data := struct {
-		Models []TableModel
-		Time   string
-	}{
-		Models: models,
-		Time:   time.Now().Format("2006-01-02 15:04:05"),
+	// Remove the generated files of dropped tables before writing the current ones
+	stale, err := filepath.Glob(filepath.Join(outputDir, "models_*.go"))
+	if err != nil {
+		return err
+	}
+	for _, path := range stale {
+		if content, err := os.ReadFile(path); err == nil && strings.HasPrefix(string(content), generatedHeader) {
+			if err := os.Remove(path); err != nil {
+				return fmt.Errorf("failed to remove %s: %w", path, err)
+			}
+		}
 	}
 
-	if err := tmpl.Execute(file, data); err != nil {
-		return fmt.Errorf("failed to execute template: %w", err)
+	generatedAt := time.Now().Format("2006-01-02 15:04:05")
+	for _, model := range models {
+		enums := m.enumModels(&model)
+		data := struct {
+			TableModel
+			Enums   []EnumModel
+			Imports [][]string
+			Time    string
+		}{
+			TableModel: model,
+			Enums:      enums,
+			Imports:    modelImports(model),
+			Time:       generatedAt,
+		}
+		if err := writeTemplate(tmpl, "model", filepath.Join(outputDir, "models_"+model.Name+".go"), data); err != nil {
+			return err
+		}
+	}
+
+	if err := writeTemplate(tmpl, "types", filepath.Join(outputDir, "models.go"), struct{ Time string }{generatedAt}); err != nil {
+		return err
 	}
 
-	log.Printf("Generated models file: %s", outputPath)
+	messages.Log(messages.MigrationModelsGenerated, len(models)+1, outputDir)
+	return nil
+}
+
+// writeTemplate executes the named template and writes the result to path, gofmt'd
+func writeTemplate(tmpl *template.Template, name, path string, data interface{}) error {
+	var buf bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&buf, name, data); err != nil {
+		return fmt.Errorf("failed to execute template for %s: %w", path, err)
+	}
+	source, err := format.Source(buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("failed to format %s: %w", path, err)
+	}
+	if err := os.WriteFile(path, source, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
 	return nil
 }
 
@@ -383,25 +894,52 @@ func (m *MigrationManager) toSnakeCase(s string) string {
 	return strings.ToLower(result.String())
 }
 
-// modelsTemplate is the Go template for generating model files
-const modelsTemplate = `// Code generated by migration system on {{.Time}}
+// generatedHeader starts every generated model file, and marks the files GenerateModels may remove
+const generatedHeader = "// Code generated by migration system"
+
+// modelTemplate is the Go template for a table's model file
+const modelTemplate = generatedHeader + ` on {{.Time}}
 // DO NOT EDIT THIS FILE MANUALLY
 
 package database
 
 import (
-	"database/sql/driver"
-	"encoding/json"
-	"fmt"
-	"time"
-
-	"github.com/shopspring/decimal"
-)
+{{range .Imports}}{{range .}}	"{{.}}"
+{{end}}
+{{end}})
+{{range $enum := .Enums}}
+// {{.Type}} is a value of {{.Table}}.{{.Column}}
+type {{.Type}} string
+
+const (
+{{range .Values}}	{{.Const}} {{$enum.Type}} = "{{.Value}}"
+{{end}})
+
+// {{.Type}}Values lists the allowed values of {{.Table}}.{{.Column}}
+var {{.Type}}Values = []{{.Type}}{ {{range .Values}}{{.Const}}, {{end}} }
+
+// Valid reports whether v is an allowed value of {{.Table}}.{{.Column}}
+func (v {{.Type}}) Valid() bool {
+	for _, value := range {{.Type}}Values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
 
-{{range .Models}}
+// Parse{{.Type}} returns s as a value of {{.Table}}.{{.Column}}, or an error if it isn't an allowed one
+func Parse{{.Type}}(s string) ({{.Type}}, error) {
+	v := {{.Type}}(s)
+	if !v.Valid() {
+		return "", fmt.Errorf("invalid {{.Table}}.{{.Column}} %q", s)
+	}
+	return v, nil
+}
+{{end}}
 // {{.Name | title}} represents the {{.Name}} table
 type {{.Name | title}} struct {
-{{range .Columns}}	{{.Name | title}} {{.Type}} ` + "`" + `db:"{{.Name}}" json:"{{.Name | snake}}"` + "`" + `{{if .IsPrimary}} // Primary key{{end}}{{if .IsUnique}} // Unique{{end}}{{if .Default}} // Default: {{.Default}}{{end}}
+{{range .Columns}}	{{.Name | title}} {{field .}} ` + "`" + `db:"{{.Name}}" json:"{{.Name | snake}}"` + "`" + `{{if .IsPrimary}} // Primary key{{end}}{{if .IsUnique}} // Unique{{end}}{{with .References}} // References {{.RefTable}}({{.RefColumn}}){{end}}{{if .Default}} // Default: {{.Default}}{{end}}
 {{end}}}
 
 // TableName returns the table name for {{.Name | title}}
@@ -414,7 +952,7 @@ func (m *{{.Name | title}}) Scan(value interface{}) error {
 	if value == nil {
 		return nil
 	}
-	
+
 	switch v := value.(type) {
 	case []byte:
 		return json.Unmarshal(v, m)
@@ -429,7 +967,33 @@ func (m *{{.Name | title}}) Scan(value interface{}) error {
 func (m {{.Name | title}}) Value() (driver.Value, error) {
 	return json.Marshal(m)
 }
-{{end}}
+{{if .BelongsTo}}
+// BelongsTo returns the foreign keys of {{.Name}}, by column
+func ({{.Name | title}}) BelongsTo() map[string]ForeignKey {
+	return map[string]ForeignKey{
+{{range .BelongsTo}}		"{{.Column}}": {Table: "{{.Table}}", Column: "{{.Column}}", RefTable: "{{.RefTable}}", RefColumn: "{{.RefColumn}}", OnDelete: "{{.OnDelete}}"},
+{{end}}	}
+}
+{{end}}{{if .HasMany}}
+// HasMany returns the foreign keys referencing {{.Name}}, by table and column
+func ({{.Name | title}}) HasMany() map[string]ForeignKey {
+	return map[string]ForeignKey{
+{{range .HasMany}}		"{{.Table}}.{{.Column}}": {Table: "{{.Table}}", Column: "{{.Column}}", RefTable: "{{.RefTable}}", RefColumn: "{{.RefColumn}}", OnDelete: "{{.OnDelete}}"},
+{{end}}	}
+}
+{{end}}`
+
+// modelTypesTemplate is the Go template for the types shared by the model files
+const modelTypesTemplate = generatedHeader + ` on {{.Time}}
+// DO NOT EDIT THIS FILE MANUALLY
+
+package database
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
 
 // Custom types for better type safety
 type JSONMap map[string]interface{}
@@ -443,7 +1007,7 @@ func (j *JSONMap) Scan(value interface{}) error {
 		*j = nil
 		return nil
 	}
-	
+
 	switch v := value.(type) {
 	case []byte:
 		return json.Unmarshal(v, j)
@@ -0,0 +1,131 @@
+package database
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDriftedMigrations(t *testing.T) {
+	if Checksum("SELECT 1;\r\n") != Checksum("SELECT 1;\n") {
+		t.Error("expected line endings not to change the checksum")
+	}
+
+	original, edited := Checksum("CREATE TABLE a ();"), Checksum("CREATE TABLE a (id INT);")
+	applied := []Migration{
+		{Name: "001_unchanged", Checksum: &original},
+		{Name: "002_edited", Checksum: &original},
+		{Name: "003_before_checksums"},
+		{Name: "004_deleted", Checksum: &original},
+	}
+	files := []MigrationFile{
+		{Name: "001_unchanged", Checksum: original},
+		{Name: "002_edited", Checksum: edited},
+		{Name: "003_before_checksums", Checksum: edited},
+		{Name: "005_pending", Checksum: edited},
+	}
+
+	drifted := DriftedMigrations(applied, files)
+	if want := []string{"002_edited"}; !reflect.DeepEqual(drifted, want) {
+		t.Fatalf("expected %v, got %v", want, drifted)
+	}
+
+	m := &MigrationManager{}
+	if err := m.checkDrift(applied, files); !errors.Is(err, ErrMigrationDrift) {
+		t.Errorf("expected ErrMigrationDrift, got %v", err)
+	}
+	m.Force = true
+	if err := m.checkDrift(applied, files); err != nil {
+		t.Errorf("expected --force to only warn, got %v", err)
+	}
+}
+
+func TestParseEnumCheck(t *testing.T) {
+	tests := []struct {
+		definition string
+		column     string
+		values     []string
+	}{
+		{"CHECK ((pose = ANY (ARRAY['front'::text, 'side'::text])))", "pose", []string{"front", "side"}},
+		{"CHECK (((difficulty)::text = ANY ((ARRAY['beginner'::character varying, 'advanced'::character varying])::text[])))", "difficulty", []string{"beginner", "advanced"}},
+		{"CHECK ((protocol = 'oidc'::text))", "protocol", []string{"oidc"}},
+		{"CHECK ((ends_at > starts_at))", "", nil},
+	}
+	for _, tt := range tests {
+		column, values := parseEnumCheck(tt.definition)
+		if column != tt.column || !reflect.DeepEqual(values, tt.values) {
+			t.Errorf("%s: expected %s %v, got %s %v", tt.definition, tt.column, tt.values, column, values)
+		}
+	}
+}
+
+func TestGenerateModelFiles(t *testing.T) {
+	dir := t.TempDir()
+	stale := filepath.Join(dir, "models_dropped.go")
+	if err := os.WriteFile(stale, []byte(generatedHeader+" on 2025-01-01\npackage database\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	m := &MigrationManager{}
+	models := []TableModel{{Name: "programs", Columns: []Column{
+		{Name: "id", Type: "string", IsPrimary: true},
+		{Name: "difficulty", Type: "string", IsNullable: true, Enum: []string{"beginner", "no-show"}},
+		{Name: "created_at", Type: "time.Time"},
+	}}, {Name: "program_weeks", Columns: []Column{
+		{Name: "program_id", Type: "string", IsPrimary: true},
+		{Name: "volume_percent", Type: "int"},
+	}}}
+	relateModels(models, []ForeignKey{
+		{Table: "program_weeks", Column: "program_id", RefTable: "programs", RefColumn: "id", OnDelete: "CASCADE"},
+		{Table: "program_weeks", Column: "user_id", RefTable: "users", RefColumn: "id", OnDelete: "CASCADE"},
+	})
+	if err := m.generateGoFiles(models, dir); err != nil {
+		t.Fatalf("error generating models. Err: %v", err)
+	}
+
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Error("expected the generated file of a dropped table to be removed")
+	}
+	content, err := os.ReadFile(filepath.Join(dir, "models_programs.go"))
+	if err != nil {
+		t.Fatalf("expected a file per table. Err: %v", err)
+	}
+	for _, want := range []string{
+		"type Programs_difficulty string",
+		`Programs_difficulty_no_show  Programs_difficulty = "no-show"`,
+		"func (v Programs_difficulty) Valid() bool",
+		"func ParsePrograms_difficulty(s string) (Programs_difficulty, error)",
+		"Difficulty *Programs_difficulty `db:\"difficulty\"",
+		"\t\"time\"\n)",
+	} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("expected generated model to contain %q:\n%s", want, content)
+		}
+	}
+
+	content, err = os.ReadFile(filepath.Join(dir, "models_program_weeks.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"`db:\"program_id\" json:\"program_id\"` // Primary key // References programs(id)",
+		`"program_id": {Table: "program_weeks", Column: "program_id", RefTable: "programs", RefColumn: "id", OnDelete: "CASCADE"},`,
+	} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("expected generated model to contain %q:\n%s", want, content)
+		}
+	}
+	if strings.Contains(string(content), "users") {
+		t.Errorf("expected keys to tables without a model to be left out:\n%s", content)
+	}
+	if got := (Programs{}).HasMany()["program_weeks.program_id"].On("pw", "p"); got != "pw.program_id = p.id" {
+		t.Errorf("unexpected join condition %q", got)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "models.go")); err != nil {
+		t.Errorf("expected the shared types file. Err: %v", err)
+	}
+}
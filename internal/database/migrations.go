@@ -3,10 +3,12 @@ package database
 import (
 	"context"
 	"fmt"
-	"log"
 	"os"
 	"path/filepath"
 
+	"fitness-hack/internal/database/migrations"
+	"fitness-hack/internal/messages"
+
 	"github.com/jmoiron/sqlx"
 )
 
@@ -26,11 +28,16 @@ func RunMigrationsFromDir(ctx context.Context, db *sqlx.DB, migrationsDir string
 	return manager.RunMigrations(ctx, migrationsDir)
 }
 
+// RunEmbeddedMigrations runs the pending migrations embedded in the binary, for
+// deployments without the source tree
+func RunEmbeddedMigrations(ctx context.Context, db *sqlx.DB) error {
+	return NewMigrationManager(db).RunMigrationFS(ctx, migrations.Files)
+}
+
 // GenerateModelsFromDB generates Go models from the current database schema
 func GenerateModelsFromDB(ctx context.Context, db *sqlx.DB) error {
 	manager := NewMigrationManager(db)
-	outputPath := filepath.Join("internal", "database", "models.go")
-	return manager.GenerateModels(ctx, outputPath)
+	return manager.GenerateModels(ctx, filepath.Join("internal", "database"))
 }
 
 // CreateMigrationFile creates a new migration file with the given name
@@ -48,6 +55,6 @@ func CreateMigrationFile(name, sql string) error {
 		return fmt.Errorf("failed to create migration file: %w", err)
 	}
 
-	log.Printf("Created migration file: %s", filename)
+	messages.Log(messages.MigrationFileCreated, filename)
 	return nil
 }
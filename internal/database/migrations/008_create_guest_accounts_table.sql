@@ -0,0 +1,17 @@
+-- Migration: 008_create_guest_accounts_table.sql
+-- Description: create guest accounts table for time-boxed demo users
+-- Date: 2025-07-08
+
+CREATE TABLE IF NOT EXISTS guest_accounts (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Cleanup job scans for expired guests
+CREATE INDEX IF NOT EXISTS idx_guest_accounts_expires_at ON guest_accounts(expires_at);
+
+-- Add comments for documentation
+COMMENT ON TABLE guest_accounts IS 'Marks users as sandboxed demo accounts that expire automatically';
+COMMENT ON COLUMN guest_accounts.user_id IS 'Reference to the guest user';
+COMMENT ON COLUMN guest_accounts.expires_at IS 'When the guest account and its data are purged';
@@ -0,0 +1,23 @@
+-- Migration: 009_create_oauth_identities_table.sql
+-- Description: create oauth identities table for social login
+-- Date: 2025-07-10
+
+CREATE TABLE IF NOT EXISTS oauth_identities (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    provider TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    email TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE(provider, subject)
+);
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_oauth_identities_user_id ON oauth_identities(user_id);
+
+-- Add comments for documentation
+COMMENT ON TABLE oauth_identities IS 'Links users to accounts at external identity providers';
+COMMENT ON COLUMN oauth_identities.provider IS 'Identity provider name (google, apple)';
+COMMENT ON COLUMN oauth_identities.subject IS 'Stable user identifier issued by the provider (sub claim)';
+COMMENT ON COLUMN oauth_identities.email IS 'Email asserted by the provider at link time';
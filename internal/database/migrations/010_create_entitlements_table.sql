@@ -0,0 +1,16 @@
+-- Migration: 010_create_entitlements_table.sql
+-- Description: create entitlements table tracking premium access per user
+-- Date: 2025-07-11
+
+CREATE TABLE IF NOT EXISTS entitlements (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    premium_until TIMESTAMP WITH TIME ZONE NOT NULL,
+    source TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Add comments for documentation
+COMMENT ON TABLE entitlements IS 'Premium access granted to users from any source (referrals, purchases)';
+COMMENT ON COLUMN entitlements.premium_until IS 'Premium features are available until this time';
+COMMENT ON COLUMN entitlements.source IS 'Source of the most recent grant';
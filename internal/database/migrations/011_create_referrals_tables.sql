@@ -0,0 +1,27 @@
+-- Migration: 011_create_referrals_tables.sql
+-- Description: create referral codes and referrals tables
+-- Date: 2025-07-11
+
+CREATE TABLE IF NOT EXISTS referral_codes (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    code TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS referrals (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    referrer_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    referred_user_id UUID NOT NULL UNIQUE REFERENCES users(id) ON DELETE CASCADE,
+    code TEXT NOT NULL,
+    reward_weeks INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_referrals_referrer_id ON referrals(referrer_id);
+
+-- Add comments for documentation
+COMMENT ON TABLE referral_codes IS 'Shareable referral code for each user';
+COMMENT ON TABLE referrals IS 'Signups attributed to a referral code';
+COMMENT ON COLUMN referrals.referred_user_id IS 'A user can only be referred once';
+COMMENT ON COLUMN referrals.reward_weeks IS 'Free premium weeks granted to both referrer and referred user';
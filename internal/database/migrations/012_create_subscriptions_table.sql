@@ -0,0 +1,25 @@
+-- Migration: 012_create_subscriptions_table.sql
+-- Description: create subscriptions table for App Store and Play Billing purchases
+-- Date: 2025-07-12
+
+CREATE TABLE IF NOT EXISTS subscriptions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    platform TEXT NOT NULL CHECK (platform IN ('apple', 'google')),
+    product_id TEXT NOT NULL,
+    original_transaction_id TEXT NOT NULL,
+    status TEXT NOT NULL CHECK (status IN ('active', 'grace', 'canceled', 'expired', 'refunded')),
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    auto_renew BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (platform, original_transaction_id)
+);
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_subscriptions_user_id ON subscriptions(user_id);
+
+-- Add comments for documentation
+COMMENT ON TABLE subscriptions IS 'Mobile store subscriptions validated server-side';
+COMMENT ON COLUMN subscriptions.original_transaction_id IS 'Apple original transaction ID or Google purchase token';
+COMMENT ON COLUMN subscriptions.status IS 'Canceled subscriptions stay premium until expires_at; refunded ones do not';
@@ -0,0 +1,23 @@
+-- Migration: 013_create_api_keys_table.sql
+-- Description: create api_keys table for programmatic clients
+-- Date: 2025-07-13
+
+CREATE TABLE IF NOT EXISTS api_keys (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL DEFAULT '',
+    prefix TEXT NOT NULL,
+    key_hash TEXT NOT NULL UNIQUE,
+    scope TEXT NOT NULL CHECK (scope IN ('read', 'write')),
+    last_used_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_api_keys_user_id ON api_keys(user_id);
+
+-- Add comments for documentation
+COMMENT ON TABLE api_keys IS 'API keys accepted via the X-API-Key header as an alternative to JWT';
+COMMENT ON COLUMN api_keys.prefix IS 'First characters of the key, shown to users to identify it';
+COMMENT ON COLUMN api_keys.key_hash IS 'SHA-256 of the key; the key itself is only shown once at creation';
+COMMENT ON COLUMN api_keys.scope IS 'read keys may only perform GET requests';
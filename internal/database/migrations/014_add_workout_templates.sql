@@ -0,0 +1,10 @@
+-- Migration: 014_add_workout_templates.sql
+-- Description: add is_template flag to workouts so they can be shared and cloned
+-- Date: 2025-07-14
+
+ALTER TABLE workouts ADD COLUMN IF NOT EXISTS is_template BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Partial index: templates are listed across all users
+CREATE INDEX IF NOT EXISTS idx_workouts_is_template ON workouts(created_at) WHERE is_template;
+
+COMMENT ON COLUMN workouts.is_template IS 'Template workouts can be cloned by any user';
@@ -0,0 +1,23 @@
+-- Migration: 015_create_data_exports_table.sql
+-- Description: create data_exports table tracking GDPR account export requests
+-- Date: 2025-07-15
+
+CREATE TABLE IF NOT EXISTS data_exports (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'ready', 'failed')),
+    storage_key TEXT NOT NULL DEFAULT '',
+    size_bytes BIGINT NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    completed_at TIMESTAMP WITH TIME ZONE,
+    expires_at TIMESTAMP WITH TIME ZONE
+);
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_data_exports_user_id ON data_exports(user_id, created_at DESC);
+
+-- Add comments for documentation
+COMMENT ON TABLE data_exports IS 'Account data export archives requested by users (GDPR right of access)';
+COMMENT ON COLUMN data_exports.storage_key IS 'Object key of the ZIP archive in the configured storage';
+COMMENT ON COLUMN data_exports.expires_at IS 'The archive can no longer be downloaded after this time';
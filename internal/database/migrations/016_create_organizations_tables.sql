@@ -0,0 +1,51 @@
+-- Migration: 016_create_organizations_tables.sql
+-- Description: create organizations, memberships and email invitations for gyms
+-- Date: 2025-07-16
+
+CREATE TABLE IF NOT EXISTS organizations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name TEXT NOT NULL,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS organization_members (
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role TEXT NOT NULL DEFAULT 'member' CHECK (role IN ('owner', 'admin', 'member')),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (organization_id, user_id)
+);
+
+CREATE TABLE IF NOT EXISTS organization_invites (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    email TEXT NOT NULL,
+    role TEXT NOT NULL DEFAULT 'member' CHECK (role IN ('admin', 'member')),
+    token_hash TEXT NOT NULL UNIQUE,
+    invited_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    send_count INTEGER NOT NULL DEFAULT 1,
+    last_sent_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    accepted_at TIMESTAMP WITH TIME ZONE,
+    accepted_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    revoked_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_organization_members_user_id ON organization_members(user_id);
+CREATE INDEX IF NOT EXISTS idx_organization_invites_organization_id ON organization_invites(organization_id);
+
+-- At most one open invitation per address and organization
+CREATE UNIQUE INDEX IF NOT EXISTS idx_organization_invites_pending
+    ON organization_invites(organization_id, lower(email))
+    WHERE accepted_at IS NULL AND revoked_at IS NULL;
+
+-- Add comments for documentation
+COMMENT ON TABLE organizations IS 'Gyms and other groups whose membership is invite-only';
+COMMENT ON COLUMN organization_members.role IS 'owner and admin members manage invitations';
+COMMENT ON TABLE organization_invites IS 'Email invitations to join an organization';
+COMMENT ON COLUMN organization_invites.token_hash IS 'SHA-256 of the invitation token; the token itself is only sent by email';
+COMMENT ON COLUMN organization_invites.send_count IS 'Number of times the invitation email was sent, including resends';
@@ -0,0 +1,31 @@
+-- Migration: 017_create_account_deletions_table.sql
+-- Description: create account_deletions table scheduling and auditing GDPR account erasure
+-- Date: 2025-07-17
+
+CREATE TABLE IF NOT EXISTS account_deletions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'canceled', 'completed')),
+    requested_ip TEXT NOT NULL DEFAULT '',
+    requested_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    purge_after TIMESTAMP WITH TIME ZONE NOT NULL,
+    canceled_at TIMESTAMP WITH TIME ZONE,
+    completed_at TIMESTAMP WITH TIME ZONE,
+    purged_rows JSONB NOT NULL DEFAULT '{}',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT NOT NULL DEFAULT ''
+);
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_account_deletions_due ON account_deletions(purge_after) WHERE status = 'pending';
+
+-- At most one scheduled deletion per user
+CREATE UNIQUE INDEX IF NOT EXISTS idx_account_deletions_pending_user
+    ON account_deletions(user_id)
+    WHERE status = 'pending';
+
+-- Add comments for documentation
+COMMENT ON TABLE account_deletions IS 'Audit trail of account deletion requests; rows outlive the deleted user';
+COMMENT ON COLUMN account_deletions.user_id IS 'Deleted user ID; intentionally not a foreign key so the record survives the purge';
+COMMENT ON COLUMN account_deletions.purge_after IS 'End of the grace period, after which the account is purged unless canceled';
+COMMENT ON COLUMN account_deletions.purged_rows IS 'Number of rows deleted per table by the purge';
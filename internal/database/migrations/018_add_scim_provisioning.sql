@@ -0,0 +1,25 @@
+-- Migration: 018_add_scim_provisioning.sql
+-- Description: SCIM provisioning tokens and provisioning state on organization members
+-- Date: 2025-07-18
+
+CREATE TABLE IF NOT EXISTS organization_scim_tokens (
+    organization_id UUID PRIMARY KEY REFERENCES organizations(id) ON DELETE CASCADE,
+    token_hash TEXT NOT NULL UNIQUE,
+    last_used_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+ALTER TABLE organization_members ADD COLUMN IF NOT EXISTS active BOOLEAN NOT NULL DEFAULT TRUE;
+ALTER TABLE organization_members ADD COLUMN IF NOT EXISTS external_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE organization_members ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW();
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_organization_members_external_id
+    ON organization_members(organization_id, external_id)
+    WHERE external_id <> '';
+
+-- Add comments for documentation
+COMMENT ON TABLE organization_scim_tokens IS 'Bearer token used by an organization''s identity provider for SCIM provisioning';
+COMMENT ON COLUMN organization_scim_tokens.token_hash IS 'SHA-256 of the token; the token itself is only shown once';
+COMMENT ON COLUMN organization_members.active IS 'Deactivated members keep their account but lose access to the organization';
+COMMENT ON COLUMN organization_members.external_id IS 'Identifier of the member in the organization''s identity provider (SCIM externalId)';
@@ -0,0 +1,26 @@
+-- Migration: 019_create_organization_sso_table.sql
+-- Description: per-organization single sign-on configuration
+-- Date: 2025-07-19
+
+CREATE TABLE IF NOT EXISTS organization_sso (
+    organization_id UUID PRIMARY KEY REFERENCES organizations(id) ON DELETE CASCADE,
+    protocol TEXT NOT NULL DEFAULT 'oidc' CHECK (protocol IN ('oidc')),
+    issuer TEXT NOT NULL,
+    client_id TEXT NOT NULL,
+    authorization_endpoint TEXT NOT NULL DEFAULT '',
+    jwks_url TEXT NOT NULL,
+    role_claim TEXT NOT NULL DEFAULT '',
+    admin_role_values TEXT NOT NULL DEFAULT '',
+    jit_provisioning BOOLEAN NOT NULL DEFAULT TRUE,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Add comments for documentation
+COMMENT ON TABLE organization_sso IS 'Identity provider an organization''s members sign in with';
+COMMENT ON COLUMN organization_sso.protocol IS 'Only OpenID Connect is supported for now; SAML will be added as another protocol';
+COMMENT ON COLUMN organization_sso.jwks_url IS 'Resolved from the issuer''s discovery document when the configuration is saved';
+COMMENT ON COLUMN organization_sso.role_claim IS 'ID token claim (e.g. groups) mapped to the member role; empty maps everyone to member';
+COMMENT ON COLUMN organization_sso.admin_role_values IS 'Comma separated role_claim values that grant the admin role';
+COMMENT ON COLUMN organization_sso.jit_provisioning IS 'Create accounts on first sign-in for users who do not have one yet';
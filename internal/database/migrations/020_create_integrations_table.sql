@@ -0,0 +1,40 @@
+-- Migration: 020_create_integrations_table.sql
+-- Description: third-party fitness service connections and imported workout sessions
+-- Date: 2025-07-20
+
+CREATE TABLE IF NOT EXISTS integrations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    provider TEXT NOT NULL CHECK (provider IN ('strava')),
+    external_user_id TEXT NOT NULL,
+    access_token TEXT NOT NULL,
+    refresh_token TEXT NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    scope TEXT NOT NULL DEFAULT '',
+    last_synced_at TIMESTAMP WITH TIME ZONE,
+    last_error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (user_id, provider),
+    UNIQUE (provider, external_user_id)
+);
+
+ALTER TABLE workout_sessions ADD COLUMN IF NOT EXISTS source TEXT NOT NULL DEFAULT '';
+ALTER TABLE workout_sessions ADD COLUMN IF NOT EXISTS external_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE workout_sessions ADD COLUMN IF NOT EXISTS exercise_id UUID REFERENCES exercises(id) ON DELETE SET NULL;
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_integrations_last_synced_at ON integrations(provider, last_synced_at NULLS FIRST);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_workout_sessions_external
+    ON workout_sessions(user_id, source, external_id)
+    WHERE external_id <> '';
+
+-- Add comments for documentation
+COMMENT ON TABLE integrations IS 'Connections between users and third-party fitness services';
+COMMENT ON COLUMN integrations.external_user_id IS 'Account identifier at the provider (e.g. Strava athlete ID), used to route webhook events';
+COMMENT ON COLUMN integrations.access_token IS 'AES-GCM encrypted with INTEGRATION_TOKEN_KEY';
+COMMENT ON COLUMN integrations.refresh_token IS 'AES-GCM encrypted with INTEGRATION_TOKEN_KEY';
+COMMENT ON COLUMN integrations.last_error IS 'Error from the most recent sync; empty when it succeeded';
+COMMENT ON COLUMN workout_sessions.source IS 'Service the session was imported from; empty for sessions logged in the app';
+COMMENT ON COLUMN workout_sessions.external_id IS 'Identifier of the imported activity at its source, used to deduplicate imports';
+COMMENT ON COLUMN workout_sessions.exercise_id IS 'Exercise an imported activity was mapped to';
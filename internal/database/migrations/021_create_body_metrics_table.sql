@@ -0,0 +1,22 @@
+-- Migration: 021_create_body_metrics_table.sql
+-- Description: body measurements imported from Apple Health and Google Fit
+-- Date: 2025-07-21
+
+CREATE TABLE IF NOT EXISTS body_metrics (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    metric TEXT NOT NULL CHECK (metric IN ('weight_kg', 'body_fat_percent', 'height_cm', 'lean_body_mass_kg', 'resting_heart_rate_bpm')),
+    measured_value NUMERIC(10, 3) NOT NULL,
+    recorded_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    source TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (user_id, metric, recorded_at)
+);
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_workout_sessions_user_started_at ON workout_sessions(user_id, started_at);
+
+-- Add comments for documentation
+COMMENT ON TABLE body_metrics IS 'Body measurements over time, one row per measurement';
+COMMENT ON COLUMN body_metrics.metric IS 'Kind of measurement; the unit of measured_value is part of the name';
+COMMENT ON COLUMN body_metrics.source IS 'Service the measurement was imported from (apple_health, google_fit)';
@@ -0,0 +1,23 @@
+-- Migration: 022_create_legal_holds_table.sql
+-- Description: legal holds exempting users and organizations from retention purges and account deletion
+-- Date: 2025-07-22
+
+CREATE TABLE IF NOT EXISTS legal_holds (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE RESTRICT,
+    user_id UUID,
+    reason TEXT NOT NULL,
+    created_by UUID NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    released_by UUID,
+    released_at TIMESTAMP WITH TIME ZONE
+);
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_legal_holds_active_user ON legal_holds(user_id) WHERE released_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_legal_holds_active_org ON legal_holds(organization_id) WHERE released_at IS NULL;
+
+-- Add comments for documentation
+COMMENT ON TABLE legal_holds IS 'While active, held data is never purged by retention jobs or account deletion';
+COMMENT ON COLUMN legal_holds.user_id IS 'Held member; NULL holds every member of the organization. No foreign key so the hold outlives account deletion requests';
+COMMENT ON COLUMN legal_holds.released_at IS 'When the hold was lifted; NULL while it is active';
@@ -0,0 +1,36 @@
+-- Migration: 023_create_data_subject_requests_table.sql
+-- Description: platform admin role and data_subject_requests table tracking DSARs handled by admins
+-- Date: 2025-07-23
+
+-- Platform-wide role, separate from organization roles
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role TEXT NOT NULL DEFAULT 'user' CHECK (role IN ('user', 'admin'));
+
+CREATE TABLE IF NOT EXISTS data_subject_requests (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL,
+    subject_email TEXT NOT NULL,
+    type TEXT NOT NULL CHECK (type IN ('access', 'deletion')),
+    status TEXT NOT NULL DEFAULT 'received' CHECK (status IN ('received', 'in_progress', 'completed', 'rejected')),
+    notes TEXT NOT NULL DEFAULT '',
+    due_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    data_export_id UUID REFERENCES data_exports(id) ON DELETE SET NULL,
+    account_deletion_id UUID REFERENCES account_deletions(id) ON DELETE SET NULL,
+    created_by UUID NOT NULL,
+    resolved_by UUID,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    completed_at TIMESTAMP WITH TIME ZONE
+);
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_data_subject_requests_open ON data_subject_requests(due_at) WHERE status IN ('received', 'in_progress');
+CREATE INDEX IF NOT EXISTS idx_data_subject_requests_user_id ON data_subject_requests(user_id);
+
+-- Add comments for documentation
+COMMENT ON COLUMN users.role IS 'Platform role; admins can use the /api/v1/admin endpoints';
+COMMENT ON TABLE data_subject_requests IS 'Data subject access and deletion requests received outside the app and fulfilled by admins';
+COMMENT ON COLUMN data_subject_requests.user_id IS 'Subject user ID; intentionally not a foreign key so the record survives the deletion it requested';
+COMMENT ON COLUMN data_subject_requests.subject_email IS 'Email of the subject when the request was received, kept for the audit trail';
+COMMENT ON COLUMN data_subject_requests.due_at IS 'Statutory response deadline';
+COMMENT ON COLUMN data_subject_requests.data_export_id IS 'Export generated to fulfill an access request';
+COMMENT ON COLUMN data_subject_requests.account_deletion_id IS 'Account deletion scheduled to fulfill a deletion request';
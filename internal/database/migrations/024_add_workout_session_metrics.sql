@@ -0,0 +1,20 @@
+-- Migration: 024_add_workout_session_metrics.sql
+-- Description: add duration, distance and heart rate summary columns to workout_sessions for recorded activities
+-- Date: 2025-07-24
+
+ALTER TABLE workout_sessions
+    ADD COLUMN IF NOT EXISTS duration_seconds INTEGER CHECK (duration_seconds >= 0),
+    ADD COLUMN IF NOT EXISTS distance_meters DOUBLE PRECISION CHECK (distance_meters >= 0),
+    ADD COLUMN IF NOT EXISTS avg_heart_rate INTEGER CHECK (avg_heart_rate > 0),
+    ADD COLUMN IF NOT EXISTS max_heart_rate INTEGER CHECK (max_heart_rate > 0);
+
+-- Imports used to record the distance in the notes; carry it over to the new column
+UPDATE workout_sessions
+SET distance_meters = substring(notes FROM '^Distance: ([0-9]+\.[0-9]+) km')::double precision * 1000
+WHERE source <> '' AND distance_meters IS NULL AND notes ~ '^Distance: [0-9]+\.[0-9]+ km';
+
+-- Add comments for documentation
+COMMENT ON COLUMN workout_sessions.duration_seconds IS 'Active time in seconds as recorded by the device, excluding pauses; NULL for sessions logged in the app';
+COMMENT ON COLUMN workout_sessions.distance_meters IS 'Distance covered, NULL when not recorded';
+COMMENT ON COLUMN workout_sessions.avg_heart_rate IS 'Average heart rate in beats per minute, NULL when not recorded';
+COMMENT ON COLUMN workout_sessions.max_heart_rate IS 'Maximum heart rate in beats per minute, NULL when not recorded';
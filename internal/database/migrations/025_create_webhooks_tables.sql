@@ -0,0 +1,44 @@
+-- Migration: 025_create_webhooks_tables.sql
+-- Description: create webhooks and webhook_deliveries tables for user-registered event notifications
+-- Date: 2025-07-28
+
+CREATE TABLE IF NOT EXISTS webhooks (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    events JSONB NOT NULL DEFAULT '[]'::jsonb CHECK (jsonb_typeof(events) = 'array'),
+    secret TEXT NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    webhook_id UUID NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+    event_id UUID NOT NULL,
+    event TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'succeeded', 'failed')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    response_status INTEGER,
+    last_error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    delivered_at TIMESTAMP WITH TIME ZONE
+);
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_webhooks_user_id ON webhooks(user_id);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook_id ON webhook_deliveries(webhook_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries(next_attempt_at) WHERE status = 'pending';
+
+-- Add comments for documentation
+COMMENT ON TABLE webhooks IS 'HTTPS endpoints users registered to be notified of events on their account';
+COMMENT ON COLUMN webhooks.events IS 'JSON array of subscribed event types, e.g. ["session.completed", "pr.achieved"]';
+COMMENT ON COLUMN webhooks.secret IS 'HMAC-SHA256 signing key; kept in plaintext because every delivery is signed with it';
+COMMENT ON TABLE webhook_deliveries IS 'One row per event per webhook, retried with exponential backoff until it succeeds or runs out of attempts';
+COMMENT ON COLUMN webhook_deliveries.event_id IS 'Shared by the deliveries of one event so receivers can deduplicate';
+COMMENT ON COLUMN webhook_deliveries.next_attempt_at IS 'When the delivery is next due; pushed forward while an attempt is in flight so only one worker sends it';
+COMMENT ON COLUMN webhook_deliveries.response_status IS 'HTTP status of the last attempt, NULL when the endpoint could not be reached';
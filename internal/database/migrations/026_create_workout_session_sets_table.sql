@@ -0,0 +1,27 @@
+-- Migration: 026_create_workout_session_sets_table.sql
+-- Description: create workout_session_sets table for sets logged live during a session
+-- Date: 2025-07-30
+
+CREATE TABLE IF NOT EXISTS workout_session_sets (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    session_id UUID NOT NULL REFERENCES workout_sessions(id) ON DELETE CASCADE,
+    exercise_id UUID REFERENCES exercises(id) ON DELETE SET NULL,
+    set_number INTEGER NOT NULL CHECK (set_number > 0),
+    reps INTEGER NOT NULL DEFAULT 0 CHECK (reps >= 0),
+    weight_kg DECIMAL(5,2) NOT NULL DEFAULT 0 CHECK (weight_kg >= 0),
+    duration_seconds INTEGER CHECK (duration_seconds >= 0),
+    rpe DECIMAL(3,1) CHECK (rpe BETWEEN 1 AND 10),
+    rest_seconds INTEGER CHECK (rest_seconds >= 0),
+    client_id TEXT NOT NULL,
+    completed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (session_id, client_id)
+);
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_workout_session_sets_session_id ON workout_session_sets(session_id, completed_at);
+
+-- Add comments for documentation
+COMMENT ON TABLE workout_session_sets IS 'Individual sets logged in real time from the workout companion';
+COMMENT ON COLUMN workout_session_sets.client_id IS 'Message ID chosen by the client; a resent set with the same ID is acknowledged without being stored twice';
+COMMENT ON COLUMN workout_session_sets.rest_seconds IS 'Rest taken before the set, when the client timed it';
@@ -0,0 +1,15 @@
+-- Migration: 027_add_exercise_catalog_keys.sql
+-- Description: key exercises by their upstream catalog so periodic syncs can upsert them
+-- Date: 2025-07-31
+
+ALTER TABLE exercises ADD COLUMN IF NOT EXISTS source TEXT NOT NULL DEFAULT '';
+ALTER TABLE exercises ADD COLUMN IF NOT EXISTS external_id TEXT NOT NULL DEFAULT '';
+
+-- Create indexes for better performance
+CREATE UNIQUE INDEX IF NOT EXISTS idx_exercises_external
+    ON exercises(source, external_id)
+    WHERE external_id <> '';
+
+-- Add comments for documentation
+COMMENT ON COLUMN exercises.source IS 'Catalog the exercise was synced from; empty for exercises created through the API';
+COMMENT ON COLUMN exercises.external_id IS 'Identifier of the exercise in its source catalog, the conflict key for catalog syncs';
@@ -0,0 +1,32 @@
+-- Migration: 028_create_devices_and_notification_preferences.sql
+-- Description: create devices and notification_preferences tables for push notifications
+-- Date: 2025-08-01
+
+CREATE TABLE IF NOT EXISTS devices (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    platform TEXT NOT NULL CHECK (platform IN ('ios', 'android')),
+    token TEXT NOT NULL UNIQUE,
+    name TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS notification_preferences (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    workout_reminders BOOLEAN NOT NULL DEFAULT TRUE,
+    reminder_after_days INTEGER NOT NULL DEFAULT 3 CHECK (reminder_after_days BETWEEN 1 AND 30),
+    personal_records BOOLEAN NOT NULL DEFAULT TRUE,
+    last_reminded_at TIMESTAMP WITH TIME ZONE,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_devices_user_id ON devices(user_id);
+
+-- Add comments for documentation
+COMMENT ON TABLE devices IS 'Mobile devices registered to receive push notifications';
+COMMENT ON COLUMN devices.token IS 'APNs device token or FCM registration token; a token re-registered by another user moves to that user';
+COMMENT ON TABLE notification_preferences IS 'Per-user push notification settings; users without a row get the column defaults';
+COMMENT ON COLUMN notification_preferences.reminder_after_days IS 'Days without a logged workout session before a reminder is sent';
+COMMENT ON COLUMN notification_preferences.last_reminded_at IS 'When the last workout reminder was sent, so reminders go out at most once per inactivity period';
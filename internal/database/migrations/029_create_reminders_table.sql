@@ -0,0 +1,35 @@
+-- Migration: 029_create_reminders_table.sql
+-- Description: create reminders table for scheduled workout reminders and add quiet hours to notification preferences
+-- Date: 2025-08-02
+
+CREATE TABLE IF NOT EXISTS reminders (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    title TEXT NOT NULL,
+    message TEXT NOT NULL DEFAULT '',
+    days_of_week SMALLINT NOT NULL CHECK (days_of_week BETWEEN 1 AND 127),
+    time_of_day SMALLINT NOT NULL CHECK (time_of_day BETWEEN 0 AND 1439),
+    timezone TEXT NOT NULL DEFAULT 'UTC',
+    push BOOLEAN NOT NULL DEFAULT TRUE,
+    email BOOLEAN NOT NULL DEFAULT FALSE,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    next_run_at TIMESTAMP WITH TIME ZONE,
+    last_sent_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+ALTER TABLE notification_preferences ADD COLUMN IF NOT EXISTS quiet_hours_start SMALLINT CHECK (quiet_hours_start BETWEEN 0 AND 1439);
+ALTER TABLE notification_preferences ADD COLUMN IF NOT EXISTS quiet_hours_end SMALLINT CHECK (quiet_hours_end BETWEEN 0 AND 1439);
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_reminders_user_id ON reminders(user_id);
+CREATE INDEX IF NOT EXISTS idx_reminders_next_run_at ON reminders(next_run_at) WHERE enabled;
+
+-- Add comments for documentation
+COMMENT ON TABLE reminders IS 'User-defined reminders sent on chosen weekdays at a local time of day';
+COMMENT ON COLUMN reminders.days_of_week IS 'Bitmask of weekdays the reminder fires on, bit 0 is Sunday';
+COMMENT ON COLUMN reminders.time_of_day IS 'Minutes after local midnight in the reminder''s timezone';
+COMMENT ON COLUMN reminders.next_run_at IS 'When the scheduler sends the reminder next; NULL while disabled';
+COMMENT ON COLUMN notification_preferences.quiet_hours_start IS 'Start of the daily window, in minutes after local midnight, in which scheduled reminders are held back';
+COMMENT ON COLUMN notification_preferences.quiet_hours_end IS 'End of the quiet hours window; a window ending before it starts runs past midnight';
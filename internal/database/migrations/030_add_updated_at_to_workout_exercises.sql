@@ -0,0 +1,10 @@
+-- Migration: 030_add_updated_at_to_workout_exercises.sql
+-- Description: track when workout exercises change so their ETags do
+-- Date: 2025-08-03
+
+ALTER TABLE workout_exercises ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP WITH TIME ZONE;
+UPDATE workout_exercises SET updated_at = COALESCE(created_at, NOW()) WHERE updated_at IS NULL;
+ALTER TABLE workout_exercises ALTER COLUMN updated_at SET DEFAULT NOW();
+
+-- Add comments for documentation
+COMMENT ON COLUMN workout_exercises.updated_at IS 'Last change to the row; existing rows start at their creation time';
@@ -0,0 +1,27 @@
+-- Migration: 031_create_training_maxes_table.sql
+-- Description: create training_maxes table and let workout exercises prescribe loads as a percentage of one
+-- Date: 2025-08-03
+
+CREATE TABLE IF NOT EXISTS training_maxes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    exercise_id UUID REFERENCES exercises(id) ON DELETE SET NULL,
+    weight_kg DECIMAL(6,2) NOT NULL CHECK (weight_kg > 0),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (user_id, name)
+);
+
+ALTER TABLE workout_exercises ADD COLUMN IF NOT EXISTS percent_of TEXT NOT NULL DEFAULT '';
+ALTER TABLE workout_exercises ADD COLUMN IF NOT EXISTS percent_value DECIMAL(4,3) NOT NULL DEFAULT 0
+    CHECK (percent_value >= 0 AND percent_value <= 2);
+
+ALTER TABLE workout_sessions ADD COLUMN IF NOT EXISTS plan JSONB NOT NULL DEFAULT '[]'::jsonb;
+
+-- Add comments for documentation
+COMMENT ON TABLE training_maxes IS 'Per-user reference loads, such as squat_1rm, that percentage-based prescriptions are resolved against';
+COMMENT ON COLUMN training_maxes.name IS 'Name prescriptions refer to the max by, unique per user';
+COMMENT ON COLUMN workout_exercises.percent_of IS 'Training max the load is prescribed from; empty when weight_kg is a fixed load';
+COMMENT ON COLUMN workout_exercises.percent_value IS 'Fraction of the training max to load, such as 0.75';
+COMMENT ON COLUMN workout_sessions.plan IS 'Workout exercises with prescribed loads resolved against the training maxes when the session started';
@@ -0,0 +1,28 @@
+-- Migration: 033_add_training_max_history.sql
+-- Description: keep the history of training max changes and mark AMRAP sets for suggested updates
+-- Date: 2025-08-03
+
+CREATE TABLE IF NOT EXISTS training_max_history (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    training_max_id UUID NOT NULL REFERENCES training_maxes(id) ON DELETE CASCADE,
+    weight_kg DECIMAL(6,2) NOT NULL CHECK (weight_kg > 0),
+    source TEXT NOT NULL DEFAULT 'manual' CHECK (source IN ('manual', 'suggestion')),
+    recorded_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Existing training maxes start their history at their current weight
+INSERT INTO training_max_history (training_max_id, weight_kg, recorded_at)
+SELECT tm.id, tm.weight_kg, COALESCE(tm.updated_at, NOW())
+FROM training_maxes tm
+WHERE NOT EXISTS (SELECT 1 FROM training_max_history h WHERE h.training_max_id = tm.id);
+
+ALTER TABLE workout_session_sets ADD COLUMN IF NOT EXISTS amrap BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_training_max_history_training_max_id ON training_max_history(training_max_id, recorded_at DESC);
+CREATE INDEX IF NOT EXISTS idx_workout_session_sets_amrap ON workout_session_sets(exercise_id, completed_at) WHERE amrap;
+
+-- Add comments for documentation
+COMMENT ON TABLE training_max_history IS 'Every weight a training max has been set to, newest last';
+COMMENT ON COLUMN training_max_history.source IS 'manual when the user set the weight, suggestion when they accepted one computed from AMRAP sets';
+COMMENT ON COLUMN workout_session_sets.amrap IS 'Set taken to as many reps as possible, used to suggest training max updates';
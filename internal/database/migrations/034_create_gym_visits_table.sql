@@ -0,0 +1,25 @@
+-- Migration: 034_create_gym_visits_table.sql
+-- Description: record member check-ins at organizations for live and historical gym occupancy
+-- Date: 2025-08-05
+
+CREATE TABLE IF NOT EXISTS gym_visits (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    checked_in_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    checked_out_at TIMESTAMP WITH TIME ZONE,
+    CHECK (checked_out_at IS NULL OR checked_out_at >= checked_in_at)
+);
+
+-- A member is checked in at most once per organization at a time
+CREATE UNIQUE INDEX IF NOT EXISTS idx_gym_visits_open
+    ON gym_visits(organization_id, user_id)
+    WHERE checked_out_at IS NULL;
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_gym_visits_organization_id ON gym_visits(organization_id, checked_in_at);
+CREATE INDEX IF NOT EXISTS idx_gym_visits_user_id ON gym_visits(user_id);
+
+-- Add comments for documentation
+COMMENT ON TABLE gym_visits IS 'Member check-ins at an organization, used for live occupancy and hourly aggregates';
+COMMENT ON COLUMN gym_visits.checked_out_at IS 'NULL while the member is checked in; visits never checked out stop counting after OCCUPANCY_MAX_STAY';
@@ -0,0 +1,39 @@
+-- Migration: 035_create_equipment_reservations.sql
+-- Description: bookable gym equipment and time-slot reservations with no-show tracking
+-- Date: 2025-08-06
+
+CREATE TABLE IF NOT EXISTS gym_equipment (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    archived_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS equipment_reservations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    equipment_id UUID NOT NULL REFERENCES gym_equipment(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    starts_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    ends_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    status TEXT NOT NULL DEFAULT 'booked' CHECK (status IN ('booked', 'cancelled', 'no_show')),
+    cancelled_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CHECK (ends_at > starts_at)
+);
+
+-- Equipment names are unique among an organization's current equipment
+CREATE UNIQUE INDEX IF NOT EXISTS idx_gym_equipment_name
+    ON gym_equipment(organization_id, lower(name))
+    WHERE archived_at IS NULL;
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_equipment_reservations_equipment_id ON equipment_reservations(equipment_id, starts_at);
+CREATE INDEX IF NOT EXISTS idx_equipment_reservations_user_id ON equipment_reservations(user_id, starts_at);
+
+-- Add comments for documentation
+COMMENT ON TABLE gym_equipment IS 'Limited equipment, such as a squat rack or platform, that members book by time slot; one row per unit';
+COMMENT ON COLUMN gym_equipment.archived_at IS 'Set when the equipment is removed; past reservations are kept for no-show history';
+COMMENT ON TABLE equipment_reservations IS 'Time-slot bookings of gym equipment; booked slots of the same equipment never overlap';
+COMMENT ON COLUMN equipment_reservations.status IS 'booked, cancelled by the member or an admin, or no_show when an admin marks the member absent';
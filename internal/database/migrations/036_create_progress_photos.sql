@@ -0,0 +1,43 @@
+-- Migration: 036_create_progress_photos.sql
+-- Description: progress photos for body composition comparison, with a PIN-protected private vault
+-- Date: 2025-08-07
+
+CREATE TABLE IF NOT EXISTS progress_photos (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    pose TEXT NOT NULL DEFAULT 'front' CHECK (pose IN ('front', 'side', 'back', 'other')),
+    taken_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    notes TEXT NOT NULL DEFAULT '',
+    content_type TEXT NOT NULL,
+    size_bytes BIGINT NOT NULL DEFAULT 0,
+    storage_key TEXT NOT NULL,
+    thumbnail_key TEXT NOT NULL DEFAULT '',
+    vaulted BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CHECK (NOT vaulted OR thumbnail_key = '')
+);
+
+CREATE TABLE IF NOT EXISTS photo_vaults (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    pin_salt BYTEA NOT NULL,
+    wrapped_key BYTEA NOT NULL,
+    failed_attempts INTEGER NOT NULL DEFAULT 0,
+    locked_until TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Vaulted photos are left out of exports unless the user asks for them
+ALTER TABLE data_exports ADD COLUMN IF NOT EXISTS include_vault BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_progress_photos_user_id ON progress_photos(user_id, taken_at DESC);
+
+-- Add comments for documentation
+COMMENT ON TABLE progress_photos IS 'Progress photos; the images are kept in object storage under storage_key';
+COMMENT ON COLUMN progress_photos.vaulted IS 'Set when the image is encrypted with the user''s vault key; vaulted photos have no thumbnail';
+COMMENT ON TABLE photo_vaults IS 'One private photo vault per user, unlocked with a PIN';
+COMMENT ON COLUMN photo_vaults.wrapped_key IS 'Random key that encrypts vaulted photos, itself encrypted with a key derived from the PIN and pin_salt';
+COMMENT ON COLUMN photo_vaults.locked_until IS 'Unlock attempts are refused until then after too many wrong PINs';
+COMMENT ON COLUMN data_exports.include_vault IS 'Whether the export includes vaulted progress photos';
@@ -0,0 +1,16 @@
+-- Migration: 037_create_session_feedback.sql
+-- Description: post-session feedback (session RPE, enjoyment, pain) for readiness and training max suggestions
+-- Date: 2025-08-11
+
+CREATE TABLE IF NOT EXISTS session_feedback (
+    session_id UUID PRIMARY KEY REFERENCES workout_sessions(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    rpe SMALLINT NOT NULL CHECK (rpe BETWEEN 1 AND 10),
+    enjoyment SMALLINT CHECK (enjoyment BETWEEN 1 AND 5),
+    pain_areas JSONB NOT NULL DEFAULT '[]'::jsonb,
+    notes TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_session_feedback_user_id ON session_feedback(user_id);
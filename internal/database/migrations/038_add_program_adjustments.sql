@@ -0,0 +1,53 @@
+-- Migration: 038_add_program_adjustments.sql
+-- Description: program weeks, coaches and the automatic adjustments made to upcoming weeks
+-- Date: 2025-08-12
+
+-- Week 1 of a program starts at started_at. Existing programs start when they were created.
+ALTER TABLE programs ADD COLUMN IF NOT EXISTS started_at TIMESTAMP WITH TIME ZONE;
+UPDATE programs SET started_at = COALESCE(created_at, NOW()) WHERE started_at IS NULL;
+ALTER TABLE programs ALTER COLUMN started_at SET DEFAULT NOW();
+ALTER TABLE programs ALTER COLUMN started_at SET NOT NULL;
+
+ALTER TABLE programs ADD COLUMN IF NOT EXISTS deload_every_weeks INTEGER CHECK (deload_every_weeks BETWEEN 2 AND 12);
+ALTER TABLE programs ADD COLUMN IF NOT EXISTS coach_id UUID REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE programs ADD COLUMN IF NOT EXISTS adjusted_week INTEGER NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_programs_coach_id ON programs(coach_id) WHERE coach_id IS NOT NULL;
+
+-- A week's volume carries over to the weeks after it until another week changes it
+CREATE TABLE IF NOT EXISTS program_weeks (
+    program_id UUID NOT NULL REFERENCES programs(id) ON DELETE CASCADE,
+    week INTEGER NOT NULL CHECK (week >= 1),
+    volume_percent INTEGER NOT NULL DEFAULT 100 CHECK (volume_percent BETWEEN 50 AND 150),
+    deload BOOLEAN NOT NULL DEFAULT false,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (program_id, week)
+);
+
+CREATE TABLE IF NOT EXISTS program_adjustments (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    program_id UUID NOT NULL REFERENCES programs(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    week INTEGER NOT NULL CHECK (week >= 1),
+    kind TEXT NOT NULL CHECK (kind IN ('add_volume', 'remove_volume', 'extend_deload')),
+    volume_percent INTEGER NOT NULL,
+    deload BOOLEAN NOT NULL DEFAULT false,
+    reason TEXT NOT NULL,
+    metrics JSONB NOT NULL DEFAULT '{}'::jsonb,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'applied', 'rejected')),
+    reviewed_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    reviewed_at TIMESTAMP WITH TIME ZONE,
+    review_note TEXT NOT NULL DEFAULT '',
+    applied_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE (program_id, week)
+);
+
+CREATE INDEX IF NOT EXISTS idx_program_adjustments_pending ON program_adjustments(program_id) WHERE status = 'pending';
+
+COMMENT ON COLUMN programs.started_at IS 'Start of week 1 of the program';
+COMMENT ON COLUMN programs.deload_every_weeks IS 'Every how many weeks the program deloads, NULL for no scheduled deloads';
+COMMENT ON COLUMN programs.coach_id IS 'Coach who reviews automatic adjustments before they apply';
+COMMENT ON COLUMN programs.adjusted_week IS 'Last week the adjustment job evaluated';
+COMMENT ON TABLE program_weeks IS 'Volume and deload changes to program weeks';
+COMMENT ON TABLE program_adjustments IS 'Audit of every automatic adjustment to a program week, applied or not';
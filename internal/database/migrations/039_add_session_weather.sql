@@ -0,0 +1,18 @@
+-- Migration: 039_add_session_weather.sql
+-- Description: start positions of outdoor sessions imported with GPS and the weather they were done in
+-- Date: 2025-08-13
+
+-- Start positions are rounded to two decimal places, about a kilometer, which is all the
+-- weather lookup needs
+ALTER TABLE workout_sessions ADD COLUMN IF NOT EXISTS start_latitude DOUBLE PRECISION CHECK (start_latitude BETWEEN -90 AND 90);
+ALTER TABLE workout_sessions ADD COLUMN IF NOT EXISTS start_longitude DOUBLE PRECISION CHECK (start_longitude BETWEEN -180 AND 180);
+
+ALTER TABLE workout_sessions ADD COLUMN IF NOT EXISTS temperature_c DOUBLE PRECISION;
+ALTER TABLE workout_sessions ADD COLUMN IF NOT EXISTS wind_speed_kmh DOUBLE PRECISION CHECK (wind_speed_kmh >= 0);
+ALTER TABLE workout_sessions ADD COLUMN IF NOT EXISTS wind_direction INTEGER CHECK (wind_direction BETWEEN 0 AND 359);
+
+-- Set once the weather was looked up, also when the provider had none for the session
+ALTER TABLE workout_sessions ADD COLUMN IF NOT EXISTS weather_checked_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_workout_sessions_weather_pending ON workout_sessions(started_at)
+    WHERE start_latitude IS NOT NULL AND weather_checked_at IS NULL;
@@ -0,0 +1,6 @@
+-- Migration: 040_add_body_metrics_recorded_at_index.sql
+-- Description: index for listing a user's measurements of every kind over a date range
+-- Date: 2025-08-14
+
+-- Ranges of one kind of measurement use the (user_id, metric, recorded_at) unique index
+CREATE INDEX IF NOT EXISTS idx_body_metrics_user_recorded_at ON body_metrics(user_id, recorded_at);
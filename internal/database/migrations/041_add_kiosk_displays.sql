@@ -0,0 +1,25 @@
+-- Migration: 041_add_kiosk_displays.sql
+-- Description: display keys for wall-mounted gym screens and leaderboard opt-in for members
+-- Date: 2025-08-15
+
+CREATE TABLE IF NOT EXISTS kiosk_displays (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    organization_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    name TEXT NOT NULL DEFAULT '',
+    timezone TEXT NOT NULL DEFAULT 'UTC',
+    prefix TEXT NOT NULL,
+    key_hash TEXT NOT NULL UNIQUE,
+    last_seen_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+ALTER TABLE organization_members ADD COLUMN IF NOT EXISTS show_on_leaderboard BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_kiosk_displays_organization_id ON kiosk_displays(organization_id);
+
+-- Add comments for documentation
+COMMENT ON TABLE kiosk_displays IS 'Screens showing an organization''s read-only display board, each with its own key';
+COMMENT ON COLUMN kiosk_displays.timezone IS 'IANA timezone of the screen, which decides where its days and weeks start';
+COMMENT ON COLUMN kiosk_displays.key_hash IS 'SHA-256 of the display key; the key itself is only shown once at creation';
+COMMENT ON COLUMN organization_members.show_on_leaderboard IS 'Whether the member agreed to be named on the organization''s display leaderboard';
@@ -0,0 +1,75 @@
+-- Migration: 042_add_muscle_groups.sql
+-- Description: muscle group taxonomy with primary and secondary muscles per exercise, replacing exercises.muscle_group
+-- Date: 2025-08-16
+
+CREATE TABLE IF NOT EXISTS muscle_groups (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    slug TEXT NOT NULL UNIQUE,
+    name TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS exercise_muscle_groups (
+    exercise_id UUID NOT NULL REFERENCES exercises(id) ON DELETE CASCADE,
+    muscle_group_id UUID NOT NULL REFERENCES muscle_groups(id) ON DELETE CASCADE,
+    role TEXT NOT NULL CHECK (role IN ('primary', 'secondary')),
+    PRIMARY KEY (exercise_id, muscle_group_id)
+);
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_exercise_muscle_groups_muscle_group_id ON exercise_muscle_groups(muscle_group_id, role);
+
+-- The muscles, plus the broader groups the importers and older exercises use
+INSERT INTO muscle_groups (slug, name) VALUES
+    ('chest', 'Chest'),
+    ('upper-back', 'Upper Back'),
+    ('lats', 'Lats'),
+    ('traps', 'Traps'),
+    ('lower-back', 'Lower Back'),
+    ('shoulders', 'Shoulders'),
+    ('biceps', 'Biceps'),
+    ('triceps', 'Triceps'),
+    ('forearms', 'Forearms'),
+    ('abs', 'Abs'),
+    ('obliques', 'Obliques'),
+    ('glutes', 'Glutes'),
+    ('hip-flexors', 'Hip Flexors'),
+    ('quadriceps', 'Quadriceps'),
+    ('hamstrings', 'Hamstrings'),
+    ('adductors', 'Adductors'),
+    ('abductors', 'Abductors'),
+    ('calves', 'Calves'),
+    ('neck', 'Neck'),
+    ('back', 'Back'),
+    ('arms', 'Arms'),
+    ('legs', 'Legs'),
+    ('core', 'Core'),
+    ('full-body', 'Full Body'),
+    ('cardio', 'Cardio')
+ON CONFLICT (slug) DO NOTHING;
+
+-- Backfill: each existing muscle group becomes the exercise's primary muscle, adding the
+-- groups the taxonomy doesn't have yet under their existing name
+INSERT INTO muscle_groups (slug, name)
+SELECT DISTINCT ON (slug) slug, trim(muscle_group)
+FROM (
+    SELECT muscle_group, trim(both '-' from regexp_replace(lower(muscle_group), '[^a-z0-9]+', '-', 'g')) AS slug
+    FROM exercises
+    WHERE muscle_group IS NOT NULL
+) e
+WHERE slug <> ''
+ORDER BY slug, trim(muscle_group)
+ON CONFLICT (slug) DO NOTHING;
+
+INSERT INTO exercise_muscle_groups (exercise_id, muscle_group_id, role)
+SELECT e.id, mg.id, 'primary'
+FROM exercises e
+JOIN muscle_groups mg ON mg.slug = trim(both '-' from regexp_replace(lower(e.muscle_group), '[^a-z0-9]+', '-', 'g'))
+ON CONFLICT (exercise_id, muscle_group_id) DO NOTHING;
+
+DROP INDEX IF EXISTS idx_exercises_muscle_group;
+ALTER TABLE exercises DROP COLUMN IF EXISTS muscle_group;
+
+-- Add comments for documentation
+COMMENT ON TABLE muscle_groups IS 'Muscle groups exercises can target, identified in the API by slug';
+COMMENT ON TABLE exercise_muscle_groups IS 'Muscles each exercise trains, as its primary or secondary muscles';
@@ -0,0 +1,26 @@
+-- Migration: 043_add_exercise_media.sql
+-- Description: demonstration images and videos attached to exercises, linked or uploaded to object storage
+-- Date: 2025-08-17
+
+CREATE TABLE IF NOT EXISTS exercise_media (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    exercise_id UUID NOT NULL REFERENCES exercises(id) ON DELETE CASCADE,
+    kind TEXT NOT NULL CHECK (kind IN ('image', 'video')),
+    status TEXT NOT NULL DEFAULT 'ready' CHECK (status IN ('pending', 'ready')),
+    url TEXT NOT NULL DEFAULT '',
+    storage_key TEXT NOT NULL DEFAULT '',
+    content_type TEXT NOT NULL DEFAULT '',
+    caption TEXT NOT NULL DEFAULT '',
+    position INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CHECK ((url = '') <> (storage_key = ''))
+);
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_exercise_media_exercise_id ON exercise_media(exercise_id, position);
+
+-- Add comments for documentation
+COMMENT ON TABLE exercise_media IS 'Demonstration images and videos shown with an exercise, in position order';
+COMMENT ON COLUMN exercise_media.status IS 'pending until an upload to storage_key is confirmed; linked media are ready at once';
+COMMENT ON COLUMN exercise_media.url IS 'External URL of linked media; empty for uploaded media';
+COMMENT ON COLUMN exercise_media.storage_key IS 'Object storage key of uploaded media; empty for linked media';
@@ -0,0 +1,26 @@
+-- Migration: 044_add_community_integrations.sql
+-- Description: Slack or Discord channel an organization's personal records and weekly leaderboards are posted to
+-- Date: 2025-08-18
+
+CREATE TABLE IF NOT EXISTS community_integrations (
+    organization_id UUID PRIMARY KEY REFERENCES organizations(id) ON DELETE CASCADE,
+    provider TEXT NOT NULL CHECK (provider IN ('slack', 'discord')),
+    webhook_url TEXT NOT NULL,
+    timezone TEXT NOT NULL DEFAULT 'UTC',
+    post_personal_records BOOLEAN NOT NULL DEFAULT TRUE,
+    post_weekly_leaderboard BOOLEAN NOT NULL DEFAULT TRUE,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    leaderboard_posted_for TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_community_integrations_leaderboard ON community_integrations(organization_id)
+    WHERE enabled AND post_weekly_leaderboard;
+
+-- Add comments for documentation
+COMMENT ON TABLE community_integrations IS 'Chat channel an organization''s community events are posted to, through an incoming webhook';
+COMMENT ON COLUMN community_integrations.webhook_url IS 'Incoming webhook URL of the channel; it grants posting rights, so it is never returned in full';
+COMMENT ON COLUMN community_integrations.timezone IS 'IANA timezone deciding where the organization''s leaderboard weeks start';
+COMMENT ON COLUMN community_integrations.leaderboard_posted_for IS 'Start of the week whose leaderboard is the latest posted, or of the week the integration was set up';
@@ -0,0 +1,17 @@
+-- Migration: 045_add_progress_photo_uploads.sql
+-- Description: direct-to-storage progress photo uploads, and image and thumbnail dimensions
+-- Date: 2025-08-19
+
+ALTER TABLE progress_photos ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'ready' CHECK (status IN ('pending', 'ready'));
+ALTER TABLE progress_photos ADD COLUMN IF NOT EXISTS width INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE progress_photos ADD COLUMN IF NOT EXISTS height INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE progress_photos ADD COLUMN IF NOT EXISTS thumbnail_width INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE progress_photos ADD COLUMN IF NOT EXISTS thumbnail_height INTEGER NOT NULL DEFAULT 0;
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_progress_photos_pending ON progress_photos(created_at) WHERE status = 'pending';
+
+-- Add comments for documentation
+COMMENT ON COLUMN progress_photos.status IS 'pending until a photo uploaded straight to storage is confirmed; pending photos are not listed';
+COMMENT ON COLUMN progress_photos.width IS 'Image width in pixels; 0 for photos uploaded before dimensions were recorded';
+COMMENT ON COLUMN progress_photos.thumbnail_width IS 'Thumbnail width in pixels; 0 without a thumbnail';
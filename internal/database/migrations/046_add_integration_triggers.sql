@@ -0,0 +1,24 @@
+-- Migration: 046_add_integration_triggers.sql
+-- Description: integration_events log polled by automation connectors, and the triggers API key scope
+-- Date: 2025-08-20
+
+CREATE TABLE IF NOT EXISTS integration_events (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    event TEXT NOT NULL,
+    payload JSONB NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+ALTER TABLE api_keys DROP CONSTRAINT IF EXISTS api_keys_scope_check;
+ALTER TABLE api_keys ADD CONSTRAINT api_keys_scope_check CHECK (scope IN ('read', 'write', 'triggers'));
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_integration_events_user_event ON integration_events(user_id, event, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_integration_events_created_at ON integration_events(created_at);
+
+-- Add comments for documentation
+COMMENT ON TABLE integration_events IS 'Webhook events kept for a while so Zapier and Make connectors can poll for them';
+COMMENT ON COLUMN integration_events.id IS 'Event ID, the same as in the webhook deliveries of the event';
+COMMENT ON COLUMN integration_events.payload IS 'The event as sent to webhooks';
+COMMENT ON COLUMN api_keys.scope IS 'read keys may only perform GET requests; triggers keys may only poll integration triggers';
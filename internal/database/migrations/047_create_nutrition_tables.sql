@@ -0,0 +1,56 @@
+-- Migration: 047_create_nutrition_tables.sql
+-- Description: create foods and nutrition_logs tables for logging meals and daily macros
+-- Date: 2025-08-21
+
+CREATE TABLE IF NOT EXISTS foods (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    brand TEXT NOT NULL DEFAULT '',
+    barcode TEXT NOT NULL DEFAULT '',
+    serving_size NUMERIC(8, 2) NOT NULL DEFAULT 100 CHECK (serving_size > 0),
+    serving_unit TEXT NOT NULL DEFAULT 'g' CHECK (serving_unit IN ('g', 'ml', 'piece')),
+    calories NUMERIC(7, 2) NOT NULL DEFAULT 0 CHECK (calories >= 0),
+    protein_g NUMERIC(7, 2) NOT NULL DEFAULT 0 CHECK (protein_g >= 0),
+    carbs_g NUMERIC(7, 2) NOT NULL DEFAULT 0 CHECK (carbs_g >= 0),
+    fat_g NUMERIC(7, 2) NOT NULL DEFAULT 0 CHECK (fat_g >= 0),
+    source TEXT NOT NULL DEFAULT '',
+    external_id TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    version INTEGER NOT NULL DEFAULT 1
+);
+
+CREATE TABLE IF NOT EXISTS nutrition_logs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    food_id UUID REFERENCES foods(id) ON DELETE SET NULL,
+    name TEXT NOT NULL,
+    meal TEXT NOT NULL DEFAULT 'snack' CHECK (meal IN ('breakfast', 'lunch', 'dinner', 'snack')),
+    servings NUMERIC(6, 2) NOT NULL DEFAULT 1 CHECK (servings > 0),
+    calories NUMERIC(8, 2) NOT NULL DEFAULT 0 CHECK (calories >= 0),
+    protein_g NUMERIC(8, 2) NOT NULL DEFAULT 0 CHECK (protein_g >= 0),
+    carbs_g NUMERIC(8, 2) NOT NULL DEFAULT 0 CHECK (carbs_g >= 0),
+    fat_g NUMERIC(8, 2) NOT NULL DEFAULT 0 CHECK (fat_g >= 0),
+    eaten_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    notes TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    version INTEGER NOT NULL DEFAULT 1
+);
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_foods_user_id ON foods(user_id);
+CREATE INDEX IF NOT EXISTS idx_foods_barcode ON foods(barcode) WHERE barcode <> '';
+CREATE UNIQUE INDEX IF NOT EXISTS idx_foods_source_external_id ON foods(source, external_id) WHERE external_id <> '';
+CREATE INDEX IF NOT EXISTS idx_nutrition_logs_user_eaten_at ON nutrition_logs(user_id, eaten_at);
+CREATE INDEX IF NOT EXISTS idx_nutrition_logs_food_id ON nutrition_logs(food_id);
+
+-- Add comments for documentation
+COMMENT ON TABLE foods IS 'Foods that can be logged, with their nutrients per serving';
+COMMENT ON COLUMN foods.user_id IS 'Owner of a custom food; NULL for the shared catalog, which every user can log from';
+COMMENT ON COLUMN foods.barcode IS 'EAN or UPC code printed on the package, empty when unknown';
+COMMENT ON COLUMN foods.source IS 'External food database the food was copied from; empty for foods added in the app';
+COMMENT ON TABLE nutrition_logs IS 'Foods a user ate, one row per food per meal';
+COMMENT ON COLUMN nutrition_logs.food_id IS 'Food the entry was logged from; NULL for quick entries and once the food is deleted';
+COMMENT ON COLUMN nutrition_logs.calories IS 'Total for all servings, copied from the food when logged so later edits to the food don''t change history';
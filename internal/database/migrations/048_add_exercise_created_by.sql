@@ -0,0 +1,11 @@
+-- Migration: 048_add_exercise_created_by.sql
+-- Description: record who added an exercise, so custom exercises count towards the user's quota
+-- Date: 2025-08-22
+
+ALTER TABLE exercises ADD COLUMN IF NOT EXISTS created_by UUID REFERENCES users(id) ON DELETE SET NULL;
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_exercises_created_by ON exercises(created_by) WHERE created_by IS NOT NULL;
+
+-- Add comments for documentation
+COMMENT ON COLUMN exercises.created_by IS 'User who added the exercise through the API; NULL for catalog and imported exercises and those added before this was recorded';
@@ -0,0 +1,21 @@
+-- Migration: 049_create_daily_habits.sql
+-- Description: create daily_habits table, one row per user and day of water, sleep and steps
+-- Date: 2025-08-23
+
+CREATE TABLE IF NOT EXISTS daily_habits (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    day DATE NOT NULL,
+    water_ml INTEGER CHECK (water_ml >= 0),
+    sleep_hours NUMERIC(4, 2) CHECK (sleep_hours >= 0 AND sleep_hours <= 24),
+    steps INTEGER CHECK (steps >= 0),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (user_id, day)
+);
+
+-- Add comments for documentation
+COMMENT ON TABLE daily_habits IS 'Daily habit log, one wide row per user and calendar day; new habits are added as columns';
+COMMENT ON COLUMN daily_habits.day IS 'Calendar day in the user''s own timezone, as the client sent it';
+COMMENT ON COLUMN daily_habits.water_ml IS 'Water drunk that day in millilitres; NULL when not logged';
+COMMENT ON COLUMN daily_habits.sleep_hours IS 'Hours slept the night before the day; NULL when not logged';
+COMMENT ON COLUMN daily_habits.steps IS 'Steps walked that day; NULL when not logged';
@@ -0,0 +1,24 @@
+-- Migration: 050_create_benchmarks.sql
+-- Description: create benchmark_consents and benchmark_distributions tables for anonymized lift benchmarks
+-- Date: 2025-08-24
+
+CREATE TABLE IF NOT EXISTS benchmark_consents (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    consented_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS benchmark_distributions (
+    exercise_id UUID NOT NULL REFERENCES exercises(id) ON DELETE CASCADE,
+    bodyweight_class TEXT NOT NULL,
+    sample_size INTEGER NOT NULL CHECK (sample_size > 0),
+    percentiles JSONB NOT NULL,
+    computed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (exercise_id, bodyweight_class)
+);
+
+-- Add comments for documentation
+COMMENT ON TABLE benchmark_consents IS 'Users who opted in to anonymized benchmarking; only their lifts are aggregated';
+COMMENT ON TABLE benchmark_distributions IS 'Estimated one-rep max distributions of consenting users, rebuilt by the benchmark aggregation job';
+COMMENT ON COLUMN benchmark_distributions.bodyweight_class IS 'Bodyweight class of the lifters, or all for every lifter whatever their bodyweight';
+COMMENT ON COLUMN benchmark_distributions.sample_size IS 'Number of lifters aggregated; classes with fewer than the minimum are never stored';
+COMMENT ON COLUMN benchmark_distributions.percentiles IS 'JSON array of the 1st to 99th percentiles of best estimated one-rep max in kg';
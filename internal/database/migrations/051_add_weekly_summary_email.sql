@@ -0,0 +1,13 @@
+-- Migration: 051_add_weekly_summary_email.sql
+-- Description: let users opt in to a weekly summary email and record the last week sent
+-- Date: 2025-08-25
+
+ALTER TABLE notification_preferences ADD COLUMN IF NOT EXISTS weekly_summary_email BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE notification_preferences ADD COLUMN IF NOT EXISTS weekly_summary_sent_for DATE;
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_notification_preferences_weekly_summary ON notification_preferences(weekly_summary_sent_for) WHERE weekly_summary_email;
+
+-- Add comments for documentation
+COMMENT ON COLUMN notification_preferences.weekly_summary_email IS 'Whether the user receives a summary of each week by email; off unless they opt in';
+COMMENT ON COLUMN notification_preferences.weekly_summary_sent_for IS 'Monday (UTC) of the last week a summary email was claimed for, so each week is sent once';
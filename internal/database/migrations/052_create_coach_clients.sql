@@ -0,0 +1,35 @@
+-- Migration: 052_create_coach_clients.sql
+-- Description: create coach_clients table for coach invitations and the clients coaches may view and assign programs to
+-- Date: 2025-08-26
+
+CREATE TABLE IF NOT EXISTS coach_clients (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    coach_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    client_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    email TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'active', 'ended')),
+    token_hash TEXT NOT NULL UNIQUE,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    accepted_at TIMESTAMP WITH TIME ZONE,
+    ended_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CHECK (client_id IS DISTINCT FROM coach_id)
+);
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_coach_clients_coach_id ON coach_clients(coach_id);
+CREATE INDEX IF NOT EXISTS idx_coach_clients_client_id ON coach_clients(client_id);
+
+-- At most one open invitation per address and coach, and one active relationship per client and coach
+CREATE UNIQUE INDEX IF NOT EXISTS idx_coach_clients_pending
+    ON coach_clients(coach_id, lower(email))
+    WHERE status = 'pending';
+CREATE UNIQUE INDEX IF NOT EXISTS idx_coach_clients_active
+    ON coach_clients(coach_id, client_id)
+    WHERE status = 'active';
+
+-- Add comments for documentation
+COMMENT ON TABLE coach_clients IS 'Coaching invitations and relationships; active ones let the coach view the client''s training and assign them programs';
+COMMENT ON COLUMN coach_clients.client_id IS 'User who accepted the invitation; NULL while it is pending';
+COMMENT ON COLUMN coach_clients.status IS 'pending until the client accepts, then active until either side ends it';
+COMMENT ON COLUMN coach_clients.token_hash IS 'SHA-256 of the invitation token; the token itself is only sent by email';
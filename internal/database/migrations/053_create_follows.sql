@@ -0,0 +1,34 @@
+-- Migration: 053_create_follows.sql
+-- Description: create follows and privacy_settings tables for the activity feed
+-- Date: 2025-08-27
+
+CREATE TABLE IF NOT EXISTS follows (
+    follower_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    followee_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (follower_id, followee_id),
+    CHECK (follower_id <> followee_id)
+);
+
+CREATE TABLE IF NOT EXISTS privacy_settings (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    allow_followers BOOLEAN NOT NULL DEFAULT TRUE,
+    share_sessions BOOLEAN NOT NULL DEFAULT TRUE,
+    share_personal_records BOOLEAN NOT NULL DEFAULT TRUE,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_follows_followee_id ON follows(followee_id);
+
+-- The feed reads the completed sessions of followed users, newest first
+CREATE INDEX IF NOT EXISTS idx_workout_sessions_user_completed_at
+    ON workout_sessions(user_id, completed_at DESC)
+    WHERE completed_at IS NOT NULL;
+
+-- Add comments for documentation
+COMMENT ON TABLE follows IS 'Users following each other; the feed shows what followed users share';
+COMMENT ON TABLE privacy_settings IS 'What a user shares with their followers; users without a row share everything';
+COMMENT ON COLUMN privacy_settings.allow_followers IS 'Whether new followers are accepted; existing followers are kept when turned off';
+COMMENT ON COLUMN privacy_settings.share_sessions IS 'Whether completed workout sessions appear in followers'' feeds';
+COMMENT ON COLUMN privacy_settings.share_personal_records IS 'Whether personal records appear in followers'' feeds';
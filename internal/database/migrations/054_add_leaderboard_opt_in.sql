@@ -0,0 +1,13 @@
+-- Migration: 054_add_leaderboard_opt_in.sql
+-- Description: let users opt in to appearing on exercise leaderboards
+-- Date: 2025-08-28
+
+ALTER TABLE privacy_settings ADD COLUMN IF NOT EXISTS show_on_leaderboards BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_privacy_settings_show_on_leaderboards
+    ON privacy_settings(user_id)
+    WHERE show_on_leaderboards;
+
+-- Add comments for documentation
+COMMENT ON COLUMN privacy_settings.show_on_leaderboards IS 'Whether the user''s lifts are ranked on exercise leaderboards under their display name; off until they opt in';
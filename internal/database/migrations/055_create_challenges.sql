@@ -0,0 +1,33 @@
+-- Migration: 055_create_challenges.sql
+-- Description: create challenges and challenge_participants tables for time-boxed group goals
+-- Date: 2025-08-29
+
+CREATE TABLE IF NOT EXISTS challenges (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    metric TEXT NOT NULL CHECK (metric IN ('workouts', 'minutes', 'volume')),
+    target NUMERIC(12,2) NOT NULL CHECK (target > 0),
+    starts_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    ends_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CHECK (ends_at > starts_at)
+);
+
+CREATE TABLE IF NOT EXISTS challenge_participants (
+    challenge_id UUID NOT NULL REFERENCES challenges(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    joined_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (challenge_id, user_id)
+);
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_challenges_ends_at ON challenges(ends_at);
+CREATE INDEX IF NOT EXISTS idx_challenge_participants_user_id ON challenge_participants(user_id);
+
+-- Add comments for documentation
+COMMENT ON TABLE challenges IS 'Time-boxed group goals, such as 20 workouts in 30 days, that any user can join';
+COMMENT ON COLUMN challenges.metric IS 'What counts towards the target: completed workout sessions, their minutes, or the kg lifted in them';
+COMMENT ON COLUMN challenges.ends_at IS 'Exclusive end; sessions completed from starts_at until then count';
+COMMENT ON TABLE challenge_participants IS 'Users taking part in a challenge; progress is counted from their sessions when read';
@@ -0,0 +1,42 @@
+-- Migration: 056_create_progression_rules.sql
+-- Description: create progression_rules and progression_steps tables for automatic progressive overload
+-- Date: 2025-08-30
+
+CREATE TABLE IF NOT EXISTS progression_rules (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    workout_exercise_id UUID NOT NULL UNIQUE REFERENCES workout_exercises(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    weight_increment_kg NUMERIC(6,2) NOT NULL DEFAULT 2.5 CHECK (weight_increment_kg >= 0),
+    min_reps INTEGER CHECK (min_reps > 0),
+    max_reps INTEGER,
+    deload_after_failures INTEGER NOT NULL DEFAULT 3 CHECK (deload_after_failures >= 0),
+    deload_percent INTEGER NOT NULL DEFAULT 10 CHECK (deload_percent BETWEEN 0 AND 50),
+    failures INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CHECK ((min_reps IS NULL) = (max_reps IS NULL) AND max_reps >= min_reps)
+);
+
+CREATE TABLE IF NOT EXISTS progression_steps (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    rule_id UUID NOT NULL REFERENCES progression_rules(id) ON DELETE CASCADE,
+    session_id UUID NOT NULL REFERENCES workout_sessions(id) ON DELETE CASCADE,
+    outcome TEXT NOT NULL CHECK (outcome IN ('progressed', 'repeated', 'deloaded')),
+    previous_reps INTEGER NOT NULL,
+    previous_weight_kg NUMERIC(8,2) NOT NULL,
+    reps INTEGER NOT NULL,
+    weight_kg NUMERIC(8,2) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (rule_id, session_id)
+);
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_progression_rules_user_id ON progression_rules(user_id);
+CREATE INDEX IF NOT EXISTS idx_progression_steps_session_id ON progression_steps(session_id);
+
+-- Add comments for documentation
+COMMENT ON TABLE progression_rules IS 'How a workout exercise''s prescription moves on after each session: more weight, or more reps then more weight, and a deload after repeated failures';
+COMMENT ON COLUMN progression_rules.min_reps IS 'With max_reps, double progression: reps go up by one per successful session until max_reps, then the weight goes up and reps restart at min_reps. Without them only the weight goes up.';
+COMMENT ON COLUMN progression_rules.deload_after_failures IS 'Consecutive failed sessions after which the weight drops by deload_percent; 0 never deloads';
+COMMENT ON COLUMN progression_rules.failures IS 'Consecutive failed sessions since the last progression or deload';
+COMMENT ON TABLE progression_steps IS 'Each time a session moved a workout exercise''s prescription on, at most once per rule and session';
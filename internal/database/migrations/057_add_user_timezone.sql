@@ -0,0 +1,8 @@
+-- Migration: 057_add_user_timezone.sql
+-- Description: store each user's timezone so sessions are grouped into days and weeks by their local date
+-- Date: 2025-08-31
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS timezone TEXT NOT NULL DEFAULT 'UTC';
+
+-- Add comments for documentation
+COMMENT ON COLUMN users.timezone IS 'IANA timezone name the user''s days and weeks are counted in, such as Europe/Berlin';
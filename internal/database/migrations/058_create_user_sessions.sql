@@ -0,0 +1,24 @@
+-- Migration: 058_create_user_sessions.sql
+-- Description: create user_sessions table tracking the device each issued token was signed in on
+-- Date: 2025-09-01
+
+CREATE TABLE IF NOT EXISTS user_sessions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token_id TEXT NOT NULL UNIQUE,
+    user_agent TEXT NOT NULL DEFAULT '',
+    ip_address TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    last_seen_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    revoked_at TIMESTAMP WITH TIME ZONE
+);
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_user_sessions_user_id ON user_sessions(user_id);
+
+-- Add comments for documentation
+COMMENT ON TABLE user_sessions IS 'One row per issued JWT, listed to users as their signed-in devices';
+COMMENT ON COLUMN user_sessions.token_id IS 'The token''s jti claim, added to the Redis revocation list when the session is revoked';
+COMMENT ON COLUMN user_sessions.ip_address IS 'Address the token was last used from';
+COMMENT ON COLUMN user_sessions.last_seen_at IS 'When the token was last used, updated at most every few minutes';
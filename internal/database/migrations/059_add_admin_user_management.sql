@@ -0,0 +1,28 @@
+-- Migration: 059_add_admin_user_management.sql
+-- Description: let admins disable accounts and force password resets, and audit what admins do
+-- Date: 2025-09-02
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS disabled_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS password_reset_required BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE IF NOT EXISTS admin_audit_log (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    admin_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    action TEXT NOT NULL,
+    path TEXT NOT NULL,
+    target_user_id UUID,
+    status_code INTEGER NOT NULL,
+    ip_address TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create indexes for better performance
+CREATE INDEX IF NOT EXISTS idx_admin_audit_log_created_at ON admin_audit_log(created_at);
+CREATE INDEX IF NOT EXISTS idx_admin_audit_log_target_user_id ON admin_audit_log(target_user_id);
+
+-- Add comments for documentation
+COMMENT ON COLUMN users.disabled_at IS 'Set while an admin has disabled the account; disabled users cannot sign in';
+COMMENT ON COLUMN users.password_reset_required IS 'Set by an admin; the next password sign-in may only change the password';
+COMMENT ON TABLE admin_audit_log IS 'Every request an admin made to /api/v1/admin, kept as an audit trail';
+COMMENT ON COLUMN admin_audit_log.action IS 'Method and route pattern, such as POST /api/v1/admin/users/:id/disable';
+COMMENT ON COLUMN admin_audit_log.target_user_id IS 'User the action was about, if any; not a foreign key so entries outlive the user';
@@ -0,0 +1,8 @@
+-- Migration: 060_add_scim_created_members.sql
+-- Description: track which members' accounts were created by the organization's SCIM provisioning
+-- Date: 2025-09-03
+
+ALTER TABLE organization_members ADD COLUMN IF NOT EXISTS scim_created BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Add comments for documentation
+COMMENT ON COLUMN organization_members.scim_created IS 'The account was created by the organization''s identity provider, which may then edit its name';
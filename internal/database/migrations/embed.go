@@ -0,0 +1,10 @@
+// Package migrations embeds the SQL migrations, so a binary can migrate its database
+// without the source tree next to it
+package migrations
+
+import "embed"
+
+// Files holds every migration, named NNN_description.sql
+//
+//go:embed *.sql
+var Files embed.FS
@@ -0,0 +1,90 @@
+// Code generated by migration system on 2025-08-08 10:12:40
+// DO NOT EDIT THIS FILE MANUALLY
+
+package database
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Account_deletions_status is a value of account_deletions.status
+type Account_deletions_status string
+
+const (
+	Account_deletions_status_pending   Account_deletions_status = "pending"
+	Account_deletions_status_canceled  Account_deletions_status = "canceled"
+	Account_deletions_status_completed Account_deletions_status = "completed"
+)
+
+// Account_deletions_statusValues lists the allowed values of account_deletions.status
+var Account_deletions_statusValues = []Account_deletions_status{Account_deletions_status_pending, Account_deletions_status_canceled, Account_deletions_status_completed}
+
+// Valid reports whether v is an allowed value of account_deletions.status
+func (v Account_deletions_status) Valid() bool {
+	for _, value := range Account_deletions_statusValues {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseAccount_deletions_status returns s as a value of account_deletions.status, or an error if it isn't an allowed one
+func ParseAccount_deletions_status(s string) (Account_deletions_status, error) {
+	v := Account_deletions_status(s)
+	if !v.Valid() {
+		return "", fmt.Errorf("invalid account_deletions.status %q", s)
+	}
+	return v, nil
+}
+
+// Account_deletions represents the account_deletions table
+type Account_deletions struct {
+	Id           string                   `db:"id" json:"id"` // Primary key
+	User_id      string                   `db:"user_id" json:"user_id"`
+	Status       Account_deletions_status `db:"status" json:"status"`             // Default: 'pending'::text
+	Requested_ip string                   `db:"requested_ip" json:"requested_ip"` // Default: ''::text
+	Requested_at time.Time                `db:"requested_at" json:"requested_at"` // Default: now()
+	Purge_after  time.Time                `db:"purge_after" json:"purge_after"`
+	Canceled_at  *time.Time               `db:"canceled_at" json:"canceled_at"`
+	Completed_at *time.Time               `db:"completed_at" json:"completed_at"`
+	Purged_rows  json.RawMessage          `db:"purged_rows" json:"purged_rows"` // Default: '{}'::jsonb
+	Attempts     int                      `db:"attempts" json:"attempts"`       // Default: 0
+	Last_error   string                   `db:"last_error" json:"last_error"`   // Default: ''::text
+}
+
+// TableName returns the table name for Account_deletions
+func (Account_deletions) TableName() string {
+	return "account_deletions"
+}
+
+// Scan implements the sql.Scanner interface for Account_deletions
+func (m *Account_deletions) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	default:
+		return fmt.Errorf("cannot scan %T into Account_deletions", value)
+	}
+}
+
+// Value implements the driver.Valuer interface for Account_deletions
+func (m Account_deletions) Value() (driver.Value, error) {
+	return json.Marshal(m)
+}
+
+// HasMany returns the foreign keys referencing account_deletions, by table and column
+func (Account_deletions) HasMany() map[string]ForeignKey {
+	return map[string]ForeignKey{
+		"data_subject_requests.account_deletion_id": {Table: "data_subject_requests", Column: "account_deletion_id", RefTable: "account_deletions", RefColumn: "id", OnDelete: "SET NULL"},
+	}
+}
@@ -0,0 +1,56 @@
+// Code generated by migration system on 2025-09-02 08:51:19
+// DO NOT EDIT THIS FILE MANUALLY
+
+package database
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Admin_audit_log represents the admin_audit_log table
+type Admin_audit_log struct {
+	Id             string    `db:"id" json:"id"`             // Primary key // Default: gen_random_uuid()
+	Admin_id       *string   `db:"admin_id" json:"admin_id"` // References users(id)
+	Action         string    `db:"action" json:"action"`
+	Path           string    `db:"path" json:"path"`
+	Target_user_id *string   `db:"target_user_id" json:"target_user_id"`
+	Status_code    int       `db:"status_code" json:"status_code"`
+	Ip_address     string    `db:"ip_address" json:"ip_address"` // Default: ''::text
+	Created_at     time.Time `db:"created_at" json:"created_at"` // Default: now()
+}
+
+// TableName returns the table name for Admin_audit_log
+func (Admin_audit_log) TableName() string {
+	return "admin_audit_log"
+}
+
+// Scan implements the sql.Scanner interface for Admin_audit_log
+func (m *Admin_audit_log) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	default:
+		return fmt.Errorf("cannot scan %T into Admin_audit_log", value)
+	}
+}
+
+// Value implements the driver.Valuer interface for Admin_audit_log
+func (m Admin_audit_log) Value() (driver.Value, error) {
+	return json.Marshal(m)
+}
+
+// BelongsTo returns the foreign keys of admin_audit_log, by column
+func (Admin_audit_log) BelongsTo() map[string]ForeignKey {
+	return map[string]ForeignKey{
+		"admin_id": {Table: "admin_audit_log", Column: "admin_id", RefTable: "users", RefColumn: "id", OnDelete: "SET NULL"},
+	}
+}
@@ -0,0 +1,87 @@
+// Code generated by migration system on 2025-08-20 09:12:05
+// DO NOT EDIT THIS FILE MANUALLY
+
+package database
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Api_keys_scope is a value of api_keys.scope
+type Api_keys_scope string
+
+const (
+	Api_keys_scope_read     Api_keys_scope = "read"
+	Api_keys_scope_write    Api_keys_scope = "write"
+	Api_keys_scope_triggers Api_keys_scope = "triggers"
+)
+
+// Api_keys_scopeValues lists the allowed values of api_keys.scope
+var Api_keys_scopeValues = []Api_keys_scope{Api_keys_scope_read, Api_keys_scope_write, Api_keys_scope_triggers}
+
+// Valid reports whether v is an allowed value of api_keys.scope
+func (v Api_keys_scope) Valid() bool {
+	for _, value := range Api_keys_scopeValues {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseApi_keys_scope returns s as a value of api_keys.scope, or an error if it isn't an allowed one
+func ParseApi_keys_scope(s string) (Api_keys_scope, error) {
+	v := Api_keys_scope(s)
+	if !v.Valid() {
+		return "", fmt.Errorf("invalid api_keys.scope %q", s)
+	}
+	return v, nil
+}
+
+// Api_keys represents the api_keys table
+type Api_keys struct {
+	Id           string         `db:"id" json:"id"`           // Primary key
+	User_id      string         `db:"user_id" json:"user_id"` // References users(id)
+	Name         string         `db:"name" json:"name"`       // Default: ''::text
+	Prefix       string         `db:"prefix" json:"prefix"`
+	Key_hash     string         `db:"key_hash" json:"key_hash"` // Unique
+	Scope        Api_keys_scope `db:"scope" json:"scope"`
+	Last_used_at *time.Time     `db:"last_used_at" json:"last_used_at"`
+	Created_at   time.Time      `db:"created_at" json:"created_at"` // Default: now()
+}
+
+// TableName returns the table name for Api_keys
+func (Api_keys) TableName() string {
+	return "api_keys"
+}
+
+// Scan implements the sql.Scanner interface for Api_keys
+func (m *Api_keys) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	default:
+		return fmt.Errorf("cannot scan %T into Api_keys", value)
+	}
+}
+
+// Value implements the driver.Valuer interface for Api_keys
+func (m Api_keys) Value() (driver.Value, error) {
+	return json.Marshal(m)
+}
+
+// BelongsTo returns the foreign keys of api_keys, by column
+func (Api_keys) BelongsTo() map[string]ForeignKey {
+	return map[string]ForeignKey{
+		"user_id": {Table: "api_keys", Column: "user_id", RefTable: "users", RefColumn: "id", OnDelete: "CASCADE"},
+	}
+}
@@ -0,0 +1,50 @@
+// Code generated by migration system on 2025-08-24 10:05:32
+// DO NOT EDIT THIS FILE MANUALLY
+
+package database
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Benchmark_consents represents the benchmark_consents table
+type Benchmark_consents struct {
+	User_id      string    `db:"user_id" json:"user_id"`           // Primary key // References users(id)
+	Consented_at time.Time `db:"consented_at" json:"consented_at"` // Default: now()
+}
+
+// TableName returns the table name for Benchmark_consents
+func (Benchmark_consents) TableName() string {
+	return "benchmark_consents"
+}
+
+// Scan implements the sql.Scanner interface for Benchmark_consents
+func (m *Benchmark_consents) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	default:
+		return fmt.Errorf("cannot scan %T into Benchmark_consents", value)
+	}
+}
+
+// Value implements the driver.Valuer interface for Benchmark_consents
+func (m Benchmark_consents) Value() (driver.Value, error) {
+	return json.Marshal(m)
+}
+
+// BelongsTo returns the foreign keys of benchmark_consents, by column
+func (Benchmark_consents) BelongsTo() map[string]ForeignKey {
+	return map[string]ForeignKey{
+		"user_id": {Table: "benchmark_consents", Column: "user_id", RefTable: "users", RefColumn: "id", OnDelete: "CASCADE"},
+	}
+}
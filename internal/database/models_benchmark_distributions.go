@@ -0,0 +1,53 @@
+// Code generated by migration system on 2025-08-24 10:05:32
+// DO NOT EDIT THIS FILE MANUALLY
+
+package database
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Benchmark_distributions represents the benchmark_distributions table
+type Benchmark_distributions struct {
+	Exercise_id      string          `db:"exercise_id" json:"exercise_id"`           // Primary key // References exercises(id)
+	Bodyweight_class string          `db:"bodyweight_class" json:"bodyweight_class"` // Primary key
+	Sample_size      int             `db:"sample_size" json:"sample_size"`
+	Percentiles      json.RawMessage `db:"percentiles" json:"percentiles"`
+	Computed_at      time.Time       `db:"computed_at" json:"computed_at"` // Default: now()
+}
+
+// TableName returns the table name for Benchmark_distributions
+func (Benchmark_distributions) TableName() string {
+	return "benchmark_distributions"
+}
+
+// Scan implements the sql.Scanner interface for Benchmark_distributions
+func (m *Benchmark_distributions) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	default:
+		return fmt.Errorf("cannot scan %T into Benchmark_distributions", value)
+	}
+}
+
+// Value implements the driver.Valuer interface for Benchmark_distributions
+func (m Benchmark_distributions) Value() (driver.Value, error) {
+	return json.Marshal(m)
+}
+
+// BelongsTo returns the foreign keys of benchmark_distributions, by column
+func (Benchmark_distributions) BelongsTo() map[string]ForeignKey {
+	return map[string]ForeignKey{
+		"exercise_id": {Table: "benchmark_distributions", Column: "exercise_id", RefTable: "exercises", RefColumn: "id", OnDelete: "CASCADE"},
+	}
+}
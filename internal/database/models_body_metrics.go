@@ -0,0 +1,90 @@
+// Code generated by migration system on 2025-08-08 10:12:40
+// DO NOT EDIT THIS FILE MANUALLY
+
+package database
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Body_metrics_metric is a value of body_metrics.metric
+type Body_metrics_metric string
+
+const (
+	Body_metrics_metric_weight_kg              Body_metrics_metric = "weight_kg"
+	Body_metrics_metric_body_fat_percent       Body_metrics_metric = "body_fat_percent"
+	Body_metrics_metric_height_cm              Body_metrics_metric = "height_cm"
+	Body_metrics_metric_lean_body_mass_kg      Body_metrics_metric = "lean_body_mass_kg"
+	Body_metrics_metric_resting_heart_rate_bpm Body_metrics_metric = "resting_heart_rate_bpm"
+)
+
+// Body_metrics_metricValues lists the allowed values of body_metrics.metric
+var Body_metrics_metricValues = []Body_metrics_metric{Body_metrics_metric_weight_kg, Body_metrics_metric_body_fat_percent, Body_metrics_metric_height_cm, Body_metrics_metric_lean_body_mass_kg, Body_metrics_metric_resting_heart_rate_bpm}
+
+// Valid reports whether v is an allowed value of body_metrics.metric
+func (v Body_metrics_metric) Valid() bool {
+	for _, value := range Body_metrics_metricValues {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseBody_metrics_metric returns s as a value of body_metrics.metric, or an error if it isn't an allowed one
+func ParseBody_metrics_metric(s string) (Body_metrics_metric, error) {
+	v := Body_metrics_metric(s)
+	if !v.Valid() {
+		return "", fmt.Errorf("invalid body_metrics.metric %q", s)
+	}
+	return v, nil
+}
+
+// Body_metrics represents the body_metrics table
+type Body_metrics struct {
+	Id             string              `db:"id" json:"id"`           // Primary key // Default: gen_random_uuid()
+	User_id        string              `db:"user_id" json:"user_id"` // References users(id)
+	Metric         Body_metrics_metric `db:"metric" json:"metric"`
+	Measured_value decimal.Decimal     `db:"measured_value" json:"measured_value"`
+	Recorded_at    time.Time           `db:"recorded_at" json:"recorded_at"`
+	Source         string              `db:"source" json:"source"`
+	Created_at     time.Time           `db:"created_at" json:"created_at"` // Default: now()
+}
+
+// TableName returns the table name for Body_metrics
+func (Body_metrics) TableName() string {
+	return "body_metrics"
+}
+
+// Scan implements the sql.Scanner interface for Body_metrics
+func (m *Body_metrics) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	default:
+		return fmt.Errorf("cannot scan %T into Body_metrics", value)
+	}
+}
+
+// Value implements the driver.Valuer interface for Body_metrics
+func (m Body_metrics) Value() (driver.Value, error) {
+	return json.Marshal(m)
+}
+
+// BelongsTo returns the foreign keys of body_metrics, by column
+func (Body_metrics) BelongsTo() map[string]ForeignKey {
+	return map[string]ForeignKey{
+		"user_id": {Table: "body_metrics", Column: "user_id", RefTable: "users", RefColumn: "id", OnDelete: "CASCADE"},
+	}
+}
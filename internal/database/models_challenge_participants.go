@@ -0,0 +1,52 @@
+// Code generated by migration system on 2025-08-29 08:47:12
+// DO NOT EDIT THIS FILE MANUALLY
+
+package database
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Challenge_participants represents the challenge_participants table
+type Challenge_participants struct {
+	Challenge_id string    `db:"challenge_id" json:"challenge_id"` // Primary key // References challenges(id)
+	User_id      string    `db:"user_id" json:"user_id"`           // Primary key // References users(id)
+	Joined_at    time.Time `db:"joined_at" json:"joined_at"`       // Default: now()
+}
+
+// TableName returns the table name for Challenge_participants
+func (Challenge_participants) TableName() string {
+	return "challenge_participants"
+}
+
+// Scan implements the sql.Scanner interface for Challenge_participants
+func (m *Challenge_participants) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	default:
+		return fmt.Errorf("cannot scan %T into Challenge_participants", value)
+	}
+}
+
+// Value implements the driver.Valuer interface for Challenge_participants
+func (m Challenge_participants) Value() (driver.Value, error) {
+	return json.Marshal(m)
+}
+
+// BelongsTo returns the foreign keys of challenge_participants, by column
+func (Challenge_participants) BelongsTo() map[string]ForeignKey {
+	return map[string]ForeignKey{
+		"challenge_id": {Table: "challenge_participants", Column: "challenge_id", RefTable: "challenges", RefColumn: "id", OnDelete: "CASCADE"},
+		"user_id":      {Table: "challenge_participants", Column: "user_id", RefTable: "users", RefColumn: "id", OnDelete: "CASCADE"},
+	}
+}
@@ -0,0 +1,97 @@
+// Code generated by migration system on 2025-08-29 08:47:12
+// DO NOT EDIT THIS FILE MANUALLY
+
+package database
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Challenges_metric is a value of challenges.metric
+type Challenges_metric string
+
+const (
+	Challenges_metric_workouts Challenges_metric = "workouts"
+	Challenges_metric_minutes  Challenges_metric = "minutes"
+	Challenges_metric_volume   Challenges_metric = "volume"
+)
+
+// Challenges_metricValues lists the allowed values of challenges.metric
+var Challenges_metricValues = []Challenges_metric{Challenges_metric_workouts, Challenges_metric_minutes, Challenges_metric_volume}
+
+// Valid reports whether v is an allowed value of challenges.metric
+func (v Challenges_metric) Valid() bool {
+	for _, value := range Challenges_metricValues {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseChallenges_metric returns s as a value of challenges.metric, or an error if it isn't an allowed one
+func ParseChallenges_metric(s string) (Challenges_metric, error) {
+	v := Challenges_metric(s)
+	if !v.Valid() {
+		return "", fmt.Errorf("invalid challenges.metric %q", s)
+	}
+	return v, nil
+}
+
+// Challenges represents the challenges table
+type Challenges struct {
+	Id          string            `db:"id" json:"id"`                 // Primary key // Default: gen_random_uuid()
+	Created_by  *string           `db:"created_by" json:"created_by"` // References users(id)
+	Name        string            `db:"name" json:"name"`
+	Description string            `db:"description" json:"description"` // Default: ''::text
+	Metric      Challenges_metric `db:"metric" json:"metric"`
+	Target      decimal.Decimal   `db:"target" json:"target"`
+	Starts_at   time.Time         `db:"starts_at" json:"starts_at"`
+	Ends_at     time.Time         `db:"ends_at" json:"ends_at"`
+	Created_at  time.Time         `db:"created_at" json:"created_at"` // Default: now()
+}
+
+// TableName returns the table name for Challenges
+func (Challenges) TableName() string {
+	return "challenges"
+}
+
+// Scan implements the sql.Scanner interface for Challenges
+func (m *Challenges) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	default:
+		return fmt.Errorf("cannot scan %T into Challenges", value)
+	}
+}
+
+// Value implements the driver.Valuer interface for Challenges
+func (m Challenges) Value() (driver.Value, error) {
+	return json.Marshal(m)
+}
+
+// BelongsTo returns the foreign keys of challenges, by column
+func (Challenges) BelongsTo() map[string]ForeignKey {
+	return map[string]ForeignKey{
+		"created_by": {Table: "challenges", Column: "created_by", RefTable: "users", RefColumn: "id", OnDelete: "SET NULL"},
+	}
+}
+
+// HasMany returns the foreign keys referencing challenges, by table and column
+func (Challenges) HasMany() map[string]ForeignKey {
+	return map[string]ForeignKey{
+		"challenge_participants.challenge_id": {Table: "challenge_participants", Column: "challenge_id", RefTable: "challenges", RefColumn: "id", OnDelete: "CASCADE"},
+	}
+}
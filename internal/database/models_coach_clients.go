@@ -0,0 +1,90 @@
+// Code generated by migration system on 2025-08-26 09:41:18
+// DO NOT EDIT THIS FILE MANUALLY
+
+package database
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Coach_clients_status is a value of coach_clients.status
+type Coach_clients_status string
+
+const (
+	Coach_clients_status_pending Coach_clients_status = "pending"
+	Coach_clients_status_active  Coach_clients_status = "active"
+	Coach_clients_status_ended   Coach_clients_status = "ended"
+)
+
+// Coach_clients_statusValues lists the allowed values of coach_clients.status
+var Coach_clients_statusValues = []Coach_clients_status{Coach_clients_status_pending, Coach_clients_status_active, Coach_clients_status_ended}
+
+// Valid reports whether v is an allowed value of coach_clients.status
+func (v Coach_clients_status) Valid() bool {
+	for _, value := range Coach_clients_statusValues {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseCoach_clients_status returns s as a value of coach_clients.status, or an error if it isn't an allowed one
+func ParseCoach_clients_status(s string) (Coach_clients_status, error) {
+	v := Coach_clients_status(s)
+	if !v.Valid() {
+		return "", fmt.Errorf("invalid coach_clients.status %q", s)
+	}
+	return v, nil
+}
+
+// Coach_clients represents the coach_clients table
+type Coach_clients struct {
+	Id          string               `db:"id" json:"id"`               // Primary key // Default: gen_random_uuid()
+	Coach_id    string               `db:"coach_id" json:"coach_id"`   // References users(id)
+	Client_id   *string              `db:"client_id" json:"client_id"` // References users(id)
+	Email       string               `db:"email" json:"email"`
+	Status      Coach_clients_status `db:"status" json:"status"`         // Default: 'pending'::text
+	Token_hash  string               `db:"token_hash" json:"token_hash"` // Unique
+	Expires_at  time.Time            `db:"expires_at" json:"expires_at"`
+	Accepted_at *time.Time           `db:"accepted_at" json:"accepted_at"`
+	Ended_at    *time.Time           `db:"ended_at" json:"ended_at"`
+	Created_at  time.Time            `db:"created_at" json:"created_at"` // Default: now()
+}
+
+// TableName returns the table name for Coach_clients
+func (Coach_clients) TableName() string {
+	return "coach_clients"
+}
+
+// Scan implements the sql.Scanner interface for Coach_clients
+func (m *Coach_clients) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	default:
+		return fmt.Errorf("cannot scan %T into Coach_clients", value)
+	}
+}
+
+// Value implements the driver.Valuer interface for Coach_clients
+func (m Coach_clients) Value() (driver.Value, error) {
+	return json.Marshal(m)
+}
+
+// BelongsTo returns the foreign keys of coach_clients, by column
+func (Coach_clients) BelongsTo() map[string]ForeignKey {
+	return map[string]ForeignKey{
+		"client_id": {Table: "coach_clients", Column: "client_id", RefTable: "users", RefColumn: "id", OnDelete: "CASCADE"},
+		"coach_id":  {Table: "coach_clients", Column: "coach_id", RefTable: "users", RefColumn: "id", OnDelete: "CASCADE"},
+	}
+}
@@ -0,0 +1,88 @@
+// Code generated by migration system on 2025-08-18 09:41:27
+// DO NOT EDIT THIS FILE MANUALLY
+
+package database
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Community_integrations_provider is a value of community_integrations.provider
+type Community_integrations_provider string
+
+const (
+	Community_integrations_provider_slack   Community_integrations_provider = "slack"
+	Community_integrations_provider_discord Community_integrations_provider = "discord"
+)
+
+// Community_integrations_providerValues lists the allowed values of community_integrations.provider
+var Community_integrations_providerValues = []Community_integrations_provider{Community_integrations_provider_slack, Community_integrations_provider_discord}
+
+// Valid reports whether v is an allowed value of community_integrations.provider
+func (v Community_integrations_provider) Valid() bool {
+	for _, value := range Community_integrations_providerValues {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseCommunity_integrations_provider returns s as a value of community_integrations.provider, or an error if it isn't an allowed one
+func ParseCommunity_integrations_provider(s string) (Community_integrations_provider, error) {
+	v := Community_integrations_provider(s)
+	if !v.Valid() {
+		return "", fmt.Errorf("invalid community_integrations.provider %q", s)
+	}
+	return v, nil
+}
+
+// Community_integrations represents the community_integrations table
+type Community_integrations struct {
+	Organization_id         string                          `db:"organization_id" json:"organization_id"` // Primary key // References organizations(id)
+	Provider                Community_integrations_provider `db:"provider" json:"provider"`
+	Webhook_url             string                          `db:"webhook_url" json:"webhook_url"`
+	Timezone                string                          `db:"timezone" json:"timezone"`                               // Default: 'UTC'::text
+	Post_personal_records   bool                            `db:"post_personal_records" json:"post_personal_records"`     // Default: true
+	Post_weekly_leaderboard bool                            `db:"post_weekly_leaderboard" json:"post_weekly_leaderboard"` // Default: true
+	Enabled                 bool                            `db:"enabled" json:"enabled"`                                 // Default: true
+	Leaderboard_posted_for  *time.Time                      `db:"leaderboard_posted_for" json:"leaderboard_posted_for"`
+	Created_at              time.Time                       `db:"created_at" json:"created_at"` // Default: now()
+	Updated_at              time.Time                       `db:"updated_at" json:"updated_at"` // Default: now()
+}
+
+// TableName returns the table name for Community_integrations
+func (Community_integrations) TableName() string {
+	return "community_integrations"
+}
+
+// Scan implements the sql.Scanner interface for Community_integrations
+func (m *Community_integrations) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	default:
+		return fmt.Errorf("cannot scan %T into Community_integrations", value)
+	}
+}
+
+// Value implements the driver.Valuer interface for Community_integrations
+func (m Community_integrations) Value() (driver.Value, error) {
+	return json.Marshal(m)
+}
+
+// BelongsTo returns the foreign keys of community_integrations, by column
+func (Community_integrations) BelongsTo() map[string]ForeignKey {
+	return map[string]ForeignKey{
+		"organization_id": {Table: "community_integrations", Column: "organization_id", RefTable: "organizations", RefColumn: "id", OnDelete: "CASCADE"},
+	}
+}
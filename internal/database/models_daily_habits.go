@@ -0,0 +1,57 @@
+// Code generated by migration system on 2025-08-23 08:47:15
+// DO NOT EDIT THIS FILE MANUALLY
+
+package database
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Daily_habits represents the daily_habits table
+type Daily_habits struct {
+	User_id     string           `db:"user_id" json:"user_id"` // Primary key // References users(id)
+	Day         time.Time        `db:"day" json:"day"`         // Primary key
+	Water_ml    *int             `db:"water_ml" json:"water_ml"`
+	Sleep_hours *decimal.Decimal `db:"sleep_hours" json:"sleep_hours"`
+	Steps       *int             `db:"steps" json:"steps"`
+	Created_at  time.Time        `db:"created_at" json:"created_at"` // Default: now()
+	Updated_at  time.Time        `db:"updated_at" json:"updated_at"` // Default: now()
+}
+
+// TableName returns the table name for Daily_habits
+func (Daily_habits) TableName() string {
+	return "daily_habits"
+}
+
+// Scan implements the sql.Scanner interface for Daily_habits
+func (m *Daily_habits) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	default:
+		return fmt.Errorf("cannot scan %T into Daily_habits", value)
+	}
+}
+
+// Value implements the driver.Valuer interface for Daily_habits
+func (m Daily_habits) Value() (driver.Value, error) {
+	return json.Marshal(m)
+}
+
+// BelongsTo returns the foreign keys of daily_habits, by column
+func (Daily_habits) BelongsTo() map[string]ForeignKey {
+	return map[string]ForeignKey{
+		"user_id": {Table: "daily_habits", Column: "user_id", RefTable: "users", RefColumn: "id", OnDelete: "CASCADE"},
+	}
+}
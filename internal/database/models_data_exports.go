@@ -0,0 +1,96 @@
+// Code generated by migration system on 2025-08-08 10:12:40
+// DO NOT EDIT THIS FILE MANUALLY
+
+package database
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Data_exports_status is a value of data_exports.status
+type Data_exports_status string
+
+const (
+	Data_exports_status_pending Data_exports_status = "pending"
+	Data_exports_status_ready   Data_exports_status = "ready"
+	Data_exports_status_failed  Data_exports_status = "failed"
+)
+
+// Data_exports_statusValues lists the allowed values of data_exports.status
+var Data_exports_statusValues = []Data_exports_status{Data_exports_status_pending, Data_exports_status_ready, Data_exports_status_failed}
+
+// Valid reports whether v is an allowed value of data_exports.status
+func (v Data_exports_status) Valid() bool {
+	for _, value := range Data_exports_statusValues {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseData_exports_status returns s as a value of data_exports.status, or an error if it isn't an allowed one
+func ParseData_exports_status(s string) (Data_exports_status, error) {
+	v := Data_exports_status(s)
+	if !v.Valid() {
+		return "", fmt.Errorf("invalid data_exports.status %q", s)
+	}
+	return v, nil
+}
+
+// Data_exports represents the data_exports table
+type Data_exports struct {
+	Id            string              `db:"id" json:"id"`                   // Primary key
+	User_id       string              `db:"user_id" json:"user_id"`         // References users(id)
+	Status        Data_exports_status `db:"status" json:"status"`           // Default: 'pending'::text
+	Storage_key   string              `db:"storage_key" json:"storage_key"` // Default: ''::text
+	Size_bytes    int64               `db:"size_bytes" json:"size_bytes"`   // Default: 0
+	Error         string              `db:"error" json:"error"`             // Default: ''::text
+	Created_at    time.Time           `db:"created_at" json:"created_at"`   // Default: now()
+	Completed_at  *time.Time          `db:"completed_at" json:"completed_at"`
+	Expires_at    *time.Time          `db:"expires_at" json:"expires_at"`
+	Include_vault bool                `db:"include_vault" json:"include_vault"` // Default: false
+}
+
+// TableName returns the table name for Data_exports
+func (Data_exports) TableName() string {
+	return "data_exports"
+}
+
+// Scan implements the sql.Scanner interface for Data_exports
+func (m *Data_exports) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	default:
+		return fmt.Errorf("cannot scan %T into Data_exports", value)
+	}
+}
+
+// Value implements the driver.Valuer interface for Data_exports
+func (m Data_exports) Value() (driver.Value, error) {
+	return json.Marshal(m)
+}
+
+// BelongsTo returns the foreign keys of data_exports, by column
+func (Data_exports) BelongsTo() map[string]ForeignKey {
+	return map[string]ForeignKey{
+		"user_id": {Table: "data_exports", Column: "user_id", RefTable: "users", RefColumn: "id", OnDelete: "CASCADE"},
+	}
+}
+
+// HasMany returns the foreign keys referencing data_exports, by table and column
+func (Data_exports) HasMany() map[string]ForeignKey {
+	return map[string]ForeignKey{
+		"data_subject_requests.data_export_id": {Table: "data_subject_requests", Column: "data_export_id", RefTable: "data_exports", RefColumn: "id", OnDelete: "SET NULL"},
+	}
+}
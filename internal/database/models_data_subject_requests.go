@@ -0,0 +1,125 @@
+// Code generated by migration system on 2025-08-08 10:12:40
+// DO NOT EDIT THIS FILE MANUALLY
+
+package database
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Data_subject_requests_type is a value of data_subject_requests.type
+type Data_subject_requests_type string
+
+const (
+	Data_subject_requests_type_access   Data_subject_requests_type = "access"
+	Data_subject_requests_type_deletion Data_subject_requests_type = "deletion"
+)
+
+// Data_subject_requests_typeValues lists the allowed values of data_subject_requests.type
+var Data_subject_requests_typeValues = []Data_subject_requests_type{Data_subject_requests_type_access, Data_subject_requests_type_deletion}
+
+// Valid reports whether v is an allowed value of data_subject_requests.type
+func (v Data_subject_requests_type) Valid() bool {
+	for _, value := range Data_subject_requests_typeValues {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseData_subject_requests_type returns s as a value of data_subject_requests.type, or an error if it isn't an allowed one
+func ParseData_subject_requests_type(s string) (Data_subject_requests_type, error) {
+	v := Data_subject_requests_type(s)
+	if !v.Valid() {
+		return "", fmt.Errorf("invalid data_subject_requests.type %q", s)
+	}
+	return v, nil
+}
+
+// Data_subject_requests_status is a value of data_subject_requests.status
+type Data_subject_requests_status string
+
+const (
+	Data_subject_requests_status_received    Data_subject_requests_status = "received"
+	Data_subject_requests_status_in_progress Data_subject_requests_status = "in_progress"
+	Data_subject_requests_status_completed   Data_subject_requests_status = "completed"
+	Data_subject_requests_status_rejected    Data_subject_requests_status = "rejected"
+)
+
+// Data_subject_requests_statusValues lists the allowed values of data_subject_requests.status
+var Data_subject_requests_statusValues = []Data_subject_requests_status{Data_subject_requests_status_received, Data_subject_requests_status_in_progress, Data_subject_requests_status_completed, Data_subject_requests_status_rejected}
+
+// Valid reports whether v is an allowed value of data_subject_requests.status
+func (v Data_subject_requests_status) Valid() bool {
+	for _, value := range Data_subject_requests_statusValues {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseData_subject_requests_status returns s as a value of data_subject_requests.status, or an error if it isn't an allowed one
+func ParseData_subject_requests_status(s string) (Data_subject_requests_status, error) {
+	v := Data_subject_requests_status(s)
+	if !v.Valid() {
+		return "", fmt.Errorf("invalid data_subject_requests.status %q", s)
+	}
+	return v, nil
+}
+
+// Data_subject_requests represents the data_subject_requests table
+type Data_subject_requests struct {
+	Id                  string                       `db:"id" json:"id"` // Primary key // Default: gen_random_uuid()
+	User_id             string                       `db:"user_id" json:"user_id"`
+	Subject_email       string                       `db:"subject_email" json:"subject_email"`
+	Type                Data_subject_requests_type   `db:"type" json:"type"`
+	Status              Data_subject_requests_status `db:"status" json:"status"` // Default: 'received'::text
+	Notes               string                       `db:"notes" json:"notes"`   // Default: ''::text
+	Due_at              time.Time                    `db:"due_at" json:"due_at"`
+	Data_export_id      *string                      `db:"data_export_id" json:"data_export_id"`           // References data_exports(id)
+	Account_deletion_id *string                      `db:"account_deletion_id" json:"account_deletion_id"` // References account_deletions(id)
+	Created_by          string                       `db:"created_by" json:"created_by"`
+	Resolved_by         *string                      `db:"resolved_by" json:"resolved_by"`
+	Created_at          time.Time                    `db:"created_at" json:"created_at"` // Default: now()
+	Updated_at          time.Time                    `db:"updated_at" json:"updated_at"` // Default: now()
+	Completed_at        *time.Time                   `db:"completed_at" json:"completed_at"`
+}
+
+// TableName returns the table name for Data_subject_requests
+func (Data_subject_requests) TableName() string {
+	return "data_subject_requests"
+}
+
+// Scan implements the sql.Scanner interface for Data_subject_requests
+func (m *Data_subject_requests) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	default:
+		return fmt.Errorf("cannot scan %T into Data_subject_requests", value)
+	}
+}
+
+// Value implements the driver.Valuer interface for Data_subject_requests
+func (m Data_subject_requests) Value() (driver.Value, error) {
+	return json.Marshal(m)
+}
+
+// BelongsTo returns the foreign keys of data_subject_requests, by column
+func (Data_subject_requests) BelongsTo() map[string]ForeignKey {
+	return map[string]ForeignKey{
+		"account_deletion_id": {Table: "data_subject_requests", Column: "account_deletion_id", RefTable: "account_deletions", RefColumn: "id", OnDelete: "SET NULL"},
+		"data_export_id":      {Table: "data_subject_requests", Column: "data_export_id", RefTable: "data_exports", RefColumn: "id", OnDelete: "SET NULL"},
+	}
+}
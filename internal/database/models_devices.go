@@ -0,0 +1,85 @@
+// Code generated by migration system on 2025-08-08 10:12:40
+// DO NOT EDIT THIS FILE MANUALLY
+
+package database
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Devices_platform is a value of devices.platform
+type Devices_platform string
+
+const (
+	Devices_platform_ios     Devices_platform = "ios"
+	Devices_platform_android Devices_platform = "android"
+)
+
+// Devices_platformValues lists the allowed values of devices.platform
+var Devices_platformValues = []Devices_platform{Devices_platform_ios, Devices_platform_android}
+
+// Valid reports whether v is an allowed value of devices.platform
+func (v Devices_platform) Valid() bool {
+	for _, value := range Devices_platformValues {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseDevices_platform returns s as a value of devices.platform, or an error if it isn't an allowed one
+func ParseDevices_platform(s string) (Devices_platform, error) {
+	v := Devices_platform(s)
+	if !v.Valid() {
+		return "", fmt.Errorf("invalid devices.platform %q", s)
+	}
+	return v, nil
+}
+
+// Devices represents the devices table
+type Devices struct {
+	Id         string           `db:"id" json:"id"`           // Primary key // Default: gen_random_uuid()
+	User_id    string           `db:"user_id" json:"user_id"` // References users(id)
+	Platform   Devices_platform `db:"platform" json:"platform"`
+	Token      string           `db:"token" json:"token"`
+	Name       string           `db:"name" json:"name"`             // Default: ''
+	Created_at time.Time        `db:"created_at" json:"created_at"` // Default: now()
+	Updated_at time.Time        `db:"updated_at" json:"updated_at"` // Default: now()
+}
+
+// TableName returns the table name for Devices
+func (Devices) TableName() string {
+	return "devices"
+}
+
+// Scan implements the sql.Scanner interface for Devices
+func (m *Devices) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	default:
+		return fmt.Errorf("cannot scan %T into Devices", value)
+	}
+}
+
+// Value implements the driver.Valuer interface for Devices
+func (m Devices) Value() (driver.Value, error) {
+	return json.Marshal(m)
+}
+
+// BelongsTo returns the foreign keys of devices, by column
+func (Devices) BelongsTo() map[string]ForeignKey {
+	return map[string]ForeignKey{
+		"user_id": {Table: "devices", Column: "user_id", RefTable: "users", RefColumn: "id", OnDelete: "CASCADE"},
+	}
+}
@@ -0,0 +1,53 @@
+// Code generated by migration system on 2025-08-08 10:12:40
+// DO NOT EDIT THIS FILE MANUALLY
+
+package database
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Entitlements represents the entitlements table
+type Entitlements struct {
+	User_id       string    `db:"user_id" json:"user_id"` // Primary key // References users(id)
+	Premium_until time.Time `db:"premium_until" json:"premium_until"`
+	Source        string    `db:"source" json:"source"`         // Default: ''::text
+	Created_at    time.Time `db:"created_at" json:"created_at"` // Default: now()
+	Updated_at    time.Time `db:"updated_at" json:"updated_at"` // Default: now()
+}
+
+// TableName returns the table name for Entitlements
+func (Entitlements) TableName() string {
+	return "entitlements"
+}
+
+// Scan implements the sql.Scanner interface for Entitlements
+func (m *Entitlements) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	default:
+		return fmt.Errorf("cannot scan %T into Entitlements", value)
+	}
+}
+
+// Value implements the driver.Valuer interface for Entitlements
+func (m Entitlements) Value() (driver.Value, error) {
+	return json.Marshal(m)
+}
+
+// BelongsTo returns the foreign keys of entitlements, by column
+func (Entitlements) BelongsTo() map[string]ForeignKey {
+	return map[string]ForeignKey{
+		"user_id": {Table: "entitlements", Column: "user_id", RefTable: "users", RefColumn: "id", OnDelete: "CASCADE"},
+	}
+}
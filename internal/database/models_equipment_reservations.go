@@ -0,0 +1,88 @@
+// Code generated by migration system on 2025-08-08 10:12:40
+// DO NOT EDIT THIS FILE MANUALLY
+
+package database
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Equipment_reservations_status is a value of equipment_reservations.status
+type Equipment_reservations_status string
+
+const (
+	Equipment_reservations_status_booked    Equipment_reservations_status = "booked"
+	Equipment_reservations_status_cancelled Equipment_reservations_status = "cancelled"
+	Equipment_reservations_status_no_show   Equipment_reservations_status = "no_show"
+)
+
+// Equipment_reservations_statusValues lists the allowed values of equipment_reservations.status
+var Equipment_reservations_statusValues = []Equipment_reservations_status{Equipment_reservations_status_booked, Equipment_reservations_status_cancelled, Equipment_reservations_status_no_show}
+
+// Valid reports whether v is an allowed value of equipment_reservations.status
+func (v Equipment_reservations_status) Valid() bool {
+	for _, value := range Equipment_reservations_statusValues {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseEquipment_reservations_status returns s as a value of equipment_reservations.status, or an error if it isn't an allowed one
+func ParseEquipment_reservations_status(s string) (Equipment_reservations_status, error) {
+	v := Equipment_reservations_status(s)
+	if !v.Valid() {
+		return "", fmt.Errorf("invalid equipment_reservations.status %q", s)
+	}
+	return v, nil
+}
+
+// Equipment_reservations represents the equipment_reservations table
+type Equipment_reservations struct {
+	Id           string                        `db:"id" json:"id"`                     // Primary key // Default: gen_random_uuid()
+	Equipment_id string                        `db:"equipment_id" json:"equipment_id"` // References gym_equipment(id)
+	User_id      string                        `db:"user_id" json:"user_id"`           // References users(id)
+	Starts_at    time.Time                     `db:"starts_at" json:"starts_at"`
+	Ends_at      time.Time                     `db:"ends_at" json:"ends_at"`
+	Status       Equipment_reservations_status `db:"status" json:"status"` // Default: 'booked'::text
+	Cancelled_at *time.Time                    `db:"cancelled_at" json:"cancelled_at"`
+	Created_at   time.Time                     `db:"created_at" json:"created_at"` // Default: now()
+}
+
+// TableName returns the table name for Equipment_reservations
+func (Equipment_reservations) TableName() string {
+	return "equipment_reservations"
+}
+
+// Scan implements the sql.Scanner interface for Equipment_reservations
+func (m *Equipment_reservations) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	default:
+		return fmt.Errorf("cannot scan %T into Equipment_reservations", value)
+	}
+}
+
+// Value implements the driver.Valuer interface for Equipment_reservations
+func (m Equipment_reservations) Value() (driver.Value, error) {
+	return json.Marshal(m)
+}
+
+// BelongsTo returns the foreign keys of equipment_reservations, by column
+func (Equipment_reservations) BelongsTo() map[string]ForeignKey {
+	return map[string]ForeignKey{
+		"equipment_id": {Table: "equipment_reservations", Column: "equipment_id", RefTable: "gym_equipment", RefColumn: "id", OnDelete: "CASCADE"},
+		"user_id":      {Table: "equipment_reservations", Column: "user_id", RefTable: "users", RefColumn: "id", OnDelete: "CASCADE"},
+	}
+}
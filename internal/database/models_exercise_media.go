@@ -0,0 +1,118 @@
+// Code generated by migration system on 2025-08-17 11:42:08
+// DO NOT EDIT THIS FILE MANUALLY
+
+package database
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Exercise_media_kind is a value of exercise_media.kind
+type Exercise_media_kind string
+
+const (
+	Exercise_media_kind_image Exercise_media_kind = "image"
+	Exercise_media_kind_video Exercise_media_kind = "video"
+)
+
+// Exercise_media_kindValues lists the allowed values of exercise_media.kind
+var Exercise_media_kindValues = []Exercise_media_kind{Exercise_media_kind_image, Exercise_media_kind_video}
+
+// Valid reports whether v is an allowed value of exercise_media.kind
+func (v Exercise_media_kind) Valid() bool {
+	for _, value := range Exercise_media_kindValues {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseExercise_media_kind returns s as a value of exercise_media.kind, or an error if it isn't an allowed one
+func ParseExercise_media_kind(s string) (Exercise_media_kind, error) {
+	v := Exercise_media_kind(s)
+	if !v.Valid() {
+		return "", fmt.Errorf("invalid exercise_media.kind %q", s)
+	}
+	return v, nil
+}
+
+// Exercise_media_status is a value of exercise_media.status
+type Exercise_media_status string
+
+const (
+	Exercise_media_status_pending Exercise_media_status = "pending"
+	Exercise_media_status_ready   Exercise_media_status = "ready"
+)
+
+// Exercise_media_statusValues lists the allowed values of exercise_media.status
+var Exercise_media_statusValues = []Exercise_media_status{Exercise_media_status_pending, Exercise_media_status_ready}
+
+// Valid reports whether v is an allowed value of exercise_media.status
+func (v Exercise_media_status) Valid() bool {
+	for _, value := range Exercise_media_statusValues {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseExercise_media_status returns s as a value of exercise_media.status, or an error if it isn't an allowed one
+func ParseExercise_media_status(s string) (Exercise_media_status, error) {
+	v := Exercise_media_status(s)
+	if !v.Valid() {
+		return "", fmt.Errorf("invalid exercise_media.status %q", s)
+	}
+	return v, nil
+}
+
+// Exercise_media represents the exercise_media table
+type Exercise_media struct {
+	Id           string                `db:"id" json:"id"`                   // Primary key // Default: gen_random_uuid()
+	Exercise_id  string                `db:"exercise_id" json:"exercise_id"` // References exercises(id)
+	Kind         Exercise_media_kind   `db:"kind" json:"kind"`
+	Status       Exercise_media_status `db:"status" json:"status"`             // Default: 'ready'
+	Url          string                `db:"url" json:"url"`                   // Default: ''
+	Storage_key  string                `db:"storage_key" json:"storage_key"`   // Default: ''
+	Content_type string                `db:"content_type" json:"content_type"` // Default: ''
+	Caption      string                `db:"caption" json:"caption"`           // Default: ''
+	Position     int                   `db:"position" json:"position"`         // Default: 0
+	Created_at   time.Time             `db:"created_at" json:"created_at"`     // Default: now()
+}
+
+// TableName returns the table name for Exercise_media
+func (Exercise_media) TableName() string {
+	return "exercise_media"
+}
+
+// Scan implements the sql.Scanner interface for Exercise_media
+func (m *Exercise_media) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	default:
+		return fmt.Errorf("cannot scan %T into Exercise_media", value)
+	}
+}
+
+// Value implements the driver.Valuer interface for Exercise_media
+func (m Exercise_media) Value() (driver.Value, error) {
+	return json.Marshal(m)
+}
+
+// BelongsTo returns the foreign keys of exercise_media, by column
+func (Exercise_media) BelongsTo() map[string]ForeignKey {
+	return map[string]ForeignKey{
+		"exercise_id": {Table: "exercise_media", Column: "exercise_id", RefTable: "exercises", RefColumn: "id", OnDelete: "CASCADE"},
+	}
+}
@@ -0,0 +1,81 @@
+// Code generated by migration system on 2025-08-16 10:05:42
+// DO NOT EDIT THIS FILE MANUALLY
+
+package database
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// Exercise_muscle_groups_role is a value of exercise_muscle_groups.role
+type Exercise_muscle_groups_role string
+
+const (
+	Exercise_muscle_groups_role_primary   Exercise_muscle_groups_role = "primary"
+	Exercise_muscle_groups_role_secondary Exercise_muscle_groups_role = "secondary"
+)
+
+// Exercise_muscle_groups_roleValues lists the allowed values of exercise_muscle_groups.role
+var Exercise_muscle_groups_roleValues = []Exercise_muscle_groups_role{Exercise_muscle_groups_role_primary, Exercise_muscle_groups_role_secondary}
+
+// Valid reports whether v is an allowed value of exercise_muscle_groups.role
+func (v Exercise_muscle_groups_role) Valid() bool {
+	for _, value := range Exercise_muscle_groups_roleValues {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseExercise_muscle_groups_role returns s as a value of exercise_muscle_groups.role, or an error if it isn't an allowed one
+func ParseExercise_muscle_groups_role(s string) (Exercise_muscle_groups_role, error) {
+	v := Exercise_muscle_groups_role(s)
+	if !v.Valid() {
+		return "", fmt.Errorf("invalid exercise_muscle_groups.role %q", s)
+	}
+	return v, nil
+}
+
+// Exercise_muscle_groups represents the exercise_muscle_groups table
+type Exercise_muscle_groups struct {
+	Exercise_id     string                      `db:"exercise_id" json:"exercise_id"`         // References exercises(id)         // Primary key
+	Muscle_group_id string                      `db:"muscle_group_id" json:"muscle_group_id"` // References muscle_groups(id) // Primary key
+	Role            Exercise_muscle_groups_role `db:"role" json:"role"`
+}
+
+// TableName returns the table name for Exercise_muscle_groups
+func (Exercise_muscle_groups) TableName() string {
+	return "exercise_muscle_groups"
+}
+
+// Scan implements the sql.Scanner interface for Exercise_muscle_groups
+func (m *Exercise_muscle_groups) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	default:
+		return fmt.Errorf("cannot scan %T into Exercise_muscle_groups", value)
+	}
+}
+
+// Value implements the driver.Valuer interface for Exercise_muscle_groups
+func (m Exercise_muscle_groups) Value() (driver.Value, error) {
+	return json.Marshal(m)
+}
+
+// BelongsTo returns the foreign keys of exercise_muscle_groups, by column
+func (Exercise_muscle_groups) BelongsTo() map[string]ForeignKey {
+	return map[string]ForeignKey{
+		"exercise_id":     {Table: "exercise_muscle_groups", Column: "exercise_id", RefTable: "exercises", RefColumn: "id", OnDelete: "CASCADE"},
+		"muscle_group_id": {Table: "exercise_muscle_groups", Column: "muscle_group_id", RefTable: "muscle_groups", RefColumn: "id", OnDelete: "CASCADE"},
+	}
+}
@@ -0,0 +1,73 @@
+// Code generated by migration system on 2025-08-24 10:05:32
+// DO NOT EDIT THIS FILE MANUALLY
+
+package database
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Exercises represents the exercises table
+type Exercises struct {
+	Id               string    `db:"id" json:"id"` // Primary key // Default: gen_random_uuid()
+	Name             string    `db:"name" json:"name"`
+	Description      string    `db:"description" json:"description"`
+	Equipment        *string   `db:"equipment" json:"equipment"`
+	Difficulty_level *string   `db:"difficulty_level" json:"difficulty_level"`
+	Instructions     string    `db:"instructions" json:"instructions"`
+	Created_at       time.Time `db:"created_at" json:"created_at"`   // Default: now()
+	Updated_at       time.Time `db:"updated_at" json:"updated_at"`   // Default: now()
+	Source           string    `db:"source" json:"source"`           // Default: ''
+	External_id      string    `db:"external_id" json:"external_id"` // Default: ''
+	Version          int       `db:"version" json:"version"`         // Default: 1
+	Created_by       *string   `db:"created_by" json:"created_by"`   // References users(id)
+}
+
+// TableName returns the table name for Exercises
+func (Exercises) TableName() string {
+	return "exercises"
+}
+
+// Scan implements the sql.Scanner interface for Exercises
+func (m *Exercises) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	default:
+		return fmt.Errorf("cannot scan %T into Exercises", value)
+	}
+}
+
+// Value implements the driver.Valuer interface for Exercises
+func (m Exercises) Value() (driver.Value, error) {
+	return json.Marshal(m)
+}
+
+// BelongsTo returns the foreign keys of exercises, by column
+func (Exercises) BelongsTo() map[string]ForeignKey {
+	return map[string]ForeignKey{
+		"created_by": {Table: "exercises", Column: "created_by", RefTable: "users", RefColumn: "id", OnDelete: "SET NULL"},
+	}
+}
+
+// HasMany returns the foreign keys referencing exercises, by table and column
+func (Exercises) HasMany() map[string]ForeignKey {
+	return map[string]ForeignKey{
+		"benchmark_distributions.exercise_id": {Table: "benchmark_distributions", Column: "exercise_id", RefTable: "exercises", RefColumn: "id", OnDelete: "CASCADE"},
+		"exercise_media.exercise_id":          {Table: "exercise_media", Column: "exercise_id", RefTable: "exercises", RefColumn: "id", OnDelete: "CASCADE"},
+		"exercise_muscle_groups.exercise_id":  {Table: "exercise_muscle_groups", Column: "exercise_id", RefTable: "exercises", RefColumn: "id", OnDelete: "CASCADE"},
+		"training_maxes.exercise_id":          {Table: "training_maxes", Column: "exercise_id", RefTable: "exercises", RefColumn: "id", OnDelete: "SET NULL"},
+		"workout_exercises.exercise_id":       {Table: "workout_exercises", Column: "exercise_id", RefTable: "exercises", RefColumn: "id", OnDelete: "CASCADE"},
+		"workout_session_sets.exercise_id":    {Table: "workout_session_sets", Column: "exercise_id", RefTable: "exercises", RefColumn: "id", OnDelete: "SET NULL"},
+		"workout_sessions.exercise_id":        {Table: "workout_sessions", Column: "exercise_id", RefTable: "exercises", RefColumn: "id", OnDelete: "SET NULL"},
+	}
+}
@@ -0,0 +1,52 @@
+// Code generated by migration system on 2025-08-27 10:15:06
+// DO NOT EDIT THIS FILE MANUALLY
+
+package database
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Follows represents the follows table
+type Follows struct {
+	Follower_id string    `db:"follower_id" json:"follower_id"` // Primary key // References users(id)
+	Followee_id string    `db:"followee_id" json:"followee_id"` // Primary key // References users(id)
+	Created_at  time.Time `db:"created_at" json:"created_at"`   // Default: now()
+}
+
+// TableName returns the table name for Follows
+func (Follows) TableName() string {
+	return "follows"
+}
+
+// Scan implements the sql.Scanner interface for Follows
+func (m *Follows) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	default:
+		return fmt.Errorf("cannot scan %T into Follows", value)
+	}
+}
+
+// Value implements the driver.Valuer interface for Follows
+func (m Follows) Value() (driver.Value, error) {
+	return json.Marshal(m)
+}
+
+// BelongsTo returns the foreign keys of follows, by column
+func (Follows) BelongsTo() map[string]ForeignKey {
+	return map[string]ForeignKey{
+		"followee_id": {Table: "follows", Column: "followee_id", RefTable: "users", RefColumn: "id", OnDelete: "CASCADE"},
+		"follower_id": {Table: "follows", Column: "follower_id", RefTable: "users", RefColumn: "id", OnDelete: "CASCADE"},
+	}
+}
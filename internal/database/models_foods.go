@@ -0,0 +1,104 @@
+// Code generated by migration system on 2025-08-21 10:04:52
+// DO NOT EDIT THIS FILE MANUALLY
+
+package database
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Foods_serving_unit is a value of foods.serving_unit
+type Foods_serving_unit string
+
+const (
+	Foods_serving_unit_g     Foods_serving_unit = "g"
+	Foods_serving_unit_ml    Foods_serving_unit = "ml"
+	Foods_serving_unit_piece Foods_serving_unit = "piece"
+)
+
+// Foods_serving_unitValues lists the allowed values of foods.serving_unit
+var Foods_serving_unitValues = []Foods_serving_unit{Foods_serving_unit_g, Foods_serving_unit_ml, Foods_serving_unit_piece}
+
+// Valid reports whether v is an allowed value of foods.serving_unit
+func (v Foods_serving_unit) Valid() bool {
+	for _, value := range Foods_serving_unitValues {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseFoods_serving_unit returns s as a value of foods.serving_unit, or an error if it isn't an allowed one
+func ParseFoods_serving_unit(s string) (Foods_serving_unit, error) {
+	v := Foods_serving_unit(s)
+	if !v.Valid() {
+		return "", fmt.Errorf("invalid foods.serving_unit %q", s)
+	}
+	return v, nil
+}
+
+// Foods represents the foods table
+type Foods struct {
+	Id           string             `db:"id" json:"id"`           // Primary key // Default: gen_random_uuid()
+	User_id      *string            `db:"user_id" json:"user_id"` // References users(id)
+	Name         string             `db:"name" json:"name"`
+	Brand        string             `db:"brand" json:"brand"`               // Default: ''::text
+	Barcode      string             `db:"barcode" json:"barcode"`           // Default: ''::text
+	Serving_size decimal.Decimal    `db:"serving_size" json:"serving_size"` // Default: 100
+	Serving_unit Foods_serving_unit `db:"serving_unit" json:"serving_unit"` // Default: 'g'::text
+	Calories     decimal.Decimal    `db:"calories" json:"calories"`         // Default: 0
+	Protein_g    decimal.Decimal    `db:"protein_g" json:"protein_g"`       // Default: 0
+	Carbs_g      decimal.Decimal    `db:"carbs_g" json:"carbs_g"`           // Default: 0
+	Fat_g        decimal.Decimal    `db:"fat_g" json:"fat_g"`               // Default: 0
+	Source       string             `db:"source" json:"source"`             // Default: ''::text
+	External_id  string             `db:"external_id" json:"external_id"`   // Default: ''::text
+	Created_at   time.Time          `db:"created_at" json:"created_at"`     // Default: now()
+	Updated_at   time.Time          `db:"updated_at" json:"updated_at"`     // Default: now()
+	Version      int                `db:"version" json:"version"`           // Default: 1
+}
+
+// TableName returns the table name for Foods
+func (Foods) TableName() string {
+	return "foods"
+}
+
+// Scan implements the sql.Scanner interface for Foods
+func (m *Foods) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	default:
+		return fmt.Errorf("cannot scan %T into Foods", value)
+	}
+}
+
+// Value implements the driver.Valuer interface for Foods
+func (m Foods) Value() (driver.Value, error) {
+	return json.Marshal(m)
+}
+
+// BelongsTo returns the foreign keys of foods, by column
+func (Foods) BelongsTo() map[string]ForeignKey {
+	return map[string]ForeignKey{
+		"user_id": {Table: "foods", Column: "user_id", RefTable: "users", RefColumn: "id", OnDelete: "CASCADE"},
+	}
+}
+
+// HasMany returns the foreign keys referencing foods, by table and column
+func (Foods) HasMany() map[string]ForeignKey {
+	return map[string]ForeignKey{
+		"nutrition_logs.food_id": {Table: "nutrition_logs", Column: "food_id", RefTable: "foods", RefColumn: "id", OnDelete: "SET NULL"},
+	}
+}
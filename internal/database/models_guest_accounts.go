@@ -0,0 +1,51 @@
+// Code generated by migration system on 2025-08-08 10:12:40
+// DO NOT EDIT THIS FILE MANUALLY
+
+package database
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Guest_accounts represents the guest_accounts table
+type Guest_accounts struct {
+	User_id    string    `db:"user_id" json:"user_id"` // Primary key // References users(id)
+	Expires_at time.Time `db:"expires_at" json:"expires_at"`
+	Created_at time.Time `db:"created_at" json:"created_at"` // Default: now()
+}
+
+// TableName returns the table name for Guest_accounts
+func (Guest_accounts) TableName() string {
+	return "guest_accounts"
+}
+
+// Scan implements the sql.Scanner interface for Guest_accounts
+func (m *Guest_accounts) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	default:
+		return fmt.Errorf("cannot scan %T into Guest_accounts", value)
+	}
+}
+
+// Value implements the driver.Valuer interface for Guest_accounts
+func (m Guest_accounts) Value() (driver.Value, error) {
+	return json.Marshal(m)
+}
+
+// BelongsTo returns the foreign keys of guest_accounts, by column
+func (Guest_accounts) BelongsTo() map[string]ForeignKey {
+	return map[string]ForeignKey{
+		"user_id": {Table: "guest_accounts", Column: "user_id", RefTable: "users", RefColumn: "id", OnDelete: "CASCADE"},
+	}
+}
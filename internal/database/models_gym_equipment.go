@@ -0,0 +1,61 @@
+// Code generated by migration system on 2025-08-08 10:12:40
+// DO NOT EDIT THIS FILE MANUALLY
+
+package database
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Gym_equipment represents the gym_equipment table
+type Gym_equipment struct {
+	Id              string     `db:"id" json:"id"`                           // Primary key // Default: gen_random_uuid()
+	Organization_id string     `db:"organization_id" json:"organization_id"` // References organizations(id)
+	Name            string     `db:"name" json:"name"`
+	Archived_at     *time.Time `db:"archived_at" json:"archived_at"`
+	Created_at      time.Time  `db:"created_at" json:"created_at"` // Default: now()
+	Updated_at      time.Time  `db:"updated_at" json:"updated_at"` // Default: now()
+}
+
+// TableName returns the table name for Gym_equipment
+func (Gym_equipment) TableName() string {
+	return "gym_equipment"
+}
+
+// Scan implements the sql.Scanner interface for Gym_equipment
+func (m *Gym_equipment) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	default:
+		return fmt.Errorf("cannot scan %T into Gym_equipment", value)
+	}
+}
+
+// Value implements the driver.Valuer interface for Gym_equipment
+func (m Gym_equipment) Value() (driver.Value, error) {
+	return json.Marshal(m)
+}
+
+// BelongsTo returns the foreign keys of gym_equipment, by column
+func (Gym_equipment) BelongsTo() map[string]ForeignKey {
+	return map[string]ForeignKey{
+		"organization_id": {Table: "gym_equipment", Column: "organization_id", RefTable: "organizations", RefColumn: "id", OnDelete: "CASCADE"},
+	}
+}
+
+// HasMany returns the foreign keys referencing gym_equipment, by table and column
+func (Gym_equipment) HasMany() map[string]ForeignKey {
+	return map[string]ForeignKey{
+		"equipment_reservations.equipment_id": {Table: "equipment_reservations", Column: "equipment_id", RefTable: "gym_equipment", RefColumn: "id", OnDelete: "CASCADE"},
+	}
+}
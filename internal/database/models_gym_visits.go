@@ -0,0 +1,54 @@
+// Code generated by migration system on 2025-08-08 10:12:40
+// DO NOT EDIT THIS FILE MANUALLY
+
+package database
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Gym_visits represents the gym_visits table
+type Gym_visits struct {
+	Id              string     `db:"id" json:"id"`                           // Primary key // Default: gen_random_uuid()
+	Organization_id string     `db:"organization_id" json:"organization_id"` // References organizations(id)
+	User_id         string     `db:"user_id" json:"user_id"`                 // References users(id)
+	Checked_in_at   time.Time  `db:"checked_in_at" json:"checked_in_at"`     // Default: now()
+	Checked_out_at  *time.Time `db:"checked_out_at" json:"checked_out_at"`
+}
+
+// TableName returns the table name for Gym_visits
+func (Gym_visits) TableName() string {
+	return "gym_visits"
+}
+
+// Scan implements the sql.Scanner interface for Gym_visits
+func (m *Gym_visits) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	default:
+		return fmt.Errorf("cannot scan %T into Gym_visits", value)
+	}
+}
+
+// Value implements the driver.Valuer interface for Gym_visits
+func (m Gym_visits) Value() (driver.Value, error) {
+	return json.Marshal(m)
+}
+
+// BelongsTo returns the foreign keys of gym_visits, by column
+func (Gym_visits) BelongsTo() map[string]ForeignKey {
+	return map[string]ForeignKey{
+		"organization_id": {Table: "gym_visits", Column: "organization_id", RefTable: "organizations", RefColumn: "id", OnDelete: "CASCADE"},
+		"user_id":         {Table: "gym_visits", Column: "user_id", RefTable: "users", RefColumn: "id", OnDelete: "CASCADE"},
+	}
+}
@@ -0,0 +1,53 @@
+// Code generated by migration system on 2025-08-20 09:12:05
+// DO NOT EDIT THIS FILE MANUALLY
+
+package database
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Integration_events represents the integration_events table
+type Integration_events struct {
+	Id         string          `db:"id" json:"id"`           // Primary key
+	User_id    string          `db:"user_id" json:"user_id"` // References users(id)
+	Event      string          `db:"event" json:"event"`
+	Payload    json.RawMessage `db:"payload" json:"payload"`
+	Created_at time.Time       `db:"created_at" json:"created_at"` // Default: now()
+}
+
+// TableName returns the table name for Integration_events
+func (Integration_events) TableName() string {
+	return "integration_events"
+}
+
+// Scan implements the sql.Scanner interface for Integration_events
+func (m *Integration_events) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	default:
+		return fmt.Errorf("cannot scan %T into Integration_events", value)
+	}
+}
+
+// Value implements the driver.Valuer interface for Integration_events
+func (m Integration_events) Value() (driver.Value, error) {
+	return json.Marshal(m)
+}
+
+// BelongsTo returns the foreign keys of integration_events, by column
+func (Integration_events) BelongsTo() map[string]ForeignKey {
+	return map[string]ForeignKey{
+		"user_id": {Table: "integration_events", Column: "user_id", RefTable: "users", RefColumn: "id", OnDelete: "CASCADE"},
+	}
+}
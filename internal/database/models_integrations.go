@@ -0,0 +1,89 @@
+// Code generated by migration system on 2025-08-08 10:12:40
+// DO NOT EDIT THIS FILE MANUALLY
+
+package database
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Integrations_provider is a value of integrations.provider
+type Integrations_provider string
+
+const (
+	Integrations_provider_strava Integrations_provider = "strava"
+)
+
+// Integrations_providerValues lists the allowed values of integrations.provider
+var Integrations_providerValues = []Integrations_provider{Integrations_provider_strava}
+
+// Valid reports whether v is an allowed value of integrations.provider
+func (v Integrations_provider) Valid() bool {
+	for _, value := range Integrations_providerValues {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseIntegrations_provider returns s as a value of integrations.provider, or an error if it isn't an allowed one
+func ParseIntegrations_provider(s string) (Integrations_provider, error) {
+	v := Integrations_provider(s)
+	if !v.Valid() {
+		return "", fmt.Errorf("invalid integrations.provider %q", s)
+	}
+	return v, nil
+}
+
+// Integrations represents the integrations table
+type Integrations struct {
+	Id               string                `db:"id" json:"id"`           // Primary key // Default: gen_random_uuid()
+	User_id          string                `db:"user_id" json:"user_id"` // References users(id)
+	Provider         Integrations_provider `db:"provider" json:"provider"`
+	External_user_id string                `db:"external_user_id" json:"external_user_id"`
+	Access_token     string                `db:"access_token" json:"access_token"`
+	Refresh_token    string                `db:"refresh_token" json:"refresh_token"`
+	Expires_at       time.Time             `db:"expires_at" json:"expires_at"`
+	Scope            string                `db:"scope" json:"scope"`
+	Last_synced_at   *time.Time            `db:"last_synced_at" json:"last_synced_at"`
+	Last_error       string                `db:"last_error" json:"last_error"`
+	Created_at       time.Time             `db:"created_at" json:"created_at"` // Default: now()
+	Updated_at       time.Time             `db:"updated_at" json:"updated_at"` // Default: now()
+}
+
+// TableName returns the table name for Integrations
+func (Integrations) TableName() string {
+	return "integrations"
+}
+
+// Scan implements the sql.Scanner interface for Integrations
+func (m *Integrations) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	default:
+		return fmt.Errorf("cannot scan %T into Integrations", value)
+	}
+}
+
+// Value implements the driver.Valuer interface for Integrations
+func (m Integrations) Value() (driver.Value, error) {
+	return json.Marshal(m)
+}
+
+// BelongsTo returns the foreign keys of integrations, by column
+func (Integrations) BelongsTo() map[string]ForeignKey {
+	return map[string]ForeignKey{
+		"user_id": {Table: "integrations", Column: "user_id", RefTable: "users", RefColumn: "id", OnDelete: "CASCADE"},
+	}
+}
@@ -0,0 +1,56 @@
+// Code generated by migration system on 2025-08-15 09:20:11
+// DO NOT EDIT THIS FILE MANUALLY
+
+package database
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Kiosk_displays represents the kiosk_displays table
+type Kiosk_displays struct {
+	Id              string     `db:"id" json:"id"`                           // Primary key
+	Organization_id string     `db:"organization_id" json:"organization_id"` // References organizations(id)
+	Name            string     `db:"name" json:"name"`                       // Default: ''::text
+	Timezone        string     `db:"timezone" json:"timezone"`               // Default: 'UTC'::text
+	Prefix          string     `db:"prefix" json:"prefix"`
+	Key_hash        string     `db:"key_hash" json:"key_hash"` // Unique
+	Last_seen_at    *time.Time `db:"last_seen_at" json:"last_seen_at"`
+	Created_at      time.Time  `db:"created_at" json:"created_at"` // Default: now()
+}
+
+// TableName returns the table name for Kiosk_displays
+func (Kiosk_displays) TableName() string {
+	return "kiosk_displays"
+}
+
+// Scan implements the sql.Scanner interface for Kiosk_displays
+func (m *Kiosk_displays) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	default:
+		return fmt.Errorf("cannot scan %T into Kiosk_displays", value)
+	}
+}
+
+// Value implements the driver.Valuer interface for Kiosk_displays
+func (m Kiosk_displays) Value() (driver.Value, error) {
+	return json.Marshal(m)
+}
+
+// BelongsTo returns the foreign keys of kiosk_displays, by column
+func (Kiosk_displays) BelongsTo() map[string]ForeignKey {
+	return map[string]ForeignKey{
+		"organization_id": {Table: "kiosk_displays", Column: "organization_id", RefTable: "organizations", RefColumn: "id", OnDelete: "CASCADE"},
+	}
+}
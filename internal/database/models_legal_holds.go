@@ -0,0 +1,56 @@
+// Code generated by migration system on 2025-08-08 10:12:40
+// DO NOT EDIT THIS FILE MANUALLY
+
+package database
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Legal_holds represents the legal_holds table
+type Legal_holds struct {
+	Id              string     `db:"id" json:"id"`                           // Primary key // Default: gen_random_uuid()
+	Organization_id string     `db:"organization_id" json:"organization_id"` // References organizations(id)
+	User_id         *string    `db:"user_id" json:"user_id"`
+	Reason          string     `db:"reason" json:"reason"`
+	Created_by      string     `db:"created_by" json:"created_by"`
+	Created_at      time.Time  `db:"created_at" json:"created_at"` // Default: now()
+	Released_by     *string    `db:"released_by" json:"released_by"`
+	Released_at     *time.Time `db:"released_at" json:"released_at"`
+}
+
+// TableName returns the table name for Legal_holds
+func (Legal_holds) TableName() string {
+	return "legal_holds"
+}
+
+// Scan implements the sql.Scanner interface for Legal_holds
+func (m *Legal_holds) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	default:
+		return fmt.Errorf("cannot scan %T into Legal_holds", value)
+	}
+}
+
+// Value implements the driver.Valuer interface for Legal_holds
+func (m Legal_holds) Value() (driver.Value, error) {
+	return json.Marshal(m)
+}
+
+// BelongsTo returns the foreign keys of legal_holds, by column
+func (Legal_holds) BelongsTo() map[string]ForeignKey {
+	return map[string]ForeignKey{
+		"organization_id": {Table: "legal_holds", Column: "organization_id", RefTable: "organizations", RefColumn: "id", OnDelete: "RESTRICT"},
+	}
+}
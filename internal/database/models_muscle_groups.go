@@ -0,0 +1,52 @@
+// Code generated by migration system on 2025-08-16 10:05:42
+// DO NOT EDIT THIS FILE MANUALLY
+
+package database
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Muscle_groups represents the muscle_groups table
+type Muscle_groups struct {
+	Id         string    `db:"id" json:"id"`     // Primary key // Default: gen_random_uuid()
+	Slug       string    `db:"slug" json:"slug"` // Unique
+	Name       string    `db:"name" json:"name"`
+	Created_at time.Time `db:"created_at" json:"created_at"` // Default: now()
+}
+
+// TableName returns the table name for Muscle_groups
+func (Muscle_groups) TableName() string {
+	return "muscle_groups"
+}
+
+// Scan implements the sql.Scanner interface for Muscle_groups
+func (m *Muscle_groups) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	default:
+		return fmt.Errorf("cannot scan %T into Muscle_groups", value)
+	}
+}
+
+// Value implements the driver.Valuer interface for Muscle_groups
+func (m Muscle_groups) Value() (driver.Value, error) {
+	return json.Marshal(m)
+}
+
+// HasMany returns the foreign keys referencing muscle_groups, by table and column
+func (Muscle_groups) HasMany() map[string]ForeignKey {
+	return map[string]ForeignKey{
+		"exercise_muscle_groups.muscle_group_id": {Table: "exercise_muscle_groups", Column: "muscle_group_id", RefTable: "muscle_groups", RefColumn: "id", OnDelete: "CASCADE"},
+	}
+}
@@ -0,0 +1,58 @@
+// Code generated by migration system on 2025-08-25 09:12:48
+// DO NOT EDIT THIS FILE MANUALLY
+
+package database
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Notification_preferences represents the notification_preferences table
+type Notification_preferences struct {
+	User_id                 string     `db:"user_id" json:"user_id"`                         // References users(id)                         // Primary key
+	Workout_reminders       bool       `db:"workout_reminders" json:"workout_reminders"`     // Default: true
+	Reminder_after_days     int        `db:"reminder_after_days" json:"reminder_after_days"` // Default: 3
+	Personal_records        bool       `db:"personal_records" json:"personal_records"`       // Default: true
+	Last_reminded_at        *time.Time `db:"last_reminded_at" json:"last_reminded_at"`
+	Updated_at              time.Time  `db:"updated_at" json:"updated_at"` // Default: now()
+	Quiet_hours_start       *int       `db:"quiet_hours_start" json:"quiet_hours_start"`
+	Quiet_hours_end         *int       `db:"quiet_hours_end" json:"quiet_hours_end"`
+	Weekly_summary_email    bool       `db:"weekly_summary_email" json:"weekly_summary_email"` // Default: false
+	Weekly_summary_sent_for *time.Time `db:"weekly_summary_sent_for" json:"weekly_summary_sent_for"`
+}
+
+// TableName returns the table name for Notification_preferences
+func (Notification_preferences) TableName() string {
+	return "notification_preferences"
+}
+
+// Scan implements the sql.Scanner interface for Notification_preferences
+func (m *Notification_preferences) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	default:
+		return fmt.Errorf("cannot scan %T into Notification_preferences", value)
+	}
+}
+
+// Value implements the driver.Valuer interface for Notification_preferences
+func (m Notification_preferences) Value() (driver.Value, error) {
+	return json.Marshal(m)
+}
+
+// BelongsTo returns the foreign keys of notification_preferences, by column
+func (Notification_preferences) BelongsTo() map[string]ForeignKey {
+	return map[string]ForeignKey{
+		"user_id": {Table: "notification_preferences", Column: "user_id", RefTable: "users", RefColumn: "id", OnDelete: "CASCADE"},
+	}
+}
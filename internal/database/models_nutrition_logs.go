@@ -0,0 +1,98 @@
+// Code generated by migration system on 2025-08-21 10:04:52
+// DO NOT EDIT THIS FILE MANUALLY
+
+package database
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Nutrition_logs_meal is a value of nutrition_logs.meal
+type Nutrition_logs_meal string
+
+const (
+	Nutrition_logs_meal_breakfast Nutrition_logs_meal = "breakfast"
+	Nutrition_logs_meal_lunch     Nutrition_logs_meal = "lunch"
+	Nutrition_logs_meal_dinner    Nutrition_logs_meal = "dinner"
+	Nutrition_logs_meal_snack     Nutrition_logs_meal = "snack"
+)
+
+// Nutrition_logs_mealValues lists the allowed values of nutrition_logs.meal
+var Nutrition_logs_mealValues = []Nutrition_logs_meal{Nutrition_logs_meal_breakfast, Nutrition_logs_meal_lunch, Nutrition_logs_meal_dinner, Nutrition_logs_meal_snack}
+
+// Valid reports whether v is an allowed value of nutrition_logs.meal
+func (v Nutrition_logs_meal) Valid() bool {
+	for _, value := range Nutrition_logs_mealValues {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseNutrition_logs_meal returns s as a value of nutrition_logs.meal, or an error if it isn't an allowed one
+func ParseNutrition_logs_meal(s string) (Nutrition_logs_meal, error) {
+	v := Nutrition_logs_meal(s)
+	if !v.Valid() {
+		return "", fmt.Errorf("invalid nutrition_logs.meal %q", s)
+	}
+	return v, nil
+}
+
+// Nutrition_logs represents the nutrition_logs table
+type Nutrition_logs struct {
+	Id         string              `db:"id" json:"id"`           // Primary key // Default: gen_random_uuid()
+	User_id    string              `db:"user_id" json:"user_id"` // References users(id)
+	Food_id    *string             `db:"food_id" json:"food_id"` // References foods(id)
+	Name       string              `db:"name" json:"name"`
+	Meal       Nutrition_logs_meal `db:"meal" json:"meal"`             // Default: 'snack'::text
+	Servings   decimal.Decimal     `db:"servings" json:"servings"`     // Default: 1
+	Calories   decimal.Decimal     `db:"calories" json:"calories"`     // Default: 0
+	Protein_g  decimal.Decimal     `db:"protein_g" json:"protein_g"`   // Default: 0
+	Carbs_g    decimal.Decimal     `db:"carbs_g" json:"carbs_g"`       // Default: 0
+	Fat_g      decimal.Decimal     `db:"fat_g" json:"fat_g"`           // Default: 0
+	Eaten_at   time.Time           `db:"eaten_at" json:"eaten_at"`     // Default: now()
+	Notes      string              `db:"notes" json:"notes"`           // Default: ''::text
+	Created_at time.Time           `db:"created_at" json:"created_at"` // Default: now()
+	Updated_at time.Time           `db:"updated_at" json:"updated_at"` // Default: now()
+	Version    int                 `db:"version" json:"version"`       // Default: 1
+}
+
+// TableName returns the table name for Nutrition_logs
+func (Nutrition_logs) TableName() string {
+	return "nutrition_logs"
+}
+
+// Scan implements the sql.Scanner interface for Nutrition_logs
+func (m *Nutrition_logs) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	default:
+		return fmt.Errorf("cannot scan %T into Nutrition_logs", value)
+	}
+}
+
+// Value implements the driver.Valuer interface for Nutrition_logs
+func (m Nutrition_logs) Value() (driver.Value, error) {
+	return json.Marshal(m)
+}
+
+// BelongsTo returns the foreign keys of nutrition_logs, by column
+func (Nutrition_logs) BelongsTo() map[string]ForeignKey {
+	return map[string]ForeignKey{
+		"food_id": {Table: "nutrition_logs", Column: "food_id", RefTable: "foods", RefColumn: "id", OnDelete: "SET NULL"},
+		"user_id": {Table: "nutrition_logs", Column: "user_id", RefTable: "users", RefColumn: "id", OnDelete: "CASCADE"},
+	}
+}
@@ -0,0 +1,55 @@
+// Code generated by migration system on 2025-08-08 10:12:40
+// DO NOT EDIT THIS FILE MANUALLY
+
+package database
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Oauth_identities represents the oauth_identities table
+type Oauth_identities struct {
+	Id         string    `db:"id" json:"id"`                 // Primary key // Default: gen_random_uuid()
+	User_id    string    `db:"user_id" json:"user_id"`       // References users(id)
+	Provider   string    `db:"provider" json:"provider"`     // Unique
+	Subject    string    `db:"subject" json:"subject"`       // Unique
+	Email      string    `db:"email" json:"email"`           // Default: ''::text
+	Created_at time.Time `db:"created_at" json:"created_at"` // Default: now()
+	Updated_at time.Time `db:"updated_at" json:"updated_at"` // Default: now()
+}
+
+// TableName returns the table name for Oauth_identities
+func (Oauth_identities) TableName() string {
+	return "oauth_identities"
+}
+
+// Scan implements the sql.Scanner interface for Oauth_identities
+func (m *Oauth_identities) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	default:
+		return fmt.Errorf("cannot scan %T into Oauth_identities", value)
+	}
+}
+
+// Value implements the driver.Valuer interface for Oauth_identities
+func (m Oauth_identities) Value() (driver.Value, error) {
+	return json.Marshal(m)
+}
+
+// BelongsTo returns the foreign keys of oauth_identities, by column
+func (Oauth_identities) BelongsTo() map[string]ForeignKey {
+	return map[string]ForeignKey{
+		"user_id": {Table: "oauth_identities", Column: "user_id", RefTable: "users", RefColumn: "id", OnDelete: "CASCADE"},
+	}
+}
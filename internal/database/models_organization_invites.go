@@ -0,0 +1,93 @@
+// Code generated by migration system on 2025-08-08 10:12:40
+// DO NOT EDIT THIS FILE MANUALLY
+
+package database
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Organization_invites_role is a value of organization_invites.role
+type Organization_invites_role string
+
+const (
+	Organization_invites_role_admin  Organization_invites_role = "admin"
+	Organization_invites_role_member Organization_invites_role = "member"
+)
+
+// Organization_invites_roleValues lists the allowed values of organization_invites.role
+var Organization_invites_roleValues = []Organization_invites_role{Organization_invites_role_admin, Organization_invites_role_member}
+
+// Valid reports whether v is an allowed value of organization_invites.role
+func (v Organization_invites_role) Valid() bool {
+	for _, value := range Organization_invites_roleValues {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseOrganization_invites_role returns s as a value of organization_invites.role, or an error if it isn't an allowed one
+func ParseOrganization_invites_role(s string) (Organization_invites_role, error) {
+	v := Organization_invites_role(s)
+	if !v.Valid() {
+		return "", fmt.Errorf("invalid organization_invites.role %q", s)
+	}
+	return v, nil
+}
+
+// Organization_invites represents the organization_invites table
+type Organization_invites struct {
+	Id              string                    `db:"id" json:"id"`                           // Primary key
+	Organization_id string                    `db:"organization_id" json:"organization_id"` // References organizations(id)
+	Email           string                    `db:"email" json:"email"`
+	Role            Organization_invites_role `db:"role" json:"role"`                 // Default: 'member'::text
+	Token_hash      string                    `db:"token_hash" json:"token_hash"`     // Unique
+	Invited_by      *string                   `db:"invited_by" json:"invited_by"`     // References users(id)
+	Send_count      int                       `db:"send_count" json:"send_count"`     // Default: 1
+	Last_sent_at    time.Time                 `db:"last_sent_at" json:"last_sent_at"` // Default: now()
+	Expires_at      time.Time                 `db:"expires_at" json:"expires_at"`
+	Accepted_at     *time.Time                `db:"accepted_at" json:"accepted_at"`
+	Accepted_by     *string                   `db:"accepted_by" json:"accepted_by"` // References users(id)
+	Revoked_at      *time.Time                `db:"revoked_at" json:"revoked_at"`
+	Created_at      time.Time                 `db:"created_at" json:"created_at"` // Default: now()
+}
+
+// TableName returns the table name for Organization_invites
+func (Organization_invites) TableName() string {
+	return "organization_invites"
+}
+
+// Scan implements the sql.Scanner interface for Organization_invites
+func (m *Organization_invites) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	default:
+		return fmt.Errorf("cannot scan %T into Organization_invites", value)
+	}
+}
+
+// Value implements the driver.Valuer interface for Organization_invites
+func (m Organization_invites) Value() (driver.Value, error) {
+	return json.Marshal(m)
+}
+
+// BelongsTo returns the foreign keys of organization_invites, by column
+func (Organization_invites) BelongsTo() map[string]ForeignKey {
+	return map[string]ForeignKey{
+		"accepted_by":     {Table: "organization_invites", Column: "accepted_by", RefTable: "users", RefColumn: "id", OnDelete: "SET NULL"},
+		"invited_by":      {Table: "organization_invites", Column: "invited_by", RefTable: "users", RefColumn: "id", OnDelete: "SET NULL"},
+		"organization_id": {Table: "organization_invites", Column: "organization_id", RefTable: "organizations", RefColumn: "id", OnDelete: "CASCADE"},
+	}
+}
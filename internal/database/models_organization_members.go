@@ -0,0 +1,89 @@
+// Code generated by migration system on 2025-08-08 10:12:40
+// DO NOT EDIT THIS FILE MANUALLY
+
+package database
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Organization_members_role is a value of organization_members.role
+type Organization_members_role string
+
+const (
+	Organization_members_role_owner  Organization_members_role = "owner"
+	Organization_members_role_admin  Organization_members_role = "admin"
+	Organization_members_role_member Organization_members_role = "member"
+)
+
+// Organization_members_roleValues lists the allowed values of organization_members.role
+var Organization_members_roleValues = []Organization_members_role{Organization_members_role_owner, Organization_members_role_admin, Organization_members_role_member}
+
+// Valid reports whether v is an allowed value of organization_members.role
+func (v Organization_members_role) Valid() bool {
+	for _, value := range Organization_members_roleValues {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseOrganization_members_role returns s as a value of organization_members.role, or an error if it isn't an allowed one
+func ParseOrganization_members_role(s string) (Organization_members_role, error) {
+	v := Organization_members_role(s)
+	if !v.Valid() {
+		return "", fmt.Errorf("invalid organization_members.role %q", s)
+	}
+	return v, nil
+}
+
+// Organization_members represents the organization_members table
+type Organization_members struct {
+	Organization_id     string                    `db:"organization_id" json:"organization_id"`         // Primary key // References organizations(id)
+	User_id             string                    `db:"user_id" json:"user_id"`                         // References users(id)                 // Primary key
+	Role                Organization_members_role `db:"role" json:"role"`                               // Default: 'member'::text
+	Created_at          time.Time                 `db:"created_at" json:"created_at"`                   // Default: now()
+	Active              bool                      `db:"active" json:"active"`                           // Default: true
+	External_id         string                    `db:"external_id" json:"external_id"`                 // Default: ''::text
+	Updated_at          time.Time                 `db:"updated_at" json:"updated_at"`                   // Default: now()
+	Show_on_leaderboard bool                      `db:"show_on_leaderboard" json:"show_on_leaderboard"` // Default: false
+	Scim_created        bool                      `db:"scim_created" json:"scim_created"`               // Default: false
+}
+
+// TableName returns the table name for Organization_members
+func (Organization_members) TableName() string {
+	return "organization_members"
+}
+
+// Scan implements the sql.Scanner interface for Organization_members
+func (m *Organization_members) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	default:
+		return fmt.Errorf("cannot scan %T into Organization_members", value)
+	}
+}
+
+// Value implements the driver.Valuer interface for Organization_members
+func (m Organization_members) Value() (driver.Value, error) {
+	return json.Marshal(m)
+}
+
+// BelongsTo returns the foreign keys of organization_members, by column
+func (Organization_members) BelongsTo() map[string]ForeignKey {
+	return map[string]ForeignKey{
+		"organization_id": {Table: "organization_members", Column: "organization_id", RefTable: "organizations", RefColumn: "id", OnDelete: "CASCADE"},
+		"user_id":         {Table: "organization_members", Column: "user_id", RefTable: "users", RefColumn: "id", OnDelete: "CASCADE"},
+	}
+}
@@ -0,0 +1,52 @@
+// Code generated by migration system on 2025-08-08 10:12:40
+// DO NOT EDIT THIS FILE MANUALLY
+
+package database
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Organization_scim_tokens represents the organization_scim_tokens table
+type Organization_scim_tokens struct {
+	Organization_id string     `db:"organization_id" json:"organization_id"` // Primary key // References organizations(id)
+	Token_hash      string     `db:"token_hash" json:"token_hash"`           // Unique
+	Last_used_at    *time.Time `db:"last_used_at" json:"last_used_at"`
+	Created_at      time.Time  `db:"created_at" json:"created_at"` // Default: now()
+}
+
+// TableName returns the table name for Organization_scim_tokens
+func (Organization_scim_tokens) TableName() string {
+	return "organization_scim_tokens"
+}
+
+// Scan implements the sql.Scanner interface for Organization_scim_tokens
+func (m *Organization_scim_tokens) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	default:
+		return fmt.Errorf("cannot scan %T into Organization_scim_tokens", value)
+	}
+}
+
+// Value implements the driver.Valuer interface for Organization_scim_tokens
+func (m Organization_scim_tokens) Value() (driver.Value, error) {
+	return json.Marshal(m)
+}
+
+// BelongsTo returns the foreign keys of organization_scim_tokens, by column
+func (Organization_scim_tokens) BelongsTo() map[string]ForeignKey {
+	return map[string]ForeignKey{
+		"organization_id": {Table: "organization_scim_tokens", Column: "organization_id", RefTable: "organizations", RefColumn: "id", OnDelete: "CASCADE"},
+	}
+}
@@ -0,0 +1,89 @@
+// Code generated by migration system on 2025-08-08 10:12:40
+// DO NOT EDIT THIS FILE MANUALLY
+
+package database
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Organization_sso_protocol is a value of organization_sso.protocol
+type Organization_sso_protocol string
+
+const (
+	Organization_sso_protocol_oidc Organization_sso_protocol = "oidc"
+)
+
+// Organization_sso_protocolValues lists the allowed values of organization_sso.protocol
+var Organization_sso_protocolValues = []Organization_sso_protocol{Organization_sso_protocol_oidc}
+
+// Valid reports whether v is an allowed value of organization_sso.protocol
+func (v Organization_sso_protocol) Valid() bool {
+	for _, value := range Organization_sso_protocolValues {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseOrganization_sso_protocol returns s as a value of organization_sso.protocol, or an error if it isn't an allowed one
+func ParseOrganization_sso_protocol(s string) (Organization_sso_protocol, error) {
+	v := Organization_sso_protocol(s)
+	if !v.Valid() {
+		return "", fmt.Errorf("invalid organization_sso.protocol %q", s)
+	}
+	return v, nil
+}
+
+// Organization_sso represents the organization_sso table
+type Organization_sso struct {
+	Organization_id        string                    `db:"organization_id" json:"organization_id"` // Primary key // References organizations(id)
+	Protocol               Organization_sso_protocol `db:"protocol" json:"protocol"`               // Default: 'oidc'::text
+	Issuer                 string                    `db:"issuer" json:"issuer"`
+	Client_id              string                    `db:"client_id" json:"client_id"`
+	Authorization_endpoint string                    `db:"authorization_endpoint" json:"authorization_endpoint"` // Default: ''::text
+	Jwks_url               string                    `db:"jwks_url" json:"jwks_url"`
+	Role_claim             string                    `db:"role_claim" json:"role_claim"`               // Default: ''::text
+	Admin_role_values      string                    `db:"admin_role_values" json:"admin_role_values"` // Default: ''::text
+	Jit_provisioning       bool                      `db:"jit_provisioning" json:"jit_provisioning"`   // Default: true
+	Enabled                bool                      `db:"enabled" json:"enabled"`                     // Default: true
+	Created_at             time.Time                 `db:"created_at" json:"created_at"`               // Default: now()
+	Updated_at             time.Time                 `db:"updated_at" json:"updated_at"`               // Default: now()
+}
+
+// TableName returns the table name for Organization_sso
+func (Organization_sso) TableName() string {
+	return "organization_sso"
+}
+
+// Scan implements the sql.Scanner interface for Organization_sso
+func (m *Organization_sso) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	default:
+		return fmt.Errorf("cannot scan %T into Organization_sso", value)
+	}
+}
+
+// Value implements the driver.Valuer interface for Organization_sso
+func (m Organization_sso) Value() (driver.Value, error) {
+	return json.Marshal(m)
+}
+
+// BelongsTo returns the foreign keys of organization_sso, by column
+func (Organization_sso) BelongsTo() map[string]ForeignKey {
+	return map[string]ForeignKey{
+		"organization_id": {Table: "organization_sso", Column: "organization_id", RefTable: "organizations", RefColumn: "id", OnDelete: "CASCADE"},
+	}
+}
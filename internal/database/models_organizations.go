@@ -0,0 +1,68 @@
+// Code generated by migration system on 2025-08-08 10:12:40
+// DO NOT EDIT THIS FILE MANUALLY
+
+package database
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Organizations represents the organizations table
+type Organizations struct {
+	Id         string    `db:"id" json:"id"` // Primary key
+	Name       string    `db:"name" json:"name"`
+	Created_by *string   `db:"created_by" json:"created_by"` // References users(id)
+	Created_at time.Time `db:"created_at" json:"created_at"` // Default: now()
+	Updated_at time.Time `db:"updated_at" json:"updated_at"` // Default: now()
+}
+
+// TableName returns the table name for Organizations
+func (Organizations) TableName() string {
+	return "organizations"
+}
+
+// Scan implements the sql.Scanner interface for Organizations
+func (m *Organizations) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	default:
+		return fmt.Errorf("cannot scan %T into Organizations", value)
+	}
+}
+
+// Value implements the driver.Valuer interface for Organizations
+func (m Organizations) Value() (driver.Value, error) {
+	return json.Marshal(m)
+}
+
+// BelongsTo returns the foreign keys of organizations, by column
+func (Organizations) BelongsTo() map[string]ForeignKey {
+	return map[string]ForeignKey{
+		"created_by": {Table: "organizations", Column: "created_by", RefTable: "users", RefColumn: "id", OnDelete: "SET NULL"},
+	}
+}
+
+// HasMany returns the foreign keys referencing organizations, by table and column
+func (Organizations) HasMany() map[string]ForeignKey {
+	return map[string]ForeignKey{
+		"community_integrations.organization_id":   {Table: "community_integrations", Column: "organization_id", RefTable: "organizations", RefColumn: "id", OnDelete: "CASCADE"},
+		"gym_equipment.organization_id":            {Table: "gym_equipment", Column: "organization_id", RefTable: "organizations", RefColumn: "id", OnDelete: "CASCADE"},
+		"gym_visits.organization_id":               {Table: "gym_visits", Column: "organization_id", RefTable: "organizations", RefColumn: "id", OnDelete: "CASCADE"},
+		"kiosk_displays.organization_id":           {Table: "kiosk_displays", Column: "organization_id", RefTable: "organizations", RefColumn: "id", OnDelete: "CASCADE"},
+		"legal_holds.organization_id":              {Table: "legal_holds", Column: "organization_id", RefTable: "organizations", RefColumn: "id", OnDelete: "RESTRICT"},
+		"organization_invites.organization_id":     {Table: "organization_invites", Column: "organization_id", RefTable: "organizations", RefColumn: "id", OnDelete: "CASCADE"},
+		"organization_members.organization_id":     {Table: "organization_members", Column: "organization_id", RefTable: "organizations", RefColumn: "id", OnDelete: "CASCADE"},
+		"organization_scim_tokens.organization_id": {Table: "organization_scim_tokens", Column: "organization_id", RefTable: "organizations", RefColumn: "id", OnDelete: "CASCADE"},
+		"organization_sso.organization_id":         {Table: "organization_sso", Column: "organization_id", RefTable: "organizations", RefColumn: "id", OnDelete: "CASCADE"},
+	}
+}
@@ -0,0 +1,55 @@
+// Code generated by migration system on 2025-08-08 10:12:40
+// DO NOT EDIT THIS FILE MANUALLY
+
+package database
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Photo_vaults represents the photo_vaults table
+type Photo_vaults struct {
+	User_id         string     `db:"user_id" json:"user_id"` // Primary key // References users(id)
+	Pin_salt        []byte     `db:"pin_salt" json:"pin_salt"`
+	Wrapped_key     []byte     `db:"wrapped_key" json:"wrapped_key"`
+	Failed_attempts int        `db:"failed_attempts" json:"failed_attempts"` // Default: 0
+	Locked_until    *time.Time `db:"locked_until" json:"locked_until"`
+	Created_at      time.Time  `db:"created_at" json:"created_at"` // Default: now()
+	Updated_at      time.Time  `db:"updated_at" json:"updated_at"` // Default: now()
+}
+
+// TableName returns the table name for Photo_vaults
+func (Photo_vaults) TableName() string {
+	return "photo_vaults"
+}
+
+// Scan implements the sql.Scanner interface for Photo_vaults
+func (m *Photo_vaults) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	default:
+		return fmt.Errorf("cannot scan %T into Photo_vaults", value)
+	}
+}
+
+// Value implements the driver.Valuer interface for Photo_vaults
+func (m Photo_vaults) Value() (driver.Value, error) {
+	return json.Marshal(m)
+}
+
+// BelongsTo returns the foreign keys of photo_vaults, by column
+func (Photo_vaults) BelongsTo() map[string]ForeignKey {
+	return map[string]ForeignKey{
+		"user_id": {Table: "photo_vaults", Column: "user_id", RefTable: "users", RefColumn: "id", OnDelete: "CASCADE"},
+	}
+}
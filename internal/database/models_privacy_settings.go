@@ -0,0 +1,54 @@
+// Code generated by migration system on 2025-08-28 09:02:37
+// DO NOT EDIT THIS FILE MANUALLY
+
+package database
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Privacy_settings represents the privacy_settings table
+type Privacy_settings struct {
+	User_id                string    `db:"user_id" json:"user_id"`                               // Primary key // References users(id)
+	Allow_followers        bool      `db:"allow_followers" json:"allow_followers"`               // Default: true
+	Share_sessions         bool      `db:"share_sessions" json:"share_sessions"`                 // Default: true
+	Share_personal_records bool      `db:"share_personal_records" json:"share_personal_records"` // Default: true
+	Updated_at             time.Time `db:"updated_at" json:"updated_at"`                         // Default: now()
+	Show_on_leaderboards   bool      `db:"show_on_leaderboards" json:"show_on_leaderboards"`     // Default: false
+}
+
+// TableName returns the table name for Privacy_settings
+func (Privacy_settings) TableName() string {
+	return "privacy_settings"
+}
+
+// Scan implements the sql.Scanner interface for Privacy_settings
+func (m *Privacy_settings) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	default:
+		return fmt.Errorf("cannot scan %T into Privacy_settings", value)
+	}
+}
+
+// Value implements the driver.Valuer interface for Privacy_settings
+func (m Privacy_settings) Value() (driver.Value, error) {
+	return json.Marshal(m)
+}
+
+// BelongsTo returns the foreign keys of privacy_settings, by column
+func (Privacy_settings) BelongsTo() map[string]ForeignKey {
+	return map[string]ForeignKey{
+		"user_id": {Table: "privacy_settings", Column: "user_id", RefTable: "users", RefColumn: "id", OnDelete: "CASCADE"},
+	}
+}
@@ -0,0 +1,127 @@
+// Code generated by migration system on 2025-08-12 08:30:05
+// DO NOT EDIT THIS FILE MANUALLY
+
+package database
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Program_adjustments_kind is a value of program_adjustments.kind
+type Program_adjustments_kind string
+
+const (
+	Program_adjustments_kind_add_volume    Program_adjustments_kind = "add_volume"
+	Program_adjustments_kind_remove_volume Program_adjustments_kind = "remove_volume"
+	Program_adjustments_kind_extend_deload Program_adjustments_kind = "extend_deload"
+)
+
+// Program_adjustments_kindValues lists the allowed values of program_adjustments.kind
+var Program_adjustments_kindValues = []Program_adjustments_kind{Program_adjustments_kind_add_volume, Program_adjustments_kind_remove_volume, Program_adjustments_kind_extend_deload}
+
+// Valid reports whether v is an allowed value of program_adjustments.kind
+func (v Program_adjustments_kind) Valid() bool {
+	for _, value := range Program_adjustments_kindValues {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseProgram_adjustments_kind returns s as a value of program_adjustments.kind, or an error if it isn't an allowed one
+func ParseProgram_adjustments_kind(s string) (Program_adjustments_kind, error) {
+	v := Program_adjustments_kind(s)
+	if !v.Valid() {
+		return "", fmt.Errorf("invalid program_adjustments.kind %q", s)
+	}
+	return v, nil
+}
+
+// Program_adjustments_status is a value of program_adjustments.status
+type Program_adjustments_status string
+
+const (
+	Program_adjustments_status_pending  Program_adjustments_status = "pending"
+	Program_adjustments_status_applied  Program_adjustments_status = "applied"
+	Program_adjustments_status_rejected Program_adjustments_status = "rejected"
+)
+
+// Program_adjustments_statusValues lists the allowed values of program_adjustments.status
+var Program_adjustments_statusValues = []Program_adjustments_status{Program_adjustments_status_pending, Program_adjustments_status_applied, Program_adjustments_status_rejected}
+
+// Valid reports whether v is an allowed value of program_adjustments.status
+func (v Program_adjustments_status) Valid() bool {
+	for _, value := range Program_adjustments_statusValues {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseProgram_adjustments_status returns s as a value of program_adjustments.status, or an error if it isn't an allowed one
+func ParseProgram_adjustments_status(s string) (Program_adjustments_status, error) {
+	v := Program_adjustments_status(s)
+	if !v.Valid() {
+		return "", fmt.Errorf("invalid program_adjustments.status %q", s)
+	}
+	return v, nil
+}
+
+// Program_adjustments represents the program_adjustments table
+type Program_adjustments struct {
+	Id             string                     `db:"id" json:"id"`                 // Primary key // Default: gen_random_uuid()
+	Program_id     string                     `db:"program_id" json:"program_id"` // Unique // References programs(id)
+	User_id        string                     `db:"user_id" json:"user_id"`       // References users(id)
+	Week           int                        `db:"week" json:"week"`             // Unique
+	Kind           Program_adjustments_kind   `db:"kind" json:"kind"`
+	Volume_percent int                        `db:"volume_percent" json:"volume_percent"`
+	Deload         bool                       `db:"deload" json:"deload"` // Default: false
+	Reason         string                     `db:"reason" json:"reason"`
+	Metrics        json.RawMessage            `db:"metrics" json:"metrics"`         // Default: '{}'::jsonb
+	Status         Program_adjustments_status `db:"status" json:"status"`           // Default: 'pending'::text
+	Reviewed_by    *string                    `db:"reviewed_by" json:"reviewed_by"` // References users(id)
+	Reviewed_at    *time.Time                 `db:"reviewed_at" json:"reviewed_at"`
+	Review_note    string                     `db:"review_note" json:"review_note"` // Default: ''::text
+	Applied_at     *time.Time                 `db:"applied_at" json:"applied_at"`
+	Created_at     time.Time                  `db:"created_at" json:"created_at"` // Default: now()
+}
+
+// TableName returns the table name for Program_adjustments
+func (Program_adjustments) TableName() string {
+	return "program_adjustments"
+}
+
+// Scan implements the sql.Scanner interface for Program_adjustments
+func (m *Program_adjustments) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	default:
+		return fmt.Errorf("cannot scan %T into Program_adjustments", value)
+	}
+}
+
+// Value implements the driver.Valuer interface for Program_adjustments
+func (m Program_adjustments) Value() (driver.Value, error) {
+	return json.Marshal(m)
+}
+
+// BelongsTo returns the foreign keys of program_adjustments, by column
+func (Program_adjustments) BelongsTo() map[string]ForeignKey {
+	return map[string]ForeignKey{
+		"program_id":  {Table: "program_adjustments", Column: "program_id", RefTable: "programs", RefColumn: "id", OnDelete: "CASCADE"},
+		"reviewed_by": {Table: "program_adjustments", Column: "reviewed_by", RefTable: "users", RefColumn: "id", OnDelete: "SET NULL"},
+		"user_id":     {Table: "program_adjustments", Column: "user_id", RefTable: "users", RefColumn: "id", OnDelete: "CASCADE"},
+	}
+}
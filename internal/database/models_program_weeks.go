@@ -0,0 +1,53 @@
+// Code generated by migration system on 2025-08-12 08:30:05
+// DO NOT EDIT THIS FILE MANUALLY
+
+package database
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Program_weeks represents the program_weeks table
+type Program_weeks struct {
+	Program_id     string    `db:"program_id" json:"program_id"`         // References programs(id)         // Primary key
+	Week           int       `db:"week" json:"week"`                     // Primary key
+	Volume_percent int       `db:"volume_percent" json:"volume_percent"` // Default: 100
+	Deload         bool      `db:"deload" json:"deload"`                 // Default: false
+	Updated_at     time.Time `db:"updated_at" json:"updated_at"`         // Default: now()
+}
+
+// TableName returns the table name for Program_weeks
+func (Program_weeks) TableName() string {
+	return "program_weeks"
+}
+
+// Scan implements the sql.Scanner interface for Program_weeks
+func (m *Program_weeks) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	default:
+		return fmt.Errorf("cannot scan %T into Program_weeks", value)
+	}
+}
+
+// Value implements the driver.Valuer interface for Program_weeks
+func (m Program_weeks) Value() (driver.Value, error) {
+	return json.Marshal(m)
+}
+
+// BelongsTo returns the foreign keys of program_weeks, by column
+func (Program_weeks) BelongsTo() map[string]ForeignKey {
+	return map[string]ForeignKey{
+		"program_id": {Table: "program_weeks", Column: "program_id", RefTable: "programs", RefColumn: "id", OnDelete: "CASCADE"},
+	}
+}
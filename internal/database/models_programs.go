@@ -0,0 +1,102 @@
+// Code generated by migration system on 2025-08-08 10:12:40
+// DO NOT EDIT THIS FILE MANUALLY
+
+package database
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Programs_difficulty is a value of programs.difficulty
+type Programs_difficulty string
+
+const (
+	Programs_difficulty_beginner     Programs_difficulty = "beginner"
+	Programs_difficulty_intermediate Programs_difficulty = "intermediate"
+	Programs_difficulty_advanced     Programs_difficulty = "advanced"
+)
+
+// Programs_difficultyValues lists the allowed values of programs.difficulty
+var Programs_difficultyValues = []Programs_difficulty{Programs_difficulty_beginner, Programs_difficulty_intermediate, Programs_difficulty_advanced}
+
+// Valid reports whether v is an allowed value of programs.difficulty
+func (v Programs_difficulty) Valid() bool {
+	for _, value := range Programs_difficultyValues {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// ParsePrograms_difficulty returns s as a value of programs.difficulty, or an error if it isn't an allowed one
+func ParsePrograms_difficulty(s string) (Programs_difficulty, error) {
+	v := Programs_difficulty(s)
+	if !v.Valid() {
+		return "", fmt.Errorf("invalid programs.difficulty %q", s)
+	}
+	return v, nil
+}
+
+// Programs represents the programs table
+type Programs struct {
+	Id                 string               `db:"id" json:"id"` // Primary key // Default: gen_random_uuid()
+	Name               string               `db:"name" json:"name"`
+	Description        string               `db:"description" json:"description"`
+	User_id            string               `db:"user_id" json:"user_id"`
+	Duration_weeks     int                  `db:"duration_weeks" json:"duration_weeks"`
+	Difficulty         *Programs_difficulty `db:"difficulty" json:"difficulty"`
+	Is_active          bool                 `db:"is_active" json:"is_active"`   // Default: true
+	Created_at         time.Time            `db:"created_at" json:"created_at"` // Default: now()
+	Updated_at         time.Time            `db:"updated_at" json:"updated_at"` // Default: now()
+	Version            int                  `db:"version" json:"version"`       // Default: 1
+	Started_at         time.Time            `db:"started_at" json:"started_at"` // Default: now()
+	Deload_every_weeks *int                 `db:"deload_every_weeks" json:"deload_every_weeks"`
+	Coach_id           *string              `db:"coach_id" json:"coach_id"`           // References users(id)
+	Adjusted_week      int                  `db:"adjusted_week" json:"adjusted_week"` // Default: 0
+}
+
+// TableName returns the table name for Programs
+func (Programs) TableName() string {
+	return "programs"
+}
+
+// Scan implements the sql.Scanner interface for Programs
+func (m *Programs) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	default:
+		return fmt.Errorf("cannot scan %T into Programs", value)
+	}
+}
+
+// Value implements the driver.Valuer interface for Programs
+func (m Programs) Value() (driver.Value, error) {
+	return json.Marshal(m)
+}
+
+// BelongsTo returns the foreign keys of programs, by column
+func (Programs) BelongsTo() map[string]ForeignKey {
+	return map[string]ForeignKey{
+		"coach_id": {Table: "programs", Column: "coach_id", RefTable: "users", RefColumn: "id", OnDelete: "SET NULL"},
+	}
+}
+
+// HasMany returns the foreign keys referencing programs, by table and column
+func (Programs) HasMany() map[string]ForeignKey {
+	return map[string]ForeignKey{
+		"program_adjustments.program_id": {Table: "program_adjustments", Column: "program_id", RefTable: "programs", RefColumn: "id", OnDelete: "CASCADE"},
+		"program_weeks.program_id":       {Table: "program_weeks", Column: "program_id", RefTable: "programs", RefColumn: "id", OnDelete: "CASCADE"},
+		"workouts.program_id":            {Table: "workouts", Column: "program_id", RefTable: "programs", RefColumn: "id", OnDelete: "SET NULL"},
+	}
+}
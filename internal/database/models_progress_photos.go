@@ -0,0 +1,127 @@
+// Code generated by migration system on 2025-08-19 08:55:02
+// DO NOT EDIT THIS FILE MANUALLY
+
+package database
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Progress_photos_pose is a value of progress_photos.pose
+type Progress_photos_pose string
+
+const (
+	Progress_photos_pose_front Progress_photos_pose = "front"
+	Progress_photos_pose_side  Progress_photos_pose = "side"
+	Progress_photos_pose_back  Progress_photos_pose = "back"
+	Progress_photos_pose_other Progress_photos_pose = "other"
+)
+
+// Progress_photos_poseValues lists the allowed values of progress_photos.pose
+var Progress_photos_poseValues = []Progress_photos_pose{Progress_photos_pose_front, Progress_photos_pose_side, Progress_photos_pose_back, Progress_photos_pose_other}
+
+// Valid reports whether v is an allowed value of progress_photos.pose
+func (v Progress_photos_pose) Valid() bool {
+	for _, value := range Progress_photos_poseValues {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseProgress_photos_pose returns s as a value of progress_photos.pose, or an error if it isn't an allowed one
+func ParseProgress_photos_pose(s string) (Progress_photos_pose, error) {
+	v := Progress_photos_pose(s)
+	if !v.Valid() {
+		return "", fmt.Errorf("invalid progress_photos.pose %q", s)
+	}
+	return v, nil
+}
+
+// Progress_photos_status is a value of progress_photos.status
+type Progress_photos_status string
+
+const (
+	Progress_photos_status_pending Progress_photos_status = "pending"
+	Progress_photos_status_ready   Progress_photos_status = "ready"
+)
+
+// Progress_photos_statusValues lists the allowed values of progress_photos.status
+var Progress_photos_statusValues = []Progress_photos_status{Progress_photos_status_pending, Progress_photos_status_ready}
+
+// Valid reports whether v is an allowed value of progress_photos.status
+func (v Progress_photos_status) Valid() bool {
+	for _, value := range Progress_photos_statusValues {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseProgress_photos_status returns s as a value of progress_photos.status, or an error if it isn't an allowed one
+func ParseProgress_photos_status(s string) (Progress_photos_status, error) {
+	v := Progress_photos_status(s)
+	if !v.Valid() {
+		return "", fmt.Errorf("invalid progress_photos.status %q", s)
+	}
+	return v, nil
+}
+
+// Progress_photos represents the progress_photos table
+type Progress_photos struct {
+	Id               string                 `db:"id" json:"id"`           // Primary key // Default: gen_random_uuid()
+	User_id          string                 `db:"user_id" json:"user_id"` // References users(id)
+	Pose             Progress_photos_pose   `db:"pose" json:"pose"`       // Default: 'front'::text
+	Taken_at         time.Time              `db:"taken_at" json:"taken_at"`
+	Notes            string                 `db:"notes" json:"notes"` // Default: ''::text
+	Content_type     string                 `db:"content_type" json:"content_type"`
+	Size_bytes       int64                  `db:"size_bytes" json:"size_bytes"`             // Default: 0
+	Storage_key      string                 `db:"storage_key" json:"storage_key"`           // Default: ''::text
+	Thumbnail_key    string                 `db:"thumbnail_key" json:"thumbnail_key"`       // Default: ''::text
+	Vaulted          bool                   `db:"vaulted" json:"vaulted"`                   // Default: false
+	Created_at       time.Time              `db:"created_at" json:"created_at"`             // Default: now()
+	Updated_at       time.Time              `db:"updated_at" json:"updated_at"`             // Default: now()
+	Status           Progress_photos_status `db:"status" json:"status"`                     // Default: 'ready'::text
+	Width            int                    `db:"width" json:"width"`                       // Default: 0
+	Height           int                    `db:"height" json:"height"`                     // Default: 0
+	Thumbnail_width  int                    `db:"thumbnail_width" json:"thumbnail_width"`   // Default: 0
+	Thumbnail_height int                    `db:"thumbnail_height" json:"thumbnail_height"` // Default: 0
+}
+
+// TableName returns the table name for Progress_photos
+func (Progress_photos) TableName() string {
+	return "progress_photos"
+}
+
+// Scan implements the sql.Scanner interface for Progress_photos
+func (m *Progress_photos) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	default:
+		return fmt.Errorf("cannot scan %T into Progress_photos", value)
+	}
+}
+
+// Value implements the driver.Valuer interface for Progress_photos
+func (m Progress_photos) Value() (driver.Value, error) {
+	return json.Marshal(m)
+}
+
+// BelongsTo returns the foreign keys of progress_photos, by column
+func (Progress_photos) BelongsTo() map[string]ForeignKey {
+	return map[string]ForeignKey{
+		"user_id": {Table: "progress_photos", Column: "user_id", RefTable: "users", RefColumn: "id", OnDelete: "CASCADE"},
+	}
+}
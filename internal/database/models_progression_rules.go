@@ -0,0 +1,69 @@
+// Code generated by migration system on 2025-08-30 09:15:48
+// DO NOT EDIT THIS FILE MANUALLY
+
+package database
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Progression_rules represents the progression_rules table
+type Progression_rules struct {
+	Id                    string          `db:"id" json:"id"`                                   // Primary key // Default: gen_random_uuid()
+	Workout_exercise_id   string          `db:"workout_exercise_id" json:"workout_exercise_id"` // Unique // References workout_exercises(id)
+	User_id               string          `db:"user_id" json:"user_id"`                         // References users(id)
+	Weight_increment_kg   decimal.Decimal `db:"weight_increment_kg" json:"weight_increment_kg"` // Default: 2.5
+	Min_reps              *int            `db:"min_reps" json:"min_reps"`
+	Max_reps              *int            `db:"max_reps" json:"max_reps"`
+	Deload_after_failures int             `db:"deload_after_failures" json:"deload_after_failures"` // Default: 3
+	Deload_percent        int             `db:"deload_percent" json:"deload_percent"`               // Default: 10
+	Failures              int             `db:"failures" json:"failures"`                           // Default: 0
+	Created_at            time.Time       `db:"created_at" json:"created_at"`                       // Default: now()
+	Updated_at            time.Time       `db:"updated_at" json:"updated_at"`                       // Default: now()
+}
+
+// TableName returns the table name for Progression_rules
+func (Progression_rules) TableName() string {
+	return "progression_rules"
+}
+
+// Scan implements the sql.Scanner interface for Progression_rules
+func (m *Progression_rules) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	default:
+		return fmt.Errorf("cannot scan %T into Progression_rules", value)
+	}
+}
+
+// Value implements the driver.Valuer interface for Progression_rules
+func (m Progression_rules) Value() (driver.Value, error) {
+	return json.Marshal(m)
+}
+
+// BelongsTo returns the foreign keys of progression_rules, by column
+func (Progression_rules) BelongsTo() map[string]ForeignKey {
+	return map[string]ForeignKey{
+		"user_id":             {Table: "progression_rules", Column: "user_id", RefTable: "users", RefColumn: "id", OnDelete: "CASCADE"},
+		"workout_exercise_id": {Table: "progression_rules", Column: "workout_exercise_id", RefTable: "workout_exercises", RefColumn: "id", OnDelete: "CASCADE"},
+	}
+}
+
+// HasMany returns the foreign keys referencing progression_rules, by table and column
+func (Progression_rules) HasMany() map[string]ForeignKey {
+	return map[string]ForeignKey{
+		"progression_steps.rule_id": {Table: "progression_steps", Column: "rule_id", RefTable: "progression_rules", RefColumn: "id", OnDelete: "CASCADE"},
+	}
+}
@@ -0,0 +1,91 @@
+// Code generated by migration system on 2025-08-30 09:15:48
+// DO NOT EDIT THIS FILE MANUALLY
+
+package database
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Progression_steps_outcome is a value of progression_steps.outcome
+type Progression_steps_outcome string
+
+const (
+	Progression_steps_outcome_progressed Progression_steps_outcome = "progressed"
+	Progression_steps_outcome_repeated   Progression_steps_outcome = "repeated"
+	Progression_steps_outcome_deloaded   Progression_steps_outcome = "deloaded"
+)
+
+// Progression_steps_outcomeValues lists the allowed values of progression_steps.outcome
+var Progression_steps_outcomeValues = []Progression_steps_outcome{Progression_steps_outcome_progressed, Progression_steps_outcome_repeated, Progression_steps_outcome_deloaded}
+
+// Valid reports whether v is an allowed value of progression_steps.outcome
+func (v Progression_steps_outcome) Valid() bool {
+	for _, value := range Progression_steps_outcomeValues {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseProgression_steps_outcome returns s as a value of progression_steps.outcome, or an error if it isn't an allowed one
+func ParseProgression_steps_outcome(s string) (Progression_steps_outcome, error) {
+	v := Progression_steps_outcome(s)
+	if !v.Valid() {
+		return "", fmt.Errorf("invalid progression_steps.outcome %q", s)
+	}
+	return v, nil
+}
+
+// Progression_steps represents the progression_steps table
+type Progression_steps struct {
+	Id                 string                    `db:"id" json:"id"`                 // Primary key // Default: gen_random_uuid()
+	Rule_id            string                    `db:"rule_id" json:"rule_id"`       // References progression_rules(id) // Unique
+	Session_id         string                    `db:"session_id" json:"session_id"` // References workout_sessions(id) // Unique
+	Outcome            Progression_steps_outcome `db:"outcome" json:"outcome"`
+	Previous_reps      int                       `db:"previous_reps" json:"previous_reps"`
+	Previous_weight_kg decimal.Decimal           `db:"previous_weight_kg" json:"previous_weight_kg"`
+	Reps               int                       `db:"reps" json:"reps"`
+	Weight_kg          decimal.Decimal           `db:"weight_kg" json:"weight_kg"`
+	Created_at         time.Time                 `db:"created_at" json:"created_at"` // Default: now()
+}
+
+// TableName returns the table name for Progression_steps
+func (Progression_steps) TableName() string {
+	return "progression_steps"
+}
+
+// Scan implements the sql.Scanner interface for Progression_steps
+func (m *Progression_steps) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	default:
+		return fmt.Errorf("cannot scan %T into Progression_steps", value)
+	}
+}
+
+// Value implements the driver.Valuer interface for Progression_steps
+func (m Progression_steps) Value() (driver.Value, error) {
+	return json.Marshal(m)
+}
+
+// BelongsTo returns the foreign keys of progression_steps, by column
+func (Progression_steps) BelongsTo() map[string]ForeignKey {
+	return map[string]ForeignKey{
+		"rule_id":    {Table: "progression_steps", Column: "rule_id", RefTable: "progression_rules", RefColumn: "id", OnDelete: "CASCADE"},
+		"session_id": {Table: "progression_steps", Column: "session_id", RefTable: "workout_sessions", RefColumn: "id", OnDelete: "CASCADE"},
+	}
+}
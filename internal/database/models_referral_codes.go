@@ -0,0 +1,51 @@
+// Code generated by migration system on 2025-08-08 10:12:40
+// DO NOT EDIT THIS FILE MANUALLY
+
+package database
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Referral_codes represents the referral_codes table
+type Referral_codes struct {
+	User_id    string    `db:"user_id" json:"user_id"`       // References users(id)       // Primary key
+	Code       string    `db:"code" json:"code"`             // Unique
+	Created_at time.Time `db:"created_at" json:"created_at"` // Default: now()
+}
+
+// TableName returns the table name for Referral_codes
+func (Referral_codes) TableName() string {
+	return "referral_codes"
+}
+
+// Scan implements the sql.Scanner interface for Referral_codes
+func (m *Referral_codes) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	default:
+		return fmt.Errorf("cannot scan %T into Referral_codes", value)
+	}
+}
+
+// Value implements the driver.Valuer interface for Referral_codes
+func (m Referral_codes) Value() (driver.Value, error) {
+	return json.Marshal(m)
+}
+
+// BelongsTo returns the foreign keys of referral_codes, by column
+func (Referral_codes) BelongsTo() map[string]ForeignKey {
+	return map[string]ForeignKey{
+		"user_id": {Table: "referral_codes", Column: "user_id", RefTable: "users", RefColumn: "id", OnDelete: "CASCADE"},
+	}
+}
@@ -0,0 +1,55 @@
+// Code generated by migration system on 2025-08-08 10:12:40
+// DO NOT EDIT THIS FILE MANUALLY
+
+package database
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Referrals represents the referrals table
+type Referrals struct {
+	Id               string    `db:"id" json:"id"`                             // Primary key // Default: gen_random_uuid()
+	Referrer_id      string    `db:"referrer_id" json:"referrer_id"`           // References users(id)
+	Referred_user_id string    `db:"referred_user_id" json:"referred_user_id"` // Unique // References users(id)
+	Code             string    `db:"code" json:"code"`
+	Reward_weeks     int       `db:"reward_weeks" json:"reward_weeks"` // Default: 0
+	Created_at       time.Time `db:"created_at" json:"created_at"`     // Default: now()
+}
+
+// TableName returns the table name for Referrals
+func (Referrals) TableName() string {
+	return "referrals"
+}
+
+// Scan implements the sql.Scanner interface for Referrals
+func (m *Referrals) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	default:
+		return fmt.Errorf("cannot scan %T into Referrals", value)
+	}
+}
+
+// Value implements the driver.Valuer interface for Referrals
+func (m Referrals) Value() (driver.Value, error) {
+	return json.Marshal(m)
+}
+
+// BelongsTo returns the foreign keys of referrals, by column
+func (Referrals) BelongsTo() map[string]ForeignKey {
+	return map[string]ForeignKey{
+		"referred_user_id": {Table: "referrals", Column: "referred_user_id", RefTable: "users", RefColumn: "id", OnDelete: "CASCADE"},
+		"referrer_id":      {Table: "referrals", Column: "referrer_id", RefTable: "users", RefColumn: "id", OnDelete: "CASCADE"},
+	}
+}
@@ -0,0 +1,63 @@
+// Code generated by migration system on 2025-08-08 10:12:40
+// DO NOT EDIT THIS FILE MANUALLY
+
+package database
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Reminders represents the reminders table
+type Reminders struct {
+	Id           string     `db:"id" json:"id"`           // Primary key // Default: gen_random_uuid()
+	User_id      string     `db:"user_id" json:"user_id"` // References users(id)
+	Title        string     `db:"title" json:"title"`
+	Message      string     `db:"message" json:"message"` // Default: ''::text
+	Days_of_week int        `db:"days_of_week" json:"days_of_week"`
+	Time_of_day  int        `db:"time_of_day" json:"time_of_day"`
+	Timezone     string     `db:"timezone" json:"timezone"` // Default: 'UTC'::text
+	Push         bool       `db:"push" json:"push"`         // Default: true
+	Email        bool       `db:"email" json:"email"`       // Default: false
+	Enabled      bool       `db:"enabled" json:"enabled"`   // Default: true
+	Next_run_at  *time.Time `db:"next_run_at" json:"next_run_at"`
+	Last_sent_at *time.Time `db:"last_sent_at" json:"last_sent_at"`
+	Created_at   time.Time  `db:"created_at" json:"created_at"` // Default: now()
+	Updated_at   time.Time  `db:"updated_at" json:"updated_at"` // Default: now()
+	Version      int        `db:"version" json:"version"`       // Default: 1
+}
+
+// TableName returns the table name for Reminders
+func (Reminders) TableName() string {
+	return "reminders"
+}
+
+// Scan implements the sql.Scanner interface for Reminders
+func (m *Reminders) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	default:
+		return fmt.Errorf("cannot scan %T into Reminders", value)
+	}
+}
+
+// Value implements the driver.Valuer interface for Reminders
+func (m Reminders) Value() (driver.Value, error) {
+	return json.Marshal(m)
+}
+
+// BelongsTo returns the foreign keys of reminders, by column
+func (Reminders) BelongsTo() map[string]ForeignKey {
+	return map[string]ForeignKey{
+		"user_id": {Table: "reminders", Column: "user_id", RefTable: "users", RefColumn: "id", OnDelete: "CASCADE"},
+	}
+}
@@ -0,0 +1,57 @@
+// Code generated by migration system on 2025-08-11 09:41:17
+// DO NOT EDIT THIS FILE MANUALLY
+
+package database
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Session_feedback represents the session_feedback table
+type Session_feedback struct {
+	Session_id string          `db:"session_id" json:"session_id"` // Primary key // References workout_sessions(id)
+	User_id    string          `db:"user_id" json:"user_id"`       // References users(id)
+	Rpe        int16           `db:"rpe" json:"rpe"`
+	Enjoyment  *int16          `db:"enjoyment" json:"enjoyment"`
+	Pain_areas json.RawMessage `db:"pain_areas" json:"pain_areas"` // Default: '[]'::jsonb
+	Notes      string          `db:"notes" json:"notes"`           // Default: ''::text
+	Created_at time.Time       `db:"created_at" json:"created_at"` // Default: now()
+	Updated_at time.Time       `db:"updated_at" json:"updated_at"` // Default: now()
+}
+
+// TableName returns the table name for Session_feedback
+func (Session_feedback) TableName() string {
+	return "session_feedback"
+}
+
+// Scan implements the sql.Scanner interface for Session_feedback
+func (m *Session_feedback) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	default:
+		return fmt.Errorf("cannot scan %T into Session_feedback", value)
+	}
+}
+
+// Value implements the driver.Valuer interface for Session_feedback
+func (m Session_feedback) Value() (driver.Value, error) {
+	return json.Marshal(m)
+}
+
+// BelongsTo returns the foreign keys of session_feedback, by column
+func (Session_feedback) BelongsTo() map[string]ForeignKey {
+	return map[string]ForeignKey{
+		"session_id": {Table: "session_feedback", Column: "session_id", RefTable: "workout_sessions", RefColumn: "id", OnDelete: "CASCADE"},
+		"user_id":    {Table: "session_feedback", Column: "user_id", RefTable: "users", RefColumn: "id", OnDelete: "CASCADE"},
+	}
+}
@@ -0,0 +1,121 @@
+// Code generated by migration system on 2025-08-08 10:12:40
+// DO NOT EDIT THIS FILE MANUALLY
+
+package database
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Subscriptions_platform is a value of subscriptions.platform
+type Subscriptions_platform string
+
+const (
+	Subscriptions_platform_apple  Subscriptions_platform = "apple"
+	Subscriptions_platform_google Subscriptions_platform = "google"
+)
+
+// Subscriptions_platformValues lists the allowed values of subscriptions.platform
+var Subscriptions_platformValues = []Subscriptions_platform{Subscriptions_platform_apple, Subscriptions_platform_google}
+
+// Valid reports whether v is an allowed value of subscriptions.platform
+func (v Subscriptions_platform) Valid() bool {
+	for _, value := range Subscriptions_platformValues {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseSubscriptions_platform returns s as a value of subscriptions.platform, or an error if it isn't an allowed one
+func ParseSubscriptions_platform(s string) (Subscriptions_platform, error) {
+	v := Subscriptions_platform(s)
+	if !v.Valid() {
+		return "", fmt.Errorf("invalid subscriptions.platform %q", s)
+	}
+	return v, nil
+}
+
+// Subscriptions_status is a value of subscriptions.status
+type Subscriptions_status string
+
+const (
+	Subscriptions_status_active   Subscriptions_status = "active"
+	Subscriptions_status_grace    Subscriptions_status = "grace"
+	Subscriptions_status_canceled Subscriptions_status = "canceled"
+	Subscriptions_status_expired  Subscriptions_status = "expired"
+	Subscriptions_status_refunded Subscriptions_status = "refunded"
+)
+
+// Subscriptions_statusValues lists the allowed values of subscriptions.status
+var Subscriptions_statusValues = []Subscriptions_status{Subscriptions_status_active, Subscriptions_status_grace, Subscriptions_status_canceled, Subscriptions_status_expired, Subscriptions_status_refunded}
+
+// Valid reports whether v is an allowed value of subscriptions.status
+func (v Subscriptions_status) Valid() bool {
+	for _, value := range Subscriptions_statusValues {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseSubscriptions_status returns s as a value of subscriptions.status, or an error if it isn't an allowed one
+func ParseSubscriptions_status(s string) (Subscriptions_status, error) {
+	v := Subscriptions_status(s)
+	if !v.Valid() {
+		return "", fmt.Errorf("invalid subscriptions.status %q", s)
+	}
+	return v, nil
+}
+
+// Subscriptions represents the subscriptions table
+type Subscriptions struct {
+	Id                      string                 `db:"id" json:"id"`           // Primary key
+	User_id                 string                 `db:"user_id" json:"user_id"` // References users(id)
+	Platform                Subscriptions_platform `db:"platform" json:"platform"`
+	Product_id              string                 `db:"product_id" json:"product_id"`
+	Original_transaction_id string                 `db:"original_transaction_id" json:"original_transaction_id"`
+	Status                  Subscriptions_status   `db:"status" json:"status"`
+	Expires_at              time.Time              `db:"expires_at" json:"expires_at"`
+	Auto_renew              bool                   `db:"auto_renew" json:"auto_renew"` // Default: false
+	Created_at              time.Time              `db:"created_at" json:"created_at"` // Default: now()
+	Updated_at              time.Time              `db:"updated_at" json:"updated_at"` // Default: now()
+}
+
+// TableName returns the table name for Subscriptions
+func (Subscriptions) TableName() string {
+	return "subscriptions"
+}
+
+// Scan implements the sql.Scanner interface for Subscriptions
+func (m *Subscriptions) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	default:
+		return fmt.Errorf("cannot scan %T into Subscriptions", value)
+	}
+}
+
+// Value implements the driver.Valuer interface for Subscriptions
+func (m Subscriptions) Value() (driver.Value, error) {
+	return json.Marshal(m)
+}
+
+// BelongsTo returns the foreign keys of subscriptions, by column
+func (Subscriptions) BelongsTo() map[string]ForeignKey {
+	return map[string]ForeignKey{
+		"user_id": {Table: "subscriptions", Column: "user_id", RefTable: "users", RefColumn: "id", OnDelete: "CASCADE"},
+	}
+}
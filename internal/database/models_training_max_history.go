@@ -0,0 +1,85 @@
+// Code generated by migration system on 2025-08-08 10:12:40
+// DO NOT EDIT THIS FILE MANUALLY
+
+package database
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Training_max_history_source is a value of training_max_history.source
+type Training_max_history_source string
+
+const (
+	Training_max_history_source_manual     Training_max_history_source = "manual"
+	Training_max_history_source_suggestion Training_max_history_source = "suggestion"
+)
+
+// Training_max_history_sourceValues lists the allowed values of training_max_history.source
+var Training_max_history_sourceValues = []Training_max_history_source{Training_max_history_source_manual, Training_max_history_source_suggestion}
+
+// Valid reports whether v is an allowed value of training_max_history.source
+func (v Training_max_history_source) Valid() bool {
+	for _, value := range Training_max_history_sourceValues {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseTraining_max_history_source returns s as a value of training_max_history.source, or an error if it isn't an allowed one
+func ParseTraining_max_history_source(s string) (Training_max_history_source, error) {
+	v := Training_max_history_source(s)
+	if !v.Valid() {
+		return "", fmt.Errorf("invalid training_max_history.source %q", s)
+	}
+	return v, nil
+}
+
+// Training_max_history represents the training_max_history table
+type Training_max_history struct {
+	Id              string                      `db:"id" json:"id"`                           // Primary key // Default: gen_random_uuid()
+	Training_max_id string                      `db:"training_max_id" json:"training_max_id"` // References training_maxes(id)
+	Weight_kg       decimal.Decimal             `db:"weight_kg" json:"weight_kg"`
+	Source          Training_max_history_source `db:"source" json:"source"`           // Default: 'manual'::text
+	Recorded_at     time.Time                   `db:"recorded_at" json:"recorded_at"` // Default: now()
+}
+
+// TableName returns the table name for Training_max_history
+func (Training_max_history) TableName() string {
+	return "training_max_history"
+}
+
+// Scan implements the sql.Scanner interface for Training_max_history
+func (m *Training_max_history) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	default:
+		return fmt.Errorf("cannot scan %T into Training_max_history", value)
+	}
+}
+
+// Value implements the driver.Valuer interface for Training_max_history
+func (m Training_max_history) Value() (driver.Value, error) {
+	return json.Marshal(m)
+}
+
+// BelongsTo returns the foreign keys of training_max_history, by column
+func (Training_max_history) BelongsTo() map[string]ForeignKey {
+	return map[string]ForeignKey{
+		"training_max_id": {Table: "training_max_history", Column: "training_max_id", RefTable: "training_maxes", RefColumn: "id", OnDelete: "CASCADE"},
+	}
+}
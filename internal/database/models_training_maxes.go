@@ -0,0 +1,65 @@
+// Code generated by migration system on 2025-08-08 10:12:40
+// DO NOT EDIT THIS FILE MANUALLY
+
+package database
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Training_maxes represents the training_maxes table
+type Training_maxes struct {
+	Id          string          `db:"id" json:"id"`           // Primary key // Default: gen_random_uuid()
+	User_id     string          `db:"user_id" json:"user_id"` // References users(id)
+	Name        string          `db:"name" json:"name"`
+	Exercise_id *string         `db:"exercise_id" json:"exercise_id"` // References exercises(id)
+	Weight_kg   decimal.Decimal `db:"weight_kg" json:"weight_kg"`
+	Created_at  time.Time       `db:"created_at" json:"created_at"` // Default: now()
+	Updated_at  time.Time       `db:"updated_at" json:"updated_at"` // Default: now()
+}
+
+// TableName returns the table name for Training_maxes
+func (Training_maxes) TableName() string {
+	return "training_maxes"
+}
+
+// Scan implements the sql.Scanner interface for Training_maxes
+func (m *Training_maxes) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	default:
+		return fmt.Errorf("cannot scan %T into Training_maxes", value)
+	}
+}
+
+// Value implements the driver.Valuer interface for Training_maxes
+func (m Training_maxes) Value() (driver.Value, error) {
+	return json.Marshal(m)
+}
+
+// BelongsTo returns the foreign keys of training_maxes, by column
+func (Training_maxes) BelongsTo() map[string]ForeignKey {
+	return map[string]ForeignKey{
+		"exercise_id": {Table: "training_maxes", Column: "exercise_id", RefTable: "exercises", RefColumn: "id", OnDelete: "SET NULL"},
+		"user_id":     {Table: "training_maxes", Column: "user_id", RefTable: "users", RefColumn: "id", OnDelete: "CASCADE"},
+	}
+}
+
+// HasMany returns the foreign keys referencing training_maxes, by table and column
+func (Training_maxes) HasMany() map[string]ForeignKey {
+	return map[string]ForeignKey{
+		"training_max_history.training_max_id": {Table: "training_max_history", Column: "training_max_id", RefTable: "training_maxes", RefColumn: "id", OnDelete: "CASCADE"},
+	}
+}
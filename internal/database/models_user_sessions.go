@@ -0,0 +1,57 @@
+// Code generated by migration system on 2025-09-01 09:02:37
+// DO NOT EDIT THIS FILE MANUALLY
+
+package database
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// User_sessions represents the user_sessions table
+type User_sessions struct {
+	Id           string     `db:"id" json:"id"`                     // Primary key // Default: gen_random_uuid()
+	User_id      string     `db:"user_id" json:"user_id"`           // References users(id)
+	Token_id     string     `db:"token_id" json:"token_id"`         // Unique
+	User_agent   string     `db:"user_agent" json:"user_agent"`     // Default: ''::text
+	Ip_address   string     `db:"ip_address" json:"ip_address"`     // Default: ''::text
+	Created_at   time.Time  `db:"created_at" json:"created_at"`     // Default: now()
+	Last_seen_at time.Time  `db:"last_seen_at" json:"last_seen_at"` // Default: now()
+	Expires_at   time.Time  `db:"expires_at" json:"expires_at"`
+	Revoked_at   *time.Time `db:"revoked_at" json:"revoked_at"`
+}
+
+// TableName returns the table name for User_sessions
+func (User_sessions) TableName() string {
+	return "user_sessions"
+}
+
+// Scan implements the sql.Scanner interface for User_sessions
+func (m *User_sessions) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	default:
+		return fmt.Errorf("cannot scan %T into User_sessions", value)
+	}
+}
+
+// Value implements the driver.Valuer interface for User_sessions
+func (m User_sessions) Value() (driver.Value, error) {
+	return json.Marshal(m)
+}
+
+// BelongsTo returns the foreign keys of user_sessions, by column
+func (User_sessions) BelongsTo() map[string]ForeignKey {
+	return map[string]ForeignKey{
+		"user_id": {Table: "user_sessions", Column: "user_id", RefTable: "users", RefColumn: "id", OnDelete: "CASCADE"},
+	}
+}
@@ -0,0 +1,134 @@
+// Code generated by migration system on 2025-09-02 08:51:19
+// DO NOT EDIT THIS FILE MANUALLY
+
+package database
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Users_role is a value of users.role
+type Users_role string
+
+const (
+	Users_role_user  Users_role = "user"
+	Users_role_admin Users_role = "admin"
+)
+
+// Users_roleValues lists the allowed values of users.role
+var Users_roleValues = []Users_role{Users_role_user, Users_role_admin}
+
+// Valid reports whether v is an allowed value of users.role
+func (v Users_role) Valid() bool {
+	for _, value := range Users_roleValues {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseUsers_role returns s as a value of users.role, or an error if it isn't an allowed one
+func ParseUsers_role(s string) (Users_role, error) {
+	v := Users_role(s)
+	if !v.Valid() {
+		return "", fmt.Errorf("invalid users.role %q", s)
+	}
+	return v, nil
+}
+
+// Users represents the users table
+type Users struct {
+	Id                      string     `db:"id" json:"id"`             // Primary key // Default: uuid_generate_v4()
+	Email                   string     `db:"email" json:"email"`       // Unique
+	Username                string     `db:"username" json:"username"` // Unique
+	Password_hash           string     `db:"password_hash" json:"password_hash"`
+	First_name              *string    `db:"first_name" json:"first_name"`
+	Last_name               *string    `db:"last_name" json:"last_name"`
+	Created_at              time.Time  `db:"created_at" json:"created_at"` // Default: now()
+	Updated_at              time.Time  `db:"updated_at" json:"updated_at"` // Default: now()
+	Role                    Users_role `db:"role" json:"role"`             // Default: 'user'::text
+	Version                 int        `db:"version" json:"version"`       // Default: 1
+	Timezone                string     `db:"timezone" json:"timezone"`     // Default: 'UTC'::text
+	Disabled_at             *time.Time `db:"disabled_at" json:"disabled_at"`
+	Password_reset_required bool       `db:"password_reset_required" json:"password_reset_required"` // Default: false
+}
+
+// TableName returns the table name for Users
+func (Users) TableName() string {
+	return "users"
+}
+
+// Scan implements the sql.Scanner interface for Users
+func (m *Users) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	default:
+		return fmt.Errorf("cannot scan %T into Users", value)
+	}
+}
+
+// Value implements the driver.Valuer interface for Users
+func (m Users) Value() (driver.Value, error) {
+	return json.Marshal(m)
+}
+
+// HasMany returns the foreign keys referencing users, by table and column
+func (Users) HasMany() map[string]ForeignKey {
+	return map[string]ForeignKey{
+		"admin_audit_log.admin_id":         {Table: "admin_audit_log", Column: "admin_id", RefTable: "users", RefColumn: "id", OnDelete: "SET NULL"},
+		"api_keys.user_id":                 {Table: "api_keys", Column: "user_id", RefTable: "users", RefColumn: "id", OnDelete: "CASCADE"},
+		"benchmark_consents.user_id":       {Table: "benchmark_consents", Column: "user_id", RefTable: "users", RefColumn: "id", OnDelete: "CASCADE"},
+		"body_metrics.user_id":             {Table: "body_metrics", Column: "user_id", RefTable: "users", RefColumn: "id", OnDelete: "CASCADE"},
+		"challenge_participants.user_id":   {Table: "challenge_participants", Column: "user_id", RefTable: "users", RefColumn: "id", OnDelete: "CASCADE"},
+		"challenges.created_by":            {Table: "challenges", Column: "created_by", RefTable: "users", RefColumn: "id", OnDelete: "SET NULL"},
+		"coach_clients.client_id":          {Table: "coach_clients", Column: "client_id", RefTable: "users", RefColumn: "id", OnDelete: "CASCADE"},
+		"coach_clients.coach_id":           {Table: "coach_clients", Column: "coach_id", RefTable: "users", RefColumn: "id", OnDelete: "CASCADE"},
+		"daily_habits.user_id":             {Table: "daily_habits", Column: "user_id", RefTable: "users", RefColumn: "id", OnDelete: "CASCADE"},
+		"data_exports.user_id":             {Table: "data_exports", Column: "user_id", RefTable: "users", RefColumn: "id", OnDelete: "CASCADE"},
+		"devices.user_id":                  {Table: "devices", Column: "user_id", RefTable: "users", RefColumn: "id", OnDelete: "CASCADE"},
+		"exercises.created_by":             {Table: "exercises", Column: "created_by", RefTable: "users", RefColumn: "id", OnDelete: "SET NULL"},
+		"entitlements.user_id":             {Table: "entitlements", Column: "user_id", RefTable: "users", RefColumn: "id", OnDelete: "CASCADE"},
+		"equipment_reservations.user_id":   {Table: "equipment_reservations", Column: "user_id", RefTable: "users", RefColumn: "id", OnDelete: "CASCADE"},
+		"follows.followee_id":              {Table: "follows", Column: "followee_id", RefTable: "users", RefColumn: "id", OnDelete: "CASCADE"},
+		"follows.follower_id":              {Table: "follows", Column: "follower_id", RefTable: "users", RefColumn: "id", OnDelete: "CASCADE"},
+		"foods.user_id":                    {Table: "foods", Column: "user_id", RefTable: "users", RefColumn: "id", OnDelete: "CASCADE"},
+		"guest_accounts.user_id":           {Table: "guest_accounts", Column: "user_id", RefTable: "users", RefColumn: "id", OnDelete: "CASCADE"},
+		"gym_visits.user_id":               {Table: "gym_visits", Column: "user_id", RefTable: "users", RefColumn: "id", OnDelete: "CASCADE"},
+		"integration_events.user_id":       {Table: "integration_events", Column: "user_id", RefTable: "users", RefColumn: "id", OnDelete: "CASCADE"},
+		"integrations.user_id":             {Table: "integrations", Column: "user_id", RefTable: "users", RefColumn: "id", OnDelete: "CASCADE"},
+		"notification_preferences.user_id": {Table: "notification_preferences", Column: "user_id", RefTable: "users", RefColumn: "id", OnDelete: "CASCADE"},
+		"nutrition_logs.user_id":           {Table: "nutrition_logs", Column: "user_id", RefTable: "users", RefColumn: "id", OnDelete: "CASCADE"},
+		"oauth_identities.user_id":         {Table: "oauth_identities", Column: "user_id", RefTable: "users", RefColumn: "id", OnDelete: "CASCADE"},
+		"organization_invites.accepted_by": {Table: "organization_invites", Column: "accepted_by", RefTable: "users", RefColumn: "id", OnDelete: "SET NULL"},
+		"organization_invites.invited_by":  {Table: "organization_invites", Column: "invited_by", RefTable: "users", RefColumn: "id", OnDelete: "SET NULL"},
+		"organization_members.user_id":     {Table: "organization_members", Column: "user_id", RefTable: "users", RefColumn: "id", OnDelete: "CASCADE"},
+		"organizations.created_by":         {Table: "organizations", Column: "created_by", RefTable: "users", RefColumn: "id", OnDelete: "SET NULL"},
+		"photo_vaults.user_id":             {Table: "photo_vaults", Column: "user_id", RefTable: "users", RefColumn: "id", OnDelete: "CASCADE"},
+		"privacy_settings.user_id":         {Table: "privacy_settings", Column: "user_id", RefTable: "users", RefColumn: "id", OnDelete: "CASCADE"},
+		"program_adjustments.reviewed_by":  {Table: "program_adjustments", Column: "reviewed_by", RefTable: "users", RefColumn: "id", OnDelete: "SET NULL"},
+		"program_adjustments.user_id":      {Table: "program_adjustments", Column: "user_id", RefTable: "users", RefColumn: "id", OnDelete: "CASCADE"},
+		"programs.coach_id":                {Table: "programs", Column: "coach_id", RefTable: "users", RefColumn: "id", OnDelete: "SET NULL"},
+		"progress_photos.user_id":          {Table: "progress_photos", Column: "user_id", RefTable: "users", RefColumn: "id", OnDelete: "CASCADE"},
+		"progression_rules.user_id":        {Table: "progression_rules", Column: "user_id", RefTable: "users", RefColumn: "id", OnDelete: "CASCADE"},
+		"referral_codes.user_id":           {Table: "referral_codes", Column: "user_id", RefTable: "users", RefColumn: "id", OnDelete: "CASCADE"},
+		"referrals.referred_user_id":       {Table: "referrals", Column: "referred_user_id", RefTable: "users", RefColumn: "id", OnDelete: "CASCADE"},
+		"referrals.referrer_id":            {Table: "referrals", Column: "referrer_id", RefTable: "users", RefColumn: "id", OnDelete: "CASCADE"},
+		"reminders.user_id":                {Table: "reminders", Column: "user_id", RefTable: "users", RefColumn: "id", OnDelete: "CASCADE"},
+		"session_feedback.user_id":         {Table: "session_feedback", Column: "user_id", RefTable: "users", RefColumn: "id", OnDelete: "CASCADE"},
+		"subscriptions.user_id":            {Table: "subscriptions", Column: "user_id", RefTable: "users", RefColumn: "id", OnDelete: "CASCADE"},
+		"training_maxes.user_id":           {Table: "training_maxes", Column: "user_id", RefTable: "users", RefColumn: "id", OnDelete: "CASCADE"},
+		"user_sessions.user_id":            {Table: "user_sessions", Column: "user_id", RefTable: "users", RefColumn: "id", OnDelete: "CASCADE"},
+		"webhooks.user_id":                 {Table: "webhooks", Column: "user_id", RefTable: "users", RefColumn: "id", OnDelete: "CASCADE"},
+	}
+}
@@ -0,0 +1,91 @@
+// Code generated by migration system on 2025-08-08 10:12:40
+// DO NOT EDIT THIS FILE MANUALLY
+
+package database
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Webhook_deliveries_status is a value of webhook_deliveries.status
+type Webhook_deliveries_status string
+
+const (
+	Webhook_deliveries_status_pending   Webhook_deliveries_status = "pending"
+	Webhook_deliveries_status_succeeded Webhook_deliveries_status = "succeeded"
+	Webhook_deliveries_status_failed    Webhook_deliveries_status = "failed"
+)
+
+// Webhook_deliveries_statusValues lists the allowed values of webhook_deliveries.status
+var Webhook_deliveries_statusValues = []Webhook_deliveries_status{Webhook_deliveries_status_pending, Webhook_deliveries_status_succeeded, Webhook_deliveries_status_failed}
+
+// Valid reports whether v is an allowed value of webhook_deliveries.status
+func (v Webhook_deliveries_status) Valid() bool {
+	for _, value := range Webhook_deliveries_statusValues {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseWebhook_deliveries_status returns s as a value of webhook_deliveries.status, or an error if it isn't an allowed one
+func ParseWebhook_deliveries_status(s string) (Webhook_deliveries_status, error) {
+	v := Webhook_deliveries_status(s)
+	if !v.Valid() {
+		return "", fmt.Errorf("invalid webhook_deliveries.status %q", s)
+	}
+	return v, nil
+}
+
+// Webhook_deliveries represents the webhook_deliveries table
+type Webhook_deliveries struct {
+	Id              string                    `db:"id" json:"id"`                 // Primary key // Default: gen_random_uuid()
+	Webhook_id      string                    `db:"webhook_id" json:"webhook_id"` // References webhooks(id)
+	Event_id        string                    `db:"event_id" json:"event_id"`
+	Event           string                    `db:"event" json:"event"`
+	Payload         json.RawMessage           `db:"payload" json:"payload"`
+	Status          Webhook_deliveries_status `db:"status" json:"status"`                   // Default: 'pending'
+	Attempts        int                       `db:"attempts" json:"attempts"`               // Default: 0
+	Next_attempt_at time.Time                 `db:"next_attempt_at" json:"next_attempt_at"` // Default: now()
+	Response_status *int                      `db:"response_status" json:"response_status"`
+	Last_error      string                    `db:"last_error" json:"last_error"` // Default: ''
+	Created_at      time.Time                 `db:"created_at" json:"created_at"` // Default: now()
+	Delivered_at    *time.Time                `db:"delivered_at" json:"delivered_at"`
+}
+
+// TableName returns the table name for Webhook_deliveries
+func (Webhook_deliveries) TableName() string {
+	return "webhook_deliveries"
+}
+
+// Scan implements the sql.Scanner interface for Webhook_deliveries
+func (m *Webhook_deliveries) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	default:
+		return fmt.Errorf("cannot scan %T into Webhook_deliveries", value)
+	}
+}
+
+// Value implements the driver.Valuer interface for Webhook_deliveries
+func (m Webhook_deliveries) Value() (driver.Value, error) {
+	return json.Marshal(m)
+}
+
+// BelongsTo returns the foreign keys of webhook_deliveries, by column
+func (Webhook_deliveries) BelongsTo() map[string]ForeignKey {
+	return map[string]ForeignKey{
+		"webhook_id": {Table: "webhook_deliveries", Column: "webhook_id", RefTable: "webhooks", RefColumn: "id", OnDelete: "CASCADE"},
+	}
+}
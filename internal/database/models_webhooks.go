@@ -0,0 +1,65 @@
+// Code generated by migration system on 2025-08-08 10:12:40
+// DO NOT EDIT THIS FILE MANUALLY
+
+package database
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Webhooks represents the webhooks table
+type Webhooks struct {
+	Id          string          `db:"id" json:"id"`           // Primary key // Default: gen_random_uuid()
+	User_id     string          `db:"user_id" json:"user_id"` // References users(id)
+	Url         string          `db:"url" json:"url"`
+	Description string          `db:"description" json:"description"` // Default: ''
+	Events      json.RawMessage `db:"events" json:"events"`           // Default: '[]'::jsonb
+	Secret      string          `db:"secret" json:"secret"`
+	Active      bool            `db:"active" json:"active"`         // Default: true
+	Created_at  time.Time       `db:"created_at" json:"created_at"` // Default: now()
+	Updated_at  time.Time       `db:"updated_at" json:"updated_at"` // Default: now()
+	Version     int             `db:"version" json:"version"`       // Default: 1
+}
+
+// TableName returns the table name for Webhooks
+func (Webhooks) TableName() string {
+	return "webhooks"
+}
+
+// Scan implements the sql.Scanner interface for Webhooks
+func (m *Webhooks) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	default:
+		return fmt.Errorf("cannot scan %T into Webhooks", value)
+	}
+}
+
+// Value implements the driver.Valuer interface for Webhooks
+func (m Webhooks) Value() (driver.Value, error) {
+	return json.Marshal(m)
+}
+
+// BelongsTo returns the foreign keys of webhooks, by column
+func (Webhooks) BelongsTo() map[string]ForeignKey {
+	return map[string]ForeignKey{
+		"user_id": {Table: "webhooks", Column: "user_id", RefTable: "users", RefColumn: "id", OnDelete: "CASCADE"},
+	}
+}
+
+// HasMany returns the foreign keys referencing webhooks, by table and column
+func (Webhooks) HasMany() map[string]ForeignKey {
+	return map[string]ForeignKey{
+		"webhook_deliveries.webhook_id": {Table: "webhook_deliveries", Column: "webhook_id", RefTable: "webhooks", RefColumn: "id", OnDelete: "CASCADE"},
+	}
+}
@@ -0,0 +1,73 @@
+// Code generated by migration system on 2025-08-30 09:15:48
+// DO NOT EDIT THIS FILE MANUALLY
+
+package database
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Workout_exercises represents the workout_exercises table
+type Workout_exercises struct {
+	Id               string          `db:"id" json:"id"`                   // Primary key // Default: gen_random_uuid()
+	Workout_id       string          `db:"workout_id" json:"workout_id"`   // References workouts(id)   // Unique
+	Exercise_id      string          `db:"exercise_id" json:"exercise_id"` // Unique // References exercises(id)
+	Sets             int             `db:"sets" json:"sets"`               // Default: 1
+	Reps             int             `db:"reps" json:"reps"`
+	Weight_kg        decimal.Decimal `db:"weight_kg" json:"weight_kg"`
+	Duration_seconds int             `db:"duration_seconds" json:"duration_seconds"`
+	Order_index      int             `db:"order_index" json:"order_index"`   // Unique // Default: 0
+	Rest_seconds     int             `db:"rest_seconds" json:"rest_seconds"` // Default: 60
+	Notes            string          `db:"notes" json:"notes"`
+	Created_at       time.Time       `db:"created_at" json:"created_at"`       // Default: now()
+	Updated_at       time.Time       `db:"updated_at" json:"updated_at"`       // Default: now()
+	Percent_of       string          `db:"percent_of" json:"percent_of"`       // Default: ''::text
+	Percent_value    decimal.Decimal `db:"percent_value" json:"percent_value"` // Default: 0
+	Version          int             `db:"version" json:"version"`             // Default: 1
+}
+
+// TableName returns the table name for Workout_exercises
+func (Workout_exercises) TableName() string {
+	return "workout_exercises"
+}
+
+// Scan implements the sql.Scanner interface for Workout_exercises
+func (m *Workout_exercises) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	default:
+		return fmt.Errorf("cannot scan %T into Workout_exercises", value)
+	}
+}
+
+// Value implements the driver.Valuer interface for Workout_exercises
+func (m Workout_exercises) Value() (driver.Value, error) {
+	return json.Marshal(m)
+}
+
+// BelongsTo returns the foreign keys of workout_exercises, by column
+func (Workout_exercises) BelongsTo() map[string]ForeignKey {
+	return map[string]ForeignKey{
+		"exercise_id": {Table: "workout_exercises", Column: "exercise_id", RefTable: "exercises", RefColumn: "id", OnDelete: "CASCADE"},
+		"workout_id":  {Table: "workout_exercises", Column: "workout_id", RefTable: "workouts", RefColumn: "id", OnDelete: "CASCADE"},
+	}
+}
+
+// HasMany returns the foreign keys referencing workout_exercises, by table and column
+func (Workout_exercises) HasMany() map[string]ForeignKey {
+	return map[string]ForeignKey{
+		"progression_rules.workout_exercise_id": {Table: "progression_rules", Column: "workout_exercise_id", RefTable: "workout_exercises", RefColumn: "id", OnDelete: "CASCADE"},
+	}
+}
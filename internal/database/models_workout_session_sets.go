@@ -0,0 +1,64 @@
+// Code generated by migration system on 2025-08-08 10:12:40
+// DO NOT EDIT THIS FILE MANUALLY
+
+package database
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Workout_session_sets represents the workout_session_sets table
+type Workout_session_sets struct {
+	Id               string           `db:"id" json:"id"`                   // Primary key // Default: gen_random_uuid()
+	Session_id       string           `db:"session_id" json:"session_id"`   // References workout_sessions(id)
+	Exercise_id      *string          `db:"exercise_id" json:"exercise_id"` // References exercises(id)
+	Set_number       int              `db:"set_number" json:"set_number"`
+	Reps             int              `db:"reps" json:"reps"`           // Default: 0
+	Weight_kg        decimal.Decimal  `db:"weight_kg" json:"weight_kg"` // Default: 0
+	Duration_seconds *int             `db:"duration_seconds" json:"duration_seconds"`
+	Rpe              *decimal.Decimal `db:"rpe" json:"rpe"`
+	Rest_seconds     *int             `db:"rest_seconds" json:"rest_seconds"`
+	Client_id        string           `db:"client_id" json:"client_id"`
+	Completed_at     time.Time        `db:"completed_at" json:"completed_at"` // Default: now()
+	Created_at       time.Time        `db:"created_at" json:"created_at"`     // Default: now()
+	Amrap            bool             `db:"amrap" json:"amrap"`               // Default: false
+}
+
+// TableName returns the table name for Workout_session_sets
+func (Workout_session_sets) TableName() string {
+	return "workout_session_sets"
+}
+
+// Scan implements the sql.Scanner interface for Workout_session_sets
+func (m *Workout_session_sets) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	default:
+		return fmt.Errorf("cannot scan %T into Workout_session_sets", value)
+	}
+}
+
+// Value implements the driver.Valuer interface for Workout_session_sets
+func (m Workout_session_sets) Value() (driver.Value, error) {
+	return json.Marshal(m)
+}
+
+// BelongsTo returns the foreign keys of workout_session_sets, by column
+func (Workout_session_sets) BelongsTo() map[string]ForeignKey {
+	return map[string]ForeignKey{
+		"exercise_id": {Table: "workout_session_sets", Column: "exercise_id", RefTable: "exercises", RefColumn: "id", OnDelete: "SET NULL"},
+		"session_id":  {Table: "workout_session_sets", Column: "session_id", RefTable: "workout_sessions", RefColumn: "id", OnDelete: "CASCADE"},
+	}
+}
@@ -0,0 +1,83 @@
+// Code generated by migration system on 2025-08-30 09:15:48
+// DO NOT EDIT THIS FILE MANUALLY
+
+package database
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Workout_sessions represents the workout_sessions table
+type Workout_sessions struct {
+	Id                 string          `db:"id" json:"id"` // Primary key // Default: gen_random_uuid()
+	User_id            string          `db:"user_id" json:"user_id"`
+	Workout_id         *string         `db:"workout_id" json:"workout_id"` // References workouts(id)
+	Name               string          `db:"name" json:"name"`
+	Started_at         time.Time       `db:"started_at" json:"started_at"` // Default: now()
+	Completed_at       time.Time       `db:"completed_at" json:"completed_at"`
+	Duration_minutes   int             `db:"duration_minutes" json:"duration_minutes"`
+	Notes              string          `db:"notes" json:"notes"`
+	Created_at         time.Time       `db:"created_at" json:"created_at"` // Default: now()
+	Updated_at         time.Time       `db:"updated_at" json:"updated_at"` // Default: now()
+	Source             string          `db:"source" json:"source"`
+	External_id        string          `db:"external_id" json:"external_id"`
+	Exercise_id        *string         `db:"exercise_id" json:"exercise_id"` // References exercises(id)
+	Duration_seconds   *int            `db:"duration_seconds" json:"duration_seconds"`
+	Distance_meters    *float64        `db:"distance_meters" json:"distance_meters"`
+	Avg_heart_rate     *int            `db:"avg_heart_rate" json:"avg_heart_rate"`
+	Max_heart_rate     *int            `db:"max_heart_rate" json:"max_heart_rate"`
+	Plan               json.RawMessage `db:"plan" json:"plan"`       // Default: '[]'::jsonb
+	Version            int             `db:"version" json:"version"` // Default: 1
+	Start_latitude     *float64        `db:"start_latitude" json:"start_latitude"`
+	Start_longitude    *float64        `db:"start_longitude" json:"start_longitude"`
+	Temperature_c      *float64        `db:"temperature_c" json:"temperature_c"`
+	Wind_speed_kmh     *float64        `db:"wind_speed_kmh" json:"wind_speed_kmh"`
+	Wind_direction     *int            `db:"wind_direction" json:"wind_direction"`
+	Weather_checked_at *time.Time      `db:"weather_checked_at" json:"weather_checked_at"`
+}
+
+// TableName returns the table name for Workout_sessions
+func (Workout_sessions) TableName() string {
+	return "workout_sessions"
+}
+
+// Scan implements the sql.Scanner interface for Workout_sessions
+func (m *Workout_sessions) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	default:
+		return fmt.Errorf("cannot scan %T into Workout_sessions", value)
+	}
+}
+
+// Value implements the driver.Valuer interface for Workout_sessions
+func (m Workout_sessions) Value() (driver.Value, error) {
+	return json.Marshal(m)
+}
+
+// BelongsTo returns the foreign keys of workout_sessions, by column
+func (Workout_sessions) BelongsTo() map[string]ForeignKey {
+	return map[string]ForeignKey{
+		"exercise_id": {Table: "workout_sessions", Column: "exercise_id", RefTable: "exercises", RefColumn: "id", OnDelete: "SET NULL"},
+		"workout_id":  {Table: "workout_sessions", Column: "workout_id", RefTable: "workouts", RefColumn: "id", OnDelete: "SET NULL"},
+	}
+}
+
+// HasMany returns the foreign keys referencing workout_sessions, by table and column
+func (Workout_sessions) HasMany() map[string]ForeignKey {
+	return map[string]ForeignKey{
+		"progression_steps.session_id":    {Table: "progression_steps", Column: "session_id", RefTable: "workout_sessions", RefColumn: "id", OnDelete: "CASCADE"},
+		"session_feedback.session_id":     {Table: "session_feedback", Column: "session_id", RefTable: "workout_sessions", RefColumn: "id", OnDelete: "CASCADE"},
+		"workout_session_sets.session_id": {Table: "workout_session_sets", Column: "session_id", RefTable: "workout_sessions", RefColumn: "id", OnDelete: "CASCADE"},
+	}
+}
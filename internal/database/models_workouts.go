@@ -0,0 +1,66 @@
+// Code generated by migration system on 2025-08-08 10:12:40
+// DO NOT EDIT THIS FILE MANUALLY
+
+package database
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Workouts represents the workouts table
+type Workouts struct {
+	Id               string    `db:"id" json:"id"` // Primary key // Default: gen_random_uuid()
+	User_id          string    `db:"user_id" json:"user_id"`
+	Name             string    `db:"name" json:"name"`
+	Description      string    `db:"description" json:"description"`
+	Duration_minutes int       `db:"duration_minutes" json:"duration_minutes"`
+	Created_at       time.Time `db:"created_at" json:"created_at"`   // Default: now()
+	Updated_at       time.Time `db:"updated_at" json:"updated_at"`   // Default: now()
+	Program_id       string    `db:"program_id" json:"program_id"`   // References programs(id)
+	Is_template      bool      `db:"is_template" json:"is_template"` // Default: false
+	Version          int       `db:"version" json:"version"`         // Default: 1
+}
+
+// TableName returns the table name for Workouts
+func (Workouts) TableName() string {
+	return "workouts"
+}
+
+// Scan implements the sql.Scanner interface for Workouts
+func (m *Workouts) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	default:
+		return fmt.Errorf("cannot scan %T into Workouts", value)
+	}
+}
+
+// Value implements the driver.Valuer interface for Workouts
+func (m Workouts) Value() (driver.Value, error) {
+	return json.Marshal(m)
+}
+
+// BelongsTo returns the foreign keys of workouts, by column
+func (Workouts) BelongsTo() map[string]ForeignKey {
+	return map[string]ForeignKey{
+		"program_id": {Table: "workouts", Column: "program_id", RefTable: "programs", RefColumn: "id", OnDelete: "SET NULL"},
+	}
+}
+
+// HasMany returns the foreign keys referencing workouts, by table and column
+func (Workouts) HasMany() map[string]ForeignKey {
+	return map[string]ForeignKey{
+		"workout_exercises.workout_id": {Table: "workout_exercises", Column: "workout_id", RefTable: "workouts", RefColumn: "id", OnDelete: "CASCADE"},
+		"workout_sessions.workout_id":  {Table: "workout_sessions", Column: "workout_id", RefTable: "workouts", RefColumn: "id", OnDelete: "SET NULL"},
+	}
+}
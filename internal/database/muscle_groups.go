@@ -0,0 +1,173 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"fitness-hack/internal/database/sqlbuild"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ErrUnknownMuscle is returned when an exercise is given a muscle that isn't in muscle_groups
+var ErrUnknownMuscle = errors.New("unknown muscle")
+
+// MuscleAssignment gives an exercise a muscle by its slug
+type MuscleAssignment struct {
+	Muscle string
+	Role   Exercise_muscle_groups_role
+}
+
+// ExerciseMuscle is a muscle an exercise works, with the role it plays
+type ExerciseMuscle struct {
+	Exercise_id string                      `db:"exercise_id" json:"exercise_id"`
+	Slug        string                      `db:"slug" json:"slug"`
+	Name        string                      `db:"name" json:"name"`
+	Role        Exercise_muscle_groups_role `db:"role" json:"role"`
+}
+
+var nonSlug = regexp.MustCompile(`[^a-z0-9]+`)
+
+// MuscleSlug returns the muscle_groups slug for a muscle name, such as "upper-back" for
+// "Upper Back". It matches the slugs the migration made from the old free-text column.
+func MuscleSlug(name string) string {
+	return strings.Trim(nonSlug.ReplaceAllString(strings.ToLower(name), "-"), "-")
+}
+
+// ListExercisesOpts narrows ListExercises. Zero fields don't filter, and they combine with
+// the sorting, paging and equality filters of ListOptions.
+type ListExercisesOpts struct {
+	ListOptions
+
+	// Muscle keeps exercises that work the muscle, by slug
+	Muscle string
+	// Role, with Muscle, keeps only exercises where the muscle plays that role
+	Role Exercise_muscle_groups_role
+}
+
+// exerciseConditions are the filters on exercises that need more than a column comparison
+var exerciseConditions = sqlbuild.Conditions{
+	"muscle": `EXISTS (SELECT 1 FROM exercise_muscle_groups emg JOIN muscle_groups m ON m.id = emg.muscle_group_id
+		WHERE emg.exercise_id = exercises.id AND m.slug = %s)`,
+	"primary_muscle": `EXISTS (SELECT 1 FROM exercise_muscle_groups emg JOIN muscle_groups m ON m.id = emg.muscle_group_id
+		WHERE emg.exercise_id = exercises.id AND emg.role = 'primary' AND m.slug = %s)`,
+	"secondary_muscle": `EXISTS (SELECT 1 FROM exercise_muscle_groups emg JOIN muscle_groups m ON m.id = emg.muscle_group_id
+		WHERE emg.exercise_id = exercises.id AND emg.role = 'secondary' AND m.slug = %s)`,
+}
+
+// query builds the SELECT for the options
+func (o ListExercisesOpts) query() (string, []interface{}, error) {
+	q := sqlbuild.New(`SELECT * FROM exercises WHERE TRUE`)
+	if o.Muscle != "" {
+		condition := "muscle"
+		if o.Role != "" {
+			condition = string(o.Role) + "_muscle"
+		}
+		q.Where(exerciseConditions, condition, o.Muscle)
+	}
+	return o.ListOptions.build(q, exerciseList)
+}
+
+// ListMuscleGroups lists the muscle taxonomy by name
+func (r *exerciseRepository) ListMuscleGroups(ctx context.Context) ([]Muscle_groups, error) {
+	groups := []Muscle_groups{}
+	if err := r.db.SelectContext(ctx, &groups, `SELECT * FROM muscle_groups ORDER BY name`); err != nil {
+		return nil, fmt.Errorf("failed to list muscle groups: %w", err)
+	}
+	return groups, nil
+}
+
+// ListExerciseMuscles returns the muscles of the exercises, primary muscles first
+func (r *exerciseRepository) ListExerciseMuscles(ctx context.Context, exerciseIDs []string) ([]ExerciseMuscle, error) {
+	muscles := []ExerciseMuscle{}
+	if len(exerciseIDs) == 0 {
+		return muscles, nil
+	}
+	err := r.db.SelectContext(ctx, &muscles, `SELECT emg.exercise_id, m.slug, m.name, emg.role
+		FROM exercise_muscle_groups emg JOIN muscle_groups m ON m.id = emg.muscle_group_id
+		WHERE emg.exercise_id = ANY($1)
+		ORDER BY emg.exercise_id, emg.role, m.name`, exerciseIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list exercise muscles: %w", err)
+	}
+	return muscles, nil
+}
+
+// SetExerciseMuscles replaces the muscles of the exercise. Returns ErrUnknownMuscle if a
+// slug isn't in muscle_groups, without changing anything.
+func (r *exerciseRepository) SetExerciseMuscles(ctx context.Context, exerciseID string, muscles []MuscleAssignment) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin exercise muscles update: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := syncExerciseMuscles(ctx, tx, map[string][]MuscleAssignment{exerciseID: muscles}); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit exercise muscles update: %w", err)
+	}
+	return nil
+}
+
+// syncExerciseMuscles makes the muscles of each exercise in wanted exactly those given,
+// returning the IDs of the exercises whose muscles changed. A muscle given twice for an
+// exercise keeps its primary role.
+func syncExerciseMuscles(ctx context.Context, tx *sqlx.Tx, wanted map[string][]MuscleAssignment) ([]string, error) {
+	exerciseIDs := make([]string, 0, len(wanted))
+	var ids, slugs, roles []string
+	for id, muscles := range wanted {
+		exerciseIDs = append(exerciseIDs, id)
+		for _, m := range muscles {
+			ids, slugs, roles = append(ids, id), append(slugs, m.Muscle), append(roles, string(m.Role))
+		}
+	}
+
+	var unknown []string
+	err := tx.SelectContext(ctx, &unknown, `SELECT DISTINCT s.slug FROM unnest($1::text[]) AS s(slug)
+		WHERE NOT EXISTS (SELECT 1 FROM muscle_groups m WHERE m.slug = s.slug) ORDER BY s.slug`, slugs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check muscles: %w", err)
+	}
+	if len(unknown) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownMuscle, strings.Join(unknown, ", "))
+	}
+
+	wantedRows := `SELECT DISTINCT ON (w.exercise_id, m.id) w.exercise_id, m.id AS muscle_group_id, w.role
+		FROM unnest($1::uuid[], $2::text[], $3::text[]) AS w(exercise_id, slug, role)
+		JOIN muscle_groups m ON m.slug = w.slug
+		ORDER BY w.exercise_id, m.id, w.role`
+
+	// Removing and then adding keeps the two statements off each other's rows
+	var removed, added []string
+	err = tx.SelectContext(ctx, &removed, `WITH wanted AS (`+wantedRows+`)
+		DELETE FROM exercise_muscle_groups emg
+		WHERE emg.exercise_id = ANY($4::uuid[]) AND NOT EXISTS (SELECT 1 FROM wanted w
+			WHERE w.exercise_id = emg.exercise_id AND w.muscle_group_id = emg.muscle_group_id)
+		RETURNING emg.exercise_id`, ids, slugs, roles, exerciseIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to remove exercise muscles: %w", err)
+	}
+	err = tx.SelectContext(ctx, &added, `INSERT INTO exercise_muscle_groups AS emg (exercise_id, muscle_group_id, role)
+		`+wantedRows+`
+		ON CONFLICT (exercise_id, muscle_group_id) DO UPDATE SET role = EXCLUDED.role
+			WHERE emg.role <> EXCLUDED.role
+		RETURNING emg.exercise_id`, ids, slugs, roles)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add exercise muscles: %w", err)
+	}
+
+	seen := map[string]bool{}
+	changed := []string{}
+	for _, id := range append(removed, added...) {
+		if !seen[id] {
+			seen[id] = true
+			changed = append(changed, id)
+		}
+	}
+	return changed, nil
+}
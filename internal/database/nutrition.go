@@ -0,0 +1,260 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"fitness-hack/internal/database/sqlbuild"
+
+	"github.com/shopspring/decimal"
+)
+
+// MealTotals adds up the nutrition logged for one meal
+type MealTotals struct {
+	Meal      Nutrition_logs_meal `db:"meal"`
+	Entries   int                 `db:"entries"`
+	Calories  decimal.Decimal     `db:"calories"`
+	Protein_g decimal.Decimal     `db:"protein_g"`
+	Carbs_g   decimal.Decimal     `db:"carbs_g"`
+	Fat_g     decimal.Decimal     `db:"fat_g"`
+}
+
+// CreateFood adds a food to the catalog, or to the user's own foods when User_id is set
+func (s *service) CreateFood(ctx context.Context, food *Foods) (*Foods, error) {
+	var created Foods
+	query := `INSERT INTO foods
+			(user_id, name, brand, barcode, serving_size, serving_unit, calories, protein_g, carbs_g, fat_g)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING *`
+	err := s.db.GetContext(ctx, &created, query,
+		food.User_id, food.Name, food.Brand, food.Barcode, food.Serving_size, food.Serving_unit,
+		food.Calories, food.Protein_g, food.Carbs_g, food.Fat_g)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create food: %w", err)
+	}
+	return &created, nil
+}
+
+// GetFood returns a food the user can log: one from the catalog or one of their own.
+// Returns sql.ErrNoRows otherwise.
+func (s *service) GetFood(ctx context.Context, id, userID string) (*Foods, error) {
+	var food Foods
+	query := `SELECT * FROM foods WHERE id = $1 AND (user_id IS NULL OR user_id = $2)`
+	if err := s.db.GetContext(ctx, &food, query, id, userID); err != nil {
+		return nil, err
+	}
+	return &food, nil
+}
+
+// SearchFoods returns up to limit foods the user can log whose name or brand contains
+// query, or whose barcode is barcode when it is set. The user's own foods come first,
+// then names starting with the query.
+func (s *service) SearchFoods(ctx context.Context, userID, query, barcode string, limit int) ([]Foods, error) {
+	foods := []Foods{}
+	var err error
+	if barcode != "" {
+		err = s.db.SelectContext(ctx, &foods, `SELECT * FROM foods
+			WHERE barcode = $2 AND (user_id IS NULL OR user_id = $1)
+			ORDER BY user_id IS NULL, name, id
+			LIMIT $3`, userID, barcode, limit)
+	} else {
+		err = s.db.SelectContext(ctx, &foods, `SELECT * FROM foods
+			WHERE (user_id IS NULL OR user_id = $1) AND strpos(lower(name || ' ' || brand), lower($2)) > 0
+			ORDER BY user_id IS NULL, strpos(lower(name), lower($2)) <> 1, name, id
+			LIMIT $3`, userID, query, limit)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to search foods: %w", err)
+	}
+	return foods, nil
+}
+
+// UpsertExternalFood adds a food found in an external food database to the catalog, or
+// refreshes the copy added when it was found before
+func (s *service) UpsertExternalFood(ctx context.Context, food *Foods) (*Foods, error) {
+	var saved Foods
+	query := `INSERT INTO foods
+			(name, brand, barcode, serving_size, serving_unit, calories, protein_g, carbs_g, fat_g, source, external_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT (source, external_id) WHERE external_id <> '' DO UPDATE SET
+			name = EXCLUDED.name,
+			brand = EXCLUDED.brand,
+			barcode = EXCLUDED.barcode,
+			serving_size = EXCLUDED.serving_size,
+			serving_unit = EXCLUDED.serving_unit,
+			calories = EXCLUDED.calories,
+			protein_g = EXCLUDED.protein_g,
+			carbs_g = EXCLUDED.carbs_g,
+			fat_g = EXCLUDED.fat_g,
+			updated_at = NOW(),
+			version = foods.version + 1
+		RETURNING *`
+	err := s.db.GetContext(ctx, &saved, query,
+		food.Name, food.Brand, food.Barcode, food.Serving_size, food.Serving_unit,
+		food.Calories, food.Protein_g, food.Carbs_g, food.Fat_g, food.Source, food.External_id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save external food: %w", err)
+	}
+	return &saved, nil
+}
+
+// UpdateFood saves one of the user's own foods, returning sql.ErrNoRows if it isn't theirs
+// and ErrVersionConflict if it's no longer at food.Version. Entries already logged from
+// it keep the nutrients they were logged with.
+func (s *service) UpdateFood(ctx context.Context, food *Foods) (*Foods, error) {
+	var updated Foods
+	query := `UPDATE foods
+		SET name = $3, brand = $4, barcode = $5, serving_size = $6, serving_unit = $7,
+			calories = $8, protein_g = $9, carbs_g = $10, fat_g = $11, updated_at = NOW(), version = version + 1
+		WHERE id = $1 AND user_id = $2 AND version = $12
+		RETURNING *`
+	err := s.db.GetContext(ctx, &updated, query,
+		food.Id, food.User_id, food.Name, food.Brand, food.Barcode, food.Serving_size, food.Serving_unit,
+		food.Calories, food.Protein_g, food.Carbs_g, food.Fat_g, food.Version)
+	if errors.Is(err, sql.ErrNoRows) {
+		var exists bool
+		query = `SELECT EXISTS (SELECT 1 FROM foods WHERE id = $1 AND user_id = $2)`
+		if s.db.GetContext(ctx, &exists, query, food.Id, food.User_id) == nil && exists {
+			return nil, ErrVersionConflict
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// DeleteFood removes one of the user's own foods, returning sql.ErrNoRows if it isn't
+// theirs. Entries logged from it are kept.
+func (s *service) DeleteFood(ctx context.Context, id, userID string) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM foods WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete food: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// CreateNutritionLog records a food the user ate
+func (s *service) CreateNutritionLog(ctx context.Context, entry *Nutrition_logs) (*Nutrition_logs, error) {
+	var created Nutrition_logs
+	query := `INSERT INTO nutrition_logs
+			(user_id, food_id, name, meal, servings, calories, protein_g, carbs_g, fat_g, eaten_at, notes)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING *`
+	err := s.db.GetContext(ctx, &created, query,
+		entry.User_id, entry.Food_id, entry.Name, entry.Meal, entry.Servings,
+		entry.Calories, entry.Protein_g, entry.Carbs_g, entry.Fat_g, entry.Eaten_at, entry.Notes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create nutrition log: %w", err)
+	}
+	return &created, nil
+}
+
+// GetNutritionLog returns one of the user's entries, or sql.ErrNoRows
+func (s *service) GetNutritionLog(ctx context.Context, id, userID string) (*Nutrition_logs, error) {
+	var entry Nutrition_logs
+	query := `SELECT * FROM nutrition_logs WHERE id = $1 AND user_id = $2`
+	if err := s.db.GetContext(ctx, &entry, query, id, userID); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+var nutritionLogList = listSpec{
+	sorts:        map[string]string{"eaten_at": "eaten_at", "created_at": "created_at", "calories": "calories"},
+	filters:      map[string]string{"meal": "meal"},
+	defaultOrder: "eaten_at DESC",
+	tiebreak:     "id",
+}
+
+// ListNutritionLogsOpts narrows ListNutritionLogs. Zero fields don't filter, and they
+// combine with the sorting, paging and equality filters of ListOptions.
+type ListNutritionLogsOpts struct {
+	ListOptions
+
+	// From and To keep entries eaten in [From, To)
+	From time.Time
+	To   time.Time
+}
+
+// query builds the SELECT of the user's entries for the options
+func (o ListNutritionLogsOpts) query(userID string) (string, []interface{}, error) {
+	q := sqlbuild.New(`SELECT * FROM nutrition_logs WHERE user_id = $1`, userID)
+	if !o.From.IsZero() {
+		q.Compare(nutritionLogList.sorts, "eaten_at", sqlbuild.Gte, o.From)
+	}
+	if !o.To.IsZero() {
+		q.Compare(nutritionLogList.sorts, "eaten_at", sqlbuild.Lt, o.To)
+	}
+	return o.ListOptions.build(q, nutritionLogList)
+}
+
+// ListNutritionLogs returns the user's entries matching opts, latest first by default
+func (s *service) ListNutritionLogs(ctx context.Context, userID string, opts ListNutritionLogsOpts) ([]Nutrition_logs, error) {
+	query, args, err := opts.query(userID)
+	if err != nil {
+		return nil, err
+	}
+	entries := []Nutrition_logs{}
+	err = s.db.SelectContext(ctx, &entries, query, args...)
+	return entries, err
+}
+
+// UpdateNutritionLog saves one of the user's entries, returning sql.ErrNoRows if it isn't
+// theirs and ErrVersionConflict if it's no longer at entry.Version
+func (s *service) UpdateNutritionLog(ctx context.Context, entry *Nutrition_logs) (*Nutrition_logs, error) {
+	var updated Nutrition_logs
+	query := `UPDATE nutrition_logs
+		SET name = $3, meal = $4, servings = $5, calories = $6, protein_g = $7, carbs_g = $8, fat_g = $9,
+			eaten_at = $10, notes = $11, updated_at = NOW(), version = version + 1
+		WHERE id = $1 AND user_id = $2 AND version = $12
+		RETURNING *`
+	err := s.db.GetContext(ctx, &updated, query,
+		entry.Id, entry.User_id, entry.Name, entry.Meal, entry.Servings, entry.Calories,
+		entry.Protein_g, entry.Carbs_g, entry.Fat_g, entry.Eaten_at, entry.Notes, entry.Version)
+	if errors.Is(err, sql.ErrNoRows) {
+		var exists bool
+		query = `SELECT EXISTS (SELECT 1 FROM nutrition_logs WHERE id = $1 AND user_id = $2)`
+		if s.db.GetContext(ctx, &exists, query, entry.Id, entry.User_id) == nil && exists {
+			return nil, ErrVersionConflict
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// DeleteNutritionLog removes one of the user's entries, returning sql.ErrNoRows if it
+// isn't theirs
+func (s *service) DeleteNutritionLog(ctx context.Context, id, userID string) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM nutrition_logs WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete nutrition log: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// GetNutritionTotals adds up the user's entries eaten in [from, to) per meal. Meals
+// without entries are left out.
+func (s *service) GetNutritionTotals(ctx context.Context, userID string, from, to time.Time) ([]MealTotals, error) {
+	totals := []MealTotals{}
+	query := `SELECT meal, COUNT(*) AS entries, SUM(calories) AS calories, SUM(protein_g) AS protein_g,
+			SUM(carbs_g) AS carbs_g, SUM(fat_g) AS fat_g
+		FROM nutrition_logs
+		WHERE user_id = $1 AND eaten_at >= $2 AND eaten_at < $3
+		GROUP BY meal`
+	if err := s.db.SelectContext(ctx, &totals, query, userID, from, to); err != nil {
+		return nil, fmt.Errorf("failed to get nutrition totals: %w", err)
+	}
+	return totals, nil
+}
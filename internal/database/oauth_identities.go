@@ -0,0 +1,75 @@
+package database
+
+import (
+	"context"
+	"fmt"
+)
+
+const insertOAuthIdentityQuery = `INSERT INTO oauth_identities (user_id, provider, subject, email)
+	VALUES (:user_id, :provider, :subject, :email)
+	RETURNING *`
+
+// GetUserByOAuthIdentity returns the user linked to the provider subject
+func (s *service) GetUserByOAuthIdentity(ctx context.Context, provider, subject string) (*Users, error) {
+	var user Users
+	query := `SELECT u.* FROM users u
+		JOIN oauth_identities oi ON oi.user_id = u.id
+		WHERE oi.provider = $1 AND oi.subject = $2`
+	err := s.db.GetContext(ctx, &user, query, provider, subject)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// LinkOAuthIdentity links an external identity to an existing user
+func (s *service) LinkOAuthIdentity(ctx context.Context, identity *Oauth_identities) (*Oauth_identities, error) {
+	row, err := s.db.NamedQueryContext(ctx, insertOAuthIdentityQuery, identity)
+	if err != nil {
+		return nil, err
+	}
+	defer row.Close()
+	if row.Next() {
+		var created Oauth_identities
+		if err := row.StructScan(&created); err != nil {
+			return nil, err
+		}
+		return &created, nil
+	}
+	return nil, fmt.Errorf("failed to insert oauth_identity")
+}
+
+// CreateOAuthUser creates a new user and links the external identity in one transaction
+func (s *service) CreateOAuthUser(ctx context.Context, user *Users, identity *Oauth_identities) (*Users, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query, args, err := tx.BindNamed(`INSERT INTO users (email, username, password_hash, first_name, last_name, created_at, updated_at)
+		VALUES (:email, :username, :password_hash, :first_name, :last_name, :created_at, :updated_at)
+		RETURNING *`, user)
+	if err != nil {
+		return nil, err
+	}
+
+	var created Users
+	if err := tx.QueryRowxContext(ctx, query, args...).StructScan(&created); err != nil {
+		return nil, fmt.Errorf("failed to insert user: %w", err)
+	}
+
+	identity.User_id = created.Id
+	query, args, err = tx.BindNamed(insertOAuthIdentityQuery, identity)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to link oauth identity: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
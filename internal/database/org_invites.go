@@ -0,0 +1,150 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInvitePending is returned when the address already has an open invitation to the
+	// organization, or from the coach
+	ErrInvitePending = errors.New("invitation already pending")
+
+	// ErrInviteExpired is returned when accepting an invitation past its expiry
+	ErrInviteExpired = errors.New("invitation expired")
+
+	// ErrInviteClosed is returned when accepting an invitation that was already accepted or revoked
+	ErrInviteClosed = errors.New("invitation no longer valid")
+
+	// ErrInviteEmailMismatch is returned when the accepting user's email differs from the invited address
+	ErrInviteEmailMismatch = errors.New("invitation was sent to a different email address")
+)
+
+// CreateOrgInvite stores a new invitation. Returns ErrInvitePending if the address
+// already has an open invitation to the organization.
+func (s *service) CreateOrgInvite(ctx context.Context, invite *Organization_invites) (*Organization_invites, error) {
+	query := `INSERT INTO organization_invites (organization_id, email, role, token_hash, invited_by, expires_at)
+		VALUES (:organization_id, :email, :role, :token_hash, :invited_by, :expires_at)
+		ON CONFLICT (organization_id, lower(email)) WHERE accepted_at IS NULL AND revoked_at IS NULL DO NOTHING
+		RETURNING *`
+
+	query, args, err := s.db.BindNamed(query, invite)
+	if err != nil {
+		return nil, err
+	}
+
+	var created Organization_invites
+	if err := s.db.QueryRowxContext(ctx, query, args...).StructScan(&created); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrInvitePending
+		}
+		return nil, err
+	}
+	return &created, nil
+}
+
+// ListPendingOrgInvites returns invitations that were neither accepted nor revoked,
+// including expired ones so admins can resend them
+func (s *service) ListPendingOrgInvites(ctx context.Context, orgID string) ([]Organization_invites, error) {
+	var invites []Organization_invites
+	query := `SELECT * FROM organization_invites
+		WHERE organization_id = $1 AND accepted_at IS NULL AND revoked_at IS NULL
+		ORDER BY created_at DESC`
+	err := s.db.SelectContext(ctx, &invites, query, orgID)
+	return invites, err
+}
+
+// ResendOrgInvite replaces the token of a pending invitation and extends its expiry;
+// links from earlier emails stop working. Returns sql.ErrNoRows if no pending
+// invitation matches.
+func (s *service) ResendOrgInvite(ctx context.Context, orgID, inviteID, tokenHash string, expiresAt time.Time) (*Organization_invites, error) {
+	var invite Organization_invites
+	query := `UPDATE organization_invites
+		SET token_hash = $3, expires_at = $4, send_count = send_count + 1, last_sent_at = NOW()
+		WHERE id = $1 AND organization_id = $2 AND accepted_at IS NULL AND revoked_at IS NULL
+		RETURNING *`
+	err := s.db.GetContext(ctx, &invite, query, inviteID, orgID, tokenHash, expiresAt)
+	if err != nil {
+		return nil, err
+	}
+	return &invite, nil
+}
+
+// RevokeOrgInvite cancels a pending invitation.
+// Returns sql.ErrNoRows if no pending invitation matches.
+func (s *service) RevokeOrgInvite(ctx context.Context, orgID, inviteID string) error {
+	query := `UPDATE organization_invites SET revoked_at = NOW()
+		WHERE id = $1 AND organization_id = $2 AND accepted_at IS NULL AND revoked_at IS NULL`
+	result, err := s.db.ExecContext(ctx, query, inviteID, orgID)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// AcceptOrgInvite adds the user to the invitation's organization with the invited
+// role. Users who are already members keep their current role. Returns
+// sql.ErrNoRows for unknown tokens.
+func (s *service) AcceptOrgInvite(ctx context.Context, tokenHash, userID string) (*Organization_members, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var invite Organization_invites
+	err = tx.GetContext(ctx, &invite,
+		`SELECT * FROM organization_invites WHERE token_hash = $1 FOR UPDATE`, tokenHash)
+	if err != nil {
+		return nil, err
+	}
+	if invite.Accepted_at != nil || invite.Revoked_at != nil {
+		return nil, ErrInviteClosed
+	}
+	if time.Now().After(invite.Expires_at) {
+		return nil, ErrInviteExpired
+	}
+
+	var email sql.NullString
+	if err := tx.GetContext(ctx, &email, `SELECT email FROM users WHERE id = $1`, userID); err != nil {
+		return nil, fmt.Errorf("failed to load user: %w", err)
+	}
+	if !email.Valid || !strings.EqualFold(email.String, invite.Email) {
+		return nil, ErrInviteEmailMismatch
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO organization_members (organization_id, user_id, role) VALUES ($1, $2, $3)
+		ON CONFLICT (organization_id, user_id) DO NOTHING`,
+		invite.Organization_id, userID, invite.Role)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add organization member: %w", err)
+	}
+
+	var member Organization_members
+	err = tx.GetContext(ctx, &member,
+		`SELECT * FROM organization_members WHERE organization_id = $1 AND user_id = $2`,
+		invite.Organization_id, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load organization member: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`UPDATE organization_invites SET accepted_at = NOW(), accepted_by = $2 WHERE id = $1`,
+		invite.Id, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to mark invitation accepted: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit invitation: %w", err)
+	}
+	return &member, nil
+}
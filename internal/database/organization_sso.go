@@ -0,0 +1,77 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+)
+
+// GetOrganizationSSO returns the organization's single sign-on configuration.
+// Returns sql.ErrNoRows if SSO is not configured.
+func (s *service) GetOrganizationSSO(ctx context.Context, orgID string) (*Organization_sso, error) {
+	var config Organization_sso
+	err := s.db.GetContext(ctx, &config, `SELECT * FROM organization_sso WHERE organization_id = $1`, orgID)
+	if err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// UpsertOrganizationSSO creates or replaces the organization's single sign-on configuration
+func (s *service) UpsertOrganizationSSO(ctx context.Context, config *Organization_sso) (*Organization_sso, error) {
+	query := `INSERT INTO organization_sso (organization_id, protocol, issuer, client_id, authorization_endpoint,
+			jwks_url, role_claim, admin_role_values, jit_provisioning, enabled)
+		VALUES (:organization_id, :protocol, :issuer, :client_id, :authorization_endpoint,
+			:jwks_url, :role_claim, :admin_role_values, :jit_provisioning, :enabled)
+		ON CONFLICT (organization_id) DO UPDATE SET
+			protocol = EXCLUDED.protocol,
+			issuer = EXCLUDED.issuer,
+			client_id = EXCLUDED.client_id,
+			authorization_endpoint = EXCLUDED.authorization_endpoint,
+			jwks_url = EXCLUDED.jwks_url,
+			role_claim = EXCLUDED.role_claim,
+			admin_role_values = EXCLUDED.admin_role_values,
+			jit_provisioning = EXCLUDED.jit_provisioning,
+			enabled = EXCLUDED.enabled,
+			updated_at = NOW()
+		RETURNING *`
+
+	query, args, err := s.db.BindNamed(query, config)
+	if err != nil {
+		return nil, err
+	}
+
+	var saved Organization_sso
+	if err := s.db.QueryRowxContext(ctx, query, args...).StructScan(&saved); err != nil {
+		return nil, err
+	}
+	return &saved, nil
+}
+
+// DeleteOrganizationSSO removes the organization's single sign-on configuration.
+// Returns sql.ErrNoRows if SSO is not configured.
+func (s *service) DeleteOrganizationSSO(ctx context.Context, orgID string) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM organization_sso WHERE organization_id = $1`, orgID)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// SyncSSOMember records the role mapped from the identity provider for a member signing in
+// through SSO, adding them to the organization if needed. The owner's role is never changed,
+// and deactivated members stay deactivated.
+func (s *service) SyncSSOMember(ctx context.Context, orgID, userID, role string) (*Organization_members, error) {
+	var member Organization_members
+	query := `INSERT INTO organization_members (organization_id, user_id, role) VALUES ($1, $2, $3)
+		ON CONFLICT (organization_id, user_id) DO UPDATE SET
+			role = CASE WHEN organization_members.role = 'owner' THEN 'owner' ELSE EXCLUDED.role END,
+			updated_at = NOW()
+		RETURNING *`
+	if err := s.db.GetContext(ctx, &member, query, orgID, userID, role); err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
@@ -0,0 +1,64 @@
+package database
+
+import (
+	"context"
+	"fmt"
+)
+
+// CreateOrganization creates an organization with ownerID as its owner
+func (s *service) CreateOrganization(ctx context.Context, name, ownerID string) (*Organizations, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var org Organizations
+	err = tx.GetContext(ctx, &org,
+		`INSERT INTO organizations (name, created_by) VALUES ($1, $2) RETURNING *`, name, ownerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create organization: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO organization_members (organization_id, user_id, role) VALUES ($1, $2, 'owner')`,
+		org.Id, ownerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add organization owner: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit organization: %w", err)
+	}
+	return &org, nil
+}
+
+// GetOrganizationByID returns the organization with the given ID
+func (s *service) GetOrganizationByID(ctx context.Context, id string) (*Organizations, error) {
+	var org Organizations
+	err := s.db.GetContext(ctx, &org, `SELECT * FROM organizations WHERE id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+	return &org, nil
+}
+
+// ListOrganizationsByUser returns the organizations the user belongs to
+func (s *service) ListOrganizationsByUser(ctx context.Context, userID string) ([]Organizations, error) {
+	var orgs []Organizations
+	query := `SELECT o.* FROM organizations o
+		JOIN organization_members m ON m.organization_id = o.id
+		WHERE m.user_id = $1 AND m.active
+		ORDER BY o.name`
+	err := s.db.SelectContext(ctx, &orgs, query, userID)
+	return orgs, err
+}
+
+// GetOrganizationRole returns the user's role in the organization.
+// Returns sql.ErrNoRows if the user is not an active member.
+func (s *service) GetOrganizationRole(ctx context.Context, orgID, userID string) (string, error) {
+	var role string
+	query := `SELECT role FROM organization_members WHERE organization_id = $1 AND user_id = $2 AND active`
+	err := s.db.GetContext(ctx, &role, query, orgID, userID)
+	return role, err
+}
@@ -0,0 +1,47 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/shopspring/decimal"
+)
+
+// PersonalRecord is a logged lift heavier than anything the user lifted before for that exercise
+type PersonalRecord struct {
+	User_id             string          `db:"user_id"`
+	Exercise_id         string          `db:"exercise_id"`
+	Workout_id          string          `db:"workout_id"`
+	Workout_exercise_id string          `db:"workout_exercise_id"`
+	Weight_kg           decimal.Decimal `db:"weight_kg"`
+	Reps                int             `db:"reps"`
+	Previous_best_kg    decimal.Decimal `db:"previous_best_kg"`
+}
+
+// GetPersonalRecord reports whether a workout exercise beats the user's previous best weight
+// for the exercise, returning nil if it doesn't. Templates are plans rather than lifts, and a
+// first attempt at an exercise has nothing to beat, so neither counts.
+func (s *service) GetPersonalRecord(ctx context.Context, workoutExerciseID string) (*PersonalRecord, error) {
+	var record PersonalRecord
+	query := `SELECT w.user_id, we.exercise_id, we.workout_id, we.id AS workout_exercise_id,
+			we.weight_kg, we.reps, best.weight_kg AS previous_best_kg
+		FROM workout_exercises we
+		JOIN workouts w ON w.id = we.workout_id
+		CROSS JOIN LATERAL (
+			SELECT MAX(o.weight_kg) AS weight_kg
+			FROM workout_exercises o
+			JOIN workouts ow ON ow.id = o.workout_id
+			WHERE ow.user_id = w.user_id AND NOT ow.is_template
+				AND o.exercise_id = we.exercise_id AND o.id <> we.id
+		) best
+		WHERE we.id = $1 AND NOT w.is_template AND best.weight_kg > 0 AND we.weight_kg > best.weight_kg`
+	err := s.db.GetContext(ctx, &record, query, workoutExerciseID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
@@ -0,0 +1,12 @@
+// Package postgres embeds the Postgres server that standalone mode runs, so a standalone
+// binary needs nothing downloaded or installed next to it
+package postgres
+
+import "embed"
+
+// Archives holds the Postgres server archives `make standalone` adds before building, named
+// GOOS-GOARCH-VERSION.txz. The repository doesn't include any, so the pattern also matches
+// this directory's other files to keep builds without them compiling.
+//
+//go:embed *
+var Archives embed.FS
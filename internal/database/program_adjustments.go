@@ -0,0 +1,211 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// ProgramSessionStats summarizes a session of one of a program's workouts, for deciding
+// how to adjust the program's next week
+type ProgramSessionStats struct {
+	Session_id   string    `db:"session_id"`
+	Started_at   time.Time `db:"started_at"`
+	Planned_sets int       `db:"planned_sets"`
+	Logged_sets  int       `db:"logged_sets"`
+	Rpe          *int16    `db:"rpe"`
+	Pain         bool      `db:"pain"`
+}
+
+var programAdjustmentList = listSpec{
+	sorts:        map[string]string{"created_at": "created_at", "week": "week"},
+	filters:      map[string]string{"status": "status", "kind": "kind"},
+	defaultOrder: "created_at DESC",
+	tiebreak:     "id",
+}
+
+// programWeekSQL numbers the week of a program $1 falls in, week 1 starting at started_at
+const programWeekSQL = `(floor(extract(epoch FROM $1::timestamptz - started_at) / 604800)::int + 1)`
+
+// ListProgramsDueForAdjustment returns active programs that have moved into a week the
+// adjustment job hasn't evaluated yet, leaving out their first week and weeks past their
+// duration
+func (s *service) ListProgramsDueForAdjustment(ctx context.Context, now time.Time, limit int) ([]Programs, error) {
+	programs := []Programs{}
+	query := `SELECT * FROM programs
+		WHERE is_active AND started_at <= $1::timestamptz - INTERVAL '7 days'
+			AND ` + programWeekSQL + ` > adjusted_week
+			AND (duration_weeks IS NULL OR duration_weeks <= 0 OR ` + programWeekSQL + ` <= duration_weeks)
+		ORDER BY started_at, id
+		LIMIT $2`
+	if err := s.db.SelectContext(ctx, &programs, query, now, limit); err != nil {
+		return nil, fmt.Errorf("failed to list programs due for adjustment: %w", err)
+	}
+	return programs, nil
+}
+
+// MarkProgramAdjusted records that the adjustment job has evaluated the program's week
+func (s *service) MarkProgramAdjusted(ctx context.Context, programID string, week int) error {
+	_, err := s.db.ExecContext(ctx, `UPDATE programs SET adjusted_week = $2 WHERE id = $1 AND adjusted_week < $2`, programID, week)
+	if err != nil {
+		return fmt.Errorf("failed to mark program adjusted: %w", err)
+	}
+	return nil
+}
+
+// CountProgramWorkouts returns how many workouts, not counting templates, the program has
+func (s *service) CountProgramWorkouts(ctx context.Context, programID string) (int, error) {
+	var count int
+	err := s.db.GetContext(ctx, &count, `SELECT COUNT(*) FROM workouts WHERE program_id = $1 AND NOT is_template`, programID)
+	return count, err
+}
+
+// ListProgramSessionStats returns the user's sessions of the program's workouts started
+// between from and to, with the sets their plan called for, the sets logged and any
+// feedback given
+func (s *service) ListProgramSessionStats(ctx context.Context, programID, userID string, from, to time.Time) ([]ProgramSessionStats, error) {
+	stats := []ProgramSessionStats{}
+	query := `SELECT ws.id AS session_id, ws.started_at,
+			COALESCE((SELECT SUM((p->>'sets')::int) FROM jsonb_array_elements(COALESCE(ws.plan, '[]'::jsonb)) p), 0) AS planned_sets,
+			(SELECT COUNT(*) FROM workout_session_sets ss WHERE ss.session_id = ws.id) AS logged_sets,
+			sf.rpe, COALESCE(jsonb_array_length(sf.pain_areas) > 0, false) AS pain
+		FROM workout_sessions ws
+		JOIN workouts w ON w.id = ws.workout_id
+		LEFT JOIN session_feedback sf ON sf.session_id = ws.id
+		WHERE w.program_id = $1 AND ws.user_id = $2 AND ws.started_at >= $3 AND ws.started_at < $4
+		ORDER BY ws.started_at`
+	if err := s.db.SelectContext(ctx, &stats, query, programID, userID, from, to); err != nil {
+		return nil, fmt.Errorf("failed to list program session stats: %w", err)
+	}
+	return stats, nil
+}
+
+// ListProgramWeeks returns the weeks of a program whose volume or deload was changed, in week order
+func (s *service) ListProgramWeeks(ctx context.Context, programID string) ([]Program_weeks, error) {
+	weeks := []Program_weeks{}
+	if err := s.db.SelectContext(ctx, &weeks, `SELECT * FROM program_weeks WHERE program_id = $1 ORDER BY week`, programID); err != nil {
+		return nil, fmt.Errorf("failed to list program weeks: %w", err)
+	}
+	return weeks, nil
+}
+
+// applyProgramWeekSQL applies the adjustments selected from a CTE named by %s to their weeks
+const applyProgramWeekSQL = `INSERT INTO program_weeks (program_id, week, volume_percent, deload)
+			SELECT program_id, week, volume_percent, deload FROM %s WHERE status = 'applied'
+			ON CONFLICT (program_id, week) DO UPDATE
+				SET volume_percent = EXCLUDED.volume_percent, deload = EXCLUDED.deload, updated_at = NOW()`
+
+// CreateProgramAdjustment records an adjustment, applying it to its week straight away
+// when its status is applied. Returns sql.ErrNoRows if the week already has an adjustment.
+func (s *service) CreateProgramAdjustment(ctx context.Context, adj *Program_adjustments) (*Program_adjustments, error) {
+	var saved Program_adjustments
+	query := `WITH saved AS (
+			INSERT INTO program_adjustments (program_id, user_id, week, kind, volume_percent, deload, reason, metrics, status, applied_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, CASE WHEN $9::text = 'applied' THEN NOW() END)
+			ON CONFLICT (program_id, week) DO NOTHING
+			RETURNING *
+		), applied AS (
+			` + fmt.Sprintf(applyProgramWeekSQL, "saved") + `
+		)
+		SELECT * FROM saved`
+	err := s.db.GetContext(ctx, &saved, query, adj.Program_id, adj.User_id, adj.Week, adj.Kind, adj.Volume_percent,
+		adj.Deload, adj.Reason, adj.Metrics, adj.Status)
+	if err != nil {
+		return nil, err
+	}
+	return &saved, nil
+}
+
+// GetProgramAdjustment returns an adjustment by ID, or sql.ErrNoRows
+func (s *service) GetProgramAdjustment(ctx context.Context, id string) (*Program_adjustments, error) {
+	var adj Program_adjustments
+	if err := s.db.GetContext(ctx, &adj, `SELECT * FROM program_adjustments WHERE id = $1`, id); err != nil {
+		return nil, err
+	}
+	return &adj, nil
+}
+
+// ListProgramAdjustments returns every adjustment made to a program, newest first by default
+func (s *service) ListProgramAdjustments(ctx context.Context, programID string, opts ListOptions) ([]Program_adjustments, error) {
+	query, args, err := opts.apply(`SELECT * FROM program_adjustments WHERE program_id = $1`, []interface{}{programID}, programAdjustmentList)
+	if err != nil {
+		return nil, err
+	}
+	adjustments := []Program_adjustments{}
+	if err := s.db.SelectContext(ctx, &adjustments, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to list program adjustments: %w", err)
+	}
+	return adjustments, nil
+}
+
+// ListPendingProgramAdjustments returns the adjustments awaiting review on programs the coach
+// manages, oldest first unless opts sorts them otherwise
+func (s *service) ListPendingProgramAdjustments(ctx context.Context, coachID string, opts ListOptions) ([]Program_adjustments, error) {
+	if opts.Sort == "" {
+		opts.Sort, opts.Order = "created_at", "asc"
+	}
+	query, args, err := opts.apply(`SELECT * FROM program_adjustments
+		WHERE status = 'pending' AND program_id IN (SELECT id FROM programs WHERE coach_id = $1)`, []interface{}{coachID}, programAdjustmentList)
+	if err != nil {
+		return nil, err
+	}
+	adjustments := []Program_adjustments{}
+	if err := s.db.SelectContext(ctx, &adjustments, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to list pending program adjustments: %w", err)
+	}
+	return adjustments, nil
+}
+
+// ReviewProgramAdjustment approves or rejects a pending adjustment, applying it to its
+// week when status is applied. Returns sql.ErrNoRows if the adjustment does not exist or
+// is no longer pending.
+func (s *service) ReviewProgramAdjustment(ctx context.Context, id, reviewerID string, status Program_adjustments_status, note string) (*Program_adjustments, error) {
+	var reviewed Program_adjustments
+	query := `WITH reviewed AS (
+			UPDATE program_adjustments
+			SET status = $3, reviewed_by = $2, reviewed_at = NOW(), review_note = $4,
+				applied_at = CASE WHEN $3::text = 'applied' THEN NOW() END
+			WHERE id = $1 AND status = 'pending'
+			RETURNING *
+		), applied AS (
+			` + fmt.Sprintf(applyProgramWeekSQL, "reviewed") + `
+		)
+		SELECT * FROM reviewed`
+	if err := s.db.GetContext(ctx, &reviewed, query, id, reviewerID, status, note); err != nil {
+		return nil, err
+	}
+	return &reviewed, nil
+}
+
+// SetProgramCoach assigns the program's coach, nil to manage it without one. Adjustments
+// still awaiting the previous coach's review are rejected.
+func (s *service) SetProgramCoach(ctx context.Context, programID string, coachID *string) (*Programs, error) {
+	var program Programs
+	query := `WITH rejected AS (
+			UPDATE program_adjustments
+			SET status = 'rejected', reviewed_at = NOW(), review_note = 'Coach changed before review'
+			WHERE program_id = $1 AND status = 'pending'
+				AND (SELECT coach_id FROM programs WHERE id = $1) IS DISTINCT FROM $2::uuid
+		)
+		UPDATE programs SET coach_id = $2, updated_at = NOW(), version = version + 1
+		WHERE id = $1
+		RETURNING *`
+	if err := s.db.GetContext(ctx, &program, query, programID, coachID); err != nil {
+		return nil, err
+	}
+	return &program, nil
+}
+
+// IsOrganizationCoach reports whether coachID is an owner or admin of an organization
+// userID is an active member of
+func (s *service) IsOrganizationCoach(ctx context.Context, coachID, userID string) (bool, error) {
+	var coach bool
+	query := `SELECT EXISTS (
+			SELECT 1 FROM organization_members c
+			JOIN organization_members m ON m.organization_id = c.organization_id
+			WHERE c.user_id = $1 AND c.role IN ('owner', 'admin') AND c.active
+				AND m.user_id = $2 AND m.active
+		)`
+	err := s.db.GetContext(ctx, &coach, query, coachID, userID)
+	return coach, err
+}
@@ -0,0 +1,95 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ProgramRepository stores training programs
+type ProgramRepository interface {
+	CreateProgram(ctx context.Context, program *Programs) (*Programs, error)
+	GetProgramByID(ctx context.Context, id string) (*Programs, error)
+	ListPrograms(ctx context.Context, opts ListOptions) ([]Programs, error)
+	UpdateProgram(ctx context.Context, program *Programs) (*Programs, error)
+	DeleteProgram(ctx context.Context, id string) error
+}
+
+type programRepository struct {
+	db *sqlx.DB
+}
+
+// NewProgramRepository returns a ProgramRepository that uses db
+func NewProgramRepository(db *sqlx.DB) ProgramRepository {
+	return &programRepository{db: db}
+}
+
+func (r *programRepository) CreateProgram(ctx context.Context, program *Programs) (*Programs, error) {
+	query := `INSERT INTO programs (id, name, description, user_id, duration_weeks, difficulty, is_active, created_at, updated_at, started_at, deload_every_weeks, coach_id)
+		VALUES (:id, :name, :description, :user_id, :duration_weeks, :difficulty, :is_active, :created_at, :updated_at, :started_at, :deload_every_weeks, :coach_id)
+		RETURNING *`
+	row, err := r.db.NamedQueryContext(ctx, query, program)
+	if err != nil {
+		return nil, TranslateError(err)
+	}
+	defer row.Close()
+	if row.Next() {
+		var created Programs
+		if err := row.StructScan(&created); err != nil {
+			return nil, err
+		}
+		return &created, nil
+	}
+	if err := row.Err(); err != nil {
+		return nil, TranslateError(err)
+	}
+	return nil, fmt.Errorf("failed to insert program")
+}
+
+func (r *programRepository) GetProgramByID(ctx context.Context, id string) (*Programs, error) {
+	var program Programs
+	query := `SELECT * FROM programs WHERE id = $1`
+	err := r.db.GetContext(ctx, &program, query, id)
+	if err != nil {
+		return nil, err
+	}
+	return &program, nil
+}
+
+// ListPrograms lists programs, newest first by default
+func (r *programRepository) ListPrograms(ctx context.Context, opts ListOptions) ([]Programs, error) {
+	query, args, err := opts.apply(`SELECT * FROM programs WHERE TRUE`, nil, programList)
+	if err != nil {
+		return nil, err
+	}
+	var rows []Programs
+	err = r.db.SelectContext(ctx, &rows, query, args...)
+	return rows, err
+}
+
+func (r *programRepository) UpdateProgram(ctx context.Context, program *Programs) (*Programs, error) {
+	query := `UPDATE programs SET name=:name, description=:description, user_id=:user_id, duration_weeks=:duration_weeks, difficulty=:difficulty, is_active=:is_active, updated_at=:updated_at, started_at=:started_at, deload_every_weeks=:deload_every_weeks, version=version+1 WHERE id=:id AND version=:version RETURNING *`
+	row, err := r.db.NamedQueryContext(ctx, query, program)
+	if err != nil {
+		return nil, TranslateError(err)
+	}
+	defer row.Close()
+	if row.Next() {
+		var updated Programs
+		if err := row.StructScan(&updated); err != nil {
+			return nil, err
+		}
+		return &updated, nil
+	}
+	if err := row.Err(); err != nil {
+		return nil, TranslateError(err)
+	}
+	return nil, ErrVersionConflict
+}
+
+func (r *programRepository) DeleteProgram(ctx context.Context, id string) error {
+	query := `DELETE FROM programs WHERE id = $1`
+	_, err := r.db.ExecContext(ctx, query, id)
+	return TranslateError(err)
+}
@@ -0,0 +1,245 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"fitness-hack/internal/database/sqlbuild"
+)
+
+// ErrPhotoVaultExists is returned when the user already has a photo vault
+var ErrPhotoVaultExists = errors.New("photo vault already exists")
+
+// BodyCompositionMetrics are the body metrics shown next to compared progress photos
+var BodyCompositionMetrics = []string{"weight_kg", "body_fat_percent", "lean_body_mass_kg"}
+
+// CreateProgressPhoto records a photo whose image has been stored under its storage key,
+// or a pending photo whose image is yet to be uploaded there. The ID is chosen by the
+// caller, since it is part of the storage key. Returns a *QuotaError if the image would
+// take the user past their photo storage quota.
+func (s *service) CreateProgressPhoto(ctx context.Context, photo *Progress_photos) (*Progress_photos, error) {
+	if err := s.quotas.check(ctx, photo.User_id, QuotaPhotoStorage, photo.Size_bytes); err != nil {
+		return nil, err
+	}
+	var created Progress_photos
+	query := `INSERT INTO progress_photos (id, user_id, pose, taken_at, notes, content_type, size_bytes, storage_key,
+			thumbnail_key, vaulted, status, width, height, thumbnail_width, thumbnail_height)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+		RETURNING *`
+	err := s.db.GetContext(ctx, &created, query, photo.Id, photo.User_id, photo.Pose, photo.Taken_at, photo.Notes,
+		photo.Content_type, photo.Size_bytes, photo.Storage_key, photo.Thumbnail_key, photo.Vaulted,
+		photo.Status, photo.Width, photo.Height, photo.Thumbnail_width, photo.Thumbnail_height)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create progress photo: %w", err)
+	}
+	return &created, nil
+}
+
+// GetProgressPhoto returns one of the user's uploaded photos, or sql.ErrNoRows
+func (s *service) GetProgressPhoto(ctx context.Context, id, userID string) (*Progress_photos, error) {
+	var photo Progress_photos
+	query := `SELECT * FROM progress_photos WHERE id = $1 AND user_id = $2 AND status = 'ready'`
+	if err := s.db.GetContext(ctx, &photo, query, id, userID); err != nil {
+		return nil, err
+	}
+	return &photo, nil
+}
+
+// GetProgressPhotoUpload returns one of the user's photos whether or not its upload has
+// been confirmed, or sql.ErrNoRows
+func (s *service) GetProgressPhotoUpload(ctx context.Context, id, userID string) (*Progress_photos, error) {
+	var photo Progress_photos
+	query := `SELECT * FROM progress_photos WHERE id = $1 AND user_id = $2`
+	if err := s.db.GetContext(ctx, &photo, query, id, userID); err != nil {
+		return nil, err
+	}
+	return &photo, nil
+}
+
+// ListProgressPhotosOpts narrows ListProgressPhotos. Zero fields don't filter, and they
+// combine with the sorting, paging and equality filters of ListOptions.
+type ListProgressPhotosOpts struct {
+	ListOptions
+
+	// From and To keep photos taken in [From, To)
+	From time.Time
+	To   time.Time
+}
+
+// query builds the SELECT of the user's uploaded photos for the options
+func (o ListProgressPhotosOpts) query(userID string) (string, []interface{}, error) {
+	q := sqlbuild.New(`SELECT * FROM progress_photos WHERE user_id = $1 AND status = 'ready'`, userID)
+	if !o.From.IsZero() {
+		q.Compare(progressPhotoList.sorts, "taken_at", sqlbuild.Gte, o.From)
+	}
+	if !o.To.IsZero() {
+		q.Compare(progressPhotoList.sorts, "taken_at", sqlbuild.Lt, o.To)
+	}
+	return o.ListOptions.build(q, progressPhotoList)
+}
+
+// ListProgressPhotos returns the user's uploaded photos matching opts, newest first by
+// default, vaulted ones included
+func (s *service) ListProgressPhotos(ctx context.Context, userID string, opts ListProgressPhotosOpts) ([]Progress_photos, error) {
+	query, args, err := opts.query(userID)
+	if err != nil {
+		return nil, err
+	}
+	photos := []Progress_photos{}
+	err = s.db.SelectContext(ctx, &photos, query, args...)
+	return photos, err
+}
+
+var progressPhotoList = listSpec{
+	sorts:        map[string]string{"taken_at": "taken_at", "created_at": "created_at"},
+	filters:      map[string]string{"pose": "pose", "vaulted": "vaulted::text"},
+	defaultOrder: "taken_at DESC, id",
+	tiebreak:     "id",
+}
+
+// CompleteProgressPhoto records that a pending photo's image was uploaded and its
+// thumbnail made, taking the size, dimensions and thumbnail from photo. Returns
+// sql.ErrNoRows if the user has no such pending photo, and a *QuotaError if the image
+// takes them past their photo storage quota.
+func (s *service) CompleteProgressPhoto(ctx context.Context, photo *Progress_photos) (*Progress_photos, error) {
+	if err := s.quotas.check(ctx, photo.User_id, QuotaPhotoStorage, photo.Size_bytes); err != nil {
+		return nil, err
+	}
+	var completed Progress_photos
+	query := `UPDATE progress_photos
+		SET status = 'ready', size_bytes = $3, width = $4, height = $5,
+			thumbnail_key = $6, thumbnail_width = $7, thumbnail_height = $8, updated_at = NOW()
+		WHERE id = $1 AND user_id = $2 AND status = 'pending'
+		RETURNING *`
+	err := s.db.GetContext(ctx, &completed, query, photo.Id, photo.User_id, photo.Size_bytes, photo.Width, photo.Height,
+		photo.Thumbnail_key, photo.Thumbnail_width, photo.Thumbnail_height)
+	if err != nil {
+		return nil, err
+	}
+	return &completed, nil
+}
+
+// MoveProgressPhoto records that a photo's image was moved into or out of the vault, taking
+// its new vaulted flag, storage key and thumbnail from photo; vaulted photos have no
+// thumbnail. Returns sql.ErrNoRows if the user has no such photo.
+func (s *service) MoveProgressPhoto(ctx context.Context, photo *Progress_photos) (*Progress_photos, error) {
+	var moved Progress_photos
+	query := `UPDATE progress_photos
+		SET vaulted = $3, storage_key = $4, thumbnail_key = $5, thumbnail_width = $6, thumbnail_height = $7, updated_at = NOW()
+		WHERE id = $1 AND user_id = $2 AND status = 'ready'
+		RETURNING *`
+	err := s.db.GetContext(ctx, &moved, query, photo.Id, photo.User_id, photo.Vaulted, photo.Storage_key,
+		photo.Thumbnail_key, photo.Thumbnail_width, photo.Thumbnail_height)
+	if err != nil {
+		return nil, err
+	}
+	return &moved, nil
+}
+
+// DeleteProgressPhoto deletes one of the user's photos, pending ones included, and returns
+// it, so the caller can delete its stored files. Returns sql.ErrNoRows if the user has no such photo.
+func (s *service) DeleteProgressPhoto(ctx context.Context, id, userID string) (*Progress_photos, error) {
+	var photo Progress_photos
+	query := `DELETE FROM progress_photos WHERE id = $1 AND user_id = $2 RETURNING *`
+	if err := s.db.GetContext(ctx, &photo, query, id, userID); err != nil {
+		return nil, err
+	}
+	return &photo, nil
+}
+
+// DeleteAbandonedProgressPhotos deletes up to limit photos whose upload was started before
+// the cutoff and never confirmed, and returns them so the caller can delete any file that
+// was uploaded.
+func (s *service) DeleteAbandonedProgressPhotos(ctx context.Context, before time.Time, limit int) ([]Progress_photos, error) {
+	photos := []Progress_photos{}
+	query := `DELETE FROM progress_photos WHERE id IN (
+			SELECT id FROM progress_photos WHERE status = 'pending' AND created_at < $1 LIMIT $2
+		)
+		RETURNING *`
+	if err := s.db.SelectContext(ctx, &photos, query, before, limit); err != nil {
+		return nil, fmt.Errorf("failed to delete abandoned progress photos: %w", err)
+	}
+	return photos, nil
+}
+
+// GetBodyMetricsNear returns, for each of the metrics, the user's measurement recorded
+// closest to at and no further than window from it. Metrics without one are left out.
+func (s *service) GetBodyMetricsNear(ctx context.Context, userID string, at time.Time, window time.Duration, metrics []string) ([]Body_metrics, error) {
+	nearest := []Body_metrics{}
+	query := `SELECT DISTINCT ON (metric) * FROM body_metrics
+		WHERE user_id = $1 AND metric = ANY($2)
+			AND recorded_at BETWEEN $3::timestamptz - $4::interval AND $3::timestamptz + $4::interval
+		ORDER BY metric, abs(extract(epoch FROM recorded_at - $3::timestamptz)), recorded_at DESC`
+	err := s.db.SelectContext(ctx, &nearest, query, userID, metrics, at, fmt.Sprintf("%d seconds", int(window.Seconds())))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find body metrics: %w", err)
+	}
+	return nearest, nil
+}
+
+// CreatePhotoVault stores the user's vault. Returns ErrPhotoVaultExists if they already
+// have one.
+func (s *service) CreatePhotoVault(ctx context.Context, userID string, pinSalt, wrappedKey []byte) (*Photo_vaults, error) {
+	var vault Photo_vaults
+	err := s.db.GetContext(ctx, &vault, `INSERT INTO photo_vaults (user_id, pin_salt, wrapped_key) VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO NOTHING
+		RETURNING *`, userID, pinSalt, wrappedKey)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrPhotoVaultExists
+		}
+		return nil, fmt.Errorf("failed to create photo vault: %w", err)
+	}
+	return &vault, nil
+}
+
+// GetPhotoVault returns the user's vault, or sql.ErrNoRows if they haven't set one up
+func (s *service) GetPhotoVault(ctx context.Context, userID string) (*Photo_vaults, error) {
+	var vault Photo_vaults
+	if err := s.db.GetContext(ctx, &vault, `SELECT * FROM photo_vaults WHERE user_id = $1`, userID); err != nil {
+		return nil, err
+	}
+	return &vault, nil
+}
+
+// ChangePhotoVaultPIN replaces the vault's salt and wrapped key after a PIN change
+func (s *service) ChangePhotoVaultPIN(ctx context.Context, userID string, pinSalt, wrappedKey []byte) error {
+	result, err := s.db.ExecContext(ctx, `UPDATE photo_vaults
+		SET pin_salt = $2, wrapped_key = $3, failed_attempts = 0, locked_until = NULL, updated_at = NOW()
+		WHERE user_id = $1`, userID, pinSalt, wrappedKey)
+	if err != nil {
+		return fmt.Errorf("failed to change photo vault pin: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// RecordPhotoVaultAttempt records the outcome of a PIN check. A correct PIN clears the
+// failure count; the maxAttempts-th wrong PIN in a row locks the vault for lockout and
+// starts the count again.
+func (s *service) RecordPhotoVaultAttempt(ctx context.Context, userID string, succeeded bool, maxAttempts int, lockout time.Duration) (*Photo_vaults, error) {
+	var vault Photo_vaults
+	query := `UPDATE photo_vaults
+		SET failed_attempts = CASE
+				WHEN $2 THEN 0
+				WHEN failed_attempts + 1 >= $3 THEN 0
+				ELSE failed_attempts + 1
+			END,
+			locked_until = CASE
+				WHEN $2 THEN NULL
+				WHEN failed_attempts + 1 >= $3 THEN NOW() + $4::interval
+				ELSE locked_until
+			END
+		WHERE user_id = $1
+		RETURNING *`
+	err := s.db.GetContext(ctx, &vault, query, userID, succeeded, maxAttempts, fmt.Sprintf("%d seconds", int(lockout.Seconds())))
+	if err != nil {
+		return nil, fmt.Errorf("failed to record photo vault attempt: %w", err)
+	}
+	return &vault, nil
+}
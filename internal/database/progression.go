@@ -0,0 +1,141 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// ProgressionStep is a step a session took, with the workout exercise it moved on
+type ProgressionStep struct {
+	Progression_steps
+	Workout_exercise_id string `db:"workout_exercise_id"`
+	Exercise_id         string `db:"exercise_id"`
+}
+
+// UpsertProgressionRule saves the progression rule of a workout exercise, replacing any
+// set before and starting its failures over. created reports whether it had none yet.
+func (s *service) UpsertProgressionRule(ctx context.Context, rule *Progression_rules) (saved *Progression_rules, created bool, err error) {
+	var row struct {
+		Progression_rules
+		Inserted bool `db:"inserted"`
+	}
+	query := `INSERT INTO progression_rules (workout_exercise_id, user_id, weight_increment_kg, min_reps, max_reps,
+			deload_after_failures, deload_percent)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (workout_exercise_id) DO UPDATE SET
+			weight_increment_kg = EXCLUDED.weight_increment_kg,
+			min_reps = EXCLUDED.min_reps,
+			max_reps = EXCLUDED.max_reps,
+			deload_after_failures = EXCLUDED.deload_after_failures,
+			deload_percent = EXCLUDED.deload_percent,
+			failures = 0,
+			updated_at = NOW()
+		RETURNING *, (xmax = 0) AS inserted`
+	err = s.db.GetContext(ctx, &row, query, rule.Workout_exercise_id, rule.User_id, rule.Weight_increment_kg,
+		rule.Min_reps, rule.Max_reps, rule.Deload_after_failures, rule.Deload_percent)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to save progression rule: %w", err)
+	}
+	return &row.Progression_rules, row.Inserted, nil
+}
+
+// GetProgressionRule returns the progression rule of the workout exercise, or sql.ErrNoRows
+func (s *service) GetProgressionRule(ctx context.Context, workoutExerciseID string) (*Progression_rules, error) {
+	var rule Progression_rules
+	err := s.db.GetContext(ctx, &rule, `SELECT * FROM progression_rules WHERE workout_exercise_id = $1`, workoutExerciseID)
+	if err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// DeleteProgressionRule deletes the progression rule of the workout exercise, with its
+// steps. Returns sql.ErrNoRows if it had none.
+func (s *service) DeleteProgressionRule(ctx context.Context, workoutExerciseID string) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM progression_rules WHERE workout_exercise_id = $1`, workoutExerciseID)
+	if err != nil {
+		return fmt.Errorf("failed to delete progression rule: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// ListProgressionRules returns the progression rules of the workout exercises that have one
+func (s *service) ListProgressionRules(ctx context.Context, workoutExerciseIDs []string) ([]Progression_rules, error) {
+	rules := []Progression_rules{}
+	if len(workoutExerciseIDs) == 0 {
+		return rules, nil
+	}
+	err := s.db.SelectContext(ctx, &rules, `SELECT * FROM progression_rules WHERE workout_exercise_id = ANY($1)`, workoutExerciseIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list progression rules: %w", err)
+	}
+	return rules, nil
+}
+
+// ListProgressionSteps returns the steps the session took, in the order of its workout's
+// exercises
+func (s *service) ListProgressionSteps(ctx context.Context, sessionID string) ([]ProgressionStep, error) {
+	steps := []ProgressionStep{}
+	query := `SELECT ps.*, pr.workout_exercise_id, we.exercise_id
+		FROM progression_steps ps
+		JOIN progression_rules pr ON pr.id = ps.rule_id
+		JOIN workout_exercises we ON we.id = pr.workout_exercise_id
+		WHERE ps.session_id = $1
+		ORDER BY we.order_index, we.id`
+	if err := s.db.SelectContext(ctx, &steps, query, sessionID); err != nil {
+		return nil, fmt.Errorf("failed to list progression steps: %w", err)
+	}
+	return steps, nil
+}
+
+// ApplyProgressionSteps records the steps and sets their rules' workout exercises to the
+// reps and weight they prescribe. A rule that already took a step for the session is left
+// alone, so applying a session twice changes nothing. Returns how many steps were applied.
+func (s *service) ApplyProgressionSteps(ctx context.Context, steps []Progression_steps) (int, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	applied := 0
+	for _, step := range steps {
+		var id string
+		err := tx.GetContext(ctx, &id, `INSERT INTO progression_steps (rule_id, session_id, outcome, previous_reps,
+				previous_weight_kg, reps, weight_kg)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+			ON CONFLICT (rule_id, session_id) DO NOTHING
+			RETURNING id`,
+			step.Rule_id, step.Session_id, step.Outcome, step.Previous_reps, step.Previous_weight_kg, step.Reps, step.Weight_kg)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to record progression step: %w", err)
+		}
+
+		_, err = tx.ExecContext(ctx, `UPDATE workout_exercises SET reps = $2, weight_kg = $3, updated_at = NOW(), version = version + 1
+			WHERE id = (SELECT workout_exercise_id FROM progression_rules WHERE id = $1)`,
+			step.Rule_id, step.Reps, step.Weight_kg)
+		if err != nil {
+			return 0, fmt.Errorf("failed to update workout exercise: %w", err)
+		}
+		_, err = tx.ExecContext(ctx, `UPDATE progression_rules
+			SET failures = CASE WHEN $2 = 'repeated' THEN failures + 1 ELSE 0 END, updated_at = NOW()
+			WHERE id = $1`, step.Rule_id, step.Outcome)
+		if err != nil {
+			return 0, fmt.Errorf("failed to update progression rule: %w", err)
+		}
+		applied++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit progression steps: %w", err)
+	}
+	return applied, nil
+}
@@ -0,0 +1,211 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// QuotaResource names user-generated content that per-user quotas limit
+type QuotaResource string
+
+const (
+	// QuotaCustomExercises counts the exercises a user added
+	QuotaCustomExercises QuotaResource = "custom_exercises"
+	// QuotaWorkouts counts a user's workouts, templates included
+	QuotaWorkouts QuotaResource = "workouts"
+	// QuotaPhotoStorage is the size in bytes of a user's progress photos
+	QuotaPhotoStorage QuotaResource = "photo_storage_bytes"
+)
+
+// QuotaResources lists the limited resources in the order usage is reported
+var QuotaResources = []QuotaResource{QuotaCustomExercises, QuotaWorkouts, QuotaPhotoStorage}
+
+// Tier is the subscription tier whose quotas apply to a user
+type Tier string
+
+const (
+	TierFree    Tier = "free"
+	TierPremium Tier = "premium"
+)
+
+// QuotaLimits are the most of each resource one user may have. Resources that are left
+// out or set to 0 are unlimited.
+type QuotaLimits map[QuotaResource]int64
+
+// Quotas are the limits of each tier
+type Quotas map[Tier]QuotaLimits
+
+// quotaEnv are the environment variables of each resource, after QUOTA_<TIER>_, and the
+// unit they are given in
+var quotaEnv = map[QuotaResource]struct {
+	name string
+	unit int64
+}{
+	QuotaCustomExercises: {"CUSTOM_EXERCISES", 1},
+	QuotaWorkouts:        {"WORKOUTS", 1},
+	QuotaPhotoStorage:    {"PHOTO_STORAGE_MB", 1 << 20},
+}
+
+// DefaultQuotas are the limits used when no QUOTA_* variables are set
+var DefaultQuotas = Quotas{
+	TierFree: {
+		QuotaCustomExercises: 100,
+		QuotaWorkouts:        500,
+		QuotaPhotoStorage:    1 << 30,
+	},
+	TierPremium: {
+		QuotaPhotoStorage: 20 << 30,
+	},
+}
+
+// QuotasFromEnv reads the limits of each tier from QUOTA_<TIER>_CUSTOM_EXERCISES,
+// QUOTA_<TIER>_WORKOUTS and QUOTA_<TIER>_PHOTO_STORAGE_MB, e.g. QUOTA_FREE_WORKOUTS.
+// Unset or invalid variables keep the DefaultQuotas limit, and 0 makes it unlimited.
+func QuotasFromEnv() Quotas {
+	quotas := make(Quotas, len(DefaultQuotas))
+	for tier, defaults := range DefaultQuotas {
+		limits := make(QuotaLimits, len(QuotaResources))
+		for _, resource := range QuotaResources {
+			limits[resource] = defaults[resource]
+			env := quotaEnv[resource]
+			value := os.Getenv("QUOTA_" + strings.ToUpper(string(tier)) + "_" + env.name)
+			if n, err := strconv.ParseInt(value, 10, 64); err == nil && n >= 0 {
+				limits[resource] = n * env.unit
+			}
+		}
+		quotas[tier] = limits
+	}
+	return quotas
+}
+
+// ErrQuotaExceeded is matched by every *QuotaError
+var ErrQuotaExceeded = errors.New("quota exceeded")
+
+// QuotaError is returned when saving content would take a user past their tier's quota
+type QuotaError struct {
+	Resource QuotaResource
+	Tier     Tier
+	Limit    int64
+	Used     int64
+	// Upgradable is set when premium has a higher limit than the user's tier
+	Upgradable bool
+}
+
+func (e *QuotaError) Error() string {
+	return fmt.Sprintf("%s quota of the %s tier reached: %d of %d used", e.Resource, e.Tier, e.Used, e.Limit)
+}
+
+// Is makes errors.Is(err, ErrQuotaExceeded) match quota errors
+func (e *QuotaError) Is(target error) bool {
+	return target == ErrQuotaExceeded
+}
+
+// ResourceUsage is how much of a resource a user has, and their limit; 0 is unlimited
+type ResourceUsage struct {
+	Resource QuotaResource
+	Used     int64
+	Limit    int64
+}
+
+// QuotaUsage reports a user's use of each limited resource against their tier's quotas
+type QuotaUsage struct {
+	Tier Tier
+	// PremiumUntil is when premium access ends, nil if the user never had it
+	PremiumUntil *time.Time
+	Resources    []ResourceUsage
+}
+
+// usageQueries count a user's use of each resource
+var usageQueries = map[QuotaResource]string{
+	QuotaCustomExercises: `SELECT COUNT(*) FROM exercises WHERE created_by = $1`,
+	QuotaWorkouts:        `SELECT COUNT(*) FROM workouts WHERE user_id = $1`,
+	QuotaPhotoStorage:    `SELECT COALESCE(SUM(size_bytes), 0) FROM progress_photos WHERE user_id = $1`,
+}
+
+// quotaChecker enforces quotas for the repositories that save limited content. A nil
+// checker enforces nothing, for repositories made outside a Service.
+//
+// Quotas are soft: usage is counted before saving, without locking, so requests racing
+// each other can take a user slightly past a limit. Content saved before a limit was
+// lowered is kept; only new content is refused.
+type quotaChecker struct {
+	db     *sqlx.DB
+	quotas Quotas
+}
+
+// tier returns the tier of the user, premium while their premium access lasts
+func (q *quotaChecker) tier(ctx context.Context, userID string) (Tier, *time.Time, error) {
+	until, err := premiumUntil(ctx, q.db, userID)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to get tier: %w", err)
+	}
+	if until != nil && until.After(time.Now()) {
+		return TierPremium, until, nil
+	}
+	return TierFree, until, nil
+}
+
+// used returns how much of the resource the user has
+func (q *quotaChecker) used(ctx context.Context, userID string, resource QuotaResource) (int64, error) {
+	var used int64
+	if err := q.db.GetContext(ctx, &used, usageQueries[resource], userID); err != nil {
+		return 0, fmt.Errorf("failed to get %s usage: %w", resource, err)
+	}
+	return used, nil
+}
+
+// check returns a *QuotaError if adding amount of the resource would take the user past
+// their limit. Adding nothing still needs room, so an upload whose size isn't known yet
+// is refused once the quota is full.
+func (q *quotaChecker) check(ctx context.Context, userID string, resource QuotaResource, amount int64) error {
+	if q == nil {
+		return nil
+	}
+	tier, _, err := q.tier(ctx, userID)
+	if err != nil {
+		return err
+	}
+	limit := q.quotas[tier][resource]
+	if limit <= 0 {
+		return nil
+	}
+	used, err := q.used(ctx, userID, resource)
+	if err != nil {
+		return err
+	}
+	if used+max(amount, 1) > limit {
+		premium := q.quotas[TierPremium][resource]
+		return &QuotaError{
+			Resource:   resource,
+			Tier:       tier,
+			Limit:      limit,
+			Used:       used,
+			Upgradable: tier != TierPremium && (premium <= 0 || premium > limit),
+		}
+	}
+	return nil
+}
+
+// GetQuotaUsage reports the user's tier and their use of each limited resource
+func (s *service) GetQuotaUsage(ctx context.Context, userID string) (*QuotaUsage, error) {
+	tier, until, err := s.quotas.tier(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	usage := &QuotaUsage{Tier: tier, PremiumUntil: until, Resources: make([]ResourceUsage, len(QuotaResources))}
+	for i, resource := range QuotaResources {
+		used, err := s.quotas.used(ctx, userID, resource)
+		if err != nil {
+			return nil, err
+		}
+		usage.Resources[i] = ResourceUsage{Resource: resource, Used: used, Limit: s.quotas.quotas[tier][resource]}
+	}
+	return usage, nil
+}
@@ -0,0 +1,30 @@
+package database
+
+import "testing"
+
+func TestQuotasFromEnv(t *testing.T) {
+	t.Setenv("QUOTA_FREE_WORKOUTS", "50")
+	t.Setenv("QUOTA_FREE_CUSTOM_EXERCISES", "0")
+	t.Setenv("QUOTA_PREMIUM_PHOTO_STORAGE_MB", "2048")
+	t.Setenv("QUOTA_PREMIUM_WORKOUTS", "lots")
+
+	quotas := QuotasFromEnv()
+	for _, tc := range []struct {
+		tier     Tier
+		resource QuotaResource
+		want     int64
+	}{
+		{TierFree, QuotaWorkouts, 50},
+		{TierFree, QuotaCustomExercises, 0},
+		{TierFree, QuotaPhotoStorage, 1 << 30},
+		{TierPremium, QuotaPhotoStorage, 2 << 30},
+		{TierPremium, QuotaWorkouts, 0},
+	} {
+		if got := quotas[tc.tier][tc.resource]; got != tc.want {
+			t.Errorf("%s %s: expected %d, got %d", tc.tier, tc.resource, tc.want, got)
+		}
+	}
+	if DefaultQuotas[TierFree][QuotaWorkouts] != 500 {
+		t.Error("expected the defaults to be left unchanged")
+	}
+}
@@ -0,0 +1,59 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// LibraryExercise is an exercise the library offers for a muscle group it works as a
+// primary muscle, with how many sets of it the user has logged
+type LibraryExercise struct {
+	Exercises
+	Muscle_slug string `db:"muscle_slug"`
+	Sets_logged int    `db:"sets_logged"`
+}
+
+// ListSessionStarts returns when the user started each session since the given time,
+// newest first
+func (s *service) ListSessionStarts(ctx context.Context, userID string, since time.Time) ([]time.Time, error) {
+	starts := []time.Time{}
+	query := `SELECT started_at FROM workout_sessions
+		WHERE user_id = $1 AND started_at >= $2
+		ORDER BY started_at DESC`
+	if err := s.db.SelectContext(ctx, &starts, query, userID, since); err != nil {
+		return nil, fmt.Errorf("failed to list session starts: %w", err)
+	}
+	return starts, nil
+}
+
+// ListLibraryExercises returns up to perMuscle exercises for each of the muscle groups, by
+// slug and in the order given, that work it as a primary muscle. Only catalog exercises and
+// the user's own are offered, those the user has logged the most sets of first, then by
+// name. An exercise working several of the muscle groups is listed under each.
+func (s *service) ListLibraryExercises(ctx context.Context, userID string, slugs []string, perMuscle int) ([]LibraryExercise, error) {
+	exercises := []LibraryExercise{}
+	if len(slugs) == 0 {
+		return exercises, nil
+	}
+	query := `SELECT e.*, m.slug AS muscle_slug
+		FROM unnest($2::text[]) WITH ORDINALITY AS wanted(slug, position)
+		JOIN muscle_groups m ON m.slug = wanted.slug
+		CROSS JOIN LATERAL (
+			SELECT e.*, (
+				SELECT COUNT(*) FROM workout_session_sets wss
+				JOIN workout_sessions ws ON ws.id = wss.session_id
+				WHERE ws.user_id = $1 AND wss.exercise_id = e.id AND left(wss.client_id, 5) <> 'copy:'
+			) AS sets_logged
+			FROM exercises e
+			JOIN exercise_muscle_groups emg ON emg.exercise_id = e.id AND emg.muscle_group_id = m.id AND emg.role = 'primary'
+			WHERE e.created_by IS NULL OR e.created_by = $1
+			ORDER BY sets_logged DESC, e.name, e.id
+			LIMIT $3
+		) e
+		ORDER BY wanted.position, e.sets_logged DESC, e.name, e.id`
+	if err := s.db.SelectContext(ctx, &exercises, query, userID, slugs, perMuscle); err != nil {
+		return nil, fmt.Errorf("failed to list library exercises: %w", err)
+	}
+	return exercises, nil
+}
@@ -0,0 +1,81 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrSelfReferral is returned when a user tries to redeem their own referral code
+var ErrSelfReferral = errors.New("cannot redeem own referral code")
+
+// GetReferralCode returns the user's referral code, or sql.ErrNoRows if none was generated yet
+func (s *service) GetReferralCode(ctx context.Context, userID string) (*Referral_codes, error) {
+	var code Referral_codes
+	query := `SELECT * FROM referral_codes WHERE user_id = $1`
+	err := s.db.GetContext(ctx, &code, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &code, nil
+}
+
+// CreateReferralCode assigns a referral code to the user
+func (s *service) CreateReferralCode(ctx context.Context, userID, code string) (*Referral_codes, error) {
+	var created Referral_codes
+	query := `INSERT INTO referral_codes (user_id, code) VALUES ($1, $2) RETURNING *`
+	err := s.db.GetContext(ctx, &created, query, userID, code)
+	if err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// RecordReferral attributes a new signup to the owner of code and grants both users
+// rewardWeeks of premium access, all within one transaction
+func (s *service) RecordReferral(ctx context.Context, code, referredUserID string, rewardWeeks int) (*Referrals, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var referrerID string
+	if err := tx.GetContext(ctx, &referrerID, `SELECT user_id FROM referral_codes WHERE code = $1`, code); err != nil {
+		return nil, err
+	}
+	if referrerID == referredUserID {
+		return nil, ErrSelfReferral
+	}
+
+	var referral Referrals
+	query := `INSERT INTO referrals (referrer_id, referred_user_id, code, reward_weeks)
+		VALUES ($1, $2, $3, $4)
+		RETURNING *`
+	if err := tx.GetContext(ctx, &referral, query, referrerID, referredUserID, code, rewardWeeks); err != nil {
+		return nil, fmt.Errorf("failed to record referral: %w", err)
+	}
+
+	if rewardWeeks > 0 {
+		reward := time.Duration(rewardWeeks) * 7 * 24 * time.Hour
+		for _, userID := range []string{referrerID, referredUserID} {
+			if _, err := grantPremium(ctx, tx, userID, reward, "referral"); err != nil {
+				return nil, fmt.Errorf("failed to grant referral reward: %w", err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &referral, nil
+}
+
+// ListReferralsByReferrer returns the signups attributed to the referrer, newest first
+func (s *service) ListReferralsByReferrer(ctx context.Context, referrerID string) ([]Referrals, error) {
+	var referrals []Referrals
+	query := `SELECT * FROM referrals WHERE referrer_id = $1 ORDER BY created_at DESC`
+	err := s.db.SelectContext(ctx, &referrals, query, referrerID)
+	return referrals, err
+}
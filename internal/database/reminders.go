@@ -0,0 +1,118 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// CreateReminder saves a new reminder for the user
+func (s *service) CreateReminder(ctx context.Context, reminder *Reminders) (*Reminders, error) {
+	var created Reminders
+	query := `INSERT INTO reminders
+			(user_id, title, message, days_of_week, time_of_day, timezone, push, email, enabled, next_run_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING *`
+	err := s.db.GetContext(ctx, &created, query,
+		reminder.User_id, reminder.Title, reminder.Message, reminder.Days_of_week, reminder.Time_of_day,
+		reminder.Timezone, reminder.Push, reminder.Email, reminder.Enabled, reminder.Next_run_at)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create reminder: %w", err)
+	}
+	return &created, nil
+}
+
+// GetReminder returns one of the user's reminders, or sql.ErrNoRows
+func (s *service) GetReminder(ctx context.Context, id, userID string) (*Reminders, error) {
+	var reminder Reminders
+	query := `SELECT * FROM reminders WHERE id = $1 AND user_id = $2`
+	if err := s.db.GetContext(ctx, &reminder, query, id, userID); err != nil {
+		return nil, err
+	}
+	return &reminder, nil
+}
+
+// ListReminders returns the user's reminders, oldest first
+func (s *service) ListReminders(ctx context.Context, userID string) ([]Reminders, error) {
+	reminders := []Reminders{}
+	query := `SELECT * FROM reminders WHERE user_id = $1 ORDER BY created_at, id`
+	if err := s.db.SelectContext(ctx, &reminders, query, userID); err != nil {
+		return nil, err
+	}
+	return reminders, nil
+}
+
+// UpdateReminder saves a reminder's schedule and channels along with its recomputed next
+// run, returning sql.ErrNoRows if it doesn't belong to the user and ErrVersionConflict if
+// it's no longer at reminder.Version
+func (s *service) UpdateReminder(ctx context.Context, reminder *Reminders) (*Reminders, error) {
+	var updated Reminders
+	query := `UPDATE reminders
+		SET title = $3, message = $4, days_of_week = $5, time_of_day = $6, timezone = $7,
+			push = $8, email = $9, enabled = $10, next_run_at = $11, updated_at = NOW(), version = version + 1
+		WHERE id = $1 AND user_id = $2 AND version = $12
+		RETURNING *`
+	err := s.db.GetContext(ctx, &updated, query,
+		reminder.Id, reminder.User_id, reminder.Title, reminder.Message, reminder.Days_of_week,
+		reminder.Time_of_day, reminder.Timezone, reminder.Push, reminder.Email, reminder.Enabled, reminder.Next_run_at, reminder.Version)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Tell a stale version apart from a reminder that isn't the user's
+		var exists bool
+		query = `SELECT EXISTS (SELECT 1 FROM reminders WHERE id = $1 AND user_id = $2)`
+		if s.db.GetContext(ctx, &exists, query, reminder.Id, reminder.User_id) == nil && exists {
+			return nil, ErrVersionConflict
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// DeleteReminder removes a reminder, returning sql.ErrNoRows if it doesn't belong to the user
+func (s *service) DeleteReminder(ctx context.Context, id, userID string) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM reminders WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete reminder: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// ClaimDueReminders returns up to limit enabled reminders that are due, pushing their next
+// run back by lease so concurrent schedulers skip them. The returned rows carry the leased
+// next_run_at, which RescheduleReminder uses to detect edits made while sending.
+func (s *service) ClaimDueReminders(ctx context.Context, limit int, lease time.Duration) ([]Reminders, error) {
+	reminders := []Reminders{}
+	query := `UPDATE reminders
+		SET next_run_at = NOW() + $2 * INTERVAL '1 second'
+		WHERE id IN (
+			SELECT id FROM reminders
+			WHERE enabled AND next_run_at <= NOW()
+			ORDER BY next_run_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`
+	if err := s.db.SelectContext(ctx, &reminders, query, limit, lease.Seconds()); err != nil {
+		return nil, fmt.Errorf("failed to claim reminders: %w", err)
+	}
+	return reminders, nil
+}
+
+// RescheduleReminder moves a claimed reminder to its next run, recording the send if sent
+// is true. Reminders edited since they were claimed already have a new schedule and are
+// left alone.
+func (s *service) RescheduleReminder(ctx context.Context, id string, claimedUntil, nextRunAt time.Time, sent bool) error {
+	query := `UPDATE reminders
+		SET next_run_at = $3, last_sent_at = CASE WHEN $4 THEN NOW() ELSE last_sent_at END
+		WHERE id = $1 AND next_run_at = $2`
+	if _, err := s.db.ExecContext(ctx, query, id, claimedUntil, nextRunAt, sent); err != nil {
+		return fmt.Errorf("failed to reschedule reminder: %w", err)
+	}
+	return nil
+}
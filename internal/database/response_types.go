@@ -1,6 +1,9 @@
 package database
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 // UserResponse represents the response structure for users
 type UserResponse struct {
@@ -9,8 +12,10 @@ type UserResponse struct {
 	Username  string    `json:"username"`
 	FirstName string    `json:"firstName"`
 	LastName  string    `json:"lastName"`
+	Timezone  string    `json:"timezone"`
 	CreatedAt time.Time `json:"createdAt"`
 	UpdatedAt time.Time `json:"updatedAt"`
+	Version   int       `json:"version"`
 }
 
 // CreateUserRequest represents the request structure for creating users
@@ -20,6 +25,12 @@ type CreateUserRequest struct {
 	Password  string `json:"password"`
 	FirstName string `json:"firstName"`
 	LastName  string `json:"lastName"`
+
+	// ReferralCode optionally attributes the signup to an existing user
+	ReferralCode string `json:"referralCode,omitempty"`
+
+	// InviteCode bypasses the registration gate during phased launches
+	InviteCode string `json:"inviteCode,omitempty"`
 }
 
 // UpdateUserRequest represents the request structure for updating users
@@ -28,6 +39,14 @@ type UpdateUserRequest struct {
 	Username  *string `json:"username,omitempty"`
 	FirstName *string `json:"firstName,omitempty"`
 	LastName  *string `json:"lastName,omitempty"`
+	Timezone  *string `json:"timezone,omitempty"`
+	Version   *int    `json:"version,omitempty"`
+}
+
+// ChangePasswordRequest represents the request structure for changing one's password
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"currentPassword"`
+	NewPassword     string `json:"newPassword"`
 }
 
 // LoginRequest represents the request structure for user login
@@ -40,6 +59,1041 @@ type LoginRequest struct {
 type LoginResponse struct {
 	Token string       `json:"token"`
 	User  UserResponse `json:"user"`
+	// PasswordResetRequired is set when an admin forced a password reset: the token can
+	// only be used to change the password
+	PasswordResetRequired bool `json:"passwordResetRequired,omitempty"`
+}
+
+// OAuthLoginRequest represents the request structure for signing in with an identity provider.
+// Apple only shares the user's name on first authorization, so clients may forward it.
+type OAuthLoginRequest struct {
+	IDToken   string `json:"idToken"`
+	FirstName string `json:"firstName,omitempty"`
+	LastName  string `json:"lastName,omitempty"`
+
+	// InviteCode bypasses the registration gate when the sign-in creates a new account
+	InviteCode string `json:"inviteCode,omitempty"`
+}
+
+// GuestLoginResponse represents the response structure for a guest/demo login
+type GuestLoginResponse struct {
+	Token     string       `json:"token"`
+	User      UserResponse `json:"user"`
+	ExpiresAt time.Time    `json:"expiresAt"`
+}
+
+// MergeAccountsRequest represents the request structure for merging another account into the caller's.
+// Ownership of the source account is proven with either its token (guests) or its credentials.
+type MergeAccountsRequest struct {
+	SourceToken    string `json:"sourceToken,omitempty"`
+	SourceEmail    string `json:"sourceEmail,omitempty"`
+	SourcePassword string `json:"sourcePassword,omitempty"`
+}
+
+// ReferralResponse represents a single signup attributed to the caller's referral code
+type ReferralResponse struct {
+	ID          string    `json:"id"`
+	RewardWeeks int       `json:"rewardWeeks"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// ReferralSummaryResponse represents the caller's referral code and earned rewards
+type ReferralSummaryResponse struct {
+	Code              string             `json:"code"`
+	ReferralCount     int                `json:"referralCount"`
+	RewardWeeksEarned int                `json:"rewardWeeksEarned"`
+	PremiumUntil      *time.Time         `json:"premiumUntil,omitempty"`
+	Referrals         []ReferralResponse `json:"referrals"`
+}
+
+// AppleReceiptRequest represents the request structure for validating an App Store receipt
+type AppleReceiptRequest struct {
+	Receipt string `json:"receipt"`
+}
+
+// GooglePurchaseRequest represents the request structure for validating a Play Billing purchase
+type GooglePurchaseRequest struct {
+	PurchaseToken string `json:"purchaseToken"`
+}
+
+// SubscriptionResponse represents the response structure for a store subscription
+type SubscriptionResponse struct {
+	ID        string    `json:"id"`
+	Platform  string    `json:"platform"`
+	ProductID string    `json:"productId"`
+	Status    string    `json:"status"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	AutoRenew bool      `json:"autoRenew"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// PremiumStatusResponse represents the caller's premium access across all sources
+type PremiumStatusResponse struct {
+	IsPremium     bool                   `json:"isPremium"`
+	PremiumUntil  *time.Time             `json:"premiumUntil,omitempty"`
+	Subscriptions []SubscriptionResponse `json:"subscriptions"`
+}
+
+// QuotaResponse represents a user's use of one limited resource. Limit and Remaining are
+// null when the resource is unlimited for the user's tier.
+type QuotaResponse struct {
+	Resource  string `json:"resource"`
+	Used      int64  `json:"used"`
+	Limit     *int64 `json:"limit"`
+	Remaining *int64 `json:"remaining"`
+}
+
+// UsageResponse represents the caller's subscription tier and their use of its quotas
+type UsageResponse struct {
+	Tier         string          `json:"tier"`
+	PremiumUntil *time.Time      `json:"premiumUntil,omitempty"`
+	Quotas       []QuotaResponse `json:"quotas"`
+}
+
+// QuotaExceededResponse represents the error returned when saving content would take
+// the caller past a quota
+type QuotaExceededResponse struct {
+	Error     string        `json:"error"`
+	Code      string        `json:"code"`
+	RequestID string        `json:"requestId,omitempty"`
+	Tier      string        `json:"tier"`
+	Quota     QuotaResponse `json:"quota"`
+}
+
+// CreateAPIKeyRequest represents the request structure for minting an API key
+type CreateAPIKeyRequest struct {
+	Name  string `json:"name"`
+	Scope string `json:"scope"`
+}
+
+// APIKeyResponse represents the response structure for API keys
+type APIKeyResponse struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scope      string     `json:"scope"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+// CreatedAPIKeyResponse includes the plaintext key, which is only returned once
+type CreatedAPIKeyResponse struct {
+	APIKeyResponse
+	Key string `json:"key"`
+}
+
+// CreateWebhookRequest represents the request structure for registering a webhook
+type CreateWebhookRequest struct {
+	URL         string   `json:"url"`
+	Description string   `json:"description"`
+	Events      []string `json:"events"`
+}
+
+// UpdateWebhookRequest represents the request structure for updating a webhook
+type UpdateWebhookRequest struct {
+	URL         *string   `json:"url,omitempty"`
+	Description *string   `json:"description,omitempty"`
+	Events      *[]string `json:"events,omitempty"`
+	Active      *bool     `json:"active,omitempty"`
+	Version     *int      `json:"version,omitempty"`
+}
+
+// WebhookResponse represents the response structure for webhooks
+type WebhookResponse struct {
+	ID          string    `json:"id"`
+	URL         string    `json:"url"`
+	Description string    `json:"description"`
+	Events      []string  `json:"events"`
+	Active      bool      `json:"active"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+	Version     int       `json:"version"`
+}
+
+// CreatedWebhookResponse includes the signing secret, which is only returned once
+type CreatedWebhookResponse struct {
+	WebhookResponse
+	Secret string `json:"secret"`
+}
+
+// PersonalRecordResponse is the data of a pr.achieved webhook event
+type PersonalRecordResponse struct {
+	ExerciseID        string  `json:"exerciseId"`
+	WorkoutID         string  `json:"workoutId"`
+	WorkoutExerciseID string  `json:"workoutExerciseId"`
+	WeightKg          float64 `json:"weightKg"`
+	Reps              int     `json:"reps"`
+	PreviousBestKg    float64 `json:"previousBestKg"`
+}
+
+// WebhookDeliveryResponse represents one event sent, or being retried, to a webhook
+type WebhookDeliveryResponse struct {
+	ID             string          `json:"id"`
+	EventID        string          `json:"eventId"`
+	Event          string          `json:"event"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	ResponseStatus *int            `json:"responseStatus,omitempty"`
+	LastError      string          `json:"lastError,omitempty"`
+	NextAttemptAt  *time.Time      `json:"nextAttemptAt,omitempty"`
+	Payload        json.RawMessage `json:"payload"`
+	CreatedAt      time.Time       `json:"createdAt"`
+	DeliveredAt    *time.Time      `json:"deliveredAt,omitempty"`
+}
+
+// IntegrationTriggerResponse describes an event automation connectors can poll for
+type IntegrationTriggerResponse struct {
+	Event       string `json:"event"`
+	Description string `json:"description"`
+	URL         string `json:"url"`
+}
+
+// RegisterDeviceRequest represents the request structure for registering a device for push notifications
+type RegisterDeviceRequest struct {
+	Platform string `json:"platform"`
+	Token    string `json:"token"`
+	Name     string `json:"name"`
+}
+
+// DeviceResponse represents a device registered for push notifications. The token is not returned.
+type DeviceResponse struct {
+	ID        string    `json:"id"`
+	Platform  string    `json:"platform"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// UserSessionResponse represents a device the user is signed in on. Current marks the
+// session of the token making the request.
+type UserSessionResponse struct {
+	ID         string    `json:"id"`
+	UserAgent  string    `json:"userAgent"`
+	IPAddress  string    `json:"ipAddress"`
+	Current    bool      `json:"current"`
+	CreatedAt  time.Time `json:"createdAt"`
+	LastSeenAt time.Time `json:"lastSeenAt"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+}
+
+// NotificationPreferencesResponse represents the user's push notification settings
+type NotificationPreferencesResponse struct {
+	WorkoutReminders  bool `json:"workoutReminders"`
+	ReminderAfterDays int  `json:"reminderAfterDays"`
+	PersonalRecords   bool `json:"personalRecords"`
+	// QuietHoursStart and QuietHoursEnd are local "HH:MM" times, omitted when quiet hours are off
+	QuietHoursStart    string `json:"quietHoursStart,omitempty"`
+	QuietHoursEnd      string `json:"quietHoursEnd,omitempty"`
+	WeeklySummaryEmail bool   `json:"weeklySummaryEmail"`
+}
+
+// UpdateNotificationPreferencesRequest represents the request structure for changing push notification settings
+type UpdateNotificationPreferencesRequest struct {
+	WorkoutReminders  *bool `json:"workoutReminders,omitempty"`
+	ReminderAfterDays *int  `json:"reminderAfterDays,omitempty"`
+	PersonalRecords   *bool `json:"personalRecords,omitempty"`
+	// Set both to "" to turn quiet hours off
+	QuietHoursStart    *string `json:"quietHoursStart,omitempty"`
+	QuietHoursEnd      *string `json:"quietHoursEnd,omitempty"`
+	WeeklySummaryEmail *bool   `json:"weeklySummaryEmail,omitempty"`
+}
+
+// CreateReminderRequest represents the request structure for scheduling a reminder
+type CreateReminderRequest struct {
+	Title    string   `json:"title"`
+	Message  string   `json:"message"`
+	Days     []string `json:"days"`
+	Time     string   `json:"time"`
+	Timezone string   `json:"timezone"`
+	Push     *bool    `json:"push,omitempty"`
+	Email    *bool    `json:"email,omitempty"`
+	Enabled  *bool    `json:"enabled,omitempty"`
+}
+
+// UpdateReminderRequest represents the request structure for changing a reminder. Fields
+// that are left out keep their current value.
+type UpdateReminderRequest struct {
+	Title    *string  `json:"title,omitempty"`
+	Message  *string  `json:"message,omitempty"`
+	Days     []string `json:"days,omitempty"`
+	Time     *string  `json:"time,omitempty"`
+	Timezone *string  `json:"timezone,omitempty"`
+	Push     *bool    `json:"push,omitempty"`
+	Email    *bool    `json:"email,omitempty"`
+	Enabled  *bool    `json:"enabled,omitempty"`
+	Version  *int     `json:"version,omitempty"`
+}
+
+// ReminderResponse represents the response structure for reminders
+type ReminderResponse struct {
+	ID         string     `json:"id"`
+	Title      string     `json:"title"`
+	Message    string     `json:"message"`
+	Days       []string   `json:"days"`
+	Time       string     `json:"time"`
+	Timezone   string     `json:"timezone"`
+	Push       bool       `json:"push"`
+	Email      bool       `json:"email"`
+	Enabled    bool       `json:"enabled"`
+	NextRunAt  *time.Time `json:"nextRunAt,omitempty"`
+	LastSentAt *time.Time `json:"lastSentAt,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	UpdatedAt  time.Time  `json:"updatedAt"`
+	Version    int        `json:"version"`
+}
+
+// CreateFoodRequest represents the request structure for adding a custom food. Nutrients
+// are per serving.
+type CreateFoodRequest struct {
+	Name        string   `json:"name"`
+	Brand       string   `json:"brand"`
+	Barcode     string   `json:"barcode"`
+	ServingSize *float64 `json:"servingSize,omitempty"`
+	ServingUnit string   `json:"servingUnit"`
+	Calories    float64  `json:"calories"`
+	ProteinG    float64  `json:"proteinG"`
+	CarbsG      float64  `json:"carbsG"`
+	FatG        float64  `json:"fatG"`
+}
+
+// UpdateFoodRequest represents the request structure for changing a custom food. Fields
+// that are left out keep their current value.
+type UpdateFoodRequest struct {
+	Name        *string  `json:"name,omitempty"`
+	Brand       *string  `json:"brand,omitempty"`
+	Barcode     *string  `json:"barcode,omitempty"`
+	ServingSize *float64 `json:"servingSize,omitempty"`
+	ServingUnit *string  `json:"servingUnit,omitempty"`
+	Calories    *float64 `json:"calories,omitempty"`
+	ProteinG    *float64 `json:"proteinG,omitempty"`
+	CarbsG      *float64 `json:"carbsG,omitempty"`
+	FatG        *float64 `json:"fatG,omitempty"`
+	Version     *int     `json:"version,omitempty"`
+}
+
+// FoodResponse represents the response structure for foods. Custom foods belong to the
+// user; the others are from the shared catalog and can't be changed.
+type FoodResponse struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Brand       string    `json:"brand"`
+	Barcode     string    `json:"barcode"`
+	ServingSize float64   `json:"servingSize"`
+	ServingUnit string    `json:"servingUnit"`
+	Calories    float64   `json:"calories"`
+	ProteinG    float64   `json:"proteinG"`
+	CarbsG      float64   `json:"carbsG"`
+	FatG        float64   `json:"fatG"`
+	Custom      bool      `json:"custom"`
+	Source      string    `json:"source,omitempty"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+	Version     int       `json:"version"`
+}
+
+// CreateNutritionLogRequest represents the request structure for logging a food. Entries
+// logged from a food take its name and nutrients; quick entries without one give their
+// own, as totals.
+type CreateNutritionLogRequest struct {
+	FoodID   string     `json:"foodId"`
+	Name     string     `json:"name"`
+	Meal     string     `json:"meal"`
+	Servings *float64   `json:"servings,omitempty"`
+	Calories *float64   `json:"calories,omitempty"`
+	ProteinG *float64   `json:"proteinG,omitempty"`
+	CarbsG   *float64   `json:"carbsG,omitempty"`
+	FatG     *float64   `json:"fatG,omitempty"`
+	EatenAt  *time.Time `json:"eatenAt,omitempty"`
+	Notes    string     `json:"notes"`
+}
+
+// UpdateNutritionLogRequest represents the request structure for changing a logged food.
+// Fields that are left out keep their current value.
+type UpdateNutritionLogRequest struct {
+	Name     *string    `json:"name,omitempty"`
+	Meal     *string    `json:"meal,omitempty"`
+	Servings *float64   `json:"servings,omitempty"`
+	Calories *float64   `json:"calories,omitempty"`
+	ProteinG *float64   `json:"proteinG,omitempty"`
+	CarbsG   *float64   `json:"carbsG,omitempty"`
+	FatG     *float64   `json:"fatG,omitempty"`
+	EatenAt  *time.Time `json:"eatenAt,omitempty"`
+	Notes    *string    `json:"notes,omitempty"`
+	Version  *int       `json:"version,omitempty"`
+}
+
+// NutritionLogResponse represents the response structure for logged foods. Nutrients are
+// totals for all servings.
+type NutritionLogResponse struct {
+	ID        string    `json:"id"`
+	FoodID    *string   `json:"foodId,omitempty"`
+	Name      string    `json:"name"`
+	Meal      string    `json:"meal"`
+	Servings  float64   `json:"servings"`
+	Calories  float64   `json:"calories"`
+	ProteinG  float64   `json:"proteinG"`
+	CarbsG    float64   `json:"carbsG"`
+	FatG      float64   `json:"fatG"`
+	EatenAt   time.Time `json:"eatenAt"`
+	Notes     string    `json:"notes"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+	Version   int       `json:"version"`
+}
+
+// MealNutritionResponse adds up the foods logged for one meal of a day
+type MealNutritionResponse struct {
+	Meal     string  `json:"meal"`
+	Entries  int     `json:"entries"`
+	Calories float64 `json:"calories"`
+	ProteinG float64 `json:"proteinG"`
+	CarbsG   float64 `json:"carbsG"`
+	FatG     float64 `json:"fatG"`
+}
+
+// DailyNutritionResponse adds up the foods a user logged on one day, in total and per meal
+type DailyNutritionResponse struct {
+	Date     string                  `json:"date"`
+	Timezone string                  `json:"timezone"`
+	Entries  int                     `json:"entries"`
+	Calories float64                 `json:"calories"`
+	ProteinG float64                 `json:"proteinG"`
+	CarbsG   float64                 `json:"carbsG"`
+	FatG     float64                 `json:"fatG"`
+	Meals    []MealNutritionResponse `json:"meals"`
+}
+
+// PutDailyHabitsRequest represents the request structure for logging a day's habits.
+// Habits that are left out keep the value already logged for the day.
+type PutDailyHabitsRequest struct {
+	WaterMl    *int     `json:"waterMl"`
+	SleepHours *float64 `json:"sleepHours"`
+	Steps      *int     `json:"steps"`
+}
+
+// DailyHabitsResponse represents the response structure for one day's habits. Habits that
+// weren't logged are null.
+type DailyHabitsResponse struct {
+	Date       string    `json:"date"`
+	WaterMl    *int      `json:"waterMl"`
+	SleepHours *float64  `json:"sleepHours"`
+	Steps      *int      `json:"steps"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+// HabitAveragesResponse averages each habit over the days it was logged on; null when it
+// wasn't logged in the range
+type HabitAveragesResponse struct {
+	WaterMl    *float64 `json:"waterMl"`
+	SleepHours *float64 `json:"sleepHours"`
+	Steps      *float64 `json:"steps"`
+}
+
+// HabitRangeResponse represents the days a user logged habits on in a range of dates
+type HabitRangeResponse struct {
+	From     string                `json:"from"`
+	To       string                `json:"to"`
+	Days     []DailyHabitsResponse `json:"days"`
+	Averages HabitAveragesResponse `json:"averages"`
+}
+
+// DataExportResponse represents the status of an account data export
+type DataExportResponse struct {
+	ID          string     `json:"id"`
+	Status      string     `json:"status"`
+	SizeBytes   int64      `json:"sizeBytes,omitempty"`
+	DownloadURL string     `json:"downloadUrl,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+	ExpiresAt   *time.Time `json:"expiresAt,omitempty"`
+}
+
+// AccountDeletionResponse represents a scheduled account deletion
+type AccountDeletionResponse struct {
+	ID          string    `json:"id"`
+	Status      string    `json:"status"`
+	RequestedAt time.Time `json:"requestedAt"`
+	PurgeAfter  time.Time `json:"purgeAfter"`
+}
+
+// OrganizationResponse represents the response structure for organizations
+type OrganizationResponse struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// GymVisitResponse represents a member's check-in at an organization
+type GymVisitResponse struct {
+	ID             string     `json:"id"`
+	OrganizationID string     `json:"organizationId"`
+	CheckedInAt    time.Time  `json:"checkedInAt"`
+	CheckedOutAt   *time.Time `json:"checkedOutAt,omitempty"`
+}
+
+// OccupancyResponse is how many members are checked in at an organization right now
+type OccupancyResponse struct {
+	OrganizationID string    `json:"organizationId"`
+	Current        int       `json:"current"`
+	AsOf           time.Time `json:"asOf"`
+}
+
+// OccupancyHistoryResponse is the typical occupancy of an organization by hour of the week
+type OccupancyHistoryResponse struct {
+	OrganizationID string                  `json:"organizationId"`
+	Timezone       string                  `json:"timezone"`
+	From           time.Time               `json:"from"`
+	To             time.Time               `json:"to"`
+	Hours          []OccupancyHourResponse `json:"hours"`
+}
+
+// OccupancyHourResponse is the occupancy during one hour of the week
+type OccupancyHourResponse struct {
+	// Weekday is 1 for Monday through 7 for Sunday
+	Weekday         int     `json:"weekday"`
+	Hour            int     `json:"hour"`
+	AverageVisitors float64 `json:"averageVisitors"`
+	PeakVisitors    int     `json:"peakVisitors"`
+}
+
+// GymEquipmentResponse represents bookable gym equipment
+type GymEquipmentResponse struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// EquipmentReservationResponse represents a time-slot booking of gym equipment. UserID
+// is only shown to the member who booked and to organization admins.
+type EquipmentReservationResponse struct {
+	ID          string     `json:"id"`
+	EquipmentID string     `json:"equipmentId"`
+	UserID      string     `json:"userId,omitempty"`
+	StartsAt    time.Time  `json:"startsAt"`
+	EndsAt      time.Time  `json:"endsAt"`
+	Status      string     `json:"status"`
+	CancelledAt *time.Time `json:"cancelledAt,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+}
+
+// KioskDisplayResponse represents a gym screen showing an organization's display board
+type KioskDisplayResponse struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Timezone   string     `json:"timezone"`
+	Prefix     string     `json:"prefix"`
+	LastSeenAt *time.Time `json:"lastSeenAt,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+// CreatedKioskDisplayResponse includes the plaintext display key, which is only returned once
+type CreatedKioskDisplayResponse struct {
+	KioskDisplayResponse
+	Key string `json:"key"`
+}
+
+// DisplayBoardResponse is what a gym screen shows: how busy the gym is, the week's
+// leaderboard and the equipment still booked today, in the screen's timezone
+type DisplayBoardResponse struct {
+	OrganizationID   string                       `json:"organizationId"`
+	OrganizationName string                       `json:"organizationName"`
+	Timezone         string                       `json:"timezone"`
+	Occupancy        int                          `json:"occupancy"`
+	WeekStartsAt     time.Time                    `json:"weekStartsAt"`
+	Leaderboard      []LeaderboardEntryResponse   `json:"leaderboard"`
+	Reservations     []DisplayReservationResponse `json:"reservations"`
+	AsOf             time.Time                    `json:"asOf"`
+}
+
+// LeaderboardEntryResponse is a member's place on an organization's weekly leaderboard
+type LeaderboardEntryResponse struct {
+	Rank     int    `json:"rank"`
+	Name     string `json:"name"`
+	Sessions int    `json:"sessions"`
+	Minutes  int    `json:"minutes"`
+}
+
+// DisplayReservationResponse is an equipment booking as shown on a gym screen, without who booked it
+type DisplayReservationResponse struct {
+	Equipment string    `json:"equipment"`
+	StartsAt  time.Time `json:"startsAt"`
+	EndsAt    time.Time `json:"endsAt"`
+}
+
+// LegalHoldResponse represents a legal hold on an organization or one of its members
+type LegalHoldResponse struct {
+	ID         string     `json:"id"`
+	UserID     *string    `json:"userId,omitempty"`
+	Reason     string     `json:"reason"`
+	Active     bool       `json:"active"`
+	CreatedBy  string     `json:"createdBy"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	ReleasedBy *string    `json:"releasedBy,omitempty"`
+	ReleasedAt *time.Time `json:"releasedAt,omitempty"`
+}
+
+// CreateLegalHoldRequest represents the request structure for placing a legal hold;
+// without a user ID the whole organization is held
+type CreateLegalHoldRequest struct {
+	UserID *string `json:"userId,omitempty"`
+	Reason string  `json:"reason"`
+}
+
+// RetentionPolicyResponse reports how long a category of user data is kept; 0 means forever
+type RetentionPolicyResponse struct {
+	Category   string `json:"category"`
+	RetainDays int    `json:"retainDays"`
+}
+
+// AdminUserResponse represents a user as admins see them, with the account's state
+type AdminUserResponse struct {
+	UserResponse
+	Role                  string     `json:"role"`
+	DisabledAt            *time.Time `json:"disabledAt,omitempty"`
+	PasswordResetRequired bool       `json:"passwordResetRequired"`
+}
+
+// ImpersonationResponse represents a read-only token an admin was issued to act as a user
+type ImpersonationResponse struct {
+	Token     string       `json:"token"`
+	ExpiresAt time.Time    `json:"expiresAt"`
+	User      UserResponse `json:"user"`
+}
+
+// AdminAuditLogResponse represents one request an admin made
+type AdminAuditLogResponse struct {
+	ID           string    `json:"id"`
+	AdminID      *string   `json:"adminId"`
+	Action       string    `json:"action"`
+	Path         string    `json:"path"`
+	TargetUserID *string   `json:"targetUserId,omitempty"`
+	StatusCode   int       `json:"statusCode"`
+	IPAddress    string    `json:"ipAddress"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+// DataSubjectRequestResponse represents a data subject request with the export or account
+// deletion generated to fulfill it
+type DataSubjectRequestResponse struct {
+	ID           string                   `json:"id"`
+	UserID       string                   `json:"userId"`
+	SubjectEmail string                   `json:"subjectEmail"`
+	Type         string                   `json:"type"`
+	Status       string                   `json:"status"`
+	Notes        string                   `json:"notes"`
+	DueAt        time.Time                `json:"dueAt"`
+	Overdue      bool                     `json:"overdue"`
+	CreatedBy    string                   `json:"createdBy"`
+	ResolvedBy   *string                  `json:"resolvedBy,omitempty"`
+	CreatedAt    time.Time                `json:"createdAt"`
+	UpdatedAt    time.Time                `json:"updatedAt"`
+	CompletedAt  *time.Time               `json:"completedAt,omitempty"`
+	Export       *DataExportResponse      `json:"export,omitempty"`
+	Deletion     *AccountDeletionResponse `json:"deletion,omitempty"`
+}
+
+// CreateDSARRequest represents the request structure for logging a data subject access
+// request (DSAR); the subject is identified by user ID or email
+type CreateDSARRequest struct {
+	UserID string     `json:"userId,omitempty"`
+	Email  string     `json:"email,omitempty"`
+	Type   string     `json:"type"`
+	Notes  string     `json:"notes,omitempty"`
+	DueAt  *time.Time `json:"dueAt,omitempty"`
+}
+
+// FulfillDSARRequest represents the request structure for fulfilling a data subject
+// request; Immediate skips the account deletion grace period
+type FulfillDSARRequest struct {
+	Immediate bool `json:"immediate,omitempty"`
+}
+
+// RejectDSARRequest represents the request structure for rejecting a data subject request
+type RejectDSARRequest struct {
+	Reason string `json:"reason"`
+}
+
+// CreateGymEquipmentRequest represents the request structure for adding gym equipment
+type CreateGymEquipmentRequest struct {
+	Name string `json:"name"`
+}
+
+// CreateEquipmentReservationRequest represents the request structure for booking equipment
+type CreateEquipmentReservationRequest struct {
+	EquipmentID string    `json:"equipmentId"`
+	StartsAt    time.Time `json:"startsAt"`
+	EndsAt      time.Time `json:"endsAt"`
+}
+
+// CreateKioskDisplayRequest represents the request structure for adding a gym screen;
+// Timezone is an IANA name and defaults to UTC
+type CreateKioskDisplayRequest struct {
+	Name     string `json:"name"`
+	Timezone string `json:"timezone"`
+}
+
+// LeaderboardVisibilityRequest represents the request structure for opting in to or out of
+// an organization's leaderboard
+type LeaderboardVisibilityRequest struct {
+	Visible *bool `json:"visible"`
+}
+
+// PhotoVaultPINRequest represents the request structure for setting up or unlocking the photo vault
+type PhotoVaultPINRequest struct {
+	PIN string `json:"pin"`
+}
+
+// ChangePhotoVaultPINRequest represents the request structure for changing the photo vault PIN
+type ChangePhotoVaultPINRequest struct {
+	CurrentPIN string `json:"currentPin"`
+	NewPIN     string `json:"newPin"`
+}
+
+// CreateDataExportRequest represents the optional request body of a data export request
+type CreateDataExportRequest struct {
+	IncludeVault bool `json:"includeVault"`
+}
+
+// CreateOrganizationRequest represents the request structure for creating organizations
+type CreateOrganizationRequest struct {
+	Name string `json:"name"`
+}
+
+// OrgInviteResponse represents a pending organization invitation
+type OrgInviteResponse struct {
+	ID         string    `json:"id"`
+	Email      string    `json:"email"`
+	Role       string    `json:"role"`
+	Expired    bool      `json:"expired"`
+	SendCount  int       `json:"sendCount"`
+	LastSentAt time.Time `json:"lastSentAt"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// CreateOrgInviteRequest represents the request structure for inviting someone to an organization
+type CreateOrgInviteRequest struct {
+	Email string `json:"email"`
+	Role  string `json:"role"`
+}
+
+// AcceptOrgInviteRequest represents the request structure for accepting an organization invitation
+type AcceptOrgInviteRequest struct {
+	Token string `json:"token"`
+}
+
+// OrganizationMemberResponse represents a user's membership in an organization
+type OrganizationMemberResponse struct {
+	OrganizationID string    `json:"organizationId"`
+	UserID         string    `json:"userId"`
+	Role           string    `json:"role"`
+	JoinedAt       time.Time `json:"joinedAt"`
+}
+
+// SCIMTokenResponse contains a newly issued SCIM token, which is only returned once
+type SCIMTokenResponse struct {
+	BaseURL string `json:"baseUrl"`
+	Token   string `json:"token"`
+}
+
+// SSOConfigRequest represents the request structure for configuring an organization's single sign-on
+type SSOConfigRequest struct {
+	Protocol        string   `json:"protocol"`
+	Issuer          string   `json:"issuer"`
+	ClientID        string   `json:"clientId"`
+	RoleClaim       string   `json:"roleClaim"`
+	AdminRoleValues []string `json:"adminRoleValues"`
+	JITProvisioning *bool    `json:"jitProvisioning,omitempty"`
+	Enabled         *bool    `json:"enabled,omitempty"`
+}
+
+// SSOConfigResponse represents an organization's single sign-on configuration
+type SSOConfigResponse struct {
+	Protocol              string    `json:"protocol"`
+	Issuer                string    `json:"issuer"`
+	ClientID              string    `json:"clientId"`
+	AuthorizationEndpoint string    `json:"authorizationEndpoint"`
+	RoleClaim             string    `json:"roleClaim"`
+	AdminRoleValues       []string  `json:"adminRoleValues"`
+	JITProvisioning       bool      `json:"jitProvisioning"`
+	Enabled               bool      `json:"enabled"`
+	UpdatedAt             time.Time `json:"updatedAt"`
+}
+
+// CommunityIntegrationRequest represents the request structure for connecting an
+// organization's Slack or Discord channel. WebhookURL may be left out to keep the current one.
+type CommunityIntegrationRequest struct {
+	WebhookURL        string `json:"webhookUrl"`
+	Timezone          string `json:"timezone"`
+	PersonalRecords   *bool  `json:"personalRecords,omitempty"`
+	WeeklyLeaderboard *bool  `json:"weeklyLeaderboard,omitempty"`
+	Enabled           *bool  `json:"enabled,omitempty"`
+}
+
+// CommunityIntegrationResponse represents an organization's community channel. The
+// webhook URL grants posting rights, so only its start is shown.
+type CommunityIntegrationResponse struct {
+	Provider             string     `json:"provider"`
+	WebhookURL           string     `json:"webhookUrl"`
+	Timezone             string     `json:"timezone"`
+	PersonalRecords      bool       `json:"personalRecords"`
+	WeeklyLeaderboard    bool       `json:"weeklyLeaderboard"`
+	Enabled              bool       `json:"enabled"`
+	LeaderboardPostedFor *time.Time `json:"leaderboardPostedFor"`
+	UpdatedAt            time.Time  `json:"updatedAt"`
+}
+
+// SSOLoginConfigResponse tells clients how to start sign-in with an organization's identity provider
+type SSOLoginConfigResponse struct {
+	Protocol              string `json:"protocol"`
+	Issuer                string `json:"issuer"`
+	ClientID              string `json:"clientId"`
+	AuthorizationEndpoint string `json:"authorizationEndpoint"`
+}
+
+// SSOLoginRequest represents the request structure for signing in with an organization's identity provider
+type SSOLoginRequest struct {
+	IDToken string `json:"idToken"`
+}
+
+// IntegrationResponse represents a user's connection to a third-party fitness service
+type IntegrationResponse struct {
+	Provider       string     `json:"provider"`
+	ExternalUserID string     `json:"externalUserId"`
+	Scope          string     `json:"scope"`
+	LastSyncedAt   *time.Time `json:"lastSyncedAt,omitempty"`
+	LastError      string     `json:"lastError,omitempty"`
+	ConnectedAt    time.Time  `json:"connectedAt"`
+}
+
+// IntegrationConnectResponse holds the URL the user is sent to to authorize a connection
+type IntegrationConnectResponse struct {
+	AuthorizeURL string    `json:"authorizeUrl"`
+	ExpiresAt    time.Time `json:"expiresAt"`
+}
+
+// HealthImportResponse reports the outcome of an Apple Health or Google Fit import
+type HealthImportResponse struct {
+	Source           string `json:"source"`
+	SessionsImported int    `json:"sessionsImported"`
+	SessionsSkipped  int    `json:"sessionsSkipped"`
+	MetricsImported  int    `json:"metricsImported"`
+	MetricsSkipped   int    `json:"metricsSkipped"`
+	// Invalid counts workouts and measurements in the export that could not be read
+	Invalid int `json:"invalid"`
+}
+
+// FileImportResponse reports the outcome of an activity file upload, per file and in total
+type FileImportResponse struct {
+	SessionsImported int                `json:"sessionsImported"`
+	SessionsSkipped  int                `json:"sessionsSkipped"`
+	Files            []FileImportResult `json:"files"`
+}
+
+// FileImportResult reports the outcome of importing one uploaded activity file
+type FileImportResult struct {
+	Filename         string `json:"filename"`
+	Format           string `json:"format,omitempty"`
+	SessionsImported int    `json:"sessionsImported"`
+	SessionsSkipped  int    `json:"sessionsSkipped"`
+	Invalid          int    `json:"invalid"`
+	Error            string `json:"error,omitempty"`
+}
+
+// ImportPreviewResponse lists what importing a set log would store, for the user to review
+// before committing it
+type ImportPreviewResponse struct {
+	PreviewID string    `json:"previewId"`
+	Source    string    `json:"source"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	// Rows counts the sets in the file, including those listed in Issues
+	Rows      int                    `json:"rows"`
+	Sessions  []ImportPreviewSession `json:"sessions"`
+	Exercises []ImportExerciseMatch  `json:"exercises"`
+	Issues    []ImportIssue          `json:"issues"`
+}
+
+// ImportPreviewSession is a session of a set log import. Duplicate sessions were imported
+// before or overlap one the user already has, and are skipped on commit.
+type ImportPreviewSession struct {
+	ExternalID      string             `json:"externalId"`
+	Name            string             `json:"name"`
+	StartedAt       time.Time          `json:"startedAt"`
+	EndedAt         time.Time          `json:"endedAt"`
+	DurationMinutes int                `json:"durationMinutes"`
+	Duplicate       bool               `json:"duplicate"`
+	Sets            []ImportPreviewSet `json:"sets"`
+}
+
+// ImportPreviewSet is a set of a set log import, with the line of the file it was read from
+type ImportPreviewSet struct {
+	Row             int      `json:"row"`
+	Exercise        string   `json:"exercise"`
+	SetNumber       int      `json:"setNumber"`
+	Reps            int      `json:"reps"`
+	WeightKg        float64  `json:"weightKg"`
+	DurationSeconds *int     `json:"durationSeconds,omitempty"`
+	RPE             *float64 `json:"rpe,omitempty"`
+}
+
+// ImportExerciseMatch is the proposed catalog exercise for an exercise named in a set log.
+// Action is "map" with the matched exercise, or "create" when nothing matched.
+type ImportExerciseMatch struct {
+	Name         string `json:"name"`
+	Action       string `json:"action"`
+	Match        string `json:"match,omitempty"`
+	ExerciseID   string `json:"exerciseId,omitempty"`
+	ExerciseName string `json:"exerciseName,omitempty"`
+	Sets         int    `json:"sets"`
+}
+
+// ImportIssue is a row of a set log that couldn't be read and won't be imported
+type ImportIssue struct {
+	Row     int    `json:"row"`
+	Message string `json:"message"`
+}
+
+// ImportCommitRequest represents the request structure for committing a set log import.
+// Exercises left out keep their proposed action.
+type ImportCommitRequest struct {
+	PreviewID string                  `json:"previewId"`
+	Exercises []ImportExerciseMapping `json:"exercises"`
+}
+
+// ImportExerciseMapping is the user's decision for an exercise named in a set log: "map"
+// it to ExerciseID, "create" it in the catalog, or "skip" its sets
+type ImportExerciseMapping struct {
+	Name       string `json:"name"`
+	Action     string `json:"action"`
+	ExerciseID string `json:"exerciseId"`
+}
+
+// ImportCommitResponse reports the outcome of committing a set log import
+type ImportCommitResponse struct {
+	Source           string `json:"source"`
+	SessionsImported int    `json:"sessionsImported"`
+	SessionsSkipped  int    `json:"sessionsSkipped"`
+	SetsImported     int    `json:"setsImported"`
+	SetsSkipped      int    `json:"setsSkipped"`
+}
+
+// DailySummaryResponse gathers a user's activity for one day
+type DailySummaryResponse struct {
+	Date                 string                   `json:"date"`
+	Timezone             string                   `json:"timezone"`
+	Sessions             []WorkoutSessionResponse `json:"sessions"`
+	SessionCount         int                      `json:"sessionCount"`
+	TotalDurationMinutes int                      `json:"totalDurationMinutes"`
+	TotalDistanceMeters  float64                  `json:"totalDistanceMeters"`
+	TotalVolumeKg        float64                  `json:"totalVolumeKg"`
+	SetCount             int                      `json:"setCount"`
+	BodyMetrics          []BodyMetricResponse     `json:"bodyMetrics"`
+}
+
+// WeeklyPersonalRecordResponse represents the heaviest set of an exercise beating the
+// user's previous best during a week
+type WeeklyPersonalRecordResponse struct {
+	ExerciseID     string    `json:"exerciseId"`
+	ExerciseName   string    `json:"exerciseName"`
+	WeightKg       float64   `json:"weightKg"`
+	Reps           int       `json:"reps"`
+	PreviousBestKg float64   `json:"previousBestKg"`
+	AchievedAt     time.Time `json:"achievedAt"`
+}
+
+// WeeklySummaryResponse represents what the user did in a week, Monday to Sunday
+type WeeklySummaryResponse struct {
+	WeekStart            string                         `json:"weekStart"`
+	WeekEnd              string                         `json:"weekEnd"`
+	Timezone             string                         `json:"timezone"`
+	SessionsCompleted    int                            `json:"sessionsCompleted"`
+	TotalDurationMinutes int                            `json:"totalDurationMinutes"`
+	TotalVolumeKg        float64                        `json:"totalVolumeKg"`
+	SetCount             int                            `json:"setCount"`
+	PersonalRecords      []WeeklyPersonalRecordResponse `json:"personalRecords"`
+	// StreakWeeks counts the weeks in a row, up to and including this one, with a session
+	StreakWeeks int `json:"streakWeeks"`
+}
+
+// BodyMetricResponse represents a single body measurement
+type BodyMetricResponse struct {
+	ID         string    `json:"id"`
+	Metric     string    `json:"metric"`
+	Value      float64   `json:"value"`
+	RecordedAt time.Time `json:"recordedAt"`
+	Source     string    `json:"source"`
+}
+
+// ProgressPhotoResponse represents a progress photo. Vaulted photos have no thumbnail, and
+// their image can only be downloaded while the vault is unlocked.
+type ProgressPhotoResponse struct {
+	ID              string    `json:"id"`
+	Pose            string    `json:"pose"`
+	TakenAt         time.Time `json:"takenAt"`
+	Notes           string    `json:"notes,omitempty"`
+	Status          string    `json:"status"`
+	ContentType     string    `json:"contentType"`
+	SizeBytes       int64     `json:"sizeBytes"`
+	Width           int       `json:"width,omitempty"`
+	Height          int       `json:"height,omitempty"`
+	Vaulted         bool      `json:"vaulted"`
+	ImageURL        string    `json:"imageUrl,omitempty"`
+	ThumbnailURL    string    `json:"thumbnailUrl,omitempty"`
+	ThumbnailWidth  int       `json:"thumbnailWidth,omitempty"`
+	ThumbnailHeight int       `json:"thumbnailHeight,omitempty"`
+	CreatedAt       time.Time `json:"createdAt"`
+}
+
+// ProgressPhotoUploadRequest represents the request structure for starting an upload of a
+// progress photo
+type ProgressPhotoUploadRequest struct {
+	ContentType string     `json:"contentType"`
+	Pose        string     `json:"pose"`
+	TakenAt     *time.Time `json:"takenAt"`
+	Notes       string     `json:"notes"`
+}
+
+// ProgressPhotoUploadResponse represents where to upload a progress photo. The image is sent
+// with a PUT to UploadURL carrying ContentType, and the upload confirmed at CompleteURL.
+type ProgressPhotoUploadResponse struct {
+	Photo       ProgressPhotoResponse `json:"photo"`
+	UploadURL   string                `json:"uploadUrl"`
+	ContentType string                `json:"contentType"`
+	CompleteURL string                `json:"completeUrl"`
+	ExpiresAt   time.Time             `json:"expiresAt"`
+}
+
+// PhotoComparisonResponse represents two progress photos side by side with the body
+// composition measured around the time each was taken
+type PhotoComparisonResponse struct {
+	Before        PhotoComparisonSide     `json:"before"`
+	After         PhotoComparisonSide     `json:"after"`
+	DaysBetween   int                     `json:"daysBetween"`
+	MetricChanges []BodyMetricChangeEntry `json:"metricChanges"`
+}
+
+// PhotoComparisonSide is one photo of a comparison and the measurements nearest to it
+type PhotoComparisonSide struct {
+	Photo       ProgressPhotoResponse `json:"photo"`
+	BodyMetrics []BodyMetricResponse  `json:"bodyMetrics"`
+}
+
+// BodyMetricChangeEntry is how a metric measured near both compared photos changed
+type BodyMetricChangeEntry struct {
+	Metric string  `json:"metric"`
+	Before float64 `json:"before"`
+	After  float64 `json:"after"`
+	Change float64 `json:"change"`
+}
+
+// PhotoVaultResponse represents the state of the user's photo vault
+type PhotoVaultResponse struct {
+	Enabled       bool       `json:"enabled"`
+	Unlocked      bool       `json:"unlocked"`
+	UnlockedUntil *time.Time `json:"unlockedUntil,omitempty"`
+
+	// LockedUntil is set while unlocking is blocked after too many wrong PINs
+	LockedUntil *time.Time `json:"lockedUntil,omitempty"`
 }
 
 // WorkoutResponse represents the response structure for workouts
@@ -50,8 +1104,10 @@ type WorkoutResponse struct {
 	Description     string    `json:"description"`
 	DurationMinutes int       `json:"durationMinutes"`
 	ProgramID       string    `json:"programId"`
+	IsTemplate      bool      `json:"isTemplate"`
 	CreatedAt       time.Time `json:"createdAt"`
 	UpdatedAt       time.Time `json:"updatedAt"`
+	Version         int       `json:"version"`
 }
 
 // CreateWorkoutRequest represents the request structure for creating workouts
@@ -60,6 +1116,7 @@ type CreateWorkoutRequest struct {
 	Description     string `json:"description"`
 	DurationMinutes int    `json:"durationMinutes"`
 	ProgramID       string `json:"programId"`
+	IsTemplate      bool   `json:"isTemplate"`
 }
 
 // UpdateWorkoutRequest represents the request structure for updating workouts
@@ -68,39 +1125,140 @@ type UpdateWorkoutRequest struct {
 	Description     *string `json:"description,omitempty"`
 	DurationMinutes *int    `json:"durationMinutes,omitempty"`
 	ProgramID       *string `json:"programId,omitempty"`
+	IsTemplate      *bool   `json:"isTemplate,omitempty"`
+	Version         *int    `json:"version,omitempty"`
+}
+
+// CloneWorkoutRequest represents the optional request body for cloning a workout
+type CloneWorkoutRequest struct {
+	Name string `json:"name"`
 }
 
 // ExerciseResponse represents the response structure for exercises
 type ExerciseResponse struct {
-	ID              string    `json:"id"`
-	Name            string    `json:"name"`
-	Description     string    `json:"description"`
-	MuscleGroup     string    `json:"muscleGroup"`
-	Equipment       string    `json:"equipment"`
-	DifficultyLevel string    `json:"difficultyLevel"`
-	Instructions    string    `json:"instructions"`
-	CreatedAt       time.Time `json:"createdAt"`
-	UpdatedAt       time.Time `json:"updatedAt"`
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// MuscleGroup is the name of the first primary muscle, kept for clients that predate Muscles
+	MuscleGroup     string                   `json:"muscleGroup"`
+	Muscles         []ExerciseMuscleResponse `json:"muscles"`
+	Equipment       string                   `json:"equipment"`
+	DifficultyLevel string                   `json:"difficultyLevel"`
+	Instructions    string                   `json:"instructions"`
+	Media           []ExerciseMediaResponse  `json:"media"`
+	CreatedAt       time.Time                `json:"createdAt"`
+	UpdatedAt       time.Time                `json:"updatedAt"`
+	Version         int                      `json:"version"`
+}
+
+// ExerciseMediaResponse represents a demonstration image or video of an exercise. URL is
+// the linked URL, or an API route serving the upload.
+type ExerciseMediaResponse struct {
+	ID          string    `json:"id"`
+	Kind        string    `json:"kind"`
+	URL         string    `json:"url"`
+	ContentType string    `json:"contentType,omitempty"`
+	Caption     string    `json:"caption"`
+	Position    int       `json:"position"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// CreateExerciseMediaRequest represents the request structure for linking media hosted elsewhere
+type CreateExerciseMediaRequest struct {
+	Kind    string `json:"kind"`
+	URL     string `json:"url"`
+	Caption string `json:"caption"`
 }
 
-// CreateExerciseRequest represents the request structure for creating exercises
+// ExerciseMediaUploadRequest represents the request structure for starting an upload of exercise media
+type ExerciseMediaUploadRequest struct {
+	Kind        string `json:"kind"`
+	ContentType string `json:"contentType"`
+	Caption     string `json:"caption"`
+}
+
+// ExerciseMediaUploadResponse represents where to upload exercise media. The file is sent
+// with a PUT to UploadURL carrying ContentType, and the upload confirmed at CompleteURL.
+type ExerciseMediaUploadResponse struct {
+	Media       ExerciseMediaResponse `json:"media"`
+	UploadURL   string                `json:"uploadUrl"`
+	ContentType string                `json:"contentType"`
+	CompleteURL string                `json:"completeUrl"`
+	ExpiresAt   time.Time             `json:"expiresAt"`
+}
+
+// ReorderExerciseMediaRequest represents the new order of an exercise's media
+type ReorderExerciseMediaRequest struct {
+	MediaIDs []string `json:"mediaIds"`
+}
+
+// ExerciseMuscleResponse represents a muscle an exercise works
+type ExerciseMuscleResponse struct {
+	Slug string `json:"slug"`
+	Name string `json:"name"`
+	Role string `json:"role"`
+}
+
+// MuscleGroupResponse represents a muscle in the exercise taxonomy
+type MuscleGroupResponse struct {
+	Slug string `json:"slug"`
+	Name string `json:"name"`
+}
+
+// MuscleRequest gives an exercise a muscle, by slug or name, as "primary" (the default) or "secondary"
+type MuscleRequest struct {
+	Muscle string `json:"muscle"`
+	Role   string `json:"role"`
+}
+
+// CreateExerciseRequest represents the request structure for creating exercises. MuscleGroup
+// is shorthand for a single primary muscle when Muscles is empty.
 type CreateExerciseRequest struct {
-	Name            string `json:"name"`
-	Description     string `json:"description"`
-	MuscleGroup     string `json:"muscleGroup"`
-	Equipment       string `json:"equipment"`
-	DifficultyLevel string `json:"difficultyLevel"`
-	Instructions    string `json:"instructions"`
+	Name            string          `json:"name"`
+	Description     string          `json:"description"`
+	MuscleGroup     string          `json:"muscleGroup"`
+	Muscles         []MuscleRequest `json:"muscles"`
+	Equipment       string          `json:"equipment"`
+	DifficultyLevel string          `json:"difficultyLevel"`
+	Instructions    string          `json:"instructions"`
+}
+
+// SyncExercisesRequest represents a batch of exercises from an upstream catalog
+type SyncExercisesRequest struct {
+	Source    string                   `json:"source"`
+	Exercises []CatalogExerciseRequest `json:"exercises"`
 }
 
-// UpdateExerciseRequest represents the request structure for updating exercises
+// CatalogExerciseRequest represents one exercise in a catalog sync, identified by its ID in the source catalog
+type CatalogExerciseRequest struct {
+	ExternalID      string          `json:"externalId"`
+	Name            string          `json:"name"`
+	Description     string          `json:"description"`
+	MuscleGroup     string          `json:"muscleGroup"`
+	Muscles         []MuscleRequest `json:"muscles"`
+	Equipment       string          `json:"equipment"`
+	DifficultyLevel string          `json:"difficultyLevel"`
+	Instructions    string          `json:"instructions"`
+}
+
+// SyncExercisesResponse represents what a catalog sync changed
+type SyncExercisesResponse struct {
+	Inserted  int `json:"inserted"`
+	Updated   int `json:"updated"`
+	Unchanged int `json:"unchanged"`
+}
+
+// UpdateExerciseRequest represents the request structure for updating exercises. Muscles
+// replaces all of the exercise's muscles, and MuscleGroup replaces them with one primary muscle.
 type UpdateExerciseRequest struct {
-	Name            *string `json:"name,omitempty"`
-	Description     *string `json:"description,omitempty"`
-	MuscleGroup     *string `json:"muscleGroup,omitempty"`
-	Equipment       *string `json:"equipment,omitempty"`
-	DifficultyLevel *string `json:"difficultyLevel,omitempty"`
-	Instructions    *string `json:"instructions,omitempty"`
+	Name            *string          `json:"name,omitempty"`
+	Description     *string          `json:"description,omitempty"`
+	MuscleGroup     *string          `json:"muscleGroup,omitempty"`
+	Muscles         *[]MuscleRequest `json:"muscles,omitempty"`
+	Equipment       *string          `json:"equipment,omitempty"`
+	DifficultyLevel *string          `json:"difficultyLevel,omitempty"`
+	Instructions    *string          `json:"instructions,omitempty"`
+	Version         *int             `json:"version,omitempty"`
 }
 
 // WorkoutExerciseResponse represents the response structure for workout exercises
@@ -116,19 +1274,32 @@ type WorkoutExerciseResponse struct {
 	RestSeconds     int       `json:"restSeconds"`
 	Notes           string    `json:"notes"`
 	CreatedAt       time.Time `json:"createdAt"`
+	UpdatedAt       time.Time `json:"updatedAt"`
+	Version         int       `json:"version"`
+
+	// WeightPrescription is set when the load is a percentage of a training max
+	WeightPrescription *WeightPrescription `json:"weightPrescription,omitempty"`
+}
+
+// WeightPrescription prescribes a load as a fraction of one of the user's training maxes,
+// e.g. {"percent_of": "squat_1rm", "value": 0.75} for 75% of the squat training max
+type WeightPrescription struct {
+	PercentOf string  `json:"percent_of"`
+	Value     float64 `json:"value"`
 }
 
 // CreateWorkoutExerciseRequest represents the request structure for creating workout exercises
 type CreateWorkoutExerciseRequest struct {
-	WorkoutID       string  `json:"workoutId"`
-	ExerciseID      string  `json:"exerciseId"`
-	Sets            int     `json:"sets"`
-	Reps            int     `json:"reps"`
-	WeightKg        float64 `json:"weightKg"`
-	DurationSeconds int     `json:"durationSeconds"`
-	OrderIndex      int     `json:"orderIndex"`
-	RestSeconds     int     `json:"restSeconds"`
-	Notes           string  `json:"notes"`
+	WorkoutID          string              `json:"workoutId"`
+	ExerciseID         string              `json:"exerciseId"`
+	Sets               int                 `json:"sets"`
+	Reps               int                 `json:"reps"`
+	WeightKg           float64             `json:"weightKg"`
+	DurationSeconds    int                 `json:"durationSeconds"`
+	OrderIndex         int                 `json:"orderIndex"`
+	RestSeconds        int                 `json:"restSeconds"`
+	Notes              string              `json:"notes"`
+	WeightPrescription *WeightPrescription `json:"weightPrescription,omitempty"`
 }
 
 // UpdateWorkoutExerciseRequest represents the request structure for updating workout exercises
@@ -142,6 +1313,10 @@ type UpdateWorkoutExerciseRequest struct {
 	OrderIndex      *int     `json:"orderIndex,omitempty"`
 	RestSeconds     *int     `json:"restSeconds,omitempty"`
 	Notes           *string  `json:"notes,omitempty"`
+	Version         *int     `json:"version,omitempty"`
+
+	// WeightPrescription with an empty percent_of switches back to the fixed weightKg
+	WeightPrescription *WeightPrescription `json:"weightPrescription,omitempty"`
 }
 
 // WorkoutSessionResponse represents the response structure for workout sessions
@@ -154,8 +1329,89 @@ type WorkoutSessionResponse struct {
 	CompletedAt     *time.Time `json:"completedAt,omitempty"`
 	DurationMinutes int        `json:"durationMinutes"`
 	Notes           string     `json:"notes"`
-	CreatedAt       time.Time  `json:"createdAt"`
-	UpdatedAt       time.Time  `json:"updatedAt"`
+	Source          string     `json:"source,omitempty"`
+	ExerciseID      *string    `json:"exerciseId,omitempty"`
+	DurationSeconds *int       `json:"durationSeconds,omitempty"`
+	DistanceMeters  *float64   `json:"distanceMeters,omitempty"`
+	AvgHeartRate    *int       `json:"avgHeartRate,omitempty"`
+	MaxHeartRate    *int       `json:"maxHeartRate,omitempty"`
+	// Weather is set on outdoor sessions imported with GPS, once it has been looked up
+	Weather *SessionWeatherResponse `json:"weather,omitempty"`
+	// RestTimer is the rest countdown running in the session, shown to its owner
+	RestTimer *RestTimerResponse `json:"restTimer,omitempty"`
+	CreatedAt time.Time          `json:"createdAt"`
+	UpdatedAt time.Time          `json:"updatedAt"`
+	Version   int                `json:"version"`
+}
+
+// SessionWeatherResponse is the weather at the start of an outdoor session, around its midpoint
+type SessionWeatherResponse struct {
+	TemperatureC float64 `json:"temperatureC"`
+	WindSpeedKmh float64 `json:"windSpeedKmh"`
+	// WindDirection is where the wind blew from, in degrees clockwise from north
+	WindDirection int `json:"windDirection"`
+}
+
+// RestTimerRequest starts or stops the rest timer of a session
+type RestTimerRequest struct {
+	// Action is "start" or "stop"
+	Action  string `json:"action"`
+	Seconds int    `json:"seconds"`
+}
+
+// RestTimerResponse is a running rest countdown
+type RestTimerResponse struct {
+	StartedAt        time.Time `json:"startedAt"`
+	EndsAt           time.Time `json:"endsAt"`
+	Seconds          int       `json:"seconds"`
+	RemainingSeconds int       `json:"remainingSeconds"`
+}
+
+// WorkoutSessionSetResponse represents a set logged during a workout session
+type WorkoutSessionSetResponse struct {
+	ID              string    `json:"id"`
+	SessionID       string    `json:"sessionId"`
+	ExerciseID      *string   `json:"exerciseId,omitempty"`
+	SetNumber       int       `json:"setNumber"`
+	Reps            int       `json:"reps"`
+	WeightKg        float64   `json:"weightKg"`
+	DurationSeconds *int      `json:"durationSeconds,omitempty"`
+	RPE             *float64  `json:"rpe,omitempty"`
+	RestSeconds     *int      `json:"restSeconds,omitempty"`
+	AMRAP           bool      `json:"amrap,omitempty"`
+	ClientID        string    `json:"clientId"`
+	CompletedAt     time.Time `json:"completedAt"`
+}
+
+// PlannedExerciseResponse represents one exercise of the plan a session was started with,
+// with any percentage prescription resolved to a load
+type PlannedExerciseResponse struct {
+	WorkoutExerciseID  string              `json:"workoutExerciseId"`
+	ExerciseID         string              `json:"exerciseId"`
+	OrderIndex         int                 `json:"orderIndex"`
+	Sets               int                 `json:"sets"`
+	Reps               int                 `json:"reps"`
+	WeightKg           float64             `json:"weightKg"`
+	DurationSeconds    int                 `json:"durationSeconds"`
+	RestSeconds        int                 `json:"restSeconds"`
+	Notes              string              `json:"notes"`
+	WeightPrescription *WeightPrescription `json:"weightPrescription,omitempty"`
+
+	// TrainingMaxKg is the training max the load was resolved from. It's missing when the
+	// user had no training max of that name, in which case weightKg is the fixed weight.
+	TrainingMaxKg *float64 `json:"trainingMaxKg,omitempty"`
+
+	// PlatesPerSideKg is how to load a standard 20 kg bar for a load resolved from a training max
+	PlatesPerSideKg []float64 `json:"platesPerSideKg,omitempty"`
+
+	// ExerciseName is set in generated plans, whose exercises aren't in any of the user's workouts
+	ExerciseName string `json:"exerciseName,omitempty"`
+}
+
+// CopiedSetsResponse represents the sets copied into a session from an earlier one
+type CopiedSetsResponse struct {
+	SourceSessionID string                      `json:"sourceSessionId"`
+	Sets            []WorkoutSessionSetResponse `json:"sets"`
 }
 
 // CreateWorkoutSessionRequest represents the request structure for creating workout sessions
@@ -176,4 +1432,489 @@ type UpdateWorkoutSessionRequest struct {
 	CompletedAt     *time.Time `json:"completedAt,omitempty"`
 	DurationMinutes *int       `json:"durationMinutes,omitempty"`
 	Notes           *string    `json:"notes,omitempty"`
+	Version         *int       `json:"version,omitempty"`
+}
+
+// TrainingMaxResponse represents the response structure for training maxes
+type TrainingMaxResponse struct {
+	ID         string    `json:"id"`
+	Name       string    `json:"name"`
+	ExerciseID *string   `json:"exerciseId,omitempty"`
+	WeightKg   float64   `json:"weightKg"`
+	CreatedAt  time.Time `json:"createdAt"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+
+	// Suggestion is set when AMRAP sets logged since the last change point to a different weight
+	Suggestion *TrainingMaxSuggestion `json:"suggestion,omitempty"`
+}
+
+// TrainingMaxSuggestion is a training max update suggested by an AMRAP set
+type TrainingMaxSuggestion struct {
+	WeightKg             float64                   `json:"weightKg"`
+	EstimatedOneRepMaxKg float64                   `json:"estimatedOneRepMaxKg"`
+	Set                  WorkoutSessionSetResponse `json:"set"`
+}
+
+// TrainingMaxHistoryResponse represents one change in a training max's history
+type TrainingMaxHistoryResponse struct {
+	WeightKg   float64   `json:"weightKg"`
+	Source     string    `json:"source"`
+	RecordedAt time.Time `json:"recordedAt"`
+}
+
+// ProgressionRuleRequest represents the request structure for setting a workout
+// exercise's progression rule. Fields left out take their defaults.
+type ProgressionRuleRequest struct {
+	WeightIncrementKg   *float64 `json:"weightIncrementKg"`
+	MinReps             *int     `json:"minReps"`
+	MaxReps             *int     `json:"maxReps"`
+	DeloadAfterFailures *int     `json:"deloadAfterFailures"`
+	DeloadPercent       *int     `json:"deloadPercent"`
+}
+
+// ProgressionRuleResponse represents the response structure for progression rules
+type ProgressionRuleResponse struct {
+	ID                  string  `json:"id"`
+	WorkoutExerciseID   string  `json:"workoutExerciseId"`
+	WeightIncrementKg   float64 `json:"weightIncrementKg"`
+	MinReps             *int    `json:"minReps,omitempty"`
+	MaxReps             *int    `json:"maxReps,omitempty"`
+	DeloadAfterFailures int     `json:"deloadAfterFailures"`
+	DeloadPercent       int     `json:"deloadPercent"`
+	// Failures counts the failed sessions since the last progression or deload
+	Failures  int       `json:"failures"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// ProgressionStepResponse represents how a session moved a workout exercise's
+// prescription on
+type ProgressionStepResponse struct {
+	WorkoutExerciseID string    `json:"workoutExerciseId"`
+	ExerciseID        string    `json:"exerciseId"`
+	Outcome           string    `json:"outcome"`
+	PreviousReps      int       `json:"previousReps"`
+	PreviousWeightKg  float64   `json:"previousWeightKg"`
+	Reps              int       `json:"reps"`
+	WeightKg          float64   `json:"weightKg"`
+	CreatedAt         time.Time `json:"createdAt"`
+}
+
+// ForecastPointResponse represents the best estimated one-rep max on a day
+type ForecastPointResponse struct {
+	Date                 string  `json:"date"`
+	EstimatedOneRepMaxKg float64 `json:"estimatedOneRepMaxKg"`
+}
+
+// ForecastProjectionResponse represents the one-rep max projected for a day, with the range
+// it is likely to fall in
+type ForecastProjectionResponse struct {
+	Date       string  `json:"date"`
+	EstimateKg float64 `json:"estimateKg"`
+	LowKg      float64 `json:"lowKg"`
+	HighKg     float64 `json:"highKg"`
+}
+
+// ForecastMilestoneResponse represents when a one-rep max target is projected to be reached.
+// Dates are nil when the trend doesn't reach the target within two years.
+type ForecastMilestoneResponse struct {
+	TargetKg     float64 `json:"targetKg"`
+	ReachedOn    *string `json:"reachedOn,omitempty"`
+	ExpectedDate *string `json:"expectedDate"`
+	EarliestDate *string `json:"earliestDate"`
+	LatestDate   *string `json:"latestDate"`
+}
+
+// ForecastResponse represents a trend fitted over a training max's one-rep max estimates and
+// projected forward
+type ForecastResponse struct {
+	Metric          string                       `json:"metric"`
+	ExerciseID      string                       `json:"exerciseId"`
+	Model           string                       `json:"model"`
+	ConfidenceLevel float64                      `json:"confidenceLevel"`
+	CurrentKg       float64                      `json:"currentKg"`
+	KgPerWeek       float64                      `json:"kgPerWeek"`
+	History         []ForecastPointResponse      `json:"history"`
+	Projection      []ForecastProjectionResponse `json:"projection"`
+	Milestones      []ForecastMilestoneResponse  `json:"milestones"`
+}
+
+// BenchmarkConsentResponse represents whether the user opted in to benchmarking
+type BenchmarkConsentResponse struct {
+	OptedIn     bool       `json:"optedIn"`
+	ConsentedAt *time.Time `json:"consentedAt"`
+}
+
+// BenchmarkPercentilesResponse represents points of a benchmark distribution, in kg
+type BenchmarkPercentilesResponse struct {
+	P10 float64 `json:"p10"`
+	P25 float64 `json:"p25"`
+	P50 float64 `json:"p50"`
+	P75 float64 `json:"p75"`
+	P90 float64 `json:"p90"`
+}
+
+// BenchmarkLiftResponse represents the user's lift placed in a benchmark distribution
+type BenchmarkLiftResponse struct {
+	EstimatedOneRepMaxKg float64 `json:"estimatedOneRepMaxKg"`
+	// Percentile is the share of lifters in the distribution, out of 100, with a lower estimate
+	Percentile int `json:"percentile"`
+}
+
+// BenchmarkResponse represents an exercise's anonymized benchmark in a bodyweight class,
+// compared with the user's own lift. You is nil when the user logged no sets to compare.
+type BenchmarkResponse struct {
+	ExerciseID      string                       `json:"exerciseId"`
+	BodyweightClass string                       `json:"bodyweightClass"`
+	SampleSize      int                          `json:"sampleSize"`
+	ComputedAt      time.Time                    `json:"computedAt"`
+	Percentiles     BenchmarkPercentilesResponse `json:"percentiles"`
+	You             *BenchmarkLiftResponse       `json:"you"`
+}
+
+// PlatesResponse represents how to load a barbell for a target weight
+type PlatesResponse struct {
+	WeightKg        float64   `json:"weightKg"`
+	BarKg           float64   `json:"barKg"`
+	PlatesPerSideKg []float64 `json:"platesPerSideKg"`
+
+	// LoadedKg is what the bar weighs when loaded, short of weightKg by remainderKg when the
+	// plates can't make the target exactly
+	LoadedKg    float64 `json:"loadedKg"`
+	RemainderKg float64 `json:"remainderKg"`
+}
+
+// PutTrainingMaxRequest represents the request structure for setting a training max
+type PutTrainingMaxRequest struct {
+	WeightKg   float64 `json:"weightKg"`
+	ExerciseID *string `json:"exerciseId,omitempty"`
+}
+
+// SessionFeedbackResponse represents the feedback given on a workout session
+type SessionFeedbackResponse struct {
+	SessionID string   `json:"sessionId"`
+	RPE       int      `json:"rpe"`
+	Enjoyment *int     `json:"enjoyment,omitempty"`
+	PainAreas []string `json:"painAreas"`
+	Notes     string   `json:"notes"`
+
+	// Load is the session RPE times the session's duration in minutes
+	Load      int       `json:"load"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// SessionFeedbackRequest represents the request structure for giving feedback on a session
+type SessionFeedbackRequest struct {
+	RPE       int      `json:"rpe"`
+	Enjoyment *int     `json:"enjoyment,omitempty"`
+	PainAreas []string `json:"painAreas,omitempty"`
+	Notes     string   `json:"notes"`
+}
+
+// WorkloadWeekResponse is the acute:chronic workload ratio of the week ending at WeekEnding
+type WorkloadWeekResponse struct {
+	WeekEnding      time.Time `json:"weekEnding"`
+	AcuteVolumeKg   float64   `json:"acuteVolumeKg"`
+	ChronicVolumeKg float64   `json:"chronicVolumeKg"`
+	Ratio           *float64  `json:"ratio,omitempty"`
+}
+
+// WorkloadResponse represents the user's volume workload and whether a deload week is due
+type WorkloadResponse struct {
+	AcuteVolumeKg   float64                `json:"acuteVolumeKg"`
+	ChronicVolumeKg float64                `json:"chronicVolumeKg"`
+	Ratio           *float64               `json:"ratio,omitempty"`
+	Status          string                 `json:"status"`
+	DeloadSuggested bool                   `json:"deloadSuggested"`
+	Reasons         []string               `json:"reasons"`
+	History         []WorkloadWeekResponse `json:"history"`
+}
+
+// AnalyticsReadinessResponse is the user's readiness from their session feedback with
+// their workload from the volume they lifted
+type AnalyticsReadinessResponse struct {
+	Readiness ReadinessResponse `json:"readiness"`
+	Workload  WorkloadResponse  `json:"workload"`
+}
+
+// ReadinessResponse represents how ready the user is to train, from their session feedback
+type ReadinessResponse struct {
+	Score  int    `json:"score"`
+	Status string `json:"status"`
+
+	// AcuteLoad is the session load of the last week, ChronicLoad the weekly average of the
+	// last four. LoadRatio is unset until there is a chronic load to compare with.
+	AcuteLoad        int      `json:"acuteLoad"`
+	ChronicLoad      int      `json:"chronicLoad"`
+	LoadRatio        *float64 `json:"loadRatio,omitempty"`
+	AverageEnjoyment *float64 `json:"averageEnjoyment,omitempty"`
+	RecentPainAreas  []string `json:"recentPainAreas"`
+	Reasons          []string `json:"reasons"`
+	SessionsRated    int      `json:"sessionsRated"`
+}
+
+// WorkoutSuggestionResponse is what the user should do today: a workout, or rest when
+// their readiness calls for it
+type WorkoutSuggestionResponse struct {
+	Kind      string                    `json:"kind"`
+	Workout   *SuggestedWorkoutResponse `json:"workout,omitempty"`
+	Readiness ReadinessResponse         `json:"readiness"`
+	Reasons   []string                  `json:"reasons"`
+}
+
+// SuggestedWorkoutResponse is a suggested workout with the plan to follow today, which is
+// the workout's plan cut down when Adjusted
+type SuggestedWorkoutResponse struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	ProgramID  string     `json:"programId,omitempty"`
+	LastDoneAt *time.Time `json:"lastDoneAt,omitempty"`
+
+	Adjusted         bool                      `json:"adjusted"`
+	EstimatedMinutes int                       `json:"estimatedMinutes"`
+	FullMinutes      int                       `json:"fullMinutes"`
+	Plan             []PlannedExerciseResponse `json:"plan"`
+}
+
+// WorkoutRecommendationResponse is the workout recommended next, and the recommender that
+// picked it. Kind is "workout" for one of the user's workouts, "generated" for a workout
+// put together from the exercise library, or "rest".
+type WorkoutRecommendationResponse struct {
+	Kind        string                    `json:"kind"`
+	Recommender string                    `json:"recommender"`
+	Workout     *SuggestedWorkoutResponse `json:"workout,omitempty"`
+	// TargetMuscles are the slugs of the muscle groups a generated workout works
+	TargetMuscles []string `json:"targetMuscles,omitempty"`
+	// RestDays is how many days ago the user last trained, unset when not in the last four weeks
+	RestDays *int     `json:"restDays,omitempty"`
+	Reasons  []string `json:"reasons"`
+}
+
+// ProgramAdjustmentMetrics is what the adjustment job measured over the program week
+// before an adjustment, stored with the adjustment
+type ProgramAdjustmentMetrics struct {
+	PlannedSessions   int `json:"plannedSessions"`
+	CompletedSessions int `json:"completedSessions"`
+	// Adherence is the share of planned sessions done, at most 1
+	Adherence float64 `json:"adherence"`
+	// SetCompletion is the share of the sets the sessions' plans called for that were
+	// logged, unset when no plan called for sets
+	SetCompletion *float64 `json:"setCompletion,omitempty"`
+	AverageRPE    *float64 `json:"averageRpe,omitempty"`
+	PainReports   int      `json:"painReports"`
+}
+
+// ProgramAdjustmentResponse represents the response structure for program adjustments
+type ProgramAdjustmentResponse struct {
+	ID            string                   `json:"id"`
+	ProgramID     string                   `json:"programId"`
+	Week          int                      `json:"week"`
+	Kind          string                   `json:"kind"`
+	VolumePercent int                      `json:"volumePercent"`
+	Deload        bool                     `json:"deload"`
+	Reason        string                   `json:"reason"`
+	Metrics       ProgramAdjustmentMetrics `json:"metrics"`
+	Status        string                   `json:"status"`
+	ReviewedBy    *string                  `json:"reviewedBy,omitempty"`
+	ReviewedAt    *time.Time               `json:"reviewedAt,omitempty"`
+	ReviewNote    string                   `json:"reviewNote,omitempty"`
+	AppliedAt     *time.Time               `json:"appliedAt,omitempty"`
+	CreatedAt     time.Time                `json:"createdAt"`
+}
+
+// ReviewProgramAdjustmentRequest represents the request structure for approving or
+// rejecting a program adjustment
+type ReviewProgramAdjustmentRequest struct {
+	Note string `json:"note"`
+}
+
+// ProgramCoachRequest represents the request structure for assigning a program's coach
+type ProgramCoachRequest struct {
+	CoachID string `json:"coachId"`
+}
+
+// CoachClientResponse represents one of a coach's clients, or an invitation they sent that
+// is still pending. ClientID and Username are only set once the invitation is accepted.
+type CoachClientResponse struct {
+	ID         string     `json:"id"`
+	ClientID   *string    `json:"clientId,omitempty"`
+	Username   *string    `json:"username,omitempty"`
+	Email      string     `json:"email"`
+	Status     string     `json:"status"`
+	Expired    bool       `json:"expired"`
+	ExpiresAt  time.Time  `json:"expiresAt"`
+	AcceptedAt *time.Time `json:"acceptedAt,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+// CoachResponse represents one of the coaches a user is a client of
+type CoachResponse struct {
+	ID       string    `json:"id"`
+	CoachID  string    `json:"coachId"`
+	Username string    `json:"username"`
+	Since    time.Time `json:"since"`
+}
+
+// CreateCoachInviteRequest represents the request structure for inviting someone to be coached
+type CreateCoachInviteRequest struct {
+	Email string `json:"email"`
+}
+
+// AcceptCoachInviteRequest represents the request structure for accepting a coaching invitation
+type AcceptCoachInviteRequest struct {
+	Token string `json:"token"`
+}
+
+// FollowResponse is a user on the other side of a follow
+type FollowResponse struct {
+	UserID     string    `json:"userId"`
+	Username   string    `json:"username"`
+	FollowedAt time.Time `json:"followedAt"`
+}
+
+// PrivacySettingsResponse is what the user shares with their followers, and whether they
+// appear on leaderboards
+type PrivacySettingsResponse struct {
+	AllowFollowers       bool `json:"allowFollowers"`
+	ShareSessions        bool `json:"shareSessions"`
+	SharePersonalRecords bool `json:"sharePersonalRecords"`
+	ShowOnLeaderboards   bool `json:"showOnLeaderboards"`
+}
+
+// UpdatePrivacySettingsRequest represents the request structure for changing privacy settings
+type UpdatePrivacySettingsRequest struct {
+	AllowFollowers       *bool `json:"allowFollowers,omitempty"`
+	ShareSessions        *bool `json:"shareSessions,omitempty"`
+	SharePersonalRecords *bool `json:"sharePersonalRecords,omitempty"`
+	ShowOnLeaderboards   *bool `json:"showOnLeaderboards,omitempty"`
+}
+
+// LeaderboardResponse is a page of an exercise's leaderboard for a period. Scores are in
+// kg for both metrics.
+type LeaderboardResponse struct {
+	ExerciseID  string                    `json:"exerciseId"`
+	Metric      string                    `json:"metric"`
+	Period      string                    `json:"period"`
+	PeriodStart *time.Time                `json:"periodStart,omitempty"`
+	ComputedAt  time.Time                 `json:"computedAt"`
+	Total       int                       `json:"total"`
+	Entries     []LeaderboardRankResponse `json:"entries"`
+	// You is the caller's place, missing unless they opted in and lifted in the period
+	You *LeaderboardRankResponse `json:"you,omitempty"`
+}
+
+// LeaderboardRankResponse is a lifter's place on a leaderboard
+type LeaderboardRankResponse struct {
+	Rank        int     `json:"rank"`
+	DisplayName string  `json:"displayName"`
+	Score       float64 `json:"score"`
+}
+
+// CreateChallengeRequest represents the request structure for creating a challenge
+type CreateChallengeRequest struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Metric      string     `json:"metric"`
+	Target      float64    `json:"target"`
+	StartsAt    *time.Time `json:"startsAt,omitempty"`
+	Days        int        `json:"days"`
+}
+
+// ChallengeResponse is a challenge with its participant count and the caller's progress,
+// set when they joined. Targets and progress are in sessions, minutes or kg by metric.
+type ChallengeResponse struct {
+	ID           string                     `json:"id"`
+	Name         string                     `json:"name"`
+	Description  string                     `json:"description"`
+	Metric       string                     `json:"metric"`
+	Target       float64                    `json:"target"`
+	StartsAt     time.Time                  `json:"startsAt"`
+	EndsAt       time.Time                  `json:"endsAt"`
+	CreatedBy    *string                    `json:"createdBy"`
+	Participants int                        `json:"participants"`
+	Joined       bool                       `json:"joined"`
+	You          *ChallengeStandingResponse `json:"you,omitempty"`
+	CreatedAt    time.Time                  `json:"createdAt"`
+}
+
+// ChallengeStandingResponse is a participant's place and progress in a challenge
+type ChallengeStandingResponse struct {
+	Rank        int        `json:"rank"`
+	DisplayName string     `json:"displayName"`
+	Progress    float64    `json:"progress"`
+	CompletedAt *time.Time `json:"completedAt"`
+}
+
+// ChallengeStandingsResponse is a page of a challenge's standings
+type ChallengeStandingsResponse struct {
+	ChallengeID string                      `json:"challengeId"`
+	Target      float64                     `json:"target"`
+	Total       int                         `json:"total"`
+	Entries     []ChallengeStandingResponse `json:"entries"`
+	// You is the caller's place, missing unless they joined
+	You *ChallengeStandingResponse `json:"you,omitempty"`
+}
+
+// FeedItemResponse is an item of the activity feed, with Session set for a completed
+// session and PersonalRecord for a personal record
+type FeedItemResponse struct {
+	ID             string                      `json:"id"`
+	Kind           string                      `json:"kind"`
+	OccurredAt     time.Time                   `json:"occurredAt"`
+	UserID         string                      `json:"userId"`
+	Username       string                      `json:"username"`
+	Session        *FeedSessionResponse        `json:"session,omitempty"`
+	PersonalRecord *FeedPersonalRecordResponse `json:"personalRecord,omitempty"`
+}
+
+// FeedSessionResponse is a completed workout session in the activity feed
+type FeedSessionResponse struct {
+	ID              string `json:"id"`
+	Name            string `json:"name"`
+	DurationMinutes int    `json:"durationMinutes"`
+}
+
+// FeedPersonalRecordResponse is a personal record in the activity feed
+type FeedPersonalRecordResponse struct {
+	ExerciseID     string  `json:"exerciseId"`
+	ExerciseName   string  `json:"exerciseName"`
+	WeightKg       float64 `json:"weightKg"`
+	Reps           int     `json:"reps"`
+	PreviousBestKg float64 `json:"previousBestKg"`
+}
+
+// RestIntervalResponse is the rest taken before a set, from when the previous set of the
+// same exercise was completed to when this one began
+type RestIntervalResponse struct {
+	SetID                 string `json:"setId"`
+	ExerciseID            string `json:"exerciseId"`
+	SetNumber             int    `json:"setNumber"`
+	RestSeconds           int    `json:"restSeconds"`
+	PrescribedRestSeconds *int   `json:"prescribedRestSeconds,omitempty"`
+	CutShort              bool   `json:"cutShort"`
+}
+
+// RestAdherenceResponse summarizes rests taken against the rests prescribed, for one
+// exercise or overall
+type RestAdherenceResponse struct {
+	ExerciseID         string `json:"exerciseId,omitempty"`
+	Rests              int    `json:"rests"`
+	AverageRestSeconds int    `json:"averageRestSeconds"`
+
+	// PrescribedRests counts the rests the session plans prescribed a rest for. The fields
+	// below only cover those; the average and adherence are unset when there are none.
+	PrescribedRests              int  `json:"prescribedRests"`
+	AveragePrescribedRestSeconds *int `json:"averagePrescribedRestSeconds,omitempty"`
+	CutShort                     int  `json:"cutShort"`
+	// Adherence is the share of prescribed rests that weren't cut short
+	Adherence *float64 `json:"adherence,omitempty"`
+}
+
+// RestAnalyticsResponse represents the rests taken between sets, by exercise
+type RestAnalyticsResponse struct {
+	Overall   RestAdherenceResponse   `json:"overall"`
+	Exercises []RestAdherenceResponse `json:"exercises"`
+	// Intervals lists each rest, for a single session
+	Intervals []RestIntervalResponse `json:"intervals,omitempty"`
 }
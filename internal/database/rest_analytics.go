@@ -0,0 +1,68 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"fitness-hack/internal/database/sqlbuild"
+)
+
+// RestSet is a set logged in a session, with the rest the session's plan prescribed for
+// its exercise. Prescribed_rest_seconds is nil when the plan has no rest for the exercise.
+type RestSet struct {
+	Workout_session_sets
+	Prescribed_rest_seconds *int `db:"prescribed_rest_seconds"`
+}
+
+// restSetColumns are the columns ListRestSets filters on
+var restSetColumns = sqlbuild.Columns{
+	"user_id":    "ws.user_id",
+	"session_id": "s.session_id",
+	"started_at": "ws.started_at",
+}
+
+// RestSetFilter narrows ListRestSets. Zero fields don't filter.
+type RestSetFilter struct {
+	UserID    string
+	SessionID string
+
+	// From and To keep the sets of sessions started in [From, To)
+	From time.Time
+	To   time.Time
+}
+
+// ListRestSets returns the logged sets of exercises matching filter, by session in the order
+// they were completed. Sets copied from an earlier session are left out, since their
+// completion times weren't logged.
+func (s *service) ListRestSets(ctx context.Context, filter RestSetFilter) ([]RestSet, error) {
+	q := sqlbuild.New(`SELECT s.*,
+			(SELECT NULLIF((p->>'restSeconds')::int, 0) FROM jsonb_array_elements(ws.plan) p
+			WHERE p->>'exerciseId' = s.exercise_id::text
+			ORDER BY (p->>'orderIndex')::int LIMIT 1) AS prescribed_rest_seconds
+		FROM workout_session_sets s
+		JOIN workout_sessions ws ON ws.id = s.session_id
+		WHERE s.exercise_id IS NOT NULL AND left(s.client_id, 5) <> 'copy:'`)
+	if filter.UserID != "" {
+		q.Filter(restSetColumns, "user_id", filter.UserID)
+	}
+	if filter.SessionID != "" {
+		q.Filter(restSetColumns, "session_id", filter.SessionID)
+	}
+	if !filter.From.IsZero() {
+		q.Compare(restSetColumns, "started_at", sqlbuild.Gte, filter.From)
+	}
+	if !filter.To.IsZero() {
+		q.Compare(restSetColumns, "started_at", sqlbuild.Lt, filter.To)
+	}
+	query, args, err := q.OrderBy(nil, "", "", "", "ws.started_at, s.session_id, s.completed_at, s.set_number").Build()
+	if err != nil {
+		return nil, err
+	}
+
+	sets := []RestSet{}
+	if err := s.db.SelectContext(ctx, &sets, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to list rest sets: %w", err)
+	}
+	return sets, nil
+}
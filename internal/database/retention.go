@@ -0,0 +1,135 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Retention categories, i.e. the kinds of user data a retention policy can apply to
+const (
+	RetentionSessions    = "sessions"
+	RetentionBodyMetrics = "body_metrics"
+	RetentionPhotos      = "photos"
+)
+
+// retentionCategory describes where a category's rows live and which column ages them
+type retentionCategory struct {
+	table      string
+	timeColumn string
+	// fileColumns hold the storage keys of the files that go with each row
+	fileColumns []string
+}
+
+var retentionCategories = map[string]retentionCategory{
+	RetentionSessions:    {table: "workout_sessions", timeColumn: "started_at"},
+	RetentionBodyMetrics: {table: "body_metrics", timeColumn: "recorded_at"},
+	RetentionPhotos:      {table: "progress_photos", timeColumn: "taken_at", fileColumns: []string{"storage_key", "thumbnail_key"}},
+}
+
+// RetainedDataPurge is what one call to PurgeRetainedData deleted
+type RetainedDataPurge struct {
+	Rows int64
+	// StorageKeys are the files of the deleted rows, which the caller deletes from storage
+	StorageKeys []string
+}
+
+// RetentionCategories returns the categories retention policies can be set for, sorted by name
+func RetentionCategories() []string {
+	categories := make([]string, 0, len(retentionCategories))
+	for category := range retentionCategories {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+	return categories
+}
+
+// heldUser returns a condition matching rows of the aliased table whose user_id is under an
+// active legal hold, either personally or through an organization they belong to
+func heldUser(alias string) string {
+	return fmt.Sprintf(`EXISTS (
+		SELECT 1 FROM legal_holds h
+		WHERE h.released_at IS NULL AND (
+			h.user_id = %[1]s.user_id
+			OR (h.user_id IS NULL AND h.organization_id IN (
+				SELECT m.organization_id FROM organization_members m WHERE m.user_id = %[1]s.user_id))
+		))`, alias)
+}
+
+// PurgeRetainedData deletes up to limit rows of a category that are older than the cutoff,
+// skipping users under legal hold, and returns how many were deleted with their files
+func (s *service) PurgeRetainedData(ctx context.Context, category string, olderThan time.Time, limit int) (*RetainedDataPurge, error) {
+	c, ok := retentionCategories[category]
+	if !ok {
+		return nil, fmt.Errorf("unknown retention category %q", category)
+	}
+
+	returning := append([]string{"id"}, c.fileColumns...)
+	query := fmt.Sprintf(`DELETE FROM %[1]s WHERE id IN (
+			SELECT t.id FROM %[1]s t
+			WHERE t.%[2]s < $1 AND NOT %[3]s
+			LIMIT $2
+		)
+		RETURNING %[4]s`, c.table, c.timeColumn, heldUser("t"), strings.Join(returning, ", "))
+	rows, err := s.db.QueryContext(ctx, query, olderThan, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to purge %s: %w", category, err)
+	}
+	defer rows.Close()
+
+	purge := &RetainedDataPurge{}
+	values := make([]string, len(returning))
+	dest := make([]any, len(values))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("failed to purge %s: %w", category, err)
+		}
+		purge.Rows++
+		for _, key := range values[1:] {
+			if key != "" {
+				purge.StorageKeys = append(purge.StorageKeys, key)
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to purge %s: %w", category, err)
+	}
+	return purge, nil
+}
+
+// CreateLegalHold places a hold on an organization, or on one of its members when userID is set
+func (s *service) CreateLegalHold(ctx context.Context, orgID string, userID *string, reason, createdBy string) (*Legal_holds, error) {
+	var hold Legal_holds
+	query := `INSERT INTO legal_holds (organization_id, user_id, reason, created_by) VALUES ($1, $2, $3, $4) RETURNING *`
+	if err := s.db.GetContext(ctx, &hold, query, orgID, userID, reason, createdBy); err != nil {
+		return nil, err
+	}
+	return &hold, nil
+}
+
+// ListLegalHolds returns the organization's holds, active ones first
+func (s *service) ListLegalHolds(ctx context.Context, orgID string) ([]Legal_holds, error) {
+	var holds []Legal_holds
+	query := `SELECT * FROM legal_holds WHERE organization_id = $1
+		ORDER BY released_at IS NOT NULL, created_at DESC`
+	err := s.db.SelectContext(ctx, &holds, query, orgID)
+	return holds, err
+}
+
+// ReleaseLegalHold lifts an active hold.
+// Returns sql.ErrNoRows if the hold does not exist in the organization or was already released.
+func (s *service) ReleaseLegalHold(ctx context.Context, orgID, holdID, releasedBy string) (*Legal_holds, error) {
+	var hold Legal_holds
+	query := `UPDATE legal_holds SET released_at = NOW(), released_by = $3
+		WHERE id = $1 AND organization_id = $2 AND released_at IS NULL
+		RETURNING *`
+	if err := s.db.GetContext(ctx, &hold, query, holdID, orgID, releasedBy); err != nil {
+		return nil, err
+	}
+	return &hold, nil
+}
@@ -0,0 +1,129 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// retentionFixture is a migrated database with an organization and a user with an old
+// session, body measurement and photo in it
+type retentionFixture struct {
+	srv    Service
+	orgID  string
+	userID string
+}
+
+func newRetentionFixture(t *testing.T) *retentionFixture {
+	t.Helper()
+	ctx := context.Background()
+	f := &retentionFixture{srv: newMigratedService(t)}
+	db := f.srv.GetDB()
+	if err := db.GetContext(ctx, &f.orgID, `INSERT INTO organizations (name) VALUES ('Gym') RETURNING id`); err != nil {
+		t.Fatal(err)
+	}
+	err := db.GetContext(ctx, &f.userID, `INSERT INTO users (email, username, password_hash) VALUES ($1, $2, 'hash') RETURNING id`,
+		testEmail(), "u_"+uuid.NewString()[:8])
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().AddDate(-2, 0, 0)
+	seeds := []struct {
+		query string
+		args  []any
+	}{
+		{`INSERT INTO organization_members (organization_id, user_id) VALUES ($1, $2)`, []any{f.orgID, f.userID}},
+		{`INSERT INTO workout_sessions (user_id, name, started_at) VALUES ($1, 'Legs', $2)`, []any{f.userID, old}},
+		{`INSERT INTO body_metrics (user_id, metric, measured_value, recorded_at) VALUES ($1, 'weight_kg', 80, $2)`, []any{f.userID, old}},
+		{`INSERT INTO progress_photos (user_id, taken_at, content_type, storage_key, thumbnail_key) VALUES ($1, $2, 'image/jpeg', $3, $4)`,
+			[]any{f.userID, old, f.photoKey(""), f.photoKey("-thumb")}},
+	}
+	for _, seed := range seeds {
+		if _, err := db.ExecContext(ctx, seed.query, seed.args...); err != nil {
+			t.Fatalf("failed to seed %q: %v", seed.query, err)
+		}
+	}
+	return f
+}
+
+// photoKey returns the storage key of the user's photo, or of its thumbnail with suffix "-thumb"
+func (f *retentionFixture) photoKey(suffix string) string {
+	return "progress-photos/" + f.userID + "/p" + suffix + ".jpg"
+}
+
+// purgeAll purges every retention category of data older than a year and returns what went
+func (f *retentionFixture) purgeAll(t *testing.T) map[string]*RetainedDataPurge {
+	t.Helper()
+	purged := map[string]*RetainedDataPurge{}
+	for _, category := range RetentionCategories() {
+		purge, err := f.srv.PurgeRetainedData(context.Background(), category, time.Now().AddDate(-1, 0, 0), 1000)
+		if err != nil {
+			t.Fatalf("failed to purge %s: %v", category, err)
+		}
+		purged[category] = purge
+	}
+	return purged
+}
+
+// remaining returns how many of the user's sessions, body measurements and photos are left
+func (f *retentionFixture) remaining(t *testing.T) int {
+	t.Helper()
+	var n int
+	err := f.srv.GetDB().GetContext(context.Background(), &n, `SELECT
+			(SELECT COUNT(*) FROM workout_sessions WHERE user_id = $1)
+			+ (SELECT COUNT(*) FROM body_metrics WHERE user_id = $1)
+			+ (SELECT COUNT(*) FROM progress_photos WHERE user_id = $1)`, f.userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestPurgeRetainedDataDeletesOldData(t *testing.T) {
+	f := newRetentionFixture(t)
+
+	purged := f.purgeAll(t)
+	if n := f.remaining(t); n != 0 {
+		t.Errorf("expected all of the user's old data purged, %d rows left", n)
+	}
+	photos := purged[RetentionPhotos]
+	if photos.Rows < 1 || len(photos.StorageKeys) < 2 {
+		t.Fatalf("expected the photo and its files purged, got %+v", photos)
+	}
+	want := map[string]bool{f.photoKey(""): true, f.photoKey("-thumb"): true}
+	for _, key := range photos.StorageKeys {
+		delete(want, key)
+	}
+	if len(want) > 0 {
+		t.Errorf("expected the photo's files returned for deletion, missing %v", want)
+	}
+}
+
+func TestPurgeRetainedDataSkipsHeldUsers(t *testing.T) {
+	for name, hold := range map[string]string{
+		"personal hold":     `INSERT INTO legal_holds (organization_id, user_id, reason, created_by) VALUES ($1, $2, 'litigation', $2)`,
+		"organization hold": `INSERT INTO legal_holds (organization_id, reason, created_by) VALUES ($1, 'litigation', $2)`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			f := newRetentionFixture(t)
+			if _, err := f.srv.GetDB().ExecContext(context.Background(), hold, f.orgID, f.userID); err != nil {
+				t.Fatal(err)
+			}
+
+			f.purgeAll(t)
+			if n := f.remaining(t); n != 3 {
+				t.Fatalf("expected the held user's session, measurement and photo kept, %d of 3 left", n)
+			}
+
+			if _, err := f.srv.GetDB().ExecContext(context.Background(), `UPDATE legal_holds SET released_at = NOW() WHERE organization_id = $1`, f.orgID); err != nil {
+				t.Fatal(err)
+			}
+			f.purgeAll(t)
+			if n := f.remaining(t); n != 0 {
+				t.Errorf("expected the data purged once the hold was released, %d rows left", n)
+			}
+		})
+	}
+}
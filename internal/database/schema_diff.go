@@ -0,0 +1,302 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// DefaultSchemaFile is the declarative schema diff-migration compares the database to. It
+// is a snapshot of the schema, written by snapshot-schema and edited by hand to describe
+// the schema wanted.
+func DefaultSchemaFile() string {
+	return "internal/database/schema.json"
+}
+
+// Schema describes the tables of a database, as far as migrations need to recreate them
+type Schema struct {
+	Tables map[string]*SchemaTable `json:"tables"`
+}
+
+// SchemaTable describes a table's columns, primary key, constraints and indexes
+type SchemaTable struct {
+	Columns    []SchemaColumn `json:"columns"`
+	PrimaryKey []string       `json:"primaryKey,omitempty"`
+
+	// Constraints are the foreign key, unique and check constraints by name, as
+	// pg_get_constraintdef prints them
+	Constraints map[string]string `json:"constraints,omitempty"`
+
+	// Indexes are the indexes not backing a constraint by name, as CREATE INDEX statements
+	Indexes map[string]string `json:"indexes,omitempty"`
+}
+
+// SchemaColumn describes a column. Type is as format_type prints it, e.g. "text" or
+// "timestamp with time zone".
+type SchemaColumn struct {
+	Name     string  `json:"name"`
+	Type     string  `json:"type"`
+	Nullable bool    `json:"nullable"`
+	Default  *string `json:"default,omitempty"`
+}
+
+// column returns the table's column with the given name, or nil
+func (t *SchemaTable) column(name string) *SchemaColumn {
+	for i := range t.Columns {
+		if t.Columns[i].Name == name {
+			return &t.Columns[i]
+		}
+	}
+	return nil
+}
+
+// IntrospectSchema reads the tables of the current schema, leaving out the migrations table
+func IntrospectSchema(ctx context.Context, db *sqlx.DB) (*Schema, error) {
+	schema := &Schema{Tables: map[string]*SchemaTable{}}
+
+	var columns []struct {
+		Table    string  `db:"table_name"`
+		Name     string  `db:"column_name"`
+		Type     string  `db:"column_type"`
+		Nullable bool    `db:"nullable"`
+		Default  *string `db:"column_default"`
+	}
+	err := db.SelectContext(ctx, &columns, `SELECT c.relname AS table_name, a.attname AS column_name,
+			format_type(a.atttypid, a.atttypmod) AS column_type, NOT a.attnotnull AS nullable,
+			pg_get_expr(d.adbin, d.adrelid) AS column_default
+		FROM pg_attribute a
+		JOIN pg_class c ON c.oid = a.attrelid
+		LEFT JOIN pg_attrdef d ON d.adrelid = a.attrelid AND d.adnum = a.attnum
+		WHERE c.relnamespace = current_schema()::regnamespace AND c.relkind = 'r'
+			AND c.relname <> 'migrations' AND a.attnum > 0 AND NOT a.attisdropped
+		ORDER BY c.relname, a.attnum`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read columns: %w", err)
+	}
+	for _, col := range columns {
+		table, ok := schema.Tables[col.Table]
+		if !ok {
+			table = &SchemaTable{Constraints: map[string]string{}, Indexes: map[string]string{}}
+			schema.Tables[col.Table] = table
+		}
+		table.Columns = append(table.Columns, SchemaColumn{Name: col.Name, Type: col.Type, Nullable: col.Nullable, Default: col.Default})
+	}
+
+	var constraints []struct {
+		Table      string `db:"table_name"`
+		Name       string `db:"constraint_name"`
+		Type       string `db:"constraint_type"`
+		Definition string `db:"definition"`
+		Columns    string `db:"columns"`
+	}
+	err = db.SelectContext(ctx, &constraints, `SELECT c.relname AS table_name, con.conname AS constraint_name,
+			con.contype::text AS constraint_type, pg_get_constraintdef(con.oid) AS definition,
+			COALESCE((SELECT string_agg(a.attname, ',' ORDER BY k.ord)
+				FROM unnest(con.conkey) WITH ORDINALITY AS k(attnum, ord)
+				JOIN pg_attribute a ON a.attrelid = con.conrelid AND a.attnum = k.attnum), '') AS columns
+		FROM pg_constraint con
+		JOIN pg_class c ON c.oid = con.conrelid
+		WHERE c.relnamespace = current_schema()::regnamespace AND c.relname <> 'migrations'
+			AND con.contype IN ('p', 'f', 'u', 'c')
+		ORDER BY c.relname, con.conname`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read constraints: %w", err)
+	}
+	for _, con := range constraints {
+		table, ok := schema.Tables[con.Table]
+		if !ok {
+			continue
+		}
+		if con.Type == "p" {
+			table.PrimaryKey = strings.Split(con.Columns, ",")
+			continue
+		}
+		table.Constraints[con.Name] = con.Definition
+	}
+
+	var indexes []struct {
+		Table      string `db:"tablename"`
+		Name       string `db:"indexname"`
+		Definition string `db:"indexdef"`
+	}
+	err = db.SelectContext(ctx, &indexes, `SELECT i.tablename, i.indexname, i.indexdef
+		FROM pg_indexes i
+		WHERE i.schemaname = current_schema() AND i.tablename <> 'migrations'
+			AND NOT EXISTS (
+				SELECT 1 FROM pg_constraint con
+				WHERE con.conindid = (quote_ident(i.schemaname) || '.' || quote_ident(i.indexname))::regclass
+			)
+		ORDER BY i.tablename, i.indexname`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read indexes: %w", err)
+	}
+	for _, idx := range indexes {
+		if table, ok := schema.Tables[idx.Table]; ok {
+			table.Indexes[idx.Name] = idx.Definition
+		}
+	}
+
+	return schema, nil
+}
+
+// LoadSchemaFile reads a schema written by SaveSchemaFile
+func LoadSchemaFile(path string) (*Schema, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var schema Schema
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil, fmt.Errorf("invalid schema file %s: %w", path, err)
+	}
+	if schema.Tables == nil {
+		schema.Tables = map[string]*SchemaTable{}
+	}
+	return &schema, nil
+}
+
+// SaveSchemaFile writes schema as indented JSON, with tables and their maps in name order
+// so snapshots diff cleanly in review
+func SaveSchemaFile(path string, schema *Schema) error {
+	data, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// DiffSchemas returns the statements that change the from schema into the to schema.
+// Statements that lose data, dropping tables and columns or narrowing a column's type,
+// are commented out so they are only run after review.
+func DiffSchemas(from, to *Schema) []string {
+	var creates, alters, constraints, indexes, drops []string
+
+	for _, name := range sortedKeys(to.Tables) {
+		want := to.Tables[name]
+		have, ok := from.Tables[name]
+		if !ok {
+			creates = append(creates, createTableSQL(name, want))
+			for _, conName := range sortedKeys(want.Constraints) {
+				constraints = append(constraints, fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s %s;", name, conName, want.Constraints[conName]))
+			}
+			for _, idxName := range sortedKeys(want.Indexes) {
+				indexes = append(indexes, want.Indexes[idxName]+";")
+			}
+			continue
+		}
+
+		for _, col := range want.Columns {
+			current := have.column(col.Name)
+			if current == nil {
+				alters = append(alters, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s;", name, columnSQL(col)))
+				continue
+			}
+			if current.Type != col.Type {
+				alters = append(alters, fmt.Sprintf("-- Review: existing values must convert to %s\n-- ALTER TABLE %s ALTER COLUMN %s TYPE %s USING %s::%s;",
+					col.Type, name, col.Name, col.Type, col.Name, col.Type))
+			}
+			if stringOrEmpty(current.Default) != stringOrEmpty(col.Default) {
+				if col.Default == nil {
+					alters = append(alters, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP DEFAULT;", name, col.Name))
+				} else {
+					alters = append(alters, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET DEFAULT %s;", name, col.Name, *col.Default))
+				}
+			}
+			if current.Nullable != col.Nullable {
+				if col.Nullable {
+					alters = append(alters, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP NOT NULL;", name, col.Name))
+				} else {
+					alters = append(alters, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET NOT NULL;", name, col.Name))
+				}
+			}
+		}
+		for _, col := range have.Columns {
+			if want.column(col.Name) == nil {
+				drops = append(drops, fmt.Sprintf("-- ALTER TABLE %s DROP COLUMN %s;", name, col.Name))
+			}
+		}
+
+		if strings.Join(have.PrimaryKey, ",") != strings.Join(want.PrimaryKey, ",") {
+			constraints = append(constraints, fmt.Sprintf("-- Review: primary key changed from (%s) to (%s)",
+				strings.Join(have.PrimaryKey, ", "), strings.Join(want.PrimaryKey, ", ")))
+		}
+		for _, conName := range sortedKeys(have.Constraints) {
+			if def, ok := want.Constraints[conName]; !ok || def != have.Constraints[conName] {
+				constraints = append(constraints, fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT IF EXISTS %s;", name, conName))
+			}
+		}
+		for _, conName := range sortedKeys(want.Constraints) {
+			if def, ok := have.Constraints[conName]; !ok || def != want.Constraints[conName] {
+				constraints = append(constraints, fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s %s;", name, conName, want.Constraints[conName]))
+			}
+		}
+		for _, idxName := range sortedKeys(have.Indexes) {
+			if def, ok := want.Indexes[idxName]; !ok || def != have.Indexes[idxName] {
+				indexes = append(indexes, fmt.Sprintf("DROP INDEX IF EXISTS %s;", idxName))
+			}
+		}
+		for _, idxName := range sortedKeys(want.Indexes) {
+			if def, ok := have.Indexes[idxName]; !ok || def != want.Indexes[idxName] {
+				indexes = append(indexes, want.Indexes[idxName]+";")
+			}
+		}
+	}
+
+	for _, name := range sortedKeys(from.Tables) {
+		if _, ok := to.Tables[name]; !ok {
+			drops = append(drops, fmt.Sprintf("-- DROP TABLE IF EXISTS %s;", name))
+		}
+	}
+	if len(drops) > 0 {
+		drops = append([]string{"-- Review: these statements drop data, uncomment them once you're sure"}, drops...)
+	}
+
+	// Tables are created before the constraints that refer to them, and drops come last
+	statements := append(creates, alters...)
+	statements = append(statements, constraints...)
+	statements = append(statements, indexes...)
+	return append(statements, drops...)
+}
+
+func createTableSQL(name string, table *SchemaTable) string {
+	lines := make([]string, 0, len(table.Columns)+1)
+	for _, col := range table.Columns {
+		lines = append(lines, "    "+columnSQL(col))
+	}
+	if len(table.PrimaryKey) > 0 {
+		lines = append(lines, fmt.Sprintf("    PRIMARY KEY (%s)", strings.Join(table.PrimaryKey, ", ")))
+	}
+	return fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (\n%s\n);", name, strings.Join(lines, ",\n"))
+}
+
+func columnSQL(col SchemaColumn) string {
+	sql := col.Name + " " + strings.ToUpper(col.Type)
+	if !col.Nullable {
+		sql += " NOT NULL"
+	}
+	if col.Default != nil {
+		sql += " DEFAULT " + *col.Default
+	}
+	return sql
+}
+
+func stringOrEmpty(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,58 @@
+package database
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffSchemas(t *testing.T) {
+	now := "now()"
+	live := &Schema{Tables: map[string]*SchemaTable{
+		"users": {
+			Columns: []SchemaColumn{
+				{Name: "id", Type: "uuid"},
+				{Name: "nickname", Type: "text", Nullable: true},
+				{Name: "created_at", Type: "timestamp with time zone", Nullable: true, Default: &now},
+			},
+			PrimaryKey: []string{"id"},
+			Indexes:    map[string]string{"idx_users_nickname": "CREATE INDEX idx_users_nickname ON public.users USING btree (nickname)"},
+		},
+		"legacy": {Columns: []SchemaColumn{{Name: "id", Type: "integer"}}},
+	}}
+	desired := &Schema{Tables: map[string]*SchemaTable{
+		"users": {
+			Columns: []SchemaColumn{
+				{Name: "id", Type: "uuid"},
+				{Name: "email", Type: "text"},
+				{Name: "created_at", Type: "timestamp with time zone"},
+			},
+			PrimaryKey:  []string{"id"},
+			Constraints: map[string]string{"users_email_key": "UNIQUE (email)"},
+		},
+		"profiles": {
+			Columns:     []SchemaColumn{{Name: "user_id", Type: "uuid"}},
+			PrimaryKey:  []string{"user_id"},
+			Constraints: map[string]string{"profiles_user_id_fkey": "FOREIGN KEY (user_id) REFERENCES users(id)"},
+		},
+	}}
+
+	want := []string{
+		"CREATE TABLE IF NOT EXISTS profiles (\n    user_id UUID NOT NULL,\n    PRIMARY KEY (user_id)\n);",
+		"ALTER TABLE users ADD COLUMN email TEXT NOT NULL;",
+		"ALTER TABLE users ALTER COLUMN created_at DROP DEFAULT;",
+		"ALTER TABLE users ALTER COLUMN created_at SET NOT NULL;",
+		"ALTER TABLE profiles ADD CONSTRAINT profiles_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id);",
+		"ALTER TABLE users ADD CONSTRAINT users_email_key UNIQUE (email);",
+		"DROP INDEX IF EXISTS idx_users_nickname;",
+		"-- Review: these statements drop data, uncomment them once you're sure",
+		"-- ALTER TABLE users DROP COLUMN nickname;",
+		"-- DROP TABLE IF EXISTS legacy;",
+	}
+	if got := DiffSchemas(live, desired); !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected statements:\n%q\nwant:\n%q", got, want)
+	}
+
+	if got := DiffSchemas(desired, desired); len(got) != 0 {
+		t.Errorf("expected no statements for identical schemas, got %q", got)
+	}
+}
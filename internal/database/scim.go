@@ -0,0 +1,253 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrMemberExists is returned when provisioning a user who is already a member of the organization
+	ErrMemberExists = errors.New("user is already a member of the organization")
+
+	// ErrAccountExists is returned when provisioning the email of an account the organization
+	// hasn't invited
+	ErrAccountExists = errors.New("account exists outside the organization")
+)
+
+// SCIMMember is an organization member as exposed to the organization's identity provider
+type SCIMMember struct {
+	User_id     string    `db:"user_id"`
+	Email       string    `db:"email"`
+	First_name  string    `db:"first_name"`
+	Last_name   string    `db:"last_name"`
+	Active      bool      `db:"active"`
+	External_id string    `db:"external_id"`
+	Created_at  time.Time `db:"created_at"`
+	Updated_at  time.Time `db:"updated_at"`
+}
+
+// SCIMMemberFilter narrows ListSCIMMembers; empty fields are ignored
+type SCIMMemberFilter struct {
+	Email      string
+	ExternalID string
+}
+
+// SCIMMemberUpdate holds the member attributes an identity provider may change; nil fields are left as is
+type SCIMMemberUpdate struct {
+	FirstName  *string
+	LastName   *string
+	Active     *bool
+	ExternalID *string
+}
+
+// Owners are managed in the app rather than by the identity provider, so they are
+// never visible to SCIM clients
+const scimMemberSelect = `SELECT m.user_id,
+		COALESCE(u.email, '') AS email,
+		COALESCE(u.first_name, '') AS first_name,
+		COALESCE(u.last_name, '') AS last_name,
+		m.active, m.external_id, m.created_at,
+		GREATEST(m.updated_at, u.updated_at) AS updated_at
+	FROM organization_members m
+	JOIN users u ON u.id = m.user_id
+	WHERE m.organization_id = $1 AND m.role <> 'owner'`
+
+// SetOrganizationSCIMToken replaces the organization's SCIM token
+func (s *service) SetOrganizationSCIMToken(ctx context.Context, orgID, tokenHash string) error {
+	query := `INSERT INTO organization_scim_tokens (organization_id, token_hash) VALUES ($1, $2)
+		ON CONFLICT (organization_id) DO UPDATE SET token_hash = EXCLUDED.token_hash, last_used_at = NULL, created_at = NOW()`
+	_, err := s.db.ExecContext(ctx, query, orgID, tokenHash)
+	return err
+}
+
+// AuthenticateSCIMToken returns the organization a SCIM token belongs to and records its use.
+// Returns sql.ErrNoRows for unknown tokens.
+func (s *service) AuthenticateSCIMToken(ctx context.Context, tokenHash string) (string, error) {
+	var orgID string
+	query := `UPDATE organization_scim_tokens SET last_used_at = NOW() WHERE token_hash = $1 RETURNING organization_id`
+	err := s.db.GetContext(ctx, &orgID, query, tokenHash)
+	return orgID, err
+}
+
+// ListSCIMMembers returns a page of the organization's members and the total number matching the filter
+func (s *service) ListSCIMMembers(ctx context.Context, orgID string, filter SCIMMemberFilter, opts ListOptions) ([]SCIMMember, int, error) {
+	where := ` AND ($2 = '' OR lower(u.email) = lower($2)) AND ($3 = '' OR m.external_id = $3)`
+
+	var total int
+	countQuery := `SELECT COUNT(*) FROM organization_members m JOIN users u ON u.id = m.user_id
+		WHERE m.organization_id = $1 AND m.role <> 'owner'` + where
+	if err := s.db.GetContext(ctx, &total, countQuery, orgID, filter.Email, filter.ExternalID); err != nil {
+		return nil, 0, fmt.Errorf("failed to count members: %w", err)
+	}
+
+	// SCIM's count=0 asks for the total alone
+	if opts.Limit == 0 {
+		return []SCIMMember{}, total, nil
+	}
+
+	query, args, err := opts.apply(scimMemberSelect+where, []interface{}{orgID, filter.Email, filter.ExternalID}, scimMemberList)
+	if err != nil {
+		return nil, 0, err
+	}
+	var members []SCIMMember
+	if err := s.db.SelectContext(ctx, &members, query, args...); err != nil {
+		return nil, 0, fmt.Errorf("failed to list members: %w", err)
+	}
+	return members, total, nil
+}
+
+// GetSCIMMember returns one member of the organization.
+// Returns sql.ErrNoRows if the user is not a (non-owner) member.
+func (s *service) GetSCIMMember(ctx context.Context, orgID, userID string) (*SCIMMember, error) {
+	var member SCIMMember
+	if err := s.db.GetContext(ctx, &member, scimMemberSelect+` AND m.user_id = $2`, orgID, userID); err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+// ProvisionSCIMMember adds the user with the member's email to the organization. An unknown
+// email gets newUser, a password-less account whose name the organization's identity provider
+// then manages. An existing account is only added when the organization invited its email, so a
+// SCIM token can't claim arbitrary users; the invitation is accepted and the profile is left alone.
+// Returns ErrMemberExists if the user already belongs to the organization, and
+// ErrAccountExists if the email belongs to an account the organization hasn't invited.
+func (s *service) ProvisionSCIMMember(ctx context.Context, orgID string, member *SCIMMember, newUser *Users) (*SCIMMember, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	role, scimCreated := Organization_members_role_member, false
+	var userID string
+	err = tx.GetContext(ctx, &userID, `SELECT id FROM users WHERE lower(email) = lower($1)`, member.Email)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		newUser.Email = member.Email
+		query, args, err := tx.BindNamed(`INSERT INTO users (email, username, password_hash, first_name, last_name, created_at, updated_at)
+			VALUES (:email, :username, :password_hash, :first_name, :last_name, :created_at, :updated_at)
+			RETURNING id`, newUser)
+		if err != nil {
+			return nil, err
+		}
+		if err := tx.GetContext(ctx, &userID, query, args...); err != nil {
+			return nil, fmt.Errorf("failed to create user: %w", TranslateError(err))
+		}
+		scimCreated = true
+	case err != nil:
+		return nil, fmt.Errorf("failed to look up user: %w", err)
+	default:
+		var isMember bool
+		err = tx.GetContext(ctx, &isMember,
+			`SELECT EXISTS (SELECT 1 FROM organization_members WHERE organization_id = $1 AND user_id = $2)`,
+			orgID, userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up organization member: %w", err)
+		}
+		if isMember {
+			return nil, ErrMemberExists
+		}
+		err = tx.GetContext(ctx, &role,
+			`UPDATE organization_invites SET accepted_at = NOW(), accepted_by = $3
+			WHERE organization_id = $1 AND lower(email) = lower($2)
+				AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > NOW()
+			RETURNING role`,
+			orgID, member.Email, userID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrAccountExists
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to accept invitation: %w", err)
+		}
+	}
+
+	result, err := tx.ExecContext(ctx,
+		`INSERT INTO organization_members (organization_id, user_id, role, active, external_id, scim_created)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (organization_id, user_id) DO NOTHING`,
+		orgID, userID, role, member.Active, member.External_id, scimCreated)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add organization member: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return nil, ErrMemberExists
+	}
+
+	var created SCIMMember
+	if err := tx.GetContext(ctx, &created, scimMemberSelect+` AND m.user_id = $2`, orgID, userID); err != nil {
+		return nil, fmt.Errorf("failed to load organization member: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit organization member: %w", err)
+	}
+	return &created, nil
+}
+
+// UpdateSCIMMember applies an identity provider update to a member. Name changes are only
+// written to the profile of accounts the organization's provisioning created; other members
+// manage their own. Returns sql.ErrNoRows if the user is not a (non-owner) member.
+func (s *service) UpdateSCIMMember(ctx context.Context, orgID, userID string, update SCIMMemberUpdate) (*SCIMMember, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var scimCreated bool
+	err = tx.GetContext(ctx, &scimCreated,
+		`UPDATE organization_members
+		SET active = COALESCE($3, active), external_id = COALESCE($4, external_id), updated_at = NOW()
+		WHERE organization_id = $1 AND user_id = $2 AND role <> 'owner'
+		RETURNING scim_created`,
+		orgID, userID, update.Active, update.ExternalID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update organization member: %w", err)
+	}
+
+	if scimCreated && (update.FirstName != nil || update.LastName != nil) {
+		_, err = tx.ExecContext(ctx,
+			`UPDATE users SET first_name = COALESCE($2, first_name), last_name = COALESCE($3, last_name), updated_at = NOW(),
+				version = version + 1
+			WHERE id = $1`,
+			userID, update.FirstName, update.LastName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to update user: %w", err)
+		}
+	}
+
+	var updated SCIMMember
+	if err := tx.GetContext(ctx, &updated, scimMemberSelect+` AND m.user_id = $2`, orgID, userID); err != nil {
+		return nil, fmt.Errorf("failed to load organization member: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit organization member: %w", err)
+	}
+	return &updated, nil
+}
+
+// RemoveSCIMMember removes the user from the organization; the account itself is kept.
+// Returns sql.ErrNoRows if the user is not a (non-owner) member.
+func (s *service) RemoveSCIMMember(ctx context.Context, orgID, userID string) error {
+	result, err := s.db.ExecContext(ctx,
+		`DELETE FROM organization_members WHERE organization_id = $1 AND user_id = $2 AND role <> 'owner'`,
+		orgID, userID)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// SCIM clients page by index, so members keep a fixed order
+var scimMemberList = listSpec{defaultOrder: "m.created_at, m.user_id"}
@@ -0,0 +1,140 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// scimFixture is a migrated database with an organization to provision members into
+type scimFixture struct {
+	srv   Service
+	orgID string
+}
+
+func newSCIMFixture(t *testing.T) *scimFixture {
+	t.Helper()
+	f := &scimFixture{srv: newMigratedService(t)}
+	if err := f.srv.GetDB().GetContext(context.Background(), &f.orgID, `INSERT INTO organizations (name) VALUES ('Gym') RETURNING id`); err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+// createUser inserts an account that signed up by itself
+func (f *scimFixture) createUser(t *testing.T, email string) string {
+	t.Helper()
+	var id string
+	err := f.srv.GetDB().GetContext(context.Background(), &id,
+		`INSERT INTO users (email, username, password_hash, first_name) VALUES ($1, $2, 'hash', 'Own') RETURNING id`,
+		email, "u_"+uuid.NewString()[:8])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+// provision provisions email with a newly built account, as the SCIM handler does
+func (f *scimFixture) provision(email string) (*SCIMMember, error) {
+	first, last := "Ada", "Lovelace"
+	now := time.Now()
+	newUser := &Users{
+		Username:      "ada_" + uuid.NewString()[:6],
+		Password_hash: "$2a$10$unusable",
+		First_name:    &first,
+		Last_name:     &last,
+		Created_at:    now,
+		Updated_at:    now,
+	}
+	member := &SCIMMember{Email: email, First_name: first, Last_name: last, Active: true, External_id: "okta-1"}
+	return f.srv.ProvisionSCIMMember(context.Background(), f.orgID, member, newUser)
+}
+
+func testEmail() string {
+	return uuid.NewString()[:8] + "@example.com"
+}
+
+func TestProvisionSCIMMemberCreatesAccount(t *testing.T) {
+	f := newSCIMFixture(t)
+	ctx := context.Background()
+	email := testEmail()
+
+	member, err := f.provision(email)
+	if err != nil {
+		t.Fatalf("failed to provision: %v", err)
+	}
+	if member.Email != email || member.First_name != "Ada" || !member.Active || member.External_id != "okta-1" {
+		t.Errorf("unexpected member %+v", member)
+	}
+
+	var user Users
+	if err := f.srv.GetDB().GetContext(ctx, &user, `SELECT * FROM users WHERE id = $1`, member.User_id); err != nil {
+		t.Fatal(err)
+	}
+	if user.Password_hash != "$2a$10$unusable" || user.Username == email {
+		t.Errorf("expected the built username and password hash stored, got %q %q", user.Username, user.Password_hash)
+	}
+
+	// The identity provider created the account, so it manages the name
+	renamed := "Augusta"
+	updated, err := f.srv.UpdateSCIMMember(ctx, f.orgID, member.User_id, SCIMMemberUpdate{FirstName: &renamed})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.First_name != renamed {
+		t.Errorf("expected the name updated, got %q", updated.First_name)
+	}
+
+	if _, err := f.provision(email); !errors.Is(err, ErrMemberExists) {
+		t.Errorf("expected ErrMemberExists provisioning twice, got %v", err)
+	}
+}
+
+func TestProvisionSCIMMemberExistingAccount(t *testing.T) {
+	f := newSCIMFixture(t)
+	ctx := context.Background()
+	email := testEmail()
+	userID := f.createUser(t, email)
+
+	if _, err := f.provision(email); !errors.Is(err, ErrAccountExists) {
+		t.Fatalf("expected an uninvited account refused, got %v", err)
+	}
+	if _, err := f.srv.GetSCIMMember(ctx, f.orgID, userID); err == nil {
+		t.Fatal("expected the uninvited account not to be added")
+	}
+
+	_, err := f.srv.GetDB().ExecContext(ctx,
+		`INSERT INTO organization_invites (organization_id, email, token_hash, expires_at) VALUES ($1, $2, $3, NOW() + INTERVAL '1 day')`,
+		f.orgID, email, uuid.NewString())
+	if err != nil {
+		t.Fatal(err)
+	}
+	member, err := f.provision(email)
+	if err != nil {
+		t.Fatalf("expected the invited account added, got %v", err)
+	}
+	if member.User_id != userID || member.First_name != "Own" {
+		t.Errorf("expected the existing account with its own profile, got %+v", member)
+	}
+	var pending int
+	if err := f.srv.GetDB().GetContext(ctx, &pending,
+		`SELECT COUNT(*) FROM organization_invites WHERE organization_id = $1 AND accepted_at IS NULL`, f.orgID); err != nil {
+		t.Fatal(err)
+	}
+	if pending != 0 {
+		t.Error("expected the invitation accepted")
+	}
+
+	// The user signed up by themselves, so only the membership follows the identity provider
+	renamed, inactive := "Mallory", false
+	updated, err := f.srv.UpdateSCIMMember(ctx, f.orgID, userID, SCIMMemberUpdate{FirstName: &renamed, Active: &inactive})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.First_name != "Own" || updated.Active {
+		t.Errorf("expected the membership deactivated and the name kept, got %+v", updated)
+	}
+}
@@ -0,0 +1,72 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// SessionLoad is a workout session the user gave feedback on, with that feedback
+type SessionLoad struct {
+	Started_at       time.Time `db:"started_at"`
+	Duration_minutes int       `db:"duration_minutes"`
+	Session_feedback
+}
+
+// UpsertSessionFeedback saves the feedback on a session, replacing any given before.
+// created reports whether the session had no feedback yet.
+func (s *service) UpsertSessionFeedback(ctx context.Context, feedback *Session_feedback) (saved *Session_feedback, created bool, err error) {
+	var row struct {
+		Session_feedback
+		Inserted bool `db:"inserted"`
+	}
+	query := `INSERT INTO session_feedback (session_id, user_id, rpe, enjoyment, pain_areas, notes)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (session_id) DO UPDATE
+			SET rpe = EXCLUDED.rpe, enjoyment = EXCLUDED.enjoyment, pain_areas = EXCLUDED.pain_areas,
+				notes = EXCLUDED.notes, updated_at = NOW()
+		RETURNING *, (xmax = 0) AS inserted`
+	err = s.db.GetContext(ctx, &row, query, feedback.Session_id, feedback.User_id, feedback.Rpe, feedback.Enjoyment,
+		feedback.Pain_areas, feedback.Notes)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to save session feedback: %w", err)
+	}
+	return &row.Session_feedback, row.Inserted, nil
+}
+
+// GetSessionFeedback returns the feedback on a session, or sql.ErrNoRows
+func (s *service) GetSessionFeedback(ctx context.Context, sessionID string) (*Session_feedback, error) {
+	var feedback Session_feedback
+	if err := s.db.GetContext(ctx, &feedback, `SELECT * FROM session_feedback WHERE session_id = $1`, sessionID); err != nil {
+		return nil, err
+	}
+	return &feedback, nil
+}
+
+// DeleteSessionFeedback removes the feedback on a session, returning sql.ErrNoRows if it has none
+func (s *service) DeleteSessionFeedback(ctx context.Context, sessionID string) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM session_feedback WHERE session_id = $1`, sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to delete session feedback: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// ListSessionLoads returns the sessions the user started since the given time and gave
+// feedback on, oldest first
+func (s *service) ListSessionLoads(ctx context.Context, userID string, since time.Time) ([]SessionLoad, error) {
+	loads := []SessionLoad{}
+	query := `SELECT ws.started_at, COALESCE(ws.duration_minutes, 0) AS duration_minutes, sf.*
+		FROM session_feedback sf
+		JOIN workout_sessions ws ON ws.id = sf.session_id
+		WHERE sf.user_id = $1 AND ws.started_at >= $2
+		ORDER BY ws.started_at`
+	if err := s.db.SelectContext(ctx, &loads, query, userID, since); err != nil {
+		return nil, fmt.Errorf("failed to list session loads: %w", err)
+	}
+	return loads, nil
+}
@@ -0,0 +1,53 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// SessionWeather is the weather a session was done in
+type SessionWeather struct {
+	TemperatureC  float64
+	WindSpeedKmh  float64
+	WindDirection int
+}
+
+// ListSessionsNeedingWeather returns sessions with a start position whose weather wasn't
+// looked up yet, newest first, skipping sessions that ended after endedBefore
+func (s *service) ListSessionsNeedingWeather(ctx context.Context, endedBefore time.Time, limit int) ([]Workout_sessions, error) {
+	sessions := []Workout_sessions{}
+	query := `SELECT * FROM workout_sessions
+		WHERE start_latitude IS NOT NULL AND start_longitude IS NOT NULL AND weather_checked_at IS NULL
+			AND completed_at < $1
+		ORDER BY started_at DESC
+		LIMIT $2`
+	if err := s.db.SelectContext(ctx, &sessions, query, endedBefore, limit); err != nil {
+		return nil, fmt.Errorf("failed to list sessions needing weather: %w", err)
+	}
+	return sessions, nil
+}
+
+// SetSessionWeather stores the weather a session was done in and marks it looked up.
+// A nil weather marks it looked up without data, so it isn't asked for again. Returns
+// sql.ErrNoRows if the session no longer exists.
+func (s *service) SetSessionWeather(ctx context.Context, sessionID string, weather *SessionWeather) error {
+	var temperature, windSpeed *float64
+	var windDirection *int
+	if weather != nil {
+		temperature, windSpeed, windDirection = &weather.TemperatureC, &weather.WindSpeedKmh, &weather.WindDirection
+	}
+	result, err := s.db.ExecContext(ctx,
+		`UPDATE workout_sessions
+		SET temperature_c = $2, wind_speed_kmh = $3, wind_direction = $4, weather_checked_at = NOW(), updated_at = NOW()
+		WHERE id = $1`,
+		sessionID, temperature, windSpeed, windDirection)
+	if err != nil {
+		return fmt.Errorf("failed to set session weather: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// ImportedSession is a session read from a set log, with its sets. The sets' Session_id is
+// ignored, since they are stored under the session once it is.
+type ImportedSession struct {
+	Session Workout_sessions
+	Sets    []Workout_session_sets
+}
+
+// SetImportResult counts the sessions and sets stored and the duplicate sessions skipped
+// by ImportSetLog
+type SetImportResult struct {
+	SessionsImported int
+	SessionsSkipped  int
+	SetsImported     int
+}
+
+// FindDuplicateImports returns the external IDs of the sessions ImportSetLog would skip:
+// those imported before from the same source, and those starting within matchWindow of
+// one of the user's sessions
+func (s *service) FindDuplicateImports(ctx context.Context, userID, source string, sessions []Workout_sessions, matchWindow time.Duration) (map[string]bool, error) {
+	externalIDs := make([]string, len(sessions))
+	starts := make([]time.Time, len(sessions))
+	for i, ws := range sessions {
+		externalIDs[i], starts[i] = ws.External_id, ws.Started_at
+	}
+
+	var duplicates []string
+	err := s.db.SelectContext(ctx, &duplicates,
+		`SELECT i.external_id FROM unnest($2::text[], $3::timestamptz[]) AS i(external_id, started_at)
+		WHERE EXISTS (
+			SELECT 1 FROM workout_sessions ws
+			WHERE ws.user_id = $1 AND (
+				(ws.source = $4 AND ws.external_id = i.external_id)
+				OR ws.started_at BETWEEN i.started_at - $5::interval AND i.started_at + $5::interval
+			)
+		)`,
+		userID, externalIDs, starts, source, fmt.Sprintf("%d seconds", int(matchWindow.Seconds())))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find duplicate imports: %w", err)
+	}
+
+	found := make(map[string]bool, len(duplicates))
+	for _, id := range duplicates {
+		found[id] = true
+	}
+	return found, nil
+}
+
+// ImportSetLog stores sessions read from a set log, with their sets, in one transaction.
+// Sessions are skipped as duplicates like ImportHealthRecords skips them, and the sets of
+// a skipped session aren't stored.
+func (s *service) ImportSetLog(ctx context.Context, userID string, sessions []ImportedSession, matchWindow time.Duration) (*SetImportResult, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result := &SetImportResult{}
+	for i := range sessions {
+		imported := &sessions[i]
+		sessionID, err := insertImportedSession(ctx, tx, userID, &imported.Session, matchWindow)
+		if err != nil {
+			return nil, err
+		}
+		if sessionID == "" {
+			result.SessionsSkipped++
+			continue
+		}
+		result.SessionsImported++
+
+		for _, set := range imported.Sets {
+			_, err := tx.ExecContext(ctx,
+				`INSERT INTO workout_session_sets
+					(session_id, exercise_id, set_number, reps, weight_kg, duration_seconds, rpe, client_id, completed_at)
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+				sessionID, set.Exercise_id, set.Set_number, set.Reps, set.Weight_kg,
+				set.Duration_seconds, set.Rpe, set.Client_id, set.Completed_at)
+			if err != nil {
+				return nil, fmt.Errorf("failed to import set: %w", err)
+			}
+			result.SetsImported++
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit import: %w", err)
+	}
+	return result, nil
+}
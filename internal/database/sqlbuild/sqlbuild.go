@@ -0,0 +1,197 @@
+// Package sqlbuild builds the dynamic parts of list queries: filters, searches, ordering
+// and paging chosen by API callers. Callers only ever pick names from a Columns whitelist;
+// the SQL text is made from the whitelisted expressions and numbered placeholders, and
+// every value is bound as an argument, so nothing a caller sends reaches the SQL text.
+package sqlbuild
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrNotAllowed is matched by errors for names missing from a whitelist and other
+// options a query doesn't accept
+var ErrNotAllowed = errors.New("not allowed")
+
+// notAllowed describes a rejected option and matches ErrNotAllowed
+type notAllowed string
+
+func (e notAllowed) Error() string { return string(e) }
+
+func (e notAllowed) Is(target error) bool { return target == ErrNotAllowed }
+
+// Columns maps the names callers may use to the SQL expressions they stand for. Only the
+// expressions, written by us, end up in the SQL text.
+type Columns map[string]string
+
+// Conditions maps the names callers may use to conditions on one value that a column
+// comparison can't express, such as a subquery. Each condition has a single %s where the
+// value's placeholder goes. Like Columns, only the conditions end up in the SQL text.
+type Conditions map[string]string
+
+// Operators that Compare accepts
+const (
+	Eq  = "="
+	Ne  = "<>"
+	Lt  = "<"
+	Lte = "<="
+	Gt  = ">"
+	Gte = ">="
+)
+
+var operators = map[string]bool{Eq: true, Ne: true, Lt: true, Lte: true, Gt: true, Gte: true}
+
+// Query appends conditions, ordering and paging to a base query. The first error stops
+// the rest of the building and is returned by Build.
+type Query struct {
+	sql   strings.Builder
+	args  []interface{}
+	order string
+	page  string
+	err   error
+}
+
+// New starts a query from base, which must end in a WHERE clause ("WHERE TRUE" when it has
+// no condition of its own) and use $1..$n for args
+func New(base string, args ...interface{}) *Query {
+	q := &Query{args: append([]interface{}{}, args...)}
+	q.sql.WriteString(base)
+	return q
+}
+
+// bind adds value to the arguments and returns its placeholder
+func (q *Query) bind(value interface{}) string {
+	q.args = append(q.args, value)
+	return fmt.Sprintf("$%d", len(q.args))
+}
+
+// column returns the expression for name, recording an error if it isn't allowed
+func (q *Query) column(allowed Columns, name, action string) (string, bool) {
+	if q.err != nil {
+		return "", false
+	}
+	expr, ok := allowed[name]
+	if !ok {
+		q.err = notAllowed(fmt.Sprintf("cannot %s %q", action, name))
+	}
+	return expr, ok
+}
+
+// Filter adds a condition that the named column equals value
+func (q *Query) Filter(allowed Columns, name string, value interface{}) *Query {
+	return q.Compare(allowed, name, Eq, value)
+}
+
+// Compare adds a condition comparing the named column to value with op, one of the
+// operator constants
+func (q *Query) Compare(allowed Columns, name, op string, value interface{}) *Query {
+	expr, ok := q.column(allowed, name, "filter on")
+	if !ok {
+		return q
+	}
+	if !operators[op] {
+		q.err = notAllowed(fmt.Sprintf("unknown operator %q", op))
+		return q
+	}
+	fmt.Fprintf(&q.sql, " AND %s %s %s", expr, op, q.bind(value))
+	return q
+}
+
+// Where adds the named condition, with value bound to its placeholder
+func (q *Query) Where(allowed Conditions, name string, value interface{}) *Query {
+	condition, ok := q.column(Columns(allowed), name, "filter on")
+	if !ok {
+		return q
+	}
+	fmt.Fprintf(&q.sql, " AND "+condition, q.bind(value))
+	return q
+}
+
+// Search adds a condition that at least one of the named columns contains term, ignoring
+// case. Wildcards in term are matched literally; an empty term adds nothing.
+func (q *Query) Search(allowed Columns, names []string, term string) *Query {
+	if term == "" || q.err != nil {
+		return q
+	}
+	if len(names) == 0 {
+		q.err = notAllowed("search needs at least one column")
+		return q
+	}
+	exprs := make([]string, 0, len(names))
+	for _, name := range names {
+		expr, ok := q.column(allowed, name, "search")
+		if !ok {
+			return q
+		}
+		exprs = append(exprs, expr)
+	}
+	placeholder := q.bind("%" + escapeLike(term) + "%")
+	q.sql.WriteString(" AND (")
+	for i, expr := range exprs {
+		if i > 0 {
+			q.sql.WriteString(" OR ")
+		}
+		fmt.Fprintf(&q.sql, "%s ILIKE %s", expr, placeholder)
+	}
+	q.sql.WriteString(")")
+	return q
+}
+
+// escapeLike makes LIKE wildcards in s match themselves, using the default escape character
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// OrderBy orders by the named column in direction, "asc" (the default when empty) or
+// "desc", then by tiebreak if set so pages don't overlap on equal values. An empty name
+// orders by fallback instead, which is trusted SQL and may be empty.
+func (q *Query) OrderBy(allowed Columns, name, direction, tiebreak, fallback string) *Query {
+	if q.err != nil {
+		return q
+	}
+	if name == "" {
+		q.order = fallback
+		return q
+	}
+	expr, ok := q.column(allowed, name, "sort by")
+	if !ok {
+		return q
+	}
+	switch strings.ToLower(direction) {
+	case "", "asc":
+		expr += " ASC"
+	case "desc":
+		expr += " DESC"
+	default:
+		q.err = notAllowed("order must be asc or desc")
+		return q
+	}
+	if tiebreak != "" {
+		expr += ", " + tiebreak
+	}
+	q.order = expr
+	return q
+}
+
+// Page limits the results to limit rows after skipping offset; a negative offset is 0
+func (q *Query) Page(limit, offset int) *Query {
+	if q.err != nil {
+		return q
+	}
+	limitArg := q.bind(limit)
+	q.page = fmt.Sprintf(" LIMIT %s OFFSET %s", limitArg, q.bind(max(offset, 0)))
+	return q
+}
+
+// Build returns the SQL and its arguments, or the first error from building it
+func (q *Query) Build() (string, []interface{}, error) {
+	if q.err != nil {
+		return "", nil, q.err
+	}
+	query := q.sql.String()
+	if q.order != "" {
+		query += " ORDER BY " + q.order
+	}
+	return query + q.page, q.args, nil
+}
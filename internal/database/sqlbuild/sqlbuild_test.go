@@ -0,0 +1,115 @@
+package sqlbuild
+
+import (
+	"errors"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+var testColumns = Columns{"name": "w.name", "started_at": "ws.started_at", "source": "ws.source"}
+
+var testConditions = Conditions{"exercise": "EXISTS (SELECT 1 FROM exercises e WHERE e.id = ws.exercise_id AND e.name = %s)"}
+
+func TestQuery(t *testing.T) {
+	query, args, err := New(`SELECT * FROM workout_sessions ws WHERE ws.user_id = $1`, "u1").
+		Filter(testColumns, "source", "strava").
+		Compare(testColumns, "started_at", Gte, "2025-01-01").
+		Where(testConditions, "exercise", "Running").
+		Search(testColumns, []string{"name", "source"}, "50%_off").
+		OrderBy(testColumns, "started_at", "desc", "ws.id", "ws.created_at DESC").
+		Page(20, 40).
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := `SELECT * FROM workout_sessions ws WHERE ws.user_id = $1 AND ws.source = $2 AND ws.started_at >= $3` +
+		` AND EXISTS (SELECT 1 FROM exercises e WHERE e.id = ws.exercise_id AND e.name = $4)` +
+		` AND (w.name ILIKE $5 OR ws.source ILIKE $5) ORDER BY ws.started_at DESC, ws.id LIMIT $6 OFFSET $7`
+	if query != expected {
+		t.Errorf("expected query\n%s\ngot\n%s", expected, query)
+	}
+	if want := []interface{}{"u1", "strava", "2025-01-01", "Running", `%50\%\_off%`, 20, 40}; !reflect.DeepEqual(args, want) {
+		t.Errorf("expected args %v, got %v", want, args)
+	}
+}
+
+func TestQueryDefaults(t *testing.T) {
+	query, args, err := New(`SELECT * FROM users WHERE TRUE`).
+		Search(testColumns, []string{"name"}, "").
+		OrderBy(testColumns, "", "", "id", "created_at DESC").
+		Page(10, -5).
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if query != `SELECT * FROM users WHERE TRUE ORDER BY created_at DESC LIMIT $1 OFFSET $2` {
+		t.Errorf("unexpected query %s", query)
+	}
+	if !reflect.DeepEqual(args, []interface{}{10, 0}) {
+		t.Errorf("unexpected args %v", args)
+	}
+}
+
+func TestQueryRejectsUnknownNames(t *testing.T) {
+	invalid := map[string]*Query{
+		"filter":    New("").Filter(testColumns, "password_hash", "x"),
+		"condition": New("").Where(testConditions, "1 = 1 OR %s", "x"),
+		"operator":  New("").Compare(testColumns, "name", "= 1 OR 1 =", "x"),
+		"search":    New("").Search(testColumns, []string{"name", "email"}, "x"),
+		"no column": New("").Search(testColumns, nil, "x"),
+		"sort":      New("").OrderBy(testColumns, "name; DROP TABLE users", "", "", ""),
+		"direction": New("").OrderBy(testColumns, "name", "sideways", "", ""),
+		"first":     New("").Filter(testColumns, "nope", "x").Filter(testColumns, "name", "x"),
+	}
+	for name, q := range invalid {
+		if _, _, err := q.Build(); !errors.Is(err, ErrNotAllowed) {
+			t.Errorf("%s: expected ErrNotAllowed, got %v", name, err)
+		}
+	}
+}
+
+// allowedSQL matches everything Query may add to the base: whitelisted expressions,
+// operators, placeholders and keywords, with nothing taken from input
+var allowedSQL = regexp.MustCompile(`^(?:` +
+	` AND (?:w\.name|ws\.started_at|ws\.source) (?:=|<>|<|<=|>|>=) \$\d+` +
+	`| AND \((?:w\.name|ws\.started_at|ws\.source) ILIKE \$\d+(?: OR (?:w\.name|ws\.started_at|ws\.source) ILIKE \$\d+)*\)` +
+	`)*(?: ORDER BY (?:ws\.created_at DESC|(?:w\.name|ws\.started_at|ws\.source) (?:ASC|DESC), ws\.id))? LIMIT \$\d+ OFFSET \$\d+$`)
+
+var placeholder = regexp.MustCompile(`\$(\d+)`)
+
+func FuzzQuery(f *testing.F) {
+	f.Add("source", "=", "strava", "name", "asc", "leg day")
+	f.Add("name'; DROP TABLE users; --", ">=", "x' OR '1'='1", "started_at", "DESC", "%_\\")
+	f.Add("started_at", "<> 1 OR 1 =", "$1", "source) --", "desc; DELETE", "ILIKE")
+
+	f.Fuzz(func(t *testing.T, filter, op, value, sort, direction, term string) {
+		const base = `SELECT * FROM workout_sessions ws WHERE ws.user_id = $1`
+		query, args, err := New(base, "u1").
+			Compare(testColumns, filter, op, value).
+			Search(testColumns, []string{"name", "source"}, term).
+			OrderBy(testColumns, sort, direction, "ws.id", "ws.created_at DESC").
+			Page(10, 0).
+			Build()
+		if err != nil {
+			if !errors.Is(err, ErrNotAllowed) {
+				t.Fatalf("unexpected error type: %v", err)
+			}
+			return
+		}
+		if !strings.HasPrefix(query, base) || !allowedSQL.MatchString(query[len(base):]) {
+			t.Fatalf("query contains SQL that isn't whitelisted: %s", query)
+		}
+		if args[1] != value {
+			t.Fatalf("value %q was not bound, args %v", value, args)
+		}
+		for _, m := range placeholder.FindAllStringSubmatch(query, -1) {
+			if n, _ := strconv.Atoi(m[1]); n < 1 || n > len(args) {
+				t.Fatalf("placeholder $%d has no argument: %s %v", n, query, args)
+			}
+		}
+	})
+}
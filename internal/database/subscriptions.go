@@ -0,0 +1,72 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// ErrSubscriptionOwned is returned when a store purchase is already linked to a different user
+var ErrSubscriptionOwned = errors.New("subscription belongs to another user")
+
+// UpsertSubscription stores the latest state of a validated store purchase for the user.
+// Purchases are keyed by platform and original transaction ID, so re-validating a receipt
+// refreshes the existing row; a receipt already claimed by another user is rejected.
+func (s *service) UpsertSubscription(ctx context.Context, sub *Subscriptions) (*Subscriptions, error) {
+	query := `INSERT INTO subscriptions (user_id, platform, product_id, original_transaction_id, status, expires_at, auto_renew)
+		VALUES (:user_id, :platform, :product_id, :original_transaction_id, :status, :expires_at, :auto_renew)
+		ON CONFLICT (platform, original_transaction_id) DO UPDATE SET
+			product_id = EXCLUDED.product_id,
+			status = EXCLUDED.status,
+			expires_at = EXCLUDED.expires_at,
+			auto_renew = EXCLUDED.auto_renew,
+			updated_at = NOW()
+		WHERE subscriptions.user_id = EXCLUDED.user_id
+		RETURNING *`
+
+	query, args, err := s.db.BindNamed(query, sub)
+	if err != nil {
+		return nil, err
+	}
+
+	var saved Subscriptions
+	if err := s.db.QueryRowxContext(ctx, query, args...).StructScan(&saved); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrSubscriptionOwned
+		}
+		return nil, err
+	}
+	return &saved, nil
+}
+
+// UpdateSubscriptionState applies a store server notification to an existing subscription.
+// Returns sql.ErrNoRows if the purchase was never validated by a user.
+func (s *service) UpdateSubscriptionState(ctx context.Context, sub *Subscriptions) (*Subscriptions, error) {
+	query := `UPDATE subscriptions SET
+			product_id = COALESCE(NULLIF(:product_id, ''), product_id),
+			status = :status,
+			expires_at = :expires_at,
+			auto_renew = :auto_renew,
+			updated_at = NOW()
+		WHERE platform = :platform AND original_transaction_id = :original_transaction_id
+		RETURNING *`
+
+	query, args, err := s.db.BindNamed(query, sub)
+	if err != nil {
+		return nil, err
+	}
+
+	var saved Subscriptions
+	if err := s.db.QueryRowxContext(ctx, query, args...).StructScan(&saved); err != nil {
+		return nil, err
+	}
+	return &saved, nil
+}
+
+// ListSubscriptionsByUser returns the user's store subscriptions, most recently expiring first
+func (s *service) ListSubscriptionsByUser(ctx context.Context, userID string) ([]Subscriptions, error) {
+	var subs []Subscriptions
+	query := `SELECT * FROM subscriptions WHERE user_id = $1 ORDER BY expires_at DESC`
+	err := s.db.SelectContext(ctx, &subs, query, userID)
+	return subs, err
+}
@@ -0,0 +1,87 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// SuggestionCandidate is a workout of the user's that could be suggested for today, with
+// when they last did it
+type SuggestionCandidate struct {
+	Workouts
+	In_active_program bool       `db:"in_active_program"`
+	Last_done_at      *time.Time `db:"last_done_at"`
+}
+
+// WorkoutMuscle is a primary muscle group of one of a workout's exercises
+type WorkoutMuscle struct {
+	Workout_id string `db:"workout_id"`
+	Slug       string `db:"slug"`
+	Name       string `db:"name"`
+}
+
+// MuscleSets is how many sets worked a muscle group, as a primary muscle
+type MuscleSets struct {
+	Slug string `db:"slug"`
+	Sets int    `db:"sets"`
+}
+
+// ListSuggestionCandidates returns up to limit of the user's workouts that aren't templates,
+// those of their active programs first and then by when they were last done, oldest first
+// and never done before anything
+func (s *service) ListSuggestionCandidates(ctx context.Context, userID string, limit int) ([]SuggestionCandidate, error) {
+	candidates := []SuggestionCandidate{}
+	query := `SELECT w.*, COALESCE(p.is_active, FALSE) AS in_active_program, done.last_done_at
+		FROM workouts w
+		LEFT JOIN programs p ON p.id = w.program_id AND p.user_id = w.user_id
+		LEFT JOIN LATERAL (
+			SELECT MAX(ws.started_at) AS last_done_at
+			FROM workout_sessions ws
+			WHERE ws.user_id = w.user_id AND ws.workout_id = w.id
+		) done ON TRUE
+		WHERE w.user_id = $1 AND NOT w.is_template
+		ORDER BY in_active_program DESC, done.last_done_at ASC NULLS FIRST, w.created_at, w.id
+		LIMIT $2`
+	if err := s.db.SelectContext(ctx, &candidates, query, userID, limit); err != nil {
+		return nil, fmt.Errorf("failed to list suggestion candidates: %w", err)
+	}
+	return candidates, nil
+}
+
+// ListRecentMuscleSets counts the sets the user logged since the given time by the primary
+// muscle groups of their exercises, most worked first
+func (s *service) ListRecentMuscleSets(ctx context.Context, userID string, since time.Time) ([]MuscleSets, error) {
+	muscles := []MuscleSets{}
+	query := `SELECT m.slug, COUNT(*) AS sets
+		FROM workout_session_sets wss
+		JOIN workout_sessions ws ON ws.id = wss.session_id
+		JOIN exercise_muscle_groups emg ON emg.exercise_id = wss.exercise_id AND emg.role = 'primary'
+		JOIN muscle_groups m ON m.id = emg.muscle_group_id
+		WHERE ws.user_id = $1 AND wss.completed_at >= $2 AND left(wss.client_id, 5) <> 'copy:'
+		GROUP BY m.slug
+		ORDER BY sets DESC, m.slug`
+	if err := s.db.SelectContext(ctx, &muscles, query, userID, since); err != nil {
+		return nil, fmt.Errorf("failed to list recent muscle sets: %w", err)
+	}
+	return muscles, nil
+}
+
+// ListWorkoutMuscles returns the primary muscle groups the workouts' exercises work, once
+// per workout
+func (s *service) ListWorkoutMuscles(ctx context.Context, workoutIDs []string) ([]WorkoutMuscle, error) {
+	muscles := []WorkoutMuscle{}
+	if len(workoutIDs) == 0 {
+		return muscles, nil
+	}
+	err := s.db.SelectContext(ctx, &muscles, `SELECT DISTINCT we.workout_id, m.slug, m.name
+		FROM workout_exercises we
+		JOIN exercise_muscle_groups emg ON emg.exercise_id = we.exercise_id AND emg.role = 'primary'
+		JOIN muscle_groups m ON m.id = emg.muscle_group_id
+		WHERE we.workout_id = ANY($1)
+		ORDER BY we.workout_id, m.name`, workoutIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list workout muscles: %w", err)
+	}
+	return muscles, nil
+}
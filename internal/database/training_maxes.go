@@ -0,0 +1,136 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Sources of a training max change, as recorded in its history
+const (
+	TrainingMaxSourceManual     = "manual"
+	TrainingMaxSourceSuggestion = "suggestion"
+)
+
+// AMRAPSet is an AMRAP set logged for the exercise a training max is linked to, with the
+// feedback given on its session
+type AMRAPSet struct {
+	TrainingMaxName string `db:"training_max_name"`
+	SessionRPE      *int16 `db:"session_rpe"`
+	PainFlagged     bool   `db:"pain_flagged"`
+	Workout_session_sets
+}
+
+// ListTrainingMaxes returns the user's training maxes by name
+func (s *service) ListTrainingMaxes(ctx context.Context, userID string) ([]Training_maxes, error) {
+	maxes := []Training_maxes{}
+	query := `SELECT * FROM training_maxes WHERE user_id = $1 ORDER BY name`
+	if err := s.db.SelectContext(ctx, &maxes, query, userID); err != nil {
+		return nil, fmt.Errorf("failed to list training maxes: %w", err)
+	}
+	return maxes, nil
+}
+
+// GetTrainingMax returns the user's training max with the given name, or sql.ErrNoRows
+func (s *service) GetTrainingMax(ctx context.Context, userID, name string) (*Training_maxes, error) {
+	var tm Training_maxes
+	query := `SELECT * FROM training_maxes WHERE user_id = $1 AND name = $2`
+	if err := s.db.GetContext(ctx, &tm, query, userID, name); err != nil {
+		return nil, err
+	}
+	return &tm, nil
+}
+
+// UpsertTrainingMax sets the user's training max with the given name, creating it if needed.
+// A new weight is added to the training max's history with source, one of the
+// TrainingMaxSource values; saving the same weight again only updates the exercise link.
+func (s *service) UpsertTrainingMax(ctx context.Context, tm *Training_maxes, source string) (*Training_maxes, error) {
+	var saved Training_maxes
+	query := `WITH previous AS (
+			SELECT weight_kg FROM training_maxes WHERE user_id = $1 AND name = $2
+		), saved AS (
+			INSERT INTO training_maxes (user_id, name, exercise_id, weight_kg)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (user_id, name) DO UPDATE
+				SET exercise_id = EXCLUDED.exercise_id, weight_kg = EXCLUDED.weight_kg, updated_at = NOW()
+			RETURNING *
+		), history AS (
+			INSERT INTO training_max_history (training_max_id, weight_kg, source)
+			SELECT id, weight_kg, $5 FROM saved
+			WHERE NOT EXISTS (SELECT 1 FROM previous WHERE previous.weight_kg = saved.weight_kg)
+		)
+		SELECT * FROM saved`
+	if err := s.db.GetContext(ctx, &saved, query, tm.User_id, tm.Name, tm.Exercise_id, tm.Weight_kg, source); err != nil {
+		return nil, fmt.Errorf("failed to save training max: %w", err)
+	}
+	return &saved, nil
+}
+
+// DeleteTrainingMax removes a training max and its history, returning sql.ErrNoRows if the
+// user has none with that name
+func (s *service) DeleteTrainingMax(ctx context.Context, userID, name string) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM training_maxes WHERE user_id = $1 AND name = $2`, userID, name)
+	if err != nil {
+		return fmt.Errorf("failed to delete training max: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// ListTrainingMaxHistory returns the weights a training max has had, newest first
+func (s *service) ListTrainingMaxHistory(ctx context.Context, trainingMaxID string, opts ListOptions) ([]Training_max_history, error) {
+	query, args, err := opts.apply(`SELECT * FROM training_max_history WHERE training_max_id = $1`, []interface{}{trainingMaxID}, trainingMaxHistoryList)
+	if err != nil {
+		return nil, err
+	}
+	history := []Training_max_history{}
+	if err := s.db.SelectContext(ctx, &history, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to list training max history: %w", err)
+	}
+	return history, nil
+}
+
+var trainingMaxHistoryList = listSpec{
+	sorts:        map[string]string{"recorded_at": "recorded_at"},
+	filters:      map[string]string{"source": "source"},
+	defaultOrder: "recorded_at DESC",
+	tiebreak:     "id",
+}
+
+// ListAMRAPSets returns the AMRAP sets the user logged for the exercises their training maxes
+// are linked to since each training max was last saved, oldest first. Sets logged before a
+// change were done against the old training max, so they don't say anything about the new one.
+func (s *service) ListAMRAPSets(ctx context.Context, userID string) ([]AMRAPSet, error) {
+	sets := []AMRAPSet{}
+	query := `SELECT tm.name AS training_max_name, sf.rpe AS session_rpe,
+			COALESCE(jsonb_array_length(sf.pain_areas) > 0, FALSE) AS pain_flagged, wss.*
+		FROM training_maxes tm
+		JOIN workout_sessions ws ON ws.user_id = tm.user_id
+		JOIN workout_session_sets wss ON wss.session_id = ws.id AND wss.exercise_id = tm.exercise_id
+		LEFT JOIN session_feedback sf ON sf.session_id = ws.id
+		WHERE tm.user_id = $1 AND wss.amrap AND wss.reps > 0 AND wss.completed_at > tm.updated_at
+		ORDER BY wss.completed_at, wss.id`
+	if err := s.db.SelectContext(ctx, &sets, query, userID); err != nil {
+		return nil, fmt.Errorf("failed to list AMRAP sets: %w", err)
+	}
+	return sets, nil
+}
+
+// ListExerciseSets returns the sets the user logged for an exercise since the given time
+// with both weight and reps, oldest first. Sets copied from an earlier session are left out,
+// since they repeat sets already counted.
+func (s *service) ListExerciseSets(ctx context.Context, userID, exerciseID string, since time.Time) ([]Workout_session_sets, error) {
+	sets := []Workout_session_sets{}
+	query := `SELECT wss.* FROM workout_session_sets wss
+		JOIN workout_sessions ws ON ws.id = wss.session_id
+		WHERE ws.user_id = $1 AND wss.exercise_id = $2 AND wss.completed_at >= $3
+			AND wss.weight_kg > 0 AND wss.reps > 0 AND left(wss.client_id, 5) <> 'copy:'
+		ORDER BY wss.completed_at, wss.id`
+	if err := s.db.SelectContext(ctx, &sets, query, userID, exerciseID, since); err != nil {
+		return nil, fmt.Errorf("failed to list exercise sets: %w", err)
+	}
+	return sets, nil
+}
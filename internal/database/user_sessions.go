@@ -0,0 +1,92 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// CreateUserSession records a token issued to a user's device. The user's sessions that
+// expired more than a month ago are deleted at the same time, so the table doesn't grow
+// with every sign-in.
+func (s *service) CreateUserSession(ctx context.Context, session *User_sessions) (*User_sessions, error) {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM user_sessions
+		WHERE user_id = $1 AND expires_at < NOW() - INTERVAL '30 days'`, session.User_id); err != nil {
+		return nil, fmt.Errorf("failed to prune user sessions: %w", err)
+	}
+
+	var created User_sessions
+	query := `INSERT INTO user_sessions (user_id, token_id, user_agent, ip_address, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING *`
+	err := s.db.GetContext(ctx, &created, query,
+		session.User_id, session.Token_id, session.User_agent, session.Ip_address, session.Expires_at)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create user session: %w", err)
+	}
+	return &created, nil
+}
+
+// ListUserSessions returns the user's sessions that are neither revoked nor expired, most
+// recently used first
+func (s *service) ListUserSessions(ctx context.Context, userID string) ([]User_sessions, error) {
+	sessions := []User_sessions{}
+	query := `SELECT * FROM user_sessions
+		WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > NOW()
+		ORDER BY last_seen_at DESC`
+	if err := s.db.SelectContext(ctx, &sessions, query, userID); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// RevokeUserSession marks one of the user's sessions revoked and returns it, so the caller
+// can revoke its token. Returns sql.ErrNoRows if the session isn't theirs, has already been
+// revoked or has expired.
+func (s *service) RevokeUserSession(ctx context.Context, id, userID string) (*User_sessions, error) {
+	var revoked User_sessions
+	query := `UPDATE user_sessions SET revoked_at = NOW()
+		WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL AND expires_at > NOW()
+		RETURNING *`
+	if err := s.db.GetContext(ctx, &revoked, query, id, userID); err != nil {
+		return nil, err
+	}
+	return &revoked, nil
+}
+
+// RevokeAllUserSessions marks every live session of the user revoked and returns them, so
+// the caller can revoke their tokens
+func (s *service) RevokeAllUserSessions(ctx context.Context, userID string) ([]User_sessions, error) {
+	revoked := []User_sessions{}
+	query := `UPDATE user_sessions SET revoked_at = NOW()
+		WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > NOW()
+		RETURNING *`
+	if err := s.db.SelectContext(ctx, &revoked, query, userID); err != nil {
+		return nil, err
+	}
+	return revoked, nil
+}
+
+// RevokeUserSessionByToken marks the session of a logged-out token revoked. Tokens issued
+// without a session are ignored.
+func (s *service) RevokeUserSessionByToken(ctx context.Context, tokenID string) error {
+	query := `UPDATE user_sessions SET revoked_at = NOW() WHERE token_id = $1 AND revoked_at IS NULL`
+	if _, err := s.db.ExecContext(ctx, query, tokenID); err != nil {
+		return fmt.Errorf("failed to revoke user session: %w", err)
+	}
+	return nil
+}
+
+// TouchUserSession records that a token was just used, and from where. Returns
+// sql.ErrNoRows if the token has no session.
+func (s *service) TouchUserSession(ctx context.Context, tokenID, ipAddress string) error {
+	result, err := s.db.ExecContext(ctx, `UPDATE user_sessions SET last_seen_at = NOW(), ip_address = $2
+		WHERE token_id = $1`, tokenID, ipAddress)
+	if err != nil {
+		return fmt.Errorf("failed to touch user session: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
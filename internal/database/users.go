@@ -0,0 +1,118 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"fitness-hack/internal/messages"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// UserRepository stores user accounts
+type UserRepository interface {
+	CreateUser(ctx context.Context, user *Users) (*Users, error)
+	GetUserByID(ctx context.Context, id string) (*Users, error)
+	GetUserByEmail(ctx context.Context, email string) (*Users, error)
+	ListUsers(ctx context.Context, opts ListOptions) ([]Users, error)
+	UpdateUser(ctx context.Context, user *Users) (*Users, error)
+	DeleteUser(ctx context.Context, id string) error
+}
+
+type userRepository struct {
+	db  *sqlx.DB
+	log *slog.Logger
+}
+
+// NewUserRepository returns a UserRepository that uses db and logs to slog's default logger
+func NewUserRepository(db *sqlx.DB) UserRepository {
+	return &userRepository{db: db, log: slog.Default()}
+}
+
+func (r *userRepository) CreateUser(ctx context.Context, user *Users) (*Users, error) {
+	query := `INSERT INTO users (email, username, password_hash, first_name, last_name, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING *`
+
+	// Optional names are stored as empty strings when unset
+	var firstName, lastName string
+	if user.First_name != nil {
+		firstName = *user.First_name
+	}
+	if user.Last_name != nil {
+		lastName = *user.Last_name
+	}
+
+	r.log.DebugContext(ctx, messages.Text(messages.DBCreatingUser),
+		"message_id", string(messages.DBCreatingUser), "email", user.Email, "username", user.Username)
+
+	row := r.db.QueryRowContext(ctx, query, user.Email, user.Username, user.Password_hash, firstName, lastName, user.Created_at, user.Updated_at)
+
+	var created Users
+	err := row.Scan(&created.Id, &created.Email, &created.Username, &created.Password_hash, &created.First_name, &created.Last_name, &created.Created_at, &created.Updated_at)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan user result: %w", TranslateError(err))
+	}
+
+	return &created, nil
+}
+
+func (r *userRepository) GetUserByID(ctx context.Context, id string) (*Users, error) {
+	var user Users
+	query := `SELECT * FROM users WHERE id = $1`
+	err := r.db.GetContext(ctx, &user, query, id)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *userRepository) GetUserByEmail(ctx context.Context, email string) (*Users, error) {
+	var user Users
+	query := `SELECT * FROM users WHERE email = $1`
+	err := r.db.GetContext(ctx, &user, query, email)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// ListUsers lists users, newest first by default
+func (r *userRepository) ListUsers(ctx context.Context, opts ListOptions) ([]Users, error) {
+	query, args, err := opts.apply(`SELECT * FROM users WHERE TRUE`, nil, userList)
+	if err != nil {
+		return nil, err
+	}
+	var rows []Users
+	err = r.db.SelectContext(ctx, &rows, query, args...)
+	return rows, err
+}
+
+func (r *userRepository) UpdateUser(ctx context.Context, user *Users) (*Users, error) {
+	query := `UPDATE users SET email=:email, username=:username, password_hash=:password_hash, first_name=:first_name, last_name=:last_name, timezone=:timezone, password_reset_required=:password_reset_required, updated_at=:updated_at, version=version+1 WHERE id=:id AND version=:version RETURNING *`
+	row, err := r.db.NamedQueryContext(ctx, query, user)
+	if err != nil {
+		return nil, TranslateError(err)
+	}
+	defer row.Close()
+	if row.Next() {
+		var updated Users
+		if err := row.StructScan(&updated); err != nil {
+			return nil, err
+		}
+		return &updated, nil
+	}
+	if err := row.Err(); err != nil {
+		return nil, TranslateError(err)
+	}
+	return nil, ErrVersionConflict
+}
+
+func (r *userRepository) DeleteUser(ctx context.Context, id string) error {
+	query := `DELETE FROM users WHERE id = $1`
+	_, err := r.db.ExecContext(ctx, query, id)
+	return TranslateError(err)
+}
+
+// --- WORKOUTS CRUD ---
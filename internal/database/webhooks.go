@@ -0,0 +1,192 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// WebhookDeliveryTarget is a delivery claimed for sending, with the endpoint and key to sign it with
+type WebhookDeliveryTarget struct {
+	Id         string `db:"id"`
+	Webhook_id string `db:"webhook_id"`
+	Event_id   string `db:"event_id"`
+	Event      string `db:"event"`
+	Payload    []byte `db:"payload"`
+	Attempts   int    `db:"attempts"`
+	Url        string `db:"url"`
+	Secret     string `db:"secret"`
+}
+
+// CreateWebhook registers a webhook for the user
+func (s *service) CreateWebhook(ctx context.Context, webhook *Webhooks) (*Webhooks, error) {
+	var created Webhooks
+	query := `INSERT INTO webhooks (user_id, url, description, events, secret)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING *`
+	err := s.db.GetContext(ctx, &created, query,
+		webhook.User_id, webhook.Url, webhook.Description, webhook.Events, webhook.Secret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create webhook: %w", err)
+	}
+	return &created, nil
+}
+
+// GetWebhook returns one of the user's webhooks, or sql.ErrNoRows
+func (s *service) GetWebhook(ctx context.Context, id, userID string) (*Webhooks, error) {
+	var webhook Webhooks
+	query := `SELECT * FROM webhooks WHERE id = $1 AND user_id = $2`
+	if err := s.db.GetContext(ctx, &webhook, query, id, userID); err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+// ListWebhooks returns the user's webhooks, oldest first
+func (s *service) ListWebhooks(ctx context.Context, userID string) ([]Webhooks, error) {
+	webhooks := []Webhooks{}
+	query := `SELECT * FROM webhooks WHERE user_id = $1 ORDER BY created_at`
+	if err := s.db.SelectContext(ctx, &webhooks, query, userID); err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+// UpdateWebhook saves the URL, description, events and active flag of a webhook,
+// returning sql.ErrNoRows if it doesn't belong to the user and ErrVersionConflict if it's
+// no longer at webhook.Version
+func (s *service) UpdateWebhook(ctx context.Context, webhook *Webhooks) (*Webhooks, error) {
+	var updated Webhooks
+	query := `UPDATE webhooks
+		SET url = $3, description = $4, events = $5, active = $6, updated_at = NOW(), version = version + 1
+		WHERE id = $1 AND user_id = $2 AND version = $7
+		RETURNING *`
+	err := s.db.GetContext(ctx, &updated, query,
+		webhook.Id, webhook.User_id, webhook.Url, webhook.Description, webhook.Events, webhook.Active, webhook.Version)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Tell a stale version apart from a webhook that isn't the user's
+		var exists bool
+		query = `SELECT EXISTS (SELECT 1 FROM webhooks WHERE id = $1 AND user_id = $2)`
+		if s.db.GetContext(ctx, &exists, query, webhook.Id, webhook.User_id) == nil && exists {
+			return nil, ErrVersionConflict
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// DeleteWebhook removes a webhook and its delivery history, returning sql.ErrNoRows
+// if it doesn't belong to the user
+func (s *service) DeleteWebhook(ctx context.Context, id, userID string) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM webhooks WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// ListWebhookDeliveries returns a webhook's deliveries, newest first
+func (s *service) ListWebhookDeliveries(ctx context.Context, webhookID string, opts ListOptions) ([]Webhook_deliveries, error) {
+	query, args, err := opts.apply(`SELECT * FROM webhook_deliveries WHERE webhook_id = $1`, []interface{}{webhookID}, webhookDeliveryList)
+	if err != nil {
+		return nil, err
+	}
+	deliveries := []Webhook_deliveries{}
+	if err := s.db.SelectContext(ctx, &deliveries, query, args...); err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+var webhookDeliveryList = listSpec{
+	sorts:        map[string]string{"created_at": "created_at"},
+	filters:      map[string]string{"event": "event", "status": "status"},
+	defaultOrder: "created_at DESC",
+	tiebreak:     "id",
+}
+
+// EnqueueWebhookEvent records the event for integration triggers to poll, and creates a
+// delivery of it for each of the user's active webhooks subscribed to it. The deliveries come
+// back claimed for lease, so the caller can send them right away without the retry job
+// picking them up too.
+func (s *service) EnqueueWebhookEvent(ctx context.Context, userID, eventID, event string, payload []byte, lease time.Duration) ([]WebhookDeliveryTarget, error) {
+	targets := []WebhookDeliveryTarget{}
+	query := `WITH recorded AS (
+			INSERT INTO integration_events (id, user_id, event, payload) VALUES ($2, $1, $3, $4)
+		), created AS (
+			INSERT INTO webhook_deliveries (webhook_id, event_id, event, payload, next_attempt_at)
+			SELECT id, $2, $3, $4, NOW() + $5 * INTERVAL '1 second'
+			FROM webhooks
+			WHERE user_id = $1 AND active AND events @> jsonb_build_array($3::text)
+			RETURNING *
+		)
+		SELECT d.id, d.webhook_id, d.event_id, d.event, d.payload, d.attempts, w.url, w.secret
+		FROM created d
+		JOIN webhooks w ON w.id = d.webhook_id`
+	err := s.db.SelectContext(ctx, &targets, query, userID, eventID, event, payload, lease.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("failed to enqueue webhook event: %w", err)
+	}
+	return targets, nil
+}
+
+// ClaimWebhookDeliveries returns up to limit pending deliveries that are due, pushing their
+// next attempt back by lease so concurrent workers skip them. A worker that dies mid-send
+// leaves the delivery to be retried once the lease runs out.
+func (s *service) ClaimWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]WebhookDeliveryTarget, error) {
+	targets := []WebhookDeliveryTarget{}
+	query := `WITH claimed AS (
+			UPDATE webhook_deliveries
+			SET next_attempt_at = NOW() + $2 * INTERVAL '1 second'
+			WHERE id IN (
+				SELECT id FROM webhook_deliveries
+				WHERE status = 'pending' AND next_attempt_at <= NOW()
+				ORDER BY next_attempt_at
+				LIMIT $1
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING *
+		)
+		SELECT d.id, d.webhook_id, d.event_id, d.event, d.payload, d.attempts, w.url, w.secret
+		FROM claimed d
+		JOIN webhooks w ON w.id = d.webhook_id`
+	if err := s.db.SelectContext(ctx, &targets, query, limit, lease.Seconds()); err != nil {
+		return nil, fmt.Errorf("failed to claim webhook deliveries: %w", err)
+	}
+	return targets, nil
+}
+
+// RecordWebhookAttempt stores the outcome of a delivery attempt. A failed attempt is retried
+// at nextAttemptAt, or marks the delivery failed when nextAttemptAt is nil.
+func (s *service) RecordWebhookAttempt(ctx context.Context, id string, succeeded bool, responseStatus *int, lastError string, nextAttemptAt *time.Time) error {
+	query := `UPDATE webhook_deliveries
+		SET attempts = attempts + 1,
+			response_status = $3,
+			last_error = $4,
+			status = CASE WHEN $2 THEN 'succeeded' WHEN $5::timestamptz IS NULL THEN 'failed' ELSE 'pending' END,
+			next_attempt_at = COALESCE($5, next_attempt_at),
+			delivered_at = CASE WHEN $2 THEN NOW() END
+		WHERE id = $1`
+	if _, err := s.db.ExecContext(ctx, query, id, succeeded, responseStatus, lastError, nextAttemptAt); err != nil {
+		return fmt.Errorf("failed to record webhook attempt: %w", err)
+	}
+	return nil
+}
+
+// CountOverdueWebhookDeliveries counts pending deliveries that were due before dueBefore,
+// which grows when the retry job isn't keeping up or isn't running
+func (s *service) CountOverdueWebhookDeliveries(ctx context.Context, dueBefore time.Time) (int, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM webhook_deliveries WHERE status = 'pending' AND next_attempt_at < $1`
+	if err := s.db.GetContext(ctx, &count, query, dueBefore); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
@@ -0,0 +1,91 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// PersonalRecordSet is a logged set heavier than anything the user lifted before for its
+// exercise
+type PersonalRecordSet struct {
+	Exercise_id      string          `db:"exercise_id"`
+	Exercise_name    string          `db:"exercise_name"`
+	Weight_kg        decimal.Decimal `db:"weight_kg"`
+	Reps             int             `db:"reps"`
+	Completed_at     time.Time       `db:"completed_at"`
+	Previous_best_kg decimal.Decimal `db:"previous_best_kg"`
+}
+
+// ListPersonalRecordSets returns, for each exercise the user set a record in during
+// [from, to), the heaviest set beating their best from before from, by exercise name. A
+// first attempt at an exercise has nothing to beat, so it doesn't count.
+func (s *service) ListPersonalRecordSets(ctx context.Context, userID string, from, to time.Time) ([]PersonalRecordSet, error) {
+	records := []PersonalRecordSet{}
+	query := `SELECT * FROM (
+			SELECT DISTINCT ON (wss.exercise_id) wss.exercise_id, e.name AS exercise_name,
+				wss.weight_kg, wss.reps, wss.completed_at, best.weight_kg AS previous_best_kg
+			FROM workout_session_sets wss
+			JOIN workout_sessions ws ON ws.id = wss.session_id
+			JOIN exercises e ON e.id = wss.exercise_id
+			CROSS JOIN LATERAL (
+				SELECT MAX(o.weight_kg) AS weight_kg
+				FROM workout_session_sets o
+				JOIN workout_sessions ows ON ows.id = o.session_id
+				WHERE ows.user_id = ws.user_id AND o.exercise_id = wss.exercise_id
+					AND o.completed_at < $2 AND o.reps > 0
+			) best
+			WHERE ws.user_id = $1 AND wss.completed_at >= $2 AND wss.completed_at < $3
+				AND wss.reps > 0 AND wss.weight_kg > best.weight_kg AND left(wss.client_id, 5) <> 'copy:'
+			ORDER BY wss.exercise_id, wss.weight_kg DESC, wss.reps DESC, wss.completed_at
+		) records
+		ORDER BY exercise_name, exercise_id`
+	if err := s.db.SelectContext(ctx, &records, query, userID, from, to); err != nil {
+		return nil, fmt.Errorf("failed to list personal record sets: %w", err)
+	}
+	return records, nil
+}
+
+// ListActiveWeeks returns the Mondays of the weeks in tz the user started a session in
+// before the given time, latest first and at most limit of them
+func (s *service) ListActiveWeeks(ctx context.Context, userID, tz string, before time.Time, limit int) ([]time.Time, error) {
+	weeks := []time.Time{}
+	query := `SELECT DISTINCT date_trunc('week', started_at AT TIME ZONE $2)::date AS week
+		FROM workout_sessions
+		WHERE user_id = $1 AND started_at < $3
+		ORDER BY week DESC
+		LIMIT $4`
+	if err := s.db.SelectContext(ctx, &weeks, query, userID, tz, before, limit); err != nil {
+		return nil, fmt.Errorf("failed to list active weeks: %w", err)
+	}
+	return weeks, nil
+}
+
+// ClaimWeeklySummaries returns up to limit users due the summary email of the week starting
+// on the date of weekStart and marks it sent for them. Users who opted in are due once per
+// week, once it has ended in their timezone, unless their account has no email address or
+// is pending deletion.
+func (s *service) ClaimWeeklySummaries(ctx context.Context, weekStart time.Time, limit int) ([]string, error) {
+	userIDs := []string{}
+	query := `UPDATE notification_preferences p SET weekly_summary_sent_for = $1
+		WHERE p.user_id IN (
+			SELECT p.user_id
+			FROM notification_preferences p
+			JOIN users u ON u.id = p.user_id
+			WHERE p.weekly_summary_email AND u.email <> ''
+				AND (p.weekly_summary_sent_for IS NULL OR p.weekly_summary_sent_for < $1)
+				AND (now() AT TIME ZONE u.timezone)::date >= $1::date + 7
+				AND NOT EXISTS (SELECT 1 FROM account_deletions ad WHERE ad.user_id = u.id AND ad.status = 'pending')
+			LIMIT $2
+			FOR UPDATE OF p SKIP LOCKED
+		)
+		-- Another instance claiming the same users concurrently has just sent them the week
+		AND (p.weekly_summary_sent_for IS NULL OR p.weekly_summary_sent_for < $1)
+		RETURNING p.user_id`
+	if err := s.db.SelectContext(ctx, &userIDs, query, weekStart.Format(time.DateOnly), limit); err != nil {
+		return nil, fmt.Errorf("failed to claim weekly summaries: %w", err)
+	}
+	return userIDs, nil
+}
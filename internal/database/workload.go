@@ -0,0 +1,32 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// SessionVolume is the weight times reps of the sets logged in a session, zero for a
+// session without sets
+type SessionVolume struct {
+	Started_at time.Time       `db:"started_at"`
+	Volume_kg  decimal.Decimal `db:"volume_kg"`
+}
+
+// ListSessionVolumes returns the volume of each session the user started since the given
+// time, oldest first. Copied sets don't count.
+func (s *service) ListSessionVolumes(ctx context.Context, userID string, since time.Time) ([]SessionVolume, error) {
+	volumes := []SessionVolume{}
+	query := `SELECT ws.started_at, COALESCE(SUM(wss.weight_kg * wss.reps), 0) AS volume_kg
+		FROM workout_sessions ws
+		LEFT JOIN workout_session_sets wss ON wss.session_id = ws.id AND left(wss.client_id, 5) <> 'copy:'
+		WHERE ws.user_id = $1 AND ws.started_at >= $2
+		GROUP BY ws.id, ws.started_at
+		ORDER BY ws.started_at`
+	if err := s.db.SelectContext(ctx, &volumes, query, userID, since); err != nil {
+		return nil, fmt.Errorf("failed to list session volumes: %w", err)
+	}
+	return volumes, nil
+}
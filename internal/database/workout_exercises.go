@@ -0,0 +1,112 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// WorkoutExerciseRepository stores the exercises planned in each workout
+type WorkoutExerciseRepository interface {
+	CreateWorkoutExercise(ctx context.Context, we *Workout_exercises) (*Workout_exercises, error)
+	GetWorkoutExerciseByID(ctx context.Context, id string) (*Workout_exercises, error)
+	ListWorkoutExercises(ctx context.Context, opts ListOptions) ([]Workout_exercises, error)
+	ListWorkoutExercisesOf(ctx context.Context, workoutIDs []string) ([]Workout_exercises, error)
+	UpdateWorkoutExercise(ctx context.Context, we *Workout_exercises) (*Workout_exercises, error)
+	DeleteWorkoutExercise(ctx context.Context, id string) error
+}
+
+type workoutExerciseRepository struct {
+	db *sqlx.DB
+}
+
+// NewWorkoutExerciseRepository returns a WorkoutExerciseRepository that uses db
+func NewWorkoutExerciseRepository(db *sqlx.DB) WorkoutExerciseRepository {
+	return &workoutExerciseRepository{db: db}
+}
+
+func (r *workoutExerciseRepository) CreateWorkoutExercise(ctx context.Context, we *Workout_exercises) (*Workout_exercises, error) {
+	query := `INSERT INTO workout_exercises (id, workout_id, exercise_id, sets, reps, weight_kg, duration_seconds, order_index, rest_seconds, notes, percent_of, percent_value, created_at)
+		VALUES (:id, :workout_id, :exercise_id, :sets, :reps, :weight_kg, :duration_seconds, :order_index, :rest_seconds, :notes, :percent_of, :percent_value, :created_at)
+		RETURNING *`
+	row, err := r.db.NamedQueryContext(ctx, query, we)
+	if err != nil {
+		return nil, TranslateError(err)
+	}
+	defer row.Close()
+	if row.Next() {
+		var created Workout_exercises
+		if err := row.StructScan(&created); err != nil {
+			return nil, err
+		}
+		return &created, nil
+	}
+	if err := row.Err(); err != nil {
+		return nil, TranslateError(err)
+	}
+	return nil, fmt.Errorf("failed to insert workout_exercise")
+}
+
+func (r *workoutExerciseRepository) GetWorkoutExerciseByID(ctx context.Context, id string) (*Workout_exercises, error) {
+	var we Workout_exercises
+	query := `SELECT * FROM workout_exercises WHERE id = $1`
+	err := r.db.GetContext(ctx, &we, query, id)
+	if err != nil {
+		return nil, err
+	}
+	return &we, nil
+}
+
+// ListWorkoutExercises lists workout exercises, newest first by default
+func (r *workoutExerciseRepository) ListWorkoutExercises(ctx context.Context, opts ListOptions) ([]Workout_exercises, error) {
+	query, args, err := opts.apply(`SELECT * FROM workout_exercises WHERE TRUE`, nil, workoutExerciseList)
+	if err != nil {
+		return nil, err
+	}
+	var rows []Workout_exercises
+	err = r.db.SelectContext(ctx, &rows, query, args...)
+	return rows, err
+}
+
+// ListWorkoutExercisesOf returns the exercises of the workouts, each workout's in order
+func (r *workoutExerciseRepository) ListWorkoutExercisesOf(ctx context.Context, workoutIDs []string) ([]Workout_exercises, error) {
+	rows := []Workout_exercises{}
+	if len(workoutIDs) == 0 {
+		return rows, nil
+	}
+	err := r.db.SelectContext(ctx, &rows, `SELECT * FROM workout_exercises WHERE workout_id = ANY($1)
+		ORDER BY workout_id, order_index, id`, workoutIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list workout exercises: %w", err)
+	}
+	return rows, nil
+}
+
+func (r *workoutExerciseRepository) UpdateWorkoutExercise(ctx context.Context, we *Workout_exercises) (*Workout_exercises, error) {
+	query := `UPDATE workout_exercises SET workout_id=:workout_id, exercise_id=:exercise_id, sets=:sets, reps=:reps, weight_kg=:weight_kg, duration_seconds=:duration_seconds, order_index=:order_index, rest_seconds=:rest_seconds, notes=:notes, percent_of=:percent_of, percent_value=:percent_value, updated_at=NOW(), version=version+1 WHERE id=:id AND version=:version RETURNING *`
+	row, err := r.db.NamedQueryContext(ctx, query, we)
+	if err != nil {
+		return nil, TranslateError(err)
+	}
+	defer row.Close()
+	if row.Next() {
+		var updated Workout_exercises
+		if err := row.StructScan(&updated); err != nil {
+			return nil, err
+		}
+		return &updated, nil
+	}
+	if err := row.Err(); err != nil {
+		return nil, TranslateError(err)
+	}
+	return nil, ErrVersionConflict
+}
+
+func (r *workoutExerciseRepository) DeleteWorkoutExercise(ctx context.Context, id string) error {
+	query := `DELETE FROM workout_exercises WHERE id = $1`
+	_, err := r.db.ExecContext(ctx, query, id)
+	return TranslateError(err)
+}
+
+// --- WORKOUT_SESSIONS CRUD ---
@@ -0,0 +1,119 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrNoPreviousSession is returned when there is no earlier session of the same workout to copy
+	ErrNoPreviousSession = errors.New("no previous session of this workout")
+	// ErrSessionHasSets is returned when copying sets into a session that already has some
+	ErrSessionHasSets = errors.New("session already has sets")
+)
+
+// LogWorkoutSessionSet stores a set logged during a session. Sets are keyed by the client's
+// ID for them, so a set resent after a dropped acknowledgment returns the stored row with
+// created false instead of being recorded twice.
+func (s *service) LogWorkoutSessionSet(ctx context.Context, set *Workout_session_sets) (*Workout_session_sets, bool, error) {
+	var logged Workout_session_sets
+	query := `INSERT INTO workout_session_sets
+			(session_id, exercise_id, set_number, reps, weight_kg, duration_seconds, rpe, rest_seconds, client_id, amrap)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		ON CONFLICT (session_id, client_id) DO NOTHING
+		RETURNING *`
+	err := s.db.GetContext(ctx, &logged, query,
+		set.Session_id, set.Exercise_id, set.Set_number, set.Reps, set.Weight_kg,
+		set.Duration_seconds, set.Rpe, set.Rest_seconds, set.Client_id, set.Amrap)
+	if err == nil {
+		return &logged, true, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, false, fmt.Errorf("failed to log workout session set: %w", err)
+	}
+
+	query = `SELECT * FROM workout_session_sets WHERE session_id = $1 AND client_id = $2`
+	if err := s.db.GetContext(ctx, &logged, query, set.Session_id, set.Client_id); err != nil {
+		return nil, false, fmt.Errorf("failed to load logged workout session set: %w", err)
+	}
+	return &logged, false, nil
+}
+
+// ListWorkoutSessionSets returns the sets logged in a session in the order they were completed
+func (s *service) ListWorkoutSessionSets(ctx context.Context, sessionID string) ([]Workout_session_sets, error) {
+	sets := []Workout_session_sets{}
+	query := `SELECT * FROM workout_session_sets WHERE session_id = $1 ORDER BY completed_at, set_number`
+	if err := s.db.SelectContext(ctx, &sets, query, sessionID); err != nil {
+		return nil, err
+	}
+	return sets, nil
+}
+
+// CopyLastWorkoutSessionSets copies the sets of the user's most recent earlier session of
+// the same workout into sessionID, as the starting point for it. Exercises, set numbers,
+// reps, loads, durations and rest carry over; RPE doesn't, since it's how the set felt,
+// and neither does the AMRAP flag, so copied reps never drive training max suggestions.
+// Copies keep the spacing of the originals with the last one completed now, so they list
+// in the same order. Returns the ID of the session copied from and the new sets, or
+// sql.ErrNoRows if the user has no such session.
+func (s *service) CopyLastWorkoutSessionSets(ctx context.Context, sessionID, userID string) (string, []Workout_session_sets, error) {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Locking the session keeps two copies from racing each other
+	var target struct {
+		WorkoutID *string `db:"workout_id"`
+		HasSets   bool    `db:"has_sets"`
+	}
+	query := `SELECT workout_id, EXISTS (SELECT 1 FROM workout_session_sets WHERE session_id = ws.id) AS has_sets
+		FROM workout_sessions ws WHERE id = $1 AND user_id = $2 FOR UPDATE`
+	if err := tx.GetContext(ctx, &target, query, sessionID, userID); err != nil {
+		return "", nil, err
+	}
+	if target.WorkoutID == nil {
+		return "", nil, ErrNoPreviousSession
+	}
+	if target.HasSets {
+		return "", nil, ErrSessionHasSets
+	}
+
+	var sourceID string
+	query = `SELECT ws.id FROM workout_sessions ws
+		WHERE ws.user_id = $1 AND ws.workout_id = $2 AND ws.id <> $3
+			AND ws.started_at < (SELECT started_at FROM workout_sessions WHERE id = $3)
+			AND EXISTS (SELECT 1 FROM workout_session_sets WHERE session_id = ws.id)
+		ORDER BY ws.started_at DESC
+		LIMIT 1`
+	err = tx.GetContext(ctx, &sourceID, query, userID, *target.WorkoutID, sessionID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil, ErrNoPreviousSession
+	}
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to find previous session: %w", err)
+	}
+
+	query = `INSERT INTO workout_session_sets
+			(session_id, exercise_id, set_number, reps, weight_kg, duration_seconds, rest_seconds, client_id, completed_at)
+		SELECT $1, exercise_id, set_number, reps, weight_kg, duration_seconds, rest_seconds, 'copy:' || id,
+			NOW() - (MAX(completed_at) OVER () - completed_at)
+		FROM workout_session_sets WHERE session_id = $2`
+	if _, err := tx.ExecContext(ctx, query, sessionID, sourceID); err != nil {
+		return "", nil, fmt.Errorf("failed to copy workout session sets: %w", err)
+	}
+
+	sets := []Workout_session_sets{}
+	query = `SELECT * FROM workout_session_sets WHERE session_id = $1 ORDER BY completed_at, set_number`
+	if err := tx.SelectContext(ctx, &sets, query, sessionID); err != nil {
+		return "", nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", nil, fmt.Errorf("failed to commit copied sets: %w", err)
+	}
+	return sourceID, sets, nil
+}
@@ -0,0 +1,124 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// copyLastFixture is a migrated database with a user's workout, an earlier session of it
+// with three sets logged, and a new empty session to copy them into
+type copyLastFixture struct {
+	srv       Service
+	userID    string
+	sourceID  string
+	sessionID string
+}
+
+func newCopyLastFixture(t *testing.T) *copyLastFixture {
+	t.Helper()
+	ctx := context.Background()
+	f := &copyLastFixture{srv: newMigratedService(t)}
+	db := f.srv.GetDB()
+	err := db.GetContext(ctx, &f.userID, `INSERT INTO users (email, username, password_hash) VALUES ($1, $2, 'hash') RETURNING id`,
+		testEmail(), "u_"+uuid.NewString()[:8])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var workoutID string
+	if err := db.GetContext(ctx, &workoutID, `INSERT INTO workouts (user_id, name) VALUES ($1, 'Legs') RETURNING id`, f.userID); err != nil {
+		t.Fatal(err)
+	}
+	query := `INSERT INTO workout_sessions (user_id, workout_id, name, started_at) VALUES ($1, $2, 'Legs', $3) RETURNING id`
+	if err := db.GetContext(ctx, &f.sourceID, query, f.userID, workoutID, time.Now().Add(-48*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.GetContext(ctx, &f.sessionID, query, f.userID, workoutID, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	for n := 1; n <= 3; n++ {
+		_, err := db.ExecContext(ctx, `INSERT INTO workout_session_sets (session_id, set_number, reps, weight_kg, rpe, client_id, completed_at)
+			VALUES ($1, $2, 5, 100, 8, $3, $4)`, f.sourceID, n, uuid.NewString(), time.Now().Add(-48*time.Hour+time.Duration(n)*time.Minute))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return f
+}
+
+// setCount returns how many sets are logged in the new session
+func (f *copyLastFixture) setCount(t *testing.T) int {
+	t.Helper()
+	var n int
+	if err := f.srv.GetDB().GetContext(context.Background(), &n, `SELECT COUNT(*) FROM workout_session_sets WHERE session_id = $1`, f.sessionID); err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestCopyLastWorkoutSessionSets(t *testing.T) {
+	f := newCopyLastFixture(t)
+	ctx := context.Background()
+
+	sourceID, sets, err := f.srv.CopyLastWorkoutSessionSets(ctx, f.sessionID, f.userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sourceID != f.sourceID || len(sets) != 3 {
+		t.Fatalf("expected the 3 sets of %s copied, got %d from %s", f.sourceID, len(sets), sourceID)
+	}
+	for i, set := range sets {
+		if set.Set_number != i+1 || set.Rpe != nil {
+			t.Errorf("expected set %d copied in order without its RPE, got %+v", i+1, set)
+		}
+	}
+
+	if _, _, err := f.srv.CopyLastWorkoutSessionSets(ctx, f.sessionID, f.userID); !errors.Is(err, ErrSessionHasSets) {
+		t.Errorf("expected ErrSessionHasSets copying twice, got %v", err)
+	}
+	if n := f.setCount(t); n != 3 {
+		t.Errorf("expected 3 sets after copying twice, got %d", n)
+	}
+}
+
+func TestCopyLastWorkoutSessionSetsRollsBack(t *testing.T) {
+	f := newCopyLastFixture(t)
+	ctx := context.Background()
+	db := f.srv.GetDB()
+
+	// Fail the copy of the third set, after the first two are already written
+	_, err := db.ExecContext(ctx, `
+		CREATE OR REPLACE FUNCTION fail_third_copied_set() RETURNS trigger AS $$
+		BEGIN
+			IF NEW.client_id LIKE 'copy:%' AND NEW.set_number = 3 THEN
+				RAISE EXCEPTION 'disk full';
+			END IF;
+			RETURN NEW;
+		END $$ LANGUAGE plpgsql;
+		CREATE TRIGGER fail_third_copied_set BEFORE INSERT ON workout_session_sets
+			FOR EACH ROW EXECUTE FUNCTION fail_third_copied_set();`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dropTrigger := func() {
+		db.ExecContext(ctx, `DROP TRIGGER IF EXISTS fail_third_copied_set ON workout_session_sets;
+			DROP FUNCTION IF EXISTS fail_third_copied_set()`)
+	}
+	t.Cleanup(dropTrigger)
+
+	if _, _, err := f.srv.CopyLastWorkoutSessionSets(ctx, f.sessionID, f.userID); err == nil {
+		t.Fatal("expected the copy to fail")
+	}
+	if n := f.setCount(t); n != 0 {
+		t.Fatalf("expected a failed copy to leave no sets behind, got %d", n)
+	}
+
+	// Nothing was left half-copied, so trying again works
+	dropTrigger()
+	if _, sets, err := f.srv.CopyLastWorkoutSessionSets(ctx, f.sessionID, f.userID); err != nil || len(sets) != 3 {
+		t.Errorf("expected the retry to copy all 3 sets, got %d, %v", len(sets), err)
+	}
+}
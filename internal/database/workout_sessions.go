@@ -0,0 +1,129 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"fitness-hack/internal/database/sqlbuild"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// WorkoutSessionRepository stores the workouts users have done
+type WorkoutSessionRepository interface {
+	CreateWorkoutSession(ctx context.Context, ws *Workout_sessions) (*Workout_sessions, error)
+	GetWorkoutSessionByID(ctx context.Context, id string) (*Workout_sessions, error)
+	ListWorkoutSessions(ctx context.Context, opts ListWorkoutSessionsOpts) ([]Workout_sessions, error)
+	UpdateWorkoutSession(ctx context.Context, ws *Workout_sessions) (*Workout_sessions, error)
+	DeleteWorkoutSession(ctx context.Context, id string) error
+}
+
+type workoutSessionRepository struct {
+	db *sqlx.DB
+}
+
+// NewWorkoutSessionRepository returns a WorkoutSessionRepository that uses db
+func NewWorkoutSessionRepository(db *sqlx.DB) WorkoutSessionRepository {
+	return &workoutSessionRepository{db: db}
+}
+
+func (r *workoutSessionRepository) CreateWorkoutSession(ctx context.Context, ws *Workout_sessions) (*Workout_sessions, error) {
+	// Sessions started without a workout have no plan
+	if len(ws.Plan) == 0 {
+		withPlan := *ws
+		withPlan.Plan = json.RawMessage("[]")
+		ws = &withPlan
+	}
+	query := `INSERT INTO workout_sessions (id, user_id, workout_id, name, started_at, completed_at, duration_minutes, notes, plan, created_at, updated_at)
+		VALUES (:id, :user_id, :workout_id, :name, :started_at, :completed_at, :duration_minutes, :notes, :plan, :created_at, :updated_at)
+		RETURNING *`
+	row, err := r.db.NamedQueryContext(ctx, query, ws)
+	if err != nil {
+		return nil, TranslateError(err)
+	}
+	defer row.Close()
+	if row.Next() {
+		var created Workout_sessions
+		if err := row.StructScan(&created); err != nil {
+			return nil, err
+		}
+		return &created, nil
+	}
+	if err := row.Err(); err != nil {
+		return nil, TranslateError(err)
+	}
+	return nil, fmt.Errorf("failed to insert workout_session")
+}
+
+func (r *workoutSessionRepository) GetWorkoutSessionByID(ctx context.Context, id string) (*Workout_sessions, error) {
+	var ws Workout_sessions
+	query := `SELECT * FROM workout_sessions WHERE id = $1`
+	err := r.db.GetContext(ctx, &ws, query, id)
+	if err != nil {
+		return nil, err
+	}
+	return &ws, nil
+}
+
+// ListWorkoutSessionsOpts narrows ListWorkoutSessions. Zero fields don't filter, and they
+// combine with the sorting, paging and equality filters of ListOptions.
+type ListWorkoutSessionsOpts struct {
+	ListOptions
+
+	// From and To keep sessions started in [From, To)
+	From time.Time
+	To   time.Time
+}
+
+// query builds the SELECT for the options
+func (o ListWorkoutSessionsOpts) query() (string, []interface{}, error) {
+	q := sqlbuild.New(`SELECT * FROM workout_sessions WHERE TRUE`)
+	if !o.From.IsZero() {
+		q.Compare(workoutSessionList.sorts, "started_at", sqlbuild.Gte, o.From)
+	}
+	if !o.To.IsZero() {
+		q.Compare(workoutSessionList.sorts, "started_at", sqlbuild.Lt, o.To)
+	}
+	return o.ListOptions.build(q, workoutSessionList)
+}
+
+// ListWorkoutSessions lists workout sessions, newest first by default
+func (r *workoutSessionRepository) ListWorkoutSessions(ctx context.Context, opts ListWorkoutSessionsOpts) ([]Workout_sessions, error) {
+	query, args, err := opts.query()
+	if err != nil {
+		return nil, err
+	}
+	var rows []Workout_sessions
+	err = r.db.SelectContext(ctx, &rows, query, args...)
+	return rows, err
+}
+
+func (r *workoutSessionRepository) UpdateWorkoutSession(ctx context.Context, ws *Workout_sessions) (*Workout_sessions, error) {
+	query := `UPDATE workout_sessions SET user_id=:user_id, workout_id=:workout_id, name=:name, started_at=:started_at, completed_at=:completed_at, duration_minutes=:duration_minutes, notes=:notes, updated_at=:updated_at, version=version+1 WHERE id=:id AND version=:version RETURNING *`
+	row, err := r.db.NamedQueryContext(ctx, query, ws)
+	if err != nil {
+		return nil, TranslateError(err)
+	}
+	defer row.Close()
+	if row.Next() {
+		var updated Workout_sessions
+		if err := row.StructScan(&updated); err != nil {
+			return nil, err
+		}
+		return &updated, nil
+	}
+	if err := row.Err(); err != nil {
+		return nil, TranslateError(err)
+	}
+	return nil, ErrVersionConflict
+}
+
+func (r *workoutSessionRepository) DeleteWorkoutSession(ctx context.Context, id string) error {
+	query := `DELETE FROM workout_sessions WHERE id = $1`
+	_, err := r.db.ExecContext(ctx, query, id)
+	return TranslateError(err)
+}
+
+// --- PROGRAMS CRUD ---
@@ -0,0 +1,81 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrNotCloneable is returned when a workout is neither a template nor owned by the caller
+var ErrNotCloneable = errors.New("workout is not a template")
+
+// ListWorkoutTemplates returns template workouts from all users, newest first
+func (r *workoutRepository) ListWorkoutTemplates(ctx context.Context, opts ListOptions) ([]Workouts, error) {
+	query, args, err := opts.apply(`SELECT * FROM workouts WHERE is_template`, nil, workoutTemplateList)
+	if err != nil {
+		return nil, err
+	}
+	var workouts []Workouts
+	err = r.db.SelectContext(ctx, &workouts, query, args...)
+	return workouts, err
+}
+
+// CloneWorkout deep-copies a workout and its workout_exercises to userID in one
+// transaction. The copy gets new IDs and is not a template itself. The program link
+// is only kept when cloning one's own workout. An empty name keeps the original name.
+// Returns sql.ErrNoRows if the workout does not exist, and a *QuotaError if the user has
+// as many workouts as their tier allows.
+func (r *workoutRepository) CloneWorkout(ctx context.Context, id, userID, name string) (*Workouts, error) {
+	if err := r.quotas.check(ctx, userID, QuotaWorkouts, 1); err != nil {
+		return nil, err
+	}
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var source struct {
+		UserID     string `db:"user_id"`
+		IsTemplate bool   `db:"is_template"`
+	}
+	if err := tx.GetContext(ctx, &source, `SELECT user_id, is_template FROM workouts WHERE id = $1 FOR SHARE`, id); err != nil {
+		return nil, err
+	}
+	if !source.IsTemplate && source.UserID != userID {
+		return nil, ErrNotCloneable
+	}
+
+	var cloneID string
+	query := `INSERT INTO workouts (user_id, name, description, duration_minutes, program_id, is_template)
+		SELECT $2, COALESCE(NULLIF($3, ''), name), description, duration_minutes,
+			CASE WHEN user_id = $2 THEN program_id END, FALSE
+		FROM workouts WHERE id = $1
+		RETURNING id`
+	if err := tx.GetContext(ctx, &cloneID, query, id, userID, name); err != nil {
+		return nil, fmt.Errorf("failed to clone workout: %w", err)
+	}
+
+	query = `INSERT INTO workout_exercises (workout_id, exercise_id, sets, reps, weight_kg, duration_seconds, order_index, rest_seconds, notes, percent_of, percent_value)
+		SELECT $2, exercise_id, sets, reps, weight_kg, duration_seconds, order_index, rest_seconds, notes, percent_of, percent_value
+		FROM workout_exercises WHERE workout_id = $1`
+	if _, err := tx.ExecContext(ctx, query, id, cloneID); err != nil {
+		return nil, fmt.Errorf("failed to clone workout exercises: %w", err)
+	}
+
+	// Nullable columns are coalesced so a clone without a program scans cleanly
+	var clone Workouts
+	query = `SELECT id, user_id, name, COALESCE(description, '') AS description,
+			COALESCE(duration_minutes, 0) AS duration_minutes, created_at, updated_at,
+			COALESCE(program_id::text, '') AS program_id, is_template, version
+		FROM workouts WHERE id = $1`
+	if err := tx.GetContext(ctx, &clone, query, cloneID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &clone, nil
+}
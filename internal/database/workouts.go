@@ -0,0 +1,172 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"fitness-hack/internal/database/sqlbuild"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// WorkoutRepository stores workouts, including the templates users clone them from
+type WorkoutRepository interface {
+	CreateWorkout(ctx context.Context, workout *Workouts) (*Workouts, error)
+	GetWorkoutByID(ctx context.Context, id string) (*Workouts, error)
+	GetWorkoutsByIDs(ctx context.Context, ids []string) ([]Workouts, error)
+	ListWorkouts(ctx context.Context, opts ListWorkoutsOpts) ([]Workouts, error)
+	UpdateWorkout(ctx context.Context, workout *Workouts) (*Workouts, error)
+	DeleteWorkout(ctx context.Context, id string) error
+	ListWorkoutTemplates(ctx context.Context, opts ListOptions) ([]Workouts, error)
+	CloneWorkout(ctx context.Context, id, userID, name string) (*Workouts, error)
+}
+
+type workoutRepository struct {
+	db     *sqlx.DB
+	quotas *quotaChecker
+}
+
+// NewWorkoutRepository returns a WorkoutRepository that uses db
+func NewWorkoutRepository(db *sqlx.DB) WorkoutRepository {
+	return &workoutRepository{db: db}
+}
+
+// CreateWorkout saves a new workout, or returns a *QuotaError if the user has as many as
+// their tier allows
+func (r *workoutRepository) CreateWorkout(ctx context.Context, workout *Workouts) (*Workouts, error) {
+	if err := r.quotas.check(ctx, workout.User_id, QuotaWorkouts, 1); err != nil {
+		return nil, err
+	}
+	query := `INSERT INTO workouts (id, user_id, name, description, duration_minutes, program_id, is_template, created_at, updated_at)
+		VALUES (:id, :user_id, :name, :description, :duration_minutes, :program_id, :is_template, :created_at, :updated_at)
+		RETURNING *`
+	row, err := r.db.NamedQueryContext(ctx, query, workout)
+	if err != nil {
+		return nil, TranslateError(err)
+	}
+	defer row.Close()
+	if row.Next() {
+		var created Workouts
+		if err := row.StructScan(&created); err != nil {
+			return nil, err
+		}
+		return &created, nil
+	}
+	if err := row.Err(); err != nil {
+		return nil, TranslateError(err)
+	}
+	return nil, fmt.Errorf("failed to insert workout")
+}
+
+func (r *workoutRepository) GetWorkoutByID(ctx context.Context, id string) (*Workouts, error) {
+	var workout Workouts
+	query := `SELECT * FROM workouts WHERE id = $1`
+	err := r.db.GetContext(ctx, &workout, query, id)
+	if err != nil {
+		return nil, err
+	}
+	return &workout, nil
+}
+
+// GetWorkoutsByIDs returns the workouts with the IDs, in no particular order. IDs without a
+// workout are left out.
+func (r *workoutRepository) GetWorkoutsByIDs(ctx context.Context, ids []string) ([]Workouts, error) {
+	workouts := []Workouts{}
+	if len(ids) == 0 {
+		return workouts, nil
+	}
+	if err := r.db.SelectContext(ctx, &workouts, `SELECT * FROM workouts WHERE id = ANY($1)`, ids); err != nil {
+		return nil, fmt.Errorf("failed to get workouts: %w", err)
+	}
+	return workouts, nil
+}
+
+// ListWorkoutsOpts narrows ListWorkouts. Zero fields don't filter, and they combine with
+// the sorting, paging and equality filters of ListOptions.
+type ListWorkoutsOpts struct {
+	ListOptions
+
+	UserID string
+	// MuscleGroup keeps workouts with at least one exercise whose primary muscles include the
+	// muscle group, by slug or name
+	MuscleGroup string
+
+	// From and To keep workouts created in [From, To)
+	From time.Time
+	To   time.Time
+}
+
+// workoutConditions are the filters on workouts that need more than a column comparison
+var workoutConditions = sqlbuild.Conditions{
+	"muscle_group": `EXISTS (SELECT 1 FROM workout_exercises we
+		JOIN exercise_muscle_groups emg ON emg.exercise_id = we.exercise_id AND emg.role = 'primary'
+		JOIN muscle_groups m ON m.id = emg.muscle_group_id
+		WHERE we.workout_id = workouts.id AND m.slug = %s)`,
+}
+
+// query builds the SELECT for the options
+func (o ListWorkoutsOpts) query() (string, []interface{}, error) {
+	q := sqlbuild.New(`SELECT * FROM workouts WHERE TRUE`)
+	if o.UserID != "" {
+		q.Filter(workoutList.filters, "user_id", o.UserID)
+	}
+	if o.MuscleGroup != "" {
+		q.Where(workoutConditions, "muscle_group", MuscleSlug(o.MuscleGroup))
+	}
+	if !o.From.IsZero() {
+		q.Compare(workoutList.sorts, "created_at", sqlbuild.Gte, o.From)
+	}
+	if !o.To.IsZero() {
+		q.Compare(workoutList.sorts, "created_at", sqlbuild.Lt, o.To)
+	}
+	return o.ListOptions.build(q, workoutList)
+}
+
+// ListWorkouts lists the workouts matching opts, newest first by default
+func (r *workoutRepository) ListWorkouts(ctx context.Context, opts ListWorkoutsOpts) ([]Workouts, error) {
+	query, args, err := opts.query()
+	if err != nil {
+		return nil, err
+	}
+	var rows []Workouts
+	err = r.db.SelectContext(ctx, &rows, query, args...)
+	return rows, err
+}
+
+func (r *workoutRepository) UpdateWorkout(ctx context.Context, workout *Workouts) (*Workouts, error) {
+	query := `UPDATE workouts SET user_id=:user_id, name=:name, description=:description, duration_minutes=:duration_minutes, program_id=:program_id, is_template=:is_template, updated_at=:updated_at, version=version+1 WHERE id=:id AND version=:version RETURNING *`
+	row, err := r.db.NamedQueryContext(ctx, query, workout)
+	if err != nil {
+		return nil, TranslateError(err)
+	}
+	defer row.Close()
+	if row.Next() {
+		var updated Workouts
+		if err := row.StructScan(&updated); err != nil {
+			return nil, err
+		}
+		return &updated, nil
+	}
+	if err := row.Err(); err != nil {
+		return nil, TranslateError(err)
+	}
+	// Tell a stale version apart from a workout that doesn't exist
+	var exists bool
+	if err := r.db.GetContext(ctx, &exists, `SELECT EXISTS (SELECT 1 FROM workouts WHERE id = $1)`, workout.Id); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, sql.ErrNoRows
+	}
+	return nil, ErrVersionConflict
+}
+
+func (r *workoutRepository) DeleteWorkout(ctx context.Context, id string) error {
+	query := `DELETE FROM workouts WHERE id = $1`
+	_, err := r.db.ExecContext(ctx, query, id)
+	return TranslateError(err)
+}
+
+// --- EXERCISES CRUD ---
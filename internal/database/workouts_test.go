@@ -0,0 +1,65 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestUpdateWorkoutBumpsVersion(t *testing.T) {
+	srv := newMigratedService(t)
+	ctx := context.Background()
+
+	var userID string
+	err := srv.GetDB().GetContext(ctx, &userID,
+		`INSERT INTO users (email, username, password_hash) VALUES ($1, $2, 'hash') RETURNING id`,
+		uuid.NewString()[:8]+"@example.com", "u_"+uuid.NewString()[:8])
+	if err != nil {
+		t.Fatal(err)
+	}
+	workout, err := srv.CreateWorkout(ctx, &Workouts{Id: uuid.NewString(), User_id: userID, Name: "Leg day", Created_at: time.Now(), Updated_at: time.Now()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if workout.Version != 1 {
+		t.Fatalf("expected a new workout at version 1, got %d", workout.Version)
+	}
+
+	stale := *workout
+	workout.Name = "Squat day"
+	updated, err := srv.UpdateWorkout(ctx, workout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Version != 2 || updated.Name != "Squat day" {
+		t.Fatalf("expected the update saved at version 2, got %q at version %d", updated.Name, updated.Version)
+	}
+
+	stale.Name = "Stale"
+	if _, err := srv.UpdateWorkout(ctx, &stale); !errors.Is(err, ErrVersionConflict) {
+		t.Errorf("expected ErrVersionConflict updating version 1, got %v", err)
+	}
+	saved, err := srv.GetWorkoutByID(ctx, workout.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.Name != "Squat day" || saved.Version != 2 {
+		t.Errorf("expected the stale update to change nothing, got %q at version %d", saved.Name, saved.Version)
+	}
+
+	if err := srv.DeleteWorkout(ctx, workout.Id); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := srv.UpdateWorkout(ctx, updated); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("expected sql.ErrNoRows updating a deleted workout, got %v", err)
+	}
+	missing := *updated
+	missing.Id = uuid.NewString()
+	if _, err := srv.UpdateWorkout(ctx, &missing); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("expected sql.ErrNoRows updating a workout that never existed, got %v", err)
+	}
+}
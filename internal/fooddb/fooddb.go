@@ -0,0 +1,49 @@
+// Package fooddb looks up packaged foods in an external food database, so users can log a
+// food by scanning its barcode or searching for it without typing in its nutrients.
+// Foods found are copied into the app's own catalog, which is searched first.
+package fooddb
+
+import (
+	"context"
+	"errors"
+	"os"
+)
+
+// ErrNotFound is returned when the database has no food with the barcode
+var ErrNotFound = errors.New("no food with this barcode")
+
+// Food is a food as an external database describes it, with nutrients per serving
+type Food struct {
+	// Source names the database and ExternalID the food in it, so a food found again
+	// updates the catalog's copy instead of adding another
+	Source      string
+	ExternalID  string
+	Name        string
+	Brand       string
+	Barcode     string
+	ServingSize float64
+	// ServingUnit is "g", "ml" or "piece"
+	ServingUnit string
+	Calories    float64
+	ProteinG    float64
+	CarbsG      float64
+	FatG        float64
+}
+
+// Provider looks up foods in an external database
+type Provider interface {
+	// Barcode returns the food with the EAN or UPC barcode, or ErrNotFound
+	Barcode(ctx context.Context, barcode string) (*Food, error)
+	// Search returns up to limit foods matching the query, best matches first
+	Search(ctx context.Context, query string, limit int) ([]Food, error)
+}
+
+// NewFromEnv returns the provider selected by FOOD_DATABASE_PROVIDER, or nil when it isn't
+// set, in which case searches only cover the app's catalog. No providers are built in yet;
+// one is added here as a case of the switch.
+func NewFromEnv() Provider {
+	switch os.Getenv("FOOD_DATABASE_PROVIDER") {
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,141 @@
+// Package health runs readiness checks against the services the API depends on and
+// combines them into a single ok, degraded or down status.
+package health
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Status is the health of one dependency or of the service as a whole
+type Status string
+
+const (
+	StatusOK       Status = "ok"
+	StatusDegraded Status = "degraded"
+	StatusDown     Status = "down"
+)
+
+// Check probes one dependency
+type Check struct {
+	Name string
+	// Critical dependencies take the whole service down when they fail; others only degrade it
+	Critical bool
+	// Timeout bounds the probe so one hung dependency can't stall the report
+	Timeout time.Duration
+	Probe   func(ctx context.Context) error
+}
+
+// Result is the outcome of one check
+type Result struct {
+	Status    Status `json:"status"`
+	Critical  bool   `json:"critical"`
+	LatencyMs int64  `json:"latencyMs"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Report is the combined outcome of all checks
+type Report struct {
+	Status    Status            `json:"status"`
+	Checks    map[string]Result `json:"checks"`
+	CheckedAt time.Time         `json:"checkedAt"`
+}
+
+// Checker runs a fixed set of checks. Reports are cached for a short time so frequent
+// probes from load balancers don't turn into a stream of requests to every dependency.
+type Checker struct {
+	checks   []Check
+	cacheTTL time.Duration
+
+	// OnFailure, if set, is called for each failed check when a fresh report is made
+	OnFailure func(name string, result Result)
+
+	mu     sync.Mutex
+	cached *Report
+}
+
+// NewChecker creates a checker for checks, reusing a report for cacheTTL
+func NewChecker(cacheTTL time.Duration, checks ...Check) *Checker {
+	return &Checker{checks: checks, cacheTTL: cacheTTL}
+}
+
+// Run probes every dependency concurrently and returns the combined report
+func (c *Checker) Run(ctx context.Context) Report {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.cached != nil && time.Since(c.cached.CheckedAt) < c.cacheTTL {
+		return *c.cached
+	}
+
+	results := make([]Result, len(c.checks))
+	var wg sync.WaitGroup
+	for i, check := range c.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = runCheck(ctx, check)
+		}()
+	}
+	wg.Wait()
+
+	report := Report{
+		Status:    StatusOK,
+		Checks:    make(map[string]Result, len(c.checks)),
+		CheckedAt: time.Now().UTC(),
+	}
+	for i, check := range c.checks {
+		result := results[i]
+		report.Checks[check.Name] = result
+		if result.Status != StatusOK && c.OnFailure != nil {
+			c.OnFailure(check.Name, result)
+		}
+		switch {
+		case result.Status == StatusOK:
+		case check.Critical:
+			report.Status = StatusDown
+		case report.Status == StatusOK:
+			report.Status = StatusDegraded
+		}
+	}
+
+	c.cached = &report
+	return report
+}
+
+// runCheck runs one probe within its timeout. A probe that ignores its context is
+// abandoned once the timeout passes.
+func runCheck(ctx context.Context, check Check) Result {
+	timeout := check.Timeout
+	if timeout <= 0 {
+		timeout = 2 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("check panicked: %v", r)
+			}
+		}()
+		done <- check.Probe(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("timed out after %s", timeout)
+	}
+
+	result := Result{Status: StatusOK, Critical: check.Critical, LatencyMs: time.Since(start).Milliseconds()}
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+	return result
+}
@@ -0,0 +1,66 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func probe(err error) func(context.Context) error {
+	return func(context.Context) error { return err }
+}
+
+func TestRunCombinesStatuses(t *testing.T) {
+	failing := errors.New("connection refused")
+	cases := []struct {
+		name     string
+		checks   []Check
+		expected Status
+	}{
+		{"all ok", []Check{{Name: "db", Critical: true, Probe: probe(nil)}, {Name: "mail", Probe: probe(nil)}}, StatusOK},
+		{"optional failure", []Check{{Name: "db", Critical: true, Probe: probe(nil)}, {Name: "mail", Probe: probe(failing)}}, StatusDegraded},
+		{"critical failure", []Check{{Name: "db", Critical: true, Probe: probe(failing)}, {Name: "mail", Probe: probe(failing)}}, StatusDown},
+	}
+	for _, tc := range cases {
+		report := NewChecker(0, tc.checks...).Run(context.Background())
+		if report.Status != tc.expected {
+			t.Errorf("%s: expected %s, got %s", tc.name, tc.expected, report.Status)
+		}
+		if len(report.Checks) != len(tc.checks) {
+			t.Errorf("%s: expected %d results, got %d", tc.name, len(tc.checks), len(report.Checks))
+		}
+	}
+}
+
+func TestRunTimesOutHungChecks(t *testing.T) {
+	hung := Check{Name: "redis", Timeout: 20 * time.Millisecond, Probe: func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	}}
+
+	start := time.Now()
+	report := NewChecker(0, hung).Run(context.Background())
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("expected the check to be abandoned after its timeout, took %v", elapsed)
+	}
+	if result := report.Checks["redis"]; result.Status != StatusDown || result.Error == "" {
+		t.Errorf("expected a timed out check to be down with an error, got %+v", result)
+	}
+	if report.Status != StatusDegraded {
+		t.Errorf("expected degraded, got %s", report.Status)
+	}
+}
+
+func TestRunCachesReports(t *testing.T) {
+	calls := 0
+	checker := NewChecker(time.Minute, Check{Name: "db", Probe: func(context.Context) error {
+		calls++
+		return nil
+	}})
+	checker.Run(context.Background())
+	checker.Run(context.Background())
+	if calls != 1 {
+		t.Errorf("expected the second run to reuse the report, probed %d times", calls)
+	}
+}
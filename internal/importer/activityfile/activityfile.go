@@ -0,0 +1,118 @@
+// Package activityfile parses activity files recorded by GPS watches and bike computers:
+// Garmin FIT, Training Center XML (TCX) and GPX.
+package activityfile
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"strings"
+
+	"fitness-hack/internal/importer"
+)
+
+// Sources identify the file format imported workouts came from
+const (
+	SourceFIT = "fit"
+	SourceTCX = "tcx"
+	SourceGPX = "gpx"
+)
+
+// ErrUnknownFormat is returned for files that are neither FIT, TCX nor GPX
+var ErrUnknownFormat = errors.New("unsupported file format, expected a FIT, TCX or GPX file")
+
+// sportExercises maps sport names, lowercased with separators removed, to exercises.
+// FIT sports are converted to these names; TCX and GPX files name the sport directly.
+var sportExercises = map[string]importer.Exercise{
+	"running":            {Name: "Running", MuscleGroup: "Cardio", Equipment: "None"},
+	"trailrunning":       {Name: "Trail Running", MuscleGroup: "Cardio", Equipment: "None"},
+	"treadmillrunning":   {Name: "Treadmill Running", MuscleGroup: "Cardio", Equipment: "Treadmill"},
+	"walking":            {Name: "Walking", MuscleGroup: "Cardio", Equipment: "None"},
+	"hiking":             {Name: "Hiking", MuscleGroup: "Cardio", Equipment: "None"},
+	"cycling":            {Name: "Cycling", MuscleGroup: "Cardio", Equipment: "Bicycle"},
+	"biking":             {Name: "Cycling", MuscleGroup: "Cardio", Equipment: "Bicycle"},
+	"mountainbiking":     {Name: "Mountain Biking", MuscleGroup: "Cardio", Equipment: "Bicycle"},
+	"swimming":           {Name: "Swimming", MuscleGroup: "Full Body", Equipment: "None"},
+	"rowing":             {Name: "Rowing", MuscleGroup: "Full Body", Equipment: "None"},
+	"crosscountryskiing": {Name: "Cross-Country Skiing", MuscleGroup: "Full Body", Equipment: "Skis"},
+	"alpineskiing":       {Name: "Alpine Skiing", MuscleGroup: "Legs", Equipment: "Skis"},
+	"training":           {Name: "Functional Training", MuscleGroup: "Full Body", Equipment: "Various"},
+	"fitnessequipment":   {Name: "Cardio Machine", MuscleGroup: "Cardio", Equipment: "Various"},
+}
+
+// exerciseForSport returns the exercise for a sport name, or the generic workout
+func exerciseForSport(sport string) importer.Exercise {
+	key := strings.NewReplacer("_", "", "-", "", " ", "").Replace(strings.ToLower(sport))
+	if exercise, ok := sportExercises[key]; ok {
+		return exercise
+	}
+	return importer.GenericExercise
+}
+
+// Detect returns the format of an activity file from its content, falling back to the
+// file extension, or "" if it is not recognized
+func Detect(filename string, data []byte) string {
+	if len(data) >= 12 && string(data[8:12]) == ".FIT" {
+		return SourceFIT
+	}
+	trimmed := bytes.TrimSpace(data)
+	if bytes.HasPrefix(trimmed, []byte("<")) {
+		// Only the root element matters; skip past the prolog
+		head := trimmed
+		if len(head) > 1024 {
+			head = head[:1024]
+		}
+		switch {
+		case bytes.Contains(head, []byte("<TrainingCenterDatabase")):
+			return SourceTCX
+		case bytes.Contains(head, []byte("<gpx")):
+			return SourceGPX
+		}
+	}
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".fit":
+		return SourceFIT
+	case ".tcx":
+		return SourceTCX
+	case ".gpx":
+		return SourceGPX
+	}
+	return ""
+}
+
+// Parse detects the format of an activity file and parses it. Workouts of the result
+// are recorded against an exercise matching the file's sport.
+func Parse(filename string, data []byte) (*importer.HealthData, error) {
+	switch Detect(filename, data) {
+	case SourceFIT:
+		return ParseFIT(bytes.NewReader(data))
+	case SourceTCX:
+		return ParseTCX(bytes.NewReader(data))
+	case SourceGPX:
+		return ParseGPX(bytes.NewReader(data))
+	}
+	return nil, ErrUnknownFormat
+}
+
+// heartRateSummary accumulates heart rate samples into an average and maximum
+type heartRateSummary struct {
+	sum, count, max int
+}
+
+func (h *heartRateSummary) add(bpm int) {
+	if bpm <= 0 || bpm > 250 {
+		return
+	}
+	h.sum += bpm
+	h.count++
+	if bpm > h.max {
+		h.max = bpm
+	}
+}
+
+func (h *heartRateSummary) average() int {
+	if h.count == 0 {
+		return 0
+	}
+	return (h.sum + h.count/2) / h.count
+}
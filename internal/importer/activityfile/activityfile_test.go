@@ -0,0 +1,194 @@
+package activityfile
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+// buildFIT assembles a FIT activity with one running session
+func buildFIT(start time.Time) []byte {
+	var records bytes.Buffer
+	le := binary.LittleEndian
+	fitTime := uint32(start.Sub(fitEpoch).Seconds())
+
+	// file_id: type, serial_number, time_created
+	records.Write([]byte{0x40, 0, 0, 0, 0, 3, 0, 1, 0x00, 3, 4, 0x8C, 4, 4, 0x86})
+	records.WriteByte(0x00)
+	records.WriteByte(fitFileTypeActivity)
+	records.Write(le.AppendUint32(nil, 3912345678))
+	records.Write(le.AppendUint32(nil, fitTime))
+
+	// session: start_time, start position, total_elapsed_time, total_timer_time, total_distance,
+	// sport, avg/max heart rate
+	records.Write([]byte{0x41, 0, 0, fitMesgSession, 0, 9,
+		2, 4, 0x86, 3, 4, 0x85, 4, 4, 0x85, 7, 4, 0x86, 8, 4, 0x86, 9, 4, 0x86, 5, 1, 0x00, 16, 1, 0x02, 17, 1, 0x02})
+	records.WriteByte(0x01)
+	records.Write(le.AppendUint32(nil, fitTime))
+	semicircles := func(degrees float64) uint32 { return uint32(int32(math.Round(degrees * math.Pow(2, 31) / 180))) }
+	records.Write(le.AppendUint32(nil, semicircles(-33.8688)))
+	records.Write(le.AppendUint32(nil, semicircles(151.2093)))
+	records.Write(le.AppendUint32(nil, 32*60*1000))
+	records.Write(le.AppendUint32(nil, 30*60*1000))
+	records.Write(le.AppendUint32(nil, 520000))
+	records.Write([]byte{1, 151, 0xFF})
+
+	// records: one with a timestamp, then one with a compressed timestamp header
+	records.Write([]byte{0x42, 0, 0, fitMesgRecord, 0, 2, fitFieldTimestamp, 4, 0x86, 3, 1, 0x02})
+	records.WriteByte(0x02)
+	records.Write(le.AppendUint32(nil, fitTime+5))
+	records.WriteByte(150)
+	records.Write([]byte{0x43, 0, 0, fitMesgRecord, 0, 1, 3, 1, 0x02})
+	records.Write([]byte{0x80 | 3<<5 | byte((fitTime+40)&0x1F), 172})
+
+	header := []byte{14, 0x20, 0, 0, 0, 0, 0, 0, '.', 'F', 'I', 'T', 0, 0}
+	le.PutUint32(header[4:8], uint32(records.Len()))
+	le.PutUint16(header[12:14], fitCRC(header[:12]))
+
+	file := append(header, records.Bytes()...)
+	return le.AppendUint16(file, fitCRC(file))
+}
+
+func TestParseFIT(t *testing.T) {
+	start := time.Date(2024, 2, 1, 18, 0, 0, 0, time.UTC)
+	file := buildFIT(start)
+
+	data, err := Parse("activity.fit", file)
+	if err != nil {
+		t.Fatalf("error parsing FIT file. Err: %v", err)
+	}
+	if data.Source != SourceFIT || len(data.Workouts) != 1 {
+		t.Fatalf("expected 1 FIT workout, got %+v", data)
+	}
+
+	w := data.Workouts[0]
+	if w.Exercise.Name != "Running" || !w.StartedAt.Equal(start) || !w.EndedAt.Equal(start.Add(32*time.Minute)) {
+		t.Errorf("unexpected workout %+v", w)
+	}
+	if w.Duration != 30*time.Minute || math.Abs(w.DistanceMeters-5200) > 0.001 {
+		t.Errorf("expected 30 minutes over 5.2 km, got %v over %v m", w.Duration, w.DistanceMeters)
+	}
+	if w.Start == nil || math.Abs(w.Start.Latitude+33.8688) > 1e-6 || math.Abs(w.Start.Longitude-151.2093) > 1e-6 {
+		t.Errorf("expected the session's start position, got %+v", w.Start)
+	}
+	// The session has no maximum heart rate, so it comes from the records
+	if w.AvgHeartRate != 151 || w.MaxHeartRate != 172 {
+		t.Errorf("expected heart rate 151/172, got %d/%d", w.AvgHeartRate, w.MaxHeartRate)
+	}
+
+	again, _ := ParseFIT(bytes.NewReader(file))
+	if again.Workouts[0].ExternalID != w.ExternalID {
+		t.Error("expected the same file to get the same ID on re-upload")
+	}
+
+	file[len(file)-3] ^= 0xFF
+	if _, err := ParseFIT(bytes.NewReader(file)); err == nil {
+		t.Error("expected checksum error for a corrupted file")
+	}
+}
+
+func TestParseTCX(t *testing.T) {
+	body := `<?xml version="1.0" encoding="UTF-8"?>
+<TrainingCenterDatabase xmlns="http://www.garmin.com/xmlschemas/TrainingCenterDatabase/v2">
+ <Activities>
+  <Activity Sport="Biking">
+   <Id>2024-02-01T18:00:00Z</Id>
+   <Lap StartTime="2024-02-01T18:00:00Z">
+    <TotalTimeSeconds>1800</TotalTimeSeconds>
+    <DistanceMeters>15000</DistanceMeters>
+    <AverageHeartRateBpm><Value>140</Value></AverageHeartRateBpm>
+    <MaximumHeartRateBpm><Value>165</Value></MaximumHeartRateBpm>
+    <Track>
+     <Trackpoint><Time>2024-02-01T18:00:00Z</Time></Trackpoint>
+     <Trackpoint>
+      <Time>2024-02-01T18:00:05Z</Time>
+      <Position><LatitudeDegrees>47.3769</LatitudeDegrees><LongitudeDegrees>8.5417</LongitudeDegrees></Position>
+     </Trackpoint>
+    </Track>
+   </Lap>
+   <Lap StartTime="2024-02-01T18:35:00Z">
+    <TotalTimeSeconds>600</TotalTimeSeconds>
+    <DistanceMeters>4000</DistanceMeters>
+    <AverageHeartRateBpm><Value>160</Value></AverageHeartRateBpm>
+    <MaximumHeartRateBpm><Value>178</Value></MaximumHeartRateBpm>
+   </Lap>
+  </Activity>
+ </Activities>
+</TrainingCenterDatabase>`
+
+	if format := Detect("ride.xml", []byte(body)); format != SourceTCX {
+		t.Fatalf("expected TCX to be detected from content, got %q", format)
+	}
+	data, err := ParseTCX(strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("error parsing TCX file. Err: %v", err)
+	}
+	if len(data.Workouts) != 1 {
+		t.Fatalf("expected 1 workout, got %d", len(data.Workouts))
+	}
+
+	w := data.Workouts[0]
+	if w.Exercise.Name != "Cycling" || w.Duration != 40*time.Minute || w.DistanceMeters != 19000 {
+		t.Errorf("unexpected workout %+v", w)
+	}
+	if !w.EndedAt.Equal(time.Date(2024, 2, 1, 18, 45, 0, 0, time.UTC)) {
+		t.Errorf("expected the workout to end with its last lap, got %v", w.EndedAt)
+	}
+	if w.AvgHeartRate != 145 || w.MaxHeartRate != 178 {
+		t.Errorf("expected time-weighted heart rate 145/178, got %d/%d", w.AvgHeartRate, w.MaxHeartRate)
+	}
+	if w.Start == nil || w.Start.Latitude != 47.3769 || w.Start.Longitude != 8.5417 {
+		t.Errorf("expected the first trackpoint with a position as the start, got %+v", w.Start)
+	}
+}
+
+func TestParseGPX(t *testing.T) {
+	body := `<?xml version="1.0" encoding="UTF-8"?>
+<gpx version="1.1" creator="test" xmlns="http://www.topografix.com/GPX/1/1"
+     xmlns:gpxtpx="http://www.garmin.com/xmlschemas/TrackPointExtension/v1">
+ <trk>
+  <name>Evening Run</name>
+  <type>running</type>
+  <trkseg>
+   <trkpt lat="0" lon="0"><time>2024-02-01T18:00:00Z</time>
+    <extensions><gpxtpx:TrackPointExtension><gpxtpx:hr>140</gpxtpx:hr></gpxtpx:TrackPointExtension></extensions>
+   </trkpt>
+   <trkpt lat="0" lon="0.01"><time>2024-02-01T18:05:00Z</time>
+    <extensions><gpxtpx:TrackPointExtension><gpxtpx:hr>160</gpxtpx:hr></gpxtpx:TrackPointExtension></extensions>
+   </trkpt>
+  </trkseg>
+ </trk>
+ <trk><name>Planned route</name><trkseg><trkpt lat="1" lon="1"/></trkseg></trk>
+</gpx>`
+
+	data, err := Parse("run.gpx", []byte(body))
+	if err != nil {
+		t.Fatalf("error parsing GPX file. Err: %v", err)
+	}
+	if len(data.Workouts) != 1 || data.Skipped != 1 {
+		t.Fatalf("expected 1 workout and the untimed route skipped, got %d and %d", len(data.Workouts), data.Skipped)
+	}
+
+	w := data.Workouts[0]
+	if w.Name != "Evening Run" || w.Exercise.Name != "Running" || w.Duration != 5*time.Minute {
+		t.Errorf("unexpected workout %+v", w)
+	}
+	// 0.01° of longitude at the equator is about 1112 m
+	if math.Abs(w.DistanceMeters-1111.9) > 1 {
+		t.Errorf("expected about 1112 m, got %v", w.DistanceMeters)
+	}
+	if w.AvgHeartRate != 150 || w.MaxHeartRate != 160 {
+		t.Errorf("expected heart rate 150/160, got %d/%d", w.AvgHeartRate, w.MaxHeartRate)
+	}
+	// Devices write 0,0 before they have a fix
+	if w.Start == nil || w.Start.Latitude != 0 || w.Start.Longitude != 0.01 {
+		t.Errorf("expected the first point with a fix as the start, got %+v", w.Start)
+	}
+
+	if _, err := Parse("notes.txt", []byte("hello")); err != ErrUnknownFormat {
+		t.Errorf("expected ErrUnknownFormat, got %v", err)
+	}
+}
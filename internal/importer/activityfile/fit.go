@@ -0,0 +1,422 @@
+package activityfile
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"time"
+
+	"fitness-hack/internal/importer"
+)
+
+// fitEpoch is the origin of FIT timestamps
+var fitEpoch = time.Date(1989, 12, 31, 0, 0, 0, 0, time.UTC)
+
+// Global message and field numbers from the FIT profile
+const (
+	fitMesgFileID  = 0
+	fitMesgSession = 18
+	fitMesgRecord  = 20
+
+	fitFileTypeActivity = 4
+	fitFieldTimestamp   = 253
+)
+
+// fitSports maps the FIT sport enum to sport names
+var fitSports = map[uint64]string{
+	1:  "running",
+	2:  "cycling",
+	4:  "fitness_equipment",
+	5:  "swimming",
+	10: "training",
+	11: "walking",
+	12: "cross_country_skiing",
+	13: "alpine_skiing",
+	15: "rowing",
+	17: "hiking",
+}
+
+var errFITTruncated = errors.New("invalid FIT file: unexpected end of data")
+
+type fitField struct {
+	num, size, baseType byte
+}
+
+type fitDefinition struct {
+	global    uint16
+	bigEndian bool
+	fields    []fitField
+	devSize   int
+}
+
+// fitMessage holds the integer fields of a data message that have a valid value
+type fitMessage map[byte]uint64
+
+func (m fitMessage) time(field byte) (time.Time, bool) {
+	v, ok := m[field]
+	if !ok {
+		return time.Time{}, false
+	}
+	return fitEpoch.Add(time.Duration(v) * time.Second), true
+}
+
+// position converts a latitude and longitude field pair, in semicircles, to a position
+func (m fitMessage) position(latField, longField byte) *importer.Position {
+	lat, ok := m[latField]
+	long, longOK := m[longField]
+	if !ok || !longOK {
+		return nil
+	}
+	toDegrees := 180 / math.Pow(2, 31)
+	return &importer.Position{
+		Latitude:  float64(int32(uint32(lat))) * toDegrees,
+		Longitude: float64(int32(uint32(long))) * toDegrees,
+	}
+}
+
+// fitDecoder walks the records of a FIT file's data section
+type fitDecoder struct {
+	data          []byte
+	pos           int
+	definitions   [16]*fitDefinition
+	lastTimestamp uint32
+}
+
+func (d *fitDecoder) read(n int) ([]byte, error) {
+	if d.pos+n > len(d.data) {
+		return nil, errFITTruncated
+	}
+	b := d.data[d.pos : d.pos+n]
+	d.pos += n
+	return b, nil
+}
+
+// next decodes one record. Definition records only update the decoder and return a nil message.
+func (d *fitDecoder) next() (uint16, fitMessage, error) {
+	h, err := d.read(1)
+	if err != nil {
+		return 0, nil, err
+	}
+	header := h[0]
+
+	// Compressed timestamp headers carry the low 5 bits of the timestamp, relative to
+	// the last full timestamp seen
+	if header&0x80 != 0 {
+		offset := uint32(header & 0x1F)
+		timestamp := d.lastTimestamp&^0x1F + offset
+		if offset < d.lastTimestamp&0x1F {
+			timestamp += 0x20
+		}
+		global, msg, err := d.readData((header >> 5) & 0x03)
+		if err != nil {
+			return 0, nil, err
+		}
+		msg[fitFieldTimestamp] = uint64(timestamp)
+		d.lastTimestamp = timestamp
+		return global, msg, nil
+	}
+
+	if header&0x40 != 0 {
+		return 0, nil, d.readDefinition(header&0x0F, header&0x20 != 0)
+	}
+	return d.readData(header & 0x0F)
+}
+
+func (d *fitDecoder) readDefinition(local byte, hasDevFields bool) error {
+	b, err := d.read(5)
+	if err != nil {
+		return err
+	}
+	def := &fitDefinition{bigEndian: b[1] == 1}
+	if def.bigEndian {
+		def.global = binary.BigEndian.Uint16(b[2:4])
+	} else {
+		def.global = binary.LittleEndian.Uint16(b[2:4])
+	}
+
+	fields, err := d.read(int(b[4]) * 3)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < len(fields); i += 3 {
+		def.fields = append(def.fields, fitField{num: fields[i], size: fields[i+1], baseType: fields[i+2]})
+	}
+
+	// Developer fields are skipped; only their sizes are needed
+	if hasDevFields {
+		n, err := d.read(1)
+		if err != nil {
+			return err
+		}
+		devFields, err := d.read(int(n[0]) * 3)
+		if err != nil {
+			return err
+		}
+		for i := 0; i < len(devFields); i += 3 {
+			def.devSize += int(devFields[i+1])
+		}
+	}
+
+	d.definitions[local] = def
+	return nil
+}
+
+func (d *fitDecoder) readData(local byte) (uint16, fitMessage, error) {
+	def := d.definitions[local]
+	if def == nil {
+		return 0, nil, errors.New("invalid FIT file: data message without a definition")
+	}
+
+	msg := make(fitMessage, len(def.fields))
+	for _, field := range def.fields {
+		b, err := d.read(int(field.size))
+		if err != nil {
+			return 0, nil, err
+		}
+		if v, ok := fitValue(b, field.baseType, def.bigEndian); ok {
+			msg[field.num] = v
+		}
+	}
+	if _, err := d.read(def.devSize); err != nil {
+		return 0, nil, err
+	}
+
+	if timestamp, ok := msg[fitFieldTimestamp]; ok {
+		d.lastTimestamp = uint32(timestamp)
+	}
+	return def.global, msg, nil
+}
+
+// fitValue decodes an unsigned integer or enum field, or a sint32 field such as a position,
+// which is returned as its bits. Other base types aren't needed and are reported as
+// invalid, as are fields holding the type's "no value" marker.
+func fitValue(b []byte, baseType byte, bigEndian bool) (uint64, bool) {
+	var order binary.ByteOrder = binary.LittleEndian
+	if bigEndian {
+		order = binary.BigEndian
+	}
+
+	var v, invalid uint64
+	switch {
+	case (baseType == 0x00 || baseType == 0x02 || baseType == 0x0A) && len(b) == 1:
+		v, invalid = uint64(b[0]), 0xFF
+	case (baseType == 0x84 || baseType == 0x8B) && len(b) == 2:
+		v, invalid = uint64(order.Uint16(b)), 0xFFFF
+	case (baseType == 0x86 || baseType == 0x8C) && len(b) == 4:
+		v, invalid = uint64(order.Uint32(b)), 0xFFFFFFFF
+	case baseType == 0x85 && len(b) == 4:
+		v, invalid = uint64(order.Uint32(b)), 0x7FFFFFFF
+	case (baseType == 0x8F || baseType == 0x90) && len(b) == 8:
+		v, invalid = order.Uint64(b), 0xFFFFFFFFFFFFFFFF
+	default:
+		return 0, false
+	}
+
+	// The "z" types use 0 rather than all ones to mark a missing value
+	zeroInvalid := baseType == 0x0A || baseType == 0x8B || baseType == 0x8C || baseType == 0x90
+	if v == invalid || (zeroInvalid && v == 0) {
+		return 0, false
+	}
+	return v, true
+}
+
+var fitCRCTable = [16]uint16{
+	0x0000, 0xCC01, 0xD801, 0x1400, 0xF001, 0x3C00, 0x2800, 0xE401,
+	0xA001, 0x6C00, 0x7800, 0xB401, 0x5000, 0x9C01, 0x8801, 0x4400,
+}
+
+// fitCRC computes the CRC-16 used by FIT files
+func fitCRC(data []byte) uint16 {
+	var crc uint16
+	for _, b := range data {
+		tmp := fitCRCTable[crc&0xF]
+		crc = (crc >> 4) & 0x0FFF
+		crc = crc ^ tmp ^ fitCRCTable[b&0xF]
+
+		tmp = fitCRCTable[crc&0xF]
+		crc = (crc >> 4) & 0x0FFF
+		crc = crc ^ tmp ^ fitCRCTable[(b>>4)&0xF]
+	}
+	return crc
+}
+
+// ParseFIT reads a Garmin FIT activity file. Each session in the file becomes a workout;
+// files without sessions are summarized from their records.
+func ParseFIT(r io.Reader) (*importer.HealthData, error) {
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read FIT file: %w", err)
+	}
+	if len(raw) < 12 || string(raw[8:12]) != ".FIT" {
+		return nil, errors.New("invalid FIT file: missing header")
+	}
+	headerSize := int(raw[0])
+	if headerSize != 12 && headerSize != 14 {
+		return nil, fmt.Errorf("invalid FIT file: unexpected header size %d", headerSize)
+	}
+	end := headerSize + int(binary.LittleEndian.Uint32(raw[4:8]))
+	if end+2 > len(raw) {
+		return nil, errFITTruncated
+	}
+	// A zero CRC means the writer didn't compute one
+	if crc := binary.LittleEndian.Uint16(raw[end : end+2]); crc != 0 && crc != fitCRC(raw[:end]) {
+		return nil, errors.New("invalid FIT file: checksum mismatch")
+	}
+
+	decoder := &fitDecoder{data: raw[headerSize:end]}
+	var fileID fitMessage
+	var sessions, records []fitMessage
+	for decoder.pos < len(decoder.data) {
+		global, msg, err := decoder.next()
+		if err != nil {
+			return nil, err
+		}
+		if msg == nil {
+			continue
+		}
+		switch global {
+		case fitMesgFileID:
+			fileID = msg
+		case fitMesgSession:
+			sessions = append(sessions, msg)
+		case fitMesgRecord:
+			records = append(records, msg)
+		}
+	}
+
+	if fileID != nil {
+		if fileType, ok := fileID[0]; ok && fileType != fitFileTypeActivity {
+			return nil, errors.New("FIT file is not an activity, e.g. a course or device settings")
+		}
+	}
+	// The device serial number and creation time identify the file across re-uploads
+	fileKey := fmt.Sprintf("%d-%d", fileID[3], fileID[4])
+
+	data := &importer.HealthData{Source: SourceFIT}
+	if len(sessions) == 0 && len(records) > 0 {
+		if w, ok := fitRecordsToWorkout(records, fileKey); ok {
+			data.Workouts = append(data.Workouts, w)
+		} else {
+			data.Skipped++
+		}
+	}
+	for _, session := range sessions {
+		if w, ok := fitSessionToWorkout(session, records, fileKey); ok {
+			data.Workouts = append(data.Workouts, w)
+		} else {
+			data.Skipped++
+		}
+	}
+
+	if len(data.Workouts) == 0 && data.Skipped == 0 {
+		return nil, errors.New("FIT file contains no activity")
+	}
+	return data, nil
+}
+
+func fitSessionToWorkout(session fitMessage, records []fitMessage, fileKey string) (importer.Workout, bool) {
+	startedAt, ok := session.time(2)
+	if !ok {
+		return importer.Workout{}, false
+	}
+
+	// Elapsed and timer times are in milliseconds; the timer excludes pauses
+	endedAt, hasEnd := session.time(fitFieldTimestamp)
+	if elapsed, ok := session[7]; ok {
+		endedAt, hasEnd = startedAt.Add(time.Duration(elapsed)*time.Millisecond), true
+	}
+	if !hasEnd || endedAt.Before(startedAt) {
+		return importer.Workout{}, false
+	}
+
+	exercise := exerciseForSport(fitSports[session[5]])
+	w := importer.Workout{
+		ExternalID:     importer.ContentID(SourceFIT, fileKey, strconv.FormatUint(session[2], 10)),
+		Name:           exercise.Name,
+		Exercise:       exercise,
+		StartedAt:      startedAt,
+		EndedAt:        endedAt,
+		Duration:       endedAt.Sub(startedAt),
+		DistanceMeters: float64(session[9]) / 100,
+		AvgHeartRate:   int(session[16]),
+		MaxHeartRate:   int(session[17]),
+		Start:          session.position(3, 4),
+	}
+	if timer, ok := session[8]; ok {
+		w.Duration = time.Duration(timer) * time.Millisecond
+	}
+
+	// Without a start position in the session, the workout starts at its first positioned record
+	if w.Start == nil {
+		for _, record := range records {
+			at, ok := record.time(fitFieldTimestamp)
+			if ok && !at.Before(startedAt) && !at.After(endedAt) {
+				if w.Start = record.position(0, 1); w.Start != nil {
+					break
+				}
+			}
+		}
+	}
+
+	// Some devices leave the heart rate summary out of the session
+	if w.AvgHeartRate == 0 || w.MaxHeartRate == 0 {
+		var hr heartRateSummary
+		for _, record := range records {
+			at, ok := record.time(fitFieldTimestamp)
+			if ok && !at.Before(startedAt) && !at.After(endedAt) {
+				hr.add(int(record[3]))
+			}
+		}
+		if w.AvgHeartRate == 0 {
+			w.AvgHeartRate = hr.average()
+		}
+		if w.MaxHeartRate == 0 {
+			w.MaxHeartRate = hr.max
+		}
+	}
+	return w, true
+}
+
+func fitRecordsToWorkout(records []fitMessage, fileKey string) (importer.Workout, bool) {
+	var startedAt, endedAt time.Time
+	var first uint64
+	var distance uint64
+	var hr heartRateSummary
+	var start *importer.Position
+	for _, record := range records {
+		at, ok := record.time(fitFieldTimestamp)
+		if !ok {
+			continue
+		}
+		if start == nil {
+			start = record.position(0, 1)
+		}
+		if startedAt.IsZero() {
+			startedAt, first = at, record[fitFieldTimestamp]
+		}
+		endedAt = at
+		if record[5] > distance {
+			distance = record[5]
+		}
+		hr.add(int(record[3]))
+	}
+	if startedAt.IsZero() || endedAt.Before(startedAt) {
+		return importer.Workout{}, false
+	}
+
+	return importer.Workout{
+		ExternalID:     importer.ContentID(SourceFIT, fileKey, strconv.FormatUint(first, 10)),
+		Name:           importer.GenericExercise.Name,
+		Exercise:       importer.GenericExercise,
+		StartedAt:      startedAt,
+		EndedAt:        endedAt,
+		Duration:       endedAt.Sub(startedAt),
+		DistanceMeters: float64(distance) / 100,
+		AvgHeartRate:   hr.average(),
+		MaxHeartRate:   hr.max,
+		Start:          start,
+	}, true
+}
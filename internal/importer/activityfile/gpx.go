@@ -0,0 +1,127 @@
+package activityfile
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"fitness-hack/internal/importer"
+)
+
+type gpxPoint struct {
+	Lat  float64 `xml:"lat,attr"`
+	Lon  float64 `xml:"lon,attr"`
+	Time string  `xml:"time"`
+	// Garmin's TrackPointExtension, written by most devices and apps that record heart rate
+	HeartRate int `xml:"extensions>TrackPointExtension>hr"`
+}
+
+type gpxTrack struct {
+	Name     string `xml:"name"`
+	Type     string `xml:"type"`
+	Segments []struct {
+		Points []gpxPoint `xml:"trkpt"`
+	} `xml:"trkseg"`
+}
+
+type gpxDocument struct {
+	XMLName xml.Name   `xml:"gpx"`
+	Tracks  []gpxTrack `xml:"trk"`
+}
+
+// earthRadiusMeters is the mean radius used for distances between track points
+const earthRadiusMeters = 6371008.8
+
+// haversine returns the distance in meters between two coordinates
+func haversine(lat1, lon1, lat2, lon2 float64) float64 {
+	toRad := math.Pi / 180
+	dLat := (lat2 - lat1) * toRad
+	dLon := (lon2 - lon1) * toRad
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*toRad)*math.Cos(lat2*toRad)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusMeters * math.Asin(math.Sqrt(a))
+}
+
+// ParseGPX reads a GPX file; each track in it becomes a workout. GPX has no summary data,
+// so the distance is measured along the track and the duration spans its first to last point.
+func ParseGPX(r io.Reader) (*importer.HealthData, error) {
+	var doc gpxDocument
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid GPX file: %w", err)
+	}
+	if len(doc.Tracks) == 0 {
+		return nil, errors.New("GPX file contains no track")
+	}
+
+	data := &importer.HealthData{Source: SourceGPX}
+	for i := range doc.Tracks {
+		if w, ok := gpxToWorkout(&doc.Tracks[i]); ok {
+			data.Workouts = append(data.Workouts, w)
+		} else {
+			data.Skipped++
+		}
+	}
+	return data, nil
+}
+
+func gpxToWorkout(track *gpxTrack) (importer.Workout, bool) {
+	var startedAt, endedAt time.Time
+	var distance float64
+	var hr heartRateSummary
+	var start *importer.Position
+
+	for _, segment := range track.Segments {
+		// Segments are recorded separately, e.g. around a pause, so distance isn't
+		// measured across the gap between them
+		for i, point := range segment.Points {
+			if i > 0 {
+				prev := segment.Points[i-1]
+				distance += haversine(prev.Lat, prev.Lon, point.Lat, point.Lon)
+			}
+			hr.add(point.HeartRate)
+			if start == nil && (point.Lat != 0 || point.Lon != 0) {
+				start = &importer.Position{Latitude: point.Lat, Longitude: point.Lon}
+			}
+
+			at, err := time.Parse(time.RFC3339, point.Time)
+			if err != nil {
+				continue
+			}
+			if startedAt.IsZero() || at.Before(startedAt) {
+				startedAt = at
+			}
+			if at.After(endedAt) {
+				endedAt = at
+			}
+		}
+	}
+	if startedAt.IsZero() {
+		// Routes drawn in a planner have no timestamps and are not activities
+		return importer.Workout{}, false
+	}
+
+	exercise := exerciseForSport(track.Type)
+	name := track.Name
+	if runes := []rune(name); len(runes) > 255 {
+		name = string(runes[:255])
+	}
+	if name == "" {
+		name = exercise.Name
+	}
+
+	return importer.Workout{
+		ExternalID:     importer.ContentID(SourceGPX, track.Type, startedAt.UTC().Format(time.RFC3339), endedAt.UTC().Format(time.RFC3339)),
+		Name:           name,
+		Exercise:       exercise,
+		StartedAt:      startedAt,
+		EndedAt:        endedAt,
+		Duration:       endedAt.Sub(startedAt),
+		DistanceMeters: math.Round(distance*10) / 10,
+		AvgHeartRate:   hr.average(),
+		MaxHeartRate:   hr.max,
+		Start:          start,
+	}, true
+}
@@ -0,0 +1,140 @@
+package activityfile
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"fitness-hack/internal/importer"
+)
+
+type tcxValue struct {
+	Value int `xml:"Value"`
+}
+
+type tcxTrackpoint struct {
+	Time           string    `xml:"Time"`
+	DistanceMeters float64   `xml:"DistanceMeters"`
+	HeartRate      *tcxValue `xml:"HeartRateBpm"`
+	Position       *struct {
+		Latitude  float64 `xml:"LatitudeDegrees"`
+		Longitude float64 `xml:"LongitudeDegrees"`
+	} `xml:"Position"`
+}
+
+type tcxLap struct {
+	StartTime        string          `xml:"StartTime,attr"`
+	TotalTimeSeconds float64         `xml:"TotalTimeSeconds"`
+	DistanceMeters   float64         `xml:"DistanceMeters"`
+	AverageHeartRate *tcxValue       `xml:"AverageHeartRateBpm"`
+	MaximumHeartRate *tcxValue       `xml:"MaximumHeartRateBpm"`
+	Trackpoints      []tcxTrackpoint `xml:"Track>Trackpoint"`
+}
+
+type tcxActivity struct {
+	Sport string   `xml:"Sport,attr"`
+	ID    string   `xml:"Id"`
+	Laps  []tcxLap `xml:"Lap"`
+}
+
+type tcxDatabase struct {
+	XMLName    xml.Name      `xml:"TrainingCenterDatabase"`
+	Activities []tcxActivity `xml:"Activities>Activity"`
+}
+
+// ParseTCX reads a Training Center XML file; each activity in it becomes a workout
+func ParseTCX(r io.Reader) (*importer.HealthData, error) {
+	var doc tcxDatabase
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid TCX file: %w", err)
+	}
+	if len(doc.Activities) == 0 {
+		return nil, errors.New("TCX file contains no activity")
+	}
+
+	data := &importer.HealthData{Source: SourceTCX}
+	for i := range doc.Activities {
+		if w, ok := tcxToWorkout(&doc.Activities[i]); ok {
+			data.Workouts = append(data.Workouts, w)
+		} else {
+			data.Skipped++
+		}
+	}
+	return data, nil
+}
+
+func tcxToWorkout(activity *tcxActivity) (importer.Workout, bool) {
+	// The activity ID is its start time
+	startedAt, err := time.Parse(time.RFC3339, activity.ID)
+	if err != nil || len(activity.Laps) == 0 {
+		return importer.Workout{}, false
+	}
+
+	var duration, distance, trackDistance float64
+	var lapHRWeighted, lapHRSeconds float64
+	var lapMaxHR int
+	var hr heartRateSummary
+	var start *importer.Position
+	endedAt := startedAt
+
+	for _, lap := range activity.Laps {
+		duration += lap.TotalTimeSeconds
+		distance += lap.DistanceMeters
+		if lapStart, err := time.Parse(time.RFC3339, lap.StartTime); err == nil {
+			if lapEnd := lapStart.Add(time.Duration(lap.TotalTimeSeconds * float64(time.Second))); lapEnd.After(endedAt) {
+				endedAt = lapEnd
+			}
+		}
+		if lap.AverageHeartRate != nil && lap.AverageHeartRate.Value > 0 {
+			lapHRWeighted += float64(lap.AverageHeartRate.Value) * lap.TotalTimeSeconds
+			lapHRSeconds += lap.TotalTimeSeconds
+		}
+		if lap.MaximumHeartRate != nil && lap.MaximumHeartRate.Value > lapMaxHR {
+			lapMaxHR = lap.MaximumHeartRate.Value
+		}
+
+		for _, point := range lap.Trackpoints {
+			if at, err := time.Parse(time.RFC3339, point.Time); err == nil && at.After(endedAt) {
+				endedAt = at
+			}
+			trackDistance = math.Max(trackDistance, point.DistanceMeters)
+			if point.HeartRate != nil {
+				hr.add(point.HeartRate.Value)
+			}
+			if start == nil && point.Position != nil {
+				start = &importer.Position{Latitude: point.Position.Latitude, Longitude: point.Position.Longitude}
+			}
+		}
+	}
+
+	exercise := exerciseForSport(activity.Sport)
+	w := importer.Workout{
+		ExternalID:     importer.ContentID(SourceTCX, activity.Sport, activity.ID),
+		Name:           exercise.Name,
+		Exercise:       exercise,
+		StartedAt:      startedAt,
+		EndedAt:        endedAt,
+		Duration:       time.Duration(duration * float64(time.Second)),
+		DistanceMeters: distance,
+		AvgHeartRate:   hr.average(),
+		MaxHeartRate:   hr.max,
+		Start:          start,
+	}
+	if w.Duration == 0 {
+		w.Duration = endedAt.Sub(startedAt)
+	}
+	if w.DistanceMeters == 0 {
+		w.DistanceMeters = trackDistance
+	}
+	// Prefer the samples; fall back to the lap summaries for files without them
+	if hr.count == 0 {
+		if lapHRSeconds > 0 {
+			w.AvgHeartRate = int(math.Round(lapHRWeighted / lapHRSeconds))
+		}
+		w.MaxHeartRate = lapMaxHR
+	}
+	return w, true
+}
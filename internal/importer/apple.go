@@ -0,0 +1,231 @@
+package importer
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// appleDateLayout is the timestamp format used throughout export.xml
+const appleDateLayout = "2006-01-02 15:04:05 -0700"
+
+var appleExercises = map[string]Exercise{
+	"HKWorkoutActivityTypeRunning":                       {Name: "Running", MuscleGroup: "Cardio", Equipment: "None"},
+	"HKWorkoutActivityTypeWalking":                       {Name: "Walking", MuscleGroup: "Cardio", Equipment: "None"},
+	"HKWorkoutActivityTypeHiking":                        {Name: "Hiking", MuscleGroup: "Cardio", Equipment: "None"},
+	"HKWorkoutActivityTypeCycling":                       {Name: "Cycling", MuscleGroup: "Cardio", Equipment: "Bicycle"},
+	"HKWorkoutActivityTypeSwimming":                      {Name: "Swimming", MuscleGroup: "Full Body", Equipment: "None"},
+	"HKWorkoutActivityTypeRowing":                        {Name: "Rowing", MuscleGroup: "Full Body", Equipment: "None"},
+	"HKWorkoutActivityTypeElliptical":                    {Name: "Elliptical", MuscleGroup: "Cardio", Equipment: "Elliptical"},
+	"HKWorkoutActivityTypeStairClimbing":                 {Name: "Stair Climber", MuscleGroup: "Legs", Equipment: "Stair Climber"},
+	"HKWorkoutActivityTypeTraditionalStrengthTraining":   {Name: "Weight Training", MuscleGroup: "Full Body", Equipment: "Weights"},
+	"HKWorkoutActivityTypeFunctionalStrengthTraining":    {Name: "Functional Training", MuscleGroup: "Full Body", Equipment: "Various"},
+	"HKWorkoutActivityTypeHighIntensityIntervalTraining": {Name: "HIIT", MuscleGroup: "Full Body", Equipment: "None"},
+	"HKWorkoutActivityTypeCrossTraining":                 {Name: "CrossFit", MuscleGroup: "Full Body", Equipment: "Various"},
+	"HKWorkoutActivityTypeCoreTraining":                  {Name: "Core Training", MuscleGroup: "Core", Equipment: "Mat"},
+	"HKWorkoutActivityTypeYoga":                          {Name: "Yoga", MuscleGroup: "Full Body", Equipment: "Mat"},
+	"HKWorkoutActivityTypePilates":                       {Name: "Pilates", MuscleGroup: "Core", Equipment: "Mat"},
+}
+
+// appleMetrics maps HealthKit quantity types to metric kinds
+var appleMetrics = map[string]string{
+	"HKQuantityTypeIdentifierBodyMass":          MetricWeight,
+	"HKQuantityTypeIdentifierBodyFatPercentage": MetricBodyFat,
+	"HKQuantityTypeIdentifierHeight":            MetricHeight,
+	"HKQuantityTypeIdentifierLeanBodyMass":      MetricLeanBodyMass,
+	"HKQuantityTypeIdentifierRestingHeartRate":  MetricRestingHeartRate,
+}
+
+type appleRecord struct {
+	Type      string `xml:"type,attr"`
+	Unit      string `xml:"unit,attr"`
+	Value     string `xml:"value,attr"`
+	StartDate string `xml:"startDate,attr"`
+}
+
+type appleWorkout struct {
+	ActivityType      string `xml:"workoutActivityType,attr"`
+	Duration          string `xml:"duration,attr"`
+	DurationUnit      string `xml:"durationUnit,attr"`
+	TotalDistance     string `xml:"totalDistance,attr"`
+	TotalDistanceUnit string `xml:"totalDistanceUnit,attr"`
+	SourceName        string `xml:"sourceName,attr"`
+	StartDate         string `xml:"startDate,attr"`
+	EndDate           string `xml:"endDate,attr"`
+	Statistics        []struct {
+		Type string `xml:"type,attr"`
+		Sum  string `xml:"sum,attr"`
+		Unit string `xml:"unit,attr"`
+	} `xml:"WorkoutStatistics"`
+}
+
+// ParseAppleHealth reads an Apple Health export.xml. The export is streamed, since
+// it usually holds millions of samples; only workouts and body measurements are kept.
+func ParseAppleHealth(r io.Reader) (*HealthData, error) {
+	data := &HealthData{Source: SourceAppleHealth}
+	decoder := xml.NewDecoder(r)
+	sawRoot := false
+
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid Apple Health export: %w", err)
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch start.Name.Local {
+		case "HealthData":
+			sawRoot = true
+		case "Record":
+			var record appleRecord
+			if err := decoder.DecodeElement(&record, &start); err != nil {
+				return nil, fmt.Errorf("invalid Apple Health export: %w", err)
+			}
+			kind, ok := appleMetrics[record.Type]
+			if !ok {
+				continue
+			}
+			if metric, ok := appleMetric(kind, &record); ok {
+				data.Metrics = append(data.Metrics, metric)
+			} else {
+				data.Skipped++
+			}
+		case "Workout":
+			var workout appleWorkout
+			if err := decoder.DecodeElement(&workout, &start); err != nil {
+				return nil, fmt.Errorf("invalid Apple Health export: %w", err)
+			}
+			if w, ok := appleToWorkout(&workout); ok {
+				data.Workouts = append(data.Workouts, w)
+			} else {
+				data.Skipped++
+			}
+		}
+	}
+
+	if !sawRoot {
+		return nil, errors.New("invalid Apple Health export: missing HealthData element")
+	}
+	return data, nil
+}
+
+func appleMetric(kind string, record *appleRecord) (Metric, bool) {
+	recordedAt, err := time.Parse(appleDateLayout, record.StartDate)
+	if err != nil {
+		return Metric{}, false
+	}
+	value, err := strconv.ParseFloat(record.Value, 64)
+	if err != nil {
+		return Metric{}, false
+	}
+
+	switch kind {
+	case MetricWeight, MetricLeanBodyMass:
+		value, ok := toKilograms(value, record.Unit)
+		return Metric{Kind: kind, Value: value, RecordedAt: recordedAt}, ok
+	case MetricHeight:
+		value, ok := toMeters(value, record.Unit)
+		return Metric{Kind: kind, Value: value * 100, RecordedAt: recordedAt}, ok
+	case MetricBodyFat:
+		// HealthKit stores percentages as fractions
+		return Metric{Kind: kind, Value: value * 100, RecordedAt: recordedAt}, record.Unit == "%"
+	default:
+		return Metric{Kind: kind, Value: value, RecordedAt: recordedAt}, true
+	}
+}
+
+func appleToWorkout(workout *appleWorkout) (Workout, bool) {
+	startedAt, err := time.Parse(appleDateLayout, workout.StartDate)
+	if err != nil {
+		return Workout{}, false
+	}
+	endedAt, err := time.Parse(appleDateLayout, workout.EndDate)
+	if err != nil || endedAt.Before(startedAt) {
+		return Workout{}, false
+	}
+
+	exercise, ok := appleExercises[workout.ActivityType]
+	if !ok {
+		exercise = GenericExercise
+	}
+
+	w := Workout{
+		// Workouts have no identifier in the export
+		ExternalID: ContentID(workout.ActivityType, workout.SourceName, workout.StartDate, workout.EndDate),
+		Name:       exercise.Name,
+		Exercise:   exercise,
+		StartedAt:  startedAt,
+		EndedAt:    endedAt,
+		Duration:   endedAt.Sub(startedAt),
+	}
+
+	if value, err := strconv.ParseFloat(workout.Duration, 64); err == nil {
+		switch workout.DurationUnit {
+		case "min":
+			w.Duration = time.Duration(value * float64(time.Minute))
+		case "s":
+			w.Duration = time.Duration(value * float64(time.Second))
+		case "hr":
+			w.Duration = time.Duration(value * float64(time.Hour))
+		}
+	}
+
+	// Older exports carry the distance as an attribute, newer ones as a statistic
+	if value, err := strconv.ParseFloat(workout.TotalDistance, 64); err == nil {
+		w.DistanceMeters, _ = toMeters(value, workout.TotalDistanceUnit)
+	}
+	for _, stat := range workout.Statistics {
+		if !strings.HasPrefix(stat.Type, "HKQuantityTypeIdentifierDistance") {
+			continue
+		}
+		if value, err := strconv.ParseFloat(stat.Sum, 64); err == nil {
+			w.DistanceMeters, _ = toMeters(value, stat.Unit)
+		}
+	}
+
+	return w, true
+}
+
+func toKilograms(value float64, unit string) (float64, bool) {
+	switch unit {
+	case "kg":
+		return value, true
+	case "g":
+		return value / 1000, true
+	case "lb":
+		return value * 0.45359237, true
+	case "st":
+		return value * 6.35029318, true
+	}
+	return 0, false
+}
+
+func toMeters(value float64, unit string) (float64, bool) {
+	switch unit {
+	case "m":
+		return value, true
+	case "km":
+		return value * 1000, true
+	case "cm":
+		return value / 100, true
+	case "mi":
+		return value * 1609.344, true
+	case "yd":
+		return value * 0.9144, true
+	case "ft":
+		return value * 0.3048, true
+	case "in":
+		return value * 0.0254, true
+	}
+	return 0, false
+}
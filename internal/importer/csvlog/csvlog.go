@@ -0,0 +1,410 @@
+// Package csvlog parses strength training logs exported as CSV, one row per set: Strong
+// and Hevy exports, and generic spreadsheets with at least a date and an exercise column.
+// Unlike the health exports, which are imported as they are, set logs name exercises in
+// the app's own words, so they are parsed into sessions for the user to review before
+// anything is stored.
+package csvlog
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"fitness-hack/internal/importer"
+)
+
+// Sources identify the app a log was exported from
+const (
+	SourceStrong = "strong"
+	SourceHevy   = "hevy"
+	SourceCSV    = "csv"
+)
+
+// Units of the weight column when the header doesn't say
+const (
+	UnitKg = "kg"
+	UnitLb = "lb"
+)
+
+// MaxRows bounds how many sets one log may contain
+const MaxRows = 10000
+
+const kgPerLb = 0.45359237
+
+// maxWeightKg is the heaviest load a set can store
+const maxWeightKg = 999.99
+
+var (
+	// ErrEmpty is returned for logs with no rows below the header
+	ErrEmpty = errors.New("the CSV file has no rows")
+	// ErrTooManyRows is returned for logs with more than MaxRows sets
+	ErrTooManyRows = fmt.Errorf("the CSV file has more than %d rows", MaxRows)
+)
+
+// Options say how to read values the log leaves ambiguous
+type Options struct {
+	// Unit is the unit of a "weight" column, UnitKg when empty. Columns named for their
+	// unit, like Hevy's weight_lbs, ignore it.
+	Unit string
+	// Location is the time zone of dates without an offset, UTC when nil
+	Location *time.Location
+}
+
+// Set is a single set of an exercise
+type Set struct {
+	// Row is the line of the file the set was read from, for pointing at it in a review
+	Row             int
+	Exercise        string
+	SetNumber       int
+	Reps            int
+	WeightKg        float64
+	DurationSeconds int
+	// RPE is 0 when not recorded
+	RPE float64
+}
+
+// Session is a workout of one or more sets
+type Session struct {
+	// ExternalID is derived from the source, start and name, so re-importing the same
+	// export is recognized
+	ExternalID string
+	Name       string
+	StartedAt  time.Time
+	EndedAt    time.Time
+	Duration   time.Duration
+	Sets       []Set
+}
+
+// Issue is a row that couldn't be read, and why
+type Issue struct {
+	Row     int
+	Message string
+}
+
+// Log is the result of parsing an export
+type Log struct {
+	Source   string
+	Rows     int
+	Sessions []Session
+	// Issues lists the rows left out of Sessions
+	Issues []Issue
+}
+
+// Exercises returns the distinct exercise names of the log in the order they first appear
+func (l *Log) Exercises() []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, session := range l.Sessions {
+		for _, set := range session.Sets {
+			if !seen[set.Exercise] {
+				seen[set.Exercise] = true
+				names = append(names, set.Exercise)
+			}
+		}
+	}
+	return names
+}
+
+// column names a field may have, normalized by normalizeHeader
+var columnAliases = map[string][]string{
+	"date":     {"date", "start_time", "started_at", "workout_date"},
+	"end":      {"end_time", "ended_at"},
+	"workout":  {"workout_name", "title", "workout"},
+	"exercise": {"exercise_name", "exercise_title", "exercise"},
+	"set":      {"set_order", "set_index", "set", "set_number"},
+	"weight":   {"weight", "weight_kg", "weight_lbs", "weight_lb"},
+	"reps":     {"reps", "repetitions"},
+	"seconds":  {"seconds", "duration_seconds"},
+	"rpe":      {"rpe"},
+	"duration": {"duration"},
+}
+
+// dateLayouts are tried in order for dates without an offset
+var dateLayouts = []string{
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2 Jan 2006, 15:04",
+	"Jan 2, 2006, 3:04 PM",
+	"2006-01-02",
+}
+
+func normalizeHeader(name string) string {
+	name = strings.TrimPrefix(name, "\ufeff")
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(name)), " ", "_")
+}
+
+// layout maps fields to their column, or -1 when the export has none
+type layout struct {
+	columns  map[string]int
+	weightKg float64
+}
+
+func newLayout(header []string, unit string) (*layout, string, error) {
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		if _, ok := index[normalizeHeader(name)]; !ok {
+			index[normalizeHeader(name)] = i
+		}
+	}
+
+	switch unit {
+	case "", UnitKg:
+		unit = UnitKg
+	case UnitLb, "lbs":
+		unit = UnitLb
+	default:
+		return nil, "", fmt.Errorf("unknown weight unit %q, expected kg or lb", unit)
+	}
+
+	l := &layout{columns: make(map[string]int)}
+	for field, aliases := range columnAliases {
+		l.columns[field] = -1
+		for _, alias := range aliases {
+			if i, ok := index[alias]; ok {
+				l.columns[field] = i
+				if field == "weight" && alias != "weight" {
+					unit = strings.TrimSuffix(strings.TrimPrefix(alias, "weight_"), "s")
+				}
+				break
+			}
+		}
+	}
+	if l.columns["date"] < 0 || l.columns["exercise"] < 0 {
+		return nil, "", errors.New("unrecognized CSV file, expected a Strong or Hevy export or a header with date and exercise columns")
+	}
+	l.weightKg = 1
+	if unit == UnitLb {
+		l.weightKg = kgPerLb
+	}
+
+	source := SourceCSV
+	switch {
+	case has(index, "exercise_title") && has(index, "start_time"):
+		source = SourceHevy
+	case has(index, "exercise_name") && has(index, "set_order"):
+		source = SourceStrong
+	}
+	return l, source, nil
+}
+
+func has(index map[string]int, name string) bool {
+	_, ok := index[name]
+	return ok
+}
+
+func (l *layout) value(record []string, field string) string {
+	i := l.columns[field]
+	if i < 0 || i >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[i])
+}
+
+// Parse reads a CSV set log. Rows that can't be read, such as ones with an unreadable date
+// or a negative weight, are left out and reported in Issues rather than failing the log.
+func Parse(data []byte, opts Options) (*Log, error) {
+	loc := opts.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	// Strong exports use semicolons in locales with decimal commas
+	if firstLine, _, _ := bytes.Cut(data, []byte("\n")); bytes.Count(firstLine, []byte(";")) > bytes.Count(firstLine, []byte(",")) {
+		r.Comma = ';'
+	}
+
+	header, err := r.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, ErrEmpty
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	cols, source, err := newLayout(header, opts.Unit)
+	if err != nil {
+		return nil, err
+	}
+
+	log := &Log{Source: source}
+	sessions := make(map[string]int)
+	// Exercise names are matched case-insensitively, keeping the first spelling seen
+	spellings := make(map[string]string)
+	setNumbers := make(map[string]int)
+
+	for {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV: %w", err)
+		}
+		row, _ := r.FieldPos(0)
+		if isBlank(record) {
+			continue
+		}
+		log.Rows++
+		if log.Rows > MaxRows {
+			return nil, ErrTooManyRows
+		}
+		// Strong records rest timers as rows of their own
+		if strings.EqualFold(cols.value(record, "set"), "rest timer") {
+			continue
+		}
+
+		set, started, err := cols.set(record, loc)
+		if err != nil {
+			log.Issues = append(log.Issues, Issue{Row: row, Message: err.Error()})
+			continue
+		}
+		set.Row = row
+		key := strings.ToLower(set.Exercise)
+		if spelling, ok := spellings[key]; ok {
+			set.Exercise = spelling
+		} else {
+			spellings[key] = set.Exercise
+		}
+
+		name := cols.value(record, "workout")
+		if name == "" {
+			name = "Workout"
+		}
+		sessionKey := started.Format(time.RFC3339) + "\x00" + name
+		i, ok := sessions[sessionKey]
+		if !ok {
+			i = len(log.Sessions)
+			sessions[sessionKey] = i
+			session := Session{
+				ExternalID: importer.ContentID(source, started.UTC().Format(time.RFC3339), name),
+				Name:       name,
+				StartedAt:  started,
+				EndedAt:    started,
+			}
+			if ended, err := parseDate(cols.value(record, "end"), loc); err == nil && ended.After(started) {
+				session.EndedAt = ended
+				session.Duration = ended.Sub(started)
+			} else if duration, err := parseDuration(cols.value(record, "duration")); err == nil && duration > 0 {
+				session.EndedAt = started.Add(duration)
+				session.Duration = duration
+			}
+			log.Sessions = append(log.Sessions, session)
+		}
+
+		setNumbers[sessionKey+"\x00"+key]++
+		set.SetNumber = setNumbers[sessionKey+"\x00"+key]
+		log.Sessions[i].Sets = append(log.Sessions[i].Sets, set)
+	}
+
+	if log.Rows == 0 {
+		return nil, ErrEmpty
+	}
+	return log, nil
+}
+
+// set reads the set of a row and the start of its session
+func (l *layout) set(record []string, loc *time.Location) (Set, time.Time, error) {
+	var set Set
+	started, err := parseDate(l.value(record, "date"), loc)
+	if err != nil {
+		return set, started, err
+	}
+	set.Exercise = strings.Join(strings.Fields(l.value(record, "exercise")), " ")
+	if set.Exercise == "" {
+		return set, started, errors.New("missing exercise name")
+	}
+
+	if set.Reps, err = parseCount(l.value(record, "reps"), "reps"); err != nil {
+		return set, started, err
+	}
+	if set.DurationSeconds, err = parseCount(l.value(record, "seconds"), "seconds"); err != nil {
+		return set, started, err
+	}
+	weight, err := parseNumber(l.value(record, "weight"), "weight")
+	if err != nil {
+		return set, started, err
+	}
+	set.WeightKg = math.Round(weight*l.weightKg*100) / 100
+	if set.WeightKg > maxWeightKg {
+		return set, started, fmt.Errorf("weight %v kg is more than the %v kg a set can record", set.WeightKg, maxWeightKg)
+	}
+	if set.RPE, err = parseNumber(l.value(record, "rpe"), "RPE"); err != nil {
+		return set, started, err
+	}
+	if set.RPE != 0 && (set.RPE < 1 || set.RPE > 10) {
+		return set, started, fmt.Errorf("RPE %v is outside 1 to 10", set.RPE)
+	}
+	if set.Reps == 0 && set.DurationSeconds == 0 {
+		return set, started, errors.New("set has neither reps nor a duration")
+	}
+	return set, started, nil
+}
+
+func parseDate(value string, loc *time.Location) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, errors.New("missing date")
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	for _, layout := range dateLayouts {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unreadable date %q", value)
+}
+
+// parseDuration reads durations written like Strong's "1h 5m", or a number of seconds
+func parseDuration(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, errors.New("missing duration")
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second, nil
+	}
+	return time.ParseDuration(strings.ReplaceAll(value, " ", ""))
+}
+
+// parseNumber reads a non-negative decimal, accepting a decimal comma; empty is 0
+func parseNumber(value, field string) (float64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	n, err := strconv.ParseFloat(strings.Replace(value, ",", ".", 1), 64)
+	if err != nil || n < 0 || math.IsNaN(n) || math.IsInf(n, 0) {
+		return 0, fmt.Errorf("unreadable %s %q", field, value)
+	}
+	return n, nil
+}
+
+// parseCount reads a non-negative whole number; empty is 0
+func parseCount(value, field string) (int, error) {
+	n, err := parseNumber(value, field)
+	if err != nil {
+		return 0, err
+	}
+	if n != math.Trunc(n) || n > math.MaxInt32 {
+		return 0, fmt.Errorf("unreadable %s %q", field, value)
+	}
+	return int(n), nil
+}
+
+func isBlank(record []string) bool {
+	for _, field := range record {
+		if strings.TrimSpace(field) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,101 @@
+package csvlog
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const strongExport = `Date,Workout Name,Duration,Exercise Name,Set Order,Weight,Reps,Distance,Seconds,Notes,Workout Notes,RPE
+2025-08-05 07:30:00,Push Day,1h 5m,Bench Press (Barbell),1,60,8,0,0,,,
+2025-08-05 07:30:00,Push Day,1h 5m,Bench Press (Barbell),Rest Timer,0,0,0,90,,,
+2025-08-05 07:30:00,Push Day,1h 5m,bench press (barbell),2,62.5,6,0,0,,,8.5
+2025-08-05 07:30:00,Push Day,1h 5m,Plank,1,0,0,0,60,,,
+2025-08-05 07:30:00,Push Day,1h 5m,Dips,1,-5,10,0,0,,,
+2025-08-07 18:00:00,Pull Day,45m,Deadlift (Barbell),1,140,5,0,0,,,12
+`
+
+func TestParseStrong(t *testing.T) {
+	loc := time.FixedZone("UTC+2", 2*60*60)
+	log, err := Parse([]byte(strongExport), Options{Location: loc})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if log.Source != SourceStrong || log.Rows != 6 {
+		t.Errorf("expected 6 Strong rows, got %d %s rows", log.Rows, log.Source)
+	}
+	if len(log.Sessions) != 1 {
+		t.Fatalf("expected the pull day, whose only set has an RPE of 12, to be left out, got %d sessions", len(log.Sessions))
+	}
+
+	push := log.Sessions[0]
+	if push.Name != "Push Day" || !push.StartedAt.Equal(time.Date(2025, 8, 5, 5, 30, 0, 0, time.UTC)) || push.Duration != 65*time.Minute {
+		t.Errorf("unexpected session %+v", push)
+	}
+	if len(push.Sets) != 3 {
+		t.Fatalf("expected 3 sets, got %+v", push.Sets)
+	}
+	second := push.Sets[1]
+	if second.Exercise != "Bench Press (Barbell)" || second.SetNumber != 2 || second.WeightKg != 62.5 || second.RPE != 8.5 || second.Row != 4 {
+		t.Errorf("expected the second bench set under the first spelling, got %+v", second)
+	}
+	if plank := push.Sets[2]; plank.DurationSeconds != 60 || plank.SetNumber != 1 {
+		t.Errorf("expected a timed plank set, got %+v", plank)
+	}
+
+	if len(log.Issues) != 2 || log.Issues[0].Row != 6 || !strings.Contains(log.Issues[0].Message, "weight") || log.Issues[1].Row != 7 {
+		t.Errorf("expected the negative weight and RPE rows as issues, got %+v", log.Issues)
+	}
+	if got := strings.Join(log.Exercises(), "|"); got != "Bench Press (Barbell)|Plank" {
+		t.Errorf("unexpected exercises %q", got)
+	}
+
+	again, _ := Parse([]byte(strongExport), Options{Location: loc})
+	if again.Sessions[0].ExternalID != push.ExternalID {
+		t.Error("expected the same export to get the same session IDs")
+	}
+}
+
+func TestParseHevy(t *testing.T) {
+	export := `"title","start_time","end_time","description","exercise_title","superset_id","exercise_notes","set_index","set_type","weight_lbs","reps","distance_miles","duration_seconds","rpe"
+"Legs","5 Aug 2025, 07:30","5 Aug 2025, 08:20","","Squat (Barbell)",,"",0,"normal",225,5,,,
+`
+	log, err := Parse([]byte(export), Options{Unit: UnitKg})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if log.Source != SourceHevy || len(log.Sessions) != 1 {
+		t.Fatalf("expected one Hevy session, got %+v", log)
+	}
+	session := log.Sessions[0]
+	if session.Duration != 50*time.Minute {
+		t.Errorf("expected the duration from the end time, got %v", session.Duration)
+	}
+	if set := session.Sets[0]; set.WeightKg != 102.06 || set.Reps != 5 {
+		t.Errorf("expected the weight_lbs column converted to kg, got %+v", set)
+	}
+}
+
+func TestParseGeneric(t *testing.T) {
+	export := "date;exercise;weight;reps\n2025-08-05;Curl;30;12\n;;;\n"
+	log, err := Parse([]byte(export), Options{Unit: UnitLb})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if log.Source != SourceCSV || log.Rows != 1 || log.Sessions[0].Name != "Workout" || log.Sessions[0].Sets[0].WeightKg != 13.61 {
+		t.Errorf("unexpected log %+v", log)
+	}
+
+	for export, want := range map[string]string{
+		"":                          "no rows",
+		"date,exercise\n":           "no rows",
+		"day,lift\n2025-08-05,Curl": "unrecognized",
+	} {
+		if _, err := Parse([]byte(export), Options{}); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%q: expected an error containing %q, got %v", export, want, err)
+		}
+	}
+	if _, err := Parse([]byte(export), Options{Unit: "stone"}); err == nil {
+		t.Error("expected an unknown unit to be rejected")
+	}
+}
@@ -0,0 +1,134 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// googleFitExercises maps Google Fit activity types to exercises
+// (https://developers.google.com/fit/rest/v1/reference/activity-types)
+var googleFitExercises = map[int64]Exercise{
+	1:   {Name: "Cycling", MuscleGroup: "Cardio", Equipment: "Bicycle"},
+	7:   {Name: "Walking", MuscleGroup: "Cardio", Equipment: "None"},
+	8:   {Name: "Running", MuscleGroup: "Cardio", Equipment: "None"},
+	9:   {Name: "Aerobics", MuscleGroup: "Cardio", Equipment: "None"},
+	25:  {Name: "Elliptical", MuscleGroup: "Cardio", Equipment: "Elliptical"},
+	35:  {Name: "Hiking", MuscleGroup: "Cardio", Equipment: "None"},
+	56:  {Name: "Running", MuscleGroup: "Cardio", Equipment: "None"},
+	58:  {Name: "Treadmill Running", MuscleGroup: "Cardio", Equipment: "Treadmill"},
+	80:  {Name: "Weight Training", MuscleGroup: "Full Body", Equipment: "Weights"},
+	82:  {Name: "Swimming", MuscleGroup: "Full Body", Equipment: "None"},
+	100: {Name: "Yoga", MuscleGroup: "Full Body", Equipment: "Mat"},
+	113: {Name: "CrossFit", MuscleGroup: "Full Body", Equipment: "Various"},
+	114: {Name: "HIIT", MuscleGroup: "Full Body", Equipment: "None"},
+}
+
+// googleFitMetrics maps Google Fit data types to metric kinds
+var googleFitMetrics = map[string]string{
+	"com.google.weight":              MetricWeight,
+	"com.google.body.fat.percentage": MetricBodyFat,
+	"com.google.height":              MetricHeight,
+}
+
+// fitInt64 is an int64 that the Fitness REST API encodes as a JSON string
+type fitInt64 int64
+
+func (n *fitInt64) UnmarshalJSON(data []byte) error {
+	v, err := strconv.ParseInt(string(bytes.Trim(data, `"`)), 10, 64)
+	if err != nil {
+		return err
+	}
+	*n = fitInt64(v)
+	return nil
+}
+
+type googleFitSession struct {
+	ID               string   `json:"id"`
+	Name             string   `json:"name"`
+	StartTimeMillis  fitInt64 `json:"startTimeMillis"`
+	EndTimeMillis    fitInt64 `json:"endTimeMillis"`
+	ActiveTimeMillis fitInt64 `json:"activeTimeMillis"`
+	ActivityType     int64    `json:"activityType"`
+}
+
+type googleFitPoint struct {
+	DataTypeName   string   `json:"dataTypeName"`
+	StartTimeNanos fitInt64 `json:"startTimeNanos"`
+	Value          []struct {
+		FpVal *float64 `json:"fpVal"`
+	} `json:"value"`
+}
+
+// googleFitExport combines the response bodies of users.sessions.list ("session") and
+// users.dataSources.datasets.get ("point"); either may be sent on its own
+type googleFitExport struct {
+	Session []googleFitSession `json:"session"`
+	Point   []googleFitPoint   `json:"point"`
+}
+
+// ParseGoogleFit reads sessions and body measurements in the Google Fitness REST API format
+func ParseGoogleFit(r io.Reader) (*HealthData, error) {
+	var export googleFitExport
+	if err := json.NewDecoder(r).Decode(&export); err != nil {
+		return nil, fmt.Errorf("invalid Google Fit export: %w", err)
+	}
+	if export.Session == nil && export.Point == nil {
+		return nil, errors.New("invalid Google Fit export: expected session or point data")
+	}
+
+	data := &HealthData{Source: SourceGoogleFit}
+	for _, session := range export.Session {
+		if session.ID == "" || session.EndTimeMillis < session.StartTimeMillis || session.StartTimeMillis == 0 {
+			data.Skipped++
+			continue
+		}
+		exercise, ok := googleFitExercises[session.ActivityType]
+		if !ok {
+			exercise = GenericExercise
+		}
+
+		w := Workout{
+			ExternalID: session.ID,
+			Name:       session.Name,
+			Exercise:   exercise,
+			StartedAt:  time.UnixMilli(int64(session.StartTimeMillis)).UTC(),
+			EndedAt:    time.UnixMilli(int64(session.EndTimeMillis)).UTC(),
+		}
+		if w.Name == "" {
+			w.Name = exercise.Name
+		}
+		w.Duration = w.EndedAt.Sub(w.StartedAt)
+		if session.ActiveTimeMillis > 0 {
+			w.Duration = time.Duration(session.ActiveTimeMillis) * time.Millisecond
+		}
+		data.Workouts = append(data.Workouts, w)
+	}
+
+	for _, point := range export.Point {
+		kind, ok := googleFitMetrics[point.DataTypeName]
+		if !ok {
+			continue
+		}
+		if len(point.Value) == 0 || point.Value[0].FpVal == nil || point.StartTimeNanos == 0 {
+			data.Skipped++
+			continue
+		}
+		value := *point.Value[0].FpVal
+		if kind == MetricHeight {
+			// Google Fit stores height in meters
+			value *= 100
+		}
+		data.Metrics = append(data.Metrics, Metric{
+			Kind:       kind,
+			Value:      value,
+			RecordedAt: time.Unix(0, int64(point.StartTimeNanos)).UTC(),
+		})
+	}
+
+	return data, nil
+}
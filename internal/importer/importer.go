@@ -0,0 +1,85 @@
+// Package importer parses workout and body measurement exports from other fitness apps
+// into a common form that can be stored as workout sessions and body metrics. Activity
+// files recorded by GPS watches and bike computers are parsed by the activityfile subpackage,
+// and set logs from strength training apps by the csvlog subpackage.
+package importer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+)
+
+// Sources identify where imported records came from
+const (
+	SourceAppleHealth = "apple_health"
+	SourceGoogleFit   = "google_fit"
+)
+
+// Body metric kinds, normalized to metric units
+const (
+	MetricWeight           = "weight_kg"
+	MetricBodyFat          = "body_fat_percent"
+	MetricHeight           = "height_cm"
+	MetricLeanBodyMass     = "lean_body_mass_kg"
+	MetricRestingHeartRate = "resting_heart_rate_bpm"
+)
+
+// Exercise is the catalog exercise an imported workout is recorded against
+type Exercise struct {
+	Name        string
+	MuscleGroup string
+	Equipment   string
+}
+
+// GenericExercise is used for activity types without a mapping
+var GenericExercise = Exercise{Name: "Workout", MuscleGroup: "Full Body", Equipment: "None"}
+
+// Position is a GPS coordinate in decimal degrees
+type Position struct {
+	Latitude  float64
+	Longitude float64
+}
+
+// Workout is a single imported workout
+type Workout struct {
+	// ExternalID identifies the workout at its source; exports without IDs get one derived
+	// from the workout's content so re-importing the same export is recognized
+	ExternalID string
+	Name       string
+	Exercise   Exercise
+	StartedAt  time.Time
+	EndedAt    time.Time
+	// Duration is the active time, which may be shorter than EndedAt - StartedAt
+	Duration       time.Duration
+	DistanceMeters float64
+	// AvgHeartRate and MaxHeartRate are in beats per minute, 0 when not recorded
+	AvgHeartRate int
+	MaxHeartRate int
+	// Start is where the workout began, nil when it wasn't recorded with GPS
+	Start *Position
+}
+
+// Metric is a single body measurement
+type Metric struct {
+	Kind       string
+	Value      float64
+	RecordedAt time.Time
+}
+
+// HealthData is the result of parsing an export
+type HealthData struct {
+	Source   string
+	Workouts []Workout
+	Metrics  []Metric
+	// Skipped counts workouts and measurements of a supported type that could not be read
+	Skipped int
+}
+
+// ContentID derives a stable identifier from the given fields, for sources whose
+// workouts carry no ID of their own
+func ContentID(fields ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(fields, "\x00")))
+	return hex.EncodeToString(sum[:16])
+}
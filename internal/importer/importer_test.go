@@ -0,0 +1,94 @@
+package importer
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+const appleExport = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE HealthData [
+<!ELEMENT HealthData (ExportDate,Me,(Record|Workout)*)>
+]>
+<HealthData locale="en_US">
+ <ExportDate value="2024-03-01 09:00:00 -0500"/>
+ <Record type="HKQuantityTypeIdentifierBodyMass" sourceName="Scale" unit="lb" value="180" startDate="2024-02-01 07:00:00 -0500" endDate="2024-02-01 07:00:00 -0500"/>
+ <Record type="HKQuantityTypeIdentifierBodyFatPercentage" sourceName="Scale" unit="%" value="0.18" startDate="2024-02-01 07:00:00 -0500" endDate="2024-02-01 07:00:00 -0500"/>
+ <Record type="HKQuantityTypeIdentifierHeartRate" sourceName="Watch" unit="count/min" value="62" startDate="2024-02-01 07:00:00 -0500" endDate="2024-02-01 07:00:00 -0500"/>
+ <Record type="HKQuantityTypeIdentifierBodyMass" sourceName="Scale" unit="lb" value="heavy" startDate="2024-02-02 07:00:00 -0500" endDate="2024-02-02 07:00:00 -0500"/>
+ <Workout workoutActivityType="HKWorkoutActivityTypeRunning" duration="30" durationUnit="min" sourceName="Watch" startDate="2024-02-01 18:00:00 -0500" endDate="2024-02-01 18:32:00 -0500">
+  <MetadataEntry key="HKIndoorWorkout" value="0"/>
+  <WorkoutStatistics type="HKQuantityTypeIdentifierDistanceWalkingRunning" startDate="2024-02-01 18:00:00 -0500" endDate="2024-02-01 18:32:00 -0500" sum="5.2" unit="km"/>
+ </Workout>
+ <Workout workoutActivityType="HKWorkoutActivityTypeKickboxing" duration="45" durationUnit="min" sourceName="Watch" startDate="2024-02-03 18:00:00 -0500" endDate="2024-02-03 18:45:00 -0500"/>
+</HealthData>`
+
+func TestParseAppleHealth(t *testing.T) {
+	data, err := ParseAppleHealth(strings.NewReader(appleExport))
+	if err != nil {
+		t.Fatalf("error parsing export. Err: %v", err)
+	}
+
+	if len(data.Metrics) != 2 || data.Skipped != 1 {
+		t.Fatalf("expected 2 metrics and 1 skipped record, got %d and %d", len(data.Metrics), data.Skipped)
+	}
+	if m := data.Metrics[0]; m.Kind != MetricWeight || math.Abs(m.Value-81.65) > 0.01 {
+		t.Errorf("expected weight in kg, got %+v", m)
+	}
+	if m := data.Metrics[1]; m.Kind != MetricBodyFat || math.Abs(m.Value-18) > 0.001 {
+		t.Errorf("expected body fat percentage, got %+v", m)
+	}
+
+	if len(data.Workouts) != 2 {
+		t.Fatalf("expected 2 workouts, got %d", len(data.Workouts))
+	}
+	run := data.Workouts[0]
+	if run.Exercise.Name != "Running" || run.Duration != 30*time.Minute || math.Abs(run.DistanceMeters-5200) > 0.001 {
+		t.Errorf("unexpected run %+v", run)
+	}
+	if data.Workouts[1].Exercise.Name != "Workout" {
+		t.Errorf("expected unmapped activity to fall back to Workout, got %q", data.Workouts[1].Exercise.Name)
+	}
+
+	again, _ := ParseAppleHealth(strings.NewReader(appleExport))
+	if again.Workouts[0].ExternalID != run.ExternalID {
+		t.Error("expected the same workout to get the same ID on re-import")
+	}
+
+	if _, err := ParseAppleHealth(strings.NewReader(`<Other/>`)); err == nil {
+		t.Error("expected error for a document that is not a Health export")
+	}
+}
+
+func TestParseGoogleFit(t *testing.T) {
+	body := `{
+		"session": [
+			{"id": "run-1", "name": "Morning run", "startTimeMillis": "1706778000000", "endTimeMillis": "1706779800000", "activityType": 8},
+			{"id": "", "startTimeMillis": "1706778000000", "endTimeMillis": "1706779800000", "activityType": 8}
+		],
+		"point": [
+			{"dataTypeName": "com.google.weight", "startTimeNanos": "1706778000000000000", "value": [{"fpVal": 80.5}]},
+			{"dataTypeName": "com.google.height", "startTimeNanos": "1706778000000000000", "value": [{"fpVal": 1.8}]},
+			{"dataTypeName": "com.google.step_count.delta", "startTimeNanos": "1706778000000000000", "value": [{"intVal": 500}]}
+		]
+	}`
+
+	data, err := ParseGoogleFit(strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("error parsing export. Err: %v", err)
+	}
+	if len(data.Workouts) != 1 || data.Skipped != 1 {
+		t.Fatalf("expected 1 workout and 1 skipped session, got %d and %d", len(data.Workouts), data.Skipped)
+	}
+	if w := data.Workouts[0]; w.ExternalID != "run-1" || w.Exercise.Name != "Running" || w.Duration != 30*time.Minute {
+		t.Errorf("unexpected workout %+v", w)
+	}
+	if len(data.Metrics) != 2 || data.Metrics[1].Kind != MetricHeight || math.Abs(data.Metrics[1].Value-180) > 0.001 {
+		t.Errorf("unexpected metrics %+v", data.Metrics)
+	}
+
+	if _, err := ParseGoogleFit(strings.NewReader(`{"foo": 1}`)); err == nil {
+		t.Error("expected error for JSON without session or point data")
+	}
+}
@@ -0,0 +1,82 @@
+// Package integrations connects FitnessHack accounts to third-party fitness services
+// (currently Strava) and imports their activities.
+package integrations
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+)
+
+var (
+	// ErrNotConfigured is returned when an integration's credentials are not set
+	ErrNotConfigured = errors.New("integration is not configured")
+
+	// ErrUnauthorized is returned when the service rejects the user's tokens, e.g. after access was revoked
+	ErrUnauthorized = errors.New("integration access was revoked")
+)
+
+// Cipher encrypts OAuth tokens before they are stored
+type Cipher struct {
+	aead cipher.AEAD
+}
+
+// NewCipher creates a cipher from a 32 byte AES-256 key
+func NewCipher(key []byte) (*Cipher, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("token key must be 32 bytes, got %d", len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Cipher{aead: aead}, nil
+}
+
+// NewCipherFromEnv creates a cipher from the base64 encoded INTEGRATION_TOKEN_KEY
+func NewCipherFromEnv() (*Cipher, error) {
+	raw := os.Getenv("INTEGRATION_TOKEN_KEY")
+	if raw == "" {
+		return nil, ErrNotConfigured
+	}
+	key, err := base64.StdEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, fmt.Errorf("INTEGRATION_TOKEN_KEY is not valid base64: %w", err)
+	}
+	return NewCipher(key)
+}
+
+// Seal encrypts plaintext with a random nonce and returns it base64 encoded
+func (c *Cipher) Seal(plaintext string) (string, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := c.aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Open decrypts a value produced by Seal
+func (c *Cipher) Open(sealed string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil {
+		return "", err
+	}
+	if len(data) < c.aead.NonceSize() {
+		return "", errors.New("sealed value is too short")
+	}
+	nonce, ciphertext := data[:c.aead.NonceSize()], data[c.aead.NonceSize():]
+	plaintext, err := c.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt token: %w", err)
+	}
+	return string(plaintext), nil
+}
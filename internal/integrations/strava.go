@@ -0,0 +1,255 @@
+package integrations
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	stravaAuthorizeURL = "https://www.strava.com/oauth/authorize"
+	stravaTokenURL     = "https://www.strava.com/oauth/token"
+	stravaDeauthURL    = "https://www.strava.com/oauth/deauthorize"
+	stravaAPIURL       = "https://www.strava.com/api/v3"
+
+	// StravaScope grants read access to all of the athlete's activities, including private ones
+	StravaScope = "read,activity:read_all"
+)
+
+// Strava talks to the Strava OAuth and activity APIs
+type Strava struct {
+	ClientID     string
+	ClientSecret string
+	RedirectURI  string
+	// WebhookVerifyToken is echoed back by Strava when the webhook subscription is created
+	WebhookVerifyToken string
+
+	AuthorizeURL string
+	TokenURL     string
+	DeauthURL    string
+	APIURL       string
+	Client       *http.Client
+}
+
+// NewStravaFromEnv configures the Strava client from STRAVA_CLIENT_ID, STRAVA_CLIENT_SECRET,
+// STRAVA_REDIRECT_URI and STRAVA_WEBHOOK_VERIFY_TOKEN
+func NewStravaFromEnv() *Strava {
+	return &Strava{
+		ClientID:           os.Getenv("STRAVA_CLIENT_ID"),
+		ClientSecret:       os.Getenv("STRAVA_CLIENT_SECRET"),
+		RedirectURI:        os.Getenv("STRAVA_REDIRECT_URI"),
+		WebhookVerifyToken: os.Getenv("STRAVA_WEBHOOK_VERIFY_TOKEN"),
+		AuthorizeURL:       stravaAuthorizeURL,
+		TokenURL:           stravaTokenURL,
+		DeauthURL:          stravaDeauthURL,
+		APIURL:             stravaAPIURL,
+		Client:             &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+// Configured reports whether OAuth credentials are set
+func (s *Strava) Configured() bool {
+	return s.ClientID != "" && s.ClientSecret != "" && s.RedirectURI != ""
+}
+
+// StravaToken is the result of a code exchange or token refresh
+type StravaToken struct {
+	AccessToken  string
+	RefreshToken string
+	ExpiresAt    time.Time
+	// AthleteID is only set on the initial code exchange
+	AthleteID string
+}
+
+// StravaActivity is the subset of a Strava activity imported as a workout session
+type StravaActivity struct {
+	ID               int64     `json:"id"`
+	Name             string    `json:"name"`
+	SportType        string    `json:"sport_type"`
+	StartDate        time.Time `json:"start_date"`
+	ElapsedTime      int       `json:"elapsed_time"`
+	MovingTime       int       `json:"moving_time"`
+	Distance         float64   `json:"distance"`
+	AverageHeartrate float64   `json:"average_heartrate"`
+	MaxHeartrate     float64   `json:"max_heartrate"`
+	Description      string    `json:"description"`
+	// StartLatLng is empty for activities recorded without GPS
+	StartLatLng []float64 `json:"start_latlng"`
+}
+
+// StravaExercise is the exercise an activity is recorded against
+type StravaExercise struct {
+	Name        string
+	MuscleGroup string
+	Equipment   string
+}
+
+// stravaExercises maps Strava sport types to exercises; types not listed are imported as
+// a generic workout
+var stravaExercises = map[string]StravaExercise{
+	"Run":                           {Name: "Running", MuscleGroup: "Cardio", Equipment: "None"},
+	"TrailRun":                      {Name: "Trail Running", MuscleGroup: "Cardio", Equipment: "None"},
+	"VirtualRun":                    {Name: "Treadmill Running", MuscleGroup: "Cardio", Equipment: "Treadmill"},
+	"Walk":                          {Name: "Walking", MuscleGroup: "Cardio", Equipment: "None"},
+	"Hike":                          {Name: "Hiking", MuscleGroup: "Cardio", Equipment: "None"},
+	"Ride":                          {Name: "Cycling", MuscleGroup: "Cardio", Equipment: "Bicycle"},
+	"MountainBikeRide":              {Name: "Mountain Biking", MuscleGroup: "Cardio", Equipment: "Bicycle"},
+	"GravelRide":                    {Name: "Cycling", MuscleGroup: "Cardio", Equipment: "Bicycle"},
+	"EBikeRide":                     {Name: "E-Bike Ride", MuscleGroup: "Cardio", Equipment: "Bicycle"},
+	"VirtualRide":                   {Name: "Indoor Cycling", MuscleGroup: "Cardio", Equipment: "Stationary Bike"},
+	"Swim":                          {Name: "Swimming", MuscleGroup: "Full Body", Equipment: "None"},
+	"Rowing":                        {Name: "Rowing", MuscleGroup: "Full Body", Equipment: "None"},
+	"VirtualRow":                    {Name: "Indoor Rowing", MuscleGroup: "Full Body", Equipment: "Rowing Machine"},
+	"Elliptical":                    {Name: "Elliptical", MuscleGroup: "Cardio", Equipment: "Elliptical"},
+	"StairStepper":                  {Name: "Stair Climber", MuscleGroup: "Legs", Equipment: "Stair Climber"},
+	"WeightTraining":                {Name: "Weight Training", MuscleGroup: "Full Body", Equipment: "Weights"},
+	"Crossfit":                      {Name: "CrossFit", MuscleGroup: "Full Body", Equipment: "Various"},
+	"HighIntensityIntervalTraining": {Name: "HIIT", MuscleGroup: "Full Body", Equipment: "None"},
+	"Yoga":                          {Name: "Yoga", MuscleGroup: "Full Body", Equipment: "Mat"},
+	"Pilates":                       {Name: "Pilates", MuscleGroup: "Core", Equipment: "Mat"},
+	"RockClimbing":                  {Name: "Rock Climbing", MuscleGroup: "Full Body", Equipment: "Climbing Gear"},
+}
+
+// ExerciseForSportType returns the exercise a Strava sport type is imported as
+func ExerciseForSportType(sportType string) StravaExercise {
+	if exercise, ok := stravaExercises[sportType]; ok {
+		return exercise
+	}
+	return StravaExercise{Name: "Workout", MuscleGroup: "Full Body", Equipment: "None"}
+}
+
+// AuthorizeURLFor returns the URL the user is sent to to grant access; state is echoed to the redirect URI
+func (s *Strava) AuthorizeURLFor(state string) string {
+	params := url.Values{
+		"client_id":       {s.ClientID},
+		"redirect_uri":    {s.RedirectURI},
+		"response_type":   {"code"},
+		"approval_prompt": {"auto"},
+		"scope":           {StravaScope},
+		"state":           {state},
+	}
+	return s.AuthorizeURL + "?" + params.Encode()
+}
+
+type stravaTokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresAt    int64  `json:"expires_at"`
+	Athlete      *struct {
+		ID int64 `json:"id"`
+	} `json:"athlete"`
+}
+
+// Exchange trades an authorization code for tokens
+func (s *Strava) Exchange(ctx context.Context, code string) (*StravaToken, error) {
+	return s.token(ctx, url.Values{"grant_type": {"authorization_code"}, "code": {code}})
+}
+
+// Refresh obtains a new access token; Strava may rotate the refresh token as well
+func (s *Strava) Refresh(ctx context.Context, refreshToken string) (*StravaToken, error) {
+	return s.token(ctx, url.Values{"grant_type": {"refresh_token"}, "refresh_token": {refreshToken}})
+}
+
+func (s *Strava) token(ctx context.Context, form url.Values) (*StravaToken, error) {
+	if !s.Configured() {
+		return nil, ErrNotConfigured
+	}
+	form.Set("client_id", s.ClientID)
+	form.Set("client_secret", s.ClientSecret)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var body stravaTokenResponse
+	if err := s.do(req, &body); err != nil {
+		return nil, fmt.Errorf("strava token request failed: %w", err)
+	}
+
+	token := &StravaToken{
+		AccessToken:  body.AccessToken,
+		RefreshToken: body.RefreshToken,
+		ExpiresAt:    time.Unix(body.ExpiresAt, 0),
+	}
+	if body.Athlete != nil {
+		token.AthleteID = strconv.FormatInt(body.Athlete.ID, 10)
+	}
+	return token, nil
+}
+
+// Deauthorize revokes the application's access to the athlete's account
+func (s *Strava) Deauthorize(ctx context.Context, accessToken string) error {
+	form := url.Values{"access_token": {accessToken}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.DeauthURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return s.do(req, nil)
+}
+
+// ListActivities returns one page of the athlete's activities started after the given time, oldest first
+func (s *Strava) ListActivities(ctx context.Context, accessToken string, after time.Time, page, perPage int) ([]StravaActivity, error) {
+	params := url.Values{
+		"after":    {strconv.FormatInt(after.Unix(), 10)},
+		"page":     {strconv.Itoa(page)},
+		"per_page": {strconv.Itoa(perPage)},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.APIURL+"/athlete/activities?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	var activities []StravaActivity
+	if err := s.do(req, &activities); err != nil {
+		return nil, fmt.Errorf("failed to list strava activities: %w", err)
+	}
+	return activities, nil
+}
+
+// GetActivity returns a single activity
+func (s *Strava) GetActivity(ctx context.Context, accessToken string, id int64) (*StravaActivity, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.APIURL+"/activities/"+strconv.FormatInt(id, 10), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	var activity StravaActivity
+	if err := s.do(req, &activity); err != nil {
+		return nil, fmt.Errorf("failed to get strava activity: %w", err)
+	}
+	return &activity, nil
+}
+
+// do sends the request and decodes a JSON response into out (if non-nil).
+// 401 responses are reported as ErrUnauthorized.
+func (s *Strava) do(req *http.Request, out interface{}) error {
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return ErrUnauthorized
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("strava returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
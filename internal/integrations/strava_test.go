@@ -0,0 +1,71 @@
+package integrations
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCipher(t *testing.T) {
+	c, err := NewCipher(bytes.Repeat([]byte{1}, 32))
+	if err != nil {
+		t.Fatalf("error creating cipher. Err: %v", err)
+	}
+
+	sealed, err := c.Seal("access-token")
+	if err != nil {
+		t.Fatalf("error sealing. Err: %v", err)
+	}
+	if sealed == "access-token" {
+		t.Fatal("expected token to be encrypted")
+	}
+	opened, err := c.Open(sealed)
+	if err != nil || opened != "access-token" {
+		t.Fatalf("expected round trip, got %q. Err: %v", opened, err)
+	}
+
+	other, _ := NewCipher(bytes.Repeat([]byte{2}, 32))
+	if _, err := other.Open(sealed); err == nil {
+		t.Fatal("expected error opening with a different key")
+	}
+	if _, err := NewCipher([]byte("short")); err == nil {
+		t.Fatal("expected error for short key")
+	}
+}
+
+func TestExerciseForSportType(t *testing.T) {
+	if got := ExerciseForSportType("TrailRun"); got.Name != "Trail Running" {
+		t.Errorf("expected Trail Running, got %q", got.Name)
+	}
+	if got := ExerciseForSportType("Kitesurf"); got.Name != "Workout" {
+		t.Errorf("expected unmapped sport to fall back to Workout, got %q", got.Name)
+	}
+}
+
+func TestStravaExchange(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil || r.PostForm.Get("code") != "good" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"access_token":"a","refresh_token":"r","expires_at":1700000000,"athlete":{"id":42}}`))
+	}))
+	defer srv.Close()
+
+	s := &Strava{ClientID: "id", ClientSecret: "secret", RedirectURI: "https://example.com/cb", TokenURL: srv.URL, Client: srv.Client()}
+
+	token, err := s.Exchange(context.Background(), "good")
+	if err != nil {
+		t.Fatalf("error exchanging code. Err: %v", err)
+	}
+	if token.AccessToken != "a" || token.RefreshToken != "r" || token.AthleteID != "42" || token.ExpiresAt.Unix() != 1700000000 {
+		t.Errorf("unexpected token %+v", token)
+	}
+
+	if _, err := s.Exchange(context.Background(), "bad"); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("expected ErrUnauthorized, got %v", err)
+	}
+}
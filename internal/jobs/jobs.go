@@ -0,0 +1,281 @@
+// Package jobs runs work outside the request that asked for it. Jobs are kept in Redis so
+// they survive restarts and can be picked up by any API instance or a dedicated worker
+// process. Failed jobs are retried with exponential backoff and moved to a dead-letter
+// list once they run out of attempts. On Lambda, jobs go through SQS instead, which
+// retries and dead-letters them itself.
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// DefaultMaxAttempts is how often a job runs before it is dead-lettered
+	DefaultMaxAttempts = 5
+
+	// maxDeadJobs bounds the dead-letter list; the oldest entries are dropped first
+	maxDeadJobs = 1000
+)
+
+// Job is a unit of work and its delivery state
+type Job struct {
+	ID          string          `json:"id"`
+	Type        string          `json:"type"`
+	Payload     json.RawMessage `json:"payload"`
+	Attempts    int             `json:"attempts"`
+	MaxAttempts int             `json:"maxAttempts"`
+	EnqueuedAt  time.Time       `json:"enqueuedAt"`
+	LastError   string          `json:"lastError,omitempty"`
+
+	// raw is the job as stored, needed to remove it from the active set
+	raw string
+}
+
+// Decode unmarshals the job's payload into v
+func (j *Job) Decode(v interface{}) error {
+	return json.Unmarshal(j.Payload, v)
+}
+
+// LastAttempt reports whether a failure now would dead-letter the job
+func (j *Job) LastAttempt() bool {
+	return j.Attempts >= j.MaxAttempts
+}
+
+// Option changes how a job is enqueued
+type Option func(*enqueueOptions)
+
+type enqueueOptions struct {
+	delay       time.Duration
+	maxAttempts int
+}
+
+// Delay runs the job no earlier than d from now
+func Delay(d time.Duration) Option {
+	return func(o *enqueueOptions) { o.delay = d }
+}
+
+// MaxAttempts overrides DefaultMaxAttempts for the job
+func MaxAttempts(n int) Option {
+	return func(o *enqueueOptions) { o.maxAttempts = n }
+}
+
+// Producer queues jobs. Queue sends them to Redis and SQS to an SQS queue.
+type Producer interface {
+	Enqueue(ctx context.Context, jobType string, payload interface{}, opts ...Option) (string, error)
+}
+
+// NewProducerFromEnv returns the producer selected by JOB_QUEUE_DRIVER: the "default"
+// Redis queue on rdb unless it is "sqs"
+func NewProducerFromEnv(ctx context.Context, rdb *redis.Client) (Producer, error) {
+	switch os.Getenv("JOB_QUEUE_DRIVER") {
+	case "sqs":
+		return NewSQSFromEnv(ctx)
+	default:
+		return NewQueue(rdb, "default"), nil
+	}
+}
+
+// newJob builds a job of the given type with its payload encoded as JSON, returning it
+// with the delay it was enqueued with
+func newJob(jobType string, payload interface{}, opts []Option) (*Job, time.Duration, error) {
+	o := enqueueOptions{maxAttempts: DefaultMaxAttempts}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.maxAttempts < 1 {
+		o.maxAttempts = 1
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to encode %s job payload: %w", jobType, err)
+	}
+	return &Job{
+		ID:          uuid.NewString(),
+		Type:        jobType,
+		Payload:     data,
+		MaxAttempts: o.maxAttempts,
+		EnqueuedAt:  time.Now().UTC(),
+	}, o.delay, nil
+}
+
+// Queue is a named job queue in Redis. Its jobs live in three keys:
+//
+//	jobs:<name>:scheduled  sorted set of jobs waiting to run, scored by when they are due
+//	jobs:<name>:active     sorted set of running jobs, scored by when their lease runs out
+//	jobs:<name>:dead       list of jobs that ran out of attempts, newest first
+type Queue struct {
+	rdb       *redis.Client
+	scheduled string
+	active    string
+	dead      string
+}
+
+// NewQueue returns the queue called name
+func NewQueue(rdb *redis.Client, name string) *Queue {
+	prefix := "jobs:" + name + ":"
+	return &Queue{
+		rdb:       rdb,
+		scheduled: prefix + "scheduled",
+		active:    prefix + "active",
+		dead:      prefix + "dead",
+	}
+}
+
+// Enqueue adds a job of the given type to the queue, returning its ID. The payload is
+// encoded as JSON and handed to the job's handler.
+func (q *Queue) Enqueue(ctx context.Context, jobType string, payload interface{}, opts ...Option) (string, error) {
+	job, delay, err := newJob(jobType, payload, opts)
+	if err != nil {
+		return "", err
+	}
+	raw, err := json.Marshal(job)
+	if err != nil {
+		return "", err
+	}
+
+	runAt := time.Now().Add(delay)
+	if err := q.rdb.ZAdd(ctx, q.scheduled, redis.Z{Score: score(runAt), Member: raw}).Err(); err != nil {
+		return "", fmt.Errorf("failed to enqueue %s job: %w", jobType, err)
+	}
+	return job.ID, nil
+}
+
+// score is a time as stored in the sorted sets, in milliseconds
+func score(t time.Time) float64 {
+	return float64(t.UnixMilli())
+}
+
+// dequeueScript moves the earliest due job from scheduled to active, leased until ARGV[2]
+var dequeueScript = redis.NewScript(`
+local due = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, 1)
+if #due == 0 then
+	return false
+end
+redis.call('ZREM', KEYS[1], due[1])
+redis.call('ZADD', KEYS[2], ARGV[2], due[1])
+return due[1]
+`)
+
+// dequeue claims the next due job, returning nil when none is due
+func (q *Queue) dequeue(ctx context.Context, lease time.Duration) (*Job, error) {
+	now := time.Now()
+	raw, err := dequeueScript.Run(ctx, q.rdb, []string{q.scheduled, q.active},
+		strconv.FormatFloat(score(now), 'f', 0, 64), strconv.FormatFloat(score(now.Add(lease)), 'f', 0, 64)).Text()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var job Job
+	if err := json.Unmarshal([]byte(raw), &job); err != nil {
+		// Unreadable entries would otherwise be redelivered forever
+		q.rdb.ZRem(ctx, q.active, raw)
+		return nil, fmt.Errorf("dropped malformed job %q: %w", raw, err)
+	}
+	job.raw = raw
+	return &job, nil
+}
+
+// ack removes a finished job
+func (q *Queue) ack(ctx context.Context, job *Job) error {
+	return q.rdb.ZRem(ctx, q.active, job.raw).Err()
+}
+
+// moveScript replaces a job in the active set with its updated copy, either rescheduled
+// (ARGV[3] is the score) or pushed onto the dead-letter list. Jobs whose lease ran out and
+// were handed to another worker are left alone.
+var moveScript = redis.NewScript(`
+if redis.call('ZREM', KEYS[1], ARGV[1]) == 0 then
+	return 0
+end
+if ARGV[3] == '' then
+	redis.call('LPUSH', KEYS[2], ARGV[2])
+	redis.call('LTRIM', KEYS[2], 0, tonumber(ARGV[4]) - 1)
+else
+	redis.call('ZADD', KEYS[2], ARGV[3], ARGV[2])
+end
+return 1
+`)
+
+// retry schedules the job to run again at runAt
+func (q *Queue) retry(ctx context.Context, job *Job, runAt time.Time) error {
+	updated, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	return moveScript.Run(ctx, q.rdb, []string{q.active, q.scheduled},
+		job.raw, updated, strconv.FormatFloat(score(runAt), 'f', 0, 64), 0).Err()
+}
+
+// kill moves the job to the dead-letter list
+func (q *Queue) kill(ctx context.Context, job *Job) error {
+	updated, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	return moveScript.Run(ctx, q.rdb, []string{q.active, q.dead}, job.raw, updated, "", maxDeadJobs).Err()
+}
+
+// requeueScript returns jobs whose lease has run out, because their worker died or
+// stalled, to the scheduled set so they run again right away
+var requeueScript = redis.NewScript(`
+local expired = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, 100)
+for _, raw in ipairs(expired) do
+	redis.call('ZREM', KEYS[1], raw)
+	redis.call('ZADD', KEYS[2], ARGV[1], raw)
+end
+return #expired
+`)
+
+// requeueExpired returns abandoned jobs to the queue
+func (q *Queue) requeueExpired(ctx context.Context) (int, error) {
+	return requeueScript.Run(ctx, q.rdb, []string{q.active, q.scheduled},
+		strconv.FormatFloat(score(time.Now()), 'f', 0, 64)).Int()
+}
+
+// Stats counts the jobs in each state
+type Stats struct {
+	Scheduled int64 `json:"scheduled"`
+	Active    int64 `json:"active"`
+	Dead      int64 `json:"dead"`
+}
+
+// Stats returns the number of scheduled, running and dead-lettered jobs
+func (q *Queue) Stats(ctx context.Context) (Stats, error) {
+	pipe := q.rdb.Pipeline()
+	scheduled := pipe.ZCard(ctx, q.scheduled)
+	active := pipe.ZCard(ctx, q.active)
+	dead := pipe.LLen(ctx, q.dead)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return Stats{}, err
+	}
+	return Stats{Scheduled: scheduled.Val(), Active: active.Val(), Dead: dead.Val()}, nil
+}
+
+// DeadJobs returns up to limit dead-lettered jobs, newest first
+func (q *Queue) DeadJobs(ctx context.Context, limit int) ([]Job, error) {
+	raws, err := q.rdb.LRange(ctx, q.dead, 0, int64(limit)-1).Result()
+	if err != nil {
+		return nil, err
+	}
+	jobs := make([]Job, 0, len(raws))
+	for _, raw := range raws {
+		var job Job
+		if err := json.Unmarshal([]byte(raw), &job); err == nil {
+			jobs = append(jobs, job)
+		}
+	}
+	return jobs, nil
+}
@@ -0,0 +1,81 @@
+package jobs
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Local holds jobs in memory for a worker in the same process, for standalone mode where
+// there is no Redis. Jobs are delayed, retried and dead-lettered like on a Queue, but
+// jobs that haven't finished are lost when the process stops.
+type Local struct {
+	mu      sync.Mutex
+	pending []localJob
+}
+
+// localJob is a job waiting to run
+type localJob struct {
+	job   *Job
+	runAt time.Time
+}
+
+// NewLocal returns an empty in-memory queue
+func NewLocal() *Local {
+	return &Local{}
+}
+
+// Enqueue adds a job of the given type to the queue, returning its ID
+func (l *Local) Enqueue(ctx context.Context, jobType string, payload interface{}, opts ...Option) (string, error) {
+	job, delay, err := newJob(jobType, payload, opts)
+	if err != nil {
+		return "", err
+	}
+	l.push(job, time.Now().Add(delay))
+	return job.ID, nil
+}
+
+func (l *Local) push(job *Job, runAt time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.pending = append(l.pending, localJob{job: job, runAt: runAt})
+}
+
+// dequeue removes and returns the earliest due job, or nil when none is due. Jobs can't
+// be abandoned by a worker in another process, so there is no lease.
+func (l *Local) dequeue(ctx context.Context, lease time.Duration) (*Job, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	next := -1
+	now := time.Now()
+	for i, p := range l.pending {
+		if !p.runAt.After(now) && (next < 0 || p.runAt.Before(l.pending[next].runAt)) {
+			next = i
+		}
+	}
+	if next < 0 {
+		return nil, nil
+	}
+	job := l.pending[next].job
+	l.pending = append(l.pending[:next], l.pending[next+1:]...)
+	return job, nil
+}
+
+func (l *Local) ack(ctx context.Context, job *Job) error {
+	return nil
+}
+
+func (l *Local) retry(ctx context.Context, job *Job, runAt time.Time) error {
+	l.push(job, runAt)
+	return nil
+}
+
+// kill drops the job; the worker has already logged it
+func (l *Local) kill(ctx context.Context, job *Job) error {
+	return nil
+}
+
+func (l *Local) requeueExpired(ctx context.Context) (int, error) {
+	return 0, nil
+}
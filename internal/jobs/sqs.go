@@ -0,0 +1,177 @@
+package jobs
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"fitness-hack/internal/messages"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/config"
+)
+
+// maxSQSDelay is the longest delay SQS supports for a message
+const maxSQSDelay = 15 * time.Minute
+
+// SQS sends jobs to an SQS queue, for deployments on Lambda where no long-lived worker
+// polls Redis. Lambda's SQS trigger delivers the jobs to Worker.HandleSQSEvent.
+//
+// Requests use the SQS JSON protocol, signed with the SDK's SigV4 signer.
+type SQS struct {
+	client   *http.Client
+	cfg      aws.Config
+	signer   *v4.Signer
+	queueURL string
+	endpoint string
+}
+
+// NewSQS returns a producer for the queue at queueURL, using cfg's region and credentials
+func NewSQS(cfg aws.Config, queueURL string) (*SQS, error) {
+	u, err := url.Parse(queueURL)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid SQS queue URL %q", queueURL)
+	}
+	if cfg.Region == "" {
+		return nil, errors.New("an AWS region is required for SQS")
+	}
+	return &SQS{
+		client:   &http.Client{Timeout: 10 * time.Second},
+		cfg:      cfg,
+		signer:   v4.NewSigner(),
+		queueURL: queueURL,
+		endpoint: u.Scheme + "://" + u.Host + "/",
+	}, nil
+}
+
+// NewSQSFromEnv returns a producer for JOB_QUEUE_SQS_URL using the default AWS credential chain
+func NewSQSFromEnv(ctx context.Context) (*SQS, error) {
+	queueURL := os.Getenv("JOB_QUEUE_SQS_URL")
+	if queueURL == "" {
+		return nil, errors.New("JOB_QUEUE_SQS_URL is required when JOB_QUEUE_DRIVER=sqs")
+	}
+
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+	return NewSQS(cfg, queueURL)
+}
+
+// Enqueue sends a job of the given type to the queue, returning its ID. SQS can't delay a
+// message by more than 15 minutes, so longer delays are rejected.
+func (q *SQS) Enqueue(ctx context.Context, jobType string, payload interface{}, opts ...Option) (string, error) {
+	job, delay, err := newJob(jobType, payload, opts)
+	if err != nil {
+		return "", err
+	}
+	if delay > maxSQSDelay {
+		return "", fmt.Errorf("failed to enqueue %s job: SQS delays are limited to %s", jobType, maxSQSDelay)
+	}
+	raw, err := json.Marshal(job)
+	if err != nil {
+		return "", err
+	}
+
+	err = q.call(ctx, "SendMessage", map[string]interface{}{
+		"QueueUrl":     q.queueURL,
+		"MessageBody":  string(raw),
+		"DelaySeconds": int(delay.Seconds()),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to enqueue %s job: %w", jobType, err)
+	}
+	return job.ID, nil
+}
+
+// call invokes an SQS action with a signed JSON request
+func (q *SQS) call(ctx context.Context, action string, input interface{}) error {
+	body, err := json.Marshal(input)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, q.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.0")
+	req.Header.Set("X-Amz-Target", "AmazonSQS."+action)
+
+	creds, err := q.cfg.Credentials.Retrieve(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load AWS credentials: %w", err)
+	}
+	hash := sha256.Sum256(body)
+	if err := q.signer.SignHTTP(ctx, creds, req, hex.EncodeToString(hash[:]), "sqs", q.cfg.Region, time.Now()); err != nil {
+		return fmt.Errorf("failed to sign SQS request: %w", err)
+	}
+
+	resp, err := q.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		// Errors carry their type in __type and their text in message
+		var apiErr struct {
+			Type    string `json:"__type"`
+			Message string `json:"message"`
+		}
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		json.Unmarshal(data, &apiErr)
+		return fmt.Errorf("SQS %s returned %d: %s %s", action, resp.StatusCode, apiErr.Type, apiErr.Message)
+	}
+	return nil
+}
+
+// HandleSQSEvent runs a batch of jobs delivered by Lambda's SQS trigger, which needs
+// ReportBatchItemFailures enabled. Failed jobs are reported back, and SQS retries them
+// after the queue's visibility timeout and dead-letters them after its maxReceiveCount,
+// which should match the jobs' MaxAttempts. Permanent failures are dropped. Jobs left
+// when ctx ends are reported as failed so they run again.
+func (w *Worker) HandleSQSEvent(ctx context.Context, event events.SQSEvent) (events.SQSEventResponse, error) {
+	var resp events.SQSEventResponse
+	for _, msg := range event.Records {
+		if ctx.Err() != nil || !w.processSQSMessage(msg) {
+			resp.BatchItemFailures = append(resp.BatchItemFailures, events.SQSBatchItemFailure{ItemIdentifier: msg.MessageId})
+		}
+	}
+	return resp, nil
+}
+
+// processSQSMessage runs one attempt at the job in msg, reporting whether it should be
+// removed from the queue
+func (w *Worker) processSQSMessage(msg events.SQSMessage) bool {
+	var job Job
+	if err := json.Unmarshal([]byte(msg.Body), &job); err != nil {
+		// Unreadable messages would otherwise be redelivered until they are dead-lettered
+		messages.Log(messages.JobsJobDead, "malformed", msg.MessageId, 1, err)
+		return true
+	}
+	job.Attempts, _ = strconv.Atoi(msg.Attributes["ApproximateReceiveCount"])
+
+	err := w.run(&job)
+	var permanent *permanentError
+	switch {
+	case err == nil:
+		return true
+	case errors.As(err, &permanent):
+		messages.Log(messages.JobsJobDead, job.Type, job.ID, job.Attempts, err)
+		return true
+	case job.LastAttempt():
+		messages.Log(messages.JobsJobDead, job.Type, job.ID, job.Attempts, err)
+	}
+	return false
+}
@@ -0,0 +1,98 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+func TestSQSEnqueue(t *testing.T) {
+	var input struct {
+		QueueUrl     string
+		MessageBody  string
+		DelaySeconds int
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Amz-Target") != "AmazonSQS.SendMessage" {
+			t.Errorf("unexpected target %q", r.Header.Get("X-Amz-Target"))
+		}
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/") {
+			t.Errorf("request is not signed: %q", r.Header.Get("Authorization"))
+		}
+		json.NewDecoder(r.Body).Decode(&input)
+		w.Write([]byte(`{"MessageId":"m1"}`))
+	}))
+	defer srv.Close()
+
+	cfg := aws.Config{
+		Region: "us-east-1",
+		Credentials: aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+			return aws.Credentials{AccessKeyID: "AKID", SecretAccessKey: "secret"}, nil
+		}),
+	}
+	q, err := NewSQS(cfg, srv.URL+"/123456789012/jobs")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	id, err := q.Enqueue(context.Background(), "echo", map[string]bool{"Fail": false}, Delay(time.Minute))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var job Job
+	if err := json.Unmarshal([]byte(input.MessageBody), &job); err != nil {
+		t.Fatalf("message body is not a job: %v", err)
+	}
+	if input.QueueUrl != srv.URL+"/123456789012/jobs" || input.DelaySeconds != 60 || job.ID != id || job.Type != "echo" {
+		t.Errorf("unexpected message %+v", input)
+	}
+
+	if _, err := q.Enqueue(context.Background(), "echo", nil, Delay(time.Hour)); err == nil {
+		t.Error("expected delays over 15 minutes to be rejected")
+	}
+}
+
+func TestHandleSQSEvent(t *testing.T) {
+	w := NewWorker(nil, 1)
+	w.Handle("echo", func(ctx context.Context, job *Job) error {
+		var payload struct{ Fail, Permanent bool }
+		job.Decode(&payload)
+		switch {
+		case payload.Permanent:
+			return Permanent(errors.New("asked to give up"))
+		case payload.Fail:
+			return errors.New("asked to fail")
+		}
+		return nil
+	})
+
+	message := func(id, payload string) events.SQSMessage {
+		return events.SQSMessage{
+			MessageId:  id,
+			Body:       `{"type":"echo","maxAttempts":5,"payload":` + payload + `}`,
+			Attributes: map[string]string{"ApproximateReceiveCount": "1"},
+		}
+	}
+	resp, err := w.HandleSQSEvent(context.Background(), events.SQSEvent{Records: []events.SQSMessage{
+		message("ok", `{}`),
+		message("retry", `{"Fail":true}`),
+		message("permanent", `{"Permanent":true}`),
+		{MessageId: "malformed", Body: "not json"},
+	}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []events.SQSBatchItemFailure{{ItemIdentifier: "retry"}}
+	if !reflect.DeepEqual(resp.BatchItemFailures, want) {
+		t.Errorf("expected failures %v, got %v", want, resp.BatchItemFailures)
+	}
+}
@@ -0,0 +1,170 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"fitness-hack/internal/messages"
+)
+
+// Handler runs one job. Returning an error retries the job unless it is wrapped with
+// Permanent or the job has no attempts left.
+type Handler func(ctx context.Context, job *Job) error
+
+// permanentError marks a failure that retrying won't fix
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps err so the job is dead-lettered without further attempts
+func Permanent(err error) error {
+	return &permanentError{err: err}
+}
+
+// backoff is the delay before retrying a job that has failed attempts times: 10s, 20s,
+// 40s and so on, capped at an hour
+func backoff(attempts int) time.Duration {
+	const maxBackoff = time.Hour
+	d := 10 * time.Second
+	for i := 1; i < attempts; i++ {
+		d *= 2
+		if d >= maxBackoff {
+			return maxBackoff
+		}
+	}
+	return d
+}
+
+// backend stores the jobs a Worker runs: a Redis Queue, or Local in standalone mode
+type backend interface {
+	dequeue(ctx context.Context, lease time.Duration) (*Job, error)
+	ack(ctx context.Context, job *Job) error
+	retry(ctx context.Context, job *Job, runAt time.Time) error
+	kill(ctx context.Context, job *Job) error
+	requeueExpired(ctx context.Context) (int, error)
+}
+
+// Worker runs jobs from a queue with a fixed number of goroutines
+type Worker struct {
+	queue    backend
+	handlers map[string]Handler
+
+	// Concurrency is how many jobs run at once
+	Concurrency int
+	// PollInterval is how long an idle goroutine waits before checking for due jobs again
+	PollInterval time.Duration
+	// Timeout bounds a single attempt. Jobs are leased for a minute longer, after which
+	// they are assumed abandoned and run again.
+	Timeout time.Duration
+}
+
+// NewWorker returns a worker for the queue with default settings
+func NewWorker(queue *Queue, concurrency int) *Worker {
+	return newWorker(queue, concurrency)
+}
+
+// NewLocalWorker returns a worker for jobs enqueued on l with default settings
+func NewLocalWorker(l *Local, concurrency int) *Worker {
+	return newWorker(l, concurrency)
+}
+
+func newWorker(queue backend, concurrency int) *Worker {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	return &Worker{
+		queue:        queue,
+		handlers:     map[string]Handler{},
+		Concurrency:  concurrency,
+		PollInterval: time.Second,
+		Timeout:      5 * time.Minute,
+	}
+}
+
+// Handle registers the handler for a job type. It must be called before Run.
+func (w *Worker) Handle(jobType string, handler Handler) {
+	w.handlers[jobType] = handler
+}
+
+// Run processes jobs until ctx is canceled, then waits for running jobs to finish
+func (w *Worker) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i := 0; i < w.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.loop(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+func (w *Worker) loop(ctx context.Context) {
+	for ctx.Err() == nil {
+		if _, err := w.queue.requeueExpired(ctx); err != nil && ctx.Err() == nil {
+			messages.Log(messages.JobsRequeueFailed, err)
+		}
+
+		job, err := w.queue.dequeue(ctx, w.Timeout+time.Minute)
+		if err != nil && ctx.Err() == nil {
+			messages.Log(messages.JobsDequeueFailed, err)
+		}
+		if job == nil {
+			select {
+			case <-ctx.Done():
+			case <-time.After(w.PollInterval):
+			}
+			continue
+		}
+		w.process(job)
+	}
+}
+
+// process runs one attempt at a job and records the outcome. It doesn't take the Run
+// context, so a shutdown lets the attempt finish instead of failing it halfway.
+func (w *Worker) process(job *Job) {
+	job.Attempts++
+	err := w.run(job)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var permanent *permanentError
+	switch {
+	case err == nil:
+		err = w.queue.ack(ctx, job)
+	case errors.As(err, &permanent) || job.LastAttempt():
+		job.LastError = err.Error()
+		messages.Log(messages.JobsJobDead, job.Type, job.ID, job.Attempts, err)
+		err = w.queue.kill(ctx, job)
+	default:
+		job.LastError = err.Error()
+		err = w.queue.retry(ctx, job, time.Now().Add(backoff(job.Attempts)))
+	}
+	if err != nil {
+		messages.Log(messages.JobsRecordOutcomeFailed, job.Type, job.ID, err)
+	}
+}
+
+func (w *Worker) run(job *Job) (err error) {
+	handler, ok := w.handlers[job.Type]
+	if !ok {
+		return Permanent(fmt.Errorf("no handler for job type %q", job.Type))
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), w.Timeout)
+	defer cancel()
+	return handler(ctx, job)
+}
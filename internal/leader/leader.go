@@ -0,0 +1,135 @@
+// Package leader elects one instance among the API replicas and workers to run singleton
+// work, such as the periodic cleanups and schedulers. Leadership is a lease on a Redis key:
+// the leader renews it well before it expires, and if the leader stops renewing (it
+// crashed or lost Redis), another instance takes the key over once it expires.
+package leader
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// DefaultTTL is how long a lease lasts without renewal, and so about how long singleton
+// work pauses after the leader dies
+const DefaultTTL = 30 * time.Second
+
+// renewScript extends the lease only if this instance still holds it
+var renewScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// releaseScript deletes the lease only if this instance still holds it
+var releaseScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+// Elector campaigns for leadership of one role. Every instance runs an Elector for the
+// role; at most one of them is leader at a time, as long as clocks run at the same rate.
+type Elector struct {
+	rdb *redis.Client
+	key string
+	ttl time.Duration
+
+	// id identifies this instance's lease, so it never renews or releases another's
+	id string
+
+	// leaderUntil is when this instance's lease runs out, in Unix nanoseconds; 0 when not leader
+	leaderUntil atomic.Int64
+
+	// solo is set for an elector that leads without campaigning
+	solo bool
+}
+
+// Solo returns an elector that is always leader, for standalone mode where one process
+// is the whole deployment
+func Solo() *Elector {
+	return &Elector{solo: true}
+}
+
+// NewElector returns an elector for role whose leases last ttl, DefaultTTL if 0
+func NewElector(rdb *redis.Client, role string, ttl time.Duration) *Elector {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	return &Elector{rdb: rdb, key: "leader:" + role, ttl: ttl, id: uuid.New().String()}
+}
+
+// IsLeader reports whether this instance holds the lease. It turns false as soon as the
+// lease may have run out, even if Redis couldn't be reached to find out.
+func (e *Elector) IsLeader() bool {
+	if e.solo {
+		return true
+	}
+	return time.Now().UnixNano() < e.leaderUntil.Load()
+}
+
+// Run campaigns until ctx is cancelled, trying to take the lease while another instance
+// holds it and renewing it while this one does. Leadership is given up on return, so a
+// replica shutting down hands over without waiting for the lease to expire.
+func (e *Elector) Run(ctx context.Context) {
+	if e.solo {
+		return
+	}
+	ticker := time.NewTicker(e.ttl / 3)
+	defer ticker.Stop()
+	defer e.release()
+
+	for {
+		e.campaign(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// campaign takes or renews the lease once
+func (e *Elector) campaign(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, e.ttl/3)
+	defer cancel()
+
+	// The deadline is taken before asking, so it never outlasts the lease in Redis
+	until := time.Now().Add(e.ttl).UnixNano()
+	var held bool
+	if e.leaderUntil.Load() != 0 {
+		renewed, err := renewScript.Run(ctx, e.rdb, []string{e.key}, e.id, e.ttl.Milliseconds()).Int()
+		if err != nil {
+			// Still leader until the lease runs out, unless a later renewal works
+			return
+		}
+		held = renewed == 1
+	}
+	if !held {
+		acquired, err := e.rdb.SetNX(ctx, e.key, e.id, e.ttl).Result()
+		if err != nil {
+			return
+		}
+		held = acquired
+	}
+	if held {
+		e.leaderUntil.Store(until)
+	} else {
+		e.leaderUntil.Store(0)
+	}
+}
+
+// release gives up the lease if this instance holds it
+func (e *Elector) release() {
+	if e.leaderUntil.Swap(0) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	releaseScript.Run(ctx, e.rdb, []string{e.key}, e.id)
+}
@@ -0,0 +1,46 @@
+package leader
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// unreachable returns a client for a port nothing listens on
+func unreachable(t *testing.T) *redis.Client {
+	rdb := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", MaxRetries: -1})
+	t.Cleanup(func() { rdb.Close() })
+	return rdb
+}
+
+func TestElectorWithoutRedis(t *testing.T) {
+	e := NewElector(unreachable(t), "test", 300*time.Millisecond)
+
+	e.campaign(context.Background())
+	if e.IsLeader() {
+		t.Fatal("became leader without reaching Redis")
+	}
+
+	// A leader that can't renew keeps its lease until it would have expired in Redis
+	e.leaderUntil.Store(time.Now().Add(100 * time.Millisecond).UnixNano())
+	e.campaign(context.Background())
+	if !e.IsLeader() {
+		t.Fatal("lost leadership before the lease ran out")
+	}
+	time.Sleep(150 * time.Millisecond)
+	if e.IsLeader() {
+		t.Fatal("still leader after the lease ran out")
+	}
+}
+
+func TestNewElectorDefaults(t *testing.T) {
+	e := NewElector(unreachable(t), "scheduler", 0)
+	if e.key != "leader:scheduler" || e.ttl != DefaultTTL {
+		t.Errorf("unexpected key %q and ttl %s", e.key, e.ttl)
+	}
+	if other := NewElector(unreachable(t), "scheduler", 0); other.id == e.id {
+		t.Error("electors share a lease id")
+	}
+}
@@ -0,0 +1,94 @@
+// Package logging builds the leveled, structured logger the server and database write to.
+// Entries are JSON lines in the format CloudWatch queries expect: timestamp (RFC 3339, UTC),
+// level, message and then the entry's own fields, such as message_id and metadata. Values
+// that may be secrets are redacted before they are written.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"fitness-hack/internal/requestid"
+)
+
+// DefaultLevel is the least severe level written unless LOG_LEVEL says otherwise
+const DefaultLevel = slog.LevelInfo
+
+// ParseLevel reads a level name: debug, info, warn (or warning) or error, in any case
+func ParseLevel(name string) (slog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	default:
+		return 0, fmt.Errorf("unknown log level %q", name)
+	}
+}
+
+// LevelFromEnv returns the level set by LOG_LEVEL, or DefaultLevel if it is unset or unknown
+func LevelFromEnv() slog.Level {
+	level, err := ParseLevel(os.Getenv("LOG_LEVEL"))
+	if err != nil {
+		return DefaultLevel
+	}
+	return level
+}
+
+// New returns a logger writing entries at level and above to w. Entries logged with a
+// context carrying a request ID include it as request_id.
+func New(w io.Writer, level slog.Leveler) *slog.Logger {
+	return slog.New(contextHandler{slog.NewJSONHandler(w, &slog.HandlerOptions{
+		Level:       level,
+		ReplaceAttr: replaceAttr,
+	})})
+}
+
+// FromEnv returns a logger writing to stderr at the level set by LOG_LEVEL
+func FromEnv() *slog.Logger {
+	return New(os.Stderr, LevelFromEnv())
+}
+
+// contextHandler adds the request ID of the context each entry is logged with
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := requestid.FromContext(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
+
+// replaceAttr names the built-in fields as CloudWatchLogEntry did and redacts secrets
+func replaceAttr(groups []string, a slog.Attr) slog.Attr {
+	if len(groups) == 0 {
+		switch a.Key {
+		case slog.TimeKey:
+			return slog.String("timestamp", a.Value.Time().UTC().Format(time.RFC3339))
+		case slog.LevelKey:
+			return slog.String("level", a.Value.String())
+		case slog.MessageKey:
+			return slog.Attr{Key: "message", Value: a.Value}
+		}
+	}
+	return redact(a)
+}
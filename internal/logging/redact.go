@@ -0,0 +1,65 @@
+package logging
+
+import (
+	"log/slog"
+	"strings"
+)
+
+// Redacted replaces the value of a field that may hold a secret
+const Redacted = "[REDACTED]"
+
+// sensitiveKeys are the words that mark a field as secret wherever they appear in its name,
+// compared without case, underscores or dashes, so "password_hash" and "X-Api-Key" match
+var sensitiveKeys = []string{
+	"password",
+	"passcode",
+	"secret",
+	"token",
+	"authorization",
+	"cookie",
+	"apikey",
+	"privatekey",
+}
+
+// secretPrefixes start values that are secrets whatever field they are in: bcrypt hashes
+// and bearer credentials
+var secretPrefixes = []string{"$2a$", "$2b$", "$2y$", "Bearer "}
+
+// redact replaces the value of a that may be a secret. Fields in groups are checked one by
+// one, but maps and structs logged as a single value are not looked into.
+func redact(a slog.Attr) slog.Attr {
+	if a.Value.Kind() == slog.KindGroup {
+		return a
+	}
+	if isSensitiveKey(a.Key) {
+		return slog.String(a.Key, Redacted)
+	}
+	if a.Value.Kind() == slog.KindString {
+		value := a.Value.String()
+		if isJWT(value) {
+			return slog.String(a.Key, Redacted)
+		}
+		for _, prefix := range secretPrefixes {
+			if strings.HasPrefix(value, prefix) {
+				return slog.String(a.Key, Redacted)
+			}
+		}
+	}
+	return a
+}
+
+func isSensitiveKey(key string) bool {
+	key = strings.NewReplacer("_", "", "-", "").Replace(strings.ToLower(key))
+	for _, word := range sensitiveKeys {
+		if strings.Contains(key, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// isJWT reports whether value looks like a compact JWT: three dot-separated parts, the
+// first a base64url JSON object
+func isJWT(value string) bool {
+	return strings.HasPrefix(value, "eyJ") && strings.Count(value, ".") == 2 && !strings.ContainsAny(value, " \t\n")
+}
@@ -0,0 +1,59 @@
+// Package mailer sends transactional email such as organization invitations.
+package mailer
+
+import (
+	"context"
+	"log"
+	"os"
+	"strconv"
+)
+
+// Message is a plain-text email
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers messages
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+
+	// Ping checks that messages can be handed off, for health checks
+	Ping(ctx context.Context) error
+}
+
+// NewFromEnv returns an SMTP mailer when SMTP_HOST is set, otherwise a mailer
+// that only logs messages so local development works without a mail server
+func NewFromEnv() Mailer {
+	host := os.Getenv("SMTP_HOST")
+	if host == "" {
+		return Log{}
+	}
+
+	port, err := strconv.Atoi(os.Getenv("SMTP_PORT"))
+	if err != nil || port <= 0 {
+		port = 587
+	}
+
+	from := os.Getenv("MAIL_FROM")
+	if from == "" {
+		from = "no-reply@fitnesshack.app"
+	}
+
+	return NewSMTP(host, port, os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD"), from)
+}
+
+// Log writes messages to the standard logger instead of sending them
+type Log struct{}
+
+// Send logs the message
+func (Log) Send(ctx context.Context, msg Message) error {
+	log.Printf("mail to=%s subject=%q\n%s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
+
+// Ping always succeeds since there is nothing to connect to
+func (Log) Ping(ctx context.Context) error {
+	return nil
+}
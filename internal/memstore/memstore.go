@@ -0,0 +1,578 @@
+// Package memstore is an in-process stand-in for Redis, for standalone mode where running
+// a Redis server is more than a single-binary install should need. It speaks the Redis
+// protocol to a go-redis client over in-memory connections, so callers keep using a
+// *redis.Client, and implements the commands the API uses: strings with expiry and TTL
+// lookups, sorted sets and transactions. Lua scripts aren't supported, so the job queue and
+// leader election have standalone variants of their own.
+//
+// Everything is lost when the process stops, which is fine for caches, rate limits and
+// idempotency keys.
+package memstore
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// sweepEvery is how many commands run between sweeps for expired keys that nobody reads
+const sweepEvery = 1000
+
+// Store holds the data for every client connected to it
+type Store struct {
+	mu       sync.Mutex
+	strings  map[string]string
+	zsets    map[string]map[string]float64
+	expires  map[string]time.Time
+	commands int
+}
+
+// New returns an empty store
+func New() *Store {
+	return &Store{
+		strings: map[string]string{},
+		zsets:   map[string]map[string]float64{},
+		expires: map[string]time.Time{},
+	}
+}
+
+// Client returns a go-redis client whose connections are served by the store
+func (s *Store) Client() *redis.Client {
+	return redis.NewClient(&redis.Options{
+		Addr:            "memstore",
+		Protocol:        2,
+		DisableIdentity: true,
+		Dialer: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			client, server := net.Pipe()
+			go s.serve(server)
+			return client, nil
+		},
+	})
+}
+
+// serve answers the commands sent on conn until it is closed
+func (s *Store) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	w := bufio.NewWriter(conn)
+
+	// queued holds the commands of an open MULTI, nil outside one
+	var queued [][]string
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+		if len(args) == 0 {
+			continue
+		}
+
+		var out reply
+		switch name := strings.ToUpper(args[0]); {
+		case name == "MULTI":
+			queued = [][]string{}
+			out = status("OK")
+		case name == "EXEC":
+			if queued == nil {
+				out = errorReply("ERR EXEC without MULTI")
+				break
+			}
+			out = s.exec(queued)
+			queued = nil
+		case name == "DISCARD":
+			queued = nil
+			out = status("OK")
+		case queued != nil:
+			queued = append(queued, args)
+			out = status("QUEUED")
+		default:
+			out = s.exec([][]string{args}).([]reply)[0]
+		}
+
+		writeReply(w, out)
+		if r.Buffered() == 0 {
+			// Pipelined commands are answered together once all of them are read
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// exec runs commands atomically and returns their replies
+func (s *Store) exec(commands [][]string) reply {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	replies := make([]reply, len(commands))
+	now := time.Now()
+	for i, args := range commands {
+		replies[i] = s.run(now, strings.ToUpper(args[0]), args[1:])
+	}
+
+	s.commands += len(commands)
+	if s.commands >= sweepEvery {
+		s.commands = 0
+		for key := range s.expires {
+			s.expire(now, key)
+		}
+	}
+	return replies
+}
+
+// expire deletes key if its time to live has run out
+func (s *Store) expire(now time.Time, key string) {
+	if at, ok := s.expires[key]; ok && !now.Before(at) {
+		s.del(key)
+	}
+}
+
+// del deletes key, reporting whether it existed
+func (s *Store) del(key string) bool {
+	_, isString := s.strings[key]
+	_, isZSet := s.zsets[key]
+	delete(s.strings, key)
+	delete(s.zsets, key)
+	delete(s.expires, key)
+	return isString || isZSet
+}
+
+// exists reports whether key holds a live value
+func (s *Store) exists(now time.Time, key string) bool {
+	s.expire(now, key)
+	_, isString := s.strings[key]
+	_, isZSet := s.zsets[key]
+	return isString || isZSet
+}
+
+var (
+	errSyntax    = errorReply("ERR syntax error")
+	errNotInt    = errorReply("ERR value is not an integer or out of range")
+	errNotFloat  = errorReply("ERR value is not a valid float")
+	errWrongType = errorReply("WRONGTYPE Operation against a key holding the wrong kind of value")
+)
+
+// run executes one command with the store locked
+func (s *Store) run(now time.Time, name string, args []string) reply {
+	arity := map[string]int{
+		"GET": 1, "SET": 2, "SETNX": 2, "DEL": 1, "EXISTS": 1, "EXPIRE": 2, "PEXPIRE": 2, "TTL": 1, "PTTL": 1,
+		"ZADD": 3, "ZREM": 2, "ZCARD": 1, "ZRANGE": 3, "ZREVRANGE": 3, "ZREMRANGEBYSCORE": 3,
+		"ZSCORE": 2, "ZREVRANK": 2, "MGET": 1, "SELECT": 1,
+	}
+	if n, ok := arity[name]; ok && len(args) < n {
+		return errorReply("ERR wrong number of arguments for '" + strings.ToLower(name) + "' command")
+	}
+
+	switch name {
+	case "PING":
+		if len(args) > 0 {
+			return bulk(args[0])
+		}
+		return status("PONG")
+	case "SELECT":
+		if args[0] != "0" {
+			return errorReply("ERR DB index is out of range")
+		}
+		return status("OK")
+
+	case "GET":
+		s.expire(now, args[0])
+		if _, ok := s.zsets[args[0]]; ok {
+			return errWrongType
+		}
+		if value, ok := s.strings[args[0]]; ok {
+			return bulk(value)
+		}
+		return nilBulk{}
+	case "MGET":
+		out := make([]reply, len(args))
+		for i, key := range args {
+			s.expire(now, key)
+			if value, ok := s.strings[key]; ok {
+				out[i] = bulk(value)
+			} else {
+				out[i] = nilBulk{}
+			}
+		}
+		return out
+	case "SET":
+		return s.set(now, args)
+	case "SETNX":
+		if s.exists(now, args[0]) {
+			return integer(0)
+		}
+		s.strings[args[0]] = args[1]
+		return integer(1)
+	case "DEL":
+		var n int64
+		for _, key := range args {
+			s.expire(now, key)
+			if s.del(key) {
+				n++
+			}
+		}
+		return integer(n)
+	case "EXISTS":
+		var n int64
+		for _, key := range args {
+			if s.exists(now, key) {
+				n++
+			}
+		}
+		return integer(n)
+	case "EXPIRE", "PEXPIRE":
+		n, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return errNotInt
+		}
+		if !s.exists(now, args[0]) {
+			return integer(0)
+		}
+		unit := time.Second
+		if name == "PEXPIRE" {
+			unit = time.Millisecond
+		}
+		s.expires[args[0]] = now.Add(time.Duration(n) * unit)
+		s.expire(now, args[0])
+		return integer(1)
+	case "TTL", "PTTL":
+		// -2 for a missing key and -1 for one that never expires, like Redis
+		if !s.exists(now, args[0]) {
+			return integer(-2)
+		}
+		at, ok := s.expires[args[0]]
+		if !ok {
+			return integer(-1)
+		}
+		left := at.Sub(now)
+		if name == "TTL" {
+			return integer(int64((left + time.Second/2) / time.Second))
+		}
+		return integer(left.Milliseconds())
+
+	case "ZADD":
+		return s.zadd(now, args)
+	case "ZREM":
+		zset, err := s.zset(now, args[0], false)
+		if err != nil {
+			return err
+		}
+		var n int64
+		for _, member := range args[1:] {
+			if _, ok := zset[member]; ok {
+				delete(zset, member)
+				n++
+			}
+		}
+		s.dropEmpty(args[0])
+		return integer(n)
+	case "ZCARD":
+		zset, err := s.zset(now, args[0], false)
+		if err != nil {
+			return err
+		}
+		return integer(int64(len(zset)))
+	case "ZRANGE":
+		return s.zrange(now, args, false)
+	case "ZREVRANGE":
+		return s.zrange(now, args, true)
+	case "ZSCORE":
+		zset, err := s.zset(now, args[0], false)
+		if err != nil {
+			return err
+		}
+		if score, ok := zset[args[1]]; ok {
+			return bulk(strconv.FormatFloat(score, 'g', -1, 64))
+		}
+		return nilBulk{}
+	case "ZREVRANK":
+		zset, err := s.zset(now, args[0], false)
+		if err != nil {
+			return err
+		}
+		members := sortedMembers(zset)
+		for i := range members {
+			if members[len(members)-1-i] == args[1] {
+				return integer(int64(i))
+			}
+		}
+		return nilBulk{}
+	case "ZREMRANGEBYSCORE":
+		zset, err := s.zset(now, args[0], false)
+		if err != nil {
+			return err
+		}
+		above, minOK := parseBound(args[1], false)
+		below, maxOK := parseBound(args[2], true)
+		if !minOK || !maxOK {
+			return errorReply("ERR min or max is not a float")
+		}
+		var n int64
+		for member, score := range zset {
+			if above(score) && below(score) {
+				delete(zset, member)
+				n++
+			}
+		}
+		s.dropEmpty(args[0])
+		return integer(n)
+
+	case "EVAL", "EVALSHA":
+		return errorReply("ERR scripts are not supported by memstore")
+	}
+	return errorReply("ERR unknown command '" + strings.ToLower(name) + "'")
+}
+
+// set implements SET key value [EX seconds | PX milliseconds] [NX | XX]
+func (s *Store) set(now time.Time, args []string) reply {
+	key, value := args[0], args[1]
+	var ttl time.Duration
+	var nx, xx bool
+	for i := 2; i < len(args); i++ {
+		switch strings.ToUpper(args[i]) {
+		case "NX":
+			nx = true
+		case "XX":
+			xx = true
+		case "EX", "PX":
+			if i+1 == len(args) {
+				return errSyntax
+			}
+			n, err := strconv.ParseInt(args[i+1], 10, 64)
+			if err != nil || n <= 0 {
+				return errorReply("ERR invalid expire time in 'set' command")
+			}
+			unit := time.Second
+			if strings.ToUpper(args[i]) == "PX" {
+				unit = time.Millisecond
+			}
+			ttl = time.Duration(n) * unit
+			i++
+		default:
+			return errSyntax
+		}
+	}
+
+	exists := s.exists(now, key)
+	if (nx && exists) || (xx && !exists) {
+		return nilBulk{}
+	}
+	s.del(key)
+	s.strings[key] = value
+	if ttl > 0 {
+		s.expires[key] = now.Add(ttl)
+	}
+	return status("OK")
+}
+
+// zset returns the sorted set at key, creating it if create is set. A missing key is an
+// empty set.
+func (s *Store) zset(now time.Time, key string, create bool) (map[string]float64, reply) {
+	s.expire(now, key)
+	if _, ok := s.strings[key]; ok {
+		return nil, errWrongType
+	}
+	zset, ok := s.zsets[key]
+	if !ok && create {
+		zset = map[string]float64{}
+		s.zsets[key] = zset
+	}
+	return zset, nil
+}
+
+// dropEmpty deletes key once its sorted set is empty, as Redis does
+func (s *Store) dropEmpty(key string) {
+	if zset, ok := s.zsets[key]; ok && len(zset) == 0 {
+		s.del(key)
+	}
+}
+
+// zadd implements ZADD key score member [score member ...]
+func (s *Store) zadd(now time.Time, args []string) reply {
+	pairs := args[1:]
+	if len(pairs)%2 != 0 {
+		return errSyntax
+	}
+	scores := make([]float64, 0, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		score, err := strconv.ParseFloat(pairs[i], 64)
+		if err != nil {
+			return errNotFloat
+		}
+		scores = append(scores, score)
+	}
+
+	zset, err := s.zset(now, args[0], true)
+	if err != nil {
+		return err
+	}
+	var added int64
+	for i, score := range scores {
+		member := pairs[2*i+1]
+		if _, ok := zset[member]; !ok {
+			added++
+		}
+		zset[member] = score
+	}
+	return integer(added)
+}
+
+// sortedMembers returns the members of a sorted set from the lowest score, ties in
+// lexicographical order
+func sortedMembers(zset map[string]float64) []string {
+	members := make([]string, 0, len(zset))
+	for member := range zset {
+		members = append(members, member)
+	}
+	sort.Slice(members, func(i, j int) bool {
+		a, b := zset[members[i]], zset[members[j]]
+		if a != b {
+			return a < b
+		}
+		return members[i] < members[j]
+	})
+	return members
+}
+
+// zrange implements ZRANGE key start stop [WITHSCORES] by rank, and ZREVRANGE with reverse
+// set
+func (s *Store) zrange(now time.Time, args []string, reverse bool) reply {
+	start, err1 := strconv.Atoi(args[1])
+	stop, err2 := strconv.Atoi(args[2])
+	if err1 != nil || err2 != nil {
+		return errNotInt
+	}
+	withScores := len(args) > 3 && strings.ToUpper(args[3]) == "WITHSCORES"
+	if len(args) > 4 || (len(args) == 4 && !withScores) {
+		return errSyntax
+	}
+
+	zset, errReply := s.zset(now, args[0], false)
+	if errReply != nil {
+		return errReply
+	}
+	members := sortedMembers(zset)
+	if reverse {
+		slices.Reverse(members)
+	}
+
+	n := len(members)
+	if start < 0 {
+		start = max(n+start, 0)
+	}
+	if stop < 0 {
+		stop = n + stop
+	}
+	stop = min(stop, n-1)
+
+	out := []reply{}
+	for i := start; i <= stop; i++ {
+		out = append(out, bulk(members[i]))
+		if withScores {
+			out = append(out, bulk(strconv.FormatFloat(zset[members[i]], 'g', -1, 64)))
+		}
+	}
+	return out
+}
+
+// parseBound parses a ZRANGEBYSCORE-style bound, such as 5, (5 or -inf, into a test that
+// a score is within it
+func parseBound(s string, upper bool) (func(float64) bool, bool) {
+	exclusive := strings.HasPrefix(s, "(")
+	value, err := strconv.ParseFloat(strings.TrimPrefix(s, "("), 64)
+	if err != nil {
+		return nil, false
+	}
+	switch {
+	case upper && exclusive:
+		return func(score float64) bool { return score < value }, true
+	case upper:
+		return func(score float64) bool { return score <= value }, true
+	case exclusive:
+		return func(score float64) bool { return score > value }, true
+	}
+	return func(score float64) bool { return score >= value }, true
+}
+
+// The replies a command can give, written in the RESP2 format
+type (
+	status     string
+	errorReply string
+	integer    int64
+	bulk       string
+	nilBulk    struct{}
+	reply      interface{}
+)
+
+// readCommand reads a command sent as an array of bulk strings, or as a plain inline line
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := readLine(r)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(line, "*") {
+		return strings.Fields(line), nil
+	}
+
+	n, err := strconv.Atoi(line[1:])
+	if err != nil || n < 0 {
+		return nil, errors.New("invalid multibulk length")
+	}
+	args := make([]string, n)
+	for i := range args {
+		header, err := readLine(r)
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimPrefix(header, "$"))
+		if !strings.HasPrefix(header, "$") || err != nil || size < 0 {
+			return nil, errors.New("invalid bulk length")
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
+
+// readLine reads a line without its CRLF
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r"), nil
+}
+
+// writeReply encodes out in RESP2
+func writeReply(w *bufio.Writer, out reply) {
+	switch v := out.(type) {
+	case status:
+		w.WriteString("+" + string(v) + "\r\n")
+	case errorReply:
+		w.WriteString("-" + string(v) + "\r\n")
+	case integer:
+		w.WriteString(":" + strconv.FormatInt(int64(v), 10) + "\r\n")
+	case bulk:
+		w.WriteString("$" + strconv.Itoa(len(v)) + "\r\n" + string(v) + "\r\n")
+	case nilBulk:
+		w.WriteString("$-1\r\n")
+	case []reply:
+		w.WriteString("*" + strconv.Itoa(len(v)) + "\r\n")
+		for _, item := range v {
+			writeReply(w, item)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package memstore
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestClient(t *testing.T) {
+	ctx := context.Background()
+	rdb := New().Client()
+	defer rdb.Close()
+
+	if err := rdb.Ping(ctx).Err(); err != nil {
+		t.Fatalf("ping failed: %v", err)
+	}
+
+	rdb.Set(ctx, "user:1", "cached", 50*time.Millisecond)
+	if got, err := rdb.Get(ctx, "user:1").Result(); err != nil || got != "cached" {
+		t.Fatalf("expected cached value, got %q, %v", got, err)
+	}
+	if ok, _ := rdb.SetNX(ctx, "user:1", "other", time.Minute).Result(); ok {
+		t.Error("SETNX overwrote an existing key")
+	}
+	time.Sleep(60 * time.Millisecond)
+	if err := rdb.Get(ctx, "user:1").Err(); !errors.Is(err, redis.Nil) {
+		t.Errorf("expected the key to expire, got %v", err)
+	}
+	if ok, _ := rdb.SetNX(ctx, "user:1", "other", 0).Result(); !ok {
+		t.Error("SETNX didn't set an expired key")
+	}
+	if n, _ := rdb.Del(ctx, "user:1", "missing").Result(); n != 1 {
+		t.Errorf("expected 1 key deleted, got %d", n)
+	}
+
+	// The rate limiter's transaction
+	pipe := rdb.TxPipeline()
+	pipe.ZAdd(ctx, "rl", redis.Z{Score: 1, Member: "a"}, redis.Z{Score: 2, Member: "b"}, redis.Z{Score: 3, Member: "c"})
+	pipe.ZRemRangeByScore(ctx, "rl", "0", "1")
+	count := pipe.ZCard(ctx, "rl")
+	oldest := pipe.ZRangeWithScores(ctx, "rl", 0, 0)
+	pipe.Expire(ctx, "rl", time.Minute)
+	if _, err := pipe.Exec(ctx); err != nil {
+		t.Fatalf("transaction failed: %v", err)
+	}
+	if count.Val() != 2 || len(oldest.Val()) != 1 || oldest.Val()[0] != (redis.Z{Score: 2, Member: "b"}) {
+		t.Errorf("unexpected sorted set: count %d, oldest %v", count.Val(), oldest.Val())
+	}
+
+	if err := rdb.Get(ctx, "rl").Err(); err == nil || errors.Is(err, redis.Nil) {
+		t.Errorf("expected a type error reading a sorted set as a string, got %v", err)
+	}
+	if err := redis.NewScript(`return 1`).Run(ctx, rdb, nil).Err(); err == nil {
+		t.Error("expected scripts to be rejected")
+	}
+}
+
+func TestLeaderboardCommands(t *testing.T) {
+	ctx := context.Background()
+	rdb := New().Client()
+	defer rdb.Close()
+
+	rdb.ZAdd(ctx, "board", redis.Z{Score: 100, Member: "a"}, redis.Z{Score: 140, Member: "b"}, redis.Z{Score: 120, Member: "c"})
+	top, err := rdb.ZRevRangeWithScores(ctx, "board", 0, 1).Result()
+	if err != nil || len(top) != 2 || top[0].Member != "b" || top[1] != (redis.Z{Score: 120, Member: "c"}) {
+		t.Errorf("expected b then c, got %v, %v", top, err)
+	}
+	if rank, err := rdb.ZRevRank(ctx, "board", "a").Result(); err != nil || rank != 2 {
+		t.Errorf("expected a to rank 2, got %d, %v", rank, err)
+	}
+	if score, err := rdb.ZScore(ctx, "board", "c").Result(); err != nil || score != 120 {
+		t.Errorf("expected c to score 120, got %v, %v", score, err)
+	}
+	if err := rdb.ZRevRank(ctx, "board", "missing").Err(); !errors.Is(err, redis.Nil) {
+		t.Errorf("expected no rank for a missing member, got %v", err)
+	}
+
+	rdb.Set(ctx, "name:a", "Ana", 0)
+	names, err := rdb.MGet(ctx, "name:a", "name:missing", "board").Result()
+	if err != nil || len(names) != 3 || names[0] != "Ana" || names[1] != nil || names[2] != nil {
+		t.Errorf("expected Ana and two nils, got %v, %v", names, err)
+	}
+}
+
+func TestTTL(t *testing.T) {
+	ctx := context.Background()
+	rdb := New().Client()
+	defer rdb.Close()
+
+	rdb.Set(ctx, "vault", "key", 10*time.Minute)
+	rdb.Set(ctx, "forever", "value", 0)
+	if ttl, err := rdb.TTL(ctx, "vault").Result(); err != nil || ttl != 10*time.Minute {
+		t.Errorf("expected 10m left, got %v, %v", ttl, err)
+	}
+	if ttl, err := rdb.PTTL(ctx, "vault").Result(); err != nil || ttl <= 9*time.Minute || ttl > 10*time.Minute {
+		t.Errorf("expected about 10m left in milliseconds, got %v, %v", ttl, err)
+	}
+	if ttl, _ := rdb.TTL(ctx, "forever").Result(); ttl != -1 {
+		t.Errorf("expected -1 for a key without expiry, got %v", ttl)
+	}
+	if ttl, _ := rdb.TTL(ctx, "missing").Result(); ttl != -2 {
+		t.Errorf("expected -2 for a missing key, got %v", ttl)
+	}
+
+	rdb.Expire(ctx, "forever", 30*time.Second)
+	if ttl, _ := rdb.TTL(ctx, "forever").Result(); ttl != 30*time.Second {
+		t.Errorf("expected EXPIRE to set 30s, got %v", ttl)
+	}
+}
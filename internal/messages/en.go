@@ -0,0 +1,181 @@
+package messages
+
+var english = map[ID]string{
+	CLIUsage: `Database Migration CLI
+======================
+Usage:
+  go migrate migrate [--force]  - Run all pending migrations
+  go migrate status [--force]   - Show migration status
+                                  Both fail if applied migrations were edited; --force only warns
+  go migrate generate-models    - Generate Go models from database schema
+  go migrate create-migration <name or filename> - Create a new migration file
+  go migrate diff-migration <name> [--from-snapshot] - Write a migration bringing the database to schema.json
+                                  --from-snapshot writes one for changes made to the database since the snapshot
+  go migrate snapshot-schema    - Write the database schema to schema.json
+  go migrate grant-admin <email>   - Give a user access to the admin API
+  go migrate revoke-admin <email>  - Remove a user's admin access
+
+Examples:
+  go migrate create-migration add user profiles
+  go migrate create-migration add_user_profiles.sql
+  go migrate create-migration add-user-profiles`,
+	CLIDatabaseUsage: `Database CLI Usage:
+  migrate [--force]          - Run all pending migrations
+  generate-models            - Generate Go models from database schema
+  status [--force]           - Show migration status
+  create-migration <name or filename> - Create a new migration file (e.g. add_user_profiles.sql or "add user profiles")
+  diff-migration <name> [--from-snapshot] - Create a migration from the differences between the database and schema.json
+  snapshot-schema            - Write the database schema to schema.json
+
+Examples:
+  create-migration add user profiles
+  create-migration add_user_profiles.sql
+  create-migration add-user-profiles`,
+	CLINoCommand:             "no command specified",
+	CLIUnknownCommand:        "unknown command: %s",
+	CLICreateMigrationUsage:  "usage: create-migration <name or filename>. Example: create-migration add_user_profiles.sql",
+	CLIDiffMigrationUsage:    "usage: diff-migration <name> [--from-snapshot]. Example: diff-migration add_user_profiles",
+	CLIRoleUsage:             "usage: %s <email>",
+	CLIInvalidMigrationName:  "invalid migration name",
+	CLINoUserWithEmail:       "no user with email %s",
+	CLIRoleChanged:           "✓ %s now has the %s role",
+	CLIMigrationCreated:      "Created migration file: %s",
+	CLIMigrationEditHint:     "Edit the file to add your SQL statements.",
+	CLIRunningMigrations:     "Running migrations...",
+	CLIMigrationsCompleted:   "Migrations completed successfully",
+	CLIGeneratingModels:      "Generating models from database schema...",
+	CLIModelsGenerated:       "Models generated successfully",
+	CLIStatusTitle:           "Migration Status:",
+	CLINoMigrationsApplied:   "No migrations applied yet.",
+	CLIMigrationAppliedAt:    "✓ %s (applied at %s)",
+	CLIMigrationDrifted:      "✗ %s (edited since it was applied)",
+	CLIPendingMigrations:     "Pending migrations:",
+	CLIPendingMigration:      "  - %s",
+	CLIMigrationsUpToDate:    "All migrations are up to date.",
+	CLISchemaUpToDate:        "The database already matches %s, no migration written.",
+	CLISchemaSnapshotWritten: "Wrote the database schema to %s",
+	CLICommandFailed:         "Error: %v",
+
+	DBConnected:              "Successfully connected to database: %s",
+	DBDisconnecting:          "Disconnecting from database: %s",
+	DBHealthCheckFailed:      "Database health check failed: %v",
+	DBCreatingUser:           "Creating user",
+	MigrationsDirMissing:     "Migrations directory does not exist: %s",
+	MigrationsNoFiles:        "No migration files found",
+	MigrationApplying:        "Applying migration: %s",
+	MigrationApplied:         "Applied migration: %s",
+	MigrationDriftIgnored:    "WARNING: migration %s was edited after it was applied, continuing because of --force",
+	MigrationLockWaiting:     "Waiting for another migration run to finish...",
+	MigrationFileCreated:     "Created migration file: %s",
+	MigrationModelsGenerated: "Generated %d model files in %s",
+
+	APIShuttingDown:     "shutting down gracefully, press Ctrl+C again to force",
+	APIForcedShutdown:   "Server forced to shutdown with error: %v",
+	APIExiting:          "Server exiting",
+	APIShutdownComplete: "Graceful shutdown complete.",
+	APICloseFailed:      "Failed to close the database: %v",
+	APIGRPCListening:    "gRPC server listening on %s",
+	APIGRPCFailed:       "gRPC server error: %v",
+	WorkerStarted:       "job worker started",
+	WorkerStopped:       "job worker stopped",
+
+	JobsRequeueFailed:       "jobs: failed to requeue expired jobs: %v",
+	JobsDequeueFailed:       "jobs: failed to dequeue: %v",
+	JobsJobDead:             "jobs: %s job %s failed after %d attempts: %v",
+	JobsRecordOutcomeFailed: "jobs: failed to record outcome of %s job %s: %v",
+	JobsWorkerNotPolling:    "jobs: JOB_QUEUE_DRIVER=%s delivers jobs to Lambda, not starting the job worker",
+
+	ServerRequestFailed:            "Request failed",
+	ServerHTTPError:                "HTTP %d",
+	ServerRequestHandled:           "HTTP %d",
+	ServerGRPCFailed:               "RPC failed",
+	ServerGRPCError:                "gRPC %s",
+	ServerGRPCHandled:              "gRPC %s",
+	ServerGraphQLFailed:            "GraphQL field failed",
+	ServerDatabaseFailed:           "Database operation failed",
+	ServerCacheFailed:              "Cache operation failed",
+	ServerValidationFailed:         "Validation error",
+	ServerStorageConfigFailed:      "Failed to configure storage: %v",
+	ServerTokenCipherConfigFailed:  "Failed to configure integration token encryption: %v",
+	ServerJobQueueConfigFailed:     "Failed to configure job queue: %v",
+	ServerMigrationsFailed:         "Failed to run migrations: %v",
+	ServerStandalone:               "Running standalone: cache and job queue are in memory and scheduled jobs run in this process",
+	ServerBundledPostgres:          "Starting the bundled Postgres in %s on port %d",
+	ServerBundledPostgresFailed:    "Failed to start the bundled Postgres: %v",
+	ServerDatabaseOpenFailed:       "Failed to connect to the database: %v",
+	ServerChaosEnabled:             "WARNING: fault injection enabled (latency=%s@%.2f errors=%d@%.2f cache=%.2f prefix=%s)",
+	ServerReadinessFailed:          "Readiness check failed",
+	AlertFailed:                    "Failed to send operator alert",
+	AuthOAuthVerificationFailed:    "OAuth token verification failed",
+	AuthSSOVerificationFailed:      "SSO token verification failed",
+	AuthMergeVerificationFailed:    "Merge source verification failed",
+	AuthBreachCheckFailed:          "Password breach check failed",
+	AccountDeletionPurgeFailed:     "Account deletion purge failed",
+	AccountPurgeFailed:             "Account purge failed",
+	AccountPurgeRecordFailed:       "failed to record purge failure for deletion %s: %v",
+	AccountPurgeExportDeleteFailed: "failed to delete stored file %s of purged user %s: %v",
+	AccountPurged:                  "Purged account %s (deletion %s)",
+	GuestCleanupFailed:             "Guest cleanup failed",
+	GuestsPurged:                   "Purged %d expired guest accounts",
+	RetentionPurgeFailed:           "Retention purge failed",
+	RetentionPurged:                "Retention purge deleted %d %s older than %d days",
+	RetentionFileDeleteFailed:      "failed to delete stored file %s of purged %s: %v",
+	ReminderSchedulerFailed:        "Reminder scheduler failed",
+	ReminderPreferencesLoadFailed:  "Failed to load notification preferences for reminder",
+	ReminderRescheduleFailed:       "Failed to reschedule reminder",
+	ReminderUserLoadFailed:         "Failed to load user for reminder email",
+	ReminderEmailFailed:            "Reminder email failed",
+	WorkoutRemindersFailed:         "Workout reminders failed",
+	ProgramAdjustmentsFailed:       "Program adjustments failed",
+	ProgramAdjustmentFailed:        "Failed to adjust program",
+	SessionWeatherFailed:           "Session weather tagging failed",
+	SessionWeatherLookupFailed:     "Failed to look up session weather",
+	PushDevicesLoadFailed:          "Failed to list devices for push notification",
+	PushTokenRemoveFailed:          "Failed to remove invalid push token",
+	PushFailed:                     "Push notification failed",
+	CompanionSessionLoadFailed:     "Failed to load workout session for companion",
+	CompanionSetLogFailed:          "Failed to log workout session set",
+	PersonalRecordCheckFailed:      "Failed to check personal record",
+	InviteEmailFailed:              "Failed to send invitation email",
+	ReferralAttributionFailed:      "Referral attribution failed",
+	ExportQueueFailed:              "Failed to queue data export",
+	ExportReadFailed:               "Failed to read export archive",
+	ExportPresignFailed:            "failed to presign export %s: %v",
+	ExportStageFailed:              "data export %s failed to %s: %v",
+	ExportMarkFailedFailed:         "failed to mark data export %s as failed: %v",
+	PhotoReadFailed:                "Failed to read progress photo",
+	PhotoStoreFailed:               "Failed to store progress photo",
+	PhotoFileDeleteFailed:          "failed to delete stored file %s of progress photo %s: %v",
+	PhotoUploadCleanupFailed:       "Failed to clean up abandoned progress photo uploads",
+	IntegrationEventPurgeFailed:    "Failed to purge integration trigger events",
+	FoodDatabaseLookupFailed:       "Food database lookup failed",
+	ExerciseMediaDeleteFailed:      "failed to delete stored file %s of exercise media %s: %v",
+	ExerciseMediaStorageFailed:     "Exercise media storage request failed",
+	StravaStateFailed:              "Failed to generate oauth state",
+	StravaCallbackRejected:         "Rejected Strava callback",
+	StravaCodeExchangeFailed:       "Strava code exchange failed",
+	StravaTokenEncryptFailed:       "Failed to encrypt Strava token",
+	StravaDeauthorizeFailed:        "Strava deauthorization failed",
+	StravaEventUnknownSubscription: "Rejected Strava event for unknown subscription",
+	StravaEventStale:               "Rejected stale Strava event",
+	StravaEventLookupFailed:        "Strava event lookup failed",
+	StravaEventFailed:              "Strava event processing failed",
+	StravaSyncFailed:               "Strava sync failed",
+	StravaSyncRecordFailed:         "failed to record sync of integration %s: %v",
+	WebhookSecretFailed:            "Failed to generate webhook secret",
+	WebhookEncodeFailed:            "Failed to encode webhook event",
+	WebhookQueueFailed:             "Failed to queue webhook event",
+	WebhookDeliveryFailed:          "Webhook delivery failed",
+	WebhookAttemptRecordFailed:     "Failed to record webhook attempt",
+	CommunityPostFailed:            "Failed to post to community channel",
+	CommunityLeaderboardsFailed:    "Weekly leaderboard posting failed",
+	BenchmarkAggregationFailed:     "Benchmark aggregation failed",
+	LeaderboardRecomputeFailed:     "Leaderboard recompute failed",
+	WeeklySummariesFailed:          "Failed to claim weekly summary emails",
+	WeeklySummaryEmailFailed:       "Failed to send weekly summary email",
+	BillingStoreValidationFailed:   "Store validation failed",
+	BillingStaleNotification:       "Rejected stale store notification",
+	BillingAppStoreRejected:        "Rejected App Store notification",
+	BillingPlayRejected:            "Rejected Play Billing notification",
+	BillingPlayLookupFailed:        "Play Billing lookup failed",
+}
@@ -0,0 +1,80 @@
+package messages
+
+// spanish covers what operators read at a terminal; server logs fall back to English
+var spanish = map[ID]string{
+	CLIUsage: `CLI de migraciones de base de datos
+===================================
+Uso:
+  go migrate migrate [--force]  - Aplica todas las migraciones pendientes
+  go migrate status [--force]   - Muestra el estado de las migraciones
+                                  Ambos fallan si se editaron migraciones aplicadas; --force solo avisa
+  go migrate generate-models    - Genera los modelos Go a partir del esquema de la base de datos
+  go migrate create-migration <nombre o archivo> - Crea un nuevo archivo de migración
+  go migrate diff-migration <nombre> [--from-snapshot] - Escribe una migración que lleva la base de datos a schema.json
+                                  --from-snapshot escribe una con los cambios hechos en la base de datos desde la instantánea
+  go migrate snapshot-schema    - Escribe el esquema de la base de datos en schema.json
+  go migrate grant-admin <email>   - Da acceso a la API de administración a un usuario
+  go migrate revoke-admin <email>  - Retira el acceso de administración a un usuario
+
+Ejemplos:
+  go migrate create-migration add user profiles
+  go migrate create-migration add_user_profiles.sql
+  go migrate create-migration add-user-profiles`,
+	CLIDatabaseUsage: `Uso de la CLI de base de datos:
+  migrate [--force]          - Aplica todas las migraciones pendientes
+  generate-models            - Genera los modelos Go a partir del esquema de la base de datos
+  status [--force]           - Muestra el estado de las migraciones
+  create-migration <nombre o archivo> - Crea un nuevo archivo de migración (p. ej. add_user_profiles.sql o "add user profiles")
+  diff-migration <nombre> [--from-snapshot] - Crea una migración con las diferencias entre la base de datos y schema.json
+  snapshot-schema            - Escribe el esquema de la base de datos en schema.json
+
+Ejemplos:
+  create-migration add user profiles
+  create-migration add_user_profiles.sql
+  create-migration add-user-profiles`,
+	CLINoCommand:             "no se indicó ningún comando",
+	CLIUnknownCommand:        "comando desconocido: %s",
+	CLICreateMigrationUsage:  "uso: create-migration <nombre o archivo>. Ejemplo: create-migration add_user_profiles.sql",
+	CLIDiffMigrationUsage:    "uso: diff-migration <nombre> [--from-snapshot]. Ejemplo: diff-migration add_user_profiles",
+	CLIRoleUsage:             "uso: %s <email>",
+	CLIInvalidMigrationName:  "nombre de migración no válido",
+	CLINoUserWithEmail:       "no hay ningún usuario con el email %s",
+	CLIRoleChanged:           "✓ %s ahora tiene el rol %s",
+	CLIMigrationCreated:      "Archivo de migración creado: %s",
+	CLIMigrationEditHint:     "Edita el archivo para añadir las sentencias SQL.",
+	CLIRunningMigrations:     "Aplicando migraciones...",
+	CLIMigrationsCompleted:   "Migraciones completadas correctamente",
+	CLIGeneratingModels:      "Generando modelos a partir del esquema de la base de datos...",
+	CLIModelsGenerated:       "Modelos generados correctamente",
+	CLIStatusTitle:           "Estado de las migraciones:",
+	CLINoMigrationsApplied:   "Todavía no se ha aplicado ninguna migración.",
+	CLIMigrationAppliedAt:    "✓ %s (aplicada el %s)",
+	CLIMigrationDrifted:      "✗ %s (editada después de aplicarse)",
+	CLIPendingMigrations:     "Migraciones pendientes:",
+	CLIPendingMigration:      "  - %s",
+	CLIMigrationsUpToDate:    "Todas las migraciones están al día.",
+	CLISchemaUpToDate:        "La base de datos ya coincide con %s, no se escribió ninguna migración.",
+	CLISchemaSnapshotWritten: "Esquema de la base de datos escrito en %s",
+	CLICommandFailed:         "Error: %v",
+
+	DBConnected:              "Conectado a la base de datos: %s",
+	DBDisconnecting:          "Desconectando de la base de datos: %s",
+	MigrationsDirMissing:     "El directorio de migraciones no existe: %s",
+	MigrationsNoFiles:        "No se encontraron archivos de migración",
+	MigrationApplying:        "Aplicando migración: %s",
+	MigrationApplied:         "Migración aplicada: %s",
+	MigrationDriftIgnored:    "AVISO: la migración %s se editó después de aplicarse, se continúa por --force",
+	MigrationLockWaiting:     "Esperando a que termine otra ejecución de migraciones...",
+	MigrationFileCreated:     "Archivo de migración creado: %s",
+	MigrationModelsGenerated: "%d archivos de modelos generados en %s",
+
+	APIShuttingDown:     "apagando de forma ordenada, pulsa Ctrl+C otra vez para forzarlo",
+	APIForcedShutdown:   "Apagado forzado del servidor con error: %v",
+	APIExiting:          "Saliendo del servidor",
+	APIShutdownComplete: "Apagado ordenado completado.",
+	APICloseFailed:      "No se pudo cerrar la base de datos: %v",
+	APIGRPCListening:    "Servidor gRPC escuchando en %s",
+	APIGRPCFailed:       "Error del servidor gRPC: %v",
+	WorkerStarted:       "worker de trabajos iniciado",
+	WorkerStopped:       "worker de trabajos detenido",
+}
@@ -0,0 +1,166 @@
+package messages
+
+// Database CLI output (cmd/migrate)
+const (
+	CLIUsage                 ID = "cli.usage"
+	CLIDatabaseUsage         ID = "cli.database_usage"
+	CLINoCommand             ID = "cli.no_command"
+	CLIUnknownCommand        ID = "cli.unknown_command"
+	CLICreateMigrationUsage  ID = "cli.create_migration_usage"
+	CLIDiffMigrationUsage    ID = "cli.diff_migration_usage"
+	CLIRoleUsage             ID = "cli.role_usage"
+	CLIInvalidMigrationName  ID = "cli.invalid_migration_name"
+	CLINoUserWithEmail       ID = "cli.no_user_with_email"
+	CLIRoleChanged           ID = "cli.role_changed"
+	CLIMigrationCreated      ID = "cli.migration_created"
+	CLIMigrationEditHint     ID = "cli.migration_edit_hint"
+	CLIRunningMigrations     ID = "cli.running_migrations"
+	CLIMigrationsCompleted   ID = "cli.migrations_completed"
+	CLIGeneratingModels      ID = "cli.generating_models"
+	CLIModelsGenerated       ID = "cli.models_generated"
+	CLIStatusTitle           ID = "cli.status_title"
+	CLINoMigrationsApplied   ID = "cli.no_migrations_applied"
+	CLIMigrationAppliedAt    ID = "cli.migration_applied_at"
+	CLIMigrationDrifted      ID = "cli.migration_drifted"
+	CLIPendingMigrations     ID = "cli.pending_migrations"
+	CLIPendingMigration      ID = "cli.pending_migration"
+	CLIMigrationsUpToDate    ID = "cli.migrations_up_to_date"
+	CLISchemaUpToDate        ID = "cli.schema_up_to_date"
+	CLISchemaSnapshotWritten ID = "cli.schema_snapshot_written"
+	CLICommandFailed         ID = "cli.command_failed"
+)
+
+// Database and migration logs
+const (
+	DBConnected              ID = "db.connected"
+	DBDisconnecting          ID = "db.disconnecting"
+	DBHealthCheckFailed      ID = "db.health_check_failed"
+	DBCreatingUser           ID = "db.creating_user"
+	MigrationsDirMissing     ID = "migrate.dir_missing"
+	MigrationsNoFiles        ID = "migrate.no_files"
+	MigrationApplying        ID = "migrate.applying"
+	MigrationApplied         ID = "migrate.applied"
+	MigrationDriftIgnored    ID = "migrate.drift_ignored"
+	MigrationLockWaiting     ID = "migrate.lock_waiting"
+	MigrationFileCreated     ID = "migrate.file_created"
+	MigrationModelsGenerated ID = "migrate.models_generated"
+)
+
+// Process lifecycle logs (cmd/api and cmd/worker)
+const (
+	APIShuttingDown     ID = "api.shutting_down"
+	APIForcedShutdown   ID = "api.forced_shutdown"
+	APIExiting          ID = "api.exiting"
+	APIShutdownComplete ID = "api.shutdown_complete"
+	APICloseFailed      ID = "api.close_failed"
+	APIGRPCListening    ID = "api.grpc_listening"
+	APIGRPCFailed       ID = "api.grpc_failed"
+	WorkerStarted       ID = "worker.started"
+	WorkerStopped       ID = "worker.stopped"
+)
+
+// Job queue logs
+const (
+	JobsRequeueFailed       ID = "jobs.requeue_failed"
+	JobsDequeueFailed       ID = "jobs.dequeue_failed"
+	JobsJobDead             ID = "jobs.job_dead"
+	JobsRecordOutcomeFailed ID = "jobs.record_outcome_failed"
+	JobsWorkerNotPolling    ID = "jobs.worker_not_polling"
+)
+
+// Server logs
+const (
+	ServerRequestFailed            ID = "server.request_failed"
+	ServerHTTPError                ID = "server.http_error"
+	ServerRequestHandled           ID = "server.request_handled"
+	ServerGRPCFailed               ID = "server.grpc_failed"
+	ServerGRPCError                ID = "server.grpc_error"
+	ServerGRPCHandled              ID = "server.grpc_handled"
+	ServerGraphQLFailed            ID = "server.graphql_failed"
+	ServerDatabaseFailed           ID = "server.database_failed"
+	ServerCacheFailed              ID = "server.cache_failed"
+	ServerValidationFailed         ID = "server.validation_failed"
+	ServerStorageConfigFailed      ID = "server.storage_config_failed"
+	ServerTokenCipherConfigFailed  ID = "server.token_cipher_config_failed"
+	ServerJobQueueConfigFailed     ID = "server.job_queue_config_failed"
+	ServerMigrationsFailed         ID = "server.migrations_failed"
+	ServerStandalone               ID = "server.standalone"
+	ServerBundledPostgres          ID = "server.bundled_postgres"
+	ServerBundledPostgresFailed    ID = "server.bundled_postgres_failed"
+	ServerDatabaseOpenFailed       ID = "server.database_open_failed"
+	ServerChaosEnabled             ID = "server.chaos_enabled"
+	ServerReadinessFailed          ID = "server.readiness_failed"
+	AlertFailed                    ID = "server.alert_failed"
+	AuthOAuthVerificationFailed    ID = "auth.oauth_verification_failed"
+	AuthSSOVerificationFailed      ID = "auth.sso_verification_failed"
+	AuthMergeVerificationFailed    ID = "auth.merge_verification_failed"
+	AuthBreachCheckFailed          ID = "auth.breach_check_failed"
+	AccountDeletionPurgeFailed     ID = "account_deletion.purge_failed"
+	AccountPurgeFailed             ID = "account_deletion.account_purge_failed"
+	AccountPurgeRecordFailed       ID = "account_deletion.record_failure_failed"
+	AccountPurgeExportDeleteFailed ID = "account_deletion.export_delete_failed"
+	AccountPurged                  ID = "account_deletion.purged"
+	GuestCleanupFailed             ID = "guests.cleanup_failed"
+	GuestsPurged                   ID = "guests.purged"
+	RetentionPurgeFailed           ID = "retention.purge_failed"
+	RetentionPurged                ID = "retention.purged"
+	RetentionFileDeleteFailed      ID = "retention.file_delete_failed"
+	ReminderSchedulerFailed        ID = "reminders.scheduler_failed"
+	ReminderPreferencesLoadFailed  ID = "reminders.preferences_load_failed"
+	ReminderRescheduleFailed       ID = "reminders.reschedule_failed"
+	ReminderUserLoadFailed         ID = "reminders.user_load_failed"
+	ReminderEmailFailed            ID = "reminders.email_failed"
+	WorkoutRemindersFailed         ID = "notifications.workout_reminders_failed"
+	ProgramAdjustmentsFailed       ID = "programs.adjustments_failed"
+	ProgramAdjustmentFailed        ID = "programs.adjustment_failed"
+	SessionWeatherFailed           ID = "workouts.session_weather_failed"
+	SessionWeatherLookupFailed     ID = "workouts.session_weather_lookup_failed"
+	PushDevicesLoadFailed          ID = "notifications.devices_load_failed"
+	PushTokenRemoveFailed          ID = "notifications.token_remove_failed"
+	PushFailed                     ID = "notifications.push_failed"
+	CompanionSessionLoadFailed     ID = "companion.session_load_failed"
+	CompanionSetLogFailed          ID = "companion.set_log_failed"
+	PersonalRecordCheckFailed      ID = "workouts.personal_record_check_failed"
+	InviteEmailFailed              ID = "orgs.invite_email_failed"
+	ReferralAttributionFailed      ID = "referrals.attribution_failed"
+	ExportQueueFailed              ID = "exports.queue_failed"
+	ExportReadFailed               ID = "exports.read_failed"
+	ExportPresignFailed            ID = "exports.presign_failed"
+	ExportStageFailed              ID = "exports.stage_failed"
+	ExportMarkFailedFailed         ID = "exports.mark_failed_failed"
+	PhotoReadFailed                ID = "photos.read_failed"
+	PhotoStoreFailed               ID = "photos.store_failed"
+	PhotoFileDeleteFailed          ID = "photos.file_delete_failed"
+	PhotoUploadCleanupFailed       ID = "photos.upload_cleanup_failed"
+	IntegrationEventPurgeFailed    ID = "integrations.event_purge_failed"
+	FoodDatabaseLookupFailed       ID = "nutrition.food_database_lookup_failed"
+	ExerciseMediaDeleteFailed      ID = "exercises.media_delete_failed"
+	ExerciseMediaStorageFailed     ID = "exercises.media_storage_failed"
+	StravaStateFailed              ID = "strava.state_failed"
+	StravaCallbackRejected         ID = "strava.callback_rejected"
+	StravaCodeExchangeFailed       ID = "strava.code_exchange_failed"
+	StravaTokenEncryptFailed       ID = "strava.token_encrypt_failed"
+	StravaDeauthorizeFailed        ID = "strava.deauthorize_failed"
+	StravaEventUnknownSubscription ID = "strava.event_unknown_subscription"
+	StravaEventStale               ID = "strava.event_stale"
+	StravaEventLookupFailed        ID = "strava.event_lookup_failed"
+	StravaEventFailed              ID = "strava.event_failed"
+	StravaSyncFailed               ID = "strava.sync_failed"
+	StravaSyncRecordFailed         ID = "strava.sync_record_failed"
+	WebhookSecretFailed            ID = "webhooks.secret_failed"
+	WebhookEncodeFailed            ID = "webhooks.encode_failed"
+	WebhookQueueFailed             ID = "webhooks.queue_failed"
+	WebhookDeliveryFailed          ID = "webhooks.delivery_failed"
+	WebhookAttemptRecordFailed     ID = "webhooks.attempt_record_failed"
+	CommunityPostFailed            ID = "community.post_failed"
+	CommunityLeaderboardsFailed    ID = "community.leaderboards_failed"
+	BenchmarkAggregationFailed     ID = "analytics.benchmark_aggregation_failed"
+	LeaderboardRecomputeFailed     ID = "analytics.leaderboard_recompute_failed"
+	WeeklySummariesFailed          ID = "analytics.weekly_summaries_failed"
+	WeeklySummaryEmailFailed       ID = "analytics.weekly_summary_email_failed"
+	BillingStoreValidationFailed   ID = "billing.store_validation_failed"
+	BillingStaleNotification       ID = "billing.stale_notification"
+	BillingAppStoreRejected        ID = "billing.app_store_rejected"
+	BillingPlayRejected            ID = "billing.play_rejected"
+	BillingPlayLookupFailed        ID = "billing.play_lookup_failed"
+)
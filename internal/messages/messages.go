@@ -0,0 +1,90 @@
+// Package messages is the catalog of CLI output and server log messages. Every message has
+// a stable ID, so log-based alerts can match on the ID instead of wording that changes, and
+// text in languages other than English, chosen with MESSAGE_LANGUAGE.
+package messages
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+)
+
+// ID identifies a message. IDs are part of the log format, so don't rename them; change the
+// text in the catalogs instead.
+type ID string
+
+// DefaultLanguage is used when MESSAGE_LANGUAGE is unset and for messages a catalog lacks
+const DefaultLanguage = "en"
+
+// catalogs holds the text of each message by language. English must have every message;
+// other languages fall back to it for the ones they lack.
+var catalogs = map[string]map[ID]string{
+	"en": english,
+	"es": spanish,
+}
+
+// Language returns the configured message language: the base language of MESSAGE_LANGUAGE,
+// so "es_ES.UTF-8" selects "es", or DefaultLanguage if it is unset or has no catalog
+func Language() string {
+	lang := strings.ToLower(os.Getenv("MESSAGE_LANGUAGE"))
+	if i := strings.IndexAny(lang, "_-."); i >= 0 {
+		lang = lang[:i]
+	}
+	if _, ok := catalogs[lang]; !ok {
+		return DefaultLanguage
+	}
+	return lang
+}
+
+// Text returns the message in the configured language, formatted with args
+func Text(id ID, args ...interface{}) string {
+	return TextIn(Language(), id, args...)
+}
+
+// TextIn returns the message in lang, formatted with args. An ID without text in any
+// catalog is returned as is, so a missing message shows up instead of vanishing.
+func TextIn(lang string, id ID, args ...interface{}) string {
+	text, ok := catalogs[lang][id]
+	if !ok {
+		text, ok = english[id]
+	}
+	if !ok {
+		return string(id)
+	}
+	if len(args) == 0 {
+		return text
+	}
+	return fmt.Sprintf(text, args...)
+}
+
+// Log writes the message at info level to slog's default logger, with its ID as message_id
+func Log(id ID, args ...interface{}) {
+	slog.Info(Text(id, args...), "message_id", string(id))
+}
+
+// beforeExit are the functions Fatal runs before exiting, most recently registered first
+var (
+	beforeExitMu sync.Mutex
+	beforeExit   []func()
+)
+
+// BeforeFatalExit registers f to run when Fatal exits the process, for cleanup deferred
+// calls would do, like stopping child processes
+func BeforeFatalExit(f func()) {
+	beforeExitMu.Lock()
+	defer beforeExitMu.Unlock()
+	beforeExit = append(beforeExit, f)
+}
+
+// Fatal writes the message at error level like Log, then calls os.Exit(1)
+func Fatal(id ID, args ...interface{}) {
+	slog.Error(Text(id, args...), "message_id", string(id))
+	beforeExitMu.Lock()
+	for i := len(beforeExit) - 1; i >= 0; i-- {
+		beforeExit[i]()
+	}
+	beforeExitMu.Unlock()
+	os.Exit(1)
+}
@@ -0,0 +1,52 @@
+package messages
+
+import (
+	"regexp"
+	"testing"
+)
+
+var verb = regexp.MustCompile(`%[-+# 0-9.]*[a-zA-Z%]`)
+
+func TestCatalogsMatchEnglish(t *testing.T) {
+	for lang, catalog := range catalogs {
+		for id, text := range catalog {
+			base, ok := english[id]
+			if !ok {
+				t.Errorf("%s: %s has no English text", lang, id)
+				continue
+			}
+			got, want := verb.FindAllString(text, -1), verb.FindAllString(base, -1)
+			if len(got) != len(want) {
+				t.Errorf("%s: %s has verbs %v, English has %v", lang, id, got, want)
+				continue
+			}
+			for i := range got {
+				if got[i] != want[i] {
+					t.Errorf("%s: %s has verbs %v, English has %v", lang, id, got, want)
+					break
+				}
+			}
+		}
+	}
+}
+
+func TestText(t *testing.T) {
+	if got := TextIn("es", CLIUnknownCommand, "seed"); got != "comando desconocido: seed" {
+		t.Errorf("Spanish text = %q", got)
+	}
+	if got := TextIn("es", ServerHTTPError, 404); got != "HTTP 404" {
+		t.Errorf("fallback to English = %q", got)
+	}
+	if got := TextIn("en", ID("missing.message")); got != "missing.message" {
+		t.Errorf("unknown ID = %q", got)
+	}
+
+	t.Setenv("MESSAGE_LANGUAGE", "es_ES.UTF-8")
+	if got := Language(); got != "es" {
+		t.Errorf("Language() = %q, want es", got)
+	}
+	t.Setenv("MESSAGE_LANGUAGE", "fr")
+	if got := Language(); got != DefaultLanguage {
+		t.Errorf("Language() = %q, want %s", got, DefaultLanguage)
+	}
+}
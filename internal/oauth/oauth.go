@@ -0,0 +1,226 @@
+// Package oauth verifies ID tokens issued by third-party identity providers
+// (Google, Apple) so users can sign in without a FitnessHack password.
+package oauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MicahParks/keyfunc/v2"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	// ErrUnknownProvider is returned when no provider is registered under the requested name
+	ErrUnknownProvider = errors.New("unknown oauth provider")
+
+	// ErrInvalidToken is returned when an ID token fails signature or claim validation
+	ErrInvalidToken = errors.New("invalid id token")
+)
+
+// Identity is the verified user information extracted from an ID token
+type Identity struct {
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	FirstName     string
+	LastName      string
+
+	// Claims holds every claim of the verified token, e.g. for mapping IdP groups to roles
+	Claims map[string]interface{}
+}
+
+// Provider verifies ID tokens for a single identity provider
+type Provider interface {
+	// Name returns the provider identifier used in routes and storage (e.g. "google")
+	Name() string
+
+	// Verify validates the ID token and returns the identity it asserts
+	Verify(ctx context.Context, idToken string) (*Identity, error)
+}
+
+// OIDCConfig configures an OpenID Connect provider
+type OIDCConfig struct {
+	Name      string
+	JWKSURL   string
+	Issuers   []string
+	Audiences []string
+}
+
+// OIDCProvider verifies ID tokens against a provider's published JWKS
+type OIDCProvider struct {
+	config OIDCConfig
+
+	mu      sync.Mutex
+	keyfunc jwt.Keyfunc
+}
+
+// NewOIDCProvider creates a provider; the JWKS is fetched lazily on first use
+func NewOIDCProvider(config OIDCConfig) *OIDCProvider {
+	return &OIDCProvider{config: config}
+}
+
+// Name returns the provider identifier
+func (p *OIDCProvider) Name() string {
+	return p.config.Name
+}
+
+// getKeyfunc loads the provider JWKS once and keeps it refreshed in the background
+func (p *OIDCProvider) getKeyfunc() (jwt.Keyfunc, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.keyfunc != nil {
+		return p.keyfunc, nil
+	}
+
+	jwks, err := keyfunc.Get(p.config.JWKSURL, keyfunc.Options{
+		RefreshInterval:   time.Hour,
+		RefreshRateLimit:  5 * time.Minute,
+		RefreshTimeout:    10 * time.Second,
+		RefreshUnknownKID: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s JWKS: %w", p.config.Name, err)
+	}
+
+	p.keyfunc = jwks.Keyfunc
+	return p.keyfunc, nil
+}
+
+// Verify validates the token signature, issuer, audience and expiry
+func (p *OIDCProvider) Verify(ctx context.Context, idToken string) (*Identity, error) {
+	if len(p.config.Audiences) == 0 {
+		return nil, fmt.Errorf("%s sign-in is not configured", p.config.Name)
+	}
+
+	kf, err := p.getKeyfunc()
+	if err != nil {
+		return nil, err
+	}
+
+	claims := jwt.MapClaims{}
+	_, err = jwt.ParseWithClaims(idToken, claims, kf,
+		jwt.WithValidMethods([]string{"RS256", "ES256"}),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	issuer, _ := claims.GetIssuer()
+	if !contains(p.config.Issuers, issuer) {
+		return nil, fmt.Errorf("%w: unexpected issuer %q", ErrInvalidToken, issuer)
+	}
+
+	audiences, _ := claims.GetAudience()
+	if !containsAny(p.config.Audiences, audiences) {
+		return nil, fmt.Errorf("%w: unexpected audience", ErrInvalidToken)
+	}
+
+	subject, _ := claims.GetSubject()
+	if subject == "" {
+		return nil, fmt.Errorf("%w: missing subject", ErrInvalidToken)
+	}
+
+	identity := &Identity{
+		Provider:      p.config.Name,
+		Subject:       subject,
+		EmailVerified: boolClaim(claims["email_verified"]),
+		Claims:        claims,
+	}
+	identity.Email, _ = claims["email"].(string)
+	identity.FirstName, _ = claims["given_name"].(string)
+	identity.LastName, _ = claims["family_name"].(string)
+
+	return identity, nil
+}
+
+// boolClaim handles providers that encode booleans as strings (Apple sends "true")
+func boolClaim(value interface{}) bool {
+	switch v := value.(type) {
+	case bool:
+		return v
+	case string:
+		return v == "true"
+	default:
+		return false
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func containsAny(allowed, values []string) bool {
+	for _, v := range values {
+		if contains(allowed, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitList parses a comma separated environment variable
+func splitList(value string) []string {
+	var out []string
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+// Registry looks up providers by name
+type Registry struct {
+	providers map[string]Provider
+}
+
+// NewRegistry creates a registry containing the given providers
+func NewRegistry(providers ...Provider) *Registry {
+	r := &Registry{providers: make(map[string]Provider)}
+	for _, p := range providers {
+		r.providers[p.Name()] = p
+	}
+	return r
+}
+
+// Get returns the provider registered under name
+func (r *Registry) Get(name string) (Provider, error) {
+	p, ok := r.providers[name]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+	return p, nil
+}
+
+// NewRegistryFromEnv creates the Google and Apple providers. Client IDs are read from
+// GOOGLE_CLIENT_IDS and APPLE_CLIENT_IDS (comma separated, one per app/platform).
+func NewRegistryFromEnv() *Registry {
+	return NewRegistry(
+		NewOIDCProvider(OIDCConfig{
+			Name:      "google",
+			JWKSURL:   "https://www.googleapis.com/oauth2/v3/certs",
+			Issuers:   []string{"https://accounts.google.com", "accounts.google.com"},
+			Audiences: splitList(os.Getenv("GOOGLE_CLIENT_IDS")),
+		}),
+		NewOIDCProvider(OIDCConfig{
+			Name:      "apple",
+			JWKSURL:   "https://appleid.apple.com/auth/keys",
+			Issuers:   []string{"https://appleid.apple.com"},
+			Audiences: splitList(os.Getenv("APPLE_CLIENT_IDS")),
+		}),
+	)
+}
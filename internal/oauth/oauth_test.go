@@ -0,0 +1,125 @@
+package oauth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func newTestProvider(t *testing.T) (*OIDCProvider, *rsa.PrivateKey) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("error generating key. Err: %v", err)
+	}
+	p := NewOIDCProvider(OIDCConfig{
+		Name:      "google",
+		Issuers:   []string{"https://accounts.google.com"},
+		Audiences: []string{"client-id"},
+	})
+	p.keyfunc = func(*jwt.Token) (interface{}, error) { return &key.PublicKey, nil }
+	return p, key
+}
+
+func signToken(t *testing.T, key *rsa.PrivateKey, claims jwt.MapClaims) string {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodRS256, claims).SignedString(key)
+	if err != nil {
+		t.Fatalf("error signing token. Err: %v", err)
+	}
+	return token
+}
+
+func TestVerify(t *testing.T) {
+	p, key := newTestProvider(t)
+
+	valid := jwt.MapClaims{
+		"iss":            "https://accounts.google.com",
+		"aud":            "client-id",
+		"sub":            "12345",
+		"email":          "user@example.com",
+		"email_verified": "true",
+		"given_name":     "Jane",
+		"exp":            time.Now().Add(time.Hour).Unix(),
+	}
+
+	identity, err := p.Verify(context.Background(), signToken(t, key, valid))
+	if err != nil {
+		t.Fatalf("expected valid token, got error: %v", err)
+	}
+	if identity.Subject != "12345" || identity.Email != "user@example.com" || !identity.EmailVerified || identity.FirstName != "Jane" {
+		t.Errorf("unexpected identity: %+v", identity)
+	}
+
+	tests := map[string]func(jwt.MapClaims){
+		"wrong issuer":   func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" },
+		"wrong audience": func(c jwt.MapClaims) { c["aud"] = "other-client" },
+		"expired":        func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Hour).Unix() },
+		"missing expiry": func(c jwt.MapClaims) { delete(c, "exp") },
+		"missing sub":    func(c jwt.MapClaims) { delete(c, "sub") },
+	}
+	for name, mutate := range tests {
+		claims := jwt.MapClaims{}
+		for k, v := range valid {
+			claims[k] = v
+		}
+		mutate(claims)
+		if _, err := p.Verify(context.Background(), signToken(t, key, claims)); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("%s: expected ErrInvalidToken, got %v", name, err)
+		}
+	}
+}
+
+func TestVerifyRejectsHMAC(t *testing.T) {
+	p, _ := newTestProvider(t)
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"iss": "https://accounts.google.com",
+		"aud": "client-id",
+		"sub": "12345",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}).SignedString([]byte("secret"))
+	if err != nil {
+		t.Fatalf("error signing token. Err: %v", err)
+	}
+
+	if _, err := p.Verify(context.Background(), token); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expected ErrInvalidToken, got %v", err)
+	}
+}
+
+func TestDiscover(t *testing.T) {
+	var issuer string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/.well-known/openid-configuration" {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 issuer,
+			"authorization_endpoint": issuer + "/authorize",
+			"jwks_uri":               issuer + "/keys",
+		})
+	}))
+	defer srv.Close()
+
+	issuer = srv.URL
+	metadata, err := Discover(context.Background(), srv.URL+"/")
+	if err != nil {
+		t.Fatalf("expected discovery to succeed, got error: %v", err)
+	}
+	if metadata.JWKSURI != srv.URL+"/keys" {
+		t.Errorf("unexpected jwks_uri: %s", metadata.JWKSURI)
+	}
+
+	issuer = "https://evil.example.com"
+	if _, err := Discover(context.Background(), srv.URL); err == nil {
+		t.Error("expected issuer mismatch to be rejected")
+	}
+}
@@ -0,0 +1,71 @@
+package password
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const hibpRangeURL = "https://api.pwnedpasswords.com/range/"
+
+// BreachChecker tells whether a password is known from a data breach
+type BreachChecker interface {
+	Breached(ctx context.Context, password string) (bool, error)
+}
+
+// NewBreachCheckerFromEnv returns the checker selected by PASSWORD_BREACH_CHECK ("hibp"),
+// or nil when it isn't set, in which case passwords aren't checked for breaches
+func NewBreachCheckerFromEnv() BreachChecker {
+	switch os.Getenv("PASSWORD_BREACH_CHECK") {
+	case "hibp":
+		return &HIBP{URL: hibpRangeURL, Client: &http.Client{Timeout: 5 * time.Second}}
+	default:
+		return nil
+	}
+}
+
+// HIBP checks passwords against Have I Been Pwned's Pwned Passwords with its k-anonymity
+// range API. Only the first five characters of the password's SHA-1 hash are sent; the
+// API answers with the suffixes of every breached hash sharing them.
+type HIBP struct {
+	URL    string
+	Client *http.Client
+}
+
+// Breached reports whether the password's hash is among those the API returns
+func (h *HIBP) Breached(ctx context.Context, password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:5], hash[5:]
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, h.URL+prefix, nil)
+	if err != nil {
+		return false, err
+	}
+	// Padding hides from observers how many suffixes share the prefix
+	req.Header.Set("Add-Padding", "true")
+	resp, err := h.Client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("pwned passwords returned %s", resp.Status)
+	}
+
+	// Each line is SUFFIX:COUNT; padding lines have a count of 0
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		candidate, count, ok := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if ok && strings.EqualFold(candidate, suffix) && count != "0" {
+			return true, nil
+		}
+	}
+	return false, scanner.Err()
+}
@@ -0,0 +1,94 @@
+// Package password checks the passwords users choose against the deployment's policy: a
+// minimum length, a minimum estimated strength and, optionally, whether the password is
+// known from a data breach.
+package password
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// DefaultMinLength is the fewest characters a password may have unless configured
+	DefaultMinLength = 8
+
+	// MaxBytes is the longest password bcrypt hashes; it ignores anything past it
+	MaxBytes = 72
+)
+
+// Policy is what a new password must satisfy
+type Policy struct {
+	// MinLength is the fewest characters a password may have
+	MinLength int
+	// MinEntropyBits is the least entropy a password may have, as estimated by Entropy.
+	// 0 turns the check off.
+	MinEntropyBits float64
+}
+
+// PolicyFromEnv reads the policy from PASSWORD_MIN_LENGTH (default 8) and
+// PASSWORD_MIN_ENTROPY_BITS (default 0, off)
+func PolicyFromEnv() Policy {
+	p := Policy{MinLength: DefaultMinLength}
+	if n, err := strconv.Atoi(os.Getenv("PASSWORD_MIN_LENGTH")); err == nil && n > 0 {
+		p.MinLength = n
+	}
+	if bits, err := strconv.ParseFloat(os.Getenv("PASSWORD_MIN_ENTROPY_BITS"), 64); err == nil && bits > 0 {
+		p.MinEntropyBits = bits
+	}
+	return p
+}
+
+// Check returns an error, fit to show the user, for the first rule the password breaks
+func (p Policy) Check(password string) error {
+	if utf8.RuneCountInString(password) < p.MinLength {
+		return fmt.Errorf("password must be at least %d characters", p.MinLength)
+	}
+	if len(password) > MaxBytes {
+		return fmt.Errorf("password must be at most %d bytes", MaxBytes)
+	}
+	if p.MinEntropyBits > 0 && Entropy(password) < p.MinEntropyBits {
+		return errors.New("password is too easy to guess; make it longer or mix in other kinds of characters")
+	}
+	return nil
+}
+
+// Entropy estimates a password's entropy in bits as its length times log2 of the number
+// of characters in the classes it draws from: lowercase, uppercase, digits, ASCII symbols
+// and anything else. Words and patterns make it overrate a password, so it only keeps out
+// the weakest ones.
+func Entropy(password string) float64 {
+	var lower, upper, digit, symbol, other bool
+	for _, r := range password {
+		switch {
+		case r >= 'a' && r <= 'z':
+			lower = true
+		case r >= 'A' && r <= 'Z':
+			upper = true
+		case r >= '0' && r <= '9':
+			digit = true
+		case r < utf8.RuneSelf && unicode.IsPrint(r):
+			symbol = true
+		default:
+			other = true
+		}
+	}
+
+	pool := 0
+	for _, class := range []struct {
+		used bool
+		size int
+	}{{lower, 26}, {upper, 26}, {digit, 10}, {symbol, 33}, {other, 100}} {
+		if class.used {
+			pool += class.size
+		}
+	}
+	if pool == 0 {
+		return 0
+	}
+	return float64(utf8.RuneCountInString(password)) * math.Log2(float64(pool))
+}
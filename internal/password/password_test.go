@@ -0,0 +1,75 @@
+package password
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPolicyCheck(t *testing.T) {
+	p := Policy{MinLength: 8, MinEntropyBits: 50}
+	for password, ok := range map[string]bool{
+		"short":                    false,
+		"aaaaaaaaaa":               false, // 10 x log2(26) is 47 bits
+		"correct horse battery":    true,
+		"Tr0ub4dour&3":             true,
+		"ñandú-ñandú":              true,
+		strings.Repeat("long", 19): false, // past bcrypt's 72 bytes
+	} {
+		if err := p.Check(password); (err == nil) != ok {
+			t.Errorf("Check(%q) = %v", password, err)
+		}
+	}
+
+	if err := (Policy{MinLength: 8}).Check("aaaaaaaa"); err != nil {
+		t.Errorf("expected no entropy check when it's off, got %v", err)
+	}
+}
+
+func TestEntropy(t *testing.T) {
+	if got := Entropy("abcd"); got != 4*4.700439718141092 {
+		t.Errorf("expected 4 lowercase letters to be worth 18.8 bits, got %v", got)
+	}
+	if Entropy("abcd1234") <= Entropy("abcdefgh") {
+		t.Error("expected digits to widen the pool")
+	}
+	if got := Entropy(""); got != 0 {
+		t.Errorf("expected no entropy for an empty password, got %v", got)
+	}
+}
+
+func TestHIBPBreached(t *testing.T) {
+	// SHA-1 of "password" is 5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		if r.Header.Get("Add-Padding") != "true" {
+			t.Error("expected padded responses to be asked for")
+		}
+		if strings.HasPrefix(r.URL.Path, "/down/") {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("0018A45C4D1DEF81644B54AB7F969B88D65:1\r\n1E4C9B93F3F0682250B6CF8331B7EE68FD8:9545824\r\n0000000000000000000000000000000000A:0\r\n"))
+	}))
+	defer server.Close()
+	h := &HIBP{URL: server.URL + "/", Client: server.Client()}
+	ctx := context.Background()
+
+	if breached, err := h.Breached(ctx, "password"); err != nil || !breached {
+		t.Errorf("expected \"password\" to be breached, got %v, %v", breached, err)
+	}
+	if paths[0] != "/5BAA6" {
+		t.Errorf("expected only the hash prefix to be sent, got %s", paths[0])
+	}
+	if breached, err := h.Breached(ctx, "a password nobody has used"); err != nil || breached {
+		t.Errorf("expected an unknown password not to be breached, got %v, %v", breached, err)
+	}
+
+	h.URL = server.URL + "/down/"
+	if _, err := h.Breached(ctx, "password"); err == nil {
+		t.Error("expected an error when the API fails")
+	}
+}
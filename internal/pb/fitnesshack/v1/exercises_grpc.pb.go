@@ -0,0 +1,165 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: fitnesshack/v1/exercises.proto
+
+package fitnesshackv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	ExerciseService_GetExercise_FullMethodName   = "/fitnesshack.v1.ExerciseService/GetExercise"
+	ExerciseService_ListExercises_FullMethodName = "/fitnesshack.v1.ExerciseService/ListExercises"
+)
+
+// ExerciseServiceClient is the client API for ExerciseService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// ExerciseService reads the exercise catalog
+type ExerciseServiceClient interface {
+	GetExercise(ctx context.Context, in *GetExerciseRequest, opts ...grpc.CallOption) (*Exercise, error)
+	// ListExercises lists the catalog, newest first unless sort is set
+	ListExercises(ctx context.Context, in *ListExercisesRequest, opts ...grpc.CallOption) (*ListExercisesResponse, error)
+}
+
+type exerciseServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewExerciseServiceClient(cc grpc.ClientConnInterface) ExerciseServiceClient {
+	return &exerciseServiceClient{cc}
+}
+
+func (c *exerciseServiceClient) GetExercise(ctx context.Context, in *GetExerciseRequest, opts ...grpc.CallOption) (*Exercise, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Exercise)
+	err := c.cc.Invoke(ctx, ExerciseService_GetExercise_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *exerciseServiceClient) ListExercises(ctx context.Context, in *ListExercisesRequest, opts ...grpc.CallOption) (*ListExercisesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListExercisesResponse)
+	err := c.cc.Invoke(ctx, ExerciseService_ListExercises_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ExerciseServiceServer is the server API for ExerciseService service.
+// All implementations must embed UnimplementedExerciseServiceServer
+// for forward compatibility.
+//
+// ExerciseService reads the exercise catalog
+type ExerciseServiceServer interface {
+	GetExercise(context.Context, *GetExerciseRequest) (*Exercise, error)
+	// ListExercises lists the catalog, newest first unless sort is set
+	ListExercises(context.Context, *ListExercisesRequest) (*ListExercisesResponse, error)
+	mustEmbedUnimplementedExerciseServiceServer()
+}
+
+// UnimplementedExerciseServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedExerciseServiceServer struct{}
+
+func (UnimplementedExerciseServiceServer) GetExercise(context.Context, *GetExerciseRequest) (*Exercise, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetExercise not implemented")
+}
+func (UnimplementedExerciseServiceServer) ListExercises(context.Context, *ListExercisesRequest) (*ListExercisesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListExercises not implemented")
+}
+func (UnimplementedExerciseServiceServer) mustEmbedUnimplementedExerciseServiceServer() {}
+func (UnimplementedExerciseServiceServer) testEmbeddedByValue()                         {}
+
+// UnsafeExerciseServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ExerciseServiceServer will
+// result in compilation errors.
+type UnsafeExerciseServiceServer interface {
+	mustEmbedUnimplementedExerciseServiceServer()
+}
+
+func RegisterExerciseServiceServer(s grpc.ServiceRegistrar, srv ExerciseServiceServer) {
+	// If the following call pancis, it indicates UnimplementedExerciseServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&ExerciseService_ServiceDesc, srv)
+}
+
+func _ExerciseService_GetExercise_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetExerciseRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ExerciseServiceServer).GetExercise(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ExerciseService_GetExercise_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ExerciseServiceServer).GetExercise(ctx, req.(*GetExerciseRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ExerciseService_ListExercises_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListExercisesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ExerciseServiceServer).ListExercises(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ExerciseService_ListExercises_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ExerciseServiceServer).ListExercises(ctx, req.(*ListExercisesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// ExerciseService_ServiceDesc is the grpc.ServiceDesc for ExerciseService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ExerciseService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "fitnesshack.v1.ExerciseService",
+	HandlerType: (*ExerciseServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetExercise",
+			Handler:    _ExerciseService_GetExercise_Handler,
+		},
+		{
+			MethodName: "ListExercises",
+			Handler:    _ExerciseService_ListExercises_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "fitnesshack/v1/exercises.proto",
+}
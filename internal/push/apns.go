@@ -0,0 +1,157 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	apnsProductionURL = "https://api.push.apple.com"
+	apnsSandboxURL    = "https://api.sandbox.push.apple.com"
+
+	// apnsTokenLifetime is how long a provider token is reused; Apple rejects tokens older
+	// than an hour and throttles clients that refresh more often than every 20 minutes
+	apnsTokenLifetime = 50 * time.Minute
+)
+
+// APNs sends notifications with token-based authentication to Apple Push Notification service
+type APNs struct {
+	KeyID    string
+	TeamID   string
+	BundleID string
+	Key      *ecdsa.PrivateKey
+
+	URL    string
+	Client *http.Client
+
+	mu          sync.Mutex
+	token       string
+	tokenIssued time.Time
+}
+
+// NewAPNsFromEnv configures APNs from APNS_KEY_PATH (the .p8 signing key), APNS_KEY_ID,
+// APNS_TEAM_ID and APNS_BUNDLE_ID, returning nil unless all are set. APNS_SANDBOX=true
+// targets the development environment.
+func NewAPNsFromEnv() *APNs {
+	path := os.Getenv("APNS_KEY_PATH")
+	keyID, teamID, bundleID := os.Getenv("APNS_KEY_ID"), os.Getenv("APNS_TEAM_ID"), os.Getenv("APNS_BUNDLE_ID")
+	if path == "" || keyID == "" || teamID == "" || bundleID == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	key, err := jwt.ParseECPrivateKeyFromPEM(data)
+	if err != nil {
+		return nil
+	}
+
+	apiURL := apnsProductionURL
+	if os.Getenv("APNS_SANDBOX") == "true" {
+		apiURL = apnsSandboxURL
+	}
+	return &APNs{
+		KeyID:    keyID,
+		TeamID:   teamID,
+		BundleID: bundleID,
+		Key:      key,
+		URL:      apiURL,
+		// APNs only speaks HTTP/2, which the default transport negotiates over TLS
+		Client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+type apnsPayload struct {
+	APS struct {
+		Alert struct {
+			Title string `json:"title"`
+			Body  string `json:"body"`
+		} `json:"alert"`
+		Sound string `json:"sound"`
+	} `json:"aps"`
+	Data map[string]string `json:"data,omitempty"`
+}
+
+// Send delivers a notification to an APNs device token
+func (a *APNs) Send(ctx context.Context, token string, n Notification) error {
+	providerToken, err := a.providerToken()
+	if err != nil {
+		return err
+	}
+
+	var payload apnsPayload
+	payload.APS.Alert.Title = n.Title
+	payload.APS.Alert.Body = n.Body
+	payload.APS.Sound = "default"
+	payload.Data = n.Data
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.URL+"/3/device/"+url.PathEscape(token), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "bearer "+providerToken)
+	req.Header.Set("apns-topic", a.BundleID)
+	req.Header.Set("apns-push-type", "alert")
+	req.Header.Set("apns-priority", "10")
+
+	res, err := a.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to contact apns: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	var apiErr struct {
+		Reason string `json:"reason"`
+	}
+	json.NewDecoder(res.Body).Decode(&apiErr)
+	switch {
+	case res.StatusCode == http.StatusGone, apiErr.Reason == "BadDeviceToken", apiErr.Reason == "DeviceTokenNotForTopic":
+		return fmt.Errorf("%w: apns returned %s", ErrInvalidToken, apiErr.Reason)
+	case apiErr.Reason == "ExpiredProviderToken":
+		a.mu.Lock()
+		a.token = ""
+		a.mu.Unlock()
+	}
+	return fmt.Errorf("apns returned HTTP %d %s", res.StatusCode, apiErr.Reason)
+}
+
+// providerToken returns the signed JWT that authenticates requests, reusing it for apnsTokenLifetime
+func (a *APNs) providerToken() (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.token != "" && time.Since(a.tokenIssued) < apnsTokenLifetime {
+		return a.token, nil
+	}
+
+	now := time.Now()
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"iss": a.TeamID,
+		"iat": now.Unix(),
+	})
+	token.Header["kid"] = a.KeyID
+	signed, err := token.SignedString(a.Key)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign apns provider token: %w", err)
+	}
+	a.token, a.tokenIssued = signed, now
+	return signed, nil
+}
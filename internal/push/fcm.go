@@ -0,0 +1,198 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	googleTokenURL    = "https://oauth2.googleapis.com/token"
+	fcmMessagingScope = "https://www.googleapis.com/auth/firebase.messaging"
+	fcmAPIURL         = "https://fcm.googleapis.com/v1"
+)
+
+// ServiceAccount holds the fields of a Firebase service account key file
+type ServiceAccount struct {
+	ProjectID   string `json:"project_id"`
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+	TokenURI    string `json:"token_uri"`
+}
+
+// FCM sends notifications with the Firebase Cloud Messaging HTTP v1 API
+type FCM struct {
+	Account *ServiceAccount
+
+	APIURL string
+	Client *http.Client
+
+	mu          sync.Mutex
+	accessToken string
+	tokenExpiry time.Time
+}
+
+// NewFCMFromEnv configures FCM from FCM_SERVICE_ACCOUNT, the path to a service account key
+// file, returning nil when it isn't set or can't be read
+func NewFCMFromEnv() *FCM {
+	path := os.Getenv("FCM_SERVICE_ACCOUNT")
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var account ServiceAccount
+	if json.Unmarshal(data, &account) != nil || account.ProjectID == "" {
+		return nil
+	}
+	return &FCM{
+		Account: &account,
+		APIURL:  fcmAPIURL,
+		Client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+type fcmMessage struct {
+	Message struct {
+		Token        string            `json:"token"`
+		Notification fcmNotification   `json:"notification"`
+		Data         map[string]string `json:"data,omitempty"`
+	} `json:"message"`
+}
+
+type fcmNotification struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+}
+
+type fcmError struct {
+	Error struct {
+		Status  string `json:"status"`
+		Message string `json:"message"`
+		Details []struct {
+			ErrorCode string `json:"errorCode"`
+		} `json:"details"`
+	} `json:"error"`
+}
+
+// Send delivers a notification to an FCM registration token
+func (f *FCM) Send(ctx context.Context, token string, n Notification) error {
+	accessToken, err := f.getAccessToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	var msg fcmMessage
+	msg.Message.Token = token
+	msg.Message.Notification = fcmNotification{Title: n.Title, Body: n.Body}
+	msg.Message.Data = n.Data
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	endpoint := fmt.Sprintf("%s/projects/%s/messages:send", f.APIURL, url.PathEscape(f.Account.ProjectID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := f.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to contact fcm: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	var apiErr fcmError
+	json.NewDecoder(res.Body).Decode(&apiErr)
+	for _, detail := range apiErr.Error.Details {
+		if detail.ErrorCode == "UNREGISTERED" {
+			return fmt.Errorf("%w: %s", ErrInvalidToken, apiErr.Error.Message)
+		}
+	}
+	if res.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%w: fcm returned HTTP 404", ErrInvalidToken)
+	}
+	return fmt.Errorf("fcm returned HTTP %d %s", res.StatusCode, apiErr.Error.Status)
+}
+
+// getAccessToken exchanges a signed service account assertion for an OAuth access token,
+// caching it until shortly before it expires
+func (f *FCM) getAccessToken(ctx context.Context) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.accessToken != "" && time.Now().Before(f.tokenExpiry) {
+		return f.accessToken, nil
+	}
+
+	tokenURL := f.Account.TokenURI
+	if tokenURL == "" {
+		tokenURL = googleTokenURL
+	}
+
+	key, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(f.Account.PrivateKey))
+	if err != nil {
+		return "", fmt.Errorf("failed to parse service account key: %w", err)
+	}
+
+	now := time.Now()
+	assertion, err := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":   f.Account.ClientEmail,
+		"scope": fcmMessagingScope,
+		"aud":   tokenURL,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	}).SignedString(key)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign service account assertion: %w", err)
+	}
+
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {assertion},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	res, err := f.Client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch google access token: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("google token endpoint returned HTTP %d", res.StatusCode)
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("failed to decode google access token: %w", err)
+	}
+
+	f.accessToken = token.AccessToken
+	f.tokenExpiry = now.Add(time.Duration(token.ExpiresIn)*time.Second - time.Minute)
+	return f.accessToken, nil
+}
@@ -0,0 +1,89 @@
+// Package push sends mobile push notifications through Apple Push Notification service
+// (APNs) for iOS devices and Firebase Cloud Messaging (FCM) for Android devices.
+package push
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+)
+
+// Supported device platforms
+const (
+	PlatformIOS     = "ios"
+	PlatformAndroid = "android"
+)
+
+var (
+	// ErrNotConfigured is returned when the provider for a platform has no credentials
+	ErrNotConfigured = errors.New("push provider is not configured")
+
+	// ErrInvalidToken is returned when the provider reports the device token is no longer
+	// valid, e.g. because the app was uninstalled; the device should be forgotten
+	ErrInvalidToken = errors.New("push token is no longer valid")
+)
+
+// Notification is an alert shown on the device. Data is delivered to the app alongside it,
+// e.g. to open the screen the notification is about.
+type Notification struct {
+	Title string
+	Body  string
+	Data  map[string]string
+}
+
+// Sender delivers notifications to one provider
+type Sender interface {
+	Send(ctx context.Context, token string, n Notification) error
+}
+
+// Notifier routes notifications to the sender for the device's platform
+type Notifier struct {
+	APNs Sender
+	FCM  Sender
+}
+
+// NewFromEnv configures APNs and FCM from the environment. When neither is configured,
+// notifications are logged instead so local development works without credentials.
+func NewFromEnv() *Notifier {
+	n := &Notifier{}
+	if apns := NewAPNsFromEnv(); apns != nil {
+		n.APNs = apns
+	}
+	if fcm := NewFCMFromEnv(); fcm != nil {
+		n.FCM = fcm
+	}
+	if n.APNs == nil && n.FCM == nil {
+		n.APNs, n.FCM = Log{}, Log{}
+	}
+	return n
+}
+
+// Send delivers the notification to a device
+func (n *Notifier) Send(ctx context.Context, platform, token string, notification Notification) error {
+	var sender Sender
+	switch platform {
+	case PlatformIOS:
+		sender = n.APNs
+	case PlatformAndroid:
+		sender = n.FCM
+	default:
+		return fmt.Errorf("unsupported push platform %q", platform)
+	}
+	if sender == nil {
+		return ErrNotConfigured
+	}
+	return sender.Send(ctx, token, notification)
+}
+
+// Log writes notifications to the standard logger instead of sending them
+type Log struct{}
+
+// Send logs the notification
+func (Log) Send(ctx context.Context, token string, n Notification) error {
+	if len(token) > 8 {
+		token = token[:8] + "…"
+	}
+	log.Printf("push to=%s title=%q body=%q data=%v", token, n.Title, n.Body, n.Data)
+	return nil
+}
@@ -0,0 +1,108 @@
+package push
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestAPNsSend(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	var gotTopic, gotAuth string
+	var gotPayload apnsPayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/3/device/stale" {
+			w.WriteHeader(http.StatusGone)
+			w.Write([]byte(`{"reason":"Unregistered"}`))
+			return
+		}
+		gotTopic, gotAuth = r.Header.Get("apns-topic"), r.Header.Get("Authorization")
+		json.NewDecoder(r.Body).Decode(&gotPayload)
+	}))
+	defer server.Close()
+
+	apns := &APNs{KeyID: "KEY123", TeamID: "TEAM123", BundleID: "app.fitnesshack", Key: key, URL: server.URL, Client: server.Client()}
+	if err := apns.Send(context.Background(), "abc", Notification{Title: "Hi", Body: "There"}); err != nil {
+		t.Fatal(err)
+	}
+	if gotTopic != "app.fitnesshack" || gotPayload.APS.Alert.Title != "Hi" {
+		t.Errorf("unexpected request: topic %q payload %+v", gotTopic, gotPayload)
+	}
+
+	token, err := jwt.Parse(strings.TrimPrefix(gotAuth, "bearer "), func(*jwt.Token) (interface{}, error) { return &key.PublicKey, nil })
+	if err != nil || token.Header["kid"] != "KEY123" || token.Claims.(jwt.MapClaims)["iss"] != "TEAM123" {
+		t.Errorf("expected a provider token signed with the key, got %v (%v)", token, err)
+	}
+
+	if err := apns.Send(context.Background(), "stale", Notification{}); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expected ErrInvalidToken for an unregistered device, got %v", err)
+	}
+}
+
+func TestFCMSend(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	tokenRequests := 0
+	var sent fcmMessage
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		tokenRequests++
+		w.Write([]byte(`{"access_token":"ya29.test","expires_in":3600}`))
+	})
+	mux.HandleFunc("/projects/demo/messages:send", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer ya29.test" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&sent)
+		if sent.Message.Token == "stale" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":{"status":"NOT_FOUND","details":[{"errorCode":"UNREGISTERED"}]}}`))
+		}
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	fcm := &FCM{
+		Account: &ServiceAccount{ProjectID: "demo", ClientEmail: "push@demo.iam", PrivateKey: string(keyPEM), TokenURI: server.URL + "/token"},
+		APIURL:  server.URL,
+		Client:  server.Client(),
+	}
+	n := Notification{Title: "Hi", Body: "There", Data: map[string]string{"type": "test"}}
+	if err := fcm.Send(context.Background(), "device-token", n); err != nil {
+		t.Fatal(err)
+	}
+	if sent.Message.Token != "device-token" || sent.Message.Notification.Title != "Hi" || sent.Message.Data["type"] != "test" {
+		t.Errorf("unexpected message %+v", sent.Message)
+	}
+
+	if err := fcm.Send(context.Background(), "stale", n); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("expected ErrInvalidToken for an unregistered token, got %v", err)
+	}
+	if tokenRequests != 1 {
+		t.Errorf("expected the access token to be reused, fetched %d times", tokenRequests)
+	}
+}
+
+func TestNotifierRoutesByPlatform(t *testing.T) {
+	n := &Notifier{FCM: Log{}}
+	if err := n.Send(context.Background(), PlatformAndroid, "token", Notification{}); err != nil {
+		t.Errorf("expected android to go through FCM, got %v", err)
+	}
+	if err := n.Send(context.Background(), PlatformIOS, "token", Notification{}); !errors.Is(err, ErrNotConfigured) {
+		t.Errorf("expected ErrNotConfigured without APNs, got %v", err)
+	}
+}
@@ -0,0 +1,73 @@
+// Package requestid carries the ID of the API request being served in contexts, so the log
+// entries and outgoing HTTP calls made for it can be traced back to it.
+package requestid
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// Header is the HTTP header a request ID travels in, to the API and from it
+const Header = "X-Request-ID"
+
+// MaxLength is the longest request ID accepted from a client
+const MaxLength = 128
+
+type contextKey struct{}
+
+// New returns a fresh request ID
+func New() string {
+	return uuid.NewString()
+}
+
+// Valid reports whether an ID sent by a client may be used as is: 1 to MaxLength letters,
+// digits, dashes, underscores, dots, colons or equals signs, which covers UUIDs and AWS
+// trace IDs. Anything else could break up log lines or headers downstream.
+func Valid(id string) bool {
+	if id == "" || len(id) > MaxLength {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-' || r == '_' || r == '.' || r == ':' || r == '=':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// NewContext returns a copy of ctx carrying id
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the request ID ctx carries, or "" if it has none
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
+
+// Transport forwards the request ID of each outgoing request's context in Header, unless
+// the request sets the header itself
+type Transport struct {
+	// Base sends the requests; http.DefaultTransport when nil
+	Base http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if id := FromContext(req.Context()); id != "" && req.Header.Get(Header) == "" {
+		// RoundTrippers must not modify the request they are given
+		req = req.Clone(req.Context())
+		req.Header.Set(Header, id)
+	}
+	return base.RoundTrip(req)
+}
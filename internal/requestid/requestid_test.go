@@ -0,0 +1,61 @@
+package requestid
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestValid(t *testing.T) {
+	for id, ok := range map[string]bool{
+		"3f2c1a9e-8d4b-4c6f-9a1e-2b7d5c8e0f13":     true,
+		"Root=1-67891233-abcdef012345678912345678": true,
+		"req_42.retry:1":                 true,
+		"":                               false,
+		"has space":                      false,
+		"line\nbreak":                    false,
+		strings.Repeat("a", MaxLength+1): false,
+	} {
+		if Valid(id) != ok {
+			t.Errorf("Valid(%q) = %v", id, !ok)
+		}
+	}
+	if id := New(); !Valid(id) {
+		t.Errorf("expected new IDs to be valid, got %q", id)
+	}
+}
+
+func TestTransport(t *testing.T) {
+	var got []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Header.Get(Header))
+	}))
+	defer server.Close()
+	client := &http.Client{Transport: &Transport{}}
+
+	send := func(ctx context.Context, header string) {
+		t.Helper()
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+		if header != "" {
+			req.Header.Set(Header, header)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if header == "" && req.Header.Get(Header) != "" {
+			t.Error("expected the caller's request left unchanged")
+		}
+	}
+	ctx := NewContext(context.Background(), "req-1")
+	send(ctx, "")
+	send(ctx, "set-by-caller")
+	send(context.Background(), "")
+
+	if len(got) != 3 || got[0] != "req-1" || got[1] != "set-by-caller" || got[2] != "" {
+		t.Errorf("unexpected forwarded IDs %q", got)
+	}
+}
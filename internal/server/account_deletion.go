@@ -0,0 +1,144 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"fitness-hack/internal/database"
+	"fitness-hack/internal/messages"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Helper to convert database account deletion to response model
+func accountDeletionToResponse(deletion *database.Account_deletions) database.AccountDeletionResponse {
+	return database.AccountDeletionResponse{
+		ID:          deletion.Id,
+		Status:      string(deletion.Status),
+		RequestedAt: deletion.Requested_at,
+		PurgeAfter:  deletion.Purge_after,
+	}
+}
+
+// DELETE /api/v1/users/me
+// Schedules the caller's account for deletion after ACCOUNT_DELETION_GRACE_PERIOD
+func (s *FiberServer) requestAccountDeletion(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+	if isAPIKeyRequest(c) {
+		return errorResponse(c, fiber.StatusForbidden, "API keys cannot delete accounts")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	grace := getEnvDuration("ACCOUNT_DELETION_GRACE_PERIOD", 30*24*time.Hour)
+	deletion, err := s.db.ScheduleAccountDeletion(ctx, userID, c.IP(), time.Now().Add(grace))
+	if err != nil {
+		LogDatabaseError(s, "schedule_account_deletion", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to schedule account deletion")
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{"data": accountDeletionToResponse(deletion)})
+}
+
+// GET /api/v1/users/me/deletion
+func (s *FiberServer) getAccountDeletion(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	deletion, err := s.db.GetPendingAccountDeletion(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errorResponse(c, fiber.StatusNotFound, "No account deletion is scheduled")
+		}
+		LogDatabaseError(s, "get_account_deletion", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to fetch account deletion")
+	}
+
+	return successResponse(c, accountDeletionToResponse(deletion))
+}
+
+// DELETE /api/v1/users/me/deletion
+func (s *FiberServer) cancelAccountDeletion(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := s.db.CancelAccountDeletion(ctx, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errorResponse(c, fiber.StatusNotFound, "No account deletion is scheduled")
+		}
+		LogDatabaseError(s, "cancel_account_deletion", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to cancel account deletion")
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// StartAccountDeletionPurge periodically purges accounts whose deletion grace period
+// has ended until ctx is cancelled
+func (s *FiberServer) StartAccountDeletionPurge(ctx context.Context) {
+	s.runPeriodically(ctx, getEnvDuration("ACCOUNT_DELETION_PURGE_INTERVAL", time.Hour), s.purgeDeletedAccounts)
+}
+
+// purgeDeletedAccounts purges due accounts one transaction at a time, then removes
+// their cached entries and stored export archives
+func (s *FiberServer) purgeDeletedAccounts(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+	defer cancel()
+
+	due, err := s.db.ListDueAccountDeletions(ctx, time.Now(), 100)
+	if err != nil {
+		s.logError("ERROR", messages.AccountDeletionPurgeFailed, err, nil, map[string]interface{}{
+			"component": "account_deletion",
+		})
+		return
+	}
+
+	for _, deletion := range due {
+		purged, err := s.db.PurgeAccount(ctx, deletion.Id)
+		if errors.Is(err, sql.ErrNoRows) {
+			// Canceled or claimed by another instance in the meantime
+			continue
+		}
+		if err != nil {
+			s.logError("ERROR", messages.AccountPurgeFailed, err, nil, map[string]interface{}{
+				"component":   "account_deletion",
+				"deletion_id": deletion.Id,
+			})
+			if err := s.db.RecordAccountDeletionFailure(ctx, deletion.Id, err.Error()); err != nil {
+				messages.Log(messages.AccountPurgeRecordFailed, deletion.Id, err)
+			}
+			continue
+		}
+
+		s.DeleteCache(ctx, userCacheKey(purged.UserID))
+		for _, id := range purged.WorkoutIDs {
+			s.DeleteCache(ctx, workoutCacheKey(id))
+		}
+		for _, key := range purged.StorageKeys {
+			if err := s.storage.Delete(ctx, key); err != nil {
+				messages.Log(messages.AccountPurgeExportDeleteFailed, key, purged.UserID, err)
+			}
+		}
+		messages.Log(messages.AccountPurged, purged.UserID, deletion.Id)
+	}
+
+	if len(due) > 0 {
+		s.cache.Del(ctx, "users:list:*", "workouts:list:*", "workout_sessions:list:*")
+	}
+}
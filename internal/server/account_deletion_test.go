@@ -0,0 +1,150 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"fitness-hack/internal/database"
+	"fitness-hack/internal/database/dbtest"
+	"fitness-hack/internal/memstore"
+	"fitness-hack/internal/storage"
+)
+
+// scheduleDeletion requests deletion of userID's account through the API
+func scheduleDeletion(t *testing.T, s *FiberServer, userID string) string {
+	t.Helper()
+	req := httptest.NewRequest("DELETE", "/api/v1/users/me", nil)
+	req.Header.Set("Authorization", bearer(t, userID))
+	resp, err := s.App.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != 202 {
+		t.Fatalf("expected the deletion scheduled, got %d", resp.StatusCode)
+	}
+	var envelope struct {
+		Data database.AccountDeletionResponse
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		t.Fatal(err)
+	}
+	return envelope.Data.ID
+}
+
+func TestPurgeDeletedAccounts(t *testing.T) {
+	ctx := context.Background()
+	s, db := newFakeServer(t)
+	s.cache = memstore.New().Client()
+	s.storage = storage.NewLocal(t.TempDir())
+	t.Setenv("ACCOUNT_DELETION_GRACE_PERIOD", "-1s")
+
+	user, err := db.CreateUser(ctx, &database.Users{Email: "gone@example.com", Username: "gone"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	workout, err := db.CreateWorkout(ctx, &database.Workouts{User_id: user.Id, Name: "Legs"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.CreateProgressPhoto(ctx, &database.Progress_photos{Id: "p1", User_id: user.Id, Storage_key: "photos/p1.jpg"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.storage.Put(ctx, "photos/p1.jpg", strings.NewReader("jpeg"), "image/jpeg"); err != nil {
+		t.Fatal(err)
+	}
+	s.cache.Set(ctx, userCacheKey(user.Id), "cached", 0)
+	s.cache.Set(ctx, workoutCacheKey(workout.Id), "cached", 0)
+
+	// One deletion is not due yet and the other was canceled
+	kept, err := db.CreateUser(ctx, &database.Users{Email: "kept@example.com", Username: "kept"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.ScheduleAccountDeletion(ctx, kept.Id, "", time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	canceled, err := db.CreateUser(ctx, &database.Users{Email: "canceled@example.com", Username: "canceled"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	scheduleDeletion(t, s, canceled.Id)
+	if err := db.CancelAccountDeletion(ctx, canceled.Id); err != nil {
+		t.Fatal(err)
+	}
+
+	deletionID := scheduleDeletion(t, s, user.Id)
+	s.purgeDeletedAccounts(ctx)
+
+	if _, err := db.GetUserByID(ctx, user.Id); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("expected the user purged, got %v", err)
+	}
+	if _, err := db.GetWorkoutByID(ctx, workout.Id); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("expected the user's workout purged, got %v", err)
+	}
+	if _, err := s.storage.Get(ctx, "photos/p1.jpg"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("expected the photo removed from storage, got %v", err)
+	}
+	for _, key := range []string{userCacheKey(user.Id), workoutCacheKey(workout.Id)} {
+		if n := s.cache.Exists(ctx, key).Val(); n != 0 {
+			t.Errorf("expected %s evicted from the cache", key)
+		}
+	}
+	deletion, err := db.GetAccountDeletion(ctx, deletionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deletion.Status != database.Account_deletions_status_completed || deletion.Completed_at == nil {
+		t.Errorf("expected the deletion completed, got %+v", deletion)
+	}
+
+	for _, id := range []string{kept.Id, canceled.Id} {
+		if _, err := db.GetUserByID(ctx, id); err != nil {
+			t.Errorf("expected user %s kept, got %v", id, err)
+		}
+	}
+}
+
+// failingPurgeDB fails every purge
+type failingPurgeDB struct {
+	*dbtest.Fake
+}
+
+func (f *failingPurgeDB) PurgeAccount(ctx context.Context, deletionID string) (*database.PurgedAccount, error) {
+	return nil, errors.New("connection reset")
+}
+
+func TestPurgeDeletedAccountsRecordsFailure(t *testing.T) {
+	ctx := context.Background()
+	db := &failingPurgeDB{Fake: dbtest.NewFake()}
+	s := newTestServer(t, db)
+	s.cache = memstore.New().Client()
+	s.storage = storage.NewLocal(t.TempDir())
+
+	user, err := db.CreateUser(ctx, &database.Users{Email: "gone@example.com", Username: "gone"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	deletion, err := db.ScheduleAccountDeletion(ctx, user.Id, "", time.Now().Add(-time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.purgeDeletedAccounts(ctx)
+
+	got, err := db.GetAccountDeletion(ctx, deletion.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != database.Account_deletions_status_pending || got.Attempts != 1 || got.Last_error != "connection reset" {
+		t.Errorf("expected the failure recorded and the deletion left pending, got %+v", got)
+	}
+	if _, err := db.GetUserByID(ctx, user.Id); err != nil {
+		t.Errorf("expected the user kept after a failed purge, got %v", err)
+	}
+}
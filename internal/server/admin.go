@@ -0,0 +1,41 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// userRoleAdmin is the platform role allowed to use the /api/v1/admin routes.
+// Admins are promoted with the migrate CLI's grant-admin command.
+const userRoleAdmin = "admin"
+
+// requireAdmin rejects requests from anyone but platform admins. The role is read from the
+// database on every request so a demotion takes effect without waiting for tokens to expire,
+// and API keys are refused so admin access always involves an interactive login.
+func (s *FiberServer) requireAdmin(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+	if isAPIKeyRequest(c) {
+		return errorResponse(c, fiber.StatusForbidden, "API keys cannot access admin endpoints")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	role, err := s.db.GetUserRole(ctx, userID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		LogDatabaseError(s, "get_user_role", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to verify permissions")
+	}
+	if role != userRoleAdmin {
+		return errorResponse(c, fiber.StatusForbidden, "Admin access required")
+	}
+
+	return c.Next()
+}
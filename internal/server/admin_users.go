@@ -0,0 +1,320 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"fitness-hack/internal/database"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// claimImpersonatedBy holds the admin who was issued a token to act as its user
+	claimImpersonatedBy = "impersonated_by"
+	// claimPasswordReset marks a token that may only be used to change the password
+	claimPasswordReset = "password_reset"
+)
+
+// Helper to convert database user to the admin response model
+func adminUserToResponse(user *database.Users) database.AdminUserResponse {
+	return database.AdminUserResponse{
+		UserResponse:          userToResponse(user),
+		Role:                  string(user.Role),
+		DisabledAt:            user.Disabled_at,
+		PasswordResetRequired: user.Password_reset_required,
+	}
+}
+
+// auditAdminActions records every request an admin makes to the admin routes, after it has
+// been handled so the outcome is known. Routes under /admin/users/:id name their user as
+// the target.
+func (s *FiberServer) auditAdminActions(c *fiber.Ctx) error {
+	err := c.Next()
+
+	route := c.Route().Path
+	if !strings.HasPrefix(route, "/api/v1/admin/") {
+		// No admin route matched
+		return err
+	}
+	status := c.Response().StatusCode()
+	if err != nil {
+		status = fiber.StatusInternalServerError
+		var fiberErr *fiber.Error
+		if errors.As(err, &fiberErr) {
+			status = fiberErr.Code
+		}
+	}
+
+	entry := &database.Admin_audit_log{
+		Action:      c.Method() + " " + route,
+		Path:        utils.CopyString(c.Path()),
+		Status_code: status,
+		Ip_address:  utils.CopyString(c.IP()),
+	}
+	if adminID, idErr := getUserIDFromJWT(c); idErr == nil {
+		entry.Admin_id = &adminID
+	}
+	if strings.HasPrefix(route, "/api/v1/admin/users/:id") {
+		// Fiber reuses the request's memory, so keep a copy
+		target := utils.CopyString(c.Params("id"))
+		entry.Target_user_id = &target
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if auditErr := s.db.CreateAdminAuditLog(ctx, entry); auditErr != nil {
+		LogDatabaseError(s, "create_admin_audit_log", auditErr, c)
+	}
+	return err
+}
+
+// limitRestrictedTokens confines tokens issued for a forced password reset to changing the
+// password, and impersonation tokens to reading
+func (s *FiberServer) limitRestrictedTokens(c *fiber.Ctx) error {
+	claims, err := getJWTClaims(c)
+	if err != nil {
+		return c.Next()
+	}
+
+	if reset, _ := claims[claimPasswordReset].(bool); reset {
+		path := c.Path()
+		changesPassword := c.Method() == fiber.MethodPut && strings.HasPrefix(path, "/api/v1/users/") && strings.HasSuffix(path, "/password")
+		logsOut := c.Method() == fiber.MethodPost && path == "/api/v1/auth/logout"
+		if !changesPassword && !logsOut {
+			return codedErrorResponse(c, fiber.StatusForbidden, codePasswordResetRequired, "Password reset required")
+		}
+	}
+	if _, impersonated := claims[claimImpersonatedBy]; impersonated {
+		if c.Method() != fiber.MethodGet && c.Method() != fiber.MethodHead {
+			return errorResponse(c, fiber.StatusForbidden, "Impersonation tokens are read-only")
+		}
+	}
+	return c.Next()
+}
+
+// signOutEverywhere revokes the tokens of every session the user has
+func (s *FiberServer) signOutEverywhere(ctx context.Context, userID string) error {
+	sessions, err := s.db.RevokeAllUserSessions(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for _, session := range sessions {
+		claims := jwt.MapClaims{"jti": session.Token_id, "exp": float64(session.Expires_at.Unix())}
+		if err := s.revokeToken(ctx, claims); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GET /api/v1/admin/users?q=
+func (s *FiberServer) searchUsers(c *fiber.Ctx) error {
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" {
+		return errorResponse(c, fiber.StatusBadRequest, "q is required")
+	}
+	limit, offset := getPaginationParams(c)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	users, err := s.db.SearchUsers(ctx, query, limit, offset)
+	if err != nil {
+		LogDatabaseError(s, "search_users", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to search users")
+	}
+
+	response := make([]database.AdminUserResponse, len(users))
+	for i := range users {
+		response[i] = adminUserToResponse(&users[i])
+	}
+	return successResponse(c, response)
+}
+
+// GET /api/v1/admin/users/:id
+func (s *FiberServer) getAdminUser(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	user, err := s.db.GetUserByID(ctx, c.Params("id"))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return notFound(c, "User")
+		}
+		LogDatabaseError(s, "get_user", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to fetch user")
+	}
+	return successResponse(c, adminUserToResponse(user))
+}
+
+// GET /api/v1/admin/users/:id/resources
+func (s *FiberServer) getUserResourceCounts(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := s.db.GetUserByID(ctx, c.Params("id")); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return notFound(c, "User")
+		}
+		LogDatabaseError(s, "get_user", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to count resources")
+	}
+
+	counts, err := s.db.CountUserResources(ctx, c.Params("id"))
+	if err != nil {
+		LogDatabaseError(s, "count_user_resources", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to count resources")
+	}
+	return successResponse(c, counts)
+}
+
+// POST /api/v1/admin/users/:id/disable
+// Disabled users can't sign in, their API keys stop working and their sessions are revoked.
+func (s *FiberServer) disableUser(c *fiber.Ctx) error {
+	return s.setUserDisabled(c, true)
+}
+
+// POST /api/v1/admin/users/:id/enable
+func (s *FiberServer) enableUser(c *fiber.Ctx) error {
+	return s.setUserDisabled(c, false)
+}
+
+func (s *FiberServer) setUserDisabled(c *fiber.Ctx, disabled bool) error {
+	adminID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+	userID := c.Params("id")
+	if disabled && userID == adminID {
+		return errorResponse(c, fiber.StatusBadRequest, "You cannot disable your own account")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	user, err := s.db.SetUserDisabled(ctx, userID, disabled)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return notFound(c, "User")
+		}
+		LogDatabaseError(s, "set_user_disabled", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to update user")
+	}
+	s.cache.Del(ctx, userCacheKey(userID))
+
+	if disabled {
+		if err := s.signOutEverywhere(ctx, userID); err != nil {
+			LogDatabaseError(s, "sign_out_everywhere", err, c)
+			return errorResponse(c, fiber.StatusInternalServerError, "Account disabled but its sessions could not be revoked")
+		}
+	}
+
+	return successResponse(c, adminUserToResponse(user))
+}
+
+// POST /api/v1/admin/users/:id/password-reset
+// Signs the user out everywhere; their next password sign-in can only change the password.
+func (s *FiberServer) forcePasswordReset(c *fiber.Ctx) error {
+	userID := c.Params("id")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	user, err := s.db.GetUserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return notFound(c, "User")
+		}
+		LogDatabaseError(s, "get_user", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to require a password reset")
+	}
+	if user.Password_hash == "" {
+		return errorResponse(c, fiber.StatusConflict, "User signs in through a provider and has no password")
+	}
+
+	user, err = s.db.RequirePasswordReset(ctx, userID)
+	if err != nil {
+		LogDatabaseError(s, "require_password_reset", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to require a password reset")
+	}
+	s.cache.Del(ctx, userCacheKey(userID))
+
+	if err := s.signOutEverywhere(ctx, userID); err != nil {
+		LogDatabaseError(s, "sign_out_everywhere", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Password reset required but sessions could not be revoked")
+	}
+
+	return successResponse(c, adminUserToResponse(user))
+}
+
+// POST /api/v1/admin/users/:id/impersonate
+// Issues a short-lived, read-only token for support to see the app as the user does. The
+// token shows among the user's sessions, so they can see and revoke it.
+func (s *FiberServer) impersonateUser(c *fiber.Ctx) error {
+	adminID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	user, err := s.db.GetUserByID(ctx, c.Params("id"))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return notFound(c, "User")
+		}
+		LogDatabaseError(s, "get_user", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to impersonate user")
+	}
+	if user.Role == userRoleAdmin {
+		return errorResponse(c, fiber.StatusForbidden, "Admins cannot be impersonated")
+	}
+
+	expiresAt := time.Now().Add(getEnvDuration("ADMIN_IMPERSONATION_TTL", time.Hour))
+	token, err := s.issueSessionToken(ctx, c, user.Id, expiresAt, jwt.MapClaims{claimImpersonatedBy: adminID})
+	if err != nil {
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to generate token")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"data": database.ImpersonationResponse{
+		Token:     token,
+		ExpiresAt: expiresAt,
+		User:      userToResponse(user),
+	}})
+}
+
+// GET /api/v1/admin/audit-log?userId=
+func (s *FiberServer) listAdminAuditLog(c *fiber.Ctx) error {
+	limit, offset := getPaginationParams(c)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	entries, err := s.db.ListAdminAuditLog(ctx, c.Query("userId"), limit, offset)
+	if err != nil {
+		LogDatabaseError(s, "list_admin_audit_log", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to fetch audit log")
+	}
+
+	response := make([]database.AdminAuditLogResponse, len(entries))
+	for i, entry := range entries {
+		response[i] = database.AdminAuditLogResponse{
+			ID:           entry.Id,
+			AdminID:      entry.Admin_id,
+			Action:       entry.Action,
+			Path:         entry.Path,
+			TargetUserID: entry.Target_user_id,
+			StatusCode:   entry.Status_code,
+			IPAddress:    entry.Ip_address,
+			CreatedAt:    entry.Created_at,
+		}
+	}
+	return successResponse(c, response)
+}
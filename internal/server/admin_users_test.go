@@ -0,0 +1,152 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"fitness-hack/internal/database"
+	"fitness-hack/internal/database/dbtest"
+	"fitness-hack/internal/memstore"
+)
+
+// adminStub makes one user an admin and keeps the audit log in memory
+type adminStub struct {
+	*dbtest.Fake
+	adminID string
+
+	mu    sync.Mutex
+	audit []database.Admin_audit_log
+}
+
+func (s *adminStub) GetUserRole(ctx context.Context, userID string) (string, error) {
+	if userID == s.adminID {
+		return userRoleAdmin, nil
+	}
+	return "user", nil
+}
+
+func (s *adminStub) CreateAdminAuditLog(ctx context.Context, entry *database.Admin_audit_log) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.audit = append(s.audit, *entry)
+	return nil
+}
+
+func TestAdminUserManagement(t *testing.T) {
+	db := dbtest.NewFake()
+	stub := &adminStub{Fake: db, adminID: "admin-1"}
+	s := newTestServer(t, stub)
+	s.cache = memstore.New().Client()
+	hash, err := hashPassword("old-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ann, err := db.CreateUser(context.Background(), &database.Users{Email: "ann@example.com", Username: "ann", Password_hash: hash})
+	if err != nil {
+		t.Fatal(err)
+	}
+	admin := bearer(t, stub.adminID)
+
+	do := func(method, path, auth, body string, out interface{}) int {
+		t.Helper()
+		req := httptest.NewRequest(method, "/api/v1"+path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		resp, err := s.App.Test(req, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out != nil {
+			json.NewDecoder(resp.Body).Decode(&struct{ Data interface{} }{out})
+		}
+		return resp.StatusCode
+	}
+	login := func() (int, database.LoginResponse) {
+		t.Helper()
+		var got database.LoginResponse
+		status := do("POST", "/auth/login", "", `{"email":"ann@example.com","password":"old-secret"}`, &got)
+		return status, got
+	}
+
+	_, session := login()
+	annAuth := "Bearer " + session.Token
+	if status := do("GET", "/admin/users?q=ann", annAuth, "", nil); status != 403 {
+		t.Errorf("expected non-admins refused, got %d", status)
+	}
+	var found []database.AdminUserResponse
+	if status := do("GET", "/admin/users?q=ANN@", admin, "", &found); status != 200 || len(found) != 1 || found[0].ID != ann.Id {
+		t.Errorf("expected ann found by email, got %d %+v", status, found)
+	}
+
+	if status := do("POST", "/admin/users/"+stub.adminID+"/disable", admin, "", nil); status != 400 {
+		t.Errorf("expected admins kept from disabling themselves, got %d", status)
+	}
+	var disabled database.AdminUserResponse
+	if status := do("POST", "/admin/users/"+ann.Id+"/disable", admin, "", &disabled); status != 200 || disabled.DisabledAt == nil {
+		t.Fatalf("expected ann disabled, got %d %+v", status, disabled)
+	}
+	if status := do("GET", "/users/me", annAuth, "", nil); status != 401 {
+		t.Errorf("expected the disabled user's token revoked, got %d", status)
+	}
+	if status, _ := login(); status != 403 {
+		t.Errorf("expected a disabled user unable to log in, got %d", status)
+	}
+	if status := do("POST", "/admin/users/"+ann.Id+"/enable", admin, "", nil); status != 200 {
+		t.Fatalf("expected ann enabled, got %d", status)
+	}
+
+	if status := do("POST", "/admin/users/"+ann.Id+"/password-reset", admin, "", nil); status != 200 {
+		t.Fatalf("expected a password reset required, got %d", status)
+	}
+	status, session := login()
+	if status != 200 || !session.PasswordResetRequired {
+		t.Fatalf("expected a login restricted to resetting the password, got %d %+v", status, session)
+	}
+	resetAuth := "Bearer " + session.Token
+	if status := do("GET", "/users/me", resetAuth, "", nil); status != 403 {
+		t.Errorf("expected the reset token limited to changing the password, got %d", status)
+	}
+	if status := do("PUT", "/users/me/password", resetAuth, `{"currentPassword":"old-secret","newPassword":"new-secret"}`, nil); status != 204 {
+		t.Errorf("expected the password changed, got %d", status)
+	}
+	if stored, _ := db.GetUserByID(context.Background(), ann.Id); stored.Password_reset_required {
+		t.Error("expected the reset cleared by the password change")
+	}
+
+	var impersonation database.ImpersonationResponse
+	if status := do("POST", "/admin/users/"+ann.Id+"/impersonate", admin, "", &impersonation); status != 201 {
+		t.Fatalf("expected an impersonation token, got %d", status)
+	}
+	impersonated := "Bearer " + impersonation.Token
+	var me database.UserResponse
+	if status := do("GET", "/users/me", impersonated, "", &me); status != 200 || me.ID != ann.Id {
+		t.Errorf("expected to read as ann, got %d %+v", status, me)
+	}
+	if status := do("PUT", "/users/me", impersonated, `{"username":"hacked"}`, nil); status != 403 {
+		t.Errorf("expected impersonation to be read-only, got %d", status)
+	}
+	if status := do("POST", "/admin/users/"+stub.adminID+"/impersonate", admin, "", nil); status != 404 {
+		t.Errorf("expected an unknown user to be 404, got %d", status)
+	}
+
+	stub.mu.Lock()
+	defer stub.mu.Unlock()
+	var disables int
+	for _, entry := range stub.audit {
+		if entry.Action == "POST /api/v1/admin/users/:id/disable" && entry.Target_user_id != nil && *entry.Target_user_id == ann.Id {
+			disables++
+			if entry.Admin_id == nil || *entry.Admin_id != stub.adminID || entry.Status_code != 200 {
+				t.Errorf("expected the admin and outcome recorded, got %+v", entry)
+			}
+		}
+	}
+	if disables != 1 || len(stub.audit) != 7 {
+		t.Errorf("expected every admin request audited, got %+v", stub.audit)
+	}
+}
@@ -0,0 +1,93 @@
+package server
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"fitness-hack/internal/alerting"
+	"fitness-hack/internal/messages"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// AlertingConfig controls the error rate and latency alerts sent to operators
+type AlertingConfig struct {
+	// Interval is how often the thresholds are checked
+	Interval time.Duration
+	// Window is how far back requests count towards the error rate and latency
+	Window time.Duration
+	// Cooldown is how long an alert that was sent stays quiet while the breach lasts
+	Cooldown   time.Duration
+	Thresholds alerting.Thresholds
+}
+
+// LoadAlertingConfig reads alert settings from the environment
+func LoadAlertingConfig() AlertingConfig {
+	return AlertingConfig{
+		Interval: getEnvDuration("ALERT_CHECK_INTERVAL", time.Minute),
+		Window:   getEnvDuration("ALERT_WINDOW", 5*time.Minute),
+		Cooldown: getEnvDuration("ALERT_COOLDOWN", 30*time.Minute),
+		Thresholds: alerting.Thresholds{
+			ErrorRate:   getEnvFloat("ALERT_ERROR_RATE", 0.05),
+			LatencyP95:  getEnvDuration("ALERT_LATENCY_P95", 2*time.Second),
+			MinRequests: getEnvInt("ALERT_MIN_REQUESTS", 50),
+		},
+	}
+}
+
+// newAlertMonitor returns the monitor for the configured notifier, or nil when no
+// notifier is configured. Replicas share cooldowns through Redis.
+func (s *FiberServer) newAlertMonitor(notifier alerting.Notifier, config AlertingConfig) *alerting.Monitor {
+	if notifier == nil {
+		return nil
+	}
+	source, _ := os.Hostname()
+	return &alerting.Monitor{
+		Window:     alerting.NewWindow(config.Window),
+		Thresholds: config.Thresholds,
+		Notifier:   notifier,
+		Cooldown:   config.Cooldown,
+		Source:     source,
+		Claim: func(ctx context.Context, rule string, cooldown time.Duration) (bool, error) {
+			return s.cache.SetNX(ctx, "alerts:"+rule, source, cooldown).Result()
+		},
+	}
+}
+
+// recordRequest counts a finished request towards the alert thresholds. errorHandler has
+// already responded to any error the handler returned, so the status is final.
+func (s *FiberServer) recordRequest(c *fiber.Ctx, latency time.Duration) {
+	if s.alerts == nil {
+		return
+	}
+	s.alerts.Window.Record(time.Now(), c.Response().StatusCode(), latency)
+}
+
+// StartAlerting checks this replica's error rate and latency every ALERT_CHECK_INTERVAL
+// (default 1 minute) until ctx is cancelled. Every replica checks its own traffic, so it
+// doesn't wait for leader election. Nothing is checked unless ALERT_SLACK_WEBHOOK_URL is set.
+func (s *FiberServer) StartAlerting(ctx context.Context) {
+	if s.alerts == nil {
+		return
+	}
+	ticker := time.NewTicker(LoadAlertingConfig().Interval)
+
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				checkCtx, cancel := context.WithTimeout(ctx, 15*time.Second)
+				if err := s.alerts.Check(checkCtx, time.Now()); err != nil {
+					s.logError("ERROR", messages.AlertFailed, err, nil, map[string]interface{}{
+						"component": "alerting",
+					})
+				}
+				cancel()
+			}
+		}
+	}()
+}
@@ -0,0 +1,194 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"os"
+	"time"
+
+	"fitness-hack/internal/database"
+
+	jwtware "github.com/gofiber/contrib/jwt"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	// apiKeyPrefix marks FitnessHack keys so they are easy to spot in leaked-secret scans
+	apiKeyPrefix = "fh_"
+
+	apiKeyScopeRead  = "read"
+	apiKeyScopeWrite = "write"
+	// apiKeyScopeTriggers keys are for automation connectors such as Zapier and Make: they
+	// can only poll integration triggers, so a leaked one exposes no more than the events
+	apiKeyScopeTriggers = "triggers"
+)
+
+// Helper to hash an API key for storage and lookup. Keys are long random
+// strings, so a fast hash is sufficient unlike user passwords.
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// Helper to convert database API key to response model
+func apiKeyToResponse(key *database.Api_keys) database.APIKeyResponse {
+	return database.APIKeyResponse{
+		ID:         key.Id,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scope:      string(key.Scope),
+		LastUsedAt: key.Last_used_at,
+		CreatedAt:  key.Created_at,
+	}
+}
+
+// isAPIKeyRequest reports whether the request was authenticated with an API key
+func isAPIKeyRequest(c *fiber.Ctx) bool {
+	_, ok := c.Locals("api_key_id").(string)
+	return ok
+}
+
+// authenticate accepts either an X-API-Key header or a JWT bearer token and
+// stores the caller's ID in c.Locals("user_id")
+func (s *FiberServer) authenticate() fiber.Handler {
+	jwtAuth := jwtware.New(jwtware.Config{
+		SigningKey: jwtware.SigningKey{Key: []byte(os.Getenv("JWT_SECRET"))},
+		SuccessHandler: func(c *fiber.Ctx) error {
+			if userID, err := getUserIDFromJWT(c); err == nil {
+				c.Locals("user_id", userID)
+			}
+			return c.Next()
+		},
+		ErrorHandler: func(c *fiber.Ctx, err error) error {
+			return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+		},
+	})
+
+	return func(c *fiber.Ctx) error {
+		if key := c.Get("X-API-Key"); key != "" {
+			return s.apiKeyAuth(c, key)
+		}
+		return jwtAuth(c)
+	}
+}
+
+// apiKeyAuth validates an API key and enforces its scope
+func (s *FiberServer) apiKeyAuth(c *fiber.Ctx, key string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	apiKey, err := s.db.AuthenticateAPIKey(ctx, hashAPIKey(key))
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			LogDatabaseError(s, "authenticate_api_key", err, c)
+		}
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	if apiKey.Scope != apiKeyScopeWrite && c.Method() != fiber.MethodGet && c.Method() != fiber.MethodHead {
+		return errorResponse(c, fiber.StatusForbidden, "API key is read-only")
+	}
+	if apiKey.Scope == apiKeyScopeTriggers && !isTriggerPath(c.Path()) {
+		return errorResponse(c, fiber.StatusForbidden, "API key can only poll integration triggers")
+	}
+
+	c.Locals("user_id", apiKey.User_id)
+	c.Locals("api_key_id", apiKey.Id)
+	return c.Next()
+}
+
+// POST /api/v1/users/me/api-keys
+func (s *FiberServer) createAPIKey(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+	if isAPIKeyRequest(c) {
+		return errorResponse(c, fiber.StatusForbidden, "API keys cannot create other API keys")
+	}
+
+	var req database.CreateAPIKeyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+	if req.Scope == "" {
+		req.Scope = apiKeyScopeRead
+	}
+	if !database.Api_keys_scope(req.Scope).Valid() {
+		return errorResponse(c, fiber.StatusBadRequest, "Scope must be 'read', 'write' or 'triggers'")
+	}
+
+	secret, err := randomHex(24)
+	if err != nil {
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to create API key")
+	}
+	key := apiKeyPrefix + secret
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	created, err := s.db.CreateAPIKey(ctx, &database.Api_keys{
+		User_id:  userID,
+		Name:     req.Name,
+		Prefix:   key[:len(apiKeyPrefix)+8],
+		Key_hash: hashAPIKey(key),
+		Scope:    database.Api_keys_scope(req.Scope),
+	})
+	if err != nil {
+		LogDatabaseError(s, "create_api_key", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to create API key")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"data": database.CreatedAPIKeyResponse{
+		APIKeyResponse: apiKeyToResponse(created),
+		Key:            key,
+	}})
+}
+
+// GET /api/v1/users/me/api-keys
+func (s *FiberServer) listAPIKeys(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	keys, err := s.db.ListAPIKeysByUser(ctx, userID)
+	if err != nil {
+		LogDatabaseError(s, "list_api_keys", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to fetch API keys")
+	}
+
+	response := make([]database.APIKeyResponse, len(keys))
+	for i := range keys {
+		response[i] = apiKeyToResponse(&keys[i])
+	}
+
+	return successResponse(c, response)
+}
+
+// DELETE /api/v1/users/me/api-keys/:keyId
+func (s *FiberServer) deleteAPIKey(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := s.db.DeleteAPIKey(ctx, c.Params("keyId"), userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return notFound(c, "API key")
+		}
+		LogDatabaseError(s, "delete_api_key", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to delete API key")
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,95 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"fitness-hack/internal/database"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// createTestAPIKey creates an API key with scope for userID and returns it with its ID
+func createTestAPIKey(t *testing.T, s *FiberServer, userID, scope string) (key, id string) {
+	t.Helper()
+	req := httptest.NewRequest("POST", "/api/v1/users/me/api-keys", strings.NewReader(`{"name":"test","scope":"`+scope+`"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", bearer(t, userID))
+	resp, err := s.App.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var envelope struct {
+		Data database.CreatedAPIKeyResponse `json:"data"`
+	}
+	json.NewDecoder(resp.Body).Decode(&envelope)
+	if resp.StatusCode != fiber.StatusCreated || envelope.Data.Key == "" {
+		t.Fatalf("expected a %s key to be created, got %d", scope, resp.StatusCode)
+	}
+	return envelope.Data.Key, envelope.Data.ID
+}
+
+// apiKeyStatus returns the status of method path sent with the API key key
+func apiKeyStatus(t *testing.T, s *FiberServer, method, path, key, body string) int {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-API-Key", key)
+	resp, err := s.App.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode
+}
+
+func TestReadOnlyAPIKey(t *testing.T) {
+	s, db := newFakeServer(t)
+	workout, err := db.CreateWorkout(context.Background(), &database.Workouts{User_id: "u1", Name: "Leg day"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	readKey, _ := createTestAPIKey(t, s, "u1", "read")
+	writeKey, _ := createTestAPIKey(t, s, "u1", "write")
+
+	if status := apiKeyStatus(t, s, "GET", "/api/v1/workouts/"+workout.Id, readKey, ""); status != fiber.StatusOK {
+		t.Errorf("expected a read key to read, got %d", status)
+	}
+	for _, tt := range []struct{ method, path, body string }{
+		{"POST", "/api/v1/workouts", `{"name":"Push day"}`},
+		{"PUT", "/api/v1/workouts/" + workout.Id, `{"name":"Pull day","version":1}`},
+		{"DELETE", "/api/v1/workouts/" + workout.Id, ""},
+	} {
+		if status := apiKeyStatus(t, s, tt.method, tt.path, readKey, tt.body); status != fiber.StatusForbidden {
+			t.Errorf("expected a read key's %s rejected, got %d", tt.method, status)
+		}
+	}
+	if _, err := db.GetWorkoutByID(context.Background(), workout.Id); err != nil {
+		t.Errorf("expected the workout to survive the read key's DELETE, got %v", err)
+	}
+
+	if status := apiKeyStatus(t, s, "POST", "/api/v1/workouts", writeKey, `{"name":"Push day"}`); status != fiber.StatusCreated {
+		t.Errorf("expected a write key to create, got %d", status)
+	}
+}
+
+func TestUnknownOrRevokedAPIKey(t *testing.T) {
+	s, _ := newFakeServer(t)
+	key, id := createTestAPIKey(t, s, "u1", "write")
+
+	if status := apiKeyStatus(t, s, "GET", "/api/v1/workouts", key, ""); status != fiber.StatusOK {
+		t.Fatalf("expected the key accepted before it is revoked, got %d", status)
+	}
+	if status := apiKeyStatus(t, s, "GET", "/api/v1/workouts", apiKeyPrefix+"0123456789abcdef", ""); status != fiber.StatusUnauthorized {
+		t.Errorf("expected an unknown key rejected, got %d", status)
+	}
+
+	if status := authedStatus(t, s, "DELETE", "/api/v1/users/me/api-keys/"+id, bearer(t, "u1")); status != fiber.StatusNoContent {
+		t.Fatalf("expected the key to be revoked, got %d", status)
+	}
+	if status := apiKeyStatus(t, s, "GET", "/api/v1/workouts", key, ""); status != fiber.StatusUnauthorized {
+		t.Errorf("expected the revoked key rejected, got %d", status)
+	}
+}
@@ -0,0 +1,209 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
+
+	"fitness-hack/internal/database"
+	"fitness-hack/internal/messages"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// benchmarkHistoryDays is how far back the lifts benchmarks compare go, for everyone in a
+// distribution and for the user placed in it
+const benchmarkHistoryDays = 365
+
+// StartBenchmarkAggregation periodically rebuilds the benchmark distributions from the lifts
+// of consenting users (every BENCHMARK_AGGREGATION_INTERVAL, default 24h)
+func (s *FiberServer) StartBenchmarkAggregation(ctx context.Context) {
+	s.runPeriodically(ctx, getEnvDuration("BENCHMARK_AGGREGATION_INTERVAL", 24*time.Hour), s.aggregateBenchmarks)
+}
+
+// aggregateBenchmarks rebuilds the benchmark distributions. Distributions of fewer than
+// BENCHMARK_MIN_SAMPLE_SIZE lifters (default 20) aren't published.
+func (s *FiberServer) aggregateBenchmarks(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+	defer cancel()
+
+	since := time.Now().AddDate(0, 0, -benchmarkHistoryDays)
+	if _, err := s.db.RefreshBenchmarkDistributions(ctx, since, getEnvInt("BENCHMARK_MIN_SAMPLE_SIZE", 20)); err != nil {
+		s.logError("ERROR", messages.BenchmarkAggregationFailed, err, nil, map[string]interface{}{"component": "benchmarks"})
+	}
+}
+
+// percentileRank returns the share of lifters, out of 100, whose estimate is below kg, from
+// the 1st to 99th percentiles of a distribution
+func percentileRank(percentiles []float64, kg float64) int {
+	return sort.SearchFloat64s(percentiles, kg)
+}
+
+// benchmarkToResponse converts a distribution to its response model, placing the user's
+// best estimated one-rep max in it when they have one
+func benchmarkToResponse(distribution *database.Benchmark_distributions, best *decimal.Decimal) (database.BenchmarkResponse, error) {
+	var percentiles []float64
+	if err := json.Unmarshal(distribution.Percentiles, &percentiles); err != nil || len(percentiles) != 99 {
+		return database.BenchmarkResponse{}, errors.New("benchmark distribution must hold 99 percentiles")
+	}
+	response := database.BenchmarkResponse{
+		ExerciseID:      distribution.Exercise_id,
+		BodyweightClass: distribution.Bodyweight_class,
+		SampleSize:      distribution.Sample_size,
+		ComputedAt:      distribution.Computed_at,
+		Percentiles: database.BenchmarkPercentilesResponse{
+			P10: roundKg(percentiles[9]),
+			P25: roundKg(percentiles[24]),
+			P50: roundKg(percentiles[49]),
+			P75: roundKg(percentiles[74]),
+			P90: roundKg(percentiles[89]),
+		},
+	}
+	if best != nil {
+		kg := best.Round(2).InexactFloat64()
+		response.You = &database.BenchmarkLiftResponse{
+			EstimatedOneRepMaxKg: kg,
+			Percentile:           percentileRank(percentiles, kg),
+		}
+	}
+	return response, nil
+}
+
+// GET /api/v1/analytics/benchmarks?exercise=uuid&bodyweight_class=80-90
+// Compares the user's best estimated one-rep max of the last year with the anonymized
+// distribution of consenting lifters. The class defaults to the user's by their latest
+// logged bodyweight, or to all lifters when they never logged one. Only users who opted in
+// can compare, since benchmarks are built from the lifts of those who did.
+func (s *FiberServer) getBenchmarks(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	exerciseID := c.Query("exercise")
+	if _, err := uuid.Parse(exerciseID); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, "exercise must be an exercise ID")
+	}
+	class := c.Query("bodyweight_class")
+	if class != "" && !database.ValidBodyweightClass(class) {
+		return errorResponse(c, fiber.StatusBadRequest, "Unknown bodyweight_class")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	profile, err := s.db.GetBenchmarkProfile(ctx, userID)
+	if err != nil {
+		LogDatabaseError(s, "get_benchmark_profile", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to get benchmarks")
+	}
+	if profile.ConsentedAt == nil {
+		return errorResponse(c, fiber.StatusForbidden, "Opt in to benchmarking to compare your lifts")
+	}
+	if class == "" {
+		class = database.BenchmarkAllClass
+		if profile.BodyweightKg != nil {
+			class = database.BodyweightClassOf(profile.BodyweightKg.InexactFloat64())
+		}
+	}
+
+	distribution, err := s.db.GetBenchmarkDistribution(ctx, exerciseID, class)
+	if errors.Is(err, sql.ErrNoRows) {
+		return errorResponse(c, fiber.StatusNotFound, "Not enough lifters to benchmark this exercise in this class yet")
+	}
+	if err != nil {
+		LogDatabaseError(s, "get_benchmark_distribution", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to get benchmarks")
+	}
+
+	sets, err := s.db.ListExerciseSets(ctx, userID, exerciseID, time.Now().AddDate(0, 0, -benchmarkHistoryDays))
+	if err != nil {
+		LogDatabaseError(s, "list_exercise_sets", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to get benchmarks")
+	}
+	var best *decimal.Decimal
+	for i := range sets {
+		estimate := estimateOneRepMax(sets[i].Weight_kg, sets[i].Reps)
+		if best == nil || estimate.GreaterThan(*best) {
+			best = &estimate
+		}
+	}
+
+	response, err := benchmarkToResponse(distribution, best)
+	if err != nil {
+		LogDatabaseError(s, "get_benchmark_distribution", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to get benchmarks")
+	}
+	return successResponse(c, response)
+}
+
+// GET /api/v1/analytics/benchmarks/consent
+func (s *FiberServer) getBenchmarkConsent(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	profile, err := s.db.GetBenchmarkProfile(ctx, userID)
+	if err != nil {
+		LogDatabaseError(s, "get_benchmark_profile", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to get benchmark consent")
+	}
+	return successResponse(c, database.BenchmarkConsentResponse{
+		OptedIn:     profile.ConsentedAt != nil,
+		ConsentedAt: profile.ConsentedAt,
+	})
+}
+
+// PUT /api/v1/analytics/benchmarks/consent
+// Opts the user in to benchmarking, adding their lifts to the next aggregation
+func (s *FiberServer) putBenchmarkConsent(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	consent, err := s.db.GrantBenchmarkConsent(ctx, userID)
+	if err != nil {
+		LogDatabaseError(s, "grant_benchmark_consent", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to opt in to benchmarking")
+	}
+	return successResponse(c, database.BenchmarkConsentResponse{
+		OptedIn:     true,
+		ConsentedAt: &consent.Consented_at,
+	})
+}
+
+// DELETE /api/v1/analytics/benchmarks/consent
+// Opts the user out of benchmarking. Their lifts leave the distributions at the next
+// aggregation.
+func (s *FiberServer) deleteBenchmarkConsent(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err = s.db.RevokeBenchmarkConsent(ctx, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return errorResponse(c, fiber.StatusNotFound, "Not opted in to benchmarking")
+	}
+	if err != nil {
+		LogDatabaseError(s, "revoke_benchmark_consent", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to opt out of benchmarking")
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,108 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"fitness-hack/internal/database"
+	"fitness-hack/internal/database/dbtest"
+
+	"github.com/shopspring/decimal"
+)
+
+const benchmarkExerciseID = "3f2c1a9e-8d4b-4c6f-9a1e-2b7d5c8e0f13"
+
+// benchmarksStub publishes one distribution of the benchmark exercise, for the 80-90 class
+type benchmarksStub struct {
+	*dbtest.Fake
+	profile database.BenchmarkProfile
+}
+
+func (s *benchmarksStub) GetBenchmarkProfile(ctx context.Context, userID string) (*database.BenchmarkProfile, error) {
+	return &s.profile, nil
+}
+
+func (s *benchmarksStub) GetBenchmarkDistribution(ctx context.Context, exerciseID, bodyweightClass string) (*database.Benchmark_distributions, error) {
+	if exerciseID != benchmarkExerciseID || bodyweightClass != "80-90" {
+		return nil, sql.ErrNoRows
+	}
+	percentiles := make([]float64, 99)
+	for i := range percentiles {
+		percentiles[i] = float64(100 + i)
+	}
+	raw, _ := json.Marshal(percentiles)
+	return &database.Benchmark_distributions{
+		Exercise_id:      exerciseID,
+		Bodyweight_class: bodyweightClass,
+		Sample_size:      240,
+		Percentiles:      raw,
+		Computed_at:      time.Now(),
+	}, nil
+}
+
+func (s *benchmarksStub) ListExerciseSets(ctx context.Context, userID, exerciseID string, since time.Time) ([]database.Workout_session_sets, error) {
+	return []database.Workout_session_sets{
+		{Weight_kg: decimal.NewFromInt(100), Reps: 5},
+		{Weight_kg: decimal.NewFromInt(105), Reps: 1},
+	}, nil
+}
+
+func getBenchmark(t *testing.T, s *FiberServer, query string) (int, database.BenchmarkResponse) {
+	t.Helper()
+	req := httptest.NewRequest("GET", "/api/v1/analytics/benchmarks?"+query, nil)
+	req.Header.Set("Authorization", bearer(t, "u1"))
+	resp, err := s.App.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var body struct{ Data database.BenchmarkResponse }
+	json.NewDecoder(resp.Body).Decode(&body)
+	return resp.StatusCode, body.Data
+}
+
+func TestGetBenchmarks(t *testing.T) {
+	stub := &benchmarksStub{Fake: dbtest.NewFake()}
+	s := newTestServer(t, stub)
+
+	if status, _ := getBenchmark(t, s, "exercise="+benchmarkExerciseID); status != 403 {
+		t.Errorf("expected users who didn't opt in to be refused, got %d", status)
+	}
+
+	consentedAt := time.Now()
+	bodyweight := decimal.RequireFromString("84.5")
+	stub.profile = database.BenchmarkProfile{ConsentedAt: &consentedAt, BodyweightKg: &bodyweight}
+
+	status, benchmark := getBenchmark(t, s, "exercise="+benchmarkExerciseID)
+	if status != 200 {
+		t.Fatalf("expected the benchmark, got %d", status)
+	}
+	if benchmark.BodyweightClass != "80-90" || benchmark.SampleSize != 240 || benchmark.Percentiles.P10 != 109 || benchmark.Percentiles.P90 != 189 {
+		t.Errorf("unexpected benchmark %+v", benchmark)
+	}
+	// 100 kg for 5 estimates 116.67 kg, above the 17 percentiles up to 116 kg
+	if benchmark.You == nil || benchmark.You.EstimatedOneRepMaxKg != 116.67 || benchmark.You.Percentile != 17 {
+		t.Errorf("unexpected placement %+v", benchmark.You)
+	}
+
+	for query, want := range map[string]int{
+		"exercise=" + benchmarkExerciseID + "&bodyweight_class=all":   404,
+		"exercise=" + benchmarkExerciseID + "&bodyweight_class=heavy": 400,
+		"exercise=squat": 400,
+	} {
+		if status, _ := getBenchmark(t, s, query); status != want {
+			t.Errorf("%s: expected %d, got %d", query, want, status)
+		}
+	}
+}
+
+func TestBodyweightClassOf(t *testing.T) {
+	for kg, want := range map[float64]string{45: "u60", 60: "60-70", 89.9: "80-90", 119.99: "110-120", 120: "o120", 180: "o120"} {
+		if got := database.BodyweightClassOf(kg); got != want {
+			t.Errorf("BodyweightClassOf(%v) = %s, want %s", kg, got, want)
+		}
+	}
+}
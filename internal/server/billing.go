@@ -0,0 +1,242 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"fitness-hack/internal/billing"
+	"fitness-hack/internal/database"
+	"fitness-hack/internal/messages"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Helper to convert a store purchase into a subscription row for the user
+func purchaseToSubscription(userID string, p *billing.Purchase) *database.Subscriptions {
+	return &database.Subscriptions{
+		User_id:                 userID,
+		Platform:                database.Subscriptions_platform(p.Platform),
+		Product_id:              p.ProductID,
+		Original_transaction_id: p.OriginalTransactionID,
+		Status:                  database.Subscriptions_status(p.Status),
+		Expires_at:              p.ExpiresAt,
+		Auto_renew:              p.AutoRenew,
+	}
+}
+
+// Helper to convert subscription to response
+func subscriptionToResponse(sub *database.Subscriptions) database.SubscriptionResponse {
+	return database.SubscriptionResponse{
+		ID:        sub.Id,
+		Platform:  string(sub.Platform),
+		ProductID: sub.Product_id,
+		Status:    string(sub.Status),
+		ExpiresAt: sub.Expires_at,
+		AutoRenew: sub.Auto_renew,
+		UpdatedAt: sub.Updated_at,
+	}
+}
+
+// billingErrorResponse maps store validation failures to HTTP responses
+func billingErrorResponse(s *FiberServer, c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, billing.ErrNotConfigured):
+		return errorResponse(c, fiber.StatusServiceUnavailable, "Store purchases are not configured")
+	case errors.Is(err, billing.ErrInvalidReceipt):
+		return errorResponse(c, fiber.StatusUnprocessableEntity, "Purchase could not be verified")
+	default:
+		LogError(s, "ERROR", messages.BillingStoreValidationFailed, err, c, nil)
+		return errorResponse(c, fiber.StatusBadGateway, "Store unavailable, please retry")
+	}
+}
+
+// saveValidatedPurchase stores the purchase for the caller and responds with it
+func (s *FiberServer) saveValidatedPurchase(ctx context.Context, c *fiber.Ctx, userID string, purchase *billing.Purchase) error {
+	sub, err := s.db.UpsertSubscription(ctx, purchaseToSubscription(userID, purchase))
+	if errors.Is(err, database.ErrSubscriptionOwned) {
+		return errorResponse(c, fiber.StatusConflict, "This purchase is linked to another account")
+	}
+	if err != nil {
+		LogDatabaseError(s, "upsert_subscription", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to save subscription")
+	}
+
+	return successResponse(c, subscriptionToResponse(sub))
+}
+
+// POST /api/v1/billing/apple/verify
+func (s *FiberServer) verifyAppleReceipt(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	var req database.AppleReceiptRequest
+	if err := c.BodyParser(&req); err != nil || req.Receipt == "" {
+		return errorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), 20*time.Second)
+	defer cancel()
+
+	purchase, err := s.billing.Apple.VerifyReceipt(ctx, req.Receipt)
+	if err != nil {
+		return billingErrorResponse(s, c, err)
+	}
+
+	return s.saveValidatedPurchase(ctx, c, userID, purchase)
+}
+
+// POST /api/v1/billing/google/verify
+func (s *FiberServer) verifyGooglePurchase(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	var req database.GooglePurchaseRequest
+	if err := c.BodyParser(&req); err != nil || req.PurchaseToken == "" {
+		return errorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), 20*time.Second)
+	defer cancel()
+
+	purchase, err := s.billing.Google.VerifyPurchase(ctx, req.PurchaseToken)
+	if err != nil {
+		return billingErrorResponse(s, c, err)
+	}
+
+	return s.saveValidatedPurchase(ctx, c, userID, purchase)
+}
+
+// GET /api/v1/billing/subscriptions
+func (s *FiberServer) getPremiumStatus(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	subs, err := s.db.ListSubscriptionsByUser(ctx, userID)
+	if err != nil {
+		LogDatabaseError(s, "list_subscriptions", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to fetch subscriptions")
+	}
+
+	premiumUntil, err := s.db.GetPremiumUntil(ctx, userID)
+	if err != nil {
+		LogDatabaseError(s, "get_premium_until", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to fetch subscriptions")
+	}
+
+	status := database.PremiumStatusResponse{
+		IsPremium:     premiumUntil != nil && premiumUntil.After(time.Now()),
+		PremiumUntil:  premiumUntil,
+		Subscriptions: make([]database.SubscriptionResponse, len(subs)),
+	}
+	for i := range subs {
+		status.Subscriptions[i] = subscriptionToResponse(&subs[i])
+	}
+
+	return successResponse(c, status)
+}
+
+// applyStoreNotification updates the stored subscription from a server notification.
+// Notifications for purchases no user has validated yet are acknowledged and ignored;
+// the app will link them when it submits the receipt.
+func (s *FiberServer) applyStoreNotification(ctx context.Context, c *fiber.Ctx, purchase *billing.Purchase) error {
+	_, err := s.db.UpdateSubscriptionState(ctx, purchaseToSubscription("", purchase))
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		LogDatabaseError(s, "update_subscription_state", err, c)
+		// A non-2xx response makes the store retry delivery
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to process notification")
+	}
+	return c.SendStatus(fiber.StatusOK)
+}
+
+// acceptStoreNotification applies replay protection before a notification is processed.
+// Already processed deliveries are acknowledged so the store stops retrying them.
+func (s *FiberServer) acceptStoreNotification(ctx context.Context, c *fiber.Ctx, provider, id string, issuedAt time.Time, process func() error) error {
+	release, err := s.claimWebhookNonce(ctx, provider, id, issuedAt)
+	switch {
+	case errors.Is(err, errReplayedRequest):
+		return c.SendStatus(fiber.StatusOK)
+	case errors.Is(err, errStaleRequest):
+		LogAuthError(s, messages.BillingStaleNotification, err, c)
+		return errorResponse(c, fiber.StatusBadRequest, "Invalid notification")
+	case err != nil:
+		LogCacheError(s, "claim_webhook_nonce", err, c)
+		return errorResponse(c, fiber.StatusServiceUnavailable, "Please retry")
+	}
+
+	if err := process(); err != nil {
+		release()
+		return err
+	}
+	if c.Response().StatusCode() >= fiber.StatusBadRequest {
+		release()
+	}
+	return nil
+}
+
+// POST /api/v1/billing/apple/notifications
+func (s *FiberServer) handleAppleNotification(c *fiber.Ctx) error {
+	var req struct {
+		SignedPayload string `json:"signedPayload"`
+	}
+	if err := c.BodyParser(&req); err != nil || req.SignedPayload == "" {
+		return errorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	notification, err := s.billing.Apple.ParseNotification(req.SignedPayload)
+	if err != nil {
+		if errors.Is(err, billing.ErrNotConfigured) {
+			return errorResponse(c, fiber.StatusServiceUnavailable, "Store notifications are not configured")
+		}
+		LogAuthError(s, messages.BillingAppStoreRejected, err, c)
+		return errorResponse(c, fiber.StatusBadRequest, "Invalid notification")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	return s.acceptStoreNotification(ctx, c, billing.PlatformApple, notification.ID, notification.SignedAt, func() error {
+		return s.applyStoreNotification(ctx, c, notification.Purchase)
+	})
+}
+
+// POST /api/v1/billing/google/notifications?token=...
+func (s *FiberServer) handleGoogleNotification(c *fiber.Ctx) error {
+	notification, err := s.billing.Google.ParseNotification(c.Query("token"), c.Body())
+	if err != nil {
+		if errors.Is(err, billing.ErrNotConfigured) {
+			return errorResponse(c, fiber.StatusServiceUnavailable, "Store notifications are not configured")
+		}
+		LogAuthError(s, messages.BillingPlayRejected, err, c)
+		return errorResponse(c, fiber.StatusBadRequest, "Invalid notification")
+	}
+	if notification == nil {
+		// Test or non-subscription notification
+		return c.SendStatus(fiber.StatusOK)
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), 20*time.Second)
+	defer cancel()
+
+	return s.acceptStoreNotification(ctx, c, billing.PlatformGoogle, notification.MessageID, notification.PublishedAt, func() error {
+		// RTDNs only say that something changed, so fetch the authoritative state
+		purchase, err := s.billing.Google.VerifyPurchase(ctx, notification.PurchaseToken)
+		if err != nil {
+			LogError(s, "ERROR", messages.BillingPlayLookupFailed, err, c, map[string]interface{}{
+				"notification_type": notification.NotificationType,
+			})
+			return errorResponse(c, fiber.StatusBadGateway, "Store unavailable, please retry")
+		}
+		return s.applyStoreNotification(ctx, c, purchase)
+	})
+}
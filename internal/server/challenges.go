@@ -0,0 +1,325 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"fitness-hack/internal/database"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	maxChallengeNameLength        = 100
+	maxChallengeDescriptionLength = 1000
+	maxChallengeDays              = 365
+	maxChallengeTarget            = 1000000
+	// maxChallengeBackdate is how long before its creation a challenge may start, so a
+	// group can set up a challenge they began earlier in the day
+	maxChallengeBackdate = 24 * time.Hour
+)
+
+// Helper to convert database challenge to response model
+func challengeToResponse(challenge *database.Challenge) database.ChallengeResponse {
+	return database.ChallengeResponse{
+		ID:           challenge.Id,
+		Name:         challenge.Name,
+		Description:  challenge.Description,
+		Metric:       string(challenge.Metric),
+		Target:       challenge.Target.InexactFloat64(),
+		StartsAt:     challenge.Starts_at,
+		EndsAt:       challenge.Ends_at,
+		CreatedBy:    challenge.Created_by,
+		Participants: challenge.Participants,
+		Joined:       challenge.Joined,
+		CreatedAt:    challenge.Created_at,
+	}
+}
+
+// rankChallengeStandings numbers the standings, leaders first, into a page of them and
+// the user's place
+func rankChallengeStandings(standings []database.ChallengeStanding, userID string, limit, offset int) (page []database.ChallengeStandingResponse, you *database.ChallengeStandingResponse) {
+	page = []database.ChallengeStandingResponse{}
+	for i, standing := range standings {
+		entry := database.ChallengeStandingResponse{
+			Rank:        i + 1,
+			DisplayName: standing.Display_name,
+			Progress:    roundKg(standing.Progress.InexactFloat64()),
+			CompletedAt: standing.Completed_at,
+		}
+		if i >= offset && i < offset+limit {
+			page = append(page, entry)
+		}
+		if standing.User_id == userID {
+			you = &entry
+		}
+	}
+	return page, you
+}
+
+// challengeResponse loads the challenge as the user sees it, with their standing when they
+// joined. Returns sql.ErrNoRows for unknown challenges.
+func (s *FiberServer) challengeResponse(ctx context.Context, id, userID string) (database.ChallengeResponse, error) {
+	challenge, err := s.db.GetChallenge(ctx, id, userID)
+	if err != nil {
+		return database.ChallengeResponse{}, err
+	}
+	response := challengeToResponse(challenge)
+	if challenge.Joined {
+		standings, err := s.db.ListChallengeStandings(ctx, id)
+		if err != nil {
+			return database.ChallengeResponse{}, err
+		}
+		_, response.You = rankChallengeStandings(standings, userID, 0, 0)
+	}
+	return response, nil
+}
+
+// POST /api/v1/challenges
+// Creates a challenge, such as 20 workouts in 30 days, and joins the creator to it
+func (s *FiberServer) createChallenge(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	var req database.CreateChallengeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || utf8.RuneCountInString(req.Name) > maxChallengeNameLength {
+		return errorResponse(c, fiber.StatusBadRequest, "name is required and must be at most 100 characters")
+	}
+	if utf8.RuneCountInString(req.Description) > maxChallengeDescriptionLength {
+		return errorResponse(c, fiber.StatusBadRequest, "description must be at most 1000 characters")
+	}
+	metric, err := database.ParseChallenges_metric(req.Metric)
+	if err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, "metric must be workouts, minutes or volume")
+	}
+	if req.Target <= 0 || req.Target > maxChallengeTarget {
+		return errorResponse(c, fiber.StatusBadRequest, "target must be above 0 and at most 1000000")
+	}
+	if req.Days < 1 || req.Days > maxChallengeDays {
+		return errorResponse(c, fiber.StatusBadRequest, "days must be between 1 and 365")
+	}
+	now := time.Now()
+	startsAt := now
+	if req.StartsAt != nil {
+		if req.StartsAt.Before(now.Add(-maxChallengeBackdate)) {
+			return errorResponse(c, fiber.StatusBadRequest, "startsAt can be at most a day in the past")
+		}
+		startsAt = *req.StartsAt
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	created, err := s.db.CreateChallenge(ctx, &database.Challenges{
+		Created_by:  &userID,
+		Name:        req.Name,
+		Description: req.Description,
+		Metric:      metric,
+		Target:      decimal.NewFromFloat(req.Target).Round(2),
+		Starts_at:   startsAt,
+		Ends_at:     startsAt.AddDate(0, 0, req.Days),
+	})
+	if err != nil {
+		LogDatabaseError(s, "create_challenge", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to create challenge")
+	}
+
+	// A backdated challenge may already count sessions towards the creator's progress
+	response, err := s.challengeResponse(ctx, created.Id, userID)
+	if err != nil {
+		LogDatabaseError(s, "get_challenge", err, c)
+		response = challengeToResponse(&database.Challenge{Challenges: *created, Participants: 1, Joined: true})
+	}
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"data": response})
+}
+
+// GET /api/v1/challenges?joined=true
+// Lists the challenges that haven't ended, ending soonest first, or with joined=true the
+// ones the user joined, ended ones included
+func (s *FiberServer) listChallenges(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+	limit, offset := getPaginationParams(c)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	challenges, err := s.db.ListChallenges(ctx, userID, c.QueryBool("joined"), limit, offset)
+	if err != nil {
+		LogDatabaseError(s, "list_challenges", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to list challenges")
+	}
+
+	responses := make([]database.ChallengeResponse, len(challenges))
+	for i := range challenges {
+		responses[i] = challengeToResponse(&challenges[i])
+	}
+	return successResponse(c, responses)
+}
+
+// GET /api/v1/challenges/:id
+func (s *FiberServer) getChallenge(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+	id := c.Params("id")
+	if _, err := uuid.Parse(id); err != nil {
+		return notFound(c, "Challenge")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	response, err := s.challengeResponse(ctx, id, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return notFound(c, "Challenge")
+	}
+	if err != nil {
+		LogDatabaseError(s, "get_challenge", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to get challenge")
+	}
+	return successResponse(c, response)
+}
+
+// DELETE /api/v1/challenges/:id
+// Only the creator can delete a challenge
+func (s *FiberServer) deleteChallenge(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+	id := c.Params("id")
+	if _, err := uuid.Parse(id); err != nil {
+		return notFound(c, "Challenge")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := s.db.DeleteChallenge(ctx, id, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return notFound(c, "Challenge")
+		}
+		LogDatabaseError(s, "delete_challenge", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to delete challenge")
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// POST /api/v1/challenges/:id/join
+// Joining a challenge already joined returns it unchanged. Sessions completed since the
+// challenge started count, including those from before joining.
+func (s *FiberServer) joinChallenge(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+	id := c.Params("id")
+	if _, err := uuid.Parse(id); err != nil {
+		return notFound(c, "Challenge")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, created, err := s.db.JoinChallenge(ctx, id, userID)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return notFound(c, "Challenge")
+	case errors.Is(err, database.ErrChallengeEnded):
+		return errorResponse(c, fiber.StatusConflict, "Challenge has ended")
+	case err != nil:
+		LogDatabaseError(s, "join_challenge", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to join challenge")
+	}
+
+	response, err := s.challengeResponse(ctx, id, userID)
+	if err != nil {
+		LogDatabaseError(s, "get_challenge", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to join challenge")
+	}
+	status := fiber.StatusOK
+	if created {
+		status = fiber.StatusCreated
+	}
+	return c.Status(status).JSON(fiber.Map{"data": response})
+}
+
+// DELETE /api/v1/challenges/:id/join
+func (s *FiberServer) leaveChallenge(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+	id := c.Params("id")
+	if _, err := uuid.Parse(id); err != nil {
+		return notFound(c, "Challenge")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := s.db.LeaveChallenge(ctx, id, userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errorResponse(c, fiber.StatusNotFound, "Not taking part in this challenge")
+		}
+		LogDatabaseError(s, "leave_challenge", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to leave challenge")
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// GET /api/v1/challenges/:id/standings?limit=&offset=
+// Progress is counted from the participants' sessions each time the standings are read, so
+// sessions logged, edited or deleted show straight away
+func (s *FiberServer) getChallengeStandings(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+	id := c.Params("id")
+	if _, err := uuid.Parse(id); err != nil {
+		return notFound(c, "Challenge")
+	}
+	limit, offset := getPaginationParams(c)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	challenge, err := s.db.GetChallenge(ctx, id, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return notFound(c, "Challenge")
+	}
+	if err != nil {
+		LogDatabaseError(s, "get_challenge", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to get challenge standings")
+	}
+	standings, err := s.db.ListChallengeStandings(ctx, id)
+	if err != nil {
+		LogDatabaseError(s, "list_challenge_standings", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to get challenge standings")
+	}
+
+	response := database.ChallengeStandingsResponse{
+		ChallengeID: id,
+		Target:      challenge.Target.InexactFloat64(),
+		Total:       len(standings),
+	}
+	response.Entries, response.You = rankChallengeStandings(standings, userID, limit, offset)
+	return successResponse(c, response)
+}
@@ -0,0 +1,139 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"fitness-hack/internal/database"
+	"fitness-hack/internal/database/dbtest"
+
+	"github.com/shopspring/decimal"
+)
+
+const challengeID = "6b1f0e52-3c1d-4f7a-9d2e-8a4c7b5e9f21"
+
+// challengesStub keeps one challenge, with u2 done and u1 halfway to its target
+type challengesStub struct {
+	*dbtest.Fake
+	challenge    *database.Challenges
+	participants map[string]bool
+}
+
+func (s *challengesStub) CreateChallenge(ctx context.Context, challenge *database.Challenges) (*database.Challenges, error) {
+	created := *challenge
+	created.Id = challengeID
+	created.Created_at = time.Now()
+	s.challenge = &created
+	s.participants[*challenge.Created_by] = true
+	return &created, nil
+}
+
+func (s *challengesStub) GetChallenge(ctx context.Context, id, userID string) (*database.Challenge, error) {
+	if s.challenge == nil || id != s.challenge.Id {
+		return nil, sql.ErrNoRows
+	}
+	return &database.Challenge{Challenges: *s.challenge, Participants: len(s.participants), Joined: s.participants[userID]}, nil
+}
+
+func (s *challengesStub) JoinChallenge(ctx context.Context, challengeID, userID string) (*database.Challenge_participants, bool, error) {
+	if s.challenge == nil || challengeID != s.challenge.Id {
+		return nil, false, sql.ErrNoRows
+	}
+	if !time.Now().Before(s.challenge.Ends_at) {
+		return nil, false, database.ErrChallengeEnded
+	}
+	created := !s.participants[userID]
+	s.participants[userID] = true
+	return &database.Challenge_participants{Challenge_id: challengeID, User_id: userID}, created, nil
+}
+
+func (s *challengesStub) ListChallengeStandings(ctx context.Context, challengeID string) ([]database.ChallengeStanding, error) {
+	completedAt := time.Now()
+	standings := []database.ChallengeStanding{}
+	for _, standing := range []database.ChallengeStanding{
+		{User_id: "u2", Display_name: "Bo R.", Progress: decimal.NewFromInt(20), Completed_at: &completedAt},
+		{User_id: "u1", Display_name: "Ana K.", Progress: decimal.NewFromInt(10)},
+	} {
+		if s.participants[standing.User_id] {
+			standings = append(standings, standing)
+		}
+	}
+	return standings, nil
+}
+
+func TestChallenges(t *testing.T) {
+	stub := &challengesStub{Fake: dbtest.NewFake(), participants: map[string]bool{}}
+	s := newTestServer(t, stub)
+
+	send := func(method, path, userID string, body interface{}) (int, json.RawMessage) {
+		t.Helper()
+		raw, _ := json.Marshal(body)
+		req := httptest.NewRequest(method, path, bytes.NewReader(raw))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", bearer(t, userID))
+		resp, err := s.App.Test(req, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var envelope struct{ Data json.RawMessage }
+		json.NewDecoder(resp.Body).Decode(&envelope)
+		return resp.StatusCode, envelope.Data
+	}
+
+	for name, body := range map[string]map[string]interface{}{
+		"no name":        {"metric": "workouts", "target": 20, "days": 30},
+		"unknown metric": {"name": "Squat month", "metric": "squats", "target": 20, "days": 30},
+		"no target":      {"name": "Squat month", "metric": "workouts", "days": 30},
+		"too long":       {"name": "Squat month", "metric": "workouts", "target": 20, "days": 400},
+		"backdated":      {"name": "Squat month", "metric": "workouts", "target": 20, "days": 30, "startsAt": time.Now().AddDate(0, 0, -3)},
+	} {
+		if status, _ := send("POST", "/api/v1/challenges", "u2", body); status != 400 {
+			t.Errorf("%s: expected 400, got %d", name, status)
+		}
+	}
+
+	start := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+	status, data := send("POST", "/api/v1/challenges", "u2", map[string]interface{}{
+		"name": "20 workouts in 30 days", "metric": "workouts", "target": 20, "days": 30, "startsAt": start,
+	})
+	if status != 201 {
+		t.Fatalf("expected the challenge to be created, got %d", status)
+	}
+	var challenge database.ChallengeResponse
+	json.Unmarshal(data, &challenge)
+	if !challenge.EndsAt.Equal(start.AddDate(0, 0, 30)) || !challenge.Joined || challenge.You == nil || challenge.You.Rank != 1 {
+		t.Errorf("unexpected challenge %+v", challenge)
+	}
+
+	if status, _ := send("POST", "/api/v1/challenges/"+challengeID+"/join", "u1", nil); status != 201 {
+		t.Errorf("expected to join, got %d", status)
+	}
+	status, data = send("POST", "/api/v1/challenges/"+challengeID+"/join", "u1", nil)
+	json.Unmarshal(data, &challenge)
+	if status != 200 || challenge.Participants != 2 || challenge.You == nil || challenge.You.Rank != 2 || challenge.You.Progress != 10 {
+		t.Errorf("expected joining again to return the challenge, got %d %+v", status, challenge)
+	}
+
+	status, data = send("GET", "/api/v1/challenges/"+challengeID+"/standings?limit=1", "u1", nil)
+	var standings database.ChallengeStandingsResponse
+	json.Unmarshal(data, &standings)
+	if status != 200 || standings.Total != 2 || standings.Target != 20 || len(standings.Entries) != 1 || standings.Entries[0].DisplayName != "Bo R." || standings.Entries[0].CompletedAt == nil {
+		t.Errorf("unexpected standings %d %+v", status, standings)
+	}
+	if standings.You == nil || standings.You.Rank != 2 {
+		t.Errorf("expected the caller in second place, got %+v", standings.You)
+	}
+
+	stub.challenge.Ends_at = time.Now().Add(-time.Minute)
+	if status, _ := send("POST", "/api/v1/challenges/"+challengeID+"/join", "u3", nil); status != 409 {
+		t.Errorf("expected ended challenges to refuse new participants, got %d", status)
+	}
+	if status, _ := send("GET", "/api/v1/challenges/00000000-0000-0000-0000-000000000000", "u1", nil); status != 404 {
+		t.Errorf("expected unknown challenges to be missing, got %d", status)
+	}
+}
@@ -0,0 +1,119 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net"
+	"strings"
+	"time"
+
+	"fitness-hack/internal/messages"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// errChaosCacheFailure is returned by Redis commands failed on purpose by fault injection
+var errChaosCacheFailure = errors.New("chaos: injected cache failure")
+
+// ChaosConfig controls fault injection for staging load tests. It must never be
+// enabled in production.
+type ChaosConfig struct {
+	Enabled bool
+	// PathPrefix limits request faults to matching paths (default /api/)
+	PathPrefix string
+	// Latency is added to LatencyRate of requests
+	Latency     time.Duration
+	LatencyRate float64
+	// ErrorRate of requests fail with ErrorStatus without reaching the handler
+	ErrorRate   float64
+	ErrorStatus int
+	// CacheFailureRate of Redis commands fail before reaching Redis
+	CacheFailureRate float64
+}
+
+// LoadChaosConfig reads fault injection settings from the environment. Rates are
+// probabilities between 0 and 1.
+func LoadChaosConfig() ChaosConfig {
+	return ChaosConfig{
+		Enabled:          getEnvBool("CHAOS_ENABLED", false),
+		PathPrefix:       getEnv("CHAOS_PATH_PREFIX", "/api/"),
+		Latency:          getEnvDuration("CHAOS_LATENCY", 0),
+		LatencyRate:      getEnvFloat("CHAOS_LATENCY_RATE", 0),
+		ErrorRate:        getEnvFloat("CHAOS_ERROR_RATE", 0),
+		ErrorStatus:      getEnvInt("CHAOS_ERROR_STATUS", fiber.StatusServiceUnavailable),
+		CacheFailureRate: getEnvFloat("CHAOS_CACHE_FAILURE_RATE", 0),
+	}
+}
+
+// chance reports true with probability rate
+func chance(rate float64) bool {
+	return rate > 0 && rand.Float64() < rate
+}
+
+// enableChaos installs the configured faults on the server
+func (s *FiberServer) enableChaos(config ChaosConfig) {
+	messages.Log(messages.ServerChaosEnabled,
+		config.Latency, config.LatencyRate, config.ErrorStatus, config.ErrorRate, config.CacheFailureRate, config.PathPrefix)
+
+	if config.CacheFailureRate > 0 && s.cache != nil {
+		s.cache.AddHook(chaosHook{rate: config.CacheFailureRate})
+	}
+	s.App.Use(chaosMiddleware(config))
+}
+
+// chaosMiddleware delays or fails a random share of requests. Injected faults are
+// reported in the X-Chaos-Injected header so load test results can be correlated.
+func chaosMiddleware(config ChaosConfig) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if !strings.HasPrefix(c.Path(), config.PathPrefix) {
+			return c.Next()
+		}
+
+		if config.Latency > 0 && chance(config.LatencyRate) {
+			c.Append("X-Chaos-Injected", "latency")
+			time.Sleep(config.Latency)
+		}
+
+		if chance(config.ErrorRate) {
+			c.Append("X-Chaos-Injected", "error")
+			return errorResponse(c, config.ErrorStatus, "Injected failure")
+		}
+
+		return c.Next()
+	}
+}
+
+// chaosHook fails a random share of Redis commands, exercising the cache fallbacks
+type chaosHook struct {
+	rate float64
+}
+
+func (h chaosHook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return next(ctx, network, addr)
+	}
+}
+
+func (h chaosHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if chance(h.rate) {
+			cmd.SetErr(errChaosCacheFailure)
+			return errChaosCacheFailure
+		}
+		return next(ctx, cmd)
+	}
+}
+
+func (h chaosHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		if chance(h.rate) {
+			for _, cmd := range cmds {
+				cmd.SetErr(errChaosCacheFailure)
+			}
+			return errChaosCacheFailure
+		}
+		return next(ctx, cmds)
+	}
+}
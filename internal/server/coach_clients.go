@@ -0,0 +1,402 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	"fitness-hack/internal/database"
+	"fitness-hack/internal/mailer"
+	"fitness-hack/internal/messages"
+	"fitness-hack/internal/push"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// Helper to convert a database coaching invitation or relationship to the coach's response model
+func coachClientToResponse(client *database.CoachClient) database.CoachClientResponse {
+	return database.CoachClientResponse{
+		ID:         client.Id,
+		ClientID:   client.Client_id,
+		Username:   client.Username,
+		Email:      client.Email,
+		Status:     string(client.Status),
+		Expired:    client.Status == database.Coach_clients_status_pending && time.Now().After(client.Expires_at),
+		ExpiresAt:  client.Expires_at,
+		AcceptedAt: client.Accepted_at,
+		CreatedAt:  client.Created_at,
+	}
+}
+
+// requireCoachOf only lets coaches with an active relationship with the :id client
+// through. Anyone else gets 404 so client IDs cannot be probed.
+func (s *FiberServer) requireCoachOf(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+	clientID := c.Params("id")
+	if _, err := uuid.Parse(clientID); err != nil {
+		return notFound(c, "Client")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	coach, err := s.db.IsCoachOf(ctx, userID, clientID)
+	if err != nil {
+		LogDatabaseError(s, "is_coach_of", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to fetch client")
+	}
+	if !coach {
+		return notFound(c, "Client")
+	}
+
+	c.Locals("client_id", clientID)
+	return c.Next()
+}
+
+// sendCoachInvite emails the coaching invitation link. The link points at
+// COACH_INVITE_URL, the client page that calls the accept endpoint with the token.
+func (s *FiberServer) sendCoachInvite(ctx context.Context, coach *database.Users, invite *database.Coach_clients, token string) error {
+	link := getEnv("COACH_INVITE_URL", "https://app.fitnesshack.app/coach/accept") + "?token=" + url.QueryEscape(token)
+
+	return s.mailer.Send(ctx, mailer.Message{
+		To:      invite.Email,
+		Subject: fmt.Sprintf("%s invited you to be coached on FitnessHack", coach.Username),
+		Body: fmt.Sprintf("%s would like to coach you on FitnessHack. Once you accept, they can see your "+
+			"workouts and sessions and assign you programs. You can end the coaching at any time.\n\n"+
+			"Accept the invitation here:\n%s\n\n"+
+			"This link expires on %s. If you weren't expecting this email you can ignore it.\n",
+			coach.Username, link, invite.Expires_at.UTC().Format("January 2, 2006 15:04 MST")),
+	})
+}
+
+// POST /api/v1/coach/invites
+func (s *FiberServer) createCoachInvite(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	var req database.CreateCoachInviteRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+	addr, err := mail.ParseAddress(strings.TrimSpace(req.Email))
+	if err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, "A valid email is required")
+	}
+
+	token, tokenHash, expiresAt, err := newInviteToken()
+	if err != nil {
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to create invitation")
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), 10*time.Second)
+	defer cancel()
+
+	coach, err := s.db.GetUserByID(ctx, userID)
+	if err != nil {
+		LogDatabaseError(s, "get_user", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to create invitation")
+	}
+	if strings.EqualFold(coach.Email, addr.Address) {
+		return errorResponse(c, fiber.StatusBadRequest, "You can't coach yourself")
+	}
+
+	invite, err := s.db.CreateCoachInvite(ctx, &database.Coach_clients{
+		Coach_id:   userID,
+		Email:      strings.ToLower(addr.Address),
+		Token_hash: tokenHash,
+		Expires_at: expiresAt,
+	})
+	if err != nil {
+		if errors.Is(err, database.ErrInvitePending) {
+			return errorResponse(c, fiber.StatusConflict, "An invitation is already pending for this email; revoke it to send another")
+		}
+		LogDatabaseError(s, "create_coach_invite", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to create invitation")
+	}
+
+	if err := s.sendCoachInvite(ctx, coach, invite, token); err != nil {
+		LogError(s, "ERROR", messages.InviteEmailFailed, err, c, map[string]interface{}{
+			"invite_id": invite.Id,
+		})
+		return errorResponse(c, fiber.StatusBadGateway, "Invitation created but the email could not be sent; revoke it and invite again")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"data": coachClientToResponse(&database.CoachClient{Coach_clients: *invite})})
+}
+
+// DELETE /api/v1/coach/invites/:inviteId
+func (s *FiberServer) revokeCoachInvite(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+	if _, err := uuid.Parse(c.Params("inviteId")); err != nil {
+		return notFound(c, "Invitation")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := s.db.RevokeCoachInvite(ctx, userID, c.Params("inviteId")); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return notFound(c, "Invitation")
+		}
+		LogDatabaseError(s, "revoke_coach_invite", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to revoke invitation")
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// POST /api/v1/coach/invites/accept
+func (s *FiberServer) acceptCoachInvite(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	var req database.AcceptCoachInviteRequest
+	if err := c.BodyParser(&req); err != nil || req.Token == "" {
+		return errorResponse(c, fiber.StatusBadRequest, "Token is required")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	relationship, err := s.db.AcceptCoachInvite(ctx, hashInviteToken(req.Token), userID)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return notFound(c, "Invitation")
+	case errors.Is(err, database.ErrInviteExpired):
+		return errorResponse(c, fiber.StatusGone, "Invitation has expired; ask your coach to invite you again")
+	case errors.Is(err, database.ErrInviteClosed):
+		return errorResponse(c, fiber.StatusGone, "Invitation is no longer valid")
+	case errors.Is(err, database.ErrInviteEmailMismatch):
+		return errorResponse(c, fiber.StatusForbidden, "Invitation was sent to a different email address")
+	case err != nil:
+		LogDatabaseError(s, "accept_coach_invite", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to accept invitation")
+	}
+
+	coach, err := s.db.GetUserByID(ctx, relationship.Coach_id)
+	if err != nil {
+		LogDatabaseError(s, "get_user", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to accept invitation")
+	}
+	return successResponse(c, database.CoachResponse{
+		ID:       relationship.Id,
+		CoachID:  relationship.Coach_id,
+		Username: coach.Username,
+		Since:    *relationship.Accepted_at,
+	})
+}
+
+// GET /api/v1/coach/clients
+// Lists the caller's clients and the invitations they sent that are still pending
+func (s *FiberServer) listCoachClients(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	clients, err := s.db.ListCoachClients(ctx, userID)
+	if err != nil {
+		LogDatabaseError(s, "list_coach_clients", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to fetch clients")
+	}
+
+	response := make([]database.CoachClientResponse, len(clients))
+	for i := range clients {
+		response[i] = coachClientToResponse(&clients[i])
+	}
+	return successResponse(c, response)
+}
+
+// DELETE /api/v1/coach/clients/:id
+// Ends the coaching of the client; their programs the caller coached lose their coach
+func (s *FiberServer) endCoachClient(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := s.db.EndCoachClient(ctx, userID, c.Locals("client_id").(string)); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return notFound(c, "Client")
+		}
+		LogDatabaseError(s, "end_coach_client", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to end coaching")
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// GET /api/v1/coach/clients/:id/sessions?from=&to=&sort=&order=
+// Lists the client's workout sessions, newest first by default
+func (s *FiberServer) listClientSessions(c *fiber.Ctx) error {
+	opts := database.ListWorkoutSessionsOpts{ListOptions: getListOptions(c)}
+	var err error
+	if opts.From, opts.To, err = getDateRange(c); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+	opts.Filters = map[string]string{"user_id": c.Locals("client_id").(string)}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	sessions, err := s.db.ListWorkoutSessions(ctx, opts)
+	if errors.Is(err, database.ErrInvalidListOption) {
+		return errorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+	if err != nil {
+		LogDatabaseError(s, "list_workout_sessions", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to fetch workout sessions")
+	}
+
+	response := make([]database.WorkoutSessionResponse, len(sessions))
+	for i := range sessions {
+		response[i] = workoutSessionToResponse(&sessions[i])
+	}
+	return successResponse(c, response)
+}
+
+// GET /api/v1/coach/clients/:id/workouts?muscleGroup=&programId=&from=&to=&sort=&order=
+// Lists the client's workouts, newest first by default
+func (s *FiberServer) listClientWorkouts(c *fiber.Ctx) error {
+	opts, err := workoutListOpts(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+	opts.Limit, opts.Offset = getPaginationParams(c)
+	opts.UserID = c.Locals("client_id").(string)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	workouts, err := s.db.ListWorkouts(ctx, opts)
+	if errors.Is(err, database.ErrInvalidListOption) {
+		return errorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+	if err != nil {
+		LogDatabaseError(s, "list_workouts", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to fetch workouts")
+	}
+
+	response := make([]database.WorkoutResponse, len(workouts))
+	for i := range workouts {
+		response[i] = workoutToResponse(&workouts[i])
+	}
+	return successResponse(c, response)
+}
+
+// POST /api/v1/coach/clients/:id/programs
+// Creates a program for the client with the caller as its coach, so adjustments to it
+// await the caller's review. The client is notified.
+func (s *FiberServer) assignClientProgram(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	var req CreateProgramRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+	if strings.TrimSpace(req.Name) == "" {
+		return errorResponse(c, fiber.StatusBadRequest, "name is required")
+	}
+	if req.Difficulty != nil && !database.Programs_difficulty(*req.Difficulty).Valid() {
+		return errorResponse(c, fiber.StatusBadRequest, "Invalid difficulty")
+	}
+	if !validDeloadInterval(req.DeloadEveryWeeks) {
+		return errorResponse(c, fiber.StatusBadRequest, "deloadEveryWeeks must be between 2 and 12")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	clientID := c.Locals("client_id").(string)
+	program := convertRequestToProgram(&req, clientID)
+	program.Coach_id = &userID
+
+	created, err := s.db.CreateProgram(ctx, program)
+	if err != nil {
+		return serverError("Failed to assign program", err)
+	}
+
+	s.sendPushNotification(ctx, clientID, push.Notification{
+		Title: "New program from your coach",
+		Body:  created.Name,
+		Data:  map[string]string{"type": "program_assigned", "programId": created.Id},
+	})
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"data": convertProgramToResponse(created)})
+}
+
+// GET /api/v1/users/me/coaches
+func (s *FiberServer) listMyCoaches(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	coaches, err := s.db.ListClientCoaches(ctx, userID)
+	if err != nil {
+		LogDatabaseError(s, "list_client_coaches", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to fetch coaches")
+	}
+
+	response := make([]database.CoachResponse, len(coaches))
+	for i, coach := range coaches {
+		response[i] = database.CoachResponse{ID: coach.Id, CoachID: coach.Coach_id}
+		if coach.Username != nil {
+			response[i].Username = *coach.Username
+		}
+		if coach.Accepted_at != nil {
+			response[i].Since = *coach.Accepted_at
+		}
+	}
+	return successResponse(c, response)
+}
+
+// DELETE /api/v1/users/me/coaches/:coachId
+// Ends the caller's coaching by the coach, as the coach ending it would
+func (s *FiberServer) leaveCoach(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+	if _, err := uuid.Parse(c.Params("coachId")); err != nil {
+		return notFound(c, "Coach")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := s.db.EndCoachClient(ctx, c.Params("coachId"), userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return notFound(c, "Coach")
+		}
+		LogDatabaseError(s, "end_coach_client", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to end coaching")
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,92 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"fitness-hack/internal/database"
+	"fitness-hack/internal/database/dbtest"
+)
+
+const coachedClientID = "8c1d7e2a-4b3f-4e6a-9d5c-1f0b2a3c4d5e"
+
+// coachClient makes u1 the coach of the coached client, through an accepted invitation
+func coachClient(t *testing.T, db *dbtest.Fake) {
+	t.Helper()
+	ctx := context.Background()
+	client, err := db.CreateUser(ctx, &database.Users{Id: coachedClientID, Email: "client@example.com", Username: "client"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	invite, err := db.CreateCoachInvite(ctx, &database.Coach_clients{Coach_id: "u1", Email: client.Email,
+		Token_hash: "coach-invite", Expires_at: time.Now().Add(time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.AcceptCoachInvite(ctx, invite.Token_hash, client.Id); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestListClientSessions(t *testing.T) {
+	s, db := newFakeServer(t)
+	coachClient(t, db)
+	ctx := context.Background()
+	for userID, name := range map[string]string{coachedClientID: "Client squats", "u3": "Someone else's run"} {
+		if _, err := db.CreateWorkoutSession(ctx, &database.Workout_sessions{User_id: userID, Name: name, Started_at: time.Now()}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	list := func(userID, clientID string) (int, []database.WorkoutSessionResponse) {
+		t.Helper()
+		req := httptest.NewRequest("GET", "/api/v1/coach/clients/"+clientID+"/sessions", nil)
+		req.Header.Set("Authorization", bearer(t, userID))
+		resp, err := s.App.Test(req, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var body struct {
+			Data []database.WorkoutSessionResponse
+		}
+		json.NewDecoder(resp.Body).Decode(&body)
+		return resp.StatusCode, body.Data
+	}
+
+	status, sessions := list("u1", coachedClientID)
+	if status != 200 || len(sessions) != 1 || sessions[0].Name != "Client squats" {
+		t.Errorf("expected the client's session, got %d %+v", status, sessions)
+	}
+	if status, _ := list("u2", coachedClientID); status != 404 {
+		t.Errorf("expected users who don't coach the client to get 404, got %d", status)
+	}
+	if status, _ := list("u1", "u3"); status != 404 {
+		t.Errorf("expected an invalid client ID to get 404, got %d", status)
+	}
+}
+
+func TestAssignClientProgram(t *testing.T) {
+	s, db := newFakeServer(t)
+	coachClient(t, db)
+
+	req := httptest.NewRequest("POST", "/api/v1/coach/clients/"+coachedClientID+"/programs",
+		strings.NewReader(`{"name":"Off-season strength","durationWeeks":8}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", bearer(t, "u1"))
+	resp, err := s.App.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != 201 {
+		t.Fatalf("expected the program to be assigned, got %d", resp.StatusCode)
+	}
+	var body struct{ Data ProgramResponse }
+	json.NewDecoder(resp.Body).Decode(&body)
+	if body.Data.UserID != coachedClientID || body.Data.CoachID == nil || *body.Data.CoachID != "u1" {
+		t.Errorf("expected a program of the client coached by u1, got %+v", body.Data)
+	}
+}
@@ -0,0 +1,406 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"fitness-hack/internal/database"
+	"fitness-hack/internal/messages"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	// communityPostTimeout bounds one post to a chat app
+	communityPostTimeout = 10 * time.Second
+
+	communityEventPersonalRecord    = "personal_record"
+	communityEventWeeklyLeaderboard = "weekly_leaderboard"
+)
+
+// discordWebhookHosts are the hosts Discord issues webhook URLs on
+var discordWebhookHosts = map[string]bool{
+	"discord.com":        true,
+	"discordapp.com":     true,
+	"ptb.discord.com":    true,
+	"canary.discord.com": true,
+}
+
+// communityProvider returns the chat app an incoming webhook URL belongs to. Only Slack
+// and Discord URLs are accepted, so the API never posts anywhere else.
+func communityProvider(raw string) (database.Community_integrations_provider, error) {
+	invalid := errors.New("webhookUrl must be a Slack or Discord incoming webhook URL")
+	if len(raw) > maxWebhookURLLength {
+		return "", invalid
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "https" || u.User != nil || u.Port() != "" {
+		return "", invalid
+	}
+	host := strings.ToLower(u.Hostname())
+	switch {
+	case host == "hooks.slack.com" && strings.HasPrefix(u.Path, "/services/"):
+		return database.Community_integrations_provider_slack, nil
+	case discordWebhookHosts[host] && strings.HasPrefix(u.Path, "/api/webhooks/"):
+		return database.Community_integrations_provider_discord, nil
+	}
+	return "", invalid
+}
+
+// maskWebhookURL hides the secret part of a chat webhook URL, keeping enough to recognize it
+func maskWebhookURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	return u.Scheme + "://" + u.Host + "/…"
+}
+
+// Helper to convert database community integration to response model
+func communityIntegrationToResponse(integration *database.Community_integrations) database.CommunityIntegrationResponse {
+	return database.CommunityIntegrationResponse{
+		Provider:             string(integration.Provider),
+		WebhookURL:           maskWebhookURL(integration.Webhook_url),
+		Timezone:             integration.Timezone,
+		PersonalRecords:      integration.Post_personal_records,
+		WeeklyLeaderboard:    integration.Post_weekly_leaderboard,
+		Enabled:              integration.Enabled,
+		LeaderboardPostedFor: integration.Leaderboard_posted_for,
+		UpdatedAt:            integration.Updated_at,
+	}
+}
+
+// communityFormatter writes posts in the markup of one chat app
+type communityFormatter struct {
+	provider database.Community_integrations_provider
+}
+
+var (
+	slackEscaper   = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+	discordEscaper = strings.NewReplacer(`\`, `\\`, "*", `\*`, "_", `\_`, "~", `\~`, "`", "\\`", "|", `\|`, ">", `\>`, "#", `\#`)
+)
+
+// text escapes user-provided text, such as member and exercise names
+func (f communityFormatter) text(s string) string {
+	if f.provider == database.Community_integrations_provider_slack {
+		return slackEscaper.Replace(s)
+	}
+	return discordEscaper.Replace(s)
+}
+
+// bold escapes s and sets it in bold
+func (f communityFormatter) bold(s string) string {
+	if f.provider == database.Community_integrations_provider_slack {
+		return "*" + f.text(s) + "*"
+	}
+	return "**" + f.text(s) + "**"
+}
+
+// payload returns the JSON body that posts text. Discord is told not to turn anything in
+// it into mentions, so a name can't ping the whole server.
+func (f communityFormatter) payload(text string) ([]byte, error) {
+	if f.provider == database.Community_integrations_provider_slack {
+		return json.Marshal(map[string]interface{}{"text": text})
+	}
+	return json.Marshal(map[string]interface{}{
+		"content":          text,
+		"allowed_mentions": map[string]interface{}{"parse": []string{}},
+	})
+}
+
+// personalRecordPost announces a member's personal record
+func (f communityFormatter) personalRecordPost(member, exercise string, record *database.PersonalRecord) string {
+	if exercise == "" {
+		exercise = "an exercise"
+	}
+	return fmt.Sprintf("🏆 %s set a new personal record on %s: %s kg for %d reps, up from %s kg.",
+		f.bold(member), f.bold(exercise), record.Weight_kg.String(), record.Reps, record.Previous_best_kg.String())
+}
+
+// leaderboardPost ranks the organization's members over the week starting at weekStart
+func (f communityFormatter) leaderboardPost(organization string, weekStart time.Time, entries []database.LeaderboardEntry) string {
+	weekEnd := weekStart.AddDate(0, 0, 6)
+	var b strings.Builder
+	fmt.Fprintf(&b, "📊 %s for %s – %s", f.bold(organization+" weekly leaderboard"),
+		weekStart.Format("Jan 2"), weekEnd.Format("Jan 2"))
+	for i, entry := range entries {
+		sessions := "sessions"
+		if entry.Sessions == 1 {
+			sessions = "session"
+		}
+		fmt.Fprintf(&b, "\n%d. %s – %d %s, %d min", i+1, f.text(entry.Display_name), entry.Sessions, sessions, entry.Minutes)
+	}
+	return b.String()
+}
+
+// postToCommunity posts text to the organization's channel. Anything but a 2xx response
+// counts as a failure.
+func (s *FiberServer) postToCommunity(ctx context.Context, integration *database.Community_integrations, text string) error {
+	body, err := communityFormatter{integration.Provider}.payload(text)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, communityPostTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, integration.Webhook_url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "FitnessHack-Community/1.0")
+
+	resp, err := s.webhookClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return errors.New("channel responded with " + resp.Status)
+	}
+	return nil
+}
+
+// logCommunityPostFailure logs a post that didn't make it; posts are not retried
+func (s *FiberServer) logCommunityPostFailure(orgID, event string, err error) {
+	s.logError("ERROR", messages.CommunityPostFailed, err, nil, map[string]interface{}{
+		"component":       "community",
+		"organization_id": orgID,
+		"event":           event,
+	})
+}
+
+// postPersonalRecordToCommunities announces a personal record in the channels of the
+// user's organizations. Only organizations the user agreed to be named on the leaderboard
+// of are told.
+func (s *FiberServer) postPersonalRecordToCommunities(record *database.PersonalRecord) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	integrations, err := s.db.ListMemberCommunityIntegrations(ctx, record.User_id)
+	if err != nil {
+		s.logCommunityPostFailure("", communityEventPersonalRecord, err)
+		return
+	}
+	if len(integrations) == 0 {
+		return
+	}
+
+	var exercise string
+	if e, err := s.db.GetExerciseByID(ctx, record.Exercise_id); err == nil {
+		exercise = e.Name
+	}
+	for i := range integrations {
+		integration := &integrations[i].Community_integrations
+		text := communityFormatter{integration.Provider}.personalRecordPost(integrations[i].Display_name, exercise, record)
+		if err := s.postToCommunity(ctx, integration, text); err != nil {
+			s.logCommunityPostFailure(integration.Organization_id, communityEventPersonalRecord, err)
+		}
+	}
+}
+
+// StartCommunityLeaderboards posts each organization's leaderboard for the past week to its
+// community channel once its week is over, checking every COMMUNITY_LEADERBOARD_INTERVAL
+// (default 15 minutes)
+func (s *FiberServer) StartCommunityLeaderboards(ctx context.Context) {
+	s.runPeriodically(ctx, getEnvDuration("COMMUNITY_LEADERBOARD_INTERVAL", 15*time.Minute), s.postWeeklyLeaderboards)
+}
+
+func (s *FiberServer) postWeeklyLeaderboards(ctx context.Context) {
+	listCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	integrations, err := s.db.ListLeaderboardCommunityIntegrations(listCtx)
+	cancel()
+	if err != nil {
+		s.logError("ERROR", messages.CommunityLeaderboardsFailed, err, nil, map[string]interface{}{
+			"component": "community",
+		})
+		return
+	}
+
+	now := time.Now()
+	for i := range integrations {
+		if ctx.Err() != nil {
+			return
+		}
+		s.postWeeklyLeaderboard(ctx, &integrations[i], now)
+	}
+}
+
+// postWeeklyLeaderboard posts the organization's leaderboard for the week before now's,
+// unless it was posted already. Weeks nobody on the leaderboard trained in are skipped.
+func (s *FiberServer) postWeeklyLeaderboard(ctx context.Context, integration *database.Community_integrations, now time.Time) {
+	loc, err := time.LoadLocation(integration.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	weekStart := displayWeekStart(now, loc)
+	if integration.Leaderboard_posted_for != nil && !integration.Leaderboard_posted_for.Before(weekStart) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	orgID := integration.Organization_id
+	claimed, err := s.db.ClaimWeeklyLeaderboard(ctx, orgID, weekStart)
+	if err != nil || !claimed {
+		if err != nil {
+			s.logCommunityPostFailure(orgID, communityEventWeeklyLeaderboard, err)
+		}
+		return
+	}
+
+	lastWeek := weekStart.AddDate(0, 0, -7)
+	entries, err := s.db.ListLeaderboard(ctx, orgID, lastWeek, weekStart, leaderboardSize)
+	if err != nil || len(entries) == 0 {
+		if err != nil {
+			s.logCommunityPostFailure(orgID, communityEventWeeklyLeaderboard, err)
+		}
+		return
+	}
+	org, err := s.db.GetOrganizationByID(ctx, orgID)
+	if err != nil {
+		s.logCommunityPostFailure(orgID, communityEventWeeklyLeaderboard, err)
+		return
+	}
+
+	text := communityFormatter{integration.Provider}.leaderboardPost(org.Name, lastWeek, entries)
+	if err := s.postToCommunity(ctx, integration, text); err != nil {
+		s.logCommunityPostFailure(orgID, communityEventWeeklyLeaderboard, err)
+	}
+}
+
+// GET /api/v1/organizations/:orgId/community
+func (s *FiberServer) getCommunityIntegration(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	integration, err := s.db.GetCommunityIntegration(ctx, c.Params("orgId"))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errorResponse(c, fiber.StatusNotFound, "No community channel is connected")
+		}
+		LogDatabaseError(s, "get_community_integration", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to fetch community channel")
+	}
+
+	return successResponse(c, communityIntegrationToResponse(integration))
+}
+
+// PUT /api/v1/organizations/:orgId/community
+// Connects a Slack or Discord channel, or changes which events are posted to it
+func (s *FiberServer) putCommunityIntegration(c *fiber.Ctx) error {
+	var req database.CommunityIntegrationRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	orgID := c.Params("orgId")
+	existing, err := s.db.GetCommunityIntegration(ctx, orgID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		LogDatabaseError(s, "get_community_integration", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to fetch community channel")
+	}
+
+	webhookURL, timezone := strings.TrimSpace(req.WebhookURL), strings.TrimSpace(req.Timezone)
+	if existing != nil {
+		if webhookURL == "" {
+			webhookURL = existing.Webhook_url
+		}
+		if timezone == "" {
+			timezone = existing.Timezone
+		}
+	}
+	if webhookURL == "" {
+		return errorResponse(c, fiber.StatusBadRequest, "webhookUrl is required")
+	}
+	provider, err := communityProvider(webhookURL)
+	if err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, "timezone must be an IANA timezone such as Europe/Berlin")
+	}
+
+	// A new channel gets its first leaderboard at the end of the current week
+	postedFor := displayWeekStart(time.Now(), loc)
+	integration, err := s.db.UpsertCommunityIntegration(ctx, &database.Community_integrations{
+		Organization_id:         orgID,
+		Provider:                provider,
+		Webhook_url:             webhookURL,
+		Timezone:                loc.String(),
+		Post_personal_records:   req.PersonalRecords == nil || *req.PersonalRecords,
+		Post_weekly_leaderboard: req.WeeklyLeaderboard == nil || *req.WeeklyLeaderboard,
+		Enabled:                 req.Enabled == nil || *req.Enabled,
+		Leaderboard_posted_for:  &postedFor,
+	})
+	if err != nil {
+		LogDatabaseError(s, "upsert_community_integration", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to save community channel")
+	}
+
+	return successResponse(c, communityIntegrationToResponse(integration))
+}
+
+// POST /api/v1/organizations/:orgId/community/test
+// Posts a test message, so admins can check the channel is connected
+func (s *FiberServer) testCommunityIntegration(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(c.UserContext(), 15*time.Second)
+	defer cancel()
+
+	orgID := c.Params("orgId")
+	integration, err := s.db.GetCommunityIntegration(ctx, orgID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errorResponse(c, fiber.StatusNotFound, "No community channel is connected")
+		}
+		LogDatabaseError(s, "get_community_integration", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to fetch community channel")
+	}
+	org, err := s.db.GetOrganizationByID(ctx, orgID)
+	if err != nil {
+		LogDatabaseError(s, "get_organization", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to fetch organization")
+	}
+
+	f := communityFormatter{integration.Provider}
+	text := "✅ FitnessHack is connected. " + f.bold(org.Name) + " personal records and weekly leaderboards will be posted here."
+	if err := s.postToCommunity(ctx, integration, text); err != nil {
+		return errorResponse(c, fiber.StatusBadGateway, "The channel didn't accept the message: "+err.Error())
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// DELETE /api/v1/organizations/:orgId/community
+func (s *FiberServer) deleteCommunityIntegration(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := s.db.DeleteCommunityIntegration(ctx, c.Params("orgId")); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errorResponse(c, fiber.StatusNotFound, "No community channel is connected")
+		}
+		LogDatabaseError(s, "delete_community_integration", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to disconnect community channel")
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
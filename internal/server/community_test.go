@@ -0,0 +1,101 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"fitness-hack/internal/database"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestCommunityProvider(t *testing.T) {
+	valid := map[string]database.Community_integrations_provider{
+		"https://hooks.slack.com/services/T000/B000/XXXX":       database.Community_integrations_provider_slack,
+		"https://discord.com/api/webhooks/123/abc":              database.Community_integrations_provider_discord,
+		"https://discordapp.com/api/webhooks/123/abc?wait=true": database.Community_integrations_provider_discord,
+	}
+	for u, want := range valid {
+		if got, err := communityProvider(u); err != nil || got != want {
+			t.Errorf("%q: expected %s, got %s, %v", u, want, got, err)
+		}
+	}
+
+	for _, u := range []string{
+		"http://hooks.slack.com/services/T000/B000/XXXX",
+		"https://hooks.slack.com/workflows/T000",
+		"https://hooks.slack.com.example.com/services/T000",
+		"https://user@discord.com/api/webhooks/123/abc",
+		"https://discord.com:8443/api/webhooks/123/abc",
+		"https://example.com/api/webhooks/123/abc",
+		"https://canary.discordapp.com/api/webhooks/123/abc",
+	} {
+		if _, err := communityProvider(u); err == nil {
+			t.Errorf("expected %q to be rejected", u)
+		}
+	}
+}
+
+func TestMaskWebhookURL(t *testing.T) {
+	if got := maskWebhookURL("https://hooks.slack.com/services/T000/B000/XXXX"); got != "https://hooks.slack.com/…" {
+		t.Errorf("expected the path to be hidden, got %q", got)
+	}
+}
+
+func TestCommunityFormatter(t *testing.T) {
+	record := &database.PersonalRecord{Weight_kg: decimal.NewFromInt(100), Reps: 5, Previous_best_kg: decimal.NewFromFloat(97.5)}
+
+	slack := communityFormatter{database.Community_integrations_provider_slack}
+	if got := slack.personalRecordPost("Sam <K>", "Bench Press", record); got != "🏆 *Sam &lt;K&gt;* set a new personal record on *Bench Press*: 100 kg for 5 reps, up from 97.5 kg." {
+		t.Errorf("unexpected Slack post %q", got)
+	}
+
+	discord := communityFormatter{database.Community_integrations_provider_discord}
+	if got := discord.personalRecordPost("sam_k", "", record); !strings.HasPrefix(got, `🏆 **sam\_k** set a new personal record on **an exercise**`) {
+		t.Errorf("unexpected Discord post %q", got)
+	}
+
+	weekStart := time.Date(2025, 8, 11, 0, 0, 0, 0, time.UTC)
+	entries := []database.LeaderboardEntry{{Display_name: "Sam K.", Sessions: 4, Minutes: 210}, {Display_name: "Alex", Sessions: 1, Minutes: 45}}
+	want := "📊 *Iron Gym weekly leaderboard* for Aug 11 – Aug 17\n1. Sam K. – 4 sessions, 210 min\n2. Alex – 1 session, 45 min"
+	if got := slack.leaderboardPost("Iron Gym", weekStart, entries); got != want {
+		t.Errorf("expected leaderboard %q, got %q", want, got)
+	}
+
+	var body map[string]interface{}
+	payload, _ := discord.payload("@everyone")
+	json.Unmarshal(payload, &body)
+	if mentions, ok := body["allowed_mentions"].(map[string]interface{}); !ok || len(mentions["parse"].([]interface{})) != 0 {
+		t.Errorf("expected Discord posts to disable mentions, got %s", payload)
+	}
+}
+
+func TestPostToCommunity(t *testing.T) {
+	bodies := make(chan string, 1)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		bodies <- string(data)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	s := &FiberServer{webhookClient: newWebhookClient()}
+	integration := &database.Community_integrations{Provider: database.Community_integrations_provider_slack, Webhook_url: receiver.URL}
+	if err := s.postToCommunity(context.Background(), integration, "hello"); err == nil || len(bodies) > 0 {
+		t.Fatal("expected the loopback receiver to be refused")
+	}
+
+	t.Setenv("WEBHOOK_ALLOW_PRIVATE_URLS", "true")
+	if err := s.postToCommunity(context.Background(), integration, "hello"); err != nil {
+		t.Fatal(err)
+	}
+	if got := <-bodies; got != `{"text":"hello"}` {
+		t.Errorf("unexpected body %s", got)
+	}
+}
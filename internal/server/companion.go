@@ -0,0 +1,599 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+
+	"fitness-hack/internal/database"
+	"fitness-hack/internal/messages"
+	"fitness-hack/internal/websocket"
+)
+
+const (
+	// companionMaxMessageBytes caps a single client message; set logs are a few hundred bytes
+	companionMaxMessageBytes = 16 * 1024
+	// companionSendBuffer is how many messages may queue for a client before it's dropped as too slow
+	companionSendBuffer = 32
+	// companionPingInterval keeps idle connections alive through proxies; a client that
+	// hasn't answered within companionReadTimeout is disconnected
+	companionPingInterval = 30 * time.Second
+	companionReadTimeout  = 60 * time.Second
+	// maxRestSeconds bounds rest timers to an hour
+	maxRestSeconds = 3600
+)
+
+// restTickInterval is how often a running rest timer reports the time remaining
+var restTickInterval = time.Second
+
+// companionRequest is a message sent by the client. ID is chosen by the client and echoed
+// in the ack or error answering it.
+type companionRequest struct {
+	Type      string          `json:"type"`
+	ID        string          `json:"id"`
+	SessionID string          `json:"sessionId"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// companionMessage is a message pushed to the client
+type companionMessage struct {
+	Type      string      `json:"type"`
+	ID        string      `json:"id,omitempty"`
+	SessionID string      `json:"sessionId,omitempty"`
+	Data      interface{} `json:"data,omitempty"`
+	Error     string      `json:"error,omitempty"`
+}
+
+// logSetRequest is the data of a set.log message
+type logSetRequest struct {
+	ExerciseID      *string  `json:"exerciseId"`
+	SetNumber       int      `json:"setNumber"`
+	Reps            int      `json:"reps"`
+	WeightKg        float64  `json:"weightKg"`
+	DurationSeconds *int     `json:"durationSeconds"`
+	RPE             *float64 `json:"rpe"`
+	RestSeconds     *int     `json:"restSeconds"`
+	AMRAP           bool     `json:"amrap"`
+}
+
+// companionClient is one open connection. Messages are queued on send and written by the
+// connection's writer goroutine, so a slow client never blocks the hub.
+type companionClient struct {
+	userID string
+	send   chan companionMessage
+	// readOnly clients, connected with an impersonation token, can follow sessions but not
+	// log sets or run rest timers
+	readOnly bool
+
+	// sessions the client subscribed to; guarded by the hub's mutex
+	sessions map[string]bool
+
+	closeOnce   sync.Once
+	done        chan struct{}
+	closeCode   int
+	closeReason string
+}
+
+func newCompanionClient(userID string) *companionClient {
+	return &companionClient{
+		userID:   userID,
+		send:     make(chan companionMessage, companionSendBuffer),
+		sessions: map[string]bool{},
+		done:     make(chan struct{}),
+	}
+}
+
+// close asks the writer to send a close frame with code and end the connection
+func (c *companionClient) close(code int, reason string) {
+	c.closeOnce.Do(func() {
+		c.closeCode, c.closeReason = code, reason
+		close(c.done)
+	})
+}
+
+// restTimer counts down a rest period for one of a user's sessions
+type restTimer struct {
+	stop chan struct{}
+}
+
+// companionHub routes workout companion messages to a user's connections and runs their
+// rest timers. State is held in memory, so a user's devices only see each other's messages
+// while connected to the same instance; deployments with several instances need sticky
+// routing by user for /ws.
+type companionHub struct {
+	mu sync.Mutex
+	// clients are the open connections by user ID
+	clients map[string]map[*companionClient]bool
+	// timers are the running rest timers keyed by user ID and session ID
+	timers map[string]*restTimer
+}
+
+func newCompanionHub() *companionHub {
+	return &companionHub{
+		clients: map[string]map[*companionClient]bool{},
+		timers:  map[string]*restTimer{},
+	}
+}
+
+func restTimerKey(userID, sessionID string) string {
+	return userID + "/" + sessionID
+}
+
+func (h *companionHub) register(client *companionClient) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.clients[client.userID] == nil {
+		h.clients[client.userID] = map[*companionClient]bool{}
+	}
+	h.clients[client.userID][client] = true
+}
+
+// unregister removes a client. Rest timers have nobody to report to once the user's last
+// connection is gone, so they are stopped.
+func (h *companionHub) unregister(client *companionClient) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.clients[client.userID], client)
+	if len(h.clients[client.userID]) > 0 {
+		return
+	}
+	delete(h.clients, client.userID)
+	prefix := restTimerKey(client.userID, "")
+	for key, timer := range h.timers {
+		if strings.HasPrefix(key, prefix) {
+			close(timer.stop)
+			delete(h.timers, key)
+		}
+	}
+}
+
+func (h *companionHub) subscribe(client *companionClient, sessionID string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	client.sessions[sessionID] = true
+}
+
+func (h *companionHub) subscribed(client *companionClient, sessionID string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return client.sessions[sessionID]
+}
+
+// publish queues msg for the user's clients subscribed to the session, other than except
+func (h *companionHub) publish(userID, sessionID string, msg companionMessage, except *companionClient) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.publishLocked(userID, sessionID, msg, except)
+}
+
+func (h *companionHub) publishLocked(userID, sessionID string, msg companionMessage, except *companionClient) {
+	for client := range h.clients[userID] {
+		if client != except && client.sessions[sessionID] {
+			client.deliver(msg)
+		}
+	}
+}
+
+// deliver queues msg without blocking, dropping the client if its buffer is full
+func (c *companionClient) deliver(msg companionMessage) {
+	select {
+	case c.send <- msg:
+	default:
+		c.close(websocket.ClosePolicyViolation, "client too slow")
+	}
+}
+
+// startRest starts a rest countdown for the session, replacing any timer already running.
+// Timer messages are published while holding the hub's lock, so a tick can't arrive after
+// the timer was cancelled or replaced.
+func (h *companionHub) startRest(userID, sessionID string, seconds int) {
+	key := restTimerKey(userID, sessionID)
+	timer := &restTimer{stop: make(chan struct{})}
+	endsAt := time.Now().Add(time.Duration(seconds) * time.Second)
+
+	h.mu.Lock()
+	if running, ok := h.timers[key]; ok {
+		close(running.stop)
+	}
+	h.timers[key] = timer
+	h.publishLocked(userID, sessionID, companionMessage{
+		Type:      "rest.started",
+		SessionID: sessionID,
+		Data:      fiber.Map{"seconds": seconds, "endsAt": endsAt.UTC()},
+	}, nil)
+	h.mu.Unlock()
+
+	ticker := time.NewTicker(restTickInterval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-timer.stop:
+				return
+			case <-ticker.C:
+			}
+
+			h.mu.Lock()
+			if h.timers[key] != timer {
+				h.mu.Unlock()
+				return
+			}
+			remaining := time.Until(endsAt).Round(time.Second)
+			if remaining > 0 {
+				h.publishLocked(userID, sessionID, companionMessage{
+					Type:      "rest.tick",
+					SessionID: sessionID,
+					Data:      fiber.Map{"remaining": int(remaining.Seconds()), "endsAt": endsAt.UTC()},
+				}, nil)
+				h.mu.Unlock()
+				continue
+			}
+			delete(h.timers, key)
+			h.publishLocked(userID, sessionID, companionMessage{Type: "rest.done", SessionID: sessionID}, nil)
+			h.mu.Unlock()
+			return
+		}
+	}()
+}
+
+// cancelRest stops the session's rest timer, reporting whether one was running
+func (h *companionHub) cancelRest(userID, sessionID string) bool {
+	key := restTimerKey(userID, sessionID)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	timer, ok := h.timers[key]
+	if ok {
+		close(timer.stop)
+		delete(h.timers, key)
+		h.publishLocked(userID, sessionID, companionMessage{Type: "rest.cancelled", SessionID: sessionID}, nil)
+	}
+	return ok
+}
+
+// wsTokenFromQuery lets browsers, which can't set headers on WebSocket requests, pass their
+// JWT as ?token=. The request logger only records the path, so the token isn't logged.
+func wsTokenFromQuery(c *fiber.Ctx) error {
+	if token := c.Query("token"); token != "" && c.Get(fiber.HeaderAuthorization) == "" {
+		c.Request().Header.Set(fiber.HeaderAuthorization, "Bearer "+token)
+	}
+	return c.Next()
+}
+
+// GET /ws
+// Opens the workout companion socket. Clients subscribe to their sessions, then log sets
+// and run rest timers that every connected device of theirs follows along with.
+func (s *FiberServer) companionSocket(c *fiber.Ctx) error {
+	// API keys are for server-to-server integrations, not live sessions
+	if isAPIKeyRequest(c) {
+		return errorResponse(c, fiber.StatusForbidden, "The workout companion requires a user token")
+	}
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+	claims, _ := getJWTClaims(c)
+	expiresAt, _ := claims.GetExpirationTime()
+
+	return websocket.Upgrade(c, companionMaxMessageBytes, func(conn *websocket.Conn) {
+		client := newCompanionClient(userID)
+		_, client.readOnly = claims[claimImpersonatedBy]
+		s.companion.register(client)
+		defer s.companion.unregister(client)
+		defer client.close(websocket.CloseNormal, "")
+
+		// The token was only checked at connect time, so hang up when it expires
+		if expiresAt != nil {
+			expiry := time.AfterFunc(time.Until(expiresAt.Time), func() {
+				client.close(websocket.ClosePolicyViolation, "token expired")
+			})
+			defer expiry.Stop()
+		}
+
+		go s.writeCompanionMessages(conn, client)
+		s.readCompanionMessages(conn, client)
+	})
+}
+
+// writeCompanionMessages sends queued messages and keepalive pings until the client is closed
+func (s *FiberServer) writeCompanionMessages(conn *websocket.Conn, client *companionClient) {
+	ping := time.NewTicker(companionPingInterval)
+	defer ping.Stop()
+
+	for {
+		var err error
+		select {
+		case msg := <-client.send:
+			var data []byte
+			if data, err = s.encoder.Marshal(msg); err == nil {
+				err = conn.WriteMessage(websocket.TextMessage, data)
+			}
+		case <-ping.C:
+			err = conn.Ping()
+		case <-client.done:
+			conn.WriteClose(client.closeCode, client.closeReason)
+			// Unblock the reader so the connection is torn down
+			conn.SetReadDeadline(time.Now())
+			return
+		}
+		if err != nil {
+			client.close(websocket.CloseGoingAway, "")
+		}
+	}
+}
+
+// readCompanionMessages handles client messages until the connection closes
+func (s *FiberServer) readCompanionMessages(conn *websocket.Conn, client *companionClient) {
+	conn.OnPong = func() {
+		conn.SetReadDeadline(time.Now().Add(companionReadTimeout))
+	}
+
+	for {
+		conn.SetReadDeadline(time.Now().Add(companionReadTimeout))
+		messageType, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		if messageType != websocket.TextMessage {
+			client.close(websocket.CloseUnsupportedData, "messages must be JSON text")
+			return
+		}
+
+		var req companionRequest
+		if err := json.Unmarshal(data, &req); err != nil {
+			client.deliver(companionMessage{Type: "error", Error: "Invalid JSON message"})
+			continue
+		}
+		if reply := s.handleCompanionRequest(client, &req); reply != nil {
+			client.deliver(*reply)
+		}
+	}
+}
+
+// companionError is the reply to a request that failed
+func companionError(req *companionRequest, message string) *companionMessage {
+	return &companionMessage{Type: "error", ID: req.ID, SessionID: req.SessionID, Error: message}
+}
+
+// handleCompanionRequest processes one client message and returns the reply to send back, if any
+func (s *FiberServer) handleCompanionRequest(client *companionClient, req *companionRequest) *companionMessage {
+	if req.Type == "ping" {
+		return &companionMessage{Type: "pong", ID: req.ID}
+	}
+	if req.SessionID == "" {
+		return companionError(req, "sessionId is required")
+	}
+
+	switch req.Type {
+	case "subscribe":
+		return s.subscribeCompanion(client, req)
+	case "rest.start", "rest.cancel", "set.log":
+	default:
+		return companionError(req, "Unknown message type")
+	}
+
+	if client.readOnly {
+		return companionError(req, "Impersonation tokens are read-only")
+	}
+	if !s.companion.subscribed(client, req.SessionID) {
+		return companionError(req, "Subscribe to the session first")
+	}
+
+	switch req.Type {
+	case "rest.start":
+		var body struct {
+			Seconds int `json:"seconds"`
+		}
+		if err := json.Unmarshal(req.Data, &body); err != nil || body.Seconds < 1 || body.Seconds > maxRestSeconds {
+			return companionError(req, "seconds must be between 1 and 3600")
+		}
+		s.companion.startRest(client.userID, req.SessionID, body.Seconds)
+		// Kept in Redis too so the countdown shows on the session for devices not connected here
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		s.saveRestTimer(ctx, req.SessionID, body.Seconds, time.Now())
+		return &companionMessage{Type: "ack", ID: req.ID, SessionID: req.SessionID}
+
+	case "rest.cancel":
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		cleared, _ := s.clearRestTimer(ctx, req.SessionID)
+		if running := s.companion.cancelRest(client.userID, req.SessionID); !running && !cleared {
+			return companionError(req, "No rest timer is running")
+		}
+		return &companionMessage{Type: "ack", ID: req.ID, SessionID: req.SessionID}
+
+	default:
+		return s.logCompanionSet(client, req)
+	}
+}
+
+// subscribeCompanion subscribes the client to one of the user's sessions
+func (s *FiberServer) subscribeCompanion(client *companionClient, req *companionRequest) *companionMessage {
+	if _, err := uuid.Parse(req.SessionID); err != nil {
+		return companionError(req, "Workout session not found")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	session, err := s.db.GetWorkoutSessionByID(ctx, req.SessionID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && session.User_id != client.userID) {
+		return companionError(req, "Workout session not found")
+	}
+	if err != nil {
+		s.logError("ERROR", messages.CompanionSessionLoadFailed, err, nil, map[string]interface{}{
+			"component":  "companion",
+			"session_id": req.SessionID,
+		})
+		return companionError(req, "Failed to subscribe")
+	}
+
+	s.companion.subscribe(client, req.SessionID)
+	return &companionMessage{Type: "ack", ID: req.ID, SessionID: req.SessionID}
+}
+
+// logCompanionSet stores a set and acknowledges it with the stored row. The client's message
+// ID identifies the set, so a set resent because the ack was lost is acknowledged again
+// without being stored or announced twice.
+func (s *FiberServer) logCompanionSet(client *companionClient, req *companionRequest) *companionMessage {
+	if req.ID == "" || len(req.ID) > 100 {
+		return companionError(req, "id is required to log a set")
+	}
+	var body logSetRequest
+	if err := json.Unmarshal(req.Data, &body); err != nil {
+		return companionError(req, "Invalid set data")
+	}
+	if msg := validateLogSet(&body); msg != "" {
+		return companionError(req, msg)
+	}
+
+	set := &database.Workout_session_sets{
+		Session_id:       req.SessionID,
+		Exercise_id:      body.ExerciseID,
+		Set_number:       body.SetNumber,
+		Reps:             body.Reps,
+		Weight_kg:        decimal.NewFromFloat(body.WeightKg).Round(2),
+		Duration_seconds: body.DurationSeconds,
+		Rest_seconds:     body.RestSeconds,
+		Client_id:        req.ID,
+		Amrap:            body.AMRAP,
+	}
+	if body.RPE != nil {
+		rpe := decimal.NewFromFloat(*body.RPE).Round(1)
+		set.Rpe = &rpe
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	logged, created, err := s.db.LogWorkoutSessionSet(ctx, set)
+	if err != nil {
+		s.logError("ERROR", messages.CompanionSetLogFailed, err, nil, map[string]interface{}{
+			"component":  "companion",
+			"session_id": req.SessionID,
+		})
+		return companionError(req, "Failed to log set")
+	}
+
+	response := workoutSessionSetToResponse(logged)
+	if created {
+		s.companion.publish(client.userID, req.SessionID, companionMessage{
+			Type:      "set.logged",
+			SessionID: req.SessionID,
+			Data:      response,
+		}, client)
+	}
+	return &companionMessage{Type: "ack", ID: req.ID, SessionID: req.SessionID, Data: response}
+}
+
+// validateLogSet returns a message describing what's wrong with a set, or "" if it's valid
+func validateLogSet(body *logSetRequest) string {
+	switch {
+	case body.ExerciseID != nil && uuid.Validate(*body.ExerciseID) != nil:
+		return "exerciseId must be a valid UUID"
+	case body.SetNumber < 1:
+		return "setNumber must be at least 1"
+	case body.Reps < 0:
+		return "reps cannot be negative"
+	case body.WeightKg < 0 || body.WeightKg >= 1000:
+		return "weightKg must be between 0 and 999.99"
+	case body.DurationSeconds != nil && *body.DurationSeconds < 0:
+		return "durationSeconds cannot be negative"
+	case body.RestSeconds != nil && *body.RestSeconds < 0:
+		return "restSeconds cannot be negative"
+	case body.RPE != nil && (*body.RPE < 1 || *body.RPE > 10):
+		return "rpe must be between 1 and 10"
+	}
+	return ""
+}
+
+// Helper to convert database workout session set to response model
+func workoutSessionSetToResponse(set *database.Workout_session_sets) database.WorkoutSessionSetResponse {
+	response := database.WorkoutSessionSetResponse{
+		ID:              set.Id,
+		SessionID:       set.Session_id,
+		ExerciseID:      set.Exercise_id,
+		SetNumber:       set.Set_number,
+		Reps:            set.Reps,
+		WeightKg:        set.Weight_kg.InexactFloat64(),
+		DurationSeconds: set.Duration_seconds,
+		RestSeconds:     set.Rest_seconds,
+		AMRAP:           set.Amrap,
+		ClientID:        set.Client_id,
+		CompletedAt:     set.Completed_at,
+	}
+	if set.Rpe != nil {
+		rpe := set.Rpe.InexactFloat64()
+		response.RPE = &rpe
+	}
+	return response
+}
+
+// GET /api/v1/workout-sessions/:id/sets
+func (s *FiberServer) listWorkoutSessionSets(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	session, err := s.db.GetWorkoutSessionByID(ctx, c.Params("id"))
+	if err != nil || session.User_id != userID {
+		return notFound(c, "Workout session")
+	}
+
+	sets, err := s.db.ListWorkoutSessionSets(ctx, session.Id)
+	if err != nil {
+		LogDatabaseError(s, "list_workout_session_sets", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to list workout session sets")
+	}
+
+	responses := make([]database.WorkoutSessionSetResponse, len(sets))
+	for i := range sets {
+		responses[i] = workoutSessionSetToResponse(&sets[i])
+	}
+	return successResponse(c, responses)
+}
+
+// POST /api/v1/workout-sessions/:id/copy-last
+func (s *FiberServer) copyLastWorkoutSessionSets(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	sourceID, sets, err := s.db.CopyLastWorkoutSessionSets(ctx, c.Params("id"), userID)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return notFound(c, "Workout session")
+	case errors.Is(err, database.ErrNoPreviousSession):
+		return errorResponse(c, fiber.StatusNotFound, "No previous session of this workout to copy")
+	case errors.Is(err, database.ErrSessionHasSets):
+		return errorResponse(c, fiber.StatusConflict, "Workout session already has sets")
+	case err != nil:
+		LogDatabaseError(s, "copy_last_workout_session_sets", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to copy previous session")
+	}
+
+	response := database.CopiedSetsResponse{
+		SourceSessionID: sourceID,
+		Sets:            make([]database.WorkoutSessionSetResponse, len(sets)),
+	}
+	for i := range sets {
+		response.Sets[i] = workoutSessionSetToResponse(&sets[i])
+	}
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"data": response})
+}
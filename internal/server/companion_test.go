@@ -0,0 +1,98 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// nextMessage waits briefly for a message queued for the client
+func nextMessage(t *testing.T, client *companionClient) companionMessage {
+	t.Helper()
+	select {
+	case msg := <-client.send:
+		return msg
+	case <-time.After(time.Second):
+		t.Fatal("expected a message")
+		return companionMessage{}
+	}
+}
+
+func TestCompanionHubRoutesBySubscription(t *testing.T) {
+	hub := newCompanionHub()
+	phone, watch, other := newCompanionClient("u1"), newCompanionClient("u1"), newCompanionClient("u2")
+	for _, c := range []*companionClient{phone, watch, other} {
+		hub.register(c)
+		hub.subscribe(c, "s1")
+	}
+	unsubscribed := newCompanionClient("u1")
+	hub.register(unsubscribed)
+
+	hub.publish("u1", "s1", companionMessage{Type: "set.logged"}, phone)
+
+	if msg := nextMessage(t, watch); msg.Type != "set.logged" {
+		t.Errorf("expected the other device to get the set, got %q", msg.Type)
+	}
+	for name, c := range map[string]*companionClient{"sender": phone, "other user": other, "unsubscribed": unsubscribed} {
+		if len(c.send) != 0 {
+			t.Errorf("expected nothing sent to the %s", name)
+		}
+	}
+}
+
+func TestCompanionRestTimer(t *testing.T) {
+	restTickInterval = 10 * time.Millisecond
+	defer func() { restTickInterval = time.Second }()
+
+	hub := newCompanionHub()
+	client := newCompanionClient("u1")
+	hub.register(client)
+	hub.subscribe(client, "s1")
+
+	hub.startRest("u1", "s1", 1)
+	if msg := nextMessage(t, client); msg.Type != "rest.started" {
+		t.Fatalf("expected rest.started, got %q", msg.Type)
+	}
+	if !hub.cancelRest("u1", "s1") {
+		t.Fatal("expected a running timer to be cancelled")
+	}
+	for msg := nextMessage(t, client); msg.Type != "rest.cancelled"; msg = nextMessage(t, client) {
+		if msg.Type != "rest.tick" {
+			t.Fatalf("expected ticks then rest.cancelled, got %q", msg.Type)
+		}
+	}
+	if hub.cancelRest("u1", "s1") {
+		t.Error("expected no timer after cancelling")
+	}
+
+	// The user's last connection going away stops their timers
+	hub.startRest("u1", "s1", 60)
+	hub.unregister(client)
+	if len(hub.timers) != 0 {
+		t.Errorf("expected timers to stop with the last connection, %d running", len(hub.timers))
+	}
+}
+
+func TestCompanionRestrictedTokens(t *testing.T) {
+	s, _ := newFakeServer(t)
+
+	token, err := generateJWTWithClaims("u1", time.Now().Add(time.Hour), jwt.MapClaims{claimPasswordReset: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status := authedStatus(t, s, "GET", "/ws", "Bearer "+token); status != fiber.StatusForbidden {
+		t.Errorf("expected a password reset token refused the socket, got %d", status)
+	}
+
+	// Impersonation tokens can connect, but the socket only lets them follow along
+	client := newCompanionClient("u1")
+	client.readOnly = true
+	for _, msgType := range []string{"set.log", "rest.start", "rest.cancel"} {
+		reply := s.handleCompanionRequest(client, &companionRequest{Type: msgType, SessionID: "s1"})
+		if reply == nil || reply.Type != "error" || reply.Error != "Impersonation tokens are read-only" {
+			t.Errorf("expected %s refused for a read-only client, got %+v", msgType, reply)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math"
+	"time"
+
+	"fitness-hack/internal/database"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// summaryDay resolves the date and tz query parameters to the bounds of that calendar
+// day. The date defaults to today in the requested timezone, which defaults to UTC.
+func summaryDay(date, tz string, now time.Time) (from, to time.Time, err error) {
+	if tz == "" {
+		tz = "UTC"
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return from, to, errors.New("tz must be an IANA timezone name")
+	}
+
+	if date == "" {
+		from = now.In(loc)
+		from = time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, loc)
+	} else if from, err = time.ParseInLocation("2006-01-02", date, loc); err != nil {
+		return from, to, errors.New("date must be formatted as YYYY-MM-DD")
+	}
+	// AddDate rather than 24h so days with a DST change keep their real length
+	return from, from.AddDate(0, 0, 1), nil
+}
+
+// userTimezone returns the timezone the request counts days in: the tz query parameter,
+// or else the one saved on the user's profile. A user that can't be found gets "", which
+// summaryDay reads as UTC.
+func (s *FiberServer) userTimezone(ctx context.Context, c *fiber.Ctx, userID string) (string, error) {
+	if tz := c.Query("tz"); tz != "" {
+		return tz, nil
+	}
+	user, err := s.db.GetUserByID(ctx, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+	return user.Timezone, nil
+}
+
+// GET /api/v1/users/me/summary
+func (s *FiberServer) getDailySummary(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tz, err := s.userTimezone(ctx, c, userID)
+	if err != nil {
+		LogDatabaseError(s, "get_user", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to fetch daily summary")
+	}
+	from, to, err := summaryDay(c.Query("date"), tz, time.Now())
+	if err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	summary, err := s.db.GetDailySummary(ctx, userID, from, to)
+	if err != nil {
+		LogDatabaseError(s, "get_daily_summary", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to fetch daily summary")
+	}
+
+	response := database.DailySummaryResponse{
+		Date:          from.Format("2006-01-02"),
+		Timezone:      from.Location().String(),
+		Sessions:      make([]database.WorkoutSessionResponse, len(summary.Sessions)),
+		SessionCount:  len(summary.Sessions),
+		TotalVolumeKg: summary.TotalVolumeKg.InexactFloat64(),
+		SetCount:      summary.SetCount,
+		BodyMetrics:   make([]database.BodyMetricResponse, len(summary.BodyMetrics)),
+	}
+	for i := range summary.Sessions {
+		session := &summary.Sessions[i]
+		response.Sessions[i] = workoutSessionToResponse(session)
+		response.TotalDurationMinutes += session.Duration_minutes
+		if session.Distance_meters != nil {
+			response.TotalDistanceMeters += *session.Distance_meters
+		}
+	}
+	response.TotalDistanceMeters = math.Round(response.TotalDistanceMeters*10) / 10
+	for i := range summary.BodyMetrics {
+		response.BodyMetrics[i] = bodyMetricToResponse(&summary.BodyMetrics[i])
+	}
+
+	return successResponse(c, response)
+}
@@ -0,0 +1,36 @@
+package server
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSummaryDay(t *testing.T) {
+	now := time.Date(2025, 3, 30, 23, 30, 0, 0, time.UTC)
+
+	from, to, err := summaryDay("", "", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !from.Equal(time.Date(2025, 3, 30, 0, 0, 0, 0, time.UTC)) || to.Sub(from) != 24*time.Hour {
+		t.Fatalf("default day: got %v - %v", from, to)
+	}
+
+	// 23:30 UTC is already the next day in Berlin, and that day is an hour short
+	from, to, err = summaryDay("", "Europe/Berlin", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if from.Format("2006-01-02") != "2025-03-31" {
+		t.Fatalf("expected Berlin's today to be 2025-03-31, got %s", from.Format("2006-01-02"))
+	}
+	if from, to, _ = summaryDay("2025-03-30", "Europe/Berlin", now); to.Sub(from) != 23*time.Hour {
+		t.Fatalf("expected a 23 hour day across the DST change, got %v", to.Sub(from))
+	}
+
+	for _, tc := range []struct{ date, tz string }{{"30/03/2025", ""}, {"2025-03-30", "Mars/Olympus"}} {
+		if _, _, err := summaryDay(tc.date, tc.tz, now); err == nil {
+			t.Errorf("expected an error for date %q tz %q", tc.date, tc.tz)
+		}
+	}
+}
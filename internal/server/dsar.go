@@ -0,0 +1,336 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"fitness-hack/internal/database"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	dsarTypeAccess   = "access"
+	dsarTypeDeletion = "deletion"
+)
+
+// dsarUnresolved reports whether a request still needs action from an admin
+func dsarUnresolved(status database.Data_subject_requests_status) bool {
+	return status == database.Data_subject_requests_status_received || status == database.Data_subject_requests_status_in_progress
+}
+
+// Helper to convert database data subject request to response model
+func dataSubjectRequestToResponse(req *database.Data_subject_requests) database.DataSubjectRequestResponse {
+	return database.DataSubjectRequestResponse{
+		ID:           req.Id,
+		UserID:       req.User_id,
+		SubjectEmail: req.Subject_email,
+		Type:         string(req.Type),
+		Status:       string(req.Status),
+		Notes:        req.Notes,
+		DueAt:        req.Due_at,
+		Overdue:      dsarUnresolved(req.Status) && time.Now().After(req.Due_at),
+		CreatedBy:    req.Created_by,
+		ResolvedBy:   req.Resolved_by,
+		CreatedAt:    req.Created_at,
+		UpdatedAt:    req.Updated_at,
+		CompletedAt:  req.Completed_at,
+	}
+}
+
+// dataSubjectRequestWithArtifacts adds the export or account deletion fulfilling the request
+func (s *FiberServer) dataSubjectRequestWithArtifacts(ctx context.Context, req *database.Data_subject_requests) (database.DataSubjectRequestResponse, error) {
+	response := dataSubjectRequestToResponse(req)
+
+	if req.Data_export_id != nil {
+		export, err := s.db.GetDataExport(ctx, *req.Data_export_id, req.User_id)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return response, err
+		}
+		if err == nil {
+			exportResponse := s.dataExportToResponse(ctx, export, "/api/v1/admin/dsar/"+req.Id+"/export")
+			response.Export = &exportResponse
+		}
+	}
+
+	if req.Account_deletion_id != nil {
+		deletion, err := s.db.GetAccountDeletion(ctx, *req.Account_deletion_id)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return response, err
+		}
+		if err == nil {
+			deletionResponse := accountDeletionToResponse(deletion)
+			response.Deletion = &deletionResponse
+		}
+	}
+
+	return response, nil
+}
+
+// syncDataSubjectRequests brings request statuses up to date with their exports and
+// deletions. A failure only leaves statuses stale, so it is logged rather than returned.
+func (s *FiberServer) syncDataSubjectRequests(ctx context.Context, c *fiber.Ctx) {
+	if err := s.db.SyncDataSubjectRequests(ctx); err != nil {
+		LogDatabaseError(s, "sync_data_subject_requests", err, c)
+	}
+}
+
+// POST /api/v1/admin/dsar
+func (s *FiberServer) createDataSubjectRequest(c *fiber.Ctx) error {
+	adminID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	var req database.CreateDSARRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+	if !database.Data_subject_requests_type(req.Type).Valid() {
+		return errorResponse(c, fiber.StatusBadRequest, "Type must be access or deletion")
+	}
+	req.UserID = strings.TrimSpace(req.UserID)
+	req.Email = strings.TrimSpace(req.Email)
+	if req.UserID == "" && req.Email == "" {
+		return errorResponse(c, fiber.StatusBadRequest, "User ID or email is required")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var user *database.Users
+	if req.UserID != "" {
+		user, err = s.db.GetUserByID(ctx, req.UserID)
+	} else {
+		user, err = s.db.GetUserByEmail(ctx, req.Email)
+	}
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return notFound(c, "User")
+		}
+		LogDatabaseError(s, "get_user", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to create data subject request")
+	}
+
+	dueAt := time.Now().AddDate(0, 0, getEnvInt("DSAR_RESPONSE_DAYS", 30))
+	if req.DueAt != nil {
+		dueAt = *req.DueAt
+	}
+
+	created, err := s.db.CreateDataSubjectRequest(ctx, &database.Data_subject_requests{
+		User_id:       user.Id,
+		Subject_email: userToResponse(user).Email,
+		Type:          database.Data_subject_requests_type(req.Type),
+		Notes:         strings.TrimSpace(req.Notes),
+		Due_at:        dueAt,
+		Created_by:    adminID,
+	})
+	if err != nil {
+		LogDatabaseError(s, "create_data_subject_request", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to create data subject request")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"data": dataSubjectRequestToResponse(created)})
+}
+
+// GET /api/v1/admin/dsar?status=received&overdue=true
+func (s *FiberServer) listDataSubjectRequests(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	s.syncDataSubjectRequests(ctx, c)
+
+	requests, err := s.db.ListDataSubjectRequests(ctx, c.Query("status"), c.QueryBool("overdue"), getListOptions(c))
+	if errors.Is(err, database.ErrInvalidListOption) {
+		return errorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+	if err != nil {
+		LogDatabaseError(s, "list_data_subject_requests", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to fetch data subject requests")
+	}
+
+	responses := make([]database.DataSubjectRequestResponse, len(requests))
+	for i := range requests {
+		responses[i] = dataSubjectRequestToResponse(&requests[i])
+	}
+	return successResponse(c, responses)
+}
+
+// GET /api/v1/admin/dsar/:id
+func (s *FiberServer) getDataSubjectRequest(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	s.syncDataSubjectRequests(ctx, c)
+
+	req, err := s.db.GetDataSubjectRequest(ctx, c.Params("id"))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return notFound(c, "Data subject request")
+		}
+		LogDatabaseError(s, "get_data_subject_request", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to fetch data subject request")
+	}
+
+	response, err := s.dataSubjectRequestWithArtifacts(ctx, req)
+	if err != nil {
+		LogDatabaseError(s, "get_data_subject_request_artifacts", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to fetch data subject request")
+	}
+	return successResponse(c, response)
+}
+
+// POST /api/v1/admin/dsar/:id/fulfill
+// Access requests generate a data export of the subject's account; deletion requests
+// schedule the account for deletion, immediately if requested. Fulfilling again retries
+// with a new export, e.g. after the previous one failed.
+func (s *FiberServer) fulfillDataSubjectRequest(c *fiber.Ctx) error {
+	adminID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	var body database.FulfillDSARRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&body); err != nil {
+			return errorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	req, err := s.db.GetDataSubjectRequest(ctx, c.Params("id"))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return notFound(c, "Data subject request")
+		}
+		LogDatabaseError(s, "get_data_subject_request", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to fulfill data subject request")
+	}
+	if !dsarUnresolved(req.Status) {
+		return errorResponse(c, fiber.StatusConflict, "Data subject request is already "+string(req.Status))
+	}
+
+	if _, err := s.db.GetUserByID(ctx, req.User_id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errorResponse(c, fiber.StatusConflict, "The subject's account no longer exists")
+		}
+		LogDatabaseError(s, "get_user", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to fulfill data subject request")
+	}
+
+	var exportID, deletionID *string
+	switch req.Type {
+	case dsarTypeAccess:
+		// Share an export already running for the user rather than starting a second one
+		export, err := s.db.GetPendingDataExport(ctx, req.User_id)
+		if errors.Is(err, sql.ErrNoRows) {
+			// Access requests cover everything held about the user, vaulted photos included
+			export, err = s.db.CreateDataExport(ctx, req.User_id, true)
+			if err == nil {
+				err = s.enqueueDataExport(ctx, export)
+			}
+		}
+		if err != nil {
+			LogDatabaseError(s, "create_data_export", err, c)
+			return errorResponse(c, fiber.StatusInternalServerError, "Failed to fulfill data subject request")
+		}
+		exportID = &export.Id
+
+	case dsarTypeDeletion:
+		purgeAfter := time.Now()
+		if !body.Immediate {
+			purgeAfter = purgeAfter.Add(getEnvDuration("ACCOUNT_DELETION_GRACE_PERIOD", 30*24*time.Hour))
+		}
+		deletion, err := s.db.ScheduleAccountDeletion(ctx, req.User_id, c.IP(), purgeAfter)
+		if err != nil {
+			LogDatabaseError(s, "schedule_account_deletion", err, c)
+			return errorResponse(c, fiber.StatusInternalServerError, "Failed to fulfill data subject request")
+		}
+		deletionID = &deletion.Id
+	}
+
+	req, err = s.db.StartDataSubjectRequest(ctx, req.Id, adminID, exportID, deletionID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errorResponse(c, fiber.StatusConflict, "Data subject request was resolved in the meantime")
+		}
+		LogDatabaseError(s, "start_data_subject_request", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to fulfill data subject request")
+	}
+
+	response, err := s.dataSubjectRequestWithArtifacts(ctx, req)
+	if err != nil {
+		LogDatabaseError(s, "get_data_subject_request_artifacts", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to fetch data subject request")
+	}
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{"data": response})
+}
+
+// POST /api/v1/admin/dsar/:id/reject
+func (s *FiberServer) rejectDataSubjectRequest(c *fiber.Ctx) error {
+	adminID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	var body database.RejectDSARRequest
+	if err := c.BodyParser(&body); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+	body.Reason = strings.TrimSpace(body.Reason)
+	if body.Reason == "" {
+		return errorResponse(c, fiber.StatusBadRequest, "Reason is required")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := s.db.GetDataSubjectRequest(ctx, c.Params("id")); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return notFound(c, "Data subject request")
+		}
+		LogDatabaseError(s, "get_data_subject_request", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to reject data subject request")
+	}
+
+	req, err := s.db.RejectDataSubjectRequest(ctx, c.Params("id"), adminID, body.Reason)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errorResponse(c, fiber.StatusConflict, "Only requests that have not been acted on can be rejected")
+		}
+		LogDatabaseError(s, "reject_data_subject_request", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to reject data subject request")
+	}
+
+	return successResponse(c, dataSubjectRequestToResponse(req))
+}
+
+// GET /api/v1/admin/dsar/:id/export
+// Downloads the archive generated for an access request, for delivery to the subject
+func (s *FiberServer) downloadDataSubjectRequestExport(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	req, err := s.db.GetDataSubjectRequest(ctx, c.Params("id"))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return notFound(c, "Data subject request")
+		}
+		LogDatabaseError(s, "get_data_subject_request", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to download export")
+	}
+	if req.Data_export_id == nil {
+		return errorResponse(c, fiber.StatusNotFound, "No export has been generated for this request")
+	}
+
+	export, err := s.db.GetDataExport(ctx, *req.Data_export_id, req.User_id)
+	if err != nil {
+		return notFound(c, "Export")
+	}
+	return s.sendDataExport(ctx, c, export)
+}
@@ -0,0 +1,159 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"fitness-hack/internal/database"
+	"fitness-hack/internal/database/dbtest"
+	"fitness-hack/internal/jobs"
+	"fitness-hack/internal/memstore"
+	"fitness-hack/internal/storage"
+)
+
+// newDSARServer returns a server whose admin-1 handles data subject requests about a
+// seeded user, and that user
+func newDSARServer(t *testing.T) (*FiberServer, *adminStub, *database.Users) {
+	t.Helper()
+	db := dbtest.NewFake()
+	stub := &adminStub{Fake: db, adminID: "admin-1"}
+	s := newTestServer(t, stub)
+	s.cache = memstore.New().Client()
+	s.storage = storage.NewLocal(t.TempDir())
+	s.jobs = jobs.NewLocal()
+	subject, err := db.CreateUser(context.Background(), &database.Users{Email: "subject@example.com", Username: "subject"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, stub, subject
+}
+
+// dsarRequest sends an admin request and decodes the response data into out
+func dsarRequest(t *testing.T, s *FiberServer, method, path, body string, out interface{}) int {
+	t.Helper()
+	req := httptest.NewRequest(method, "/api/v1/admin/dsar"+path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", bearer(t, "admin-1"))
+	resp, err := s.App.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != nil {
+		json.NewDecoder(resp.Body).Decode(&struct{ Data interface{} }{out})
+	}
+	return resp.StatusCode
+}
+
+func TestDSARAccessExport(t *testing.T) {
+	ctx := context.Background()
+	s, _, subject := newDSARServer(t)
+
+	var created database.DataSubjectRequestResponse
+	if status := dsarRequest(t, s, "POST", "", `{"email":"subject@example.com","type":"access"}`, &created); status != 201 {
+		t.Fatalf("expected the request created, got %d", status)
+	}
+	if created.UserID != subject.Id || created.Status != "received" {
+		t.Fatalf("expected a received request for the subject, got %+v", created)
+	}
+	if status := dsarRequest(t, s, "GET", "/"+created.ID+"/export", "", nil); status != 404 {
+		t.Errorf("expected no export before fulfilling, got %d", status)
+	}
+
+	var started database.DataSubjectRequestResponse
+	if status := dsarRequest(t, s, "POST", "/"+created.ID+"/fulfill", "", &started); status != 202 {
+		t.Fatalf("expected the request fulfilled, got %d", status)
+	}
+	if started.Status != "in_progress" || started.Export == nil {
+		t.Fatalf("expected an export in progress, got %+v", started)
+	}
+	if status := dsarRequest(t, s, "GET", "/"+created.ID+"/export", "", nil); status != 409 {
+		t.Errorf("expected the download refused while the export runs, got %d", status)
+	}
+	if status := dsarRequest(t, s, "POST", "/"+created.ID+"/reject", `{"reason":"too late"}`, nil); status != 409 {
+		t.Errorf("expected a request being acted on not to be rejected, got %d", status)
+	}
+
+	// Stand in for the job worker
+	if err := s.runDataExport(ctx, started.Export.ID, subject.Id, true, true); err != nil {
+		t.Fatal(err)
+	}
+
+	var got database.DataSubjectRequestResponse
+	if status := dsarRequest(t, s, "GET", "/"+created.ID, "", &got); status != 200 {
+		t.Fatalf("expected the request fetched, got %d", status)
+	}
+	if got.Status != "completed" || got.CompletedAt == nil {
+		t.Errorf("expected the request completed with its export, got %+v", got)
+	}
+	if status := dsarRequest(t, s, "GET", "/"+created.ID+"/export", "", nil); status != 200 {
+		t.Errorf("expected the export downloaded, got %d", status)
+	}
+	if status := dsarRequest(t, s, "POST", "/"+created.ID+"/fulfill", "", nil); status != 409 {
+		t.Errorf("expected a completed request not fulfilled again, got %d", status)
+	}
+}
+
+func TestDSARErasure(t *testing.T) {
+	ctx := context.Background()
+	s, db, subject := newDSARServer(t)
+	workout, err := db.CreateWorkout(ctx, &database.Workouts{User_id: subject.Id, Name: "Legs"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var created database.DataSubjectRequestResponse
+	if status := dsarRequest(t, s, "POST", "", `{"userId":"`+subject.Id+`","type":"deletion"}`, &created); status != 201 {
+		t.Fatalf("expected the request created, got %d", status)
+	}
+	var started database.DataSubjectRequestResponse
+	if status := dsarRequest(t, s, "POST", "/"+created.ID+"/fulfill", `{"immediate":true}`, &started); status != 202 {
+		t.Fatalf("expected the request fulfilled, got %d", status)
+	}
+	if started.Deletion == nil || started.Deletion.Status != "pending" {
+		t.Fatalf("expected the account scheduled for deletion, got %+v", started)
+	}
+
+	s.purgeDeletedAccounts(ctx)
+
+	if _, err := db.GetUserByID(ctx, subject.Id); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("expected the subject erased, got %v", err)
+	}
+	if _, err := db.GetWorkoutByID(ctx, workout.Id); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("expected the subject's workouts erased, got %v", err)
+	}
+	var got database.DataSubjectRequestResponse
+	if status := dsarRequest(t, s, "GET", "/"+created.ID, "", &got); status != 200 {
+		t.Fatalf("expected the request fetched, got %d", status)
+	}
+	if got.Status != "completed" || got.Deletion == nil || got.Deletion.Status != "completed" {
+		t.Errorf("expected the request completed by the purge, got %+v", got)
+	}
+}
+
+func TestDSARReject(t *testing.T) {
+	s, _, subject := newDSARServer(t)
+
+	var created database.DataSubjectRequestResponse
+	dsarRequest(t, s, "POST", "", `{"userId":"`+subject.Id+`","type":"deletion"}`, &created)
+	if status := dsarRequest(t, s, "POST", "/"+created.ID+"/reject", `{"reason":" "}`, nil); status != 400 {
+		t.Errorf("expected a reason required, got %d", status)
+	}
+	var rejected database.DataSubjectRequestResponse
+	if status := dsarRequest(t, s, "POST", "/"+created.ID+"/reject", `{"reason":"Identity not verified"}`, &rejected); status != 200 {
+		t.Fatalf("expected the request rejected, got %d", status)
+	}
+	if rejected.Status != "rejected" {
+		t.Errorf("expected status rejected, got %q", rejected.Status)
+	}
+	if status := dsarRequest(t, s, "POST", "/"+created.ID+"/fulfill", `{"immediate":true}`, nil); status != 409 {
+		t.Errorf("expected a rejected request not fulfilled, got %d", status)
+	}
+	if _, err := s.db.GetUserByID(context.Background(), subject.Id); err != nil {
+		t.Errorf("expected the subject kept, got %v", err)
+	}
+}
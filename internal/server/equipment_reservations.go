@@ -0,0 +1,317 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"fitness-hack/internal/database"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// maxReservationListRange bounds the from/to range of a reservation listing
+const maxReservationListRange = 31 * 24 * time.Hour
+
+// isOrgAdmin reports whether the caller is an owner or admin of the :orgId organization,
+// as found by requireOrgMember
+func isOrgAdmin(c *fiber.Ctx) bool {
+	role := c.Locals("org_role")
+	return role == orgRoleOwner || role == orgRoleAdmin
+}
+
+// Helper to convert database gym equipment to response model
+func gymEquipmentToResponse(equipment *database.Gym_equipment) database.GymEquipmentResponse {
+	return database.GymEquipmentResponse{
+		ID:        equipment.Id,
+		Name:      equipment.Name,
+		CreatedAt: equipment.Created_at,
+	}
+}
+
+// reservationToResponse converts a reservation for viewerID, leaving out who booked it
+// unless the viewer did or is an admin
+func reservationToResponse(reservation *database.Equipment_reservations, viewerID string, admin bool) database.EquipmentReservationResponse {
+	response := database.EquipmentReservationResponse{
+		ID:          reservation.Id,
+		EquipmentID: reservation.Equipment_id,
+		StartsAt:    reservation.Starts_at,
+		EndsAt:      reservation.Ends_at,
+		Status:      string(reservation.Status),
+		CancelledAt: reservation.Cancelled_at,
+		CreatedAt:   reservation.Created_at,
+	}
+	if admin || reservation.User_id == viewerID {
+		response.UserID = reservation.User_id
+	}
+	return response
+}
+
+// validateReservationSlot checks a requested slot against the booking rules: it starts in
+// the future, within the booking window, and is no longer than the longest allowed slot
+func validateReservationSlot(startsAt, endsAt, now time.Time) error {
+	if startsAt.IsZero() || endsAt.IsZero() {
+		return errors.New("startsAt and endsAt are required")
+	}
+	if !endsAt.After(startsAt) {
+		return errors.New("endsAt must be after startsAt")
+	}
+	if !startsAt.After(now) {
+		return errors.New("startsAt must be in the future")
+	}
+	if window := getEnvDuration("RESERVATION_BOOKING_WINDOW", 14*24*time.Hour); startsAt.After(now.Add(window)) {
+		return errors.New("startsAt is too far ahead; reservations open " + window.String() + " in advance")
+	}
+	if maxDuration := getEnvDuration("RESERVATION_MAX_DURATION", 2*time.Hour); endsAt.Sub(startsAt) > maxDuration {
+		return errors.New("reservations can be at most " + maxDuration.String() + " long")
+	}
+	return nil
+}
+
+// POST /api/v1/organizations/:orgId/equipment
+func (s *FiberServer) createGymEquipment(c *fiber.Ctx) error {
+	var req database.CreateGymEquipmentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return errorResponse(c, fiber.StatusBadRequest, "Name is required")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	equipment, err := s.db.CreateGymEquipment(ctx, c.Params("orgId"), req.Name)
+	if err != nil {
+		if errors.Is(err, database.ErrEquipmentExists) {
+			return errorResponse(c, fiber.StatusConflict, "Equipment with this name already exists")
+		}
+		LogDatabaseError(s, "create_gym_equipment", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to create equipment")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"data": gymEquipmentToResponse(equipment)})
+}
+
+// GET /api/v1/organizations/:orgId/equipment
+func (s *FiberServer) listGymEquipment(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	equipment, err := s.db.ListGymEquipment(ctx, c.Params("orgId"))
+	if err != nil {
+		LogDatabaseError(s, "list_gym_equipment", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to fetch equipment")
+	}
+
+	response := make([]database.GymEquipmentResponse, len(equipment))
+	for i := range equipment {
+		response[i] = gymEquipmentToResponse(&equipment[i])
+	}
+	return successResponse(c, response)
+}
+
+// DELETE /api/v1/organizations/:orgId/equipment/:equipmentId
+// Upcoming reservations of the equipment are cancelled; past ones are kept.
+func (s *FiberServer) archiveGymEquipment(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := s.db.ArchiveGymEquipment(ctx, c.Params("orgId"), c.Params("equipmentId")); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return notFound(c, "Equipment")
+		}
+		LogDatabaseError(s, "archive_gym_equipment", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to remove equipment")
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// POST /api/v1/organizations/:orgId/reservations
+func (s *FiberServer) createEquipmentReservation(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	var req database.CreateEquipmentReservationRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+	if req.EquipmentID == "" {
+		return errorResponse(c, fiber.StatusBadRequest, "equipmentId is required")
+	}
+	now := time.Now()
+	if err := validateReservationSlot(req.StartsAt, req.EndsAt, now); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+	orgID := c.Params("orgId")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Members who keep missing their slots lose booking until older no-shows age out
+	if limit := getEnvInt("RESERVATION_NO_SHOW_LIMIT", 3); limit > 0 {
+		since := now.Add(-getEnvDuration("RESERVATION_NO_SHOW_WINDOW", 30*24*time.Hour))
+		noShows, err := s.db.CountEquipmentNoShows(ctx, orgID, userID, since)
+		if err != nil {
+			LogDatabaseError(s, "count_equipment_no_shows", err, c)
+			return errorResponse(c, fiber.StatusInternalServerError, "Failed to create reservation")
+		}
+		if noShows >= limit {
+			return errorResponse(c, fiber.StatusForbidden, "Booking is suspended after repeated no-shows")
+		}
+	}
+
+	reservation, err := s.db.CreateEquipmentReservation(ctx, orgID, &database.Equipment_reservations{
+		Equipment_id: req.EquipmentID,
+		User_id:      userID,
+		Starts_at:    req.StartsAt,
+		Ends_at:      req.EndsAt,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return notFound(c, "Equipment")
+		case errors.Is(err, database.ErrReservationConflict):
+			return errorResponse(c, fiber.StatusConflict, "Equipment is already booked for part of this time")
+		}
+		LogDatabaseError(s, "create_equipment_reservation", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to create reservation")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"data": reservationToResponse(reservation, userID, true)})
+}
+
+// GET /api/v1/organizations/:orgId/reservations
+// With mine=true, lists the caller's reservations of any status. Otherwise admins see all
+// reservations and members see the booked slots, so they can find free ones.
+func (s *FiberServer) listEquipmentReservations(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+	admin := isOrgAdmin(c)
+
+	filter := database.ReservationFilter{EquipmentID: c.Query("equipmentId"), Status: c.Query("status")}
+	if filter.Status != "" && filter.Status != database.ReservationBooked &&
+		filter.Status != database.ReservationCancelled && filter.Status != database.ReservationNoShow {
+		return errorResponse(c, fiber.StatusBadRequest, "status must be booked, cancelled or no_show")
+	}
+	switch {
+	case c.QueryBool("mine"):
+		filter.UserID = userID
+	case !admin:
+		filter.Status = database.ReservationBooked
+	}
+
+	filter.From = time.Now()
+	if from := c.Query("from"); from != "" {
+		if filter.From, err = time.Parse(time.RFC3339, from); err != nil {
+			return errorResponse(c, fiber.StatusBadRequest, "from must be an RFC 3339 timestamp")
+		}
+	}
+	filter.To = filter.From.Add(7 * 24 * time.Hour)
+	if to := c.Query("to"); to != "" {
+		if filter.To, err = time.Parse(time.RFC3339, to); err != nil {
+			return errorResponse(c, fiber.StatusBadRequest, "to must be an RFC 3339 timestamp")
+		}
+	}
+	if !filter.To.After(filter.From) || filter.To.Sub(filter.From) > maxReservationListRange {
+		return errorResponse(c, fiber.StatusBadRequest, "to must be after from and at most 31 days later")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	reservations, err := s.db.ListEquipmentReservations(ctx, c.Params("orgId"), filter)
+	if err != nil {
+		LogDatabaseError(s, "list_equipment_reservations", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to fetch reservations")
+	}
+
+	response := make([]database.EquipmentReservationResponse, len(reservations))
+	for i := range reservations {
+		response[i] = reservationToResponse(&reservations[i], userID, admin)
+	}
+	return successResponse(c, response)
+}
+
+// DELETE /api/v1/organizations/:orgId/reservations/:reservationId
+// Members cancel their own reservations until the slot starts; admins cancel any booked one.
+func (s *FiberServer) cancelEquipmentReservation(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+	orgID, admin := c.Params("orgId"), isOrgAdmin(c)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	reservation, err := s.db.GetEquipmentReservation(ctx, orgID, c.Params("reservationId"))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return notFound(c, "Reservation")
+		}
+		LogDatabaseError(s, "get_equipment_reservation", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to cancel reservation")
+	}
+	if !admin {
+		if reservation.User_id != userID {
+			return notFound(c, "Reservation")
+		}
+		if !reservation.Starts_at.After(time.Now()) {
+			return errorResponse(c, fiber.StatusConflict, "Reservation has already started")
+		}
+	}
+
+	if _, err := s.db.CancelEquipmentReservation(ctx, orgID, reservation.Id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errorResponse(c, fiber.StatusConflict, "Reservation is not booked")
+		}
+		LogDatabaseError(s, "cancel_equipment_reservation", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to cancel reservation")
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// POST /api/v1/organizations/:orgId/reservations/:reservationId/no-show
+func (s *FiberServer) markEquipmentReservationNoShow(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+	orgID := c.Params("orgId")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	reservation, err := s.db.GetEquipmentReservation(ctx, orgID, c.Params("reservationId"))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return notFound(c, "Reservation")
+		}
+		LogDatabaseError(s, "get_equipment_reservation", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to record no-show")
+	}
+	if reservation.Starts_at.After(time.Now()) {
+		return errorResponse(c, fiber.StatusConflict, "Reservation hasn't started yet")
+	}
+
+	reservation, err = s.db.MarkEquipmentReservationNoShow(ctx, orgID, reservation.Id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errorResponse(c, fiber.StatusConflict, "Reservation is not booked")
+		}
+		LogDatabaseError(s, "mark_equipment_reservation_no_show", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to record no-show")
+	}
+
+	return successResponse(c, reservationToResponse(reservation, userID, true))
+}
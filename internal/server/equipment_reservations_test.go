@@ -0,0 +1,32 @@
+package server
+
+import (
+	"testing"
+	"time"
+)
+
+func TestValidateReservationSlot(t *testing.T) {
+	t.Setenv("RESERVATION_BOOKING_WINDOW", "72h")
+	t.Setenv("RESERVATION_MAX_DURATION", "90m")
+	now := time.Date(2025, 3, 3, 12, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name     string
+		startsAt time.Time
+		endsAt   time.Time
+		ok       bool
+	}{
+		{"valid", now.Add(time.Hour), now.Add(2 * time.Hour), true},
+		{"longest allowed", now.Add(time.Hour), now.Add(150 * time.Minute), true},
+		{"missing end", now.Add(time.Hour), time.Time{}, false},
+		{"ends before start", now.Add(2 * time.Hour), now.Add(time.Hour), false},
+		{"in the past", now.Add(-time.Hour), now.Add(time.Hour), false},
+		{"beyond booking window", now.Add(73 * time.Hour), now.Add(74 * time.Hour), false},
+		{"too long", now.Add(time.Hour), now.Add(3 * time.Hour), false},
+	}
+	for _, tc := range cases {
+		if err := validateReservationSlot(tc.startsAt, tc.endsAt, now); (err == nil) != tc.ok {
+			t.Errorf("%s: expected ok=%v, got %v", tc.name, tc.ok, err)
+		}
+	}
+}
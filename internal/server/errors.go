@@ -0,0 +1,202 @@
+package server
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"fitness-hack/internal/database"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Error codes tell clients what went wrong without parsing the message, which may change.
+// They are part of the API, so don't rename them.
+const (
+	codeValidationFailed      = "VALIDATION_FAILED"
+	codeUnauthorized          = "UNAUTHORIZED"
+	codePaymentRequired       = "PAYMENT_REQUIRED"
+	codeForbidden             = "FORBIDDEN"
+	codeNotFound              = "NOT_FOUND"
+	codeConflict              = "CONFLICT"
+	codeAlreadyExists         = "ALREADY_EXISTS"
+	codeVersionConflict       = "VERSION_CONFLICT"
+	codeGone                  = "GONE"
+	codePreconditionFailed    = "PRECONDITION_FAILED"
+	codePayloadTooLarge       = "PAYLOAD_TOO_LARGE"
+	codeUnsupportedMediaType  = "UNSUPPORTED_MEDIA_TYPE"
+	codeUnprocessable         = "UNPROCESSABLE"
+	codeLocked                = "LOCKED"
+	codeRateLimited           = "RATE_LIMITED"
+	codeQuotaExceeded         = "QUOTA_EXCEEDED"
+	codePasswordResetRequired = "PASSWORD_RESET_REQUIRED"
+	codeAccountDisabled       = "ACCOUNT_DISABLED"
+	codeInternal              = "INTERNAL_ERROR"
+	codeUpstreamFailed        = "UPSTREAM_FAILED"
+	codeUnavailable           = "SERVICE_UNAVAILABLE"
+)
+
+// statusCodes are the codes of errors that don't name a more specific one
+var statusCodes = map[int]string{
+	fiber.StatusBadRequest:            codeValidationFailed,
+	fiber.StatusUnauthorized:          codeUnauthorized,
+	fiber.StatusPaymentRequired:       codePaymentRequired,
+	fiber.StatusForbidden:             codeForbidden,
+	fiber.StatusNotFound:              codeNotFound,
+	fiber.StatusConflict:              codeConflict,
+	fiber.StatusGone:                  codeGone,
+	fiber.StatusPreconditionFailed:    codePreconditionFailed,
+	fiber.StatusRequestEntityTooLarge: codePayloadTooLarge,
+	fiber.StatusUnsupportedMediaType:  codeUnsupportedMediaType,
+	fiber.StatusUnprocessableEntity:   codeUnprocessable,
+	fiber.StatusLocked:                codeLocked,
+	fiber.StatusTooManyRequests:       codeRateLimited,
+	fiber.StatusInternalServerError:   codeInternal,
+	fiber.StatusBadGateway:            codeUpstreamFailed,
+	fiber.StatusServiceUnavailable:    codeUnavailable,
+	fiber.StatusGatewayTimeout:        codeUpstreamFailed,
+}
+
+// codeForStatus returns the error code of status, falling back to the code of its class
+func codeForStatus(status int) string {
+	if code, ok := statusCodes[status]; ok {
+		return code
+	}
+	if status >= 500 {
+		return codeInternal
+	}
+	return codeValidationFailed
+}
+
+// APIError is an error a handler returns for the ErrorHandler to respond with. Message is
+// shown to the user; Err, the cause, is only logged.
+type APIError struct {
+	Status  int
+	Code    string
+	Message string
+	Err     error
+}
+
+func (e *APIError) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
+
+// serverError returns an error that responds 500 with message, unless err is a database
+// error the ErrorHandler maps to a client error, such as a missing row or a duplicate
+func serverError(message string, err error) *APIError {
+	return &APIError{Status: fiber.StatusInternalServerError, Code: codeInternal, Message: message, Err: err}
+}
+
+// toAPIError decides the response to an error a handler returned. Errors are never sent
+// as they are: a cause the mapping doesn't recognize responds with a generic message.
+func toAPIError(err error) *APIError {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.Status < fiber.StatusInternalServerError {
+		return apiErr
+	}
+
+	var constraintErr *database.ConstraintError
+	if errors.As(database.TranslateError(err), &constraintErr) {
+		return constraintAPIError(constraintErr, err)
+	}
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return &APIError{Status: fiber.StatusNotFound, Code: codeNotFound, Message: "Not found", Err: err}
+	case errors.Is(err, database.ErrVersionConflict):
+		return &APIError{Status: fiber.StatusConflict, Code: codeVersionConflict, Message: versionConflictMessage, Err: err}
+	case database.IsInvalidInput(err):
+		return &APIError{Status: fiber.StatusBadRequest, Code: codeValidationFailed, Message: "A value in the request is invalid", Err: err}
+	}
+
+	if apiErr != nil {
+		return apiErr
+	}
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		// Fiber's messages, such as "Cannot GET /x", are safe to show
+		return &APIError{Status: fiberErr.Code, Code: codeForStatus(fiberErr.Code), Message: fiberErr.Message, Err: err}
+	}
+	return serverError("Internal server error", err)
+}
+
+// duplicateMessages are the messages of unique constraints users commonly run into
+var duplicateMessages = map[string]string{
+	"users_email_key":    "An account with this email already exists",
+	"users_username_key": "This username is taken",
+}
+
+// constraintAPIError describes a constraint violation in terms of the request: duplicates
+// conflict with an existing resource, while missing references and rejected values are
+// invalid input. Only the column is shown, never the values or the driver's message.
+func constraintAPIError(constraintErr *database.ConstraintError, err error) *APIError {
+	field := strings.ReplaceAll(constraintErr.Column, "_", " ")
+	switch {
+	case errors.Is(constraintErr, database.ErrDuplicate):
+		message := duplicateMessages[constraintErr.Constraint]
+		if message == "" && field != "" {
+			message = fmt.Sprintf("Another resource already has this %s", field)
+		}
+		if message == "" {
+			message = "A resource with these values already exists"
+		}
+		return &APIError{Status: fiber.StatusConflict, Code: codeAlreadyExists, Message: message, Err: err}
+	case errors.Is(constraintErr, database.ErrStillReferenced):
+		return &APIError{Status: fiber.StatusConflict, Code: codeConflict, Message: "The resource is still in use", Err: err}
+	case errors.Is(constraintErr, database.ErrReferenceNotFound):
+		message := "The request refers to a missing resource"
+		if resource, ok := strings.CutSuffix(field, " id"); ok {
+			message = fmt.Sprintf("No %s with this ID exists", resource)
+		}
+		return &APIError{Status: fiber.StatusBadRequest, Code: codeValidationFailed, Message: message, Err: err}
+	}
+	message := "A value in the request is invalid"
+	if field != "" {
+		message = fmt.Sprintf("Invalid value for %s", field)
+	}
+	return &APIError{Status: fiber.StatusBadRequest, Code: codeValidationFailed, Message: message, Err: err}
+}
+
+// handleError is the Fiber ErrorHandler: it responds to an error a handler returned
+func handleError(c *fiber.Ctx, err error) error {
+	apiErr := toAPIError(err)
+	return codedErrorResponse(c, apiErr.Status, apiErr.Code, apiErr.Message)
+}
+
+// Helper function to create error response, with the code of its status
+func errorResponse(c *fiber.Ctx, status int, message string) error {
+	return codedErrorResponse(c, status, codeForStatus(status), message)
+}
+
+// codedErrorResponse writes an error response with a specific code. It carries the request
+// ID so a user reporting the error can be matched to the server's logs.
+func codedErrorResponse(c *fiber.Ctx, status int, code, message string) error {
+	body := fiber.Map{
+		"error": message,
+		"code":  code,
+	}
+	if id := getRequestID(c); id != "" {
+		body["requestId"] = id
+	}
+	return c.Status(status).JSON(body)
+}
+
+// notFound responds 404 that resource, such as "Workout session", was not found, with the
+// code WORKOUT_SESSION_NOT_FOUND
+func notFound(c *fiber.Ctx, resource string) error {
+	apiErr := resourceNotFound(resource)
+	return codedErrorResponse(c, apiErr.Status, apiErr.Code, apiErr.Message)
+}
+
+// resourceNotFound returns the error notFound responds with
+func resourceNotFound(resource string) *APIError {
+	code := strings.ToUpper(strings.ReplaceAll(resource, " ", "_")) + "_NOT_FOUND"
+	return &APIError{Status: fiber.StatusNotFound, Code: code, Message: resource + " not found"}
+}
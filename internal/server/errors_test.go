@@ -0,0 +1,128 @@
+package server
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"fitness-hack/internal/database"
+	"fitness-hack/internal/requestid"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestToAPIError(t *testing.T) {
+	driverErr := &pgconn.PgError{Code: "23505", Message: `duplicate key value violates unique constraint "users_email_key"`}
+	for name, tc := range map[string]struct {
+		err    error
+		status int
+		code   string
+	}{
+		"no rows":            {fmt.Errorf("get workout: %w", sql.ErrNoRows), fiber.StatusNotFound, codeNotFound},
+		"version conflict":   {database.ErrVersionConflict, fiber.StatusConflict, codeVersionConflict},
+		"unique violation":   {serverError("Failed to create user", driverErr), fiber.StatusConflict, codeAlreadyExists},
+		"missing reference":  {&pgconn.PgError{Code: "23503", Detail: `Key (exercise_id)=(x) is not present in table "exercises".`}, fiber.StatusBadRequest, codeValidationFailed},
+		"still referenced":   {&pgconn.PgError{Code: "23503", Detail: `Key (id)=(x) is still referenced from table "workouts".`}, fiber.StatusConflict, codeConflict},
+		"check violation":    {&pgconn.PgError{Code: "23514", ConstraintName: "workouts_duration_check"}, fiber.StatusBadRequest, codeValidationFailed},
+		"invalid uuid":       {&pgconn.PgError{Code: "22P02"}, fiber.StatusBadRequest, codeValidationFailed},
+		"client error":       {&APIError{Status: fiber.StatusGone, Code: codeGone, Message: "Expired"}, fiber.StatusGone, codeGone},
+		"fiber error":        {fiber.ErrMethodNotAllowed, fiber.StatusMethodNotAllowed, codeValidationFailed},
+		"server error":       {serverError("Failed to save", errors.New("boom")), fiber.StatusInternalServerError, codeInternal},
+		"unrecognized error": {errors.New("dial tcp 10.0.0.5:5432: connection refused"), fiber.StatusInternalServerError, codeInternal},
+	} {
+		got := toAPIError(tc.err)
+		if got.Status != tc.status || got.Code != tc.code {
+			t.Errorf("%s: expected %d %s, got %d %s", name, tc.status, tc.code, got.Status, got.Code)
+		}
+		if strings.Contains(got.Message, "10.0.0.5") || strings.Contains(got.Message, "users_email_key") {
+			t.Errorf("%s: expected the cause kept out of the message, got %q", name, got.Message)
+		}
+	}
+
+	for _, tc := range []struct {
+		err     *pgconn.PgError
+		message string
+	}{
+		{&pgconn.PgError{Code: "23505", ConstraintName: "users_email_key", Detail: "Key (email)=(ann@example.com) already exists."}, "An account with this email already exists"},
+		{&pgconn.PgError{Code: "23505", ConstraintName: "foods_barcode_key", Detail: "Key (barcode)=(123) already exists."}, "Another resource already has this barcode"},
+		{&pgconn.PgError{Code: "23503", Detail: `Key (exercise_id)=(x) is not present in table "exercises".`}, "No exercise with this ID exists"},
+		{&pgconn.PgError{Code: "23502", ColumnName: "name"}, "Invalid value for name"},
+	} {
+		if got := toAPIError(fmt.Errorf("insert: %w", tc.err)); got.Message != tc.message {
+			t.Errorf("expected %q for %s, got %q", tc.message, tc.err.Detail, got.Message)
+		}
+	}
+}
+
+func TestErrorEnvelope(t *testing.T) {
+	s, _ := newFakeServer(t)
+	s.App.Get("/test/duplicate", func(c *fiber.Ctx) error {
+		return serverError("Failed to create user", &pgconn.PgError{Code: "23505", Message: "duplicate key value"})
+	})
+	s.App.Get("/test/missing", func(c *fiber.Ctx) error {
+		return notFound(c, "Workout session")
+	})
+
+	for path, want := range map[string]struct {
+		status int
+		code   string
+	}{
+		"/test/duplicate": {fiber.StatusConflict, codeAlreadyExists},
+		"/test/missing":   {fiber.StatusNotFound, "WORKOUT_SESSION_NOT_FOUND"},
+		"/no/such/route":  {fiber.StatusNotFound, codeNotFound},
+	} {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set(requestid.Header, "req-42")
+		resp, err := s.App.Test(req, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var body struct {
+			Error     string `json:"error"`
+			Code      string `json:"code"`
+			RequestID string `json:"requestId"`
+		}
+		json.NewDecoder(resp.Body).Decode(&body)
+		if resp.StatusCode != want.status || body.Code != want.code {
+			t.Errorf("%s: expected %d %s, got %d %+v", path, want.status, want.code, resp.StatusCode, body)
+		}
+		if body.Error == "" || body.RequestID != "req-42" || strings.Contains(body.Error, "duplicate key") {
+			t.Errorf("%s: unexpected envelope %+v", path, body)
+		}
+	}
+}
+
+func TestSignupDuplicates(t *testing.T) {
+	s, _ := newFakeServer(t)
+	signup := func(email, username string) (int, string, string) {
+		t.Helper()
+		body := fmt.Sprintf(`{"email":%q,"username":%q,"password":"a long enough password"}`, email, username)
+		req := httptest.NewRequest("POST", "/api/v1/users", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := s.App.Test(req, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var envelope struct {
+			Error string `json:"error"`
+			Code  string `json:"code"`
+		}
+		json.NewDecoder(resp.Body).Decode(&envelope)
+		return resp.StatusCode, envelope.Code, envelope.Error
+	}
+
+	if status, _, message := signup("ann@example.com", "ann"); status != fiber.StatusCreated {
+		t.Fatalf("expected the first signup to succeed, got %d %s", status, message)
+	}
+	if status, code, message := signup("ann@example.com", "ann2"); status != fiber.StatusConflict || code != codeAlreadyExists || message != "An account with this email already exists" {
+		t.Errorf("expected a taken email to conflict, got %d %s %q", status, code, message)
+	}
+	if status, code, message := signup("ann2@example.com", "ann"); status != fiber.StatusConflict || message != "This username is taken" {
+		t.Errorf("expected a taken username to conflict, got %d %s %q", status, code, message)
+	}
+}
@@ -0,0 +1,86 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// resourceETag returns the strong entity tag of one version of a resource. Every write
+// bumps updated_at, so the pair changes whenever the representation does.
+func resourceETag(id string, updatedAt time.Time) string {
+	sum := sha256.Sum256([]byte(id + "|" + updatedAt.UTC().Format(time.RFC3339Nano)))
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// listETag returns the entity tag of a list response from the tags of its items in order,
+// so it changes when an item is added, removed, reordered or updated
+func listETag(itemTags []string) string {
+	sum := sha256.Sum256([]byte(strings.Join(itemTags, ",")))
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// notModified sets the response's ETag and reports whether the client's If-None-Match
+// already names it, in which case the handler should answer 304 Not Modified
+func notModified(c *fiber.Ctx, etag string) bool {
+	c.Set(fiber.HeaderETag, etag)
+	return etagListMatches(c.Get(fiber.HeaderIfNoneMatch), etag, false)
+}
+
+// respondWithETag sends data with its ETag, or 304 Not Modified when the client already
+// has this version
+func respondWithETag(c *fiber.Ctx, etag string, data interface{}) error {
+	if notModified(c, etag) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+	return successResponse(c, data)
+}
+
+// ifMatchFails reports whether the request carries an If-Match precondition that the
+// current version of the resource doesn't satisfy. A request without If-Match always
+// passes so clients that don't send one keep last-write-wins behavior.
+func ifMatchFails(c *fiber.Ctx, etag string) bool {
+	header := c.Get(fiber.HeaderIfMatch)
+	return header != "" && !etagListMatches(header, etag, true)
+}
+
+// preconditionFailed responds when an If-Match precondition fails, sending the current
+// ETag so the client can refetch and retry
+func preconditionFailed(c *fiber.Ctx, etag string) error {
+	c.Set(fiber.HeaderETag, etag)
+	return errorResponse(c, fiber.StatusPreconditionFailed, "Resource has changed since it was fetched")
+}
+
+// versionConflictMessage tells the client how to recover from a version conflict
+const versionConflictMessage = "Resource was changed by another request; fetch it again and retry"
+
+// versionConflict responds when an update was based on a stale version, either the one the
+// client sent or the one the handler read before another request saved a newer one
+func versionConflict(c *fiber.Ctx) error {
+	return codedErrorResponse(c, fiber.StatusConflict, codeVersionConflict, versionConflictMessage)
+}
+
+// etagListMatches reports whether etag is in the comma-separated list of an If-Match or
+// If-None-Match header. If-Match uses strong comparison (RFC 9110 section 13.1.1), so
+// weak tags never match it; If-None-Match uses weak comparison.
+func etagListMatches(header, etag string, strong bool) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if weak := strings.HasPrefix(candidate, "W/"); weak {
+			if strong {
+				continue
+			}
+			candidate = strings.TrimPrefix(candidate, "W/")
+		}
+		if candidate == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,174 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"fitness-hack/internal/database"
+	"fitness-hack/internal/database/dbtest"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+func TestResourceETag(t *testing.T) {
+	updated := time.Date(2025, 3, 1, 9, 0, 0, 123000, time.UTC)
+	etag := resourceETag("id-1", updated)
+	if etag != resourceETag("id-1", updated.In(time.FixedZone("CET", 3600))) {
+		t.Error("ETag depends on the timestamp's location")
+	}
+	if etag == resourceETag("id-1", updated.Add(time.Microsecond)) || etag == resourceETag("id-2", updated) {
+		t.Error("ETag didn't change with the version")
+	}
+	if listETag([]string{etag, `"b"`}) == listETag([]string{`"b"`, etag}) {
+		t.Error("list ETag ignores order")
+	}
+}
+
+func TestETagPreconditions(t *testing.T) {
+	const etag = `"abc"`
+	app := fiber.New()
+	app.Get("/", func(c *fiber.Ctx) error { return respondWithETag(c, etag, "ok") })
+	app.Put("/", func(c *fiber.Ctx) error {
+		if ifMatchFails(c, etag) {
+			return preconditionFailed(c, etag)
+		}
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+
+	tests := []struct {
+		method, header, value string
+		want                  int
+	}{
+		{"GET", "", "", fiber.StatusOK},
+		{"GET", "If-None-Match", `"abc"`, fiber.StatusNotModified},
+		{"GET", "If-None-Match", `"x", W/"abc"`, fiber.StatusNotModified},
+		{"GET", "If-None-Match", `*`, fiber.StatusNotModified},
+		{"GET", "If-None-Match", `"x"`, fiber.StatusOK},
+		{"PUT", "", "", fiber.StatusNoContent},
+		{"PUT", "If-Match", `"abc"`, fiber.StatusNoContent},
+		{"PUT", "If-Match", `*`, fiber.StatusNoContent},
+		{"PUT", "If-Match", `W/"abc"`, fiber.StatusPreconditionFailed},
+		{"PUT", "If-Match", `"x"`, fiber.StatusPreconditionFailed},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, "/", nil)
+		if tt.header != "" {
+			req.Header.Set(tt.header, tt.value)
+		}
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != tt.want {
+			t.Errorf("%s with %s: %s: status = %d, want %d", tt.method, tt.header, tt.value, resp.StatusCode, tt.want)
+		}
+		if got := resp.Header.Get("ETag"); got != etag && resp.StatusCode != fiber.StatusNoContent {
+			t.Errorf("%s with %s: %s: ETag = %q, want %q", tt.method, tt.header, tt.value, got, etag)
+		}
+	}
+}
+
+// putVersioned sends a PUT as u1 and returns the status, the error code and the version saved.
+// Programs respond without the data envelope, so the version is read from either.
+func putVersioned(t *testing.T, s *FiberServer, path, body string) (int, string, int) {
+	t.Helper()
+	req := httptest.NewRequest("PUT", path, strings.NewReader(body))
+	req.Header.Set("Authorization", bearer(t, "u1"))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.App.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var envelope struct {
+		Data struct {
+			Version int `json:"version"`
+		} `json:"data"`
+		Version int    `json:"version"`
+		Code    string `json:"code"`
+	}
+	json.NewDecoder(resp.Body).Decode(&envelope)
+	return resp.StatusCode, envelope.Code, envelope.Data.Version + envelope.Version
+}
+
+func TestVersionConflicts(t *testing.T) {
+	s, db := newFakeServer(t)
+	ctx := context.Background()
+	user, err := db.CreateUser(ctx, &database.Users{Email: "u1@example.com", Username: "u1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	workout, _ := db.CreateWorkout(ctx, &database.Workouts{User_id: "u1", Name: "Leg day"})
+	exercise, _ := db.CreateExercise(ctx, &database.Exercises{Name: "Squat"})
+	workoutExercise, _ := db.CreateWorkoutExercise(ctx, &database.Workout_exercises{Workout_id: workout.Id, Exercise_id: exercise.Id, Sets: 3})
+	session, _ := db.CreateWorkoutSession(ctx, &database.Workout_sessions{User_id: "u1", Name: "Monday"})
+	program, _ := db.CreateProgram(ctx, &database.Programs{User_id: "u1", Name: "5/3/1"})
+
+	for _, tt := range []struct {
+		name, path, body string
+	}{
+		{"users", "/api/v1/users/", `{"firstName":"Ada","version":%d}`},
+		{"workouts", "/api/v1/workouts/", `{"name":"Squat day","version":%d}`},
+		{"exercises", "/api/v1/exercises/", `{"name":"Back squat","version":%d}`},
+		{"workout-exercises", "/api/v1/workout-exercises/", `{"sets":5,"version":%d}`},
+		{"workout-sessions", "/api/v1/workout-sessions/", `{"name":"Tuesday","version":%d}`},
+		{"programs", "/api/v1/programs/", `{"name":"5x5","version":%d}`},
+	} {
+		id := map[string]string{
+			"users": user.Id, "workouts": workout.Id, "exercises": exercise.Id,
+			"workout-exercises": workoutExercise.Id, "workout-sessions": session.Id, "programs": program.Id,
+		}[tt.name]
+
+		if status, _, version := putVersioned(t, s, tt.path+id, fmt.Sprintf(tt.body, 1)); status != fiber.StatusOK || version != 2 {
+			t.Errorf("%s: PUT at the current version = %d at version %d, want 200 at version 2", tt.name, status, version)
+			continue
+		}
+		if status, code, _ := putVersioned(t, s, tt.path+id, fmt.Sprintf(tt.body, 1)); status != fiber.StatusConflict || code != codeVersionConflict {
+			t.Errorf("%s: PUT at a stale version = %d %s, want 409 %s", tt.name, status, code, codeVersionConflict)
+		}
+		if status, code, _ := putVersioned(t, s, tt.path+uuid.NewString(), fmt.Sprintf(tt.body, 1)); status != fiber.StatusNotFound {
+			t.Errorf("%s: PUT of a missing row = %d %s, want 404", tt.name, status, code)
+		}
+	}
+}
+
+// racingDB lands another update on every workout it reads, like a concurrent request
+// saving first
+type racingDB struct {
+	*dbtest.Fake
+}
+
+func (r *racingDB) GetWorkoutByID(ctx context.Context, id string) (*database.Workouts, error) {
+	workout, err := r.Fake.GetWorkoutByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	concurrent := *workout
+	concurrent.Name = "Concurrent edit"
+	if _, err := r.Fake.UpdateWorkout(ctx, &concurrent); err != nil {
+		return nil, err
+	}
+	return workout, nil
+}
+
+func TestVersionConflictWithoutVersion(t *testing.T) {
+	db := &racingDB{Fake: dbtest.NewFake()}
+	s := newTestServer(t, db)
+	workout, err := db.CreateWorkout(context.Background(), &database.Workouts{User_id: "u1", Name: "Leg day"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Without a version the update is based on the version read, which the other update bumped
+	if status, code, _ := putVersioned(t, s, "/api/v1/workouts/"+workout.Id, `{"name":"Squat day"}`); status != fiber.StatusConflict || code != codeVersionConflict {
+		t.Errorf("PUT racing another update = %d %s, want 409 %s", status, code, codeVersionConflict)
+	}
+	if saved, _ := db.Fake.GetWorkoutByID(context.Background(), workout.Id); saved.Name != "Concurrent edit" || saved.Version != 2 {
+		t.Errorf("expected the first update kept, got %q at version %d", saved.Name, saved.Version)
+	}
+}
@@ -0,0 +1,112 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"fitness-hack/internal/database"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// maxCatalogSyncExercises bounds one sync request; larger catalogs are sent in several
+const maxCatalogSyncExercises = 5000
+
+// validateCatalogSync checks a sync batch, returning a message for the first problem found
+func validateCatalogSync(req *database.SyncExercisesRequest) string {
+	if req.Source == "" || len(req.Source) > 100 {
+		return "source is required and must be at most 100 characters"
+	}
+	if len(req.Exercises) == 0 || len(req.Exercises) > maxCatalogSyncExercises {
+		return fmt.Sprintf("exercises must contain between 1 and %d items", maxCatalogSyncExercises)
+	}
+
+	seen := make(map[string]bool, len(req.Exercises))
+	for i, e := range req.Exercises {
+		switch {
+		case e.ExternalID == "" || len(e.ExternalID) > 255:
+			return fmt.Sprintf("exercises[%d].externalId is required and must be at most 255 characters", i)
+		case seen[e.ExternalID]:
+			return fmt.Sprintf("exercises[%d].externalId %q appears more than once", i, e.ExternalID)
+		case e.Name == "" || len(e.Name) > 255:
+			return fmt.Sprintf("exercises[%d].name is required and must be at most 255 characters", i)
+		case len(e.MuscleGroup) > 100 || len(e.Equipment) > 100:
+			return fmt.Sprintf("exercises[%d].muscleGroup and equipment must be at most 100 characters", i)
+		case len(e.DifficultyLevel) > 50:
+			return fmt.Sprintf("exercises[%d].difficultyLevel must be at most 50 characters", i)
+		}
+		if _, msg := muscleAssignments(e.MuscleGroup, e.Muscles); msg != "" {
+			return fmt.Sprintf("exercises[%d].%s", i, msg)
+		}
+		seen[e.ExternalID] = true
+	}
+	return ""
+}
+
+// POST /api/v1/admin/exercises/sync
+// Upserts a batch of exercises from an upstream catalog by source and external ID, so a
+// scheduled sync can resend the whole catalog and only the changes are written. Muscles
+// must already be in the taxonomy; the batch is rejected otherwise.
+func (s *FiberServer) syncExerciseCatalog(c *fiber.Ctx) error {
+	var req database.SyncExercisesRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+	req.Source = strings.TrimSpace(req.Source)
+	for i := range req.Exercises {
+		req.Exercises[i].ExternalID = strings.TrimSpace(req.Exercises[i].ExternalID)
+		req.Exercises[i].Name = strings.TrimSpace(req.Exercises[i].Name)
+	}
+	if msg := validateCatalogSync(&req); msg != "" {
+		return errorResponse(c, fiber.StatusBadRequest, msg)
+	}
+
+	exercises := make([]database.CatalogExercise, len(req.Exercises))
+	for i, e := range req.Exercises {
+		muscles, _ := muscleAssignments(e.MuscleGroup, e.Muscles)
+		exercises[i] = database.CatalogExercise{
+			Exercises: database.Exercises{
+				Source:           req.Source,
+				External_id:      e.ExternalID,
+				Name:             e.Name,
+				Description:      e.Description,
+				Equipment:        &e.Equipment,
+				Difficulty_level: &e.DifficultyLevel,
+				Instructions:     e.Instructions,
+			},
+			Muscles: muscles,
+		}
+	}
+
+	// Large catalogs take longer than the usual request budget
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	result, err := s.db.UpsertExercises(ctx, exercises)
+	if errors.Is(err, database.ErrUnknownMuscle) {
+		return errorResponse(c, fiber.StatusBadRequest, err.Error()+"; see /api/v1/muscle-groups")
+	}
+	if err != nil {
+		LogDatabaseError(s, "upsert_exercises", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to sync exercises")
+	}
+
+	// Invalidate cache
+	if len(result.ChangedIDs) > 0 {
+		keys := make([]string, 0, len(result.ChangedIDs)+1)
+		for _, id := range result.ChangedIDs {
+			keys = append(keys, exerciseCacheKey(id))
+		}
+		keys = append(keys, "exercises:list:*")
+		s.cache.Del(ctx, keys...)
+	}
+
+	return successResponse(c, database.SyncExercisesResponse{
+		Inserted:  result.Inserted,
+		Updated:   result.Updated,
+		Unchanged: result.Unchanged,
+	})
+}
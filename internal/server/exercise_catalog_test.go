@@ -0,0 +1,38 @@
+package server
+
+import (
+	"strings"
+	"testing"
+
+	"fitness-hack/internal/database"
+)
+
+func TestValidateCatalogSync(t *testing.T) {
+	valid := database.SyncExercisesRequest{
+		Source: "wger",
+		Exercises: []database.CatalogExerciseRequest{
+			{ExternalID: "1", Name: "Bench Press"},
+			{ExternalID: "2", Name: "Squat"},
+		},
+	}
+	if msg := validateCatalogSync(&valid); msg != "" {
+		t.Fatalf("expected a valid batch, got %q", msg)
+	}
+
+	cases := map[string]database.SyncExercisesRequest{
+		"missing source": {Exercises: valid.Exercises},
+		"empty batch":    {Source: "wger"},
+		"duplicate key": {Source: "wger", Exercises: []database.CatalogExerciseRequest{
+			{ExternalID: "1", Name: "Bench Press"}, {ExternalID: "1", Name: "Incline Bench Press"},
+		}},
+		"missing name":      {Source: "wger", Exercises: []database.CatalogExerciseRequest{{ExternalID: "1"}}},
+		"long muscle group": {Source: "wger", Exercises: []database.CatalogExerciseRequest{{ExternalID: "1", Name: "Row", MuscleGroup: strings.Repeat("x", 101)}}},
+		"bad muscle role": {Source: "wger", Exercises: []database.CatalogExerciseRequest{{ExternalID: "1", Name: "Row",
+			Muscles: []database.MuscleRequest{{Muscle: "lats", Role: "tertiary"}}}}},
+	}
+	for name, req := range cases {
+		if msg := validateCatalogSync(&req); msg == "" {
+			t.Errorf("%s: expected the batch to be rejected", name)
+		}
+	}
+}
@@ -0,0 +1,424 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"fitness-hack/internal/database"
+	"fitness-hack/internal/messages"
+	"fitness-hack/internal/storage"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+const (
+	// exerciseMediaUploadTTL is how long an upload URL for exercise media stays valid
+	exerciseMediaUploadTTL = 15 * time.Minute
+
+	// exerciseMediaLinkTTL is how long the storage links exercise media redirects to stay valid
+	exerciseMediaLinkTTL = time.Hour
+
+	// maxExerciseMediaURL bounds linked media URLs
+	maxExerciseMediaURL = 2048
+
+	// maxExerciseMediaCaption bounds media captions
+	maxExerciseMediaCaption = 500
+)
+
+// exerciseMediaContentTypes are the formats accepted for uploaded exercise media, by kind
+var exerciseMediaContentTypes = map[database.Exercise_media_kind]map[string]string{
+	database.Exercise_media_kind_image: {"image/jpeg": "jpg", "image/png": "png", "image/webp": "webp", "image/gif": "gif"},
+	database.Exercise_media_kind_video: {"video/mp4": "mp4", "video/webm": "webm", "video/quicktime": "mov"},
+}
+
+// exerciseMediaPath is the API route of an exercise's media
+func exerciseMediaPath(exerciseID, mediaID string) string {
+	return "/api/v1/exercises/" + exerciseID + "/media/" + mediaID
+}
+
+// Helper to convert database exercise media to response model
+func exerciseMediaToResponse(media *database.Exercise_media) database.ExerciseMediaResponse {
+	response := database.ExerciseMediaResponse{
+		ID:          media.Id,
+		Kind:        string(media.Kind),
+		URL:         media.Url,
+		ContentType: media.Content_type,
+		Caption:     media.Caption,
+		Position:    media.Position,
+		CreatedAt:   media.Created_at,
+	}
+	if media.Storage_key != "" {
+		response.URL = exerciseMediaPath(media.Exercise_id, media.Id) + "/file"
+	}
+	return response
+}
+
+// validateExerciseMediaLink checks a linked media request, returning a message for the
+// first problem found
+func validateExerciseMediaLink(req *database.CreateExerciseMediaRequest) string {
+	if _, err := database.ParseExercise_media_kind(req.Kind); err != nil {
+		return "kind must be image or video"
+	}
+	if req.URL == "" || len(req.URL) > maxExerciseMediaURL {
+		return fmt.Sprintf("url is required and must be at most %d characters", maxExerciseMediaURL)
+	}
+	u, err := url.Parse(req.URL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return "url must be an http or https URL"
+	}
+	if len(req.Caption) > maxExerciseMediaCaption {
+		return fmt.Sprintf("caption must be at most %d characters", maxExerciseMediaCaption)
+	}
+	return ""
+}
+
+// validateExerciseMediaUpload checks an upload request, returning a message for the first
+// problem found
+func validateExerciseMediaUpload(req *database.ExerciseMediaUploadRequest) string {
+	kind, err := database.ParseExercise_media_kind(req.Kind)
+	if err != nil {
+		return "kind must be image or video"
+	}
+	if _, ok := exerciseMediaContentTypes[kind][req.ContentType]; !ok {
+		accepted := make([]string, 0, len(exerciseMediaContentTypes[kind]))
+		for contentType := range exerciseMediaContentTypes[kind] {
+			accepted = append(accepted, contentType)
+		}
+		sort.Strings(accepted)
+		return fmt.Sprintf("contentType of %s media must be one of %s", kind, strings.Join(accepted, ", "))
+	}
+	if len(req.Caption) > maxExerciseMediaCaption {
+		return fmt.Sprintf("caption must be at most %d characters", maxExerciseMediaCaption)
+	}
+	return ""
+}
+
+// invalidateExercise drops the cached copies of an exercise after its media changed
+func (s *FiberServer) invalidateExercise(ctx context.Context, exerciseID string) {
+	s.DeleteCache(ctx, exerciseCacheKey(exerciseID))
+	s.cache.Del(ctx, "exercises:list:*")
+}
+
+// deleteExerciseMediaObject removes the stored file of uploaded media. Failures only leave
+// an orphaned file, so they are logged rather than returned.
+func (s *FiberServer) deleteExerciseMediaObject(ctx context.Context, media *database.Exercise_media) {
+	if media.Storage_key == "" {
+		return
+	}
+	if err := s.storage.Delete(ctx, media.Storage_key); err != nil && !errors.Is(err, storage.ErrNotFound) {
+		messages.Log(messages.ExerciseMediaDeleteFailed, media.Storage_key, media.Id, err)
+	}
+}
+
+// exerciseExists writes a 404 response unless the exercise exists, reporting whether it does
+func (s *FiberServer) exerciseExists(ctx context.Context, c *fiber.Ctx, id string) (bool, error) {
+	if _, err := s.db.GetExerciseByID(ctx, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, notFound(c, "Exercise")
+		}
+		LogDatabaseError(s, "get_exercise", err, c)
+		return false, errorResponse(c, fiber.StatusInternalServerError, "Failed to fetch exercise")
+	}
+	return true, nil
+}
+
+// GET /api/v1/exercises/:id/media
+func (s *FiberServer) listExerciseMedia(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	id := c.Params("id")
+	if ok, err := s.exerciseExists(ctx, c, id); !ok {
+		return err
+	}
+	media, err := s.db.ListExerciseMedia(ctx, []string{id})
+	if err != nil {
+		LogDatabaseError(s, "list_exercise_media", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to fetch exercise media")
+	}
+
+	response := []database.ExerciseMediaResponse{}
+	for i := range media {
+		if media[i].Status == database.Exercise_media_status_ready {
+			response = append(response, exerciseMediaToResponse(&media[i]))
+		}
+	}
+	return successResponse(c, response)
+}
+
+// POST /api/v1/exercises/:id/media
+// Links an image or video hosted elsewhere, such as on a video platform.
+func (s *FiberServer) createExerciseMedia(c *fiber.Ctx) error {
+	var req database.CreateExerciseMediaRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+	req.URL, req.Caption = strings.TrimSpace(req.URL), strings.TrimSpace(req.Caption)
+	if msg := validateExerciseMediaLink(&req); msg != "" {
+		return errorResponse(c, fiber.StatusBadRequest, msg)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	id := c.Params("id")
+	if ok, err := s.exerciseExists(ctx, c, id); !ok {
+		return err
+	}
+	media, err := s.db.CreateExerciseMedia(ctx, &database.Exercise_media{
+		Id:          uuid.New().String(),
+		Exercise_id: id,
+		Kind:        database.Exercise_media_kind(req.Kind),
+		Status:      database.Exercise_media_status_ready,
+		Url:         req.URL,
+		Caption:     req.Caption,
+	})
+	if err != nil {
+		LogDatabaseError(s, "create_exercise_media", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to add exercise media")
+	}
+	s.invalidateExercise(ctx, id)
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"data": exerciseMediaToResponse(media)})
+}
+
+// POST /api/v1/exercises/:id/media/upload-url
+// Starts an upload: the file is PUT to the returned URL, straight to object storage when
+// it can presign uploads and through the API otherwise, then confirmed at completeUrl.
+// Media isn't shown until the upload is confirmed.
+func (s *FiberServer) createExerciseMediaUploadURL(c *fiber.Ctx) error {
+	var req database.ExerciseMediaUploadRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+	req.Caption = strings.TrimSpace(req.Caption)
+	if msg := validateExerciseMediaUpload(&req); msg != "" {
+		return errorResponse(c, fiber.StatusBadRequest, msg)
+	}
+	kind := database.Exercise_media_kind(req.Kind)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	id := c.Params("id")
+	if ok, err := s.exerciseExists(ctx, c, id); !ok {
+		return err
+	}
+
+	mediaID := uuid.New().String()
+	key := fmt.Sprintf("exercise-media/%s/%s.%s", id, mediaID, exerciseMediaContentTypes[kind][req.ContentType])
+	expiresAt := time.Now().Add(exerciseMediaUploadTTL)
+	uploadURL, err := s.storage.PresignPut(ctx, key, req.ContentType, exerciseMediaUploadTTL)
+	if errors.Is(err, storage.ErrPresignUnsupported) {
+		uploadURL = exerciseMediaPath(id, mediaID) + "/file"
+	} else if err != nil {
+		LogError(s, "ERROR", messages.ExerciseMediaStorageFailed, err, c, map[string]interface{}{"exercise_id": id})
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to start upload")
+	}
+
+	media, err := s.db.CreateExerciseMedia(ctx, &database.Exercise_media{
+		Id:           mediaID,
+		Exercise_id:  id,
+		Kind:         kind,
+		Status:       database.Exercise_media_status_pending,
+		Storage_key:  key,
+		Content_type: req.ContentType,
+		Caption:      req.Caption,
+	})
+	if err != nil {
+		LogDatabaseError(s, "create_exercise_media", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to start upload")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"data": database.ExerciseMediaUploadResponse{
+		Media:       exerciseMediaToResponse(media),
+		UploadURL:   uploadURL,
+		ContentType: req.ContentType,
+		CompleteURL: exerciseMediaPath(id, mediaID) + "/complete",
+		ExpiresAt:   expiresAt,
+	}})
+}
+
+// pendingUpload returns the uploaded media of the route, writing the error response when
+// there is none
+func (s *FiberServer) pendingUpload(ctx context.Context, c *fiber.Ctx) (*database.Exercise_media, error) {
+	media, err := s.db.GetExerciseMedia(ctx, c.Params("id"), c.Params("mediaId"))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, notFound(c, "Media")
+		}
+		LogDatabaseError(s, "get_exercise_media", err, c)
+		return nil, errorResponse(c, fiber.StatusInternalServerError, "Failed to fetch exercise media")
+	}
+	if media.Storage_key == "" {
+		return nil, errorResponse(c, fiber.StatusConflict, "Linked media has no file to upload")
+	}
+	return media, nil
+}
+
+// PUT /api/v1/exercises/:id/media/:mediaId/file
+// Receives an upload when object storage can't take it directly
+func (s *FiberServer) uploadExerciseMediaFile(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	media, err := s.pendingUpload(ctx, c)
+	if media == nil {
+		return err
+	}
+	if media.Status != database.Exercise_media_status_pending {
+		return errorResponse(c, fiber.StatusConflict, "Media has already been uploaded")
+	}
+	if contentType, _, err := mime.ParseMediaType(c.Get(fiber.HeaderContentType)); err != nil || contentType != media.Content_type {
+		return errorResponse(c, fiber.StatusUnsupportedMediaType, "Content-Type must be "+media.Content_type)
+	}
+	if len(c.Body()) == 0 {
+		return errorResponse(c, fiber.StatusBadRequest, "The file is empty")
+	}
+
+	if err := s.storage.Put(ctx, media.Storage_key, bytes.NewReader(c.Body()), media.Content_type); err != nil {
+		LogError(s, "ERROR", messages.ExerciseMediaStorageFailed, err, c, map[string]interface{}{"media_id": media.Id})
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to store file")
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// POST /api/v1/exercises/:id/media/:mediaId/complete
+// Confirms an upload once the file is stored, adding the media to the exercise. Confirming
+// again returns the media unchanged.
+func (s *FiberServer) completeExerciseMediaUpload(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	media, err := s.pendingUpload(ctx, c)
+	if media == nil {
+		return err
+	}
+	if media.Status == database.Exercise_media_status_ready {
+		return successResponse(c, exerciseMediaToResponse(media))
+	}
+
+	body, err := s.storage.Get(ctx, media.Storage_key)
+	if errors.Is(err, storage.ErrNotFound) {
+		return errorResponse(c, fiber.StatusConflict, "The file hasn't been uploaded yet")
+	}
+	if err != nil {
+		LogError(s, "ERROR", messages.ExerciseMediaStorageFailed, err, c, map[string]interface{}{"media_id": media.Id})
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to check upload")
+	}
+	body.Close()
+
+	media, err = s.db.MarkExerciseMediaReady(ctx, media.Exercise_id, media.Id)
+	if err != nil {
+		LogDatabaseError(s, "mark_exercise_media_ready", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to complete upload")
+	}
+	s.invalidateExercise(ctx, media.Exercise_id)
+
+	return successResponse(c, exerciseMediaToResponse(media))
+}
+
+// GET /api/v1/exercises/:id/media/:mediaId/file
+// Redirects to the uploaded file in object storage, or streams it when storage can't hand
+// out links.
+func (s *FiberServer) getExerciseMediaFile(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	media, err := s.pendingUpload(ctx, c)
+	if media == nil {
+		return err
+	}
+	if media.Status != database.Exercise_media_status_ready {
+		return notFound(c, "Media")
+	}
+
+	link, err := s.storage.PresignGet(ctx, media.Storage_key, exerciseMediaLinkTTL)
+	if err == nil {
+		return c.Redirect(link, fiber.StatusFound)
+	}
+	if !errors.Is(err, storage.ErrPresignUnsupported) {
+		LogError(s, "ERROR", messages.ExerciseMediaStorageFailed, err, c, map[string]interface{}{"media_id": media.Id})
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to read file")
+	}
+
+	body, err := s.storage.Get(ctx, media.Storage_key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return notFound(c, "Media")
+		}
+		LogError(s, "ERROR", messages.ExerciseMediaStorageFailed, err, c, map[string]interface{}{"media_id": media.Id})
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to read file")
+	}
+	defer body.Close()
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to read file")
+	}
+
+	c.Set(fiber.HeaderCacheControl, "public, max-age=3600")
+	c.Set(fiber.HeaderContentType, media.Content_type)
+	return c.Send(data)
+}
+
+// PUT /api/v1/exercises/:id/media/order
+// Reorders the exercise's media; mediaIds must list each of them once.
+func (s *FiberServer) reorderExerciseMedia(c *fiber.Ctx) error {
+	var req database.ReorderExerciseMediaRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+	for _, mediaID := range req.MediaIDs {
+		if _, err := uuid.Parse(mediaID); err != nil {
+			return errorResponse(c, fiber.StatusBadRequest, "mediaIds must be media IDs")
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	id := c.Params("id")
+	if ok, err := s.exerciseExists(ctx, c, id); !ok {
+		return err
+	}
+	err := s.db.ReorderExerciseMedia(ctx, id, req.MediaIDs)
+	if errors.Is(err, database.ErrMediaOrderMismatch) {
+		return errorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+	if err != nil {
+		LogDatabaseError(s, "reorder_exercise_media", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to reorder exercise media")
+	}
+	s.invalidateExercise(ctx, id)
+
+	return s.listExerciseMedia(c)
+}
+
+// DELETE /api/v1/exercises/:id/media/:mediaId
+func (s *FiberServer) deleteExerciseMedia(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	media, err := s.db.DeleteExerciseMedia(ctx, c.Params("id"), c.Params("mediaId"))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return notFound(c, "Media")
+		}
+		LogDatabaseError(s, "delete_exercise_media", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to delete exercise media")
+	}
+	s.invalidateExercise(ctx, media.Exercise_id)
+	s.deleteExerciseMediaObject(ctx, media)
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
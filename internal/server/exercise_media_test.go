@@ -0,0 +1,138 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"fitness-hack/internal/database"
+	"fitness-hack/internal/storage"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestValidateExerciseMedia(t *testing.T) {
+	for name, req := range map[string]database.CreateExerciseMediaRequest{
+		"bad kind":    {Kind: "audio", URL: "https://example.com/a.mp3"},
+		"missing url": {Kind: "image"},
+		"not http":    {Kind: "video", URL: "ftp://example.com/squat.mp4"},
+		"no host":     {Kind: "video", URL: "https:///squat.mp4"},
+		"long url":    {Kind: "image", URL: "https://example.com/" + strings.Repeat("a", maxExerciseMediaURL)},
+	} {
+		if msg := validateExerciseMediaLink(&req); msg == "" {
+			t.Errorf("%s: expected the link to be rejected", name)
+		}
+	}
+	if msg := validateExerciseMediaLink(&database.CreateExerciseMediaRequest{Kind: "video", URL: "https://youtu.be/abc"}); msg != "" {
+		t.Errorf("valid link rejected: %s", msg)
+	}
+
+	if msg := validateExerciseMediaUpload(&database.ExerciseMediaUploadRequest{Kind: "image", ContentType: "video/mp4"}); msg == "" {
+		t.Error("expected a video content type to be rejected for an image")
+	}
+	if msg := validateExerciseMediaUpload(&database.ExerciseMediaUploadRequest{Kind: "video", ContentType: "video/mp4"}); msg != "" {
+		t.Errorf("valid upload rejected: %s", msg)
+	}
+}
+
+func TestExerciseMedia(t *testing.T) {
+	s, db := newFakeServer(t)
+	s.storage = storage.NewLocal(t.TempDir())
+	exercise, err := db.CreateExercise(context.Background(), &database.Exercises{Name: "Squat"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth := bearer(t, "u1")
+	base := "/api/v1/exercises/" + exercise.Id
+
+	do := func(method, path, contentType, body string, out interface{}) int {
+		t.Helper()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", auth)
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		resp, err := s.App.Test(req, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out != nil {
+			envelope := struct {
+				Data interface{} `json:"data"`
+			}{out}
+			json.NewDecoder(resp.Body).Decode(&envelope)
+		}
+		return resp.StatusCode
+	}
+
+	var linked database.ExerciseMediaResponse
+	if status := do("POST", base+"/media", "application/json", `{"kind":"video","url":"https://youtu.be/squat"}`, &linked); status != fiber.StatusCreated {
+		t.Fatalf("linking media = %d, want 201", status)
+	}
+	if status := do("POST", base+"/media", "application/json", `{"kind":"video","url":"javascript:alert(1)"}`, nil); status != fiber.StatusBadRequest {
+		t.Errorf("linking a bad URL = %d, want 400", status)
+	}
+
+	// Without presigned uploads the file goes through the API
+	var upload database.ExerciseMediaUploadResponse
+	if status := do("POST", base+"/media/upload-url", "application/json", `{"kind":"image","contentType":"image/png","caption":"Bottom"}`, &upload); status != fiber.StatusCreated {
+		t.Fatalf("upload-url = %d, want 201", status)
+	}
+	if upload.UploadURL != upload.Media.URL {
+		t.Errorf("uploadUrl = %q, want the API file route %q", upload.UploadURL, upload.Media.URL)
+	}
+	if status := do("POST", upload.CompleteURL, "", "", nil); status != fiber.StatusConflict {
+		t.Errorf("completing before uploading = %d, want 409", status)
+	}
+
+	var got database.ExerciseResponse
+	do("GET", base, "", "", &got)
+	if len(got.Media) != 1 || got.Media[0].ID != linked.ID {
+		t.Fatalf("media before the upload completed = %v, want only the link", got.Media)
+	}
+
+	if status := do("PUT", upload.UploadURL, "image/jpeg", "jpeg", nil); status != fiber.StatusUnsupportedMediaType {
+		t.Errorf("uploading the wrong type = %d, want 415", status)
+	}
+	if status := do("PUT", upload.UploadURL, "image/png", "png bytes", nil); status != fiber.StatusNoContent {
+		t.Fatalf("uploading = %d, want 204", status)
+	}
+	if status := do("POST", upload.CompleteURL, "", "", nil); status != fiber.StatusOK {
+		t.Fatalf("completing = %d, want 200", status)
+	}
+
+	req := httptest.NewRequest("GET", upload.Media.URL, nil)
+	req.Header.Set("Authorization", auth)
+	resp, err := s.App.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body, _ := io.ReadAll(resp.Body); resp.StatusCode != fiber.StatusOK || string(body) != "png bytes" || resp.Header.Get("Content-Type") != "image/png" {
+		t.Errorf("file = %d %q %q, want the uploaded PNG", resp.StatusCode, body, resp.Header.Get("Content-Type"))
+	}
+
+	if status := do("PUT", base+"/media/order", "application/json", `{"mediaIds":["`+linked.ID+`"]}`, nil); status != fiber.StatusBadRequest {
+		t.Errorf("reordering part of the media = %d, want 400", status)
+	}
+	var ordered []database.ExerciseMediaResponse
+	if status := do("PUT", base+"/media/order", "application/json", `{"mediaIds":["`+upload.Media.ID+`","`+linked.ID+`"]}`, &ordered); status != fiber.StatusOK {
+		t.Fatalf("reordering = %d, want 200", status)
+	}
+	if len(ordered) != 2 || ordered[0].ID != upload.Media.ID || ordered[1].ID != linked.ID {
+		t.Errorf("reordered media = %v, want the upload first", ordered)
+	}
+
+	if status := do("DELETE", base+"/media/"+upload.Media.ID, "", "", nil); status != fiber.StatusNoContent {
+		t.Fatalf("deleting = %d, want 204", status)
+	}
+	if _, err := s.storage.Get(context.Background(), "exercise-media/"+exercise.Id+"/"+upload.Media.ID+".png"); err == nil {
+		t.Error("expected the stored file to be deleted")
+	}
+	do("GET", base, "", "", &got)
+	if len(got.Media) != 1 || got.Media[0].ID != linked.ID {
+		t.Errorf("media after deleting the upload = %v, want the link", got.Media)
+	}
+}
@@ -3,12 +3,14 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
 	"fitness-hack/internal/database"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
 )
 
 // Cache key helpers
@@ -16,52 +18,121 @@ func exerciseCacheKey(id string) string {
 	return fmt.Sprintf("exercise:%s", id)
 }
 
-func exercisesListCacheKey(limit, offset int) string {
-	return fmt.Sprintf("exercises:list:%d:%d", limit, offset)
+func exercisesListCacheKey(opts database.ListExercisesOpts) string {
+	return fmt.Sprintf("exercises:list:%d:%d:%s:%s:%s:%s", opts.Limit, opts.Offset, opts.Sort, opts.Order, opts.Muscle, opts.Role)
 }
 
-// Helper to convert database exercise to response model
-func exerciseToResponse(exercise *database.Exercises) database.ExerciseResponse {
-	// Handle type assertions safely
-	var name string
-	if exercise.Name != nil {
-		if str, ok := exercise.Name.(string); ok {
-			name = str
+// cachedExercise is how an exercise is cached, with its muscles and media
+type cachedExercise struct {
+	Exercise database.Exercises        `json:"exercise"`
+	Muscles  []database.ExerciseMuscle `json:"muscles"`
+	Media    []database.Exercise_media `json:"media"`
+}
+
+// Helper to convert database exercise to response model. Media still being uploaded is
+// left out.
+func exerciseToResponse(exercise *database.Exercises, muscles []database.ExerciseMuscle, media []database.Exercise_media) database.ExerciseResponse {
+	response := database.ExerciseResponse{
+		ID:              exercise.Id,
+		Name:            exercise.Name,
+		Description:     exercise.Description,
+		Muscles:         make([]database.ExerciseMuscleResponse, 0, len(muscles)),
+		Equipment:       stringValue(exercise.Equipment),
+		DifficultyLevel: stringValue(exercise.Difficulty_level),
+		Instructions:    exercise.Instructions,
+		Media:           make([]database.ExerciseMediaResponse, 0, len(media)),
+		CreatedAt:       exercise.Created_at,
+		UpdatedAt:       exercise.Updated_at,
+		Version:         exercise.Version,
+	}
+	for i := range media {
+		if media[i].Status == database.Exercise_media_status_ready {
+			response.Media = append(response.Media, exerciseMediaToResponse(&media[i]))
 		}
 	}
-
-	var muscleGroup string
-	if exercise.Muscle_group != nil {
-		if str, ok := exercise.Muscle_group.(string); ok {
-			muscleGroup = str
+	for _, m := range muscles {
+		if response.MuscleGroup == "" && m.Role == database.Exercise_muscle_groups_role_primary {
+			response.MuscleGroup = m.Name
 		}
+		response.Muscles = append(response.Muscles, database.ExerciseMuscleResponse{Slug: m.Slug, Name: m.Name, Role: string(m.Role)})
 	}
+	return response
+}
 
-	var equipment string
-	if exercise.Equipment != nil {
-		if str, ok := exercise.Equipment.(string); ok {
-			equipment = str
-		}
+// loadExercises pairs exercises with their muscles and media
+func (s *FiberServer) loadExercises(ctx context.Context, exercises []database.Exercises) ([]cachedExercise, error) {
+	ids := make([]string, len(exercises))
+	for i, exercise := range exercises {
+		ids[i] = exercise.Id
+	}
+	muscles, err := s.db.ListExerciseMuscles(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	byExercise := make(map[string][]database.ExerciseMuscle, len(exercises))
+	for _, m := range muscles {
+		byExercise[m.Exercise_id] = append(byExercise[m.Exercise_id], m)
+	}
+	media, err := s.db.ListExerciseMedia(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	mediaByExercise := make(map[string][]database.Exercise_media, len(exercises))
+	for _, m := range media {
+		mediaByExercise[m.Exercise_id] = append(mediaByExercise[m.Exercise_id], m)
 	}
 
-	var difficultyLevel string
-	if exercise.Difficulty_level != nil {
-		if str, ok := exercise.Difficulty_level.(string); ok {
-			difficultyLevel = str
-		}
+	loaded := make([]cachedExercise, len(exercises))
+	for i, exercise := range exercises {
+		loaded[i] = cachedExercise{Exercise: exercise, Muscles: byExercise[exercise.Id], Media: mediaByExercise[exercise.Id]}
 	}
+	return loaded, nil
+}
 
-	return database.ExerciseResponse{
-		ID:              exercise.Id,
-		Name:            name,
-		Description:     exercise.Description,
-		MuscleGroup:     muscleGroup,
-		Equipment:       equipment,
-		DifficultyLevel: difficultyLevel,
-		Instructions:    exercise.Instructions,
-		CreatedAt:       exercise.Created_at,
-		UpdatedAt:       exercise.Updated_at,
+// exercisesToResponses converts loaded exercises to response models
+func exercisesToResponses(exercises []cachedExercise) []database.ExerciseResponse {
+	responses := make([]database.ExerciseResponse, len(exercises))
+	for i := range exercises {
+		responses[i] = exerciseToResponse(&exercises[i].Exercise, exercises[i].Muscles, exercises[i].Media)
+	}
+	return responses
+}
+
+// setExerciseMuscles checks the muscles against the taxonomy and gives them to the
+// exercise, writing the error response when it fails
+func (s *FiberServer) setExerciseMuscles(ctx context.Context, c *fiber.Ctx, exerciseID string, muscles []database.MuscleAssignment) error {
+	err := s.db.SetExerciseMuscles(ctx, exerciseID, muscles)
+	if errors.Is(err, database.ErrUnknownMuscle) {
+		return errorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+	if err != nil {
+		LogDatabaseError(s, "set_exercise_muscles", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to save exercise muscles")
 	}
+	return nil
+}
+
+// checkMuscles writes a 400 response naming the first muscle missing from the taxonomy,
+// reporting whether the muscles were all found
+func (s *FiberServer) checkMuscles(ctx context.Context, c *fiber.Ctx, muscles []database.MuscleAssignment) (bool, error) {
+	unknown, err := s.unknownMuscle(ctx, muscles)
+	if err != nil {
+		LogDatabaseError(s, "list_muscle_groups", err, c)
+		return false, errorResponse(c, fiber.StatusInternalServerError, "Failed to check muscles")
+	}
+	if unknown != "" {
+		return false, errorResponse(c, fiber.StatusBadRequest, fmt.Sprintf("unknown muscle %q; see /api/v1/muscle-groups", unknown))
+	}
+	return true, nil
+}
+
+// exercisesETag returns the ETag of a list of exercises
+func exercisesETag(responses []database.ExerciseResponse) string {
+	tags := make([]string, len(responses))
+	for i, response := range responses {
+		tags[i] = resourceETag(response.ID, response.UpdatedAt)
+	}
+	return listETag(tags)
 }
 
 // Exercises handlers
@@ -71,31 +142,55 @@ func (s *FiberServer) createExercise(c *fiber.Ctx) error {
 		return errorResponse(c, fiber.StatusBadRequest, "Invalid request body")
 	}
 
+	muscles, msg := muscleAssignments(req.MuscleGroup, req.Muscles)
+	if msg != "" {
+		return errorResponse(c, fiber.StatusBadRequest, msg)
+	}
+
 	// Create database exercise
 	exercise := database.Exercises{
 		Name:             req.Name,
 		Description:      req.Description,
-		Muscle_group:     req.MuscleGroup,
-		Equipment:        req.Equipment,
-		Difficulty_level: req.DifficultyLevel,
+		Equipment:        &req.Equipment,
+		Difficulty_level: &req.DifficultyLevel,
 		Instructions:     req.Instructions,
 		Created_at:       time.Now(),
 		Updated_at:       time.Now(),
 	}
+	// Exercises added by a user count towards their custom exercise quota
+	if userID, err := getUserIDFromJWT(c); err == nil {
+		exercise.Created_by = &userID
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	if ok, err := s.checkMuscles(ctx, c, muscles); !ok {
+		return err
+	}
+
 	createdExercise, err := s.db.CreateExercise(ctx, &exercise)
+	if quotaErr, ok := asQuotaError(err); ok {
+		return quotaExceeded(c, quotaErr)
+	}
 	if err != nil {
-		return errorResponse(c, fiber.StatusInternalServerError, "Failed to create exercise: "+err.Error())
+		return serverError("Failed to create exercise", err)
 	}
 
 	// Invalidate exercises list cache
 	s.cache.Del(ctx, "exercises:list:*")
 
+	if err := s.setExerciseMuscles(ctx, c, createdExercise.Id, muscles); err != nil {
+		return err
+	}
+	loaded, err := s.loadExercises(ctx, []database.Exercises{*createdExercise})
+	if err != nil {
+		LogDatabaseError(s, "load_exercises", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to fetch exercise details")
+	}
+
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
-		"data": exerciseToResponse(createdExercise),
+		"data": exercisesToResponses(loaded)[0],
 	})
 }
 
@@ -110,65 +205,142 @@ func (s *FiberServer) getExercise(c *fiber.Ctx) error {
 
 	// Try to get from cache first
 	cacheKey := exerciseCacheKey(id)
+	// Entries cached before exercises had muscles have no exercise and are refetched
 	if cachedData, err := s.GetCache(ctx, cacheKey); err == nil {
-		var exercise database.Exercises
-		if json.Unmarshal([]byte(cachedData), &exercise) == nil {
-			return successResponse(c, exerciseToResponse(&exercise))
+		var cached cachedExercise
+		if json.Unmarshal([]byte(cachedData), &cached) == nil && cached.Exercise.Id != "" {
+			return respondWithETag(c, resourceETag(cached.Exercise.Id, cached.Exercise.Updated_at), exerciseToResponse(&cached.Exercise, cached.Muscles, cached.Media))
 		}
 	}
 
 	// Get from database
 	exercise, err := s.db.GetExerciseByID(ctx, id)
 	if err != nil {
-		return errorResponse(c, fiber.StatusNotFound, "Exercise not found")
+		return notFound(c, "Exercise")
+	}
+	loaded, err := s.loadExercises(ctx, []database.Exercises{*exercise})
+	if err != nil {
+		return serverError("Failed to fetch exercise details", err)
 	}
 
 	// Cache the exercise data
-	if exerciseData, err := json.Marshal(exercise); err == nil {
+	if exerciseData, err := json.Marshal(loaded[0]); err == nil {
 		s.SetCache(ctx, cacheKey, string(exerciseData), 10*time.Minute)
 	}
 
-	return successResponse(c, exerciseToResponse(exercise))
+	return respondWithETag(c, resourceETag(exercise.Id, exercise.Updated_at), exercisesToResponses(loaded)[0])
+}
+
+// exerciseListOpts reads the filters and sort of an exercise list from the query string.
+// muscle takes a slug or a name.
+func exerciseListOpts(c *fiber.Ctx) (database.ListExercisesOpts, error) {
+	opts := database.ListExercisesOpts{ListOptions: getListOptions(c), Muscle: database.MuscleSlug(c.Query("muscle"))}
+	if role := c.Query("role"); role != "" {
+		parsed, err := database.ParseExercise_muscle_groups_role(role)
+		if err != nil {
+			return opts, errors.New("role must be primary or secondary")
+		}
+		if opts.Muscle == "" {
+			return opts, errors.New("role filters by the muscle given in muscle, which is missing")
+		}
+		opts.Role = parsed
+	}
+	return opts, nil
 }
 
+// maxExerciseBatch is how many exercises one ?ids= request may fetch
+const maxExerciseBatch = 100
+
+// GET /api/v1/exercises?muscle=&role=&sort=&order=
+// GET /api/v1/exercises?ids=
 func (s *FiberServer) listExercises(c *fiber.Ctx) error {
-	limit, offset := getPaginationParams(c)
+	if ids := c.Query("ids"); ids != "" {
+		return s.getExercisesByIDs(c, splitList(ids))
+	}
+
+	opts, err := exerciseListOpts(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	// Try to get from cache first
-	cacheKey := exercisesListCacheKey(limit, offset)
+	// Try to get from cache first; entries cached before exercises had muscles are refetched
+	cacheKey := exercisesListCacheKey(opts)
 	if cachedData, err := s.GetCache(ctx, cacheKey); err == nil {
-		var exercises []database.Exercises
-		if json.Unmarshal([]byte(cachedData), &exercises) == nil {
-			// Convert to response models
-			responses := make([]database.ExerciseResponse, len(exercises))
-			for i, exercise := range exercises {
-				responses[i] = exerciseToResponse(&exercise)
-			}
-			return successResponse(c, responses)
+		var cached []cachedExercise
+		if json.Unmarshal([]byte(cachedData), &cached) == nil && (len(cached) == 0 || cached[0].Exercise.Id != "") {
+			responses := exercisesToResponses(cached)
+			return respondWithETag(c, exercisesETag(responses), responses)
 		}
 	}
 
 	// Get from database
-	exercises, err := s.db.ListExercises(ctx, limit, offset)
+	exercises, err := s.db.ListExercises(ctx, opts)
+	if errors.Is(err, database.ErrInvalidListOption) {
+		return errorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
 	if err != nil {
-		return errorResponse(c, fiber.StatusInternalServerError, "Failed to fetch exercises: "+err.Error())
+		return serverError("Failed to fetch exercises", err)
+	}
+	loaded, err := s.loadExercises(ctx, exercises)
+	if err != nil {
+		return serverError("Failed to fetch exercise details", err)
 	}
 
 	// Cache the exercises data
-	if exercisesData, err := json.Marshal(exercises); err == nil {
+	if exercisesData, err := json.Marshal(loaded); err == nil {
 		s.SetCache(ctx, cacheKey, string(exercisesData), 10*time.Minute)
 	}
 
-	// Convert to response models
-	responses := make([]database.ExerciseResponse, len(exercises))
-	for i, exercise := range exercises {
-		responses[i] = exerciseToResponse(&exercise)
+	responses := exercisesToResponses(loaded)
+	return respondWithETag(c, exercisesETag(responses), responses)
+}
+
+// getExercisesByIDs responds with the exercises with the IDs in one query, in the order
+// they were asked for. IDs without an exercise are left out, and repeated IDs are returned
+// once. IDs that aren't UUIDs are rejected, as Postgres would fail the whole query on them.
+func (s *FiberServer) getExercisesByIDs(c *fiber.Ctx, ids []string) error {
+	seen := make(map[string]bool, len(ids))
+	unique := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if _, err := uuid.Parse(id); err != nil {
+			return errorResponse(c, fiber.StatusBadRequest, fmt.Sprintf("ids must be exercise IDs, got %q", id))
+		}
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	if len(unique) > maxExerciseBatch {
+		return errorResponse(c, fiber.StatusBadRequest, fmt.Sprintf("ids can list at most %d exercises", maxExerciseBatch))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	exercises, err := s.db.GetExercisesByIDs(ctx, unique)
+	if err != nil {
+		return serverError("Failed to fetch exercises", err)
+	}
+	byID := make(map[string]database.Exercises, len(exercises))
+	for _, exercise := range exercises {
+		byID[exercise.Id] = exercise
+	}
+	ordered := make([]database.Exercises, 0, len(exercises))
+	for _, id := range unique {
+		if exercise, ok := byID[id]; ok {
+			ordered = append(ordered, exercise)
+		}
 	}
 
-	return successResponse(c, responses)
+	loaded, err := s.loadExercises(ctx, ordered)
+	if err != nil {
+		return serverError("Failed to fetch exercise details", err)
+	}
+	responses := exercisesToResponses(loaded)
+	return respondWithETag(c, exercisesETag(responses), responses)
 }
 
 func (s *FiberServer) updateExercise(c *fiber.Ctx) error {
@@ -182,13 +354,74 @@ func (s *FiberServer) updateExercise(c *fiber.Ctx) error {
 		return errorResponse(c, fiber.StatusBadRequest, "Invalid request body")
 	}
 
+	return s.saveExerciseUpdate(c, id, req, nil)
+}
+
+// exercisePatchFields are the exercise fields a merge patch may change
+var exercisePatchFields = patchFields{
+	"name":            false,
+	"description":     true,
+	"muscleGroup":     true,
+	"muscles":         true,
+	"equipment":       true,
+	"difficultyLevel": true,
+	"instructions":    true,
+	"version":         false,
+}
+
+// PATCH /api/v1/exercises/:id
+func (s *FiberServer) patchExercise(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if id == "" {
+		return errorResponse(c, fiber.StatusBadRequest, "Exercise ID is required")
+	}
+
+	var req database.UpdateExerciseRequest
+	cleared, err := applyMergePatch(c.Body(), &req, exercisePatchFields)
+	if err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	return s.saveExerciseUpdate(c, id, req, cleared)
+}
+
+// saveExerciseUpdate applies the set fields of req to the stored exercise, clears the
+// fields in cleared, and saves it
+func (s *FiberServer) saveExerciseUpdate(c *fiber.Ctx, id string, req database.UpdateExerciseRequest, cleared map[string]bool) error {
+	// Muscles replace the exercise's muscles; muscleGroup replaces them with one primary muscle
+	var muscles []database.MuscleAssignment
+	switch {
+	case req.Muscles != nil || cleared["muscles"] || cleared["muscleGroup"]:
+		var list []database.MuscleRequest
+		if req.Muscles != nil {
+			list = *req.Muscles
+		}
+		var msg string
+		if muscles, msg = muscleAssignments("", list); msg != "" {
+			return errorResponse(c, fiber.StatusBadRequest, msg)
+		}
+	case req.MuscleGroup != nil:
+		muscles, _ = muscleAssignments(*req.MuscleGroup, nil)
+	}
+
 	// Get existing exercise
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	if ok, err := s.checkMuscles(ctx, c, muscles); !ok {
+		return err
+	}
+
 	existingExercise, err := s.db.GetExerciseByID(ctx, id)
 	if err != nil {
-		return errorResponse(c, fiber.StatusNotFound, "Exercise not found")
+		return notFound(c, "Exercise")
+	}
+
+	if etag := resourceETag(existingExercise.Id, existingExercise.Updated_at); ifMatchFails(c, etag) {
+		return preconditionFailed(c, etag)
+	}
+	if req.Version != nil {
+		existingExercise.Version = *req.Version
 	}
 
 	// Update fields if provided
@@ -198,30 +431,56 @@ func (s *FiberServer) updateExercise(c *fiber.Ctx) error {
 	if req.Description != nil {
 		existingExercise.Description = *req.Description
 	}
-	if req.MuscleGroup != nil {
-		existingExercise.Muscle_group = *req.MuscleGroup
-	}
 	if req.Equipment != nil {
 		existingExercise.Equipment = req.Equipment
 	}
 	if req.DifficultyLevel != nil {
-		existingExercise.Difficulty_level = *req.DifficultyLevel
+		existingExercise.Difficulty_level = req.DifficultyLevel
 	}
 	if req.Instructions != nil {
 		existingExercise.Instructions = *req.Instructions
 	}
+
+	// Nullable text columns the model holds as strings are stored empty, the others as NULL
+	if cleared["description"] {
+		existingExercise.Description = ""
+	}
+	if cleared["instructions"] {
+		existingExercise.Instructions = ""
+	}
+	if cleared["equipment"] {
+		existingExercise.Equipment = nil
+	}
+	if cleared["difficultyLevel"] {
+		existingExercise.Difficulty_level = nil
+	}
 	existingExercise.Updated_at = time.Now()
 
 	updatedExercise, err := s.db.UpdateExercise(ctx, existingExercise)
+	if errors.Is(err, database.ErrVersionConflict) {
+		return versionConflict(c)
+	}
 	if err != nil {
-		return errorResponse(c, fiber.StatusInternalServerError, "Failed to update exercise: "+err.Error())
+		return serverError("Failed to update exercise", err)
 	}
 
 	// Invalidate cache
 	s.DeleteCache(ctx, exerciseCacheKey(id))
 	s.cache.Del(ctx, "exercises:list:*")
 
-	return successResponse(c, exerciseToResponse(updatedExercise))
+	if muscles != nil {
+		if err := s.setExerciseMuscles(ctx, c, id, muscles); err != nil {
+			return err
+		}
+	}
+	loaded, err := s.loadExercises(ctx, []database.Exercises{*updatedExercise})
+	if err != nil {
+		LogDatabaseError(s, "load_exercises", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to fetch exercise details")
+	}
+
+	c.Set(fiber.HeaderETag, resourceETag(updatedExercise.Id, updatedExercise.Updated_at))
+	return successResponse(c, exercisesToResponses(loaded)[0])
 }
 
 func (s *FiberServer) deleteExercise(c *fiber.Ctx) error {
@@ -233,14 +492,34 @@ func (s *FiberServer) deleteExercise(c *fiber.Ctx) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	err := s.db.DeleteExercise(ctx, id)
+	if c.Get(fiber.HeaderIfMatch) != "" {
+		existing, err := s.db.GetExerciseByID(ctx, id)
+		if err != nil {
+			return notFound(c, "Exercise")
+		}
+		if etag := resourceETag(existing.Id, existing.Updated_at); ifMatchFails(c, etag) {
+			return preconditionFailed(c, etag)
+		}
+	}
+
+	// Uploaded media outlives its rows in storage unless removed with them
+	media, err := s.db.ListExerciseMedia(ctx, []string{id})
+	if err != nil {
+		return serverError("Failed to delete exercise", err)
+	}
+
+	err = s.db.DeleteExercise(ctx, id)
 	if err != nil {
-		return errorResponse(c, fiber.StatusInternalServerError, "Failed to delete exercise: "+err.Error())
+		return serverError("Failed to delete exercise", err)
 	}
 
 	// Invalidate cache
 	s.DeleteCache(ctx, exerciseCacheKey(id))
 	s.cache.Del(ctx, "exercises:list:*")
 
+	for i := range media {
+		s.deleteExerciseMediaObject(ctx, &media[i])
+	}
+
 	return c.Status(fiber.StatusNoContent).Send(nil)
 }
@@ -0,0 +1,64 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"fitness-hack/internal/database"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+func TestListExercisesByIDs(t *testing.T) {
+	s, db := newFakeServer(t)
+	ctx := context.Background()
+	var ids []string
+	for _, name := range []string{"Squat", "Bench Press", "Deadlift"} {
+		exercise, err := db.CreateExercise(ctx, &database.Exercises{Name: name})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, exercise.Id)
+	}
+	if err := db.SetExerciseMuscles(ctx, ids[2], []database.MuscleAssignment{{Muscle: "hamstrings", Role: "primary"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	get := func(query string) (int, []database.ExerciseResponse) {
+		t.Helper()
+		req := httptest.NewRequest("GET", "/api/v1/exercises"+query, nil)
+		req.Header.Set("Authorization", bearer(t, "u1"))
+		resp, err := s.App.Test(req, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var envelope struct {
+			Data []database.ExerciseResponse `json:"data"`
+		}
+		json.NewDecoder(resp.Body).Decode(&envelope)
+		return resp.StatusCode, envelope.Data
+	}
+
+	status, got := get("?ids=" + ids[2] + "," + uuid.NewString() + "," + ids[0] + "," + ids[2])
+	if status != fiber.StatusOK || len(got) != 2 || got[0].Name != "Deadlift" || got[1].Name != "Squat" {
+		t.Fatalf("?ids = %d %v, want Deadlift and Squat in the order asked for", status, got)
+	}
+	if len(got[0].Muscles) != 1 || got[0].Muscles[0].Slug != "hamstrings" {
+		t.Errorf("expected the exercise's muscles, got %v", got[0].Muscles)
+	}
+
+	tooMany := make([]string, maxExerciseBatch+1)
+	for i := range tooMany {
+		tooMany[i] = uuid.NewString()
+	}
+	if status, _ := get("?ids=" + strings.Join(tooMany, ",")); status != fiber.StatusBadRequest {
+		t.Errorf("expected more than %d IDs rejected, got %d", maxExerciseBatch, status)
+	}
+	if status, _ := get("?ids=" + ids[0] + ",missing"); status != fiber.StatusBadRequest {
+		t.Errorf("expected an ID that isn't a UUID rejected, got %d", status)
+	}
+}
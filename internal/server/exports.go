@@ -0,0 +1,252 @@
+package server
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"fitness-hack/internal/database"
+	"fitness-hack/internal/messages"
+	"fitness-hack/internal/storage"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Helper to convert a data export to response model, including a download URL once ready.
+// downloadPath is the API route serving the archive when storage cannot presign URLs.
+func (s *FiberServer) dataExportToResponse(ctx context.Context, export *database.Data_exports, downloadPath string) database.DataExportResponse {
+	response := database.DataExportResponse{
+		ID:          export.Id,
+		Status:      string(export.Status),
+		SizeBytes:   export.Size_bytes,
+		CreatedAt:   export.Created_at,
+		CompletedAt: export.Completed_at,
+		ExpiresAt:   export.Expires_at,
+	}
+
+	if export.Status != "ready" || export.Expires_at == nil || time.Now().After(*export.Expires_at) {
+		return response
+	}
+
+	// Prefer a direct link from object storage; otherwise stream through the API
+	url, err := s.storage.PresignGet(ctx, export.Storage_key, time.Until(*export.Expires_at))
+	if errors.Is(err, storage.ErrPresignUnsupported) {
+		url = downloadPath
+	} else if err != nil {
+		messages.Log(messages.ExportPresignFailed, export.Id, err)
+		return response
+	}
+	response.DownloadURL = url
+	return response
+}
+
+// myExportDownloadPath is the route users download their own exports from
+func myExportDownloadPath(exportID string) string {
+	return "/api/v1/users/me/exports/" + exportID + "/download"
+}
+
+// buildExportArchive writes one JSON file per data section into a ZIP archive
+func buildExportArchive(userID string, data map[string]json.RawMessage) (*bytes.Buffer, error) {
+	sections := make([]string, 0, len(data))
+	for section := range data {
+		sections = append(sections, section)
+	}
+	sort.Strings(sections)
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	manifest, err := json.MarshalIndent(map[string]interface{}{
+		"userId":      userID,
+		"generatedAt": time.Now().UTC(),
+		"sections":    sections,
+	}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := writeZipFile(zw, "manifest.json", manifest); err != nil {
+		return nil, err
+	}
+
+	for _, section := range sections {
+		var pretty bytes.Buffer
+		if err := json.Indent(&pretty, data[section], "", "  "); err != nil {
+			return nil, fmt.Errorf("invalid %s data: %w", section, err)
+		}
+		if err := writeZipFile(zw, section+".json", pretty.Bytes()); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return &buf, nil
+}
+
+func writeZipFile(zw *zip.Writer, name string, content []byte) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(content)
+	return err
+}
+
+// runDataExport assembles the archive and records the outcome. Failures are only recorded
+// on the export once final is set, so earlier attempts leave it pending for the job retry.
+func (s *FiberServer) runDataExport(ctx context.Context, exportID, userID string, includeVault, final bool) error {
+	fail := func(stage string, err error) error {
+		messages.Log(messages.ExportStageFailed, exportID, stage, err)
+		if final {
+			if err := s.db.FailDataExport(ctx, exportID, "Failed to "+stage); err != nil {
+				messages.Log(messages.ExportMarkFailedFailed, exportID, err)
+			}
+		}
+		return fmt.Errorf("failed to %s: %w", stage, err)
+	}
+
+	data, err := s.db.CollectUserData(ctx, userID, includeVault)
+	if err != nil {
+		return fail("collect data", err)
+	}
+
+	archive, err := buildExportArchive(userID, data)
+	if err != nil {
+		return fail("build archive", err)
+	}
+	size := int64(archive.Len())
+
+	key := fmt.Sprintf("exports/%s/%s.zip", userID, exportID)
+	if err := s.storage.Put(ctx, key, archive, "application/zip"); err != nil {
+		return fail("store archive", err)
+	}
+
+	expiresAt := time.Now().Add(getEnvDuration("DATA_EXPORT_RETENTION", 7*24*time.Hour))
+	if err := s.db.CompleteDataExport(ctx, exportID, key, size, expiresAt); err != nil {
+		return fail("mark export ready", err)
+	}
+	return nil
+}
+
+// POST /api/v1/users/me/export
+// Vaulted progress photos are left out unless the body sets includeVault, which needs the
+// photo vault to be unlocked.
+func (s *FiberServer) requestDataExport(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	var req database.CreateDataExportRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return errorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if req.IncludeVault {
+		if _, err := s.unlockedVaultKey(ctx, userID); err != nil {
+			return errorResponse(c, fiber.StatusLocked, "Photo vault is locked, unlock it to include vaulted photos")
+		}
+	}
+
+	// Only one export runs per user at a time
+	if pending, err := s.db.GetPendingDataExport(ctx, userID); err == nil {
+		return c.Status(fiber.StatusAccepted).JSON(fiber.Map{"data": s.dataExportToResponse(ctx, pending, myExportDownloadPath(pending.Id))})
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		LogDatabaseError(s, "get_pending_data_export", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to request export")
+	}
+
+	export, err := s.db.CreateDataExport(ctx, userID, req.IncludeVault)
+	if err != nil {
+		LogDatabaseError(s, "create_data_export", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to request export")
+	}
+
+	if err := s.enqueueDataExport(ctx, export); err != nil {
+		s.logError("ERROR", messages.ExportQueueFailed, err, c, map[string]interface{}{
+			"component": "jobs",
+			"export_id": export.Id,
+		})
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to request export")
+	}
+
+	c.Location("/api/v1/users/me/exports/" + export.Id)
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{"data": s.dataExportToResponse(ctx, export, myExportDownloadPath(export.Id))})
+}
+
+// GET /api/v1/users/me/exports/:exportId
+func (s *FiberServer) getDataExport(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	export, err := s.db.GetDataExport(ctx, c.Params("exportId"), userID)
+	if err != nil {
+		return notFound(c, "Export")
+	}
+
+	return successResponse(c, s.dataExportToResponse(ctx, export, myExportDownloadPath(export.Id)))
+}
+
+// GET /api/v1/users/me/exports/:exportId/download
+func (s *FiberServer) downloadDataExport(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	export, err := s.db.GetDataExport(ctx, c.Params("exportId"), userID)
+	if err != nil {
+		return notFound(c, "Export")
+	}
+	return s.sendDataExport(ctx, c, export)
+}
+
+// sendDataExport streams a ready, unexpired export archive as a ZIP attachment
+func (s *FiberServer) sendDataExport(ctx context.Context, c *fiber.Ctx, export *database.Data_exports) error {
+	if export.Status != "ready" {
+		return errorResponse(c, fiber.StatusConflict, "Export is not ready")
+	}
+	if export.Expires_at == nil || time.Now().After(*export.Expires_at) {
+		return errorResponse(c, fiber.StatusGone, "Export has expired, please request a new one")
+	}
+
+	body, err := s.storage.Get(ctx, export.Storage_key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return errorResponse(c, fiber.StatusGone, "Export has expired, please request a new one")
+		}
+		LogError(s, "ERROR", messages.ExportReadFailed, err, c, nil)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to download export")
+	}
+	defer body.Close()
+
+	archive, err := io.ReadAll(body)
+	if err != nil {
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to download export")
+	}
+
+	c.Set(fiber.HeaderContentType, "application/zip")
+	c.Attachment(fmt.Sprintf("fitnesshack-export-%s.zip", export.Created_at.Format("2006-01-02")))
+	return c.Send(archive)
+}
@@ -0,0 +1,99 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"fitness-hack/internal/database"
+	"fitness-hack/internal/importer/activityfile"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// maxImportFiles bounds how many activity files one upload may contain
+const maxImportFiles = 20
+
+// POST /api/v1/import/files
+// Accepts one or more FIT, TCX or GPX files in the multipart "file" field. Files are
+// imported independently, so one unreadable file doesn't fail the others.
+func (s *FiberServer) importActivityFiles(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	form, err := c.MultipartForm()
+	if err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, "Request must be multipart/form-data with one or more files in the \"file\" field")
+	}
+	files := form.File["file"]
+	if len(files) == 0 {
+		return errorResponse(c, fiber.StatusBadRequest, "Request must be multipart/form-data with one or more files in the \"file\" field")
+	}
+	if len(files) > maxImportFiles {
+		return errorResponse(c, fiber.StatusBadRequest, fmt.Sprintf("Too many files, upload at most %d at a time", maxImportFiles))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	matchWindow := getEnvDuration("HEALTH_IMPORT_MATCH_WINDOW", 5*time.Minute)
+	exerciseIDs := make(map[string]string)
+	response := database.FileImportResponse{Files: make([]database.FileImportResult, 0, len(files))}
+
+	// Files imported before a failure stay imported, so invalidate on every exit
+	defer func() {
+		if response.SessionsImported > 0 {
+			s.cache.Del(ctx, "workout_sessions:list:*")
+		}
+	}()
+
+	for _, header := range files {
+		result := database.FileImportResult{Filename: header.Filename}
+
+		file, err := header.Open()
+		if err != nil {
+			result.Error = "Failed to read file"
+			response.Files = append(response.Files, result)
+			continue
+		}
+		body, err := io.ReadAll(file)
+		file.Close()
+		if err != nil {
+			result.Error = "Failed to read file"
+			response.Files = append(response.Files, result)
+			continue
+		}
+
+		data, err := activityfile.Parse(header.Filename, body)
+		if err != nil {
+			LogValidationError(s, "file", err, c)
+			result.Error = err.Error()
+			response.Files = append(response.Files, result)
+			continue
+		}
+		result.Format = data.Source
+		result.Invalid = data.Skipped
+
+		sessions, err := s.importedSessions(ctx, data.Source, data.Workouts, exerciseIDs)
+		if err != nil {
+			LogDatabaseError(s, "find_or_create_exercise", err, c)
+			return errorResponse(c, fiber.StatusInternalServerError, "Failed to import files")
+		}
+		imported, err := s.db.ImportHealthRecords(ctx, userID, sessions, nil, matchWindow)
+		if err != nil {
+			LogDatabaseError(s, "import_health_records", err, c)
+			return errorResponse(c, fiber.StatusInternalServerError, "Failed to import files")
+		}
+
+		result.SessionsImported = imported.SessionsImported
+		result.SessionsSkipped = imported.SessionsSkipped
+		response.SessionsImported += imported.SessionsImported
+		response.SessionsSkipped += imported.SessionsSkipped
+		response.Files = append(response.Files, result)
+	}
+
+	return successResponse(c, response)
+}
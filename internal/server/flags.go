@@ -0,0 +1,35 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// featureFlagKey is the Redis key holding the JSON value of a runtime feature flag
+func featureFlagKey(name string) string {
+	return "feature_flags:" + name
+}
+
+// loadFeatureFlag decodes the named flag from Redis into dest. Flags can be changed at
+// runtime (e.g. `redis-cli SET feature_flags:<name> '<json>'`) without a redeploy.
+// It reports false when the flag is unset so callers can fall back to env defaults.
+func (s *FiberServer) loadFeatureFlag(ctx context.Context, name string, dest interface{}) (bool, error) {
+	if s.cache == nil {
+		return false, nil
+	}
+
+	raw, err := s.GetCache(ctx, featureFlagKey(name))
+	if errors.Is(err, redis.Nil) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if err := json.Unmarshal([]byte(raw), dest); err != nil {
+		return false, err
+	}
+	return true, nil
+}
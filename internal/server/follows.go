@@ -0,0 +1,276 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"fitness-hack/internal/database"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// Helper to convert a database follow to response model
+func followToResponse(user *database.FollowedUser) database.FollowResponse {
+	return database.FollowResponse{UserID: user.User_id, Username: user.Username, FollowedAt: user.Followed_at}
+}
+
+// Helper to convert database privacy settings to response model
+func privacySettingsToResponse(settings *database.Privacy_settings) database.PrivacySettingsResponse {
+	return database.PrivacySettingsResponse{
+		AllowFollowers:       settings.Allow_followers,
+		ShareSessions:        settings.Share_sessions,
+		SharePersonalRecords: settings.Share_personal_records,
+		ShowOnLeaderboards:   settings.Show_on_leaderboards,
+	}
+}
+
+// Helper to convert a database feed item to response model
+func feedItemToResponse(item *database.FeedItem) database.FeedItemResponse {
+	response := database.FeedItemResponse{
+		ID:         item.Item_id,
+		Kind:       item.Kind,
+		OccurredAt: item.Occurred_at,
+		UserID:     item.User_id,
+		Username:   item.Username,
+	}
+	if item.Session_id != nil {
+		response.Session = &database.FeedSessionResponse{ID: *item.Session_id}
+		if item.Session_name != nil {
+			response.Session.Name = *item.Session_name
+		}
+		if item.Duration_minutes != nil {
+			response.Session.DurationMinutes = *item.Duration_minutes
+		}
+	}
+	if item.Exercise_id != nil {
+		record := &database.FeedPersonalRecordResponse{ExerciseID: *item.Exercise_id}
+		if item.Exercise_name != nil {
+			record.ExerciseName = *item.Exercise_name
+		}
+		if item.Weight_kg != nil {
+			record.WeightKg = item.Weight_kg.InexactFloat64()
+		}
+		if item.Reps != nil {
+			record.Reps = *item.Reps
+		}
+		if item.Previous_best_kg != nil {
+			record.PreviousBestKg = item.Previous_best_kg.InexactFloat64()
+		}
+		response.PersonalRecord = record
+	}
+	return response
+}
+
+// POST /api/v1/users/:id/follow
+func (s *FiberServer) followUser(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+	followeeID := c.Params("id")
+	if _, err := uuid.Parse(followeeID); err != nil {
+		return notFound(c, "User")
+	}
+	if followeeID == userID {
+		return errorResponse(c, fiber.StatusBadRequest, "You can't follow yourself")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	followee, err := s.db.GetUserByID(ctx, followeeID)
+	if err != nil {
+		return notFound(c, "User")
+	}
+	settings, err := s.db.GetPrivacySettings(ctx, followeeID)
+	if err != nil {
+		LogDatabaseError(s, "get_privacy_settings", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to follow user")
+	}
+	if !settings.Allow_followers {
+		return errorResponse(c, fiber.StatusForbidden, "This user doesn't accept followers")
+	}
+
+	follow, err := s.db.FollowUser(ctx, userID, followeeID)
+	if err != nil {
+		LogDatabaseError(s, "follow_user", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to follow user")
+	}
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"data": followToResponse(&database.FollowedUser{
+		User_id:     followee.Id,
+		Username:    followee.Username,
+		Followed_at: follow.Created_at,
+	})})
+}
+
+// DELETE /api/v1/users/:id/follow
+func (s *FiberServer) unfollowUser(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+	if _, err := uuid.Parse(c.Params("id")); err != nil {
+		return notFound(c, "Follow")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := s.db.UnfollowUser(ctx, userID, c.Params("id")); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return notFound(c, "Follow")
+		}
+		LogDatabaseError(s, "unfollow_user", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to unfollow user")
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// DELETE /api/v1/users/me/followers/:followerId
+// Stops a follower following the caller
+func (s *FiberServer) removeFollower(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+	if _, err := uuid.Parse(c.Params("followerId")); err != nil {
+		return notFound(c, "Follower")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := s.db.UnfollowUser(ctx, c.Params("followerId"), userID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return notFound(c, "Follower")
+		}
+		LogDatabaseError(s, "unfollow_user", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to remove follower")
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// GET /api/v1/users/me/following
+func (s *FiberServer) listFollowing(c *fiber.Ctx) error {
+	return s.listFollows(c, "list_following", s.db.ListFollowing)
+}
+
+// GET /api/v1/users/me/followers
+func (s *FiberServer) listFollowers(c *fiber.Ctx) error {
+	return s.listFollows(c, "list_followers", s.db.ListFollowers)
+}
+
+// listFollows answers with the caller's side of their follows as listed by list
+func (s *FiberServer) listFollows(c *fiber.Ctx, operation string, list func(context.Context, string) ([]database.FollowedUser, error)) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	users, err := list(ctx, userID)
+	if err != nil {
+		LogDatabaseError(s, operation, err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to list follows")
+	}
+	response := make([]database.FollowResponse, len(users))
+	for i := range users {
+		response[i] = followToResponse(&users[i])
+	}
+	return successResponse(c, response)
+}
+
+// GET /api/v1/users/me/privacy
+func (s *FiberServer) getPrivacySettings(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	settings, err := s.db.GetPrivacySettings(ctx, userID)
+	if err != nil {
+		LogDatabaseError(s, "get_privacy_settings", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to get privacy settings")
+	}
+	return successResponse(c, privacySettingsToResponse(settings))
+}
+
+// PUT /api/v1/users/me/privacy
+func (s *FiberServer) updatePrivacySettings(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	var req database.UpdatePrivacySettingsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	settings, err := s.db.GetPrivacySettings(ctx, userID)
+	if err != nil {
+		LogDatabaseError(s, "get_privacy_settings", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to update privacy settings")
+	}
+	if req.AllowFollowers != nil {
+		settings.Allow_followers = *req.AllowFollowers
+	}
+	if req.ShareSessions != nil {
+		settings.Share_sessions = *req.ShareSessions
+	}
+	if req.SharePersonalRecords != nil {
+		settings.Share_personal_records = *req.SharePersonalRecords
+	}
+	if req.ShowOnLeaderboards != nil {
+		settings.Show_on_leaderboards = *req.ShowOnLeaderboards
+	}
+
+	updated, err := s.db.UpdatePrivacySettings(ctx, settings)
+	if err != nil {
+		LogDatabaseError(s, "update_privacy_settings", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to update privacy settings")
+	}
+	return successResponse(c, privacySettingsToResponse(updated))
+}
+
+// GET /api/v1/feed
+// Pages back through the feed with ?before= set to the occurredAt of the last item
+func (s *FiberServer) getFeed(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	before := time.Now()
+	if value := c.Query("before"); value != "" {
+		if before, err = time.Parse(time.RFC3339, value); err != nil {
+			return errorResponse(c, fiber.StatusBadRequest, "before must be an RFC 3339 timestamp")
+		}
+	}
+	limit, _ := getPaginationParams(c)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	items, err := s.db.ListFeed(ctx, userID, before, limit)
+	if err != nil {
+		LogDatabaseError(s, "list_feed", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to get feed")
+	}
+	response := make([]database.FeedItemResponse, len(items))
+	for i := range items {
+		response[i] = feedItemToResponse(&items[i])
+	}
+	return successResponse(c, response)
+}
@@ -0,0 +1,74 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"fitness-hack/internal/database"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestFollowUser(t *testing.T) {
+	s, db := newFakeServer(t)
+	ctx := context.Background()
+	public, err := db.CreateUser(ctx, &database.Users{Email: "ana@example.com", Username: "ana"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	private, err := db.CreateUser(ctx, &database.Users{Email: "bo@example.com", Username: "bo"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.UpdatePrivacySettings(ctx, &database.Privacy_settings{User_id: private.Id, Share_sessions: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	follow := func(followerID, followeeID string) (int, database.FollowResponse) {
+		t.Helper()
+		req := httptest.NewRequest("POST", "/api/v1/users/"+followeeID+"/follow", nil)
+		req.Header.Set("Authorization", bearer(t, followerID))
+		resp, err := s.App.Test(req, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var body struct {
+			Data database.FollowResponse
+		}
+		json.NewDecoder(resp.Body).Decode(&body)
+		return resp.StatusCode, body.Data
+	}
+
+	status, followed := follow(private.Id, public.Id)
+	following, _ := db.ListFollowing(ctx, private.Id)
+	if status != 201 || followed.Username != "ana" || len(following) != 1 || following[0].User_id != public.Id {
+		t.Errorf("expected bo to follow ana, got %d %+v", status, followed)
+	}
+	if status, _ := follow(public.Id, private.Id); status != 403 {
+		t.Errorf("expected users who don't accept followers to get 403, got %d", status)
+	}
+	if status, _ := follow(public.Id, public.Id); status != 400 {
+		t.Errorf("expected following yourself to get 400, got %d", status)
+	}
+	if status, _ := follow(public.Id, "8c1d7e2a-4b3f-4e6a-9d5c-000000000000"); status != 404 {
+		t.Errorf("expected an unknown user to get 404, got %d", status)
+	}
+}
+
+func TestFeedItemToResponse(t *testing.T) {
+	exerciseID, name, reps := "exercise-1", "Back squat", 5
+	weight, best := decimal.NewFromFloat(142.5), decimal.NewFromInt(140)
+	item := database.FeedItem{
+		Kind: "personal_record", Item_id: "we-1", User_id: "u2", Username: "ana",
+		Exercise_id: &exerciseID, Exercise_name: &name, Weight_kg: &weight, Reps: &reps, Previous_best_kg: &best,
+	}
+	got := feedItemToResponse(&item)
+	if got.Session != nil || got.PersonalRecord == nil {
+		t.Fatalf("expected only a personal record, got %+v", got)
+	}
+	if record := got.PersonalRecord; record.WeightKg != 142.5 || record.PreviousBestKg != 140 || record.ExerciseName != "Back squat" {
+		t.Errorf("unexpected personal record %+v", record)
+	}
+}
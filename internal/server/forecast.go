@@ -0,0 +1,286 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"fitness-hack/internal/database"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	// forecastHistoryDays is how far back the estimates a trend is fitted over go
+	forecastHistoryDays = 365
+
+	// A trend needs estimates from minForecastDays days spread over at least
+	// minForecastSpanDays, or a single good week would be projected for months
+	minForecastDays     = 5
+	minForecastSpanDays = 14
+
+	defaultForecastWeeks = 12
+	maxForecastWeeks     = 52
+
+	// maxMilestoneDays is how far ahead milestones are looked for
+	maxMilestoneDays = 730
+
+	maxForecastTargets = 5
+
+	// forecastConfidence is the chance a projected one-rep max falls in its range, and
+	// forecastZ the matching quantile of the normal distribution
+	forecastConfidence = 0.8
+	forecastZ          = 1.2816
+
+	// milestoneStepKg is what default milestones are rounded to
+	milestoneStepKg = 5.0
+)
+
+// trendFit is a straight line fitted by least squares through one-rep max estimates, with x
+// counted in days from origin
+type trendFit struct {
+	origin           time.Time
+	intercept, slope float64
+	n                int
+	meanX, sxx       float64
+	residualSE       float64
+}
+
+// fitTrend fits a trend through points, which must cover at least three different days
+func fitTrend(points []database.ForecastPointResponse) trendFit {
+	fit := trendFit{n: len(points)}
+	fit.origin, _ = time.Parse("2006-01-02", points[0].Date)
+	xs := make([]float64, len(points))
+	var meanY float64
+	for i, p := range points {
+		day, _ := time.Parse("2006-01-02", p.Date)
+		xs[i] = day.Sub(fit.origin).Hours() / 24
+		fit.meanX += xs[i]
+		meanY += p.EstimatedOneRepMaxKg
+	}
+	fit.meanX /= float64(fit.n)
+	meanY /= float64(fit.n)
+
+	var sxy float64
+	for i, p := range points {
+		fit.sxx += (xs[i] - fit.meanX) * (xs[i] - fit.meanX)
+		sxy += (xs[i] - fit.meanX) * (p.EstimatedOneRepMaxKg - meanY)
+	}
+	fit.slope = sxy / fit.sxx
+	fit.intercept = meanY - fit.slope*fit.meanX
+
+	var sse float64
+	for i, p := range points {
+		residual := p.EstimatedOneRepMaxKg - fit.at(xs[i])
+		sse += residual * residual
+	}
+	fit.residualSE = math.Sqrt(sse / float64(fit.n-2))
+	return fit
+}
+
+// at returns the trend x days from the origin
+func (f trendFit) at(x float64) float64 {
+	return f.intercept + f.slope*x
+}
+
+// project returns the trend on day, with the range a one-rep max estimated that day falls in
+// with forecastConfidence. The range widens the further day is from the estimates.
+func (f trendFit) project(day time.Time) (estimate, low, high float64) {
+	x := day.Sub(f.origin).Hours() / 24
+	estimate = f.at(x)
+	margin := studentT(forecastZ, f.n-2) * f.residualSE *
+		math.Sqrt(1+1/float64(f.n)+(x-f.meanX)*(x-f.meanX)/f.sxx)
+	return estimate, estimate - margin, estimate + margin
+}
+
+// studentT approximates the quantile of Student's t distribution with df degrees of freedom
+// matching the normal quantile z, by the Cornish-Fisher expansion. It is within 2% from
+// three degrees of freedom on, which is all a trend is fitted with.
+func studentT(z float64, df int) float64 {
+	v := float64(df)
+	z3, z5 := z*z*z, z*z*z*z*z
+	return z + (z3+z)/(4*v) + (5*z5+16*z3+3*z)/(96*v*v)
+}
+
+// dailyBests returns the best estimated one-rep max of each day sets were logged on, oldest
+// first. Days are calendar days in loc.
+func dailyBests(sets []database.Workout_session_sets, loc *time.Location) []database.ForecastPointResponse {
+	best := map[string]decimal.Decimal{}
+	for i := range sets {
+		day := sets[i].Completed_at.In(loc).Format("2006-01-02")
+		estimate := estimateOneRepMax(sets[i].Weight_kg, sets[i].Reps)
+		if previous, ok := best[day]; !ok || estimate.GreaterThan(previous) {
+			best[day] = estimate
+		}
+	}
+
+	points := make([]database.ForecastPointResponse, 0, len(best))
+	for day, estimate := range best {
+		points = append(points, database.ForecastPointResponse{
+			Date:                 day,
+			EstimatedOneRepMaxKg: estimate.Round(2).InexactFloat64(),
+		})
+	}
+	sort.Slice(points, func(i, j int) bool { return points[i].Date < points[j].Date })
+	return points
+}
+
+// forecastSpan reports whether the estimates are spread over at least days days
+func forecastSpan(history []database.ForecastPointResponse, days int) bool {
+	first, _ := time.Parse("2006-01-02", history[0].Date)
+	last, _ := time.Parse("2006-01-02", history[len(history)-1].Date)
+	return !last.Before(first.AddDate(0, 0, days))
+}
+
+// forecastMilestone works out when the trend, and the ends of its range, first reach
+// targetKg after today. A target already lifted reports the first day it was.
+func forecastMilestone(fit trendFit, history []database.ForecastPointResponse, targetKg float64, today time.Time) database.ForecastMilestoneResponse {
+	milestone := database.ForecastMilestoneResponse{TargetKg: targetKg}
+	for _, p := range history {
+		if p.EstimatedOneRepMaxKg >= targetKg {
+			date := p.Date
+			milestone.ReachedOn = &date
+			return milestone
+		}
+	}
+
+	for days := 1; days <= maxMilestoneDays; days++ {
+		day := today.AddDate(0, 0, days)
+		estimate, low, high := fit.project(day)
+		date := day.Format("2006-01-02")
+		if milestone.EarliestDate == nil && high >= targetKg {
+			milestone.EarliestDate = &date
+		}
+		if milestone.ExpectedDate == nil && estimate >= targetKg {
+			milestone.ExpectedDate = &date
+		}
+		if low >= targetKg {
+			milestone.LatestDate = &date
+			break
+		}
+	}
+	return milestone
+}
+
+// forecastTargets parses the targets query parameter. Without targets, the next two
+// multiples of milestoneStepKg above currentKg are used.
+func forecastTargets(value string, currentKg float64) ([]float64, error) {
+	if value == "" {
+		next := math.Floor(currentKg/milestoneStepKg)*milestoneStepKg + milestoneStepKg
+		return []float64{next, next + milestoneStepKg}, nil
+	}
+	parts := strings.Split(value, ",")
+	if len(parts) > maxForecastTargets {
+		return nil, fmt.Errorf("At most %d targets can be given", maxForecastTargets)
+	}
+	targets := make([]float64, len(parts))
+	for i, part := range parts {
+		target, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil || target <= 0 || target >= 10000 {
+			return nil, errors.New("targets must be weights in kg, greater than 0 and less than 10000")
+		}
+		targets[i] = target
+	}
+	return targets, nil
+}
+
+// roundKg rounds a projected weight to the 0.01 kg weights are stored with
+func roundKg(kg float64) float64 {
+	return math.Round(kg*100) / 100
+}
+
+// GET /api/v1/analytics/forecast?metric=squat_1rm&weeks=12&targets=140,150&tz=Europe/Berlin
+// Fits a linear trend over the best estimated one-rep max of each day in the last year, for
+// the exercise the training max named by metric is linked to, and projects it forward.
+func (s *FiberServer) getForecast(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	metric := c.Query("metric")
+	if !trainingMaxNamePattern.MatchString(metric) {
+		return errorResponse(c, fiber.StatusBadRequest, "metric must name a training max, such as squat_1rm")
+	}
+	weeks, err := strconv.Atoi(c.Query("weeks", strconv.Itoa(defaultForecastWeeks)))
+	if err != nil || weeks < 1 || weeks > maxForecastWeeks {
+		return errorResponse(c, fiber.StatusBadRequest, fmt.Sprintf("weeks must be between 1 and %d", maxForecastWeeks))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tm, err := s.db.GetTrainingMax(ctx, userID, metric)
+	if errors.Is(err, sql.ErrNoRows) {
+		return notFound(c, "Training max")
+	}
+	if err != nil {
+		LogDatabaseError(s, "get_training_max", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to forecast")
+	}
+	if tm.Exercise_id == nil {
+		return errorResponse(c, fiber.StatusUnprocessableEntity, "Link the training max to an exercise to forecast it")
+	}
+
+	tz, err := s.userTimezone(ctx, c, userID)
+	if err != nil {
+		LogDatabaseError(s, "get_user", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to forecast")
+	}
+	local, _, err := summaryDay("", tz, time.Now())
+	if err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+	// The trend is fitted over calendar dates, so today is the local date at midnight UTC
+	today := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+	sets, err := s.db.ListExerciseSets(ctx, userID, *tm.Exercise_id, local.AddDate(0, 0, -forecastHistoryDays))
+	if err != nil {
+		LogDatabaseError(s, "list_exercise_sets", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to forecast")
+	}
+	history := dailyBests(sets, local.Location())
+	if len(history) < minForecastDays || !forecastSpan(history, minForecastSpanDays) {
+		return errorResponse(c, fiber.StatusUnprocessableEntity, fmt.Sprintf(
+			"Not enough history to forecast: log sets on at least %d days over %d days or more", minForecastDays, minForecastSpanDays))
+	}
+
+	fit := fitTrend(history)
+	current, _, _ := fit.project(today)
+	targets, err := forecastTargets(c.Query("targets"), current)
+	if err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	response := database.ForecastResponse{
+		Metric:          metric,
+		ExerciseID:      *tm.Exercise_id,
+		Model:           "linear",
+		ConfidenceLevel: forecastConfidence,
+		CurrentKg:       roundKg(current),
+		KgPerWeek:       roundKg(fit.slope * 7),
+		History:         history,
+		Projection:      make([]database.ForecastProjectionResponse, weeks),
+		Milestones:      make([]database.ForecastMilestoneResponse, len(targets)),
+	}
+	for i := range response.Projection {
+		day := today.AddDate(0, 0, 7*(i+1))
+		estimate, low, high := fit.project(day)
+		response.Projection[i] = database.ForecastProjectionResponse{
+			Date:       day.Format("2006-01-02"),
+			EstimateKg: roundKg(estimate),
+			LowKg:      roundKg(math.Max(low, 0)),
+			HighKg:     roundKg(high),
+		}
+	}
+	for i, target := range targets {
+		response.Milestones[i] = forecastMilestone(fit, history, target, today)
+	}
+	return successResponse(c, response)
+}
@@ -0,0 +1,93 @@
+package server
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"fitness-hack/internal/database"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestDailyBests(t *testing.T) {
+	day := time.Date(2024, 3, 1, 18, 0, 0, 0, time.UTC)
+	sets := []database.Workout_session_sets{
+		{Weight_kg: decimal.NewFromInt(100), Reps: 5, Completed_at: day},
+		{Weight_kg: decimal.NewFromInt(110), Reps: 1, Completed_at: day.Add(10 * time.Minute)},
+		{Weight_kg: decimal.NewFromInt(90), Reps: 3, Completed_at: day.AddDate(0, 0, -2)},
+	}
+
+	bests := dailyBests(sets, time.UTC)
+	if len(bests) != 2 || bests[0].Date != "2024-02-28" || bests[1].Date != "2024-03-01" {
+		t.Fatalf("expected a best for each day oldest first, got %+v", bests)
+	}
+	// 100 kg for 5 estimates 116.67 kg, beating the 110 kg single
+	if bests[1].EstimatedOneRepMaxKg != 116.67 || bests[0].EstimatedOneRepMaxKg != 99 {
+		t.Errorf("unexpected estimates %+v", bests)
+	}
+
+	// 18:00 UTC is already the next morning in Auckland
+	auckland, err := time.LoadLocation("Pacific/Auckland")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bests := dailyBests(sets, auckland); len(bests) != 2 || bests[0].Date != "2024-02-29" || bests[1].Date != "2024-03-02" {
+		t.Errorf("expected the days in Auckland, got %+v", bests)
+	}
+}
+
+func TestForecastTrend(t *testing.T) {
+	// 100 kg gaining 1 kg a week, give or take half a kilo
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var history []database.ForecastPointResponse
+	for week := 0; week < 10; week++ {
+		noise := 0.5
+		if week%2 == 1 {
+			noise = -0.5
+		}
+		history = append(history, database.ForecastPointResponse{
+			Date:                 start.AddDate(0, 0, 7*week).Format("2006-01-02"),
+			EstimatedOneRepMaxKg: 100 + float64(week) + noise,
+		})
+	}
+
+	fit := fitTrend(history)
+	if math.Abs(fit.slope*7-1) > 0.05 {
+		t.Errorf("expected about 1 kg a week, got %v", fit.slope*7)
+	}
+	today := start.AddDate(0, 0, 63)
+	estimate, low, high := fit.project(today.AddDate(0, 0, 70))
+	if math.Abs(estimate-119) > 0.5 || low >= estimate || high <= estimate {
+		t.Errorf("expected about 119 kg in 10 weeks with a range around it, got %v (%v-%v)", estimate, low, high)
+	}
+	_, nearLow, nearHigh := fit.project(today.AddDate(0, 0, 7))
+	if high-low <= nearHigh-nearLow {
+		t.Error("expected the range to widen further out")
+	}
+
+	milestone := forecastMilestone(fit, history, 120, today)
+	if milestone.ReachedOn != nil || milestone.ExpectedDate == nil || milestone.EarliestDate == nil || milestone.LatestDate == nil {
+		t.Fatalf("expected 120 kg to be projected, got %+v", milestone)
+	}
+	if !(*milestone.EarliestDate < *milestone.ExpectedDate && *milestone.ExpectedDate < *milestone.LatestDate) {
+		t.Errorf("expected earliest < expected < latest, got %+v", milestone)
+	}
+	if reached := forecastMilestone(fit, history, 105, today); reached.ReachedOn == nil || *reached.ReachedOn != "2024-02-12" {
+		t.Errorf("expected 105 kg to be reached on 2024-02-12, got %+v", reached)
+	}
+}
+
+func TestForecastTargets(t *testing.T) {
+	if targets, _ := forecastTargets("", 101.3); len(targets) != 2 || targets[0] != 105 || targets[1] != 110 {
+		t.Errorf("expected the next two multiples of 5 kg, got %v", targets)
+	}
+	if targets, err := forecastTargets("100, 112.5", 90); err != nil || len(targets) != 2 || targets[1] != 112.5 {
+		t.Errorf("expected the targets given, got %v, %v", targets, err)
+	}
+	for _, value := range []string{"heavy", "0", "100,,110", "1,2,3,4,5,6"} {
+		if _, err := forecastTargets(value, 90); err == nil {
+			t.Errorf("expected %q to be rejected", value)
+		}
+	}
+}
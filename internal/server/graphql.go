@@ -0,0 +1,130 @@
+package server
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	graphql "github.com/graph-gophers/graphql-go"
+	gqlerrors "github.com/graph-gophers/graphql-go/errors"
+
+	"fitness-hack/internal/messages"
+)
+
+// graphqlSchema is the schema of /api/v1/graphql. It is parsed at startup by graphql-go and
+// served by the hand-written resolvers in graphql_resolvers.go, rather than generated with
+// gqlgen; see GraphQL API in SERVER_ARCHITECTURE.md.
+//
+//go:embed schema.graphql
+var graphqlSchema string
+
+// graphqlMaxDepth bounds how deeply queries nest, so one request can't fan out without end
+const graphqlMaxDepth = 10
+
+// graphqlRequest is the state the resolvers of one GraphQL request share
+type graphqlRequest struct {
+	userID  string
+	loaders *graphqlLoaders
+}
+
+type graphqlRequestKey struct{}
+
+// graphqlFrom returns the state of the GraphQL request ctx is for
+func graphqlFrom(ctx context.Context) *graphqlRequest {
+	return ctx.Value(graphqlRequestKey{}).(*graphqlRequest)
+}
+
+// graphqlQuery is a GraphQL request, sent as a JSON body or in the query string
+type graphqlQuery struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// graphqlPanics logs the panics of resolvers and reports them as internal errors, without
+// the panic value
+type graphqlPanics struct {
+	s *FiberServer
+}
+
+func (p graphqlPanics) LogPanic(ctx context.Context, value interface{}) {
+	p.s.writeLog(ctx, "ERROR", messages.ServerGraphQLFailed, fmt.Errorf("panic: %v", value), nil, nil)
+}
+
+func (p graphqlPanics) MakePanicError(ctx context.Context, value interface{}) *gqlerrors.QueryError {
+	return &gqlerrors.QueryError{Message: "Internal server error", Extensions: map[string]interface{}{"code": codeInternal}}
+}
+
+// GET, POST /api/v1/graphql
+//
+// graphqlHandler serves the read-only GraphQL API. Queries can be sent with GET, which
+// read-only API keys and impersonation tokens are limited to, or as a JSON body with POST.
+// Errors are reported in the response's errors with the code the REST API would respond with
+// in their extensions.
+func (s *FiberServer) graphqlHandler() fiber.Handler {
+	panics := graphqlPanics{s: s}
+	schema := graphql.MustParseSchema(graphqlSchema, &graphqlResolver{s: s},
+		graphql.MaxDepth(graphqlMaxDepth), graphql.Logger(panics), graphql.PanicHandler(panics))
+
+	return func(c *fiber.Ctx) error {
+		userID, ok := c.Locals("user_id").(string)
+		if !ok || userID == "" {
+			return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+		}
+
+		var query graphqlQuery
+		if c.Method() == fiber.MethodPost {
+			if err := c.BodyParser(&query); err != nil {
+				return errorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+			}
+		} else {
+			query.Query = c.Query("query")
+			query.OperationName = c.Query("operationName")
+			if variables := c.Query("variables"); variables != "" {
+				if err := json.Unmarshal([]byte(variables), &query.Variables); err != nil {
+					return errorResponse(c, fiber.StatusBadRequest, "Invalid variables")
+				}
+			}
+		}
+		if query.Query == "" {
+			return errorResponse(c, fiber.StatusBadRequest, "Query is required")
+		}
+
+		ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+		defer cancel()
+		ctx = context.WithValue(ctx, graphqlRequestKey{}, &graphqlRequest{userID: userID, loaders: s.newGraphQLLoaders()})
+
+		response := schema.Exec(ctx, query.Query, query.OperationName, query.Variables)
+		for _, queryErr := range response.Errors {
+			s.reportGraphQLError(c, queryErr)
+		}
+		return c.JSON(response)
+	}
+}
+
+// reportGraphQLError gives a query error the message and code the REST API would respond
+// with, logging server errors. Errors in the query itself are validation failures.
+func (s *FiberServer) reportGraphQLError(c *fiber.Ctx, queryErr *gqlerrors.QueryError) {
+	if queryErr.Extensions != nil {
+		return
+	}
+	if queryErr.ResolverError == nil {
+		queryErr.Extensions = map[string]interface{}{"code": codeValidationFailed}
+		return
+	}
+
+	apiErr := toAPIError(queryErr.ResolverError)
+	if apiErr.Status >= fiber.StatusInternalServerError {
+		s.logError("ERROR", messages.ServerGraphQLFailed, queryErr.ResolverError, c, map[string]interface{}{
+			"path": fmt.Sprint(queryErr.Path),
+		})
+	}
+	queryErr.Message = apiErr.Message
+	queryErr.Extensions = map[string]interface{}{"code": apiErr.Code}
+	if id := getRequestID(c); id != "" {
+		queryErr.Extensions["requestId"] = id
+	}
+}
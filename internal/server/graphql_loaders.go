@@ -0,0 +1,111 @@
+package server
+
+import (
+	"context"
+
+	"fitness-hack/internal/database"
+
+	"github.com/graph-gophers/dataloader"
+)
+
+// batchLoader loads values by key for one GraphQL request. It's a dataloader, which
+// collects the keys asked for within a few milliseconds into one fetch and caches what it
+// fetched. graphql-go only resolves a few fields at once, so resolvers also queue the keys of a
+// list's items before the items' fields are resolved; that way a list costs one query however
+// long it is.
+type batchLoader[V any] struct {
+	loader *dataloader.Loader
+}
+
+func newBatchLoader[V any](fetch func(ctx context.Context, keys []string) (map[string]V, error)) *batchLoader[V] {
+	batch := func(ctx context.Context, keys dataloader.Keys) []*dataloader.Result {
+		results := make([]*dataloader.Result, len(keys))
+		values, err := fetch(ctx, keys.Keys())
+		for i, key := range keys {
+			results[i] = &dataloader.Result{Error: err}
+			if value, ok := values[key.String()]; ok && err == nil {
+				results[i].Data = value
+			}
+		}
+		return results
+	}
+	return &batchLoader[V]{loader: dataloader.NewBatchedLoader(batch)}
+}
+
+// Queue adds keys to the next fetch
+func (l *batchLoader[V]) Queue(ctx context.Context, keys ...string) {
+	l.loader.LoadMany(ctx, dataloader.NewKeysFromStrings(keys))
+}
+
+// Load returns the value of key, fetching it along with the queued keys. ok is false when
+// there is no value for key.
+func (l *batchLoader[V]) Load(ctx context.Context, key string) (value V, ok bool, err error) {
+	data, err := l.loader.Load(ctx, dataloader.StringKey(key))()
+	if err != nil {
+		return value, false, err
+	}
+	value, ok = data.(V)
+	return value, ok, nil
+}
+
+// graphqlLoaders are the batch loaders of one GraphQL request. Each fetch queues the keys the
+// fields below it will load, so a workout list, its exercises and their muscles take a query
+// each.
+type graphqlLoaders struct {
+	workouts         *batchLoader[database.Workouts]
+	workoutExercises *batchLoader[[]database.Workout_exercises]
+	exercises        *batchLoader[database.Exercises]
+	muscles          *batchLoader[[]database.ExerciseMuscle]
+}
+
+func (s *FiberServer) newGraphQLLoaders() *graphqlLoaders {
+	l := &graphqlLoaders{}
+	l.workouts = newBatchLoader(func(ctx context.Context, ids []string) (map[string]database.Workouts, error) {
+		workouts, err := s.db.GetWorkoutsByIDs(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		byID := make(map[string]database.Workouts, len(workouts))
+		for _, workout := range workouts {
+			byID[workout.Id] = workout
+			l.workoutExercises.Queue(ctx, workout.Id)
+		}
+		return byID, nil
+	})
+	l.workoutExercises = newBatchLoader(func(ctx context.Context, workoutIDs []string) (map[string][]database.Workout_exercises, error) {
+		rows, err := s.db.ListWorkoutExercisesOf(ctx, workoutIDs)
+		if err != nil {
+			return nil, err
+		}
+		byWorkout := make(map[string][]database.Workout_exercises, len(workoutIDs))
+		for _, row := range rows {
+			byWorkout[row.Workout_id] = append(byWorkout[row.Workout_id], row)
+			l.exercises.Queue(ctx, row.Exercise_id)
+		}
+		return byWorkout, nil
+	})
+	l.exercises = newBatchLoader(func(ctx context.Context, ids []string) (map[string]database.Exercises, error) {
+		exercises, err := s.db.GetExercisesByIDs(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		byID := make(map[string]database.Exercises, len(exercises))
+		for _, exercise := range exercises {
+			byID[exercise.Id] = exercise
+			l.muscles.Queue(ctx, exercise.Id)
+		}
+		return byID, nil
+	})
+	l.muscles = newBatchLoader(func(ctx context.Context, exerciseIDs []string) (map[string][]database.ExerciseMuscle, error) {
+		muscles, err := s.db.ListExerciseMuscles(ctx, exerciseIDs)
+		if err != nil {
+			return nil, err
+		}
+		byExercise := make(map[string][]database.ExerciseMuscle, len(exerciseIDs))
+		for _, m := range muscles {
+			byExercise[m.Exercise_id] = append(byExercise[m.Exercise_id], m)
+		}
+		return byExercise, nil
+	})
+	return l
+}
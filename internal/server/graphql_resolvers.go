@@ -0,0 +1,346 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	graphql "github.com/graph-gophers/graphql-go"
+
+	"fitness-hack/internal/database"
+)
+
+// graphqlResolver resolves the Query type. Workouts and workout sessions are the caller's
+// own; other users' are null, as they are not found over gRPC.
+type graphqlResolver struct {
+	s *FiberServer
+}
+
+// listArgs are the pagination and sort arguments of every list field
+type listArgs struct {
+	Limit  *int32
+	Offset *int32
+	Sort   *string
+	Order  *string
+}
+
+// listOptions returns the list options of args with the defaults and bounds of grpcPage
+func (args listArgs) listOptions() database.ListOptions {
+	limit, offset := grpcPage(int32Value(args.Limit), int32Value(args.Offset))
+	return database.ListOptions{Limit: limit, Offset: offset, Sort: stringValue(args.Sort), Order: stringValue(args.Order), Filters: map[string]string{}}
+}
+
+func int32Value(n *int32) int32 {
+	if n == nil {
+		return 0
+	}
+	return *n
+}
+
+// listError turns the error of a list query into the error its field reports
+func listError(err error, message string) error {
+	if errors.Is(err, database.ErrInvalidListOption) {
+		return &APIError{Status: fiber.StatusBadRequest, Code: codeValidationFailed, Message: err.Error()}
+	}
+	return serverError(message, err)
+}
+
+// graphqlTime converts t to a GraphQL time in UTC
+func graphqlTime(t time.Time) graphql.Time {
+	return graphql.Time{Time: t.UTC()}
+}
+
+// graphqlTimeOrNull converts t to a GraphQL time, null for the zero time
+func graphqlTimeOrNull(t time.Time) *graphql.Time {
+	if t.IsZero() {
+		return nil
+	}
+	value := graphqlTime(t)
+	return &value
+}
+
+func (r *graphqlResolver) Me(ctx context.Context) (*userResolver, error) {
+	user, err := r.s.db.GetUserByID(ctx, graphqlFrom(ctx).userID)
+	if err != nil {
+		return nil, resourceNotFound("User")
+	}
+	return &userResolver{user: user}, nil
+}
+
+func (r *graphqlResolver) Workout(ctx context.Context, args struct{ ID graphql.ID }) (*workoutResolver, error) {
+	req := graphqlFrom(ctx)
+	workout, ok, err := req.loaders.workouts.Load(ctx, string(args.ID))
+	if err != nil {
+		return nil, serverError("Failed to fetch workout", err)
+	}
+	if !ok || workout.User_id != req.userID {
+		return nil, nil
+	}
+	return &workoutResolver{workout: &workout}, nil
+}
+
+func (r *graphqlResolver) Workouts(ctx context.Context, args struct {
+	listArgs
+	ProgramID   *graphql.ID
+	MuscleGroup *string
+}) ([]*workoutResolver, error) {
+	req := graphqlFrom(ctx)
+	opts := database.ListWorkoutsOpts{
+		ListOptions: args.listOptions(),
+		UserID:      req.userID,
+		MuscleGroup: stringValue(args.MuscleGroup),
+	}
+	if args.ProgramID != nil {
+		opts.Filters["program_id"] = string(*args.ProgramID)
+	}
+
+	workouts, err := r.s.db.ListWorkouts(ctx, opts)
+	if err != nil {
+		return nil, listError(err, "Failed to fetch workouts")
+	}
+	resolvers := make([]*workoutResolver, len(workouts))
+	for i := range workouts {
+		req.loaders.workoutExercises.Queue(ctx, workouts[i].Id)
+		resolvers[i] = &workoutResolver{workout: &workouts[i]}
+	}
+	return resolvers, nil
+}
+
+func (r *graphqlResolver) Exercise(ctx context.Context, args struct{ ID graphql.ID }) (*exerciseResolver, error) {
+	exercise, ok, err := graphqlFrom(ctx).loaders.exercises.Load(ctx, string(args.ID))
+	if err != nil {
+		return nil, serverError("Failed to fetch exercise", err)
+	}
+	if !ok {
+		return nil, nil
+	}
+	return &exerciseResolver{exercise: &exercise}, nil
+}
+
+func (r *graphqlResolver) Exercises(ctx context.Context, args struct {
+	listArgs
+	Muscle          *string
+	Equipment       *string
+	DifficultyLevel *string
+}) ([]*exerciseResolver, error) {
+	opts := database.ListExercisesOpts{
+		ListOptions: args.listOptions(),
+		Muscle:      database.MuscleSlug(stringValue(args.Muscle)),
+	}
+	if args.Equipment != nil {
+		opts.Filters["equipment"] = *args.Equipment
+	}
+	if args.DifficultyLevel != nil {
+		opts.Filters["difficulty_level"] = *args.DifficultyLevel
+	}
+
+	exercises, err := r.s.db.ListExercises(ctx, opts)
+	if err != nil {
+		return nil, listError(err, "Failed to fetch exercises")
+	}
+	loaders := graphqlFrom(ctx).loaders
+	resolvers := make([]*exerciseResolver, len(exercises))
+	for i := range exercises {
+		loaders.muscles.Queue(ctx, exercises[i].Id)
+		resolvers[i] = &exerciseResolver{exercise: &exercises[i]}
+	}
+	return resolvers, nil
+}
+
+func (r *graphqlResolver) WorkoutSession(ctx context.Context, args struct{ ID graphql.ID }) (*workoutSessionResolver, error) {
+	req := graphqlFrom(ctx)
+	session, err := r.s.db.GetWorkoutSessionByID(ctx, string(args.ID))
+	if err != nil || session.User_id != req.userID {
+		return nil, nil
+	}
+	if session.Workout_id != nil {
+		req.loaders.workouts.Queue(ctx, *session.Workout_id)
+	}
+	return &workoutSessionResolver{session: session}, nil
+}
+
+func (r *graphqlResolver) WorkoutSessions(ctx context.Context, args struct {
+	listArgs
+	WorkoutID *graphql.ID
+	From      *graphql.Time
+	To        *graphql.Time
+}) ([]*workoutSessionResolver, error) {
+	req := graphqlFrom(ctx)
+	opts := database.ListWorkoutSessionsOpts{ListOptions: args.listOptions()}
+	opts.Filters["user_id"] = req.userID
+	if args.WorkoutID != nil {
+		opts.Filters["workout_id"] = string(*args.WorkoutID)
+	}
+	if args.From != nil {
+		opts.From = args.From.Time
+	}
+	if args.To != nil {
+		opts.To = args.To.Time
+	}
+
+	sessions, err := r.s.db.ListWorkoutSessions(ctx, opts)
+	if err != nil {
+		return nil, listError(err, "Failed to fetch workout sessions")
+	}
+	resolvers := make([]*workoutSessionResolver, len(sessions))
+	for i := range sessions {
+		if sessions[i].Workout_id != nil {
+			req.loaders.workouts.Queue(ctx, *sessions[i].Workout_id)
+		}
+		resolvers[i] = &workoutSessionResolver{session: &sessions[i]}
+	}
+	return resolvers, nil
+}
+
+type userResolver struct {
+	user *database.Users
+}
+
+func (r *userResolver) ID() graphql.ID          { return graphql.ID(r.user.Id) }
+func (r *userResolver) Email() string           { return r.user.Email }
+func (r *userResolver) Username() string        { return r.user.Username }
+func (r *userResolver) FirstName() *string      { return r.user.First_name }
+func (r *userResolver) LastName() *string       { return r.user.Last_name }
+func (r *userResolver) Timezone() string        { return r.user.Timezone }
+func (r *userResolver) CreatedAt() graphql.Time { return graphqlTime(r.user.Created_at) }
+func (r *userResolver) UpdatedAt() graphql.Time { return graphqlTime(r.user.Updated_at) }
+func (r *userResolver) Version() int32          { return int32(r.user.Version) }
+
+type workoutResolver struct {
+	workout *database.Workouts
+}
+
+func (r *workoutResolver) ID() graphql.ID          { return graphql.ID(r.workout.Id) }
+func (r *workoutResolver) UserID() graphql.ID      { return graphql.ID(r.workout.User_id) }
+func (r *workoutResolver) Name() string            { return r.workout.Name }
+func (r *workoutResolver) Description() string     { return r.workout.Description }
+func (r *workoutResolver) DurationMinutes() int32  { return int32(r.workout.Duration_minutes) }
+func (r *workoutResolver) IsTemplate() bool        { return r.workout.Is_template }
+func (r *workoutResolver) CreatedAt() graphql.Time { return graphqlTime(r.workout.Created_at) }
+func (r *workoutResolver) UpdatedAt() graphql.Time { return graphqlTime(r.workout.Updated_at) }
+func (r *workoutResolver) Version() int32          { return int32(r.workout.Version) }
+
+func (r *workoutResolver) ProgramID() *graphql.ID {
+	if r.workout.Program_id == "" {
+		return nil
+	}
+	id := graphql.ID(r.workout.Program_id)
+	return &id
+}
+
+func (r *workoutResolver) Exercises(ctx context.Context) ([]*workoutExerciseResolver, error) {
+	rows, _, err := graphqlFrom(ctx).loaders.workoutExercises.Load(ctx, r.workout.Id)
+	if err != nil {
+		return nil, serverError("Failed to fetch workout exercises", err)
+	}
+	resolvers := make([]*workoutExerciseResolver, len(rows))
+	for i := range rows {
+		resolvers[i] = &workoutExerciseResolver{we: &rows[i]}
+	}
+	return resolvers, nil
+}
+
+type workoutExerciseResolver struct {
+	we *database.Workout_exercises
+}
+
+func (r *workoutExerciseResolver) ID() graphql.ID          { return graphql.ID(r.we.Id) }
+func (r *workoutExerciseResolver) WorkoutID() graphql.ID   { return graphql.ID(r.we.Workout_id) }
+func (r *workoutExerciseResolver) ExerciseID() graphql.ID  { return graphql.ID(r.we.Exercise_id) }
+func (r *workoutExerciseResolver) Sets() int32             { return int32(r.we.Sets) }
+func (r *workoutExerciseResolver) Reps() int32             { return int32(r.we.Reps) }
+func (r *workoutExerciseResolver) WeightKg() float64       { return r.we.Weight_kg.InexactFloat64() }
+func (r *workoutExerciseResolver) DurationSeconds() int32  { return int32(r.we.Duration_seconds) }
+func (r *workoutExerciseResolver) OrderIndex() int32       { return int32(r.we.Order_index) }
+func (r *workoutExerciseResolver) RestSeconds() int32      { return int32(r.we.Rest_seconds) }
+func (r *workoutExerciseResolver) Notes() string           { return r.we.Notes }
+func (r *workoutExerciseResolver) CreatedAt() graphql.Time { return graphqlTime(r.we.Created_at) }
+func (r *workoutExerciseResolver) UpdatedAt() graphql.Time { return graphqlTime(r.we.Updated_at) }
+func (r *workoutExerciseResolver) Version() int32          { return int32(r.we.Version) }
+
+func (r *workoutExerciseResolver) Exercise(ctx context.Context) (*exerciseResolver, error) {
+	exercise, ok, err := graphqlFrom(ctx).loaders.exercises.Load(ctx, r.we.Exercise_id)
+	if err != nil {
+		return nil, serverError("Failed to fetch exercise", err)
+	}
+	if !ok {
+		return nil, nil
+	}
+	return &exerciseResolver{exercise: &exercise}, nil
+}
+
+type exerciseResolver struct {
+	exercise *database.Exercises
+}
+
+func (r *exerciseResolver) ID() graphql.ID           { return graphql.ID(r.exercise.Id) }
+func (r *exerciseResolver) Name() string             { return r.exercise.Name }
+func (r *exerciseResolver) Description() string      { return r.exercise.Description }
+func (r *exerciseResolver) Equipment() *string       { return r.exercise.Equipment }
+func (r *exerciseResolver) DifficultyLevel() *string { return r.exercise.Difficulty_level }
+func (r *exerciseResolver) Instructions() string     { return r.exercise.Instructions }
+func (r *exerciseResolver) CreatedAt() graphql.Time  { return graphqlTime(r.exercise.Created_at) }
+func (r *exerciseResolver) UpdatedAt() graphql.Time  { return graphqlTime(r.exercise.Updated_at) }
+func (r *exerciseResolver) Version() int32           { return int32(r.exercise.Version) }
+
+func (r *exerciseResolver) Muscles(ctx context.Context) ([]*exerciseMuscleResolver, error) {
+	muscles, _, err := graphqlFrom(ctx).loaders.muscles.Load(ctx, r.exercise.Id)
+	if err != nil {
+		return nil, serverError("Failed to fetch exercise muscles", err)
+	}
+	resolvers := make([]*exerciseMuscleResolver, len(muscles))
+	for i := range muscles {
+		resolvers[i] = &exerciseMuscleResolver{muscle: &muscles[i]}
+	}
+	return resolvers, nil
+}
+
+type exerciseMuscleResolver struct {
+	muscle *database.ExerciseMuscle
+}
+
+func (r *exerciseMuscleResolver) Slug() string { return r.muscle.Slug }
+func (r *exerciseMuscleResolver) Name() string { return r.muscle.Name }
+func (r *exerciseMuscleResolver) Role() string { return string(r.muscle.Role) }
+
+type workoutSessionResolver struct {
+	session *database.Workout_sessions
+}
+
+func (r *workoutSessionResolver) ID() graphql.ID          { return graphql.ID(r.session.Id) }
+func (r *workoutSessionResolver) UserID() graphql.ID      { return graphql.ID(r.session.User_id) }
+func (r *workoutSessionResolver) Name() string            { return r.session.Name }
+func (r *workoutSessionResolver) StartedAt() graphql.Time { return graphqlTime(r.session.Started_at) }
+func (r *workoutSessionResolver) CompletedAt() *graphql.Time {
+	return graphqlTimeOrNull(r.session.Completed_at)
+}
+func (r *workoutSessionResolver) DurationMinutes() int32  { return int32(r.session.Duration_minutes) }
+func (r *workoutSessionResolver) Notes() string           { return r.session.Notes }
+func (r *workoutSessionResolver) Source() string          { return r.session.Source }
+func (r *workoutSessionResolver) CreatedAt() graphql.Time { return graphqlTime(r.session.Created_at) }
+func (r *workoutSessionResolver) UpdatedAt() graphql.Time { return graphqlTime(r.session.Updated_at) }
+func (r *workoutSessionResolver) Version() int32          { return int32(r.session.Version) }
+
+func (r *workoutSessionResolver) WorkoutID() *graphql.ID {
+	if r.session.Workout_id == nil {
+		return nil
+	}
+	id := graphql.ID(*r.session.Workout_id)
+	return &id
+}
+
+func (r *workoutSessionResolver) Workout(ctx context.Context) (*workoutResolver, error) {
+	if r.session.Workout_id == nil {
+		return nil, nil
+	}
+	req := graphqlFrom(ctx)
+	workout, ok, err := req.loaders.workouts.Load(ctx, *r.session.Workout_id)
+	if err != nil {
+		return nil, serverError("Failed to fetch workout", err)
+	}
+	if !ok || workout.User_id != req.userID {
+		return nil, nil
+	}
+	return &workoutResolver{workout: &workout}, nil
+}
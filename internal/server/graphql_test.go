@@ -0,0 +1,178 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+
+	"fitness-hack/internal/database"
+	"fitness-hack/internal/database/dbtest"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// countingDB counts the batch queries the GraphQL loaders make
+type countingDB struct {
+	*dbtest.Fake
+	mu    sync.Mutex
+	calls map[string]int
+}
+
+func (c *countingDB) count(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.calls[name]++
+}
+
+func (c *countingDB) ListWorkoutExercisesOf(ctx context.Context, workoutIDs []string) ([]database.Workout_exercises, error) {
+	c.count("ListWorkoutExercisesOf")
+	return c.Fake.ListWorkoutExercisesOf(ctx, workoutIDs)
+}
+
+func (c *countingDB) GetExercisesByIDs(ctx context.Context, ids []string) ([]database.Exercises, error) {
+	c.count("GetExercisesByIDs")
+	return c.Fake.GetExercisesByIDs(ctx, ids)
+}
+
+func (c *countingDB) ListExerciseMuscles(ctx context.Context, exerciseIDs []string) ([]database.ExerciseMuscle, error) {
+	c.count("ListExerciseMuscles")
+	return c.Fake.ListExerciseMuscles(ctx, exerciseIDs)
+}
+
+// graphqlResult is a GraphQL response with the errors' messages and codes
+type graphqlResult struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message    string                 `json:"message"`
+		Extensions map[string]interface{} `json:"extensions"`
+	} `json:"errors"`
+}
+
+// postGraphQL sends query as userID, or without a token for ""
+func postGraphQL(t *testing.T, s *FiberServer, userID, query string, variables map[string]interface{}) (int, graphqlResult) {
+	t.Helper()
+	body, _ := json.Marshal(graphqlQuery{Query: query, Variables: variables})
+	req := httptest.NewRequest("POST", "/api/v1/graphql", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if userID != "" {
+		req.Header.Set("Authorization", bearer(t, userID))
+	}
+	resp, err := s.App.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var result graphqlResult
+	json.NewDecoder(resp.Body).Decode(&result)
+	return resp.StatusCode, result
+}
+
+func TestGraphQLWorkouts(t *testing.T) {
+	db := &countingDB{Fake: dbtest.NewFake(), calls: map[string]int{}}
+	s := newTestServer(t, db)
+	ctx := context.Background()
+
+	squat, _ := db.CreateExercise(ctx, &database.Exercises{Name: "Squat"})
+	bench, _ := db.CreateExercise(ctx, &database.Exercises{Name: "Bench Press"})
+	if err := db.SetExerciseMuscles(ctx, squat.Id, []database.MuscleAssignment{{Muscle: "quadriceps", Role: "primary"}}); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Leg day", "Push day"} {
+		workout, err := db.CreateWorkout(ctx, &database.Workouts{User_id: "u1", Name: name})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, exercise := range []*database.Exercises{squat, bench} {
+			if _, err := db.CreateWorkoutExercise(ctx, &database.Workout_exercises{Workout_id: workout.Id, Exercise_id: exercise.Id, Sets: 3, Order_index: i}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if _, err := db.CreateWorkout(ctx, &database.Workouts{User_id: "u2", Name: "Pull day"}); err != nil {
+		t.Fatal(err)
+	}
+
+	query := `{ workouts(sort: "name", order: "asc") { name exercises { sets exercise { name muscles { slug role } } } } }`
+	status, result := postGraphQL(t, s, "u1", query, nil)
+	if status != fiber.StatusOK || len(result.Errors) != 0 {
+		t.Fatalf("expected the query to succeed, got %d %v", status, result.Errors)
+	}
+	var data struct {
+		Workouts []struct {
+			Name      string
+			Exercises []struct {
+				Sets     int
+				Exercise struct {
+					Name    string
+					Muscles []struct{ Slug, Role string }
+				}
+			}
+		}
+	}
+	if err := json.Unmarshal(result.Data, &data); err != nil {
+		t.Fatal(err)
+	}
+	if len(data.Workouts) != 2 || data.Workouts[0].Name != "Leg day" || data.Workouts[1].Name != "Push day" {
+		t.Fatalf("expected only the caller's workouts, got %+v", data.Workouts)
+	}
+	exercises := data.Workouts[0].Exercises
+	if len(exercises) != 2 || exercises[0].Exercise.Name != "Squat" || exercises[1].Exercise.Name != "Bench Press" {
+		t.Fatalf("expected the workout's exercises in order, got %+v", exercises)
+	}
+	if muscles := exercises[0].Exercise.Muscles; len(muscles) != 1 || muscles[0].Slug != "quadriceps" || muscles[0].Role != "primary" {
+		t.Errorf("unexpected muscles %+v", muscles)
+	}
+
+	for _, name := range []string{"ListWorkoutExercisesOf", "GetExercisesByIDs", "ListExerciseMuscles"} {
+		if db.calls[name] != 1 {
+			t.Errorf("expected one %s call for the whole query, got %d", name, db.calls[name])
+		}
+	}
+}
+
+func TestGraphQLErrors(t *testing.T) {
+	s, db := newFakeServer(t)
+	ctx := context.Background()
+	other, err := db.CreateWorkout(ctx, &database.Workouts{User_id: "u2", Name: "Pull day"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if status, _ := postGraphQL(t, s, "", `{ me { id } }`, nil); status != fiber.StatusUnauthorized {
+		t.Errorf("expected 401 without a token, got %d", status)
+	}
+
+	status, result := postGraphQL(t, s, "u1", `query($id: ID!) { workout(id: $id) { name } }`, map[string]interface{}{"id": other.Id})
+	if status != fiber.StatusOK || len(result.Errors) != 0 || string(result.Data) != `{"workout":null}` {
+		t.Errorf("expected another user's workout to be null, got %d %s %v", status, result.Data, result.Errors)
+	}
+
+	status, result = postGraphQL(t, s, "u1", `{ me { id } }`, nil)
+	if status != fiber.StatusOK || len(result.Errors) != 1 || result.Errors[0].Extensions["code"] != "USER_NOT_FOUND" {
+		t.Errorf("expected USER_NOT_FOUND, got %d %v", status, result.Errors)
+	}
+
+	_, result = postGraphQL(t, s, "u1", `{ workouts { nope } }`, nil)
+	if len(result.Errors) != 1 || result.Errors[0].Extensions["code"] != codeValidationFailed {
+		t.Errorf("expected an unknown field to fail validation, got %v", result.Errors)
+	}
+}
+
+func TestGraphQLGet(t *testing.T) {
+	s, _ := newFakeServer(t)
+
+	req := httptest.NewRequest("GET", "/api/v1/graphql?query="+url.QueryEscape(`{ exercises { id } }`), nil)
+	req.Header.Set("Authorization", bearer(t, "u1"))
+	resp, err := s.App.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var result graphqlResult
+	json.NewDecoder(resp.Body).Decode(&result)
+	if resp.StatusCode != fiber.StatusOK || string(result.Data) != `{"exercises":[]}` {
+		t.Errorf("expected an empty exercise list, got %d %s %v", resp.StatusCode, result.Data, result.Errors)
+	}
+}
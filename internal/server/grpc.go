@@ -0,0 +1,248 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"fitness-hack/internal/messages"
+	fitnesshackv1 "fitness-hack/internal/pb/fitnesshack/v1"
+	"fitness-hack/internal/requestid"
+)
+
+// grpcErrorDomain is the domain of the ErrorInfo detail gRPC errors carry their code in
+const grpcErrorDomain = "fitness-hack"
+
+// NewGRPCServer returns a gRPC server for the user, workout, workout session and exercise
+// services. It shares the REST API's database, cache and logger, and its RPCs need the same
+// JWT, sent as "authorization: Bearer <token>" metadata.
+func (s *FiberServer) NewGRPCServer() *grpc.Server {
+	server := grpc.NewServer(grpc.ChainUnaryInterceptor(s.grpcRequestID, s.grpcLogging, s.grpcAuth))
+	fitnesshackv1.RegisterUserServiceServer(server, &userService{s: s})
+	fitnesshackv1.RegisterWorkoutServiceServer(server, &workoutService{s: s})
+	fitnesshackv1.RegisterWorkoutSessionServiceServer(server, &workoutSessionService{s: s})
+	fitnesshackv1.RegisterExerciseServiceServer(server, &exerciseService{s: s})
+	return server
+}
+
+// grpcCall is the state interceptors share with the rest of an RPC, as c.Locals does for
+// REST requests
+type grpcCall struct {
+	userID string
+}
+
+type grpcCallKey struct{}
+
+// grpcCallFrom returns the state of the RPC ctx is for, or an empty one outside grpcLogging
+func grpcCallFrom(ctx context.Context) *grpcCall {
+	if call, ok := ctx.Value(grpcCallKey{}).(*grpcCall); ok {
+		return call
+	}
+	return &grpcCall{}
+}
+
+// grpcUserID returns the caller grpcAuth authenticated
+func grpcUserID(ctx context.Context) (string, error) {
+	if userID := grpcCallFrom(ctx).userID; userID != "" {
+		return userID, nil
+	}
+	return "", errUnauthenticated()
+}
+
+// errUnauthenticated is the error of an RPC without a usable token
+func errUnauthenticated() error {
+	return &APIError{Status: fiber.StatusUnauthorized, Code: codeUnauthorized, Message: "Unauthorized"}
+}
+
+// grpcMetadata returns the first value of the incoming metadata key, or ""
+func grpcMetadata(ctx context.Context, key string) string {
+	if values := metadata.ValueFromIncomingContext(ctx, key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// grpcRequestID gives every RPC an ID, as assignRequestID does for REST requests: the
+// client's x-request-id metadata when it is valid, otherwise a new one. The ID is returned
+// in the response header metadata.
+func (s *FiberServer) grpcRequestID(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	id := grpcMetadata(ctx, requestid.Header)
+	if !requestid.Valid(id) {
+		id = requestid.New()
+	}
+	ctx = requestid.NewContext(ctx, id)
+	// The header can only fail to be set once it was sent, which nothing has done yet
+	_ = grpc.SetHeader(ctx, metadata.Pairs(requestid.Header, id))
+	return handler(ctx, req)
+}
+
+// grpcLogging turns the errors of RPCs into gRPC statuses and logs every RPC, as
+// errorHandler does for REST requests
+func (s *FiberServer) grpcLogging(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+	call := &grpcCall{}
+	resp, err := handler(context.WithValue(ctx, grpcCallKey{}, call), req)
+	statusErr := grpcError(err)
+
+	code := status.Code(statusErr)
+	var ip string
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		ip = p.Addr.String()
+	}
+	fields := []string{"method", info.FullMethod, "ip", ip, "user_agent", grpcMetadata(ctx, "user-agent"), "user_id", call.userID}
+	metadata := map[string]interface{}{
+		"code":    code.String(),
+		"latency": time.Since(start).String(),
+	}
+	switch code {
+	case codes.OK:
+		s.writeLog(ctx, "INFO", messages.ServerGRPCHandled, nil, fields, metadata, code)
+	case codes.Internal, codes.Unknown, codes.Unavailable, codes.DataLoss:
+		s.writeLog(ctx, "ERROR", messages.ServerGRPCFailed, err, fields, metadata)
+	default:
+		s.writeLog(ctx, "WARN", messages.ServerGRPCError, err, fields, metadata, code)
+	}
+	return resp, statusErr
+}
+
+// grpcAuth authenticates RPCs with a JWT, and applies the checks the REST API makes of
+// tokens: logged-out tokens are rejected, tokens for a forced password reset can't be used
+// and impersonation tokens may only call Get and List methods
+func (s *FiberServer) grpcAuth(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	token, ok := strings.CutPrefix(grpcMetadata(ctx, "authorization"), "Bearer ")
+	if !ok {
+		return nil, errUnauthenticated()
+	}
+	claims, err := parseJWT(token)
+	if err != nil {
+		return nil, errUnauthenticated()
+	}
+	userID, _ := claims["user_id"].(string)
+	if userID == "" {
+		return nil, errUnauthenticated()
+	}
+
+	// Redis failures fail open, as they do for REST requests
+	revokedCtx, cancel := context.WithTimeout(ctx, 500*time.Millisecond)
+	revoked, err := s.isTokenRevoked(revokedCtx, claims)
+	cancel()
+	if err != nil {
+		s.writeLog(ctx, "WARN", messages.ServerCacheFailed, err, []string{"method", info.FullMethod}, map[string]interface{}{
+			"operation": "check_revoked_token",
+			"component": "cache",
+		})
+	}
+	if revoked {
+		return nil, errUnauthenticated()
+	}
+
+	if reset, _ := claims[claimPasswordReset].(bool); reset {
+		return nil, &APIError{Status: fiber.StatusForbidden, Code: codePasswordResetRequired, Message: "Password reset required"}
+	}
+	if _, impersonated := claims[claimImpersonatedBy]; impersonated {
+		name := path.Base(info.FullMethod)
+		if !strings.HasPrefix(name, "Get") && !strings.HasPrefix(name, "List") {
+			return nil, &APIError{Status: fiber.StatusForbidden, Code: codeForbidden, Message: "Impersonation tokens are read-only"}
+		}
+	}
+
+	grpcCallFrom(ctx).userID = userID
+	return handler(ctx, req)
+}
+
+// grpcCodes are the gRPC codes of REST statuses. Conflicts depend on their error code.
+var grpcCodes = map[int]codes.Code{
+	fiber.StatusBadRequest:            codes.InvalidArgument,
+	fiber.StatusUnauthorized:          codes.Unauthenticated,
+	fiber.StatusPaymentRequired:       codes.ResourceExhausted,
+	fiber.StatusForbidden:             codes.PermissionDenied,
+	fiber.StatusNotFound:              codes.NotFound,
+	fiber.StatusGone:                  codes.NotFound,
+	fiber.StatusPreconditionFailed:    codes.FailedPrecondition,
+	fiber.StatusRequestEntityTooLarge: codes.InvalidArgument,
+	fiber.StatusUnprocessableEntity:   codes.InvalidArgument,
+	fiber.StatusTooManyRequests:       codes.ResourceExhausted,
+	fiber.StatusInternalServerError:   codes.Internal,
+	fiber.StatusBadGateway:            codes.Unavailable,
+	fiber.StatusServiceUnavailable:    codes.Unavailable,
+	fiber.StatusGatewayTimeout:        codes.DeadlineExceeded,
+}
+
+// grpcError turns the error an RPC returned into a gRPC status with the message and code
+// the REST API would respond with. The code is in an ErrorInfo detail's reason.
+func grpcError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return status.FromContextError(err).Err()
+	}
+
+	apiErr := toAPIError(err)
+	if quotaErr, ok := asQuotaError(err); ok {
+		apiErr.Status, apiErr.Message = quotaErrorMessage(quotaErr)
+		apiErr.Code = codeQuotaExceeded
+	}
+
+	code, ok := grpcCodes[apiErr.Status]
+	switch {
+	case apiErr.Status == fiber.StatusConflict && apiErr.Code == codeAlreadyExists:
+		code = codes.AlreadyExists
+	case apiErr.Status == fiber.StatusConflict && apiErr.Code == codeVersionConflict:
+		code = codes.Aborted
+	case apiErr.Status == fiber.StatusConflict:
+		code = codes.FailedPrecondition
+	case !ok && apiErr.Status >= fiber.StatusInternalServerError:
+		code = codes.Internal
+	case !ok:
+		code = codes.InvalidArgument
+	}
+
+	st := status.New(code, apiErr.Message)
+	if detailed, err := st.WithDetails(&errdetails.ErrorInfo{Reason: apiErr.Code, Domain: grpcErrorDomain}); err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
+
+// grpcPage returns the limit and offset of a list RPC with the defaults and bounds of
+// getPaginationParams
+func grpcPage(limit, offset int32) (int, int) {
+	if limit <= 0 || limit > 100 {
+		limit = 10
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return int(limit), int(offset)
+}
+
+// timestampProto converts t to a protobuf timestamp, nil for the zero time
+func timestampProto(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+// timeValue returns the time ts holds, or the zero time for nil
+func timeValue(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+	return ts.AsTime()
+}
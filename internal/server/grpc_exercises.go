@@ -0,0 +1,75 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"fitness-hack/internal/database"
+	fitnesshackv1 "fitness-hack/internal/pb/fitnesshack/v1"
+)
+
+// exerciseService is the gRPC counterpart of reading /api/v1/exercises
+type exerciseService struct {
+	fitnesshackv1.UnimplementedExerciseServiceServer
+	s *FiberServer
+}
+
+// exerciseToProto converts a database exercise to its protobuf message
+func exerciseToProto(exercise *database.Exercises) *fitnesshackv1.Exercise {
+	return &fitnesshackv1.Exercise{
+		Id:              exercise.Id,
+		Name:            exercise.Name,
+		Description:     exercise.Description,
+		Equipment:       stringValue(exercise.Equipment),
+		DifficultyLevel: stringValue(exercise.Difficulty_level),
+		Instructions:    exercise.Instructions,
+		CreatedAt:       timestampProto(exercise.Created_at),
+		UpdatedAt:       timestampProto(exercise.Updated_at),
+		Version:         int32(exercise.Version),
+	}
+}
+
+func (e *exerciseService) GetExercise(ctx context.Context, req *fitnesshackv1.GetExerciseRequest) (*fitnesshackv1.Exercise, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	exercise, err := e.s.db.GetExerciseByID(ctx, req.Id)
+	if err != nil {
+		return nil, resourceNotFound("Exercise")
+	}
+	return exerciseToProto(exercise), nil
+}
+
+func (e *exerciseService) ListExercises(ctx context.Context, req *fitnesshackv1.ListExercisesRequest) (*fitnesshackv1.ListExercisesResponse, error) {
+	limit, offset := grpcPage(req.Limit, req.Offset)
+	opts := database.ListExercisesOpts{
+		ListOptions: database.ListOptions{Limit: limit, Offset: offset, Sort: req.Sort, Order: req.Order, Filters: map[string]string{}},
+		Muscle:      database.MuscleSlug(req.Muscle),
+	}
+	if req.Equipment != "" {
+		opts.Filters["equipment"] = req.Equipment
+	}
+	if req.DifficultyLevel != "" {
+		opts.Filters["difficulty_level"] = req.DifficultyLevel
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	exercises, err := e.s.db.ListExercises(ctx, opts)
+	if errors.Is(err, database.ErrInvalidListOption) {
+		return nil, &APIError{Status: fiber.StatusBadRequest, Code: codeValidationFailed, Message: err.Error()}
+	}
+	if err != nil {
+		return nil, serverError("Failed to fetch exercises", err)
+	}
+
+	response := &fitnesshackv1.ListExercisesResponse{Exercises: make([]*fitnesshackv1.Exercise, len(exercises))}
+	for i := range exercises {
+		response.Exercises[i] = exerciseToProto(&exercises[i])
+	}
+	return response, nil
+}
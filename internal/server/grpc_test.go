@@ -0,0 +1,145 @@
+package server
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	fitnesshackv1 "fitness-hack/internal/pb/fitnesshack/v1"
+	"fitness-hack/internal/requestid"
+)
+
+// newGRPCClient serves s's gRPC API in memory and returns a connection to it
+func newGRPCClient(t *testing.T, s *FiberServer) *grpc.ClientConn {
+	t.Helper()
+	listener := bufconn.Listen(1 << 20)
+	server := s.NewGRPCServer()
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// grpcAs returns a context whose RPCs authenticate as userID
+func grpcAs(t *testing.T, userID string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", bearer(t, userID))
+}
+
+// grpcReason returns the code of the ErrorInfo detail of err
+func grpcReason(err error) string {
+	for _, detail := range status.Convert(err).Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok {
+			return info.Reason
+		}
+	}
+	return ""
+}
+
+func TestGRPCWorkouts(t *testing.T) {
+	s, _ := newFakeServer(t)
+	workouts := fitnesshackv1.NewWorkoutServiceClient(newGRPCClient(t, s))
+
+	_, err := workouts.ListWorkouts(context.Background(), &fitnesshackv1.ListWorkoutsRequest{})
+	if status.Code(err) != codes.Unauthenticated || grpcReason(err) != codeUnauthorized {
+		t.Fatalf("expected Unauthenticated without a token, got %v", err)
+	}
+
+	ctx := grpcAs(t, "u1")
+	created, err := workouts.CreateWorkout(ctx, &fitnesshackv1.CreateWorkoutRequest{Name: "Push day", DurationMinutes: 45})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created.UserId != "u1" || created.Name != "Push day" || created.DurationMinutes != 45 {
+		t.Errorf("unexpected workout %v", created)
+	}
+	if _, err := workouts.CreateWorkout(grpcAs(t, "u2"), &fitnesshackv1.CreateWorkoutRequest{Name: "Pull day"}); err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := workouts.ListWorkouts(ctx, &fitnesshackv1.ListWorkoutsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Workouts) != 1 || list.Workouts[0].Id != created.Id {
+		t.Errorf("expected only the caller's workout, got %v", list.Workouts)
+	}
+
+	_, err = workouts.GetWorkout(grpcAs(t, "u2"), &fitnesshackv1.GetWorkoutRequest{Id: created.Id})
+	if status.Code(err) != codes.NotFound || grpcReason(err) != "WORKOUT_NOT_FOUND" {
+		t.Errorf("expected another user's workout not found, got %v", err)
+	}
+
+	name := "Push day B"
+	updated, err := workouts.UpdateWorkout(ctx, &fitnesshackv1.UpdateWorkoutRequest{Id: created.Id, Name: &name, Version: &created.Version})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Name != name || updated.DurationMinutes != 45 || updated.Version != created.Version+1 {
+		t.Errorf("unexpected update %v", updated)
+	}
+	_, err = workouts.UpdateWorkout(ctx, &fitnesshackv1.UpdateWorkoutRequest{Id: created.Id, Name: &name, Version: &created.Version})
+	if status.Code(err) != codes.Aborted || grpcReason(err) != codeVersionConflict {
+		t.Errorf("expected a stale version aborted, got %v", err)
+	}
+
+	if _, err := workouts.DeleteWorkout(ctx, &fitnesshackv1.DeleteWorkoutRequest{Id: created.Id}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := workouts.GetWorkout(ctx, &fitnesshackv1.GetWorkoutRequest{Id: created.Id}); status.Code(err) != codes.NotFound {
+		t.Errorf("expected the deleted workout not found, got %v", err)
+	}
+}
+
+func TestGRPCRestrictedTokens(t *testing.T) {
+	s, _ := newFakeServer(t)
+	workouts := fitnesshackv1.NewWorkoutServiceClient(newGRPCClient(t, s))
+
+	for _, tc := range []struct {
+		claims map[string]interface{}
+		reason string
+	}{
+		{map[string]interface{}{claimImpersonatedBy: "admin"}, codeForbidden},
+		{map[string]interface{}{claimPasswordReset: true}, codePasswordResetRequired},
+	} {
+		token, err := generateJWTWithClaims("u1", time.Now().Add(time.Hour), tc.claims)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+		_, err = workouts.CreateWorkout(ctx, &fitnesshackv1.CreateWorkoutRequest{Name: "Leg day"})
+		if status.Code(err) != codes.PermissionDenied || grpcReason(err) != tc.reason {
+			t.Errorf("expected %s, got %v", tc.reason, err)
+		}
+	}
+}
+
+func TestGRPCRequestID(t *testing.T) {
+	s, _ := newFakeServer(t)
+	exercises := fitnesshackv1.NewExerciseServiceClient(newGRPCClient(t, s))
+
+	ctx := metadata.AppendToOutgoingContext(grpcAs(t, "u1"), requestid.Header, "trace-123")
+	var header metadata.MD
+	_, err := exercises.GetExercise(ctx, &fitnesshackv1.GetExerciseRequest{Id: "missing"}, grpc.Header(&header))
+	if status.Code(err) != codes.NotFound || grpcReason(err) != "EXERCISE_NOT_FOUND" {
+		t.Errorf("expected the exercise not found, got %v", err)
+	}
+	if got := header.Get(requestid.Header); len(got) != 1 || got[0] != "trace-123" {
+		t.Errorf("expected the request ID echoed, got %v", got)
+	}
+}
@@ -0,0 +1,100 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"fitness-hack/internal/database"
+	fitnesshackv1 "fitness-hack/internal/pb/fitnesshack/v1"
+)
+
+// userService is the gRPC counterpart of /api/v1/users/me
+type userService struct {
+	fitnesshackv1.UnimplementedUserServiceServer
+	s *FiberServer
+}
+
+// userToProto converts a database user to its protobuf message
+func userToProto(user *database.Users) *fitnesshackv1.User {
+	return &fitnesshackv1.User{
+		Id:        user.Id,
+		Email:     user.Email,
+		Username:  user.Username,
+		FirstName: stringValue(user.First_name),
+		LastName:  stringValue(user.Last_name),
+		Timezone:  user.Timezone,
+		CreatedAt: timestampProto(user.Created_at),
+		UpdatedAt: timestampProto(user.Updated_at),
+		Version:   int32(user.Version),
+	}
+}
+
+// intPointer converts an optional protobuf integer to the *int of an update request
+func intPointer(n *int32) *int {
+	if n == nil {
+		return nil
+	}
+	value := int(*n)
+	return &value
+}
+
+func (u *userService) GetMe(ctx context.Context, req *fitnesshackv1.GetMeRequest) (*fitnesshackv1.User, error) {
+	userID, err := grpcUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	user, err := u.s.db.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, resourceNotFound("User")
+	}
+	return userToProto(user), nil
+}
+
+func (u *userService) UpdateMe(ctx context.Context, req *fitnesshackv1.UpdateMeRequest) (*fitnesshackv1.User, error) {
+	userID, err := grpcUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	update := database.UpdateUserRequest{
+		Email:     req.Email,
+		Username:  req.Username,
+		FirstName: req.FirstName,
+		LastName:  req.LastName,
+		Timezone:  req.Timezone,
+		Version:   intPointer(req.Version),
+	}
+	if err := normalizeTimezone(update.Timezone); err != nil {
+		return nil, &APIError{Status: fiber.StatusBadRequest, Code: codeValidationFailed, Message: err.Error()}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	existingUser, err := u.s.db.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, resourceNotFound("User")
+	}
+	applyUserUpdate(existingUser, update, nil)
+
+	updatedUser, err := u.s.db.UpdateUser(ctx, existingUser)
+	if errors.Is(err, database.ErrVersionConflict) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, serverError("Failed to update user", err)
+	}
+
+	// Invalidate cache
+	u.s.DeleteCache(ctx, userCacheKey(userID))
+	u.s.cache.Del(ctx, "users:list:*")
+
+	return userToProto(updatedUser), nil
+}
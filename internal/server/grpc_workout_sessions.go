@@ -0,0 +1,216 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"fitness-hack/internal/database"
+	fitnesshackv1 "fitness-hack/internal/pb/fitnesshack/v1"
+)
+
+// workoutSessionService is the gRPC counterpart of /api/v1/workout-sessions. Callers only
+// see their own sessions; other users' are not found.
+type workoutSessionService struct {
+	fitnesshackv1.UnimplementedWorkoutSessionServiceServer
+	s *FiberServer
+}
+
+// workoutSessionToProto converts a database workout session to its protobuf message
+func workoutSessionToProto(ws *database.Workout_sessions) *fitnesshackv1.WorkoutSession {
+	return &fitnesshackv1.WorkoutSession{
+		Id:              ws.Id,
+		UserId:          ws.User_id,
+		WorkoutId:       stringValue(ws.Workout_id),
+		Name:            ws.Name,
+		StartedAt:       timestampProto(ws.Started_at),
+		CompletedAt:     timestampProto(ws.Completed_at),
+		DurationMinutes: int32(ws.Duration_minutes),
+		Notes:           ws.Notes,
+		Source:          ws.Source,
+		CreatedAt:       timestampProto(ws.Created_at),
+		UpdatedAt:       timestampProto(ws.Updated_at),
+		Version:         int32(ws.Version),
+	}
+}
+
+// ownWorkoutSession returns the caller's workout session with the ID
+func (w *workoutSessionService) ownWorkoutSession(ctx context.Context, userID, id string) (*database.Workout_sessions, error) {
+	session, err := w.s.db.GetWorkoutSessionByID(ctx, id)
+	if err != nil || session.User_id != userID {
+		return nil, resourceNotFound("Workout session")
+	}
+	return session, nil
+}
+
+func (w *workoutSessionService) CreateWorkoutSession(ctx context.Context, req *fitnesshackv1.CreateWorkoutSessionRequest) (*fitnesshackv1.WorkoutSession, error) {
+	userID, err := grpcUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Set default started_at if not provided
+	startedAt := time.Now()
+	if req.StartedAt != nil {
+		startedAt = req.StartedAt.AsTime()
+	}
+
+	workoutSession := database.Workout_sessions{
+		User_id:          userID,
+		Name:             req.Name,
+		Started_at:       startedAt,
+		Completed_at:     timeValue(req.CompletedAt),
+		Duration_minutes: int(req.DurationMinutes),
+		Notes:            req.Notes,
+		Created_at:       time.Now(),
+		Updated_at:       time.Now(),
+	}
+	if req.WorkoutId != "" {
+		workoutSession.Workout_id = &req.WorkoutId
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	// Percentage prescriptions are resolved now, as they are for sessions created over REST
+	if workoutSession.Workout_id != nil {
+		plan, err := w.s.sessionPlan(ctx, userID, *workoutSession.Workout_id, startedAt)
+		if err != nil {
+			return nil, serverError("Failed to create workout session", err)
+		}
+		workoutSession.Plan = plan
+	}
+
+	createdWorkoutSession, err := w.s.db.CreateWorkoutSession(ctx, &workoutSession)
+	if err != nil {
+		return nil, serverError("Failed to create workout session", err)
+	}
+
+	// Invalidate workout sessions list cache
+	w.s.cache.Del(ctx, "workout_sessions:list:*")
+
+	if !createdWorkoutSession.Completed_at.IsZero() {
+		w.s.emitWebhookEvent(userID, webhookEventSessionCompleted, workoutSessionToResponse(createdWorkoutSession))
+	}
+	return workoutSessionToProto(createdWorkoutSession), nil
+}
+
+func (w *workoutSessionService) GetWorkoutSession(ctx context.Context, req *fitnesshackv1.GetWorkoutSessionRequest) (*fitnesshackv1.WorkoutSession, error) {
+	userID, err := grpcUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	session, err := w.ownWorkoutSession(ctx, userID, req.Id)
+	if err != nil {
+		return nil, err
+	}
+	return workoutSessionToProto(session), nil
+}
+
+func (w *workoutSessionService) ListWorkoutSessions(ctx context.Context, req *fitnesshackv1.ListWorkoutSessionsRequest) (*fitnesshackv1.ListWorkoutSessionsResponse, error) {
+	userID, err := grpcUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	limit, offset := grpcPage(req.Limit, req.Offset)
+	opts := database.ListWorkoutSessionsOpts{
+		ListOptions: database.ListOptions{Limit: limit, Offset: offset, Sort: req.Sort, Order: req.Order, Filters: map[string]string{"user_id": userID}},
+		From:        timeValue(req.From),
+		To:          timeValue(req.To),
+	}
+	if req.WorkoutId != "" {
+		opts.Filters["workout_id"] = req.WorkoutId
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	sessions, err := w.s.db.ListWorkoutSessions(ctx, opts)
+	if errors.Is(err, database.ErrInvalidListOption) {
+		return nil, &APIError{Status: fiber.StatusBadRequest, Code: codeValidationFailed, Message: err.Error()}
+	}
+	if err != nil {
+		return nil, serverError("Failed to fetch workout sessions", err)
+	}
+
+	response := &fitnesshackv1.ListWorkoutSessionsResponse{WorkoutSessions: make([]*fitnesshackv1.WorkoutSession, len(sessions))}
+	for i := range sessions {
+		response.WorkoutSessions[i] = workoutSessionToProto(&sessions[i])
+	}
+	return response, nil
+}
+
+func (w *workoutSessionService) UpdateWorkoutSession(ctx context.Context, req *fitnesshackv1.UpdateWorkoutSessionRequest) (*fitnesshackv1.WorkoutSession, error) {
+	userID, err := grpcUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	update := database.UpdateWorkoutSessionRequest{
+		Name:            req.Name,
+		DurationMinutes: intPointer(req.DurationMinutes),
+		Notes:           req.Notes,
+		Version:         intPointer(req.Version),
+	}
+	if req.CompletedAt != nil {
+		completedAt := req.CompletedAt.AsTime()
+		update.CompletedAt = &completedAt
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	existingWorkoutSession, err := w.ownWorkoutSession(ctx, userID, req.Id)
+	if err != nil {
+		return nil, err
+	}
+	completing := applyWorkoutSessionUpdate(existingWorkoutSession, update, nil)
+
+	updatedWorkoutSession, err := w.s.db.UpdateWorkoutSession(ctx, existingWorkoutSession)
+	if errors.Is(err, database.ErrVersionConflict) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, serverError("Failed to update workout session", err)
+	}
+
+	// Invalidate cache
+	w.s.DeleteCache(ctx, workoutSessionCacheKey(req.Id))
+	w.s.cache.Del(ctx, "workout_sessions:list:*")
+
+	if completing {
+		w.s.emitWebhookEvent(userID, webhookEventSessionCompleted, workoutSessionToResponse(updatedWorkoutSession))
+	}
+	return workoutSessionToProto(updatedWorkoutSession), nil
+}
+
+func (w *workoutSessionService) DeleteWorkoutSession(ctx context.Context, req *fitnesshackv1.DeleteWorkoutSessionRequest) (*emptypb.Empty, error) {
+	userID, err := grpcUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if _, err := w.ownWorkoutSession(ctx, userID, req.Id); err != nil {
+		return nil, err
+	}
+	if err := w.s.db.DeleteWorkoutSession(ctx, req.Id); err != nil {
+		return nil, serverError("Failed to delete workout session", err)
+	}
+
+	// Invalidate cache
+	w.s.DeleteCache(ctx, workoutSessionCacheKey(req.Id))
+	w.s.cache.Del(ctx, "workout_sessions:list:*")
+
+	return &emptypb.Empty{}, nil
+}
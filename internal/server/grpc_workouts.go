@@ -0,0 +1,186 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"fitness-hack/internal/database"
+	fitnesshackv1 "fitness-hack/internal/pb/fitnesshack/v1"
+)
+
+// workoutService is the gRPC counterpart of /api/v1/workouts. Callers only see their own
+// workouts; other users' are not found.
+type workoutService struct {
+	fitnesshackv1.UnimplementedWorkoutServiceServer
+	s *FiberServer
+}
+
+// workoutToProto converts a database workout to its protobuf message
+func workoutToProto(workout *database.Workouts) *fitnesshackv1.Workout {
+	return &fitnesshackv1.Workout{
+		Id:              workout.Id,
+		UserId:          workout.User_id,
+		Name:            workout.Name,
+		Description:     workout.Description,
+		DurationMinutes: int32(workout.Duration_minutes),
+		ProgramId:       workout.Program_id,
+		IsTemplate:      workout.Is_template,
+		CreatedAt:       timestampProto(workout.Created_at),
+		UpdatedAt:       timestampProto(workout.Updated_at),
+		Version:         int32(workout.Version),
+	}
+}
+
+// ownWorkout returns the caller's workout with the ID
+func (w *workoutService) ownWorkout(ctx context.Context, userID, id string) (*database.Workouts, error) {
+	workout, err := w.s.db.GetWorkoutByID(ctx, id)
+	if err != nil || workout.User_id != userID {
+		return nil, resourceNotFound("Workout")
+	}
+	return workout, nil
+}
+
+func (w *workoutService) CreateWorkout(ctx context.Context, req *fitnesshackv1.CreateWorkoutRequest) (*fitnesshackv1.Workout, error) {
+	userID, err := grpcUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	workout := database.Workouts{
+		User_id:          userID,
+		Name:             req.Name,
+		Description:      req.Description,
+		Duration_minutes: int(req.DurationMinutes),
+		Is_template:      req.IsTemplate,
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	createdWorkout, err := w.s.db.CreateWorkout(ctx, &workout)
+	if err != nil {
+		return nil, serverError("Failed to create workout", err)
+	}
+
+	// Invalidate workouts list cache
+	w.s.cache.Del(ctx, "workouts:list:*")
+
+	return workoutToProto(createdWorkout), nil
+}
+
+func (w *workoutService) GetWorkout(ctx context.Context, req *fitnesshackv1.GetWorkoutRequest) (*fitnesshackv1.Workout, error) {
+	userID, err := grpcUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	workout, err := w.ownWorkout(ctx, userID, req.Id)
+	if err != nil {
+		return nil, err
+	}
+	return workoutToProto(workout), nil
+}
+
+func (w *workoutService) ListWorkouts(ctx context.Context, req *fitnesshackv1.ListWorkoutsRequest) (*fitnesshackv1.ListWorkoutsResponse, error) {
+	userID, err := grpcUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	limit, offset := grpcPage(req.Limit, req.Offset)
+	opts := database.ListWorkoutsOpts{
+		ListOptions: database.ListOptions{Limit: limit, Offset: offset, Sort: req.Sort, Order: req.Order, Filters: map[string]string{}},
+		UserID:      userID,
+		MuscleGroup: req.MuscleGroup,
+	}
+	if req.ProgramId != "" {
+		opts.Filters["program_id"] = req.ProgramId
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	workouts, err := w.s.db.ListWorkouts(ctx, opts)
+	if errors.Is(err, database.ErrInvalidListOption) {
+		return nil, &APIError{Status: fiber.StatusBadRequest, Code: codeValidationFailed, Message: err.Error()}
+	}
+	if err != nil {
+		return nil, serverError("Failed to fetch workouts", err)
+	}
+
+	response := &fitnesshackv1.ListWorkoutsResponse{Workouts: make([]*fitnesshackv1.Workout, len(workouts))}
+	for i := range workouts {
+		response.Workouts[i] = workoutToProto(&workouts[i])
+	}
+	return response, nil
+}
+
+func (w *workoutService) UpdateWorkout(ctx context.Context, req *fitnesshackv1.UpdateWorkoutRequest) (*fitnesshackv1.Workout, error) {
+	userID, err := grpcUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	existingWorkout, err := w.ownWorkout(ctx, userID, req.Id)
+	if err != nil {
+		return nil, err
+	}
+	applyWorkoutUpdate(existingWorkout, database.UpdateWorkoutRequest{
+		Name:            req.Name,
+		Description:     req.Description,
+		DurationMinutes: intPointer(req.DurationMinutes),
+		IsTemplate:      req.IsTemplate,
+		Version:         intPointer(req.Version),
+	}, nil)
+
+	updatedWorkout, err := w.s.db.UpdateWorkout(ctx, existingWorkout)
+	if errors.Is(err, database.ErrVersionConflict) {
+		return nil, err
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, resourceNotFound("Workout")
+	}
+	if err != nil {
+		return nil, serverError("Failed to update workout", err)
+	}
+
+	// Invalidate cache
+	w.s.DeleteCache(ctx, workoutCacheKey(req.Id))
+	w.s.cache.Del(ctx, "workouts:list:*")
+
+	return workoutToProto(updatedWorkout), nil
+}
+
+func (w *workoutService) DeleteWorkout(ctx context.Context, req *fitnesshackv1.DeleteWorkoutRequest) (*emptypb.Empty, error) {
+	userID, err := grpcUserID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if _, err := w.ownWorkout(ctx, userID, req.Id); err != nil {
+		return nil, err
+	}
+	if err := w.s.db.DeleteWorkout(ctx, req.Id); err != nil {
+		return nil, serverError("Failed to delete workout", err)
+	}
+
+	// Invalidate cache
+	w.s.DeleteCache(ctx, workoutCacheKey(req.Id))
+	w.s.cache.Del(ctx, "workouts:list:*")
+
+	return &emptypb.Empty{}, nil
+}
@@ -0,0 +1,206 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"fitness-hack/internal/database"
+	"fitness-hack/internal/messages"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// Helper to generate a random hex string of n bytes
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// isGuest reports whether the request was authenticated with a guest token
+func isGuest(c *fiber.Ctx) bool {
+	claims, err := getJWTClaims(c)
+	if err != nil {
+		return false
+	}
+	guest, _ := claims["guest"].(bool)
+	return guest
+}
+
+// denyGuests blocks sandboxed guest accounts from routes that affect other users or shared data
+func (s *FiberServer) denyGuests(c *fiber.Ctx) error {
+	if isGuest(c) {
+		return errorResponse(c, fiber.StatusForbidden, "Not available for guest accounts, please register")
+	}
+	return c.Next()
+}
+
+// POST /api/v1/auth/guest
+func (s *FiberServer) createGuestSession(c *fiber.Ctx) error {
+	suffix, err := randomHex(6)
+	if err != nil {
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to create guest account")
+	}
+
+	// Guests never log in with a password, so use an unguessable one
+	password, err := randomHex(32)
+	if err != nil {
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to create guest account")
+	}
+	hash, err := hashPassword(password)
+	if err != nil {
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to hash password")
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(getEnvDuration("GUEST_ACCOUNT_TTL", 24*time.Hour))
+
+	firstName, lastName := "Guest", ""
+	user := database.Users{
+		Email:         fmt.Sprintf("guest-%s@guest.fitnesshack.local", suffix),
+		Username:      "guest_" + suffix,
+		Password_hash: hash,
+		First_name:    &firstName,
+		Last_name:     &lastName,
+		Created_at:    now,
+		Updated_at:    now,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	guest, err := s.db.CreateGuestAccount(ctx, &user, expiresAt)
+	if err != nil {
+		LogDatabaseError(s, "create_guest_account", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to create guest account")
+	}
+
+	if err := s.seedGuestData(ctx, guest.Id); err != nil {
+		// Seeding is best effort: the account is still usable and is purged on expiry
+		LogDatabaseError(s, "seed_guest_data", err, c)
+	}
+
+	token, err := s.issueSessionToken(ctx, c, guest.Id, expiresAt, jwt.MapClaims{"guest": true})
+	if err != nil {
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to generate token")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"data": database.GuestLoginResponse{
+			Token:     token,
+			User:      userToResponse(guest),
+			ExpiresAt: expiresAt,
+		},
+	})
+}
+
+// seedGuestData preloads a guest account with a sample program, workout and completed session
+func (s *FiberServer) seedGuestData(ctx context.Context, userID string) error {
+	now := time.Now()
+
+	difficulty := database.Programs_difficulty_beginner
+	program, err := s.db.CreateProgram(ctx, &database.Programs{
+		Id:             uuid.New().String(),
+		Name:           "Demo Strength Program",
+		Description:    "A four week beginner program to explore FitnessHack",
+		User_id:        userID,
+		Duration_weeks: 4,
+		Difficulty:     &difficulty,
+		Is_active:      true,
+		Created_at:     now,
+		Updated_at:     now,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create demo program: %w", err)
+	}
+
+	workout, err := s.db.CreateWorkout(ctx, &database.Workouts{
+		Id:               uuid.New().String(),
+		User_id:          userID,
+		Name:             "Full Body Demo",
+		Description:      "A sample full body workout",
+		Duration_minutes: 45,
+		Program_id:       program.Id,
+		Created_at:       now,
+		Updated_at:       now,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create demo workout: %w", err)
+	}
+
+	// Attach a few exercises from the shared catalog when it has any
+	exercises, err := s.db.ListExercises(ctx, database.ListExercisesOpts{ListOptions: database.ListOptions{Limit: 3}})
+	if err != nil {
+		return fmt.Errorf("failed to load demo exercises: %w", err)
+	}
+	for i, exercise := range exercises {
+		_, err := s.db.CreateWorkoutExercise(ctx, &database.Workout_exercises{
+			Id:           uuid.New().String(),
+			Workout_id:   workout.Id,
+			Exercise_id:  exercise.Id,
+			Sets:         3,
+			Reps:         10,
+			Weight_kg:    decimal.NewFromInt(20),
+			Order_index:  i,
+			Rest_seconds: 90,
+			Created_at:   now,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create demo workout exercise: %w", err)
+		}
+	}
+
+	startedAt := now.Add(-24 * time.Hour)
+	_, err = s.db.CreateWorkoutSession(ctx, &database.Workout_sessions{
+		Id:               uuid.New().String(),
+		User_id:          userID,
+		Workout_id:       &workout.Id,
+		Name:             workout.Name,
+		Started_at:       startedAt,
+		Completed_at:     startedAt.Add(45 * time.Minute),
+		Duration_minutes: 45,
+		Notes:            "Sample session",
+		Created_at:       now,
+		Updated_at:       now,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create demo session: %w", err)
+	}
+
+	return nil
+}
+
+// StartGuestCleanup periodically purges expired guest accounts until ctx is cancelled
+func (s *FiberServer) StartGuestCleanup(ctx context.Context) {
+	s.runPeriodically(ctx, getEnvDuration("GUEST_CLEANUP_INTERVAL", 15*time.Minute), s.purgeExpiredGuests)
+}
+
+// purgeExpiredGuests deletes expired guest accounts and their cached entries
+func (s *FiberServer) purgeExpiredGuests(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+
+	ids, err := s.db.DeleteExpiredGuestAccounts(ctx, time.Now())
+	if err != nil {
+		s.logError("ERROR", messages.GuestCleanupFailed, err, nil, map[string]interface{}{
+			"component": "guest_cleanup",
+		})
+		return
+	}
+
+	for _, id := range ids {
+		s.DeleteCache(ctx, userCacheKey(id))
+	}
+	if len(ids) > 0 {
+		s.cache.Del(ctx, "users:list:*")
+		messages.Log(messages.GuestsPurged, len(ids))
+	}
+}
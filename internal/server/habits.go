@@ -0,0 +1,238 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"fitness-hack/internal/database"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	maxWaterMl = 20000
+	maxSteps   = 200000
+	// habitRangeDays is the range listed when from isn't given, and maxHabitRangeDays the
+	// longest range that can be listed at once
+	habitRangeDays    = 30
+	maxHabitRangeDays = 366
+)
+
+// Helper to convert database daily habits to response model
+func dailyHabitsToResponse(habits *database.Daily_habits) database.DailyHabitsResponse {
+	response := database.DailyHabitsResponse{
+		Date:      habits.Day.Format("2006-01-02"),
+		WaterMl:   habits.Water_ml,
+		Steps:     habits.Steps,
+		UpdatedAt: habits.Updated_at,
+	}
+	if habits.Sleep_hours != nil {
+		hours := habits.Sleep_hours.InexactFloat64()
+		response.SleepHours = &hours
+	}
+	return response
+}
+
+// habitDay parses the :date of a habits route. Days up to tomorrow are accepted, since it
+// is already tomorrow in some timezones.
+func habitDay(value string, now time.Time) (time.Time, error) {
+	day, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return day, errors.New("date must be formatted as YYYY-MM-DD")
+	}
+	if day.After(now.UTC().AddDate(0, 0, 1)) {
+		return day, errors.New("date can't be in the future")
+	}
+	return day, nil
+}
+
+// habitAverages averages each habit over the days it was logged on
+func habitAverages(days []database.Daily_habits) database.HabitAveragesResponse {
+	var water, steps, sleepDays int
+	var waterSum, stepsSum int64
+	sleepSum := decimal.Zero
+	for _, day := range days {
+		if day.Water_ml != nil {
+			water++
+			waterSum += int64(*day.Water_ml)
+		}
+		if day.Steps != nil {
+			steps++
+			stepsSum += int64(*day.Steps)
+		}
+		if day.Sleep_hours != nil {
+			sleepDays++
+			sleepSum = sleepSum.Add(*day.Sleep_hours)
+		}
+	}
+
+	var averages database.HabitAveragesResponse
+	average := func(sum decimal.Decimal, n int) *float64 {
+		if n == 0 {
+			return nil
+		}
+		value := sum.Div(decimal.NewFromInt(int64(n))).Round(2).InexactFloat64()
+		return &value
+	}
+	averages.WaterMl = average(decimal.NewFromInt(waterSum), water)
+	averages.Steps = average(decimal.NewFromInt(stepsSum), steps)
+	averages.SleepHours = average(sleepSum, sleepDays)
+	return averages
+}
+
+// PUT /api/v1/habits/:date
+// Logs habits for a day, creating the day or updating the habits given. Habits left out
+// keep the value already logged.
+func (s *FiberServer) putDailyHabits(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	day, err := habitDay(c.Params("date"), time.Now())
+	if err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+	var req database.PutDailyHabitsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+	if req.WaterMl == nil && req.SleepHours == nil && req.Steps == nil {
+		return errorResponse(c, fiber.StatusBadRequest, "Log at least one of waterMl, sleepHours and steps")
+	}
+	if req.WaterMl != nil && (*req.WaterMl < 0 || *req.WaterMl > maxWaterMl) {
+		return errorResponse(c, fiber.StatusBadRequest, fmt.Sprintf("waterMl must be between 0 and %d", maxWaterMl))
+	}
+	if req.SleepHours != nil && (*req.SleepHours < 0 || *req.SleepHours > 24) {
+		return errorResponse(c, fiber.StatusBadRequest, "sleepHours must be between 0 and 24")
+	}
+	if req.Steps != nil && (*req.Steps < 0 || *req.Steps > maxSteps) {
+		return errorResponse(c, fiber.StatusBadRequest, fmt.Sprintf("steps must be between 0 and %d", maxSteps))
+	}
+
+	habits := database.Daily_habits{User_id: userID, Day: day, Water_ml: req.WaterMl, Steps: req.Steps}
+	if req.SleepHours != nil {
+		hours := decimal.NewFromFloat(*req.SleepHours).Round(2)
+		habits.Sleep_hours = &hours
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	saved, err := s.db.UpsertDailyHabits(ctx, &habits)
+	if err != nil {
+		LogDatabaseError(s, "upsert_daily_habits", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to save habits")
+	}
+	return successResponse(c, dailyHabitsToResponse(saved))
+}
+
+// GET /api/v1/habits/:date
+func (s *FiberServer) getDailyHabits(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	day, err := habitDay(c.Params("date"), time.Now())
+	if err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	habits, err := s.db.GetDailyHabits(ctx, userID, day)
+	if errors.Is(err, sql.ErrNoRows) {
+		return errorResponse(c, fiber.StatusNotFound, "No habits logged on this day")
+	}
+	if err != nil {
+		LogDatabaseError(s, "get_daily_habits", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to get habits")
+	}
+	return successResponse(c, dailyHabitsToResponse(habits))
+}
+
+// GET /api/v1/habits?from=2024-01-01&to=2024-01-31&tz=Europe/Berlin
+// Lists the days habits were logged on in a range of dates, both included, with the
+// average of each habit. The range defaults to the 30 days up to today in tz.
+func (s *FiberServer) listDailyHabits(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tz, err := s.userTimezone(ctx, c, userID)
+	if err != nil {
+		LogDatabaseError(s, "get_user", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to list habits")
+	}
+	to, _, err := summaryDay(c.Query("to"), tz, time.Now())
+	if err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+	// Dates are compared as calendar days, whatever timezone today was found in
+	to = time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	from := to.AddDate(0, 0, 1-habitRangeDays)
+	if value := c.Query("from"); value != "" {
+		if from, err = time.Parse("2006-01-02", value); err != nil {
+			return errorResponse(c, fiber.StatusBadRequest, "from must be formatted as YYYY-MM-DD")
+		}
+	}
+	if from.After(to) {
+		return errorResponse(c, fiber.StatusBadRequest, "from must not be after to")
+	}
+	if from.AddDate(0, 0, maxHabitRangeDays).Before(to.AddDate(0, 0, 1)) {
+		return errorResponse(c, fiber.StatusBadRequest, fmt.Sprintf("The range can be at most %d days", maxHabitRangeDays))
+	}
+
+	days, err := s.db.ListDailyHabits(ctx, userID, from, to)
+	if err != nil {
+		LogDatabaseError(s, "list_daily_habits", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to list habits")
+	}
+
+	response := database.HabitRangeResponse{
+		From:     from.Format("2006-01-02"),
+		To:       to.Format("2006-01-02"),
+		Days:     make([]database.DailyHabitsResponse, len(days)),
+		Averages: habitAverages(days),
+	}
+	for i := range days {
+		response.Days[i] = dailyHabitsToResponse(&days[i])
+	}
+	return successResponse(c, response)
+}
+
+// DELETE /api/v1/habits/:date
+func (s *FiberServer) deleteDailyHabits(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	day, err := habitDay(c.Params("date"), time.Now())
+	if err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err = s.db.DeleteDailyHabits(ctx, userID, day)
+	if errors.Is(err, sql.ErrNoRows) {
+		return errorResponse(c, fiber.StatusNotFound, "No habits logged on this day")
+	}
+	if err != nil {
+		LogDatabaseError(s, "delete_daily_habits", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to delete habits")
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
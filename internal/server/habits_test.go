@@ -0,0 +1,93 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"fitness-hack/internal/database"
+)
+
+func putHabits(t *testing.T, s *FiberServer, date, body string) int {
+	t.Helper()
+	req := httptest.NewRequest("PUT", "/api/v1/habits/"+date, strings.NewReader(body))
+	req.Header.Set("Authorization", bearer(t, "u1"))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.App.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode
+}
+
+func TestPutDailyHabits(t *testing.T) {
+	s, _ := newFakeServer(t)
+
+	for _, tc := range []struct {
+		date, body string
+		status     int
+	}{
+		{"2024-03-01", `{"waterMl":1500}`, 200},
+		{"2024-03-01", `{"sleepHours":7.5,"steps":9000}`, 200},
+		{"2024-03-01", `{}`, 400},
+		{"2024-03-01", `{"sleepHours":25}`, 400},
+		{"2024-03-01", `{"steps":-1}`, 400},
+		{"01-03-2024", `{"steps":100}`, 400},
+		{time.Now().AddDate(0, 0, 3).Format(time.DateOnly), `{"steps":100}`, 400},
+	} {
+		if status := putHabits(t, s, tc.date, tc.body); status != tc.status {
+			t.Errorf("PUT %s %s: expected %d, got %d", tc.date, tc.body, tc.status, status)
+		}
+	}
+
+	req := httptest.NewRequest("GET", "/api/v1/habits/2024-03-01", nil)
+	req.Header.Set("Authorization", bearer(t, "u1"))
+	resp, err := s.App.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var day struct{ Data database.DailyHabitsResponse }
+	json.NewDecoder(resp.Body).Decode(&day)
+	if day.Data.WaterMl == nil || *day.Data.WaterMl != 1500 || *day.Data.SleepHours != 7.5 || *day.Data.Steps != 9000 {
+		t.Errorf("expected the second PUT to keep the water logged, got %+v", day.Data)
+	}
+}
+
+func TestListDailyHabits(t *testing.T) {
+	s, _ := newFakeServer(t)
+
+	putHabits(t, s, "2024-03-01", `{"waterMl":1000,"sleepHours":7}`)
+	putHabits(t, s, "2024-03-02", `{"waterMl":2000}`)
+	putHabits(t, s, "2024-03-05", `{"sleepHours":8.5,"steps":4000}`)
+
+	req := httptest.NewRequest("GET", "/api/v1/habits?from=2024-03-01&to=2024-03-04", nil)
+	req.Header.Set("Authorization", bearer(t, "u1"))
+	resp, err := s.App.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var body struct{ Data database.HabitRangeResponse }
+	json.NewDecoder(resp.Body).Decode(&body)
+
+	if len(body.Data.Days) != 2 || body.Data.Days[1].Date != "2024-03-02" {
+		t.Fatalf("expected the 2 days logged in range, got %+v", body.Data.Days)
+	}
+	averages := body.Data.Averages
+	if *averages.WaterMl != 1500 || *averages.SleepHours != 7 || averages.Steps != nil {
+		t.Errorf("unexpected averages %+v", averages)
+	}
+
+	for _, query := range []string{"from=2024-03-05&to=2024-03-01", "from=2023-01-01&to=2024-03-01", "from=yesterday"} {
+		req := httptest.NewRequest("GET", "/api/v1/habits?"+query, nil)
+		req.Header.Set("Authorization", bearer(t, "u1"))
+		resp, err := s.App.Test(req, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != 400 {
+			t.Errorf("%s: expected 400, got %d", query, resp.StatusCode)
+		}
+	}
+}
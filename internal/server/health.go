@@ -0,0 +1,93 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"fitness-hack/internal/health"
+	"fitness-hack/internal/messages"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// newHealthChecker registers the readiness checks. Only the database is critical: without
+// Redis the cache and rate limits are skipped, and storage, mail and webhooks back features
+// that can wait, so their failures degrade the service rather than take it down.
+func (s *FiberServer) newHealthChecker() *health.Checker {
+	checker := health.NewChecker(getEnvDuration("READYZ_CACHE_TTL", 5*time.Second),
+		health.Check{
+			Name:     "database",
+			Critical: true,
+			Timeout:  getEnvDuration("HEALTH_DATABASE_TIMEOUT", time.Second),
+			Probe:    s.db.Ping,
+		},
+		health.Check{
+			Name:    "redis",
+			Timeout: getEnvDuration("HEALTH_REDIS_TIMEOUT", 500*time.Millisecond),
+			Probe: func(ctx context.Context) error {
+				return s.cache.Ping(ctx).Err()
+			},
+		},
+		health.Check{
+			Name:    "storage",
+			Timeout: getEnvDuration("HEALTH_STORAGE_TIMEOUT", 2*time.Second),
+			Probe:   s.storage.Ping,
+		},
+		health.Check{
+			Name:    "mail",
+			Timeout: getEnvDuration("HEALTH_MAIL_TIMEOUT", 3*time.Second),
+			Probe:   s.mailer.Ping,
+		},
+		health.Check{
+			Name:    "webhook_queue",
+			Timeout: getEnvDuration("HEALTH_QUEUE_TIMEOUT", time.Second),
+			Probe:   s.checkWebhookQueue,
+		},
+	)
+	checker.OnFailure = func(name string, result health.Result) {
+		s.logError("WARN", messages.ServerReadinessFailed, nil, nil, map[string]interface{}{
+			"component": "health",
+			"check":     name,
+			"error":     result.Error,
+		})
+	}
+	return checker
+}
+
+// checkWebhookQueue fails when deliveries pile up well past their due time,
+// meaning no instance is running the delivery job or it can't keep up
+func (s *FiberServer) checkWebhookQueue(ctx context.Context) error {
+	overdue, err := s.db.CountOverdueWebhookDeliveries(ctx, time.Now().Add(-10*time.Minute))
+	if err != nil {
+		return err
+	}
+	if limit := getEnvInt("HEALTH_QUEUE_BACKLOG_LIMIT", 100); overdue > limit {
+		return fmt.Errorf("%d webhook deliveries are more than 10 minutes overdue", overdue)
+	}
+	return nil
+}
+
+// GET /readyz
+// Reports ok or degraded with 200 so a degraded instance stays in rotation, and down with
+// 503. Error details can reveal internal hostnames, so failures are logged and the details
+// only included in the response when HEALTH_EXPOSE_ERRORS is set.
+func (s *FiberServer) readyzHandler(c *fiber.Ctx) error {
+	report := s.health.Run(c.UserContext())
+
+	if !getEnvBool("HEALTH_EXPOSE_ERRORS", false) {
+		// The report may be cached, so redact a copy
+		checks := make(map[string]health.Result, len(report.Checks))
+		for name, result := range report.Checks {
+			result.Error = ""
+			checks[name] = result
+		}
+		report.Checks = checks
+	}
+
+	status := fiber.StatusOK
+	if report.Status == health.StatusDown {
+		status = fiber.StatusServiceUnavailable
+	}
+	return c.Status(status).JSON(report)
+}
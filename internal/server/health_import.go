@@ -0,0 +1,175 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math"
+	"time"
+
+	"fitness-hack/internal/database"
+	"fitness-hack/internal/importer"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shopspring/decimal"
+)
+
+// Helper to convert database body metric to response model
+func bodyMetricToResponse(metric *database.Body_metrics) database.BodyMetricResponse {
+	return database.BodyMetricResponse{
+		ID:         metric.Id,
+		Metric:     string(metric.Metric),
+		Value:      metric.Measured_value.InexactFloat64(),
+		RecordedAt: metric.Recorded_at,
+		Source:     metric.Source,
+	}
+}
+
+// Helper to convert an imported workout to a database session. Metrics that weren't
+// recorded are left NULL rather than stored as zero. The start position is rounded to two
+// decimal places, about a kilometer, which is all the weather lookup needs.
+func importedWorkoutToSession(source string, w *importer.Workout, exerciseID string) database.Workout_sessions {
+	ws := database.Workout_sessions{
+		Name:             w.Name,
+		Started_at:       w.StartedAt,
+		Completed_at:     w.EndedAt,
+		Duration_minutes: int(math.Round(w.Duration.Minutes())),
+		Source:           source,
+		External_id:      w.ExternalID,
+		Exercise_id:      &exerciseID,
+		Duration_seconds: positiveOrNil(int(math.Round(w.Duration.Seconds()))),
+		Avg_heart_rate:   positiveOrNil(w.AvgHeartRate),
+		Max_heart_rate:   positiveOrNil(w.MaxHeartRate),
+	}
+	if w.DistanceMeters > 0 {
+		distance := math.Round(w.DistanceMeters*10) / 10
+		ws.Distance_meters = &distance
+	}
+	if w.Start != nil {
+		latitude, longitude := math.Round(w.Start.Latitude*100)/100, math.Round(w.Start.Longitude*100)/100
+		ws.Start_latitude, ws.Start_longitude = &latitude, &longitude
+	}
+	return ws
+}
+
+func positiveOrNil(n int) *int {
+	if n <= 0 {
+		return nil
+	}
+	return &n
+}
+
+// importedSessions converts imported workouts to sessions. exerciseIDs caches the exercise
+// found for each name, so each distinct exercise is resolved once rather than per workout.
+func (s *FiberServer) importedSessions(ctx context.Context, source string, workouts []importer.Workout, exerciseIDs map[string]string) ([]database.Workout_sessions, error) {
+	sessions := make([]database.Workout_sessions, 0, len(workouts))
+	for i := range workouts {
+		workout := &workouts[i]
+		exerciseID, ok := exerciseIDs[workout.Exercise.Name]
+		if !ok {
+			exercise, err := s.db.FindOrCreateExercise(ctx, workout.Exercise.Name, workout.Exercise.MuscleGroup, workout.Exercise.Equipment)
+			if err != nil {
+				return nil, err
+			}
+			exerciseID = exercise.Id
+			exerciseIDs[workout.Exercise.Name] = exerciseID
+		}
+		sessions = append(sessions, importedWorkoutToSession(source, workout, exerciseID))
+	}
+	return sessions, nil
+}
+
+// parseHealthExport detects the export format from the payload: Apple Health exports
+// are XML, Google Fit data is JSON
+func parseHealthExport(body []byte) (*importer.HealthData, error) {
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '<' {
+		return importer.ParseAppleHealth(bytes.NewReader(body))
+	}
+	return importer.ParseGoogleFit(bytes.NewReader(body))
+}
+
+// POST /api/v1/import/health
+func (s *FiberServer) importHealthData(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+	if len(c.Body()) == 0 {
+		return errorResponse(c, fiber.StatusBadRequest, "Request body must contain an Apple Health or Google Fit export")
+	}
+
+	data, err := parseHealthExport(c.Body())
+	if err != nil {
+		LogValidationError(s, "body", err, c)
+		return errorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	sessions, err := s.importedSessions(ctx, data.Source, data.Workouts, make(map[string]string))
+	if err != nil {
+		LogDatabaseError(s, "find_or_create_exercise", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to import health data")
+	}
+
+	metrics := make([]database.Body_metrics, len(data.Metrics))
+	for i, metric := range data.Metrics {
+		metrics[i] = database.Body_metrics{
+			Metric:         database.Body_metrics_metric(metric.Kind),
+			Measured_value: decimal.NewFromFloat(metric.Value).Round(3),
+			Recorded_at:    metric.RecordedAt,
+			Source:         data.Source,
+		}
+	}
+
+	matchWindow := getEnvDuration("HEALTH_IMPORT_MATCH_WINDOW", 5*time.Minute)
+	result, err := s.db.ImportHealthRecords(ctx, userID, sessions, metrics, matchWindow)
+	if err != nil {
+		LogDatabaseError(s, "import_health_records", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to import health data")
+	}
+
+	if result.SessionsImported > 0 {
+		s.cache.Del(ctx, "workout_sessions:list:*")
+	}
+
+	return successResponse(c, database.HealthImportResponse{
+		Source:           data.Source,
+		SessionsImported: result.SessionsImported,
+		SessionsSkipped:  result.SessionsSkipped,
+		MetricsImported:  result.MetricsImported,
+		MetricsSkipped:   result.MetricsSkipped,
+		Invalid:          data.Skipped,
+	})
+}
+
+// GET /api/v1/users/me/body-metrics?metric=weight_kg&from=&to=
+func (s *FiberServer) listBodyMetrics(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+	opts := database.ListBodyMetricsOpts{ListOptions: getListOptions(c), Metric: c.Query("metric")}
+	if opts.From, opts.To, err = getDateRange(c); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	metrics, err := s.db.ListBodyMetrics(ctx, userID, opts)
+	if errors.Is(err, database.ErrInvalidListOption) {
+		return errorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+	if err != nil {
+		LogDatabaseError(s, "list_body_metrics", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to fetch body metrics")
+	}
+
+	responses := make([]database.BodyMetricResponse, len(metrics))
+	for i := range metrics {
+		responses[i] = bodyMetricToResponse(&metrics[i])
+	}
+	return successResponse(c, responses)
+}
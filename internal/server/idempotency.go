@@ -0,0 +1,180 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// idempotencyKeyHeader carries the client's key for a POST it may retry
+	idempotencyKeyHeader = "Idempotency-Key"
+
+	// idempotentReplayedHeader marks a response replayed from an earlier request with the same key
+	idempotentReplayedHeader = "Idempotent-Replayed"
+
+	// maxIdempotencyKeyLength fits a UUID or ULID with room for a client prefix
+	maxIdempotencyKeyLength = 255
+
+	// maxIdempotentResponseBytes bounds what is stored per key. Larger responses aren't kept,
+	// so retries of those requests run again.
+	maxIdempotentResponseBytes = 1 << 20
+
+	// idempotencyLockTTL bounds how long a key stays claimed by a request that never
+	// finishes, such as one on an instance that crashed, before a retry may run it again
+	idempotencyLockTTL = time.Minute
+)
+
+// idempotentResponse is what is stored under an idempotency key. Status is 0 while the
+// first request with the key is still being handled.
+type idempotentResponse struct {
+	RequestHash string `json:"requestHash"`
+	Status      int    `json:"status"`
+	ContentType string `json:"contentType,omitempty"`
+	Location    string `json:"location,omitempty"`
+	Body        []byte `json:"body,omitempty"`
+}
+
+// validIdempotencyKey reports whether key is 1-255 printable ASCII characters
+func validIdempotencyKey(key string) bool {
+	if key == "" || len(key) > maxIdempotencyKeyLength {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < 0x21 || key[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// idempotencyRequestHash identifies what a request asked for, so a key reused for a
+// different request is caught instead of replaying an unrelated response
+func idempotencyRequestHash(method, path string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method + " " + path + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// idempotency returns a middleware that makes POST requests carrying an Idempotency-Key
+// safe to retry. The first request with a key runs and its response is stored for
+// IDEMPOTENCY_TTL (default 24 hours); retries with the same key get the stored response
+// instead of running again. Keys are scoped to the caller, so it must run after
+// authentication. Server errors aren't stored, so a retry after one runs again, and Redis
+// failures fail open like the rate limiter.
+func (s *FiberServer) idempotency() fiber.Handler {
+	ttl := getEnvDuration("IDEMPOTENCY_TTL", 24*time.Hour)
+
+	return func(c *fiber.Ctx) error {
+		key := c.Get(idempotencyKeyHeader)
+		if c.Method() != fiber.MethodPost || key == "" {
+			return c.Next()
+		}
+		if !validIdempotencyKey(key) {
+			return errorResponse(c, fiber.StatusBadRequest, "Idempotency-Key must be 1-255 printable ASCII characters")
+		}
+		userID, err := getUserIDFromJWT(c)
+		if err != nil || s.cache == nil {
+			return c.Next()
+		}
+
+		keyHash := sha256.Sum256([]byte(key))
+		cacheKey := "idempotency:" + userID + ":" + hex.EncodeToString(keyHash[:])
+		requestHash := idempotencyRequestHash(c.Method(), c.Path(), c.Body())
+
+		ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+		defer cancel()
+
+		pending, _ := json.Marshal(idempotentResponse{RequestHash: requestHash})
+		claimed, err := s.cache.SetNX(ctx, cacheKey, pending, idempotencyLockTTL).Result()
+		if err != nil {
+			LogCacheError(s, "idempotency_claim", err, c)
+			return c.Next()
+		}
+		if !claimed {
+			return s.replayIdempotentResponse(ctx, c, cacheKey, requestHash)
+		}
+
+		// Anything that isn't stored below is released so the client's retry runs again
+		stored := false
+		defer func() {
+			if !stored {
+				ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+				defer cancel()
+				s.cache.Del(ctx, cacheKey)
+			}
+		}()
+
+		if err := c.Next(); err != nil {
+			return err
+		}
+
+		status := c.Response().StatusCode()
+		body := c.Response().Body()
+		if status >= fiber.StatusInternalServerError || len(body) > maxIdempotentResponseBytes {
+			return nil
+		}
+
+		response, err := json.Marshal(idempotentResponse{
+			RequestHash: requestHash,
+			Status:      status,
+			ContentType: string(c.Response().Header.ContentType()),
+			Location:    string(c.Response().Header.Peek(fiber.HeaderLocation)),
+			Body:        body,
+		})
+		if err != nil {
+			return nil
+		}
+		saveCtx, saveCancel := context.WithTimeout(context.Background(), time.Second)
+		defer saveCancel()
+		if err := s.cache.Set(saveCtx, cacheKey, response, ttl).Err(); err != nil {
+			LogCacheError(s, "idempotency_store", err, c)
+			return nil
+		}
+		stored = true
+		return nil
+	}
+}
+
+// replayIdempotentResponse answers a request whose idempotency key was already claimed
+func (s *FiberServer) replayIdempotentResponse(ctx context.Context, c *fiber.Ctx, cacheKey, requestHash string) error {
+	raw, err := s.cache.Get(ctx, cacheKey).Bytes()
+	if errors.Is(err, redis.Nil) {
+		// The first request failed and released the key just now
+		c.Set(fiber.HeaderRetryAfter, "1")
+		return errorResponse(c, fiber.StatusConflict, "A request with this Idempotency-Key is being processed")
+	}
+	if err != nil {
+		LogCacheError(s, "idempotency_replay", err, c)
+		return errorResponse(c, fiber.StatusServiceUnavailable, "Unable to check Idempotency-Key")
+	}
+
+	var previous idempotentResponse
+	if err := json.Unmarshal(raw, &previous); err != nil {
+		LogCacheError(s, "idempotency_replay", err, c)
+		return errorResponse(c, fiber.StatusServiceUnavailable, "Unable to check Idempotency-Key")
+	}
+	if previous.RequestHash != requestHash {
+		return errorResponse(c, fiber.StatusUnprocessableEntity, "Idempotency-Key was already used for a different request")
+	}
+	if previous.Status == 0 {
+		c.Set(fiber.HeaderRetryAfter, "1")
+		return errorResponse(c, fiber.StatusConflict, "A request with this Idempotency-Key is being processed")
+	}
+
+	c.Set(idempotentReplayedHeader, "true")
+	if previous.ContentType != "" {
+		c.Set(fiber.HeaderContentType, previous.ContentType)
+	}
+	if previous.Location != "" {
+		c.Set(fiber.HeaderLocation, previous.Location)
+	}
+	return c.Status(previous.Status).Send(previous.Body)
+}
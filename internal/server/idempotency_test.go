@@ -0,0 +1,64 @@
+package server
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestValidIdempotencyKey(t *testing.T) {
+	for key, want := range map[string]bool{
+		"8e0f3c52-6a1b-4d2e-9f7a-1c2b3d4e5f60": true,
+		"session-start:42":                     true,
+		"":                                     false,
+		"has space":                            false,
+		"tab\there":                            false,
+		"café":                                 false,
+		strings.Repeat("k", 255):               true,
+		strings.Repeat("k", 256):               false,
+	} {
+		if got := validIdempotencyKey(key); got != want {
+			t.Errorf("validIdempotencyKey(%q) = %v, want %v", key, got, want)
+		}
+	}
+}
+
+func TestIdempotencyRequestHash(t *testing.T) {
+	base := idempotencyRequestHash("POST", "/api/v1/workout-sessions", []byte(`{"name":"Leg day"}`))
+	if base != idempotencyRequestHash("POST", "/api/v1/workout-sessions", []byte(`{"name":"Leg day"}`)) {
+		t.Error("the same request hashed differently")
+	}
+	if base == idempotencyRequestHash("POST", "/api/v1/workouts", []byte(`{"name":"Leg day"}`)) {
+		t.Error("requests to different paths hashed the same")
+	}
+	if base == idempotencyRequestHash("POST", "/api/v1/workout-sessions", []byte(`{"name":"Arm day"}`)) {
+		t.Error("requests with different bodies hashed the same")
+	}
+}
+
+func TestIdempotencyRejectsInvalidKey(t *testing.T) {
+	s := &FiberServer{}
+	app := fiber.New()
+	app.Use(s.idempotency())
+	app.Post("/", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusCreated) })
+
+	for key, want := range map[string]int{
+		"":          fiber.StatusCreated,
+		"retry-1":   fiber.StatusCreated,
+		"not valid": fiber.StatusBadRequest,
+	} {
+		req := httptest.NewRequest("POST", "/", nil)
+		if key != "" {
+			req.Header.Set(idempotencyKeyHeader, key)
+		}
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != want {
+			t.Errorf("Idempotency-Key %q: status = %d, want %d", key, resp.StatusCode, want)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	"fitness-hack/internal/database"
+	"fitness-hack/internal/messages"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	// integrationTriggersPath is where automation connectors poll for events
+	integrationTriggersPath = "/api/v1/integrations/triggers"
+
+	// integrationEventPurgeBatch bounds the events deleted per statement
+	integrationEventPurgeBatch = 1000
+)
+
+// isTriggerPath reports whether path is one of the integration trigger routes, the only
+// ones triggers-scoped API keys can call
+func isTriggerPath(path string) bool {
+	path = strings.TrimSuffix(path, "/")
+	return path == integrationTriggersPath || strings.HasPrefix(path, integrationTriggersPath+"/")
+}
+
+// integrationEventRetention is how long events are kept for polling,
+// INTEGRATION_EVENT_RETENTION (default 7 days). Connectors poll every few minutes, so this
+// only needs to cover one that was paused for a while.
+func integrationEventRetention() time.Duration {
+	return getEnvDuration("INTEGRATION_EVENT_RETENTION", 7*24*time.Hour)
+}
+
+// GET /api/v1/integrations/triggers
+// Lists the events connectors can poll for. Connectors can call it to test an API key.
+func (s *FiberServer) listIntegrationTriggers(c *fiber.Ctx) error {
+	triggers := make([]database.IntegrationTriggerResponse, 0, len(webhookEvents))
+	for event, description := range webhookEvents {
+		triggers = append(triggers, database.IntegrationTriggerResponse{
+			Event:       event,
+			Description: description,
+			URL:         integrationTriggersPath + "/" + event,
+		})
+	}
+	sort.Slice(triggers, func(i, j int) bool { return triggers[i].Event < triggers[j].Event })
+	return successResponse(c, triggers)
+}
+
+// GET /api/v1/integrations/triggers/:event?since=&limit=
+// Returns the user's events of one type, newest first, in the body webhooks receive. Each
+// event has a unique ID for connectors to deduplicate on. since (RFC 3339) only returns
+// events recorded after it; limit defaults to 50, at most 100.
+func (s *FiberServer) pollIntegrationTrigger(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	event := c.Params("event")
+	if _, ok := webhookEvents[event]; !ok {
+		return errorResponse(c, fiber.StatusNotFound, "Unknown trigger event")
+	}
+	since := time.Now().Add(-integrationEventRetention())
+	if value := c.Query("since"); value != "" {
+		if since, err = time.Parse(time.RFC3339, value); err != nil {
+			return errorResponse(c, fiber.StatusBadRequest, "since must be an RFC 3339 timestamp")
+		}
+	}
+	limit := c.QueryInt("limit", 50)
+	if limit <= 0 || limit > 100 {
+		return errorResponse(c, fiber.StatusBadRequest, "limit must be between 1 and 100")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	events, err := s.db.ListIntegrationEvents(ctx, userID, event, since, limit)
+	if err != nil {
+		LogDatabaseError(s, "list_integration_events", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to fetch events")
+	}
+
+	payloads := make([]json.RawMessage, len(events))
+	for i := range events {
+		payloads[i] = events[i].Payload
+	}
+	return successResponse(c, payloads)
+}
+
+// StartIntegrationEventPurge periodically deletes events older than their retention
+// (every INTEGRATION_EVENT_PURGE_INTERVAL, default 1h)
+func (s *FiberServer) StartIntegrationEventPurge(ctx context.Context) {
+	s.runPeriodically(ctx, getEnvDuration("INTEGRATION_EVENT_PURGE_INTERVAL", time.Hour), s.purgeIntegrationEvents)
+}
+
+func (s *FiberServer) purgeIntegrationEvents(ctx context.Context) {
+	cutoff := time.Now().Add(-integrationEventRetention())
+	for ctx.Err() == nil {
+		batchCtx, cancel := context.WithTimeout(ctx, time.Minute)
+		deleted, err := s.db.PurgeIntegrationEvents(batchCtx, cutoff, integrationEventPurgeBatch)
+		cancel()
+		if err != nil {
+			s.logError("ERROR", messages.IntegrationEventPurgeFailed, err, nil, map[string]interface{}{"component": "integrations"})
+			return
+		}
+		if deleted < integrationEventPurgeBatch {
+			return
+		}
+	}
+}
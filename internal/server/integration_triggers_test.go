@@ -0,0 +1,91 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"fitness-hack/internal/database"
+	"fitness-hack/internal/database/dbtest"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// triggerStub serves a triggers-scoped API key of u1 and records the events polled for
+type triggerStub struct {
+	*dbtest.Fake
+	polled []string
+}
+
+func (t *triggerStub) AuthenticateAPIKey(ctx context.Context, keyHash string) (*database.Api_keys, error) {
+	return &database.Api_keys{Id: "k1", User_id: "u1", Scope: database.Api_keys_scope_triggers}, nil
+}
+
+func (t *triggerStub) ListIntegrationEvents(ctx context.Context, userID, event string, since time.Time, limit int) ([]database.Integration_events, error) {
+	t.polled = append(t.polled, userID+" "+event)
+	return []database.Integration_events{
+		{Id: "e2", Payload: json.RawMessage(`{"id":"e2","type":"pr.achieved"}`)},
+		{Id: "e1", Payload: json.RawMessage(`{"id":"e1","type":"pr.achieved"}`)},
+	}, nil
+}
+
+func TestIsTriggerPath(t *testing.T) {
+	for path, want := range map[string]bool{
+		"/api/v1/integrations/triggers":                   true,
+		"/api/v1/integrations/triggers/":                  true,
+		"/api/v1/integrations/triggers/session.completed": true,
+		"/api/v1/integrations/triggersx":                  false,
+		"/api/v1/integrations":                            false,
+		"/api/v1/users/me":                                false,
+	} {
+		if got := isTriggerPath(path); got != want {
+			t.Errorf("isTriggerPath(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestPollIntegrationTrigger(t *testing.T) {
+	stub := &triggerStub{Fake: dbtest.NewFake()}
+	s := newTestServer(t, stub)
+
+	get := func(path string) (int, []map[string]interface{}) {
+		t.Helper()
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("X-API-Key", "fh_connector")
+		resp, err := s.App.Test(req, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var body struct {
+			Data []map[string]interface{} `json:"data"`
+		}
+		json.NewDecoder(resp.Body).Decode(&body)
+		return resp.StatusCode, body.Data
+	}
+
+	status, events := get("/api/v1/integrations/triggers/pr.achieved?since=2025-08-01T00:00:00Z")
+	if status != fiber.StatusOK {
+		t.Fatalf("polling = %d, want 200", status)
+	}
+	if len(events) != 2 || events[0]["id"] != "e2" || events[0]["type"] != "pr.achieved" {
+		t.Errorf("expected the stored webhook bodies newest first, got %v", events)
+	}
+	if len(stub.polled) != 1 || stub.polled[0] != "u1 pr.achieved" {
+		t.Errorf("expected the key owner's events to be polled, got %v", stub.polled)
+	}
+
+	if status, triggers := get("/api/v1/integrations/triggers"); status != fiber.StatusOK || len(triggers) != len(webhookEvents) {
+		t.Errorf("listing triggers = %d, %v", status, triggers)
+	}
+	if status, _ := get("/api/v1/integrations/triggers/user.deleted"); status != fiber.StatusNotFound {
+		t.Errorf("polling an unknown event = %d, want 404", status)
+	}
+	if status, _ := get("/api/v1/integrations/triggers/pr.achieved?since=yesterday"); status != fiber.StatusBadRequest {
+		t.Errorf("polling with a bad since = %d, want 400", status)
+	}
+	if status, _ := get("/api/v1/workouts"); status != fiber.StatusForbidden {
+		t.Errorf("calling another route with a triggers key = %d, want 403", status)
+	}
+}
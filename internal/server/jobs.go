@@ -0,0 +1,71 @@
+package server
+
+import (
+	"context"
+	"os"
+
+	"fitness-hack/internal/database"
+	"fitness-hack/internal/jobs"
+	"fitness-hack/internal/messages"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+// Job types run by the job worker
+const (
+	jobDataExport = "data_export"
+)
+
+// dataExportJob is the payload of a data_export job
+type dataExportJob struct {
+	ExportID     string `json:"exportId"`
+	UserID       string `json:"userId"`
+	IncludeVault bool   `json:"includeVault,omitempty"`
+}
+
+// enqueueDataExport queues a pending export to be built by a job worker. When the job
+// can't be queued the export is marked failed, so it doesn't block the user's next request.
+func (s *FiberServer) enqueueDataExport(ctx context.Context, export *database.Data_exports) error {
+	_, err := s.jobs.Enqueue(ctx, jobDataExport, dataExportJob{ExportID: export.Id, UserID: export.User_id, IncludeVault: export.Include_vault})
+	if err != nil {
+		if failErr := s.db.FailDataExport(ctx, export.Id, "Failed to queue export"); failErr != nil {
+			messages.Log(messages.ExportMarkFailedFailed, export.Id, failErr)
+		}
+	}
+	return err
+}
+
+func (s *FiberServer) handleDataExportJob(ctx context.Context, job *jobs.Job) error {
+	var payload dataExportJob
+	if err := job.Decode(&payload); err != nil {
+		return jobs.Permanent(err)
+	}
+	return s.runDataExport(ctx, payload.ExportID, payload.UserID, payload.IncludeVault, job.LastAttempt())
+}
+
+// handleJobs registers every job type's handler with worker
+func (s *FiberServer) handleJobs(worker *jobs.Worker) *jobs.Worker {
+	worker.Handle(jobDataExport, s.handleDataExportJob)
+	return worker
+}
+
+// RunJobWorker processes queued jobs until ctx is canceled, then waits for the jobs
+// already running. JOB_WORKER_CONCURRENCY (default 4) sets how many run at once. Jobs sent
+// to SQS are run by Lambda instead, so with SQS this only waits for ctx.
+func (s *FiberServer) RunJobWorker(ctx context.Context) {
+	concurrency := getEnvInt("JOB_WORKER_CONCURRENCY", 4)
+	switch queue := s.jobs.(type) {
+	case *jobs.Queue:
+		s.handleJobs(jobs.NewWorker(queue, concurrency)).Run(ctx)
+	case *jobs.Local:
+		s.handleJobs(jobs.NewLocalWorker(queue, concurrency)).Run(ctx)
+	default:
+		messages.Log(messages.JobsWorkerNotPolling, os.Getenv("JOB_QUEUE_DRIVER"))
+		<-ctx.Done()
+	}
+}
+
+// HandleSQSEvent runs the jobs in a batch delivered by Lambda's SQS trigger
+func (s *FiberServer) HandleSQSEvent(ctx context.Context, event events.SQSEvent) (events.SQSEventResponse, error) {
+	return s.handleJobs(jobs.NewWorker(nil, 1)).HandleSQSEvent(ctx, event)
+}
@@ -0,0 +1,98 @@
+package server
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+)
+
+const (
+	jsonTimeLayoutSeconds = "2006-01-02T15:04:05Z07:00"
+	jsonTimeLayoutMillis  = "2006-01-02T15:04:05.000Z07:00"
+)
+
+// jsonEncoder encodes everything the API sends, so timestamps look the same across
+// entities: RFC 3339 in UTC at a fixed precision. time.Time values keep the location the
+// database driver or cache gave them and print as many fractional digits as they have,
+// so the encoder rewrites them after marshaling.
+type jsonEncoder struct {
+	layout string
+}
+
+// newJSONEncoderFromEnv returns an encoder using JSON_TIME_PRECISION: "s" (the default)
+// for whole seconds or "ms" for milliseconds
+func newJSONEncoderFromEnv() jsonEncoder {
+	if os.Getenv("JSON_TIME_PRECISION") == "ms" {
+		return jsonEncoder{layout: jsonTimeLayoutMillis}
+	}
+	return jsonEncoder{layout: jsonTimeLayoutSeconds}
+}
+
+// Marshal encodes v as JSON with its timestamps normalized. The zero encoder uses
+// whole seconds.
+func (e jsonEncoder) Marshal(v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	layout := e.layout
+	if layout == "" {
+		layout = jsonTimeLayoutSeconds
+	}
+	return normalizeTimestamps(data, layout), nil
+}
+
+// normalizeTimestamps rewrites every JSON string in data that is an RFC 3339 timestamp,
+// which is how encoding/json writes time.Time, in UTC using layout. Strings from user
+// input that happen to hold a timestamp are rewritten too and keep the instant they name.
+func normalizeTimestamps(data []byte, layout string) []byte {
+	var out []byte
+	last := 0
+	for i := 0; i < len(data); i++ {
+		if data[i] != '"' {
+			continue
+		}
+		start, end, escaped := i+1, i+1, false
+		for ; end < len(data) && data[end] != '"'; end++ {
+			if data[end] == '\\' {
+				escaped = true
+				end++
+			}
+		}
+		i = end
+
+		value := data[start:min(end, len(data))]
+		if escaped || !looksLikeTimestamp(value) {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339Nano, string(value))
+		if err != nil {
+			continue
+		}
+		if out == nil {
+			out = make([]byte, 0, len(data)+16)
+		}
+		out = append(out, data[last:start]...)
+		out = t.UTC().AppendFormat(out, layout)
+		last = end
+	}
+
+	if out == nil {
+		return data
+	}
+	return append(out, data[last:]...)
+}
+
+// looksLikeTimestamp cheaply rules out strings that can't be RFC 3339 timestamps before
+// they are parsed
+func looksLikeTimestamp(s []byte) bool {
+	if len(s) < len("2006-01-02T15:04:05Z") || len(s) > len("2006-01-02T15:04:05.999999999-07:00") {
+		return false
+	}
+	return s[4] == '-' && s[7] == '-' && s[10] == 'T' && s[13] == ':' && s[16] == ':' &&
+		isDigit(s[0]) && isDigit(s[3]) && isDigit(s[9]) && isDigit(s[18])
+}
+
+func isDigit(b byte) bool {
+	return b >= '0' && b <= '9'
+}
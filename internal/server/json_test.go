@@ -0,0 +1,40 @@
+package server
+
+import (
+	"testing"
+	"time"
+)
+
+func TestJSONEncoderNormalizesTimestamps(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	completed := time.Date(2025, 3, 1, 10, 15, 30, 123456789, berlin)
+	v := map[string]interface{}{
+		"startedAt":   time.Date(2025, 3, 1, 9, 0, 0, 0, time.UTC),
+		"completedAt": &completed,
+		"notes":       `said "2025-03-01T10:15:30+01:00" twice`,
+		"name":        "2025-03-01T10:15:30+01:00",
+		"date":        "2025-03-01",
+	}
+
+	data, err := jsonEncoder{}.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"completedAt":"2025-03-01T09:15:30Z","date":"2025-03-01",` +
+		`"name":"2025-03-01T09:15:30Z","notes":"said \"2025-03-01T10:15:30+01:00\" twice",` +
+		`"startedAt":"2025-03-01T09:00:00Z"}`
+	if string(data) != want {
+		t.Fatalf("got  %s\nwant %s", data, want)
+	}
+
+	data, err = jsonEncoder{layout: jsonTimeLayoutMillis}.Marshal([]time.Time{completed, completed.Truncate(time.Second)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `["2025-03-01T09:15:30.123Z","2025-03-01T09:15:30.000Z"]`; string(data) != want {
+		t.Fatalf("got  %s\nwant %s", data, want)
+	}
+}
@@ -0,0 +1,302 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"fitness-hack/internal/database"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	// displayKeyPrefix marks display keys so they are easy to spot in leaked-secret scans
+	displayKeyPrefix = "fhk_"
+
+	// leaderboardSize is how many members a display board ranks
+	leaderboardSize = 10
+
+	// maxDisplayWait bounds how long a display board request waits for the board to change
+	maxDisplayWait = 55 * time.Second
+
+	// displayPollInterval is how often a waiting display board request looks for changes
+	displayPollInterval = 5 * time.Second
+)
+
+// Helper to convert database kiosk display to response model
+func kioskDisplayToResponse(display *database.Kiosk_displays) database.KioskDisplayResponse {
+	return database.KioskDisplayResponse{
+		ID:         display.Id,
+		Name:       display.Name,
+		Timezone:   display.Timezone,
+		Prefix:     display.Prefix,
+		LastSeenAt: display.Last_seen_at,
+		CreatedAt:  display.Created_at,
+	}
+}
+
+// displayWeekStart returns midnight of the Monday starting now's week in loc
+func displayWeekStart(now time.Time, loc *time.Location) time.Time {
+	local := now.In(loc)
+	daysSinceMonday := (int(local.Weekday()) + 6) % 7
+	return time.Date(local.Year(), local.Month(), local.Day()-daysSinceMonday, 0, 0, 0, 0, loc)
+}
+
+// displayBoardETag returns the entity tag of a display board. AsOf is left out so the tag
+// only changes when something on the board does.
+func displayBoardETag(board database.DisplayBoardResponse) string {
+	board.AsOf = time.Time{}
+	data, _ := json.Marshal(board)
+	sum := sha256.Sum256(data)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// displayAuth authenticates a gym screen by its display key and stores the display in
+// c.Locals("display")
+func (s *FiberServer) displayAuth(c *fiber.Ctx) error {
+	key, ok := strings.CutPrefix(c.Get(fiber.HeaderAuthorization), "Bearer ")
+	if !ok || !strings.HasPrefix(key, displayKeyPrefix) {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	display, err := s.db.AuthenticateKioskDisplay(ctx, hashAPIKey(key))
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			LogDatabaseError(s, "authenticate_kiosk_display", err, c)
+		}
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	c.Locals("display", display)
+	return c.Next()
+}
+
+// POST /api/v1/organizations/:orgId/displays
+func (s *FiberServer) createKioskDisplay(c *fiber.Ctx) error {
+	var req database.CreateKioskDisplayRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+	if req.Timezone == "" {
+		req.Timezone = "UTC"
+	}
+	loc, err := time.LoadLocation(req.Timezone)
+	if err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, "timezone must be an IANA timezone name")
+	}
+
+	secret, err := randomHex(24)
+	if err != nil {
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to create display")
+	}
+	key := displayKeyPrefix + secret
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	created, err := s.db.CreateKioskDisplay(ctx, &database.Kiosk_displays{
+		Organization_id: c.Params("orgId"),
+		Name:            strings.TrimSpace(req.Name),
+		Timezone:        loc.String(),
+		Prefix:          key[:len(displayKeyPrefix)+8],
+		Key_hash:        hashAPIKey(key),
+	})
+	if err != nil {
+		LogDatabaseError(s, "create_kiosk_display", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to create display")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"data": database.CreatedKioskDisplayResponse{
+		KioskDisplayResponse: kioskDisplayToResponse(created),
+		Key:                  key,
+	}})
+}
+
+// GET /api/v1/organizations/:orgId/displays
+func (s *FiberServer) listKioskDisplays(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	displays, err := s.db.ListKioskDisplays(ctx, c.Params("orgId"))
+	if err != nil {
+		LogDatabaseError(s, "list_kiosk_displays", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to fetch displays")
+	}
+
+	response := make([]database.KioskDisplayResponse, len(displays))
+	for i := range displays {
+		response[i] = kioskDisplayToResponse(&displays[i])
+	}
+	return successResponse(c, response)
+}
+
+// DELETE /api/v1/organizations/:orgId/displays/:displayId
+func (s *FiberServer) deleteKioskDisplay(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := s.db.DeleteKioskDisplay(ctx, c.Params("orgId"), c.Params("displayId")); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return notFound(c, "Display")
+		}
+		LogDatabaseError(s, "delete_kiosk_display", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to delete display")
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// PUT /api/v1/organizations/:orgId/leaderboard
+// Members are left off the organization's leaderboard until they opt in here.
+func (s *FiberServer) setLeaderboardVisibility(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	var req database.LeaderboardVisibilityRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+	if req.Visible == nil {
+		return errorResponse(c, fiber.StatusBadRequest, "visible is required")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := s.db.SetLeaderboardVisibility(ctx, c.Params("orgId"), userID, *req.Visible); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return notFound(c, "Organization")
+		}
+		LogDatabaseError(s, "set_leaderboard_visibility", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to update leaderboard visibility")
+	}
+
+	return successResponse(c, fiber.Map{"visible": *req.Visible})
+}
+
+// GET /api/v1/display/board?wait=
+// With If-None-Match and wait (in seconds, at most maxDisplayWait), the request is held
+// until the board changes or wait runs out, so screens can long-poll instead of refetching
+// an unchanged board.
+func (s *FiberServer) getDisplayBoard(c *fiber.Ctx) error {
+	display, ok := c.Locals("display").(*database.Kiosk_displays)
+	if !ok {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	wait, err := strconv.Atoi(c.Query("wait", "0"))
+	if err != nil || wait < 0 {
+		return errorResponse(c, fiber.StatusBadRequest, "wait must be a number of seconds")
+	}
+	deadline := time.Now().Add(min(time.Duration(wait)*time.Second, maxDisplayWait))
+
+	for {
+		board, err := s.buildDisplayBoard(c, display, time.Now())
+		if err != nil {
+			return errorResponse(c, fiber.StatusInternalServerError, "Failed to fetch display board")
+		}
+		etag := displayBoardETag(board)
+		if !notModified(c, etag) {
+			return successResponse(c, board)
+		}
+		if time.Until(deadline) < displayPollInterval {
+			return c.SendStatus(fiber.StatusNotModified)
+		}
+		time.Sleep(displayPollInterval)
+	}
+}
+
+// buildDisplayBoard gathers what the display shows at now. Occupancy falls back to the
+// database when the Redis counter is unavailable, as on the occupancy endpoint.
+func (s *FiberServer) buildDisplayBoard(c *fiber.Ctx, display *database.Kiosk_displays, now time.Time) (database.DisplayBoardResponse, error) {
+	orgID := display.Organization_id
+	loc, err := time.LoadLocation(display.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	local := now.In(loc)
+	endOfDay := time.Date(local.Year(), local.Month(), local.Day()+1, 0, 0, 0, 0, loc)
+
+	board := database.DisplayBoardResponse{
+		OrganizationID: orgID,
+		Timezone:       loc.String(),
+		WeekStartsAt:   displayWeekStart(now, loc),
+		Leaderboard:    []database.LeaderboardEntryResponse{},
+		Reservations:   []database.DisplayReservationResponse{},
+		AsOf:           now.UTC(),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	org, err := s.db.GetOrganizationByID(ctx, orgID)
+	if err != nil {
+		LogDatabaseError(s, "get_organization", err, c)
+		return board, err
+	}
+	board.OrganizationName = org.Name
+
+	maxStay := occupancyMaxStay()
+	if board.Occupancy, err = s.liveOccupancy(ctx, orgID, maxStay); err != nil {
+		LogCacheError(s, "occupancy_count", err, c)
+		if board.Occupancy, err = s.db.CountOpenGymVisits(ctx, orgID, maxStay); err != nil {
+			LogDatabaseError(s, "count_open_gym_visits", err, c)
+			return board, err
+		}
+	}
+
+	entries, err := s.db.ListLeaderboard(ctx, orgID, board.WeekStartsAt, board.WeekStartsAt.AddDate(0, 0, 7), leaderboardSize)
+	if err != nil {
+		LogDatabaseError(s, "list_leaderboard", err, c)
+		return board, err
+	}
+	for i, entry := range entries {
+		board.Leaderboard = append(board.Leaderboard, database.LeaderboardEntryResponse{
+			Rank:     i + 1,
+			Name:     entry.Display_name,
+			Sessions: entry.Sessions,
+			Minutes:  entry.Minutes,
+		})
+	}
+
+	equipment, err := s.db.ListGymEquipment(ctx, orgID)
+	if err != nil {
+		LogDatabaseError(s, "list_gym_equipment", err, c)
+		return board, err
+	}
+	names := make(map[string]string, len(equipment))
+	for _, e := range equipment {
+		names[e.Id] = e.Name
+	}
+
+	reservations, err := s.db.ListEquipmentReservations(ctx, orgID, database.ReservationFilter{
+		Status: database.ReservationBooked,
+		From:   now,
+		To:     endOfDay,
+	})
+	if err != nil {
+		LogDatabaseError(s, "list_equipment_reservations", err, c)
+		return board, err
+	}
+	for _, r := range reservations {
+		board.Reservations = append(board.Reservations, database.DisplayReservationResponse{
+			Equipment: names[r.Equipment_id],
+			StartsAt:  r.Starts_at,
+			EndsAt:    r.Ends_at,
+		})
+	}
+
+	return board, nil
+}
@@ -0,0 +1,75 @@
+package server
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"fitness-hack/internal/database"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestDisplayWeekStart(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("timezone data not available")
+	}
+
+	cases := []struct {
+		name string
+		now  time.Time
+		want time.Time
+	}{
+		{"midweek", time.Date(2025, 8, 14, 18, 0, 0, 0, berlin), time.Date(2025, 8, 11, 0, 0, 0, 0, berlin)},
+		{"monday", time.Date(2025, 8, 11, 0, 30, 0, 0, berlin), time.Date(2025, 8, 11, 0, 0, 0, 0, berlin)},
+		{"sunday", time.Date(2025, 8, 17, 23, 0, 0, 0, berlin), time.Date(2025, 8, 11, 0, 0, 0, 0, berlin)},
+		// Still Sunday in UTC, but Monday already in Berlin
+		{"other timezone", time.Date(2025, 8, 17, 22, 30, 0, 0, time.UTC), time.Date(2025, 8, 18, 0, 0, 0, 0, berlin)},
+	}
+	for _, tc := range cases {
+		if got := displayWeekStart(tc.now, berlin); !got.Equal(tc.want) {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.want, got)
+		}
+	}
+}
+
+func TestDisplayBoardETag(t *testing.T) {
+	board := database.DisplayBoardResponse{
+		OrganizationID: "org-1",
+		Occupancy:      12,
+		Leaderboard:    []database.LeaderboardEntryResponse{{Rank: 1, Name: "Sam K.", Sessions: 4, Minutes: 210}},
+		AsOf:           time.Now(),
+	}
+	etag := displayBoardETag(board)
+
+	later := board
+	later.AsOf = board.AsOf.Add(time.Minute)
+	if displayBoardETag(later) != etag {
+		t.Error("expected the ETag not to change with only the time")
+	}
+
+	busier := board
+	busier.Occupancy++
+	if displayBoardETag(busier) == etag {
+		t.Error("expected the ETag to change with the occupancy")
+	}
+}
+
+func TestDisplayBoardRequiresDisplayKey(t *testing.T) {
+	s, _ := newFakeServer(t)
+
+	for _, auth := range []string{"", bearer(t, "u1"), "Bearer fh_0123456789"} {
+		req := httptest.NewRequest(fiber.MethodGet, "/api/v1/display/board", nil)
+		if auth != "" {
+			req.Header.Set(fiber.HeaderAuthorization, auth)
+		}
+		resp, err := s.App.Test(req, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != fiber.StatusUnauthorized {
+			t.Errorf("expected 401 for %q, got %d", auth, resp.StatusCode)
+		}
+	}
+}
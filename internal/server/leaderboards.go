@@ -0,0 +1,279 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"time"
+
+	"fitness-hack/internal/database"
+	"fitness-hack/internal/messages"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// leaderboardPeriods are the periods exercise leaderboards rank, in recompute order
+var leaderboardPeriods = []string{"week", "month", "year", "all"}
+
+// leaderboardPeriodStart returns when the period containing now started: midnight UTC of
+// its Monday, first of the month or first of the year, or the zero time for all. ok is
+// false for an unknown period.
+func leaderboardPeriodStart(period string, now time.Time) (start time.Time, ok bool) {
+	now = now.UTC()
+	switch period {
+	case "week":
+		return displayWeekStart(now, time.UTC), true
+	case "month":
+		return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC), true
+	case "year":
+		return time.Date(now.Year(), time.January, 1, 0, 0, 0, 0, time.UTC), true
+	case "all":
+		return time.Time{}, true
+	}
+	return time.Time{}, false
+}
+
+// leaderboardGenerationKey holds the Unix nanoseconds of the latest recompute of a metric
+// and period's boards, which names the board keys readers use
+func leaderboardGenerationKey(metric, period string) string {
+	return "leaderboard:" + metric + ":" + period
+}
+
+// leaderboardKey is the sorted set of an exercise's board from one recompute, of user IDs
+// scored by the metric
+func leaderboardKey(metric, period, generation, exerciseID string) string {
+	return leaderboardGenerationKey(metric, period) + ":" + generation + ":" + exerciseID
+}
+
+// leaderboardNameKey holds the display name a lifter is ranked under
+func leaderboardNameKey(userID string) string {
+	return "leaderboard:name:" + userID
+}
+
+// leaderboardRecomputeInterval is how often the boards are rebuilt from the database
+func leaderboardRecomputeInterval() time.Duration {
+	return getEnvDuration("LEADERBOARD_RECOMPUTE_INTERVAL", time.Hour)
+}
+
+// StartLeaderboardRecompute periodically rebuilds the exercise leaderboards in Redis (every
+// LEADERBOARD_RECOMPUTE_INTERVAL, default 1h)
+func (s *FiberServer) StartLeaderboardRecompute(ctx context.Context) {
+	s.runPeriodically(ctx, leaderboardRecomputeInterval(), s.recomputeLeaderboards)
+}
+
+// recomputeLeaderboards rebuilds every metric and period's boards. A board that fails keeps
+// serving its previous recompute until that expires.
+func (s *FiberServer) recomputeLeaderboards(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+	defer cancel()
+
+	// Boards outlive a couple of failed recomputes, then expire with the recompute that
+	// stored them
+	ttl := 3 * leaderboardRecomputeInterval()
+	now := time.Now()
+	for _, metric := range []string{database.LeaderboardMetricOneRepMax, database.LeaderboardMetricVolume} {
+		for _, period := range leaderboardPeriods {
+			since, _ := leaderboardPeriodStart(period, now)
+			scores, err := s.db.ListLeaderboardScores(ctx, metric, since, "")
+			if err == nil {
+				err = s.storeLeaderboards(ctx, metric, period, now, scores, ttl)
+			}
+			if err != nil {
+				s.logError("ERROR", messages.LeaderboardRecomputeFailed, err, nil, map[string]interface{}{
+					"component": "leaderboards",
+					"metric":    metric,
+					"period":    period,
+				})
+			}
+		}
+	}
+}
+
+// storeLeaderboards writes the scores of every exercise as a new generation of the metric
+// and period's boards. Readers switch to it once it is complete, and exercises nobody
+// scored in leave the boards with the generation before.
+func (s *FiberServer) storeLeaderboards(ctx context.Context, metric, period string, computedAt time.Time, scores []database.LeaderboardScore, ttl time.Duration) error {
+	generation := strconv.FormatInt(computedAt.UnixNano(), 10)
+	_, err := s.cache.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		boards := map[string]bool{}
+		named := map[string]bool{}
+		for _, score := range scores {
+			key := leaderboardKey(metric, period, generation, score.Exercise_id)
+			pipe.ZAdd(ctx, key, redis.Z{Score: score.Score.Round(2).InexactFloat64(), Member: score.User_id})
+			if !boards[key] {
+				boards[key] = true
+				pipe.Expire(ctx, key, ttl)
+			}
+			if !named[score.User_id] {
+				named[score.User_id] = true
+				pipe.Set(ctx, leaderboardNameKey(score.User_id), score.Display_name, ttl)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return s.cache.Set(ctx, leaderboardGenerationKey(metric, period), generation, ttl).Err()
+}
+
+// cachedLeaderboard reads a page of an exercise's board, and the user's place on it, from
+// the latest recompute. It returns nil before the metric and period were first recomputed.
+func (s *FiberServer) cachedLeaderboard(ctx context.Context, metric, period, exerciseID, userID string, limit, offset int) (*database.LeaderboardResponse, error) {
+	generation, err := s.cache.Get(ctx, leaderboardGenerationKey(metric, period)).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	nanos, err := strconv.ParseInt(generation, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	key := leaderboardKey(metric, period, generation, exerciseID)
+	var page *redis.ZSliceCmd
+	var total, rank *redis.IntCmd
+	var score *redis.FloatCmd
+	// A user off the board has no rank or score, which fails the pipeline with redis.Nil
+	_, _ = s.cache.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		page = pipe.ZRevRangeWithScores(ctx, key, int64(offset), int64(offset+limit-1))
+		total = pipe.ZCard(ctx, key)
+		rank = pipe.ZRevRank(ctx, key, userID)
+		score = pipe.ZScore(ctx, key, userID)
+		return nil
+	})
+	for _, cmd := range []redis.Cmder{page, total, rank, score} {
+		if err := cmd.Err(); err != nil && !errors.Is(err, redis.Nil) {
+			return nil, err
+		}
+	}
+
+	response := &database.LeaderboardResponse{
+		ComputedAt: time.Unix(0, nanos).UTC(),
+		Total:      int(total.Val()),
+		Entries:    make([]database.LeaderboardRankResponse, len(page.Val())),
+	}
+	userIDs := make([]string, 0, len(page.Val())+1)
+	for i, z := range page.Val() {
+		member, _ := z.Member.(string)
+		userIDs = append(userIDs, member)
+		response.Entries[i] = database.LeaderboardRankResponse{Rank: offset + i + 1, Score: z.Score}
+	}
+	if rank.Err() == nil && score.Err() == nil {
+		userIDs = append(userIDs, userID)
+		response.You = &database.LeaderboardRankResponse{Rank: int(rank.Val()) + 1, Score: score.Val()}
+	}
+	if len(userIDs) == 0 {
+		return response, nil
+	}
+
+	nameKeys := make([]string, len(userIDs))
+	for i, id := range userIDs {
+		nameKeys[i] = leaderboardNameKey(id)
+	}
+	names, err := s.cache.MGet(ctx, nameKeys...).Result()
+	if err != nil {
+		return nil, err
+	}
+	for i := range response.Entries {
+		response.Entries[i].DisplayName, _ = names[i].(string)
+	}
+	if response.You != nil {
+		response.You.DisplayName, _ = names[len(names)-1].(string)
+	}
+	return response, nil
+}
+
+// rankLeaderboardScores ranks an exercise's scores, highest first, into a page of the
+// board and the user's place on it
+func rankLeaderboardScores(scores []database.LeaderboardScore, userID string, limit, offset int) *database.LeaderboardResponse {
+	response := &database.LeaderboardResponse{
+		Total:   len(scores),
+		Entries: []database.LeaderboardRankResponse{},
+	}
+	for i, score := range scores {
+		entry := database.LeaderboardRankResponse{
+			Rank:        i + 1,
+			DisplayName: score.Display_name,
+			Score:       score.Score.Round(2).InexactFloat64(),
+		}
+		if i >= offset && i < offset+limit {
+			response.Entries = append(response.Entries, entry)
+		}
+		if score.User_id == userID {
+			response.You = &entry
+		}
+	}
+	return response
+}
+
+// GET /api/v1/leaderboards/exercises/:id?metric=1rm&period=month
+// Ranks the lifters who opted in to leaderboards by their best estimated one-rep max or
+// their total volume of a catalog exercise in the period. Boards are read from the latest
+// background recompute, or ranked from the database before the first one or when Redis is
+// unavailable.
+func (s *FiberServer) getExerciseLeaderboard(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	exerciseID := c.Params("id")
+	if _, err := uuid.Parse(exerciseID); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, "id must be an exercise ID")
+	}
+	metric := c.Query("metric", database.LeaderboardMetricOneRepMax)
+	if !database.ValidLeaderboardMetric(metric) {
+		return errorResponse(c, fiber.StatusBadRequest, "metric must be 1rm or volume")
+	}
+	period := c.Query("period", "month")
+	start, ok := leaderboardPeriodStart(period, time.Now())
+	if !ok {
+		return errorResponse(c, fiber.StatusBadRequest, "period must be week, month, year or all")
+	}
+	limit, offset := getPaginationParams(c)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Custom exercises are private to their creator, so they have no leaderboard
+	exercise, err := s.db.GetExerciseByID(ctx, exerciseID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && exercise.Created_by != nil) {
+		return notFound(c, "Exercise")
+	}
+	if err != nil {
+		LogDatabaseError(s, "get_exercise", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to get leaderboard")
+	}
+
+	response, err := s.cachedLeaderboard(ctx, metric, period, exerciseID, userID, limit, offset)
+	if err != nil {
+		LogCacheError(s, "leaderboard_read", err, c)
+		response = nil
+	}
+	if response != nil {
+		start, _ = leaderboardPeriodStart(period, response.ComputedAt)
+	} else {
+		computedAt := time.Now().UTC()
+		scores, err := s.db.ListLeaderboardScores(ctx, metric, start, exerciseID)
+		if err != nil {
+			LogDatabaseError(s, "list_leaderboard_scores", err, c)
+			return errorResponse(c, fiber.StatusInternalServerError, "Failed to get leaderboard")
+		}
+		response = rankLeaderboardScores(scores, userID, limit, offset)
+		response.ComputedAt = computedAt
+	}
+
+	response.ExerciseID = exerciseID
+	response.Metric = metric
+	response.Period = period
+	if !start.IsZero() {
+		response.PeriodStart = &start
+	}
+	return successResponse(c, response)
+}
@@ -0,0 +1,156 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"fitness-hack/internal/database"
+	"fitness-hack/internal/database/dbtest"
+	"fitness-hack/internal/memstore"
+
+	"github.com/shopspring/decimal"
+)
+
+// leaderboardStub scores three lifters in one exercise, whatever the metric and period
+type leaderboardStub struct {
+	*dbtest.Fake
+	calls int
+}
+
+func (s *leaderboardStub) ListLeaderboardScores(ctx context.Context, metric string, since time.Time, exerciseID string) ([]database.LeaderboardScore, error) {
+	s.calls++
+	scores := []database.LeaderboardScore{}
+	for _, score := range []database.LeaderboardScore{
+		{User_id: "u2", Display_name: "Bo R.", Score: decimal.RequireFromString("182.5")},
+		{User_id: "u1", Display_name: "Ana K.", Score: decimal.RequireFromString("140.004")},
+		{User_id: "u3", Display_name: "cy", Score: decimal.NewFromInt(120)},
+	} {
+		score.Exercise_id = benchmarkExerciseID
+		if exerciseID == "" || exerciseID == score.Exercise_id {
+			scores = append(scores, score)
+		}
+	}
+	return scores, nil
+}
+
+func getLeaderboard(t *testing.T, s *FiberServer, exerciseID, query string) (int, database.LeaderboardResponse) {
+	t.Helper()
+	req := httptest.NewRequest("GET", "/api/v1/leaderboards/exercises/"+exerciseID+"?"+query, nil)
+	req.Header.Set("Authorization", bearer(t, "u1"))
+	resp, err := s.App.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var body struct{ Data database.LeaderboardResponse }
+	json.NewDecoder(resp.Body).Decode(&body)
+	return resp.StatusCode, body.Data
+}
+
+func TestGetExerciseLeaderboard(t *testing.T) {
+	db := dbtest.NewFake()
+	stub := &leaderboardStub{Fake: db}
+	s := newTestServer(t, stub)
+	ctx := context.Background()
+	catalog, _ := db.CreateExercise(ctx, &database.Exercises{Id: benchmarkExerciseID, Name: "Back squat"})
+	creator := "u1"
+	custom, _ := db.CreateExercise(ctx, &database.Exercises{Name: "Zercher squat", Created_by: &creator})
+
+	for query, want := range map[string]int{
+		"metric=reps":      400,
+		"period=fortnight": 400,
+	} {
+		if status, _ := getLeaderboard(t, s, catalog.Id, query); status != want {
+			t.Errorf("%s: expected %d, got %d", query, want, status)
+		}
+	}
+	if status, _ := getLeaderboard(t, s, custom.Id, ""); status != 404 {
+		t.Errorf("expected custom exercises to have no leaderboard, got %d", status)
+	}
+
+	// Before the first recompute, and without Redis, the board is ranked from the database
+	status, board := getLeaderboard(t, s, catalog.Id, "limit=1&offset=1")
+	if status != 200 {
+		t.Fatalf("expected the leaderboard, got %d", status)
+	}
+	if board.Metric != "1rm" || board.Period != "month" || board.PeriodStart == nil || board.PeriodStart.Day() != 1 || board.Total != 3 {
+		t.Errorf("unexpected leaderboard %+v", board)
+	}
+	if len(board.Entries) != 1 || board.Entries[0] != (database.LeaderboardRankResponse{Rank: 2, DisplayName: "Ana K.", Score: 140}) {
+		t.Errorf("unexpected entries %+v", board.Entries)
+	}
+	if board.You == nil || board.You.Rank != 2 {
+		t.Errorf("expected the caller in second place, got %+v", board.You)
+	}
+}
+
+func TestRecomputeLeaderboards(t *testing.T) {
+	db := dbtest.NewFake()
+	stub := &leaderboardStub{Fake: db}
+	s := newTestServer(t, stub)
+	s.cache = memstore.New().Client()
+	ctx := context.Background()
+	catalog, _ := db.CreateExercise(ctx, &database.Exercises{Name: "Back squat"})
+	other, _ := db.CreateExercise(ctx, &database.Exercises{Name: "Deadlift"})
+
+	// The stub scores benchmarkExerciseID, so point the board at the seeded exercise
+	computedAt := time.Date(2024, time.March, 14, 9, 0, 0, 0, time.UTC)
+	scores, _ := stub.ListLeaderboardScores(ctx, database.LeaderboardMetricVolume, time.Time{}, "")
+	for i := range scores {
+		scores[i].Exercise_id = catalog.Id
+	}
+	if err := s.storeLeaderboards(ctx, database.LeaderboardMetricVolume, "all", computedAt, scores, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	stub.calls = 0
+
+	status, board := getLeaderboard(t, s, catalog.Id, "metric=volume&period=all&limit=2")
+	if status != 200 {
+		t.Fatalf("expected the leaderboard, got %d", status)
+	}
+	if stub.calls != 0 {
+		t.Errorf("expected the board to be read from Redis, the database was queried %d times", stub.calls)
+	}
+	if !board.ComputedAt.Equal(computedAt) || board.PeriodStart != nil || board.Total != 3 {
+		t.Errorf("unexpected leaderboard %+v", board)
+	}
+	want := []database.LeaderboardRankResponse{{Rank: 1, DisplayName: "Bo R.", Score: 182.5}, {Rank: 2, DisplayName: "Ana K.", Score: 140}}
+	if len(board.Entries) != 2 || board.Entries[0] != want[0] || board.Entries[1] != want[1] {
+		t.Errorf("unexpected entries %+v", board.Entries)
+	}
+	if board.You == nil || *board.You != want[1] {
+		t.Errorf("expected the caller in second place, got %+v", board.You)
+	}
+
+	// Nobody scored in the other exercise in that recompute
+	if _, board := getLeaderboard(t, s, other.Id, "metric=volume&period=all"); board.Total != 0 || len(board.Entries) != 0 || board.You != nil {
+		t.Errorf("expected an empty board, got %+v", board)
+	}
+
+	s.recomputeLeaderboards(ctx)
+	if stub.calls != 8 {
+		t.Errorf("expected every metric and period to be recomputed, got %d queries", stub.calls)
+	}
+	if _, board := getLeaderboard(t, s, catalog.Id, "metric=volume&period=all"); board.Total != 0 || board.ComputedAt.Equal(computedAt) {
+		t.Errorf("expected the recompute to replace the board, got %+v", board)
+	}
+}
+
+func TestLeaderboardPeriodStart(t *testing.T) {
+	now := time.Date(2024, time.March, 14, 9, 30, 0, 0, time.FixedZone("EST", -5*3600))
+	for period, want := range map[string]time.Time{
+		"week":  time.Date(2024, time.March, 11, 0, 0, 0, 0, time.UTC),
+		"month": time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC),
+		"year":  time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC),
+		"all":   {},
+	} {
+		if got, ok := leaderboardPeriodStart(period, now); !ok || !got.Equal(want) {
+			t.Errorf("%s: expected %v, got %v", period, want, got)
+		}
+	}
+	if _, ok := leaderboardPeriodStart("day", now); ok {
+		t.Error("expected day to be unknown")
+	}
+}
@@ -0,0 +1,95 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// revokedTokenKey is the Redis key marking a token ID as revoked
+func revokedTokenKey(jti string) string {
+	return "auth:revoked:" + jti
+}
+
+// revokeToken adds the token's ID to the revocation list until the token would have expired anyway
+func (s *FiberServer) revokeToken(ctx context.Context, claims jwt.MapClaims) error {
+	jti, _ := claims["jti"].(string)
+	if jti == "" {
+		return nil
+	}
+
+	ttl := time.Minute
+	if exp, err := claims.GetExpirationTime(); err == nil && exp != nil {
+		ttl = time.Until(exp.Time)
+	}
+	if ttl <= 0 {
+		return nil
+	}
+
+	return s.SetCache(ctx, revokedTokenKey(jti), "1", ttl)
+}
+
+// isTokenRevoked reports whether the token's ID is on the revocation list.
+// Tokens issued without a jti cannot be revoked and are never reported.
+func (s *FiberServer) isTokenRevoked(ctx context.Context, claims jwt.MapClaims) (bool, error) {
+	jti, _ := claims["jti"].(string)
+	if jti == "" || s.cache == nil {
+		return false, nil
+	}
+
+	n, err := s.cache.Exists(ctx, revokedTokenKey(jti)).Result()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// rejectRevokedTokens rejects requests authenticated with a logged-out token, and records
+// that the token's session was seen. Redis failures fail open so an outage does not lock
+// every user out.
+func (s *FiberServer) rejectRevokedTokens(c *fiber.Ctx) error {
+	claims, err := getJWTClaims(c)
+	if err != nil {
+		return c.Next()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	revoked, err := s.isTokenRevoked(ctx, claims)
+	if err != nil {
+		LogCacheError(s, "check_revoked_token", err, c)
+		return c.Next()
+	}
+	if revoked {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+	s.touchUserSession(ctx, c, claims)
+	return c.Next()
+}
+
+// POST /api/v1/auth/logout
+func (s *FiberServer) logoutUser(c *fiber.Ctx) error {
+	claims, err := getJWTClaims(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := s.revokeToken(ctx, claims); err != nil {
+		LogCacheError(s, "revoke_token", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to log out")
+	}
+	// The token is already rejected, so failing to drop it from the session list isn't fatal
+	if jti, _ := claims["jti"].(string); jti != "" {
+		if err := s.db.RevokeUserSessionByToken(ctx, jti); err != nil {
+			LogDatabaseError(s, "revoke_user_session", err, c)
+		}
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,73 @@
+package server
+
+import (
+	"context"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"fitness-hack/internal/memstore"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// authedStatus returns the status of method path sent with the Authorization header auth
+func authedStatus(t *testing.T, s *FiberServer, method, path, auth string) int {
+	t.Helper()
+	req := httptest.NewRequest(method, path, nil)
+	req.Header.Set("Authorization", auth)
+	resp, err := s.App.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode
+}
+
+func TestLogoutRevokesToken(t *testing.T) {
+	s, _ := newFakeServer(t)
+	s.cache = memstore.New().Client()
+	token, other := bearer(t, "u1"), bearer(t, "u1")
+
+	if status := authedStatus(t, s, "GET", "/api/v1/workouts", token); status != 200 {
+		t.Fatalf("expected the token accepted before logging out, got %d", status)
+	}
+	if status := authedStatus(t, s, "POST", "/api/v1/auth/logout", token); status != 204 {
+		t.Fatalf("expected to log out, got %d", status)
+	}
+	if status := authedStatus(t, s, "GET", "/api/v1/workouts", token); status != 401 {
+		t.Errorf("expected the logged-out token rejected, got %d", status)
+	}
+	if status := authedStatus(t, s, "POST", "/api/v1/auth/logout", token); status != 401 {
+		t.Errorf("expected logging out twice rejected, got %d", status)
+	}
+	if status := authedStatus(t, s, "GET", "/api/v1/workouts", other); status != 200 {
+		t.Errorf("expected the user's other token still accepted, got %d", status)
+	}
+}
+
+func TestLogoutTokenWithoutJTI(t *testing.T) {
+	s, _ := newFakeServer(t)
+	s.cache = memstore.New().Client()
+	// Tokens issued before revocation existed have no jti
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"user_id": "u1",
+		"iat":     time.Now().Unix(),
+		"exp":     time.Now().Add(time.Hour).Unix(),
+	}).SignedString([]byte(os.Getenv("JWT_SECRET")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	token := "Bearer " + signed
+
+	if status := authedStatus(t, s, "POST", "/api/v1/auth/logout", token); status != 204 {
+		t.Fatalf("expected logging out to succeed, got %d", status)
+	}
+	// There is nothing to put on the revocation list, so the token keeps working until it expires
+	if status := authedStatus(t, s, "GET", "/api/v1/workouts", token); status != 200 {
+		t.Errorf("expected the token without a jti still accepted, got %d", status)
+	}
+	if revoked, err := s.isTokenRevoked(context.Background(), jwt.MapClaims{"user_id": "u1"}); err != nil || revoked {
+		t.Errorf("expected a token without a jti never reported revoked, got %v %v", revoked, err)
+	}
+}
@@ -0,0 +1,123 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"fitness-hack/internal/database"
+	"fitness-hack/internal/messages"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// POST /api/v1/users/merge
+func (s *FiberServer) mergeAccounts(c *fiber.Ctx) error {
+	var req database.MergeAccountsRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	targetID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	sourceID, err := s.resolveMergeSource(ctx, &req)
+	if err != nil {
+		LogAuthError(s, messages.AuthMergeVerificationFailed, err, c)
+		return errorResponse(c, fiber.StatusUnauthorized, "Invalid source account credentials")
+	}
+	if sourceID == targetID {
+		return errorResponse(c, fiber.StatusBadRequest, "Cannot merge an account into itself")
+	}
+
+	// The source's sessions are deleted with it, so their tokens are looked up beforehand to
+	// be revoked once the merge is done
+	sessions, err := s.db.ListUserSessions(ctx, sourceID)
+	if err != nil {
+		LogDatabaseError(s, "list_user_sessions", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to merge accounts")
+	}
+
+	result, err := s.db.MergeUsers(ctx, sourceID, targetID)
+	switch {
+	case errors.Is(err, database.ErrMergeSourceHeld):
+		return errorResponse(c, fiber.StatusConflict, "The source account is under a legal hold")
+	case errors.Is(err, database.ErrMergeVaults):
+		return errorResponse(c, fiber.StatusConflict, "Both accounts have a photo vault, and vaults can't be combined")
+	case errors.Is(err, database.ErrMergeIntegrations):
+		return errorResponse(c, fiber.StatusConflict, "Both accounts are connected to the same integration; disconnect one first")
+	case err != nil:
+		LogDatabaseError(s, "merge_users", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to merge accounts")
+	}
+
+	for _, session := range sessions {
+		claims := jwt.MapClaims{"jti": session.Token_id, "exp": float64(session.Expires_at.Unix())}
+		if err := s.revokeToken(ctx, claims); err != nil {
+			LogCacheError(s, "revoke_token", err, c)
+		}
+	}
+
+	// Invalidate cache
+	s.DeleteCache(ctx, userCacheKey(sourceID))
+	s.DeleteCache(ctx, userCacheKey(targetID))
+	s.cache.Del(ctx, "users:list:*", "workouts:list:*", "workout_sessions:list:*")
+
+	return successResponse(c, result)
+}
+
+var (
+	errMergeRestrictedToken = errors.New("source token is restricted to a password reset or read-only impersonation")
+	errMergeSourceLocked    = errors.New("source account is disabled or has a password reset pending")
+)
+
+// resolveMergeSource returns the ID of the account the caller proved ownership of. The source
+// token comes in the body, past limitRestrictedTokens, so the restrictions it would apply are
+// checked here: impersonation and forced password reset tokens can't give an account away.
+// Neither can the password of a disabled account or one an admin required a new password for.
+func (s *FiberServer) resolveMergeSource(ctx context.Context, req *database.MergeAccountsRequest) (string, error) {
+	var user *database.Users
+	if req.SourceToken != "" {
+		claims, err := parseJWT(req.SourceToken)
+		if err != nil {
+			return "", err
+		}
+		if revoked, err := s.isTokenRevoked(ctx, claims); err != nil || revoked {
+			return "", fiber.ErrUnauthorized
+		}
+		_, impersonated := claims[claimImpersonatedBy]
+		reset, _ := claims[claimPasswordReset].(bool)
+		if impersonated || reset {
+			return "", errMergeRestrictedToken
+		}
+		userID, ok := claims["user_id"].(string)
+		if !ok {
+			return "", fiber.ErrUnauthorized
+		}
+		if user, err = s.db.GetUserByID(ctx, userID); err != nil {
+			return "", err
+		}
+	} else {
+		if req.SourceEmail == "" || req.SourcePassword == "" {
+			return "", fiber.ErrUnauthorized
+		}
+		var err error
+		if user, err = s.db.GetUserByEmail(ctx, req.SourceEmail); err != nil {
+			return "", err
+		}
+		if user.Password_hash == "" || !checkPasswordHash(req.SourcePassword, user.Password_hash) {
+			return "", fiber.ErrUnauthorized
+		}
+	}
+
+	if user.Disabled_at != nil || user.Password_reset_required {
+		return "", errMergeSourceLocked
+	}
+	return user.Id, nil
+}
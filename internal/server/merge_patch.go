@@ -0,0 +1,87 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"reflect"
+	"sort"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// mergePatchContentType is the media type of RFC 7386 JSON merge patches
+const mergePatchContentType = "application/merge-patch+json"
+
+// patchFields lists the JSON fields a PATCH may change, mapped to whether the field may
+// be set to null to clear it
+type patchFields map[string]bool
+
+// acceptMergePatch rejects PATCH bodies that aren't merge patches. Plain application/json
+// is accepted too since many HTTP clients can't set a custom content type.
+func acceptMergePatch(c *fiber.Ctx) error {
+	mediaType, _, err := mime.ParseMediaType(c.Get(fiber.HeaderContentType))
+	if err != nil || (mediaType != mergePatchContentType && mediaType != fiber.MIMEApplicationJSON) {
+		return errorResponse(c, fiber.StatusUnsupportedMediaType, "PATCH requests must be sent as "+mergePatchContentType)
+	}
+	return c.Next()
+}
+
+// applyMergePatch decodes the RFC 7386 merge patch in body into dst, one of the update
+// request structs whose pointer fields PUT handlers already apply to the stored record.
+// Scalars and arrays are replaced wholesale by a merge patch, so applying the set fields
+// to the current record is the merge the RFC describes; the few object fields are merged
+// member by member by the handler that owns them. Fields set to null are returned so
+// callers can clear them.
+func applyMergePatch(body []byte, dst interface{}, fields patchFields) (map[string]bool, error) {
+	var patch map[string]json.RawMessage
+	if err := json.Unmarshal(body, &patch); err != nil || patch == nil {
+		return nil, errors.New("patch must be a JSON object")
+	}
+
+	// Check fields in a stable order so the same bad patch always gets the same error
+	names := make([]string, 0, len(patch))
+	for name := range patch {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	cleared := map[string]bool{}
+	for _, name := range names {
+		nullable, ok := fields[name]
+		if !ok {
+			return nil, fmt.Errorf("%s cannot be changed", name)
+		}
+		if string(bytes.TrimSpace(patch[name])) == "null" {
+			if !nullable {
+				return nil, fmt.Errorf("%s cannot be null", name)
+			}
+			cleared[name] = true
+		}
+	}
+
+	if err := json.Unmarshal(body, dst); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) && typeErr.Field != "" {
+			return nil, fmt.Errorf("%s must be a %s", typeErr.Field, jsonTypeName(typeErr.Type.Kind()))
+		}
+		return nil, errors.New("patch has values of the wrong type")
+	}
+	return cleared, nil
+}
+
+// jsonTypeName describes a Go kind in the terms of JSON for error messages
+func jsonTypeName(kind reflect.Kind) string {
+	switch kind {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	default:
+		return "number"
+	}
+}
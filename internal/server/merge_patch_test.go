@@ -0,0 +1,69 @@
+package server
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"fitness-hack/internal/database"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestApplyMergePatch(t *testing.T) {
+	var req database.UpdateWorkoutSessionRequest
+	cleared, err := applyMergePatch([]byte(`{"name":"Leg day","completedAt":null,"notes":null}`), &req, workoutSessionPatchFields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Name == nil || *req.Name != "Leg day" {
+		t.Fatalf("name = %v, want Leg day", req.Name)
+	}
+	if req.CompletedAt != nil || req.Notes != nil || req.DurationMinutes != nil {
+		t.Fatalf("unpatched or nulled fields were set: %+v", req)
+	}
+	if len(cleared) != 2 || !cleared["completedAt"] || !cleared["notes"] {
+		t.Fatalf("cleared = %v, want completedAt and notes", cleared)
+	}
+
+	tests := []struct {
+		body string
+		want string
+	}{
+		{`[]`, "patch must be a JSON object"},
+		{`null`, "patch must be a JSON object"},
+		{`{"userId":"x"}`, "userId cannot be changed"},
+		{`{"name":null}`, "name cannot be null"},
+		{`{"zeta":1,"alpha":1}`, "alpha cannot be changed"},
+		{`{"durationMinutes":"long"}`, "durationMinutes must be a number"},
+	}
+	for _, tt := range tests {
+		var req database.UpdateWorkoutSessionRequest
+		if _, err := applyMergePatch([]byte(tt.body), &req, workoutSessionPatchFields); err == nil || err.Error() != tt.want {
+			t.Errorf("applyMergePatch(%s) error = %v, want %q", tt.body, err, tt.want)
+		}
+	}
+}
+
+func TestAcceptMergePatch(t *testing.T) {
+	app := fiber.New()
+	app.Patch("/", acceptMergePatch, func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusNoContent) })
+
+	for contentType, want := range map[string]int{
+		"application/merge-patch+json":      fiber.StatusNoContent,
+		"application/json; charset=utf-8":   fiber.StatusNoContent,
+		"application/json-patch+json":       fiber.StatusUnsupportedMediaType,
+		"application/x-www-form-urlencoded": fiber.StatusUnsupportedMediaType,
+		"":                                  fiber.StatusUnsupportedMediaType,
+	} {
+		req := httptest.NewRequest("PATCH", "/", strings.NewReader(`{}`))
+		req.Header.Set("Content-Type", contentType)
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != want {
+			t.Errorf("Content-Type %q: status = %d, want %d", contentType, resp.StatusCode, want)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"fitness-hack/internal/database"
+	"fitness-hack/internal/database/dbtest"
+	"fitness-hack/internal/memstore"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// mergeStub records the merges the handler asks for instead of moving anything, and fails
+// them with err when it's set
+type mergeStub struct {
+	*dbtest.Fake
+	mu     sync.Mutex
+	merges [][2]string
+	err    error
+}
+
+func (m *mergeStub) MergeUsers(ctx context.Context, sourceID, targetID string) (*database.MergeResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.err != nil {
+		return nil, m.err
+	}
+	m.merges = append(m.merges, [2]string{sourceID, targetID})
+	return &database.MergeResult{SourceUserID: sourceID, TargetUserID: targetID}, nil
+}
+
+// newMergeServer returns a test server that records merges, with a source account
+// src@example.com whose password is "source-secret"
+func newMergeServer(t *testing.T) (*FiberServer, *mergeStub, *database.Users) {
+	t.Helper()
+	db := &mergeStub{Fake: dbtest.NewFake()}
+	s := newTestServer(t, db)
+	s.cache = memstore.New().Client()
+	hash, err := hashPassword("source-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	source, err := db.CreateUser(context.Background(), &database.Users{Email: "src@example.com", Username: "src", Password_hash: hash})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, db, source
+}
+
+// postMerge sends body to /users/merge as targetID and returns the status
+func postMerge(t *testing.T, s *FiberServer, targetID string, body database.MergeAccountsRequest) int {
+	t.Helper()
+	payload, _ := json.Marshal(body)
+	req := httptest.NewRequest("POST", "/api/v1/users/merge", strings.NewReader(string(payload)))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", bearer(t, targetID))
+	resp, err := s.App.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode
+}
+
+func TestMergeAccounts(t *testing.T) {
+	s, db, source := newMergeServer(t)
+	token, err := generateJWT(source.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if status := postMerge(t, s, "target", database.MergeAccountsRequest{SourceEmail: "src@example.com", SourcePassword: "wrong"}); status != fiber.StatusUnauthorized {
+		t.Errorf("expected a wrong password rejected, got %d", status)
+	}
+	if status := postMerge(t, s, "target", database.MergeAccountsRequest{SourceEmail: "src@example.com", SourcePassword: "source-secret"}); status != fiber.StatusOK {
+		t.Errorf("expected to merge with the source's password, got %d", status)
+	}
+	if status := postMerge(t, s, "target", database.MergeAccountsRequest{SourceToken: token}); status != fiber.StatusOK {
+		t.Errorf("expected to merge with the source's token, got %d", status)
+	}
+	if status := postMerge(t, s, source.Id, database.MergeAccountsRequest{SourceToken: token}); status != fiber.StatusBadRequest {
+		t.Errorf("expected merging an account into itself rejected, got %d", status)
+	}
+	if len(db.merges) != 2 || db.merges[0] != [2]string{source.Id, "target"} {
+		t.Errorf("expected two merges of the source into the caller, got %v", db.merges)
+	}
+}
+
+func TestMergeRejectsRestrictedSourceTokens(t *testing.T) {
+	s, db, source := newMergeServer(t)
+
+	for name, claims := range map[string]jwt.MapClaims{
+		"impersonation":  {claimImpersonatedBy: "admin-1"},
+		"password reset": {claimPasswordReset: true},
+	} {
+		token, err := generateJWTWithClaims(source.Id, time.Now().Add(time.Hour), claims)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if status := postMerge(t, s, "admin-1", database.MergeAccountsRequest{SourceToken: token}); status != fiber.StatusUnauthorized {
+			t.Errorf("expected an %s token rejected as the source, got %d", name, status)
+		}
+	}
+	if len(db.merges) != 0 {
+		t.Errorf("expected nothing merged, got %v", db.merges)
+	}
+}
+
+func TestMergeRejectsLockedSourceAccounts(t *testing.T) {
+	ctx := context.Background()
+	for name, lock := range map[string]func(db *mergeStub, userID string) error{
+		"disabled": func(db *mergeStub, userID string) error {
+			_, err := db.SetUserDisabled(ctx, userID, true)
+			return err
+		},
+		"password reset pending": func(db *mergeStub, userID string) error {
+			_, err := db.RequirePasswordReset(ctx, userID)
+			return err
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			s, db, source := newMergeServer(t)
+			token, err := generateJWT(source.Id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := lock(db, source.Id); err != nil {
+				t.Fatal(err)
+			}
+
+			if status := postMerge(t, s, "target", database.MergeAccountsRequest{SourceEmail: "src@example.com", SourcePassword: "source-secret"}); status != fiber.StatusUnauthorized {
+				t.Errorf("expected the source's password rejected, got %d", status)
+			}
+			if status := postMerge(t, s, "target", database.MergeAccountsRequest{SourceToken: token}); status != fiber.StatusUnauthorized {
+				t.Errorf("expected the source's token rejected, got %d", status)
+			}
+			if len(db.merges) != 0 {
+				t.Errorf("expected nothing merged, got %v", db.merges)
+			}
+		})
+	}
+}
+
+func TestMergeRefusesConflictingAccounts(t *testing.T) {
+	for _, err := range []error{database.ErrMergeSourceHeld, database.ErrMergeVaults, database.ErrMergeIntegrations} {
+		s, db, _ := newMergeServer(t)
+		db.err = err
+		if status := postMerge(t, s, "target", database.MergeAccountsRequest{SourceEmail: "src@example.com", SourcePassword: "source-secret"}); status != fiber.StatusConflict {
+			t.Errorf("expected %q to be a conflict, got %d", err, status)
+		}
+	}
+}
+
+func TestMergeRevokesSourceSessions(t *testing.T) {
+	s, db, source := newMergeServer(t)
+	ctx := context.Background()
+	expires := time.Now().Add(time.Hour)
+	if _, err := db.CreateUserSession(ctx, &database.User_sessions{User_id: source.Id, Token_id: "source-jti", Expires_at: expires}); err != nil {
+		t.Fatal(err)
+	}
+	claims := jwt.MapClaims{"jti": "source-jti", "exp": float64(expires.Unix())}
+
+	db.err = database.ErrMergeVaults
+	postMerge(t, s, "target", database.MergeAccountsRequest{SourceEmail: "src@example.com", SourcePassword: "source-secret"})
+	if revoked, err := s.isTokenRevoked(ctx, claims); err != nil || revoked {
+		t.Fatalf("expected the source's token kept after a refused merge, got %v %v", revoked, err)
+	}
+
+	db.err = nil
+	if status := postMerge(t, s, "target", database.MergeAccountsRequest{SourceEmail: "src@example.com", SourcePassword: "source-secret"}); status != fiber.StatusOK {
+		t.Fatalf("expected the merge to succeed, got %d", status)
+	}
+	if revoked, err := s.isTokenRevoked(ctx, claims); err != nil || !revoked {
+		t.Errorf("expected the source's token revoked after the merge, got %v %v", revoked, err)
+	}
+}
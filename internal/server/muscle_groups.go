@@ -0,0 +1,110 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"fitness-hack/internal/database"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// muscleGroupsCacheKey holds the muscle taxonomy, which only changes with migrations
+const muscleGroupsCacheKey = "muscle_groups"
+
+// maxExerciseMuscles bounds how many muscles one exercise can be given
+const maxExerciseMuscles = 20
+
+// muscleAssignments turns the muscles of a request into assignments by slug, with
+// muscleGroup standing for one primary muscle when muscles is empty. Returns a message for
+// the first problem found.
+func muscleAssignments(muscleGroup string, muscles []database.MuscleRequest) ([]database.MuscleAssignment, string) {
+	assignments := []database.MuscleAssignment{}
+	if len(muscles) == 0 {
+		if slug := database.MuscleSlug(muscleGroup); slug != "" {
+			assignments = append(assignments, database.MuscleAssignment{Muscle: slug, Role: database.Exercise_muscle_groups_role_primary})
+		}
+		return assignments, ""
+	}
+
+	if len(muscles) > maxExerciseMuscles {
+		return nil, fmt.Sprintf("muscles must contain at most %d items", maxExerciseMuscles)
+	}
+	for i, m := range muscles {
+		slug := database.MuscleSlug(m.Muscle)
+		if slug == "" {
+			return nil, fmt.Sprintf("muscles[%d].muscle is required", i)
+		}
+		role := database.Exercise_muscle_groups_role_primary
+		if m.Role != "" {
+			var err error
+			if role, err = database.ParseExercise_muscle_groups_role(m.Role); err != nil {
+				return nil, fmt.Sprintf("muscles[%d].role must be primary or secondary", i)
+			}
+		}
+		assignments = append(assignments, database.MuscleAssignment{Muscle: slug, Role: role})
+	}
+	return assignments, ""
+}
+
+// muscleGroups returns the muscle taxonomy, from the cache when it can
+func (s *FiberServer) muscleGroups(ctx context.Context) ([]database.Muscle_groups, error) {
+	if cachedData, err := s.GetCache(ctx, muscleGroupsCacheKey); err == nil {
+		var groups []database.Muscle_groups
+		if json.Unmarshal([]byte(cachedData), &groups) == nil {
+			return groups, nil
+		}
+	}
+
+	groups, err := s.db.ListMuscleGroups(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if data, err := json.Marshal(groups); err == nil {
+		s.SetCache(ctx, muscleGroupsCacheKey, string(data), time.Hour)
+	}
+	return groups, nil
+}
+
+// unknownMuscle returns the first assigned muscle that isn't in the taxonomy, or ""
+func (s *FiberServer) unknownMuscle(ctx context.Context, assignments []database.MuscleAssignment) (string, error) {
+	if len(assignments) == 0 {
+		return "", nil
+	}
+	groups, err := s.muscleGroups(ctx)
+	if err != nil {
+		return "", err
+	}
+	known := make(map[string]bool, len(groups))
+	for _, g := range groups {
+		known[g.Slug] = true
+	}
+	for _, a := range assignments {
+		if !known[a.Muscle] {
+			return a.Muscle, nil
+		}
+	}
+	return "", nil
+}
+
+// GET /api/v1/muscle-groups
+func (s *FiberServer) listMuscleGroups(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	groups, err := s.muscleGroups(ctx)
+	if err != nil {
+		LogDatabaseError(s, "list_muscle_groups", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to fetch muscle groups")
+	}
+
+	response := make([]database.MuscleGroupResponse, len(groups))
+	tags := make([]string, len(groups))
+	for i, g := range groups {
+		response[i] = database.MuscleGroupResponse{Slug: g.Slug, Name: g.Name}
+		tags[i] = resourceETag(g.Id, g.Created_at)
+	}
+	return respondWithETag(c, listETag(tags), response)
+}
@@ -0,0 +1,88 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"fitness-hack/internal/database"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestMuscleAssignments(t *testing.T) {
+	primary, secondary := database.Exercise_muscle_groups_role_primary, database.Exercise_muscle_groups_role_secondary
+
+	got, msg := muscleAssignments("Upper Back", nil)
+	if want := []database.MuscleAssignment{{Muscle: "upper-back", Role: primary}}; msg != "" || !reflect.DeepEqual(got, want) {
+		t.Errorf("muscleGroup shorthand = %v %q, want %v", got, msg, want)
+	}
+	got, msg = muscleAssignments("Legs", []database.MuscleRequest{{Muscle: "Hamstrings"}, {Muscle: "glutes", Role: "secondary"}})
+	if want := []database.MuscleAssignment{{Muscle: "hamstrings", Role: primary}, {Muscle: "glutes", Role: secondary}}; msg != "" || !reflect.DeepEqual(got, want) {
+		t.Errorf("muscles = %v %q, want %v", got, msg, want)
+	}
+	if got, msg := muscleAssignments("", nil); msg != "" || len(got) != 0 || got == nil {
+		t.Errorf("no muscles = %#v %q, want an empty list", got, msg)
+	}
+
+	for name, muscles := range map[string][]database.MuscleRequest{
+		"missing muscle": {{Role: "primary"}},
+		"bad role":       {{Muscle: "lats", Role: "tertiary"}},
+	} {
+		if _, msg := muscleAssignments("", muscles); msg == "" {
+			t.Errorf("%s: expected the muscles to be rejected", name)
+		}
+	}
+}
+
+func TestListExercisesByMuscle(t *testing.T) {
+	s, db := newFakeServer(t)
+	ctx := context.Background()
+	give := func(name string, muscles ...database.MuscleAssignment) {
+		t.Helper()
+		exercise, err := db.CreateExercise(ctx, &database.Exercises{Name: name})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := db.SetExerciseMuscles(ctx, exercise.Id, muscles); err != nil {
+			t.Fatal(err)
+		}
+	}
+	give("Romanian Deadlift", database.MuscleAssignment{Muscle: "hamstrings", Role: "primary"}, database.MuscleAssignment{Muscle: "glutes", Role: "secondary"})
+	give("Squat", database.MuscleAssignment{Muscle: "quadriceps", Role: "primary"}, database.MuscleAssignment{Muscle: "hamstrings", Role: "secondary"})
+	give("Bench Press", database.MuscleAssignment{Muscle: "chest", Role: "primary"})
+	auth := bearer(t, "u1")
+
+	list := func(query string) (int, []database.ExerciseResponse) {
+		t.Helper()
+		req := httptest.NewRequest("GET", "/api/v1/exercises"+query, nil)
+		req.Header.Set("Authorization", auth)
+		resp, err := s.App.Test(req, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var envelope struct {
+			Data []database.ExerciseResponse `json:"data"`
+		}
+		json.NewDecoder(resp.Body).Decode(&envelope)
+		return resp.StatusCode, envelope.Data
+	}
+
+	if status, got := list("?muscle=hamstrings&sort=name"); status != fiber.StatusOK || len(got) != 2 || got[0].Name != "Romanian Deadlift" || got[1].Name != "Squat" {
+		t.Errorf("?muscle=hamstrings = %d %v, want both hamstring exercises", status, got)
+	}
+	status, got := list("?muscle=Hamstrings&role=secondary")
+	if status != fiber.StatusOK || len(got) != 1 || got[0].Name != "Squat" {
+		t.Fatalf("?muscle=Hamstrings&role=secondary = %d %v, want Squat", status, got)
+	}
+	if got[0].MuscleGroup != "quadriceps" || len(got[0].Muscles) != 2 || got[0].Muscles[1] != (database.ExerciseMuscleResponse{Slug: "hamstrings", Name: "hamstrings", Role: "secondary"}) {
+		t.Errorf("Squat = %+v, want quadriceps primary and hamstrings secondary", got[0])
+	}
+	for _, query := range []string{"?muscle=chest&role=tertiary", "?role=primary"} {
+		if status, _ := list(query); status != fiber.StatusBadRequest {
+			t.Errorf("GET %s = %d, want 400", query, status)
+		}
+	}
+}
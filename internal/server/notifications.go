@@ -0,0 +1,303 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"fitness-hack/internal/database"
+	"fitness-hack/internal/messages"
+	"fitness-hack/internal/push"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// workoutReminderBatch is how many reminders are claimed per query
+const workoutReminderBatch = 100
+
+// Helper to convert database device to response model
+func deviceToResponse(device *database.Devices) database.DeviceResponse {
+	return database.DeviceResponse{
+		ID:        device.Id,
+		Platform:  string(device.Platform),
+		Name:      device.Name,
+		CreatedAt: device.Created_at,
+		UpdatedAt: device.Updated_at,
+	}
+}
+
+// Helper to convert database notification preferences to response model
+func notificationPreferencesToResponse(prefs *database.Notification_preferences) database.NotificationPreferencesResponse {
+	resp := database.NotificationPreferencesResponse{
+		WorkoutReminders:   prefs.Workout_reminders,
+		ReminderAfterDays:  prefs.Reminder_after_days,
+		PersonalRecords:    prefs.Personal_records,
+		WeeklySummaryEmail: prefs.Weekly_summary_email,
+	}
+	if prefs.Quiet_hours_start != nil && prefs.Quiet_hours_end != nil {
+		resp.QuietHoursStart = formatClock(*prefs.Quiet_hours_start)
+		resp.QuietHoursEnd = formatClock(*prefs.Quiet_hours_end)
+	}
+	return resp
+}
+
+// POST /api/v1/devices
+func (s *FiberServer) registerDevice(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	var req database.RegisterDeviceRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+	req.Token = strings.TrimSpace(req.Token)
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Platform != push.PlatformIOS && req.Platform != push.PlatformAndroid {
+		return errorResponse(c, fiber.StatusBadRequest, "platform must be ios or android")
+	}
+	if req.Token == "" || len(req.Token) > 4096 {
+		return errorResponse(c, fiber.StatusBadRequest, "token is required and must be at most 4096 characters")
+	}
+	if len(req.Name) > 100 {
+		return errorResponse(c, fiber.StatusBadRequest, "name must be at most 100 characters")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	device, err := s.db.RegisterDevice(ctx, &database.Devices{
+		User_id:  userID,
+		Platform: database.Devices_platform(req.Platform),
+		Token:    req.Token,
+		Name:     req.Name,
+	})
+	if err != nil {
+		LogDatabaseError(s, "register_device", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to register device")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"data": deviceToResponse(device)})
+}
+
+// GET /api/v1/devices
+func (s *FiberServer) listDevices(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	devices, err := s.db.ListDevices(ctx, userID)
+	if err != nil {
+		LogDatabaseError(s, "list_devices", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to list devices")
+	}
+
+	responses := make([]database.DeviceResponse, len(devices))
+	for i := range devices {
+		responses[i] = deviceToResponse(&devices[i])
+	}
+	return successResponse(c, responses)
+}
+
+// DELETE /api/v1/devices/:id
+func (s *FiberServer) deleteDevice(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err = s.db.DeleteDevice(ctx, c.Params("id"), userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return notFound(c, "Device")
+	}
+	if err != nil {
+		LogDatabaseError(s, "delete_device", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to delete device")
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// GET /api/v1/users/me/notification-preferences
+func (s *FiberServer) getNotificationPreferences(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	prefs, err := s.db.GetNotificationPreferences(ctx, userID)
+	if err != nil {
+		LogDatabaseError(s, "get_notification_preferences", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to get notification preferences")
+	}
+	return successResponse(c, notificationPreferencesToResponse(prefs))
+}
+
+// PUT /api/v1/users/me/notification-preferences
+func (s *FiberServer) updateNotificationPreferences(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	var req database.UpdateNotificationPreferencesRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+	if req.ReminderAfterDays != nil && (*req.ReminderAfterDays < 1 || *req.ReminderAfterDays > 30) {
+		return errorResponse(c, fiber.StatusBadRequest, "reminderAfterDays must be between 1 and 30")
+	}
+	if (req.QuietHoursStart == nil) != (req.QuietHoursEnd == nil) {
+		return errorResponse(c, fiber.StatusBadRequest, "quietHoursStart and quietHoursEnd must be set together")
+	}
+	var quietStart, quietEnd *int
+	if req.QuietHoursStart != nil && (*req.QuietHoursStart != "" || *req.QuietHoursEnd != "") {
+		start, err := parseClock(*req.QuietHoursStart)
+		if err != nil {
+			return errorResponse(c, fiber.StatusBadRequest, "quietHoursStart "+err.Error())
+		}
+		end, err := parseClock(*req.QuietHoursEnd)
+		if err != nil {
+			return errorResponse(c, fiber.StatusBadRequest, "quietHoursEnd "+err.Error())
+		}
+		quietStart, quietEnd = &start, &end
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	prefs, err := s.db.GetNotificationPreferences(ctx, userID)
+	if err != nil {
+		LogDatabaseError(s, "get_notification_preferences", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to update notification preferences")
+	}
+	if req.WorkoutReminders != nil {
+		prefs.Workout_reminders = *req.WorkoutReminders
+	}
+	if req.ReminderAfterDays != nil {
+		prefs.Reminder_after_days = *req.ReminderAfterDays
+	}
+	if req.PersonalRecords != nil {
+		prefs.Personal_records = *req.PersonalRecords
+	}
+	if req.QuietHoursStart != nil {
+		prefs.Quiet_hours_start, prefs.Quiet_hours_end = quietStart, quietEnd
+	}
+	if req.WeeklySummaryEmail != nil {
+		prefs.Weekly_summary_email = *req.WeeklySummaryEmail
+	}
+
+	updated, err := s.db.UpdateNotificationPreferences(ctx, prefs)
+	if err != nil {
+		LogDatabaseError(s, "update_notification_preferences", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to update notification preferences")
+	}
+	return successResponse(c, notificationPreferencesToResponse(updated))
+}
+
+// sendPushNotification sends a notification to each of the user's devices, forgetting
+// devices whose tokens the provider no longer accepts
+func (s *FiberServer) sendPushNotification(ctx context.Context, userID string, n push.Notification) {
+	devices, err := s.db.ListDevices(ctx, userID)
+	if err != nil {
+		s.logError("ERROR", messages.PushDevicesLoadFailed, err, nil, map[string]interface{}{
+			"component": "push",
+			"user_id":   userID,
+		})
+		return
+	}
+
+	for _, device := range devices {
+		err := s.notifier.Send(ctx, string(device.Platform), device.Token, n)
+		switch {
+		case err == nil, errors.Is(err, push.ErrNotConfigured):
+		case errors.Is(err, push.ErrInvalidToken):
+			if err := s.db.DeleteDeviceByToken(ctx, device.Token); err != nil {
+				s.logError("ERROR", messages.PushTokenRemoveFailed, err, nil, map[string]interface{}{
+					"component": "push",
+					"device_id": device.Id,
+				})
+			}
+		default:
+			s.logError("WARN", messages.PushFailed, err, nil, map[string]interface{}{
+				"component": "push",
+				"device_id": device.Id,
+				"platform":  device.Platform,
+			})
+		}
+	}
+}
+
+// notifyPersonalRecord tells the user about a new personal record, unless they turned these off
+func (s *FiberServer) notifyPersonalRecord(record *database.PersonalRecord) {
+	// Each device is a separate request to the provider, so allow more than the usual budget
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	prefs, err := s.db.GetNotificationPreferences(ctx, record.User_id)
+	if err != nil || !prefs.Personal_records {
+		return
+	}
+
+	title := "New personal record!"
+	if exercise, err := s.db.GetExerciseByID(ctx, record.Exercise_id); err == nil {
+		if exercise.Name != "" {
+			title = "New personal record: " + exercise.Name
+		}
+	}
+	s.sendPushNotification(ctx, record.User_id, push.Notification{
+		Title: title,
+		Body: fmt.Sprintf("%s kg for %d reps, up from your previous best of %s kg.",
+			record.Weight_kg.String(), record.Reps, record.Previous_best_kg.String()),
+		Data: map[string]string{
+			"type":       "pr.achieved",
+			"workoutId":  record.Workout_id,
+			"exerciseId": record.Exercise_id,
+		},
+	})
+}
+
+// StartWorkoutReminders periodically reminds users who haven't trained in a while
+func (s *FiberServer) StartWorkoutReminders(ctx context.Context) {
+	s.runPeriodically(ctx, getEnvDuration("WORKOUT_REMINDER_INTERVAL", time.Hour), s.sendWorkoutReminders)
+}
+
+func (s *FiberServer) sendWorkoutReminders(ctx context.Context) {
+	for ctx.Err() == nil {
+		claimCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		userIDs, err := s.db.ClaimWorkoutReminders(claimCtx, workoutReminderBatch)
+		cancel()
+		if err != nil {
+			s.logError("ERROR", messages.WorkoutRemindersFailed, err, nil, map[string]interface{}{
+				"component": "push",
+			})
+			return
+		}
+
+		for _, userID := range userIDs {
+			sendCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+			s.sendPushNotification(sendCtx, userID, push.Notification{
+				Title: "Ready for your next workout?",
+				Body:  "It's been a few days since your last session. Even a short one keeps your progress going.",
+				Data:  map[string]string{"type": "workout.reminder"},
+			})
+			cancel()
+		}
+		if len(userIDs) < workoutReminderBatch {
+			return
+		}
+	}
+}
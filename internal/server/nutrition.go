@@ -0,0 +1,819 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"fitness-hack/internal/database"
+	"fitness-hack/internal/fooddb"
+	"fitness-hack/internal/messages"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	// maxFoodNutrient and maxLogNutrient are the largest values the foods and
+	// nutrition_logs columns hold
+	maxFoodNutrient = 99999.99
+	maxLogNutrient  = 999999.99
+	maxServings     = 9999.99
+
+	foodSearchDefaultLimit = 20
+	foodSearchMaxLimit     = 50
+)
+
+// Helper to convert database food to response model
+func foodToResponse(food *database.Foods) database.FoodResponse {
+	return database.FoodResponse{
+		ID:          food.Id,
+		Name:        food.Name,
+		Brand:       food.Brand,
+		Barcode:     food.Barcode,
+		ServingSize: food.Serving_size.InexactFloat64(),
+		ServingUnit: string(food.Serving_unit),
+		Calories:    food.Calories.InexactFloat64(),
+		ProteinG:    food.Protein_g.InexactFloat64(),
+		CarbsG:      food.Carbs_g.InexactFloat64(),
+		FatG:        food.Fat_g.InexactFloat64(),
+		Custom:      food.User_id != nil,
+		Source:      food.Source,
+		CreatedAt:   food.Created_at,
+		UpdatedAt:   food.Updated_at,
+		Version:     food.Version,
+	}
+}
+
+// Helper to convert database nutrition log to response model
+func nutritionLogToResponse(entry *database.Nutrition_logs) database.NutritionLogResponse {
+	return database.NutritionLogResponse{
+		ID:        entry.Id,
+		FoodID:    entry.Food_id,
+		Name:      entry.Name,
+		Meal:      string(entry.Meal),
+		Servings:  entry.Servings.InexactFloat64(),
+		Calories:  entry.Calories.InexactFloat64(),
+		ProteinG:  entry.Protein_g.InexactFloat64(),
+		CarbsG:    entry.Carbs_g.InexactFloat64(),
+		FatG:      entry.Fat_g.InexactFloat64(),
+		EatenAt:   entry.Eaten_at,
+		Notes:     entry.Notes,
+		CreatedAt: entry.Created_at,
+		UpdatedAt: entry.Updated_at,
+		Version:   entry.Version,
+	}
+}
+
+// nutrient converts a nutrient amount to the two decimals it is stored with
+func nutrient(name string, value, max float64) (decimal.Decimal, error) {
+	if value < 0 || value > max {
+		return decimal.Zero, fmt.Errorf("%s must be between 0 and %v", name, max)
+	}
+	return decimal.NewFromFloat(value).Round(2), nil
+}
+
+// validBarcode reports whether code looks like an EAN-8, UPC-A, EAN-13 or GTIN-14 code
+func validBarcode(code string) bool {
+	if len(code) < 8 || len(code) > 14 {
+		return false
+	}
+	for _, r := range code {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// validateFood checks a food's description and nutrients
+func validateFood(food *database.Foods) error {
+	food.Name = strings.TrimSpace(food.Name)
+	food.Brand = strings.TrimSpace(food.Brand)
+	food.Barcode = strings.TrimSpace(food.Barcode)
+	if food.Name == "" || len(food.Name) > 200 {
+		return errors.New("name is required and must be at most 200 characters")
+	}
+	if len(food.Brand) > 200 {
+		return errors.New("brand must be at most 200 characters")
+	}
+	if food.Barcode != "" && !validBarcode(food.Barcode) {
+		return errors.New("barcode must be an EAN or UPC code of 8 to 14 digits")
+	}
+	if !food.Serving_unit.Valid() {
+		return errors.New("servingUnit must be g, ml or piece")
+	}
+	if !food.Serving_size.IsPositive() || food.Serving_size.GreaterThan(decimal.NewFromFloat(maxFoodNutrient)) {
+		return fmt.Errorf("servingSize must be greater than 0 and at most %v", maxFoodNutrient)
+	}
+	return nil
+}
+
+// setFoodNutrients sets the nutrients of a food from request values, leaving those that
+// are nil unchanged
+func setFoodNutrients(food *database.Foods, calories, protein, carbs, fat *float64) error {
+	for _, n := range []struct {
+		name  string
+		value *float64
+		dst   *decimal.Decimal
+	}{
+		{"calories", calories, &food.Calories},
+		{"proteinG", protein, &food.Protein_g},
+		{"carbsG", carbs, &food.Carbs_g},
+		{"fatG", fat, &food.Fat_g},
+	} {
+		if n.value == nil {
+			continue
+		}
+		value, err := nutrient(n.name, *n.value, maxFoodNutrient)
+		if err != nil {
+			return err
+		}
+		*n.dst = value
+	}
+	return nil
+}
+
+// setLogNutrients sets the totals of an entry from request values, leaving those that are
+// nil unchanged
+func setLogNutrients(entry *database.Nutrition_logs, calories, protein, carbs, fat *float64) error {
+	for _, n := range []struct {
+		name  string
+		value *float64
+		dst   *decimal.Decimal
+	}{
+		{"calories", calories, &entry.Calories},
+		{"proteinG", protein, &entry.Protein_g},
+		{"carbsG", carbs, &entry.Carbs_g},
+		{"fatG", fat, &entry.Fat_g},
+	} {
+		if n.value == nil {
+			continue
+		}
+		value, err := nutrient(n.name, *n.value, maxLogNutrient)
+		if err != nil {
+			return err
+		}
+		*n.dst = value
+	}
+	return nil
+}
+
+// scaleNutritionLog changes an entry's servings, scaling its totals to match
+func scaleNutritionLog(entry *database.Nutrition_logs, servings decimal.Decimal) {
+	if entry.Servings.IsPositive() {
+		scale := func(d decimal.Decimal) decimal.Decimal {
+			return d.Mul(servings).Div(entry.Servings).Round(2)
+		}
+		entry.Calories, entry.Protein_g = scale(entry.Calories), scale(entry.Protein_g)
+		entry.Carbs_g, entry.Fat_g = scale(entry.Carbs_g), scale(entry.Fat_g)
+	}
+	entry.Servings = servings
+}
+
+// parseServings checks a number of servings
+func parseServings(value float64) (decimal.Decimal, error) {
+	if value <= 0 || value > maxServings {
+		return decimal.Zero, fmt.Errorf("servings must be greater than 0 and at most %v", maxServings)
+	}
+	return decimal.NewFromFloat(value).Round(2), nil
+}
+
+// validateNutritionLog checks an entry's text and that its totals fit
+func validateNutritionLog(entry *database.Nutrition_logs) error {
+	entry.Name = strings.TrimSpace(entry.Name)
+	entry.Notes = strings.TrimSpace(entry.Notes)
+	if entry.Name == "" || len(entry.Name) > 200 {
+		return errors.New("name is required and must be at most 200 characters")
+	}
+	if len(entry.Notes) > 500 {
+		return errors.New("notes must be at most 500 characters")
+	}
+	if !entry.Meal.Valid() {
+		return errors.New("meal must be breakfast, lunch, dinner or snack")
+	}
+	max := decimal.NewFromFloat(maxLogNutrient)
+	for _, total := range []decimal.Decimal{entry.Calories, entry.Protein_g, entry.Carbs_g, entry.Fat_g} {
+		if total.GreaterThan(max) {
+			return fmt.Errorf("nutrients of an entry must be at most %v in total", maxLogNutrient)
+		}
+	}
+	return nil
+}
+
+// POST /api/v1/nutrition/foods
+func (s *FiberServer) createFood(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	var req database.CreateFoodRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+	food := database.Foods{
+		User_id:      &userID,
+		Name:         req.Name,
+		Brand:        req.Brand,
+		Barcode:      req.Barcode,
+		Serving_size: decimal.NewFromInt(100),
+		Serving_unit: database.Foods_serving_unit(req.ServingUnit),
+	}
+	if req.ServingUnit == "" {
+		food.Serving_unit = database.Foods_serving_unit_g
+	}
+	if req.ServingSize != nil {
+		food.Serving_size = decimal.NewFromFloat(*req.ServingSize).Round(2)
+	}
+	if err := setFoodNutrients(&food, &req.Calories, &req.ProteinG, &req.CarbsG, &req.FatG); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+	if err := validateFood(&food); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	created, err := s.db.CreateFood(ctx, &food)
+	if err != nil {
+		LogDatabaseError(s, "create_food", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to create food")
+	}
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"data": foodToResponse(created)})
+}
+
+// GET /api/v1/nutrition/foods/search?q=oats&barcode=&limit=20
+// Searches the catalog and the user's own foods by name or brand, or by barcode. When a
+// food database is configured, foods it has that the catalog lacks are added to it.
+func (s *FiberServer) searchFoods(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	query, barcode := strings.TrimSpace(c.Query("q")), strings.TrimSpace(c.Query("barcode"))
+	if barcode != "" {
+		if !validBarcode(barcode) {
+			return errorResponse(c, fiber.StatusBadRequest, "barcode must be an EAN or UPC code of 8 to 14 digits")
+		}
+		query = ""
+	} else if len([]rune(query)) < 2 {
+		return errorResponse(c, fiber.StatusBadRequest, "q must be at least 2 characters, or search by barcode")
+	}
+	limit := c.QueryInt("limit", foodSearchDefaultLimit)
+	if limit < 1 || limit > foodSearchMaxLimit {
+		return errorResponse(c, fiber.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", foodSearchMaxLimit))
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), 10*time.Second)
+	defer cancel()
+
+	foods, err := s.db.SearchFoods(ctx, userID, query, barcode, limit)
+	if err != nil {
+		LogDatabaseError(s, "search_foods", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to search foods")
+	}
+
+	// The catalog's own results are returned even if the food database can't be reached
+	if s.foodDB != nil && len(foods) < limit && (barcode == "" || len(foods) == 0) {
+		found, err := s.lookupExternalFoods(ctx, query, barcode, limit-len(foods))
+		if err != nil {
+			LogError(s, "WARN", messages.FoodDatabaseLookupFailed, err, c, map[string]interface{}{"component": "nutrition"})
+		}
+		seen := make(map[string]bool, len(foods))
+		for _, food := range foods {
+			seen[food.Id] = true
+		}
+		for _, food := range found {
+			if !seen[food.Id] {
+				seen[food.Id] = true
+				foods = append(foods, food)
+			}
+		}
+	}
+
+	responses := make([]database.FoodResponse, len(foods))
+	for i := range foods {
+		responses[i] = foodToResponse(&foods[i])
+	}
+	return successResponse(c, responses)
+}
+
+// lookupExternalFoods finds foods in the food database and adds them to the catalog,
+// returning those saved before any error
+func (s *FiberServer) lookupExternalFoods(ctx context.Context, query, barcode string, limit int) ([]database.Foods, error) {
+	var found []fooddb.Food
+	if barcode != "" {
+		food, err := s.foodDB.Barcode(ctx, barcode)
+		if errors.Is(err, fooddb.ErrNotFound) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		found = []fooddb.Food{*food}
+	} else {
+		var err error
+		if found, err = s.foodDB.Search(ctx, query, limit); err != nil {
+			return nil, err
+		}
+	}
+
+	saved := make([]database.Foods, 0, len(found))
+	for _, external := range found {
+		food := database.Foods{
+			Name:         external.Name,
+			Brand:        external.Brand,
+			Barcode:      external.Barcode,
+			Serving_size: decimal.NewFromFloat(external.ServingSize).Round(2),
+			Serving_unit: database.Foods_serving_unit(external.ServingUnit),
+			Source:       external.Source,
+			External_id:  external.ExternalID,
+		}
+		// Foods the app can't store are left out rather than failing the search
+		if setFoodNutrients(&food, &external.Calories, &external.ProteinG, &external.CarbsG, &external.FatG) != nil ||
+			validateFood(&food) != nil || external.ExternalID == "" {
+			continue
+		}
+		stored, err := s.db.UpsertExternalFood(ctx, &food)
+		if err != nil {
+			return saved, err
+		}
+		saved = append(saved, *stored)
+	}
+	return saved, nil
+}
+
+// GET /api/v1/nutrition/foods/:id
+func (s *FiberServer) getFood(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	food, err := s.db.GetFood(ctx, c.Params("id"), userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return notFound(c, "Food")
+	}
+	if err != nil {
+		LogDatabaseError(s, "get_food", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to get food")
+	}
+	return respondWithETag(c, resourceETag(food.Id, food.Updated_at), foodToResponse(food))
+}
+
+// PUT /api/v1/nutrition/foods/:id
+func (s *FiberServer) updateFood(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	var req database.UpdateFoodRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	return s.saveFoodUpdate(c, userID, req)
+}
+
+// foodPatchFields are the food fields a merge patch may change
+var foodPatchFields = patchFields{
+	"name":        false,
+	"brand":       false,
+	"barcode":     false,
+	"servingSize": false,
+	"servingUnit": false,
+	"calories":    false,
+	"proteinG":    false,
+	"carbsG":      false,
+	"fatG":        false,
+	"version":     false,
+}
+
+// PATCH /api/v1/nutrition/foods/:id
+func (s *FiberServer) patchFood(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	var req database.UpdateFoodRequest
+	if _, err := applyMergePatch(c.Body(), &req, foodPatchFields); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	return s.saveFoodUpdate(c, userID, req)
+}
+
+// saveFoodUpdate applies the set fields of req to one of the user's own foods, validates it
+// and saves it. Catalog foods can't be changed.
+func (s *FiberServer) saveFoodUpdate(c *fiber.Ctx, userID string, req database.UpdateFoodRequest) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	food, err := s.db.GetFood(ctx, c.Params("id"), userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return notFound(c, "Food")
+	}
+	if err != nil {
+		LogDatabaseError(s, "get_food", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to update food")
+	}
+	if food.User_id == nil {
+		return errorResponse(c, fiber.StatusForbidden, "Only custom foods can be changed")
+	}
+
+	if etag := resourceETag(food.Id, food.Updated_at); ifMatchFails(c, etag) {
+		return preconditionFailed(c, etag)
+	}
+	if req.Version != nil {
+		food.Version = *req.Version
+	}
+
+	if req.Name != nil {
+		food.Name = *req.Name
+	}
+	if req.Brand != nil {
+		food.Brand = *req.Brand
+	}
+	if req.Barcode != nil {
+		food.Barcode = *req.Barcode
+	}
+	if req.ServingSize != nil {
+		food.Serving_size = decimal.NewFromFloat(*req.ServingSize).Round(2)
+	}
+	if req.ServingUnit != nil {
+		food.Serving_unit = database.Foods_serving_unit(*req.ServingUnit)
+	}
+	if err := setFoodNutrients(food, req.Calories, req.ProteinG, req.CarbsG, req.FatG); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+	if err := validateFood(food); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	updated, err := s.db.UpdateFood(ctx, food)
+	if errors.Is(err, database.ErrVersionConflict) {
+		return versionConflict(c)
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return notFound(c, "Food")
+	}
+	if err != nil {
+		LogDatabaseError(s, "update_food", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to update food")
+	}
+	c.Set(fiber.HeaderETag, resourceETag(updated.Id, updated.Updated_at))
+	return successResponse(c, foodToResponse(updated))
+}
+
+// DELETE /api/v1/nutrition/foods/:id
+// Deletes one of the user's own foods. Entries logged from it keep their nutrients.
+func (s *FiberServer) deleteFood(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err = s.db.DeleteFood(ctx, c.Params("id"), userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return notFound(c, "Food")
+	}
+	if err != nil {
+		LogDatabaseError(s, "delete_food", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to delete food")
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// POST /api/v1/nutrition/logs
+func (s *FiberServer) createNutritionLog(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	var req database.CreateNutritionLogRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+	entry := database.Nutrition_logs{
+		User_id:  userID,
+		Name:     req.Name,
+		Meal:     database.Nutrition_logs_meal(req.Meal),
+		Servings: decimal.NewFromInt(1),
+		Eaten_at: time.Now(),
+		Notes:    req.Notes,
+	}
+	if req.Meal == "" {
+		entry.Meal = database.Nutrition_logs_meal_snack
+	}
+	if req.EatenAt != nil {
+		entry.Eaten_at = *req.EatenAt
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if req.FoodID != "" {
+		if req.Calories != nil || req.ProteinG != nil || req.CarbsG != nil || req.FatG != nil {
+			return errorResponse(c, fiber.StatusBadRequest, "Nutrients come from the food; leave them out, or log a quick entry without a foodId")
+		}
+		food, err := s.db.GetFood(ctx, req.FoodID, userID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return errorResponse(c, fiber.StatusBadRequest, "Food not found")
+		}
+		if err != nil {
+			LogDatabaseError(s, "get_food", err, c)
+			return errorResponse(c, fiber.StatusInternalServerError, "Failed to log food")
+		}
+		entry.Food_id = &food.Id
+		if strings.TrimSpace(entry.Name) == "" {
+			entry.Name = food.Name
+		}
+		entry.Calories, entry.Protein_g, entry.Carbs_g, entry.Fat_g = food.Calories, food.Protein_g, food.Carbs_g, food.Fat_g
+	} else if err := setLogNutrients(&entry, req.Calories, req.ProteinG, req.CarbsG, req.FatG); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	// Nutrients so far are for one serving: per serving of the food, or as given
+	if req.Servings != nil {
+		servings, err := parseServings(*req.Servings)
+		if err != nil {
+			return errorResponse(c, fiber.StatusBadRequest, err.Error())
+		}
+		if req.FoodID != "" {
+			scaleNutritionLog(&entry, servings)
+		} else {
+			entry.Servings = servings
+		}
+	}
+	if err := validateNutritionLog(&entry); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	created, err := s.db.CreateNutritionLog(ctx, &entry)
+	if err != nil {
+		LogDatabaseError(s, "create_nutrition_log", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to log food")
+	}
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"data": nutritionLogToResponse(created)})
+}
+
+// GET /api/v1/nutrition/logs?meal=lunch&from=&to=
+func (s *FiberServer) listNutritionLogs(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+	opts := database.ListNutritionLogsOpts{ListOptions: getListOptions(c)}
+	if meal := c.Query("meal"); meal != "" {
+		opts.Filters = map[string]string{"meal": meal}
+	}
+	if opts.From, opts.To, err = getDateRange(c); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	entries, err := s.db.ListNutritionLogs(ctx, userID, opts)
+	if errors.Is(err, database.ErrInvalidListOption) {
+		return errorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+	if err != nil {
+		LogDatabaseError(s, "list_nutrition_logs", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to list nutrition logs")
+	}
+
+	responses := make([]database.NutritionLogResponse, len(entries))
+	for i := range entries {
+		responses[i] = nutritionLogToResponse(&entries[i])
+	}
+	return successResponse(c, responses)
+}
+
+// GET /api/v1/nutrition/logs/:id
+func (s *FiberServer) getNutritionLog(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	entry, err := s.db.GetNutritionLog(ctx, c.Params("id"), userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return notFound(c, "Nutrition log")
+	}
+	if err != nil {
+		LogDatabaseError(s, "get_nutrition_log", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to get nutrition log")
+	}
+	return respondWithETag(c, resourceETag(entry.Id, entry.Updated_at), nutritionLogToResponse(entry))
+}
+
+// PUT /api/v1/nutrition/logs/:id
+func (s *FiberServer) updateNutritionLog(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	var req database.UpdateNutritionLogRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	return s.saveNutritionLogUpdate(c, userID, req)
+}
+
+// nutritionLogPatchFields are the nutrition log fields a merge patch may change
+var nutritionLogPatchFields = patchFields{
+	"name":     false,
+	"meal":     false,
+	"servings": false,
+	"calories": false,
+	"proteinG": false,
+	"carbsG":   false,
+	"fatG":     false,
+	"eatenAt":  false,
+	"notes":    false,
+	"version":  false,
+}
+
+// PATCH /api/v1/nutrition/logs/:id
+func (s *FiberServer) patchNutritionLog(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	var req database.UpdateNutritionLogRequest
+	if _, err := applyMergePatch(c.Body(), &req, nutritionLogPatchFields); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	return s.saveNutritionLogUpdate(c, userID, req)
+}
+
+// saveNutritionLogUpdate applies the set fields of req to the user's entry, validates it
+// and saves it. Changing the servings scales the totals, unless new totals are given.
+func (s *FiberServer) saveNutritionLogUpdate(c *fiber.Ctx, userID string, req database.UpdateNutritionLogRequest) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	entry, err := s.db.GetNutritionLog(ctx, c.Params("id"), userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return notFound(c, "Nutrition log")
+	}
+	if err != nil {
+		LogDatabaseError(s, "get_nutrition_log", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to update nutrition log")
+	}
+
+	if etag := resourceETag(entry.Id, entry.Updated_at); ifMatchFails(c, etag) {
+		return preconditionFailed(c, etag)
+	}
+	if req.Version != nil {
+		entry.Version = *req.Version
+	}
+
+	if req.Name != nil {
+		entry.Name = *req.Name
+	}
+	if req.Meal != nil {
+		entry.Meal = database.Nutrition_logs_meal(*req.Meal)
+	}
+	if req.Servings != nil {
+		servings, err := parseServings(*req.Servings)
+		if err != nil {
+			return errorResponse(c, fiber.StatusBadRequest, err.Error())
+		}
+		scaleNutritionLog(entry, servings)
+	}
+	if err := setLogNutrients(entry, req.Calories, req.ProteinG, req.CarbsG, req.FatG); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+	if req.EatenAt != nil {
+		entry.Eaten_at = *req.EatenAt
+	}
+	if req.Notes != nil {
+		entry.Notes = *req.Notes
+	}
+	if err := validateNutritionLog(entry); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	updated, err := s.db.UpdateNutritionLog(ctx, entry)
+	if errors.Is(err, database.ErrVersionConflict) {
+		return versionConflict(c)
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return notFound(c, "Nutrition log")
+	}
+	if err != nil {
+		LogDatabaseError(s, "update_nutrition_log", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to update nutrition log")
+	}
+	c.Set(fiber.HeaderETag, resourceETag(updated.Id, updated.Updated_at))
+	return successResponse(c, nutritionLogToResponse(updated))
+}
+
+// DELETE /api/v1/nutrition/logs/:id
+func (s *FiberServer) deleteNutritionLog(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err = s.db.DeleteNutritionLog(ctx, c.Params("id"), userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return notFound(c, "Nutrition log")
+	}
+	if err != nil {
+		LogDatabaseError(s, "delete_nutrition_log", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to delete nutrition log")
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// GET /api/v1/nutrition/daily?date=2024-01-01&tz=Europe/Berlin
+// Adds up the foods logged on a calendar day, in total and per meal. Every meal is listed,
+// with zeros when nothing was logged for it.
+func (s *FiberServer) getDailyNutrition(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tz, err := s.userTimezone(ctx, c, userID)
+	if err != nil {
+		LogDatabaseError(s, "get_user", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to fetch daily nutrition")
+	}
+	from, to, err := summaryDay(c.Query("date"), tz, time.Now())
+	if err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	totals, err := s.db.GetNutritionTotals(ctx, userID, from, to)
+	if err != nil {
+		LogDatabaseError(s, "get_nutrition_totals", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to fetch daily nutrition")
+	}
+
+	return successResponse(c, dailyNutrition(from, totals))
+}
+
+// dailyNutrition builds the day's response from its per-meal totals
+func dailyNutrition(day time.Time, totals []database.MealTotals) database.DailyNutritionResponse {
+	byMeal := make(map[database.Nutrition_logs_meal]database.MealTotals, len(totals))
+	var calories, protein, carbs, fat decimal.Decimal
+	response := database.DailyNutritionResponse{
+		Date:     day.Format("2006-01-02"),
+		Timezone: day.Location().String(),
+		Meals:    make([]database.MealNutritionResponse, len(database.Nutrition_logs_mealValues)),
+	}
+	for _, total := range totals {
+		byMeal[total.Meal] = total
+		response.Entries += total.Entries
+		calories, protein = calories.Add(total.Calories), protein.Add(total.Protein_g)
+		carbs, fat = carbs.Add(total.Carbs_g), fat.Add(total.Fat_g)
+	}
+	response.Calories, response.ProteinG = calories.InexactFloat64(), protein.InexactFloat64()
+	response.CarbsG, response.FatG = carbs.InexactFloat64(), fat.InexactFloat64()
+
+	for i, meal := range database.Nutrition_logs_mealValues {
+		total := byMeal[meal]
+		response.Meals[i] = database.MealNutritionResponse{
+			Meal:     string(meal),
+			Entries:  total.Entries,
+			Calories: total.Calories.InexactFloat64(),
+			ProteinG: total.Protein_g.InexactFloat64(),
+			CarbsG:   total.Carbs_g.InexactFloat64(),
+			FatG:     total.Fat_g.InexactFloat64(),
+		}
+	}
+	return response
+}
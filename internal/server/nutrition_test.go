@@ -0,0 +1,189 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"fitness-hack/internal/database"
+	"fitness-hack/internal/database/dbtest"
+	"fitness-hack/internal/fooddb"
+
+	"github.com/shopspring/decimal"
+)
+
+// nutritionStub keeps foods and entries in memory. Oats are in the shared catalog.
+type nutritionStub struct {
+	*dbtest.Fake
+	foods   map[string]*database.Foods
+	entries []database.Nutrition_logs
+	totals  []database.MealTotals
+}
+
+func newNutritionStub() *nutritionStub {
+	return &nutritionStub{Fake: dbtest.NewFake(), foods: map[string]*database.Foods{
+		"oats": {Id: "oats", Name: "Rolled Oats", Serving_size: decimal.NewFromInt(40), Serving_unit: database.Foods_serving_unit_g,
+			Calories: decimal.NewFromFloat(150.4), Protein_g: decimal.NewFromInt(5), Carbs_g: decimal.NewFromInt(27), Fat_g: decimal.NewFromFloat(2.5)},
+	}}
+}
+
+func (s *nutritionStub) GetFood(ctx context.Context, id, userID string) (*database.Foods, error) {
+	if food, ok := s.foods[id]; ok && (food.User_id == nil || *food.User_id == userID) {
+		copied := *food
+		return &copied, nil
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (s *nutritionStub) SearchFoods(ctx context.Context, userID, query, barcode string, limit int) ([]database.Foods, error) {
+	foods := []database.Foods{}
+	for _, food := range s.foods {
+		if (barcode != "" && food.Barcode == barcode) || (query != "" && strings.Contains(strings.ToLower(food.Name), strings.ToLower(query))) {
+			foods = append(foods, *food)
+		}
+	}
+	return foods, nil
+}
+
+func (s *nutritionStub) UpsertExternalFood(ctx context.Context, food *database.Foods) (*database.Foods, error) {
+	saved := *food
+	saved.Id = food.Source + ":" + food.External_id
+	s.foods[saved.Id] = &saved
+	return &saved, nil
+}
+
+func (s *nutritionStub) CreateNutritionLog(ctx context.Context, entry *database.Nutrition_logs) (*database.Nutrition_logs, error) {
+	created := *entry
+	created.Id = "log1"
+	s.entries = append(s.entries, created)
+	return &created, nil
+}
+
+func (s *nutritionStub) GetNutritionTotals(ctx context.Context, userID string, from, to time.Time) ([]database.MealTotals, error) {
+	return s.totals, nil
+}
+
+// fakeFoodDB knows one cereal bar, by barcode and by name
+type fakeFoodDB struct{}
+
+var cerealBar = fooddb.Food{Source: "fake", ExternalID: "bar-1", Name: "Cereal Bar", Barcode: "4001234567890",
+	ServingSize: 25, ServingUnit: "g", Calories: 98, ProteinG: 1.5, CarbsG: 17, FatG: 2.6}
+
+func (fakeFoodDB) Barcode(ctx context.Context, barcode string) (*fooddb.Food, error) {
+	if barcode != cerealBar.Barcode {
+		return nil, fooddb.ErrNotFound
+	}
+	return &cerealBar, nil
+}
+
+func (fakeFoodDB) Search(ctx context.Context, query string, limit int) ([]fooddb.Food, error) {
+	return []fooddb.Food{cerealBar}, nil
+}
+
+func nutritionRequest(t *testing.T, s *FiberServer, method, path, body string, out interface{}) int {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Authorization", bearer(t, "u1"))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.App.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	json.NewDecoder(resp.Body).Decode(&struct{ Data interface{} }{out})
+	return resp.StatusCode
+}
+
+func TestCreateNutritionLog(t *testing.T) {
+	stub := newNutritionStub()
+	s := newTestServer(t, stub)
+
+	var entry database.NutritionLogResponse
+	status := nutritionRequest(t, s, "POST", "/api/v1/nutrition/logs", `{"foodId":"oats","meal":"breakfast","servings":1.5}`, &entry)
+	if status != 201 {
+		t.Fatalf("expected the entry to be created, got %d", status)
+	}
+	if entry.Name != "Rolled Oats" || entry.Calories != 225.6 || entry.CarbsG != 40.5 || entry.FatG != 3.75 {
+		t.Errorf("expected the food's nutrients times 1.5 servings, got %+v", entry)
+	}
+
+	var quick database.NutritionLogResponse
+	status = nutritionRequest(t, s, "POST", "/api/v1/nutrition/logs", `{"name":"Coffee","calories":5}`, &quick)
+	if status != 201 || quick.Meal != "snack" || quick.Calories != 5 || quick.FoodID != nil {
+		t.Errorf("expected a quick snack entry, got %d %+v", status, quick)
+	}
+
+	for _, body := range []string{
+		`{"foodId":"oats","calories":100}`,
+		`{"foodId":"missing"}`,
+		`{"calories":100}`,
+		`{"name":"Toast","meal":"brunch"}`,
+		`{"name":"Toast","servings":0}`,
+		`{"name":"Toast","calories":-1}`,
+	} {
+		if status := nutritionRequest(t, s, "POST", "/api/v1/nutrition/logs", body, nil); status != 400 {
+			t.Errorf("%s: expected the entry to be rejected, got %d", body, status)
+		}
+	}
+}
+
+func TestDailyNutrition(t *testing.T) {
+	stub := newNutritionStub()
+	stub.totals = []database.MealTotals{
+		{Meal: "dinner", Entries: 2, Calories: decimal.NewFromInt(700), Protein_g: decimal.NewFromInt(45), Carbs_g: decimal.NewFromInt(60), Fat_g: decimal.NewFromInt(25)},
+		{Meal: "breakfast", Entries: 1, Calories: decimal.NewFromFloat(225.6), Protein_g: decimal.NewFromFloat(7.5)},
+	}
+	s := newTestServer(t, stub)
+
+	var day database.DailyNutritionResponse
+	if status := nutritionRequest(t, s, "GET", "/api/v1/nutrition/daily?date=2025-08-21&tz=Europe/Berlin", "", &day); status != 200 {
+		t.Fatalf("expected the day's totals, got %d", status)
+	}
+	if day.Date != "2025-08-21" || day.Timezone != "Europe/Berlin" || day.Entries != 3 || day.Calories != 925.6 || day.ProteinG != 52.5 {
+		t.Errorf("unexpected totals %+v", day)
+	}
+	var meals []string
+	for _, meal := range day.Meals {
+		meals = append(meals, meal.Meal)
+	}
+	if strings.Join(meals, ",") != "breakfast,lunch,dinner,snack" || day.Meals[1].Entries != 0 || day.Meals[2].Calories != 700 {
+		t.Errorf("expected every meal in order, got %+v", day.Meals)
+	}
+
+	if status := nutritionRequest(t, s, "GET", "/api/v1/nutrition/daily?date=21.08.2025", "", nil); status != 400 {
+		t.Errorf("expected a malformed date to be rejected, got %d", status)
+	}
+}
+
+func TestSearchFoods(t *testing.T) {
+	stub := newNutritionStub()
+	s := newTestServer(t, stub)
+
+	var foods []database.FoodResponse
+	if status := nutritionRequest(t, s, "GET", "/api/v1/nutrition/foods/search?barcode=4001234567890", "", &foods); status != 200 || len(foods) != 0 {
+		t.Fatalf("expected no foods without a food database, got %d %+v", status, foods)
+	}
+
+	s.foodDB = fakeFoodDB{}
+	nutritionRequest(t, s, "GET", "/api/v1/nutrition/foods/search?barcode=4001234567890", "", &foods)
+	if len(foods) != 1 || foods[0].ID != "fake:bar-1" || foods[0].Calories != 98 || foods[0].Custom {
+		t.Fatalf("expected the bar from the food database, got %+v", foods)
+	}
+	if stub.foods["fake:bar-1"] == nil {
+		t.Error("expected the bar to be added to the catalog")
+	}
+
+	nutritionRequest(t, s, "GET", "/api/v1/nutrition/foods/search?q=oat", "", &foods)
+	if len(foods) != 2 || foods[0].ID != "oats" {
+		t.Errorf("expected catalog matches first, then the food database's, got %+v", foods)
+	}
+
+	for _, query := range []string{"q=o", "barcode=12ab", "q=oat&limit=51", ""} {
+		if status := nutritionRequest(t, s, "GET", "/api/v1/nutrition/foods/search?"+query, "", nil); status != 400 {
+			t.Errorf("%q: expected the search to be rejected, got %d", query, status)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"fitness-hack/internal/database"
+	"fitness-hack/internal/messages"
+	"fitness-hack/internal/oauth"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// errEmailTaken is returned when an unverified provider email collides with an existing account
+var errEmailTaken = errors.New("email already registered")
+
+// POST /api/v1/auth/oauth/:provider
+func (s *FiberServer) oauthLogin(c *fiber.Ctx) error {
+	var req database.OAuthLoginRequest
+	if err := c.BodyParser(&req); err != nil || req.IDToken == "" {
+		return errorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	provider, err := s.oauth.Get(c.Params("provider"))
+	if err != nil {
+		return errorResponse(c, fiber.StatusNotFound, "Unknown provider")
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), 10*time.Second)
+	defer cancel()
+
+	identity, err := provider.Verify(ctx, req.IDToken)
+	if err != nil {
+		LogAuthError(s, messages.AuthOAuthVerificationFailed, err, c)
+		if errors.Is(err, oauth.ErrInvalidToken) {
+			return errorResponse(c, fiber.StatusUnauthorized, "Invalid credentials")
+		}
+		return errorResponse(c, fiber.StatusServiceUnavailable, "Provider unavailable")
+	}
+
+	user, err := s.db.GetUserByOAuthIdentity(ctx, identity.Provider, identity.Subject)
+	if errors.Is(err, sql.ErrNoRows) {
+		user, err = s.linkOrCreateOAuthUser(ctx, identity, &req, s.registrationAllowed(c))
+	}
+	if errors.Is(err, errEmailTaken) {
+		return errorResponse(c, fiber.StatusConflict, "An account with this email already exists, sign in with your password to link it")
+	}
+	if errors.Is(err, errRegistrationClosed) {
+		return errorResponse(c, fiber.StatusForbidden, "Registration is not yet available in your region, an invite code is required")
+	}
+	if err != nil {
+		LogDatabaseError(s, "oauth_login", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to sign in")
+	}
+
+	if user.Disabled_at != nil {
+		return codedErrorResponse(c, fiber.StatusForbidden, codeAccountDisabled, "This account has been disabled")
+	}
+
+	token, err := s.issueSessionToken(ctx, c, user.Id, time.Now().Add(sessionTokenTTL), nil)
+	if err != nil {
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to generate token")
+	}
+
+	return successResponse(c, database.LoginResponse{
+		User:  userToResponse(user),
+		Token: token,
+	})
+}
+
+// linkOrCreateOAuthUser links the identity to the account with the same verified email,
+// or creates a new account when none exists and canRegister allows it
+func (s *FiberServer) linkOrCreateOAuthUser(ctx context.Context, identity *oauth.Identity, req *database.OAuthLoginRequest, canRegister bool) (*database.Users, error) {
+	link := &database.Oauth_identities{
+		Provider: identity.Provider,
+		Subject:  identity.Subject,
+		Email:    identity.Email,
+	}
+
+	if identity.Email == "" {
+		return nil, errors.New("provider did not share an email address")
+	}
+
+	existing, err := s.db.GetUserByEmail(ctx, identity.Email)
+	if err == nil {
+		// Only trust the provider's email for linking when it has verified it
+		if !identity.EmailVerified {
+			return nil, errEmailTaken
+		}
+		link.User_id = existing.Id
+		if _, err := s.db.LinkOAuthIdentity(ctx, link); err != nil {
+			return nil, err
+		}
+		return existing, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if !canRegister {
+		return nil, errRegistrationClosed
+	}
+
+	user, err := newPasswordlessUser(identity.Email, firstNonEmpty(identity.FirstName, req.FirstName), firstNonEmpty(identity.LastName, req.LastName))
+	if err != nil {
+		return nil, err
+	}
+	return s.db.CreateOAuthUser(ctx, user, link)
+}
+
+// newPasswordlessUser builds the account for a user signing up through an identity provider
+// or provisioned by one. Such accounts have no usable password until the user sets one.
+func newPasswordlessUser(email, firstName, lastName string) (*database.Users, error) {
+	password, err := randomHex(32)
+	if err != nil {
+		return nil, err
+	}
+	hash, err := hashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+	suffix, err := randomHex(3)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	return &database.Users{
+		Email:         email,
+		Username:      usernameFromEmail(email) + "_" + suffix,
+		Password_hash: hash,
+		First_name:    &firstName,
+		Last_name:     &lastName,
+		Created_at:    now,
+		Updated_at:    now,
+	}, nil
+}
+
+// usernameFromEmail derives a username base from the local part of an email address
+func usernameFromEmail(email string) string {
+	local := strings.ToLower(strings.SplitN(email, "@", 2)[0])
+	local = strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '_' {
+			return r
+		}
+		return -1
+	}, local)
+	if len(local) > 40 {
+		local = local[:40]
+	}
+	if local == "" {
+		local = "user"
+	}
+	return local
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
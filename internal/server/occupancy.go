@@ -0,0 +1,208 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strconv"
+	"time"
+
+	"fitness-hack/internal/database"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	defaultOccupancyHistoryDays = 28
+	maxOccupancyHistoryDays     = 90
+)
+
+// occupancyKey is the sorted set of members checked in at an organization, scored by
+// check-in time in Unix seconds
+func occupancyKey(orgID string) string {
+	return "occupancy:" + orgID
+}
+
+// occupancyMaxStay is how long a visit without a check-out keeps counting
+func occupancyMaxStay() time.Duration {
+	return getEnvDuration("OCCUPANCY_MAX_STAY", 3*time.Hour)
+}
+
+// Helper to convert database gym visit to response model
+func gymVisitToResponse(visit *database.Gym_visits) database.GymVisitResponse {
+	return database.GymVisitResponse{
+		ID:             visit.Id,
+		OrganizationID: visit.Organization_id,
+		CheckedInAt:    visit.Checked_in_at,
+		CheckedOutAt:   visit.Checked_out_at,
+	}
+}
+
+// liveOccupancy counts the members checked in at the organization from the Redis counter,
+// dropping check-ins older than maxStay first
+func (s *FiberServer) liveOccupancy(ctx context.Context, orgID string, maxStay time.Duration) (int, error) {
+	key := occupancyKey(orgID)
+	stale := strconv.FormatInt(time.Now().Add(-maxStay).Unix(), 10)
+
+	var count *redis.IntCmd
+	_, err := s.cache.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZRemRangeByScore(ctx, key, "-inf", "("+stale)
+		count = pipe.ZCard(ctx, key)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return int(count.Val()), nil
+}
+
+// POST /api/v1/organizations/:orgId/check-in
+func (s *FiberServer) checkIn(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+	orgID := c.Params("orgId")
+	maxStay := occupancyMaxStay()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	visit, created, err := s.db.CheckInGymVisit(ctx, orgID, userID, maxStay)
+	if err != nil {
+		LogDatabaseError(s, "check_in_gym_visit", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to check in")
+	}
+
+	// The counter only speeds up reads; occupancy falls back to the database without it
+	key := occupancyKey(orgID)
+	_, err = s.cache.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZAdd(ctx, key, redis.Z{Score: float64(visit.Checked_in_at.Unix()), Member: userID})
+		pipe.Expire(ctx, key, maxStay)
+		return nil
+	})
+	if err != nil {
+		LogCacheError(s, "occupancy_check_in", err, c)
+	}
+
+	status := fiber.StatusOK
+	if created {
+		status = fiber.StatusCreated
+	}
+	return c.Status(status).JSON(fiber.Map{"data": gymVisitToResponse(visit)})
+}
+
+// POST /api/v1/organizations/:orgId/check-out
+func (s *FiberServer) checkOut(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+	orgID := c.Params("orgId")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	visit, err := s.db.CheckOutGymVisit(ctx, orgID, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errorResponse(c, fiber.StatusConflict, "Not checked in")
+		}
+		LogDatabaseError(s, "check_out_gym_visit", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to check out")
+	}
+
+	if err := s.cache.ZRem(ctx, occupancyKey(orgID), userID).Err(); err != nil {
+		LogCacheError(s, "occupancy_check_out", err, c)
+	}
+
+	return successResponse(c, gymVisitToResponse(visit))
+}
+
+// GET /api/v1/organizations/:orgId/occupancy
+func (s *FiberServer) getOccupancy(c *fiber.Ctx) error {
+	orgID := c.Params("orgId")
+	maxStay := occupancyMaxStay()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := s.db.GetOrganizationByID(ctx, orgID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return notFound(c, "Organization")
+		}
+		LogDatabaseError(s, "get_organization", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to fetch organization")
+	}
+
+	current, err := s.liveOccupancy(ctx, orgID, maxStay)
+	if err != nil {
+		LogCacheError(s, "occupancy_count", err, c)
+		if current, err = s.db.CountOpenGymVisits(ctx, orgID, maxStay); err != nil {
+			LogDatabaseError(s, "count_open_gym_visits", err, c)
+			return errorResponse(c, fiber.StatusInternalServerError, "Failed to fetch occupancy")
+		}
+	}
+
+	return successResponse(c, database.OccupancyResponse{
+		OrganizationID: orgID,
+		Current:        current,
+		AsOf:           time.Now().UTC(),
+	})
+}
+
+// GET /api/v1/organizations/:orgId/occupancy/history
+func (s *FiberServer) getOccupancyHistory(c *fiber.Ctx) error {
+	orgID := c.Params("orgId")
+
+	days, err := strconv.Atoi(c.Query("days", strconv.Itoa(defaultOccupancyHistoryDays)))
+	if err != nil || days < 1 || days > maxOccupancyHistoryDays {
+		return errorResponse(c, fiber.StatusBadRequest, "days must be between 1 and "+strconv.Itoa(maxOccupancyHistoryDays))
+	}
+	tz := c.Query("tz", "UTC")
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, "tz must be an IANA timezone name")
+	}
+
+	// Whole hours only, so the current hour doesn't drag its weekday's average down
+	now := time.Now().In(loc)
+	to := time.Date(now.Year(), now.Month(), now.Day(), now.Hour(), 0, 0, 0, loc)
+	from := to.AddDate(0, 0, -days)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := s.db.GetOrganizationByID(ctx, orgID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return notFound(c, "Organization")
+		}
+		LogDatabaseError(s, "get_organization", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to fetch organization")
+	}
+
+	hours, err := s.db.GetHourlyOccupancy(ctx, orgID, from, to, loc.String(), occupancyMaxStay())
+	if err != nil {
+		LogDatabaseError(s, "get_hourly_occupancy", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to fetch occupancy history")
+	}
+
+	response := database.OccupancyHistoryResponse{
+		OrganizationID: orgID,
+		Timezone:       loc.String(),
+		From:           from,
+		To:             to,
+		Hours:          make([]database.OccupancyHourResponse, len(hours)),
+	}
+	for i, h := range hours {
+		response.Hours[i] = database.OccupancyHourResponse{
+			Weekday:         h.Weekday,
+			Hour:            h.Hour,
+			AverageVisitors: h.AverageVisitors,
+			PeakVisitors:    h.PeakVisitors,
+		}
+	}
+
+	return successResponse(c, response)
+}
@@ -0,0 +1,36 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"fitness-hack/internal/memstore"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestLiveOccupancyDropsStaleCheckIns(t *testing.T) {
+	cache := memstore.New().Client()
+	t.Cleanup(func() { cache.Close() })
+	s := &FiberServer{cache: cache}
+
+	ctx := context.Background()
+	now := time.Now()
+	key := occupancyKey("org-1")
+	cache.ZAdd(ctx, key,
+		redis.Z{Score: float64(now.Add(-time.Minute).Unix()), Member: "recent"},
+		redis.Z{Score: float64(now.Add(-2 * time.Hour).Unix()), Member: "earlier"},
+		redis.Z{Score: float64(now.Add(-4 * time.Hour).Unix()), Member: "forgot-to-check-out"})
+
+	count, err := s.liveOccupancy(ctx, "org-1", 3*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Fatalf("expected 2 members checked in, got %d", count)
+	}
+	if left := cache.ZCard(ctx, key).Val(); left != 2 {
+		t.Errorf("expected the stale check-in to be removed, %d left", left)
+	}
+}
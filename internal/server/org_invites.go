@@ -0,0 +1,238 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	"fitness-hack/internal/database"
+	"fitness-hack/internal/mailer"
+	"fitness-hack/internal/messages"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Helper to hash an invitation token for storage and lookup
+func hashInviteToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Helper to convert database invitation to response model
+func orgInviteToResponse(invite *database.Organization_invites) database.OrgInviteResponse {
+	return database.OrgInviteResponse{
+		ID:         invite.Id,
+		Email:      invite.Email,
+		Role:       string(invite.Role),
+		Expired:    time.Now().After(invite.Expires_at),
+		SendCount:  invite.Send_count,
+		LastSentAt: invite.Last_sent_at,
+		ExpiresAt:  invite.Expires_at,
+		CreatedAt:  invite.Created_at,
+	}
+}
+
+// newInviteToken returns a fresh invitation token, its hash and expiry (ORG_INVITE_TTL, default 7 days)
+func newInviteToken() (token, hash string, expiresAt time.Time, err error) {
+	token, err = randomHex(32)
+	if err != nil {
+		return "", "", time.Time{}, err
+	}
+	ttl := getEnvDuration("ORG_INVITE_TTL", 7*24*time.Hour)
+	return token, hashInviteToken(token), time.Now().Add(ttl), nil
+}
+
+// sendOrgInvite emails the invitation link. The link points at ORG_INVITE_URL,
+// the client page that calls the accept endpoint with the token.
+func (s *FiberServer) sendOrgInvite(ctx context.Context, org *database.Organizations, invite *database.Organization_invites, token string) error {
+	link := getEnv("ORG_INVITE_URL", "https://app.fitnesshack.app/invites/accept") + "?token=" + url.QueryEscape(token)
+
+	return s.mailer.Send(ctx, mailer.Message{
+		To:      invite.Email,
+		Subject: fmt.Sprintf("You're invited to join %s on FitnessHack", org.Name),
+		Body: fmt.Sprintf("You've been invited to join %s on FitnessHack as %s.\n\n"+
+			"Accept the invitation here:\n%s\n\n"+
+			"This link expires on %s. If you weren't expecting this email you can ignore it.\n",
+			org.Name, invite.Role, link, invite.Expires_at.UTC().Format("January 2, 2006 15:04 MST")),
+	})
+}
+
+// POST /api/v1/organizations/:orgId/invites
+func (s *FiberServer) createOrgInvite(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	var req database.CreateOrgInviteRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+	addr, err := mail.ParseAddress(strings.TrimSpace(req.Email))
+	if err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, "A valid email is required")
+	}
+	if req.Role == "" {
+		req.Role = orgRoleMember
+	}
+	if req.Role != orgRoleMember && req.Role != orgRoleAdmin {
+		return errorResponse(c, fiber.StatusBadRequest, "Role must be 'member' or 'admin'")
+	}
+	if req.Role == orgRoleAdmin && c.Locals("org_role") != orgRoleOwner {
+		return errorResponse(c, fiber.StatusForbidden, "Only the organization owner can invite admins")
+	}
+
+	token, tokenHash, expiresAt, err := newInviteToken()
+	if err != nil {
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to create invitation")
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), 10*time.Second)
+	defer cancel()
+
+	org, err := s.db.GetOrganizationByID(ctx, c.Params("orgId"))
+	if err != nil {
+		LogDatabaseError(s, "get_organization", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to create invitation")
+	}
+
+	invite, err := s.db.CreateOrgInvite(ctx, &database.Organization_invites{
+		Organization_id: org.Id,
+		Email:           strings.ToLower(addr.Address),
+		Role:            database.Organization_invites_role(req.Role),
+		Token_hash:      tokenHash,
+		Invited_by:      &userID,
+		Expires_at:      expiresAt,
+	})
+	if err != nil {
+		if errors.Is(err, database.ErrInvitePending) {
+			return errorResponse(c, fiber.StatusConflict, "An invitation is already pending for this email; resend it instead")
+		}
+		LogDatabaseError(s, "create_org_invite", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to create invitation")
+	}
+
+	if err := s.sendOrgInvite(ctx, org, invite, token); err != nil {
+		LogError(s, "ERROR", messages.InviteEmailFailed, err, c, map[string]interface{}{
+			"invite_id": invite.Id,
+		})
+		return errorResponse(c, fiber.StatusBadGateway, "Invitation created but the email could not be sent; try resending it")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"data": orgInviteToResponse(invite)})
+}
+
+// GET /api/v1/organizations/:orgId/invites
+func (s *FiberServer) listOrgInvites(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	invites, err := s.db.ListPendingOrgInvites(ctx, c.Params("orgId"))
+	if err != nil {
+		LogDatabaseError(s, "list_org_invites", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to fetch invitations")
+	}
+
+	response := make([]database.OrgInviteResponse, len(invites))
+	for i := range invites {
+		response[i] = orgInviteToResponse(&invites[i])
+	}
+
+	return successResponse(c, response)
+}
+
+// POST /api/v1/organizations/:orgId/invites/:inviteId/resend
+func (s *FiberServer) resendOrgInvite(c *fiber.Ctx) error {
+	token, tokenHash, expiresAt, err := newInviteToken()
+	if err != nil {
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to resend invitation")
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), 10*time.Second)
+	defer cancel()
+
+	org, err := s.db.GetOrganizationByID(ctx, c.Params("orgId"))
+	if err != nil {
+		LogDatabaseError(s, "get_organization", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to resend invitation")
+	}
+
+	invite, err := s.db.ResendOrgInvite(ctx, org.Id, c.Params("inviteId"), tokenHash, expiresAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return notFound(c, "Invitation")
+		}
+		LogDatabaseError(s, "resend_org_invite", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to resend invitation")
+	}
+
+	if err := s.sendOrgInvite(ctx, org, invite, token); err != nil {
+		LogError(s, "ERROR", messages.InviteEmailFailed, err, c, map[string]interface{}{
+			"invite_id": invite.Id,
+		})
+		return errorResponse(c, fiber.StatusBadGateway, "Failed to send invitation email")
+	}
+
+	return successResponse(c, orgInviteToResponse(invite))
+}
+
+// DELETE /api/v1/organizations/:orgId/invites/:inviteId
+func (s *FiberServer) revokeOrgInvite(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := s.db.RevokeOrgInvite(ctx, c.Params("orgId"), c.Params("inviteId")); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return notFound(c, "Invitation")
+		}
+		LogDatabaseError(s, "revoke_org_invite", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to revoke invitation")
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// POST /api/v1/organizations/invites/accept
+func (s *FiberServer) acceptOrgInvite(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	var req database.AcceptOrgInviteRequest
+	if err := c.BodyParser(&req); err != nil || req.Token == "" {
+		return errorResponse(c, fiber.StatusBadRequest, "Token is required")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	member, err := s.db.AcceptOrgInvite(ctx, hashInviteToken(req.Token), userID)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return notFound(c, "Invitation")
+	case errors.Is(err, database.ErrInviteExpired):
+		return errorResponse(c, fiber.StatusGone, "Invitation has expired; ask an admin to resend it")
+	case errors.Is(err, database.ErrInviteClosed):
+		return errorResponse(c, fiber.StatusGone, "Invitation is no longer valid")
+	case errors.Is(err, database.ErrInviteEmailMismatch):
+		return errorResponse(c, fiber.StatusForbidden, "Invitation was sent to a different email address")
+	case err != nil:
+		LogDatabaseError(s, "accept_org_invite", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to accept invitation")
+	}
+
+	return successResponse(c, database.OrganizationMemberResponse{
+		OrganizationID: member.Organization_id,
+		UserID:         member.User_id,
+		Role:           string(member.Role),
+		JoinedAt:       member.Created_at,
+	})
+}
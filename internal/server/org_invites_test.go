@@ -0,0 +1,126 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"fitness-hack/internal/database"
+	"fitness-hack/internal/mailer"
+)
+
+// outbox records the messages sent instead of delivering them
+type outbox struct {
+	mu   sync.Mutex
+	sent []mailer.Message
+}
+
+func (o *outbox) Send(ctx context.Context, msg mailer.Message) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.sent = append(o.sent, msg)
+	return nil
+}
+
+func (o *outbox) Ping(ctx context.Context) error { return nil }
+
+// lastToken returns the invitation token linked in the most recent message
+func (o *outbox) lastToken(t *testing.T) string {
+	t.Helper()
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if len(o.sent) == 0 {
+		t.Fatal("expected an invitation email")
+	}
+	body := o.sent[len(o.sent)-1].Body
+	start := strings.Index(body, "?token=")
+	if start < 0 {
+		t.Fatalf("expected an invitation link in %q", body)
+	}
+	token, err := url.QueryUnescape(strings.Fields(body[start+len("?token="):])[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestOrgInviteExpiryAndResend(t *testing.T) {
+	ctx := context.Background()
+	s, db := newFakeServer(t)
+	mail := &outbox{}
+	s.mailer = mail
+	for _, u := range []database.Users{{Id: "owner", Email: "owner@example.com", Username: "owner"}, {Id: "ann", Email: "ann@example.com", Username: "ann"}} {
+		if _, err := db.CreateUser(ctx, &u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	org, err := db.CreateOrganization(ctx, "Iron Gym", "owner")
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := "/api/v1/organizations/" + org.Id + "/invites"
+
+	do := func(path, user, body string, out interface{}) int {
+		t.Helper()
+		req := httptest.NewRequest("POST", path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", bearer(t, user))
+		resp, err := s.App.Test(req, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out != nil {
+			json.NewDecoder(resp.Body).Decode(&struct{ Data interface{} }{out})
+		}
+		return resp.StatusCode
+	}
+
+	t.Setenv("ORG_INVITE_TTL", "-1h")
+	var invite database.OrgInviteResponse
+	if status := do(base, "owner", `{"email":"Ann@example.com"}`, &invite); status != 201 {
+		t.Fatalf("expected the invitation created, got %d", status)
+	}
+	if !invite.Expired || invite.SendCount != 1 {
+		t.Fatalf("expected an already expired invitation sent once, got %+v", invite)
+	}
+	expired := mail.lastToken(t)
+	if status := do("/api/v1/organizations/invites/accept", "ann", `{"token":"`+expired+`"}`, nil); status != 410 {
+		t.Errorf("expected an expired invitation refused, got %d", status)
+	}
+	if status := do(base, "owner", `{"email":"ann@example.com"}`, nil); status != 409 {
+		t.Errorf("expected a second invitation for the same email refused, got %d", status)
+	}
+
+	t.Setenv("ORG_INVITE_TTL", "1h")
+	var resent database.OrgInviteResponse
+	if status := do(base+"/"+invite.ID+"/resend", "owner", "", &resent); status != 200 {
+		t.Fatalf("expected the invitation resent, got %d", status)
+	}
+	if resent.Expired || resent.SendCount != 2 || !resent.ExpiresAt.After(invite.ExpiresAt) {
+		t.Errorf("expected the resend to renew the expiry, got %+v", resent)
+	}
+	fresh := mail.lastToken(t)
+	if fresh == expired {
+		t.Fatal("expected resending to issue a new token")
+	}
+	if status := do("/api/v1/organizations/invites/accept", "ann", `{"token":"`+expired+`"}`, nil); status != 404 {
+		t.Errorf("expected the replaced token to stop working, got %d", status)
+	}
+	if status := do("/api/v1/organizations/invites/accept", "ann", `{"token":"`+fresh+`"}`, nil); status != 200 {
+		t.Fatalf("expected the resent invitation accepted, got %d", status)
+	}
+	if role, err := db.GetOrganizationRole(ctx, org.Id, "ann"); err != nil || role != orgRoleMember {
+		t.Errorf("expected ann to join as a member, got %q, %v", role, err)
+	}
+
+	if status := do(base+"/"+invite.ID+"/resend", "owner", "", nil); status != 404 {
+		t.Errorf("expected an accepted invitation not resent, got %d", status)
+	}
+	if status := do("/api/v1/organizations/invites/accept", "ann", `{"token":"`+fresh+`"}`, nil); status != 410 {
+		t.Errorf("expected an accepted invitation not reused, got %d", status)
+	}
+}
@@ -0,0 +1,131 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"fitness-hack/internal/database"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	orgRoleOwner  = "owner"
+	orgRoleAdmin  = "admin"
+	orgRoleMember = "member"
+)
+
+// Helper to convert database organization to response model
+func organizationToResponse(org *database.Organizations) database.OrganizationResponse {
+	return database.OrganizationResponse{
+		ID:        org.Id,
+		Name:      org.Name,
+		CreatedAt: org.Created_at,
+	}
+}
+
+// requireOrgAdmin only lets owners and admins of the :orgId organization through.
+// Non-members get 404 so organization IDs cannot be probed.
+func (s *FiberServer) requireOrgAdmin(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	role, err := s.db.GetOrganizationRole(ctx, c.Params("orgId"), userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return notFound(c, "Organization")
+		}
+		LogDatabaseError(s, "get_organization_role", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to fetch organization")
+	}
+	if role != orgRoleOwner && role != orgRoleAdmin {
+		return errorResponse(c, fiber.StatusForbidden, "Only organization admins can do this")
+	}
+
+	c.Locals("org_role", role)
+	return c.Next()
+}
+
+// requireOrgMember only lets active members of the :orgId organization through, whatever
+// their role. Non-members get 404, as with requireOrgAdmin.
+func (s *FiberServer) requireOrgMember(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	role, err := s.db.GetOrganizationRole(ctx, c.Params("orgId"), userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return notFound(c, "Organization")
+		}
+		LogDatabaseError(s, "get_organization_role", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to fetch organization")
+	}
+
+	c.Locals("org_role", role)
+	return c.Next()
+}
+
+// POST /api/v1/organizations
+func (s *FiberServer) createOrganization(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	var req database.CreateOrganizationRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return errorResponse(c, fiber.StatusBadRequest, "Name is required")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	org, err := s.db.CreateOrganization(ctx, req.Name, userID)
+	if err != nil {
+		LogDatabaseError(s, "create_organization", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to create organization")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"data": organizationToResponse(org)})
+}
+
+// GET /api/v1/organizations
+func (s *FiberServer) listOrganizations(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	orgs, err := s.db.ListOrganizationsByUser(ctx, userID)
+	if err != nil {
+		LogDatabaseError(s, "list_organizations", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to fetch organizations")
+	}
+
+	response := make([]database.OrganizationResponse, len(orgs))
+	for i := range orgs {
+		response[i] = organizationToResponse(&orgs[i])
+	}
+
+	return successResponse(c, response)
+}
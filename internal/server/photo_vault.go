@@ -0,0 +1,231 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"math"
+	"strconv"
+	"time"
+
+	"fitness-hack/internal/database"
+	"fitness-hack/internal/vault"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// errVaultLocked is returned when vaulted photos are used while the vault is locked
+var errVaultLocked = errors.New("photo vault is locked")
+
+// photoVaultCacheKey holds the user's vault key while the vault is unlocked
+func photoVaultCacheKey(userID string) string {
+	return "photo_vault:unlocked:" + userID
+}
+
+// unlockedVaultKey returns the user's vault key, or errVaultLocked unless they unlocked the
+// vault within PHOTO_VAULT_UNLOCK_TTL
+func (s *FiberServer) unlockedVaultKey(ctx context.Context, userID string) ([]byte, error) {
+	encoded, err := s.GetCache(ctx, photoVaultCacheKey(userID))
+	if err != nil {
+		return nil, errVaultLocked
+	}
+	return base64.StdEncoding.DecodeString(encoded)
+}
+
+// unlockVault keeps the vault key for PHOTO_VAULT_UNLOCK_TTL (default 10 minutes), until
+// the vault is locked again
+func (s *FiberServer) unlockVault(ctx context.Context, userID string, dataKey []byte) error {
+	ttl := getEnvDuration("PHOTO_VAULT_UNLOCK_TTL", 10*time.Minute)
+	return s.SetCache(ctx, photoVaultCacheKey(userID), base64.StdEncoding.EncodeToString(dataKey), ttl)
+}
+
+// photoVaultResponse describes the user's vault, which is nil if they haven't set one up
+func (s *FiberServer) photoVaultResponse(ctx context.Context, userID string, v *database.Photo_vaults) database.PhotoVaultResponse {
+	response := database.PhotoVaultResponse{Enabled: v != nil}
+	if v == nil {
+		return response
+	}
+	if v.Locked_until != nil && v.Locked_until.After(time.Now()) {
+		response.LockedUntil = v.Locked_until
+	}
+	if ttl, err := s.cache.TTL(ctx, photoVaultCacheKey(userID)).Result(); err == nil && ttl > 0 {
+		until := time.Now().Add(ttl)
+		response.Unlocked = true
+		response.UnlockedUntil = &until
+	}
+	return response
+}
+
+// checkVaultPIN returns the vault key if pin is right. Wrong PINs count towards a lockout of
+// PHOTO_VAULT_LOCKOUT (default 15 minutes) after PHOTO_VAULT_MAX_ATTEMPTS (default 5) in a
+// row. On failure the error response has been sent and is returned with a nil key.
+func (s *FiberServer) checkVaultPIN(ctx context.Context, c *fiber.Ctx, v *database.Photo_vaults, pin string) ([]byte, error) {
+	if v.Locked_until != nil && v.Locked_until.After(time.Now()) {
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(time.Until(*v.Locked_until).Seconds()))))
+		return nil, errorResponse(c, fiber.StatusTooManyRequests, "Too many incorrect PINs, try again later")
+	}
+
+	dataKey, err := vault.Unwrap(pin, v.Pin_salt, v.Wrapped_key)
+	maxAttempts := getEnvInt("PHOTO_VAULT_MAX_ATTEMPTS", 5)
+	lockout := getEnvDuration("PHOTO_VAULT_LOCKOUT", 15*time.Minute)
+	if _, recordErr := s.db.RecordPhotoVaultAttempt(ctx, v.User_id, err == nil, maxAttempts, lockout); recordErr != nil {
+		LogDatabaseError(s, "record_photo_vault_attempt", recordErr, c)
+		return nil, errorResponse(c, fiber.StatusInternalServerError, "Failed to unlock photo vault")
+	}
+	if err != nil {
+		return nil, errorResponse(c, fiber.StatusForbidden, "Incorrect PIN")
+	}
+	return dataKey, nil
+}
+
+// GET /api/v1/users/me/photo-vault
+func (s *FiberServer) getPhotoVault(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	v, err := s.db.GetPhotoVault(ctx, userID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		LogDatabaseError(s, "get_photo_vault", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to fetch photo vault")
+	}
+	return successResponse(c, s.photoVaultResponse(ctx, userID, v))
+}
+
+// POST /api/v1/users/me/photo-vault
+// Sets up the vault with a PIN and leaves it unlocked.
+func (s *FiberServer) createPhotoVault(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	var req database.PhotoVaultPINRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+	salt, wrappedKey, dataKey, err := vault.New(req.PIN)
+	if err != nil {
+		if errors.Is(err, vault.ErrInvalidPIN) {
+			return errorResponse(c, fiber.StatusBadRequest, "PIN must be 6 to 12 digits")
+		}
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to create photo vault")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	v, err := s.db.CreatePhotoVault(ctx, userID, salt, wrappedKey)
+	if err != nil {
+		if errors.Is(err, database.ErrPhotoVaultExists) {
+			return errorResponse(c, fiber.StatusConflict, "Photo vault already exists")
+		}
+		LogDatabaseError(s, "create_photo_vault", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to create photo vault")
+	}
+	if err := s.unlockVault(ctx, userID, dataKey); err != nil {
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to unlock photo vault")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"data": s.photoVaultResponse(ctx, userID, v)})
+}
+
+// PUT /api/v1/users/me/photo-vault/pin
+// Vaulted photos aren't re-encrypted; the vault key is wrapped under the new PIN instead.
+func (s *FiberServer) changePhotoVaultPIN(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	var req database.ChangePhotoVaultPINRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+	if err := vault.ValidatePIN(req.NewPIN); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, "PIN must be 6 to 12 digits")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	v, err := s.db.GetPhotoVault(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errorResponse(c, fiber.StatusNotFound, "Photo vault not set up")
+		}
+		LogDatabaseError(s, "get_photo_vault", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to change PIN")
+	}
+	dataKey, err := s.checkVaultPIN(ctx, c, v, req.CurrentPIN)
+	if dataKey == nil {
+		return err
+	}
+
+	salt, wrappedKey, err := vault.Rewrap(req.NewPIN, dataKey)
+	if err != nil {
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to change PIN")
+	}
+	if err := s.db.ChangePhotoVaultPIN(ctx, userID, salt, wrappedKey); err != nil {
+		LogDatabaseError(s, "change_photo_vault_pin", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to change PIN")
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// POST /api/v1/users/me/photo-vault/unlock
+func (s *FiberServer) unlockPhotoVault(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	var req database.PhotoVaultPINRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	v, err := s.db.GetPhotoVault(ctx, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errorResponse(c, fiber.StatusNotFound, "Photo vault not set up")
+		}
+		LogDatabaseError(s, "get_photo_vault", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to unlock photo vault")
+	}
+	dataKey, err := s.checkVaultPIN(ctx, c, v, req.PIN)
+	if dataKey == nil {
+		return err
+	}
+	if err := s.unlockVault(ctx, userID, dataKey); err != nil {
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to unlock photo vault")
+	}
+
+	v.Locked_until = nil
+	return successResponse(c, s.photoVaultResponse(ctx, userID, v))
+}
+
+// POST /api/v1/users/me/photo-vault/lock
+func (s *FiberServer) lockPhotoVault(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := s.DeleteCache(ctx, photoVaultCacheKey(userID)); err != nil {
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to lock photo vault")
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
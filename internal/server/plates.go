@@ -0,0 +1,99 @@
+package server
+
+import (
+	"sort"
+	"strings"
+
+	"fitness-hack/internal/database"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shopspring/decimal"
+)
+
+// defaultBarKg is the weight of a standard Olympic barbell
+var defaultBarKg = decimal.NewFromInt(20)
+
+// standardPlatesKg are the plates of a typical gym, heaviest first. Loads rounded to
+// prescriptionRoundingKg can always be made from them exactly.
+var standardPlatesKg = []decimal.Decimal{
+	decimal.NewFromInt(25),
+	decimal.NewFromInt(20),
+	decimal.NewFromInt(15),
+	decimal.NewFromInt(10),
+	decimal.NewFromInt(5),
+	decimal.NewFromFloat(2.5),
+	decimal.NewFromFloat(1.25),
+}
+
+// maxPlateTypes bounds the plates query parameter
+const maxPlateTypes = 20
+
+// platesPerSide returns the plates to put on each side of a bar of barKg to load it to
+// loadKg, heaviest first, and the part of the load they can't make up. plates must be
+// sorted heaviest first; any number of each is assumed to be available.
+func platesPerSide(loadKg, barKg decimal.Decimal, plates []decimal.Decimal) ([]decimal.Decimal, decimal.Decimal) {
+	two := decimal.NewFromInt(2)
+	perSide := []decimal.Decimal{}
+	remaining := loadKg.Sub(barKg).Div(two)
+	for _, plate := range plates {
+		for remaining.GreaterThanOrEqual(plate) {
+			perSide = append(perSide, plate)
+			remaining = remaining.Sub(plate)
+		}
+	}
+	return perSide, remaining.Mul(two)
+}
+
+// decimalsToFloats converts weights for a response
+func decimalsToFloats(values []decimal.Decimal) []float64 {
+	floats := make([]float64, len(values))
+	for i, v := range values {
+		floats[i] = v.InexactFloat64()
+	}
+	return floats
+}
+
+// GET /api/v1/plates?weightKg=...&barKg=...&plates=...
+func (s *FiberServer) calculatePlates(c *fiber.Ctx) error {
+	weightKg, err := decimal.NewFromString(c.Query("weightKg"))
+	if err != nil || weightKg.Sign() <= 0 || weightKg.GreaterThanOrEqual(decimal.NewFromInt(10000)) {
+		return errorResponse(c, fiber.StatusBadRequest, "weightKg must be a number greater than 0 and less than 10000")
+	}
+
+	barKg := defaultBarKg
+	if raw := c.Query("barKg"); raw != "" {
+		barKg, err = decimal.NewFromString(raw)
+		if err != nil || barKg.Sign() < 0 {
+			return errorResponse(c, fiber.StatusBadRequest, "barKg must be a number of at least 0")
+		}
+	}
+	if weightKg.LessThan(barKg) {
+		return errorResponse(c, fiber.StatusBadRequest, "weightKg cannot be less than barKg")
+	}
+
+	plates := standardPlatesKg
+	if raw := c.Query("plates"); raw != "" {
+		fields := strings.Split(raw, ",")
+		if len(fields) > maxPlateTypes {
+			return errorResponse(c, fiber.StatusBadRequest, "plates can list at most 20 weights")
+		}
+		plates = make([]decimal.Decimal, len(fields))
+		for i, field := range fields {
+			plate, err := decimal.NewFromString(strings.TrimSpace(field))
+			if err != nil || plate.Sign() <= 0 {
+				return errorResponse(c, fiber.StatusBadRequest, "plates must be a comma-separated list of weights greater than 0")
+			}
+			plates[i] = plate
+		}
+		sort.Slice(plates, func(i, j int) bool { return plates[i].GreaterThan(plates[j]) })
+	}
+
+	perSide, remainder := platesPerSide(weightKg, barKg, plates)
+	return successResponse(c, database.PlatesResponse{
+		WeightKg:        weightKg.InexactFloat64(),
+		BarKg:           barKg.InexactFloat64(),
+		PlatesPerSideKg: decimalsToFloats(perSide),
+		LoadedKg:        weightKg.Sub(remainder).InexactFloat64(),
+		RemainderKg:     remainder.InexactFloat64(),
+	})
+}
@@ -0,0 +1,560 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"fitness-hack/internal/database"
+	"fitness-hack/internal/messages"
+	"fitness-hack/internal/push"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	// programVolumeStep is how much one adjustment changes a week's volume, in percent of
+	// the program's sets. Volume stays between minProgramVolume and maxProgramVolume.
+	programVolumeStep = 10
+	minProgramVolume  = 70
+	maxProgramVolume  = 130
+
+	// A week is struggling when fewer than lowAdherence of its sessions were done, fewer
+	// than lowSetCompletion of the planned sets were logged, or it was strained: sessions
+	// averaging strainedAverageRPE or more, or pain reported in painReportLimit sessions
+	lowAdherence       = 0.5
+	lowSetCompletion   = 0.8
+	strainedAverageRPE = 8.5
+	painReportLimit    = 2
+
+	// A week is going well when at least highAdherence of its sessions were done, at least
+	// fullSetCompletion of the planned sets were logged, sessions averaged easyAverageRPE
+	// or less and no pain was reported
+	highAdherence     = 0.9
+	fullSetCompletion = 0.95
+	easyAverageRPE    = 7.0
+
+	programWeekLength = 7 * 24 * time.Hour
+
+	// programAdjustBatch is how many due programs the adjustment job loads at a time
+	programAdjustBatch = 100
+)
+
+// deloadLoadFactor scales the loads of deload weeks, which also halve their sets
+var deloadLoadFactor = decimal.NewFromFloat(0.8)
+
+// Helper to convert database program adjustment to response model
+func programAdjustmentToResponse(adj *database.Program_adjustments) database.ProgramAdjustmentResponse {
+	response := database.ProgramAdjustmentResponse{
+		ID:            adj.Id,
+		ProgramID:     adj.Program_id,
+		Week:          adj.Week,
+		Kind:          string(adj.Kind),
+		VolumePercent: adj.Volume_percent,
+		Deload:        adj.Deload,
+		Reason:        adj.Reason,
+		Status:        string(adj.Status),
+		ReviewedBy:    adj.Reviewed_by,
+		ReviewedAt:    adj.Reviewed_at,
+		ReviewNote:    adj.Review_note,
+		AppliedAt:     adj.Applied_at,
+		CreatedAt:     adj.Created_at,
+	}
+	if len(adj.Metrics) > 0 {
+		_ = json.Unmarshal(adj.Metrics, &response.Metrics)
+	}
+	return response
+}
+
+// programWeek numbers the week of a program that at falls in, week 1 starting at
+// startedAt. Times before the program starts are in week 0.
+func programWeek(startedAt, at time.Time) int {
+	if at.Before(startedAt) {
+		return 0
+	}
+	return int(at.Sub(startedAt)/programWeekLength) + 1
+}
+
+// programWeekSettings returns the volume, in percent of the program's sets, and whether
+// the week is a deload. A changed week's volume carries over to the weeks after it, while
+// a deload only applies to its own week or to the program's scheduled deload weeks.
+func programWeekSettings(program *database.Programs, weeks []database.Program_weeks, week int) (volume int, deload bool) {
+	volume = 100
+	for _, w := range weeks {
+		if w.Week > week {
+			break
+		}
+		volume = w.Volume_percent
+		deload = w.Week == week && w.Deload
+	}
+	if every := program.Deload_every_weeks; every != nil && *every > 0 && week > 0 && week%*every == 0 {
+		deload = true
+	}
+	return volume, deload
+}
+
+// programWeekMetrics measures a program week from the sessions done in it
+func programWeekMetrics(plannedSessions int, stats []database.ProgramSessionStats) database.ProgramAdjustmentMetrics {
+	metrics := database.ProgramAdjustmentMetrics{PlannedSessions: plannedSessions, CompletedSessions: len(stats)}
+	if plannedSessions > 0 {
+		metrics.Adherence = math.Round(math.Min(float64(len(stats))/float64(plannedSessions), 1)*100) / 100
+	}
+
+	plannedSets, loggedSets, rpeSum, rated := 0, 0, 0, 0
+	for _, session := range stats {
+		plannedSets += session.Planned_sets
+		loggedSets += min(session.Logged_sets, session.Planned_sets)
+		if session.Rpe != nil {
+			rpeSum += int(*session.Rpe)
+			rated++
+		}
+		if session.Pain {
+			metrics.PainReports++
+		}
+	}
+	if plannedSets > 0 {
+		completion := math.Round(float64(loggedSets)/float64(plannedSets)*100) / 100
+		metrics.SetCompletion = &completion
+	}
+	if rated > 0 {
+		average := math.Round(float64(rpeSum)/float64(rated)*10) / 10
+		metrics.AverageRPE = &average
+	}
+	return metrics
+}
+
+// weekSignals describes what stood out about a measured week, for an adjustment's reason
+func weekSignals(m database.ProgramAdjustmentMetrics) string {
+	signals := []string{fmt.Sprintf("%d of %d sessions done", min(m.CompletedSessions, m.PlannedSessions), m.PlannedSessions)}
+	if m.SetCompletion != nil {
+		signals = append(signals, fmt.Sprintf("%.0f%% of planned sets logged", *m.SetCompletion*100))
+	}
+	if m.AverageRPE != nil {
+		signals = append(signals, fmt.Sprintf("average session RPE %.1f", *m.AverageRPE))
+	}
+	if m.PainReports > 0 {
+		signals = append(signals, fmt.Sprintf("pain reported in %d sessions", m.PainReports))
+	}
+	return strings.Join(signals, ", ")
+}
+
+// proposeProgramAdjustment decides how to adjust week of a program from the metrics of the
+// week before it, returning nil when the week should stay as it is:
+//   - extend_deload makes the week a deload too when a deload week was still strained
+//   - remove_volume takes programVolumeStep off the week's volume when the last week was
+//     struggling
+//   - add_volume adds programVolumeStep when the last week went well
+//
+// Weeks after an unattended week and scheduled deloads are left alone.
+func proposeProgramAdjustment(program *database.Programs, weeks []database.Program_weeks, week int, metrics database.ProgramAdjustmentMetrics) *database.Program_adjustments {
+	if metrics.CompletedSessions == 0 || week < 2 {
+		return nil
+	}
+	previousVolume, previousDeload := programWeekSettings(program, weeks, week-1)
+	_, deload := programWeekSettings(program, weeks, week)
+
+	strained := metrics.AverageRPE != nil && *metrics.AverageRPE >= strainedAverageRPE || metrics.PainReports >= painReportLimit
+	adjustment := &database.Program_adjustments{
+		Program_id:     program.Id,
+		User_id:        program.User_id,
+		Week:           week,
+		Volume_percent: previousVolume,
+	}
+	switch {
+	case deload:
+		return nil
+
+	case previousDeload && strained:
+		adjustment.Kind = database.Program_adjustments_kind_extend_deload
+		adjustment.Deload = true
+		adjustment.Reason = "Still recovering after the deload week: " + weekSignals(metrics)
+
+	case strained || metrics.Adherence < lowAdherence || metrics.SetCompletion != nil && *metrics.SetCompletion < lowSetCompletion:
+		if previousVolume <= minProgramVolume {
+			return nil
+		}
+		adjustment.Kind = database.Program_adjustments_kind_remove_volume
+		adjustment.Volume_percent = max(previousVolume-programVolumeStep, minProgramVolume)
+		adjustment.Reason = "Last week was a struggle: " + weekSignals(metrics)
+
+	case !previousDeload && metrics.Adherence >= highAdherence && metrics.PainReports == 0 &&
+		metrics.AverageRPE != nil && *metrics.AverageRPE <= easyAverageRPE &&
+		(metrics.SetCompletion == nil || *metrics.SetCompletion >= fullSetCompletion):
+		if previousVolume >= maxProgramVolume {
+			return nil
+		}
+		adjustment.Kind = database.Program_adjustments_kind_add_volume
+		adjustment.Volume_percent = min(previousVolume+programVolumeStep, maxProgramVolume)
+		adjustment.Reason = "Last week went well: " + weekSignals(metrics)
+
+	default:
+		return nil
+	}
+	return adjustment
+}
+
+// adjustPlanForWeek scales the sets of a session plan to the program week's volume, at
+// least one set per exercise. Deload weeks also halve the sets and take loads down to
+// deloadLoadFactor, rounded to prescriptionRoundingKg.
+func adjustPlanForWeek(plan []database.PlannedExerciseResponse, volume int, deload bool) {
+	for i := range plan {
+		planned := &plan[i]
+		planned.Sets = max(int(math.Round(float64(planned.Sets*volume)/100)), 1)
+		if !deload {
+			continue
+		}
+		planned.Sets = (planned.Sets + 1) / 2
+		if planned.WeightKg <= 0 {
+			continue
+		}
+		load := decimal.NewFromFloat(planned.WeightKg).Mul(deloadLoadFactor).Div(prescriptionRoundingKg).Round(0).Mul(prescriptionRoundingKg)
+		planned.WeightKg = load.InexactFloat64()
+		planned.PlatesPerSideKg = nil
+		if planned.TrainingMaxKg != nil && load.GreaterThanOrEqual(defaultBarKg) {
+			plates, _ := platesPerSide(load, defaultBarKg, standardPlatesKg)
+			planned.PlatesPerSideKg = decimalsToFloats(plates)
+		}
+	}
+}
+
+// adjustSessionPlan applies the program week a session of the workout started at falls
+// in to its plan, when the workout belongs to a program
+func (s *FiberServer) adjustSessionPlan(ctx context.Context, plan []database.PlannedExerciseResponse, workoutID string, startedAt time.Time) error {
+	workout, err := s.db.GetWorkoutByID(ctx, workoutID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil || workout.Program_id == "" {
+		return err
+	}
+	program, err := s.db.GetProgramByID(ctx, workout.Program_id)
+	if err != nil {
+		return err
+	}
+	week := programWeek(program.Started_at, startedAt)
+	if week == 0 {
+		return nil
+	}
+	weeks, err := s.db.ListProgramWeeks(ctx, program.Id)
+	if err != nil {
+		return err
+	}
+	if volume, deload := programWeekSettings(program, weeks, week); volume != 100 || deload {
+		adjustPlanForWeek(plan, volume, deload)
+	}
+	return nil
+}
+
+// StartProgramAdjustments periodically adjusts the weeks programs have moved into
+func (s *FiberServer) StartProgramAdjustments(ctx context.Context) {
+	s.runPeriodically(ctx, getEnvDuration("PROGRAM_ADJUSTMENT_INTERVAL", 6*time.Hour), s.adjustDuePrograms)
+}
+
+func (s *FiberServer) adjustDuePrograms(ctx context.Context) {
+	for ctx.Err() == nil {
+		now := time.Now()
+		listCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		programs, err := s.db.ListProgramsDueForAdjustment(listCtx, now, programAdjustBatch)
+		cancel()
+		if err != nil {
+			s.logError("ERROR", messages.ProgramAdjustmentsFailed, err, nil, map[string]interface{}{
+				"component": "program_adjustments",
+			})
+			return
+		}
+
+		// A program that failed is still due, so stop after this batch rather than fetch it again
+		failed := false
+		for i := range programs {
+			adjustCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+			err := s.adjustProgram(adjustCtx, &programs[i], now)
+			cancel()
+			if err != nil {
+				failed = true
+				s.logError("ERROR", messages.ProgramAdjustmentFailed, err, nil, map[string]interface{}{
+					"component":  "program_adjustments",
+					"program_id": programs[i].Id,
+				})
+			}
+		}
+		if failed || len(programs) < programAdjustBatch {
+			return
+		}
+	}
+}
+
+// adjustProgram evaluates the week of the program now falls in, from the sessions of the
+// week before. Adjustments to coached programs wait for the coach's review; the others
+// apply straight away.
+func (s *FiberServer) adjustProgram(ctx context.Context, program *database.Programs, now time.Time) error {
+	week := programWeek(program.Started_at, now)
+	planned, err := s.db.CountProgramWorkouts(ctx, program.Id)
+	if err != nil {
+		return err
+	}
+
+	if planned > 0 {
+		weeks, err := s.db.ListProgramWeeks(ctx, program.Id)
+		if err != nil {
+			return err
+		}
+		from := program.Started_at.Add(time.Duration(week-2) * programWeekLength)
+		stats, err := s.db.ListProgramSessionStats(ctx, program.Id, program.User_id, from, from.Add(programWeekLength))
+		if err != nil {
+			return err
+		}
+		metrics := programWeekMetrics(planned, stats)
+		if adjustment := proposeProgramAdjustment(program, weeks, week, metrics); adjustment != nil {
+			if err := s.saveProgramAdjustment(ctx, program, adjustment, metrics); err != nil {
+				return err
+			}
+		}
+	}
+	return s.db.MarkProgramAdjusted(ctx, program.Id, week)
+}
+
+// saveProgramAdjustment records a proposed adjustment and lets the coach know when it
+// awaits their review
+func (s *FiberServer) saveProgramAdjustment(ctx context.Context, program *database.Programs, adjustment *database.Program_adjustments, metrics database.ProgramAdjustmentMetrics) error {
+	adjustment.Status = database.Program_adjustments_status_applied
+	if program.Coach_id != nil {
+		adjustment.Status = database.Program_adjustments_status_pending
+	}
+	metricsJSON, err := json.Marshal(metrics)
+	if err != nil {
+		return err
+	}
+	adjustment.Metrics = metricsJSON
+
+	saved, err := s.db.CreateProgramAdjustment(ctx, adjustment)
+	if errors.Is(err, sql.ErrNoRows) {
+		// The week was already adjusted
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if saved.Status == database.Program_adjustments_status_pending {
+		s.sendPushNotification(ctx, *program.Coach_id, push.Notification{
+			Title: "Program adjustment to review",
+			Body:  fmt.Sprintf("Week %d of %s: %s", saved.Week, program.Name, saved.Reason),
+			Data:  map[string]string{"type": "program_adjustment", "programId": program.Id, "adjustmentId": saved.Id},
+		})
+	}
+	return nil
+}
+
+// programForReview returns the program with the given ID if the user owns or coaches it,
+// and whether they are its coach. Other users get sql.ErrNoRows.
+func (s *FiberServer) programForReview(ctx context.Context, id, userID string) (*database.Programs, bool, error) {
+	program, err := s.db.GetProgramByID(ctx, id)
+	if err != nil {
+		return nil, false, err
+	}
+	coach := program.Coach_id != nil && *program.Coach_id == userID
+	if program.User_id != userID && !coach {
+		return nil, false, sql.ErrNoRows
+	}
+	return program, coach, nil
+}
+
+// GET /api/v1/programs/:id/adjustments
+func (s *FiberServer) listProgramAdjustments(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	opts := getListOptions(c)
+	opts.Filters = map[string]string{}
+	if status := c.Query("status"); status != "" {
+		if !database.Program_adjustments_status(status).Valid() {
+			return errorResponse(c, fiber.StatusBadRequest, "status must be pending, applied or rejected")
+		}
+		opts.Filters["status"] = status
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	program, _, err := s.programForReview(ctx, c.Params("id"), userID)
+	if err != nil {
+		return notFound(c, "Program")
+	}
+
+	adjustments, err := s.db.ListProgramAdjustments(ctx, program.Id, opts)
+	if errors.Is(err, database.ErrInvalidListOption) {
+		return errorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+	if err != nil {
+		LogDatabaseError(s, "list_program_adjustments", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to list program adjustments")
+	}
+	responses := make([]database.ProgramAdjustmentResponse, len(adjustments))
+	for i := range adjustments {
+		responses[i] = programAdjustmentToResponse(&adjustments[i])
+	}
+	return successResponse(c, responses)
+}
+
+// reviewProgramAdjustment returns the handler with which a program's coach approves
+// (applying the adjustment to its week) or rejects an adjustment awaiting review
+//
+// POST /api/v1/programs/:id/adjustments/:adjustmentId/approve
+// POST /api/v1/programs/:id/adjustments/:adjustmentId/reject
+func (s *FiberServer) reviewProgramAdjustment(approve bool) fiber.Handler {
+	status := database.Program_adjustments_status_rejected
+	if approve {
+		status = database.Program_adjustments_status_applied
+	}
+
+	return func(c *fiber.Ctx) error {
+		userID, err := getUserIDFromJWT(c)
+		if err != nil {
+			return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+		}
+
+		var req database.ReviewProgramAdjustmentRequest
+		if len(c.Body()) > 0 {
+			if err := c.BodyParser(&req); err != nil {
+				return errorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+			}
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		program, coach, err := s.programForReview(ctx, c.Params("id"), userID)
+		if err != nil {
+			return notFound(c, "Program")
+		}
+		if !coach {
+			return errorResponse(c, fiber.StatusForbidden, "Only the program's coach can review adjustments")
+		}
+
+		adjustment, err := s.db.GetProgramAdjustment(ctx, c.Params("adjustmentId"))
+		if err != nil || adjustment.Program_id != program.Id {
+			return notFound(c, "Program adjustment")
+		}
+
+		reviewed, err := s.db.ReviewProgramAdjustment(ctx, adjustment.Id, userID, status, strings.TrimSpace(req.Note))
+		if errors.Is(err, sql.ErrNoRows) {
+			return errorResponse(c, fiber.StatusConflict, "Program adjustment was already reviewed")
+		}
+		if err != nil {
+			LogDatabaseError(s, "review_program_adjustment", err, c)
+			return errorResponse(c, fiber.StatusInternalServerError, "Failed to review program adjustment")
+		}
+		return successResponse(c, programAdjustmentToResponse(reviewed))
+	}
+}
+
+// GET /api/v1/users/me/adjustment-reviews
+// Lists the adjustments awaiting the caller's review on the programs they coach
+func (s *FiberServer) listAdjustmentReviews(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	adjustments, err := s.db.ListPendingProgramAdjustments(ctx, userID, getListOptions(c))
+	if errors.Is(err, database.ErrInvalidListOption) {
+		return errorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+	if err != nil {
+		LogDatabaseError(s, "list_pending_program_adjustments", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to list program adjustments")
+	}
+	responses := make([]database.ProgramAdjustmentResponse, len(adjustments))
+	for i := range adjustments {
+		responses[i] = programAdjustmentToResponse(&adjustments[i])
+	}
+	return successResponse(c, responses)
+}
+
+// PUT /api/v1/programs/:id/coach
+// Coaches must coach the program's owner, or be an owner or admin of an organization the
+// owner belongs to
+func (s *FiberServer) setProgramCoach(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	var req database.ProgramCoachRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+	if req.CoachID == "" {
+		return errorResponse(c, fiber.StatusBadRequest, "coachId is required")
+	}
+	if req.CoachID == userID {
+		return errorResponse(c, fiber.StatusBadRequest, "You can't coach your own program")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	program, err := s.db.GetProgramByID(ctx, c.Params("id"))
+	if err != nil || program.User_id != userID {
+		return notFound(c, "Program")
+	}
+
+	coach, err := s.db.IsOrganizationCoach(ctx, req.CoachID, userID)
+	if err != nil {
+		LogDatabaseError(s, "is_organization_coach", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to set program coach")
+	}
+	if !coach {
+		if coach, err = s.db.IsCoachOf(ctx, req.CoachID, userID); err != nil {
+			LogDatabaseError(s, "is_coach_of", err, c)
+			return errorResponse(c, fiber.StatusInternalServerError, "Failed to set program coach")
+		}
+	}
+	if !coach {
+		return errorResponse(c, fiber.StatusBadRequest, "Coach must be one of your coaches or an admin of one of your organizations")
+	}
+
+	updated, err := s.db.SetProgramCoach(ctx, program.Id, &req.CoachID)
+	if err != nil {
+		LogDatabaseError(s, "set_program_coach", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to set program coach")
+	}
+	return c.JSON(convertProgramToResponse(updated))
+}
+
+// DELETE /api/v1/programs/:id/coach
+// The program's owner or coach can end the coaching. Adjustments awaiting review are rejected.
+func (s *FiberServer) removeProgramCoach(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	program, _, err := s.programForReview(ctx, c.Params("id"), userID)
+	if err != nil {
+		return notFound(c, "Program")
+	}
+	if program.Coach_id == nil {
+		return errorResponse(c, fiber.StatusNotFound, "Program has no coach")
+	}
+
+	if _, err := s.db.SetProgramCoach(ctx, program.Id, nil); err != nil {
+		LogDatabaseError(s, "set_program_coach", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to remove program coach")
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,103 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"fitness-hack/internal/database"
+)
+
+func TestProgramWeekSettings(t *testing.T) {
+	start := time.Date(2025, 8, 4, 6, 0, 0, 0, time.UTC)
+	if week := programWeek(start, start.AddDate(0, 0, 13)); week != 2 {
+		t.Errorf("13 days in is week %d, want 2", week)
+	}
+	if week := programWeek(start, start.Add(-time.Hour)); week != 0 {
+		t.Errorf("before the start is week %d, want 0", week)
+	}
+
+	every := 4
+	program := &database.Programs{Deload_every_weeks: &every}
+	weeks := []database.Program_weeks{{Week: 2, Volume_percent: 110}, {Week: 5, Volume_percent: 110, Deload: true}}
+	tests := []struct {
+		week   int
+		volume int
+		deload bool
+	}{
+		{1, 100, false},
+		{3, 110, false},
+		{4, 110, true},
+		{5, 110, true},
+		{6, 110, false},
+	}
+	for _, tt := range tests {
+		if volume, deload := programWeekSettings(program, weeks, tt.week); volume != tt.volume || deload != tt.deload {
+			t.Errorf("week %d = %d%%, deload %v, want %d%%, deload %v", tt.week, volume, deload, tt.volume, tt.deload)
+		}
+	}
+}
+
+func TestProposeProgramAdjustment(t *testing.T) {
+	rpe := func(v int16) *int16 { return &v }
+	session := func(planned, logged int, sessionRPE *int16, pain bool) database.ProgramSessionStats {
+		return database.ProgramSessionStats{Planned_sets: planned, Logged_sets: logged, Rpe: sessionRPE, Pain: pain}
+	}
+
+	every := 4
+	program := &database.Programs{Id: "program", User_id: "user", Deload_every_weeks: &every}
+	tests := []struct {
+		name   string
+		weeks  []database.Program_weeks
+		week   int
+		stats  []database.ProgramSessionStats
+		kind   database.Program_adjustments_kind
+		volume int
+	}{
+		{"easy week adds volume", nil, 2, []database.ProgramSessionStats{session(12, 12, rpe(6), false), session(12, 12, rpe(7), false), session(10, 10, nil, false)}, database.Program_adjustments_kind_add_volume, 110},
+		{"missed sets remove volume", []database.Program_weeks{{Week: 2, Volume_percent: 110}}, 3, []database.ProgramSessionStats{session(12, 8, rpe(7), false), session(12, 8, rpe(7), false), session(12, 12, rpe(7), false)}, database.Program_adjustments_kind_remove_volume, 100},
+		{"pain removes volume down to the minimum", []database.Program_weeks{{Week: 2, Volume_percent: 75}}, 3, []database.ProgramSessionStats{session(10, 10, rpe(6), true), session(10, 10, rpe(6), true), session(10, 10, rpe(6), false)}, database.Program_adjustments_kind_remove_volume, 70},
+		{"strained deload is extended", nil, 5, []database.ProgramSessionStats{session(6, 6, rpe(9), false), session(6, 6, rpe(8), false), session(6, 6, rpe(9), false)}, database.Program_adjustments_kind_extend_deload, 100},
+		{"scheduled deload is left alone", nil, 4, []database.ProgramSessionStats{session(10, 4, rpe(10), true)}, "", 0},
+		{"easy deload doesn't add volume", nil, 5, []database.ProgramSessionStats{session(6, 6, rpe(5), false), session(6, 6, rpe(5), false), session(6, 6, rpe(5), false)}, "", 0},
+		{"unattended week is left alone", nil, 3, nil, "", 0},
+		{"no feedback doesn't add volume", nil, 2, []database.ProgramSessionStats{session(10, 10, nil, false), session(10, 10, nil, false), session(10, 10, nil, false)}, "", 0},
+	}
+	for _, tt := range tests {
+		adjustment := proposeProgramAdjustment(program, tt.weeks, tt.week, programWeekMetrics(3, tt.stats))
+		if tt.kind == "" {
+			if adjustment != nil {
+				t.Errorf("%s: got %+v, want no adjustment", tt.name, adjustment)
+			}
+			continue
+		}
+		if adjustment == nil || adjustment.Kind != tt.kind || adjustment.Volume_percent != tt.volume || adjustment.Week != tt.week {
+			t.Errorf("%s: got %+v, want %s to %d%% in week %d", tt.name, adjustment, tt.kind, tt.volume, tt.week)
+		}
+	}
+
+	metrics := programWeekMetrics(4, []database.ProgramSessionStats{session(10, 12, rpe(8), true), session(10, 5, rpe(9), false)})
+	if metrics.Adherence != 0.5 || *metrics.SetCompletion != 0.75 || *metrics.AverageRPE != 8.5 || metrics.PainReports != 1 {
+		t.Errorf("unexpected metrics %+v", metrics)
+	}
+	if got, want := weekSignals(metrics), "2 of 4 sessions done, 75% of planned sets logged, average session RPE 8.5, pain reported in 1 sessions"; got != want {
+		t.Errorf("weekSignals = %q, want %q", got, want)
+	}
+}
+
+func TestAdjustPlanForWeek(t *testing.T) {
+	trainingMax := 140.0
+	plan := []database.PlannedExerciseResponse{
+		{Sets: 3, WeightKg: 105, TrainingMaxKg: &trainingMax, PlatesPerSideKg: []float64{25, 15, 2.5}},
+		{Sets: 1, WeightKg: 0},
+	}
+	adjustPlanForWeek(plan, 130, false)
+	if plan[0].Sets != 4 || plan[1].Sets != 1 || plan[0].WeightKg != 105 {
+		t.Errorf("130%% volume = %+v, want 4 and 1 sets at the same loads", plan)
+	}
+
+	adjustPlanForWeek(plan, 100, true)
+	// 80% of 105 kg is 84 kg, which rounds to 85
+	if plan[0].Sets != 2 || plan[0].WeightKg != 85 || len(plan[0].PlatesPerSideKg) == 0 || plan[1].Sets != 1 {
+		t.Errorf("deload = %+v, want half the sets at 85 kg", plan)
+	}
+}
@@ -1,6 +1,7 @@
 package server
 
 import (
+	"errors"
 	"time"
 
 	"fitness-hack/internal/database"
@@ -20,14 +21,22 @@ type ProgramResponse struct {
 	IsActive      bool      `json:"isActive"`
 	CreatedAt     time.Time `json:"createdAt"`
 	UpdatedAt     time.Time `json:"updatedAt"`
+	Version       int       `json:"version"`
+
+	// StartedAt is when week 1 of the program starts. Every DeloadEveryWeeks-th week is a deload.
+	StartedAt        time.Time `json:"startedAt"`
+	DeloadEveryWeeks *int      `json:"deloadEveryWeeks,omitempty"`
+	CoachID          *string   `json:"coachId,omitempty"`
 }
 
 // CreateProgramRequest represents the request structure for creating programs
 type CreateProgramRequest struct {
-	Name          string  `json:"name"`
-	Description   *string `json:"description,omitempty"`
-	DurationWeeks *int    `json:"durationWeeks,omitempty"`
-	Difficulty    *string `json:"difficulty,omitempty"`
+	Name             string     `json:"name"`
+	Description      *string    `json:"description,omitempty"`
+	DurationWeeks    *int       `json:"durationWeeks,omitempty"`
+	Difficulty       *string    `json:"difficulty,omitempty"`
+	StartedAt        *time.Time `json:"startedAt,omitempty"`
+	DeloadEveryWeeks *int       `json:"deloadEveryWeeks,omitempty"`
 }
 
 // UpdateProgramRequest represents the request structure for updating programs
@@ -37,28 +46,23 @@ type UpdateProgramRequest struct {
 	DurationWeeks *int    `json:"durationWeeks,omitempty"`
 	Difficulty    *string `json:"difficulty,omitempty"`
 	IsActive      *bool   `json:"isActive,omitempty"`
+	Version       *int    `json:"version,omitempty"`
+
+	StartedAt        *time.Time `json:"startedAt,omitempty"`
+	DeloadEveryWeeks *int       `json:"deloadEveryWeeks,omitempty"`
 }
 
 // convertProgramToResponse converts a database Programs to ProgramResponse
 func convertProgramToResponse(program *database.Programs) *ProgramResponse {
-	// Handle type assertions safely
-	var name string
-	if program.Name != nil {
-		if str, ok := program.Name.(string); ok {
-			name = str
-		}
-	}
-
 	var difficulty *string
 	if program.Difficulty != nil {
-		if str, ok := program.Difficulty.(string); ok {
-			difficulty = &str
-		}
+		str := string(*program.Difficulty)
+		difficulty = &str
 	}
 
 	return &ProgramResponse{
 		ID:            program.Id,
-		Name:          name,
+		Name:          program.Name,
 		Description:   &program.Description,
 		UserID:        program.User_id,
 		DurationWeeks: &program.Duration_weeks,
@@ -66,6 +70,11 @@ func convertProgramToResponse(program *database.Programs) *ProgramResponse {
 		IsActive:      program.Is_active,
 		CreatedAt:     program.Created_at,
 		UpdatedAt:     program.Updated_at,
+		Version:       program.Version,
+
+		StartedAt:        program.Started_at,
+		DeloadEveryWeeks: program.Deload_every_weeks,
+		CoachID:          program.Coach_id,
 	}
 }
 
@@ -84,40 +93,60 @@ func convertRequestToProgram(req *CreateProgramRequest, userID string) *database
 		durationWeeks = *req.DurationWeeks
 	}
 
-	var difficulty interface{}
+	var difficulty *database.Programs_difficulty
 	if req.Difficulty != nil {
-		difficulty = *req.Difficulty
+		value := database.Programs_difficulty(*req.Difficulty)
+		difficulty = &value
+	}
+
+	startedAt := now
+	if req.StartedAt != nil {
+		startedAt = *req.StartedAt
 	}
 
 	return &database.Programs{
-		Id:             uuid.New().String(),
-		Name:           req.Name,
-		Description:    description,
-		User_id:        userID,
-		Duration_weeks: durationWeeks,
-		Difficulty:     difficulty,
-		Is_active:      true,
-		Created_at:     now,
-		Updated_at:     now,
+		Id:                 uuid.New().String(),
+		Name:               req.Name,
+		Description:        description,
+		User_id:            userID,
+		Duration_weeks:     durationWeeks,
+		Difficulty:         difficulty,
+		Is_active:          true,
+		Created_at:         now,
+		Updated_at:         now,
+		Started_at:         startedAt,
+		Deload_every_weeks: req.DeloadEveryWeeks,
 	}
 }
 
+// validDeloadInterval reports whether weeks is an allowed number of weeks between deloads
+func validDeloadInterval(weeks *int) bool {
+	return weeks == nil || *weeks >= 2 && *weeks <= 12
+}
+
 // createProgram handles POST /api/programs
 func (s *FiberServer) createProgram(c *fiber.Ctx) error {
 	var req CreateProgramRequest
 	if err := c.BodyParser(&req); err != nil {
 		return errorResponse(c, fiber.StatusBadRequest, "Invalid request body")
 	}
+	if req.Difficulty != nil && !database.Programs_difficulty(*req.Difficulty).Valid() {
+		return errorResponse(c, fiber.StatusBadRequest, "Invalid difficulty")
+	}
+	if !validDeloadInterval(req.DeloadEveryWeeks) {
+		return errorResponse(c, fiber.StatusBadRequest, "deloadEveryWeeks must be between 2 and 12")
+	}
 
-	// TODO: Get user ID from authentication context
-	// For now, using a placeholder user ID
-	userID := "placeholder-user-id"
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
 
 	program := convertRequestToProgram(&req, userID)
 
 	createdProgram, err := s.db.CreateProgram(c.Context(), program)
 	if err != nil {
-		return errorResponse(c, fiber.StatusInternalServerError, "Failed to create program")
+		return serverError("Failed to create program", err)
 	}
 
 	response := convertProgramToResponse(createdProgram)
@@ -130,7 +159,11 @@ func (s *FiberServer) getProgram(c *fiber.Ctx) error {
 
 	program, err := s.db.GetProgramByID(c.Context(), id)
 	if err != nil {
-		return errorResponse(c, fiber.StatusNotFound, "Program not found")
+		return notFound(c, "Program")
+	}
+
+	if notModified(c, resourceETag(program.Id, program.Updated_at)) {
+		return c.SendStatus(fiber.StatusNotModified)
 	}
 
 	response := convertProgramToResponse(program)
@@ -139,16 +172,22 @@ func (s *FiberServer) getProgram(c *fiber.Ctx) error {
 
 // listPrograms handles GET /api/programs
 func (s *FiberServer) listPrograms(c *fiber.Ctx) error {
-	limit, offset := getPaginationParams(c)
-
-	programs, err := s.db.ListPrograms(c.Context(), limit, offset)
+	programs, err := s.db.ListPrograms(c.Context(), getListOptions(c))
+	if errors.Is(err, database.ErrInvalidListOption) {
+		return errorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
 	if err != nil {
 		return errorResponse(c, fiber.StatusInternalServerError, "Failed to list programs")
 	}
 
 	responses := make([]*ProgramResponse, len(programs))
+	tags := make([]string, len(programs))
 	for i, program := range programs {
 		responses[i] = convertProgramToResponse(&program)
+		tags[i] = resourceETag(program.Id, program.Updated_at)
+	}
+	if notModified(c, listETag(tags)) {
+		return c.SendStatus(fiber.StatusNotModified)
 	}
 
 	return c.JSON(responses)
@@ -163,10 +202,55 @@ func (s *FiberServer) updateProgram(c *fiber.Ctx) error {
 		return errorResponse(c, fiber.StatusBadRequest, "Invalid request body")
 	}
 
+	return s.saveProgramUpdate(c, id, req, nil)
+}
+
+// programPatchFields are the program fields a merge patch may change
+var programPatchFields = patchFields{
+	"name":             false,
+	"description":      true,
+	"durationWeeks":    false,
+	"difficulty":       true,
+	"isActive":         false,
+	"version":          false,
+	"startedAt":        false,
+	"deloadEveryWeeks": true,
+}
+
+// patchProgram handles PATCH /api/programs/{id}
+func (s *FiberServer) patchProgram(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	var req UpdateProgramRequest
+	cleared, err := applyMergePatch(c.Body(), &req, programPatchFields)
+	if err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	return s.saveProgramUpdate(c, id, req, cleared)
+}
+
+// saveProgramUpdate applies the set fields of req to the stored program, clears the
+// fields in cleared, and saves it
+func (s *FiberServer) saveProgramUpdate(c *fiber.Ctx, id string, req UpdateProgramRequest, cleared map[string]bool) error {
+	if req.Difficulty != nil && !database.Programs_difficulty(*req.Difficulty).Valid() {
+		return errorResponse(c, fiber.StatusBadRequest, "Invalid difficulty")
+	}
+	if !validDeloadInterval(req.DeloadEveryWeeks) {
+		return errorResponse(c, fiber.StatusBadRequest, "deloadEveryWeeks must be between 2 and 12")
+	}
+
 	// Get existing program
 	existingProgram, err := s.db.GetProgramByID(c.Context(), id)
 	if err != nil {
-		return errorResponse(c, fiber.StatusNotFound, "Program not found")
+		return notFound(c, "Program")
+	}
+
+	if etag := resourceETag(existingProgram.Id, existingProgram.Updated_at); ifMatchFails(c, etag) {
+		return preconditionFailed(c, etag)
+	}
+	if req.Version != nil {
+		existingProgram.Version = *req.Version
 	}
 
 	// Update fields if provided
@@ -180,18 +264,38 @@ func (s *FiberServer) updateProgram(c *fiber.Ctx) error {
 		existingProgram.Duration_weeks = *req.DurationWeeks
 	}
 	if req.Difficulty != nil {
-		existingProgram.Difficulty = *req.Difficulty
+		difficulty := database.Programs_difficulty(*req.Difficulty)
+		existingProgram.Difficulty = &difficulty
 	}
 	if req.IsActive != nil {
 		existingProgram.Is_active = *req.IsActive
 	}
+	if req.StartedAt != nil {
+		existingProgram.Started_at = *req.StartedAt
+	}
+	if req.DeloadEveryWeeks != nil {
+		existingProgram.Deload_every_weeks = req.DeloadEveryWeeks
+	}
+	if cleared["description"] {
+		existingProgram.Description = ""
+	}
+	if cleared["difficulty"] {
+		existingProgram.Difficulty = nil
+	}
+	if cleared["deloadEveryWeeks"] {
+		existingProgram.Deload_every_weeks = nil
+	}
 	existingProgram.Updated_at = time.Now()
 
 	updatedProgram, err := s.db.UpdateProgram(c.Context(), existingProgram)
+	if errors.Is(err, database.ErrVersionConflict) {
+		return versionConflict(c)
+	}
 	if err != nil {
-		return errorResponse(c, fiber.StatusInternalServerError, "Failed to update program")
+		return serverError("Failed to update program", err)
 	}
 
+	c.Set(fiber.HeaderETag, resourceETag(updatedProgram.Id, updatedProgram.Updated_at))
 	response := convertProgramToResponse(updatedProgram)
 	return c.JSON(response)
 }
@@ -200,9 +304,19 @@ func (s *FiberServer) updateProgram(c *fiber.Ctx) error {
 func (s *FiberServer) deleteProgram(c *fiber.Ctx) error {
 	id := c.Params("id")
 
+	if c.Get(fiber.HeaderIfMatch) != "" {
+		existing, err := s.db.GetProgramByID(c.Context(), id)
+		if err != nil {
+			return notFound(c, "Program")
+		}
+		if etag := resourceETag(existing.Id, existing.Updated_at); ifMatchFails(c, etag) {
+			return preconditionFailed(c, etag)
+		}
+	}
+
 	err := s.db.DeleteProgram(c.Context(), id)
 	if err != nil {
-		return errorResponse(c, fiber.StatusInternalServerError, "Failed to delete program")
+		return serverError("Failed to delete program", err)
 	}
 
 	return c.SendStatus(fiber.StatusNoContent)
@@ -0,0 +1,788 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"math"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"fitness-hack/internal/database"
+	"fitness-hack/internal/messages"
+	"fitness-hack/internal/storage"
+	"fitness-hack/internal/vault"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// photoThumbnailSize is the longest side of progress photo thumbnails, in pixels
+const photoThumbnailSize = 320
+
+const (
+	// photoUploadTTL is how long a presigned progress photo upload URL stays valid
+	photoUploadTTL = 15 * time.Minute
+	// photoUploadAbandonedAfter is how long an unconfirmed upload is kept before it is deleted
+	photoUploadAbandonedAfter = 24 * time.Hour
+	// photoUploadCleanupBatch bounds the abandoned uploads deleted per statement
+	photoUploadCleanupBatch = 100
+)
+
+// photoContentTypes are the image formats accepted for progress photos, by file extension
+var photoContentTypes = map[string]string{
+	"image/jpeg": "jpg",
+	"image/png":  "png",
+}
+
+// Helper to convert database progress photo to response model
+func progressPhotoToResponse(photo *database.Progress_photos) database.ProgressPhotoResponse {
+	response := database.ProgressPhotoResponse{
+		ID:          photo.Id,
+		Pose:        string(photo.Pose),
+		TakenAt:     photo.Taken_at,
+		Notes:       photo.Notes,
+		Status:      string(photo.Status),
+		ContentType: photo.Content_type,
+		SizeBytes:   photo.Size_bytes,
+		Width:       photo.Width,
+		Height:      photo.Height,
+		Vaulted:     photo.Vaulted,
+		CreatedAt:   photo.Created_at,
+	}
+	if photo.Status == database.Progress_photos_status_ready {
+		response.ImageURL = progressPhotoPath(photo.Id) + "/image"
+	}
+	if photo.Thumbnail_key != "" {
+		response.ThumbnailURL = progressPhotoPath(photo.Id) + "/thumbnail"
+		response.ThumbnailWidth, response.ThumbnailHeight = photo.Thumbnail_width, photo.Thumbnail_height
+	}
+	return response
+}
+
+// progressPhotoPath returns the API path of a progress photo
+func progressPhotoPath(photoID string) string {
+	return "/api/v1/progress-photos/" + photoID
+}
+
+// photoStorageKeys returns where a photo's image and thumbnail are stored. Vaulted images
+// get their own key, so moving a photo in or out of the vault never overwrites the copy
+// being moved.
+func photoStorageKeys(userID, photoID, contentType string, vaulted bool) (imageKey, thumbnailKey string) {
+	prefix := fmt.Sprintf("progress-photos/%s/%s", userID, photoID)
+	if vaulted {
+		return prefix + ".vault", ""
+	}
+	return prefix + "." + photoContentTypes[contentType], prefix + "-thumb.jpg"
+}
+
+// thumbnailScale returns the factor a width x height image is scaled by so its longest side
+// is at most size pixels. Smaller images aren't scaled up.
+func thumbnailScale(width, height, size int) float64 {
+	return math.Min(1, float64(size)/float64(max(width, height)))
+}
+
+// thumbnailSize returns the dimensions of the thumbnail of a width x height image
+func thumbnailSize(width, height, size int) (int, int) {
+	scale := thumbnailScale(width, height, size)
+	return max(1, int(math.Round(float64(width)*scale))), max(1, int(math.Round(float64(height)*scale)))
+}
+
+// makeThumbnail scales img down so its longest side is at most size pixels and encodes it
+// as a JPEG
+func makeThumbnail(img image.Image, size int) ([]byte, error) {
+	bounds := img.Bounds()
+	scale := thumbnailScale(bounds.Dx(), bounds.Dy(), size)
+	width, height := thumbnailSize(bounds.Dx(), bounds.Dy(), size)
+
+	// Nearest-neighbour sampling is plenty for a list thumbnail
+	thumb := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		srcY := bounds.Min.Y + int(float64(y)/scale)
+		for x := 0; x < width; x++ {
+			thumb.Set(x, y, img.At(bounds.Min.X+int(float64(x)/scale), srcY))
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, thumb, &jpeg.Options{Quality: 80}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// storePhotoImage writes a photo's image, encrypted with dataKey if the photo is vaulted
+// and with a thumbnail otherwise
+func (s *FiberServer) storePhotoImage(ctx context.Context, photo *database.Progress_photos, body []byte, dataKey []byte) error {
+	imageKey, thumbnailKey := photoStorageKeys(photo.User_id, photo.Id, photo.Content_type, photo.Vaulted)
+	if photo.Vaulted {
+		sealed, err := vault.Seal(dataKey, body)
+		if err != nil {
+			return err
+		}
+		if err := s.storage.Put(ctx, imageKey, bytes.NewReader(sealed), "application/octet-stream"); err != nil {
+			return err
+		}
+		photo.Thumbnail_width, photo.Thumbnail_height = 0, 0
+	} else {
+		img, _, err := image.Decode(bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		if err := s.storage.Put(ctx, imageKey, bytes.NewReader(body), photo.Content_type); err != nil {
+			return err
+		}
+		if err := s.storePhotoThumbnail(ctx, photo, img, thumbnailKey); err != nil {
+			return err
+		}
+	}
+	photo.Storage_key, photo.Thumbnail_key = imageKey, thumbnailKey
+	return nil
+}
+
+// storePhotoThumbnail writes the thumbnail of a photo's decoded image under key, recording
+// the image's and the thumbnail's dimensions on photo
+func (s *FiberServer) storePhotoThumbnail(ctx context.Context, photo *database.Progress_photos, img image.Image, key string) error {
+	thumbnail, err := makeThumbnail(img, photoThumbnailSize)
+	if err != nil {
+		return err
+	}
+	if err := s.storage.Put(ctx, key, bytes.NewReader(thumbnail), "image/jpeg"); err != nil {
+		return err
+	}
+	bounds := img.Bounds()
+	photo.Width, photo.Height = bounds.Dx(), bounds.Dy()
+	photo.Thumbnail_width, photo.Thumbnail_height = thumbnailSize(bounds.Dx(), bounds.Dy(), photoThumbnailSize)
+	return nil
+}
+
+// readPhotoImage returns a photo's image, decrypted with dataKey if the photo is vaulted
+func (s *FiberServer) readPhotoImage(ctx context.Context, photo *database.Progress_photos, dataKey []byte) ([]byte, error) {
+	body, err := s.storage.Get(ctx, photo.Storage_key)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+	if photo.Vaulted {
+		return vault.Open(dataKey, data)
+	}
+	return data, nil
+}
+
+// deletePhotoFiles removes stored files a photo no longer uses. Failures only leave an
+// orphaned file, so they are logged rather than returned.
+func (s *FiberServer) deletePhotoFiles(ctx context.Context, photoID string, keys ...string) {
+	for _, key := range keys {
+		if key == "" {
+			continue
+		}
+		if err := s.storage.Delete(ctx, key); err != nil && !errors.Is(err, storage.ErrNotFound) {
+			messages.Log(messages.PhotoFileDeleteFailed, key, photoID, err)
+		}
+	}
+}
+
+// photoVaultKey returns the vault key needed to read photo, nil for photos outside the
+// vault. On failure the error response has been sent and is returned with ok false.
+func (s *FiberServer) photoVaultKey(ctx context.Context, c *fiber.Ctx, userID string, photo *database.Progress_photos) (key []byte, ok bool, err error) {
+	if !photo.Vaulted {
+		return nil, true, nil
+	}
+	key, keyErr := s.unlockedVaultKey(ctx, userID)
+	if keyErr != nil {
+		return nil, false, errorResponse(c, fiber.StatusLocked, "Photo vault is locked, unlock it to view vaulted photos")
+	}
+	return key, true, nil
+}
+
+// POST /api/v1/progress-photos
+// Accepts a JPEG or PNG in the multipart "file" field, with optional pose, takenAt (RFC
+// 3339, default now) and notes fields. With vault=true the image is encrypted with the
+// vault key and gets no thumbnail, which needs the vault to be unlocked.
+func (s *FiberServer) uploadProgressPhoto(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, "Request must be multipart/form-data with an image in the \"file\" field")
+	}
+	file, err := header.Open()
+	if err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, "Failed to read file")
+	}
+	body, err := io.ReadAll(file)
+	file.Close()
+	if err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, "Failed to read file")
+	}
+
+	contentType := http.DetectContentType(body)
+	if _, ok := photoContentTypes[contentType]; !ok {
+		return errorResponse(c, fiber.StatusBadRequest, "File must be a JPEG or PNG image")
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(body))
+	if err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, "File must be a JPEG or PNG image")
+	}
+
+	photo := &database.Progress_photos{
+		Id:           uuid.NewString(),
+		User_id:      userID,
+		Pose:         database.Progress_photos_pose(c.FormValue("pose", "front")),
+		Taken_at:     time.Now(),
+		Notes:        strings.TrimSpace(c.FormValue("notes")),
+		Content_type: contentType,
+		Size_bytes:   int64(len(body)),
+		Status:       database.Progress_photos_status_ready,
+		Width:        config.Width,
+		Height:       config.Height,
+	}
+	if !photo.Pose.Valid() {
+		return errorResponse(c, fiber.StatusBadRequest, "pose must be front, side, back or other")
+	}
+	if takenAt := c.FormValue("takenAt"); takenAt != "" {
+		if photo.Taken_at, err = time.Parse(time.RFC3339, takenAt); err != nil {
+			return errorResponse(c, fiber.StatusBadRequest, "takenAt must be an RFC 3339 timestamp")
+		}
+	}
+	if vaulted := c.FormValue("vault"); vaulted != "" {
+		if photo.Vaulted, err = strconv.ParseBool(vaulted); err != nil {
+			return errorResponse(c, fiber.StatusBadRequest, "vault must be true or false")
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	dataKey, ok, err := s.photoVaultKey(ctx, c, userID, photo)
+	if !ok {
+		return err
+	}
+	if err := s.storePhotoImage(ctx, photo, body, dataKey); err != nil {
+		LogError(s, "ERROR", messages.PhotoStoreFailed, err, c, nil)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to upload photo")
+	}
+
+	created, err := s.db.CreateProgressPhoto(ctx, photo)
+	if err != nil {
+		s.deletePhotoFiles(ctx, photo.Id, photo.Storage_key, photo.Thumbnail_key)
+		if quotaErr, ok := asQuotaError(err); ok {
+			return quotaExceeded(c, quotaErr)
+		}
+		LogDatabaseError(s, "create_progress_photo", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to upload photo")
+	}
+
+	c.Location(progressPhotoPath(created.Id))
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"data": progressPhotoToResponse(created)})
+}
+
+// photoMaxBytes is the largest progress photo accepted, PROGRESS_PHOTO_MAX_BYTES (default 20 MB)
+func photoMaxBytes() int64 {
+	return int64(getEnvInt("PROGRESS_PHOTO_MAX_BYTES", 20<<20))
+}
+
+// POST /api/v1/progress-photos/upload-url
+// Starts an upload straight to object storage: the image is sent with a PUT to the returned
+// URL, then confirmed at the complete URL, which checks it and makes the thumbnail. The photo
+// isn't listed until then. Storage that can't presign URLs is uploaded to through the API.
+func (s *FiberServer) createProgressPhotoUploadURL(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	var req database.ProgressPhotoUploadRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+	if _, ok := photoContentTypes[req.ContentType]; !ok {
+		return errorResponse(c, fiber.StatusBadRequest, "contentType must be image/jpeg or image/png")
+	}
+	if req.Pose == "" {
+		req.Pose = string(database.Progress_photos_pose_front)
+	}
+	photo := &database.Progress_photos{
+		Id:           uuid.NewString(),
+		User_id:      userID,
+		Pose:         database.Progress_photos_pose(req.Pose),
+		Taken_at:     time.Now(),
+		Notes:        strings.TrimSpace(req.Notes),
+		Content_type: req.ContentType,
+		Status:       database.Progress_photos_status_pending,
+	}
+	if !photo.Pose.Valid() {
+		return errorResponse(c, fiber.StatusBadRequest, "pose must be front, side, back or other")
+	}
+	if req.TakenAt != nil {
+		photo.Taken_at = *req.TakenAt
+	}
+	photo.Storage_key, _ = photoStorageKeys(userID, photo.Id, photo.Content_type, false)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	expiresAt := time.Now().Add(photoUploadTTL)
+	uploadURL, err := s.storage.PresignPut(ctx, photo.Storage_key, photo.Content_type, photoUploadTTL)
+	if errors.Is(err, storage.ErrPresignUnsupported) {
+		uploadURL = progressPhotoPath(photo.Id) + "/file"
+	} else if err != nil {
+		LogError(s, "ERROR", messages.PhotoStoreFailed, err, c, map[string]interface{}{"photo_id": photo.Id})
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to start upload")
+	}
+
+	created, err := s.db.CreateProgressPhoto(ctx, photo)
+	if quotaErr, ok := asQuotaError(err); ok {
+		return quotaExceeded(c, quotaErr)
+	}
+	if err != nil {
+		LogDatabaseError(s, "create_progress_photo", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to start upload")
+	}
+
+	c.Location(progressPhotoPath(created.Id))
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"data": database.ProgressPhotoUploadResponse{
+		Photo:       progressPhotoToResponse(created),
+		UploadURL:   uploadURL,
+		ContentType: created.Content_type,
+		CompleteURL: progressPhotoPath(created.Id) + "/complete",
+		ExpiresAt:   expiresAt,
+	}})
+}
+
+// PUT /api/v1/progress-photos/:id/file
+// Receives an upload when object storage can't take it directly
+func (s *FiberServer) uploadProgressPhotoFile(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	photo, err := s.db.GetProgressPhotoUpload(ctx, c.Params("id"), userID)
+	if err != nil {
+		return notFound(c, "Photo")
+	}
+	if photo.Status != database.Progress_photos_status_pending {
+		return errorResponse(c, fiber.StatusConflict, "Photo has already been uploaded")
+	}
+	if contentType, _, err := mime.ParseMediaType(c.Get(fiber.HeaderContentType)); err != nil || contentType != photo.Content_type {
+		return errorResponse(c, fiber.StatusUnsupportedMediaType, "Content-Type must be "+photo.Content_type)
+	}
+	if len(c.Body()) == 0 {
+		return errorResponse(c, fiber.StatusBadRequest, "The file is empty")
+	}
+	if int64(len(c.Body())) > photoMaxBytes() {
+		return errorResponse(c, fiber.StatusRequestEntityTooLarge, "Photo is too large")
+	}
+
+	if err := s.storage.Put(ctx, photo.Storage_key, bytes.NewReader(c.Body()), photo.Content_type); err != nil {
+		LogError(s, "ERROR", messages.PhotoStoreFailed, err, c, map[string]interface{}{"photo_id": photo.Id})
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to store photo")
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// POST /api/v1/progress-photos/:id/complete
+// Confirms an upload once the image is stored: checks it is the JPEG or PNG announced and
+// not too large, records its dimensions and makes its thumbnail. An image that fails the
+// checks, or doesn't fit in the photo storage quota, is deleted so it can be uploaded again.
+// Confirming again returns the photo unchanged.
+func (s *FiberServer) completeProgressPhotoUpload(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	photo, err := s.db.GetProgressPhotoUpload(ctx, c.Params("id"), userID)
+	if err != nil {
+		return notFound(c, "Photo")
+	}
+	if photo.Status == database.Progress_photos_status_ready {
+		return successResponse(c, progressPhotoToResponse(photo))
+	}
+
+	object, err := s.storage.Get(ctx, photo.Storage_key)
+	if errors.Is(err, storage.ErrNotFound) {
+		return errorResponse(c, fiber.StatusConflict, "The photo hasn't been uploaded yet")
+	}
+	if err != nil {
+		LogError(s, "ERROR", messages.PhotoReadFailed, err, c, map[string]interface{}{"photo_id": photo.Id})
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to check upload")
+	}
+	maxBytes := photoMaxBytes()
+	body, err := io.ReadAll(io.LimitReader(object, maxBytes+1))
+	object.Close()
+	if err != nil {
+		LogError(s, "ERROR", messages.PhotoReadFailed, err, c, map[string]interface{}{"photo_id": photo.Id})
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to check upload")
+	}
+	if int64(len(body)) > maxBytes {
+		s.deletePhotoFiles(ctx, photo.Id, photo.Storage_key)
+		return errorResponse(c, fiber.StatusRequestEntityTooLarge, "Photo is too large")
+	}
+	var img image.Image
+	if http.DetectContentType(body) == photo.Content_type {
+		img, _, err = image.Decode(bytes.NewReader(body))
+	}
+	if img == nil || err != nil {
+		s.deletePhotoFiles(ctx, photo.Id, photo.Storage_key)
+		return errorResponse(c, fiber.StatusBadRequest, "Uploaded file must be a "+photo.Content_type+" image")
+	}
+
+	_, thumbnailKey := photoStorageKeys(userID, photo.Id, photo.Content_type, false)
+	if err := s.storePhotoThumbnail(ctx, photo, img, thumbnailKey); err != nil {
+		LogError(s, "ERROR", messages.PhotoStoreFailed, err, c, map[string]interface{}{"photo_id": photo.Id})
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to complete upload")
+	}
+	photo.Thumbnail_key = thumbnailKey
+	photo.Size_bytes = int64(len(body))
+
+	completed, err := s.db.CompleteProgressPhoto(ctx, photo)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Completed or deleted by a concurrent request
+		if completed, err = s.db.GetProgressPhoto(ctx, photo.Id, userID); err != nil {
+			return notFound(c, "Photo")
+		}
+		return successResponse(c, progressPhotoToResponse(completed))
+	}
+	if quotaErr, ok := asQuotaError(err); ok {
+		s.deletePhotoFiles(ctx, photo.Id, photo.Storage_key, photo.Thumbnail_key)
+		return quotaExceeded(c, quotaErr)
+	}
+	if err != nil {
+		LogDatabaseError(s, "complete_progress_photo", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to complete upload")
+	}
+
+	return successResponse(c, progressPhotoToResponse(completed))
+}
+
+// StartProgressPhotoUploadCleanup periodically deletes uploads that were started more than a
+// day ago and never confirmed (every PROGRESS_PHOTO_UPLOAD_CLEANUP_INTERVAL, default 1h)
+func (s *FiberServer) StartProgressPhotoUploadCleanup(ctx context.Context) {
+	s.runPeriodically(ctx, getEnvDuration("PROGRESS_PHOTO_UPLOAD_CLEANUP_INTERVAL", time.Hour), s.purgeAbandonedPhotoUploads)
+}
+
+func (s *FiberServer) purgeAbandonedPhotoUploads(ctx context.Context) {
+	cutoff := time.Now().Add(-photoUploadAbandonedAfter)
+	for ctx.Err() == nil {
+		batchCtx, cancel := context.WithTimeout(ctx, time.Minute)
+		photos, err := s.db.DeleteAbandonedProgressPhotos(batchCtx, cutoff, photoUploadCleanupBatch)
+		if err != nil {
+			cancel()
+			s.logError("ERROR", messages.PhotoUploadCleanupFailed, err, nil, map[string]interface{}{"component": "progress_photos"})
+			return
+		}
+		for i := range photos {
+			s.deletePhotoFiles(batchCtx, photos[i].Id, photos[i].Storage_key, photos[i].Thumbnail_key)
+		}
+		cancel()
+		if len(photos) < photoUploadCleanupBatch {
+			return
+		}
+	}
+}
+
+// GET /api/v1/progress-photos?from=&to=
+// Lists the user's own photos taken in the date range. Vaulted photos are listed without
+// thumbnails whether or not the vault is unlocked, and uploads not yet confirmed aren't
+// listed.
+func (s *FiberServer) listProgressPhotos(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	from, to, err := getDateRange(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+	opts := database.ListProgressPhotosOpts{ListOptions: getListOptions(c), From: from, To: to}
+	opts.Filters = map[string]string{}
+	for _, filter := range []string{"pose", "vaulted"} {
+		if value := c.Query(filter); value != "" {
+			opts.Filters[filter] = value
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	photos, err := s.db.ListProgressPhotos(ctx, userID, opts)
+	if errors.Is(err, database.ErrInvalidListOption) {
+		return errorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+	if err != nil {
+		LogDatabaseError(s, "list_progress_photos", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to fetch photos")
+	}
+
+	response := make([]database.ProgressPhotoResponse, len(photos))
+	for i := range photos {
+		response[i] = progressPhotoToResponse(&photos[i])
+	}
+	return successResponse(c, response)
+}
+
+// GET /api/v1/progress-photos/:id
+func (s *FiberServer) getProgressPhoto(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	photo, err := s.db.GetProgressPhoto(ctx, c.Params("id"), userID)
+	if err != nil {
+		return notFound(c, "Photo")
+	}
+	return successResponse(c, progressPhotoToResponse(photo))
+}
+
+// GET /api/v1/progress-photos/:id/image
+func (s *FiberServer) getProgressPhotoImage(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	photo, err := s.db.GetProgressPhoto(ctx, c.Params("id"), userID)
+	if err != nil {
+		return notFound(c, "Photo")
+	}
+	dataKey, ok, err := s.photoVaultKey(ctx, c, userID, photo)
+	if !ok {
+		return err
+	}
+
+	img, err := s.readPhotoImage(ctx, photo, dataKey)
+	if err != nil {
+		LogError(s, "ERROR", messages.PhotoReadFailed, err, c, map[string]interface{}{"photo_id": photo.Id})
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to read photo")
+	}
+
+	// Decrypted vault images must not outlive the unlock in a cache
+	if photo.Vaulted {
+		c.Set(fiber.HeaderCacheControl, "private, no-store")
+	} else {
+		c.Set(fiber.HeaderCacheControl, "private, max-age=3600")
+	}
+	c.Set(fiber.HeaderContentType, photo.Content_type)
+	return c.Send(img)
+}
+
+// GET /api/v1/progress-photos/:id/thumbnail
+func (s *FiberServer) getProgressPhotoThumbnail(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	photo, err := s.db.GetProgressPhoto(ctx, c.Params("id"), userID)
+	if err != nil {
+		return notFound(c, "Photo")
+	}
+	if photo.Thumbnail_key == "" {
+		return errorResponse(c, fiber.StatusNotFound, "Vaulted photos have no thumbnail")
+	}
+
+	body, err := s.storage.Get(ctx, photo.Thumbnail_key)
+	if err != nil {
+		LogError(s, "ERROR", messages.PhotoReadFailed, err, c, map[string]interface{}{"photo_id": photo.Id})
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to read thumbnail")
+	}
+	defer body.Close()
+	thumbnail, err := io.ReadAll(body)
+	if err != nil {
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to read thumbnail")
+	}
+
+	c.Set(fiber.HeaderCacheControl, "private, max-age=3600")
+	c.Set(fiber.HeaderContentType, "image/jpeg")
+	return c.Send(thumbnail)
+}
+
+// POST /api/v1/progress-photos/:id/vault moves a photo into the vault, and
+// DELETE /api/v1/progress-photos/:id/vault moves it back out. Both need the vault unlocked.
+func (s *FiberServer) moveProgressPhoto(vaulted bool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := getUserIDFromJWT(c)
+		if err != nil {
+			return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+
+		photo, err := s.db.GetProgressPhoto(ctx, c.Params("id"), userID)
+		if err != nil {
+			return notFound(c, "Photo")
+		}
+		if photo.Vaulted == vaulted {
+			return successResponse(c, progressPhotoToResponse(photo))
+		}
+		dataKey, err := s.unlockedVaultKey(ctx, userID)
+		if err != nil {
+			return errorResponse(c, fiber.StatusLocked, "Photo vault is locked, unlock it to move photos")
+		}
+
+		img, err := s.readPhotoImage(ctx, photo, dataKey)
+		if err != nil {
+			LogError(s, "ERROR", messages.PhotoReadFailed, err, c, map[string]interface{}{"photo_id": photo.Id})
+			return errorResponse(c, fiber.StatusInternalServerError, "Failed to move photo")
+		}
+
+		// Write the new copy before recording the move, and only then remove the old one
+		moved := *photo
+		moved.Vaulted = vaulted
+		if err := s.storePhotoImage(ctx, &moved, img, dataKey); err != nil {
+			LogError(s, "ERROR", messages.PhotoStoreFailed, err, c, map[string]interface{}{"photo_id": photo.Id})
+			return errorResponse(c, fiber.StatusInternalServerError, "Failed to move photo")
+		}
+		updated, err := s.db.MoveProgressPhoto(ctx, &moved)
+		if err != nil {
+			s.deletePhotoFiles(ctx, photo.Id, moved.Storage_key, moved.Thumbnail_key)
+			if errors.Is(err, sql.ErrNoRows) {
+				return notFound(c, "Photo")
+			}
+			LogDatabaseError(s, "move_progress_photo", err, c)
+			return errorResponse(c, fiber.StatusInternalServerError, "Failed to move photo")
+		}
+		s.deletePhotoFiles(ctx, photo.Id, photo.Storage_key, photo.Thumbnail_key)
+
+		return successResponse(c, progressPhotoToResponse(updated))
+	}
+}
+
+// DELETE /api/v1/progress-photos/:id
+func (s *FiberServer) deleteProgressPhoto(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	photo, err := s.db.DeleteProgressPhoto(ctx, c.Params("id"), userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return notFound(c, "Photo")
+		}
+		LogDatabaseError(s, "delete_progress_photo", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to delete photo")
+	}
+	s.deletePhotoFiles(ctx, photo.Id, photo.Storage_key, photo.Thumbnail_key)
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// comparePhotoMetrics lists the changes in metrics measured near both photos
+func comparePhotoMetrics(before, after []database.Body_metrics) []database.BodyMetricChangeEntry {
+	changes := []database.BodyMetricChangeEntry{}
+	for _, metric := range database.BodyCompositionMetrics {
+		var from, to *database.Body_metrics
+		for i := range before {
+			if string(before[i].Metric) == metric {
+				from = &before[i]
+			}
+		}
+		for i := range after {
+			if string(after[i].Metric) == metric {
+				to = &after[i]
+			}
+		}
+		if from == nil || to == nil {
+			continue
+		}
+		changes = append(changes, database.BodyMetricChangeEntry{
+			Metric: metric,
+			Before: from.Measured_value.InexactFloat64(),
+			After:  to.Measured_value.InexactFloat64(),
+			Change: to.Measured_value.Sub(from.Measured_value).InexactFloat64(),
+		})
+	}
+	return changes
+}
+
+// GET /api/v1/progress-photos/compare?before=<id>&after=<id>
+// Shows two photos with the weight, body fat and lean mass measured within
+// PROGRESS_PHOTO_METRIC_WINDOW (default 7 days) of each. Comparing a vaulted photo needs
+// the vault to be unlocked.
+func (s *FiberServer) compareProgressPhotos(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+	beforeID, afterID := c.Query("before"), c.Query("after")
+	if beforeID == "" || afterID == "" {
+		return errorResponse(c, fiber.StatusBadRequest, "before and after photo IDs are required")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	window := getEnvDuration("PROGRESS_PHOTO_METRIC_WINDOW", 7*24*time.Hour)
+	sides := make([]database.PhotoComparisonSide, 2)
+	metrics := make([][]database.Body_metrics, 2)
+	photos := make([]*database.Progress_photos, 2)
+	for i, id := range []string{beforeID, afterID} {
+		photo, err := s.db.GetProgressPhoto(ctx, id, userID)
+		if err != nil {
+			return notFound(c, "Photo")
+		}
+		if _, ok, err := s.photoVaultKey(ctx, c, userID, photo); !ok {
+			return err
+		}
+		metrics[i], err = s.db.GetBodyMetricsNear(ctx, userID, photo.Taken_at, window, database.BodyCompositionMetrics)
+		if err != nil {
+			LogDatabaseError(s, "get_body_metrics_near", err, c)
+			return errorResponse(c, fiber.StatusInternalServerError, "Failed to compare photos")
+		}
+		photos[i] = photo
+		sides[i] = database.PhotoComparisonSide{
+			Photo:       progressPhotoToResponse(photo),
+			BodyMetrics: make([]database.BodyMetricResponse, len(metrics[i])),
+		}
+		for j := range metrics[i] {
+			sides[i].BodyMetrics[j] = bodyMetricToResponse(&metrics[i][j])
+		}
+	}
+
+	return successResponse(c, database.PhotoComparisonResponse{
+		Before:        sides[0],
+		After:         sides[1],
+		DaysBetween:   int(math.Round(photos[1].Taken_at.Sub(photos[0].Taken_at).Hours() / 24)),
+		MetricChanges: comparePhotoMetrics(metrics[0], metrics[1]),
+	})
+}
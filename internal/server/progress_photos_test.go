@@ -0,0 +1,182 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"fitness-hack/internal/database"
+	"fitness-hack/internal/storage"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shopspring/decimal"
+)
+
+func TestMakeThumbnail(t *testing.T) {
+	thumbnail, err := makeThumbnail(image.NewRGBA(image.Rect(0, 0, 1200, 1600)), 320)
+	if err != nil {
+		t.Fatalf("error making thumbnail. Err: %v", err)
+	}
+	img, err := jpeg.Decode(bytes.NewReader(thumbnail))
+	if err != nil {
+		t.Fatalf("expected a JPEG thumbnail. Err: %v", err)
+	}
+	if got := img.Bounds().Size(); got != image.Pt(240, 320) {
+		t.Errorf("expected 240x320, got %v", got)
+	}
+
+	// Images smaller than the thumbnail aren't scaled up
+	thumbnail, _ = makeThumbnail(image.NewRGBA(image.Rect(0, 0, 100, 50)), 320)
+	if img, _ := jpeg.Decode(bytes.NewReader(thumbnail)); img == nil || img.Bounds().Size() != image.Pt(100, 50) {
+		t.Errorf("expected small image to keep its size")
+	}
+}
+
+func TestThumbnailSize(t *testing.T) {
+	if width, height := thumbnailSize(4032, 3024, 320); width != 320 || height != 240 {
+		t.Errorf("expected 320x240, got %dx%d", width, height)
+	}
+	if width, height := thumbnailSize(3000, 2, 320); width != 320 || height != 1 {
+		t.Errorf("expected sides to stay at least a pixel, got %dx%d", width, height)
+	}
+}
+
+func TestPhotoStorageKeys(t *testing.T) {
+	imageKey, thumbnailKey := photoStorageKeys("u1", "p1", "image/png", false)
+	if imageKey != "progress-photos/u1/p1.png" || thumbnailKey != "progress-photos/u1/p1-thumb.jpg" {
+		t.Errorf("unexpected keys %q, %q", imageKey, thumbnailKey)
+	}
+	imageKey, thumbnailKey = photoStorageKeys("u1", "p1", "image/png", true)
+	if imageKey != "progress-photos/u1/p1.vault" || thumbnailKey != "" {
+		t.Errorf("expected vaulted photo to have its own key and no thumbnail, got %q, %q", imageKey, thumbnailKey)
+	}
+}
+
+func TestComparePhotoMetrics(t *testing.T) {
+	metric := func(name, value string) database.Body_metrics {
+		return database.Body_metrics{Metric: database.Body_metrics_metric(name), Measured_value: decimal.RequireFromString(value)}
+	}
+	before := []database.Body_metrics{metric("body_fat_percent", "22.5"), metric("weight_kg", "82.4")}
+	after := []database.Body_metrics{metric("weight_kg", "79.9")}
+
+	changes := comparePhotoMetrics(before, after)
+	if len(changes) != 1 {
+		t.Fatalf("expected only metrics measured near both photos, got %+v", changes)
+	}
+	if changes[0].Metric != "weight_kg" || changes[0].Change != -2.5 {
+		t.Errorf("expected weight to drop 2.5 kg, got %+v", changes[0])
+	}
+}
+
+func TestProgressPhotoUpload(t *testing.T) {
+	s, _ := newFakeServer(t)
+	s.storage = storage.NewLocal(t.TempDir())
+
+	do := func(method, path, user, contentType string, body io.Reader, out interface{}) int {
+		t.Helper()
+		req := httptest.NewRequest(method, path, body)
+		req.Header.Set("Authorization", bearer(t, user))
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		resp, err := s.App.Test(req, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out != nil {
+			envelope := struct {
+				Data interface{} `json:"data"`
+			}{out}
+			json.NewDecoder(resp.Body).Decode(&envelope)
+		}
+		return resp.StatusCode
+	}
+
+	// Without presigned uploads the image goes through the API
+	var upload database.ProgressPhotoUploadResponse
+	body := `{"contentType":"image/png","pose":"side","takenAt":"2025-08-19T07:30:00Z"}`
+	if status := do("POST", "/api/v1/progress-photos/upload-url", "u1", "application/json", strings.NewReader(body), &upload); status != fiber.StatusCreated {
+		t.Fatalf("upload-url = %d, want 201", status)
+	}
+	if upload.Photo.Status != "pending" || upload.Photo.ImageURL != "" {
+		t.Errorf("expected a pending photo without an image, got %+v", upload.Photo)
+	}
+	if upload.UploadURL != "/api/v1/progress-photos/"+upload.Photo.ID+"/file" {
+		t.Errorf("uploadUrl = %q, want the API file route", upload.UploadURL)
+	}
+	if status := do("POST", upload.CompleteURL, "u1", "", nil, nil); status != fiber.StatusConflict {
+		t.Errorf("completing before uploading = %d, want 409", status)
+	}
+	if status := do("GET", "/api/v1/progress-photos/"+upload.Photo.ID, "u1", "", nil, nil); status != fiber.StatusNotFound {
+		t.Errorf("fetching a pending photo = %d, want 404", status)
+	}
+
+	var img bytes.Buffer
+	png.Encode(&img, image.NewRGBA(image.Rect(0, 0, 800, 600)))
+	if status := do("PUT", upload.UploadURL, "u2", "image/png", bytes.NewReader(img.Bytes()), nil); status != fiber.StatusNotFound {
+		t.Errorf("uploading to another user's photo = %d, want 404", status)
+	}
+	if status := do("PUT", upload.UploadURL, "u1", "image/jpeg", bytes.NewReader(img.Bytes()), nil); status != fiber.StatusUnsupportedMediaType {
+		t.Errorf("uploading the wrong type = %d, want 415", status)
+	}
+	if status := do("PUT", upload.UploadURL, "u1", "image/png", bytes.NewReader(img.Bytes()), nil); status != fiber.StatusNoContent {
+		t.Fatalf("uploading = %d, want 204", status)
+	}
+
+	var photo database.ProgressPhotoResponse
+	if status := do("POST", upload.CompleteURL, "u1", "", nil, &photo); status != fiber.StatusOK {
+		t.Fatalf("completing = %d, want 200", status)
+	}
+	if photo.Status != "ready" || photo.Width != 800 || photo.Height != 600 || photo.SizeBytes != int64(img.Len()) {
+		t.Errorf("unexpected completed photo %+v", photo)
+	}
+	if photo.ThumbnailURL == "" || photo.ThumbnailWidth != 320 || photo.ThumbnailHeight != 240 {
+		t.Errorf("expected a 320x240 thumbnail, got %+v", photo)
+	}
+	if status := do("POST", upload.CompleteURL, "u1", "", nil, &photo); status != fiber.StatusOK || photo.Status != "ready" {
+		t.Errorf("completing again = %d, want the photo unchanged", status)
+	}
+	if status := do("GET", photo.ThumbnailURL, "u2", "", nil, nil); status != fiber.StatusNotFound {
+		t.Errorf("fetching another user's thumbnail = %d, want 404", status)
+	}
+	if status := do("GET", photo.ThumbnailURL, "u1", "", nil, nil); status != fiber.StatusOK {
+		t.Errorf("fetching the thumbnail = %d, want 200", status)
+	}
+}
+
+func TestProgressPhotoUploadRejectsMismatchedImage(t *testing.T) {
+	s, _ := newFakeServer(t)
+	s.storage = storage.NewLocal(t.TempDir())
+
+	req := httptest.NewRequest("POST", "/api/v1/progress-photos/upload-url", strings.NewReader(`{"contentType":"image/jpeg"}`))
+	req.Header.Set("Authorization", bearer(t, "u1"))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.App.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var envelope struct {
+		Data database.ProgressPhotoUploadResponse `json:"data"`
+	}
+	json.NewDecoder(resp.Body).Decode(&envelope)
+	upload := envelope.Data
+
+	// Presigned uploads can't check the body, so completing does
+	if err := s.storage.Put(req.Context(), "progress-photos/u1/"+upload.Photo.ID+".jpg", strings.NewReader("not an image"), "image/jpeg"); err != nil {
+		t.Fatal(err)
+	}
+	req = httptest.NewRequest("POST", upload.CompleteURL, nil)
+	req.Header.Set("Authorization", bearer(t, "u1"))
+	if resp, err = s.App.Test(req, -1); err != nil || resp.StatusCode != fiber.StatusBadRequest {
+		t.Fatalf("completing with a bad image = %v, %v; want 400", resp.StatusCode, err)
+	}
+	if _, err := s.storage.Get(req.Context(), "progress-photos/u1/"+upload.Photo.ID+".jpg"); err != storage.ErrNotFound {
+		t.Errorf("expected the bad image to be deleted, got %v", err)
+	}
+}
@@ -0,0 +1,351 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"fitness-hack/internal/database"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	defaultWeightIncrementKg   = 2.5
+	maxWeightIncrementKg       = 50
+	maxProgressionReps         = 100
+	defaultDeloadAfterFailures = 3
+	maxDeloadAfterFailures     = 20
+	defaultDeloadPercent       = 10
+	maxDeloadPercent           = 50
+)
+
+// deloadStep is what deloaded weights are rounded down to, in kg
+var deloadStep = decimal.RequireFromString("0.5")
+
+func progressionRuleToResponse(rule *database.Progression_rules) database.ProgressionRuleResponse {
+	return database.ProgressionRuleResponse{
+		ID:                  rule.Id,
+		WorkoutExerciseID:   rule.Workout_exercise_id,
+		WeightIncrementKg:   rule.Weight_increment_kg.InexactFloat64(),
+		MinReps:             rule.Min_reps,
+		MaxReps:             rule.Max_reps,
+		DeloadAfterFailures: rule.Deload_after_failures,
+		DeloadPercent:       rule.Deload_percent,
+		Failures:            rule.Failures,
+		CreatedAt:           rule.Created_at,
+		UpdatedAt:           rule.Updated_at,
+	}
+}
+
+func progressionStepToResponse(step *database.ProgressionStep) database.ProgressionStepResponse {
+	return database.ProgressionStepResponse{
+		WorkoutExerciseID: step.Workout_exercise_id,
+		ExerciseID:        step.Exercise_id,
+		Outcome:           string(step.Outcome),
+		PreviousReps:      step.Previous_reps,
+		PreviousWeightKg:  step.Previous_weight_kg.InexactFloat64(),
+		Reps:              step.Reps,
+		WeightKg:          step.Weight_kg.InexactFloat64(),
+		CreatedAt:         step.Created_at,
+	}
+}
+
+// newProgressionRule validates the request and returns the rule it describes, with the
+// defaults filled in
+func newProgressionRule(req *database.ProgressionRuleRequest) (*database.Progression_rules, error) {
+	rule := &database.Progression_rules{
+		Weight_increment_kg:   decimal.NewFromFloat(defaultWeightIncrementKg),
+		Min_reps:              req.MinReps,
+		Max_reps:              req.MaxReps,
+		Deload_after_failures: defaultDeloadAfterFailures,
+		Deload_percent:        defaultDeloadPercent,
+	}
+	if req.WeightIncrementKg != nil {
+		if *req.WeightIncrementKg < 0 || *req.WeightIncrementKg > maxWeightIncrementKg {
+			return nil, fmt.Errorf("weightIncrementKg must be between 0 and %d", maxWeightIncrementKg)
+		}
+		rule.Weight_increment_kg = decimal.NewFromFloat(*req.WeightIncrementKg).Round(2)
+	}
+	if (req.MinReps == nil) != (req.MaxReps == nil) {
+		return nil, errors.New("minReps and maxReps must be given together")
+	}
+	if req.MinReps != nil && (*req.MinReps < 1 || *req.MaxReps < *req.MinReps || *req.MaxReps > maxProgressionReps) {
+		return nil, fmt.Errorf("minReps must be at least 1 and maxReps between minReps and %d", maxProgressionReps)
+	}
+	if req.DeloadAfterFailures != nil {
+		if *req.DeloadAfterFailures < 0 || *req.DeloadAfterFailures > maxDeloadAfterFailures {
+			return nil, fmt.Errorf("deloadAfterFailures must be between 0 and %d", maxDeloadAfterFailures)
+		}
+		rule.Deload_after_failures = *req.DeloadAfterFailures
+	}
+	if req.DeloadPercent != nil {
+		if *req.DeloadPercent < 0 || *req.DeloadPercent > maxDeloadPercent {
+			return nil, fmt.Errorf("deloadPercent must be between 0 and %d", maxDeloadPercent)
+		}
+		rule.Deload_percent = *req.DeloadPercent
+	}
+	return rule, nil
+}
+
+// progressionStep works out the workout exercise's next prescription from the sets the
+// session logged of it. The session succeeds when it logged at least the planned sets at
+// the planned reps and weight. Success adds a rep, or with no double progression or at
+// maxReps adds weight and goes back to minReps. Failing deloadAfterFailures sessions in a
+// row takes deloadPercent off the weight, rounded down to deloadStep. ok is false when
+// the session logged no sets of the exercise.
+func progressionStep(rule *database.Progression_rules, we *database.Workout_exercises, planned database.PlannedExerciseResponse, sets []database.Workout_session_sets) (step database.Progression_steps, ok bool) {
+	logged, met := 0, 0
+	plannedWeight := decimal.NewFromFloat(planned.WeightKg)
+	for _, set := range sets {
+		if set.Exercise_id == nil || *set.Exercise_id != we.Exercise_id {
+			continue
+		}
+		logged++
+		if set.Reps >= planned.Reps && set.Weight_kg.GreaterThanOrEqual(plannedWeight) {
+			met++
+		}
+	}
+	if logged == 0 {
+		return step, false
+	}
+
+	step = database.Progression_steps{
+		Rule_id:            rule.Id,
+		Outcome:            database.Progression_steps_outcome_repeated,
+		Previous_reps:      we.Reps,
+		Previous_weight_kg: we.Weight_kg,
+		Reps:               we.Reps,
+		Weight_kg:          we.Weight_kg,
+	}
+	switch {
+	case met >= planned.Sets:
+		step.Outcome = database.Progression_steps_outcome_progressed
+		if rule.Max_reps != nil && we.Reps < *rule.Max_reps {
+			step.Reps = we.Reps + 1
+		} else {
+			step.Weight_kg = we.Weight_kg.Add(rule.Weight_increment_kg)
+			if rule.Min_reps != nil {
+				step.Reps = *rule.Min_reps
+			}
+		}
+	case rule.Deload_after_failures > 0 && rule.Failures+1 >= rule.Deload_after_failures:
+		step.Outcome = database.Progression_steps_outcome_deloaded
+		kept := decimal.NewFromInt(int64(100 - rule.Deload_percent)).Div(decimal.NewFromInt(100))
+		step.Weight_kg = we.Weight_kg.Mul(kept).Div(deloadStep).Floor().Mul(deloadStep)
+		if rule.Min_reps != nil {
+			step.Reps = *rule.Min_reps
+		}
+	}
+	return step, true
+}
+
+// ownWorkoutExercise returns the workout exercise with the given ID if it belongs to one of
+// the user's workouts, or sql.ErrNoRows
+func (s *FiberServer) ownWorkoutExercise(ctx context.Context, id, userID string) (*database.Workout_exercises, error) {
+	we, err := s.db.GetWorkoutExerciseByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	workout, err := s.db.GetWorkoutByID(ctx, we.Workout_id)
+	if err != nil {
+		return nil, err
+	}
+	if workout.User_id != userID {
+		return nil, sql.ErrNoRows
+	}
+	return we, nil
+}
+
+// PUT /api/v1/workout-exercises/:id/progression
+// Replaces any rule set before, answering 201 for the first rule of the workout exercise
+func (s *FiberServer) putProgressionRule(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	var req database.ProgressionRuleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+	rule, err := newProgressionRule(&req)
+	if err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	we, err := s.ownWorkoutExercise(ctx, c.Params("id"), userID)
+	if err != nil {
+		return notFound(c, "Workout exercise")
+	}
+	if we.Percent_of != "" {
+		return errorResponse(c, fiber.StatusBadRequest, "Workout exercises prescribed from a training max progress with the training max")
+	}
+
+	rule.Workout_exercise_id = we.Id
+	rule.User_id = userID
+	saved, created, err := s.db.UpsertProgressionRule(ctx, rule)
+	if err != nil {
+		LogDatabaseError(s, "upsert_progression_rule", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to save progression rule")
+	}
+
+	status := fiber.StatusOK
+	if created {
+		status = fiber.StatusCreated
+	}
+	return c.Status(status).JSON(fiber.Map{"data": progressionRuleToResponse(saved)})
+}
+
+// GET /api/v1/workout-exercises/:id/progression
+func (s *FiberServer) getProgressionRule(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	we, err := s.ownWorkoutExercise(ctx, c.Params("id"), userID)
+	if err != nil {
+		return notFound(c, "Workout exercise")
+	}
+	rule, err := s.db.GetProgressionRule(ctx, we.Id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return errorResponse(c, fiber.StatusNotFound, "Workout exercise has no progression rule")
+	}
+	if err != nil {
+		LogDatabaseError(s, "get_progression_rule", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to get progression rule")
+	}
+	return successResponse(c, progressionRuleToResponse(rule))
+}
+
+// DELETE /api/v1/workout-exercises/:id/progression
+func (s *FiberServer) deleteProgressionRule(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	we, err := s.ownWorkoutExercise(ctx, c.Params("id"), userID)
+	if err != nil {
+		return notFound(c, "Workout exercise")
+	}
+	err = s.db.DeleteProgressionRule(ctx, we.Id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return errorResponse(c, fiber.StatusNotFound, "Workout exercise has no progression rule")
+	}
+	if err != nil {
+		LogDatabaseError(s, "delete_progression_rule", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to delete progression rule")
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// POST /api/v1/workout-sessions/:id/progression
+// Moves the prescriptions of the session's workout exercises on, answering 201 when any
+// moved and 200 with the steps taken before when the session was already applied
+func (s *FiberServer) applySessionProgression(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	session, err := s.ownSession(ctx, c.Params("id"), userID)
+	if err != nil {
+		return notFound(c, "Workout session")
+	}
+	if session.Completed_at.IsZero() {
+		return errorResponse(c, fiber.StatusConflict, "Workout session is not completed")
+	}
+
+	plan := []database.PlannedExerciseResponse{}
+	if err := json.Unmarshal(session.Plan, &plan); err != nil {
+		LogDatabaseError(s, "decode_session_plan", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to apply progression")
+	}
+	planned := map[string]database.PlannedExerciseResponse{}
+	ids := []string{}
+	for _, p := range plan {
+		if p.WorkoutExerciseID != "" {
+			planned[p.WorkoutExerciseID] = p
+			ids = append(ids, p.WorkoutExerciseID)
+		}
+	}
+
+	rules, err := s.db.ListProgressionRules(ctx, ids)
+	if err != nil {
+		LogDatabaseError(s, "list_progression_rules", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to apply progression")
+	}
+	steps := []database.Progression_steps{}
+	if len(rules) > 0 {
+		sets, err := s.db.ListWorkoutSessionSets(ctx, session.Id)
+		if err != nil {
+			LogDatabaseError(s, "list_workout_session_sets", err, c)
+			return errorResponse(c, fiber.StatusInternalServerError, "Failed to apply progression")
+		}
+		for i := range rules {
+			we, err := s.db.GetWorkoutExerciseByID(ctx, rules[i].Workout_exercise_id)
+			if err != nil {
+				LogDatabaseError(s, "get_workout_exercise", err, c)
+				return errorResponse(c, fiber.StatusInternalServerError, "Failed to apply progression")
+			}
+			// A training max prescription set after the rule takes over from it
+			if we.Percent_of != "" {
+				continue
+			}
+			if step, ok := progressionStep(&rules[i], we, planned[we.Id], sets); ok {
+				step.Session_id = session.Id
+				steps = append(steps, step)
+			}
+		}
+	}
+
+	applied := 0
+	if len(steps) > 0 {
+		applied, err = s.db.ApplyProgressionSteps(ctx, steps)
+		if err != nil {
+			LogDatabaseError(s, "apply_progression_steps", err, c)
+			return errorResponse(c, fiber.StatusInternalServerError, "Failed to apply progression")
+		}
+		for _, step := range steps {
+			for _, rule := range rules {
+				if rule.Id == step.Rule_id {
+					s.cache.Del(ctx, workoutExerciseCacheKey(rule.Workout_exercise_id))
+				}
+			}
+		}
+	}
+
+	taken, err := s.db.ListProgressionSteps(ctx, session.Id)
+	if err != nil {
+		LogDatabaseError(s, "list_progression_steps", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to apply progression")
+	}
+	responses := make([]database.ProgressionStepResponse, len(taken))
+	for i := range taken {
+		responses[i] = progressionStepToResponse(&taken[i])
+	}
+
+	status := fiber.StatusOK
+	if applied > 0 {
+		status = fiber.StatusCreated
+	}
+	return c.Status(status).JSON(fiber.Map{"data": responses})
+}
@@ -0,0 +1,86 @@
+package server
+
+import (
+	"testing"
+
+	"fitness-hack/internal/database"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestProgressionStep(t *testing.T) {
+	squat := "squat"
+	we := &database.Workout_exercises{Id: "we", Exercise_id: squat, Sets: 3, Reps: 8, Weight_kg: decimal.NewFromInt(100)}
+	planned := database.PlannedExerciseResponse{WorkoutExerciseID: "we", ExerciseID: squat, Sets: 3, Reps: 8, WeightKg: 100}
+	logged := func(reps ...int) []database.Workout_session_sets {
+		sets := []database.Workout_session_sets{}
+		for i, r := range reps {
+			sets = append(sets, database.Workout_session_sets{Exercise_id: &squat, Set_number: i + 1, Reps: r, Weight_kg: decimal.NewFromInt(100)})
+		}
+		return sets
+	}
+	minReps, maxReps := 6, 10
+
+	for _, tc := range []struct {
+		name    string
+		rule    database.Progression_rules
+		sets    []database.Workout_session_sets
+		outcome database.Progression_steps_outcome
+		reps    int
+		weight  string
+	}{
+		{"all sets hit", database.Progression_rules{Weight_increment_kg: decimal.RequireFromString("2.5"), Deload_after_failures: 3}, logged(8, 8, 9), "progressed", 8, "102.5"},
+		{"a set short", database.Progression_rules{Weight_increment_kg: decimal.RequireFromString("2.5"), Deload_after_failures: 3}, logged(8, 8, 6), "repeated", 8, "100"},
+		{"too few sets", database.Progression_rules{Weight_increment_kg: decimal.RequireFromString("2.5"), Deload_after_failures: 3}, logged(8, 8), "repeated", 8, "100"},
+		{"third failure", database.Progression_rules{Weight_increment_kg: decimal.RequireFromString("2.5"), Deload_after_failures: 3, Deload_percent: 15, Failures: 2}, logged(5, 5, 5), "deloaded", 8, "85"},
+		{"never deloads", database.Progression_rules{Failures: 9}, logged(5, 5, 5), "repeated", 8, "100"},
+		{"double progression adds a rep", database.Progression_rules{Weight_increment_kg: decimal.NewFromInt(5), Min_reps: &minReps, Max_reps: &maxReps}, logged(8, 8, 8), "progressed", 9, "100"},
+	} {
+		step, ok := progressionStep(&tc.rule, we, planned, tc.sets)
+		if !ok || step.Outcome != tc.outcome || step.Reps != tc.reps || !step.Weight_kg.Equal(decimal.RequireFromString(tc.weight)) {
+			t.Errorf("%s: expected %s to %d x %s kg, got %+v", tc.name, tc.outcome, tc.reps, tc.weight, step)
+		}
+		if step.Previous_reps != 8 || !step.Previous_weight_kg.Equal(decimal.NewFromInt(100)) {
+			t.Errorf("%s: expected the previous prescription kept, got %+v", tc.name, step)
+		}
+	}
+
+	// At maxReps double progression adds weight and goes back to minReps
+	top := *we
+	top.Reps = maxReps
+	planned.Reps = maxReps
+	rule := database.Progression_rules{Weight_increment_kg: decimal.NewFromInt(5), Min_reps: &minReps, Max_reps: &maxReps}
+	if step, _ := progressionStep(&rule, &top, planned, logged(10, 10, 10)); step.Reps != minReps || !step.Weight_kg.Equal(decimal.NewFromInt(105)) {
+		t.Errorf("expected 6 x 105 kg, got %d x %s", step.Reps, step.Weight_kg)
+	}
+
+	// Deloads round down to half a kg
+	rule = database.Progression_rules{Deload_after_failures: 1, Deload_percent: 10}
+	odd := *we
+	odd.Weight_kg = decimal.RequireFromString("72.5")
+	if step, _ := progressionStep(&rule, &odd, planned, logged(1)); !step.Weight_kg.Equal(decimal.NewFromInt(65)) {
+		t.Errorf("expected 65 kg, got %s", step.Weight_kg)
+	}
+
+	if _, ok := progressionStep(&rule, we, planned, nil); ok {
+		t.Error("expected no step for an exercise the session skipped")
+	}
+}
+
+func TestNewProgressionRule(t *testing.T) {
+	rule, err := newProgressionRule(&database.ProgressionRuleRequest{})
+	if err != nil || !rule.Weight_increment_kg.Equal(decimal.RequireFromString("2.5")) || rule.Deload_after_failures != 3 || rule.Deload_percent != 10 {
+		t.Errorf("expected the defaults, got %+v %v", rule, err)
+	}
+
+	six, five, negative := 6, 5, -1.0
+	for name, req := range map[string]database.ProgressionRuleRequest{
+		"min without max":    {MinReps: &six},
+		"max below min":      {MinReps: &six, MaxReps: &five},
+		"negative increment": {WeightIncrementKg: &negative},
+	} {
+		if _, err := newProgressionRule(&req); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
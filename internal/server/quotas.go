@@ -0,0 +1,86 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"fitness-hack/internal/database"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// quotaLabels name the limited resources in error messages
+var quotaLabels = map[database.QuotaResource]string{
+	database.QuotaCustomExercises: "custom exercises",
+	database.QuotaWorkouts:        "workouts",
+	database.QuotaPhotoStorage:    "progress photo storage",
+}
+
+// quotaToResponse converts a resource's usage to its response model
+func quotaToResponse(resource database.QuotaResource, used, limit int64) database.QuotaResponse {
+	quota := database.QuotaResponse{Resource: string(resource), Used: used}
+	if limit > 0 {
+		remaining := max(limit-used, 0)
+		quota.Limit, quota.Remaining = &limit, &remaining
+	}
+	return quota
+}
+
+// asQuotaError returns the quota error in err's chain, if any
+func asQuotaError(err error) (*database.QuotaError, bool) {
+	var quotaErr *database.QuotaError
+	ok := errors.As(err, &quotaErr)
+	return quotaErr, ok
+}
+
+// quotaExceeded responds to a quota error: 402 Payment Required when upgrading to premium
+// would lift the limit, 429 Too Many Requests when the user must delete something first
+func quotaExceeded(c *fiber.Ctx, quotaErr *database.QuotaError) error {
+	status, message := quotaErrorMessage(quotaErr)
+	return c.Status(status).JSON(database.QuotaExceededResponse{
+		Error:     message,
+		Code:      codeQuotaExceeded,
+		RequestID: getRequestID(c),
+		Tier:      string(quotaErr.Tier),
+		Quota:     quotaToResponse(quotaErr.Resource, quotaErr.Used, quotaErr.Limit),
+	})
+}
+
+// quotaErrorMessage returns the status and message quotaExceeded responds with
+func quotaErrorMessage(quotaErr *database.QuotaError) (int, string) {
+	label := quotaLabels[quotaErr.Resource]
+	if quotaErr.Upgradable {
+		return fiber.StatusPaymentRequired, fmt.Sprintf("Your %s quota is used up; upgrade to premium for more", label)
+	}
+	return fiber.StatusTooManyRequests, fmt.Sprintf("Your %s quota is used up; delete some to make room", label)
+}
+
+// GET /api/v1/users/me/usage
+// Reports the caller's subscription tier and how much of each quota they use
+func (s *FiberServer) getUsage(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	usage, err := s.db.GetQuotaUsage(ctx, userID)
+	if err != nil {
+		LogDatabaseError(s, "get_quota_usage", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to fetch usage")
+	}
+
+	response := database.UsageResponse{
+		Tier:         string(usage.Tier),
+		PremiumUntil: usage.PremiumUntil,
+		Quotas:       make([]database.QuotaResponse, len(usage.Resources)),
+	}
+	for i, resource := range usage.Resources {
+		response.Quotas[i] = quotaToResponse(resource.Resource, resource.Used, resource.Limit)
+	}
+	return successResponse(c, response)
+}
@@ -0,0 +1,92 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"fitness-hack/internal/database"
+	"fitness-hack/internal/database/dbtest"
+)
+
+// quotaStub wraps the fake database, refusing new workouts with its error and reporting a
+// free user's usage
+type quotaStub struct {
+	*dbtest.Fake
+	err error
+}
+
+func (s *quotaStub) CreateWorkout(ctx context.Context, workout *database.Workouts) (*database.Workouts, error) {
+	return nil, s.err
+}
+
+func (s *quotaStub) GetQuotaUsage(ctx context.Context, userID string) (*database.QuotaUsage, error) {
+	return &database.QuotaUsage{Tier: database.TierFree, Resources: []database.ResourceUsage{
+		{Resource: database.QuotaCustomExercises, Used: 3, Limit: 100},
+		{Resource: database.QuotaWorkouts, Used: 12},
+		{Resource: database.QuotaPhotoStorage, Used: 2 << 30, Limit: 1 << 30},
+	}}, nil
+}
+
+func TestCreateWorkoutOverQuota(t *testing.T) {
+	stub := &quotaStub{Fake: dbtest.NewFake()}
+	s := newTestServer(t, stub)
+
+	for _, tc := range []struct {
+		err    *database.QuotaError
+		status int
+		hint   string
+	}{
+		{&database.QuotaError{Resource: database.QuotaWorkouts, Tier: database.TierFree, Limit: 500, Used: 500, Upgradable: true}, 402, "upgrade"},
+		{&database.QuotaError{Resource: database.QuotaWorkouts, Tier: database.TierPremium, Limit: 5000, Used: 5000}, 429, "delete"},
+	} {
+		stub.err = tc.err
+		req := httptest.NewRequest("POST", "/api/v1/workouts", strings.NewReader(`{"name":"Legs"}`))
+		req.Header.Set("Authorization", bearer(t, "u1"))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := s.App.Test(req, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var body database.QuotaExceededResponse
+		json.NewDecoder(resp.Body).Decode(&body)
+		if resp.StatusCode != tc.status {
+			t.Errorf("%s tier: expected %d, got %d", tc.err.Tier, tc.status, resp.StatusCode)
+		}
+		if !strings.Contains(body.Error, tc.hint) || body.Tier != string(tc.err.Tier) ||
+			body.Quota.Resource != "workouts" || *body.Quota.Limit != tc.err.Limit || *body.Quota.Remaining != 0 {
+			t.Errorf("%s tier: unexpected body %+v", tc.err.Tier, body)
+		}
+	}
+}
+
+func TestGetUsage(t *testing.T) {
+	s := newTestServer(t, &quotaStub{Fake: dbtest.NewFake()})
+
+	req := httptest.NewRequest("GET", "/api/v1/users/me/usage", nil)
+	req.Header.Set("Authorization", bearer(t, "u1"))
+	resp, err := s.App.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != 200 {
+		t.Fatalf("expected the usage, got %d", resp.StatusCode)
+	}
+	var usage struct{ Data database.UsageResponse }
+	json.NewDecoder(resp.Body).Decode(&usage)
+
+	if usage.Data.Tier != "free" || len(usage.Data.Quotas) != 3 {
+		t.Fatalf("unexpected usage %+v", usage.Data)
+	}
+	if exercises := usage.Data.Quotas[0]; *exercises.Limit != 100 || *exercises.Remaining != 97 {
+		t.Errorf("expected 97 custom exercises left, got %+v", exercises)
+	}
+	if workouts := usage.Data.Quotas[1]; workouts.Limit != nil || workouts.Remaining != nil {
+		t.Errorf("expected unlimited workouts, got %+v", workouts)
+	}
+	if photos := usage.Data.Quotas[2]; *photos.Remaining != 0 {
+		t.Errorf("expected no storage left over the limit, got %+v", photos)
+	}
+}
@@ -0,0 +1,106 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// RateLimit describes how many requests are allowed within a sliding window
+type RateLimit struct {
+	Max    int
+	Window time.Duration
+}
+
+// RateLimitConfig holds the rate limits applied by RegisterFiberRoutes
+type RateLimitConfig struct {
+	Enabled bool
+	Login   RateLimit
+	Public  RateLimit
+	Default RateLimit
+}
+
+// LoadRateLimitConfig reads rate limit settings from the environment
+func LoadRateLimitConfig() RateLimitConfig {
+	return RateLimitConfig{
+		Enabled: getEnvBool("RATE_LIMIT_ENABLED", true),
+		Login: RateLimit{
+			Max:    getEnvInt("RATE_LIMIT_LOGIN_MAX", 10),
+			Window: getEnvDuration("RATE_LIMIT_LOGIN_WINDOW", time.Minute),
+		},
+		Public: RateLimit{
+			Max:    getEnvInt("RATE_LIMIT_PUBLIC_MAX", 30),
+			Window: getEnvDuration("RATE_LIMIT_PUBLIC_WINDOW", time.Minute),
+		},
+		Default: RateLimit{
+			Max:    getEnvInt("RATE_LIMIT_DEFAULT_MAX", 100),
+			Window: getEnvDuration("RATE_LIMIT_DEFAULT_WINDOW", time.Minute),
+		},
+	}
+}
+
+// rateLimitKey identifies the caller by user ID when authenticated, otherwise by IP
+func rateLimitKey(c *fiber.Ctx, name string) string {
+	if userID, err := getUserIDFromJWT(c); err == nil {
+		return fmt.Sprintf("ratelimit:%s:user:%s", name, userID)
+	}
+	return fmt.Sprintf("ratelimit:%s:ip:%s", name, c.IP())
+}
+
+// rateLimiter returns a middleware enforcing a Redis-backed sliding window limit.
+// Each request is recorded in a sorted set scored by its timestamp; entries older
+// than the window are trimmed before counting. Redis failures fail open.
+func (s *FiberServer) rateLimiter(name string, limit RateLimit) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if limit.Max <= 0 || s.cache == nil {
+			return c.Next()
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+		defer cancel()
+
+		key := rateLimitKey(c, name)
+		now := time.Now()
+		member := fmt.Sprintf("%d-%s", now.UnixNano(), uuid.NewString())
+		windowStart := now.Add(-limit.Window).UnixNano()
+
+		pipe := s.cache.TxPipeline()
+		pipe.ZRemRangeByScore(ctx, key, "0", strconv.FormatInt(windowStart, 10))
+		pipe.ZAdd(ctx, key, redis.Z{Score: float64(now.UnixNano()), Member: member})
+		count := pipe.ZCard(ctx, key)
+		oldest := pipe.ZRangeWithScores(ctx, key, 0, 0)
+		pipe.Expire(ctx, key, limit.Window)
+		if _, err := pipe.Exec(ctx); err != nil {
+			LogCacheError(s, "rate_limit", err, c)
+			return c.Next()
+		}
+
+		c.Set("X-RateLimit-Limit", strconv.Itoa(limit.Max))
+
+		if count.Val() > int64(limit.Max) {
+			// Rejected requests should not extend the caller's window
+			s.cache.ZRem(ctx, key, member)
+
+			retryAfter := limit.Window
+			if entries := oldest.Val(); len(entries) > 0 {
+				oldestAt := time.Unix(0, int64(entries[0].Score))
+				retryAfter = oldestAt.Add(limit.Window).Sub(now)
+			}
+			if retryAfter < time.Second {
+				retryAfter = time.Second
+			}
+			c.Set("X-RateLimit-Remaining", "0")
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			return errorResponse(c, fiber.StatusTooManyRequests, "Too many requests")
+		}
+
+		c.Set("X-RateLimit-Remaining", strconv.FormatInt(int64(limit.Max)-count.Val(), 10))
+		return c.Next()
+	}
+}
@@ -0,0 +1,106 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"fitness-hack/internal/memstore"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// newRateLimitedServer returns a fake-backed test server with rate limiting enabled,
+// using the limits set in the environment
+func newRateLimitedServer(t *testing.T) *FiberServer {
+	t.Helper()
+	s, _ := newFakeServer(t)
+	t.Setenv("RATE_LIMIT_ENABLED", "true")
+	s.App = fiber.New(fiber.Config{JSONEncoder: s.encoder.Marshal, ErrorHandler: handleError})
+	s.cache = memstore.New().Client()
+	s.RegisterFiberRoutes()
+	return s
+}
+
+// sendRateLimited sends a request with a login body, authenticated when auth is set
+func sendRateLimited(t *testing.T, s *FiberServer, method, path, auth string) *http.Response {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(`{"email":"nobody@example.com","password":"wrong"}`))
+	req.Header.Set("Content-Type", "application/json")
+	if auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+	resp, err := s.App.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func TestRateLimitRejectsWithRetryAfter(t *testing.T) {
+	t.Setenv("RATE_LIMIT_LOGIN_MAX", "2")
+	t.Setenv("RATE_LIMIT_LOGIN_WINDOW", "1m")
+	s := newRateLimitedServer(t)
+
+	for i := 0; i < 2; i++ {
+		resp := sendRateLimited(t, s, "POST", "/api/v1/auth/login", "")
+		if resp.StatusCode == fiber.StatusTooManyRequests {
+			t.Fatalf("expected attempt %d allowed", i+1)
+		}
+		if got := resp.Header.Get("X-RateLimit-Remaining"); got != strconv.Itoa(1-i) {
+			t.Errorf("expected %d attempts remaining, got %q", 1-i, got)
+		}
+	}
+
+	resp := sendRateLimited(t, s, "POST", "/api/v1/auth/login", "")
+	if resp.StatusCode != fiber.StatusTooManyRequests {
+		t.Fatalf("expected the third attempt rejected, got %d", resp.StatusCode)
+	}
+	retryAfter, err := strconv.Atoi(resp.Header.Get(fiber.HeaderRetryAfter))
+	if err != nil || retryAfter < 1 || retryAfter > 60 {
+		t.Errorf("expected Retry-After within the window, got %q", resp.Header.Get(fiber.HeaderRetryAfter))
+	}
+	if got := resp.Header.Get("X-RateLimit-Remaining"); got != "0" {
+		t.Errorf("expected no attempts remaining, got %q", got)
+	}
+
+	// Rejected attempts don't count, so the window isn't pushed back by retrying
+	resp = sendRateLimited(t, s, "POST", "/api/v1/auth/login", "")
+	if again, _ := strconv.Atoi(resp.Header.Get(fiber.HeaderRetryAfter)); again < 1 || again > retryAfter {
+		t.Errorf("expected Retry-After not extended past %d, got %q", retryAfter, resp.Header.Get(fiber.HeaderRetryAfter))
+	}
+}
+
+func TestRateLimitPerUser(t *testing.T) {
+	t.Setenv("RATE_LIMIT_DEFAULT_MAX", "1")
+	s := newRateLimitedServer(t)
+	u1, u2 := bearer(t, "u1"), bearer(t, "u2")
+
+	if resp := sendRateLimited(t, s, "GET", "/api/v1/workouts", u1); resp.StatusCode != 200 {
+		t.Fatalf("expected the first request allowed, got %d", resp.StatusCode)
+	}
+	if resp := sendRateLimited(t, s, "GET", "/api/v1/workouts", u1); resp.StatusCode != fiber.StatusTooManyRequests {
+		t.Errorf("expected u1's second request rejected, got %d", resp.StatusCode)
+	}
+	if resp := sendRateLimited(t, s, "GET", "/api/v1/workouts", u2); resp.StatusCode != 200 {
+		t.Errorf("expected u2 limited separately from u1, got %d", resp.StatusCode)
+	}
+}
+
+func TestRateLimitFailsOpen(t *testing.T) {
+	t.Setenv("RATE_LIMIT_DEFAULT_MAX", "1")
+	s := newRateLimitedServer(t)
+	// Redis is down
+	s.cache = redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", DialTimeout: 10 * time.Millisecond, MaxRetries: -1})
+	t.Cleanup(func() { s.cache.Close() })
+
+	for i := 0; i < 3; i++ {
+		if resp := sendRateLimited(t, s, "GET", "/api/v1/workouts", bearer(t, "u1")); resp.StatusCode != 200 {
+			t.Fatalf("expected requests allowed while Redis is down, got %d", resp.StatusCode)
+		}
+	}
+}
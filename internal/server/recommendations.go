@@ -0,0 +1,317 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"fitness-hack/internal/database"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	// recommendationHistoryDays is how far back recommendations look for the user's sessions
+	recommendationHistoryDays = 28
+
+	// A rest day is recommended once the user has trained maxTrainingStreak days in a row
+	maxTrainingStreak = 6
+
+	// Generated workouts work the generatedMuscles muscle groups trained least in the last
+	// week, with up to exercisesPerMuscle exercises each of generatedSets sets of
+	// generatedReps reps
+	generatedMuscles     = 3
+	exercisesPerMuscle   = 2
+	generatedSets        = 3
+	generatedReps        = 10
+	generatedRestSeconds = 90
+)
+
+// recommendationInput is what recommenders know about the user's training when asked for
+// their next workout
+type recommendationInput struct {
+	userID string
+	now    time.Time
+
+	// restDays is how many days ago the user last trained, and streak how many days in a
+	// row they trained up to then. restDays is nil when they didn't train in the last
+	// recommendationHistoryDays.
+	restDays *int
+	streak   int
+
+	// weekSets counts the sets of the last week by primary muscle group, by slug
+	weekSets map[string]int
+	// fatigued are the muscle groups given fatiguedMuscleSets or more sets in the last
+	// muscleRecoveryWindow, by slug
+	fatigued map[string]bool
+}
+
+// recommender recommends the user's next workout. It returns nil when it has nothing to
+// recommend, and the next recommender is asked.
+type recommender interface {
+	Name() string
+	Recommend(ctx context.Context, s *FiberServer, in *recommendationInput) (*database.WorkoutRecommendationResponse, error)
+}
+
+// defaultRecommenders are asked in turn when the server sets no recommenders of its own
+var defaultRecommenders = []recommender{restDayRecommender{}, programRecommender{}, libraryRecommender{}}
+
+// trainingDays returns how many days before today, in loc, the user last started a
+// session, and how many days in a row they trained up to then. ok is false without sessions.
+func trainingDays(starts []time.Time, now time.Time, loc *time.Location) (restDays, streak int, ok bool) {
+	day := func(t time.Time) int {
+		y, m, d := t.In(loc).Date()
+		return int(time.Date(y, m, d, 0, 0, 0, 0, time.UTC).Unix() / 86400)
+	}
+	days := map[int]bool{}
+	last := 0
+	for _, start := range starts {
+		d := day(start)
+		if len(days) == 0 || d > last {
+			last = d
+		}
+		days[d] = true
+	}
+	if len(days) == 0 {
+		return 0, 0, false
+	}
+	for days[last-streak] {
+		streak++
+	}
+	return max(day(now)-last, 0), streak, true
+}
+
+// loadRecommendationInput gathers what recommenders know about the user's training
+func (s *FiberServer) loadRecommendationInput(ctx context.Context, userID string, now time.Time, loc *time.Location) (*recommendationInput, error) {
+	in := &recommendationInput{userID: userID, now: now, weekSets: map[string]int{}, fatigued: map[string]bool{}}
+
+	starts, err := s.db.ListSessionStarts(ctx, userID, now.AddDate(0, 0, -recommendationHistoryDays))
+	if err != nil {
+		return nil, err
+	}
+	if restDays, streak, ok := trainingDays(starts, now, loc); ok {
+		in.restDays, in.streak = &restDays, streak
+	}
+
+	week, err := s.db.ListRecentMuscleSets(ctx, userID, now.AddDate(0, 0, -7))
+	if err != nil {
+		return nil, err
+	}
+	for _, muscle := range week {
+		in.weekSets[muscle.Slug] = muscle.Sets
+	}
+	recent, err := s.db.ListRecentMuscleSets(ctx, userID, now.Add(-muscleRecoveryWindow))
+	if err != nil {
+		return nil, err
+	}
+	for _, muscle := range recent {
+		in.fatigued[muscle.Slug] = muscle.Sets >= fatiguedMuscleSets
+	}
+	return in, nil
+}
+
+// restDayRecommender recommends a rest day after maxTrainingStreak days of training in a row
+type restDayRecommender struct{}
+
+func (restDayRecommender) Name() string { return "rest_days" }
+
+func (restDayRecommender) Recommend(ctx context.Context, s *FiberServer, in *recommendationInput) (*database.WorkoutRecommendationResponse, error) {
+	if in.restDays == nil || *in.restDays > 1 || in.streak < maxTrainingStreak {
+		return nil, nil
+	}
+	return &database.WorkoutRecommendationResponse{
+		Kind:    "rest",
+		Reasons: []string{fmt.Sprintf("You've trained %d days in a row, so take a rest day", in.streak)},
+	}, nil
+}
+
+// programRecommender recommends the next workout of the user's active programs, the way
+// chooseSuggestion picks it
+type programRecommender struct{}
+
+func (programRecommender) Name() string { return "program" }
+
+func (programRecommender) Recommend(ctx context.Context, s *FiberServer, in *recommendationInput) (*database.WorkoutRecommendationResponse, error) {
+	candidates, err := s.db.ListSuggestionCandidates(ctx, in.userID, suggestionCandidates)
+	if err != nil {
+		return nil, err
+	}
+	// Workouts of active programs come first
+	active := 0
+	for active < len(candidates) && candidates[active].In_active_program {
+		active++
+	}
+	if active == 0 {
+		return nil, nil
+	}
+	candidates = candidates[:active]
+
+	workoutIDs := make([]string, len(candidates))
+	for i, candidate := range candidates {
+		workoutIDs[i] = candidate.Id
+	}
+	workoutMuscles, err := s.db.ListWorkoutMuscles(ctx, workoutIDs)
+	if err != nil {
+		return nil, err
+	}
+	muscles := map[string][]database.WorkoutMuscle{}
+	for _, muscle := range workoutMuscles {
+		muscles[muscle.Workout_id] = append(muscles[muscle.Workout_id], muscle)
+	}
+
+	chosen, reasons := chooseSuggestion(candidates, muscles, in.fatigued, in.now)
+	workout := candidates[chosen]
+	plan, err := s.resolveSessionPlan(ctx, in.userID, workout.Id, in.now)
+	if err != nil {
+		return nil, err
+	}
+	recommended := &database.SuggestedWorkoutResponse{
+		ID:          workout.Id,
+		Name:        workout.Name,
+		ProgramID:   workout.Program_id,
+		LastDoneAt:  workout.Last_done_at,
+		Plan:        plan,
+		FullMinutes: planMinutes(plan),
+	}
+	if len(plan) == 0 {
+		recommended.FullMinutes = workout.Duration_minutes
+	}
+	recommended.EstimatedMinutes = recommended.FullMinutes
+	return &database.WorkoutRecommendationResponse{Kind: "workout", Workout: recommended, Reasons: reasons}, nil
+}
+
+// libraryRecommender puts a workout together from the exercise library for the muscle
+// groups the user trained least in the last week, leaving out those still recovering
+type libraryRecommender struct{}
+
+func (libraryRecommender) Name() string { return "library" }
+
+func (libraryRecommender) Recommend(ctx context.Context, s *FiberServer, in *recommendationInput) (*database.WorkoutRecommendationResponse, error) {
+	groups, err := s.db.ListMuscleGroups(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rested := []database.Muscle_groups{}
+	recovering := []string{}
+	for _, group := range groups {
+		if in.fatigued[group.Slug] {
+			recovering = append(recovering, strings.ToLower(group.Name))
+		} else {
+			rested = append(rested, group)
+		}
+	}
+	// Groups come sorted by name, which breaks ties
+	sort.SliceStable(rested, func(i, j int) bool {
+		return in.weekSets[rested[i].Slug] < in.weekSets[rested[j].Slug]
+	})
+
+	// Ask for every rested group, as some may have no exercises
+	slugs := make([]string, len(rested))
+	for i, group := range rested {
+		slugs[i] = group.Slug
+	}
+	exercises, err := s.db.ListLibraryExercises(ctx, in.userID, slugs, exercisesPerMuscle)
+	if err != nil {
+		return nil, err
+	}
+	byMuscle := map[string][]database.LibraryExercise{}
+	for _, exercise := range exercises {
+		byMuscle[exercise.Muscle_slug] = append(byMuscle[exercise.Muscle_slug], exercise)
+	}
+
+	plan := []database.PlannedExerciseResponse{}
+	targets, names := []string{}, []string{}
+	added := map[string]bool{}
+	for _, group := range rested {
+		if len(targets) == generatedMuscles {
+			break
+		}
+		n := len(plan)
+		for _, exercise := range byMuscle[group.Slug] {
+			if added[exercise.Id] {
+				continue
+			}
+			added[exercise.Id] = true
+			plan = append(plan, database.PlannedExerciseResponse{
+				ExerciseID:   exercise.Id,
+				ExerciseName: exercise.Name,
+				OrderIndex:   len(plan),
+				Sets:         generatedSets,
+				Reps:         generatedReps,
+				RestSeconds:  generatedRestSeconds,
+			})
+		}
+		if len(plan) > n {
+			targets = append(targets, group.Slug)
+			names = append(names, group.Name)
+		}
+	}
+	if len(plan) == 0 {
+		return nil, nil
+	}
+
+	reasons := []string{"Put together from the exercise library for the muscles you trained least this week: " + strings.ToLower(strings.Join(names, ", "))}
+	if len(recovering) > 0 {
+		reasons = append(reasons, "Leaves out muscles you trained hard in the last two days: "+strings.Join(recovering, ", "))
+	}
+	minutes := planMinutes(plan)
+	return &database.WorkoutRecommendationResponse{
+		Kind: "generated",
+		Workout: &database.SuggestedWorkoutResponse{
+			Name:             strings.Join(names, ", "),
+			EstimatedMinutes: minutes,
+			FullMinutes:      minutes,
+			Plan:             plan,
+		},
+		TargetMuscles: targets,
+		Reasons:       reasons,
+	}, nil
+}
+
+// GET /api/v1/recommendations/next-workout
+func (s *FiberServer) getNextWorkoutRecommendation(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tz, err := s.userTimezone(ctx, c, userID)
+	if err != nil {
+		LogDatabaseError(s, "get_user", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to get recommendation")
+	}
+	now := time.Now()
+	today, _, err := summaryDay("", tz, now)
+	if err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	in, err := s.loadRecommendationInput(ctx, userID, now, today.Location())
+	if err != nil {
+		LogDatabaseError(s, "load_recommendation_input", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to get recommendation")
+	}
+
+	recommenders := s.recommenders
+	if recommenders == nil {
+		recommenders = defaultRecommenders
+	}
+	for _, r := range recommenders {
+		recommendation, err := r.Recommend(ctx, s, in)
+		if err != nil {
+			LogDatabaseError(s, "recommend_"+r.Name(), err, c)
+			return errorResponse(c, fiber.StatusInternalServerError, "Failed to get recommendation")
+		}
+		if recommendation != nil {
+			recommendation.Recommender = r.Name()
+			recommendation.RestDays = in.restDays
+			return successResponse(c, recommendation)
+		}
+	}
+	return errorResponse(c, fiber.StatusNotFound, "No workout to recommend")
+}
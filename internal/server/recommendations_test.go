@@ -0,0 +1,137 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"fitness-hack/internal/database"
+	"fitness-hack/internal/database/dbtest"
+)
+
+// recommendationStub trained legs hard yesterday and offers two workouts, or none without
+// a program, and a library exercise for each muscle group but the quads
+type recommendationStub struct {
+	*dbtest.Fake
+	starts  []time.Time
+	program bool
+}
+
+func (s *recommendationStub) ListSessionStarts(ctx context.Context, userID string, since time.Time) ([]time.Time, error) {
+	return s.starts, nil
+}
+
+func (s *recommendationStub) ListRecentMuscleSets(ctx context.Context, userID string, since time.Time) ([]database.MuscleSets, error) {
+	return []database.MuscleSets{{Slug: "quadriceps", Sets: 8}, {Slug: "chest", Sets: 4}}, nil
+}
+
+func (s *recommendationStub) ListSuggestionCandidates(ctx context.Context, userID string, limit int) ([]database.SuggestionCandidate, error) {
+	if !s.program {
+		return []database.SuggestionCandidate{}, nil
+	}
+	legs := database.SuggestionCandidate{In_active_program: true}
+	legs.Id, legs.Name = "legs", "Leg day"
+	pull := database.SuggestionCandidate{In_active_program: true}
+	pull.Id, pull.Name = "pull", "Pull day"
+	return []database.SuggestionCandidate{legs, pull}, nil
+}
+
+func (s *recommendationStub) ListWorkoutMuscles(ctx context.Context, workoutIDs []string) ([]database.WorkoutMuscle, error) {
+	return []database.WorkoutMuscle{
+		{Workout_id: "legs", Slug: "quadriceps", Name: "Quadriceps"},
+		{Workout_id: "pull", Slug: "lats", Name: "Lats"},
+	}, nil
+}
+
+func (s *recommendationStub) ListTrainingMaxes(ctx context.Context, userID string) ([]database.Training_maxes, error) {
+	return []database.Training_maxes{}, nil
+}
+
+func (s *recommendationStub) ListLibraryExercises(ctx context.Context, userID string, slugs []string, perMuscle int) ([]database.LibraryExercise, error) {
+	exercises := []database.LibraryExercise{}
+	for _, slug := range slugs {
+		if slug == "quadriceps" {
+			continue
+		}
+		exercise := database.LibraryExercise{Muscle_slug: slug}
+		exercise.Id, exercise.Name = slug+"-exercise", slug+" exercise"
+		exercises = append(exercises, exercise)
+	}
+	return exercises, nil
+}
+
+func getNextWorkout(t *testing.T, s *FiberServer) (int, database.WorkoutRecommendationResponse) {
+	t.Helper()
+	req := httptest.NewRequest("GET", "/api/v1/recommendations/next-workout", nil)
+	req.Header.Set("Authorization", bearer(t, "u1"))
+	resp, err := s.App.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var body struct {
+		Data database.WorkoutRecommendationResponse
+	}
+	json.NewDecoder(resp.Body).Decode(&body)
+	return resp.StatusCode, body.Data
+}
+
+func TestNextWorkoutRecommendation(t *testing.T) {
+	now := time.Now()
+	db := dbtest.NewFake()
+	stub := &recommendationStub{Fake: db, starts: []time.Time{now.AddDate(0, 0, -1)}, program: true}
+	s := newTestServer(t, stub)
+	ctx := context.Background()
+	for _, slug := range []string{"quadriceps", "lats", "chest", "hamstrings", "biceps"} {
+		exercise, _ := db.CreateExercise(ctx, &database.Exercises{Name: slug})
+		db.SetExerciseMuscles(ctx, exercise.Id, []database.MuscleAssignment{{Muscle: slug, Role: "primary"}})
+	}
+
+	status, recommendation := getNextWorkout(t, s)
+	if status != 200 || recommendation.Recommender != "program" || recommendation.Workout == nil || recommendation.Workout.ID != "pull" {
+		t.Fatalf("expected pull day while the quads recover, got %d %+v", status, recommendation)
+	}
+	if recommendation.RestDays == nil || *recommendation.RestDays != 1 {
+		t.Errorf("expected a rest day since the last session, got %v", recommendation.RestDays)
+	}
+
+	stub.program = false
+	status, recommendation = getNextWorkout(t, s)
+	if status != 200 || recommendation.Kind != "generated" || recommendation.Recommender != "library" || recommendation.Workout == nil {
+		t.Fatalf("expected a generated workout, got %d %+v", status, recommendation)
+	}
+	// Chest had sets this week, so the untrained groups come first, by name
+	want := []string{"biceps", "hamstrings", "lats"}
+	if len(recommendation.TargetMuscles) != 3 || recommendation.TargetMuscles[0] != want[0] || recommendation.TargetMuscles[1] != want[1] || recommendation.TargetMuscles[2] != want[2] {
+		t.Errorf("expected %v, got %v", want, recommendation.TargetMuscles)
+	}
+	if plan := recommendation.Workout.Plan; len(plan) != 3 || plan[0].ExerciseName != "biceps exercise" || plan[2].OrderIndex != 2 || plan[0].Sets != generatedSets {
+		t.Errorf("unexpected plan %+v", plan)
+	}
+
+	for i := 0; i < maxTrainingStreak; i++ {
+		stub.starts = append(stub.starts, now.AddDate(0, 0, -i))
+	}
+	if status, recommendation := getNextWorkout(t, s); status != 200 || recommendation.Kind != "rest" || recommendation.Recommender != "rest_days" {
+		t.Errorf("expected a rest day after %d days of training, got %d %+v", maxTrainingStreak, status, recommendation)
+	}
+}
+
+func TestTrainingDays(t *testing.T) {
+	loc := time.FixedZone("EST", -5*3600)
+	now := time.Date(2025, 8, 26, 12, 0, 0, 0, loc)
+	starts := []time.Time{
+		// 02:00 UTC on the 26th is still the 25th in loc
+		time.Date(2025, 8, 26, 2, 0, 0, 0, time.UTC),
+		time.Date(2025, 8, 24, 18, 0, 0, 0, loc),
+		time.Date(2025, 8, 24, 7, 0, 0, 0, loc),
+		time.Date(2025, 8, 21, 7, 0, 0, 0, loc),
+	}
+	if restDays, streak, ok := trainingDays(starts, now, loc); !ok || restDays != 1 || streak != 2 {
+		t.Errorf("expected 1 rest day after 2 days of training, got %d %d %v", restDays, streak, ok)
+	}
+	if _, _, ok := trainingDays(nil, now, loc); ok {
+		t.Error("expected no training days without sessions")
+	}
+}
@@ -0,0 +1,117 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"math/big"
+	"strings"
+	"time"
+
+	"fitness-hack/internal/database"
+	"fitness-hack/internal/messages"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// referralCodeAlphabet omits characters that are easily confused (0/O, 1/I/L)
+const referralCodeAlphabet = "ABCDEFGHJKMNPQRSTUVWXYZ23456789"
+
+// Helper to generate a random referral code
+func generateReferralCode() (string, error) {
+	var sb strings.Builder
+	max := big.NewInt(int64(len(referralCodeAlphabet)))
+	for i := 0; i < 8; i++ {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		sb.WriteByte(referralCodeAlphabet[n.Int64()])
+	}
+	return sb.String(), nil
+}
+
+// getOrCreateReferralCode returns the user's referral code, generating one on first use
+func (s *FiberServer) getOrCreateReferralCode(ctx context.Context, userID string) (*database.Referral_codes, error) {
+	code, err := s.db.GetReferralCode(ctx, userID)
+	if err == nil || !errors.Is(err, sql.ErrNoRows) {
+		return code, err
+	}
+
+	// Retry on the unlikely event of a collision with an existing code
+	for attempt := 0; attempt < 5; attempt++ {
+		value, genErr := generateReferralCode()
+		if genErr != nil {
+			return nil, genErr
+		}
+		if code, err = s.db.CreateReferralCode(ctx, userID, value); err == nil {
+			return code, nil
+		}
+		// A concurrent request may have created the user's code already
+		if existing, getErr := s.db.GetReferralCode(ctx, userID); getErr == nil {
+			return existing, nil
+		}
+	}
+	return nil, err
+}
+
+// attributeReferral credits the owner of code with the new user's signup.
+// Failures are logged but never block registration.
+func (s *FiberServer) attributeReferral(ctx context.Context, c *fiber.Ctx, code, userID string) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code == "" {
+		return
+	}
+
+	rewardWeeks := getEnvInt("REFERRAL_REWARD_WEEKS", 2)
+	if _, err := s.db.RecordReferral(ctx, code, userID, rewardWeeks); err != nil {
+		LogError(s, "WARN", messages.ReferralAttributionFailed, err, c, map[string]interface{}{
+			"component":     "referrals",
+			"referral_code": code,
+		})
+	}
+}
+
+// GET /api/v1/users/me/referrals
+func (s *FiberServer) getMyReferrals(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	code, err := s.getOrCreateReferralCode(ctx, userID)
+	if err != nil {
+		LogDatabaseError(s, "get_referral_code", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to load referral code")
+	}
+
+	referrals, err := s.db.ListReferralsByReferrer(ctx, userID)
+	if err != nil {
+		LogDatabaseError(s, "list_referrals", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to fetch referrals")
+	}
+
+	summary := database.ReferralSummaryResponse{
+		Code:          code.Code,
+		ReferralCount: len(referrals),
+		Referrals:     make([]database.ReferralResponse, len(referrals)),
+	}
+	for i, referral := range referrals {
+		summary.RewardWeeksEarned += referral.Reward_weeks
+		summary.Referrals[i] = database.ReferralResponse{
+			ID:          referral.Id,
+			RewardWeeks: referral.Reward_weeks,
+			CreatedAt:   referral.Created_at,
+		}
+	}
+
+	if premiumUntil, err := s.db.GetPremiumUntil(ctx, userID); err == nil {
+		summary.PremiumUntil = premiumUntil
+	}
+
+	return successResponse(c, summary)
+}
@@ -0,0 +1,97 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"fitness-hack/internal/database"
+	"fitness-hack/internal/memstore"
+	"fitness-hack/internal/oauth"
+)
+
+// emailProvider is an identity provider whose ID tokens are the verified email they assert
+type emailProvider struct{}
+
+func (emailProvider) Name() string { return "test" }
+
+func (emailProvider) Verify(ctx context.Context, idToken string) (*oauth.Identity, error) {
+	return &oauth.Identity{Provider: "test", Subject: idToken, Email: idToken, EmailVerified: true}, nil
+}
+
+func TestRegistrationGateCoversOAuthSignUp(t *testing.T) {
+	t.Setenv("REGISTRATION_GATE_ENABLED", "true")
+	t.Setenv("REGISTRATION_ALLOWED_COUNTRIES", "NZ")
+	t.Setenv("REGISTRATION_INVITE_CODES", "early-bird")
+	ctx := context.Background()
+	s, db := newFakeServer(t)
+	s.oauth = oauth.NewRegistry(emailProvider{})
+	if _, err := db.CreateUser(ctx, &database.Users{Email: "existing@example.com", Username: "existing"}); err != nil {
+		t.Fatal(err)
+	}
+
+	signIn := func(body string, headers map[string]string) int {
+		t.Helper()
+		req := httptest.NewRequest("POST", "/api/v1/auth/oauth/test", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+		resp, err := s.App.Test(req, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode
+	}
+
+	if status := signIn(`{"idToken":"new@example.com"}`, nil); status != 403 {
+		t.Errorf("expected an OAuth sign-up without an invite refused, got %d", status)
+	}
+	if _, err := db.GetUserByEmail(ctx, "new@example.com"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("expected no account created for a refused sign-up, got %v", err)
+	}
+	if status := signIn(`{"idToken":"new@example.com","inviteCode":"not-a-code"}`, nil); status != 403 {
+		t.Errorf("expected an unknown invite code refused, got %d", status)
+	}
+
+	if status := signIn(`{"idToken":"existing@example.com"}`, nil); status != 200 {
+		t.Errorf("expected existing accounts to sign in while the gate is closed, got %d", status)
+	}
+	if status := signIn(`{"idToken":"invited@example.com","inviteCode":"early-bird"}`, nil); status != 200 {
+		t.Errorf("expected an invite code in the body to open the gate, got %d", status)
+	}
+	if status := signIn(`{"idToken":"header@example.com"}`, map[string]string{"X-Invite-Code": "early-bird"}); status != 200 {
+		t.Errorf("expected an invite code header to open the gate, got %d", status)
+	}
+	if status := signIn(`{"idToken":"kiwi@example.com"}`, map[string]string{"CloudFront-Viewer-Country": "nz"}); status != 200 {
+		t.Errorf("expected sign-ups from an allowed country, got %d", status)
+	}
+	for _, email := range []string{"invited@example.com", "header@example.com", "kiwi@example.com"} {
+		if _, err := db.GetUserByEmail(ctx, email); err != nil {
+			t.Errorf("expected an account for %s, got %v", email, err)
+		}
+	}
+}
+
+func TestRegistrationGateFlagOverridesEnv(t *testing.T) {
+	t.Setenv("REGISTRATION_GATE_ENABLED", "false")
+	s, _ := newFakeServer(t)
+	s.oauth = oauth.NewRegistry(emailProvider{})
+	s.cache = memstore.New().Client()
+	s.cache.Set(context.Background(), featureFlagKey("registration_gate"), `{"enabled":true,"inviteCodes":["early-bird"]}`, 0)
+
+	for _, path := range []string{"/api/v1/auth/oauth/test", "/api/v1/users", "/api/v1/auth/guest"} {
+		req := httptest.NewRequest("POST", path, strings.NewReader(`{"idToken":"new@example.com","email":"new@example.com","username":"new","password":"correct-horse"}`))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := s.App.Test(req, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != 403 {
+			t.Errorf("expected %s gated by the feature flag, got %d", path, resp.StatusCode)
+		}
+	}
+}
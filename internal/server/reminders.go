@@ -0,0 +1,527 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"fitness-hack/internal/database"
+	"fitness-hack/internal/mailer"
+	"fitness-hack/internal/messages"
+	"fitness-hack/internal/push"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	maxRemindersPerUser = 20
+	// reminderClaimBatch is how many due reminders are claimed per query
+	reminderClaimBatch = 100
+	// reminderSendLease is how long a claimed reminder is held before another scheduler may retry it
+	reminderSendLease = 5 * time.Minute
+)
+
+// reminderDays are the weekday names used by the API, indexed by time.Weekday
+var reminderDays = [7]string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// parseClock parses a "HH:MM" time of day into minutes after midnight
+func parseClock(value string) (int, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, errors.New("must be a 24-hour time formatted as HH:MM")
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// formatClock formats minutes after midnight as "HH:MM"
+func formatClock(minutes int) string {
+	return fmt.Sprintf("%02d:%02d", minutes/60, minutes%60)
+}
+
+// daysToMask converts weekday names to the bitmask stored in reminders.days_of_week
+func daysToMask(days []string) (int, error) {
+	if len(days) == 0 {
+		return 0, errors.New("days must list at least one weekday")
+	}
+	mask := 0
+	for _, day := range days {
+		found := false
+		for i, name := range reminderDays {
+			if strings.EqualFold(day, name) {
+				mask |= 1 << i
+				found = true
+			}
+		}
+		if !found {
+			return 0, fmt.Errorf("unknown day %q, use sun, mon, tue, wed, thu, fri or sat", day)
+		}
+	}
+	return mask, nil
+}
+
+// maskToDays converts a days_of_week bitmask back to weekday names, starting on Sunday
+func maskToDays(mask int) []string {
+	days := []string{}
+	for i, name := range reminderDays {
+		if mask&(1<<i) != 0 {
+			days = append(days, name)
+		}
+	}
+	return days
+}
+
+// reminderLocation returns the reminder's timezone. Timezones are validated when saved,
+// so UTC is only used if the tz database on this host lacks one another host accepted.
+func reminderLocation(reminder *database.Reminders) *time.Location {
+	loc, err := time.LoadLocation(reminder.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// nextReminderRun returns the first time after after that the reminder is scheduled for
+func nextReminderRun(reminder *database.Reminders, after time.Time) time.Time {
+	loc := reminderLocation(reminder)
+	local := after.In(loc)
+	for i := 0; i <= 7; i++ {
+		run := time.Date(local.Year(), local.Month(), local.Day()+i,
+			reminder.Time_of_day/60, reminder.Time_of_day%60, 0, 0, loc)
+		if reminder.Days_of_week&(1<<run.Weekday()) != 0 && run.After(after) {
+			return run
+		}
+	}
+	// Unreachable with a non-empty mask, which the table enforces
+	return after.Add(24 * time.Hour)
+}
+
+// quietHoursEnd reports whether t falls inside the user's quiet hours in loc and, if so,
+// when they end. A window whose end is before its start runs past midnight.
+func quietHoursEnd(prefs *database.Notification_preferences, t time.Time, loc *time.Location) (time.Time, bool) {
+	if prefs.Quiet_hours_start == nil || prefs.Quiet_hours_end == nil || *prefs.Quiet_hours_start == *prefs.Quiet_hours_end {
+		return time.Time{}, false
+	}
+	start, end := *prefs.Quiet_hours_start, *prefs.Quiet_hours_end
+	local := t.In(loc)
+	minute := local.Hour()*60 + local.Minute()
+
+	var quiet bool
+	if start < end {
+		quiet = minute >= start && minute < end
+	} else {
+		quiet = minute >= start || minute < end
+	}
+	if !quiet {
+		return time.Time{}, false
+	}
+
+	day := local.Day()
+	if minute >= end {
+		day++
+	}
+	return time.Date(local.Year(), local.Month(), day, end/60, end%60, 0, 0, loc), true
+}
+
+// Helper to convert database reminder to response model
+func reminderToResponse(reminder *database.Reminders) database.ReminderResponse {
+	return database.ReminderResponse{
+		ID:         reminder.Id,
+		Title:      reminder.Title,
+		Message:    reminder.Message,
+		Days:       maskToDays(reminder.Days_of_week),
+		Time:       formatClock(reminder.Time_of_day),
+		Timezone:   reminder.Timezone,
+		Push:       reminder.Push,
+		Email:      reminder.Email,
+		Enabled:    reminder.Enabled,
+		NextRunAt:  reminder.Next_run_at,
+		LastSentAt: reminder.Last_sent_at,
+		CreatedAt:  reminder.Created_at,
+		UpdatedAt:  reminder.Updated_at,
+		Version:    reminder.Version,
+	}
+}
+
+// remindersETag returns the ETag of a list of reminders
+func remindersETag(responses []database.ReminderResponse) string {
+	tags := make([]string, len(responses))
+	for i, response := range responses {
+		tags[i] = resourceETag(response.ID, response.UpdatedAt)
+	}
+	return listETag(tags)
+}
+
+// validateReminder checks a reminder's text and channels and schedules its next run
+func validateReminder(reminder *database.Reminders, now time.Time) error {
+	reminder.Title = strings.TrimSpace(reminder.Title)
+	reminder.Message = strings.TrimSpace(reminder.Message)
+	if reminder.Title == "" || len(reminder.Title) > 100 {
+		return errors.New("title is required and must be at most 100 characters")
+	}
+	if len(reminder.Message) > 500 {
+		return errors.New("message must be at most 500 characters")
+	}
+	if _, err := time.LoadLocation(reminder.Timezone); err != nil || reminder.Timezone == "" {
+		return errors.New("timezone must be an IANA timezone name")
+	}
+	if !reminder.Push && !reminder.Email {
+		return errors.New("at least one of push or email must be enabled")
+	}
+
+	reminder.Next_run_at = nil
+	if reminder.Enabled {
+		next := nextReminderRun(reminder, now)
+		reminder.Next_run_at = &next
+	}
+	return nil
+}
+
+// POST /api/v1/reminders
+func (s *FiberServer) createReminder(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	var req database.CreateReminderRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+	reminder := database.Reminders{
+		User_id:  userID,
+		Title:    req.Title,
+		Message:  req.Message,
+		Timezone: req.Timezone,
+		Push:     req.Push == nil || *req.Push,
+		Email:    req.Email != nil && *req.Email,
+		Enabled:  req.Enabled == nil || *req.Enabled,
+	}
+	if reminder.Timezone == "" {
+		reminder.Timezone = "UTC"
+	}
+	if reminder.Days_of_week, err = daysToMask(req.Days); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+	if reminder.Time_of_day, err = parseClock(req.Time); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, "time "+err.Error())
+	}
+	if err := validateReminder(&reminder, time.Now()); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	existing, err := s.db.ListReminders(ctx, userID)
+	if err != nil {
+		LogDatabaseError(s, "list_reminders", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to create reminder")
+	}
+	if len(existing) >= maxRemindersPerUser {
+		return errorResponse(c, fiber.StatusConflict, fmt.Sprintf("A user can have at most %d reminders", maxRemindersPerUser))
+	}
+
+	created, err := s.db.CreateReminder(ctx, &reminder)
+	if err != nil {
+		LogDatabaseError(s, "create_reminder", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to create reminder")
+	}
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"data": reminderToResponse(created)})
+}
+
+// GET /api/v1/reminders
+func (s *FiberServer) listReminders(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	reminders, err := s.db.ListReminders(ctx, userID)
+	if err != nil {
+		LogDatabaseError(s, "list_reminders", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to list reminders")
+	}
+
+	responses := make([]database.ReminderResponse, len(reminders))
+	for i := range reminders {
+		responses[i] = reminderToResponse(&reminders[i])
+	}
+	return respondWithETag(c, remindersETag(responses), responses)
+}
+
+// GET /api/v1/reminders/:id
+func (s *FiberServer) getReminder(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	reminder, err := s.db.GetReminder(ctx, c.Params("id"), userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return notFound(c, "Reminder")
+	}
+	if err != nil {
+		LogDatabaseError(s, "get_reminder", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to get reminder")
+	}
+	return respondWithETag(c, resourceETag(reminder.Id, reminder.Updated_at), reminderToResponse(reminder))
+}
+
+// PUT /api/v1/reminders/:id
+func (s *FiberServer) updateReminder(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	var req database.UpdateReminderRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	return s.saveReminderUpdate(c, userID, req)
+}
+
+// reminderPatchFields are the reminder fields a merge patch may change
+var reminderPatchFields = patchFields{
+	"title":    false,
+	"message":  false,
+	"days":     false,
+	"time":     false,
+	"timezone": false,
+	"push":     false,
+	"email":    false,
+	"enabled":  false,
+	"version":  false,
+}
+
+// PATCH /api/v1/reminders/:id
+func (s *FiberServer) patchReminder(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	var req database.UpdateReminderRequest
+	if _, err := applyMergePatch(c.Body(), &req, reminderPatchFields); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	return s.saveReminderUpdate(c, userID, req)
+}
+
+// saveReminderUpdate applies the set fields of req to the user's reminder, validates it
+// and saves it
+func (s *FiberServer) saveReminderUpdate(c *fiber.Ctx, userID string, req database.UpdateReminderRequest) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	reminder, err := s.db.GetReminder(ctx, c.Params("id"), userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return notFound(c, "Reminder")
+	}
+	if err != nil {
+		LogDatabaseError(s, "get_reminder", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to update reminder")
+	}
+
+	if etag := resourceETag(reminder.Id, reminder.Updated_at); ifMatchFails(c, etag) {
+		return preconditionFailed(c, etag)
+	}
+	if req.Version != nil {
+		reminder.Version = *req.Version
+	}
+
+	if req.Title != nil {
+		reminder.Title = *req.Title
+	}
+	if req.Message != nil {
+		reminder.Message = *req.Message
+	}
+	if req.Days != nil {
+		if reminder.Days_of_week, err = daysToMask(req.Days); err != nil {
+			return errorResponse(c, fiber.StatusBadRequest, err.Error())
+		}
+	}
+	if req.Time != nil {
+		if reminder.Time_of_day, err = parseClock(*req.Time); err != nil {
+			return errorResponse(c, fiber.StatusBadRequest, "time "+err.Error())
+		}
+	}
+	if req.Timezone != nil {
+		reminder.Timezone = *req.Timezone
+	}
+	if req.Push != nil {
+		reminder.Push = *req.Push
+	}
+	if req.Email != nil {
+		reminder.Email = *req.Email
+	}
+	if req.Enabled != nil {
+		reminder.Enabled = *req.Enabled
+	}
+	if err := validateReminder(reminder, time.Now()); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	updated, err := s.db.UpdateReminder(ctx, reminder)
+	if errors.Is(err, database.ErrVersionConflict) {
+		return versionConflict(c)
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return notFound(c, "Reminder")
+	}
+	if err != nil {
+		LogDatabaseError(s, "update_reminder", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to update reminder")
+	}
+	c.Set(fiber.HeaderETag, resourceETag(updated.Id, updated.Updated_at))
+	return successResponse(c, reminderToResponse(updated))
+}
+
+// DELETE /api/v1/reminders/:id
+func (s *FiberServer) deleteReminder(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if c.Get(fiber.HeaderIfMatch) != "" {
+		existing, err := s.db.GetReminder(ctx, c.Params("id"), userID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return notFound(c, "Reminder")
+		}
+		if err != nil {
+			LogDatabaseError(s, "get_reminder", err, c)
+			return errorResponse(c, fiber.StatusInternalServerError, "Failed to delete reminder")
+		}
+		if etag := resourceETag(existing.Id, existing.Updated_at); ifMatchFails(c, etag) {
+			return preconditionFailed(c, etag)
+		}
+	}
+
+	err = s.db.DeleteReminder(ctx, c.Params("id"), userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return notFound(c, "Reminder")
+	}
+	if err != nil {
+		LogDatabaseError(s, "delete_reminder", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to delete reminder")
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// StartReminderScheduler periodically sends the reminders that have come due
+func (s *FiberServer) StartReminderScheduler(ctx context.Context) {
+	s.runPeriodically(ctx, getEnvDuration("REMINDER_SCHEDULER_INTERVAL", time.Minute), s.sendDueReminders)
+}
+
+func (s *FiberServer) sendDueReminders(ctx context.Context) {
+	for ctx.Err() == nil {
+		claimCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		reminders, err := s.db.ClaimDueReminders(claimCtx, reminderClaimBatch, reminderSendLease)
+		cancel()
+		if err != nil {
+			s.logError("ERROR", messages.ReminderSchedulerFailed, err, nil, map[string]interface{}{
+				"component": "reminders",
+			})
+			return
+		}
+
+		for i := range reminders {
+			sendCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+			s.sendReminder(sendCtx, &reminders[i])
+			cancel()
+		}
+		if len(reminders) < reminderClaimBatch {
+			return
+		}
+	}
+}
+
+// sendReminder delivers a claimed reminder and schedules its next run. Reminders that
+// come due during the user's quiet hours are held until the quiet hours end.
+func (s *FiberServer) sendReminder(ctx context.Context, reminder *database.Reminders) {
+	claimedUntil := *reminder.Next_run_at
+	now := time.Now()
+
+	prefs, err := s.db.GetNotificationPreferences(ctx, reminder.User_id)
+	if err != nil {
+		// Leave the reminder claimed; it is retried once the lease runs out
+		s.logError("ERROR", messages.ReminderPreferencesLoadFailed, err, nil, map[string]interface{}{
+			"component":   "reminders",
+			"reminder_id": reminder.Id,
+		})
+		return
+	}
+
+	next, sent := nextReminderRun(reminder, now), false
+	if end, quiet := quietHoursEnd(prefs, now, reminderLocation(reminder)); quiet {
+		next = end
+	} else {
+		s.deliverReminder(ctx, reminder)
+		sent = true
+	}
+
+	if err := s.db.RescheduleReminder(ctx, reminder.Id, claimedUntil, next, sent); err != nil {
+		s.logError("ERROR", messages.ReminderRescheduleFailed, err, nil, map[string]interface{}{
+			"component":   "reminders",
+			"reminder_id": reminder.Id,
+		})
+	}
+}
+
+// deliverReminder sends the reminder over each channel it has enabled
+func (s *FiberServer) deliverReminder(ctx context.Context, reminder *database.Reminders) {
+	body := reminder.Message
+	if body == "" {
+		body = "Time for your workout."
+	}
+
+	if reminder.Push {
+		s.sendPushNotification(ctx, reminder.User_id, push.Notification{
+			Title: reminder.Title,
+			Body:  body,
+			Data:  map[string]string{"type": "reminder", "reminderId": reminder.Id},
+		})
+	}
+
+	if reminder.Email {
+		user, err := s.db.GetUserByID(ctx, reminder.User_id)
+		if err != nil {
+			s.logError("ERROR", messages.ReminderUserLoadFailed, err, nil, map[string]interface{}{
+				"component":   "reminders",
+				"reminder_id": reminder.Id,
+			})
+			return
+		}
+		// Guest accounts have no email address
+		if user.Email == "" {
+			return
+		}
+		err = s.mailer.Send(ctx, mailer.Message{
+			To:      user.Email,
+			Subject: reminder.Title,
+			Body: body + "\n\nYou're receiving this because you scheduled this reminder in FitnessHack. " +
+				"You can change or turn it off in the app.\n",
+		})
+		if err != nil {
+			s.logError("WARN", messages.ReminderEmailFailed, err, nil, map[string]interface{}{
+				"component":   "reminders",
+				"reminder_id": reminder.Id,
+			})
+		}
+	}
+}
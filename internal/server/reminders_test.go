@@ -0,0 +1,66 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"fitness-hack/internal/database"
+)
+
+func TestNextReminderRun(t *testing.T) {
+	mask, err := daysToMask([]string{"mon", "THU"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if days := maskToDays(mask); len(days) != 2 || days[0] != "mon" || days[1] != "thu" {
+		t.Fatalf("expected [mon thu], got %v", days)
+	}
+	if _, err := daysToMask([]string{"monday"}); err == nil {
+		t.Fatal("expected an error for an unknown day")
+	}
+
+	reminder := &database.Reminders{Days_of_week: mask, Time_of_day: 7*60 + 30, Timezone: "America/New_York"}
+	// Monday 2025-03-03 07:30 in New York is 12:30 UTC
+	monday := time.Date(2025, 3, 3, 12, 30, 0, 0, time.UTC)
+
+	cases := []struct {
+		after time.Time
+		want  time.Time
+	}{
+		{monday.Add(-time.Minute), monday},
+		{monday, time.Date(2025, 3, 6, 12, 30, 0, 0, time.UTC)},
+		// Sunday 2025-03-09 is the DST change, so the next Monday's 07:30 is 11:30 UTC
+		{time.Date(2025, 3, 7, 0, 0, 0, 0, time.UTC), time.Date(2025, 3, 10, 11, 30, 0, 0, time.UTC)},
+	}
+	for _, tc := range cases {
+		if got := nextReminderRun(reminder, tc.after); !got.Equal(tc.want) {
+			t.Errorf("after %v: expected %v, got %v", tc.after, tc.want, got)
+		}
+	}
+}
+
+func TestQuietHoursEnd(t *testing.T) {
+	start, end := 22*60, 7*60
+	prefs := &database.Notification_preferences{Quiet_hours_start: &start, Quiet_hours_end: &end}
+
+	cases := []struct {
+		at      time.Time
+		quiet   bool
+		resumes time.Time
+	}{
+		{time.Date(2025, 3, 3, 21, 59, 0, 0, time.UTC), false, time.Time{}},
+		{time.Date(2025, 3, 3, 23, 0, 0, 0, time.UTC), true, time.Date(2025, 3, 4, 7, 0, 0, 0, time.UTC)},
+		{time.Date(2025, 3, 4, 6, 59, 0, 0, time.UTC), true, time.Date(2025, 3, 4, 7, 0, 0, 0, time.UTC)},
+		{time.Date(2025, 3, 4, 7, 0, 0, 0, time.UTC), false, time.Time{}},
+	}
+	for _, tc := range cases {
+		resumes, quiet := quietHoursEnd(prefs, tc.at, time.UTC)
+		if quiet != tc.quiet || !resumes.Equal(tc.resumes) {
+			t.Errorf("at %v: expected (%v, %v), got (%v, %v)", tc.at, tc.resumes, tc.quiet, resumes, quiet)
+		}
+	}
+
+	if _, quiet := quietHoursEnd(&database.Notification_preferences{}, time.Now(), time.UTC); quiet {
+		t.Error("expected no quiet hours when they are not set")
+	}
+}
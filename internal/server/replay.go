@@ -0,0 +1,57 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// maxClockSkew tolerates senders whose clocks run slightly ahead of ours
+const maxClockSkew = 5 * time.Minute
+
+var (
+	// errReplayedRequest is returned when a nonce has already been used
+	errReplayedRequest = errors.New("request has already been processed")
+
+	// errStaleRequest is returned when a request is outside its validity window
+	errStaleRequest = errors.New("request has expired")
+)
+
+// claimNonce records nonce as used within scope until validUntil, rejecting it if it
+// was seen before or has already expired. Nonces only need to be remembered while the
+// request would otherwise be valid, which keeps the replay cache bounded. Call the
+// returned release func if processing fails so a legitimate retry is accepted.
+func (s *FiberServer) claimNonce(ctx context.Context, scope, nonce string, validUntil time.Time) (func(), error) {
+	ttl := time.Until(validUntil)
+	if nonce == "" || ttl <= 0 {
+		return nil, errStaleRequest
+	}
+
+	key := fmt.Sprintf("replay:%s:%s", scope, nonce)
+	ok, err := s.cache.SetNX(ctx, key, "1", ttl).Result()
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errReplayedRequest
+	}
+
+	release := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		s.cache.Del(ctx, key)
+	}
+	return release, nil
+}
+
+// claimWebhookNonce applies replay protection to a provider webhook identified by a
+// delivery ID and the time the provider signed or published it. Providers retry failed
+// deliveries for days, so the window is long (REPLAY_WEBHOOK_WINDOW, default 7 days).
+func (s *FiberServer) claimWebhookNonce(ctx context.Context, provider, id string, issuedAt time.Time) (func(), error) {
+	if issuedAt.IsZero() || issuedAt.After(time.Now().Add(maxClockSkew)) {
+		return nil, errStaleRequest
+	}
+	window := getEnvDuration("REPLAY_WEBHOOK_WINDOW", 7*24*time.Hour)
+	return s.claimNonce(ctx, "webhook:"+provider, id, issuedAt.Add(window))
+}
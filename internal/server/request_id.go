@@ -0,0 +1,33 @@
+package server
+
+import (
+	"fitness-hack/internal/requestid"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+)
+
+// assignRequestID gives every request an ID: the client's X-Request-ID when it is valid,
+// otherwise a new one. The ID is returned in the response header, stored in
+// c.Locals("request_id") and the user context, and written in log entries and error
+// responses. Outgoing HTTP calls made with contexts derived from c.UserContext() forward it.
+func (s *FiberServer) assignRequestID(c *fiber.Ctx) error {
+	id := c.Get(requestid.Header)
+	if requestid.Valid(id) {
+		// Fiber reuses the request's memory, so keep a copy
+		id = utils.CopyString(id)
+	} else {
+		id = requestid.New()
+	}
+
+	c.Locals("request_id", id)
+	c.SetUserContext(requestid.NewContext(c.UserContext(), id))
+	c.Set(requestid.Header, id)
+	return c.Next()
+}
+
+// getRequestID returns the ID assignRequestID gave the request, or "" if it hasn't run
+func getRequestID(c *fiber.Ctx) string {
+	id, _ := c.Locals("request_id").(string)
+	return id
+}
@@ -0,0 +1,61 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"fitness-hack/internal/requestid"
+)
+
+// contextBreachChecker records the request ID of each context it is called with
+type contextBreachChecker struct {
+	ids []string
+}
+
+func (b *contextBreachChecker) Breached(ctx context.Context, password string) (bool, error) {
+	b.ids = append(b.ids, requestid.FromContext(ctx))
+	return false, nil
+}
+
+func TestRequestIDs(t *testing.T) {
+	s, _ := newFakeServer(t)
+	checker := &contextBreachChecker{}
+	s.breaches = checker
+
+	do := func(method, path, id, body string) (string, string) {
+		t.Helper()
+		req := httptest.NewRequest(method, "/api/v1"+path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if id != "" {
+			req.Header.Set(requestid.Header, id)
+		}
+		resp, err := s.App.Test(req, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var envelope struct {
+			RequestID string `json:"requestId"`
+		}
+		json.NewDecoder(resp.Body).Decode(&envelope)
+		return resp.Header.Get(requestid.Header), envelope.RequestID
+	}
+
+	header, body := do("GET", "/users/me", "", "")
+	if !requestid.Valid(header) || body != header {
+		t.Errorf("expected a new ID in the header and error body, got %q and %q", header, body)
+	}
+	if header, body := do("GET", "/users/me", "client-7:retry.1", ""); header != "client-7:retry.1" || body != header {
+		t.Errorf("expected the client's ID kept, got %q and %q", header, body)
+	}
+	if header, _ := do("GET", "/users/me", "not an id", ""); header == "not an id" || !requestid.Valid(header) {
+		t.Errorf("expected an invalid ID replaced, got %q", header)
+	}
+
+	header, _ = do("POST", "/users", "signup-1", `{"email":"ann@example.com","username":"ann","password":"a long enough password"}`)
+	if header != "signup-1" || len(checker.ids) != 1 || checker.ids[0] != "signup-1" {
+		t.Errorf("expected the ID passed on to the breach check, got %q", checker.ids)
+	}
+}
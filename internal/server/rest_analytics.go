@@ -0,0 +1,184 @@
+package server
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"fitness-hack/internal/database"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	// restCutShortRatio is the share of the prescribed rest below which a rest is cut short
+	restCutShortRatio = 0.8
+
+	// restAnalyticsDays is how far back rest analytics look when no range is given
+	restAnalyticsDays = 28
+)
+
+// restIntervals measures the rest before each set from the set before it in the same
+// session. Only rests between sets of the same exercise count, so moving on to the next
+// exercise isn't taken for a long rest. The set's duration, when logged, is taken off the
+// gap between completions. Gaps longer than the longest rest timer are breaks rather than
+// rests and are skipped.
+func restIntervals(sets []database.RestSet) []database.RestIntervalResponse {
+	intervals := []database.RestIntervalResponse{}
+	for i := 1; i < len(sets); i++ {
+		prev, set := &sets[i-1], &sets[i]
+		if set.Session_id != prev.Session_id || set.Exercise_id == nil || prev.Exercise_id == nil ||
+			*set.Exercise_id != *prev.Exercise_id {
+			continue
+		}
+
+		rest := set.Completed_at.Sub(prev.Completed_at)
+		if set.Duration_seconds != nil {
+			rest -= time.Duration(*set.Duration_seconds) * time.Second
+		}
+		seconds := int(math.Max(0, math.Round(rest.Seconds())))
+		if seconds > maxRestSeconds {
+			continue
+		}
+
+		interval := database.RestIntervalResponse{
+			SetID:                 set.Id,
+			ExerciseID:            *set.Exercise_id,
+			SetNumber:             set.Set_number,
+			RestSeconds:           seconds,
+			PrescribedRestSeconds: set.Prescribed_rest_seconds,
+		}
+		if set.Prescribed_rest_seconds != nil {
+			interval.CutShort = float64(seconds) < restCutShortRatio*float64(*set.Prescribed_rest_seconds)
+		}
+		intervals = append(intervals, interval)
+	}
+	return intervals
+}
+
+// restAdherence summarizes rest intervals, overall and by exercise in the order each
+// exercise was first rested for
+func restAdherence(intervals []database.RestIntervalResponse) database.RestAnalyticsResponse {
+	type totals struct {
+		rests, restSeconds            int
+		prescribed, prescribedSeconds int
+		cutShort                      int
+	}
+	summarize := func(exerciseID string, t *totals) database.RestAdherenceResponse {
+		summary := database.RestAdherenceResponse{
+			ExerciseID:      exerciseID,
+			Rests:           t.rests,
+			PrescribedRests: t.prescribed,
+			CutShort:        t.cutShort,
+		}
+		if t.rests > 0 {
+			summary.AverageRestSeconds = int(math.Round(float64(t.restSeconds) / float64(t.rests)))
+		}
+		if t.prescribed > 0 {
+			average := int(math.Round(float64(t.prescribedSeconds) / float64(t.prescribed)))
+			adherence := math.Round(float64(t.prescribed-t.cutShort)/float64(t.prescribed)*100) / 100
+			summary.AveragePrescribedRestSeconds = &average
+			summary.Adherence = &adherence
+		}
+		return summary
+	}
+
+	var overall totals
+	byExercise := map[string]*totals{}
+	order := []string{}
+	for _, interval := range intervals {
+		t, ok := byExercise[interval.ExerciseID]
+		if !ok {
+			t = &totals{}
+			byExercise[interval.ExerciseID] = t
+			order = append(order, interval.ExerciseID)
+		}
+		for _, sum := range []*totals{&overall, t} {
+			sum.rests++
+			sum.restSeconds += interval.RestSeconds
+			if interval.PrescribedRestSeconds != nil {
+				sum.prescribed++
+				sum.prescribedSeconds += *interval.PrescribedRestSeconds
+				if interval.CutShort {
+					sum.cutShort++
+				}
+			}
+		}
+	}
+
+	response := database.RestAnalyticsResponse{
+		Overall:   summarize("", &overall),
+		Exercises: make([]database.RestAdherenceResponse, len(order)),
+	}
+	for i, exerciseID := range order {
+		response.Exercises[i] = summarize(exerciseID, byExercise[exerciseID])
+	}
+	return response
+}
+
+// GET /api/v1/workout-sessions/:id/rest
+func (s *FiberServer) getWorkoutSessionRest(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	session, err := s.db.GetWorkoutSessionByID(ctx, c.Params("id"))
+	if err != nil || session.User_id != userID {
+		return notFound(c, "Workout session")
+	}
+
+	sets, err := s.db.ListRestSets(ctx, database.RestSetFilter{SessionID: session.Id})
+	if err != nil {
+		LogDatabaseError(s, "list_rest_sets", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to get session rest")
+	}
+	intervals := restIntervals(sets)
+	response := restAdherence(intervals)
+	response.Intervals = intervals
+	return successResponse(c, response)
+}
+
+// GET /api/v1/users/me/rest-analytics?from=&to=
+func (s *FiberServer) getRestAnalytics(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	filter, err := restAnalyticsFilter(c, time.Now())
+	if err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+	filter.UserID = userID
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	sets, err := s.db.ListRestSets(ctx, filter)
+	if err != nil {
+		LogDatabaseError(s, "list_rest_sets", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to get rest analytics")
+	}
+	return successResponse(c, restAdherence(restIntervals(sets)))
+}
+
+// restAnalyticsFilter reads the range of sessions to analyze. from defaults to
+// restAnalyticsDays days before to, or before now when to isn't given either.
+func restAnalyticsFilter(c *fiber.Ctx, now time.Time) (database.RestSetFilter, error) {
+	var filter database.RestSetFilter
+	var err error
+	if filter.From, filter.To, err = getDateRange(c); err != nil {
+		return filter, err
+	}
+	if filter.From.IsZero() {
+		if !filter.To.IsZero() {
+			now = filter.To
+		}
+		filter.From = now.AddDate(0, 0, -restAnalyticsDays)
+	}
+	return filter, nil
+}
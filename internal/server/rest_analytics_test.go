@@ -0,0 +1,73 @@
+package server
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"fitness-hack/internal/database"
+)
+
+func TestRestAnalytics(t *testing.T) {
+	start := time.Date(2025, 8, 14, 18, 0, 0, 0, time.UTC)
+	prescribed := 180
+	set := func(session, exercise string, number, atSeconds int, duration *int) database.RestSet {
+		rs := database.RestSet{Prescribed_rest_seconds: &prescribed}
+		rs.Id = fmt.Sprintf("%s-%s-%d", session, exercise, number)
+		rs.Session_id = session
+		rs.Exercise_id = &exercise
+		rs.Set_number = number
+		rs.Completed_at = start.Add(time.Duration(atSeconds) * time.Second)
+		rs.Duration_seconds = duration
+		if exercise == "curl" {
+			rs.Prescribed_rest_seconds = nil
+		}
+		return rs
+	}
+	plank := 60
+
+	sets := []database.RestSet{
+		set("s1", "squat", 1, 0, nil),
+		set("s1", "squat", 2, 200, nil),    // full rest
+		set("s1", "squat", 3, 320, nil),    // 120s, cut short
+		set("s1", "plank", 1, 600, nil),    // next exercise, not a rest
+		set("s1", "plank", 2, 860, &plank), // 200s after the 60s hold
+		set("s1", "curl", 1, 900, nil),
+		set("s1", "curl", 2, 990, nil),       // 90s with no prescription
+		set("s2", "curl", 1, 9000, nil),      // another session
+		set("s2", "curl", 2, 9000+4000, nil), // a break, not a rest
+	}
+
+	intervals := restIntervals(sets)
+	if len(intervals) != 4 {
+		t.Fatalf("expected 4 rests, got %+v", intervals)
+	}
+	if intervals[1].RestSeconds != 120 || !intervals[1].CutShort || intervals[0].CutShort {
+		t.Errorf("expected only the 120s squat rest to be cut short, got %+v", intervals[:2])
+	}
+	if intervals[2].ExerciseID != "plank" || intervals[2].RestSeconds != 200 {
+		t.Errorf("expected the plank's hold taken off its rest, got %+v", intervals[2])
+	}
+	if intervals[3].PrescribedRestSeconds != nil || intervals[3].CutShort {
+		t.Errorf("expected a rest without a prescription not to be cut short, got %+v", intervals[3])
+	}
+
+	got := restAdherence(intervals)
+	overall := got.Overall
+	if overall.Rests != 4 || overall.PrescribedRests != 3 || overall.CutShort != 1 || overall.AverageRestSeconds != 153 {
+		t.Errorf("unexpected overall summary %+v", overall)
+	}
+	if overall.Adherence == nil || *overall.Adherence != 0.67 || *overall.AveragePrescribedRestSeconds != 180 {
+		t.Errorf("expected 0.67 adherence to 180s rests, got %+v", overall)
+	}
+	if len(got.Exercises) != 3 || got.Exercises[0].ExerciseID != "squat" || got.Exercises[0].AverageRestSeconds != 160 {
+		t.Errorf("unexpected exercise summaries %+v", got.Exercises)
+	}
+	if curl := got.Exercises[2]; curl.Rests != 1 || curl.Adherence != nil {
+		t.Errorf("expected curl rests without adherence, got %+v", curl)
+	}
+
+	if empty := restAdherence(nil); empty.Overall.Rests != 0 || len(empty.Exercises) != 0 || empty.Overall.Adherence != nil {
+		t.Errorf("expected an empty summary, got %+v", empty)
+	}
+}
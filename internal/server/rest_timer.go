@@ -0,0 +1,130 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"fitness-hack/internal/database"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// restTimerState is a session's rest countdown as kept in Redis. The key expires when the
+// countdown ends, so any instance can tell whether one is running.
+type restTimerState struct {
+	StartedAt time.Time `json:"startedAt"`
+	EndsAt    time.Time `json:"endsAt"`
+	Seconds   int       `json:"seconds"`
+}
+
+func restTimerCacheKey(sessionID string) string {
+	return "rest_timer:" + sessionID
+}
+
+// restTimerToResponse returns the timer with the whole seconds left at now, or nil once
+// it has run out
+func restTimerToResponse(state *restTimerState, now time.Time) *database.RestTimerResponse {
+	remaining := state.EndsAt.Sub(now)
+	if remaining <= 0 {
+		return nil
+	}
+	return &database.RestTimerResponse{
+		StartedAt:        state.StartedAt,
+		EndsAt:           state.EndsAt,
+		Seconds:          state.Seconds,
+		RemainingSeconds: int(math.Ceil(remaining.Seconds())),
+	}
+}
+
+// saveRestTimer stores a countdown of seconds starting at now, replacing the one running
+func (s *FiberServer) saveRestTimer(ctx context.Context, sessionID string, seconds int, now time.Time) (*restTimerState, error) {
+	state := &restTimerState{
+		StartedAt: now.UTC(),
+		EndsAt:    now.UTC().Add(time.Duration(seconds) * time.Second),
+		Seconds:   seconds,
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.cache.Set(ctx, restTimerCacheKey(sessionID), data, time.Duration(seconds)*time.Second).Err(); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// loadRestTimer returns the session's running countdown, or nil when there is none
+func (s *FiberServer) loadRestTimer(ctx context.Context, sessionID string, now time.Time) (*database.RestTimerResponse, error) {
+	data, err := s.cache.Get(ctx, restTimerCacheKey(sessionID)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var state restTimerState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, err
+	}
+	return restTimerToResponse(&state, now), nil
+}
+
+// clearRestTimer removes the session's countdown, reporting whether one was stored
+func (s *FiberServer) clearRestTimer(ctx context.Context, sessionID string) (bool, error) {
+	n, err := s.cache.Del(ctx, restTimerCacheKey(sessionID)).Result()
+	return n > 0, err
+}
+
+// POST /api/v1/workout-sessions/:id/rest-timer
+// Starts or stops the session's rest timer. The timer is kept in Redis so every device and
+// API instance sees the same countdown, and connected companion sockets follow along.
+func (s *FiberServer) setRestTimer(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	var req database.RestTimerRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+	if req.Action != "start" && req.Action != "stop" {
+		return errorResponse(c, fiber.StatusBadRequest, `action must be "start" or "stop"`)
+	}
+	if req.Action == "start" && (req.Seconds < 1 || req.Seconds > maxRestSeconds) {
+		return errorResponse(c, fiber.StatusBadRequest, fmt.Sprintf("seconds must be between 1 and %d", maxRestSeconds))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	session, err := s.ownSession(ctx, c.Params("id"), userID)
+	if err != nil {
+		return notFound(c, "Workout session")
+	}
+
+	if req.Action == "stop" {
+		cleared, err := s.clearRestTimer(ctx, session.Id)
+		if err != nil {
+			LogDatabaseError(s, "clear_rest_timer", err, c)
+			return errorResponse(c, fiber.StatusInternalServerError, "Failed to stop rest timer")
+		}
+		if running := s.companion.cancelRest(userID, session.Id); !running && !cleared {
+			return errorResponse(c, fiber.StatusNotFound, "No rest timer is running")
+		}
+		return c.SendStatus(fiber.StatusNoContent)
+	}
+
+	now := time.Now()
+	state, err := s.saveRestTimer(ctx, session.Id, req.Seconds, now)
+	if err != nil {
+		LogDatabaseError(s, "save_rest_timer", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to start rest timer")
+	}
+	s.companion.startRest(userID, session.Id, req.Seconds)
+	return successResponse(c, restTimerToResponse(state, now))
+}
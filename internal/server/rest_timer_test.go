@@ -0,0 +1,81 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"fitness-hack/internal/database"
+	"fitness-hack/internal/memstore"
+)
+
+func TestRestTimerToResponse(t *testing.T) {
+	now := time.Date(2025, 8, 30, 12, 0, 0, 0, time.UTC)
+	state := &restTimerState{StartedAt: now.Add(-30 * time.Second), EndsAt: now.Add(59500 * time.Millisecond), Seconds: 90}
+	if got := restTimerToResponse(state, now); got == nil || got.RemainingSeconds != 60 || got.Seconds != 90 {
+		t.Errorf("expected 60 seconds left, got %+v", got)
+	}
+	if got := restTimerToResponse(state, state.EndsAt); got != nil {
+		t.Errorf("expected no timer once it ran out, got %+v", got)
+	}
+}
+
+func TestRestTimerSyncedAcrossRequests(t *testing.T) {
+	s, db := newFakeServer(t)
+	s.cache = memstore.New().Client()
+	session, err := db.CreateWorkoutSession(context.Background(), &database.Workout_sessions{User_id: "u1", Name: "Push", Started_at: time.Now()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	do := func(method, path, userID, body string) (int, *database.WorkoutSessionResponse) {
+		t.Helper()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", bearer(t, userID))
+		resp, err := s.App.Test(req, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var envelope struct {
+			Data *database.WorkoutSessionResponse `json:"data"`
+		}
+		json.NewDecoder(resp.Body).Decode(&envelope)
+		return resp.StatusCode, envelope.Data
+	}
+	timerPath := "/api/v1/workout-sessions/" + session.Id + "/rest-timer"
+	sessionPath := "/api/v1/workout-sessions/" + session.Id
+
+	for _, body := range []string{`{"action":"pause"}`, `{"action":"start","seconds":0}`, `{"action":"start","seconds":3601}`} {
+		if status, _ := do("POST", timerPath, "u1", body); status != 400 {
+			t.Errorf("%s: expected 400, got %d", body, status)
+		}
+	}
+	if status, _ := do("POST", timerPath, "u2", `{"action":"start","seconds":90}`); status != 404 {
+		t.Errorf("expected another user's session to be 404, got %d", status)
+	}
+	if status, _ := do("POST", timerPath, "u1", `{"action":"stop"}`); status != 404 {
+		t.Errorf("expected 404 without a timer running, got %d", status)
+	}
+
+	if status, _ := do("POST", timerPath, "u1", `{"action":"start","seconds":90}`); status != 200 {
+		t.Fatalf("expected the timer to start, got %d", status)
+	}
+	status, got := do("GET", sessionPath, "u1", "")
+	if status != 200 || got.RestTimer == nil || got.RestTimer.Seconds != 90 || got.RestTimer.RemainingSeconds < 89 {
+		t.Fatalf("expected the running timer on the session, got %d %+v", status, got)
+	}
+	if _, got := do("GET", sessionPath, "u2", ""); got == nil || got.RestTimer != nil {
+		t.Errorf("expected the timer hidden from other users, got %+v", got)
+	}
+
+	if status, _ := do("POST", timerPath, "u1", `{"action":"stop"}`); status != 204 {
+		t.Errorf("expected the timer to stop, got %d", status)
+	}
+	if _, got := do("GET", sessionPath, "u1", ""); got == nil || got.RestTimer != nil {
+		t.Errorf("expected no timer after stopping it, got %+v", got)
+	}
+}
@@ -0,0 +1,183 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"fitness-hack/internal/database"
+	"fitness-hack/internal/messages"
+	"fitness-hack/internal/storage"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// retentionPurgeBatch bounds the rows deleted per statement so purges don't hold long locks
+const retentionPurgeBatch = 1000
+
+// retentionDays returns how many days a category of data is kept, configured with
+// RETENTION_<CATEGORY>_DAYS (e.g. RETENTION_SESSIONS_DAYS). 0, the default, keeps it forever.
+func retentionDays(category string) int {
+	days := getEnvInt("RETENTION_"+strings.ToUpper(category)+"_DAYS", 0)
+	if days < 0 {
+		return 0
+	}
+	return days
+}
+
+// Helper to convert database legal hold to response model
+func legalHoldToResponse(hold *database.Legal_holds) database.LegalHoldResponse {
+	return database.LegalHoldResponse{
+		ID:         hold.Id,
+		UserID:     hold.User_id,
+		Reason:     hold.Reason,
+		Active:     hold.Released_at == nil,
+		CreatedBy:  hold.Created_by,
+		CreatedAt:  hold.Created_at,
+		ReleasedBy: hold.Released_by,
+		ReleasedAt: hold.Released_at,
+	}
+}
+
+// GET /api/v1/retention-policies
+func (s *FiberServer) listRetentionPolicies(c *fiber.Ctx) error {
+	categories := database.RetentionCategories()
+	policies := make([]database.RetentionPolicyResponse, len(categories))
+	for i, category := range categories {
+		policies[i] = database.RetentionPolicyResponse{Category: category, RetainDays: retentionDays(category)}
+	}
+	return successResponse(c, policies)
+}
+
+// StartRetentionPurge periodically deletes data older than its category's retention period
+// (every RETENTION_PURGE_INTERVAL, default 24h). Data of users under legal hold is kept.
+func (s *FiberServer) StartRetentionPurge(ctx context.Context) {
+	s.runPeriodically(ctx, getEnvDuration("RETENTION_PURGE_INTERVAL", 24*time.Hour), s.purgeRetainedData)
+}
+
+func (s *FiberServer) purgeRetainedData(ctx context.Context) {
+	for _, category := range database.RetentionCategories() {
+		days := retentionDays(category)
+		if days == 0 {
+			continue
+		}
+		cutoff := time.Now().AddDate(0, 0, -days)
+
+		var total int64
+		for ctx.Err() == nil {
+			batchCtx, cancel := context.WithTimeout(ctx, time.Minute)
+			purge, err := s.db.PurgeRetainedData(batchCtx, category, cutoff, retentionPurgeBatch)
+			if err != nil {
+				cancel()
+				s.logError("ERROR", messages.RetentionPurgeFailed, err, nil, map[string]interface{}{
+					"component": "retention",
+					"category":  category,
+				})
+				break
+			}
+			for _, key := range purge.StorageKeys {
+				if err := s.storage.Delete(batchCtx, key); err != nil && !errors.Is(err, storage.ErrNotFound) {
+					messages.Log(messages.RetentionFileDeleteFailed, key, category, err)
+				}
+			}
+			cancel()
+			total += purge.Rows
+			if purge.Rows < retentionPurgeBatch {
+				break
+			}
+		}
+
+		if total > 0 {
+			messages.Log(messages.RetentionPurged, total, category, days)
+			if category == database.RetentionSessions {
+				s.cache.Del(ctx, "workout_sessions:list:*")
+			}
+		}
+	}
+}
+
+// GET /api/v1/organizations/:orgId/legal-holds
+func (s *FiberServer) listLegalHolds(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	holds, err := s.db.ListLegalHolds(ctx, c.Params("orgId"))
+	if err != nil {
+		LogDatabaseError(s, "list_legal_holds", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to fetch legal holds")
+	}
+
+	responses := make([]database.LegalHoldResponse, len(holds))
+	for i := range holds {
+		responses[i] = legalHoldToResponse(&holds[i])
+	}
+	return successResponse(c, responses)
+}
+
+// POST /api/v1/organizations/:orgId/legal-holds
+func (s *FiberServer) createLegalHold(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	var req database.CreateLegalHoldRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+	req.Reason = strings.TrimSpace(req.Reason)
+	if req.Reason == "" {
+		return errorResponse(c, fiber.StatusBadRequest, "Reason is required")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	orgID := c.Params("orgId")
+	if req.UserID != nil {
+		// Holds only reach the organization's own members
+		if _, err := s.db.GetOrganizationRole(ctx, orgID, *req.UserID); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return errorResponse(c, fiber.StatusBadRequest, "User is not a member of the organization")
+			}
+			LogDatabaseError(s, "get_organization_role", err, c)
+			return errorResponse(c, fiber.StatusInternalServerError, "Failed to create legal hold")
+		}
+	}
+
+	hold, err := s.db.CreateLegalHold(ctx, orgID, req.UserID, req.Reason, userID)
+	if err != nil {
+		LogDatabaseError(s, "create_legal_hold", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to create legal hold")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"data": legalHoldToResponse(hold)})
+}
+
+// DELETE /api/v1/organizations/:orgId/legal-holds/:holdId
+// Only the owner can release a hold, since releasing one lets pending purges proceed
+func (s *FiberServer) releaseLegalHold(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+	if c.Locals("org_role") != orgRoleOwner {
+		return errorResponse(c, fiber.StatusForbidden, "Only the organization owner can release legal holds")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	hold, err := s.db.ReleaseLegalHold(ctx, c.Params("orgId"), c.Params("holdId"), userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return notFound(c, "Active legal hold")
+		}
+		LogDatabaseError(s, "release_legal_hold", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to release legal hold")
+	}
+
+	return successResponse(c, legalHoldToResponse(hold))
+}
@@ -0,0 +1,58 @@
+package server
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"fitness-hack/internal/database"
+	"fitness-hack/internal/database/dbtest"
+	"fitness-hack/internal/storage"
+)
+
+// retentionStub purges the files it's given from the photos category, once
+type retentionStub struct {
+	*dbtest.Fake
+	photoKeys  []string
+	categories []string
+}
+
+func (r *retentionStub) PurgeRetainedData(ctx context.Context, category string, olderThan time.Time, limit int) (*database.RetainedDataPurge, error) {
+	r.categories = append(r.categories, category)
+	purge := &database.RetainedDataPurge{}
+	if category == database.RetentionPhotos {
+		purge.Rows, purge.StorageKeys = int64(len(r.photoKeys)), r.photoKeys
+		r.photoKeys = nil
+	}
+	return purge, nil
+}
+
+func TestRetentionPurgeDeletesPhotoFiles(t *testing.T) {
+	t.Setenv("RETENTION_PHOTOS_DAYS", "30")
+	db := &retentionStub{Fake: dbtest.NewFake(), photoKeys: []string{"progress-photos/u1/p1.jpg", "progress-photos/u1/p1-thumb.jpg"}}
+	s := newTestServer(t, db)
+	s.storage = storage.NewLocal(t.TempDir())
+	ctx := context.Background()
+	for _, key := range append(db.photoKeys, "progress-photos/u1/p2.jpg") {
+		if err := s.storage.Put(ctx, key, strings.NewReader("image"), "image/jpeg"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s.purgeRetainedData(ctx)
+
+	if len(db.categories) != 1 || db.categories[0] != database.RetentionPhotos {
+		t.Errorf("expected only photos purged, got %v", db.categories)
+	}
+	for _, key := range []string{"progress-photos/u1/p1.jpg", "progress-photos/u1/p1-thumb.jpg"} {
+		if _, err := s.storage.Get(ctx, key); err != storage.ErrNotFound {
+			t.Errorf("expected %s deleted with its photo, got %v", key, err)
+		}
+	}
+	if body, err := s.storage.Get(ctx, "progress-photos/u1/p2.jpg"); err != nil {
+		t.Errorf("expected the kept photo's file kept, got %v", err)
+	} else {
+		body.Close()
+	}
+}
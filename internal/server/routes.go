@@ -1,20 +1,26 @@
 package server
 
 import (
-	"os"
+	"errors"
 	"strconv"
+	"time"
+
+	"fitness-hack/internal/database"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
-	jwtware "github.com/gofiber/jwt/v3"
 )
 
 func (s *FiberServer) RegisterFiberRoutes() {
+	// Every request gets an ID before anything can log or fail
+	s.App.Use(s.assignRequestID)
+
 	// Apply CORS middleware
 	s.App.Use(cors.New(cors.Config{
 		AllowOrigins:     "*",
 		AllowMethods:     "GET,POST,PUT,DELETE,OPTIONS,PATCH",
-		AllowHeaders:     "Accept,Authorization,Content-Type",
+		AllowHeaders:     "Accept,Authorization,Content-Type,X-API-Key,If-Match,If-None-Match,Idempotency-Key,X-Request-ID",
+		ExposeHeaders:    "ETag,Idempotent-Replayed,Retry-After,X-RateLimit-Limit,X-RateLimit-Remaining,X-Request-ID",
 		AllowCredentials: false, // credentials require explicit origins
 		MaxAge:           300,
 	}))
@@ -22,44 +28,341 @@ func (s *FiberServer) RegisterFiberRoutes() {
 	// Health and basic routes
 	s.App.Get("/", s.HelloWorldHandler)
 	s.App.Get("/health", s.healthHandler)
+	s.App.Get("/readyz", s.readyzHandler)
 
 	// API v1 group
 	api := s.App.Group("/api/v1")
 
+	// Rate limits are keyed per user once authenticated, per IP otherwise
+	limits := LoadRateLimitConfig()
+	if !limits.Enabled {
+		limits = RateLimitConfig{}
+	}
+
 	// Public routes (no JWT required)
-	api.Post("/auth/login", s.loginUser)
-	api.Post("/users", s.createUser)
-
-	// JWT Middleware for all other /api/v1 routes
-	api.Use(jwtware.New(jwtware.Config{
-		SigningKey: []byte(os.Getenv("JWT_SECRET")),
-		ErrorHandler: func(c *fiber.Ctx, err error) error {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Unauthorized"})
-		},
-	}))
+	api.Post("/auth/login", s.rateLimiter("login", limits.Login), s.loginUser)
+	api.Post("/users", s.rateLimiter("public", limits.Public), s.gateRegistration, s.createUser)
+	api.Post("/auth/guest", s.rateLimiter("public", limits.Public), s.gateRegistration, s.createGuestSession)
+	api.Post("/auth/oauth/:provider", s.rateLimiter("login", limits.Login), s.oauthLogin)
+	api.Get("/auth/sso/:orgId", s.rateLimiter("public", limits.Public), s.getSSOLoginConfig)
+	api.Post("/auth/sso/:orgId", s.rateLimiter("login", limits.Login), s.ssoLogin)
+
+	// Store server notifications (authenticated by signature / push token)
+	api.Post("/billing/apple/notifications", s.handleAppleNotification)
+	api.Post("/billing/google/notifications", s.handleGoogleNotification)
+
+	// Strava OAuth redirect (user identified by the signed state) and webhook events
+	api.Get("/integrations/strava/callback", s.rateLimiter("public", limits.Public), s.stravaCallback)
+	api.Get("/integrations/strava/webhook", s.verifyStravaWebhook)
+	api.Post("/integrations/strava/webhook", s.handleStravaWebhook)
+
+	// Gym occupancy is public so members can check how busy it is before signing in
+	api.Get("/organizations/:orgId/occupancy", s.rateLimiter("public", limits.Public), s.getOccupancy)
+	api.Get("/organizations/:orgId/occupancy/history", s.rateLimiter("public", limits.Public), s.getOccupancyHistory)
+
+	// Gym screens (authenticated by the display's key, which only reads the board)
+	api.Get("/display/board", s.rateLimiter("public", limits.Public), s.displayAuth, s.getDisplayBoard)
+
+	// Signed share links (authenticated by signature, single use)
+	api.Get("/shared/workouts/:id", s.rateLimiter("public", limits.Public), s.verifySignedLink, s.getSharedWorkout)
+
+	// Workout companion socket (JWT from the Authorization header or ?token=)
+	s.App.Get("/ws", wsTokenFromQuery, s.authenticate(), s.rejectRevokedTokens, s.limitRestrictedTokens, s.rateLimiter("default", limits.Default), s.companionSocket)
+
+	// SCIM provisioning for organizations (authenticated by the organization's SCIM token)
+	scim := s.App.Group("/scim/v2", s.rateLimiter("default", limits.Default), s.scimAuth)
+	scim.Get("/ServiceProviderConfig", s.scimServiceProviderConfig)
+	scim.Get("/Users", s.scimListUsers)
+	scim.Post("/Users", s.scimCreateUser)
+	scim.Get("/Users/:id", s.scimGetUser)
+	scim.Put("/Users/:id", s.scimReplaceUser)
+	scim.Patch("/Users/:id", s.scimPatchUser)
+	scim.Delete("/Users/:id", s.scimDeleteUser)
+
+	// JWT or API key authentication for all other /api/v1 routes
+	api.Use(s.authenticate())
+	api.Use(s.rejectRevokedTokens)
+	api.Use(s.limitRestrictedTokens)
+	api.Use(s.rateLimiter("default", limits.Default))
+
+	// Retried POSTs with the same Idempotency-Key replay the first response
+	api.Use(s.idempotency())
+
+	api.Post("/auth/logout", s.logoutUser)
 
 	// Protected Users routes
 	users := api.Group("/users")
-	users.Get("/", s.listUsers)
+	users.Get("/", s.denyGuests, s.listUsers)
+	users.Post("/merge", s.denyGuests, s.mergeAccounts)
+	users.Get("/me/referrals", s.denyGuests, s.getMyReferrals)
+	users.Get("/me/api-keys", s.denyGuests, s.listAPIKeys)
+	users.Post("/me/api-keys", s.denyGuests, s.createAPIKey)
+	users.Delete("/me/api-keys/:keyId", s.denyGuests, s.deleteAPIKey)
+	users.Get("/me/sessions", s.listMySessions)
+	users.Delete("/me/sessions/:sessionId", s.revokeMySession)
+	users.Get("/me", s.getMe)
+	users.Put("/me", s.denyGuests, s.updateMe)
+	users.Put("/me/password", s.denyGuests, s.rateLimiter("login", limits.Login), s.changeMyPassword)
+	users.Delete("/me", s.requestAccountDeletion)
+	users.Get("/me/deletion", s.getAccountDeletion)
+	users.Delete("/me/deletion", s.cancelAccountDeletion)
+	users.Post("/me/export", s.requestDataExport)
+	users.Get("/me/exports/:exportId", s.getDataExport)
+	users.Get("/me/exports/:exportId/download", s.downloadDataExport)
+	users.Get("/me/body-metrics", s.listBodyMetrics)
+	users.Get("/me/summary", s.getDailySummary)
+	users.Get("/me/usage", s.getUsage)
+	users.Get("/me/readiness", s.getReadiness)
+	users.Get("/me/suggestion", s.getWorkoutSuggestion)
+	users.Get("/me/rest-analytics", s.getRestAnalytics)
+	users.Get("/me/adjustment-reviews", s.listAdjustmentReviews)
+	users.Get("/me/coaches", s.listMyCoaches)
+	users.Delete("/me/coaches/:coachId", s.leaveCoach)
+	users.Get("/me/photo-vault", s.getPhotoVault)
+	users.Post("/me/photo-vault", s.createPhotoVault)
+	users.Put("/me/photo-vault/pin", s.changePhotoVaultPIN)
+	users.Post("/me/photo-vault/unlock", s.rateLimiter("login", limits.Login), s.unlockPhotoVault)
+	users.Post("/me/photo-vault/lock", s.lockPhotoVault)
+	users.Get("/me/notification-preferences", s.getNotificationPreferences)
+	users.Put("/me/notification-preferences", s.updateNotificationPreferences)
+	users.Get("/me/privacy", s.getPrivacySettings)
+	users.Put("/me/privacy", s.updatePrivacySettings)
+	users.Get("/me/following", s.listFollowing)
+	users.Get("/me/followers", s.listFollowers)
+	users.Delete("/me/followers/:followerId", s.removeFollower)
+	users.Post("/:id/follow", s.denyGuests, s.followUser)
+	users.Delete("/:id/follow", s.unfollowUser)
 	users.Get("/:id", s.getUser)
-	users.Put("/:id", s.updateUser)
-	users.Delete("/:id", s.deleteUser)
+	users.Put("/:id", s.denyGuests, s.updateUser)
+	users.Put("/:id/password", s.denyGuests, s.rateLimiter("login", limits.Login), s.changeUserPassword)
+	users.Patch("/:id", s.denyGuests, acceptMergePatch, s.patchUser)
+	users.Delete("/:id", s.denyGuests, s.deleteUser)
+
+	// Activity feed of followed users
+	api.Get("/feed", s.getFeed)
+
+	// Billing routes
+	billingRoutes := api.Group("/billing")
+	billingRoutes.Get("/subscriptions", s.getPremiumStatus)
+	billingRoutes.Post("/apple/verify", s.denyGuests, s.verifyAppleReceipt)
+	billingRoutes.Post("/google/verify", s.denyGuests, s.verifyGooglePurchase)
+
+	// Organization routes
+	orgs := api.Group("/organizations")
+	orgs.Post("/", s.denyGuests, s.createOrganization)
+	orgs.Get("/", s.listOrganizations)
+	orgs.Post("/invites/accept", s.denyGuests, s.acceptOrgInvite)
+	orgs.Get("/:orgId/invites", s.requireOrgAdmin, s.listOrgInvites)
+	orgs.Post("/:orgId/invites", s.denyGuests, s.requireOrgAdmin, s.createOrgInvite)
+	orgs.Post("/:orgId/invites/:inviteId/resend", s.requireOrgAdmin, s.resendOrgInvite)
+	orgs.Delete("/:orgId/invites/:inviteId", s.requireOrgAdmin, s.revokeOrgInvite)
+	orgs.Post("/:orgId/scim-token", s.denyGuests, s.requireOrgAdmin, s.createSCIMToken)
+	orgs.Get("/:orgId/sso", s.requireOrgAdmin, s.getOrganizationSSO)
+	orgs.Put("/:orgId/sso", s.denyGuests, s.requireOrgAdmin, s.putOrganizationSSO)
+	orgs.Delete("/:orgId/sso", s.denyGuests, s.requireOrgAdmin, s.deleteOrganizationSSO)
+	orgs.Get("/:orgId/community", s.requireOrgAdmin, s.getCommunityIntegration)
+	orgs.Put("/:orgId/community", s.denyGuests, s.requireOrgAdmin, s.putCommunityIntegration)
+	orgs.Delete("/:orgId/community", s.denyGuests, s.requireOrgAdmin, s.deleteCommunityIntegration)
+	orgs.Post("/:orgId/community/test", s.denyGuests, s.requireOrgAdmin, s.testCommunityIntegration)
+	orgs.Post("/:orgId/check-in", s.denyGuests, s.requireOrgMember, s.checkIn)
+	orgs.Post("/:orgId/check-out", s.denyGuests, s.requireOrgMember, s.checkOut)
+	orgs.Get("/:orgId/equipment", s.requireOrgMember, s.listGymEquipment)
+	orgs.Post("/:orgId/equipment", s.denyGuests, s.requireOrgAdmin, s.createGymEquipment)
+	orgs.Delete("/:orgId/equipment/:equipmentId", s.denyGuests, s.requireOrgAdmin, s.archiveGymEquipment)
+	orgs.Get("/:orgId/reservations", s.requireOrgMember, s.listEquipmentReservations)
+	orgs.Post("/:orgId/reservations", s.denyGuests, s.requireOrgMember, s.createEquipmentReservation)
+	orgs.Delete("/:orgId/reservations/:reservationId", s.denyGuests, s.requireOrgMember, s.cancelEquipmentReservation)
+	orgs.Post("/:orgId/reservations/:reservationId/no-show", s.denyGuests, s.requireOrgAdmin, s.markEquipmentReservationNoShow)
+	orgs.Get("/:orgId/displays", s.requireOrgAdmin, s.listKioskDisplays)
+	orgs.Post("/:orgId/displays", s.denyGuests, s.requireOrgAdmin, s.createKioskDisplay)
+	orgs.Delete("/:orgId/displays/:displayId", s.requireOrgAdmin, s.deleteKioskDisplay)
+	orgs.Put("/:orgId/leaderboard", s.denyGuests, s.requireOrgMember, s.setLeaderboardVisibility)
+	orgs.Get("/:orgId/legal-holds", s.requireOrgAdmin, s.listLegalHolds)
+	orgs.Post("/:orgId/legal-holds", s.denyGuests, s.requireOrgAdmin, s.createLegalHold)
+	orgs.Delete("/:orgId/legal-holds/:holdId", s.denyGuests, s.requireOrgAdmin, s.releaseLegalHold)
+
+	api.Get("/retention-policies", s.listRetentionPolicies)
+
+	// Coaching routes (the :id of a client is their user ID)
+	coach := api.Group("/coach")
+	coach.Post("/invites", s.denyGuests, s.createCoachInvite)
+	coach.Post("/invites/accept", s.denyGuests, s.acceptCoachInvite)
+	coach.Delete("/invites/:inviteId", s.revokeCoachInvite)
+	coach.Get("/clients", s.listCoachClients)
+	coach.Delete("/clients/:id", s.requireCoachOf, s.endCoachClient)
+	coach.Get("/clients/:id/sessions", s.requireCoachOf, s.listClientSessions)
+	coach.Get("/clients/:id/workouts", s.requireCoachOf, s.listClientWorkouts)
+	coach.Post("/clients/:id/programs", s.denyGuests, s.requireCoachOf, s.assignClientProgram)
+
+	// Platform admin routes, every request recorded in the audit log
+	admin := api.Group("/admin", s.requireAdmin, s.auditAdminActions)
+	admin.Get("/users", s.searchUsers)
+	admin.Get("/users/:id", s.getAdminUser)
+	admin.Get("/users/:id/resources", s.getUserResourceCounts)
+	admin.Post("/users/:id/disable", s.disableUser)
+	admin.Post("/users/:id/enable", s.enableUser)
+	admin.Post("/users/:id/password-reset", s.forcePasswordReset)
+	admin.Post("/users/:id/impersonate", s.impersonateUser)
+	admin.Get("/audit-log", s.listAdminAuditLog)
+	admin.Get("/dsar", s.listDataSubjectRequests)
+	admin.Post("/dsar", s.createDataSubjectRequest)
+	admin.Get("/dsar/:id", s.getDataSubjectRequest)
+	admin.Post("/dsar/:id/fulfill", s.fulfillDataSubjectRequest)
+	admin.Post("/dsar/:id/reject", s.rejectDataSubjectRequest)
+	admin.Get("/dsar/:id/export", s.downloadDataSubjectRequestExport)
+	admin.Post("/exercises/sync", s.syncExerciseCatalog)
+
+	// Third-party integration routes
+	integrationRoutes := api.Group("/integrations")
+	integrationRoutes.Get("/", s.listIntegrations)
+	integrationRoutes.Get("/triggers", s.listIntegrationTriggers)
+	integrationRoutes.Get("/triggers/:event", s.pollIntegrationTrigger)
+	integrationRoutes.Post("/strava/connect", s.denyGuests, s.connectStrava)
+	integrationRoutes.Post("/strava/sync", s.syncStrava)
+	integrationRoutes.Delete("/strava", s.disconnectStrava)
+
+	// Webhook routes
+	webhooks := api.Group("/webhooks")
+	webhooks.Post("/", s.denyGuests, s.createWebhook)
+	webhooks.Get("/", s.listWebhooks)
+	webhooks.Get("/:id", s.getWebhook)
+	webhooks.Put("/:id", s.denyGuests, s.updateWebhook)
+	webhooks.Patch("/:id", s.denyGuests, acceptMergePatch, s.patchWebhook)
+	webhooks.Delete("/:id", s.deleteWebhook)
+	webhooks.Get("/:id/deliveries", s.listWebhookDeliveries)
+
+	// Push notification device routes
+	devices := api.Group("/devices")
+	devices.Post("/", s.registerDevice)
+	devices.Get("/", s.listDevices)
+	devices.Delete("/:id", s.deleteDevice)
+
+	// Scheduled reminder routes
+	reminders := api.Group("/reminders")
+	reminders.Post("/", s.createReminder)
+	reminders.Get("/", s.listReminders)
+	reminders.Get("/:id", s.getReminder)
+	reminders.Put("/:id", s.updateReminder)
+	reminders.Patch("/:id", acceptMergePatch, s.patchReminder)
+	reminders.Delete("/:id", s.deleteReminder)
+
+	// Training max routes
+	trainingMaxes := api.Group("/training-maxes")
+	trainingMaxes.Get("/", s.listTrainingMaxes)
+	trainingMaxes.Get("/:name", s.getTrainingMax)
+	trainingMaxes.Put("/:name", s.putTrainingMax)
+	trainingMaxes.Delete("/:name", s.deleteTrainingMax)
+	trainingMaxes.Get("/:name/history", s.listTrainingMaxHistory)
+	trainingMaxes.Post("/:name/accept-suggestion", s.acceptTrainingMaxSuggestion)
+
+	// Analytics routes
+	analytics := api.Group("/analytics")
+	analytics.Get("/forecast", s.getForecast)
+	analytics.Get("/weekly-summary", s.getWeeklySummary)
+	analytics.Get("/readiness", s.getAnalyticsReadiness)
+	analytics.Get("/benchmarks", s.getBenchmarks)
+	analytics.Get("/benchmarks/consent", s.getBenchmarkConsent)
+	analytics.Put("/benchmarks/consent", s.putBenchmarkConsent)
+	analytics.Delete("/benchmarks/consent", s.deleteBenchmarkConsent)
+
+	// Leaderboards of the lifters who opted in
+	api.Get("/leaderboards/exercises/:id", s.getExerciseLeaderboard)
+
+	// Recommendations
+	api.Get("/recommendations/next-workout", s.getNextWorkoutRecommendation)
+
+	// Challenge routes
+	challenges := api.Group("/challenges")
+	challenges.Post("/", s.denyGuests, s.createChallenge)
+	challenges.Get("/", s.listChallenges)
+	challenges.Get("/:id", s.getChallenge)
+	challenges.Delete("/:id", s.deleteChallenge)
+	challenges.Post("/:id/join", s.denyGuests, s.joinChallenge)
+	challenges.Delete("/:id/join", s.leaveChallenge)
+	challenges.Get("/:id/standings", s.getChallengeStandings)
+
+	// Progress photo routes
+	progressPhotos := api.Group("/progress-photos")
+	progressPhotos.Post("/", s.uploadProgressPhoto)
+	progressPhotos.Get("/", s.listProgressPhotos)
+	progressPhotos.Post("/upload-url", s.createProgressPhotoUploadURL)
+	progressPhotos.Get("/compare", s.compareProgressPhotos)
+	progressPhotos.Put("/:id/file", s.uploadProgressPhotoFile)
+	progressPhotos.Post("/:id/complete", s.completeProgressPhotoUpload)
+	progressPhotos.Get("/:id", s.getProgressPhoto)
+	progressPhotos.Get("/:id/image", s.getProgressPhotoImage)
+	progressPhotos.Get("/:id/thumbnail", s.getProgressPhotoThumbnail)
+	progressPhotos.Post("/:id/vault", s.moveProgressPhoto(true))
+	progressPhotos.Delete("/:id/vault", s.moveProgressPhoto(false))
+	progressPhotos.Delete("/:id", s.deleteProgressPhoto)
+
+	// Nutrition routes
+	nutrition := api.Group("/nutrition")
+	nutrition.Get("/daily", s.getDailyNutrition)
+	nutrition.Post("/foods", s.createFood)
+	nutrition.Get("/foods/search", s.searchFoods)
+	nutrition.Get("/foods/:id", s.getFood)
+	nutrition.Put("/foods/:id", s.updateFood)
+	nutrition.Patch("/foods/:id", acceptMergePatch, s.patchFood)
+	nutrition.Delete("/foods/:id", s.deleteFood)
+	nutrition.Post("/logs", s.createNutritionLog)
+	nutrition.Get("/logs", s.listNutritionLogs)
+	nutrition.Get("/logs/:id", s.getNutritionLog)
+	nutrition.Put("/logs/:id", s.updateNutritionLog)
+	nutrition.Patch("/logs/:id", acceptMergePatch, s.patchNutritionLog)
+	nutrition.Delete("/logs/:id", s.deleteNutritionLog)
+
+	// Habit routes
+	habits := api.Group("/habits")
+	habits.Get("/", s.listDailyHabits)
+	habits.Get("/:date", s.getDailyHabits)
+	habits.Put("/:date", s.putDailyHabits)
+	habits.Delete("/:date", s.deleteDailyHabits)
+
+	// Plate calculator
+	api.Get("/plates", s.calculatePlates)
+
+	// Import routes
+	importRoutes := api.Group("/import")
+	importRoutes.Post("/health", s.importHealthData)
+	importRoutes.Post("/files", s.importActivityFiles)
+	importRoutes.Post("/preview", s.previewSetLogImport)
+	importRoutes.Post("/commit", s.commitSetLogImport)
+
+	// GraphQL API
+	graphqlHandler := s.graphqlHandler()
+	api.Get("/graphql", graphqlHandler)
+	api.Post("/graphql", graphqlHandler)
 
 	// Workouts routes
 	workouts := api.Group("/workouts")
 	workouts.Post("/", s.createWorkout)
 	workouts.Get("/", s.listWorkouts)
+	workouts.Get("/templates", s.listWorkoutTemplates)
+	workouts.Post("/:id/clone", s.cloneWorkout)
+	workouts.Post("/:id/share-link", s.createWorkoutShareLink)
 	workouts.Get("/:id", s.getWorkout)
 	workouts.Put("/:id", s.updateWorkout)
+	workouts.Patch("/:id", acceptMergePatch, s.patchWorkout)
 	workouts.Delete("/:id", s.deleteWorkout)
 
 	// Exercises routes
 	exercises := api.Group("/exercises")
-	exercises.Post("/", s.createExercise)
+	exercises.Post("/", s.denyGuests, s.createExercise)
 	exercises.Get("/", s.listExercises)
 	exercises.Get("/:id", s.getExercise)
-	exercises.Put("/:id", s.updateExercise)
-	exercises.Delete("/:id", s.deleteExercise)
+	exercises.Put("/:id", s.denyGuests, s.updateExercise)
+	exercises.Patch("/:id", s.denyGuests, acceptMergePatch, s.patchExercise)
+	exercises.Delete("/:id", s.denyGuests, s.deleteExercise)
+	exercises.Get("/:id/media", s.listExerciseMedia)
+	exercises.Post("/:id/media", s.denyGuests, s.createExerciseMedia)
+	exercises.Post("/:id/media/upload-url", s.denyGuests, s.createExerciseMediaUploadURL)
+	exercises.Put("/:id/media/order", s.denyGuests, s.reorderExerciseMedia)
+	exercises.Put("/:id/media/:mediaId/file", s.denyGuests, s.uploadExerciseMediaFile)
+	exercises.Post("/:id/media/:mediaId/complete", s.denyGuests, s.completeExerciseMediaUpload)
+	exercises.Get("/:id/media/:mediaId/file", s.getExerciseMediaFile)
+	exercises.Delete("/:id/media/:mediaId", s.denyGuests, s.deleteExerciseMedia)
+	api.Get("/muscle-groups", s.listMuscleGroups)
 
 	// Workout exercises routes
 	workoutExercises := api.Group("/workout-exercises")
@@ -67,14 +370,28 @@ func (s *FiberServer) RegisterFiberRoutes() {
 	workoutExercises.Get("/", s.listWorkoutExercises)
 	workoutExercises.Get("/:id", s.getWorkoutExercise)
 	workoutExercises.Put("/:id", s.updateWorkoutExercise)
+	workoutExercises.Patch("/:id", acceptMergePatch, s.patchWorkoutExercise)
 	workoutExercises.Delete("/:id", s.deleteWorkoutExercise)
+	workoutExercises.Get("/:id/progression", s.getProgressionRule)
+	workoutExercises.Put("/:id/progression", s.putProgressionRule)
+	workoutExercises.Delete("/:id/progression", s.deleteProgressionRule)
 
 	// Workout sessions routes
 	workoutSessions := api.Group("/workout-sessions")
 	workoutSessions.Post("/", s.createWorkoutSession)
 	workoutSessions.Get("/", s.listWorkoutSessions)
 	workoutSessions.Get("/:id", s.getWorkoutSession)
+	workoutSessions.Get("/:id/sets", s.listWorkoutSessionSets)
+	workoutSessions.Post("/:id/copy-last", s.copyLastWorkoutSessionSets)
+	workoutSessions.Get("/:id/plan", s.getWorkoutSessionPlan)
+	workoutSessions.Get("/:id/rest", s.getWorkoutSessionRest)
+	workoutSessions.Post("/:id/rest-timer", s.setRestTimer)
+	workoutSessions.Get("/:id/feedback", s.getSessionFeedback)
+	workoutSessions.Post("/:id/feedback", s.saveSessionFeedback)
+	workoutSessions.Delete("/:id/feedback", s.deleteSessionFeedback)
+	workoutSessions.Post("/:id/progression", s.applySessionProgression)
 	workoutSessions.Put("/:id", s.updateWorkoutSession)
+	workoutSessions.Patch("/:id", acceptMergePatch, s.patchWorkoutSession)
 	workoutSessions.Delete("/:id", s.deleteWorkoutSession)
 
 	// Programs routes
@@ -83,7 +400,13 @@ func (s *FiberServer) RegisterFiberRoutes() {
 	programs.Get("/", s.listPrograms)
 	programs.Get("/:id", s.getProgram)
 	programs.Put("/:id", s.updateProgram)
+	programs.Patch("/:id", acceptMergePatch, s.patchProgram)
 	programs.Delete("/:id", s.deleteProgram)
+	programs.Put("/:id/coach", s.setProgramCoach)
+	programs.Delete("/:id/coach", s.removeProgramCoach)
+	programs.Get("/:id/adjustments", s.listProgramAdjustments)
+	programs.Post("/:id/adjustments/:adjustmentId/approve", s.reviewProgramAdjustment(true))
+	programs.Post("/:id/adjustments/:adjustmentId/reject", s.reviewProgramAdjustment(false))
 }
 
 func (s *FiberServer) HelloWorldHandler(c *fiber.Ctx) error {
@@ -117,11 +440,31 @@ func getPaginationParams(c *fiber.Ctx) (limit, offset int) {
 	return limit, offset
 }
 
-// Helper function to create error response
-func errorResponse(c *fiber.Ctx, status int, message string) error {
-	return c.Status(status).JSON(fiber.Map{
-		"error": message,
-	})
+// getDateRange returns the ?from=&to= of a list request as RFC 3339 timestamps, each zero
+// when not given
+func getDateRange(c *fiber.Ctx) (from, to time.Time, err error) {
+	if value := c.Query("from"); value != "" {
+		if from, err = time.Parse(time.RFC3339, value); err != nil {
+			return from, to, errors.New("from must be an RFC 3339 timestamp")
+		}
+	}
+	if value := c.Query("to"); value != "" {
+		if to, err = time.Parse(time.RFC3339, value); err != nil {
+			return from, to, errors.New("to must be an RFC 3339 timestamp")
+		}
+	}
+	if !from.IsZero() && !to.IsZero() && !from.Before(to) {
+		return from, to, errors.New("from must be before to")
+	}
+	return from, to, nil
+}
+
+// getListOptions returns the paging and ?sort=&order= of a list request. The sort is
+// checked against the list's allowed columns when it is queried, which returns
+// database.ErrInvalidListOption for any other.
+func getListOptions(c *fiber.Ctx) database.ListOptions {
+	limit, offset := getPaginationParams(c)
+	return database.ListOptions{Limit: limit, Offset: offset, Sort: c.Query("sort"), Order: c.Query("order")}
 }
 
 // Helper function to create success response
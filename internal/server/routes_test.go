@@ -1,10 +1,16 @@
 package server
 
 import (
-	"github.com/gofiber/fiber/v2"
+	"context"
+	"encoding/json"
 	"io"
 	"net/http"
+	"net/http/httptest"
 	"testing"
+
+	"fitness-hack/internal/database"
+
+	"github.com/gofiber/fiber/v2"
 )
 
 func TestHandler(t *testing.T) {
@@ -37,3 +43,45 @@ func TestHandler(t *testing.T) {
 		t.Errorf("expected response body to be %v; got %v", expected, string(body))
 	}
 }
+
+func TestListSortParams(t *testing.T) {
+	s, db := newFakeServer(t)
+	ctx := context.Background()
+	for _, name := range []string{"Squat", "Bench Press", "Deadlift"} {
+		if _, err := db.CreateExercise(ctx, &database.Exercises{Name: name}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	auth := bearer(t, "u1")
+
+	list := func(query string) (int, []string) {
+		t.Helper()
+		req := httptest.NewRequest("GET", "/api/v1/exercises"+query, nil)
+		req.Header.Set("Authorization", auth)
+		resp, err := s.App.Test(req, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var envelope struct {
+			Data []database.ExerciseResponse `json:"data"`
+		}
+		json.NewDecoder(resp.Body).Decode(&envelope)
+		names := make([]string, len(envelope.Data))
+		for i, exercise := range envelope.Data {
+			names[i] = exercise.Name
+		}
+		return resp.StatusCode, names
+	}
+
+	if status, got := list("?sort=name"); status != fiber.StatusOK || len(got) != 3 || got[0] != "Bench Press" || got[2] != "Squat" {
+		t.Errorf("sorted list = %d %v, want exercises by name", status, got)
+	}
+	if status, got := list("?sort=name&order=desc&limit=1"); status != fiber.StatusOK || len(got) != 1 || got[0] != "Squat" {
+		t.Errorf("sorted page = %d %v, want Squat first", status, got)
+	}
+	for _, query := range []string{"?sort=bogus", "?sort=name&order=sideways"} {
+		if status, _ := list(query); status != fiber.StatusBadRequest {
+			t.Errorf("GET %s = %d, want 400", query, status)
+		}
+	}
+}
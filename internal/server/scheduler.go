@@ -0,0 +1,84 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// StartSchedulers starts every periodic job until ctx is cancelled. Both the API and
+// cmd/worker call it; leader election keeps the jobs from running twice.
+func (s *FiberServer) StartSchedulers(ctx context.Context) {
+	s.StartGuestCleanup(ctx)
+	s.StartAccountDeletionPurge(ctx)
+	s.StartStravaSync(ctx)
+	s.StartRetentionPurge(ctx)
+	s.StartWebhookDelivery(ctx)
+	s.StartWorkoutReminders(ctx)
+	s.StartReminderScheduler(ctx)
+	s.StartProgramAdjustments(ctx)
+	s.StartSessionWeather(ctx)
+	s.StartCommunityLeaderboards(ctx)
+	s.StartProgressPhotoUploadCleanup(ctx)
+	s.StartIntegrationEventPurge(ctx)
+	s.StartBenchmarkAggregation(ctx)
+	s.StartLeaderboardRecompute(ctx)
+	s.StartWeeklySummaries(ctx)
+}
+
+// RunScheduledJob runs one periodic job by name, for deployments where EventBridge
+// schedules trigger the jobs instead of StartSchedulers. EventBridge fires each schedule
+// once, so no leader is elected.
+func (s *FiberServer) RunScheduledJob(ctx context.Context, name string) error {
+	jobs := map[string]func(context.Context){
+		"guest_cleanup":           s.purgeExpiredGuests,
+		"account_deletion_purge":  s.purgeDeletedAccounts,
+		"retention_purge":         s.purgeRetainedData,
+		"webhook_delivery":        s.deliverDueWebhooks,
+		"workout_reminders":       s.sendWorkoutReminders,
+		"reminder_scheduler":      s.sendDueReminders,
+		"program_adjustments":     s.adjustDuePrograms,
+		"session_weather":         s.tagSessionWeather,
+		"community_leaderboards":  s.postWeeklyLeaderboards,
+		"photo_upload_cleanup":    s.purgeAbandonedPhotoUploads,
+		"integration_event_purge": s.purgeIntegrationEvents,
+		"benchmark_aggregation":   s.aggregateBenchmarks,
+		"leaderboard_recompute":   s.recomputeLeaderboards,
+		"weekly_summaries":        s.sendWeeklySummaries,
+		"strava_sync": func(ctx context.Context) {
+			if s.stravaAvailable() {
+				s.syncDueStravaIntegrations(ctx, getEnvDuration("STRAVA_SYNC_INTERVAL", 6*time.Hour))
+			}
+		},
+	}
+	run, ok := jobs[name]
+	if !ok {
+		return fmt.Errorf("no scheduled job called %q", name)
+	}
+	run(ctx)
+	return nil
+}
+
+// runPeriodically calls run every interval until ctx is cancelled, on whichever replica
+// currently leads the scheduler role. The other replicas skip their ticks, so a purge or a
+// batch of reminders runs once across the deployment rather than once per replica.
+func (s *FiberServer) runPeriodically(ctx context.Context, interval time.Duration, run func(context.Context)) {
+	s.campaignOnce.Do(func() {
+		go s.leader.Run(ctx)
+	})
+	ticker := time.NewTicker(interval)
+
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if s.leader.IsLeader() {
+					run(ctx)
+				}
+			}
+		}
+	}()
+}
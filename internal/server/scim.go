@@ -0,0 +1,487 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/mail"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"fitness-hack/internal/database"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	scimContentType = "application/scim+json"
+
+	// scimTokenPrefix marks SCIM tokens so they are easy to spot in leaked-secret scans
+	scimTokenPrefix = "fhscim_"
+
+	scimUserSchema         = "urn:ietf:params:scim:schemas:core:2.0:User"
+	scimListResponseSchema = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	scimErrorSchema        = "urn:ietf:params:scim:api:messages:2.0:Error"
+	scimConfigSchema       = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+
+	scimMaxResults = 200
+)
+
+// scimFilterPattern matches the only filters identity providers need for user sync:
+// an equality test on userName, externalId or the email address
+var scimFilterPattern = regexp.MustCompile(`(?i)^\s*(userName|externalId|emails(?:\.value)?)\s+eq\s+"([^"]*)"\s*$`)
+
+type scimName struct {
+	GivenName  *string `json:"givenName,omitempty"`
+	FamilyName *string `json:"familyName,omitempty"`
+}
+
+type scimEmail struct {
+	Value   string `json:"value"`
+	Primary bool   `json:"primary,omitempty"`
+}
+
+type scimMeta struct {
+	ResourceType string    `json:"resourceType"`
+	Created      time.Time `json:"created"`
+	LastModified time.Time `json:"lastModified"`
+	Location     string    `json:"location"`
+}
+
+// scimUser is the SCIM core User resource, limited to the attributes FitnessHack stores
+type scimUser struct {
+	Schemas    []string    `json:"schemas"`
+	ID         string      `json:"id,omitempty"`
+	ExternalID *string     `json:"externalId,omitempty"`
+	UserName   string      `json:"userName"`
+	Name       *scimName   `json:"name,omitempty"`
+	Emails     []scimEmail `json:"emails,omitempty"`
+	Active     *bool       `json:"active,omitempty"`
+	Meta       *scimMeta   `json:"meta,omitempty"`
+}
+
+type scimPatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value"`
+}
+
+type scimPatchRequest struct {
+	Operations []scimPatchOperation `json:"Operations"`
+}
+
+// scimError writes an error in the format defined by RFC 7644 section 3.12
+func scimError(c *fiber.Ctx, status int, scimType, detail string) error {
+	body := fiber.Map{
+		"schemas": []string{scimErrorSchema},
+		"status":  strconv.Itoa(status),
+		"detail":  detail,
+	}
+	if scimType != "" {
+		body["scimType"] = scimType
+	}
+	c.Set(fiber.HeaderContentType, scimContentType)
+	return c.Status(status).JSON(body)
+}
+
+// scimJSON writes a SCIM response body with the SCIM media type
+func scimJSON(c *fiber.Ctx, status int, body interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	c.Set(fiber.HeaderContentType, scimContentType)
+	return c.Status(status).Send(data)
+}
+
+// Helper to convert an organization member to a SCIM User resource
+func scimMemberToResource(c *fiber.Ctx, member *database.SCIMMember) scimUser {
+	active := member.Active
+	user := scimUser{
+		Schemas:  []string{scimUserSchema},
+		ID:       member.User_id,
+		UserName: member.Email,
+		Name: &scimName{
+			GivenName:  &member.First_name,
+			FamilyName: &member.Last_name,
+		},
+		Emails: []scimEmail{{Value: member.Email, Primary: true}},
+		Active: &active,
+		Meta: &scimMeta{
+			ResourceType: "User",
+			Created:      member.Created_at,
+			LastModified: member.Updated_at,
+			Location:     c.BaseURL() + "/scim/v2/Users/" + member.User_id,
+		},
+	}
+	if member.External_id != "" {
+		user.ExternalID = &member.External_id
+	}
+	return user
+}
+
+// scimUserEmail returns the address identifying the user: userName when it is an
+// email address, otherwise the primary (or first) email
+func scimUserEmail(user *scimUser) string {
+	if addr, err := mail.ParseAddress(user.UserName); err == nil {
+		return addr.Address
+	}
+	for _, email := range user.Emails {
+		if email.Primary {
+			return email.Value
+		}
+	}
+	if len(user.Emails) > 0 {
+		return user.Emails[0].Value
+	}
+	return ""
+}
+
+// scimBool accepts both JSON booleans and the string form some identity providers send
+func scimBool(raw json.RawMessage) (bool, error) {
+	var b bool
+	if err := json.Unmarshal(raw, &b); err == nil {
+		return b, nil
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return false, errors.New("expected a boolean")
+	}
+	return strconv.ParseBool(s)
+}
+
+// scimOrgID returns the organization resolved from the SCIM token
+func scimOrgID(c *fiber.Ctx) string {
+	orgID, _ := c.Locals("scim_org_id").(string)
+	return orgID
+}
+
+// scimAuth authenticates the organization's identity provider by its bearer token
+func (s *FiberServer) scimAuth(c *fiber.Ctx) error {
+	token, ok := strings.CutPrefix(c.Get(fiber.HeaderAuthorization), "Bearer ")
+	if !ok || !strings.HasPrefix(token, scimTokenPrefix) {
+		return scimError(c, fiber.StatusUnauthorized, "", "Unauthorized")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	orgID, err := s.db.AuthenticateSCIMToken(ctx, hashAPIKey(token))
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			LogDatabaseError(s, "authenticate_scim_token", err, c)
+		}
+		return scimError(c, fiber.StatusUnauthorized, "", "Unauthorized")
+	}
+
+	c.Locals("scim_org_id", orgID)
+	return c.Next()
+}
+
+// POST /api/v1/organizations/:orgId/scim-token
+// Issues a new SCIM bearer token for the organization, replacing the previous one
+func (s *FiberServer) createSCIMToken(c *fiber.Ctx) error {
+	if c.Locals("org_role") != orgRoleOwner {
+		return errorResponse(c, fiber.StatusForbidden, "Only the organization owner can configure provisioning")
+	}
+
+	secret, err := randomHex(32)
+	if err != nil {
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to create SCIM token")
+	}
+	token := scimTokenPrefix + secret
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := s.db.SetOrganizationSCIMToken(ctx, c.Params("orgId"), hashAPIKey(token)); err != nil {
+		LogDatabaseError(s, "set_scim_token", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to create SCIM token")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"data": database.SCIMTokenResponse{
+		BaseURL: c.BaseURL() + "/scim/v2",
+		Token:   token,
+	}})
+}
+
+// GET /scim/v2/ServiceProviderConfig
+func (s *FiberServer) scimServiceProviderConfig(c *fiber.Ctx) error {
+	return scimJSON(c, fiber.StatusOK, fiber.Map{
+		"schemas":        []string{scimConfigSchema},
+		"patch":          fiber.Map{"supported": true},
+		"bulk":           fiber.Map{"supported": false, "maxOperations": 0, "maxPayloadSize": 0},
+		"filter":         fiber.Map{"supported": true, "maxResults": scimMaxResults},
+		"changePassword": fiber.Map{"supported": false},
+		"sort":           fiber.Map{"supported": false},
+		"etag":           fiber.Map{"supported": false},
+		"authenticationSchemes": []fiber.Map{{
+			"type":        "oauthbearertoken",
+			"name":        "Bearer token",
+			"description": "Organization SCIM token issued by the organization owner",
+		}},
+	})
+}
+
+// GET /scim/v2/Users
+func (s *FiberServer) scimListUsers(c *fiber.Ctx) error {
+	var filter database.SCIMMemberFilter
+	if raw := c.Query("filter"); raw != "" {
+		match := scimFilterPattern.FindStringSubmatch(raw)
+		if match == nil {
+			return scimError(c, fiber.StatusBadRequest, "invalidFilter", "Only 'eq' filters on userName, externalId or emails are supported")
+		}
+		if strings.EqualFold(match[1], "externalId") {
+			filter.ExternalID = match[2]
+		} else {
+			filter.Email = match[2]
+		}
+	}
+
+	startIndex, err := strconv.Atoi(c.Query("startIndex", "1"))
+	if err != nil || startIndex < 1 {
+		startIndex = 1
+	}
+	count, err := strconv.Atoi(c.Query("count", "100"))
+	if err != nil || count < 0 {
+		count = 100
+	}
+	if count > scimMaxResults {
+		count = scimMaxResults
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	members, total, err := s.db.ListSCIMMembers(ctx, scimOrgID(c), filter, database.ListOptions{Limit: count, Offset: startIndex - 1})
+	if err != nil {
+		LogDatabaseError(s, "list_scim_members", err, c)
+		return scimError(c, fiber.StatusInternalServerError, "", "Failed to fetch users")
+	}
+
+	resources := make([]scimUser, len(members))
+	for i := range members {
+		resources[i] = scimMemberToResource(c, &members[i])
+	}
+
+	return scimJSON(c, fiber.StatusOK, fiber.Map{
+		"schemas":      []string{scimListResponseSchema},
+		"totalResults": total,
+		"startIndex":   startIndex,
+		"itemsPerPage": len(resources),
+		"Resources":    resources,
+	})
+}
+
+// GET /scim/v2/Users/:id
+func (s *FiberServer) scimGetUser(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	member, err := s.db.GetSCIMMember(ctx, scimOrgID(c), c.Params("id"))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return scimError(c, fiber.StatusNotFound, "", "User not found")
+		}
+		LogDatabaseError(s, "get_scim_member", err, c)
+		return scimError(c, fiber.StatusInternalServerError, "", "Failed to fetch user")
+	}
+
+	return scimJSON(c, fiber.StatusOK, scimMemberToResource(c, member))
+}
+
+// POST /scim/v2/Users
+func (s *FiberServer) scimCreateUser(c *fiber.Ctx) error {
+	var req scimUser
+	if err := json.Unmarshal(c.Body(), &req); err != nil {
+		return scimError(c, fiber.StatusBadRequest, "invalidSyntax", "Invalid request body")
+	}
+	addr, err := mail.ParseAddress(scimUserEmail(&req))
+	if err != nil {
+		return scimError(c, fiber.StatusBadRequest, "invalidValue", "userName or emails must contain a valid email address")
+	}
+
+	member := &database.SCIMMember{
+		Email:  strings.ToLower(addr.Address),
+		Active: req.Active == nil || *req.Active,
+	}
+	if req.ExternalID != nil {
+		member.External_id = *req.ExternalID
+	}
+	if req.Name != nil {
+		if req.Name.GivenName != nil {
+			member.First_name = *req.Name.GivenName
+		}
+		if req.Name.FamilyName != nil {
+			member.Last_name = *req.Name.FamilyName
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	created, err := s.provisionSCIMMember(ctx, scimOrgID(c), member)
+	if err != nil {
+		if errors.Is(err, database.ErrMemberExists) {
+			return scimError(c, fiber.StatusConflict, "uniqueness", "User is already a member of the organization")
+		}
+		if errors.Is(err, database.ErrAccountExists) {
+			return scimError(c, fiber.StatusConflict, "uniqueness", "An account with this email already exists, invite it to the organization first")
+		}
+		LogDatabaseError(s, "provision_scim_member", err, c)
+		return scimError(c, fiber.StatusInternalServerError, "", "Failed to create user")
+	}
+
+	return scimJSON(c, fiber.StatusCreated, scimMemberToResource(c, created))
+}
+
+// provisionSCIMMember adds the member, creating their account if the email is new. Usernames
+// end in a random suffix; the rare collision is retried with a fresh one.
+func (s *FiberServer) provisionSCIMMember(ctx context.Context, orgID string, member *database.SCIMMember) (*database.SCIMMember, error) {
+	for attempt := 1; ; attempt++ {
+		newUser, err := newPasswordlessUser(member.Email, member.First_name, member.Last_name)
+		if err != nil {
+			return nil, err
+		}
+		created, err := s.db.ProvisionSCIMMember(ctx, orgID, member, newUser)
+		var constraintErr *database.ConstraintError
+		if attempt < 3 && errors.As(err, &constraintErr) && constraintErr.Column == "username" {
+			continue
+		}
+		return created, err
+	}
+}
+
+// PUT /scim/v2/Users/:id
+func (s *FiberServer) scimReplaceUser(c *fiber.Ctx) error {
+	var req scimUser
+	if err := json.Unmarshal(c.Body(), &req); err != nil {
+		return scimError(c, fiber.StatusBadRequest, "invalidSyntax", "Invalid request body")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	current, err := s.db.GetSCIMMember(ctx, scimOrgID(c), c.Params("id"))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return scimError(c, fiber.StatusNotFound, "", "User not found")
+		}
+		LogDatabaseError(s, "get_scim_member", err, c)
+		return scimError(c, fiber.StatusInternalServerError, "", "Failed to update user")
+	}
+	if email := scimUserEmail(&req); email != "" && !strings.EqualFold(email, current.Email) {
+		return scimError(c, fiber.StatusBadRequest, "mutability", "userName cannot be changed")
+	}
+
+	update := database.SCIMMemberUpdate{Active: req.Active, ExternalID: req.ExternalID}
+	if req.Name != nil {
+		update.FirstName = req.Name.GivenName
+		update.LastName = req.Name.FamilyName
+	}
+
+	return s.applySCIMUpdate(ctx, c, update)
+}
+
+// PATCH /scim/v2/Users/:id
+// Attributes FitnessHack does not store are ignored so identity providers can send their
+// full attribute mapping.
+func (s *FiberServer) scimPatchUser(c *fiber.Ctx) error {
+	var req scimPatchRequest
+	if err := json.Unmarshal(c.Body(), &req); err != nil {
+		return scimError(c, fiber.StatusBadRequest, "invalidSyntax", "Invalid request body")
+	}
+
+	var update database.SCIMMemberUpdate
+	for _, op := range req.Operations {
+		switch strings.ToLower(op.Op) {
+		case "add", "replace":
+		default:
+			return scimError(c, fiber.StatusBadRequest, "invalidPath", fmt.Sprintf("Unsupported patch operation %q", op.Op))
+		}
+
+		values := map[string]json.RawMessage{}
+		if op.Path == "" {
+			if err := json.Unmarshal(op.Value, &values); err != nil {
+				return scimError(c, fiber.StatusBadRequest, "invalidValue", "Patch value must be an object when no path is given")
+			}
+			// Flatten a nested name object into the same paths as path-based operations
+			if raw, ok := values["name"]; ok {
+				var name map[string]json.RawMessage
+				if err := json.Unmarshal(raw, &name); err != nil {
+					return scimError(c, fiber.StatusBadRequest, "invalidValue", "name must be an object")
+				}
+				for attr, value := range name {
+					values["name."+attr] = value
+				}
+				delete(values, "name")
+			}
+		} else {
+			values[op.Path] = op.Value
+		}
+
+		for path, value := range values {
+			switch strings.ToLower(path) {
+			case "active":
+				active, err := scimBool(value)
+				if err != nil {
+					return scimError(c, fiber.StatusBadRequest, "invalidValue", "active must be a boolean")
+				}
+				update.Active = &active
+			case "externalid", "name.givenname", "name.familyname":
+				var str string
+				if err := json.Unmarshal(value, &str); err != nil {
+					return scimError(c, fiber.StatusBadRequest, "invalidValue", path+" must be a string")
+				}
+				switch strings.ToLower(path) {
+				case "externalid":
+					update.ExternalID = &str
+				case "name.givenname":
+					update.FirstName = &str
+				default:
+					update.LastName = &str
+				}
+			}
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	return s.applySCIMUpdate(ctx, c, update)
+}
+
+// applySCIMUpdate saves a member update and responds with the resulting resource
+func (s *FiberServer) applySCIMUpdate(ctx context.Context, c *fiber.Ctx, update database.SCIMMemberUpdate) error {
+	updated, err := s.db.UpdateSCIMMember(ctx, scimOrgID(c), c.Params("id"), update)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return scimError(c, fiber.StatusNotFound, "", "User not found")
+		}
+		LogDatabaseError(s, "update_scim_member", err, c)
+		return scimError(c, fiber.StatusInternalServerError, "", "Failed to update user")
+	}
+
+	return scimJSON(c, fiber.StatusOK, scimMemberToResource(c, updated))
+}
+
+// DELETE /scim/v2/Users/:id
+// Removes the user from the organization; their FitnessHack account is kept
+func (s *FiberServer) scimDeleteUser(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := s.db.RemoveSCIMMember(ctx, scimOrgID(c), c.Params("id")); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return scimError(c, fiber.StatusNotFound, "", "User not found")
+		}
+		LogDatabaseError(s, "remove_scim_member", err, c)
+		return scimError(c, fiber.StatusInternalServerError, "", "Failed to delete user")
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -2,81 +2,168 @@ package server
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
+	"log/slog"
+	"maps"
+	"net/http"
 	"os"
 	"runtime"
+	"slices"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/gofiber/fiber/v2/middleware/logger"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/redis/go-redis/v9"
 
+	"fitness-hack/internal/alerting"
+	"fitness-hack/internal/billing"
 	"fitness-hack/internal/database"
+	"fitness-hack/internal/fooddb"
+	"fitness-hack/internal/health"
+	"fitness-hack/internal/integrations"
+	"fitness-hack/internal/jobs"
+	"fitness-hack/internal/leader"
+	"fitness-hack/internal/logging"
+	"fitness-hack/internal/mailer"
+	"fitness-hack/internal/memstore"
+	"fitness-hack/internal/messages"
+	"fitness-hack/internal/oauth"
+	"fitness-hack/internal/password"
+	"fitness-hack/internal/push"
+	"fitness-hack/internal/requestid"
+	"fitness-hack/internal/storage"
+	"fitness-hack/internal/weather"
 )
 
 type FiberServer struct {
 	*fiber.App
-	db    database.Service
-	cache *redis.Client
+	db      database.Service
+	cache   *redis.Client
+	log     *slog.Logger
+	oauth   *oauth.Registry
+	billing *billing.Providers
+	storage storage.Store
+	mailer  mailer.Mailer
+	strava  *integrations.Strava
+
+	// notifier sends push notifications to registered devices
+	notifier *push.Notifier
+
+	// encoder writes every JSON response and message with timestamps in UTC
+	encoder jsonEncoder
+
+	// jobs queues work for the job worker, which runs in this process or in cmd/worker,
+	// or for Lambda when JOB_QUEUE_DRIVER=sqs
+	jobs jobs.Producer
+
+	// leader elects the replica that runs the periodic jobs, campaigning from the first
+	// scheduler started under campaignOnce
+	leader       *leader.Elector
+	campaignOnce sync.Once
+
+	// tokenCipher encrypts third-party OAuth tokens; nil when INTEGRATION_TOKEN_KEY is not set
+	tokenCipher *integrations.Cipher
+
+	// webhookClient sends webhook deliveries and refuses to connect to private addresses
+	webhookClient *http.Client
+
+	// health runs the /readyz dependency checks
+	health *health.Checker
+
+	// companion routes workout companion messages between a user's /ws connections
+	companion *companionHub
+
+	// ssoProviders caches ID token verifiers per organization
+	ssoProviders sync.Map
+
+	// weather looks up the weather of outdoor sessions; nil when WEATHER_PROVIDER is not set
+	weather weather.Provider
+
+	// foodDB looks up foods missing from the catalog; nil when FOOD_DATABASE_PROVIDER is not set
+	foodDB fooddb.Provider
+
+	// breaches tells whether a new password is known from a data breach; nil when
+	// PASSWORD_BREACH_CHECK is not set
+	breaches password.BreachChecker
+
+	// alerts watches error rates and latency for operators; nil when ALERT_SLACK_WEBHOOK_URL is not set
+	alerts *alerting.Monitor
+
+	// recommenders are asked in turn for the user's next workout; defaultRecommenders when nil
+	recommenders []recommender
+
+	// stopBundledDB stops standalone mode's bundled Postgres; nil when it isn't running
+	stopBundledDB func() error
 }
 
-// CloudWatchLogEntry represents a structured log entry for AWS CloudWatch
-type CloudWatchLogEntry struct {
-	Timestamp  string                 `json:"timestamp"`
-	Level      string                 `json:"level"`
-	Message    string                 `json:"message"`
-	Error      string                 `json:"error,omitempty"`
-	RequestID  string                 `json:"request_id,omitempty"`
-	UserID     string                 `json:"user_id,omitempty"`
-	Method     string                 `json:"method,omitempty"`
-	Path       string                 `json:"path,omitempty"`
-	StatusCode int                    `json:"status_code,omitempty"`
-	Latency    string                 `json:"latency,omitempty"`
-	IP         string                 `json:"ip,omitempty"`
-	UserAgent  string                 `json:"user_agent,omitempty"`
-	Stack      []string               `json:"stack,omitempty"`
-	Metadata   map[string]interface{} `json:"metadata,omitempty"`
+// logError writes a structured entry to the server's logger in the CloudWatch format. The
+// message is the catalog text for id, formatted with args; alerts should match message_id,
+// which doesn't change with the text.
+func (s *FiberServer) logError(level string, id messages.ID, err error, c *fiber.Ctx, metadata map[string]interface{}, args ...interface{}) {
+	// The request's context carries its ID into the entry
+	ctx := context.Background()
+	var fields []string
+	if c != nil {
+		ctx = c.UserContext()
+		// Extract user ID from JWT if available
+		userID, _ := getUserIDFromJWT(c)
+		fields = []string{"method", c.Method(), "path", c.Path(), "ip", c.IP(), "user_agent", c.Get("User-Agent"), "user_id", userID}
+	}
+	s.writeLog(ctx, level, id, err, fields, metadata, args...)
 }
 
-// logError logs structured errors for CloudWatch
-func (s *FiberServer) logError(level, message string, err error, c *fiber.Ctx, metadata map[string]interface{}) {
-	entry := CloudWatchLogEntry{
-		Timestamp: time.Now().UTC().Format(time.RFC3339),
-		Level:     level,
-		Message:   message,
-		Metadata:  metadata,
+// writeLog writes the entries of logError and the gRPC server. fields are key and value
+// pairs describing the request.
+func (s *FiberServer) writeLog(ctx context.Context, level string, id messages.ID, err error, fields []string, metadata map[string]interface{}, args ...interface{}) {
+	lvl, parseErr := logging.ParseLevel(level)
+	if parseErr != nil {
+		lvl = slog.LevelError
+	}
+	logger := s.logger()
+	if !logger.Enabled(ctx, lvl) {
+		return
 	}
 
+	attrs := []slog.Attr{slog.String("message_id", string(id))}
+	// Empty fields are left out, as they were with omitempty
+	addString := func(key, value string) {
+		if value != "" {
+			attrs = append(attrs, slog.String(key, value))
+		}
+	}
 	if err != nil {
-		entry.Error = err.Error()
+		addString("error", err.Error())
+	}
+	for i := 0; i+1 < len(fields); i += 2 {
+		addString(fields[i], fields[i+1])
 	}
 
-	if c != nil {
-		entry.RequestID = c.Get("X-Request-ID")
-		entry.Method = c.Method()
-		entry.Path = c.Path()
-		entry.IP = c.IP()
-		entry.UserAgent = c.Get("User-Agent")
+	// Add stack trace for errors
+	if lvl >= slog.LevelError {
+		attrs = append(attrs, slog.Any("stack", getStackTrace()))
+	}
 
-		// Extract user ID from JWT if available
-		if userID, err := getUserIDFromJWT(c); err == nil {
-			entry.UserID = userID
+	// Metadata is a group rather than one value so each field is checked for secrets
+	if len(metadata) > 0 {
+		group := make([]any, 0, len(metadata))
+		for _, key := range slices.Sorted(maps.Keys(metadata)) {
+			group = append(group, slog.Any(key, metadata[key]))
 		}
+		attrs = append(attrs, slog.Group("metadata", group...))
 	}
 
-	// Add stack trace for errors
-	if level == "ERROR" || level == "FATAL" {
-		entry.Stack = getStackTrace()
-	}
+	logger.LogAttrs(ctx, lvl, messages.Text(id, args...), attrs...)
+}
 
-	// Output as JSON
-	if logData, err := json.Marshal(entry); err == nil {
-		fmt.Fprintf(os.Stderr, "%s\n", string(logData))
+// logger returns the server's logger, or the default one for servers not built with New
+func (s *FiberServer) logger() *slog.Logger {
+	if s.log == nil {
+		return slog.Default()
 	}
+	return s.log
 }
 
 // getStackTrace returns the current stack trace
@@ -91,36 +178,43 @@ func getStackTrace() []string {
 	return stack
 }
 
-// errorHandler middleware for catching and logging errors
+// errorHandler middleware for catching and logging errors, and logging every request
 func (s *FiberServer) errorHandler(c *fiber.Ctx) error {
 	start := time.Now()
 
 	err := c.Next()
 
-	latency := time.Since(start)
-
-	// Log errors
+	// Respond to returned errors here rather than in the ErrorHandler, so the status is known
 	if err != nil {
-		s.logError("ERROR", "Request failed", err, c, map[string]interface{}{
-			"latency": latency.String(),
-		})
-		return err
+		if respondErr := handleError(c, err); respondErr != nil {
+			return respondErr
+		}
 	}
 
-	// Log 4xx and 5xx status codes
+	latency := time.Since(start)
+	s.recordRequest(c, latency)
+
+	// Log returned errors that responded 5xx with their cause, other 4xx and 5xx status codes
+	// as warnings and everything else as the access log
 	status := c.Response().StatusCode()
-	if status >= 400 {
-		s.logError("WARN", fmt.Sprintf("HTTP %d", status), nil, c, map[string]interface{}{
-			"status_code": status,
-			"latency":     latency.String(),
-		})
+	metadata := map[string]interface{}{
+		"status_code": status,
+		"latency":     latency.String(),
+	}
+	switch {
+	case err != nil && status >= 500:
+		s.logError("ERROR", messages.ServerRequestFailed, err, c, metadata)
+	case status >= 400:
+		s.logError("WARN", messages.ServerHTTPError, err, c, metadata, status)
+	default:
+		s.logError("INFO", messages.ServerRequestHandled, nil, c, metadata, status)
 	}
 
 	return nil
 }
 
-func New() *FiberServer {
-	// Redis config from env
+// newRedisClientFromEnv returns a client for the Redis server at REDIS_ADDR
+func newRedisClientFromEnv() *redis.Client {
 	redisAddr := os.Getenv("REDIS_ADDR")
 	if redisAddr == "" {
 		redisAddr = "localhost:6379"
@@ -132,50 +226,157 @@ func New() *FiberServer {
 			redisDB = dbInt
 		}
 	}
-	cache := redis.NewClient(&redis.Options{
+	return redis.NewClient(&redis.Options{
 		Addr:     redisAddr,
 		Password: redisPassword,
 		DB:       redisDB,
 	})
+}
+
+func New() *FiberServer {
+	// Standalone mode (STANDALONE=true, or cmd/api --standalone) runs everything from this
+	// binary: Postgres is the bundled one unless BLUEPRINT_DB_HOST points elsewhere, the cache
+	// and job queue are kept in memory, scheduled jobs run without leader election, and
+	// embedded migrations run on start
+	standalone := getEnvBool("STANDALONE", false)
+
+	// LOG_LEVEL sets the least severe level written. The logger also becomes slog's and the
+	// log package's default, so messages.Log and packages writing with log share its format.
+	logger := logging.FromEnv()
+	slog.SetDefault(logger)
+
+	// Outgoing HTTP calls through the default transport, which the provider clients use,
+	// forward the ID of the request they are made for
+	if _, ok := http.DefaultTransport.(*requestid.Transport); !ok {
+		http.DefaultTransport = &requestid.Transport{Base: http.DefaultTransport}
+	}
+
+	dbConfig := database.DefaultConfig()
+	var stopBundledDB func() error
+	if standalone && dbConfig.Host == "" {
+		bundled := database.BundledConfigFromEnv()
+		messages.Log(messages.ServerBundledPostgres, bundled.DataDir, bundled.Port)
+		var err error
+		if dbConfig, stopBundledDB, err = database.StartBundled(bundled); err != nil {
+			messages.Fatal(messages.ServerBundledPostgresFailed, err)
+		}
+		// Postgres runs as a child process, so don't leave it behind when startup fails
+		messages.BeforeFatalExit(func() { stopBundledDB() })
+	}
+	dbConfig.Logger = logger
+	db, err := database.Open(dbConfig)
+	if err != nil {
+		messages.Fatal(messages.ServerDatabaseOpenFailed, err)
+	}
+
+	// MIGRATE_ON_START=true applies pending migrations before the server takes traffic, for
+	// deploys without a separate migrate step. Replicas starting together take turns on the
+	// migration lock, which bounds the wait, so there's no deadline here.
+	if standalone || getEnvBool("MIGRATE_ON_START", false) {
+		if err := database.RunEmbeddedMigrations(context.Background(), db.GetDB()); err != nil {
+			messages.Fatal(messages.ServerMigrationsFailed, err)
+		}
+	}
+
+	var cache *redis.Client
+	var jobQueue jobs.Producer
+	var elector *leader.Elector
+	if standalone {
+		cache = memstore.New().Client()
+		jobQueue = jobs.NewLocal()
+		elector = leader.Solo()
+		messages.Log(messages.ServerStandalone)
+	} else {
+		cache = newRedisClientFromEnv()
+		var err error
+		jobQueue, err = jobs.NewProducerFromEnv(context.Background(), cache)
+		if err != nil {
+			messages.Fatal(messages.ServerJobQueueConfigFailed, err)
+		}
+		elector = leader.NewElector(cache, "scheduler", getEnvDuration("LEADER_LEASE_TTL", leader.DefaultTTL))
+	}
+
+	store, err := storage.NewFromEnv(context.Background())
+	if err != nil {
+		messages.Fatal(messages.ServerStorageConfigFailed, err)
+	}
+
+	tokenCipher, err := integrations.NewCipherFromEnv()
+	if err != nil && !errors.Is(err, integrations.ErrNotConfigured) {
+		messages.Fatal(messages.ServerTokenCipherConfigFailed, err)
+	}
+
+	encoder := newJSONEncoderFromEnv()
 
 	server := &FiberServer{
 		App: fiber.New(fiber.Config{
 			ServerHeader: "fitness-hack",
 			AppName:      "fitness-hack",
-			ErrorHandler: func(c *fiber.Ctx, err error) error {
-				// We'll set up the error handler after server creation
-				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-					"error": "Internal server error",
-				})
-			},
+			JSONEncoder:  encoder.Marshal,
+			// Health exports easily exceed Fiber's 4 MB default
+			BodyLimit: getEnvInt("MAX_REQUEST_BODY_BYTES", 4*1024*1024),
+			// Errors returned before errorHandler runs, or by handlers it doesn't wrap
+			ErrorHandler: handleError,
 		}),
-		db:    database.New(),
-		cache: cache,
+		db:      db,
+		cache:   cache,
+		log:     logger,
+		oauth:   oauth.NewRegistryFromEnv(),
+		billing: billing.NewProvidersFromEnv(),
+		storage: store,
+		mailer:  mailer.NewFromEnv(),
+		strava:  integrations.NewStravaFromEnv(),
+
+		notifier: push.NewFromEnv(),
+		encoder:  encoder,
+		jobs:     jobQueue,
+		leader:   elector,
+
+		tokenCipher:   tokenCipher,
+		stopBundledDB: stopBundledDB,
+		webhookClient: newWebhookClient(),
+		companion:     newCompanionHub(),
+		weather:       weather.NewFromEnv(),
+		foodDB:        fooddb.NewFromEnv(),
+		breaches:      password.NewBreachCheckerFromEnv(),
 	}
+	server.health = server.newHealthChecker()
+	server.alerts = server.newAlertMonitor(alerting.NewFromEnv(), LoadAlertingConfig())
 
-	// Add error logging middleware first
+	// Add error and access logging middleware first
 	server.App.Use(server.errorHandler)
 
-	// Add request logging middleware
-	server.App.Use(logger.New(logger.Config{
-		Format:     "${time} | ${method} | ${path} | ${status} | ${latency} | ${ip} | ${userAgent}\n",
-		TimeFormat: "2006-01-02 15:04:05",
-		TimeZone:   "Local",
-		Output:     os.Stdout,
-	}))
+	// Fault injection for staging load tests; registered after logging so injected
+	// failures are logged like real ones
+	if chaos := LoadChaosConfig(); chaos.Enabled {
+		server.enableChaos(chaos)
+	}
 
 	return server
 }
 
-// getUserIDFromJWT extracts the user_id from the JWT claims in the Fiber context
-func getUserIDFromJWT(c *fiber.Ctx) (string, error) {
+// getJWTClaims returns the claims of the JWT stored in the Fiber context
+func getJWTClaims(c *fiber.Ctx) (jwt.MapClaims, error) {
 	token, ok := c.Locals("user").(*jwt.Token)
 	if !ok || token == nil {
-		return "", errors.New("invalid or missing JWT token")
+		return nil, errors.New("invalid or missing JWT token")
 	}
 	claims, ok := token.Claims.(jwt.MapClaims)
 	if !ok {
-		return "", errors.New("invalid JWT claims")
+		return nil, errors.New("invalid JWT claims")
+	}
+	return claims, nil
+}
+
+// getUserIDFromJWT extracts the user_id from the JWT claims in the Fiber context
+func getUserIDFromJWT(c *fiber.Ctx) (string, error) {
+	claims, err := getJWTClaims(c)
+	if err != nil {
+		// API key requests carry no JWT; apiKeyAuth stores the key owner instead
+		if userID, ok := c.Locals("user_id").(string); ok && userID != "" {
+			return userID, nil
+		}
+		return "", err
 	}
 	userID, ok := claims["user_id"].(string)
 	if !ok {
@@ -184,6 +385,18 @@ func getUserIDFromJWT(c *fiber.Ctx) (string, error) {
 	return userID, nil
 }
 
+// Close closes the database connection and stops the bundled Postgres, once the server
+// has shut down
+func (s *FiberServer) Close() error {
+	err := s.db.Close()
+	if s.stopBundledDB != nil {
+		if stopErr := s.stopBundledDB(); stopErr != nil {
+			err = errors.Join(err, stopErr)
+		}
+	}
+	return err
+}
+
 // SetCache sets a value in Redis with expiration (in seconds)
 func (s *FiberServer) SetCache(ctx context.Context, key string, value string, expiration time.Duration) error {
 	return s.cache.Set(ctx, key, value, expiration).Err()
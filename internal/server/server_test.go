@@ -0,0 +1,53 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"fitness-hack/internal/database"
+	"fitness-hack/internal/database/dbtest"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// newTestServer returns a server with every route registered on top of db, usually a
+// dbtest.Fake, so handler tests run without Postgres. Redis points at a closed port, so
+// cache reads miss and writes fail fast the way they do when Redis is down.
+func newTestServer(t *testing.T, db database.Service) *FiberServer {
+	t.Helper()
+	t.Setenv("JWT_SECRET", "test-secret")
+	t.Setenv("RATE_LIMIT_ENABLED", "false")
+
+	cache := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", DialTimeout: 10 * time.Millisecond, MaxRetries: -1})
+	t.Cleanup(func() { cache.Close() })
+
+	encoder := newJSONEncoderFromEnv()
+	s := &FiberServer{
+		App:           fiber.New(fiber.Config{JSONEncoder: encoder.Marshal, ErrorHandler: handleError}),
+		db:            db,
+		cache:         cache,
+		encoder:       encoder,
+		webhookClient: newWebhookClient(),
+		companion:     newCompanionHub(),
+	}
+	s.RegisterFiberRoutes()
+	return s
+}
+
+// newFakeServer returns a test server on an empty dbtest.Fake, and the fake for seeding
+func newFakeServer(t *testing.T) (*FiberServer, *dbtest.Fake) {
+	t.Helper()
+	db := dbtest.NewFake()
+	return newTestServer(t, db), db
+}
+
+// bearer returns an Authorization header value for userID
+func bearer(t *testing.T, userID string) string {
+	t.Helper()
+	token, err := generateJWT(userID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return "Bearer " + token
+}
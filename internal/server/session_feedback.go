@@ -0,0 +1,301 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"fitness-hack/internal/database"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// painAreas are the body areas feedback can flag pain in
+var painAreas = []string{"neck", "shoulder", "elbow", "wrist", "upper_back", "lower_back", "hip", "knee", "ankle", "other"}
+
+const (
+	// hardSessionRPE is the session RPE from which a session counts as very hard. Readiness
+	// drops for two days after one, and AMRAP sets logged in one don't raise training maxes.
+	hardSessionRPE = 9
+
+	// Readiness compares the load of the last acuteLoadDays with the weekly average over
+	// chronicLoadWeeks, the acute:chronic workload ratio
+	acuteLoadDays    = 7
+	chronicLoadWeeks = 4
+)
+
+// Helper to convert database session feedback to response model
+func sessionFeedbackToResponse(feedback *database.Session_feedback, durationMinutes int) database.SessionFeedbackResponse {
+	response := database.SessionFeedbackResponse{
+		SessionID: feedback.Session_id,
+		RPE:       int(feedback.Rpe),
+		PainAreas: feedbackPainAreas(feedback),
+		Notes:     feedback.Notes,
+		Load:      int(feedback.Rpe) * durationMinutes,
+		CreatedAt: feedback.Created_at,
+		UpdatedAt: feedback.Updated_at,
+	}
+	if feedback.Enjoyment != nil {
+		enjoyment := int(*feedback.Enjoyment)
+		response.Enjoyment = &enjoyment
+	}
+	return response
+}
+
+// feedbackPainAreas decodes the pain areas stored with feedback
+func feedbackPainAreas(feedback *database.Session_feedback) []string {
+	areas := []string{}
+	if len(feedback.Pain_areas) > 0 {
+		_ = json.Unmarshal(feedback.Pain_areas, &areas)
+	}
+	return areas
+}
+
+// validateSessionFeedback checks a feedback request, returning its pain areas without duplicates
+func validateSessionFeedback(req *database.SessionFeedbackRequest) ([]string, error) {
+	if req.RPE < 1 || req.RPE > 10 {
+		return nil, errors.New("rpe must be between 1 and 10")
+	}
+	if req.Enjoyment != nil && (*req.Enjoyment < 1 || *req.Enjoyment > 5) {
+		return nil, errors.New("enjoyment must be between 1 and 5")
+	}
+
+	areas := []string{}
+	seen := map[string]bool{}
+	for _, area := range req.PainAreas {
+		if !containsString(painAreas, area) {
+			return nil, fmt.Errorf("painAreas must be some of %s", strings.Join(painAreas, ", "))
+		}
+		if !seen[area] {
+			seen[area] = true
+			areas = append(areas, area)
+		}
+	}
+	return areas, nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// computeReadiness scores how ready the user is to train from 0 to 100, using the
+// feedback on the sessions of the last chronicLoadWeeks. The score drops when the last
+// week's load is well above the usual, after a very hard session, when pain was reported
+// in the last week, and when recent sessions weren't enjoyed.
+func computeReadiness(loads []database.SessionLoad, now time.Time) database.ReadinessResponse {
+	response := database.ReadinessResponse{
+		Score:           100,
+		RecentPainAreas: []string{},
+		Reasons:         []string{},
+		SessionsRated:   len(loads),
+	}
+
+	acuteSince := now.AddDate(0, 0, -acuteLoadDays)
+	chronicLoad, enjoymentSum, enjoymentCount := 0, 0, 0
+	painSeen := map[string]bool{}
+	var last *database.SessionLoad
+	for i := range loads {
+		load := &loads[i]
+		sessionLoad := int(load.Rpe) * load.Duration_minutes
+		chronicLoad += sessionLoad
+		if last == nil || load.Started_at.After(last.Started_at) {
+			last = load
+		}
+		if load.Started_at.Before(acuteSince) {
+			continue
+		}
+
+		response.AcuteLoad += sessionLoad
+		if load.Enjoyment != nil {
+			enjoymentSum += int(*load.Enjoyment)
+			enjoymentCount++
+		}
+		for _, area := range feedbackPainAreas(&load.Session_feedback) {
+			if !painSeen[area] {
+				painSeen[area] = true
+				response.RecentPainAreas = append(response.RecentPainAreas, area)
+			}
+		}
+	}
+	response.ChronicLoad = chronicLoad / chronicLoadWeeks
+
+	if response.ChronicLoad > 0 {
+		ratio := math.Round(float64(response.AcuteLoad)/float64(response.ChronicLoad)*100) / 100
+		response.LoadRatio = &ratio
+		switch {
+		case ratio > 1.5:
+			response.Score -= 30
+			response.Reasons = append(response.Reasons, "Training load this week is well above your usual")
+		case ratio > 1.3:
+			response.Score -= 15
+			response.Reasons = append(response.Reasons, "Training load this week is above your usual")
+		}
+	}
+
+	if last != nil && last.Rpe >= hardSessionRPE && now.Sub(last.Started_at) < 48*time.Hour {
+		response.Score -= 15
+		response.Reasons = append(response.Reasons, "Your last session was very hard")
+	}
+
+	if n := len(response.RecentPainAreas); n > 0 {
+		response.Score -= 15 * min(n, 2)
+		response.Reasons = append(response.Reasons, "Pain reported this week: "+strings.ReplaceAll(strings.Join(response.RecentPainAreas, ", "), "_", " "))
+	}
+
+	if enjoymentCount > 0 {
+		average := math.Round(float64(enjoymentSum)/float64(enjoymentCount)*10) / 10
+		response.AverageEnjoyment = &average
+		if average <= 2 {
+			response.Score -= 10
+			response.Reasons = append(response.Reasons, "Recent sessions haven't been enjoyable")
+		}
+	}
+
+	response.Status = readinessStatus(response.Score)
+	return response
+}
+
+// ownSession returns the user's workout session with the given ID, or sql.ErrNoRows
+func (s *FiberServer) ownSession(ctx context.Context, id, userID string) (*database.Workout_sessions, error) {
+	session, err := s.db.GetWorkoutSessionByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if session.User_id != userID {
+		return nil, sql.ErrNoRows
+	}
+	return session, nil
+}
+
+// POST /api/v1/workout-sessions/:id/feedback
+// Replaces any feedback given before, answering 201 for the first feedback on the session
+func (s *FiberServer) saveSessionFeedback(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	var req database.SessionFeedbackRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+	areas, err := validateSessionFeedback(&req)
+	if err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	session, err := s.ownSession(ctx, c.Params("id"), userID)
+	if err != nil {
+		return notFound(c, "Workout session")
+	}
+
+	painJSON, err := json.Marshal(areas)
+	if err != nil {
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to save session feedback")
+	}
+	feedback := &database.Session_feedback{
+		Session_id: session.Id,
+		User_id:    userID,
+		Rpe:        int16(req.RPE),
+		Pain_areas: painJSON,
+		Notes:      strings.TrimSpace(req.Notes),
+	}
+	if req.Enjoyment != nil {
+		enjoyment := int16(*req.Enjoyment)
+		feedback.Enjoyment = &enjoyment
+	}
+
+	saved, created, err := s.db.UpsertSessionFeedback(ctx, feedback)
+	if err != nil {
+		LogDatabaseError(s, "upsert_session_feedback", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to save session feedback")
+	}
+
+	status := fiber.StatusOK
+	if created {
+		status = fiber.StatusCreated
+	}
+	return c.Status(status).JSON(fiber.Map{"data": sessionFeedbackToResponse(saved, session.Duration_minutes)})
+}
+
+// GET /api/v1/workout-sessions/:id/feedback
+func (s *FiberServer) getSessionFeedback(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	session, err := s.ownSession(ctx, c.Params("id"), userID)
+	if err != nil {
+		return notFound(c, "Workout session")
+	}
+
+	feedback, err := s.db.GetSessionFeedback(ctx, session.Id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return errorResponse(c, fiber.StatusNotFound, "No feedback given on this session")
+	}
+	if err != nil {
+		LogDatabaseError(s, "get_session_feedback", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to get session feedback")
+	}
+	return successResponse(c, sessionFeedbackToResponse(feedback, session.Duration_minutes))
+}
+
+// DELETE /api/v1/workout-sessions/:id/feedback
+func (s *FiberServer) deleteSessionFeedback(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	session, err := s.ownSession(ctx, c.Params("id"), userID)
+	if err != nil {
+		return notFound(c, "Workout session")
+	}
+
+	if err := s.db.DeleteSessionFeedback(ctx, session.Id); errors.Is(err, sql.ErrNoRows) {
+		return errorResponse(c, fiber.StatusNotFound, "No feedback given on this session")
+	} else if err != nil {
+		LogDatabaseError(s, "delete_session_feedback", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to delete session feedback")
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// GET /api/v1/users/me/readiness
+func (s *FiberServer) getReadiness(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	loads, err := s.db.ListSessionLoads(ctx, userID, now.AddDate(0, 0, -7*chronicLoadWeeks))
+	if err != nil {
+		LogDatabaseError(s, "list_session_loads", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to get readiness")
+	}
+	return successResponse(c, computeReadiness(loads, now))
+}
@@ -0,0 +1,61 @@
+package server
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"fitness-hack/internal/database"
+)
+
+func TestValidateSessionFeedback(t *testing.T) {
+	areas, err := validateSessionFeedback(&database.SessionFeedbackRequest{RPE: 7, PainAreas: []string{"knee", "lower_back", "knee"}})
+	if err != nil || !reflect.DeepEqual(areas, []string{"knee", "lower_back"}) {
+		t.Errorf("expected pain areas without duplicates, got %v, %v", areas, err)
+	}
+
+	enjoyment := 6
+	invalid := []database.SessionFeedbackRequest{
+		{RPE: 0},
+		{RPE: 11},
+		{RPE: 5, Enjoyment: &enjoyment},
+		{RPE: 5, PainAreas: []string{"toe"}},
+	}
+	for _, req := range invalid {
+		if _, err := validateSessionFeedback(&req); err == nil {
+			t.Errorf("validateSessionFeedback(%+v) succeeded", req)
+		}
+	}
+}
+
+func TestComputeReadiness(t *testing.T) {
+	now := time.Date(2025, 8, 11, 12, 0, 0, 0, time.UTC)
+	session := func(daysAgo, rpe, minutes int, painAreas ...string) database.SessionLoad {
+		pain, _ := json.Marshal(append([]string{}, painAreas...))
+		load := database.SessionLoad{Started_at: now.AddDate(0, 0, -daysAgo), Duration_minutes: minutes}
+		load.Rpe = int16(rpe)
+		load.Pain_areas = pain
+		return load
+	}
+
+	if got := computeReadiness(nil, now); got.Score != 100 || got.Status != "ready" || got.LoadRatio != nil {
+		t.Errorf("expected full readiness without feedback, got %+v", got)
+	}
+
+	// Three earlier weeks of 300 load, then a week of 1000 ending in a very hard session
+	// with knee pain
+	loads := []database.SessionLoad{session(25, 5, 60), session(18, 5, 60), session(11, 5, 60)}
+	loads = append(loads, session(5, 8, 50), session(1, 10, 60, "knee"))
+	got := computeReadiness(loads, now)
+	if got.AcuteLoad != 1000 || got.ChronicLoad != 475 || got.LoadRatio == nil || *got.LoadRatio != 2.11 {
+		t.Errorf("expected acute 1000, chronic 475 and ratio 2.11, got %+v", got)
+	}
+	// 100 - 30 for the load spike - 15 for the hard session - 15 for the knee
+	if got.Score != 40 || got.Status != "caution" || len(got.Reasons) != 3 {
+		t.Errorf("expected a score of 40 with three reasons, got %+v", got)
+	}
+	if !reflect.DeepEqual(got.RecentPainAreas, []string{"knee"}) {
+		t.Errorf("expected recent knee pain, got %v", got.RecentPainAreas)
+	}
+}
@@ -0,0 +1,98 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+
+	"fitness-hack/internal/database"
+	"fitness-hack/internal/messages"
+	"fitness-hack/internal/weather"
+)
+
+const (
+	// sessionWeatherBatch is how many sessions are looked up per batch
+	sessionWeatherBatch = 50
+
+	// sessionWeatherDelay is how long after a session ends its weather is looked up, so
+	// the provider has its readings for the hour
+	sessionWeatherDelay = time.Hour
+)
+
+// StartSessionWeather tags outdoor sessions imported with GPS with the weather they were
+// done in, every SESSION_WEATHER_INTERVAL (default 15 minutes). Nothing is tagged unless
+// WEATHER_PROVIDER is set.
+func (s *FiberServer) StartSessionWeather(ctx context.Context) {
+	if s.weather == nil {
+		return
+	}
+	s.runPeriodically(ctx, getEnvDuration("SESSION_WEATHER_INTERVAL", 15*time.Minute), s.tagSessionWeather)
+}
+
+func (s *FiberServer) tagSessionWeather(ctx context.Context) {
+	if s.weather == nil {
+		return
+	}
+
+	tagged := 0
+	defer func() {
+		if tagged > 0 {
+			s.cache.Del(ctx, "workout_sessions:list:*")
+		}
+	}()
+
+	for ctx.Err() == nil {
+		listCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		sessions, err := s.db.ListSessionsNeedingWeather(listCtx, time.Now().Add(-sessionWeatherDelay), sessionWeatherBatch)
+		cancel()
+		if err != nil {
+			s.logError("ERROR", messages.SessionWeatherFailed, err, nil, map[string]interface{}{
+				"component": "session_weather",
+			})
+			return
+		}
+
+		for i := range sessions {
+			lookupCtx, cancel := context.WithTimeout(ctx, 20*time.Second)
+			err := s.tagSession(lookupCtx, &sessions[i])
+			cancel()
+			if err != nil {
+				// The provider is likely down or limiting requests, so the rest wait for the next run
+				s.logError("ERROR", messages.SessionWeatherLookupFailed, err, nil, map[string]interface{}{
+					"component":  "session_weather",
+					"session_id": sessions[i].Id,
+				})
+				return
+			}
+			tagged++
+		}
+		if len(sessions) < sessionWeatherBatch {
+			return
+		}
+	}
+}
+
+// tagSession looks up the weather at the session's start position around its midpoint.
+// Sessions the provider has no weather for are marked looked up without it.
+func (s *FiberServer) tagSession(ctx context.Context, ws *database.Workout_sessions) error {
+	midpoint := ws.Started_at.Add(ws.Completed_at.Sub(ws.Started_at) / 2)
+	conditions, err := s.weather.Historical(ctx, *ws.Start_latitude, *ws.Start_longitude, midpoint)
+	if err != nil && !errors.Is(err, weather.ErrNoData) {
+		return err
+	}
+
+	var sessionWeather *database.SessionWeather
+	if conditions != nil {
+		sessionWeather = &database.SessionWeather{
+			TemperatureC:  math.Round(conditions.TemperatureC*10) / 10,
+			WindSpeedKmh:  math.Round(conditions.WindSpeedKmh*10) / 10,
+			WindDirection: conditions.WindDirection,
+		}
+	}
+	if err := s.db.SetSessionWeather(ctx, ws.Id, sessionWeather); err != nil {
+		return err
+	}
+	s.DeleteCache(ctx, workoutSessionCacheKey(ws.Id))
+	return nil
+}
@@ -0,0 +1,40 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"fitness-hack/internal/importer"
+)
+
+func TestImportedSessionWeather(t *testing.T) {
+	start := time.Date(2024, 2, 1, 18, 0, 0, 0, time.UTC)
+	w := &importer.Workout{
+		ExternalID: "run-1",
+		Name:       "Evening Run",
+		StartedAt:  start,
+		EndedAt:    start.Add(30 * time.Minute),
+		Duration:   30 * time.Minute,
+		Start:      &importer.Position{Latitude: 52.52437, Longitude: 13.41053},
+	}
+
+	ws := importedWorkoutToSession("gpx", w, "exercise-1")
+	if ws.Start_latitude == nil || *ws.Start_latitude != 52.52 || *ws.Start_longitude != 13.41 {
+		t.Fatalf("expected the start position rounded to 2 decimals, got %v, %v", ws.Start_latitude, ws.Start_longitude)
+	}
+	if response := workoutSessionToResponse(&ws); response.Weather != nil {
+		t.Errorf("expected no weather before it is looked up, got %+v", response.Weather)
+	}
+
+	temperature, wind, direction := 4.5, 12.3, 270
+	ws.Temperature_c, ws.Wind_speed_kmh, ws.Wind_direction = &temperature, &wind, &direction
+	response := workoutSessionToResponse(&ws)
+	if response.Weather == nil || response.Weather.TemperatureC != 4.5 || response.Weather.WindDirection != 270 {
+		t.Errorf("unexpected weather %+v", response.Weather)
+	}
+
+	w.Start = nil
+	if ws := importedWorkoutToSession("gpx", w, "exercise-1"); ws.Start_latitude != nil {
+		t.Error("expected no start position for a workout without GPS")
+	}
+}
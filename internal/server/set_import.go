@@ -0,0 +1,344 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"fitness-hack/internal/database"
+	"fitness-hack/internal/importer/csvlog"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/shopspring/decimal"
+)
+
+// Actions for an exercise named in a set log
+const (
+	importActionMap    = "map"
+	importActionCreate = "create"
+	importActionSkip   = "skip"
+)
+
+// How a proposed catalog exercise matched the name in a set log
+const (
+	importMatchExact   = "exact"
+	importMatchSimilar = "similar"
+)
+
+// importPreview is what a preview keeps in the cache until it is committed
+type importPreview struct {
+	Log       *csvlog.Log                    `json:"log"`
+	Exercises []database.ImportExerciseMatch `json:"exercises"`
+}
+
+func importPreviewKey(userID, previewID string) string {
+	return "import_preview:" + userID + ":" + previewID
+}
+
+// exerciseMatchKey loosens an exercise name for matching: its words lowercased, without
+// punctuation and sorted, so Strong's "Bench Press (Barbell)" matches "Barbell Bench Press"
+func exerciseMatchKey(name string) string {
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	sort.Strings(words)
+	return strings.Join(words, " ")
+}
+
+// matchImportedExercises proposes a catalog exercise for each name in a set log: one with
+// the same name ignoring case, else one with the same words, else creating it
+func matchImportedExercises(log *csvlog.Log, catalog []database.ExerciseName) []database.ImportExerciseMatch {
+	exact := make(map[string]database.ExerciseName, len(catalog))
+	similar := make(map[string]database.ExerciseName, len(catalog))
+	for _, exercise := range catalog {
+		if _, ok := exact[strings.ToLower(exercise.Name)]; !ok {
+			exact[strings.ToLower(exercise.Name)] = exercise
+		}
+		if _, ok := similar[exerciseMatchKey(exercise.Name)]; !ok {
+			similar[exerciseMatchKey(exercise.Name)] = exercise
+		}
+	}
+
+	sets := make(map[string]int)
+	for _, session := range log.Sessions {
+		for _, set := range session.Sets {
+			sets[set.Exercise]++
+		}
+	}
+
+	names := log.Exercises()
+	matches := make([]database.ImportExerciseMatch, len(names))
+	for i, name := range names {
+		match := database.ImportExerciseMatch{Name: name, Action: importActionCreate, Sets: sets[name]}
+		if exercise, ok := exact[strings.ToLower(name)]; ok {
+			match.Action, match.Match, match.ExerciseID, match.ExerciseName = importActionMap, importMatchExact, exercise.Id, exercise.Name
+		} else if exercise, ok := similar[exerciseMatchKey(name)]; ok && exerciseMatchKey(name) != "" {
+			match.Action, match.Match, match.ExerciseID, match.ExerciseName = importActionMap, importMatchSimilar, exercise.Id, exercise.Name
+		}
+		matches[i] = match
+	}
+	return matches
+}
+
+// Helper to convert a set log session to a database session, without its sets
+func setLogSessionToSession(source string, session *csvlog.Session) database.Workout_sessions {
+	return database.Workout_sessions{
+		Name:             session.Name,
+		Started_at:       session.StartedAt,
+		Completed_at:     session.EndedAt,
+		Duration_minutes: int(math.Round(session.Duration.Minutes())),
+		Source:           source,
+		External_id:      session.ExternalID,
+		Duration_seconds: positiveOrNil(int(math.Round(session.Duration.Seconds()))),
+	}
+}
+
+// importedSetLogSessions converts the sessions of a set log to the sessions and sets to
+// store, recording each set against exerciseIDs[name]. Sets of exercises without an ID
+// are left out, and so are sessions left without sets. Set logs don't say when each set
+// was done, so sets are spread evenly over their session to keep the order of the file.
+func importedSetLogSessions(log *csvlog.Log, exerciseIDs map[string]string) ([]database.ImportedSession, int) {
+	var sessions []database.ImportedSession
+	skipped := 0
+	for i := range log.Sessions {
+		session := &log.Sessions[i]
+		imported := database.ImportedSession{Session: setLogSessionToSession(log.Source, session)}
+
+		step := session.EndedAt.Sub(session.StartedAt) / time.Duration(len(session.Sets))
+		if step <= 0 {
+			step = time.Second
+		}
+		for j, set := range session.Sets {
+			exerciseID, ok := exerciseIDs[set.Exercise]
+			if !ok {
+				skipped++
+				continue
+			}
+			row := database.Workout_session_sets{
+				Exercise_id:      &exerciseID,
+				Set_number:       set.SetNumber,
+				Reps:             set.Reps,
+				Weight_kg:        decimal.NewFromFloat(set.WeightKg).Round(2),
+				Duration_seconds: positiveOrNil(set.DurationSeconds),
+				Client_id:        fmt.Sprintf("import:%d", set.Row),
+				Completed_at:     session.StartedAt.Add(step * time.Duration(j+1)),
+			}
+			if set.RPE > 0 {
+				rpe := decimal.NewFromFloat(set.RPE).Round(1)
+				row.Rpe = &rpe
+			}
+			imported.Sets = append(imported.Sets, row)
+		}
+		if len(imported.Sets) > 0 {
+			sessions = append(sessions, imported)
+		}
+	}
+	return sessions, skipped
+}
+
+// POST /api/v1/import/preview?unit=lb&timezone=Europe/Berlin
+// Parses a CSV set log from Strong, Hevy or a spreadsheet without storing anything, and
+// proposes a catalog exercise for each exercise it names. The preview is kept for
+// IMPORT_PREVIEW_TTL so it can be committed with the user's corrections.
+func (s *FiberServer) previewSetLogImport(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+	if len(c.Body()) == 0 {
+		return errorResponse(c, fiber.StatusBadRequest, "Request body must contain a CSV export of logged sets")
+	}
+
+	opts := csvlog.Options{Unit: strings.ToLower(c.Query("unit"))}
+	if timezone := c.Query("timezone"); timezone != "" {
+		if opts.Location, err = time.LoadLocation(timezone); err != nil {
+			return errorResponse(c, fiber.StatusBadRequest, "Invalid timezone")
+		}
+	}
+	log, err := csvlog.Parse(c.Body(), opts)
+	if err != nil {
+		LogValidationError(s, "body", err, c)
+		return errorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	catalog, err := s.db.ListExerciseNames(ctx)
+	if err != nil {
+		LogDatabaseError(s, "list_exercise_names", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to preview import")
+	}
+
+	sessions := make([]database.Workout_sessions, len(log.Sessions))
+	for i := range log.Sessions {
+		sessions[i] = setLogSessionToSession(log.Source, &log.Sessions[i])
+	}
+	matchWindow := getEnvDuration("HEALTH_IMPORT_MATCH_WINDOW", 5*time.Minute)
+	duplicates, err := s.db.FindDuplicateImports(ctx, userID, log.Source, sessions, matchWindow)
+	if err != nil {
+		LogDatabaseError(s, "find_duplicate_imports", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to preview import")
+	}
+
+	preview := importPreview{Log: log, Exercises: matchImportedExercises(log, catalog)}
+	raw, err := json.Marshal(preview)
+	if err != nil {
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to preview import")
+	}
+	ttl := getEnvDuration("IMPORT_PREVIEW_TTL", time.Hour)
+	previewID := uuid.NewString()
+	if err := s.cache.Set(ctx, importPreviewKey(userID, previewID), raw, ttl).Err(); err != nil {
+		LogCacheError(s, "import_preview_store", err, c)
+		return errorResponse(c, fiber.StatusServiceUnavailable, "Unable to save import preview")
+	}
+
+	response := database.ImportPreviewResponse{
+		PreviewID: previewID,
+		Source:    log.Source,
+		ExpiresAt: time.Now().Add(ttl),
+		Rows:      log.Rows,
+		Sessions:  make([]database.ImportPreviewSession, len(log.Sessions)),
+		Exercises: preview.Exercises,
+		Issues:    make([]database.ImportIssue, len(log.Issues)),
+	}
+	for i, session := range log.Sessions {
+		previewed := database.ImportPreviewSession{
+			ExternalID:      session.ExternalID,
+			Name:            session.Name,
+			StartedAt:       session.StartedAt,
+			EndedAt:         session.EndedAt,
+			DurationMinutes: sessions[i].Duration_minutes,
+			Duplicate:       duplicates[session.ExternalID],
+			Sets:            make([]database.ImportPreviewSet, len(session.Sets)),
+		}
+		for j, set := range session.Sets {
+			previewed.Sets[j] = database.ImportPreviewSet{
+				Row:             set.Row,
+				Exercise:        set.Exercise,
+				SetNumber:       set.SetNumber,
+				Reps:            set.Reps,
+				WeightKg:        set.WeightKg,
+				DurationSeconds: positiveOrNil(set.DurationSeconds),
+			}
+			if set.RPE > 0 {
+				rpe := set.RPE
+				previewed.Sets[j].RPE = &rpe
+			}
+		}
+		response.Sessions[i] = previewed
+	}
+	for i, issue := range log.Issues {
+		response.Issues[i] = database.ImportIssue{Row: issue.Row, Message: issue.Message}
+	}
+
+	return successResponse(c, response)
+}
+
+// POST /api/v1/import/commit
+// Stores a previewed set log with the user's decision for each exercise it names. Sessions
+// that were imported before or overlap one the user already has are skipped.
+func (s *FiberServer) commitSetLogImport(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	var req database.ImportCommitRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+	if req.PreviewID == "" {
+		return errorResponse(c, fiber.StatusBadRequest, "previewId is required")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	key := importPreviewKey(userID, req.PreviewID)
+	raw, err := s.cache.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return errorResponse(c, fiber.StatusNotFound, "Import preview not found or expired, preview the file again")
+	}
+	if err != nil {
+		LogCacheError(s, "import_preview_load", err, c)
+		return errorResponse(c, fiber.StatusServiceUnavailable, "Unable to load import preview")
+	}
+	var preview importPreview
+	if err := json.Unmarshal(raw, &preview); err != nil || preview.Log == nil {
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to import")
+	}
+
+	decisions := make(map[string]database.ImportExerciseMapping, len(preview.Exercises))
+	for _, match := range preview.Exercises {
+		decisions[match.Name] = database.ImportExerciseMapping{Name: match.Name, Action: match.Action, ExerciseID: match.ExerciseID}
+	}
+	for _, mapping := range req.Exercises {
+		if _, ok := decisions[mapping.Name]; !ok {
+			return errorResponse(c, fiber.StatusBadRequest, fmt.Sprintf("Exercise %q is not in the import", mapping.Name))
+		}
+		switch mapping.Action {
+		case importActionMap:
+			if mapping.ExerciseID == "" {
+				return errorResponse(c, fiber.StatusBadRequest, fmt.Sprintf("exerciseId is required to map %q", mapping.Name))
+			}
+			_, err := s.db.GetExerciseByID(ctx, mapping.ExerciseID)
+			if errors.Is(err, sql.ErrNoRows) {
+				return errorResponse(c, fiber.StatusBadRequest, fmt.Sprintf("Exercise %s not found", mapping.ExerciseID))
+			}
+			if err != nil {
+				LogDatabaseError(s, "get_exercise", err, c)
+				return errorResponse(c, fiber.StatusInternalServerError, "Failed to import")
+			}
+		case importActionCreate, importActionSkip:
+		default:
+			return errorResponse(c, fiber.StatusBadRequest, "action must be map, create or skip")
+		}
+		decisions[mapping.Name] = mapping
+	}
+
+	exerciseIDs := make(map[string]string, len(decisions))
+	for name, decision := range decisions {
+		switch decision.Action {
+		case importActionMap:
+			exerciseIDs[name] = decision.ExerciseID
+		case importActionCreate:
+			exercise, err := s.db.FindOrCreateExercise(ctx, name, "", "")
+			if err != nil {
+				LogDatabaseError(s, "find_or_create_exercise", err, c)
+				return errorResponse(c, fiber.StatusInternalServerError, "Failed to import")
+			}
+			exerciseIDs[name] = exercise.Id
+		}
+	}
+
+	sessions, setsSkipped := importedSetLogSessions(preview.Log, exerciseIDs)
+	matchWindow := getEnvDuration("HEALTH_IMPORT_MATCH_WINDOW", 5*time.Minute)
+	result, err := s.db.ImportSetLog(ctx, userID, sessions, matchWindow)
+	if err != nil {
+		LogDatabaseError(s, "import_set_log", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to import")
+	}
+
+	s.cache.Del(ctx, key)
+	if result.SessionsImported > 0 {
+		s.cache.Del(ctx, "workout_sessions:list:*")
+	}
+
+	return successResponse(c, database.ImportCommitResponse{
+		Source:           preview.Log.Source,
+		SessionsImported: result.SessionsImported,
+		SessionsSkipped:  result.SessionsSkipped,
+		SetsImported:     result.SetsImported,
+		SetsSkipped:      setsSkipped,
+	})
+}
@@ -0,0 +1,139 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"fitness-hack/internal/database"
+	"fitness-hack/internal/database/dbtest"
+	"fitness-hack/internal/importer/csvlog"
+	"fitness-hack/internal/memstore"
+)
+
+// setImportStub reports the Push Day as a duplicate, creates exercises as "new-<name>" and
+// records the sessions imported
+type setImportStub struct {
+	*dbtest.Fake
+	imported []database.ImportedSession
+}
+
+func (s *setImportStub) FindDuplicateImports(ctx context.Context, userID, source string, sessions []database.Workout_sessions, matchWindow time.Duration) (map[string]bool, error) {
+	duplicates := make(map[string]bool)
+	for _, ws := range sessions {
+		duplicates[ws.External_id] = ws.Name == "Push Day"
+	}
+	return duplicates, nil
+}
+
+func (s *setImportStub) FindOrCreateExercise(ctx context.Context, name, muscleGroup, equipment string) (*database.Exercises, error) {
+	return &database.Exercises{Id: "new-" + name, Name: name}, nil
+}
+
+func (s *setImportStub) ImportSetLog(ctx context.Context, userID string, sessions []database.ImportedSession, matchWindow time.Duration) (*database.SetImportResult, error) {
+	s.imported = append(s.imported, sessions...)
+	result := &database.SetImportResult{}
+	for _, session := range sessions {
+		result.SessionsImported++
+		result.SetsImported += len(session.Sets)
+	}
+	return result, nil
+}
+
+func TestMatchImportedExercises(t *testing.T) {
+	log := &csvlog.Log{Sessions: []csvlog.Session{{Sets: []csvlog.Set{
+		{Exercise: "squat"}, {Exercise: "Bench Press (Barbell)"}, {Exercise: "Bench Press (Barbell)"}, {Exercise: "Zercher Carry"},
+	}}}}
+	catalog := []database.ExerciseName{{Id: "e1", Name: "Barbell Bench Press"}, {Id: "e2", Name: "Squat"}}
+
+	var got []string
+	for _, match := range matchImportedExercises(log, catalog) {
+		got = append(got, strings.Join([]string{match.Name, match.Action, match.Match, match.ExerciseID}, ":"))
+	}
+	want := "squat:map:exact:e2 Bench Press (Barbell):map:similar:e1 Zercher Carry:create::"
+	if strings.Join(got, " ") != want {
+		t.Errorf("expected %q, got %q", want, strings.Join(got, " "))
+	}
+}
+
+func TestSetLogImport(t *testing.T) {
+	db := dbtest.NewFake()
+	stub := &setImportStub{Fake: db}
+	s := newTestServer(t, stub)
+	s.cache = memstore.New().Client()
+	bench, _ := db.CreateExercise(context.Background(), &database.Exercises{Name: "Bench Press"})
+	row, _ := db.CreateExercise(context.Background(), &database.Exercises{Name: "Seated Cable Row"})
+
+	post := func(path, contentType, body string, out interface{}) int {
+		t.Helper()
+		req := httptest.NewRequest("POST", path, strings.NewReader(body))
+		req.Header.Set("Authorization", bearer(t, "u1"))
+		req.Header.Set("Content-Type", contentType)
+		resp, err := s.App.Test(req, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		json.NewDecoder(resp.Body).Decode(&struct{ Data interface{} }{out})
+		return resp.StatusCode
+	}
+
+	export := "Date,Workout Name,Exercise Name,Set Order,Weight,Reps\n" +
+		"2025-08-05 07:30:00,Push Day,Bench Press,1,135,8\n" +
+		"2025-08-07 07:30:00,Pull Day,Cable Row,1,100,10\n" +
+		"2025-08-07 07:30:00,Pull Day,Face Pull,1,40,15\n" +
+		"2025-08-07 07:30:00,Pull Day,Shrug,1,heavy,10\n"
+	var preview database.ImportPreviewResponse
+	if status := post("/api/v1/import/preview?unit=lb", "text/csv", export, &preview); status != 200 {
+		t.Fatalf("expected the preview to succeed, got %d", status)
+	}
+	if preview.PreviewID == "" || len(preview.Sessions) != 2 || !preview.Sessions[0].Duplicate || preview.Sessions[1].Duplicate {
+		t.Fatalf("expected the push day flagged as a duplicate, got %+v", preview)
+	}
+	if preview.Sessions[0].Sets[0].WeightKg != 61.23 {
+		t.Errorf("expected pounds converted to kg, got %v", preview.Sessions[0].Sets[0].WeightKg)
+	}
+	if len(preview.Issues) != 1 || preview.Issues[0].Row != 5 {
+		t.Errorf("expected the shrug row as an issue, got %+v", preview.Issues)
+	}
+	if len(preview.Exercises) != 3 || preview.Exercises[0].ExerciseID != bench.Id || preview.Exercises[1].Action != "create" {
+		t.Errorf("unexpected proposals %+v", preview.Exercises)
+	}
+	if len(stub.imported) != 0 {
+		t.Fatal("expected the preview not to import anything")
+	}
+
+	var result database.ImportCommitResponse
+	body := `{"previewId":"` + preview.PreviewID + `","exercises":[
+		{"name":"Cable Row","action":"map","exerciseId":"` + row.Id + `"},
+		{"name":"Face Pull","action":"skip"}]}`
+	if status := post("/api/v1/import/commit", "application/json", body, &result); status != 200 {
+		t.Fatalf("expected the commit to succeed, got %d", status)
+	}
+	if result.SessionsImported != 2 || result.SetsImported != 2 || result.SetsSkipped != 1 {
+		t.Errorf("unexpected result %+v", result)
+	}
+	if got := *stub.imported[1].Sets[0].Exercise_id; got != row.Id {
+		t.Errorf("expected the cable row mapped to the catalog row, got %s", got)
+	}
+
+	if status := post("/api/v1/import/commit", "application/json", body, nil); status != 404 {
+		t.Errorf("expected a committed preview to be gone, got %d", status)
+	}
+	post("/api/v1/import/preview", "text/csv", export, &preview)
+	for _, body := range []string{
+		`{}`,
+		`{"previewId":"` + preview.PreviewID + `","exercises":[{"name":"Bench Press","action":"map","exerciseId":"missing"}]}`,
+		`{"previewId":"` + preview.PreviewID + `","exercises":[{"name":"Deadlift","action":"create"}]}`,
+		`{"previewId":"` + preview.PreviewID + `","exercises":[{"name":"Face Pull","action":"merge"}]}`,
+	} {
+		if status := post("/api/v1/import/commit", "application/json", body, nil); status != 400 {
+			t.Errorf("%s: expected the commit to be rejected, got %d", body, status)
+		}
+	}
+	if status := post("/api/v1/import/preview", "text/csv", "day,lift\n2025-08-05,Curl\n", nil); status != 400 {
+		t.Errorf("expected an unrecognized file to be rejected, got %d", status)
+	}
+}
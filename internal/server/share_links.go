@@ -0,0 +1,124 @@
+package server
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Helper to return the key used to sign share links, falling back to the JWT secret
+func linkSigningKey() []byte {
+	if key := os.Getenv("LINK_SIGNING_SECRET"); key != "" {
+		return []byte(key)
+	}
+	return []byte(os.Getenv("JWT_SECRET"))
+}
+
+// Helper to compute the signature binding a path to its expiry and nonce
+func linkSignature(path string, expires int64, nonce string) string {
+	mac := hmac.New(sha256.New, linkSigningKey())
+	fmt.Fprintf(mac, "%s\n%d\n%s", path, expires, nonce)
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// signLink returns path with expires, nonce and sig query parameters appended
+func signLink(path string, expiresAt time.Time) (string, error) {
+	nonce, err := randomHex(16)
+	if err != nil {
+		return "", err
+	}
+	expires := expiresAt.Unix()
+	query := url.Values{
+		"expires": {strconv.FormatInt(expires, 10)},
+		"nonce":   {nonce},
+		"sig":     {linkSignature(path, expires, nonce)},
+	}
+	return path + "?" + query.Encode(), nil
+}
+
+// verifySignedLink only lets a signed link through once and before it expires.
+// If the handler fails with a server error the nonce is released so the link can be retried.
+func (s *FiberServer) verifySignedLink(c *fiber.Ctx) error {
+	expires, err := strconv.ParseInt(c.Query("expires"), 10, 64)
+	nonce := c.Query("nonce")
+	if err != nil || nonce == "" {
+		return errorResponse(c, fiber.StatusForbidden, "Invalid link")
+	}
+
+	expected := linkSignature(c.Path(), expires, nonce)
+	if !hmac.Equal([]byte(expected), []byte(c.Query("sig"))) {
+		return errorResponse(c, fiber.StatusForbidden, "Invalid link")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	release, err := s.claimNonce(ctx, "link", nonce, time.Unix(expires, 0))
+	switch {
+	case errors.Is(err, errStaleRequest):
+		return errorResponse(c, fiber.StatusGone, "Link has expired")
+	case errors.Is(err, errReplayedRequest):
+		return errorResponse(c, fiber.StatusGone, "Link has already been used")
+	case err != nil:
+		LogCacheError(s, "claim_link_nonce", err, c)
+		return errorResponse(c, fiber.StatusServiceUnavailable, "Please try again later")
+	}
+
+	if err := c.Next(); err != nil {
+		release()
+		return err
+	}
+	if c.Response().StatusCode() >= fiber.StatusInternalServerError {
+		release()
+	}
+	return nil
+}
+
+// POST /api/v1/workouts/:id/share-link
+func (s *FiberServer) createWorkoutShareLink(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	workout, err := s.db.GetWorkoutByID(ctx, c.Params("id"))
+	if err != nil || workout.User_id != userID {
+		return notFound(c, "Workout")
+	}
+
+	expiresAt := time.Now().Add(getEnvDuration("SHARE_LINK_TTL", 24*time.Hour))
+	link, err := signLink("/api/v1/shared/workouts/"+workout.Id, expiresAt)
+	if err != nil {
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to create share link")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"data": fiber.Map{
+		"url":       c.BaseURL() + link,
+		"expiresAt": expiresAt,
+	}})
+}
+
+// GET /api/v1/shared/workouts/:id (signed link, no JWT)
+func (s *FiberServer) getSharedWorkout(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	workout, err := s.db.GetWorkoutByID(ctx, c.Params("id"))
+	if err != nil {
+		return notFound(c, "Workout")
+	}
+
+	return successResponse(c, workoutToResponse(workout))
+}
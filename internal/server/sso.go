@@ -0,0 +1,328 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"fitness-hack/internal/database"
+	"fitness-hack/internal/messages"
+	"fitness-hack/internal/oauth"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var (
+	// errSSONotProvisioned is returned when JIT provisioning is off and the user has no account
+	errSSONotProvisioned = errors.New("user has not been provisioned")
+
+	// errSSOAccountExists is returned when the IdP asserts the email of an account outside the organization
+	errSSOAccountExists = errors.New("account exists outside the organization")
+)
+
+// ssoProvider caches the verifier built for one organization's configuration
+type ssoProvider struct {
+	updatedAt time.Time
+	provider  *oauth.OIDCProvider
+}
+
+// ssoProviderName is the oauth_identities provider under which an organization's SSO subjects are linked
+func ssoProviderName(orgID string) string {
+	return "sso:" + orgID
+}
+
+// Helper to convert database SSO configuration to response model
+func ssoConfigToResponse(config *database.Organization_sso) database.SSOConfigResponse {
+	return database.SSOConfigResponse{
+		Protocol:              string(config.Protocol),
+		Issuer:                config.Issuer,
+		ClientID:              config.Client_id,
+		AuthorizationEndpoint: config.Authorization_endpoint,
+		RoleClaim:             config.Role_claim,
+		AdminRoleValues:       splitList(config.Admin_role_values),
+		JITProvisioning:       config.Jit_provisioning,
+		Enabled:               config.Enabled,
+		UpdatedAt:             config.Updated_at,
+	}
+}
+
+// ssoVerifier returns the ID token verifier for the organization's current configuration,
+// reusing the cached one (and its JWKS) until the configuration changes
+func (s *FiberServer) ssoVerifier(config *database.Organization_sso) *oauth.OIDCProvider {
+	if cached, ok := s.ssoProviders.Load(config.Organization_id); ok {
+		if entry := cached.(ssoProvider); entry.updatedAt.Equal(config.Updated_at) {
+			return entry.provider
+		}
+	}
+
+	issuer := strings.TrimSuffix(config.Issuer, "/")
+	provider := oauth.NewOIDCProvider(oauth.OIDCConfig{
+		Name:      ssoProviderName(config.Organization_id),
+		JWKSURL:   config.Jwks_url,
+		Issuers:   []string{issuer, issuer + "/"},
+		Audiences: []string{config.Client_id},
+	})
+	s.ssoProviders.Store(config.Organization_id, ssoProvider{updatedAt: config.Updated_at, provider: provider})
+	return provider
+}
+
+// ssoRole maps the identity's role claim to an organization role. The claim may be a
+// single string or a list such as IdP groups.
+func ssoRole(config *database.Organization_sso, claims map[string]interface{}) string {
+	if config.Role_claim == "" {
+		return orgRoleMember
+	}
+
+	var values []string
+	switch v := claims[config.Role_claim].(type) {
+	case string:
+		values = []string{v}
+	case []interface{}:
+		for _, item := range v {
+			if str, ok := item.(string); ok {
+				values = append(values, str)
+			}
+		}
+	}
+
+	for _, admin := range splitList(config.Admin_role_values) {
+		for _, value := range values {
+			if value == admin {
+				return orgRoleAdmin
+			}
+		}
+	}
+	return orgRoleMember
+}
+
+// getEnabledSSO loads the organization's SSO configuration, treating disabled SSO as not configured
+func (s *FiberServer) getEnabledSSO(ctx context.Context, orgID string) (*database.Organization_sso, error) {
+	config, err := s.db.GetOrganizationSSO(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	if !config.Enabled {
+		return nil, sql.ErrNoRows
+	}
+	return config, nil
+}
+
+// GET /api/v1/auth/sso/:orgId
+// Returns what a client needs to start the authorization code flow with the organization's IdP
+func (s *FiberServer) getSSOLoginConfig(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	config, err := s.getEnabledSSO(ctx, c.Params("orgId"))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errorResponse(c, fiber.StatusNotFound, "Single sign-on is not configured for this organization")
+		}
+		LogDatabaseError(s, "get_organization_sso", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to fetch single sign-on configuration")
+	}
+
+	return successResponse(c, database.SSOLoginConfigResponse{
+		Protocol:              string(config.Protocol),
+		Issuer:                config.Issuer,
+		ClientID:              config.Client_id,
+		AuthorizationEndpoint: config.Authorization_endpoint,
+	})
+}
+
+// POST /api/v1/auth/sso/:orgId
+func (s *FiberServer) ssoLogin(c *fiber.Ctx) error {
+	var req database.SSOLoginRequest
+	if err := c.BodyParser(&req); err != nil || req.IDToken == "" {
+		return errorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), 10*time.Second)
+	defer cancel()
+
+	config, err := s.getEnabledSSO(ctx, c.Params("orgId"))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errorResponse(c, fiber.StatusNotFound, "Single sign-on is not configured for this organization")
+		}
+		LogDatabaseError(s, "get_organization_sso", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to sign in")
+	}
+
+	identity, err := s.ssoVerifier(config).Verify(ctx, req.IDToken)
+	if err != nil {
+		LogAuthError(s, messages.AuthSSOVerificationFailed, err, c)
+		if errors.Is(err, oauth.ErrInvalidToken) {
+			return errorResponse(c, fiber.StatusUnauthorized, "Invalid credentials")
+		}
+		return errorResponse(c, fiber.StatusServiceUnavailable, "Identity provider unavailable")
+	}
+
+	user, err := s.db.GetUserByOAuthIdentity(ctx, identity.Provider, identity.Subject)
+	if errors.Is(err, sql.ErrNoRows) {
+		user, err = s.linkOrProvisionSSOUser(ctx, config, identity)
+	}
+	switch {
+	case errors.Is(err, errSSONotProvisioned):
+		return errorResponse(c, fiber.StatusForbidden, "Your account has not been provisioned for this organization")
+	case errors.Is(err, errSSOAccountExists):
+		return errorResponse(c, fiber.StatusConflict, "An account with this email already exists, join the organization through an invitation first")
+	case err != nil:
+		LogDatabaseError(s, "sso_login", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to sign in")
+	}
+
+	member, err := s.db.SyncSSOMember(ctx, config.Organization_id, user.Id, ssoRole(config, identity.Claims))
+	if err != nil {
+		LogDatabaseError(s, "sync_sso_member", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to sign in")
+	}
+	if !member.Active {
+		return errorResponse(c, fiber.StatusForbidden, "Your organization membership is suspended")
+	}
+
+	if user.Disabled_at != nil {
+		return codedErrorResponse(c, fiber.StatusForbidden, codeAccountDisabled, "This account has been disabled")
+	}
+
+	token, err := s.issueSessionToken(ctx, c, user.Id, time.Now().Add(sessionTokenTTL), nil)
+	if err != nil {
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to generate token")
+	}
+
+	return successResponse(c, database.LoginResponse{
+		User:  userToResponse(user),
+		Token: token,
+	})
+}
+
+// linkOrProvisionSSOUser links a first-time SSO identity to an account. Existing accounts are
+// only linked when they already belong to the organization, so an IdP cannot claim arbitrary
+// users by asserting their email. Otherwise a new account is created when JIT provisioning is on.
+func (s *FiberServer) linkOrProvisionSSOUser(ctx context.Context, config *database.Organization_sso, identity *oauth.Identity) (*database.Users, error) {
+	if identity.Email == "" {
+		return nil, errors.New("identity provider did not share an email address")
+	}
+
+	link := &database.Oauth_identities{
+		Provider: identity.Provider,
+		Subject:  identity.Subject,
+		Email:    identity.Email,
+	}
+
+	existing, err := s.db.GetUserByEmail(ctx, identity.Email)
+	if err == nil {
+		if _, err := s.db.GetOrganizationRole(ctx, config.Organization_id, existing.Id); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, errSSOAccountExists
+			}
+			return nil, err
+		}
+		link.User_id = existing.Id
+		if _, err := s.db.LinkOAuthIdentity(ctx, link); err != nil {
+			return nil, err
+		}
+		return existing, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if !config.Jit_provisioning {
+		return nil, errSSONotProvisioned
+	}
+
+	user, err := newPasswordlessUser(identity.Email, identity.FirstName, identity.LastName)
+	if err != nil {
+		return nil, err
+	}
+	return s.db.CreateOAuthUser(ctx, user, link)
+}
+
+// GET /api/v1/organizations/:orgId/sso
+func (s *FiberServer) getOrganizationSSO(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	config, err := s.db.GetOrganizationSSO(ctx, c.Params("orgId"))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errorResponse(c, fiber.StatusNotFound, "Single sign-on is not configured")
+		}
+		LogDatabaseError(s, "get_organization_sso", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to fetch single sign-on configuration")
+	}
+
+	return successResponse(c, ssoConfigToResponse(config))
+}
+
+// PUT /api/v1/organizations/:orgId/sso
+func (s *FiberServer) putOrganizationSSO(c *fiber.Ctx) error {
+	if c.Locals("org_role") != orgRoleOwner {
+		return errorResponse(c, fiber.StatusForbidden, "Only the organization owner can configure single sign-on")
+	}
+
+	var req database.SSOConfigRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+	if req.Protocol == "" {
+		req.Protocol = "oidc"
+	}
+	if req.Protocol != "oidc" {
+		return errorResponse(c, fiber.StatusBadRequest, "Only the 'oidc' protocol is supported")
+	}
+	if !strings.HasPrefix(req.Issuer, "https://") || req.ClientID == "" {
+		return errorResponse(c, fiber.StatusBadRequest, "An https issuer and a clientId are required")
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), 15*time.Second)
+	defer cancel()
+
+	// Resolve the JWKS now so a misconfigured issuer is reported to the owner rather than at sign-in
+	metadata, err := oauth.Discover(ctx, req.Issuer)
+	if err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, "Could not load the issuer's OpenID configuration: "+err.Error())
+	}
+
+	config, err := s.db.UpsertOrganizationSSO(ctx, &database.Organization_sso{
+		Organization_id:        c.Params("orgId"),
+		Protocol:               database.Organization_sso_protocol(req.Protocol),
+		Issuer:                 strings.TrimSuffix(req.Issuer, "/"),
+		Client_id:              req.ClientID,
+		Authorization_endpoint: metadata.AuthorizationEndpoint,
+		Jwks_url:               metadata.JWKSURI,
+		Role_claim:             req.RoleClaim,
+		Admin_role_values:      strings.Join(req.AdminRoleValues, ","),
+		Jit_provisioning:       req.JITProvisioning == nil || *req.JITProvisioning,
+		Enabled:                req.Enabled == nil || *req.Enabled,
+	})
+	if err != nil {
+		LogDatabaseError(s, "upsert_organization_sso", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to save single sign-on configuration")
+	}
+
+	return successResponse(c, ssoConfigToResponse(config))
+}
+
+// DELETE /api/v1/organizations/:orgId/sso
+func (s *FiberServer) deleteOrganizationSSO(c *fiber.Ctx) error {
+	if c.Locals("org_role") != orgRoleOwner {
+		return errorResponse(c, fiber.StatusForbidden, "Only the organization owner can configure single sign-on")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := s.db.DeleteOrganizationSSO(ctx, c.Params("orgId")); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errorResponse(c, fiber.StatusNotFound, "Single sign-on is not configured")
+		}
+		LogDatabaseError(s, "delete_organization_sso", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to delete single sign-on configuration")
+	}
+
+	s.ssoProviders.Delete(c.Params("orgId"))
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,543 @@
+package server
+
+import (
+	"context"
+	"crypto/hmac"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"fitness-hack/internal/database"
+	"fitness-hack/internal/importer"
+	"fitness-hack/internal/integrations"
+	"fitness-hack/internal/messages"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	integrationStrava = "strava"
+
+	// stravaStateTTL bounds how long the user may take on Strava's consent screen
+	stravaStateTTL = 15 * time.Minute
+
+	// stravaPageSize is the largest page the activities API returns
+	stravaPageSize = 100
+)
+
+// errInvalidStravaState is returned when the OAuth state does not come from a connect request
+var errInvalidStravaState = errors.New("invalid oauth state")
+
+// Helper to convert database integration to response model
+func integrationToResponse(integration *database.Integrations) database.IntegrationResponse {
+	return database.IntegrationResponse{
+		Provider:       string(integration.Provider),
+		ExternalUserID: integration.External_user_id,
+		Scope:          integration.Scope,
+		LastSyncedAt:   integration.Last_synced_at,
+		LastError:      integration.Last_error,
+		ConnectedAt:    integration.Created_at,
+	}
+}
+
+// stravaAvailable reports whether Strava credentials and the token encryption key are configured
+func (s *FiberServer) stravaAvailable() bool {
+	return s.strava.Configured() && s.tokenCipher != nil
+}
+
+// stravaState returns an OAuth state value binding the callback to the user who started the
+// flow; it is signed like share links and can be used once
+func stravaState(userID string, expiresAt time.Time) (string, error) {
+	nonce, err := randomHex(16)
+	if err != nil {
+		return "", err
+	}
+	expires := expiresAt.Unix()
+	sig := linkSignature("strava:"+userID, expires, nonce)
+	return fmt.Sprintf("%s.%d.%s.%s", userID, expires, nonce, sig), nil
+}
+
+// verifyStravaState returns the user a state value was issued to
+func (s *FiberServer) verifyStravaState(ctx context.Context, state string) (string, error) {
+	parts := strings.Split(state, ".")
+	if len(parts) != 4 {
+		return "", errInvalidStravaState
+	}
+	userID, nonce, sig := parts[0], parts[2], parts[3]
+	expires, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return "", errInvalidStravaState
+	}
+	if !hmac.Equal([]byte(linkSignature("strava:"+userID, expires, nonce)), []byte(sig)) {
+		return "", errInvalidStravaState
+	}
+	if _, err := s.claimNonce(ctx, "strava_state", nonce, time.Unix(expires, 0)); err != nil {
+		return "", err
+	}
+	return userID, nil
+}
+
+// POST /api/v1/integrations/strava/connect
+func (s *FiberServer) connectStrava(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+	if !s.stravaAvailable() {
+		return errorResponse(c, fiber.StatusServiceUnavailable, "Strava integration is not configured")
+	}
+
+	expiresAt := time.Now().Add(stravaStateTTL)
+	state, err := stravaState(userID, expiresAt)
+	if err != nil {
+		LogError(s, "ERROR", messages.StravaStateFailed, err, c, nil)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to start Strava connection")
+	}
+
+	return successResponse(c, database.IntegrationConnectResponse{
+		AuthorizeURL: s.strava.AuthorizeURLFor(state),
+		ExpiresAt:    expiresAt,
+	})
+}
+
+// finishStravaConnect sends the browser back to the app when STRAVA_APP_REDIRECT_URL is set,
+// and answers with JSON otherwise
+func finishStravaConnect(c *fiber.Ctx, status int, message string, integration *database.Integrations) error {
+	if target := getEnv("STRAVA_APP_REDIRECT_URL", ""); target != "" {
+		result := url.Values{"strava": {"connected"}}
+		if integration == nil {
+			result = url.Values{"strava": {"error"}, "message": {message}}
+		}
+		separator := "?"
+		if strings.Contains(target, "?") {
+			separator = "&"
+		}
+		return c.Redirect(target+separator+result.Encode(), fiber.StatusFound)
+	}
+	if integration == nil {
+		return errorResponse(c, status, message)
+	}
+	return successResponse(c, integrationToResponse(integration))
+}
+
+// GET /api/v1/integrations/strava/callback?code=...&state=...&scope=...
+// Public: Strava redirects the user's browser here, so the user is identified by the state
+func (s *FiberServer) stravaCallback(c *fiber.Ctx) error {
+	if !s.stravaAvailable() {
+		return errorResponse(c, fiber.StatusServiceUnavailable, "Strava integration is not configured")
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), 15*time.Second)
+	defer cancel()
+
+	userID, err := s.verifyStravaState(ctx, c.Query("state"))
+	if err != nil {
+		LogAuthError(s, messages.StravaCallbackRejected, err, c)
+		return finishStravaConnect(c, fiber.StatusBadRequest, "Connection request is invalid or has expired", nil)
+	}
+	if c.Query("error") != "" {
+		return finishStravaConnect(c, fiber.StatusBadRequest, "Strava access was denied", nil)
+	}
+	scope := c.Query("scope")
+	if !strings.Contains(scope, "activity:read") {
+		return finishStravaConnect(c, fiber.StatusBadRequest, "Access to activities is required", nil)
+	}
+
+	token, err := s.strava.Exchange(ctx, c.Query("code"))
+	if err != nil {
+		LogError(s, "ERROR", messages.StravaCodeExchangeFailed, err, c, map[string]interface{}{"user_id": userID})
+		return finishStravaConnect(c, fiber.StatusBadGateway, "Could not connect to Strava", nil)
+	}
+
+	accessToken, err := s.tokenCipher.Seal(token.AccessToken)
+	if err != nil {
+		LogError(s, "ERROR", messages.StravaTokenEncryptFailed, err, c, nil)
+		return finishStravaConnect(c, fiber.StatusInternalServerError, "Failed to connect Strava", nil)
+	}
+	refreshToken, err := s.tokenCipher.Seal(token.RefreshToken)
+	if err != nil {
+		LogError(s, "ERROR", messages.StravaTokenEncryptFailed, err, c, nil)
+		return finishStravaConnect(c, fiber.StatusInternalServerError, "Failed to connect Strava", nil)
+	}
+
+	integration, err := s.db.UpsertIntegration(ctx, &database.Integrations{
+		User_id:          userID,
+		Provider:         integrationStrava,
+		External_user_id: token.AthleteID,
+		Access_token:     accessToken,
+		Refresh_token:    refreshToken,
+		Expires_at:       token.ExpiresAt,
+		Scope:            scope,
+	})
+	if errors.Is(err, database.ErrIntegrationInUse) {
+		return finishStravaConnect(c, fiber.StatusConflict, "This Strava account is connected to another user", nil)
+	}
+	if err != nil {
+		LogDatabaseError(s, "upsert_integration", err, c)
+		return finishStravaConnect(c, fiber.StatusInternalServerError, "Failed to connect Strava", nil)
+	}
+
+	go s.runStravaSync(*integration)
+
+	return finishStravaConnect(c, fiber.StatusOK, "", integration)
+}
+
+// GET /api/v1/integrations
+func (s *FiberServer) listIntegrations(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	connections, err := s.db.ListIntegrationsByUser(ctx, userID)
+	if err != nil {
+		LogDatabaseError(s, "list_integrations", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to fetch integrations")
+	}
+
+	responses := make([]database.IntegrationResponse, len(connections))
+	for i := range connections {
+		responses[i] = integrationToResponse(&connections[i])
+	}
+	return successResponse(c, responses)
+}
+
+// POST /api/v1/integrations/strava/sync
+func (s *FiberServer) syncStrava(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+	if !s.stravaAvailable() {
+		return errorResponse(c, fiber.StatusServiceUnavailable, "Strava integration is not configured")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	integration, err := s.db.GetIntegration(ctx, userID, integrationStrava)
+	if err != nil {
+		return errorResponse(c, fiber.StatusNotFound, "Strava is not connected")
+	}
+
+	go s.runStravaSync(*integration)
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{"data": integrationToResponse(integration)})
+}
+
+// DELETE /api/v1/integrations/strava
+// Imported sessions are kept; only the connection is removed
+func (s *FiberServer) disconnectStrava(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	integration, err := s.db.GetIntegration(ctx, userID, integrationStrava)
+	if err != nil {
+		return errorResponse(c, fiber.StatusNotFound, "Strava is not connected")
+	}
+
+	// Revoking at Strava is best effort; the user can also revoke from their Strava settings
+	if s.stravaAvailable() {
+		if accessToken, err := s.stravaAccessToken(ctx, integration); err == nil {
+			if err := s.strava.Deauthorize(ctx, accessToken); err != nil {
+				LogError(s, "WARN", messages.StravaDeauthorizeFailed, err, c, nil)
+			}
+		}
+	}
+
+	if err := s.db.DeleteIntegration(ctx, userID, integrationStrava); err != nil && !errors.Is(err, sql.ErrNoRows) {
+		LogDatabaseError(s, "delete_integration", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to disconnect Strava")
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// GET /api/v1/integrations/strava/webhook
+// Strava validates the subscription callback by echoing hub.challenge
+func (s *FiberServer) verifyStravaWebhook(c *fiber.Ctx) error {
+	token := s.strava.WebhookVerifyToken
+	if token == "" || c.Query("hub.mode") != "subscribe" ||
+		!hmac.Equal([]byte(c.Query("hub.verify_token")), []byte(token)) {
+		return errorResponse(c, fiber.StatusForbidden, "Invalid verification request")
+	}
+	return c.JSON(fiber.Map{"hub.challenge": c.Query("hub.challenge")})
+}
+
+// stravaEvent is a webhook event; Strava sends one per activity change or deauthorization
+type stravaEvent struct {
+	ObjectType     string            `json:"object_type"`
+	ObjectID       int64             `json:"object_id"`
+	AspectType     string            `json:"aspect_type"`
+	OwnerID        int64             `json:"owner_id"`
+	SubscriptionID int64             `json:"subscription_id"`
+	EventTime      int64             `json:"event_time"`
+	Updates        map[string]string `json:"updates"`
+}
+
+// POST /api/v1/integrations/strava/webhook
+// Strava does not sign events, so they only identify what changed; activities are always
+// fetched with the athlete's own token. Strava expects an answer within two seconds, so events
+// are processed in the background.
+func (s *FiberServer) handleStravaWebhook(c *fiber.Ctx) error {
+	if !s.stravaAvailable() {
+		return errorResponse(c, fiber.StatusServiceUnavailable, "Strava integration is not configured")
+	}
+
+	var event stravaEvent
+	if err := c.BodyParser(&event); err != nil || event.OwnerID == 0 {
+		return errorResponse(c, fiber.StatusBadRequest, "Invalid event")
+	}
+	if id := getEnv("STRAVA_WEBHOOK_SUBSCRIPTION_ID", ""); id != "" && id != strconv.FormatInt(event.SubscriptionID, 10) {
+		LogAuthError(s, messages.StravaEventUnknownSubscription, nil, c)
+		return errorResponse(c, fiber.StatusForbidden, "Unknown subscription")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	id := fmt.Sprintf("%s:%d:%s:%d", event.ObjectType, event.ObjectID, event.AspectType, event.EventTime)
+	_, err := s.claimWebhookNonce(ctx, integrationStrava, id, time.Unix(event.EventTime, 0))
+	switch {
+	case errors.Is(err, errReplayedRequest):
+		return c.SendStatus(fiber.StatusOK)
+	case errors.Is(err, errStaleRequest):
+		LogAuthError(s, messages.StravaEventStale, err, c)
+		return errorResponse(c, fiber.StatusBadRequest, "Invalid event")
+	case err != nil:
+		LogCacheError(s, "claim_webhook_nonce", err, c)
+		return errorResponse(c, fiber.StatusServiceUnavailable, "Please retry")
+	}
+
+	go s.processStravaEvent(event)
+
+	return c.SendStatus(fiber.StatusOK)
+}
+
+// processStravaEvent applies a webhook event to the athlete's imported sessions
+func (s *FiberServer) processStravaEvent(event stravaEvent) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	metadata := map[string]interface{}{
+		"component":   "strava",
+		"object_type": event.ObjectType,
+		"object_id":   event.ObjectID,
+		"aspect_type": event.AspectType,
+	}
+
+	integration, err := s.db.GetIntegrationByExternalUser(ctx, integrationStrava, strconv.FormatInt(event.OwnerID, 10))
+	if errors.Is(err, sql.ErrNoRows) {
+		// Athlete disconnected in the meantime
+		return
+	}
+	if err != nil {
+		s.logError("ERROR", messages.StravaEventLookupFailed, err, nil, metadata)
+		return
+	}
+
+	switch {
+	case event.ObjectType == "athlete" && event.Updates["authorized"] == "false":
+		err = s.db.DeleteIntegration(ctx, integration.User_id, integrationStrava)
+		if errors.Is(err, sql.ErrNoRows) {
+			err = nil
+		}
+	case event.ObjectType == "activity" && event.AspectType == "delete":
+		err = s.db.DeleteImportedSession(ctx, integration.User_id, integrationStrava, strconv.FormatInt(event.ObjectID, 10))
+		if errors.Is(err, sql.ErrNoRows) {
+			err = nil
+		}
+		s.cache.Del(ctx, "workout_sessions:list:*")
+	case event.ObjectType == "activity":
+		var accessToken string
+		accessToken, err = s.stravaAccessToken(ctx, integration)
+		if err != nil {
+			break
+		}
+		var activity *integrations.StravaActivity
+		activity, err = s.strava.GetActivity(ctx, accessToken, event.ObjectID)
+		if err != nil {
+			break
+		}
+		err = s.importStravaActivity(ctx, integration.User_id, activity)
+	}
+
+	if err != nil {
+		s.logError("ERROR", messages.StravaEventFailed, err, nil, metadata)
+	}
+}
+
+// stravaAccessToken returns a usable access token for the connection, refreshing it
+// shortly before it expires
+func (s *FiberServer) stravaAccessToken(ctx context.Context, integration *database.Integrations) (string, error) {
+	if time.Until(integration.Expires_at) > 5*time.Minute {
+		return s.tokenCipher.Open(integration.Access_token)
+	}
+
+	refreshToken, err := s.tokenCipher.Open(integration.Refresh_token)
+	if err != nil {
+		return "", err
+	}
+	token, err := s.strava.Refresh(ctx, refreshToken)
+	if err != nil {
+		return "", err
+	}
+
+	sealedAccess, err := s.tokenCipher.Seal(token.AccessToken)
+	if err != nil {
+		return "", err
+	}
+	sealedRefresh, err := s.tokenCipher.Seal(token.RefreshToken)
+	if err != nil {
+		return "", err
+	}
+	if err := s.db.UpdateIntegrationTokens(ctx, integration.Id, sealedAccess, sealedRefresh, token.ExpiresAt); err != nil {
+		return "", fmt.Errorf("failed to store refreshed tokens: %w", err)
+	}
+
+	integration.Access_token, integration.Refresh_token, integration.Expires_at = sealedAccess, sealedRefresh, token.ExpiresAt
+	return token.AccessToken, nil
+}
+
+// importStravaActivity stores an activity as a workout session recorded against the
+// exercise its sport type maps to. Re-importing an activity updates the existing session.
+func (s *FiberServer) importStravaActivity(ctx context.Context, userID string, activity *integrations.StravaActivity) error {
+	mapped := integrations.ExerciseForSportType(activity.SportType)
+	exercise, err := s.db.FindOrCreateExercise(ctx, mapped.Name, mapped.MuscleGroup, mapped.Equipment)
+	if err != nil {
+		return err
+	}
+
+	name := activity.Name
+	if name == "" {
+		name = mapped.Name
+	}
+	activeSeconds := activity.MovingTime
+	if activeSeconds == 0 {
+		activeSeconds = activity.ElapsedTime
+	}
+
+	workout := &importer.Workout{
+		ExternalID:     strconv.FormatInt(activity.ID, 10),
+		Name:           name,
+		StartedAt:      activity.StartDate,
+		EndedAt:        activity.StartDate.Add(time.Duration(activity.ElapsedTime) * time.Second),
+		Duration:       time.Duration(activeSeconds) * time.Second,
+		DistanceMeters: activity.Distance,
+		AvgHeartRate:   int(math.Round(activity.AverageHeartrate)),
+		MaxHeartRate:   int(math.Round(activity.MaxHeartrate)),
+	}
+	if len(activity.StartLatLng) == 2 {
+		workout.Start = &importer.Position{Latitude: activity.StartLatLng[0], Longitude: activity.StartLatLng[1]}
+	}
+	ws := importedWorkoutToSession(integrationStrava, workout, exercise.Id)
+	ws.User_id = userID
+	ws.Notes = activity.Description
+
+	session, err := s.db.UpsertImportedSession(ctx, &ws)
+	if err != nil {
+		return fmt.Errorf("failed to import activity %d: %w", activity.ID, err)
+	}
+
+	s.DeleteCache(ctx, workoutSessionCacheKey(session.Id))
+	s.cache.Del(ctx, "workout_sessions:list:*")
+	return nil
+}
+
+// importStravaActivities imports the athlete's activities started since the last sync.
+// The first sync goes back STRAVA_INITIAL_SYNC_DAYS (default 30); later ones overlap by a
+// day so activities uploaded late from a device are picked up.
+func (s *FiberServer) importStravaActivities(ctx context.Context, integration *database.Integrations) (int, error) {
+	accessToken, err := s.stravaAccessToken(ctx, integration)
+	if err != nil {
+		return 0, err
+	}
+
+	after := time.Now().AddDate(0, 0, -getEnvInt("STRAVA_INITIAL_SYNC_DAYS", 30))
+	if integration.Last_synced_at != nil {
+		after = integration.Last_synced_at.Add(-24 * time.Hour)
+	}
+
+	imported := 0
+	for page := 1; ; page++ {
+		activities, err := s.strava.ListActivities(ctx, accessToken, after, page, stravaPageSize)
+		if err != nil {
+			return imported, err
+		}
+		for i := range activities {
+			if err := s.importStravaActivity(ctx, integration.User_id, &activities[i]); err != nil {
+				return imported, err
+			}
+			imported++
+		}
+		if len(activities) < stravaPageSize {
+			return imported, nil
+		}
+	}
+}
+
+// runStravaSync syncs one connection and records the outcome on it
+func (s *FiberServer) runStravaSync(integration database.Integrations) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	startedAt := time.Now()
+	imported, err := s.importStravaActivities(ctx, &integration)
+
+	syncErr := ""
+	if err != nil {
+		syncErr = err.Error()
+		s.logError("ERROR", messages.StravaSyncFailed, err, nil, map[string]interface{}{
+			"component":      "strava",
+			"integration_id": integration.Id,
+			"imported":       imported,
+		})
+	}
+	if err := s.db.MarkIntegrationSynced(ctx, integration.Id, startedAt, syncErr); err != nil {
+		messages.Log(messages.StravaSyncRecordFailed, integration.Id, err)
+	}
+}
+
+// StartStravaSync periodically imports new activities for connections that have not been
+// synced within STRAVA_SYNC_INTERVAL (default 6h). Webhooks deliver most activities as they
+// are uploaded; the sync catches events that were missed.
+func (s *FiberServer) StartStravaSync(ctx context.Context) {
+	if !s.stravaAvailable() {
+		return
+	}
+	interval := getEnvDuration("STRAVA_SYNC_INTERVAL", 6*time.Hour)
+	s.runPeriodically(ctx, interval, func(ctx context.Context) {
+		s.syncDueStravaIntegrations(ctx, interval)
+	})
+}
+
+func (s *FiberServer) syncDueStravaIntegrations(ctx context.Context, interval time.Duration) {
+	listCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	due, err := s.db.ListIntegrationsDueForSync(listCtx, integrationStrava, time.Now().Add(-interval), 100)
+	cancel()
+	if err != nil {
+		s.logError("ERROR", messages.StravaSyncFailed, err, nil, map[string]interface{}{
+			"component": "strava",
+		})
+		return
+	}
+
+	for _, integration := range due {
+		if ctx.Err() != nil {
+			return
+		}
+		s.runStravaSync(integration)
+	}
+}
@@ -0,0 +1,269 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"fitness-hack/internal/database"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	// suggestionCandidates is how many of the user's workouts a suggestion considers
+	suggestionCandidates = 50
+
+	// A muscle group counts as still recovering when fatiguedMuscleSets or more sets worked
+	// it in the last muscleRecoveryWindow
+	fatiguedMuscleSets   = 6
+	muscleRecoveryWindow = 48 * time.Hour
+
+	// Readiness drops by shortSleepPenalty when the user logged under shortSleepHours of
+	// sleep last night
+	shortSleepHours   = 6
+	shortSleepPenalty = 15
+
+	// cautionVolumePercent is the share of its sets a workout keeps on a caution day
+	cautionVolumePercent = 67
+
+	// Time estimates give each rep secondsPerRep and each set at least minSetSeconds of work
+	secondsPerRep = 4
+	minSetSeconds = 20
+
+	minSuggestionMinutes = 10
+	maxSuggestionMinutes = 240
+)
+
+// readinessStatus is the status of a readiness score
+func readinessStatus(score int) string {
+	switch {
+	case score >= 70:
+		return "ready"
+	case score >= 40:
+		return "caution"
+	default:
+		return "rest"
+	}
+}
+
+// exerciseSetSeconds estimates how long one set of the planned exercise and its rest take
+func exerciseSetSeconds(planned database.PlannedExerciseResponse) int {
+	work := planned.DurationSeconds
+	if work <= 0 {
+		work = max(planned.Reps*secondsPerRep, minSetSeconds)
+	}
+	return work + planned.RestSeconds
+}
+
+// planMinutes estimates how many minutes the plan takes, rounded up
+func planMinutes(plan []database.PlannedExerciseResponse) int {
+	seconds := 0
+	for _, planned := range plan {
+		seconds += planned.Sets * exerciseSetSeconds(planned)
+	}
+	return (seconds + 59) / 60
+}
+
+// shortenPlan cuts sets from the plan until it fits in the minutes, taking them from the
+// exercises with the most sets first. Once every exercise is down to one set it drops
+// exercises from the end, always keeping the first.
+func shortenPlan(plan []database.PlannedExerciseResponse, minutes int) []database.PlannedExerciseResponse {
+	for planMinutes(plan) > minutes {
+		most := -1
+		for i, planned := range plan {
+			if planned.Sets > 1 && (most < 0 || planned.Sets >= plan[most].Sets) {
+				most = i
+			}
+		}
+		switch {
+		case most >= 0:
+			plan[most].Sets--
+		case len(plan) > 1:
+			plan = plan[:len(plan)-1]
+		default:
+			return plan
+		}
+	}
+	return plan
+}
+
+// chooseSuggestion picks the candidate to suggest: the first, in schedule order, that
+// works none of the fatigued muscle groups, or failing that the one that works the fewest.
+// It returns the candidate's index with the reasons it was picked.
+func chooseSuggestion(candidates []database.SuggestionCandidate, muscles map[string][]database.WorkoutMuscle, fatigued map[string]bool, now time.Time) (int, []string) {
+	recovering := func(workoutID string) []string {
+		names := []string{}
+		for _, muscle := range muscles[workoutID] {
+			if fatigued[muscle.Slug] {
+				names = append(names, strings.ToLower(muscle.Name))
+			}
+		}
+		return names
+	}
+
+	chosen, fewest := 0, -1
+	for i, candidate := range candidates {
+		n := len(recovering(candidate.Id))
+		if fewest < 0 || n < fewest {
+			chosen, fewest = i, n
+		}
+		if n == 0 {
+			break
+		}
+	}
+
+	candidate := candidates[chosen]
+	reasons := []string{}
+	if candidate.In_active_program {
+		reasons = append(reasons, "It's part of your active program")
+	}
+	if candidate.Last_done_at == nil {
+		reasons = append(reasons, "You haven't done this workout yet")
+	} else if days := int(now.Sub(*candidate.Last_done_at).Hours() / 24); days == 1 {
+		reasons = append(reasons, "You last did this workout yesterday")
+	} else if days > 1 {
+		reasons = append(reasons, fmt.Sprintf("You last did this workout %d days ago", days))
+	}
+	if fewest > 0 {
+		reasons = append(reasons, "Every workout works muscles you trained hard in the last two days, this one the fewest: "+strings.Join(recovering(candidate.Id), ", "))
+	} else if chosen > 0 {
+		first := candidates[0]
+		reasons = append(reasons, fmt.Sprintf("Picked over %s, which works muscles you trained hard in the last two days: %s", first.Name, strings.Join(recovering(first.Id), ", ")))
+	}
+	return chosen, reasons
+}
+
+// GET /api/v1/users/me/suggestion
+func (s *FiberServer) getWorkoutSuggestion(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	minutes := 0
+	if raw := c.Query("minutes"); raw != "" {
+		minutes, err = strconv.Atoi(raw)
+		if err != nil || minutes < minSuggestionMinutes || minutes > maxSuggestionMinutes {
+			return errorResponse(c, fiber.StatusBadRequest, fmt.Sprintf("minutes must be between %d and %d", minSuggestionMinutes, maxSuggestionMinutes))
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tz, err := s.userTimezone(ctx, c, userID)
+	if err != nil {
+		LogDatabaseError(s, "get_user", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to get suggestion")
+	}
+	now := time.Now()
+	today, _, err := summaryDay("", tz, now)
+	if err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	loads, err := s.db.ListSessionLoads(ctx, userID, now.AddDate(0, 0, -7*chronicLoadWeeks))
+	if err != nil {
+		LogDatabaseError(s, "list_session_loads", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to get suggestion")
+	}
+	readiness := computeReadiness(loads, now)
+
+	// Sleep is logged on the day it ends, so today's entry is last night's
+	habits, err := s.db.GetDailyHabits(ctx, userID, today)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		LogDatabaseError(s, "get_daily_habits", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to get suggestion")
+	}
+	if habits != nil && habits.Sleep_hours != nil && habits.Sleep_hours.LessThan(decimal.NewFromInt(shortSleepHours)) {
+		readiness.Score -= shortSleepPenalty
+		readiness.Reasons = append(readiness.Reasons, fmt.Sprintf("You slept under %d hours last night", shortSleepHours))
+		readiness.Status = readinessStatus(readiness.Score)
+	}
+
+	suggestion := database.WorkoutSuggestionResponse{Kind: "rest", Readiness: readiness, Reasons: []string{}}
+	if readiness.Status == "rest" {
+		suggestion.Reasons = append(suggestion.Reasons, "Your readiness is low, so take a rest day")
+		return successResponse(c, suggestion)
+	}
+
+	candidates, err := s.db.ListSuggestionCandidates(ctx, userID, suggestionCandidates)
+	if err != nil {
+		LogDatabaseError(s, "list_suggestion_candidates", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to get suggestion")
+	}
+	if len(candidates) == 0 {
+		return errorResponse(c, fiber.StatusNotFound, "No workouts to suggest; create a workout first")
+	}
+
+	workoutIDs := make([]string, len(candidates))
+	for i, candidate := range candidates {
+		workoutIDs[i] = candidate.Id
+	}
+	workoutMuscles, err := s.db.ListWorkoutMuscles(ctx, workoutIDs)
+	if err != nil {
+		LogDatabaseError(s, "list_workout_muscles", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to get suggestion")
+	}
+	muscles := map[string][]database.WorkoutMuscle{}
+	for _, muscle := range workoutMuscles {
+		muscles[muscle.Workout_id] = append(muscles[muscle.Workout_id], muscle)
+	}
+	recent, err := s.db.ListRecentMuscleSets(ctx, userID, now.Add(-muscleRecoveryWindow))
+	if err != nil {
+		LogDatabaseError(s, "list_recent_muscle_sets", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to get suggestion")
+	}
+	fatigued := map[string]bool{}
+	for _, muscle := range recent {
+		fatigued[muscle.Slug] = muscle.Sets >= fatiguedMuscleSets
+	}
+
+	chosen, reasons := chooseSuggestion(candidates, muscles, fatigued, now)
+	workout := candidates[chosen]
+	plan, err := s.resolveSessionPlan(ctx, userID, workout.Id, now)
+	if err != nil {
+		LogDatabaseError(s, "resolve_session_plan", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to get suggestion")
+	}
+
+	suggested := &database.SuggestedWorkoutResponse{
+		ID:         workout.Id,
+		Name:       workout.Name,
+		ProgramID:  workout.Program_id,
+		LastDoneAt: workout.Last_done_at,
+		Plan:       plan,
+	}
+	suggested.FullMinutes = planMinutes(plan)
+	if len(plan) == 0 {
+		suggested.FullMinutes = workout.Duration_minutes
+	}
+	if readiness.Status == "caution" {
+		adjustPlanForWeek(suggested.Plan, cautionVolumePercent, false)
+		suggested.Adjusted = true
+		reasons = append(reasons, "Your readiness is fair, so sets are cut by a third")
+	}
+	if minutes > 0 && len(plan) > 0 && planMinutes(suggested.Plan) > minutes {
+		suggested.Plan = shortenPlan(suggested.Plan, minutes)
+		suggested.Adjusted = true
+		reasons = append(reasons, fmt.Sprintf("Shortened to fit in %d minutes", minutes))
+	}
+	suggested.EstimatedMinutes = suggested.FullMinutes
+	if len(plan) > 0 {
+		suggested.EstimatedMinutes = planMinutes(suggested.Plan)
+	}
+	if minutes > 0 && suggested.EstimatedMinutes > minutes {
+		reasons = append(reasons, fmt.Sprintf("Even cut down, this workout takes about %d minutes", suggested.EstimatedMinutes))
+	}
+
+	suggestion.Kind = "workout"
+	suggestion.Workout = suggested
+	suggestion.Reasons = reasons
+	return successResponse(c, suggestion)
+}
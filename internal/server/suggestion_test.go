@@ -0,0 +1,61 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"fitness-hack/internal/database"
+)
+
+func TestShortenPlan(t *testing.T) {
+	// 4 x 5 squats at 20s + 120s rest is 560s, 3 x 10 rows at 40s + 60s rest is 300s
+	plan := []database.PlannedExerciseResponse{
+		{ExerciseID: "squat", Sets: 4, Reps: 5, RestSeconds: 120},
+		{ExerciseID: "row", Sets: 3, Reps: 10, RestSeconds: 60},
+		{ExerciseID: "plank", Sets: 2, DurationSeconds: 60},
+	}
+	if got := planMinutes(plan); got != 17 {
+		t.Fatalf("expected 17 minutes, got %d", got)
+	}
+
+	short := shortenPlan(append([]database.PlannedExerciseResponse{}, plan...), 12)
+	if got := planMinutes(short); got > 12 {
+		t.Errorf("expected the plan to fit 12 minutes, got %d", got)
+	}
+	if len(short) != 3 || short[0].Sets != 2 || short[1].Sets != 2 || short[2].Sets != 2 {
+		t.Errorf("expected sets cut from the biggest exercises first, got %+v", short)
+	}
+
+	short = shortenPlan(append([]database.PlannedExerciseResponse{}, plan...), 3)
+	if len(short) != 1 || short[0].Sets != 1 {
+		t.Errorf("expected a single set of the first exercise, got %+v", short)
+	}
+}
+
+func TestChooseSuggestion(t *testing.T) {
+	now := time.Date(2025, 8, 26, 12, 0, 0, 0, time.UTC)
+	lastDone := now.AddDate(0, 0, -3)
+	candidate := func(id string, lastDone *time.Time) database.SuggestionCandidate {
+		c := database.SuggestionCandidate{Last_done_at: lastDone, In_active_program: true}
+		c.Id, c.Name = id, id
+		return c
+	}
+	candidates := []database.SuggestionCandidate{candidate("legs", nil), candidate("pull", &lastDone)}
+	muscles := map[string][]database.WorkoutMuscle{
+		"legs": {{Workout_id: "legs", Slug: "quadriceps", Name: "Quadriceps"}},
+		"pull": {{Workout_id: "pull", Slug: "lats", Name: "Lats"}},
+	}
+
+	if chosen, reasons := chooseSuggestion(candidates, muscles, map[string]bool{}, now); chosen != 0 || len(reasons) != 2 {
+		t.Errorf("expected the next workout in the schedule, got %d %v", chosen, reasons)
+	}
+
+	chosen, reasons := chooseSuggestion(candidates, muscles, map[string]bool{"quadriceps": true}, now)
+	if chosen != 1 || len(reasons) != 3 || reasons[1] != "You last did this workout 3 days ago" {
+		t.Errorf("expected legs to be skipped while the quads recover, got %d %v", chosen, reasons)
+	}
+
+	if chosen, _ := chooseSuggestion(candidates, muscles, map[string]bool{"quadriceps": true, "lats": true}, now); chosen != 0 {
+		t.Errorf("expected the first workout when everything is recovering, got %d", chosen)
+	}
+}
@@ -0,0 +1,409 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"regexp"
+	"time"
+
+	"fitness-hack/internal/database"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/shopspring/decimal"
+)
+
+// prescriptionRoundingKg is what percentage loads are rounded to: the smallest jump with
+// 1.25 kg plates on each side of the bar
+var prescriptionRoundingKg = decimal.NewFromFloat(2.5)
+
+// maxPrescriptionPercent caps prescriptions at twice the training max, well above any
+// overload set, to catch percentages sent as 75 instead of 0.75
+const maxPrescriptionPercent = 2.0
+
+// trainingMaxFactor is the share of an estimated one-rep max that suggestions use as the
+// training max, following 5/3/1
+var trainingMaxFactor = decimal.NewFromFloat(0.9)
+
+// maxEstimateReps caps the reps one-rep maxes are estimated from. Epley's formula
+// overestimates long sets, so they are counted as if they stopped here.
+const maxEstimateReps = 12
+
+// trainingMaxNamePattern matches the names prescriptions refer to training maxes by, such as squat_1rm
+var trainingMaxNamePattern = regexp.MustCompile(`^[a-z0-9_]{1,64}$`)
+
+// Helper to convert database training max to response model
+func trainingMaxToResponse(tm *database.Training_maxes) database.TrainingMaxResponse {
+	return database.TrainingMaxResponse{
+		ID:         tm.Id,
+		Name:       tm.Name,
+		ExerciseID: tm.Exercise_id,
+		WeightKg:   tm.Weight_kg.InexactFloat64(),
+		CreatedAt:  tm.Created_at,
+		UpdatedAt:  tm.Updated_at,
+	}
+}
+
+// validateWeightPrescription checks a prescription sent for a workout exercise. An empty
+// percent_of means the exercise has a fixed weight.
+func validateWeightPrescription(p *database.WeightPrescription) error {
+	if p == nil || p.PercentOf == "" {
+		return nil
+	}
+	if !trainingMaxNamePattern.MatchString(p.PercentOf) {
+		return errors.New("weightPrescription.percent_of must be 1-64 lowercase letters, digits or underscores")
+	}
+	if p.Value <= 0 || p.Value > maxPrescriptionPercent {
+		return errors.New("weightPrescription.value must be a fraction of the training max, greater than 0 and at most 2")
+	}
+	return nil
+}
+
+// setWeightPrescription stores p on the workout exercise, or clears it when p has no percent_of
+func setWeightPrescription(we *database.Workout_exercises, p *database.WeightPrescription) {
+	if p == nil || p.PercentOf == "" {
+		we.Percent_of = ""
+		we.Percent_value = decimal.Zero
+		return
+	}
+	we.Percent_of = p.PercentOf
+	we.Percent_value = decimal.NewFromFloat(p.Value).Round(3)
+}
+
+// weightPrescriptionOf returns the workout exercise's prescription, nil for a fixed weight
+func weightPrescriptionOf(we *database.Workout_exercises) *database.WeightPrescription {
+	if we.Percent_of == "" {
+		return nil
+	}
+	return &database.WeightPrescription{PercentOf: we.Percent_of, Value: we.Percent_value.InexactFloat64()}
+}
+
+// resolvePlan resolves the workout exercises' prescriptions against the user's training
+// maxes. Exercises without a prescription, or whose training max the user hasn't set,
+// keep their fixed weight.
+func resolvePlan(exercises []database.Workout_exercises, maxes []database.Training_maxes) []database.PlannedExerciseResponse {
+	byName := make(map[string]decimal.Decimal, len(maxes))
+	for _, tm := range maxes {
+		byName[tm.Name] = tm.Weight_kg
+	}
+
+	plan := make([]database.PlannedExerciseResponse, len(exercises))
+	for i := range exercises {
+		we := &exercises[i]
+		planned := database.PlannedExerciseResponse{
+			WorkoutExerciseID:  we.Id,
+			ExerciseID:         we.Exercise_id,
+			OrderIndex:         we.Order_index,
+			Sets:               we.Sets,
+			Reps:               we.Reps,
+			WeightKg:           we.Weight_kg.InexactFloat64(),
+			DurationSeconds:    we.Duration_seconds,
+			RestSeconds:        we.Rest_seconds,
+			Notes:              we.Notes,
+			WeightPrescription: weightPrescriptionOf(we),
+		}
+		if trainingMax, ok := byName[we.Percent_of]; ok && we.Percent_of != "" {
+			load := trainingMax.Mul(we.Percent_value).Div(prescriptionRoundingKg).Round(0).Mul(prescriptionRoundingKg)
+			planned.WeightKg = load.InexactFloat64()
+			trainingMaxKg := trainingMax.InexactFloat64()
+			planned.TrainingMaxKg = &trainingMaxKg
+			if load.GreaterThanOrEqual(defaultBarKg) {
+				plates, _ := platesPerSide(load, defaultBarKg, standardPlatesKg)
+				planned.PlatesPerSideKg = decimalsToFloats(plates)
+			}
+		}
+		plan[i] = planned
+	}
+	return plan
+}
+
+// estimateOneRepMax estimates the most weight that could be lifted once from a set taken
+// to failure, using Epley's formula
+func estimateOneRepMax(weightKg decimal.Decimal, reps int) decimal.Decimal {
+	if reps > maxEstimateReps {
+		reps = maxEstimateReps
+	}
+	if reps <= 1 {
+		return weightKg
+	}
+	return weightKg.Mul(decimal.NewFromInt(int64(30 + reps))).Div(decimal.NewFromInt(30))
+}
+
+// suggestTrainingMaxes suggests new training maxes from the AMRAP sets logged since each was
+// last saved, keyed by training max name. The best set by estimated one-rep max counts, and
+// the suggestion is trainingMaxFactor of its estimate rounded down to prescriptionRoundingKg,
+// which may be lower than the current training max after a poor set. Sets from sessions
+// rated hardSessionRPE or above, or with pain reported, never raise a training max. Training
+// maxes the suggestion wouldn't change are left out.
+func suggestTrainingMaxes(maxes []database.Training_maxes, sets []database.AMRAPSet) map[string]*database.TrainingMaxSuggestion {
+	current := make(map[string]decimal.Decimal, len(maxes))
+	for _, tm := range maxes {
+		current[tm.Name] = tm.Weight_kg
+	}
+
+	best := map[string]decimal.Decimal{}
+	suggestions := map[string]*database.TrainingMaxSuggestion{}
+	for i := range sets {
+		set := &sets[i]
+		estimate := estimateOneRepMax(set.Weight_kg, set.Reps)
+		suggested := estimate.Mul(trainingMaxFactor).Div(prescriptionRoundingKg).Floor().Mul(prescriptionRoundingKg)
+		weight, ok := current[set.TrainingMaxName]
+		strained := set.PainFlagged || set.SessionRPE != nil && *set.SessionRPE >= hardSessionRPE
+		if strained && suggested.GreaterThan(weight) {
+			continue
+		}
+		if previous, ok := best[set.TrainingMaxName]; ok && !estimate.GreaterThan(previous) {
+			continue
+		}
+		best[set.TrainingMaxName] = estimate
+
+		if !ok || suggested.Sign() <= 0 || suggested.Equal(weight) {
+			delete(suggestions, set.TrainingMaxName)
+			continue
+		}
+		suggestions[set.TrainingMaxName] = &database.TrainingMaxSuggestion{
+			WeightKg:             suggested.InexactFloat64(),
+			EstimatedOneRepMaxKg: estimate.Round(2).InexactFloat64(),
+			Set:                  workoutSessionSetToResponse(&set.Workout_session_sets),
+		}
+	}
+	return suggestions
+}
+
+// trainingMaxSuggestions returns the user's current training max suggestions by name
+func (s *FiberServer) trainingMaxSuggestions(ctx context.Context, userID string, maxes []database.Training_maxes) (map[string]*database.TrainingMaxSuggestion, error) {
+	sets, err := s.db.ListAMRAPSets(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return suggestTrainingMaxes(maxes, sets), nil
+}
+
+// sessionPlan resolves the plan of a session of the workout started at startedAt, with the
+// volume and deload of the program week it falls in
+func (s *FiberServer) sessionPlan(ctx context.Context, userID, workoutID string, startedAt time.Time) (json.RawMessage, error) {
+	plan, err := s.resolveSessionPlan(ctx, userID, workoutID, startedAt)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(plan)
+}
+
+// resolveSessionPlan is sessionPlan before encoding
+func (s *FiberServer) resolveSessionPlan(ctx context.Context, userID, workoutID string, startedAt time.Time) ([]database.PlannedExerciseResponse, error) {
+	exercises, err := s.db.ListWorkoutExercises(ctx, database.ListOptions{
+		Limit:   500,
+		Sort:    "order_index",
+		Filters: map[string]string{"workout_id": workoutID},
+	})
+	if err != nil {
+		return nil, err
+	}
+	maxes, err := s.db.ListTrainingMaxes(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	plan := resolvePlan(exercises, maxes)
+	if err := s.adjustSessionPlan(ctx, plan, workoutID, startedAt); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// GET /api/v1/training-maxes
+func (s *FiberServer) listTrainingMaxes(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	maxes, err := s.db.ListTrainingMaxes(ctx, userID)
+	if err != nil {
+		LogDatabaseError(s, "list_training_maxes", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to list training maxes")
+	}
+	suggestions, err := s.trainingMaxSuggestions(ctx, userID, maxes)
+	if err != nil {
+		LogDatabaseError(s, "list_amrap_sets", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to list training maxes")
+	}
+
+	responses := make([]database.TrainingMaxResponse, len(maxes))
+	for i := range maxes {
+		responses[i] = trainingMaxToResponse(&maxes[i])
+		responses[i].Suggestion = suggestions[maxes[i].Name]
+	}
+	return successResponse(c, responses)
+}
+
+// GET /api/v1/training-maxes/:name
+func (s *FiberServer) getTrainingMax(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tm, err := s.db.GetTrainingMax(ctx, userID, c.Params("name"))
+	if errors.Is(err, sql.ErrNoRows) {
+		return notFound(c, "Training max")
+	}
+	if err != nil {
+		LogDatabaseError(s, "get_training_max", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to get training max")
+	}
+	suggestions, err := s.trainingMaxSuggestions(ctx, userID, []database.Training_maxes{*tm})
+	if err != nil {
+		LogDatabaseError(s, "list_amrap_sets", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to get training max")
+	}
+
+	response := trainingMaxToResponse(tm)
+	response.Suggestion = suggestions[tm.Name]
+	return successResponse(c, response)
+}
+
+// PUT /api/v1/training-maxes/:name
+func (s *FiberServer) putTrainingMax(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	name := c.Params("name")
+	if !trainingMaxNamePattern.MatchString(name) {
+		return errorResponse(c, fiber.StatusBadRequest, "Training max name must be 1-64 lowercase letters, digits or underscores")
+	}
+
+	var req database.PutTrainingMaxRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+	if req.WeightKg <= 0 || req.WeightKg >= 10000 {
+		return errorResponse(c, fiber.StatusBadRequest, "weightKg must be greater than 0 and less than 10000")
+	}
+	if req.ExerciseID != nil && *req.ExerciseID == "" {
+		req.ExerciseID = nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	saved, err := s.db.UpsertTrainingMax(ctx, &database.Training_maxes{
+		User_id:     userID,
+		Name:        name,
+		Exercise_id: req.ExerciseID,
+		Weight_kg:   decimal.NewFromFloat(req.WeightKg).Round(2),
+	}, database.TrainingMaxSourceManual)
+	if err != nil {
+		LogDatabaseError(s, "upsert_training_max", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to save training max")
+	}
+	return successResponse(c, trainingMaxToResponse(saved))
+}
+
+// POST /api/v1/training-maxes/:name/accept-suggestion
+func (s *FiberServer) acceptTrainingMaxSuggestion(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tm, err := s.db.GetTrainingMax(ctx, userID, c.Params("name"))
+	if errors.Is(err, sql.ErrNoRows) {
+		return notFound(c, "Training max")
+	}
+	if err != nil {
+		LogDatabaseError(s, "get_training_max", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to accept suggestion")
+	}
+
+	// The suggestion is worked out again rather than taken from the client, so only what the
+	// logged sets support can be saved as one
+	suggestions, err := s.trainingMaxSuggestions(ctx, userID, []database.Training_maxes{*tm})
+	if err != nil {
+		LogDatabaseError(s, "list_amrap_sets", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to accept suggestion")
+	}
+	suggestion := suggestions[tm.Name]
+	if suggestion == nil {
+		return errorResponse(c, fiber.StatusConflict, "There is no suggested update for this training max")
+	}
+
+	tm.Weight_kg = decimal.NewFromFloat(suggestion.WeightKg)
+	saved, err := s.db.UpsertTrainingMax(ctx, tm, database.TrainingMaxSourceSuggestion)
+	if err != nil {
+		LogDatabaseError(s, "upsert_training_max", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to accept suggestion")
+	}
+	return successResponse(c, trainingMaxToResponse(saved))
+}
+
+// GET /api/v1/training-maxes/:name/history
+func (s *FiberServer) listTrainingMaxHistory(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tm, err := s.db.GetTrainingMax(ctx, userID, c.Params("name"))
+	if errors.Is(err, sql.ErrNoRows) {
+		return notFound(c, "Training max")
+	}
+	if err != nil {
+		LogDatabaseError(s, "get_training_max", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to list training max history")
+	}
+
+	history, err := s.db.ListTrainingMaxHistory(ctx, tm.Id, getListOptions(c))
+	if errors.Is(err, database.ErrInvalidListOption) {
+		return errorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+	if err != nil {
+		LogDatabaseError(s, "list_training_max_history", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to list training max history")
+	}
+
+	responses := make([]database.TrainingMaxHistoryResponse, len(history))
+	for i, h := range history {
+		responses[i] = database.TrainingMaxHistoryResponse{
+			WeightKg:   h.Weight_kg.InexactFloat64(),
+			Source:     string(h.Source),
+			RecordedAt: h.Recorded_at,
+		}
+	}
+	return successResponse(c, responses)
+}
+
+// DELETE /api/v1/training-maxes/:name
+func (s *FiberServer) deleteTrainingMax(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err = s.db.DeleteTrainingMax(ctx, userID, c.Params("name"))
+	if errors.Is(err, sql.ErrNoRows) {
+		return notFound(c, "Training max")
+	}
+	if err != nil {
+		LogDatabaseError(s, "delete_training_max", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to delete training max")
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,136 @@
+package server
+
+import (
+	"fmt"
+	"testing"
+
+	"fitness-hack/internal/database"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestResolvePlan(t *testing.T) {
+	exercises := []database.Workout_exercises{
+		{Id: "squat", Sets: 3, Reps: 5, Weight_kg: decimal.NewFromInt(60), Percent_of: "squat_1rm", Percent_value: decimal.RequireFromString("0.85")},
+		{Id: "bench", Sets: 3, Reps: 5, Weight_kg: decimal.NewFromInt(50), Percent_of: "bench_1rm", Percent_value: decimal.RequireFromString("0.75")},
+		{Id: "rows", Sets: 3, Reps: 10, Weight_kg: decimal.NewFromInt(40)},
+	}
+	maxes := []database.Training_maxes{
+		{Name: "squat_1rm", Weight_kg: decimal.RequireFromString("142.5")},
+	}
+
+	plan := resolvePlan(exercises, maxes)
+	// 85% of 142.5 is 121.125, which rounds to the nearest 2.5 kg
+	if plan[0].WeightKg != 120 || plan[0].TrainingMaxKg == nil || *plan[0].TrainingMaxKg != 142.5 {
+		t.Errorf("squat = %v kg from %v, want 120 kg from 142.5", plan[0].WeightKg, plan[0].TrainingMaxKg)
+	}
+	if got := plan[0].PlatesPerSideKg; len(got) != 2 || got[0] != 25 || got[1] != 25 {
+		t.Errorf("squat plates per side = %v, want [25 25]", got)
+	}
+	if plan[1].WeightKg != 50 || plan[1].TrainingMaxKg != nil || plan[1].WeightPrescription == nil {
+		t.Errorf("bench without a training max = %+v, want the fixed 50 kg with its prescription", plan[1])
+	}
+	if plan[2].WeightKg != 40 || plan[2].WeightPrescription != nil {
+		t.Errorf("rows = %+v, want the fixed 40 kg", plan[2])
+	}
+}
+
+func TestValidateWeightPrescription(t *testing.T) {
+	valid := []*database.WeightPrescription{
+		nil,
+		{PercentOf: ""},
+		{PercentOf: "squat_1rm", Value: 0.75},
+		{PercentOf: "deadlift_tm", Value: 1.1},
+	}
+	for _, p := range valid {
+		if err := validateWeightPrescription(p); err != nil {
+			t.Errorf("validateWeightPrescription(%+v) = %v", p, err)
+		}
+	}
+
+	invalid := []*database.WeightPrescription{
+		{PercentOf: "Squat 1RM", Value: 0.75},
+		{PercentOf: "squat_1rm"},
+		{PercentOf: "squat_1rm", Value: 75},
+	}
+	for _, p := range invalid {
+		if err := validateWeightPrescription(p); err == nil {
+			t.Errorf("validateWeightPrescription(%+v) succeeded", p)
+		}
+	}
+}
+
+func TestSuggestTrainingMaxes(t *testing.T) {
+	maxes := []database.Training_maxes{
+		{Name: "squat_tm", Weight_kg: decimal.NewFromInt(100)},
+		{Name: "bench_tm", Weight_kg: decimal.NewFromInt(80)},
+		{Name: "press_tm", Weight_kg: decimal.RequireFromString("52.5")},
+		{Name: "row_tm", Weight_kg: decimal.NewFromInt(60)},
+	}
+	amrap := func(name string, weightKg int64, reps int) database.AMRAPSet {
+		set := database.AMRAPSet{TrainingMaxName: name}
+		set.Weight_kg = decimal.NewFromInt(weightKg)
+		set.Reps = reps
+		return set
+	}
+	sets := []database.AMRAPSet{
+		amrap("squat_tm", 100, 5),
+		amrap("squat_tm", 100, 8),
+		amrap("bench_tm", 80, 3),
+		amrap("bench_tm", 70, 2),
+		amrap("press_tm", 50, 5),
+		amrap("row_tm", 60, 20),
+		amrap("deadlift_tm", 140, 5),
+	}
+
+	got := suggestTrainingMaxes(maxes, sets)
+	want := map[string]float64{
+		// 100 kg for 8 estimates 126.67 kg, 90% of which rounds down to 112.5 kg
+		"squat_tm": 112.5,
+		// A weaker set suggests lowering the training max
+		"bench_tm": 77.5,
+		// Reps past 12 don't count, so 60 kg for 20 estimates 84 kg
+		"row_tm": 75,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got suggestions for %d training maxes, want %d: %v", len(got), len(want), got)
+	}
+	for name, weightKg := range want {
+		if got[name] == nil || got[name].WeightKg != weightKg {
+			t.Errorf("%s suggestion = %+v, want %v kg", name, got[name], weightKg)
+		}
+	}
+	if got["squat_tm"] != nil && got["squat_tm"].Set.Reps != 8 {
+		t.Errorf("squat suggestion is based on the %d-rep set, want the 8-rep set", got["squat_tm"].Set.Reps)
+	}
+
+	// Sets from a very hard session or one with pain don't raise a training max, but can lower it
+	hardRPE := int16(hardSessionRPE)
+	for i := range sets {
+		sets[i].SessionRPE = &hardRPE
+	}
+	sets[2].SessionRPE, sets[2].PainFlagged = nil, true
+	got = suggestTrainingMaxes(maxes, sets)
+	if len(got) != 1 || got["bench_tm"] == nil || got["bench_tm"].WeightKg != 77.5 {
+		t.Errorf("got suggestions %v from strained sessions, want only lowering bench to 77.5 kg", got)
+	}
+}
+
+func TestPlatesPerSide(t *testing.T) {
+	tests := []struct {
+		loadKg    string
+		perSide   string
+		remainder string
+	}{
+		{"105", "[25 15 2.5]", "0"},
+		{"20", "[]", "0"},
+		// 43 kg can't be made up from the smallest plates, so 0.5 kg is left over
+		{"63", "[20 1.25]", "0.5"},
+	}
+	for _, tt := range tests {
+		perSide, remainder := platesPerSide(decimal.RequireFromString(tt.loadKg), defaultBarKg, standardPlatesKg)
+		if got := fmt.Sprint(decimalsToFloats(perSide)); got != tt.perSide || remainder.String() != tt.remainder {
+			t.Errorf("platesPerSide(%s) = %s with %s kg left, want %s with %s kg left", tt.loadKg, got, remainder, tt.perSide, tt.remainder)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"fitness-hack/internal/database"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	// sessionTokenTTL is how long tokens issued at sign-in stay valid
+	sessionTokenTTL = 24 * time.Hour
+
+	// sessionSeenInterval is how often a session's last seen time is written back, so busy
+	// clients don't cost a database write per request
+	sessionSeenInterval = 5 * time.Minute
+
+	// maxUserAgentLength bounds the user agent stored for a session
+	maxUserAgentLength = 512
+)
+
+// sessionSeenKey is the Redis key held while a session's last seen time is fresh
+func sessionSeenKey(jti string) string {
+	return "auth:seen:" + jti
+}
+
+// Helper to convert database user session to response model
+func userSessionToResponse(session *database.User_sessions, currentTokenID string) database.UserSessionResponse {
+	return database.UserSessionResponse{
+		ID:         session.Id,
+		UserAgent:  session.User_agent,
+		IPAddress:  session.Ip_address,
+		Current:    session.Token_id == currentTokenID,
+		CreatedAt:  session.Created_at,
+		LastSeenAt: session.Last_seen_at,
+		ExpiresAt:  session.Expires_at,
+	}
+}
+
+// issueSessionToken signs a token for the user and records the device it was issued to, so
+// it shows among their sessions and can be revoked from another device
+func (s *FiberServer) issueSessionToken(ctx context.Context, c *fiber.Ctx, userID string, expiresAt time.Time, extra jwt.MapClaims) (string, error) {
+	jti := uuid.NewString()
+	claims := jwt.MapClaims{"jti": jti}
+	for key, value := range extra {
+		claims[key] = value
+	}
+	token, err := generateJWTWithClaims(userID, expiresAt, claims)
+	if err != nil {
+		return "", err
+	}
+
+	// Fiber reuses the request's memory, so the session keeps copies
+	userAgent := utils.CopyString(c.Get(fiber.HeaderUserAgent))
+	if len(userAgent) > maxUserAgentLength {
+		userAgent = userAgent[:maxUserAgentLength]
+	}
+	if _, err := s.db.CreateUserSession(ctx, &database.User_sessions{
+		User_id:    userID,
+		Token_id:   jti,
+		User_agent: userAgent,
+		Ip_address: utils.CopyString(c.IP()),
+		Expires_at: expiresAt,
+	}); err != nil {
+		LogDatabaseError(s, "create_user_session", err, c)
+		return "", err
+	}
+	return token, nil
+}
+
+// touchUserSession updates the last seen time and address of the token's session, at most
+// once per sessionSeenInterval. Without Redis to throttle the writes it is skipped.
+func (s *FiberServer) touchUserSession(ctx context.Context, c *fiber.Ctx, claims jwt.MapClaims) {
+	jti, _ := claims["jti"].(string)
+	if jti == "" || s.cache == nil {
+		return
+	}
+
+	fresh, err := s.cache.SetNX(ctx, sessionSeenKey(jti), "1", sessionSeenInterval).Result()
+	if err != nil {
+		LogCacheError(s, "touch_user_session", err, c)
+		return
+	}
+	if !fresh {
+		return
+	}
+	if err := s.db.TouchUserSession(ctx, jti, c.IP()); err != nil && !errors.Is(err, sql.ErrNoRows) {
+		LogDatabaseError(s, "touch_user_session", err, c)
+	}
+}
+
+// GET /api/v1/users/me/sessions
+func (s *FiberServer) listMySessions(c *fiber.Ctx) error {
+	claims, err := getJWTClaims(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+	currentTokenID, _ := claims["jti"].(string)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	sessions, err := s.db.ListUserSessions(ctx, userID)
+	if err != nil {
+		LogDatabaseError(s, "list_user_sessions", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to fetch sessions")
+	}
+
+	response := make([]database.UserSessionResponse, len(sessions))
+	for i := range sessions {
+		response[i] = userSessionToResponse(&sessions[i], currentTokenID)
+	}
+
+	return successResponse(c, response)
+}
+
+// DELETE /api/v1/users/me/sessions/:sessionId
+// Signs the device out: its token joins the revocation list checked by rejectRevokedTokens.
+func (s *FiberServer) revokeMySession(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	session, err := s.db.RevokeUserSession(ctx, c.Params("sessionId"), userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return notFound(c, "Session")
+		}
+		LogDatabaseError(s, "revoke_user_session", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to revoke session")
+	}
+
+	claims := jwt.MapClaims{"jti": session.Token_id, "exp": float64(session.Expires_at.Unix())}
+	if err := s.revokeToken(ctx, claims); err != nil {
+		LogCacheError(s, "revoke_token", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to revoke session")
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,86 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"fitness-hack/internal/database"
+	"fitness-hack/internal/memstore"
+)
+
+func TestUserSessionsRevokeDevices(t *testing.T) {
+	s, db := newFakeServer(t)
+	s.cache = memstore.New().Client()
+	hash, err := hashPassword("old-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.CreateUser(context.Background(), &database.Users{Email: "ann@example.com", Username: "ann", Password_hash: hash}); err != nil {
+		t.Fatal(err)
+	}
+
+	login := func(userAgent string) string {
+		t.Helper()
+		req := httptest.NewRequest("POST", "/api/v1/auth/login", strings.NewReader(`{"email":"ann@example.com","password":"old-secret"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("User-Agent", userAgent)
+		resp, err := s.App.Test(req, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var envelope struct{ Data database.LoginResponse }
+		json.NewDecoder(resp.Body).Decode(&envelope)
+		if resp.StatusCode != 200 || envelope.Data.Token == "" {
+			t.Fatalf("expected to log in, got %d", resp.StatusCode)
+		}
+		return "Bearer " + envelope.Data.Token
+	}
+	do := func(method, path, auth string) (int, []database.UserSessionResponse) {
+		t.Helper()
+		req := httptest.NewRequest(method, "/api/v1/users/me/sessions"+path, nil)
+		req.Header.Set("Authorization", auth)
+		resp, err := s.App.Test(req, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var envelope struct {
+			Data []database.UserSessionResponse
+		}
+		json.NewDecoder(resp.Body).Decode(&envelope)
+		return resp.StatusCode, envelope.Data
+	}
+
+	phone, laptop := login("Phone/1.0"), login("Laptop/2.0")
+	status, sessions := do("GET", "", phone)
+	if status != 200 || len(sessions) != 2 {
+		t.Fatalf("expected both devices listed, got %d %+v", status, sessions)
+	}
+	var laptopID string
+	for _, session := range sessions {
+		if session.Current != (session.UserAgent == "Phone/1.0") {
+			t.Errorf("expected only the phone marked current, got %+v", session)
+		}
+		if session.UserAgent == "Laptop/2.0" {
+			laptopID = session.ID
+		}
+	}
+
+	if status, _ := do("DELETE", "/"+laptopID, bearer(t, "someone-else")); status != 404 {
+		t.Errorf("expected another user's session to be 404, got %d", status)
+	}
+	if status, _ := do("DELETE", "/"+laptopID, phone); status != 204 {
+		t.Fatalf("expected the laptop signed out, got %d", status)
+	}
+	if status, _ := do("GET", "", laptop); status != 401 {
+		t.Errorf("expected the laptop's token rejected, got %d", status)
+	}
+	if status, sessions := do("GET", "", phone); status != 200 || len(sessions) != 1 || !sessions[0].Current {
+		t.Errorf("expected only the phone left, got %d %+v", status, sessions)
+	}
+	if status, _ := do("DELETE", "/"+laptopID, phone); status != 404 {
+		t.Errorf("expected a revoked session to be 404, got %d", status)
+	}
+}
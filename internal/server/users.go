@@ -3,14 +3,18 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"time"
 
 	"fitness-hack/internal/database"
+	"fitness-hack/internal/messages"
+	"fitness-hack/internal/password"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -19,16 +23,47 @@ func userCacheKey(id string) string {
 	return fmt.Sprintf("user:%s", id)
 }
 
-func usersListCacheKey(limit, offset int) string {
-	return fmt.Sprintf("users:list:%d:%d", limit, offset)
+func usersListCacheKey(opts database.ListOptions) string {
+	return fmt.Sprintf("users:list:%d:%d:%s:%s", opts.Limit, opts.Offset, opts.Sort, opts.Order)
+}
+
+// bcryptCost is the cost passwords are hashed with, BCRYPT_COST (default 10). Hashes made
+// at another cost keep working after it changes.
+func bcryptCost() int {
+	cost := getEnvInt("BCRYPT_COST", bcrypt.DefaultCost)
+	if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+		return bcrypt.DefaultCost
+	}
+	return cost
 }
 
 // Helper to hash password
 func hashPassword(password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcryptCost())
 	return string(bytes), err
 }
 
+// validateNewPassword checks a password a user chose against the password policy and,
+// when PASSWORD_BREACH_CHECK is set, the passwords known from data breaches. The returned
+// error is fit to show the user. A breach check that fails lets the password through.
+func (s *FiberServer) validateNewPassword(ctx context.Context, c *fiber.Ctx, pw string) error {
+	if err := password.PolicyFromEnv().Check(pw); err != nil {
+		return err
+	}
+	if s.breaches == nil {
+		return nil
+	}
+	breached, err := s.breaches.Breached(ctx, pw)
+	if err != nil {
+		LogError(s, "WARN", messages.AuthBreachCheckFailed, err, c, map[string]interface{}{"component": "auth"})
+		return nil
+	}
+	if breached {
+		return errors.New("password has appeared in a data breach; choose another")
+	}
+	return nil
+}
+
 // Helper to check password
 func checkPasswordHash(password, hash string) bool {
 	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
@@ -36,55 +71,70 @@ func checkPasswordHash(password, hash string) bool {
 
 // Helper to generate JWT
 func generateJWT(userID string) (string, error) {
+	return generateJWTWithClaims(userID, time.Now().Add(sessionTokenTTL), nil)
+}
+
+// Helper to generate JWT with a custom expiry and additional claims
+func generateJWTWithClaims(userID string, expiresAt time.Time, extra jwt.MapClaims) (string, error) {
 	secret := os.Getenv("JWT_SECRET")
 	claims := jwt.MapClaims{
 		"user_id": userID,
-		"exp":     time.Now().Add(24 * time.Hour).Unix(),
+		"jti":     uuid.NewString(),
+		"iat":     time.Now().Unix(),
+		"exp":     expiresAt.Unix(),
+	}
+	for key, value := range extra {
+		claims[key] = value
 	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString([]byte(secret))
 }
 
-// Helper to convert database user to response model
-func userToResponse(user *database.Users) database.UserResponse {
-	// Handle type assertions safely
-	var email string
-	if user.Email != nil {
-		if str, ok := user.Email.(string); ok {
-			email = str
-		}
-	}
-
-	var username string
-	if user.Username != nil {
-		if str, ok := user.Username.(string); ok {
-			username = str
-		}
-	}
-
-	var firstName string
-	if user.First_name != nil {
-		if str, ok := user.First_name.(string); ok {
-			firstName = str
-		}
-	}
-
-	var lastName string
-	if user.Last_name != nil {
-		if str, ok := user.Last_name.(string); ok {
-			lastName = str
+// Helper to parse and validate a JWT issued by this server
+func parseJWT(tokenString string) (jwt.MapClaims, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
+		return []byte(os.Getenv("JWT_SECRET")), nil
+	})
+	if err != nil {
+		return nil, err
 	}
+	return claims, nil
+}
 
+// Helper to convert database user to response model
+func userToResponse(user *database.Users) database.UserResponse {
 	return database.UserResponse{
 		ID:        user.Id,
-		Email:     email,
-		Username:  username,
-		FirstName: firstName,
-		LastName:  lastName,
+		Email:     user.Email,
+		Username:  user.Username,
+		FirstName: stringValue(user.First_name),
+		LastName:  stringValue(user.Last_name),
+		Timezone:  user.Timezone,
 		CreatedAt: user.Created_at,
 		UpdatedAt: user.Updated_at,
+		Version:   user.Version,
+	}
+}
+
+// stringValue returns the string s points to, or "" for nil
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
 	}
+	return *s
+}
+
+// usersETag returns the ETag of a list of users
+func usersETag(responses []database.UserResponse) string {
+	tags := make([]string, len(responses))
+	for i, response := range responses {
+		tags[i] = resourceETag(response.ID, response.UpdatedAt)
+	}
+	return listETag(tags)
 }
 
 // Users handlers
@@ -94,6 +144,13 @@ func (s *FiberServer) createUser(c *fiber.Ctx) error {
 		return errorResponse(c, fiber.StatusBadRequest, "Invalid request body")
 	}
 
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
+	defer cancel()
+
+	if err := s.validateNewPassword(ctx, c, req.Password); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
 	// Hash password
 	hash, err := hashPassword(req.Password)
 	if err != nil {
@@ -105,27 +162,23 @@ func (s *FiberServer) createUser(c *fiber.Ctx) error {
 		Email:         req.Email,
 		Username:      req.Username,
 		Password_hash: hash,
-		First_name:    req.FirstName,
-		Last_name:     req.LastName,
+		First_name:    &req.FirstName,
+		Last_name:     &req.LastName,
 		Created_at:    time.Now(),
 		Updated_at:    time.Now(),
 	}
 
-	// Log the user struct being created
-	fmt.Printf("DEBUG: Creating user struct: %+v\n", user)
-
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-
+	// A taken email or username responds 409 from the ErrorHandler
 	createdUser, err := s.db.CreateUser(ctx, &user)
 	if err != nil {
-		fmt.Printf("DEBUG: CreateUser error: %v\n", err)
-		return errorResponse(c, fiber.StatusInternalServerError, "Failed to create user: "+err.Error())
+		return serverError("Failed to create user", err)
 	}
 
 	// Invalidate users list cache
 	s.cache.Del(ctx, "users:list:*")
 
+	s.attributeReferral(ctx, c, req.ReferralCode, createdUser.Id)
+
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
 		"data": userToResponse(createdUser),
 	})
@@ -136,7 +189,20 @@ func (s *FiberServer) getUser(c *fiber.Ctx) error {
 	if id == "" {
 		return errorResponse(c, fiber.StatusBadRequest, "User ID is required")
 	}
+	return s.respondWithUser(c, id)
+}
+
+// GET /api/v1/users/me
+func (s *FiberServer) getMe(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+	return s.respondWithUser(c, userID)
+}
 
+// respondWithUser sends the user with its ETag, from the cache when it has them
+func (s *FiberServer) respondWithUser(c *fiber.Ctx, id string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
@@ -145,14 +211,14 @@ func (s *FiberServer) getUser(c *fiber.Ctx) error {
 	if cachedData, err := s.GetCache(ctx, cacheKey); err == nil {
 		var user database.Users
 		if json.Unmarshal([]byte(cachedData), &user) == nil {
-			return successResponse(c, userToResponse(&user))
+			return respondWithETag(c, resourceETag(user.Id, user.Updated_at), userToResponse(&user))
 		}
 	}
 
 	// Get from database
 	user, err := s.db.GetUserByID(ctx, id)
 	if err != nil {
-		return errorResponse(c, fiber.StatusNotFound, "User not found")
+		return notFound(c, "User")
 	}
 
 	// Cache the user data (without password hash)
@@ -162,17 +228,17 @@ func (s *FiberServer) getUser(c *fiber.Ctx) error {
 		s.SetCache(ctx, cacheKey, string(userData), 10*time.Minute)
 	}
 
-	return successResponse(c, userToResponse(user))
+	return respondWithETag(c, resourceETag(user.Id, user.Updated_at), userToResponse(user))
 }
 
 func (s *FiberServer) listUsers(c *fiber.Ctx) error {
-	limit, offset := getPaginationParams(c)
+	opts := getListOptions(c)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	// Try to get from cache first
-	cacheKey := usersListCacheKey(limit, offset)
+	cacheKey := usersListCacheKey(opts)
 	if cachedData, err := s.GetCache(ctx, cacheKey); err == nil {
 		var users []database.Users
 		if json.Unmarshal([]byte(cachedData), &users) == nil {
@@ -181,14 +247,17 @@ func (s *FiberServer) listUsers(c *fiber.Ctx) error {
 			for i, user := range users {
 				responses[i] = userToResponse(&user)
 			}
-			return successResponse(c, responses)
+			return respondWithETag(c, usersETag(responses), responses)
 		}
 	}
 
 	// Get from database
-	users, err := s.db.ListUsers(ctx, limit, offset)
+	users, err := s.db.ListUsers(ctx, opts)
+	if errors.Is(err, database.ErrInvalidListOption) {
+		return errorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
 	if err != nil {
-		return errorResponse(c, fiber.StatusInternalServerError, "Failed to fetch users: "+err.Error())
+		return serverError("Failed to fetch users", err)
 	}
 
 	// Cache the users data (without password hashes)
@@ -207,7 +276,7 @@ func (s *FiberServer) listUsers(c *fiber.Ctx) error {
 		responses[i] = userToResponse(&user)
 	}
 
-	return successResponse(c, responses)
+	return respondWithETag(c, usersETag(responses), responses)
 }
 
 func (s *FiberServer) updateUser(c *fiber.Ctx) error {
@@ -221,42 +290,199 @@ func (s *FiberServer) updateUser(c *fiber.Ctx) error {
 		return errorResponse(c, fiber.StatusBadRequest, "Invalid request body")
 	}
 
-	// Get existing user
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	return s.saveUserUpdate(c, id, req, nil)
+}
+
+// PUT /api/v1/users/me
+func (s *FiberServer) updateMe(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	var req database.UpdateUserRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	return s.saveUserUpdate(c, userID, req, nil)
+}
+
+// PUT /api/v1/users/me/password
+func (s *FiberServer) changeMyPassword(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+	return s.changePassword(c, userID)
+}
+
+// PUT /api/v1/users/:id/password
+// Users can only change their own password.
+func (s *FiberServer) changeUserPassword(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+	if c.Params("id") != userID {
+		return errorResponse(c, fiber.StatusForbidden, "You can only change your own password")
+	}
+	return s.changePassword(c, userID)
+}
+
+// changePassword changes the user's password after checking the current one. Tokens
+// already issued stay valid.
+func (s *FiberServer) changePassword(c *fiber.Ctx, userID string) error {
+	var req database.ChangePasswordRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+	if req.NewPassword == "" {
+		return errorResponse(c, fiber.StatusBadRequest, "newPassword is required")
+	}
+
+	ctx, cancel := context.WithTimeout(c.UserContext(), 5*time.Second)
 	defer cancel()
 
-	existingUser, err := s.db.GetUserByID(ctx, id)
+	user, err := s.db.GetUserByID(ctx, userID)
 	if err != nil {
-		return errorResponse(c, fiber.StatusNotFound, "User not found")
+		return notFound(c, "User")
+	}
+	// Accounts created through a sign-in provider have no password to check against
+	if user.Password_hash == "" || !checkPasswordHash(req.CurrentPassword, user.Password_hash) {
+		return errorResponse(c, fiber.StatusForbidden, "Current password is incorrect")
+	}
+	if err := s.validateNewPassword(ctx, c, req.NewPassword); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err.Error())
 	}
 
-	// Update fields if provided
-	if req.Email != nil {
-		existingUser.Email = *req.Email
+	hash, err := hashPassword(req.NewPassword)
+	if err != nil {
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to hash password")
 	}
-	if req.Username != nil {
-		existingUser.Username = *req.Username
+	user.Password_hash = hash
+	user.Password_reset_required = false
+	user.Updated_at = time.Now()
+
+	if _, err := s.db.UpdateUser(ctx, user); errors.Is(err, database.ErrVersionConflict) {
+		return versionConflict(c)
+	} else if err != nil {
+		LogDatabaseError(s, "update_user", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to change password")
 	}
-	if req.FirstName != nil {
-		existingUser.First_name = *req.FirstName
+
+	s.DeleteCache(ctx, userCacheKey(userID))
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// userPatchFields are the user fields a merge patch may change
+var userPatchFields = patchFields{
+	"email":     false,
+	"username":  false,
+	"firstName": true,
+	"lastName":  true,
+	"timezone":  false,
+	"version":   false,
+}
+
+// PATCH /api/v1/users/:id
+func (s *FiberServer) patchUser(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if id == "" {
+		return errorResponse(c, fiber.StatusBadRequest, "User ID is required")
 	}
-	if req.LastName != nil {
-		existingUser.Last_name = *req.LastName
+
+	var req database.UpdateUserRequest
+	cleared, err := applyMergePatch(c.Body(), &req, userPatchFields)
+	if err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	return s.saveUserUpdate(c, id, req, cleared)
+}
+
+// saveUserUpdate applies the set fields of req to the stored user, clears the fields in
+// cleared, and saves it
+func (s *FiberServer) saveUserUpdate(c *fiber.Ctx, id string, req database.UpdateUserRequest, cleared map[string]bool) error {
+	if err := normalizeTimezone(req.Timezone); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	// Get existing user
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	existingUser, err := s.db.GetUserByID(ctx, id)
+	if err != nil {
+		return notFound(c, "User")
 	}
-	existingUser.Updated_at = time.Now()
+
+	if etag := resourceETag(existingUser.Id, existingUser.Updated_at); ifMatchFails(c, etag) {
+		return preconditionFailed(c, etag)
+	}
+	applyUserUpdate(existingUser, req, cleared)
 
 	updatedUser, err := s.db.UpdateUser(ctx, existingUser)
+	if errors.Is(err, database.ErrVersionConflict) {
+		return versionConflict(c)
+	}
 	if err != nil {
-		return errorResponse(c, fiber.StatusInternalServerError, "Failed to update user: "+err.Error())
+		return serverError("Failed to update user", err)
 	}
 
 	// Invalidate cache
 	s.DeleteCache(ctx, userCacheKey(id))
 	s.cache.Del(ctx, "users:list:*")
 
+	c.Set(fiber.HeaderETag, resourceETag(updatedUser.Id, updatedUser.Updated_at))
 	return successResponse(c, userToResponse(updatedUser))
 }
 
+// normalizeTimezone replaces the IANA timezone name timezone points to with its canonical
+// form. A nil timezone is left unset.
+func normalizeTimezone(timezone *string) error {
+	if timezone == nil {
+		return nil
+	}
+	loc, err := time.LoadLocation(*timezone)
+	if err != nil || *timezone == "" || *timezone == "Local" {
+		return errors.New("timezone must be an IANA timezone name")
+	}
+	*timezone = loc.String()
+	return nil
+}
+
+// applyUserUpdate sets the fields of user that req sets and clears those in cleared
+func applyUserUpdate(user *database.Users, req database.UpdateUserRequest, cleared map[string]bool) {
+	if req.Version != nil {
+		user.Version = *req.Version
+	}
+
+	// Update fields if provided
+	if req.Email != nil {
+		user.Email = *req.Email
+	}
+	if req.Username != nil {
+		user.Username = *req.Username
+	}
+	if req.FirstName != nil {
+		user.First_name = req.FirstName
+	}
+	if req.LastName != nil {
+		user.Last_name = req.LastName
+	}
+	if req.Timezone != nil {
+		user.Timezone = *req.Timezone
+	}
+	if cleared["firstName"] {
+		user.First_name = nil
+	}
+	if cleared["lastName"] {
+		user.Last_name = nil
+	}
+	user.Updated_at = time.Now()
+}
+
 func (s *FiberServer) deleteUser(c *fiber.Ctx) error {
 	id := c.Params("id")
 	if id == "" {
@@ -266,9 +492,19 @@ func (s *FiberServer) deleteUser(c *fiber.Ctx) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	if c.Get(fiber.HeaderIfMatch) != "" {
+		existing, err := s.db.GetUserByID(ctx, id)
+		if err != nil {
+			return notFound(c, "User")
+		}
+		if etag := resourceETag(existing.Id, existing.Updated_at); ifMatchFails(c, etag) {
+			return preconditionFailed(c, etag)
+		}
+	}
+
 	err := s.db.DeleteUser(ctx, id)
 	if err != nil {
-		return errorResponse(c, fiber.StatusInternalServerError, "Failed to delete user: "+err.Error())
+		return serverError("Failed to delete user", err)
 	}
 
 	// Invalidate cache
@@ -294,31 +530,31 @@ func (s *FiberServer) loginUser(c *fiber.Ctx) error {
 		return errorResponse(c, fiber.StatusUnauthorized, "Invalid credentials")
 	}
 
-	// Handle type assertion for password hash
-	var passwordHash string
-	if user.Password_hash != nil {
-		if str, ok := user.Password_hash.(string); ok {
-			passwordHash = str
-		}
-	}
-
-	if user == nil || passwordHash == "" {
+	if user == nil || user.Password_hash == "" {
 		return errorResponse(c, fiber.StatusUnauthorized, "Invalid credentials")
 	}
 
-	if !checkPasswordHash(req.Password, passwordHash) {
+	if !checkPasswordHash(req.Password, user.Password_hash) {
 		return errorResponse(c, fiber.StatusUnauthorized, "Invalid credentials")
 	}
+	if user.Disabled_at != nil {
+		return codedErrorResponse(c, fiber.StatusForbidden, codeAccountDisabled, "This account has been disabled")
+	}
 
-	// Generate JWT
-	token, err := generateJWT(user.Id)
+	// Generate JWT, only good for changing the password if an admin forced a reset
+	var claims jwt.MapClaims
+	if user.Password_reset_required {
+		claims = jwt.MapClaims{claimPasswordReset: true}
+	}
+	token, err := s.issueSessionToken(ctx, c, user.Id, time.Now().Add(sessionTokenTTL), claims)
 	if err != nil {
 		return errorResponse(c, fiber.StatusInternalServerError, "Failed to generate token")
 	}
 
 	response := database.LoginResponse{
-		User:  userToResponse(user),
-		Token: token,
+		User:                  userToResponse(user),
+		Token:                 token,
+		PasswordResetRequired: user.Password_reset_required,
 	}
 
 	return successResponse(c, response)
@@ -0,0 +1,106 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"fitness-hack/internal/database"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestUsersMe(t *testing.T) {
+	s, db := newFakeServer(t)
+	hash, err := hashPassword("old-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	user, err := db.CreateUser(context.Background(), &database.Users{Email: "ann@example.com", Username: "ann", Password_hash: hash})
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth := bearer(t, user.Id)
+
+	do := func(method, path, body string) (int, database.UserResponse) {
+		t.Helper()
+		req := httptest.NewRequest(method, "/api/v1/users/me"+path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", auth)
+		resp, err := s.App.Test(req, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var envelope struct{ Data database.UserResponse }
+		json.NewDecoder(resp.Body).Decode(&envelope)
+		return resp.StatusCode, envelope.Data
+	}
+
+	if status, me := do("GET", "", ""); status != 200 || me.ID != user.Id || me.Timezone != "UTC" {
+		t.Fatalf("expected the caller's profile, got %d %+v", status, me)
+	}
+	if status, me := do("PUT", "", `{"username":"ann_k","timezone":"Europe/Berlin"}`); status != 200 || me.Username != "ann_k" || me.Timezone != "Europe/Berlin" {
+		t.Errorf("expected the profile updated, got %d %+v", status, me)
+	}
+	if status, _ := do("PUT", "", `{"timezone":"Mars/Olympus"}`); status != 400 {
+		t.Errorf("expected an unknown timezone to be rejected, got %d", status)
+	}
+
+	for _, tc := range []struct {
+		body   string
+		status int
+	}{
+		{`{"currentPassword":"old-secret"}`, 400},
+		{`{"currentPassword":"old-secret","newPassword":"short"}`, 400},
+		{`{"currentPassword":"wrong","newPassword":"new-secret"}`, 403},
+		{`{"currentPassword":"old-secret","newPassword":"new-secret"}`, 204},
+		{`{"currentPassword":"old-secret","newPassword":"newer-secret"}`, 403},
+	} {
+		if status, _ := do("PUT", "/password", tc.body); status != tc.status {
+			t.Errorf("%s: expected %d, got %d", tc.body, tc.status, status)
+		}
+	}
+	for userID, status := range map[string]int{"someone-else": 403, user.Id: 204} {
+		req := httptest.NewRequest("PUT", "/api/v1/users/"+userID+"/password", strings.NewReader(`{"currentPassword":"new-secret","newPassword":"new-secret"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", auth)
+		resp, err := s.App.Test(req, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != status {
+			t.Errorf("PUT /users/%s/password: expected %d, got %d", userID, status, resp.StatusCode)
+		}
+	}
+	stored, _ := db.GetUserByID(context.Background(), user.Id)
+	if !checkPasswordHash("new-secret", stored.Password_hash) || stored.Username != "ann_k" {
+		t.Errorf("expected the new password saved with the profile kept, got %+v", stored)
+	}
+}
+
+type breachList map[string]bool
+
+func (b breachList) Breached(_ context.Context, pw string) (bool, error) { return b[pw], nil }
+
+func TestValidateNewPasswordRejectsBreached(t *testing.T) {
+	s, _ := newFakeServer(t)
+	s.breaches = breachList{"password123": true}
+	s.App.Post("/check", func(c *fiber.Ctx) error {
+		if err := s.validateNewPassword(context.Background(), c, string(c.Body())); err != nil {
+			return c.Status(fiber.StatusBadRequest).SendString(err.Error())
+		}
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+
+	for pw, status := range map[string]int{"password123": 400, "short": 400, "a fresh passphrase": 204} {
+		resp, err := s.App.Test(httptest.NewRequest("POST", "/check", strings.NewReader(pw)), -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != status {
+			t.Errorf("%q: expected %d, got %d", pw, status, resp.StatusCode)
+		}
+	}
+}
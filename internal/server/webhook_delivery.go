@@ -0,0 +1,237 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+
+	"fitness-hack/internal/database"
+	"fitness-hack/internal/messages"
+
+	"github.com/google/uuid"
+)
+
+const (
+	webhookSignatureHeader = "X-FitnessHack-Signature"
+	webhookEventHeader     = "X-FitnessHack-Event"
+	webhookDeliveryHeader  = "X-FitnessHack-Delivery"
+
+	// webhookTimeout bounds one attempt, so a slow endpoint can't stall the queue
+	webhookTimeout = 10 * time.Second
+	// webhookAttemptLease must outlast an attempt; it is how long a claimed delivery is hidden
+	// from other workers
+	webhookAttemptLease = 2 * time.Minute
+	webhookClaimBatch   = 50
+)
+
+// webhookEvent is the JSON body sent for every delivery
+type webhookEvent struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	CreatedAt time.Time   `json:"createdAt"`
+	Data      interface{} `json:"data"`
+}
+
+// webhookAllowPrivateURLs permits http and private addresses, for testing against a local receiver
+func webhookAllowPrivateURLs() bool {
+	return getEnvBool("WEBHOOK_ALLOW_PRIVATE_URLS", false)
+}
+
+// isPublicIP reports whether ip is routable on the internet, so webhooks can't be aimed at
+// the API's own network or cloud metadata endpoints
+func isPublicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsMulticast() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() {
+		return false
+	}
+	// Carrier-grade NAT space is not covered by IsPrivate
+	if ip4 := ip.To4(); ip4 != nil && ip4[0] == 100 && ip4[1]&0xC0 == 64 {
+		return false
+	}
+	return true
+}
+
+// newWebhookClient returns the HTTP client deliveries are sent with. Addresses are checked
+// as the connection is made, after DNS resolution, so a hostname can't be pointed at a
+// private address after the webhook was registered. Redirects are not followed for the same reason.
+func newWebhookClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			if webhookAllowPrivateURLs() {
+				return nil
+			}
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !isPublicIP(ip) {
+				return fmt.Errorf("webhook address %s is not public", host)
+			}
+			return nil
+		},
+	}
+	return &http.Client{
+		Timeout: webhookTimeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: 5 * time.Second,
+			MaxIdleConnsPerHost: 2,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// signWebhookPayload returns the signature header value for a delivery body. The HMAC covers
+// the timestamp as well as the body so receivers can reject replayed deliveries.
+func signWebhookPayload(secret string, timestamp time.Time, body []byte) string {
+	t := strconv.FormatInt(timestamp.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(t))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "t=" + t + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookRetryDelay returns how long to wait after the given number of failed attempts:
+// one minute, doubling each time, capped at six hours
+func webhookRetryDelay(attempts int) time.Duration {
+	delay := time.Minute
+	for i := 1; i < attempts && delay < 6*time.Hour; i++ {
+		delay *= 2
+	}
+	return min(delay, 6*time.Hour)
+}
+
+// emitWebhookEvent queues an event for the user's subscribed webhooks and attempts the
+// deliveries right away. It returns immediately; failures are left to the retry job.
+func (s *FiberServer) emitWebhookEvent(userID, event string, data interface{}) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), webhookAttemptLease)
+		defer cancel()
+
+		eventID := uuid.NewString()
+		payload, err := s.encoder.Marshal(webhookEvent{
+			ID:        eventID,
+			Type:      event,
+			CreatedAt: time.Now().UTC(),
+			Data:      data,
+		})
+		if err != nil {
+			s.logError("ERROR", messages.WebhookEncodeFailed, err, nil, map[string]interface{}{
+				"component": "webhooks",
+				"event":     event,
+			})
+			return
+		}
+
+		targets, err := s.db.EnqueueWebhookEvent(ctx, userID, eventID, event, payload, webhookAttemptLease)
+		if err != nil {
+			s.logError("ERROR", messages.WebhookQueueFailed, err, nil, map[string]interface{}{
+				"component": "webhooks",
+				"event":     event,
+				"user_id":   userID,
+			})
+			return
+		}
+		for _, target := range targets {
+			s.deliverWebhook(ctx, target)
+		}
+	}()
+}
+
+// StartWebhookDelivery retries failed webhook deliveries, and picks up deliveries whose
+// first attempt was cut short by a restart
+func (s *FiberServer) StartWebhookDelivery(ctx context.Context) {
+	s.runPeriodically(ctx, getEnvDuration("WEBHOOK_DELIVERY_INTERVAL", 30*time.Second), s.deliverDueWebhooks)
+}
+
+func (s *FiberServer) deliverDueWebhooks(ctx context.Context) {
+	for ctx.Err() == nil {
+		claimCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		targets, err := s.db.ClaimWebhookDeliveries(claimCtx, webhookClaimBatch, webhookAttemptLease)
+		cancel()
+		if err != nil {
+			s.logError("ERROR", messages.WebhookDeliveryFailed, err, nil, map[string]interface{}{
+				"component": "webhooks",
+			})
+			return
+		}
+
+		for _, target := range targets {
+			s.deliverWebhook(ctx, target)
+		}
+		if len(targets) < webhookClaimBatch {
+			return
+		}
+	}
+}
+
+// deliverWebhook makes one attempt at a delivery and records the outcome
+func (s *FiberServer) deliverWebhook(ctx context.Context, target database.WebhookDeliveryTarget) {
+	statusCode, err := s.sendWebhook(ctx, target)
+
+	var responseStatus *int
+	if statusCode != 0 {
+		responseStatus = &statusCode
+	}
+	var lastError string
+	var nextAttemptAt *time.Time
+	if err != nil {
+		lastError = err.Error()
+		if len(lastError) > 500 {
+			lastError = lastError[:500]
+		}
+		if attempts := target.Attempts + 1; attempts < getEnvInt("WEBHOOK_MAX_ATTEMPTS", 8) {
+			next := time.Now().Add(webhookRetryDelay(attempts))
+			nextAttemptAt = &next
+		}
+	}
+
+	recordCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if recordErr := s.db.RecordWebhookAttempt(recordCtx, target.Id, err == nil, responseStatus, lastError, nextAttemptAt); recordErr != nil {
+		s.logError("ERROR", messages.WebhookAttemptRecordFailed, recordErr, nil, map[string]interface{}{
+			"component":   "webhooks",
+			"delivery_id": target.Id,
+		})
+	}
+}
+
+// sendWebhook posts a delivery, returning the response status if the endpoint answered.
+// Anything but a 2xx response counts as a failure.
+func (s *FiberServer) sendWebhook(ctx context.Context, target database.WebhookDeliveryTarget) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target.Url, bytes.NewReader(target.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "FitnessHack-Webhooks/1.0")
+	req.Header.Set(webhookEventHeader, target.Event)
+	req.Header.Set(webhookDeliveryHeader, target.Id)
+	req.Header.Set(webhookSignatureHeader, signWebhookPayload(target.Secret, time.Now(), target.Payload))
+
+	resp, err := s.webhookClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, errors.New("endpoint responded with " + resp.Status)
+	}
+	return resp.StatusCode, nil
+}
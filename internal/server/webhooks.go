@@ -0,0 +1,401 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"fitness-hack/internal/database"
+	"fitness-hack/internal/messages"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	webhookEventSessionCompleted = "session.completed"
+	webhookEventPRAchieved       = "pr.achieved"
+
+	// maxWebhooksPerUser keeps one account from fanning a single event out to many endpoints
+	maxWebhooksPerUser  = 10
+	maxWebhookURLLength = 2048
+)
+
+// webhookEvents lists the event types users can subscribe to, or poll for as integration
+// triggers, with what they announce
+var webhookEvents = map[string]string{
+	webhookEventSessionCompleted: "A workout session was completed",
+	webhookEventPRAchieved:       "A new personal record was set",
+}
+
+// Helper to convert database webhook to response model
+func webhookToResponse(webhook *database.Webhooks) database.WebhookResponse {
+	events := []string{}
+	json.Unmarshal(webhook.Events, &events)
+	return database.WebhookResponse{
+		ID:          webhook.Id,
+		URL:         webhook.Url,
+		Description: webhook.Description,
+		Events:      events,
+		Active:      webhook.Active,
+		CreatedAt:   webhook.Created_at,
+		UpdatedAt:   webhook.Updated_at,
+		Version:     webhook.Version,
+	}
+}
+
+// webhooksETag returns the ETag of a list of webhooks
+func webhooksETag(responses []database.WebhookResponse) string {
+	tags := make([]string, len(responses))
+	for i, response := range responses {
+		tags[i] = resourceETag(response.ID, response.UpdatedAt)
+	}
+	return listETag(tags)
+}
+
+// Helper to convert database webhook delivery to response model
+func webhookDeliveryToResponse(delivery *database.Webhook_deliveries) database.WebhookDeliveryResponse {
+	resp := database.WebhookDeliveryResponse{
+		ID:             delivery.Id,
+		EventID:        delivery.Event_id,
+		Event:          delivery.Event,
+		Status:         string(delivery.Status),
+		Attempts:       delivery.Attempts,
+		ResponseStatus: delivery.Response_status,
+		LastError:      delivery.Last_error,
+		Payload:        delivery.Payload,
+		CreatedAt:      delivery.Created_at,
+		DeliveredAt:    delivery.Delivered_at,
+	}
+	if delivery.Status == "pending" {
+		resp.NextAttemptAt = &delivery.Next_attempt_at
+	}
+	return resp
+}
+
+// validateWebhookURL checks that a webhook points at a public HTTPS endpoint. Hostnames are
+// resolved again when delivering, so this only rejects what is wrong on its face.
+func validateWebhookURL(raw string) error {
+	if len(raw) > maxWebhookURLLength {
+		return fmt.Errorf("url must be at most %d characters", maxWebhookURLLength)
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return errors.New("url must be an absolute URL")
+	}
+	if u.User != nil {
+		return errors.New("url must not contain credentials")
+	}
+
+	allowPrivate := webhookAllowPrivateURLs()
+	if u.Scheme != "https" && !(allowPrivate && u.Scheme == "http") {
+		return errors.New("url must use https")
+	}
+	if ip := net.ParseIP(u.Hostname()); ip != nil && !allowPrivate && !isPublicIP(ip) {
+		return errors.New("url must not point at a private or reserved address")
+	}
+	if host := strings.ToLower(u.Hostname()); !allowPrivate && (host == "localhost" || strings.HasSuffix(host, ".localhost")) {
+		return errors.New("url must not point at a private or reserved address")
+	}
+	return nil
+}
+
+// normalizeWebhookEvents validates the subscribed events and removes duplicates
+func normalizeWebhookEvents(events []string) ([]string, error) {
+	if len(events) == 0 {
+		return nil, errors.New("events must list at least one event")
+	}
+	seen := make(map[string]bool, len(events))
+	normalized := make([]string, 0, len(events))
+	for _, event := range events {
+		if _, ok := webhookEvents[event]; !ok {
+			return nil, fmt.Errorf("unknown event %q", event)
+		}
+		if !seen[event] {
+			seen[event] = true
+			normalized = append(normalized, event)
+		}
+	}
+	return normalized, nil
+}
+
+// POST /api/v1/webhooks
+// The signing secret is only returned in this response.
+func (s *FiberServer) createWebhook(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	var req database.CreateWebhookRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+	if err := validateWebhookURL(req.URL); err != nil {
+		LogValidationError(s, "url", err, c)
+		return errorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+	events, err := normalizeWebhookEvents(req.Events)
+	if err != nil {
+		LogValidationError(s, "events", err, c)
+		return errorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	existing, err := s.db.ListWebhooks(ctx, userID)
+	if err != nil {
+		LogDatabaseError(s, "list_webhooks", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to create webhook")
+	}
+	if len(existing) >= maxWebhooksPerUser {
+		return errorResponse(c, fiber.StatusConflict, fmt.Sprintf("At most %d webhooks can be registered", maxWebhooksPerUser))
+	}
+
+	secret, err := randomHex(32)
+	if err != nil {
+		LogError(s, "ERROR", messages.WebhookSecretFailed, err, c, nil)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to create webhook")
+	}
+	eventsJSON, _ := json.Marshal(events)
+
+	webhook, err := s.db.CreateWebhook(ctx, &database.Webhooks{
+		User_id:     userID,
+		Url:         req.URL,
+		Description: req.Description,
+		Events:      eventsJSON,
+		Secret:      "whsec_" + secret,
+	})
+	if err != nil {
+		LogDatabaseError(s, "create_webhook", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to create webhook")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"data": database.CreatedWebhookResponse{
+			WebhookResponse: webhookToResponse(webhook),
+			Secret:          webhook.Secret,
+		},
+	})
+}
+
+// GET /api/v1/webhooks
+func (s *FiberServer) listWebhooks(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	webhooks, err := s.db.ListWebhooks(ctx, userID)
+	if err != nil {
+		LogDatabaseError(s, "list_webhooks", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to list webhooks")
+	}
+
+	responses := make([]database.WebhookResponse, len(webhooks))
+	for i := range webhooks {
+		responses[i] = webhookToResponse(&webhooks[i])
+	}
+	return respondWithETag(c, webhooksETag(responses), responses)
+}
+
+// GET /api/v1/webhooks/:id
+func (s *FiberServer) getWebhook(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	webhook, err := s.db.GetWebhook(ctx, c.Params("id"), userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return notFound(c, "Webhook")
+	}
+	if err != nil {
+		LogDatabaseError(s, "get_webhook", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to get webhook")
+	}
+
+	return respondWithETag(c, resourceETag(webhook.Id, webhook.Updated_at), webhookToResponse(webhook))
+}
+
+// PUT /api/v1/webhooks/:id
+func (s *FiberServer) updateWebhook(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	var req database.UpdateWebhookRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	return s.saveWebhookUpdate(c, userID, req)
+}
+
+// webhookPatchFields are the webhook fields a merge patch may change. None of the
+// columns are nullable.
+var webhookPatchFields = patchFields{
+	"url":         false,
+	"description": false,
+	"events":      false,
+	"active":      false,
+	"version":     false,
+}
+
+// PATCH /api/v1/webhooks/:id
+func (s *FiberServer) patchWebhook(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	var req database.UpdateWebhookRequest
+	if _, err := applyMergePatch(c.Body(), &req, webhookPatchFields); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	return s.saveWebhookUpdate(c, userID, req)
+}
+
+// saveWebhookUpdate applies the set fields of req to the user's webhook and saves it
+func (s *FiberServer) saveWebhookUpdate(c *fiber.Ctx, userID string, req database.UpdateWebhookRequest) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	webhook, err := s.db.GetWebhook(ctx, c.Params("id"), userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return notFound(c, "Webhook")
+	}
+	if err != nil {
+		LogDatabaseError(s, "get_webhook", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to update webhook")
+	}
+
+	if etag := resourceETag(webhook.Id, webhook.Updated_at); ifMatchFails(c, etag) {
+		return preconditionFailed(c, etag)
+	}
+	if req.Version != nil {
+		webhook.Version = *req.Version
+	}
+
+	if req.URL != nil {
+		if err := validateWebhookURL(*req.URL); err != nil {
+			LogValidationError(s, "url", err, c)
+			return errorResponse(c, fiber.StatusBadRequest, err.Error())
+		}
+		webhook.Url = *req.URL
+	}
+	if req.Description != nil {
+		webhook.Description = *req.Description
+	}
+	if req.Events != nil {
+		events, err := normalizeWebhookEvents(*req.Events)
+		if err != nil {
+			LogValidationError(s, "events", err, c)
+			return errorResponse(c, fiber.StatusBadRequest, err.Error())
+		}
+		webhook.Events, _ = json.Marshal(events)
+	}
+	if req.Active != nil {
+		webhook.Active = *req.Active
+	}
+
+	updated, err := s.db.UpdateWebhook(ctx, webhook)
+	if errors.Is(err, database.ErrVersionConflict) {
+		return versionConflict(c)
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return notFound(c, "Webhook")
+	}
+	if err != nil {
+		LogDatabaseError(s, "update_webhook", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to update webhook")
+	}
+
+	c.Set(fiber.HeaderETag, resourceETag(updated.Id, updated.Updated_at))
+	return successResponse(c, webhookToResponse(updated))
+}
+
+// DELETE /api/v1/webhooks/:id
+func (s *FiberServer) deleteWebhook(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if c.Get(fiber.HeaderIfMatch) != "" {
+		existing, err := s.db.GetWebhook(ctx, c.Params("id"), userID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return notFound(c, "Webhook")
+		}
+		if err != nil {
+			LogDatabaseError(s, "get_webhook", err, c)
+			return errorResponse(c, fiber.StatusInternalServerError, "Failed to delete webhook")
+		}
+		if etag := resourceETag(existing.Id, existing.Updated_at); ifMatchFails(c, etag) {
+			return preconditionFailed(c, etag)
+		}
+	}
+
+	err = s.db.DeleteWebhook(ctx, c.Params("id"), userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return notFound(c, "Webhook")
+	}
+	if err != nil {
+		LogDatabaseError(s, "delete_webhook", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to delete webhook")
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// GET /api/v1/webhooks/:id/deliveries
+func (s *FiberServer) listWebhookDeliveries(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	webhook, err := s.db.GetWebhook(ctx, c.Params("id"), userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return notFound(c, "Webhook")
+	}
+	if err != nil {
+		LogDatabaseError(s, "get_webhook", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to list webhook deliveries")
+	}
+
+	deliveries, err := s.db.ListWebhookDeliveries(ctx, webhook.Id, getListOptions(c))
+	if errors.Is(err, database.ErrInvalidListOption) {
+		return errorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+	if err != nil {
+		LogDatabaseError(s, "list_webhook_deliveries", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to list webhook deliveries")
+	}
+
+	responses := make([]database.WebhookDeliveryResponse, len(deliveries))
+	for i := range deliveries {
+		responses[i] = webhookDeliveryToResponse(&deliveries[i])
+	}
+	return successResponse(c, responses)
+}
@@ -0,0 +1,217 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"fitness-hack/internal/database"
+	"fitness-hack/internal/mailer"
+	"fitness-hack/internal/messages"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	// maxStreakWeeks is the longest streak counted, ten years of training every week
+	maxStreakWeeks = 520
+
+	weeklySummaryBatch = 100
+)
+
+// streakWeeks counts the weeks in a row with a session, ending with the week starting
+// weekStart. activeWeeks are the Mondays of weeks with a session up to that week, latest first.
+func streakWeeks(activeWeeks []time.Time, weekStart time.Time) int {
+	expected := time.Date(weekStart.Year(), weekStart.Month(), weekStart.Day(), 0, 0, 0, 0, time.UTC)
+	streak := 0
+	for _, week := range activeWeeks {
+		week = time.Date(week.Year(), week.Month(), week.Day(), 0, 0, 0, 0, time.UTC)
+		if !week.Equal(expected) {
+			break
+		}
+		streak++
+		expected = expected.AddDate(0, 0, -7)
+	}
+	return streak
+}
+
+// weeklySummary aggregates the user's week starting weekStart, a Monday midnight in the
+// timezone weeks are counted in
+func (s *FiberServer) weeklySummary(ctx context.Context, userID string, weekStart time.Time) (*database.WeeklySummaryResponse, error) {
+	weekEnd := weekStart.AddDate(0, 0, 7)
+	summary, err := s.db.GetDailySummary(ctx, userID, weekStart, weekEnd)
+	if err != nil {
+		return nil, err
+	}
+	records, err := s.db.ListPersonalRecordSets(ctx, userID, weekStart, weekEnd)
+	if err != nil {
+		return nil, err
+	}
+	activeWeeks, err := s.db.ListActiveWeeks(ctx, userID, weekStart.Location().String(), weekEnd, maxStreakWeeks)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &database.WeeklySummaryResponse{
+		WeekStart:         weekStart.Format("2006-01-02"),
+		WeekEnd:           weekEnd.AddDate(0, 0, -1).Format("2006-01-02"),
+		Timezone:          weekStart.Location().String(),
+		SessionsCompleted: len(summary.Sessions),
+		TotalVolumeKg:     summary.TotalVolumeKg.Round(2).InexactFloat64(),
+		SetCount:          summary.SetCount,
+		PersonalRecords:   make([]database.WeeklyPersonalRecordResponse, len(records)),
+		StreakWeeks:       streakWeeks(activeWeeks, weekStart),
+	}
+	for i := range summary.Sessions {
+		response.TotalDurationMinutes += summary.Sessions[i].Duration_minutes
+	}
+	for i, record := range records {
+		response.PersonalRecords[i] = database.WeeklyPersonalRecordResponse{
+			ExerciseID:     record.Exercise_id,
+			ExerciseName:   record.Exercise_name,
+			WeightKg:       record.Weight_kg.InexactFloat64(),
+			Reps:           record.Reps,
+			PreviousBestKg: record.Previous_best_kg.InexactFloat64(),
+			AchievedAt:     record.Completed_at,
+		}
+	}
+	return response, nil
+}
+
+// weeklySummaryEmail writes the summary email of a week
+func weeklySummaryEmail(to string, summary *database.WeeklySummaryResponse) mailer.Message {
+	sessions := "sessions"
+	if summary.SessionsCompleted == 1 {
+		sessions = "session"
+	}
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "Your week of %s to %s:\n\n", summary.WeekStart, summary.WeekEnd)
+	fmt.Fprintf(&body, "- %d %s, %d minutes in total\n", summary.SessionsCompleted, sessions, summary.TotalDurationMinutes)
+	if summary.SetCount > 0 {
+		fmt.Fprintf(&body, "- %d sets moving %s kg\n", summary.SetCount, formatKg(math.Round(summary.TotalVolumeKg)))
+	}
+	if summary.StreakWeeks > 1 {
+		fmt.Fprintf(&body, "- %d weeks in a row with a workout\n", summary.StreakWeeks)
+	}
+	if len(summary.PersonalRecords) > 0 {
+		body.WriteString("\nNew personal records:\n")
+		for _, record := range summary.PersonalRecords {
+			fmt.Fprintf(&body, "- %s: %s kg x %d (previous best %s kg)\n",
+				record.ExerciseName, formatKg(record.WeightKg), record.Reps, formatKg(record.PreviousBestKg))
+		}
+	}
+	body.WriteString("\nYou're receiving this because you turned on weekly summaries in FitnessHack. " +
+		"You can turn them off in your notification preferences.\n")
+
+	return mailer.Message{
+		To:      to,
+		Subject: fmt.Sprintf("Your week in FitnessHack: %d %s", summary.SessionsCompleted, sessions),
+		Body:    body.String(),
+	}
+}
+
+// formatKg formats a weight without trailing zeros
+func formatKg(kg float64) string {
+	return strings.TrimSuffix(strings.TrimRight(fmt.Sprintf("%.2f", kg), "0"), ".")
+}
+
+// StartWeeklySummaries periodically emails users who opted in a summary of the week that
+// just ended (every WEEKLY_SUMMARY_INTERVAL, default 1h)
+func (s *FiberServer) StartWeeklySummaries(ctx context.Context) {
+	s.runPeriodically(ctx, getEnvDuration("WEEKLY_SUMMARY_INTERVAL", time.Hour), s.sendWeeklySummaries)
+}
+
+// sendWeeklySummaries emails the summary of last week, Monday to Sunday in each user's
+// timezone, to each user due one. Weeks are claimed by the date of last week's Monday in
+// UTC; users behind UTC are claimed on a later run, once their Sunday is over too. Users who
+// didn't train that week are skipped.
+func (s *FiberServer) sendWeeklySummaries(ctx context.Context) {
+	weekStart := displayWeekStart(time.Now(), time.UTC).AddDate(0, 0, -7)
+	for ctx.Err() == nil {
+		claimCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		userIDs, err := s.db.ClaimWeeklySummaries(claimCtx, weekStart, weeklySummaryBatch)
+		cancel()
+		if err != nil {
+			s.logError("ERROR", messages.WeeklySummariesFailed, err, nil, map[string]interface{}{
+				"component": "weekly_summary",
+			})
+			return
+		}
+
+		for _, userID := range userIDs {
+			sendCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+			s.sendWeeklySummary(sendCtx, userID, weekStart)
+			cancel()
+		}
+		if len(userIDs) < weeklySummaryBatch {
+			return
+		}
+	}
+}
+
+// sendWeeklySummary emails the user the summary of the week starting on the date of
+// weekStart in their timezone
+func (s *FiberServer) sendWeeklySummary(ctx context.Context, userID string, weekStart time.Time) {
+	logFailure := func(err error) {
+		s.logError("WARN", messages.WeeklySummaryEmailFailed, err, nil, map[string]interface{}{
+			"component": "weekly_summary",
+			"user_id":   userID,
+		})
+	}
+
+	user, err := s.db.GetUserByID(ctx, userID)
+	if err != nil {
+		logFailure(err)
+		return
+	}
+	loc, err := time.LoadLocation(user.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	weekStart = time.Date(weekStart.Year(), weekStart.Month(), weekStart.Day(), 0, 0, 0, 0, loc)
+
+	summary, err := s.weeklySummary(ctx, userID, weekStart)
+	if err != nil {
+		logFailure(err)
+		return
+	}
+	if summary.SessionsCompleted == 0 {
+		return
+	}
+	if err := s.mailer.Send(ctx, weeklySummaryEmail(user.Email, summary)); err != nil {
+		logFailure(err)
+	}
+}
+
+// GET /api/v1/analytics/weekly-summary?week=2024-01-03&tz=Europe/Berlin
+// Summarizes the week, Monday to Sunday in tz, containing the date week. The week defaults
+// to the current one, so far.
+func (s *FiberServer) getWeeklySummary(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tz, err := s.userTimezone(ctx, c, userID)
+	if err != nil {
+		LogDatabaseError(s, "get_user", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to fetch weekly summary")
+	}
+	day, _, err := summaryDay(c.Query("week"), tz, time.Now())
+	if err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, strings.Replace(err.Error(), "date", "week", 1))
+	}
+
+	summary, err := s.weeklySummary(ctx, userID, displayWeekStart(day, day.Location()))
+	if err != nil {
+		LogDatabaseError(s, "get_weekly_summary", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to fetch weekly summary")
+	}
+	return successResponse(c, summary)
+}
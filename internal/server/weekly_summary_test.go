@@ -0,0 +1,140 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"fitness-hack/internal/database"
+	"fitness-hack/internal/database/dbtest"
+
+	"github.com/shopspring/decimal"
+)
+
+// weeklySummaryStub reports two sessions and a squat record in any week, and sessions in
+// the three weeks up to 2024-01-08 and in December
+type weeklySummaryStub struct {
+	*dbtest.Fake
+}
+
+func (s *weeklySummaryStub) GetDailySummary(ctx context.Context, userID string, from, to time.Time) (*database.DailySummary, error) {
+	return &database.DailySummary{
+		Sessions:      []database.Workout_sessions{{Id: "s1", Duration_minutes: 45}, {Id: "s2", Duration_minutes: 60}},
+		TotalVolumeKg: decimal.RequireFromString("8250.5"),
+		SetCount:      32,
+	}, nil
+}
+
+func (s *weeklySummaryStub) ListPersonalRecordSets(ctx context.Context, userID string, from, to time.Time) ([]database.PersonalRecordSet, error) {
+	return []database.PersonalRecordSet{{
+		Exercise_id:      "squat",
+		Exercise_name:    "Back Squat",
+		Weight_kg:        decimal.RequireFromString("142.5"),
+		Reps:             3,
+		Completed_at:     from.Add(26 * time.Hour),
+		Previous_best_kg: decimal.NewFromInt(140),
+	}}, nil
+}
+
+func (s *weeklySummaryStub) ListActiveWeeks(ctx context.Context, userID, tz string, before time.Time, limit int) ([]time.Time, error) {
+	return []time.Time{
+		time.Date(2024, 1, 8, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2023, 12, 25, 0, 0, 0, 0, time.UTC),
+		time.Date(2023, 12, 11, 0, 0, 0, 0, time.UTC),
+	}, nil
+}
+
+func TestGetWeeklySummary(t *testing.T) {
+	db := dbtest.NewFake()
+	s := newTestServer(t, &weeklySummaryStub{Fake: db})
+
+	req := httptest.NewRequest("GET", "/api/v1/analytics/weekly-summary?week=2024-01-10&tz=Europe/Berlin", nil)
+	req.Header.Set("Authorization", bearer(t, "u1"))
+	resp, err := s.App.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != 200 {
+		t.Fatalf("expected the summary, got %d", resp.StatusCode)
+	}
+	var body struct {
+		Data database.WeeklySummaryResponse
+	}
+	json.NewDecoder(resp.Body).Decode(&body)
+
+	summary := body.Data
+	if summary.WeekStart != "2024-01-08" || summary.WeekEnd != "2024-01-14" || summary.Timezone != "Europe/Berlin" {
+		t.Errorf("expected the week of Monday 2024-01-08 in Berlin, got %+v", summary)
+	}
+	if summary.SessionsCompleted != 2 || summary.TotalDurationMinutes != 105 || summary.TotalVolumeKg != 8250.5 || summary.SetCount != 32 {
+		t.Errorf("unexpected totals %+v", summary)
+	}
+	if len(summary.PersonalRecords) != 1 || summary.PersonalRecords[0].WeightKg != 142.5 {
+		t.Errorf("unexpected records %+v", summary.PersonalRecords)
+	}
+	if summary.StreakWeeks != 3 {
+		t.Errorf("expected a 3 week streak, got %d", summary.StreakWeeks)
+	}
+
+	// Without tz the week is counted in the timezone saved on the user's profile
+	user, err := db.CreateUser(context.Background(), &database.Users{Email: "ann@example.com", Username: "ann", Timezone: "America/Denver"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req = httptest.NewRequest("GET", "/api/v1/analytics/weekly-summary?week=2024-01-10", nil)
+	req.Header.Set("Authorization", bearer(t, user.Id))
+	resp, err = s.App.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	json.NewDecoder(resp.Body).Decode(&body)
+	if body.Data.Timezone != "America/Denver" {
+		t.Errorf("expected the user's timezone, got %q", body.Data.Timezone)
+	}
+
+	req = httptest.NewRequest("GET", "/api/v1/analytics/weekly-summary?week=last", nil)
+	req.Header.Set("Authorization", bearer(t, "u1"))
+	if resp, err := s.App.Test(req, -1); err != nil || resp.StatusCode != 400 {
+		t.Errorf("expected a bad week to be rejected, got %v, %v", resp.StatusCode, err)
+	}
+}
+
+func TestStreakWeeks(t *testing.T) {
+	weeks := []time.Time{
+		time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2023, 12, 25, 0, 0, 0, 0, time.UTC),
+	}
+	if got := streakWeeks(weeks, time.Date(2024, 1, 8, 0, 0, 0, 0, time.UTC)); got != 0 {
+		t.Errorf("expected no streak for a week without sessions, got %d", got)
+	}
+	if got := streakWeeks(weeks, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)); got != 2 {
+		t.Errorf("expected a 2 week streak, got %d", got)
+	}
+}
+
+func TestWeeklySummaryEmail(t *testing.T) {
+	msg := weeklySummaryEmail("ann@example.com", &database.WeeklySummaryResponse{
+		WeekStart:            "2024-01-08",
+		WeekEnd:              "2024-01-14",
+		SessionsCompleted:    1,
+		TotalDurationMinutes: 50,
+		TotalVolumeKg:        4100.4,
+		SetCount:             18,
+		StreakWeeks:          4,
+		PersonalRecords: []database.WeeklyPersonalRecordResponse{
+			{ExerciseName: "Deadlift", WeightKg: 182.5, Reps: 1, PreviousBestKg: 180},
+		},
+	})
+	if msg.To != "ann@example.com" || msg.Subject != "Your week in FitnessHack: 1 session" {
+		t.Errorf("unexpected message %+v", msg)
+	}
+	for _, line := range []string{"18 sets moving 4100 kg", "4 weeks in a row", "Deadlift: 182.5 kg x 1 (previous best 180 kg)"} {
+		if !strings.Contains(msg.Body, line) {
+			t.Errorf("expected the body to mention %q, got:\n%s", line, msg.Body)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"fitness-hack/internal/analytics"
+	"fitness-hack/internal/database"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	defaultWorkloadWeeks = 8
+	maxWorkloadWeeks     = 26
+)
+
+func workloadWeekToResponse(w analytics.Week) database.WorkloadWeekResponse {
+	return database.WorkloadWeekResponse{
+		WeekEnding:      w.End,
+		AcuteVolumeKg:   w.AcuteKg,
+		ChronicVolumeKg: w.ChronicKg,
+		Ratio:           w.Ratio,
+	}
+}
+
+// GET /api/v1/analytics/readiness?weeks=8
+// Returns the feedback readiness of GET /users/me/readiness with the acute:chronic ratio
+// of the volume lifted, for the current week and the weeks before it
+func (s *FiberServer) getAnalyticsReadiness(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+	weeks, err := strconv.Atoi(c.Query("weeks", strconv.Itoa(defaultWorkloadWeeks)))
+	if err != nil || weeks < 1 || weeks > maxWorkloadWeeks {
+		return errorResponse(c, fiber.StatusBadRequest, fmt.Sprintf("weeks must be between 1 and %d", maxWorkloadWeeks))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now().UTC()
+	loads, err := s.db.ListSessionLoads(ctx, userID, now.AddDate(0, 0, -7*chronicLoadWeeks))
+	if err != nil {
+		LogDatabaseError(s, "list_session_loads", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to get readiness")
+	}
+
+	// The oldest week reported needs a full chronic window before it
+	since := now.AddDate(0, 0, -7*(weeks-1+analytics.ChronicWeeks))
+	volumes, err := s.db.ListSessionVolumes(ctx, userID, since)
+	if err != nil {
+		LogDatabaseError(s, "list_session_volumes", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to get readiness")
+	}
+	sessions := make([]analytics.Session, len(volumes))
+	for i, v := range volumes {
+		sessions[i] = analytics.Session{At: v.Started_at, VolumeKg: v.Volume_kg.InexactFloat64()}
+	}
+	workload := analytics.ComputeWorkload(sessions, now, weeks)
+
+	response := database.AnalyticsReadinessResponse{
+		Readiness: computeReadiness(loads, now),
+		Workload: database.WorkloadResponse{
+			AcuteVolumeKg:   workload.AcuteKg,
+			ChronicVolumeKg: workload.ChronicKg,
+			Ratio:           workload.Ratio,
+			Status:          string(workload.Status),
+			DeloadSuggested: workload.DeloadSuggested,
+			Reasons:         workload.Reasons,
+			History:         make([]database.WorkloadWeekResponse, len(workload.History)),
+		},
+	}
+	for i, w := range workload.History {
+		response.Workload.History[i] = workloadWeekToResponse(w)
+	}
+	return successResponse(c, response)
+}
@@ -3,10 +3,12 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
 	"fitness-hack/internal/database"
+	"fitness-hack/internal/messages"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/shopspring/decimal"
@@ -17,8 +19,8 @@ func workoutExerciseCacheKey(id string) string {
 	return fmt.Sprintf("workout_exercise:%s", id)
 }
 
-func workoutExercisesListCacheKey(limit, offset int) string {
-	return fmt.Sprintf("workout_exercises:list:%d:%d", limit, offset)
+func workoutExercisesListCacheKey(opts database.ListOptions) string {
+	return fmt.Sprintf("workout_exercises:list:%d:%d:%s:%s", opts.Limit, opts.Offset, opts.Sort, opts.Order)
 }
 
 // Helper to convert database workout exercise to response model
@@ -44,7 +46,50 @@ func workoutExerciseToResponse(we *database.Workout_exercises) database.WorkoutE
 		RestSeconds:     we.Rest_seconds,
 		Notes:           we.Notes,
 		CreatedAt:       we.Created_at,
+		UpdatedAt:       we.Updated_at,
+		Version:         we.Version,
+
+		WeightPrescription: weightPrescriptionOf(we),
+	}
+}
+
+// workoutExercisesETag returns the ETag of a list of workout exercises
+func workoutExercisesETag(responses []database.WorkoutExerciseResponse) string {
+	tags := make([]string, len(responses))
+	for i, response := range responses {
+		tags[i] = resourceETag(response.ID, response.UpdatedAt)
+	}
+	return listETag(tags)
+}
+
+// emitPersonalRecord sends a pr.achieved webhook event and push notification, and posts to
+// the user's organizations' community channels, if the workout exercise is a new best
+func (s *FiberServer) emitPersonalRecord(workoutExerciseID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	record, err := s.db.GetPersonalRecord(ctx, workoutExerciseID)
+	if err != nil {
+		s.logError("ERROR", messages.PersonalRecordCheckFailed, err, nil, map[string]interface{}{
+			"component":           "webhooks",
+			"workout_exercise_id": workoutExerciseID,
+		})
+		return
+	}
+	if record == nil {
+		return
 	}
+
+	s.emitWebhookEvent(record.User_id, webhookEventPRAchieved, database.PersonalRecordResponse{
+		ExerciseID:        record.Exercise_id,
+		WorkoutID:         record.Workout_id,
+		WorkoutExerciseID: record.Workout_exercise_id,
+		WeightKg:          record.Weight_kg.InexactFloat64(),
+		Reps:              record.Reps,
+		PreviousBestKg:    record.Previous_best_kg.InexactFloat64(),
+	})
+	s.notifyPersonalRecord(record)
+	s.postPersonalRecordToCommunities(record)
 }
 
 // Workout exercises handlers
@@ -53,6 +98,10 @@ func (s *FiberServer) createWorkoutExercise(c *fiber.Ctx) error {
 	if err := c.BodyParser(&req); err != nil {
 		return errorResponse(c, fiber.StatusBadRequest, "Invalid request body")
 	}
+	if err := validateWeightPrescription(req.WeightPrescription); err != nil {
+		LogValidationError(s, "weightPrescription", err, c)
+		return errorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
 
 	// Create database workout exercise
 	workoutExercise := database.Workout_exercises{
@@ -67,18 +116,21 @@ func (s *FiberServer) createWorkoutExercise(c *fiber.Ctx) error {
 		Notes:            req.Notes,
 		Created_at:       time.Now(),
 	}
+	setWeightPrescription(&workoutExercise, req.WeightPrescription)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	createdWorkoutExercise, err := s.db.CreateWorkoutExercise(ctx, &workoutExercise)
 	if err != nil {
-		return errorResponse(c, fiber.StatusInternalServerError, "Failed to create workout exercise: "+err.Error())
+		return serverError("Failed to create workout exercise", err)
 	}
 
 	// Invalidate workout exercises list cache
 	s.cache.Del(ctx, "workout_exercises:list:*")
 
+	go s.emitPersonalRecord(createdWorkoutExercise.Id)
+
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
 		"data": workoutExerciseToResponse(createdWorkoutExercise),
 	})
@@ -98,14 +150,14 @@ func (s *FiberServer) getWorkoutExercise(c *fiber.Ctx) error {
 	if cachedData, err := s.GetCache(ctx, cacheKey); err == nil {
 		var workoutExercise database.Workout_exercises
 		if json.Unmarshal([]byte(cachedData), &workoutExercise) == nil {
-			return successResponse(c, workoutExerciseToResponse(&workoutExercise))
+			return respondWithETag(c, resourceETag(workoutExercise.Id, workoutExercise.Updated_at), workoutExerciseToResponse(&workoutExercise))
 		}
 	}
 
 	// Get from database
 	workoutExercise, err := s.db.GetWorkoutExerciseByID(ctx, id)
 	if err != nil {
-		return errorResponse(c, fiber.StatusNotFound, "Workout exercise not found")
+		return notFound(c, "Workout exercise")
 	}
 
 	// Cache the workout exercise data
@@ -113,17 +165,17 @@ func (s *FiberServer) getWorkoutExercise(c *fiber.Ctx) error {
 		s.SetCache(ctx, cacheKey, string(workoutExerciseData), 10*time.Minute)
 	}
 
-	return successResponse(c, workoutExerciseToResponse(workoutExercise))
+	return respondWithETag(c, resourceETag(workoutExercise.Id, workoutExercise.Updated_at), workoutExerciseToResponse(workoutExercise))
 }
 
 func (s *FiberServer) listWorkoutExercises(c *fiber.Ctx) error {
-	limit, offset := getPaginationParams(c)
+	opts := getListOptions(c)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	// Try to get from cache first
-	cacheKey := workoutExercisesListCacheKey(limit, offset)
+	cacheKey := workoutExercisesListCacheKey(opts)
 	if cachedData, err := s.GetCache(ctx, cacheKey); err == nil {
 		var workoutExercises []database.Workout_exercises
 		if json.Unmarshal([]byte(cachedData), &workoutExercises) == nil {
@@ -132,14 +184,17 @@ func (s *FiberServer) listWorkoutExercises(c *fiber.Ctx) error {
 			for i, we := range workoutExercises {
 				responses[i] = workoutExerciseToResponse(&we)
 			}
-			return successResponse(c, responses)
+			return respondWithETag(c, workoutExercisesETag(responses), responses)
 		}
 	}
 
 	// Get from database
-	workoutExercises, err := s.db.ListWorkoutExercises(ctx, limit, offset)
+	workoutExercises, err := s.db.ListWorkoutExercises(ctx, opts)
+	if errors.Is(err, database.ErrInvalidListOption) {
+		return errorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
 	if err != nil {
-		return errorResponse(c, fiber.StatusInternalServerError, "Failed to fetch workout exercises: "+err.Error())
+		return serverError("Failed to fetch workout exercises", err)
 	}
 
 	// Cache the workout exercises data
@@ -153,7 +208,7 @@ func (s *FiberServer) listWorkoutExercises(c *fiber.Ctx) error {
 		responses[i] = workoutExerciseToResponse(&we)
 	}
 
-	return successResponse(c, responses)
+	return respondWithETag(c, workoutExercisesETag(responses), responses)
 }
 
 func (s *FiberServer) updateWorkoutExercise(c *fiber.Ctx) error {
@@ -167,13 +222,57 @@ func (s *FiberServer) updateWorkoutExercise(c *fiber.Ctx) error {
 		return errorResponse(c, fiber.StatusBadRequest, "Invalid request body")
 	}
 
+	return s.saveWorkoutExerciseUpdate(c, id, req, nil)
+}
+
+// workoutExercisePatchFields are the workout exercise fields a merge patch may change
+var workoutExercisePatchFields = patchFields{
+	"workoutId":          false,
+	"exerciseId":         false,
+	"sets":               false,
+	"reps":               false,
+	"weightKg":           false,
+	"durationSeconds":    false,
+	"orderIndex":         false,
+	"restSeconds":        false,
+	"notes":              true,
+	"weightPrescription": true,
+	"version":            false,
+}
+
+// PATCH /api/v1/workout-exercises/:id
+func (s *FiberServer) patchWorkoutExercise(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if id == "" {
+		return errorResponse(c, fiber.StatusBadRequest, "Workout exercise ID is required")
+	}
+
+	var req database.UpdateWorkoutExerciseRequest
+	cleared, err := applyMergePatch(c.Body(), &req, workoutExercisePatchFields)
+	if err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	return s.saveWorkoutExerciseUpdate(c, id, req, cleared)
+}
+
+// saveWorkoutExerciseUpdate applies the set fields of req to the stored workout exercise,
+// clears the fields in cleared, and saves it
+func (s *FiberServer) saveWorkoutExerciseUpdate(c *fiber.Ctx, id string, req database.UpdateWorkoutExerciseRequest, cleared map[string]bool) error {
 	// Get existing workout exercise
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	existingWorkoutExercise, err := s.db.GetWorkoutExerciseByID(ctx, id)
 	if err != nil {
-		return errorResponse(c, fiber.StatusNotFound, "Workout exercise not found")
+		return notFound(c, "Workout exercise")
+	}
+
+	if etag := resourceETag(existingWorkoutExercise.Id, existingWorkoutExercise.Updated_at); ifMatchFails(c, etag) {
+		return preconditionFailed(c, etag)
+	}
+	if req.Version != nil {
+		existingWorkoutExercise.Version = *req.Version
 	}
 
 	// Update fields if provided
@@ -204,16 +303,43 @@ func (s *FiberServer) updateWorkoutExercise(c *fiber.Ctx) error {
 	if req.Notes != nil {
 		existingWorkoutExercise.Notes = *req.Notes
 	}
+	if cleared["notes"] {
+		existingWorkoutExercise.Notes = ""
+	}
+	if req.WeightPrescription != nil {
+		prescription := *req.WeightPrescription
+		// A merge patch only replaces the members of the prescription it names
+		if current := weightPrescriptionOf(existingWorkoutExercise); cleared != nil && current != nil {
+			if prescription.PercentOf == "" {
+				prescription.PercentOf = current.PercentOf
+			}
+			if prescription.Value == 0 {
+				prescription.Value = current.Value
+			}
+		}
+		if err := validateWeightPrescription(&prescription); err != nil {
+			LogValidationError(s, "weightPrescription", err, c)
+			return errorResponse(c, fiber.StatusBadRequest, err.Error())
+		}
+		setWeightPrescription(existingWorkoutExercise, &prescription)
+	}
+	if cleared["weightPrescription"] {
+		setWeightPrescription(existingWorkoutExercise, nil)
+	}
 
 	updatedWorkoutExercise, err := s.db.UpdateWorkoutExercise(ctx, existingWorkoutExercise)
+	if errors.Is(err, database.ErrVersionConflict) {
+		return versionConflict(c)
+	}
 	if err != nil {
-		return errorResponse(c, fiber.StatusInternalServerError, "Failed to update workout exercise: "+err.Error())
+		return serverError("Failed to update workout exercise", err)
 	}
 
 	// Invalidate cache
 	s.DeleteCache(ctx, workoutExerciseCacheKey(id))
 	s.cache.Del(ctx, "workout_exercises:list:*")
 
+	c.Set(fiber.HeaderETag, resourceETag(updatedWorkoutExercise.Id, updatedWorkoutExercise.Updated_at))
 	return successResponse(c, workoutExerciseToResponse(updatedWorkoutExercise))
 }
 
@@ -226,9 +352,19 @@ func (s *FiberServer) deleteWorkoutExercise(c *fiber.Ctx) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	if c.Get(fiber.HeaderIfMatch) != "" {
+		existing, err := s.db.GetWorkoutExerciseByID(ctx, id)
+		if err != nil {
+			return notFound(c, "Workout exercise")
+		}
+		if etag := resourceETag(existing.Id, existing.Updated_at); ifMatchFails(c, etag) {
+			return preconditionFailed(c, etag)
+		}
+	}
+
 	err := s.db.DeleteWorkoutExercise(ctx, id)
 	if err != nil {
-		return errorResponse(c, fiber.StatusInternalServerError, "Failed to delete workout exercise: "+err.Error())
+		return serverError("Failed to delete workout exercise", err)
 	}
 
 	// Invalidate cache
@@ -3,6 +3,7 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
@@ -16,24 +17,52 @@ func workoutSessionCacheKey(id string) string {
 	return fmt.Sprintf("workout_session:%s", id)
 }
 
-func workoutSessionsListCacheKey(limit, offset int) string {
-	return fmt.Sprintf("workout_sessions:list:%d:%d", limit, offset)
+func workoutSessionsListCacheKey(opts database.ListOptions) string {
+	return fmt.Sprintf("workout_sessions:list:%d:%d:%s:%s", opts.Limit, opts.Offset, opts.Sort, opts.Order)
 }
 
 // Helper to convert database workout session to response model
 func workoutSessionToResponse(ws *database.Workout_sessions) database.WorkoutSessionResponse {
-	return database.WorkoutSessionResponse{
+	var workoutID string
+	if ws.Workout_id != nil {
+		workoutID = *ws.Workout_id
+	}
+	response := database.WorkoutSessionResponse{
 		ID:              ws.Id,
 		UserID:          ws.User_id,
-		WorkoutID:       ws.Workout_id,
-		Name:            ws.Name.(string),
+		WorkoutID:       workoutID,
+		Name:            ws.Name,
 		StartedAt:       ws.Started_at,
 		CompletedAt:     &ws.Completed_at,
 		DurationMinutes: ws.Duration_minutes,
 		Notes:           ws.Notes,
+		Source:          ws.Source,
+		ExerciseID:      ws.Exercise_id,
+		DurationSeconds: ws.Duration_seconds,
+		DistanceMeters:  ws.Distance_meters,
+		AvgHeartRate:    ws.Avg_heart_rate,
+		MaxHeartRate:    ws.Max_heart_rate,
 		CreatedAt:       ws.Created_at,
 		UpdatedAt:       ws.Updated_at,
+		Version:         ws.Version,
+	}
+	if ws.Temperature_c != nil && ws.Wind_speed_kmh != nil && ws.Wind_direction != nil {
+		response.Weather = &database.SessionWeatherResponse{
+			TemperatureC:  *ws.Temperature_c,
+			WindSpeedKmh:  *ws.Wind_speed_kmh,
+			WindDirection: *ws.Wind_direction,
+		}
 	}
+	return response
+}
+
+// workoutSessionsETag returns the ETag of a list of workout sessions
+func workoutSessionsETag(responses []database.WorkoutSessionResponse) string {
+	tags := make([]string, len(responses))
+	for i, response := range responses {
+		tags[i] = resourceETag(response.ID, response.UpdatedAt)
+	}
+	return listETag(tags)
 }
 
 // Workout sessions handlers
@@ -55,7 +84,6 @@ func (s *FiberServer) createWorkoutSession(c *fiber.Ctx) error {
 	// Create database workout session
 	workoutSession := database.Workout_sessions{
 		User_id:          userID,
-		Workout_id:       req.WorkoutID,
 		Name:             req.Name,
 		Started_at:       startedAt,
 		Completed_at:     *req.CompletedAt,
@@ -64,20 +92,39 @@ func (s *FiberServer) createWorkoutSession(c *fiber.Ctx) error {
 		Created_at:       time.Now(),
 		Updated_at:       time.Now(),
 	}
+	if req.WorkoutID != "" {
+		workoutSession.Workout_id = &req.WorkoutID
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	// Percentage prescriptions are resolved now so later training max changes don't
+	// rewrite a session that's under way
+	if workoutSession.Workout_id != nil {
+		plan, err := s.sessionPlan(ctx, userID, *workoutSession.Workout_id, startedAt)
+		if err != nil {
+			LogDatabaseError(s, "resolve_session_plan", err, c)
+			return errorResponse(c, fiber.StatusInternalServerError, "Failed to create workout session")
+		}
+		workoutSession.Plan = plan
+	}
+
 	createdWorkoutSession, err := s.db.CreateWorkoutSession(ctx, &workoutSession)
 	if err != nil {
-		return errorResponse(c, fiber.StatusInternalServerError, "Failed to create workout session: "+err.Error())
+		return serverError("Failed to create workout session", err)
 	}
 
 	// Invalidate workout sessions list cache
 	s.cache.Del(ctx, "workout_sessions:list:*")
 
+	response := workoutSessionToResponse(createdWorkoutSession)
+	if !createdWorkoutSession.Completed_at.IsZero() {
+		s.emitWebhookEvent(userID, webhookEventSessionCompleted, response)
+	}
+
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
-		"data": workoutSessionToResponse(createdWorkoutSession),
+		"data": response,
 	})
 }
 
@@ -95,14 +142,14 @@ func (s *FiberServer) getWorkoutSession(c *fiber.Ctx) error {
 	if cachedData, err := s.GetCache(ctx, cacheKey); err == nil {
 		var workoutSession database.Workout_sessions
 		if json.Unmarshal([]byte(cachedData), &workoutSession) == nil {
-			return successResponse(c, workoutSessionToResponse(&workoutSession))
+			return s.respondWithWorkoutSession(ctx, c, &workoutSession)
 		}
 	}
 
 	// Get from database
 	workoutSession, err := s.db.GetWorkoutSessionByID(ctx, id)
 	if err != nil {
-		return errorResponse(c, fiber.StatusNotFound, "Workout session not found")
+		return notFound(c, "Workout session")
 	}
 
 	// Cache the workout session data
@@ -110,37 +157,64 @@ func (s *FiberServer) getWorkoutSession(c *fiber.Ctx) error {
 		s.SetCache(ctx, cacheKey, string(workoutSessionData), 10*time.Minute)
 	}
 
-	return successResponse(c, workoutSessionToResponse(workoutSession))
+	return s.respondWithWorkoutSession(ctx, c, workoutSession)
 }
 
+// respondWithWorkoutSession sends the session with its ETag. The owner also gets the rest
+// timer running in it, which changes without the session being updated, so the response
+// isn't tagged while one runs.
+func (s *FiberServer) respondWithWorkoutSession(ctx context.Context, c *fiber.Ctx, workoutSession *database.Workout_sessions) error {
+	response := workoutSessionToResponse(workoutSession)
+	if userID, err := getUserIDFromJWT(c); err == nil && userID == workoutSession.User_id {
+		// The timer is best effort; the session is still returned when Redis is down
+		response.RestTimer, _ = s.loadRestTimer(ctx, workoutSession.Id, time.Now())
+	}
+	if response.RestTimer != nil {
+		return successResponse(c, response)
+	}
+	return respondWithETag(c, resourceETag(workoutSession.Id, workoutSession.Updated_at), response)
+}
+
+// GET /api/v1/workout-sessions?from=&to=&sort=&order=
+// Lists over a date range aren't cached.
 func (s *FiberServer) listWorkoutSessions(c *fiber.Ctx) error {
-	limit, offset := getPaginationParams(c)
+	opts := database.ListWorkoutSessionsOpts{ListOptions: getListOptions(c)}
+	var err error
+	if opts.From, opts.To, err = getDateRange(c); err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+	ranged := !opts.From.IsZero() || !opts.To.IsZero()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	// Try to get from cache first
-	cacheKey := workoutSessionsListCacheKey(limit, offset)
-	if cachedData, err := s.GetCache(ctx, cacheKey); err == nil {
-		var workoutSessions []database.Workout_sessions
-		if json.Unmarshal([]byte(cachedData), &workoutSessions) == nil {
-			// Convert to response models
-			responses := make([]database.WorkoutSessionResponse, len(workoutSessions))
-			for i, ws := range workoutSessions {
-				responses[i] = workoutSessionToResponse(&ws)
+	cacheKey := workoutSessionsListCacheKey(opts.ListOptions)
+	if !ranged {
+		if cachedData, err := s.GetCache(ctx, cacheKey); err == nil {
+			var workoutSessions []database.Workout_sessions
+			if json.Unmarshal([]byte(cachedData), &workoutSessions) == nil {
+				// Convert to response models
+				responses := make([]database.WorkoutSessionResponse, len(workoutSessions))
+				for i, ws := range workoutSessions {
+					responses[i] = workoutSessionToResponse(&ws)
+				}
+				return respondWithETag(c, workoutSessionsETag(responses), responses)
 			}
-			return successResponse(c, responses)
 		}
 	}
 
 	// Get from database
-	workoutSessions, err := s.db.ListWorkoutSessions(ctx, limit, offset)
+	workoutSessions, err := s.db.ListWorkoutSessions(ctx, opts)
+	if errors.Is(err, database.ErrInvalidListOption) {
+		return errorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
 	if err != nil {
-		return errorResponse(c, fiber.StatusInternalServerError, "Failed to fetch workout sessions: "+err.Error())
+		return serverError("Failed to fetch workout sessions", err)
 	}
 
 	// Cache the workout sessions data
-	if workoutSessionsData, err := json.Marshal(workoutSessions); err == nil {
+	if workoutSessionsData, err := json.Marshal(workoutSessions); err == nil && !ranged {
 		s.SetCache(ctx, cacheKey, string(workoutSessionsData), 10*time.Minute)
 	}
 
@@ -150,7 +224,7 @@ func (s *FiberServer) listWorkoutSessions(c *fiber.Ctx) error {
 		responses[i] = workoutSessionToResponse(&ws)
 	}
 
-	return successResponse(c, responses)
+	return respondWithETag(c, workoutSessionsETag(responses), responses)
 }
 
 func (s *FiberServer) updateWorkoutSession(c *fiber.Ctx) error {
@@ -164,46 +238,138 @@ func (s *FiberServer) updateWorkoutSession(c *fiber.Ctx) error {
 		return errorResponse(c, fiber.StatusBadRequest, "Invalid request body")
 	}
 
+	return s.saveWorkoutSessionUpdate(c, id, req, nil)
+}
+
+// workoutSessionPatchFields are the workout session fields a merge patch may change
+var workoutSessionPatchFields = patchFields{
+	"workoutId":       true,
+	"name":            false,
+	"startedAt":       false,
+	"completedAt":     true,
+	"durationMinutes": false,
+	"notes":           true,
+	"version":         false,
+}
+
+// PATCH /api/v1/workout-sessions/:id
+func (s *FiberServer) patchWorkoutSession(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if id == "" {
+		return errorResponse(c, fiber.StatusBadRequest, "Workout session ID is required")
+	}
+
+	var req database.UpdateWorkoutSessionRequest
+	cleared, err := applyMergePatch(c.Body(), &req, workoutSessionPatchFields)
+	if err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	return s.saveWorkoutSessionUpdate(c, id, req, cleared)
+}
+
+// saveWorkoutSessionUpdate applies the set fields of req to the stored workout session,
+// clears the fields in cleared, and saves it. Clearing completedAt reopens the session.
+func (s *FiberServer) saveWorkoutSessionUpdate(c *fiber.Ctx, id string, req database.UpdateWorkoutSessionRequest, cleared map[string]bool) error {
 	// Get existing workout session
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	existingWorkoutSession, err := s.db.GetWorkoutSessionByID(ctx, id)
 	if err != nil {
-		return errorResponse(c, fiber.StatusNotFound, "Workout session not found")
+		return notFound(c, "Workout session")
+	}
+
+	if etag := resourceETag(existingWorkoutSession.Id, existingWorkoutSession.Updated_at); ifMatchFails(c, etag) {
+		return preconditionFailed(c, etag)
+	}
+	completing := applyWorkoutSessionUpdate(existingWorkoutSession, req, cleared)
+
+	updatedWorkoutSession, err := s.db.UpdateWorkoutSession(ctx, existingWorkoutSession)
+	if errors.Is(err, database.ErrVersionConflict) {
+		return versionConflict(c)
+	}
+	if err != nil {
+		return serverError("Failed to update workout session", err)
+	}
+
+	// Invalidate cache
+	s.DeleteCache(ctx, workoutSessionCacheKey(id))
+	s.cache.Del(ctx, "workout_sessions:list:*")
+
+	response := workoutSessionToResponse(updatedWorkoutSession)
+	if completing {
+		s.emitWebhookEvent(updatedWorkoutSession.User_id, webhookEventSessionCompleted, response)
+	}
+
+	c.Set(fiber.HeaderETag, resourceETag(updatedWorkoutSession.Id, updatedWorkoutSession.Updated_at))
+	return successResponse(c, response)
+}
+
+// applyWorkoutSessionUpdate sets the fields of session that req sets and clears those in
+// cleared. It reports whether the update completes the session.
+func applyWorkoutSessionUpdate(session *database.Workout_sessions, req database.UpdateWorkoutSessionRequest, cleared map[string]bool) bool {
+	if req.Version != nil {
+		session.Version = *req.Version
 	}
 
 	// Update fields if provided
 	if req.WorkoutID != nil {
-		existingWorkoutSession.Workout_id = *req.WorkoutID
+		session.Workout_id = req.WorkoutID
+		if *req.WorkoutID == "" {
+			session.Workout_id = nil
+		}
 	}
 	if req.Name != nil {
-		existingWorkoutSession.Name = *req.Name
+		session.Name = *req.Name
 	}
 	if req.StartedAt != nil {
-		existingWorkoutSession.Started_at = *req.StartedAt
+		session.Started_at = *req.StartedAt
 	}
+	completing := session.Completed_at.IsZero() && req.CompletedAt != nil && !req.CompletedAt.IsZero()
 	if req.CompletedAt != nil {
-		existingWorkoutSession.Completed_at = *req.CompletedAt
+		session.Completed_at = *req.CompletedAt
 	}
 	if req.DurationMinutes != nil {
-		existingWorkoutSession.Duration_minutes = *req.DurationMinutes
+		session.Duration_minutes = *req.DurationMinutes
 	}
 	if req.Notes != nil {
-		existingWorkoutSession.Notes = *req.Notes
+		session.Notes = *req.Notes
 	}
-	existingWorkoutSession.Updated_at = time.Now()
+	if cleared["workoutId"] {
+		session.Workout_id = nil
+	}
+	if cleared["completedAt"] {
+		session.Completed_at = time.Time{}
+	}
+	if cleared["notes"] {
+		session.Notes = ""
+	}
+	session.Updated_at = time.Now()
+	return completing
+}
 
-	updatedWorkoutSession, err := s.db.UpdateWorkoutSession(ctx, existingWorkoutSession)
+// GET /api/v1/workout-sessions/:id/plan
+func (s *FiberServer) getWorkoutSessionPlan(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
 	if err != nil {
-		return errorResponse(c, fiber.StatusInternalServerError, "Failed to update workout session: "+err.Error())
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
 	}
 
-	// Invalidate cache
-	s.DeleteCache(ctx, workoutSessionCacheKey(id))
-	s.cache.Del(ctx, "workout_sessions:list:*")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	session, err := s.db.GetWorkoutSessionByID(ctx, c.Params("id"))
+	if err != nil || session.User_id != userID {
+		return notFound(c, "Workout session")
+	}
 
-	return successResponse(c, workoutSessionToResponse(updatedWorkoutSession))
+	plan := []database.PlannedExerciseResponse{}
+	if err := json.Unmarshal(session.Plan, &plan); err != nil {
+		LogDatabaseError(s, "decode_session_plan", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to get workout session plan")
+	}
+	return successResponse(c, plan)
 }
 
 func (s *FiberServer) deleteWorkoutSession(c *fiber.Ctx) error {
@@ -215,9 +381,19 @@ func (s *FiberServer) deleteWorkoutSession(c *fiber.Ctx) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	if c.Get(fiber.HeaderIfMatch) != "" {
+		existing, err := s.db.GetWorkoutSessionByID(ctx, id)
+		if err != nil {
+			return notFound(c, "Workout session")
+		}
+		if etag := resourceETag(existing.Id, existing.Updated_at); ifMatchFails(c, etag) {
+			return preconditionFailed(c, etag)
+		}
+	}
+
 	err := s.db.DeleteWorkoutSession(ctx, id)
 	if err != nil {
-		return errorResponse(c, fiber.StatusInternalServerError, "Failed to delete workout session: "+err.Error())
+		return serverError("Failed to delete workout session", err)
 	}
 
 	// Invalidate cache
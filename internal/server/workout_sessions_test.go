@@ -0,0 +1,58 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"fitness-hack/internal/database"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestListWorkoutSessionsDateRange(t *testing.T) {
+	s, db := newFakeServer(t)
+	ctx := context.Background()
+	monday := time.Date(2025, 8, 4, 0, 0, 0, 0, time.UTC)
+	for i, name := range []string{"Last week", "Monday", "Sunday", "Next week"} {
+		started := monday.AddDate(0, 0, []int{-3, 0, 6, 7}[i]).Add(18 * time.Hour)
+		if _, err := db.CreateWorkoutSession(ctx, &database.Workout_sessions{User_id: "u1", Name: name, Started_at: started}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	auth := bearer(t, "u1")
+
+	list := func(query string) (int, []string) {
+		t.Helper()
+		req := httptest.NewRequest("GET", "/api/v1/workout-sessions"+query, nil)
+		req.Header.Set("Authorization", auth)
+		resp, err := s.App.Test(req, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var envelope struct {
+			Data []database.WorkoutSessionResponse `json:"data"`
+		}
+		json.NewDecoder(resp.Body).Decode(&envelope)
+		names := make([]string, len(envelope.Data))
+		for i, ws := range envelope.Data {
+			names[i] = ws.Name
+		}
+		return resp.StatusCode, names
+	}
+
+	week := "?from=2025-08-04T00:00:00Z&to=2025-08-11T00:00:00Z&sort=started_at"
+	if status, got := list(week); status != fiber.StatusOK || len(got) != 2 || got[0] != "Monday" || got[1] != "Sunday" {
+		t.Errorf("GET %s = %d %v, want the week's two sessions", week, status, got)
+	}
+	if status, got := list("?from=2025-08-04T00:00:00%2B02:00"); status != fiber.StatusOK || len(got) != 3 {
+		t.Errorf("open-ended range = %d %v, want three sessions", status, got)
+	}
+	for _, query := range []string{"?from=monday", "?from=2025-08-11T00:00:00Z&to=2025-08-04T00:00:00Z"} {
+		if status, _ := list(query); status != fiber.StatusBadRequest {
+			t.Errorf("GET %s = %d, want 400", query, status)
+		}
+	}
+}
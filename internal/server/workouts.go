@@ -2,7 +2,9 @@ package server
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
@@ -28,11 +30,23 @@ func workoutToResponse(workout *database.Workouts) database.WorkoutResponse {
 		Name:            workout.Name,
 		Description:     workout.Description,
 		DurationMinutes: workout.Duration_minutes,
+		ProgramID:       workout.Program_id,
+		IsTemplate:      workout.Is_template,
 		CreatedAt:       workout.Created_at,
 		UpdatedAt:       workout.Updated_at,
+		Version:         workout.Version,
 	}
 }
 
+// workoutsETag returns the ETag of a list of workouts
+func workoutsETag(responses []database.WorkoutResponse) string {
+	tags := make([]string, len(responses))
+	for i, response := range responses {
+		tags[i] = resourceETag(response.ID, response.UpdatedAt)
+	}
+	return listETag(tags)
+}
+
 // Workouts handlers
 func (s *FiberServer) createWorkout(c *fiber.Ctx) error {
 	var req database.CreateWorkoutRequest
@@ -49,14 +63,18 @@ func (s *FiberServer) createWorkout(c *fiber.Ctx) error {
 		Name:             req.Name,
 		Description:      req.Description,
 		Duration_minutes: req.DurationMinutes,
+		Is_template:      req.IsTemplate,
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	createdWorkout, err := s.db.CreateWorkout(ctx, &workout)
+	if quotaErr, ok := asQuotaError(err); ok {
+		return quotaExceeded(c, quotaErr)
+	}
 	if err != nil {
-		return errorResponse(c, fiber.StatusInternalServerError, "Failed to create workout: "+err.Error())
+		return serverError("Failed to create workout", err)
 	}
 
 	// Invalidate workouts list cache
@@ -81,14 +99,14 @@ func (s *FiberServer) getWorkout(c *fiber.Ctx) error {
 	if cachedData, err := s.GetCache(ctx, cacheKey); err == nil {
 		var workout database.Workouts
 		if json.Unmarshal([]byte(cachedData), &workout) == nil {
-			return successResponse(c, workoutToResponse(&workout))
+			return respondWithETag(c, resourceETag(workout.Id, workout.Updated_at), workoutToResponse(&workout))
 		}
 	}
 
 	// Get from database
 	workout, err := s.db.GetWorkoutByID(ctx, id)
 	if err != nil {
-		return errorResponse(c, fiber.StatusNotFound, "Workout not found")
+		return notFound(c, "Workout")
 	}
 
 	// Cache the workout data
@@ -96,37 +114,67 @@ func (s *FiberServer) getWorkout(c *fiber.Ctx) error {
 		s.SetCache(ctx, cacheKey, string(workoutData), 10*time.Minute)
 	}
 
-	return successResponse(c, workoutToResponse(workout))
+	return respondWithETag(c, resourceETag(workout.Id, workout.Updated_at), workoutToResponse(workout))
 }
 
+// workoutListOpts reads the filters and sort of a workout list from the query string
+func workoutListOpts(c *fiber.Ctx) (database.ListWorkoutsOpts, error) {
+	opts := database.ListWorkoutsOpts{
+		ListOptions: database.ListOptions{Sort: c.Query("sort"), Order: c.Query("order"), Filters: map[string]string{}},
+		UserID:      c.Query("userId"),
+		MuscleGroup: c.Query("muscleGroup"),
+	}
+	if programID := c.Query("programId"); programID != "" {
+		opts.Filters["program_id"] = programID
+	}
+
+	var err error
+	opts.From, opts.To, err = getDateRange(c)
+	return opts, err
+}
+
+// GET /api/v1/workouts?userId=&muscleGroup=&programId=&from=&to=&sort=&order=
+// Only the unfiltered list is cached.
 func (s *FiberServer) listWorkouts(c *fiber.Ctx) error {
 	limit, offset := getPaginationParams(c)
+	opts, err := workoutListOpts(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+	opts.Limit, opts.Offset = limit, offset
+	filtered := opts.UserID != "" || opts.MuscleGroup != "" || !opts.From.IsZero() || !opts.To.IsZero() ||
+		opts.Sort != "" || len(opts.Filters) > 0
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	// Try to get from cache first
 	cacheKey := workoutsListCacheKey(limit, offset)
-	if cachedData, err := s.GetCache(ctx, cacheKey); err == nil {
-		var workouts []database.Workouts
-		if json.Unmarshal([]byte(cachedData), &workouts) == nil {
-			// Convert to response models
-			responses := make([]database.WorkoutResponse, len(workouts))
-			for i, workout := range workouts {
-				responses[i] = workoutToResponse(&workout)
+	if !filtered {
+		if cachedData, err := s.GetCache(ctx, cacheKey); err == nil {
+			var workouts []database.Workouts
+			if json.Unmarshal([]byte(cachedData), &workouts) == nil {
+				// Convert to response models
+				responses := make([]database.WorkoutResponse, len(workouts))
+				for i, workout := range workouts {
+					responses[i] = workoutToResponse(&workout)
+				}
+				return respondWithETag(c, workoutsETag(responses), responses)
 			}
-			return successResponse(c, responses)
 		}
 	}
 
 	// Get from database
-	workouts, err := s.db.ListWorkouts(ctx, limit, offset)
+	workouts, err := s.db.ListWorkouts(ctx, opts)
+	if errors.Is(err, database.ErrInvalidListOption) {
+		return errorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
 	if err != nil {
-		return errorResponse(c, fiber.StatusInternalServerError, "Failed to fetch workouts: "+err.Error())
+		return serverError("Failed to fetch workouts", err)
 	}
 
 	// Cache the workouts data
-	if workoutsData, err := json.Marshal(workouts); err == nil {
+	if workoutsData, err := json.Marshal(workouts); err == nil && !filtered {
 		s.SetCache(ctx, cacheKey, string(workoutsData), 10*time.Minute)
 	}
 
@@ -136,7 +184,7 @@ func (s *FiberServer) listWorkouts(c *fiber.Ctx) error {
 		responses[i] = workoutToResponse(&workout)
 	}
 
-	return successResponse(c, responses)
+	return respondWithETag(c, workoutsETag(responses), responses)
 }
 
 func (s *FiberServer) updateWorkout(c *fiber.Ctx) error {
@@ -150,39 +198,96 @@ func (s *FiberServer) updateWorkout(c *fiber.Ctx) error {
 		return errorResponse(c, fiber.StatusBadRequest, "Invalid request body")
 	}
 
+	return s.saveWorkoutUpdate(c, id, req, nil)
+}
+
+// workoutPatchFields are the workout fields a merge patch may change
+var workoutPatchFields = patchFields{
+	"name":            false,
+	"description":     true,
+	"durationMinutes": false,
+	"isTemplate":      false,
+	"version":         false,
+}
+
+// PATCH /api/v1/workouts/:id
+func (s *FiberServer) patchWorkout(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if id == "" {
+		return errorResponse(c, fiber.StatusBadRequest, "Workout ID is required")
+	}
+
+	var req database.UpdateWorkoutRequest
+	cleared, err := applyMergePatch(c.Body(), &req, workoutPatchFields)
+	if err != nil {
+		return errorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+
+	return s.saveWorkoutUpdate(c, id, req, cleared)
+}
+
+// saveWorkoutUpdate applies the set fields of req to the stored workout, clears the
+// fields in cleared, and saves it
+func (s *FiberServer) saveWorkoutUpdate(c *fiber.Ctx, id string, req database.UpdateWorkoutRequest, cleared map[string]bool) error {
 	// Get existing workout
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	existingWorkout, err := s.db.GetWorkoutByID(ctx, id)
 	if err != nil {
-		return errorResponse(c, fiber.StatusNotFound, "Workout not found")
+		return notFound(c, "Workout")
 	}
 
-	// Update fields if provided
-	if req.Name != nil {
-		existingWorkout.Name = *req.Name
-	}
-	if req.Description != nil {
-		existingWorkout.Description = *req.Description
-	}
-	if req.DurationMinutes != nil {
-		existingWorkout.Duration_minutes = *req.DurationMinutes
+	if etag := resourceETag(existingWorkout.Id, existingWorkout.Updated_at); ifMatchFails(c, etag) {
+		return preconditionFailed(c, etag)
 	}
-	existingWorkout.Updated_at = time.Now()
+	applyWorkoutUpdate(existingWorkout, req, cleared)
 
 	updatedWorkout, err := s.db.UpdateWorkout(ctx, existingWorkout)
+	if errors.Is(err, database.ErrVersionConflict) {
+		return versionConflict(c)
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		// Deleted since it was read
+		return notFound(c, "Workout")
+	}
 	if err != nil {
-		return errorResponse(c, fiber.StatusInternalServerError, "Failed to update workout: "+err.Error())
+		return serverError("Failed to update workout", err)
 	}
 
 	// Invalidate cache
 	s.DeleteCache(ctx, workoutCacheKey(id))
 	s.cache.Del(ctx, "workouts:list:*")
 
+	c.Set(fiber.HeaderETag, resourceETag(updatedWorkout.Id, updatedWorkout.Updated_at))
 	return successResponse(c, workoutToResponse(updatedWorkout))
 }
 
+// applyWorkoutUpdate sets the fields of workout that req sets and clears those in cleared
+func applyWorkoutUpdate(workout *database.Workouts, req database.UpdateWorkoutRequest, cleared map[string]bool) {
+	if req.Version != nil {
+		workout.Version = *req.Version
+	}
+
+	// Update fields if provided
+	if req.Name != nil {
+		workout.Name = *req.Name
+	}
+	if req.Description != nil {
+		workout.Description = *req.Description
+	}
+	if req.DurationMinutes != nil {
+		workout.Duration_minutes = *req.DurationMinutes
+	}
+	if req.IsTemplate != nil {
+		workout.Is_template = *req.IsTemplate
+	}
+	if cleared["description"] {
+		workout.Description = ""
+	}
+	workout.Updated_at = time.Now()
+}
+
 func (s *FiberServer) deleteWorkout(c *fiber.Ctx) error {
 	id := c.Params("id")
 	if id == "" {
@@ -192,9 +297,19 @@ func (s *FiberServer) deleteWorkout(c *fiber.Ctx) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	if c.Get(fiber.HeaderIfMatch) != "" {
+		existing, err := s.db.GetWorkoutByID(ctx, id)
+		if err != nil {
+			return notFound(c, "Workout")
+		}
+		if etag := resourceETag(existing.Id, existing.Updated_at); ifMatchFails(c, etag) {
+			return preconditionFailed(c, etag)
+		}
+	}
+
 	err := s.db.DeleteWorkout(ctx, id)
 	if err != nil {
-		return errorResponse(c, fiber.StatusInternalServerError, "Failed to delete workout: "+err.Error())
+		return serverError("Failed to delete workout", err)
 	}
 
 	// Invalidate cache
@@ -203,3 +318,65 @@ func (s *FiberServer) deleteWorkout(c *fiber.Ctx) error {
 
 	return c.Status(fiber.StatusNoContent).Send(nil)
 }
+
+// GET /api/v1/workouts/templates
+func (s *FiberServer) listWorkoutTemplates(c *fiber.Ctx) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	workouts, err := s.db.ListWorkoutTemplates(ctx, getListOptions(c))
+	if errors.Is(err, database.ErrInvalidListOption) {
+		return errorResponse(c, fiber.StatusBadRequest, err.Error())
+	}
+	if err != nil {
+		LogDatabaseError(s, "list_workout_templates", err, c)
+		return errorResponse(c, fiber.StatusInternalServerError, "Failed to fetch workout templates")
+	}
+
+	responses := make([]database.WorkoutResponse, len(workouts))
+	for i, workout := range workouts {
+		responses[i] = workoutToResponse(&workout)
+	}
+
+	return respondWithETag(c, workoutsETag(responses), responses)
+}
+
+// POST /api/v1/workouts/:id/clone
+func (s *FiberServer) cloneWorkout(c *fiber.Ctx) error {
+	userID, err := getUserIDFromJWT(c)
+	if err != nil {
+		return errorResponse(c, fiber.StatusUnauthorized, "Unauthorized")
+	}
+
+	// The body is optional; an empty name keeps the original
+	var req database.CloneWorkoutRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return errorResponse(c, fiber.StatusBadRequest, "Invalid request body")
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	clone, err := s.db.CloneWorkout(ctx, c.Params("id"), userID, req.Name)
+	if quotaErr, ok := asQuotaError(err); ok {
+		return quotaExceeded(c, quotaErr)
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows), errors.Is(err, database.ErrNotCloneable):
+			return notFound(c, "Workout")
+		default:
+			LogDatabaseError(s, "clone_workout", err, c)
+			return errorResponse(c, fiber.StatusInternalServerError, "Failed to clone workout")
+		}
+	}
+
+	// Invalidate workouts list cache
+	s.cache.Del(ctx, "workouts:list:*")
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"data": workoutToResponse(clone),
+	})
+}
@@ -0,0 +1,92 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"fitness-hack/internal/database"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestWorkoutHandlersWithFakeDatabase(t *testing.T) {
+	s, db := newFakeServer(t)
+	workout, err := db.CreateWorkout(context.Background(), &database.Workouts{User_id: "u1", Name: "Leg day"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth := bearer(t, "u1")
+
+	do := func(method, path, body string) (int, database.WorkoutResponse) {
+		t.Helper()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", auth)
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := s.App.Test(req, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var envelope struct {
+			Data database.WorkoutResponse `json:"data"`
+		}
+		json.NewDecoder(resp.Body).Decode(&envelope)
+		return resp.StatusCode, envelope.Data
+	}
+
+	status, got := do("GET", "/api/v1/workouts/"+workout.Id, "")
+	if status != fiber.StatusOK || got.Name != "Leg day" || got.Version != 1 {
+		t.Fatalf("GET = %d %+v, want 200 with the seeded workout", status, got)
+	}
+	if status, _ := do("GET", "/api/v1/workouts/missing", ""); status != fiber.StatusNotFound {
+		t.Errorf("GET missing workout = %d, want 404", status)
+	}
+
+	status, got = do("PUT", "/api/v1/workouts/"+workout.Id, `{"name":"Squat day","version":1}`)
+	if status != fiber.StatusOK || got.Name != "Squat day" || got.Version != 2 {
+		t.Fatalf("PUT = %d %+v, want 200 at version 2", status, got)
+	}
+	if status, _ := do("PUT", "/api/v1/workouts/"+workout.Id, `{"name":"Stale","version":1}`); status != fiber.StatusConflict {
+		t.Errorf("PUT with a stale version = %d, want 409", status)
+	}
+}
+
+func TestListWorkoutsFilters(t *testing.T) {
+	s, db := newFakeServer(t)
+	ctx := context.Background()
+	for _, w := range []database.Workouts{{User_id: "u1", Name: "Leg day"}, {User_id: "u2", Name: "Push day"}} {
+		if _, err := db.CreateWorkout(ctx, &w); err != nil {
+			t.Fatal(err)
+		}
+	}
+	auth := bearer(t, "u1")
+
+	list := func(query string) (int, []database.WorkoutResponse) {
+		t.Helper()
+		req := httptest.NewRequest("GET", "/api/v1/workouts"+query, nil)
+		req.Header.Set("Authorization", auth)
+		resp, err := s.App.Test(req, -1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var envelope struct {
+			Data []database.WorkoutResponse `json:"data"`
+		}
+		json.NewDecoder(resp.Body).Decode(&envelope)
+		return resp.StatusCode, envelope.Data
+	}
+
+	if status, got := list("?userId=u1&from=2000-01-01T00:00:00Z"); status != fiber.StatusOK || len(got) != 1 || got[0].Name != "Leg day" {
+		t.Errorf("filtered list = %d %+v, want u1's workout", status, got)
+	}
+	if status, got := list("?sort=name&order=desc"); status != fiber.StatusOK || len(got) != 2 || got[0].Name != "Push day" {
+		t.Errorf("sorted list = %d %+v, want both workouts by name descending", status, got)
+	}
+	for _, query := range []string{"?from=yesterday", "?sort=password_hash"} {
+		if status, _ := list(query); status != fiber.StatusBadRequest {
+			t.Errorf("GET %s = %d, want 400", query, status)
+		}
+	}
+}
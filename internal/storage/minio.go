@@ -0,0 +1,56 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"os"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// NewMinIOFromEnv creates a store for MINIO_BUCKET on the MinIO server at MINIO_ENDPOINT,
+// such as http://minio:9000. Any other S3-compatible service that supports path-style
+// requests works too. Credentials come from MINIO_ACCESS_KEY and MINIO_SECRET_KEY.
+//
+// Presigned download URLs point at MINIO_PUBLIC_ENDPOINT if set, for servers that clients
+// reach under a different address than the API does.
+func NewMinIOFromEnv(ctx context.Context) (*S3, error) {
+	endpoint := os.Getenv("MINIO_ENDPOINT")
+	bucket := os.Getenv("MINIO_BUCKET")
+	if endpoint == "" || bucket == "" {
+		return nil, errors.New("MINIO_ENDPOINT and MINIO_BUCKET are required when STORAGE_DRIVER=minio")
+	}
+	accessKey, secretKey := os.Getenv("MINIO_ACCESS_KEY"), os.Getenv("MINIO_SECRET_KEY")
+	if accessKey == "" || secretKey == "" {
+		return nil, errors.New("MINIO_ACCESS_KEY and MINIO_SECRET_KEY are required when STORAGE_DRIVER=minio")
+	}
+
+	region := os.Getenv("MINIO_REGION")
+	if region == "" {
+		// MinIO ignores the region unless one is configured on the server
+		region = "us-east-1"
+	}
+	cfg := aws.Config{
+		Region: region,
+		Credentials: aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+			return aws.Credentials{AccessKeyID: accessKey, SecretAccessKey: secretKey, Source: "MinIO environment"}, nil
+		}),
+	}
+	client := newPathStyleClient(cfg, endpoint)
+
+	store := NewS3(client, bucket)
+	if public := os.Getenv("MINIO_PUBLIC_ENDPOINT"); public != "" {
+		store.presign = s3.NewPresignClient(newPathStyleClient(cfg, public))
+	}
+	return store, nil
+}
+
+// newPathStyleClient returns an S3 client for endpoint that puts the bucket in the path,
+// since self-hosted servers rarely have a DNS name per bucket
+func newPathStyleClient(cfg aws.Config, endpoint string) *s3.Client {
+	return s3.NewFromConfig(cfg, func(o *s3.Options) {
+		o.BaseEndpoint = aws.String(endpoint)
+		o.UsePathStyle = true
+	})
+}
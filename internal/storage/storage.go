@@ -0,0 +1,54 @@
+// Package storage stores generated files such as account exports in S3, on a self-hosted
+// S3-compatible server such as MinIO or, for local development, on the filesystem.
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"time"
+)
+
+var (
+	// ErrNotFound is returned when no object exists under the key
+	ErrNotFound = errors.New("object not found")
+
+	// ErrPresignUnsupported is returned by stores that cannot hand out direct download or
+	// upload URLs
+	ErrPresignUnsupported = errors.New("presigned urls are not supported by this store")
+)
+
+// Store reads and writes objects by key
+type Store interface {
+	Put(ctx context.Context, key string, body io.Reader, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+
+	// PresignGet returns a time-limited URL clients can download the object from directly
+	PresignGet(ctx context.Context, key string, ttl time.Duration) (string, error)
+
+	// PresignPut returns a time-limited URL clients can upload the object to directly with
+	// a PUT carrying the given Content-Type
+	PresignPut(ctx context.Context, key, contentType string, ttl time.Duration) (string, error)
+
+	// Ping checks that the store is reachable, for health checks
+	Ping(ctx context.Context) error
+}
+
+// NewFromEnv configures the store selected by STORAGE_DRIVER ("local" by default, "s3"
+// or "minio")
+func NewFromEnv(ctx context.Context) (Store, error) {
+	switch os.Getenv("STORAGE_DRIVER") {
+	case "s3":
+		return NewS3FromEnv(ctx)
+	case "minio":
+		return NewMinIOFromEnv(ctx)
+	default:
+		dir := os.Getenv("STORAGE_LOCAL_DIR")
+		if dir == "" {
+			dir = "./data/storage"
+		}
+		return NewLocal(dir), nil
+	}
+}
@@ -0,0 +1,145 @@
+// Package vault encrypts a user's private progress photos under a key only their PIN can
+// unlock. Each vault has a random data key that encrypts the photos; the data key is stored
+// wrapped (encrypted) under a key derived from the PIN, so changing the PIN only rewraps
+// the data key and the server can't read vaulted photos without the PIN.
+package vault
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// KeySize is the size of data keys and PIN-derived keys, for AES-256
+const KeySize = 32
+
+// PIN length bounds. PINs are digits only so they are quick to enter on a phone; failed
+// unlock attempts are limited by the server.
+const (
+	MinPINLength = 6
+	MaxPINLength = 12
+)
+
+var (
+	// ErrInvalidPIN is returned when a PIN isn't 6 to 12 digits
+	ErrInvalidPIN = fmt.Errorf("pin must be %d to %d digits", MinPINLength, MaxPINLength)
+
+	// ErrWrongPIN is returned when a PIN doesn't unwrap the vault's data key
+	ErrWrongPIN = errors.New("wrong pin")
+)
+
+// Argon2id parameters for deriving a key from a PIN
+const (
+	argonTime    = 3
+	argonMemory  = 64 * 1024
+	argonThreads = 2
+	saltSize     = 16
+)
+
+// ValidatePIN checks that pin is 6 to 12 digits
+func ValidatePIN(pin string) error {
+	if len(pin) < MinPINLength || len(pin) > MaxPINLength {
+		return ErrInvalidPIN
+	}
+	for _, r := range pin {
+		if r < '0' || r > '9' {
+			return ErrInvalidPIN
+		}
+	}
+	return nil
+}
+
+// New creates a vault for pin, returning the salt and wrapped data key to store and the
+// data key itself
+func New(pin string) (salt, wrappedKey, dataKey []byte, err error) {
+	if err := ValidatePIN(pin); err != nil {
+		return nil, nil, nil, err
+	}
+	salt = make([]byte, saltSize)
+	dataKey = make([]byte, KeySize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, nil, nil, err
+	}
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, nil, nil, err
+	}
+	wrappedKey, err = Seal(deriveKey(pin, salt), dataKey)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return salt, wrappedKey, dataKey, nil
+}
+
+// Unwrap returns the data key wrapped under pin, or ErrWrongPIN
+func Unwrap(pin string, salt, wrappedKey []byte) ([]byte, error) {
+	dataKey, err := Open(deriveKey(pin, salt), wrappedKey)
+	if err != nil {
+		return nil, ErrWrongPIN
+	}
+	return dataKey, nil
+}
+
+// Rewrap wraps dataKey under a new PIN, returning the new salt and wrapped key
+func Rewrap(newPIN string, dataKey []byte) (salt, wrappedKey []byte, err error) {
+	if err := ValidatePIN(newPIN); err != nil {
+		return nil, nil, err
+	}
+	salt = make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, nil, err
+	}
+	wrappedKey, err = Seal(deriveKey(newPIN, salt), dataKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return salt, wrappedKey, nil
+}
+
+// Seal encrypts plaintext under key with AES-GCM, prefixing the random nonce
+func Seal(key, plaintext []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// Open decrypts a value produced by Seal
+func Open(key, sealed []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("sealed value is too short")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt: %w", err)
+	}
+	return plaintext, nil
+}
+
+func deriveKey(pin string, salt []byte) []byte {
+	return argon2.IDKey([]byte(pin), salt, argonTime, argonMemory, argonThreads, KeySize)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("key must be %d bytes, got %d", KeySize, len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
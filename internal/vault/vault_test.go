@@ -0,0 +1,54 @@
+package vault
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestValidatePIN(t *testing.T) {
+	for pin, ok := range map[string]bool{
+		"123456":        true,
+		"000000000000":  true,
+		"12345":         false,
+		"1234567890123": false,
+		"12345a":        false,
+		"":              false,
+	} {
+		if err := ValidatePIN(pin); (err == nil) != ok {
+			t.Errorf("%q: expected ok=%v, got %v", pin, ok, err)
+		}
+	}
+}
+
+func TestVaultRoundTrip(t *testing.T) {
+	salt, wrapped, dataKey, err := New("246810")
+	if err != nil {
+		t.Fatalf("error creating vault. Err: %v", err)
+	}
+
+	unwrapped, err := Unwrap("246810", salt, wrapped)
+	if err != nil || !bytes.Equal(unwrapped, dataKey) {
+		t.Fatalf("expected the data key back. Err: %v", err)
+	}
+	if _, err := Unwrap("246811", salt, wrapped); !errors.Is(err, ErrWrongPIN) {
+		t.Fatalf("expected ErrWrongPIN, got %v", err)
+	}
+
+	sealed, err := Seal(dataKey, []byte("photo"))
+	if err != nil {
+		t.Fatalf("error sealing. Err: %v", err)
+	}
+	if opened, err := Open(dataKey, sealed); err != nil || string(opened) != "photo" {
+		t.Fatalf("expected round trip, got %q. Err: %v", opened, err)
+	}
+
+	// A new PIN unwraps the same data key, so vaulted photos stay readable
+	newSalt, rewrapped, err := Rewrap("13579135", dataKey)
+	if err != nil {
+		t.Fatalf("error rewrapping. Err: %v", err)
+	}
+	if unwrapped, err := Unwrap("13579135", newSalt, rewrapped); err != nil || !bytes.Equal(unwrapped, dataKey) {
+		t.Fatalf("expected the data key under the new pin. Err: %v", err)
+	}
+}
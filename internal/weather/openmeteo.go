@@ -0,0 +1,126 @@
+package weather
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+)
+
+const (
+	openMeteoArchiveURL  = "https://archive-api.open-meteo.com/v1/archive"
+	openMeteoForecastURL = "https://api.open-meteo.com/v1/forecast"
+
+	// openMeteoArchiveDelay is how long the archive takes to cover a day; more recent hours
+	// are looked up in the forecast API, which keeps the past three months
+	openMeteoArchiveDelay = 7 * 24 * time.Hour
+)
+
+// OpenMeteo looks up conditions in the Open-Meteo historical weather and forecast APIs
+type OpenMeteo struct {
+	ArchiveURL  string
+	ForecastURL string
+	// APIKey is only needed for the commercial plan, whose URLs it is used with
+	APIKey string
+	Client *http.Client
+}
+
+// NewOpenMeteoFromEnv configures Open-Meteo, using the commercial plan when
+// OPEN_METEO_API_KEY is set
+func NewOpenMeteoFromEnv() *OpenMeteo {
+	o := &OpenMeteo{
+		ArchiveURL:  openMeteoArchiveURL,
+		ForecastURL: openMeteoForecastURL,
+		APIKey:      os.Getenv("OPEN_METEO_API_KEY"),
+		Client:      &http.Client{Timeout: 15 * time.Second},
+	}
+	if o.APIKey != "" {
+		o.ArchiveURL = "https://customer-archive-api.open-meteo.com/v1/archive"
+		o.ForecastURL = "https://customer-api.open-meteo.com/v1/forecast"
+	}
+	return o
+}
+
+// openMeteoHourly holds the hourly series of a response. Hours without data are null.
+type openMeteoHourly struct {
+	Time          []string   `json:"time"`
+	Temperature   []*float64 `json:"temperature_2m"`
+	WindSpeed     []*float64 `json:"wind_speed_10m"`
+	WindDirection []*float64 `json:"wind_direction_10m"`
+}
+
+// Historical returns the conditions at the hour closest to at
+func (o *OpenMeteo) Historical(ctx context.Context, latitude, longitude float64, at time.Time) (*Conditions, error) {
+	hour := at.UTC().Round(time.Hour)
+	endpoint := o.ArchiveURL
+	if time.Since(hour) < openMeteoArchiveDelay {
+		endpoint = o.ForecastURL
+	}
+
+	params := url.Values{
+		"latitude":        {strconv.FormatFloat(latitude, 'f', 4, 64)},
+		"longitude":       {strconv.FormatFloat(longitude, 'f', 4, 64)},
+		"hourly":          {"temperature_2m,wind_speed_10m,wind_direction_10m"},
+		"wind_speed_unit": {"kmh"},
+		"timezone":        {"GMT"},
+		"start_date":      {hour.Format("2006-01-02")},
+		"end_date":        {hour.Format("2006-01-02")},
+	}
+	if o.APIKey != "" {
+		params.Set("apikey", o.APIKey)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := o.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("open-meteo request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	// Dates outside the range the API covers are rejected as a bad request
+	if resp.StatusCode == http.StatusBadRequest {
+		return nil, ErrNoData
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("open-meteo returned %d: %s", resp.StatusCode, body)
+	}
+
+	var result struct {
+		Hourly openMeteoHourly `json:"hourly"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("invalid open-meteo response: %w", err)
+	}
+	return result.Hourly.at(hour.Format("2006-01-02T15:04"))
+}
+
+// at returns the conditions of the given hour, formatted like the response's times
+func (h *openMeteoHourly) at(hour string) (*Conditions, error) {
+	for i, t := range h.Time {
+		if t != hour {
+			continue
+		}
+		if i >= len(h.Temperature) || i >= len(h.WindSpeed) || i >= len(h.WindDirection) ||
+			h.Temperature[i] == nil || h.WindSpeed[i] == nil || h.WindDirection[i] == nil {
+			return nil, ErrNoData
+		}
+		return &Conditions{
+			TemperatureC:  *h.Temperature[i],
+			WindSpeedKmh:  *h.WindSpeed[i],
+			WindDirection: int(math.Round(*h.WindDirection[i])) % 360,
+		}, nil
+	}
+	return nil, ErrNoData
+}
@@ -0,0 +1,62 @@
+package weather
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestOpenMeteoHistorical(t *testing.T) {
+	var archived, forecast int
+	handler := func(counter *int) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			*counter++
+			q := r.URL.Query()
+			if q.Get("latitude") != "52.5200" || q.Get("timezone") != "GMT" {
+				t.Errorf("unexpected query %s", r.URL.RawQuery)
+			}
+			if q.Get("start_date") == "1900-01-01" {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"error":true,"reason":"Parameter 'start_date' is out of allowed range"}`))
+				return
+			}
+			day := q.Get("start_date")
+			w.Write([]byte(`{"hourly":{"time":["` + day + `T06:00","` + day + `T07:00","` + day + `T08:00"],
+				"temperature_2m":[3.1,4.6,null],"wind_speed_10m":[10.2,14.8,null],"wind_direction_10m":[250,359.6,null]}}`))
+		}
+	}
+	archive := httptest.NewServer(handler(&archived))
+	defer archive.Close()
+	live := httptest.NewServer(handler(&forecast))
+	defer live.Close()
+
+	o := &OpenMeteo{ArchiveURL: archive.URL, ForecastURL: live.URL, Client: archive.Client()}
+	ctx := context.Background()
+
+	// 07:20 rounds to the 07:00 reading
+	conditions, err := o.Historical(ctx, 52.52, 13.405, time.Date(2024, 2, 1, 7, 20, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("error looking up weather. Err: %v", err)
+	}
+	if conditions.TemperatureC != 4.6 || conditions.WindSpeedKmh != 14.8 || conditions.WindDirection != 0 {
+		t.Errorf("unexpected conditions %+v", conditions)
+	}
+	if archived != 1 || forecast != 0 {
+		t.Errorf("expected an old session to be looked up in the archive, got %d archive and %d forecast requests", archived, forecast)
+	}
+
+	yesterday := time.Now().UTC().Add(-24 * time.Hour).Truncate(24 * time.Hour).Add(6 * time.Hour)
+	if _, err := o.Historical(ctx, 52.52, 13.405, yesterday); err != nil || forecast != 1 {
+		t.Errorf("expected a recent session to be looked up in the forecast API, got %d requests. Err: %v", forecast, err)
+	}
+
+	if _, err := o.Historical(ctx, 52.52, 13.405, time.Date(2024, 2, 1, 8, 0, 0, 0, time.UTC)); !errors.Is(err, ErrNoData) {
+		t.Errorf("expected ErrNoData for an hour without readings, got %v", err)
+	}
+	if _, err := o.Historical(ctx, 52.52, 13.405, time.Date(1900, 1, 1, 8, 0, 0, 0, time.UTC)); !errors.Is(err, ErrNoData) {
+		t.Errorf("expected ErrNoData for a date out of range, got %v", err)
+	}
+}
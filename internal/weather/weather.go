@@ -0,0 +1,40 @@
+// Package weather looks up past weather conditions, to tag outdoor sessions imported
+// with GPS so paces can be compared across hot, cold and windy days.
+package weather
+
+import (
+	"context"
+	"errors"
+	"os"
+	"time"
+)
+
+// ErrNoData is returned when the provider has no conditions for the place and time, e.g.
+// for a time before its records begin. Asking again won't help.
+var ErrNoData = errors.New("no weather data for this place and time")
+
+// Conditions is the weather at a place around a time
+type Conditions struct {
+	TemperatureC float64
+	WindSpeedKmh float64
+	// WindDirection is where the wind blows from, in degrees clockwise from north
+	WindDirection int
+}
+
+// Provider looks up past weather conditions
+type Provider interface {
+	// Historical returns the conditions at the coordinates, in decimal degrees, at the
+	// hour closest to the given time
+	Historical(ctx context.Context, latitude, longitude float64, at time.Time) (*Conditions, error)
+}
+
+// NewFromEnv returns the provider selected by WEATHER_PROVIDER ("open-meteo"), or nil when
+// it isn't set, in which case sessions aren't tagged
+func NewFromEnv() Provider {
+	switch os.Getenv("WEATHER_PROVIDER") {
+	case "open-meteo":
+		return NewOpenMeteoFromEnv()
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,334 @@
+// Package websocket implements the server side of the WebSocket protocol (RFC 6455) on
+// top of Fiber. It covers what the API needs: the upgrade handshake, text and binary
+// messages with fragmentation, and ping, pong and close handling. Extensions such as
+// per-message compression are not negotiated.
+package websocket
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// MessageType is the opcode of a data frame
+type MessageType int
+
+const (
+	TextMessage   MessageType = 1
+	BinaryMessage MessageType = 2
+
+	opContinuation = 0x0
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xA
+)
+
+// Close codes from RFC 6455 section 7.4.1
+const (
+	CloseNormal          = 1000
+	CloseGoingAway       = 1001
+	CloseProtocolError   = 1002
+	CloseUnsupportedData = 1003
+	CloseInvalidPayload  = 1007
+	ClosePolicyViolation = 1008
+	CloseMessageTooBig   = 1009
+	CloseInternalError   = 1011
+)
+
+// handshakeGUID is appended to the client's key to compute Sec-WebSocket-Accept
+const handshakeGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// writeTimeout bounds each frame write so a stalled client can't block its sender forever
+const writeTimeout = 10 * time.Second
+
+// CloseError is returned by ReadMessage once the connection is closed
+type CloseError struct {
+	Code   int
+	Reason string
+}
+
+func (e *CloseError) Error() string {
+	return fmt.Sprintf("websocket closed with code %d %s", e.Code, e.Reason)
+}
+
+// IsUpgrade reports whether the request asks to switch to the WebSocket protocol
+func IsUpgrade(c *fiber.Ctx) bool {
+	return c.Method() == fiber.MethodGet &&
+		strings.EqualFold(c.Get(fiber.HeaderUpgrade), "websocket") &&
+		headerContainsToken(c.Get(fiber.HeaderConnection), "upgrade")
+}
+
+func headerContainsToken(header, token string) bool {
+	for _, part := range strings.Split(header, ",") {
+		if strings.EqualFold(strings.TrimSpace(part), token) {
+			return true
+		}
+	}
+	return false
+}
+
+// acceptKey computes the Sec-WebSocket-Accept value for a client's Sec-WebSocket-Key
+func acceptKey(key string) string {
+	sum := sha1.Sum([]byte(key + handshakeGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// Upgrade completes the handshake and runs handler on the connection once the 101
+// response has been sent. The connection is closed when handler returns.
+func Upgrade(c *fiber.Ctx, maxMessageSize int64, handler func(*Conn)) error {
+	if !IsUpgrade(c) {
+		return c.Status(fiber.StatusUpgradeRequired).JSON(fiber.Map{"error": "WebSocket upgrade required"})
+	}
+	if c.Get("Sec-WebSocket-Version") != "13" {
+		c.Set("Sec-WebSocket-Version", "13")
+		return c.Status(fiber.StatusUpgradeRequired).JSON(fiber.Map{"error": "Unsupported WebSocket version"})
+	}
+	key := c.Get("Sec-WebSocket-Key")
+	if decoded, err := base64.StdEncoding.DecodeString(key); err != nil || len(decoded) != 16 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid Sec-WebSocket-Key"})
+	}
+
+	c.Status(fiber.StatusSwitchingProtocols)
+	c.Set(fiber.HeaderUpgrade, "websocket")
+	c.Set(fiber.HeaderConnection, "Upgrade")
+	c.Set("Sec-WebSocket-Accept", acceptKey(key))
+
+	c.Context().Hijack(func(nc net.Conn) {
+		conn := newConn(nc, maxMessageSize)
+		defer nc.Close()
+		handler(conn)
+	})
+	return nil
+}
+
+// Conn is an upgraded connection. Reads must happen from a single goroutine; writes are
+// safe from any goroutine.
+type Conn struct {
+	conn           net.Conn
+	br             *bufio.Reader
+	maxMessageSize int64
+
+	// OnPong, if set, is called from ReadMessage for every pong received
+	OnPong func()
+
+	wmu    sync.Mutex
+	closed bool
+}
+
+func newConn(nc net.Conn, maxMessageSize int64) *Conn {
+	return &Conn{conn: nc, br: bufio.NewReader(nc), maxMessageSize: maxMessageSize}
+}
+
+// SetReadDeadline sets the deadline for the next ReadMessage
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
+
+// frameHeader is the decoded start of a frame
+type frameHeader struct {
+	fin    bool
+	opcode byte
+	length int64
+	mask   [4]byte
+}
+
+func (c *Conn) readHeader() (frameHeader, error) {
+	var h frameHeader
+	var b [8]byte
+	if _, err := io.ReadFull(c.br, b[:2]); err != nil {
+		return h, err
+	}
+	h.fin = b[0]&0x80 != 0
+	h.opcode = b[0] & 0x0F
+	if b[0]&0x70 != 0 {
+		return h, c.fail(CloseProtocolError, "reserved bits set")
+	}
+	// Clients must mask every frame (section 5.1)
+	if b[1]&0x80 == 0 {
+		return h, c.fail(CloseProtocolError, "unmasked client frame")
+	}
+
+	switch length := b[1] & 0x7F; length {
+	case 126:
+		if _, err := io.ReadFull(c.br, b[:2]); err != nil {
+			return h, err
+		}
+		h.length = int64(binary.BigEndian.Uint16(b[:2]))
+	case 127:
+		if _, err := io.ReadFull(c.br, b[:8]); err != nil {
+			return h, err
+		}
+		h.length = int64(binary.BigEndian.Uint64(b[:8]))
+		if h.length < 0 {
+			return h, c.fail(CloseProtocolError, "invalid frame length")
+		}
+	default:
+		h.length = int64(length)
+	}
+
+	if h.opcode >= opClose && (!h.fin || h.length > 125) {
+		return h, c.fail(CloseProtocolError, "invalid control frame")
+	}
+	_, err := io.ReadFull(c.br, h.mask[:])
+	return h, err
+}
+
+func (c *Conn) readPayload(h frameHeader) ([]byte, error) {
+	payload := make([]byte, h.length)
+	if _, err := io.ReadFull(c.br, payload); err != nil {
+		return nil, err
+	}
+	for i := range payload {
+		payload[i] ^= h.mask[i%4]
+	}
+	return payload, nil
+}
+
+// ReadMessage returns the next data message. Pings are answered and close frames are
+// acknowledged while reading; once the peer closes, a *CloseError is returned.
+func (c *Conn) ReadMessage() (MessageType, []byte, error) {
+	var messageType MessageType
+	var message []byte
+
+	for {
+		h, err := c.readHeader()
+		if err != nil {
+			return 0, nil, err
+		}
+
+		switch h.opcode {
+		case opPing, opPong, opClose:
+			payload, err := c.readPayload(h)
+			if err != nil {
+				return 0, nil, err
+			}
+			switch h.opcode {
+			case opPing:
+				if err := c.writeFrame(opPong, payload); err != nil {
+					return 0, nil, err
+				}
+			case opPong:
+				if c.OnPong != nil {
+					c.OnPong()
+				}
+			case opClose:
+				closeErr := &CloseError{Code: 1005}
+				if len(payload) >= 2 {
+					closeErr.Code = int(binary.BigEndian.Uint16(payload))
+					closeErr.Reason = string(payload[2:])
+				}
+				c.WriteClose(CloseNormal, "")
+				return 0, nil, closeErr
+			}
+			continue
+
+		case opContinuation:
+			if messageType == 0 {
+				return 0, nil, c.fail(CloseProtocolError, "unexpected continuation frame")
+			}
+		case byte(TextMessage), byte(BinaryMessage):
+			if messageType != 0 {
+				return 0, nil, c.fail(CloseProtocolError, "expected continuation frame")
+			}
+			messageType = MessageType(h.opcode)
+		default:
+			return 0, nil, c.fail(CloseProtocolError, "unknown opcode")
+		}
+
+		if c.maxMessageSize > 0 && int64(len(message))+h.length > c.maxMessageSize {
+			return 0, nil, c.fail(CloseMessageTooBig, "message too big")
+		}
+		payload, err := c.readPayload(h)
+		if err != nil {
+			return 0, nil, err
+		}
+		message = append(message, payload...)
+
+		if h.fin {
+			if messageType == TextMessage && !utf8.Valid(message) {
+				return 0, nil, c.fail(CloseInvalidPayload, "invalid UTF-8")
+			}
+			return messageType, message, nil
+		}
+	}
+}
+
+// fail closes the connection with code and returns the matching error
+func (c *Conn) fail(code int, reason string) error {
+	c.WriteClose(code, reason)
+	return &CloseError{Code: code, Reason: reason}
+}
+
+func (c *Conn) writeFrame(opcode byte, payload []byte) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	if c.closed {
+		return net.ErrClosed
+	}
+
+	// Server frames are never masked
+	header := make([]byte, 2, 10)
+	header[0] = 0x80 | opcode
+	switch n := len(payload); {
+	case n <= 125:
+		header[1] = byte(n)
+	case n <= 0xFFFF:
+		header[1] = 126
+		header = binary.BigEndian.AppendUint16(header, uint16(n))
+	default:
+		header[1] = 127
+		header = binary.BigEndian.AppendUint64(header, uint64(n))
+	}
+
+	c.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	if _, err := c.conn.Write(append(header, payload...)); err != nil {
+		return err
+	}
+	if opcode == opClose {
+		c.closed = true
+	}
+	return nil
+}
+
+// WriteMessage sends a complete message in a single frame
+func (c *Conn) WriteMessage(messageType MessageType, data []byte) error {
+	if messageType != TextMessage && messageType != BinaryMessage {
+		return errors.New("websocket: invalid message type")
+	}
+	return c.writeFrame(byte(messageType), data)
+}
+
+// WriteJSON sends v as a text message
+func (c *Conn) WriteJSON(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return c.WriteMessage(TextMessage, data)
+}
+
+// Ping sends a ping; the peer's pong is reported through OnPong
+func (c *Conn) Ping() error {
+	return c.writeFrame(opPing, nil)
+}
+
+// WriteClose starts the closing handshake. Nothing can be written afterwards.
+func (c *Conn) WriteClose(code int, reason string) error {
+	if len(reason) > 123 {
+		reason = reason[:123]
+	}
+	payload := binary.BigEndian.AppendUint16(nil, uint16(code))
+	return c.writeFrame(opClose, append(payload, reason...))
+}
@@ -0,0 +1,158 @@
+package websocket
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// startEchoServer serves an endpoint that echoes each message back until the client closes
+func startEchoServer(t *testing.T) string {
+	t.Helper()
+	app := fiber.New(fiber.Config{DisableStartupMessage: true})
+	app.Get("/ws", func(c *fiber.Ctx) error {
+		return Upgrade(c, 1024, func(conn *Conn) {
+			for {
+				messageType, data, err := conn.ReadMessage()
+				if err != nil {
+					return
+				}
+				conn.WriteMessage(messageType, data)
+			}
+		})
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go app.Listener(ln)
+	t.Cleanup(func() { app.Shutdown() })
+	return ln.Addr().String()
+}
+
+// dial performs the opening handshake over a raw connection
+func dial(t *testing.T, addr string) (net.Conn, *bufio.Reader) {
+	t.Helper()
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	// Example key and accept value from RFC 6455 section 1.3
+	io.WriteString(conn, "GET /ws HTTP/1.1\r\nHost: "+addr+"\r\nUpgrade: websocket\r\nConnection: keep-alive, Upgrade\r\n"+
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\nSec-WebSocket-Version: 13\r\n\r\n")
+
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("expected 101, got %d", resp.StatusCode)
+	}
+	if accept := resp.Header.Get("Sec-WebSocket-Accept"); accept != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("unexpected Sec-WebSocket-Accept %q", accept)
+	}
+	return conn, br
+}
+
+// writeClientFrame writes a masked frame as a client must
+func writeClientFrame(t *testing.T, conn net.Conn, fin bool, opcode byte, payload []byte) {
+	t.Helper()
+	first := opcode
+	if fin {
+		first |= 0x80
+	}
+	mask := [4]byte{0x12, 0x34, 0x56, 0x78}
+	frame := []byte{first, 0x80 | byte(len(payload))}
+	frame = append(frame, mask[:]...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+	if _, err := conn.Write(frame); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// readServerFrame reads one unmasked frame with a payload under 126 bytes
+func readServerFrame(t *testing.T, br *bufio.Reader) (byte, []byte) {
+	t.Helper()
+	var header [2]byte
+	if _, err := io.ReadFull(br, header[:]); err != nil {
+		t.Fatal(err)
+	}
+	if header[1]&0x80 != 0 {
+		t.Fatal("server frames must not be masked")
+	}
+	payload := make([]byte, header[1]&0x7F)
+	if _, err := io.ReadFull(br, payload); err != nil {
+		t.Fatal(err)
+	}
+	return header[0] & 0x0F, payload
+}
+
+func TestEchoFragmentedMessageAndPing(t *testing.T) {
+	conn, br := dial(t, startEchoServer(t))
+
+	writeClientFrame(t, conn, true, opPing, []byte("hi"))
+	if opcode, payload := readServerFrame(t, br); opcode != opPong || string(payload) != "hi" {
+		t.Fatalf("expected pong echoing the ping, got opcode %d %q", opcode, payload)
+	}
+
+	writeClientFrame(t, conn, false, byte(TextMessage), []byte("hello, "))
+	writeClientFrame(t, conn, true, opContinuation, []byte("world"))
+	if opcode, payload := readServerFrame(t, br); opcode != byte(TextMessage) || string(payload) != "hello, world" {
+		t.Fatalf("expected the reassembled message echoed, got opcode %d %q", opcode, payload)
+	}
+
+	writeClientFrame(t, conn, true, opClose, binary.BigEndian.AppendUint16(nil, CloseNormal))
+	if opcode, payload := readServerFrame(t, br); opcode != opClose || binary.BigEndian.Uint16(payload) != CloseNormal {
+		t.Fatalf("expected the close to be acknowledged, got opcode %d %v", opcode, payload)
+	}
+}
+
+func TestRejectsProtocolViolations(t *testing.T) {
+	addr := startEchoServer(t)
+
+	// Unmasked client frame
+	conn, br := dial(t, addr)
+	conn.Write([]byte{0x81, 0x02, 'h', 'i'})
+	if opcode, payload := readServerFrame(t, br); opcode != opClose || binary.BigEndian.Uint16(payload) != CloseProtocolError {
+		t.Fatalf("expected a protocol error close, got opcode %d %v", opcode, payload)
+	}
+
+	// Message over the size limit
+	conn, br = dial(t, addr)
+	writeClientFrame(t, conn, false, byte(BinaryMessage), make([]byte, 100))
+	for i := 0; i < 10; i++ {
+		writeClientFrame(t, conn, false, opContinuation, make([]byte, 100))
+	}
+	if opcode, payload := readServerFrame(t, br); opcode != opClose || binary.BigEndian.Uint16(payload) != CloseMessageTooBig {
+		t.Fatalf("expected a message too big close, got opcode %d %v", opcode, payload)
+	}
+}
+
+func TestUpgradeRequiresHandshakeHeaders(t *testing.T) {
+	app := fiber.New()
+	app.Get("/ws", func(c *fiber.Ctx) error {
+		return Upgrade(c, 0, func(*Conn) {})
+	})
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/ws", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != fiber.StatusUpgradeRequired {
+		t.Errorf("expected 426 for a plain GET, got %d", resp.StatusCode)
+	}
+}